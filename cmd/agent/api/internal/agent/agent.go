@@ -196,6 +196,7 @@ func getStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	jsonStats, _ = scrubber.RedactSecretValues(jsonStats)
 	w.Write(jsonStats)
 }
 
@@ -316,6 +317,7 @@ func getFormattedStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s, _ = scrubber.RedactSecretValues(s)
 	w.Write(s)
 }
 
@@ -9,6 +9,7 @@ package secret
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -27,6 +28,9 @@ import (
 // cliParams are the command-line arguments for this subcommand
 type cliParams struct {
 	*command.GlobalParams
+
+	jsonOutput      bool
+	prettyPrintJSON bool
 }
 
 // Commands returns a slice of subcommands for the 'agent' command.
@@ -48,6 +52,8 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 			)
 		},
 	}
+	secretInfoCommand.Flags().BoolVarP(&cliParams.jsonOutput, "json", "j", false, "print out raw json")
+	secretInfoCommand.Flags().BoolVarP(&cliParams.prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
 
 	return []*cobra.Command{secretInfoCommand}
 }
@@ -58,14 +64,14 @@ func secret(log log.Component, config config.Component, cliParams *cliParams) er
 		return nil
 	}
 
-	if err := showSecretInfo(config); err != nil {
+	if err := showSecretInfo(config, cliParams); err != nil {
 		fmt.Println(err)
 		return nil
 	}
 	return nil
 }
 
-func showSecretInfo(config config.Component) error {
+func showSecretInfo(config config.Component, cliParams *cliParams) error {
 	c := util.GetClient(false)
 	ipcAddress, err := pkgconfig.GetIPCAddress()
 	if err != nil {
@@ -90,6 +96,25 @@ func showSecretInfo(config config.Component) error {
 	if err != nil {
 		return fmt.Errorf("Could not Unmarshal agent answer: %s", r)
 	}
-	info.Print(os.Stdout)
+	return printSecretInfo(os.Stdout, info, cliParams)
+}
+
+func printSecretInfo(w io.Writer, info *secrets.SecretInfo, cliParams *cliParams) error {
+	if !cliParams.jsonOutput && !cliParams.prettyPrintJSON {
+		info.Print(w)
+		return nil
+	}
+
+	var out []byte
+	var err error
+	if cliParams.prettyPrintJSON {
+		out, err = json.MarshalIndent(info, "", "  ")
+	} else {
+		out, err = json.Marshal(info)
+	}
+	if err != nil {
+		return fmt.Errorf("Could not marshal secrets information: %s", err)
+	}
+	fmt.Fprintln(w, string(out))
 	return nil
 }
@@ -6,12 +6,16 @@
 package secret
 
 import (
+	"bytes"
+	"encoding/json"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/cmd/agent/command"
 	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
 
@@ -24,3 +28,39 @@ func TestCommand(t *testing.T) {
 			require.Equal(t, false, coreParams.ConfigLoadSecrets())
 		})
 }
+
+func TestCommandJSON(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"secret", "--json"},
+		secret,
+		func(cliParams *cliParams, coreParams core.BundleParams) {
+			require.True(t, cliParams.jsonOutput)
+			require.False(t, cliParams.prettyPrintJSON)
+		})
+}
+
+func TestPrintSecretInfoJSON(t *testing.T) {
+	info := &secrets.SecretInfo{
+		ExecutablePath: "/bin/secrets",
+		RightsOK:       true,
+		Backend:        secrets.SecretBackendInfo{Command: "/bin/secrets", Timeout: 5},
+		SecretsHandles: map[string][]string{"pass1": {"datadog.yaml"}},
+		SecretsStatus: map[string]secrets.SecretHandleStatus{
+			"pass1": {Resolved: true, Origins: []string{"datadog.yaml"}},
+			"pass2": {Resolved: false, Origins: []string{"redisdb.yaml"}, Error: "some error"},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, printSecretInfo(&out, info, &cliParams{jsonOutput: true}))
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &res))
+	assert.Equal(t, true, res["rights_ok"])
+	assert.Equal(t, "/bin/secrets", res["backend"].(map[string]interface{})["command"])
+	status := res["secrets_status"].(map[string]interface{})
+	assert.Equal(t, true, status["pass1"].(map[string]interface{})["resolved"])
+	assert.Equal(t, false, status["pass2"].(map[string]interface{})["resolved"])
+	assert.Equal(t, "some error", status["pass2"].(map[string]interface{})["error"])
+}
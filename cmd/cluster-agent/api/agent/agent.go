@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 	"github.com/DataDog/datadog-agent/pkg/version"
 	"github.com/DataDog/datadog-agent/pkg/workloadmeta"
 )
@@ -65,6 +66,7 @@ func getStatus(w http.ResponseWriter, r *http.Request) {
 		setJSONError(w, err, 500)
 		return
 	}
+	jsonStats, _ = scrubber.RedactSecretValues(jsonStats)
 	w.Write(jsonStats)
 }
 
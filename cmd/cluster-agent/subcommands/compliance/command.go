@@ -27,7 +27,7 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	}
 
 	bundleParams := core.BundleParams{
-		ConfigParams: config.NewClusterAgentParams(""),
+		ConfigParams: config.NewClusterAgentParams("", config.WithConfigLoadSecrets(true)),
 		LogParams:    log.LogForOneShot(command.LoggerName, command.DefaultLogLevel, true),
 	}
 
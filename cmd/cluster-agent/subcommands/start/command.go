@@ -15,6 +15,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -116,6 +117,11 @@ func start(log log.Component, config config.Component, cliParams *command.Global
 		return fmt.Errorf("no API key configured, exiting")
 	}
 
+	// Secret handles left unresolved would be used as-is, as credentials or in the configuration of the webhooks
+	if keys := pkgconfig.UnresolvedSecretKeys(pkgconfig.Datadog, "external_metrics_provider", "admission_controller", "cluster_agent", "cluster_checks"); len(keys) > 0 {
+		pkglog.Errorf("Secret handles could not be resolved for %s, check the secret_backend_command setting", strings.Join(keys, ", "))
+	}
+
 	// Expose the registered metrics via HTTP.
 	http.Handle("/metrics", telemetry.Handler())
 	metricsPort := pkgconfig.Datadog.GetInt("metrics_port")
@@ -18,6 +18,8 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/comp/core/log"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/ruletest"
+	"github.com/DataDog/datadog-agent/pkg/compliance/utils"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 	"github.com/DataDog/datadog-agent/pkg/util/startstop"
 )
@@ -29,6 +31,7 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	}
 
 	complianceCmd.AddCommand(complianceEventCommand(globalParams))
+	complianceCmd.AddCommand(complianceTestCommand(globalParams))
 	complianceCmd.AddCommand(check.SecurityAgentCommands(globalParams)...)
 
 	return []*cobra.Command{complianceCmd}
@@ -104,3 +107,76 @@ func eventRun(log log.Component, config config.Component, eventArgs *cliParams)
 
 	return nil
 }
+
+type testCliParams struct {
+	*command.GlobalParams
+
+	fixtures []string
+	verbose  bool
+}
+
+func complianceTestCommand(globalParams *command.GlobalParams) *cobra.Command {
+	testArgs := &testCliParams{
+		GlobalParams: globalParams,
+	}
+
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Test compliance rules against fixture resources",
+		Long: `Run the rules of compliance suites against snapshots of the resources they observe, and compare their
+findings with the expected results. Each argument is a fixtures file listing the test cases of a suite.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			testArgs.fixtures = args
+			return fxutil.OneShot(testRun,
+				fx.Supply(testArgs),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewSecurityAgentParams(globalParams.ConfigFilePaths),
+					LogParams:    log.LogForOneShot(command.LoggerName, "off", true),
+				}),
+				core.Bundle,
+			)
+		},
+	}
+
+	testCmd.Flags().BoolVarP(&testArgs.verbose, flags.Verbose, "v", false, "Print the events reported by the rules")
+
+	return testCmd
+}
+
+func testRun(log log.Component, config config.Component, testArgs *testCliParams) error {
+	failed := 0
+	for _, fixtures := range testArgs.fixtures {
+		results, err := ruletest.Run(fixtures)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			if result.Passed() {
+				fmt.Printf("PASS %s: %s\n", fixtures, result.Name)
+			} else {
+				failed++
+				fmt.Printf("FAIL %s: %s\n", fixtures, result.Name)
+				for _, failure := range result.Failures {
+					fmt.Printf("    %s\n", failure)
+				}
+			}
+
+			if testArgs.verbose {
+				for _, e := range result.Events {
+					eventJSON, err := utils.PrettyPrintJSON(e, "  ")
+					if err != nil {
+						return err
+					}
+					fmt.Println(string(eventJSON))
+				}
+			}
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d compliance rule test(s) failed", failed)
+	}
+	return nil
+}
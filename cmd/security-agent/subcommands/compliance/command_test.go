@@ -45,7 +45,7 @@ func TestCommands(t *testing.T) {
 			subcommandNames = append(subcommandNames, subcommand.Use)
 		}
 
-		require.Equal(t, []string{"event"}, subcommandNames, "subcommand missing")
+		require.Equal(t, []string{"event", "test"}, subcommandNames, "subcommand missing")
 
 		fxutil.TestOneShotSubcommand(t,
 			Commands(&command.GlobalParams{}),
@@ -55,3 +55,16 @@ func TestCommands(t *testing.T) {
 		)
 	}
 }
+
+func TestTestSubcommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"compliance", "test", "--verbose", "cis-docker.fixtures.yaml"},
+		testRun,
+		func(cliParams *testCliParams, params core.BundleParams) {
+			require.Equal(t, command.LoggerName, params.LoggerName(), "logger name not matching")
+			require.Equal(t, []string{"cis-docker.fixtures.yaml"}, cliParams.fixtures, "fixtures arg input not matching")
+			require.True(t, cliParams.verbose, "verbose arg input not matching")
+		},
+	)
+}
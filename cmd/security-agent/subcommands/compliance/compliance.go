@@ -8,6 +8,7 @@ package compliance
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/DataDog/datadog-agent/cmd/security-agent/command"
@@ -18,11 +19,17 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/compliance/agent"
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/exceptions"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/remote"
+	"github.com/DataDog/datadog-agent/pkg/config/remote/data"
 	"github.com/DataDog/datadog-agent/pkg/util/startstop"
 	"github.com/DataDog/datadog-agent/pkg/version"
 	ddgostatsd "github.com/DataDog/datadog-go/v5/statsd"
 )
 
+const remotePollInterval = 10 * time.Second
+
 func StartCompliance(log log.Component, config config.Component, hostname string, stopper startstop.Stopper, statsdClient *ddgostatsd.Client) (*agent.Agent, error) {
 	enabled := config.GetBool("compliance_config.enabled")
 	if !enabled {
@@ -61,6 +68,13 @@ func StartCompliance(log log.Component, config config.Component, hostname string
 		checks.WithStatsd(statsdClient),
 	}
 
+	var localExceptions []*exceptions.Exception
+	if err := pkgconfig.Datadog.UnmarshalKey("compliance_config.exceptions", &localExceptions); err != nil {
+		log.Errorf("Failed to parse the compliance exceptions: %v", err)
+	}
+	exceptionRegistry := exceptions.NewRegistry(localExceptions)
+	options = append(options, checks.WithExceptions(exceptionRegistry))
+
 	agent, err := agent.New(
 		reporter,
 		scheduler,
@@ -72,6 +86,7 @@ func StartCompliance(log log.Component, config config.Component, hostname string
 		log.Errorf("Compliance agent failed to initialize: %v", err)
 		return nil, err
 	}
+	agent.SetExceptions(exceptionRegistry)
 	err = agent.Run()
 	if err != nil {
 		log.Errorf("Error starting compliance agent, exiting: %v", err)
@@ -79,6 +94,10 @@ func StartCompliance(log log.Component, config config.Component, hostname string
 	}
 	stopper.Add(agent)
 
+	if err := enableRemoteConfiguration(config, agent, runPath); err != nil {
+		log.Errorf("Compliance features driven by remote configuration are disabled: %v", err)
+	}
+
 	log.Infof("Running compliance checks every %s", checkInterval)
 
 	// Send the compliance 'running' metrics periodically
@@ -88,6 +107,41 @@ func StartCompliance(log log.Component, config config.Component, hostname string
 	return agent, nil
 }
 
+func enableRemoteConfiguration(config config.Component, complianceAgent *agent.Agent, runPath string) error {
+	var remoteConfig agent.RemoteConfig
+	var products []data.Product
+
+	if config.GetBool("compliance_config.remote_bundles.enabled") {
+		publicKey, err := agent.ParsePublicKey(config.GetString("compliance_config.remote_bundles.public_key"))
+		if err != nil {
+			return err
+		}
+		remoteConfig.Bundles = &agent.RemoteBundlesConfig{
+			PublicKey:     publicKey,
+			PinnedVersion: config.GetString("compliance_config.remote_bundles.pinned_version"),
+			StoreDir:      filepath.Join(runPath, "compliance", "bundles"),
+		}
+		products = append(products, data.ProductCSPMBundles)
+	}
+
+	if config.GetBool("compliance_config.remote_exceptions.enabled") {
+		remoteConfig.Exceptions = true
+		products = append(products, data.ProductCSPMExceptions)
+	}
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	client, err := remote.NewUnverifiedGRPCClient("security-agent", version.AgentVersion, products, remotePollInterval)
+	if err != nil {
+		return err
+	}
+
+	complianceAgent.EnableRemoteConfiguration(client, remoteConfig)
+	return nil
+}
+
 // sendRunningMetrics exports a metric to distinguish between security-agent modules that are activated
 func sendRunningMetrics(statsdClient *ddgostatsd.Client, moduleName string) *time.Ticker {
 	// Retrieve the agent version using a dedicated package
@@ -20,6 +20,9 @@ type Factory struct {
 	Name             config.ModuleName
 	ConfigNamespaces []string
 	Fn               func(cfg *config.Config) (Module, error)
+	// Capabilities are the capabilities the module needs once started. The system-probe can't drop its privileges
+	// when a module which doesn't set them is enabled.
+	Capabilities []string
 }
 
 // Module defines the common API implemented by every System Probe Module
@@ -39,6 +39,8 @@ type loader struct {
 	cfg     *config.Config
 	routers map[config.ModuleName]*Router
 	closed  bool
+	// privilegesDropped is set once the system-probe lacks the privileges to start the modules again
+	privilegesDropped bool
 }
 
 // Register a set of modules, which involves:
@@ -111,6 +113,9 @@ func RestartModule(factory Factory) error {
 	if l.closed == true {
 		return fmt.Errorf("can't restart module because system-probe is shutting down")
 	}
+	if l.privilegesDropped {
+		return fmt.Errorf("can't restart module because system-probe dropped its privileges")
+	}
 
 	currentModule := l.modules[factory.Name]
 	if currentModule == nil {
@@ -140,6 +145,14 @@ func RestartModule(factory Factory) error {
 	return nil
 }
 
+// PrivilegesDropped prevents the modules from being restarted, as they can't be started again once the privileges of
+// the system-probe are dropped
+func PrivilegesDropped() {
+	l.Lock()
+	defer l.Unlock()
+	l.privilegesDropped = true
+}
+
 // Close each registered module
 func Close() {
 	l.Lock()
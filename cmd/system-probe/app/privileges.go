@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/DataDog/datadog-agent/cmd/system-probe/api/module"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/config"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/modules"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// requiredCapabilities returns the capabilities needed by the enabled modules once they are started
+func requiredCapabilities(cfg *config.Config, factories []module.Factory) ([]string, error) {
+	capabilities := append([]string{}, cfg.KeepCapabilities...)
+	for _, factory := range factories {
+		if !cfg.ModuleIsEnabled(factory.Name) {
+			continue
+		}
+		if factory.Capabilities == nil {
+			return nil, fmt.Errorf("module %s does not support running with dropped privileges", factory.Name)
+		}
+		capabilities = append(capabilities, factory.Capabilities...)
+	}
+	return capabilities, nil
+}
+
+// dropPrivileges drops the capabilities which are not needed anymore once the modules are started, and writes the
+// matching seccomp profile if configured
+func dropPrivileges(cfg *config.Config) error {
+	capabilities, err := requiredCapabilities(cfg, modules.All)
+	if err != nil {
+		return err
+	}
+
+	kept, err := utils.DropCapabilities(capabilities)
+	if err != nil {
+		return err
+	}
+	module.PrivilegesDropped()
+	log.Infof("dropped privileges, running with capabilities %v only", kept)
+
+	if cfg.SeccompProfilePath == "" {
+		return nil
+	}
+	profile, err := utils.SeccompProfile(kept)
+	if err != nil {
+		return fmt.Errorf("could not generate seccomp profile: %w", err)
+	}
+	if err := os.WriteFile(cfg.SeccompProfilePath, profile, 0644); err != nil {
+		return fmt.Errorf("could not write seccomp profile: %w", err)
+	}
+	log.Infof("seccomp profile matching the capabilities of system-probe written to %s", cfg.SeccompProfilePath)
+	return nil
+}
@@ -222,6 +222,12 @@ func StartSystemProbe() error {
 	if err = api.StartServer(cfg); err != nil {
 		return log.Criticalf("Error while starting api server, exiting: %v", err)
 	}
+
+	if cfg.DropPrivileges {
+		if err := dropPrivileges(cfg); err != nil {
+			log.Errorf("unable to drop privileges, system-probe keeps running with its current privileges: %s", err)
+		}
+	}
 	return nil
 }
 
@@ -69,6 +69,13 @@ type Config struct {
 
 	// Settings for profiling, or nil if not enabled
 	ProfilingSettings *profiling.Settings
+
+	// DropPrivileges drops the capabilities the enabled modules don't need once they are started
+	DropPrivileges bool
+	// KeepCapabilities are kept in addition to the ones required by the enabled modules when dropping privileges
+	KeepCapabilities []string
+	// SeccompProfilePath is where the seccomp profile matching the kept capabilities is written, if set
+	SeccompProfilePath string
 }
 
 // New creates a config object for system-probe. It assumes no configuration has been loaded as this point.
@@ -154,6 +161,10 @@ func load() (*Config, error) {
 		StatsdPort: cfg.GetInt("dogstatsd_port"),
 
 		ProfilingSettings: profSettings,
+
+		DropPrivileges:     cfg.GetBool(key(spNS, "drop_privileges.enabled")),
+		KeepCapabilities:   cfg.GetStringSlice(key(spNS, "drop_privileges.keep_capabilities")),
+		SeccompProfilePath: cfg.GetString(key(spNS, "drop_privileges.seccomp_profile")),
 	}
 
 	// backwards compatible log settings
@@ -219,6 +230,12 @@ func load() (*Config, error) {
 	cfg.Set(key(spNS, "sysprobe_socket"), c.SocketAddress)
 	cfg.Set(key(spNS, "enabled"), c.Enabled)
 
+	// the perf buffers are grown by restarting the network tracer, which can't load its eBPF programs again once the
+	// privileges are dropped
+	if c.DropPrivileges && cfg.GetBool(key(spNS, "perf_buffer_adaptive_sizing")) {
+		return nil, fmt.Errorf("%s.perf_buffer_adaptive_sizing can't be enabled along with %s.drop_privileges.enabled", spNS, spNS)
+	}
+
 	if cfg.GetBool(key(smNS, "process_service_inference", "enabled")) {
 		if !usmEnabled {
 			log.Info("service monitoring is disabled, disabling process service inference")
@@ -63,3 +63,36 @@ func TestRuntimeSecurityLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestDropPrivileges(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		cfg, err := New("")
+		require.NoError(t, err)
+
+		assert.False(t, cfg.DropPrivileges)
+		assert.Empty(t, cfg.KeepCapabilities)
+		assert.Empty(t, cfg.SeccompProfilePath)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_DROP_PRIVILEGES_ENABLED", "true")
+		t.Setenv("DD_SYSTEM_PROBE_DROP_PRIVILEGES_KEEP_CAPABILITIES", "CAP_SYS_ADMIN CAP_SYS_RESOURCE")
+		t.Setenv("DD_SYSTEM_PROBE_DROP_PRIVILEGES_SECCOMP_PROFILE", "/tmp/seccomp.json")
+		cfg, err := New("")
+		require.NoError(t, err)
+
+		assert.True(t, cfg.DropPrivileges)
+		assert.Equal(t, []string{"CAP_SYS_ADMIN", "CAP_SYS_RESOURCE"}, cfg.KeepCapabilities)
+		assert.Equal(t, "/tmp/seccomp.json", cfg.SeccompProfilePath)
+	})
+
+	t.Run("with perf buffer adaptive sizing", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_DROP_PRIVILEGES_ENABLED", "true")
+		t.Setenv("DD_SYSTEM_PROBE_PERF_BUFFER_ADAPTIVE_SIZING", "true")
+		_, err := New("")
+		assert.Error(t, err)
+	})
+}
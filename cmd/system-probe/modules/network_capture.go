@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+// +build linux windows
+
+package modules
+
+import (
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// offlineCaptureClientID is the client the snapshots of the offline capture are taken for, so that they don't
+	// interfere with the deltas of the process-agent
+	offlineCaptureClientID = "offline-capture"
+
+	offlineCaptureFilePrefix = "connections-"
+	offlineCaptureFileSuffix = ".json.gz"
+	// offlineCaptureTimeFormat sorts in chronological order
+	offlineCaptureTimeFormat = "20060102T150405.000Z"
+)
+
+// offlineCapture writes periodic snapshots of the connections, including their USM stats, to gzipped JSON files,
+// keeping the most recent ones only
+type offlineCapture struct {
+	dir            string
+	maxFiles       int
+	getConnections func(clientID string) (*network.Connections, error)
+	marshaler      encoding.Marshaler
+}
+
+func newOfflineCapture(cfg *networkconfig.Config, getConnections func(clientID string) (*network.Connections, error)) (*offlineCapture, error) {
+	if err := os.MkdirAll(cfg.OfflineCaptureDir, 0700); err != nil {
+		return nil, fmt.Errorf("could not create offline capture directory %s: %w", cfg.OfflineCaptureDir, err)
+	}
+
+	return &offlineCapture{
+		dir:            cfg.OfflineCaptureDir,
+		maxFiles:       cfg.OfflineCaptureMaxFiles,
+		getConnections: getConnections,
+		marshaler:      encoding.GetMarshaler(encoding.ContentTypeJSON),
+	}, nil
+}
+
+// start writes a snapshot of the connections every interval, until done is closed
+func (c *offlineCapture) start(interval time.Duration, done <-chan struct{}) {
+	log.Infof("writing snapshots of the network connections to %s every %s", c.dir, interval)
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if err := c.capture(now); err != nil {
+					log.Errorf("unable to write snapshot of the network connections: %s", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// capture writes a snapshot of the connections active since the last capture, then removes the oldest snapshots
+func (c *offlineCapture) capture(now time.Time) error {
+	cs, err := c.getConnections(offlineCaptureClientID)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve connections: %w", err)
+	}
+	defer network.Reclaim(cs)
+
+	buf, err := c.marshaler.Marshal(cs)
+	if err != nil {
+		return fmt.Errorf("unable to marshal connections: %w", err)
+	}
+
+	name := offlineCaptureFilePrefix + now.UTC().Format(offlineCaptureTimeFormat) + offlineCaptureFileSuffix
+	if err := writeGzipFile(filepath.Join(c.dir, name), buf); err != nil {
+		return err
+	}
+	log.Debugf("wrote snapshot of %d connections to %s", len(cs.Conns), name)
+
+	return c.prune()
+}
+
+// writeGzipFile writes the data to a temporary file first, so that partially written snapshots are never left behind
+func writeGzipFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if _, err := gz.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// prune removes the oldest snapshots beyond maxFiles
+func (c *offlineCapture) prune() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var snapshots []string
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if name := e.Name(); strings.HasPrefix(name, offlineCaptureFilePrefix) && strings.HasSuffix(name, offlineCaptureFileSuffix) {
+			snapshots = append(snapshots, name)
+		}
+	}
+	if len(snapshots) <= c.maxFiles {
+		return nil
+	}
+
+	sort.Strings(snapshots)
+	for _, name := range snapshots[:len(snapshots)-c.maxFiles] {
+		if err := os.Remove(filepath.Join(c.dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
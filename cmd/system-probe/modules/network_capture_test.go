@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+// +build linux windows
+
+package modules
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestOfflineCapture(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "captures")
+	cfg := &networkconfig.Config{OfflineCaptureDir: dir, OfflineCaptureMaxFiles: 2}
+
+	var clientIDs []string
+	capture, err := newOfflineCapture(cfg, func(clientID string) (*network.Connections, error) {
+		clientIDs = append(clientIDs, clientID)
+		return &network.Connections{
+			BufferedData: network.BufferedData{
+				Conns: []network.ConnectionStats{{
+					Source: util.AddressFromString("10.1.1.1"),
+					Dest:   util.AddressFromString("10.2.2.2"),
+					SPort:  uint16(1000 + len(clientIDs)),
+					DPort:  80,
+				}},
+			},
+		}, nil
+	})
+	require.NoError(t, err)
+
+	// an unrelated file, which must not be pruned
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600))
+
+	start := time.Date(2022, 11, 3, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, capture.capture(start.Add(time.Duration(i)*30*time.Second)))
+	}
+	assert.Equal(t, []string{offlineCaptureClientID, offlineCaptureClientID, offlineCaptureClientID}, clientIDs)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{
+		"connections-20221103T100030.000Z.json.gz",
+		"connections-20221103T100100.000Z.json.gz",
+		"notes.txt",
+	}, names)
+
+	f, err := os.Open(filepath.Join(dir, names[1]))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	buf, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	cs, err := encoding.GetUnmarshaler(encoding.ContentTypeJSON).Unmarshal(buf)
+	require.NoError(t, err)
+	require.Len(t, cs.Conns, 1)
+	assert.Equal(t, int32(1003), cs.Conns[0].Laddr.Port)
+}
+
+func TestOfflineCaptureError(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &networkconfig.Config{OfflineCaptureDir: dir, OfflineCaptureMaxFiles: 2}
+
+	capture, err := newOfflineCapture(cfg, func(clientID string) (*network.Connections, error) {
+		return nil, errors.New("tracer stopped")
+	})
+	require.NoError(t, err)
+
+	assert.Error(t, capture.capture(time.Now()))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
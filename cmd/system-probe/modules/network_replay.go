@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+// +build linux windows
+
+package modules
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/cmd/system-probe/api/module"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var _ module.Module = &networkReplay{}
+
+// networkReplay serves the snapshots written by the offline capture in place of the connections of the tracer, so
+// that the payloads built from them by the process-agent can be reproduced deterministically
+type networkReplay struct {
+	path      string
+	snapshots []string
+
+	mux  sync.Mutex
+	next int
+}
+
+func newNetworkReplay(path string) (*networkReplay, error) {
+	snapshots, err := listSnapshots(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("no snapshot of the network connections found in %s", path)
+	}
+
+	log.Infof("replaying %d snapshots of the network connections from %s", len(snapshots), path)
+	return &networkReplay{path: path, snapshots: snapshots}, nil
+}
+
+// listSnapshots returns the snapshots at the given path in chronological order. The path is either a snapshot or
+// the directory of the offline capture.
+func listSnapshots(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []string
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if name := e.Name(); strings.HasPrefix(name, offlineCaptureFilePrefix) && strings.HasSuffix(name, offlineCaptureFileSuffix) {
+			snapshots = append(snapshots, filepath.Join(path, name))
+		}
+	}
+	sort.Strings(snapshots)
+	return snapshots, nil
+}
+
+// readSnapshot decodes a snapshot, which is gzipped unless its name tells otherwise
+func readSnapshot(path string) (*model.Connections, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("could not decompress snapshot %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read snapshot %s: %w", path, err)
+	}
+	cs, err := encoding.GetUnmarshaler(encoding.ContentTypeJSON).Unmarshal(buf)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode snapshot %s: %w", path, err)
+	}
+	return cs, nil
+}
+
+// nextSnapshot returns the next snapshot to serve, looping back to the first one after the last
+func (r *networkReplay) nextSnapshot() (string, *model.Connections, error) {
+	r.mux.Lock()
+	path := r.snapshots[r.next]
+	r.next = (r.next + 1) % len(r.snapshots)
+	r.mux.Unlock()
+
+	cs, err := readSnapshot(path)
+	return path, cs, err
+}
+
+// marshalSnapshot encodes a snapshot in the format the client asked for, as the marshalers of the tracer do
+func marshalSnapshot(accept string, cs *model.Connections) ([]byte, string, error) {
+	if strings.Contains(accept, encoding.ContentTypeProtobuf) {
+		buf, err := proto.Marshal(cs)
+		return buf, encoding.ContentTypeProtobuf, err
+	}
+
+	writer := new(bytes.Buffer)
+	marshaler := jsonpb.Marshaler{EmitDefaults: true}
+	err := marshaler.Marshal(writer, cs)
+	return writer.Bytes(), encoding.ContentTypeJSON, err
+}
+
+func (r *networkReplay) GetStats() map[string]interface{} {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return map[string]interface{}{
+		"replay_path":   r.path,
+		"snapshots":     len(r.snapshots),
+		"next_snapshot": r.next,
+	}
+}
+
+// Register registers the endpoints the process-agent relies on
+func (r *networkReplay) Register(httpMux *module.Router) error {
+	httpMux.HandleFunc("/connections", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests, func(w http.ResponseWriter, req *http.Request) {
+		path, cs, err := r.nextSnapshot()
+		if err != nil {
+			log.Errorf("unable to replay snapshot: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		buf, contentType, err := marshalSnapshot(req.Header.Get("Accept"), cs)
+		if err != nil {
+			log.Errorf("unable to marshall snapshot %s with type %s: %s", path, contentType, err)
+			w.WriteHeader(500)
+			return
+		}
+
+		w.Header().Set("Content-type", contentType)
+		w.Write(buf) //nolint:errcheck
+		log.Debugf("/connections: replayed %d connections from %s", len(cs.Conns), path)
+	}))
+
+	httpMux.HandleFunc("/register", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests, func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	return nil
+}
+
+func (r *networkReplay) Close() {}
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+// +build linux windows
+
+package modules
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/system-probe/api/module"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	networkconfig "github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// writeSnapshots writes a snapshot per source port with the offline capture
+func writeSnapshots(t *testing.T, dir string, ports ...uint16) {
+	cfg := &networkconfig.Config{OfflineCaptureDir: dir, OfflineCaptureMaxFiles: len(ports)}
+
+	i := 0
+	capture, err := newOfflineCapture(cfg, func(clientID string) (*network.Connections, error) {
+		port := ports[i]
+		i++
+		return &network.Connections{
+			BufferedData: network.BufferedData{
+				Conns: []network.ConnectionStats{{
+					Source: util.AddressFromString("10.1.1.1"),
+					Dest:   util.AddressFromString("10.2.2.2"),
+					SPort:  port,
+					DPort:  80,
+				}},
+			},
+		}, nil
+	})
+	require.NoError(t, err)
+
+	start := time.Date(2022, 11, 3, 10, 0, 0, 0, time.UTC)
+	for j := range ports {
+		require.NoError(t, capture.capture(start.Add(time.Duration(j)*30*time.Second)))
+	}
+}
+
+func TestNetworkReplay(t *testing.T) {
+	dir := t.TempDir()
+	writeSnapshots(t, dir, 1001, 1002)
+	// an unrelated file, which must not be replayed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600))
+
+	replay, err := newNetworkReplay(dir)
+	require.NoError(t, err)
+	require.Len(t, replay.snapshots, 2)
+
+	router := mux.NewRouter()
+	require.NoError(t, replay.Register(module.NewRouter(router)))
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	getPort := func(contentType string) int32 {
+		req, err := http.NewRequest("GET", srv.URL+"/connections?client_id=1", nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept", contentType)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, contentType, resp.Header.Get("Content-type"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		cs, err := encoding.GetUnmarshaler(contentType).Unmarshal(body)
+		require.NoError(t, err)
+		require.Len(t, cs.Conns, 1)
+		return cs.Conns[0].Laddr.Port
+	}
+
+	// the snapshots are served in chronological order, and in a loop
+	assert.Equal(t, int32(1001), getPort(encoding.ContentTypeProtobuf))
+	assert.Equal(t, int32(1002), getPort(encoding.ContentTypeJSON))
+	assert.Equal(t, int32(1001), getPort(encoding.ContentTypeProtobuf))
+}
+
+func TestNetworkReplaySingleSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeSnapshots(t, dir, 1001, 1002)
+
+	snapshots, err := listSnapshots(dir)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+
+	replay, err := newNetworkReplay(snapshots[1])
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, cs, err := replay.nextSnapshot()
+		require.NoError(t, err)
+		assert.Equal(t, int32(1002), cs.Conns[0].Laddr.Port)
+	}
+}
+
+func TestNetworkReplayNoSnapshot(t *testing.T) {
+	_, err := newNetworkReplay(t.TempDir())
+	assert.Error(t, err)
+
+	_, err = newNetworkReplay(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
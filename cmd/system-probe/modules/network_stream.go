@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+// +build linux windows
+
+package modules
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding"
+)
+
+const (
+	// defaultStreamInterval is the interval at which the deltas are pushed to a streaming client which didn't set one
+	defaultStreamInterval = 30 * time.Second
+
+	// minStreamInterval is the minimum time between two deltas of a stream, the connections closed in the meantime
+	// being sent with the next one
+	minStreamInterval = time.Second
+)
+
+// connectionsStream pushes the connection deltas of a client over a long-lived response, instead of the client polling
+// them. The deltas are sent every interval, and as soon as connections are closed, so that the closed flows reach the
+// client in near real time. The deltas being smaller than the ones polled every check, so are their encoding buffers.
+type connectionsStream struct {
+	clientID       string
+	interval       time.Duration
+	getConnections func(clientID string) (*network.Connections, error)
+	marshaler      encoding.Marshaler
+}
+
+// run sends the first delta right away, then the next ones until ctx is done or the client can't be written to
+func (s *connectionsStream) run(ctx context.Context, w io.Writer, flush func(), closed <-chan struct{}) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var (
+		lastSent time.Time
+		// pending is set while the connections closed since the last delta wait for minStreamInterval to elapse
+		pending <-chan time.Time
+	)
+	for {
+		if err := s.send(w, flush); err != nil {
+			return err
+		}
+		lastSent, pending = time.Now(), nil
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				break wait
+			case <-pending:
+				break wait
+			case <-closed:
+				if pending != nil {
+					continue
+				}
+				if delay := minStreamInterval - time.Since(lastSent); delay > 0 {
+					pending = time.After(delay)
+					continue
+				}
+				break wait
+			}
+		}
+	}
+}
+
+// send writes the delta of the client as a frame of the stream
+func (s *connectionsStream) send(w io.Writer, flush func()) error {
+	cs, err := s.getConnections(s.clientID)
+	if err != nil {
+		return fmt.Errorf("unable to retrieve connections: %w", err)
+	}
+	defer network.Reclaim(cs)
+
+	buf, err := s.marshaler.Marshal(cs)
+	if err != nil {
+		return fmt.Errorf("unable to marshall connections with type %s: %w", s.marshaler.ContentType(), err)
+	}
+	if err := encoding.WriteStreamFrame(w, buf); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+// +build linux windows
+
+package modules
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/encoding"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestConnectionsStream(t *testing.T) {
+	var deltas int
+	stream := &connectionsStream{
+		clientID: "stream-client",
+		interval: time.Hour,
+		getConnections: func(clientID string) (*network.Connections, error) {
+			assert.Equal(t, "stream-client", clientID)
+			deltas++
+			return &network.Connections{
+				BufferedData: network.BufferedData{
+					Conns: []network.ConnectionStats{{
+						Source: util.AddressFromString("10.1.1.1"),
+						Dest:   util.AddressFromString("10.2.2.2"),
+						SPort:  uint16(1000 + deltas),
+						DPort:  80,
+					}},
+				},
+			}, nil
+		},
+		marshaler: encoding.GetMarshaler(encoding.ContentTypeProtobuf),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	closed := make(chan struct{}, 1)
+	r, w := io.Pipe()
+	done := make(chan error)
+	go func() {
+		done <- stream.run(ctx, w, func() {}, closed)
+		w.Close()
+	}()
+
+	readDelta := func() uint16 {
+		frame, err := encoding.ReadStreamFrame(r)
+		require.NoError(t, err)
+		conns, err := encoding.GetUnmarshaler(encoding.ContentTypeProtobuf).Unmarshal(frame)
+		require.NoError(t, err)
+		require.Len(t, conns.Conns, 1)
+		return uint16(conns.Conns[0].Laddr.Port)
+	}
+
+	// the first delta is sent right away
+	assert.Equal(t, uint16(1001), readDelta())
+
+	// the closed connections are sent without waiting for the interval, once minStreamInterval elapsed
+	start := time.Now()
+	closed <- struct{}{}
+	assert.Equal(t, uint16(1002), readDelta())
+	assert.GreaterOrEqual(t, time.Since(start), minStreamInterval/2)
+
+	cancel()
+	require.NoError(t, <-done)
+	_, err := encoding.ReadStreamFrame(r)
+	assert.Equal(t, io.EOF, err)
+}
@@ -10,11 +10,14 @@ package modules
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,15 +43,54 @@ var ErrSysprobeUnsupported = errors.New("system-probe unsupported")
 const inactivityLogDuration = 10 * time.Minute
 const inactivityRestartDuration = 20 * time.Minute
 
+// processStatsClientID is the client of the process stats of the requests which don't set one, so that they don't
+// consume the connection deltas of the connections clients
+const processStatsClientID = "process-stats"
+
+// maxUSMAttachmentsHitsWindow bounds the window the hits of the uprobes are counted over, as the BPF statistics of the
+// kernel slightly slow down every eBPF program meanwhile
+const maxUSMAttachmentsHitsWindow = 30 * time.Second
+
+// perfBufferCheckInterval is the interval the adaptive sizing of the perf buffers checks their loss at
+const perfBufferCheckInterval = time.Minute
+
+// restartNetworkTracer restarts the network tracer module, it is set by init as the module factory can't refer to
+// itself
+var restartNetworkTracer func() error
+
+func init() {
+	restartNetworkTracer = func() error {
+		return module.RestartModule(NetworkTracer)
+	}
+}
+
 // NetworkTracer is a factory for NPM's tracer
 var NetworkTracer = module.Factory{
 	Name:             config.NetworkTracerModule,
 	ConfigNamespaces: []string{"network_config", "service_monitoring_config"},
+	// once the eBPF programs are loaded, the tracer still reads the eBPF maps, attaches uprobes to the processes using
+	// TLS libraries, dumps the conntrack tables through netlink and reads the /proc entries of the other processes. It
+	// also enters the network namespaces of the containers to look up their routes and conntrack entries, and the java
+	// TLS support takes the identity of the java processes and chowns the agent it attaches to them.
+	Capabilities: []string{
+		"CAP_BPF", "CAP_PERFMON", "CAP_NET_ADMIN", "CAP_SYS_PTRACE", "CAP_DAC_READ_SEARCH", "CAP_SYS_ADMIN",
+		"CAP_SETUID", "CAP_SETGID", "CAP_CHOWN",
+	},
 	Fn: func(cfg *config.Config) (module.Module, error) {
 		ncfg := networkconfig.New()
 
-		// Checking whether the current OS + kernel version is supported by the tracer
-		if supported, msg := tracer.IsTracerSupportedByOS(ncfg.ExcludedBPFLinuxVersions); !supported {
+		if ncfg.OfflineCaptureReplayPath != "" {
+			log.Warnf("replaying the network connections from %s, the host is not monitored", ncfg.OfflineCaptureReplayPath)
+			replay, err := newNetworkReplay(ncfg.OfflineCaptureReplayPath)
+			if err != nil {
+				return nil, fmt.Errorf("could not replay network connections: %w", err)
+			}
+			return replay, nil
+		}
+
+		// Checking whether the current OS + kernel version is supported by the tracer, which doesn't matter when it
+		// doesn't load any eBPF program
+		if supported, msg := tracer.IsTracerSupportedByOS(ncfg.ExcludedBPFLinuxVersions); !supported && !ncfg.EnableEbpfLess {
 			return nil, fmt.Errorf("%w: %s", ErrSysprobeUnsupported, msg)
 		}
 
@@ -64,6 +106,12 @@ var NetworkTracer = module.Factory{
 		done := make(chan struct{})
 		if err == nil {
 			startTelemetryReporter(cfg, done)
+			if ncfg.EnableOfflineCapture {
+				startOfflineCapture(ncfg, t, done)
+			}
+			if ncfg.PerfBufferAdaptiveSizing {
+				startPerfBufferAdaptiveSizing(t, done)
+			}
 		}
 
 		return &networkTracer{tracer: t, done: done}, err
@@ -107,6 +155,82 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		logRequests(id, count, len(cs.Conns), start)
 	}))
 
+	httpMux.HandleFunc("/connections/stream", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			log.Errorf("unable to stream connections to client %s: the response can't be flushed", id)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		interval := defaultStreamInterval
+		if rawInterval := req.URL.Query().Get("interval"); rawInterval != "" {
+			seconds, err := strconv.Atoi(rawInterval)
+			if err != nil || seconds <= 0 {
+				log.Errorf("invalid stream interval for client %s: %s", id, rawInterval)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			interval = time.Duration(seconds) * time.Second
+		}
+
+		if err := nt.tracer.RegisterClient(id); err != nil {
+			log.Errorf("unable to register client: %s", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		closed, unsubscribe := nt.tracer.SubscribeClosedConnections()
+		defer unsubscribe()
+
+		stream := &connectionsStream{
+			clientID: id,
+			interval: interval,
+			getConnections: func(clientID string) (*network.Connections, error) {
+				start := time.Now()
+				cs, err := nt.tracer.GetActiveConnections(clientID)
+				if err != nil {
+					return nil, err
+				}
+				if nt.restartTimer != nil {
+					nt.restartTimer.Reset(inactivityRestartDuration)
+				}
+				logRequests(clientID, runCounter.Inc(), len(cs.Conns), start)
+				return cs, nil
+			},
+			marshaler: encoding.GetMarshaler(req.Header.Get("Accept")),
+		}
+
+		w.Header().Set("Content-type", stream.marshaler.ContentType())
+		w.WriteHeader(http.StatusOK)
+		log.Infof("streaming connections to client %s every %s", id, interval)
+		if err := stream.run(req.Context(), w, flusher.Flush, closed); err != nil {
+			log.Warnf("connections stream of client %s ended: %s", id, err)
+			return
+		}
+		log.Infof("connections stream of client %s ended", id)
+	})
+
+	httpMux.HandleFunc("/processes", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests, func(w http.ResponseWriter, req *http.Request) {
+		// the process stats are computed from connection deltas, which must not be shared with a connections client
+		id := processStatsClientID
+		if rawCID := req.URL.Query().Get("client_id"); rawCID != "" {
+			id = rawCID
+		}
+		processes, err := nt.tracer.GetProcessStats(id)
+		if err != nil {
+			log.Errorf("unable to retrieve process stats: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		if nt.restartTimer != nil {
+			nt.restartTimer.Reset(inactivityRestartDuration)
+		}
+		log.Tracef("/processes: %d processes for client %s", len(processes), id)
+		utils.WriteAsJSON(w, processes)
+	}))
+
 	httpMux.HandleFunc("/register", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests, func(w http.ResponseWriter, req *http.Request) {
 		id := getClientID(req)
 		err := nt.tracer.RegisterClient(id)
@@ -114,11 +238,51 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		if err != nil {
 			log.Errorf("unable to register client: %s", err)
 			w.WriteHeader(http.StatusInternalServerError)
-		} else {
-			w.WriteHeader(http.StatusOK)
+			return
 		}
+
+		if rawMax := req.URL.Query().Get("max_connections"); rawMax != "" {
+			maxConns, err := strconv.Atoi(rawMax)
+			if err != nil {
+				log.Errorf("invalid max_connections for client %s: %s", id, err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			nt.tracer.SetClientMaxConnections(id, maxConns)
+			log.Debugf("limiting the connections of client %s to %d", id, maxConns)
+		}
+		w.WriteHeader(http.StatusOK)
 	}))
 
+	httpMux.HandleFunc("/usm/config", func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var requested map[string]bool
+			if err := json.NewDecoder(req.Body).Decode(&requested); err != nil {
+				log.Errorf("invalid usm config: %s", err)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if status, err := setUSMProtocols(nt.tracer, requested); err != nil {
+				log.Errorf("unable to update usm config: %s", err)
+				w.WriteHeader(status)
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		protocols, err := nt.tracer.GetUSMProtocols()
+		if err != nil {
+			log.Errorf("unable to retrieve usm config: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+		utils.WriteAsJSON(w, protocols)
+	})
+
 	httpMux.HandleFunc("/debug/net_maps", func(w http.ResponseWriter, req *http.Request) {
 		cs, err := nt.tracer.DebugNetworkMaps()
 		if err != nil {
@@ -155,6 +319,135 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		utils.WriteAsJSON(w, debugging.HTTP(cs.HTTP, cs.DNS))
 	})
 
+	httpMux.HandleFunc("/debug/postgres_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.Postgres(cs.Postgres, cs.DNS))
+	})
+
+	httpMux.HandleFunc("/debug/mysql_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.MySQL(cs.MySQL, cs.DNS))
+	})
+
+	httpMux.HandleFunc("/debug/redis_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.Redis(cs.Redis, cs.DNS))
+	})
+
+	httpMux.HandleFunc("/debug/mongo_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.Mongo(cs.Mongo, cs.DNS))
+	})
+
+	httpMux.HandleFunc("/debug/amqp_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.AMQP(cs.AMQP, cs.DNS))
+	})
+
+	httpMux.HandleFunc("/debug/grpc_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.GRPC(cs.GRPC, cs.DNS))
+	})
+
+	httpMux.HandleFunc("/debug/http3_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.HTTP3(cs.HTTP3, cs.DNS))
+	})
+
+	httpMux.HandleFunc("/debug/cassandra_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.Cassandra(cs.Cassandra, cs.DNS))
+	})
+
+	httpMux.HandleFunc("/debug/memcached_monitoring", func(w http.ResponseWriter, req *http.Request) {
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.Memcached(cs.Memcached, cs.DNS))
+	})
+
+	// /debug/usm_consistency compares the HTTP stats collected since the last call against the server access log
+	// provided in the request body (as JSON lines), and reports missed or extra transactions
+	httpMux.HandleFunc("/debug/usm_consistency", func(w http.ResponseWriter, req *http.Request) {
+		accessLog, err := debugging.ParseAccessLog(req.Body)
+		if err != nil {
+			log.Errorf("unable to parse access log: %s", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		id := getClientID(req)
+		cs, err := nt.tracer.GetActiveConnections(id)
+		if err != nil {
+			log.Errorf("unable to retrieve connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, debugging.CheckConsistency(debugging.HTTP(cs.HTTP, cs.DNS), accessLog))
+	})
+
 	// /debug/ebpf_maps as default will dump all registered maps/perfmaps
 	// an optional ?maps= argument could be pass with a list of map name : ?maps=map1,map2,map3
 	httpMux.HandleFunc("/debug/ebpf_maps", func(w http.ResponseWriter, req *http.Request) {
@@ -173,6 +466,52 @@ func (nt *networkTracer) Register(httpMux *module.Router) error {
 		utils.WriteAsJSON(w, ebpfMaps)
 	})
 
+	// /debug/usm/attachments lists the binaries and the shared libraries whose uprobes are attached, or failed to attach.
+	// An optional ?hits_window= argument, such as ?hits_window=10s, counts the hits of the hooks over the window.
+	httpMux.HandleFunc("/debug/usm/attachments", func(w http.ResponseWriter, req *http.Request) {
+		var hitsWindow time.Duration
+		if window := req.URL.Query().Get("hits_window"); window != "" {
+			var err error
+			hitsWindow, err = time.ParseDuration(window)
+			if err != nil || hitsWindow < 0 || hitsWindow > maxUSMAttachmentsHitsWindow {
+				log.Errorf("invalid hits window %q, it must be a duration of at most %s", window, maxUSMAttachmentsHitsWindow)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+
+		attachments, err := nt.tracer.DebugUSMAttachments(req.Context(), hitsWindow)
+		if err != nil {
+			log.Errorf("unable to retrieve uprobe attachments: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, attachments)
+	})
+
+	httpMux.HandleFunc("/debug/tcp_drops", func(w http.ResponseWriter, req *http.Request) {
+		drops, err := nt.tracer.DebugTCPDrops()
+		if err != nil {
+			log.Errorf("unable to retrieve tcp drops: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, drops)
+	})
+
+	httpMux.HandleFunc("/debug/http2_connections", func(w http.ResponseWriter, req *http.Request) {
+		connections, err := nt.tracer.DebugHTTP2Connections()
+		if err != nil {
+			log.Errorf("unable to retrieve http2 connections: %s", err)
+			w.WriteHeader(500)
+			return
+		}
+
+		utils.WriteAsJSON(w, connections)
+	})
+
 	httpMux.HandleFunc("/debug/conntrack/cached", func(w http.ResponseWriter, req *http.Request) {
 		ctx, cancelFunc := context.WithTimeout(req.Context(), 30*time.Second)
 		defer cancelFunc()
@@ -254,6 +593,31 @@ func logRequests(client string, count uint64, connectionsCount int, start time.T
 	}
 }
 
+// setUSMProtocols enables or disables the requested USM protocols, in a stable order. It returns the status code to
+// reply with when a protocol can't be toggled.
+func setUSMProtocols(t *tracer.Tracer, requested map[string]bool) (int, error) {
+	current, err := t.GetUSMProtocols()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	names := make([]string, 0, len(requested))
+	for name := range requested {
+		if _, ok := current[name]; !ok {
+			return http.StatusBadRequest, fmt.Errorf("unknown protocol or disabled at startup: %s", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := t.SetUSMProtocolEnabled(name, requested[name]); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	return http.StatusOK, nil
+}
+
 func getClientID(req *http.Request) string {
 	var clientID = network.DEBUGCLIENT
 	if rawCID := req.URL.Query().Get("client_id"); rawCID != "" {
@@ -277,6 +641,43 @@ func writeConnections(w http.ResponseWriter, marshaler encoding.Marshaler, cs *n
 	log.Tracef("/connections: %d connections, %d bytes", len(cs.Conns), len(buf))
 }
 
+func startOfflineCapture(cfg *networkconfig.Config, t *tracer.Tracer, done <-chan struct{}) {
+	capture, err := newOfflineCapture(cfg, t.GetActiveConnections)
+	if err != nil {
+		log.Errorf("failed to start the offline capture of the network connections: %s", err)
+		return
+	}
+	capture.start(cfg.OfflineCaptureInterval, done)
+}
+
+// startPerfBufferAdaptiveSizing restarts the network tracer once some of its perf buffers keep losing samples, so that
+// they are grown when its eBPF programs are loaded again
+func startPerfBufferAdaptiveSizing(t *tracer.Tracer, done <-chan struct{}) {
+	ticker := time.NewTicker(perfBufferCheckInterval)
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				lossy := t.SustainedPerfBufferLoss()
+				if len(lossy) == 0 {
+					continue
+				}
+				log.Warnf("restarting the network tracer to grow the perf buffers of %s, which keep losing samples", strings.Join(lossy, ", "))
+				// the restart is not attempted again when it fails. The config validation rejects adaptive sizing
+				// with dropped privileges, as the modules can't be restarted once they are dropped.
+				if err := restartNetworkTracer(); err != nil {
+					log.Errorf("could not restart the network tracer to grow its perf buffers: %s", err)
+				}
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
 func startTelemetryReporter(cfg *config.Config, done <-chan struct{}) {
 	statsdAddr := os.Getenv("STATSD_URL")
 	if statsdAddr == "" {
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/syndtr/gocapability/capability"
+	"golang.org/x/sys/unix"
+	"kernel.org/pub/linux/libs/security/libcap/psx"
+)
+
+// resolveCapabilities parses capability names, such as CAP_BPF, and returns the ones to keep on the running kernel.
+// CAP_BPF and CAP_PERFMON are replaced by CAP_SYS_ADMIN on kernels older than 5.8, which do not know them.
+func resolveCapabilities(names []string, lastCap capability.Cap) ([]capability.Cap, error) {
+	known := make(map[string]capability.Cap)
+	for _, c := range capability.List() {
+		known[c.String()] = c
+	}
+
+	set := make(map[capability.Cap]struct{})
+	for _, name := range names {
+		c, ok := known[strings.TrimPrefix(strings.ToLower(name), "cap_")]
+		if !ok {
+			return nil, fmt.Errorf("unknown capability %s", name)
+		}
+		if c > lastCap {
+			if c != capability.CAP_BPF && c != capability.CAP_PERFMON {
+				return nil, fmt.Errorf("capability %s is not supported by the running kernel", name)
+			}
+			c = capability.CAP_SYS_ADMIN
+		}
+		set[c] = struct{}{}
+	}
+
+	caps := make([]capability.Cap, 0, len(set))
+	for c := range set {
+		caps = append(caps, c)
+	}
+	sort.Slice(caps, func(i, j int) bool { return caps[i] < caps[j] })
+	return caps, nil
+}
+
+func capabilityNames(caps []capability.Cap) []string {
+	names := make([]string, 0, len(caps))
+	for _, c := range caps {
+		names = append(names, "CAP_"+strings.ToUpper(c.String()))
+	}
+	return names
+}
+
+// DropCapabilities removes all the capabilities of the process but the given ones, from all its threads. The
+// bounding and ambient sets are cleared as well, so that the dropped capabilities cannot be regained, even by
+// executing another binary. The capabilities which are kept are returned.
+func DropCapabilities(names []string) ([]string, error) {
+	keep, err := resolveCapabilities(names, capability.CAP_LAST_CAP)
+	if err != nil {
+		return nil, err
+	}
+	kept := make(map[capability.Cap]struct{}, len(keep))
+	for _, c := range keep {
+		kept[c] = struct{}{}
+	}
+
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capget(&hdr, &data[0]); err != nil {
+		return nil, fmt.Errorf("could not get the capabilities of the process: %w", err)
+	}
+
+	var permitted [2]uint32
+	for _, c := range keep {
+		permitted[c>>5] |= 1 << (uint(c) & 31)
+	}
+	for i := range data {
+		// capabilities can't be added to the permitted set
+		data[i].Permitted &= permitted[i]
+		data[i].Effective = data[i].Permitted
+		data[i].Inheritable = 0
+	}
+
+	// the capabilities are per thread, and the threads of the process are already running, such as the ones of the Go
+	// runtime or of the C libraries, so the system calls are run on all of them by psx, whether cgo is linked or not.
+	// The bounding set must be reduced first, as it requires CAP_SETPCAP.
+	for c := capability.Cap(0); c <= capability.CAP_LAST_CAP; c++ {
+		if _, ok := kept[c]; ok {
+			continue
+		}
+		if err := allThreadsPrctl(unix.PR_CAPBSET_DROP, uintptr(c), 0); err != nil {
+			return nil, fmt.Errorf("could not drop capability %s from the bounding set: %w", capabilityNames([]capability.Cap{c})[0], err)
+		}
+	}
+	if err := allThreadsPrctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0); err != nil {
+		return nil, fmt.Errorf("could not clear the ambient capabilities: %w", err)
+	}
+
+	if _, _, errno := psx.Syscall3(unix.SYS_CAPSET, uintptr(unsafe.Pointer(&hdr)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return nil, fmt.Errorf("could not set the capabilities of the process: %w", errno)
+	}
+	return capabilityNames(keep), nil
+}
+
+func allThreadsPrctl(option int, arg2, arg3 uintptr) error {
+	// some options, such as PR_CAP_AMBIENT, require the unused arguments to be 0
+	if _, _, errno := psx.Syscall6(unix.SYS_PRCTL, uintptr(option), arg2, arg3, 0, 0, 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package utils
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syndtr/gocapability/capability"
+)
+
+func TestResolveCapabilities(t *testing.T) {
+	caps, err := resolveCapabilities([]string{"CAP_PERFMON", "cap_bpf", "net_admin", "CAP_BPF"}, capability.CAP_BPF)
+	require.NoError(t, err)
+	assert.Equal(t, []capability.Cap{capability.CAP_NET_ADMIN, capability.CAP_PERFMON, capability.CAP_BPF}, caps)
+	assert.Equal(t, []string{"CAP_NET_ADMIN", "CAP_PERFMON", "CAP_BPF"}, capabilityNames(caps))
+
+	t.Run("kernel older than 5.8", func(t *testing.T) {
+		caps, err := resolveCapabilities([]string{"CAP_BPF", "CAP_PERFMON", "CAP_NET_ADMIN"}, capability.CAP_AUDIT_READ)
+		require.NoError(t, err)
+		assert.Equal(t, []capability.Cap{capability.CAP_NET_ADMIN, capability.CAP_SYS_ADMIN}, caps)
+	})
+
+	t.Run("unknown capability", func(t *testing.T) {
+		_, err := resolveCapabilities([]string{"CAP_BPF", "CAP_FLY"}, capability.CAP_BPF)
+		assert.Error(t, err)
+	})
+
+	t.Run("capability unknown to the kernel", func(t *testing.T) {
+		_, err := resolveCapabilities([]string{"CAP_CHECKPOINT_RESTORE"}, capability.CAP_BPF)
+		assert.Error(t, err)
+	})
+}
+
+// TestDropCapabilities drops the capabilities in a child process, as they can't be regained
+func TestDropCapabilities(t *testing.T) {
+	if os.Getenv("DD_TEST_DROP_CAPABILITIES") == "1" {
+		dropCapabilities(t)
+		return
+	}
+
+	caps, err := capability.NewPid2(0)
+	require.NoError(t, err)
+	require.NoError(t, caps.Load())
+	if !caps.Get(capability.EFFECTIVE, capability.CAP_SETPCAP) || !caps.Get(capability.EFFECTIVE, capability.CAP_NET_ADMIN) {
+		t.Skip("CAP_SETPCAP and CAP_NET_ADMIN are required to drop the capabilities")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestDropCapabilities$", "-test.v")
+	cmd.Env = append(os.Environ(), "DD_TEST_DROP_CAPABILITIES=1")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func dropCapabilities(t *testing.T) {
+	// threads which are already running when the capabilities are dropped
+	stop := make(chan struct{})
+	var started, stopped sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		started.Add(1)
+		stopped.Add(1)
+		go func() {
+			defer stopped.Done()
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			started.Done()
+			<-stop
+		}()
+	}
+	started.Wait()
+	defer func() {
+		close(stop)
+		stopped.Wait()
+	}()
+
+	kept, err := DropCapabilities([]string{"CAP_NET_ADMIN"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CAP_NET_ADMIN"}, kept)
+
+	tasks, err := filepath.Glob("/proc/self/task/*/status")
+	require.NoError(t, err)
+	require.Greater(t, len(tasks), 4)
+
+	netAdmin := uint64(1) << uint(capability.CAP_NET_ADMIN)
+	for _, task := range tasks {
+		status := readCapabilities(t, task)
+		assert.Equal(t, netAdmin, status["CapEff"], task)
+		assert.Equal(t, netAdmin, status["CapPrm"], task)
+		assert.Equal(t, netAdmin, status["CapBnd"], task)
+		assert.Zero(t, status["CapInh"], task)
+		assert.Zero(t, status["CapAmb"], task)
+	}
+}
+
+func readCapabilities(t *testing.T, path string) map[string]uint64 {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	caps := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || !strings.HasPrefix(fields[0], "Cap") {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 16, 64)
+		require.NoError(t, err)
+		caps[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	require.NoError(t, scanner.Err())
+	return caps
+}
@@ -0,0 +1,18 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !linux
+// +build !linux
+
+package utils
+
+import "errors"
+
+var errCapabilitiesUnsupported = errors.New("capabilities are only supported on linux")
+
+// DropCapabilities is not supported on this platform
+func DropCapabilities(names []string) ([]string, error) {
+	return nil, errCapabilitiesUnsupported
+}
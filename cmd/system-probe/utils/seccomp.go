@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package utils
+
+import (
+	"encoding/json"
+	"runtime"
+	"sort"
+)
+
+// seccompProfile is a seccomp profile in the format of the OCI runtime specification, as used by docker and
+// kubernetes
+type seccompProfile struct {
+	DefaultAction string        `json:"defaultAction"`
+	Architectures []string      `json:"architectures,omitempty"`
+	Syscalls      []seccompRule `json:"syscalls"`
+}
+
+type seccompRule struct {
+	Names  []string `json:"names"`
+	Action string   `json:"action"`
+}
+
+// baseSyscalls are the syscalls used by the system-probe once its modules are started, independently of its
+// capabilities
+var baseSyscalls = []string{
+	"accept", "accept4", "arch_prctl", "bind", "brk", "capget", "clock_getres", "clock_gettime", "clock_nanosleep",
+	"clone", "clone3", "close", "connect", "dup", "dup2", "dup3", "epoll_create", "epoll_create1", "epoll_ctl",
+	"epoll_pwait", "epoll_wait", "eventfd2", "execve", "exit", "exit_group", "faccessat", "faccessat2", "fcntl", "fstat",
+	"fstatfs", "fsync", "futex", "getcwd", "getdents64", "getegid", "geteuid", "getgid", "getpeername", "getpid",
+	"getppid", "getrandom", "getrlimit", "getrusage", "getsockname", "getsockopt", "gettid", "gettimeofday", "getuid",
+	"inotify_add_watch", "inotify_init1", "inotify_rm_watch", "ioctl", "lseek", "lstat", "madvise", "mincore",
+	"mkdirat", "mmap", "mprotect", "munmap", "nanosleep", "newfstatat", "open", "openat", "pipe", "pipe2", "poll",
+	"ppoll", "prctl", "pread64", "prlimit64", "pselect6", "read", "readlink", "readlinkat", "readv", "recvfrom",
+	"recvmsg", "renameat", "restart_syscall", "rt_sigaction", "rt_sigprocmask", "rt_sigreturn", "sched_getaffinity",
+	"sched_yield", "select", "sendmsg", "sendto", "set_robust_list", "set_tid_address", "setitimer", "setsockopt",
+	"shutdown", "sigaltstack", "socket", "stat", "statfs", "statx", "sysinfo", "tgkill", "timer_create",
+	"timer_delete", "timer_settime", "uname", "unlinkat", "wait4", "waitid", "write", "writev",
+}
+
+// capabilitySyscalls are the syscalls which can only be used with a capability
+var capabilitySyscalls = map[string][]string{
+	"CAP_BPF":        {"bpf"},
+	"CAP_CHOWN":      {"chown", "fchown", "fchownat", "lchown"},
+	"CAP_PERFMON":    {"perf_event_open"},
+	"CAP_SETGID":     {"setfsgid", "setgid", "setgroups", "setregid", "setresgid"},
+	"CAP_SETUID":     {"setfsuid", "setresuid", "setreuid", "setuid"},
+	"CAP_SYS_ADMIN":  {"bpf", "perf_event_open", "setns", "unshare"},
+	"CAP_SYS_PTRACE": {"process_vm_readv", "ptrace"},
+}
+
+// SeccompProfile returns a seccomp profile allowing the syscalls used by the system-probe once it runs with the given
+// capabilities, which are expected to be resolved by DropCapabilities
+func SeccompProfile(capabilities []string) ([]byte, error) {
+	allowed := make(map[string]struct{}, len(baseSyscalls))
+	for _, name := range baseSyscalls {
+		allowed[name] = struct{}{}
+	}
+	for _, c := range capabilities {
+		for _, name := range capabilitySyscalls[c] {
+			allowed[name] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(allowed))
+	for name := range allowed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	profile := seccompProfile{
+		DefaultAction: "SCMP_ACT_ERRNO",
+		Architectures: seccompArchitectures(runtime.GOARCH),
+		Syscalls:      []seccompRule{{Names: names, Action: "SCMP_ACT_ALLOW"}},
+	}
+	return json.MarshalIndent(profile, "", "  ")
+}
+
+func seccompArchitectures(goarch string) []string {
+	switch goarch {
+	case "amd64":
+		return []string{"SCMP_ARCH_X86_64", "SCMP_ARCH_X86", "SCMP_ARCH_X32"}
+	case "arm64":
+		return []string{"SCMP_ARCH_AARCH64", "SCMP_ARCH_ARM"}
+	default:
+		return nil
+	}
+}
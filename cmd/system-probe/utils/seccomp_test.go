@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeccompProfile(t *testing.T) {
+	allowed := func(capabilities ...string) []string {
+		buf, err := SeccompProfile(capabilities)
+		require.NoError(t, err)
+
+		var profile seccompProfile
+		require.NoError(t, json.Unmarshal(buf, &profile))
+		assert.Equal(t, "SCMP_ACT_ERRNO", profile.DefaultAction)
+		require.Len(t, profile.Syscalls, 1)
+		assert.Equal(t, "SCMP_ACT_ALLOW", profile.Syscalls[0].Action)
+		return profile.Syscalls[0].Names
+	}
+
+	names := allowed("CAP_BPF", "CAP_PERFMON", "CAP_NET_ADMIN")
+	assert.Contains(t, names, "bpf")
+	assert.Contains(t, names, "perf_event_open")
+	assert.Contains(t, names, "futex")
+	assert.NotContains(t, names, "setns")
+	assert.IsIncreasing(t, names)
+
+	names = allowed()
+	assert.NotContains(t, names, "bpf")
+	assert.NotContains(t, names, "perf_event_open")
+
+	names = allowed("CAP_SYS_ADMIN")
+	assert.Contains(t, names, "bpf")
+	assert.Contains(t, names, "setns")
+
+	names = allowed("CAP_SETUID", "CAP_SETGID", "CAP_CHOWN")
+	assert.Contains(t, names, "setresuid")
+	assert.Contains(t, names, "setresgid")
+	assert.Contains(t, names, "fchownat")
+}
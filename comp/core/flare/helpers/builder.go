@@ -135,6 +135,10 @@ func (fb *builder) Save() (string, error) {
 	_ = fb.AddFileFromFunc("permissions.log", fb.permsInfos.commit)
 	_ = fb.logFile.Close()
 
+	// Files added without scrubbing, or content that no replacer matched, could still contain the value of a
+	// resolved secret: we check the whole flare before archiving it.
+	fb.redactSecretValues()
+
 	archiveName := getArchiveName()
 	archiveTmpPath := filepath.Join(fb.tmpDir, archiveName)
 	archiveFinalPath := filepath.Join(os.TempDir(), archiveName)
@@ -161,6 +165,33 @@ func (fb *builder) Save() (string, error) {
 	return archiveFinalPath, os.Rename(archiveTmpPath, archiveFinalPath)
 }
 
+// redactSecretValues redacts the values of the resolved secrets from every file of the flare
+func (fb *builder) redactSecretValues() {
+	_ = filepath.Walk(fb.flareDir, func(path string, f os.FileInfo, err error) error {
+		if err != nil || f.IsDir() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Errorf("error reading flare file '%s' to check for secrets: %s", path, err)
+			return nil
+		}
+		redacted, found := scrubber.RedactSecretValues(content)
+		if !found {
+			return nil
+		}
+
+		log.Warnf("Redacted the value of a resolved secret from flare file '%s'", strings.TrimPrefix(path, fb.flareDir))
+		if err := os.WriteFile(path, redacted, filePerm); err != nil {
+			// the file can't be left in the flare with the secret
+			log.Errorf("error writing redacted flare file '%s', removing it: %s", path, err)
+			os.Remove(path)
+		}
+		return nil
+	})
+}
+
 func (fb *builder) clean() {
 	os.RemoveAll(fb.tmpDir)
 }
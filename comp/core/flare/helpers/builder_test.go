@@ -18,6 +18,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/util/hostname"
 	"github.com/DataDog/datadog-agent/pkg/util/hostname/validate"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 var FromSlash = filepath.FromSlash
@@ -101,6 +102,24 @@ func TestSave(t *testing.T) {
 	assert.FileExists(t, filepath.Join(tmpDir, hostname, "test/depth1/depth2/test4"))
 }
 
+func TestRedactSecretValues(t *testing.T) {
+	scrubber.AddSecretValues("resolved-secret")
+	defer scrubber.ClearSecretValues()
+
+	fb, err := newBuilder(t.TempDir(), "test-host")
+	require.NoError(t, err)
+	defer fb.logFile.Close()
+
+	fb.AddFile(FromSlash("test/scrubbed"), []byte("custom_field: resolved-secret"))
+	assertFileContent(t, fb, "custom_field: ********", "test/scrubbed")
+
+	fb.AddFileWithoutScrubbing(FromSlash("test/not_scrubbed"), []byte("custom_field: resolved-secret"))
+	assertFileContent(t, fb, "custom_field: resolved-secret", "test/not_scrubbed")
+
+	fb.redactSecretValues()
+	assertFileContent(t, fb, "custom_field: ********", "test/not_scrubbed")
+}
+
 func TestAddFileFromFunc(t *testing.T) {
 	fb := getNewBuilder(t)
 	defer fb.clean()
@@ -240,6 +240,7 @@ require (
 	k8s.io/kubelet v0.25.5
 	k8s.io/metrics v0.25.5
 	k8s.io/utils v0.0.0-20221108210102-8e77b1f39fe2
+	kernel.org/pub/linux/libs/security/libcap/psx v1.2.71
 	sigs.k8s.io/custom-metrics-apiserver v1.25.1
 )
 
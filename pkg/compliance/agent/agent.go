@@ -8,15 +8,21 @@ package agent
 
 import (
 	"context"
+	"crypto/ed25519"
+	"errors"
 	"expvar"
+	"fmt"
 	"path"
 	"path/filepath"
+	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/compliance"
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/exceptions"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -39,6 +45,51 @@ type Agent struct {
 	configDir string
 	endpoints *config.Endpoints
 	cancel    context.CancelFunc
+
+	exceptions   *exceptions.Registry
+	remoteClient RemoteClient
+
+	// mu protects the scheduled checks and the state of the remote bundles
+	mu            sync.Mutex
+	checks        []compliance.Check
+	remoteBundles *remoteBundles
+}
+
+// RemoteClient abstracts the Remote Configuration client delivering the rule bundles and the exceptions
+type RemoteClient interface {
+	RegisterCSPMBundlesUpdate(fn func(update map[string]state.ConfigCSPMBundle))
+	RegisterCSPMExceptionsUpdate(fn func(update map[string]state.ConfigCSPMExceptions))
+	Start()
+	Close()
+}
+
+// RemoteConfig holds the configuration of the compliance features driven by Remote Configuration
+type RemoteConfig struct {
+	// Bundles enables the rule bundles, when set
+	Bundles *RemoteBundlesConfig
+	// Exceptions enables the exceptions, added to the ones of the registry set with SetExceptions
+	Exceptions bool
+}
+
+// RemoteBundlesConfig holds the configuration of the rule bundles delivered through Remote Configuration
+type RemoteBundlesConfig struct {
+	// PublicKey is the key the signature of the bundles is verified with
+	PublicKey ed25519.PublicKey
+	// PinnedVersion is the version of the bundle to activate, instead of the latest one
+	PinnedVersion string
+	// StoreDir is the directory the bundles are written to
+	StoreDir string
+}
+
+type remoteBundles struct {
+	publicKey     ed25519.PublicKey
+	pinnedVersion string
+	store         *bundleStore
+
+	// active is the version of the active bundle, empty when the rules of the config directory are active
+	active    string
+	previous  string
+	lastError error
 }
 
 // New creates a new instance of Agent
@@ -120,6 +171,9 @@ func (a *Agent) Run() error {
 		}),
 	)
 
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	onCheck := func(rule *compliance.RuleCommon, check compliance.Check, err error) bool {
 		if err != nil {
 			log.Infof("%s: check not scheduled: %v", rule.ID, err)
@@ -131,12 +185,185 @@ func (a *Agent) Run() error {
 			log.Errorf("%s: failed to schedule check: %v", rule.ID, err)
 			return false
 		}
+		a.checks = append(a.checks, check)
 
 		return true
 	}
 	return a.buildChecks(onCheck)
 }
 
+// SetExceptions sets the registry of the exceptions, to report the expired ones
+func (a *Agent) SetExceptions(registry *exceptions.Registry) {
+	a.exceptions = registry
+	if a.telemetry != nil {
+		a.telemetry.exceptions = registry
+	}
+}
+
+// EnableRemoteConfiguration subscribes to the rule bundles and the exceptions delivered through Remote Configuration.
+//
+// The latest bundle, or the pinned one, replaces the rules of the config directory. A bundle that fails to load is not
+// activated, and the previously active rules keep running.
+func (a *Agent) EnableRemoteConfiguration(client RemoteClient, cfg RemoteConfig) {
+	a.remoteClient = client
+
+	if cfg.Bundles != nil {
+		a.mu.Lock()
+		a.remoteBundles = &remoteBundles{
+			publicKey:     cfg.Bundles.PublicKey,
+			pinnedVersion: cfg.Bundles.PinnedVersion,
+			store:         &bundleStore{dir: cfg.Bundles.StoreDir},
+		}
+		a.mu.Unlock()
+
+		client.RegisterCSPMBundlesUpdate(a.onBundlesUpdate)
+	}
+
+	if cfg.Exceptions && a.exceptions != nil {
+		client.RegisterCSPMExceptionsUpdate(a.onExceptionsUpdate)
+	}
+
+	client.Start()
+}
+
+func (a *Agent) onExceptionsUpdate(configs map[string]state.ConfigCSPMExceptions) {
+	var remote []*exceptions.Exception
+	for path, config := range configs {
+		list, err := exceptions.Parse(config.Config)
+		if err != nil {
+			log.Errorf("Ignoring compliance exceptions %s: %v", path, err)
+			continue
+		}
+		remote = append(remote, list...)
+	}
+	a.exceptions.SetRemote(remote)
+	log.Infof("Updated the compliance exceptions, %d received through remote configuration", len(remote))
+}
+
+func (a *Agent) onBundlesUpdate(configs map[string]state.ConfigCSPMBundle) {
+	var bundles []*Bundle
+	for path, config := range configs {
+		bundle, err := ParseSignedBundle(config.Config, a.remoteBundles.publicKey)
+		if err != nil {
+			log.Errorf("Ignoring compliance bundle %s: %v", path, err)
+			continue
+		}
+		bundles = append(bundles, bundle)
+	}
+	a.updateBundles(bundles)
+}
+
+func (a *Agent) updateBundles(bundles []*Bundle) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rb := a.remoteBundles
+	version, dir, err := a.selectBundle(bundles)
+	if err != nil {
+		log.Errorf("Failed to select a compliance bundle, keeping the active rules: %v", err)
+		rb.lastError = err
+		return
+	}
+	if version == rb.active {
+		return
+	}
+
+	if err := a.activate(dir); err != nil {
+		err = fmt.Errorf("failed to activate the rules from %s: %w", dir, err)
+		log.Errorf("Rolled back to the previously active compliance rules: %v", err)
+		rb.lastError = err
+		return
+	}
+
+	log.Infof("Activated compliance rules from %s", dir)
+	rb.previous, rb.active, rb.lastError = rb.active, version, nil
+	rb.store.prune(rb.active, rb.previous, rb.pinnedVersion)
+}
+
+// selectBundle returns the version and directory of the rules to activate. The pinned version is looked for in the
+// delivered bundles first, then in the bundles stored on disk, so the agent can be rolled back to a bundle that isn't
+// distributed anymore. Without any bundle, the rules of the config directory are activated.
+func (a *Agent) selectBundle(bundles []*Bundle) (string, string, error) {
+	rb := a.remoteBundles
+
+	var selected *Bundle
+	if rb.pinnedVersion != "" {
+		for _, bundle := range bundles {
+			if bundle.Version == rb.pinnedVersion {
+				selected = bundle
+				break
+			}
+		}
+		if selected == nil {
+			if rb.store.has(rb.pinnedVersion) {
+				return rb.pinnedVersion, rb.store.path(rb.pinnedVersion), nil
+			}
+			return "", "", fmt.Errorf("pinned bundle version %s is not available", rb.pinnedVersion)
+		}
+	} else {
+		selected = latestBundle(bundles)
+		if selected == nil {
+			return "", a.configDir, nil
+		}
+	}
+
+	if selected.Version == rb.active {
+		return selected.Version, rb.store.path(selected.Version), nil
+	}
+	dir, err := rb.store.write(selected)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to write bundle %s: %w", selected.Version, err)
+	}
+	return selected.Version, dir, nil
+}
+
+// activate replaces the scheduled checks by the checks of the rules of a directory. The previous checks are
+// scheduled back if any of the new checks fails to be scheduled.
+func (a *Agent) activate(dir string) error {
+	var checks []compliance.Check
+	onCheck := func(rule *compliance.RuleCommon, check compliance.Check, err error) bool {
+		if err != nil {
+			log.Infof("%s: check not scheduled: %v", rule.ID, err)
+			return true
+		}
+		checks = append(checks, check)
+		return true
+	}
+	if err := a.loadChecks(dir, onCheck); err != nil {
+		return err
+	}
+
+	previous := a.checks
+	a.cancelChecks()
+	if err := a.enterChecks(checks); err != nil {
+		a.cancelChecks()
+		if rollbackErr := a.enterChecks(previous); rollbackErr != nil {
+			log.Errorf("Failed to schedule back the previous checks: %v", rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (a *Agent) enterChecks(checks []compliance.Check) error {
+	for _, check := range checks {
+		if err := a.scheduler.Enter(check); err != nil {
+			return fmt.Errorf("failed to schedule check %s: %w", check.ID(), err)
+		}
+		a.checks = append(a.checks, check)
+	}
+	return nil
+}
+
+func (a *Agent) cancelChecks() {
+	for _, check := range a.checks {
+		if err := a.scheduler.Cancel(check.ID()); err != nil {
+			log.Errorf("%s: failed to cancel check: %v", check.ID(), err)
+		}
+	}
+	a.checks = nil
+}
+
 func runCheck(rule *compliance.RuleCommon, check compliance.Check, err error) bool {
 	if err != nil {
 		log.Infof("%s: Not running check: %v", rule.ID, err)
@@ -164,6 +391,10 @@ func (a *Agent) RunChecksFromFile(file string) error {
 
 // Stop stops the Compliance Agent
 func (a *Agent) Stop() {
+	if a.remoteClient != nil {
+		a.remoteClient.Close()
+	}
+
 	if err := a.scheduler.Stop(); err != nil {
 		log.Errorf("Scheduler failed to stop: %v", err)
 	}
@@ -194,9 +425,45 @@ func (a *Agent) buildChecks(onCheck compliance.CheckVisitor) error {
 	return nil
 }
 
+// loadChecks builds the checks of the rules of a directory, and fails if any of the rule files fails to load
+func (a *Agent) loadChecks(dir string, onCheck compliance.CheckVisitor) error {
+	log.Infof("Loading compliance rules from %s", dir)
+	files, err := filepath.Glob(path.Join(dir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no rule file found")
+	}
+
+	for _, file := range files {
+		if err := a.builder.ChecksFromFile(file, onCheck); err != nil {
+			return fmt.Errorf("failed to load rules from %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
 // GetStatus returns the agent status
 func (a *Agent) GetStatus() map[string]interface{} {
-	return map[string]interface{}{
+	agentStatus := map[string]interface{}{
 		"endpoints": a.endpoints.GetStatus(),
 	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if rb := a.remoteBundles; rb != nil {
+		bundleStatus := map[string]interface{}{
+			"active_version": rb.active,
+			"pinned_version": rb.pinnedVersion,
+		}
+		if rb.lastError != nil {
+			bundleStatus["last_error"] = rb.lastError.Error()
+		}
+		agentStatus["remoteBundles"] = bundleStatus
+	}
+	if a.exceptions != nil {
+		agentStatus["exceptions"] = a.exceptions.GetStatus()
+	}
+	return agentStatus
 }
@@ -13,13 +13,16 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/exceptions"
 	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
 	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
 	"github.com/DataDog/datadog-agent/pkg/util"
 
 	"github.com/stretchr/testify/assert"
@@ -350,3 +353,31 @@ func TestRunChecksFromFile(t *testing.T) {
 	)
 	assert.NoError(err)
 }
+
+func TestRemoteExceptions(t *testing.T) {
+	assert := assert.New(t)
+
+	opts := aggregator.DefaultAgentDemultiplexerOptions(nil)
+	opts.DontStartForwarders = true
+	aggregator.InitAndStartAgentDemultiplexer(opts, "foo")
+
+	e := enterTempEnv(t, false)
+	defer e.leave()
+
+	agent, err := New(&mocks.Reporter{}, &mocks.Scheduler{}, e.dir, &config.Endpoints{})
+	assert.NoError(err)
+
+	registry := exceptions.NewRegistry(nil)
+	agent.SetExceptions(registry)
+
+	client := &mockRemoteClient{}
+	agent.EnableRemoteConfiguration(client, RemoteConfig{Exceptions: true})
+	assert.Nil(client.update)
+
+	client.updateExceptions(map[string]state.ConfigCSPMExceptions{
+		"exceptions": {Config: []byte(`{"exceptions": [{"rule_id": "cis-docker-1", "justification": "vendor image", "expires": "2999-12-31"}]}`)},
+		"invalid":    {Config: []byte(`{"exceptions": {}}`)},
+	})
+	assert.NotNil(registry.Match("cis-docker-1", "docker_daemon", "the-host", time.Now()))
+	assert.Equal(1, agent.GetStatus()["exceptions"].(map[string]interface{})["active"])
+}
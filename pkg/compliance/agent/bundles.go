@@ -0,0 +1,174 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// maxStoredBundles is the number of bundles kept on disk, so the agent can be pinned back to a previous version
+// that is not distributed anymore
+const maxStoredBundles = 3
+
+// Bundle is a set of rule files released together, such as a new release of the benchmarks
+type Bundle struct {
+	Version string            `json:"version"`
+	Files   map[string]string `json:"files"`
+}
+
+// signedBundle is the payload distributed through Remote Configuration
+type signedBundle struct {
+	Bundle    []byte `json:"bundle"`
+	Signature []byte `json:"signature"`
+}
+
+// ParsePublicKey decodes a base64 encoded ed25519 public key
+func ParsePublicKey(key string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bundle public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid bundle public key: expected %d bytes, got %d", ed25519.PublicKeySize, len(raw))
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ParseSignedBundle verifies the signature of a signed bundle and returns the bundle
+func ParseSignedBundle(raw []byte, publicKey ed25519.PublicKey) (*Bundle, error) {
+	var signed signedBundle
+	if err := json.Unmarshal(raw, &signed); err != nil {
+		return nil, fmt.Errorf("failed to parse signed bundle: %w", err)
+	}
+
+	if !ed25519.Verify(publicKey, signed.Bundle, signed.Signature) {
+		return nil, errors.New("invalid bundle signature")
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(signed.Bundle, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if _, err := semver.NewVersion(bundle.Version); err != nil {
+		return nil, fmt.Errorf("invalid bundle version `%s`: %w", bundle.Version, err)
+	}
+	for name := range bundle.Files {
+		if !isValidBundleFileName(name) {
+			return nil, fmt.Errorf("invalid file name `%s` in bundle %s", name, bundle.Version)
+		}
+	}
+
+	return &bundle, nil
+}
+
+func isValidBundleFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && filepath.Base(name) == name
+}
+
+// latestBundle returns the bundle with the highest version
+func latestBundle(bundles []*Bundle) *Bundle {
+	var latest *Bundle
+	var latestVersion *semver.Version
+	for _, bundle := range bundles {
+		version, err := semver.NewVersion(bundle.Version)
+		if err != nil {
+			continue
+		}
+		if latestVersion == nil || version.GreaterThan(latestVersion) {
+			latest, latestVersion = bundle, version
+		}
+	}
+	return latest
+}
+
+// bundleStore stores the rule files of the bundles on disk, one directory per version
+type bundleStore struct {
+	dir string
+}
+
+func (s *bundleStore) path(version string) string {
+	return filepath.Join(s.dir, version)
+}
+
+// has returns true if a bundle version is stored
+func (s *bundleStore) has(version string) bool {
+	info, err := os.Stat(s.path(version))
+	return err == nil && info.IsDir()
+}
+
+// write writes the files of a bundle and returns the directory they are written to
+func (s *bundleStore) write(bundle *Bundle) (string, error) {
+	dir := s.path(bundle.Version)
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return "", err
+	}
+
+	for name, content := range bundle.Files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0600); err != nil {
+			os.RemoveAll(tmpDir)
+			return "", err
+		}
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// prune removes the oldest bundles, except the ones listed
+func (s *bundleStore) prune(keep ...string) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+
+	var versions []*semver.Version
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if version, err := semver.NewVersion(entry.Name()); err == nil && version.Original() == entry.Name() {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) <= maxStoredBundles {
+		return
+	}
+
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+	for _, version := range versions[maxStoredBundles:] {
+		if contains(keep, version.Original()) {
+			continue
+		}
+		os.RemoveAll(s.path(version.Original()))
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+// +build !windows
+
+package agent
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+	"github.com/DataDog/datadog-agent/pkg/logs/config"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
+)
+
+type mockRemoteClient struct {
+	update           func(map[string]state.ConfigCSPMBundle)
+	updateExceptions func(map[string]state.ConfigCSPMExceptions)
+}
+
+func (c *mockRemoteClient) RegisterCSPMBundlesUpdate(fn func(map[string]state.ConfigCSPMBundle)) {
+	c.update = fn
+	fn(map[string]state.ConfigCSPMBundle{})
+}
+
+func (c *mockRemoteClient) RegisterCSPMExceptionsUpdate(fn func(map[string]state.ConfigCSPMExceptions)) {
+	c.updateExceptions = fn
+	fn(map[string]state.ConfigCSPMExceptions{})
+}
+
+func (c *mockRemoteClient) Start() {}
+func (c *mockRemoteClient) Close() {}
+
+func signBundle(t *testing.T, privateKey ed25519.PrivateKey, bundle *Bundle) []byte {
+	t.Helper()
+	rawBundle, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	raw, err := json.Marshal(signedBundle{
+		Bundle:    rawBundle,
+		Signature: ed25519.Sign(privateKey, rawBundle),
+	})
+	require.NoError(t, err)
+	return raw
+}
+
+func TestParseSignedBundle(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	bundle := &Bundle{Version: "1.0.0", Files: map[string]string{"cis.yaml": "rules: []"}}
+	parsed, err := ParseSignedBundle(signBundle(t, privateKey, bundle), publicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle, parsed)
+
+	_, err = ParseSignedBundle(signBundle(t, otherKey, bundle), publicKey)
+	assert.EqualError(t, err, "invalid bundle signature")
+
+	_, err = ParseSignedBundle(signBundle(t, privateKey, &Bundle{Version: "latest"}), publicKey)
+	assert.Error(t, err)
+
+	_, err = ParseSignedBundle(signBundle(t, privateKey, &Bundle{Version: "1.0.0", Files: map[string]string{"../cis.yaml": ""}}), publicKey)
+	assert.Error(t, err)
+}
+
+func TestRemoteBundles(t *testing.T) {
+	opts := aggregator.DefaultAgentDemultiplexerOptions(nil)
+	opts.DontStartForwarders = true
+	aggregator.InitAndStartAgentDemultiplexer(opts, "foo")
+
+	e := enterTempEnv(t, true)
+	defer e.leave()
+
+	readFile := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(e.dir, name))
+		require.NoError(t, err)
+		return string(content)
+	}
+	ruleFiles := map[string]string{
+		"cis-kubernetes.yaml":   readFile("cis-kubernetes.yaml"),
+		"cis-kubernetes-1.rego": readFile("cis-kubernetes-1.rego"),
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	update := func(client *mockRemoteClient, bundles ...*Bundle) {
+		configs := make(map[string]state.ConfigCSPMBundle)
+		for _, bundle := range bundles {
+			configs[bundle.Version] = state.ConfigCSPMBundle{Config: signBundle(t, privateKey, bundle)}
+		}
+		client.update(configs)
+	}
+
+	scheduler := &mocks.Scheduler{}
+	scheduler.On("Run").Return(nil)
+	scheduler.On("Enter", mock.Anything).Return(nil)
+	scheduler.On("Cancel", mock.Anything).Return(nil)
+
+	kubeClient := &mocks.KubeClient{}
+	newAgent := func(pinnedVersion string) (*Agent, *mockRemoteClient) {
+		agent, err := New(
+			&mocks.Reporter{},
+			scheduler,
+			e.dir,
+			&config.Endpoints{},
+			checks.WithHostname("the-host"),
+			checks.WithHostRootMount(e.dir),
+			checks.WithKubernetesClient(kubeClient, "kube_system_uuid"),
+		)
+		require.NoError(t, err)
+		require.NoError(t, agent.Run())
+
+		client := &mockRemoteClient{}
+		agent.EnableRemoteConfiguration(client, RemoteConfig{
+			Bundles: &RemoteBundlesConfig{
+				PublicKey:     publicKey,
+				PinnedVersion: pinnedVersion,
+				StoreDir:      filepath.Join(e.dir, "bundles"),
+			},
+		})
+		return agent, client
+	}
+
+	v1 := &Bundle{Version: "1.0.0", Files: ruleFiles}
+	v2 := &Bundle{Version: "2.0.0", Files: ruleFiles}
+	broken := &Bundle{Version: "3.0.0", Files: map[string]string{"cis-kubernetes.yaml": "rules: {"}}
+
+	agent, client := newAgent("")
+	assert.Equal(t, "", agent.remoteBundles.active)
+
+	update(client, v1, v2)
+	assert.Equal(t, "2.0.0", agent.remoteBundles.active)
+	assert.Len(t, agent.checks, 1)
+	assert.FileExists(t, filepath.Join(e.dir, "bundles", "2.0.0", "cis-kubernetes.yaml"))
+
+	// a bundle that fails to load keeps the previous rules active
+	update(client, v1, v2, broken)
+	assert.Equal(t, "2.0.0", agent.remoteBundles.active)
+	assert.Len(t, agent.checks, 1)
+	assert.Error(t, agent.remoteBundles.lastError)
+	assert.Contains(t, agent.GetStatus()["remoteBundles"], "last_error")
+
+	// without any bundle, the rules of the config directory are activated back
+	update(client)
+	assert.Equal(t, "", agent.remoteBundles.active)
+	assert.Len(t, agent.checks, 1)
+	assert.NoError(t, agent.remoteBundles.lastError)
+
+	// a pinned version is found on disk when it isn't distributed anymore
+	pinned, client := newAgent("1.0.0")
+	update(client, v2)
+	assert.Error(t, pinned.remoteBundles.lastError)
+	update(client, v1, v2)
+	assert.Equal(t, "1.0.0", pinned.remoteBundles.active)
+
+	pinned, client = newAgent("2.0.0")
+	update(client)
+	assert.Equal(t, "2.0.0", pinned.remoteBundles.active)
+}
+
+func TestBundleStorePrune(t *testing.T) {
+	store := &bundleStore{dir: t.TempDir()}
+	for _, version := range []string{"1.0.0", "1.1.0", "1.2.0", "2.0.0", "2.1.0"} {
+		_, err := store.write(&Bundle{Version: version, Files: map[string]string{"cis.yaml": ""}})
+		require.NoError(t, err)
+	}
+
+	store.prune("1.0.0")
+	for _, version := range []string{"1.0.0", "1.2.0", "2.0.0", "2.1.0"} {
+		assert.True(t, store.has(version), version)
+	}
+	assert.False(t, store.has("1.1.0"))
+}
@@ -9,17 +9,20 @@ import (
 	"context"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/compliance/exceptions"
 	"github.com/DataDog/datadog-agent/pkg/security/common"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const (
-	containersCountMetricName = "datadog.security_agent.compliance.containers_running"
+	containersCountMetricName   = "datadog.security_agent.compliance.containers_running"
+	expiredExceptionsMetricName = "datadog.security_agent.compliance.exceptions_expired"
 )
 
 // telemetry reports environment information (e.g containers running) when the compliance component is running
 type telemetry struct {
 	containers *common.ContainersTelemetry
+	exceptions *exceptions.Registry
 }
 
 func newTelemetry() (*telemetry, error) {
@@ -46,6 +49,7 @@ func (t *telemetry) run(ctx context.Context) {
 			return
 		case <-metricsTicker.C:
 			t.reportContainers()
+			t.reportExpiredExceptions()
 		}
 	}
 }
@@ -53,3 +57,14 @@ func (t *telemetry) run(ctx context.Context) {
 func (t *telemetry) reportContainers() {
 	t.containers.ReportContainers(containersCountMetricName)
 }
+
+func (t *telemetry) reportExpiredExceptions() {
+	if t.exceptions == nil {
+		return
+	}
+
+	for _, e := range t.exceptions.Expired(time.Now()) {
+		t.containers.Sender.Gauge(expiredExceptionsMetricName, 1.0, "", []string{"rule_id:" + e.RuleID})
+	}
+	t.containers.Sender.Commit()
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
 	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/exceptions"
 	"github.com/DataDog/datadog-agent/pkg/compliance/rego"
 	"github.com/DataDog/datadog-agent/pkg/compliance/resources/audit"
 	"github.com/DataDog/datadog-agent/pkg/compliance/resources/file"
@@ -239,6 +240,15 @@ func WithRegoInput(regoInputPath string) BuilderOption {
 	}
 }
 
+// WithRegoInputs configures a builder to provide the given rego inputs, indexed by rule ID, instead of the inputs
+// built from the current environment
+func WithRegoInputs(regoInputs map[string]eval.RegoInputMap) BuilderOption {
+	return func(b *builder) error {
+		b.regoInputOverride = regoInputs
+		return nil
+	}
+}
+
 // WithRegoInputDumpPath configures a builder to dump the rego input to the provided file path
 func WithRegoInputDumpPath(regoInputDumpPath string) BuilderOption {
 	return func(b *builder) error {
@@ -255,6 +265,14 @@ func WithRegoEvalSkip(regoEvalSkip bool) BuilderOption {
 	}
 }
 
+// WithExceptions configures a builder to report the failures covered by an exception as accepted risks
+func WithExceptions(registry *exceptions.Registry) BuilderOption {
+	return func(b *builder) error {
+		b.exceptions = registry
+		return nil
+	}
+}
+
 // IsFramework matches a compliance suite by the name of the framework
 func IsFramework(framework string) SuiteMatcher {
 	return func(s *compliance.SuiteMeta) bool {
@@ -317,6 +335,8 @@ type builder struct {
 	regoInputDumpPath string
 	regoEvalSkip      bool
 
+	exceptions *exceptions.Registry
+
 	status *status
 }
 
@@ -489,6 +509,8 @@ func (b *builder) checkFromRegoRule(meta *compliance.SuiteMeta, rule *compliance
 		scope:           ruleScope,
 		checkable:       regoCheck,
 
+		exceptions: b.exceptions,
+
 		eventNotify: notify,
 	}, nil
 }
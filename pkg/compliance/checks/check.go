@@ -14,6 +14,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/compliance"
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/exceptions"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/version"
 
@@ -24,6 +25,7 @@ import (
 	_ "github.com/DataDog/datadog-agent/pkg/compliance/resources/docker"
 	_ "github.com/DataDog/datadog-agent/pkg/compliance/resources/file"
 	_ "github.com/DataDog/datadog-agent/pkg/compliance/resources/group"
+	_ "github.com/DataDog/datadog-agent/pkg/compliance/resources/helm"
 	_ "github.com/DataDog/datadog-agent/pkg/compliance/resources/kubeapiserver"
 	_ "github.com/DataDog/datadog-agent/pkg/compliance/resources/process"
 )
@@ -48,6 +50,8 @@ type complianceCheck struct {
 
 	checkable Checkable
 
+	exceptions *exceptions.Registry
+
 	eventNotify eventNotify
 }
 
@@ -156,6 +160,10 @@ func (c *complianceCheck) Run() error {
 		}
 		resourceQuadIDs[quadID] = true
 
+		if result == event.Failed {
+			data, result = c.applyException(quadID, data)
+		}
+
 		evaluator := report.Evaluator
 		if evaluator == "" {
 			evaluator = "legacy"
@@ -184,6 +192,29 @@ func (c *complianceCheck) Run() error {
 	return err
 }
 
+// applyException reports the failure of a resource as an accepted risk when it is covered by an exception. An expired
+// exception is added to the data of the event, but the resource is reported as failed.
+func (c *complianceCheck) applyException(quadID resourceQuadID, data event.Data) (event.Data, string) {
+	now := time.Now()
+	exception := c.exceptions.Match(quadID.AgentRuleID, quadID.ResourceType, quadID.ResourceID, now)
+	if exception == nil {
+		return data, event.Failed
+	}
+
+	exceptionData := make(event.Data, len(data)+3)
+	for k, v := range data {
+		exceptionData[k] = v
+	}
+	exceptionData["exception.justification"] = exception.Justification
+	exceptionData["exception.expires_at"] = exception.ExpiresAt().Format(time.RFC3339)
+
+	if exception.Expired(now) {
+		exceptionData["exception.expired"] = true
+		return exceptionData, event.Failed
+	}
+	return exceptionData, event.AcceptedRisk
+}
+
 // ExpireAtIntervalFactor represents the amount of intervals between a check and its expiration
 const ExpireAtIntervalFactor = 3
 
@@ -16,6 +16,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/compliance"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/exceptions"
 	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
@@ -33,6 +34,7 @@ func TestCheckRun(t *testing.T) {
 	tests := []struct {
 		name         string
 		checkReports []*compliance.Report
+		exceptions   []*exceptions.Exception
 		expectEvent  *event.Event
 		expectErr    error
 	}{
@@ -82,6 +84,63 @@ func TestCheckRun(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "failed check with exception",
+			checkReports: []*compliance.Report{
+				{
+					Passed: false,
+					Data: event.Data{
+						"file.permissions": 0644,
+					},
+				},
+			},
+			exceptions: []*exceptions.Exception{
+				{RuleID: ruleID, ResourceID: "resource-*", Justification: "accepted", Expires: "2999-12-31"},
+			},
+			expectEvent: &event.Event{
+				AgentRuleID:      ruleID,
+				AgentFrameworkID: frameworkID,
+				AgentVersion:     version.AgentVersion,
+				ResourceType:     resourceType,
+				ResourceID:       resourceID,
+				Result:           "accepted_risk",
+				Evaluator:        "legacy",
+				Data: event.Data{
+					"file.permissions":        0644,
+					"exception.justification": "accepted",
+					"exception.expires_at":    "3000-01-01T00:00:00Z",
+				},
+			},
+		},
+		{
+			name: "failed check with expired exception",
+			checkReports: []*compliance.Report{
+				{
+					Passed: false,
+					Data: event.Data{
+						"file.permissions": 0644,
+					},
+				},
+			},
+			exceptions: []*exceptions.Exception{
+				{RuleID: ruleID, Justification: "accepted", Expires: "2000-01-01T00:00:00Z"},
+			},
+			expectEvent: &event.Event{
+				AgentRuleID:      ruleID,
+				AgentFrameworkID: frameworkID,
+				AgentVersion:     version.AgentVersion,
+				ResourceType:     resourceType,
+				ResourceID:       resourceID,
+				Result:           "failed",
+				Evaluator:        "legacy",
+				Data: event.Data{
+					"file.permissions":        0644,
+					"exception.justification": "accepted",
+					"exception.expires_at":    "2000-01-01T00:00:00Z",
+					"exception.expired":       true,
+				},
+			},
+		},
 		{
 			name: "check error",
 			checkReports: []*compliance.Report{
@@ -125,6 +184,8 @@ func TestCheckRun(t *testing.T) {
 				scope:     resourceType,
 
 				suiteMeta: &compliance.SuiteMeta{Framework: frameworkID},
+
+				exceptions: exceptions.NewRegistry(test.exceptions),
 			}
 
 			env.On("Hostname").Return(resourceID)
@@ -12,6 +12,8 @@ const (
 	Passed = "passed"
 	// Failed is used to report unsuccessful result of a rule check (condition failed)
 	Failed = "failed"
+	// AcceptedRisk is used to report unsuccessful result of a rule check covered by an exception
+	AcceptedRisk = "accepted_risk"
 	// Error is used to report result of a rule check that resulted in an error (unable to evaluate condition)
 	Error = "error"
 )
@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package exceptions implements the exceptions marking the findings of compliance rules as accepted risks
+package exceptions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// dateLayout is the layout of the expiry dates that don't specify a time, expiring at the end of the day
+const dateLayout = "2006-01-02"
+
+// Exception accepts the risk of the findings of a rule, for all the resources or for the ones matching a pattern,
+// until an expiry date
+type Exception struct {
+	RuleID        string `json:"rule_id" mapstructure:"rule_id"`
+	ResourceType  string `json:"resource_type,omitempty" mapstructure:"resource_type"`
+	ResourceID    string `json:"resource_id,omitempty" mapstructure:"resource_id"`
+	Justification string `json:"justification" mapstructure:"justification"`
+	Expires       string `json:"expires" mapstructure:"expires"`
+
+	expiresAt time.Time
+}
+
+// ExpiresAt returns the time the exception expires at
+func (e *Exception) ExpiresAt() time.Time {
+	return e.expiresAt
+}
+
+// Validate checks the fields of the exception and parses its expiry date
+func (e *Exception) Validate() error {
+	if e.RuleID == "" {
+		return errors.New("missing rule id")
+	}
+	if e.Justification == "" {
+		return fmt.Errorf("%s: missing justification", e.RuleID)
+	}
+	if _, err := filepath.Match(e.ResourceID, ""); err != nil {
+		return fmt.Errorf("%s: invalid resource id pattern `%s`: %w", e.RuleID, e.ResourceID, err)
+	}
+
+	if t, err := time.Parse(time.RFC3339, e.Expires); err == nil {
+		e.expiresAt = t
+	} else if t, err := time.Parse(dateLayout, e.Expires); err == nil {
+		e.expiresAt = t.AddDate(0, 0, 1)
+	} else {
+		return fmt.Errorf("%s: invalid expiry date `%s`, expected YYYY-MM-DD or RFC3339", e.RuleID, e.Expires)
+	}
+	return nil
+}
+
+// Matches returns true if the exception applies to a resource evaluated by a rule
+func (e *Exception) Matches(ruleID, resourceType, resourceID string) bool {
+	if e.RuleID != ruleID {
+		return false
+	}
+	if e.ResourceType != "" && e.ResourceType != resourceType {
+		return false
+	}
+	if e.ResourceID == "" {
+		return true
+	}
+	matched, _ := filepath.Match(e.ResourceID, resourceID)
+	return matched
+}
+
+// Expired returns true if the exception expired at the given time
+func (e *Exception) Expired(now time.Time) bool {
+	return !now.Before(e.expiresAt)
+}
+
+// Parse parses a list of exceptions in JSON, as delivered by Remote Configuration
+func Parse(data []byte) ([]*Exception, error) {
+	var payload struct {
+		Exceptions []*Exception `json:"exceptions"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	return payload.Exceptions, nil
+}
+
+// Registry holds the exceptions defined in the configuration and the ones delivered through Remote Configuration
+type Registry struct {
+	sync.RWMutex
+
+	local  []*Exception
+	remote []*Exception
+	// reported holds the expired exceptions a warning was logged for
+	reported map[*Exception]struct{}
+}
+
+// NewRegistry returns a registry of exceptions. The invalid exceptions are ignored.
+func NewRegistry(local []*Exception) *Registry {
+	return &Registry{
+		local:    validate(local),
+		reported: make(map[*Exception]struct{}),
+	}
+}
+
+// SetRemote replaces the exceptions delivered through Remote Configuration
+func (r *Registry) SetRemote(remote []*Exception) {
+	remote = validate(remote)
+
+	r.Lock()
+	defer r.Unlock()
+	r.remote = remote
+	r.reported = make(map[*Exception]struct{})
+}
+
+// Match returns the exception applying to a resource evaluated by a rule, or nil. An expired exception is returned
+// when no other exception applies, so the findings can be reported as such.
+func (r *Registry) Match(ruleID, resourceType, resourceID string, now time.Time) *Exception {
+	if r == nil {
+		return nil
+	}
+
+	r.RLock()
+	defer r.RUnlock()
+
+	var expired *Exception
+	for _, exceptions := range [][]*Exception{r.local, r.remote} {
+		for _, e := range exceptions {
+			if !e.Matches(ruleID, resourceType, resourceID) {
+				continue
+			}
+			if !e.Expired(now) {
+				return e
+			}
+			expired = e
+		}
+	}
+	return expired
+}
+
+// Expired returns the exceptions that expired at the given time, and logs a warning the first time an exception is
+// found expired
+func (r *Registry) Expired(now time.Time) []*Exception {
+	r.Lock()
+	defer r.Unlock()
+
+	var expired []*Exception
+	for _, exceptions := range [][]*Exception{r.local, r.remote} {
+		for _, e := range exceptions {
+			if !e.Expired(now) {
+				continue
+			}
+			expired = append(expired, e)
+			if _, ok := r.reported[e]; !ok {
+				r.reported[e] = struct{}{}
+				log.Warnf("%s: compliance exception expired on %s, findings are reported as failed again: %s", e.RuleID, e.expiresAt.Format(time.RFC3339), e.Justification)
+			}
+		}
+	}
+	return expired
+}
+
+// GetStatus returns the number of active exceptions and the list of the expired ones
+func (r *Registry) GetStatus() map[string]interface{} {
+	now := time.Now()
+	expired := r.Expired(now)
+
+	r.RLock()
+	defer r.RUnlock()
+
+	expiredStatus := make([]map[string]interface{}, 0, len(expired))
+	for _, e := range expired {
+		expiredStatus = append(expiredStatus, map[string]interface{}{
+			"rule_id":       e.RuleID,
+			"resource_type": e.ResourceType,
+			"resource_id":   e.ResourceID,
+			"justification": e.Justification,
+			"expired_at":    e.expiresAt.Format(time.RFC3339),
+		})
+	}
+	return map[string]interface{}{
+		"active":  len(r.local) + len(r.remote) - len(expired),
+		"expired": expiredStatus,
+	}
+}
+
+func validate(exceptions []*Exception) []*Exception {
+	valid := make([]*Exception, 0, len(exceptions))
+	for _, e := range exceptions {
+		if e == nil {
+			continue
+		}
+		if err := e.Validate(); err != nil {
+			log.Errorf("Ignoring invalid compliance exception: %v", err)
+			continue
+		}
+		valid = append(valid, e)
+	}
+	return valid
+}
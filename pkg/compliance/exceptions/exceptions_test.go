@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package exceptions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	e := &Exception{RuleID: "cis-docker-1", Justification: "legacy host", Expires: "2023-03-31"}
+	require.NoError(t, e.Validate())
+	assert.Equal(t, time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), e.ExpiresAt())
+
+	e = &Exception{RuleID: "cis-docker-1", Justification: "legacy host", Expires: "2023-03-31T12:00:00Z"}
+	require.NoError(t, e.Validate())
+	assert.Equal(t, time.Date(2023, 3, 31, 12, 0, 0, 0, time.UTC), e.ExpiresAt())
+
+	assert.Error(t, (&Exception{Justification: "legacy host", Expires: "2023-03-31"}).Validate())
+	assert.Error(t, (&Exception{RuleID: "cis-docker-1", Expires: "2023-03-31"}).Validate())
+	assert.Error(t, (&Exception{RuleID: "cis-docker-1", Justification: "legacy host", Expires: "next month"}).Validate())
+	assert.Error(t, (&Exception{RuleID: "cis-docker-1", Justification: "legacy host", Expires: "2023-03-31", ResourceID: "["}).Validate())
+}
+
+func TestRegistry(t *testing.T) {
+	now := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	registry := NewRegistry([]*Exception{
+		{RuleID: "cis-docker-1", ResourceType: "docker_container", ResourceID: "web-*", Justification: "vendor image", Expires: "2023-06-30"},
+		{RuleID: "cis-docker-2", Justification: "migration", Expires: "2023-01-31"},
+		{RuleID: "cis-docker-3", Justification: "invalid", Expires: "tomorrow"},
+	})
+
+	e := registry.Match("cis-docker-1", "docker_container", "web-1", now)
+	require.NotNil(t, e)
+	assert.False(t, e.Expired(now))
+	assert.Nil(t, registry.Match("cis-docker-1", "docker_container", "db-1", now))
+	assert.Nil(t, registry.Match("cis-docker-1", "docker_image", "web-1", now))
+	assert.Nil(t, registry.Match("cis-docker-3", "docker_container", "web-1", now))
+
+	e = registry.Match("cis-docker-2", "docker_daemon", "host", now)
+	require.NotNil(t, e)
+	assert.True(t, e.Expired(now))
+
+	// an active remote exception takes precedence over an expired one
+	remote, err := Parse([]byte(`{"exceptions": [{"rule_id": "cis-docker-2", "justification": "extended", "expires": "2023-12-31"}]}`))
+	require.NoError(t, err)
+	registry.SetRemote(remote)
+	e = registry.Match("cis-docker-2", "docker_daemon", "host", now)
+	require.NotNil(t, e)
+	assert.Equal(t, "extended", e.Justification)
+
+	expired := registry.Expired(now)
+	require.Len(t, expired, 1)
+	assert.Equal(t, "cis-docker-2", expired[0].RuleID)
+	assert.Equal(t, "migration", expired[0].Justification)
+}
@@ -6,7 +6,13 @@
 package rego
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strconv"
 
 	_ "embed"
@@ -14,6 +20,11 @@ import (
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/rego"
 	"github.com/open-policy-agent/opa/types"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	fileutils "github.com/DataDog/datadog-agent/pkg/compliance/utils/file"
+	"github.com/DataDog/datadog-agent/pkg/compliance/utils/packages"
+	"github.com/DataDog/datadog-agent/pkg/compliance/utils/systemd"
 )
 
 //go:embed rego_helpers/datadog.rego
@@ -42,3 +53,163 @@ var octalLiteralFunc = rego.Function1(
 		return ast.IntNumberTerm(int(value)), err
 	},
 )
+
+// hostBuiltins returns the builtins giving access to the host, so rules don't have to rely on commands. The paths are
+// relative to the host root of the environment.
+func hostBuiltins(e env.Env) []func(*rego.Rego) {
+	return []func(*rego.Rego){
+		packageVersionFunc(e),
+		fileGlobFunc(e),
+		fileSHA256Func(e),
+		parseFileFunc(e),
+		systemdUnitPropertyFunc(e),
+	}
+}
+
+// packageVersionFunc returns the version of an installed package, and is undefined if it is not installed
+func packageVersionFunc(e env.Env) func(*rego.Rego) {
+	return rego.Function1(
+		&rego.Function{
+			Name: "package_version",
+			Decl: types.NewFunction(types.Args(types.S), types.S),
+		},
+		func(_ rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+			name, ok := a.Value.(ast.String)
+			if !ok {
+				return nil, errors.New("expected a package name")
+			}
+
+			version, installed, err := packages.GetVersion(e, string(name))
+			if err != nil || !installed {
+				return nil, err
+			}
+			return ast.StringTerm(version), nil
+		},
+	)
+}
+
+// fileGlobFunc returns the paths of the files matching a pattern
+func fileGlobFunc(e env.Env) func(*rego.Rego) {
+	return rego.Function1(
+		&rego.Function{
+			Name: "file_glob",
+			Decl: types.NewFunction(types.Args(types.S), types.NewArray(nil, types.S)),
+		},
+		func(_ rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+			pattern, ok := a.Value.(ast.String)
+			if !ok {
+				return nil, errors.New("expected a glob pattern")
+			}
+
+			matches, err := filepath.Glob(e.NormalizeToHostRoot(string(pattern)))
+			if err != nil {
+				return nil, err
+			}
+
+			paths := make([]*ast.Term, 0, len(matches))
+			for _, match := range matches {
+				paths = append(paths, ast.StringTerm(e.RelativeToHostRoot(match)))
+			}
+			return ast.ArrayTerm(paths...), nil
+		},
+	)
+}
+
+// fileSHA256Func returns the hex encoded SHA-256 hash of the content of a file, and is undefined if the file doesn't
+// exist
+func fileSHA256Func(e env.Env) func(*rego.Rego) {
+	return rego.Function1(
+		&rego.Function{
+			Name: "file_sha256",
+			Decl: types.NewFunction(types.Args(types.S), types.S),
+		},
+		func(_ rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+			path, ok := a.Value.(ast.String)
+			if !ok {
+				return nil, errors.New("expected a file path")
+			}
+
+			f, err := os.Open(e.NormalizeToHostRoot(string(path)))
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil, nil
+				}
+				return nil, err
+			}
+			defer f.Close()
+
+			h := sha256.New()
+			if _, err := io.Copy(h, f); err != nil {
+				return nil, err
+			}
+			return ast.StringTerm(hex.EncodeToString(h.Sum(nil))), nil
+		},
+	)
+}
+
+// parseFileFunc returns the content of a file parsed with the given parser (json, yaml, ini or raw), and is undefined
+// if the file doesn't exist
+func parseFileFunc(e env.Env) func(*rego.Rego) {
+	return rego.Function2(
+		&rego.Function{
+			Name: "parse_file",
+			Decl: types.NewFunction(types.Args(types.S, types.S), types.A),
+		},
+		func(_ rego.BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+			path, ok := a.Value.(ast.String)
+			if !ok {
+				return nil, errors.New("expected a file path")
+			}
+			parser, ok := b.Value.(ast.String)
+			if !ok {
+				return nil, errors.New("expected a parser")
+			}
+
+			data, err := os.ReadFile(e.NormalizeToHostRoot(string(path)))
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil, nil
+				}
+				return nil, err
+			}
+
+			content, err := fileutils.ParseContent(data, string(parser))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+
+			value, err := ast.InterfaceToValue(content)
+			if err != nil {
+				return nil, err
+			}
+			return ast.NewTerm(value), nil
+		},
+	)
+}
+
+// systemdUnitPropertyFunc returns the value of a property of a systemd unit, and is undefined if the unit or the
+// property is not defined
+func systemdUnitPropertyFunc(e env.Env) func(*rego.Rego) {
+	return rego.Function2(
+		&rego.Function{
+			Name: "systemd_unit_property",
+			Decl: types.NewFunction(types.Args(types.S, types.S), types.S),
+		},
+		func(_ rego.BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+			unit, ok := a.Value.(ast.String)
+			if !ok {
+				return nil, errors.New("expected a unit name")
+			}
+			property, ok := b.Value.(ast.String)
+			if !ok {
+				return nil, errors.New("expected a property name")
+			}
+
+			value, found, err := systemd.GetUnitProperty(e, string(unit), string(property))
+			if err != nil || !found {
+				return nil, err
+			}
+			return ast.StringTerm(value), nil
+		},
+	)
+}
@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+// +build !windows
+
+package rego
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+)
+
+func newHostEnv(t *testing.T, files map[string]string) *mocks.Env {
+	hostRoot := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(hostRoot, path)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	env := &mocks.Env{}
+	env.On("NormalizeToHostRoot", mock.Anything).Return(func(path string) string {
+		return filepath.Join(hostRoot, path)
+	}).Maybe()
+	env.On("RelativeToHostRoot", mock.Anything).Return(func(path string) string {
+		return strings.TrimPrefix(path, hostRoot)
+	}).Maybe()
+	return env
+}
+
+func evalHostBuiltin(t *testing.T, env *mocks.Env, query string) interface{} {
+	r := rego.New(append(hostBuiltins(env), rego.Query(query))...)
+	results, err := r.Eval(context.Background())
+	assert.NoError(t, err)
+	if len(results) == 0 {
+		return nil
+	}
+	return results[0].Expressions[0].Value
+}
+
+func TestHostBuiltins(t *testing.T) {
+	env := newHostEnv(t, map[string]string{
+		"/var/lib/dpkg/status":                                       "Package: openssh-server\nStatus: install ok installed\nVersion: 1:8.9p1-3\n\nPackage: telnetd\nStatus: deinstall ok config-files\nVersion: 0.17-44\n",
+		"/etc/app/app.ini":                                           "debug = false\n[server]\nport = 8080\n",
+		"/etc/app/app.yaml":                                          "server:\n  port: 8080\n",
+		"/etc/app/app.json":                                          `{"server": {"port": 8080}}`,
+		"/etc/systemd/system/kubelet.service":                        "[Service]\nUser=root\nExecStart=/usr/bin/kubelet\n",
+		"/etc/systemd/system/kubelet.service.d/10-override.conf":     "[Service]\nExecStart=\nExecStart=/usr/bin/kubelet \\\n  --anonymous-auth=false\n",
+		"/usr/lib/systemd/system/kubelet.service.d/05-env.conf":      "[Service]\nEnvironment=FOO=bar\n",
+		"/usr/lib/systemd/system/kubelet.service.d/10-override.conf": "[Service]\nUser=nobody\n",
+	})
+
+	assert.Equal(t, "1:8.9p1-3", evalHostBuiltin(t, env, `package_version("openssh-server")`))
+	assert.Nil(t, evalHostBuiltin(t, env, `package_version("telnetd")`))
+	assert.Nil(t, evalHostBuiltin(t, env, `package_version("nginx")`))
+
+	assert.Equal(t, []interface{}{"/etc/app/app.ini", "/etc/app/app.json", "/etc/app/app.yaml"}, evalHostBuiltin(t, env, `file_glob("/etc/app/*")`))
+	assert.Equal(t, "a7c65ac4504f4b15c695cc9cad633374cf845136cbfab17af03eac1a48e37bbe", evalHostBuiltin(t, env, `file_sha256("/etc/app/app.yaml")`))
+	assert.Nil(t, evalHostBuiltin(t, env, `file_sha256("/etc/app/missing")`))
+
+	assert.Equal(t, "8080", evalHostBuiltin(t, env, `parse_file("/etc/app/app.ini", "ini").server.port`))
+	assert.Equal(t, "false", evalHostBuiltin(t, env, `parse_file("/etc/app/app.ini", "ini").DEFAULT.debug`))
+	assert.Equal(t, true, evalHostBuiltin(t, env, `parse_file("/etc/app/app.yaml", "yaml").server.port == 8080`))
+	assert.Equal(t, true, evalHostBuiltin(t, env, `parse_file("/etc/app/app.json", "json").server.port == 8080`))
+
+	assert.Equal(t, "root", evalHostBuiltin(t, env, `systemd_unit_property("kubelet.service", "User")`))
+	assert.Equal(t, "/usr/bin/kubelet --anonymous-auth=false", evalHostBuiltin(t, env, `systemd_unit_property("kubelet.service", "ExecStart")`))
+	assert.Equal(t, "FOO=bar", evalHostBuiltin(t, env, `systemd_unit_property("kubelet.service", "Environment")`))
+	assert.Nil(t, evalHostBuiltin(t, env, `systemd_unit_property("kubelet.service", "Group")`))
+	assert.Nil(t, evalHostBuiltin(t, env, `systemd_unit_property("docker.service", "User")`))
+}
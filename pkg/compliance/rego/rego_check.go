@@ -287,7 +287,12 @@ func (r *regoCheck) buildNormalInput(env env.Env) (eval.RegoInputMap, error) {
 			ctx, cancel := context.WithTimeout(context.Background(), regoEvalTimeout)
 			defer cancel()
 
-			regoMod := rego.New(append(regoInput.regoModuleArgs, rego.Input(input))...)
+			args := make([]func(*rego.Rego), len(regoInput.regoModuleArgs), len(regoInput.regoModuleArgs)+6)
+			copy(args, regoInput.regoModuleArgs)
+			args = append(args, rego.Input(input))
+			args = append(args, hostBuiltins(env)...)
+
+			regoMod := rego.New(args...)
 			results, err := regoMod.Eval(ctx)
 			if err != nil {
 				return nil, err
@@ -526,9 +531,10 @@ func (r *regoCheck) Check(env env.Env) compliance.Reports {
 	ctx, cancel := context.WithTimeout(context.Background(), regoEvalTimeout)
 	defer cancel()
 
-	args := make([]func(*rego.Rego), len(r.regoModuleArgs), len(r.regoModuleArgs)+2)
+	args := make([]func(*rego.Rego), len(r.regoModuleArgs), len(r.regoModuleArgs)+6)
 	copy(args, r.regoModuleArgs)
 	args = append(args, rego.Input(input))
+	args = append(args, hostBuiltins(env)...)
 
 	regoMod := rego.New(args...)
 	results, err := regoMod.Eval(ctx)
@@ -696,7 +702,7 @@ func resourceKindToResolverAndFields(env env.Env, kind compliance.ResourceKind)
 		if env.DockerClient() == nil {
 			return nil, nil, log.Error("docker client not initialized")
 		}
-	case compliance.KindKubernetes:
+	case compliance.KindKubernetes, compliance.KindHelm:
 		if env.KubeClient() == nil {
 			return nil, nil, log.Error("kube client not initialized")
 		}
@@ -30,6 +30,8 @@ const (
 	KindAudit = ResourceKind("audit")
 	// KindKubernetes is used for a KubernetesResource
 	KindKubernetes = ResourceKind("kubernetes")
+	// KindHelm is used for a HelmResource
+	KindHelm = ResourceKind("helm")
 	// KindConstants is used for Constants check
 	KindConstants = ResourceKind("constants")
 	// KindCustom is used for a Custom check
@@ -45,6 +47,7 @@ type ResourceCommon struct {
 	Audit         *Audit              `yaml:"audit,omitempty"`
 	Docker        *DockerResource     `yaml:"docker,omitempty"`
 	KubeApiserver *KubernetesResource `yaml:"kubeApiserver,omitempty"`
+	Helm          *HelmResource       `yaml:"helm,omitempty"`
 	Constants     *ConstantsResource  `yaml:"constants,omitempty"`
 	Custom        *Custom             `yaml:"custom,omitempty"`
 }
@@ -86,6 +89,8 @@ func (r *ResourceCommon) Kind() ResourceKind {
 		return KindDocker
 	case r.KubeApiserver != nil:
 		return KindKubernetes
+	case r.Helm != nil:
+		return KindHelm
 	case r.Constants != nil:
 		return KindConstants
 	case r.Custom != nil:
@@ -172,6 +177,36 @@ type KubernetesAPIRequest struct {
 	ResourceName string `yaml:"resourceName,omitempty"`
 }
 
+// Fields & functions available for HelmResource
+const (
+	HelmReleaseFieldName         = "helm.release.name"
+	HelmReleaseFieldNamespace    = "helm.release.namespace"
+	HelmReleaseFieldRevision     = "helm.release.revision"
+	HelmReleaseFieldStatus       = "helm.release.status"
+	HelmReleaseFieldChart        = "helm.release.chart"
+	HelmReleaseFieldChartVersion = "helm.release.chartVersion"
+	HelmReleaseFieldAppVersion   = "helm.release.appVersion"
+
+	HelmReleaseFuncJQ = "helm.release.jq"
+)
+
+// HelmResource describes the deployed releases of Helm charts, as stored by Helm in the cluster
+type HelmResource struct {
+	Namespace string `yaml:"namespace,omitempty"`
+	// Name of the release. Defaults to all the releases.
+	ReleaseName string `yaml:"releaseName,omitempty"`
+	// Name of the chart of the release. Defaults to all the charts.
+	Chart string `yaml:"chart,omitempty"`
+	// Storage driver used by Helm to store the releases, either secret or configmap.
+	// Defaults to secret.
+	Storage string `yaml:"storage,omitempty"`
+}
+
+// String returns human-friendly information string about the HelmResource
+func (hr *HelmResource) String() string {
+	return fmt.Sprintf("Helm release: %s - Chart: %s - Namespace: %s - Storage: %s", hr.ReleaseName, hr.Chart, hr.Namespace, hr.Storage)
+}
+
 // Fields & functions available for Group
 const (
 	GroupFieldName  = "group.name"
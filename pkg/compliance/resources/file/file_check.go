@@ -7,22 +7,17 @@ package file
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/compliance"
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
 	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
 	"github.com/DataDog/datadog-agent/pkg/compliance/resources"
 	fileutils "github.com/DataDog/datadog-agent/pkg/compliance/utils/file"
-	"github.com/DataDog/datadog-agent/pkg/util/jsonquery"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
-	yamlv2 "gopkg.in/yaml.v2"
-	"gopkg.in/yaml.v3"
 )
 
 var reportedFields = []string{
@@ -45,7 +40,7 @@ func resolve(_ context.Context, e env.Env, ruleID string, res compliance.Resourc
 
 	log.Debugf("%s: running file check for %q", ruleID, file.Path)
 
-	fileContentParser, err := validateParserKind(file.Parser)
+	fileContentParser, err := fileutils.ValidateParserKind(file.Parser)
 	if err != nil {
 		return nil, err
 	}
@@ -165,53 +160,6 @@ func fileRegexp(path string) eval.Function {
 	return fileQuery(path, fileutils.RegexpGetter)
 }
 
-type contentParser func([]byte) (interface{}, error)
-
-var contentParsers = map[string]contentParser{
-	"json": parseJSONContent,
-	"yaml": parseYAMLContent,
-	"raw":  parseRawContent,
-}
-
-func parseRawContent(data []byte) (interface{}, error) {
-	return string(data), nil
-}
-
-func parseJSONContent(data []byte) (interface{}, error) {
-	var content interface{}
-
-	if err := json.Unmarshal(data, &content); err != nil {
-		return nil, err
-	}
-
-	return content, nil
-}
-
-func parseYAMLContent(data []byte) (interface{}, error) {
-	var content interface{}
-
-	if err := yaml.Unmarshal(data, &content); err != nil {
-		if err := yamlv2.Unmarshal(data, &content); err != nil {
-			return nil, err
-		}
-	}
-
-	content = jsonquery.NormalizeYAMLForGoJQ(content)
-	return content, nil
-}
-
-func validateParserKind(parser string) (string, error) {
-	if parser == "" {
-		return "", nil
-	}
-
-	normParser := strings.ToLower(parser)
-	if _, ok := contentParsers[normParser]; !ok {
-		return "", fmt.Errorf("undefined file content parser %s", parser)
-	}
-	return normParser, nil
-}
-
 // readContent unmarshal file
 func readContent(filePath, parser string) (interface{}, error) {
 	if parser == "" {
@@ -229,12 +177,7 @@ func readContent(filePath, parser string) (interface{}, error) {
 		return "", err
 	}
 
-	parserFunc := contentParsers[parser]
-	if parserFunc != nil {
-		return parserFunc(data)
-	}
-
-	return string(data), nil
+	return fileutils.ParseContent(data, parser)
 }
 
 // QueryValueFromFile retrieves a value from a file with the provided getter func
@@ -0,0 +1,286 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
+	"github.com/DataDog/datadog-agent/pkg/compliance/resources"
+	"github.com/DataDog/datadog-agent/pkg/util/jsonquery"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	storageSecret    = "secret"
+	storageConfigMap = "configmap"
+
+	resourceType = "helm_release"
+)
+
+var reportedFields = []string{
+	compliance.HelmReleaseFieldName,
+	compliance.HelmReleaseFieldNamespace,
+	compliance.HelmReleaseFieldRevision,
+	compliance.HelmReleaseFieldChart,
+	compliance.HelmReleaseFieldChartVersion,
+}
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// release holds the fields of a Helm release used by the compliance rules
+type release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+		Values map[string]interface{} `json:"values"`
+	} `json:"chart"`
+	Config   map[string]interface{} `json:"config"`
+	Manifest string                 `json:"manifest"`
+}
+
+func resolve(ctx context.Context, e env.Env, ruleID string, res compliance.ResourceCommon, rego bool) (resources.Resolved, error) {
+	if res.Helm == nil {
+		return nil, fmt.Errorf("expecting Helm resource in Helm check")
+	}
+
+	helmResource := res.Helm
+
+	var resourceSchema schema.GroupVersionResource
+	switch helmResource.Storage {
+	case storageSecret, "":
+		resourceSchema = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	case storageConfigMap:
+		resourceSchema = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	default:
+		return nil, fmt.Errorf("cannot run Helm check, unsupported storage '%s'", helmResource.Storage)
+	}
+	resourceDef := e.KubeClient().Resource(resourceSchema)
+
+	var resourceAPI dynamic.ResourceInterface
+	if len(helmResource.Namespace) > 0 {
+		resourceAPI = resourceDef.Namespace(helmResource.Namespace)
+	} else {
+		resourceAPI = resourceDef
+	}
+
+	// Helm labels the objects storing the releases, only the deployed revision of each release is of interest
+	labelSelector := "owner=helm,status=deployed"
+	if len(helmResource.ReleaseName) > 0 {
+		labelSelector += ",name=" + helmResource.ReleaseName
+	}
+
+	list, err := resourceAPI.List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list Helm releases:'%v', ns:'%s' name:'%s', err: %v", resourceSchema, helmResource.Namespace, helmResource.ReleaseName, err)
+	}
+
+	var instances []resources.ResolvedInstance
+	for _, object := range list.Items {
+		rel, err := releaseFromObject(object, resourceSchema.Resource == "secrets")
+		if err != nil {
+			log.Warnf("%s: unable to decode Helm release from %s/%s: %v", ruleID, object.GetNamespace(), object.GetName(), err)
+			continue
+		}
+
+		if len(helmResource.Chart) > 0 && rel.Chart.Metadata.Name != helmResource.Chart {
+			continue
+		}
+
+		instance, err := newReleaseInstance(rel)
+		if err != nil {
+			log.Warnf("%s: unable to parse the manifest of Helm release %s/%s: %v", ruleID, rel.Namespace, rel.Name, err)
+			continue
+		}
+		instances = append(instances, instance)
+	}
+
+	log.Debugf("%s: Got %d Helm releases", ruleID, len(instances))
+
+	return resources.NewResolvedInstances(instances), nil
+}
+
+func newReleaseInstance(rel *release) (resources.ResolvedInstance, error) {
+	manifest, err := parseManifest(rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	values := coalesceValues(rel.Chart.Values, rel.Config)
+	userValues := rel.Config
+	if userValues == nil {
+		userValues = map[string]interface{}{}
+	}
+
+	chart := map[string]interface{}{
+		"name":       rel.Chart.Metadata.Name,
+		"version":    rel.Chart.Metadata.Version,
+		"appVersion": rel.Chart.Metadata.AppVersion,
+	}
+
+	return resources.NewResolvedInstance(
+		eval.NewInstance(
+			eval.VarMap{
+				compliance.HelmReleaseFieldName:         rel.Name,
+				compliance.HelmReleaseFieldNamespace:    rel.Namespace,
+				compliance.HelmReleaseFieldRevision:     rel.Version,
+				compliance.HelmReleaseFieldStatus:       rel.Info.Status,
+				compliance.HelmReleaseFieldChart:        rel.Chart.Metadata.Name,
+				compliance.HelmReleaseFieldChartVersion: rel.Chart.Metadata.Version,
+				compliance.HelmReleaseFieldAppVersion:   rel.Chart.Metadata.AppVersion,
+			},
+			eval.FunctionMap{
+				compliance.HelmReleaseFuncJQ: helmReleaseJQ(values),
+			},
+			eval.RegoInputMap{
+				"name":       rel.Name,
+				"namespace":  rel.Namespace,
+				"revision":   rel.Version,
+				"status":     rel.Info.Status,
+				"chart":      chart,
+				"values":     values,
+				"userValues": userValues,
+				"manifest":   manifest,
+			},
+		),
+		rel.Namespace+"/"+rel.Name,
+		resourceType,
+	), nil
+}
+
+// releaseFromObject decodes the release stored by Helm in a secret or a configmap. Helm stores the release as base64
+// encoded and gzipped JSON, which the API server base64 encodes once more in the case of a secret.
+func releaseFromObject(object unstructured.Unstructured, secret bool) (*release, error) {
+	data, found, err := unstructured.NestedString(object.Object, "data", "release")
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.New("no release data")
+	}
+
+	if secret {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, err
+		}
+		data = string(decoded)
+	}
+
+	return decodeRelease(data)
+}
+
+func decodeRelease(data string) (*release, error) {
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(b, gzipMagic) {
+		r, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		if b, err = io.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+
+	var rel release
+	if err := json.Unmarshal(b, &rel); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
+
+// parseManifest returns the objects rendered by the chart of a release
+func parseManifest(manifest string) ([]interface{}, error) {
+	objects := []interface{}{}
+	decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	for {
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			if err == io.EOF {
+				return objects, nil
+			}
+			return nil, err
+		}
+		// documents only made of comments are decoded as empty objects
+		if len(object) > 0 {
+			objects = append(objects, object)
+		}
+	}
+}
+
+// coalesceValues returns the values used to render the chart of a release, which are the default values of the chart
+// overridden by the values supplied by the user. Like Helm does, a null user value removes the default value.
+func coalesceValues(defaults, overrides map[string]interface{}) map[string]interface{} {
+	values := make(map[string]interface{}, len(defaults)+len(overrides))
+	for k, v := range defaults {
+		values[k] = v
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(values, k)
+			continue
+		}
+		override, isMap := v.(map[string]interface{})
+		if def, ok := values[k].(map[string]interface{}); ok && isMap {
+			values[k] = coalesceValues(def, override)
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
+
+func helmReleaseJQ(values map[string]interface{}) eval.Function {
+	return func(_ eval.Instance, args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf(`invalid number of arguments, expecting 1 got %d`, len(args))
+		}
+		query, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New(`expecting string value for query argument"`)
+		}
+
+		v, _, err := jsonquery.RunSingleOutput(query, values)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+}
+
+func init() {
+	resources.RegisterHandler(compliance.KindHelm, resolve, reportedFields)
+}
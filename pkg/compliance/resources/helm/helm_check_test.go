@@ -0,0 +1,341 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+	"github.com/DataDog/datadog-agent/pkg/compliance/rego"
+	resource_test "github.com/DataDog/datadog-agent/pkg/compliance/resources/tests"
+
+	assert "github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+	kscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+type helmFixture struct {
+	name         string
+	module       string
+	resource     compliance.RegoInput
+	objects      []runtime.Object
+	expectReport *compliance.Report
+}
+
+type fakeKubeClient struct {
+	*fake.FakeDynamicClient
+}
+
+func (f *fakeKubeClient) ClusterID() (string, error) {
+	return "fake-k8s-cluster", nil
+}
+
+const testManifest = `---
+# Source: mychart/templates/ingress.yaml
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: %s
+spec:
+  tls: %s
+---
+# Source: mychart/templates/empty.yaml
+`
+
+func encodeRelease(t *testing.T, rel map[string]interface{}) string {
+	b, err := json.Marshal(rel)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err = w.Write(b)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func newRelease(t *testing.T, name string, revision int, status string, config map[string]interface{}, tls string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      name,
+		"namespace": "testns",
+		"version":   revision,
+		"info": map[string]interface{}{
+			"status": status,
+		},
+		"chart": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":       "mychart",
+				"version":    "1.2.3",
+				"appVersion": "4.5.6",
+			},
+			"values": map[string]interface{}{
+				"auth": map[string]interface{}{
+					"username": "admin",
+					"password": "changeme",
+				},
+				"ingress": map[string]interface{}{
+					"enabled": true,
+				},
+			},
+		},
+		"config":   config,
+		"manifest": fmt.Sprintf(testManifest, name, tls),
+	}
+}
+
+func newReleaseSecret(t *testing.T, rel map[string]interface{}) *corev1.Secret {
+	name := rel["name"].(string)
+	revision := rel["version"].(int)
+	status := rel["info"].(map[string]interface{})["status"].(string)
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("sh.helm.release.v1.%s.v%d", name, revision),
+			Namespace: "testns",
+			Labels: map[string]string{
+				"name":    name,
+				"owner":   "helm",
+				"status":  status,
+				"version": fmt.Sprint(revision),
+			},
+		},
+		Type: "helm.sh/release.v1",
+		Data: map[string][]byte{
+			"release": []byte(encodeRelease(t, rel)),
+		},
+	}
+}
+
+func newReleaseConfigMap(t *testing.T, rel map[string]interface{}) *corev1.ConfigMap {
+	secret := newReleaseSecret(t, rel)
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		ObjectMeta: secret.ObjectMeta,
+		Data: map[string]string{
+			"release": string(secret.Data["release"]),
+		},
+	}
+}
+
+func (f *helmFixture) run(t *testing.T) {
+	t.Helper()
+
+	assert := assert.New(t)
+
+	env := &mocks.Env{}
+	env.On("MaxEventsPerRun").Return(30).Maybe()
+	env.On("ProvidedInput", "rule-id").Return(nil).Maybe()
+	env.On("DumpInputPath").Return("").Maybe()
+	env.On("ShouldSkipRegoEval").Return(false).Maybe()
+	env.On("Hostname").Return("test-host").Maybe()
+
+	defer env.AssertExpectations(t)
+
+	kubeClient := &fakeKubeClient{
+		FakeDynamicClient: fake.NewSimpleDynamicClient(kscheme.Scheme, f.objects...),
+	}
+	env.On("KubeClient").Return(kubeClient)
+
+	regoRule := resource_test.NewTestRule(f.resource, "helm_release", f.module)
+
+	helmCheck := rego.NewCheck(regoRule)
+	err := helmCheck.CompileRule(regoRule, "", &compliance.SuiteMeta{}, nil)
+	assert.NoError(err)
+
+	reports := helmCheck.Check(env)
+
+	assert.Len(reports, len(f.objects)-1)
+	assert.Equal(f.expectReport.Passed, reports[0].Passed)
+	assert.Equal(f.expectReport.Data, reports[0].Data)
+	assert.Equal(f.expectReport.Resource, reports[0].Resource)
+}
+
+func TestHelmCheck(t *testing.T) {
+	module := `package datadog
+
+	import data.datadog as dd
+
+	compliant(rel) {
+		%s
+	}
+
+	findings[f] {
+		rel := input.releases[_]
+		compliant(rel)
+		f := dd.passed_finding(
+				"helm_release",
+				sprintf("%%s/%%s", [rel.namespace, rel.name]),
+				{
+					"helm.release.name": rel.name,
+					"helm.release.namespace": rel.namespace,
+					"helm.release.revision": rel.revision,
+					"helm.release.chart": rel.chart.name,
+					"helm.release.chartVersion": rel.chart.version,
+				}
+		)
+	}
+
+	findings[f] {
+		rel := input.releases[_]
+		not compliant(rel)
+		f := dd.failing_finding(
+				"helm_release",
+				sprintf("%%s/%%s", [rel.namespace, rel.name]),
+				{
+					"helm.release.name": rel.name,
+					"helm.release.namespace": rel.namespace,
+					"helm.release.revision": rel.revision,
+					"helm.release.chart": rel.chart.name,
+					"helm.release.chartVersion": rel.chart.version,
+				}
+		)
+	}
+	`
+
+	passwordChanged := `rel.values.auth.password != "changeme"`
+	ingressTLS := `ingress := rel.manifest[_]
+		ingress.kind == "Ingress"
+		count(ingress.spec.tls) > 0`
+
+	expectData := func(name string, revision int) event.Data {
+		return event.Data{
+			compliance.HelmReleaseFieldName:         name,
+			compliance.HelmReleaseFieldNamespace:    "testns",
+			compliance.HelmReleaseFieldRevision:     json.Number(fmt.Sprint(revision)),
+			compliance.HelmReleaseFieldChart:        "mychart",
+			compliance.HelmReleaseFieldChartVersion: "1.2.3",
+		}
+	}
+
+	tests := []helmFixture{
+		{
+			name: "default password kept",
+			resource: compliance.RegoInput{
+				ResourceCommon: compliance.ResourceCommon{
+					Helm: &compliance.HelmResource{
+						Namespace:   "testns",
+						ReleaseName: "myrelease",
+					},
+				},
+				TagName: "releases",
+			},
+			module: fmt.Sprintf(module, passwordChanged),
+			objects: []runtime.Object{
+				newReleaseSecret(t, newRelease(t, "myrelease", 1, "superseded", map[string]interface{}{"auth": map[string]interface{}{"password": "secret"}}, "[]")),
+				newReleaseSecret(t, newRelease(t, "myrelease", 2, "deployed", map[string]interface{}{"auth": map[string]interface{}{"username": "root"}}, "[]")),
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data:   expectData("myrelease", 2),
+				Resource: compliance.ReportResource{
+					ID:   "testns/myrelease",
+					Type: "helm_release",
+				},
+			},
+		},
+		{
+			name: "default password changed",
+			resource: compliance.RegoInput{
+				ResourceCommon: compliance.ResourceCommon{
+					Helm: &compliance.HelmResource{
+						Namespace: "testns",
+						Chart:     "mychart",
+					},
+				},
+				TagName: "releases",
+			},
+			module: fmt.Sprintf(module, passwordChanged),
+			objects: []runtime.Object{
+				newReleaseSecret(t, newRelease(t, "myrelease", 1, "superseded", nil, "[]")),
+				newReleaseSecret(t, newRelease(t, "myrelease", 2, "deployed", map[string]interface{}{"auth": map[string]interface{}{"password": "secret"}}, "[]")),
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data:   expectData("myrelease", 2),
+				Resource: compliance.ReportResource{
+					ID:   "testns/myrelease",
+					Type: "helm_release",
+				},
+			},
+		},
+		{
+			name: "ingress tls enforced in configmap storage",
+			resource: compliance.RegoInput{
+				ResourceCommon: compliance.ResourceCommon{
+					Helm: &compliance.HelmResource{
+						Storage: "configmap",
+					},
+				},
+				TagName: "releases",
+			},
+			module: fmt.Sprintf(module, ingressTLS),
+			objects: []runtime.Object{
+				newReleaseConfigMap(t, newRelease(t, "myrelease", 3, "deployed", nil, `[{"hosts": ["example.com"], "secretName": "tls"}]`)),
+				newReleaseConfigMap(t, newRelease(t, "myrelease", 2, "superseded", nil, "[]")),
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data:   expectData("myrelease", 3),
+				Resource: compliance.ReportResource{
+					ID:   "testns/myrelease",
+					Type: "helm_release",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t)
+		})
+	}
+}
+
+func TestCoalesceValues(t *testing.T) {
+	defaults := map[string]interface{}{
+		"auth": map[string]interface{}{
+			"username": "admin",
+			"password": "changeme",
+		},
+		"replicas": 1,
+		"debug":    true,
+	}
+	overrides := map[string]interface{}{
+		"auth": map[string]interface{}{
+			"password": "secret",
+		},
+		"replicas": 3,
+		"debug":    nil,
+	}
+
+	values := coalesceValues(defaults, overrides)
+	assert.Equal(t, map[string]interface{}{
+		"auth": map[string]interface{}{
+			"username": "admin",
+			"password": "secret",
+		},
+		"replicas": 3,
+	}, values)
+	// the default values are left untouched
+	assert.Equal(t, "changeme", defaults["auth"].(map[string]interface{})["password"])
+}
@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package ruletest runs the rules of a compliance suite against fixture snapshots of the resources they observe, and
+// compares their findings with the expected results, so that rules can be validated without a live host or cluster.
+package ruletest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/agent"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
+	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+// DefaultHostname is the hostname of the context of the test cases which do not set one
+const DefaultHostname = "test-host"
+
+// Fixtures is a set of test cases for the rules of a compliance suite
+type Fixtures struct {
+	// Suite is the path of the compliance suite holding the rules, relative to the fixtures file
+	Suite string     `yaml:"suite"`
+	Tests []TestCase `yaml:"tests"`
+
+	path string
+}
+
+// TestCase is a snapshot of the resources observed by a rule, and the results the rule is expected to report for
+// them
+type TestCase struct {
+	Name     string `yaml:"name"`
+	RuleID   string `yaml:"rule"`
+	Hostname string `yaml:"hostname,omitempty"`
+	// Input is the rego input of the rule, in the format of the --dump-rego-input flag of the check command: the
+	// resources (files, processes, kubernetes objects, ...) are indexed by the tag of the rule inputs. The context
+	// of the input is built from the rule ID and the hostname when it is not provided.
+	Input  map[string]interface{} `yaml:"input"`
+	Expect Expectation            `yaml:"expect"`
+}
+
+// Expectation holds the results a rule is expected to report, as passed, failed or error
+type Expectation struct {
+	// Result is the result expected for all the findings of the rule
+	Result string `yaml:"result,omitempty"`
+	// Resources are the results expected for the findings of the given resource IDs
+	Resources map[string]string `yaml:"resources,omitempty"`
+}
+
+// Result is the result of a test case
+type Result struct {
+	Name   string
+	RuleID string
+	// Failures describes how the findings of the rule differ from the expected results
+	Failures []string
+	Events   []*event.Event
+}
+
+// Passed returns true if the findings of the rule match the expected results
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// LoadFixtures loads a fixtures file
+func LoadFixtures(path string) (*Fixtures, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fixtures{path: path}
+	if err := yaml.Unmarshal(content, f); err != nil {
+		return nil, fmt.Errorf("could not parse fixtures %s: %w", path, err)
+	}
+	if f.Suite == "" {
+		return nil, fmt.Errorf("no compliance suite set in fixtures %s", path)
+	}
+	for i, test := range f.Tests {
+		if test.RuleID == "" {
+			return nil, fmt.Errorf("no rule set for test %d of fixtures %s", i, path)
+		}
+		if test.Expect.Result == "" && len(test.Expect.Resources) == 0 {
+			return nil, fmt.Errorf("no expected result set for test %s of fixtures %s", test.name(), path)
+		}
+	}
+	return f, nil
+}
+
+// Run loads a fixtures file and runs its test cases
+func Run(path string) ([]*Result, error) {
+	f, err := LoadFixtures(path)
+	if err != nil {
+		return nil, err
+	}
+	return f.Run()
+}
+
+// SuitePath returns the path of the compliance suite of the fixtures
+func (f *Fixtures) SuitePath() string {
+	if filepath.IsAbs(f.Suite) {
+		return f.Suite
+	}
+	return filepath.Join(filepath.Dir(f.path), f.Suite)
+}
+
+// Run runs the test cases of the fixtures
+func (f *Fixtures) Run() ([]*Result, error) {
+	results := make([]*Result, 0, len(f.Tests))
+	for _, test := range f.Tests {
+		result, err := test.run(f.SuitePath())
+		if err != nil {
+			return nil, fmt.Errorf("could not run test %s: %w", test.name(), err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (t *TestCase) name() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.RuleID
+}
+
+func (t *TestCase) run(suitePath string) (*Result, error) {
+	hostname := t.Hostname
+	if hostname == "" {
+		hostname = DefaultHostname
+	}
+
+	input := make(eval.RegoInputMap, len(t.Input)+1)
+	for k, v := range t.Input {
+		input[k] = v
+	}
+	if _, ok := input["context"]; !ok {
+		input["context"] = map[string]interface{}{
+			"ruleID":   t.RuleID,
+			"hostname": hostname,
+		}
+	}
+
+	reporter := &eventCollector{}
+	err := agent.RunChecksFromFile(reporter, suitePath,
+		checks.WithHostname(hostname),
+		checks.WithMatchRule(checks.IsRuleID(t.RuleID)),
+		checks.WithRegoInputs(map[string]eval.RegoInputMap{t.RuleID: input}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Name:     t.name(),
+		RuleID:   t.RuleID,
+		Failures: t.Expect.compare(reporter.events),
+		Events:   reporter.events,
+	}, nil
+}
+
+func (e *Expectation) compare(events []*event.Event) []string {
+	if len(events) == 0 {
+		return []string{"the rule reported no finding, make sure it is part of the compliance suite"}
+	}
+
+	var failures []string
+	seen := make(map[string]struct{})
+	for _, ev := range events {
+		seen[ev.ResourceID] = struct{}{}
+		if e.Result != "" && ev.Result != e.Result {
+			failures = append(failures, fmt.Sprintf("resource %s: expected result %s, got %s%s", ev.ResourceID, e.Result, ev.Result, eventError(ev)))
+		}
+		if expected, ok := e.Resources[ev.ResourceID]; ok && ev.Result != expected {
+			failures = append(failures, fmt.Sprintf("resource %s: expected result %s, got %s%s", ev.ResourceID, expected, ev.Result, eventError(ev)))
+		}
+	}
+
+	missing := []string{}
+	for resourceID := range e.Resources {
+		if _, ok := seen[resourceID]; !ok {
+			missing = append(missing, resourceID)
+		}
+	}
+	sort.Strings(missing)
+	for _, resourceID := range missing {
+		failures = append(failures, fmt.Sprintf("resource %s: no finding reported", resourceID))
+	}
+	return failures
+}
+
+// eventError returns the error reported by a rule, if any, for the failure messages
+func eventError(ev *event.Event) string {
+	if ev.Result != event.Error {
+		return ""
+	}
+	if data, ok := ev.Data.(event.Data); ok {
+		if err, ok := data["error"]; ok {
+			return fmt.Sprintf(" (%v)", err)
+		}
+	}
+	return ""
+}
+
+// eventCollector is a reporter keeping the events of the rules in memory
+type eventCollector struct {
+	events []*event.Event
+}
+
+func (c *eventCollector) Report(ev *event.Event) {
+	c.events = append(c.events, ev)
+}
+
+func (c *eventCollector) ReportRaw(content []byte, service string, tags ...string) {}
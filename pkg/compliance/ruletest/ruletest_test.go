@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package ruletest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	results, err := Run("./testdata/cis-test.fixtures.yaml")
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	for _, result := range results {
+		assert.True(t, result.Passed(), "%s: %v", result.Name, result.Failures)
+		assert.NotEmpty(t, result.Events, result.Name)
+	}
+	assert.Equal(t, "admin.conf is writable by group", results[1].Name)
+	assert.Equal(t, "cis-test-file-permissions", results[1].RuleID)
+}
+
+func TestRunFailures(t *testing.T) {
+	suite, err := filepath.Abs("./testdata/cis-test.yaml")
+	require.NoError(t, err)
+
+	fixtures := filepath.Join(t.TempDir(), "fixtures.yaml")
+	require.NoError(t, os.WriteFile(fixtures, []byte(`suite: `+suite+`
+tests:
+- rule: cis-test-file-permissions
+  input:
+    file:
+      path: /etc/kubernetes/admin.conf
+      permissions: 0o666
+  expect:
+    result: passed
+- rule: cis-test-file-permissions
+  input:
+    file:
+      path: /etc/kubernetes/admin.conf
+      permissions: 0o600
+  expect:
+    resources:
+      other-host_kubernetes_master_node: passed
+- rule: unknown-rule
+  input: {}
+  expect:
+    result: passed
+`), 0644))
+
+	results, err := Run(fixtures)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "cis-test-file-permissions", results[0].Name)
+	assert.Equal(t, []string{"resource test-host_kubernetes_master_node: expected result passed, got failed"}, results[0].Failures)
+	assert.Equal(t, []string{"resource other-host_kubernetes_master_node: no finding reported"}, results[1].Failures)
+	assert.False(t, results[2].Passed())
+	assert.Empty(t, results[2].Events)
+}
+
+func TestLoadFixturesErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixtures string
+	}{
+		{name: "no suite", fixtures: "tests: []"},
+		{name: "no rule", fixtures: "suite: cis-test.yaml\ntests:\n- expect:\n    result: passed"},
+		{name: "no expectation", fixtures: "suite: cis-test.yaml\ntests:\n- rule: cis-test-file-permissions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fixtures.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.fixtures), 0644))
+
+			_, err := LoadFixtures(path)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package datadog
+
+import data.datadog as dd
+
+compliant(process) {
+	process.flags["--anonymous-auth"] == "false"
+}
+
+findings[f] {
+	count(input.process) == 0
+	f := dd.error_finding(
+		"kubernetes_master_node",
+		sprintf("%s_kubernetes_master_node", [input.context.hostname]),
+		"no kube-apiserver process found",
+	)
+}
+
+findings[f] {
+	process := input.process[_]
+	compliant(process)
+	f := dd.passed_finding(
+		"kubernetes_master_node",
+		sprintf("%s_kubernetes_master_node", [input.context.hostname]),
+		{"process.name": process.name},
+	)
+}
+
+findings[f] {
+	process := input.process[_]
+	not compliant(process)
+	f := dd.failing_finding(
+		"kubernetes_master_node",
+		sprintf("%s_kubernetes_master_node", [input.context.hostname]),
+		{"process.name": process.name},
+	)
+}
@@ -0,0 +1,29 @@
+package datadog
+
+import data.datadog as dd
+
+compliant(sa) {
+	sa.resource.Object.automountServiceAccountToken == false
+}
+
+findings[f] {
+	sa := input.serviceaccounts[_]
+	sa.name == "default"
+	compliant(sa)
+	f := dd.passed_finding(
+		"kube_serviceaccount",
+		sprintf("%s_%s", [input.context.kubernetes_cluster, sa.namespace]),
+		{"kube.serviceaccount.namespace": sa.namespace},
+	)
+}
+
+findings[f] {
+	sa := input.serviceaccounts[_]
+	sa.name == "default"
+	not compliant(sa)
+	f := dd.failing_finding(
+		"kube_serviceaccount",
+		sprintf("%s_%s", [input.context.kubernetes_cluster, sa.namespace]),
+		{"kube.serviceaccount.namespace": sa.namespace},
+	)
+}
@@ -0,0 +1,32 @@
+package datadog
+
+import data.datadog as dd
+
+compliant(file) {
+	bits.and(file.permissions, 18) == 0
+}
+
+file_data(file) = d {
+	d := {
+		"file.path": file.path,
+		"file.permissions": file.permissions,
+	}
+}
+
+findings[f] {
+	compliant(input.file)
+	f := dd.passed_finding(
+		"kubernetes_master_node",
+		sprintf("%s_kubernetes_master_node", [input.context.hostname]),
+		file_data(input.file),
+	)
+}
+
+findings[f] {
+	not compliant(input.file)
+	f := dd.failing_finding(
+		"kubernetes_master_node",
+		sprintf("%s_kubernetes_master_node", [input.context.hostname]),
+		file_data(input.file),
+	)
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
 	"github.com/DataDog/datadog-agent/pkg/util/jsonquery"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/go-ini/ini"
+	yamlv2 "gopkg.in/yaml.v2"
 	"gopkg.in/yaml.v3"
 )
 
@@ -121,3 +123,86 @@ func RegexpGetter(data []byte, expr string) (string, error) {
 
 	return string(match), nil
 }
+
+type contentParser func([]byte) (interface{}, error)
+
+var contentParsers = map[string]contentParser{
+	"json": parseJSONContent,
+	"yaml": parseYAMLContent,
+	"ini":  parseINIContent,
+	"raw":  parseRawContent,
+}
+
+func parseRawContent(data []byte) (interface{}, error) {
+	return string(data), nil
+}
+
+func parseJSONContent(data []byte) (interface{}, error) {
+	var content interface{}
+
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+func parseYAMLContent(data []byte) (interface{}, error) {
+	var content interface{}
+
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		if err := yamlv2.Unmarshal(data, &content); err != nil {
+			return nil, err
+		}
+	}
+
+	content = jsonquery.NormalizeYAMLForGoJQ(content)
+	return content, nil
+}
+
+// parseINIContent returns the keys of an INI file by section. The keys defined before the first section are in the
+// DEFAULT section.
+func parseINIContent(data []byte) (interface{}, error) {
+	f, err := ini.Load(data)
+	if err != nil {
+		return nil, err
+	}
+
+	content := make(map[string]interface{})
+	for _, section := range f.Sections() {
+		keys := make(map[string]interface{})
+		for _, key := range section.Keys() {
+			keys[key.Name()] = key.Value()
+		}
+		content[section.Name()] = keys
+	}
+	return content, nil
+}
+
+// ParseContent parses the content of a file with the given parser: json, yaml, ini or raw (the default)
+func ParseContent(data []byte, parser string) (interface{}, error) {
+	parser, err := ValidateParserKind(parser)
+	if err != nil {
+		return nil, err
+	}
+
+	parserFunc := contentParsers[parser]
+	if parserFunc != nil {
+		return parserFunc(data)
+	}
+
+	return string(data), nil
+}
+
+// ValidateParserKind returns the normalized name of a file content parser, or an error if it is not defined
+func ValidateParserKind(parser string) (string, error) {
+	if parser == "" {
+		return "", nil
+	}
+
+	normParser := strings.ToLower(parser)
+	if _, ok := contentParsers[normParser]; !ok {
+		return "", fmt.Errorf("undefined file content parser %s", parser)
+	}
+	return normParser, nil
+}
@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package packages
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/compliance/utils/command"
+)
+
+const (
+	dpkgStatusPath   = "/var/lib/dpkg/status"
+	apkInstalledPath = "/lib/apk/db/installed"
+	rpmDBPath        = "/var/lib/rpm"
+)
+
+// ErrNoPackageManager is returned when none of the supported package managers is installed on the host
+var ErrNoPackageManager = errors.New("no supported package manager found")
+
+// GetVersion returns the version of an installed package, and false if the package is not installed. The databases
+// of dpkg and apk are read directly, while rpm is queried.
+func GetVersion(e env.Env, name string) (string, bool, error) {
+	if f, err := os.Open(e.NormalizeToHostRoot(dpkgStatusPath)); err == nil {
+		defer f.Close()
+		return findDpkgVersion(f, name)
+	}
+
+	if f, err := os.Open(e.NormalizeToHostRoot(apkInstalledPath)); err == nil {
+		defer f.Close()
+		return findApkVersion(f, name)
+	}
+
+	if _, err := os.Stat(e.NormalizeToHostRoot(rpmDBPath)); err == nil {
+		return queryRpmVersion(e, name)
+	}
+
+	return "", false, ErrNoPackageManager
+}
+
+// findDpkgVersion looks for a package in the status file of dpkg, made of stanzas of "Field: value" lines separated
+// by empty lines
+func findDpkgVersion(r io.Reader, name string) (string, bool, error) {
+	var pkg, version, status string
+	check := func() bool {
+		return pkg == name && strings.HasSuffix(status, " installed")
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if check() {
+				return version, true, nil
+			}
+			pkg, version, status = "", "", ""
+			continue
+		}
+
+		field, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch field {
+		case "Package":
+			pkg = strings.TrimSpace(value)
+		case "Version":
+			version = strings.TrimSpace(value)
+		case "Status":
+			status = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, err
+	}
+
+	return version, check(), nil
+}
+
+// findApkVersion looks for a package in the installed database of apk, made of stanzas of "K:value" lines separated
+// by empty lines
+func findApkVersion(r io.Reader, name string) (string, bool, error) {
+	var pkg, version string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if pkg == name {
+				return version, true, nil
+			}
+			pkg, version = "", ""
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "P:"):
+			pkg = line[2:]
+		case strings.HasPrefix(line, "V:"):
+			version = line[2:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, err
+	}
+
+	return version, pkg == name, nil
+}
+
+func queryRpmVersion(e env.Env, name string) (string, bool, error) {
+	cmd := &compliance.BinaryCmd{
+		Name: "rpm",
+		Args: []string{"--root", e.NormalizeToHostRoot("/"), "-q", "--queryformat", "%{VERSION}-%{RELEASE}", name},
+	}
+
+	exitCode, stdout, err := command.RunBinaryCmd(cmd, compliance.DefaultTimeout)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to query rpm database: %w", err)
+	}
+	if exitCode != 0 {
+		// rpm exits with a non-zero code when the package is not installed
+		return "", false, nil
+	}
+
+	return strings.TrimSpace(stdout), true, nil
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package packages
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+const dpkgStatus = `Package: openssh-server
+Status: install ok installed
+Priority: optional
+Version: 1:8.9p1-3ubuntu0.1
+
+Package: telnetd
+Status: deinstall ok config-files
+Version: 0.17-44build1
+
+Package: rsyslog
+Status: install ok installed
+Version: 8.2112.0-2ubuntu2.2`
+
+const apkInstalled = `C:Q1Q0X3bFQ8Fz5sCq5zzTsZhNPcmKk=
+P:musl
+V:1.2.3-r4
+A:x86_64
+
+C:Q1Uf6H5Kk2kB8FzLQhKT3pJ2qM8kc=
+P:busybox
+V:1.35.0-r29
+A:x86_64
+`
+
+func TestFindDpkgVersion(t *testing.T) {
+	for name, expected := range map[string]string{
+		"openssh-server": "1:8.9p1-3ubuntu0.1",
+		"rsyslog":        "8.2112.0-2ubuntu2.2",
+		"telnetd":        "",
+		"nginx":          "",
+	} {
+		version, installed, err := findDpkgVersion(strings.NewReader(dpkgStatus), name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected != "", installed, name)
+		if installed {
+			assert.Equal(t, expected, version)
+		}
+	}
+}
+
+func TestFindApkVersion(t *testing.T) {
+	for name, expected := range map[string]string{
+		"musl":    "1.2.3-r4",
+		"busybox": "1.35.0-r29",
+		"nginx":   "",
+	} {
+		version, installed, err := findApkVersion(strings.NewReader(apkInstalled), name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected != "", installed, name)
+		if installed {
+			assert.Equal(t, expected, version)
+		}
+	}
+}
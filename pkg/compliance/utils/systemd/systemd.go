@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package systemd
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+)
+
+// unitPaths are the directories systemd loads the units from, by order of precedence
+var unitPaths = []string{
+	"/etc/systemd/system",
+	"/run/systemd/system",
+	"/usr/local/lib/systemd/system",
+	"/usr/lib/systemd/system",
+	"/lib/systemd/system",
+}
+
+// GetUnitProperty returns the value of a property of a unit, as defined by its unit file and drop-in files, and false
+// if the unit or the property is not defined. The unit files are read rather than querying systemd, so the properties
+// of the units of the host can be read from a container.
+func GetUnitProperty(e env.Env, unit, property string) (string, bool, error) {
+	files := unitFiles(e, unit)
+	if len(files) == 0 {
+		return "", false, nil
+	}
+
+	var value string
+	var found bool
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", false, err
+		}
+		v, ok, err := findProperty(f, property)
+		f.Close()
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			value, found = v, true
+		}
+	}
+	return value, found, nil
+}
+
+// unitFiles returns the unit file of a unit, followed by its drop-in files, sorted by name as done by systemd
+func unitFiles(e env.Env, unit string) []string {
+	var files []string
+	for _, dir := range unitPaths {
+		path := e.NormalizeToHostRoot(filepath.Join(dir, unit))
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+			break
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	dropIns := make(map[string]string)
+	for i := len(unitPaths) - 1; i >= 0; i-- {
+		matches, _ := filepath.Glob(e.NormalizeToHostRoot(filepath.Join(unitPaths[i], unit+".d", "*.conf")))
+		for _, match := range matches {
+			// a drop-in file overrides the drop-in files of the same name in the directories of lower precedence
+			dropIns[filepath.Base(match)] = match
+		}
+	}
+
+	names := make([]string, 0, len(dropIns))
+	for name := range dropIns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		files = append(files, dropIns[name])
+	}
+	return files
+}
+
+// findProperty returns the last value assigned to a property in a unit file. An empty assignment resets the value.
+func findProperty(r io.Reader, property string) (string, bool, error) {
+	var value string
+	var found bool
+
+	scanner := bufio.NewScanner(r)
+	var line string
+	for scanner.Scan() {
+		line += strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, "\\") {
+			line = strings.TrimSpace(strings.TrimSuffix(line, "\\")) + " "
+			continue
+		}
+
+		key, v, ok := strings.Cut(line, "=")
+		line = ""
+		if !ok || strings.HasPrefix(key, "#") || strings.HasPrefix(key, ";") {
+			continue
+		}
+		if strings.TrimSpace(key) == property {
+			value, found = strings.TrimSpace(v), true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, err
+	}
+	return value, found, nil
+}
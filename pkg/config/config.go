@@ -16,6 +16,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -129,6 +130,17 @@ func PrometheusScrapeChecksTransformer(in string) interface{} {
 	return promChecks
 }
 
+// externalMetricsEndpointsTransformer parses the `external_metrics_provider.endpoints` set as JSON in the
+// environment. The endpoints are kept as maps rather than Endpoint, so their secret handles are resolved along with the
+// rest of the configuration.
+func externalMetricsEndpointsTransformer(in string) interface{} {
+	var endpoints []map[string]interface{}
+	if err := json.Unmarshal([]byte(in), &endpoints); err != nil {
+		log.Errorf(`"external_metrics_provider.endpoints" can not be parsed: %v`, err)
+	}
+	return endpoints
+}
+
 // MetadataProviders helps unmarshalling `metadata_providers` config param
 type MetadataProviders struct {
 	Name     string        `mapstructure:"name"`
@@ -968,7 +980,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("external_metrics_provider.endpoint", "")                       // Override the Datadog API endpoint to query external metrics from
 	config.BindEnvAndSetDefault("external_metrics_provider.api_key", "")                        // Override the Datadog API Key for external metrics endpoint
 	config.BindEnvAndSetDefault("external_metrics_provider.app_key", "")                        // Override the Datadog APP Key for external metrics endpoint
-	config.SetKnown("external_metrics_provider.endpoints")                                      // List of redundant endpoints to query external metrics from
+	config.BindEnv("external_metrics_provider.endpoints")                                       // List of redundant endpoints to query external metrics from
 	config.BindEnvAndSetDefault("external_metrics_provider.refresh_period", 30)                 // value in seconds. Frequency of calls to Datadog to refresh metric values
 	config.BindEnvAndSetDefault("external_metrics_provider.batch_window", 10)                   // value in seconds. Batch the events from the Autoscalers informer to push updates to the ConfigMap (GlobalStore)
 	config.BindEnvAndSetDefault("external_metrics_provider.max_age", 120)                       // value in seconds. 4 cycles from the Autoscaler controller (up to Kubernetes 1.11) is enough to consider a metric stale
@@ -983,6 +995,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("external_metrics_provider.config", map[string]string{})        // list of options that can be used to configure the external metrics server
 	config.BindEnvAndSetDefault("external_metrics_provider.local_copy_refresh_rate", 30)        // value in seconds
 	config.BindEnvAndSetDefault("external_metrics_provider.chunk_size", 35)                     // Maximum number of queries to batch when querying Datadog.
+	config.SetEnvKeyTransformer("external_metrics_provider.endpoints", externalMetricsEndpointsTransformer)
 	AddOverrideFunc(sanitizeExternalMetricsProviderChunkSize)
 	// Cluster check Autodiscovery
 	config.BindEnvAndSetDefault("cluster_checks.enabled", false)
@@ -1143,6 +1156,11 @@ func InitConfig(config Config) {
 	config.BindEnv("compliance_config.run_commands_as")
 	bindEnvAndSetLogsConfigKeys(config, "compliance_config.endpoints.")
 	config.BindEnvAndSetDefault("compliance_config.opa.metrics.enabled", false)
+	config.BindEnvAndSetDefault("compliance_config.remote_bundles.enabled", false)
+	config.BindEnvAndSetDefault("compliance_config.remote_bundles.public_key", "")
+	config.BindEnvAndSetDefault("compliance_config.remote_bundles.pinned_version", "")
+	config.SetKnown("compliance_config.exceptions")
+	config.BindEnvAndSetDefault("compliance_config.remote_exceptions.enabled", false)
 
 	// Datadog security agent (runtime)
 	config.BindEnvAndSetDefault("runtime_security_config.enabled", false)
@@ -1661,6 +1679,69 @@ func ResolveSecrets(config Config, origin string) error {
 	return nil
 }
 
+// UnresolvedSecretKeys returns the settings under the given prefixes still holding a secret handle, because the
+// secrets were not resolved or the secret backend is not configured
+func UnresolvedSecretKeys(config Config, prefixes ...string) []string {
+	var keys []string
+	for _, key := range config.AllKeys() {
+		for _, prefix := range prefixes {
+			if key != prefix && !strings.HasPrefix(key, prefix+".") {
+				continue
+			}
+			if hasSecretHandle(config.Get(key)) {
+				keys = append(keys, key)
+			}
+			break
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func hasSecretHandle(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return secrets.IsEnc(v)
+	case []string:
+		for _, s := range v {
+			if secrets.IsEnc(s) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			if hasSecretHandle(e) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		for _, e := range v {
+			if hasSecretHandle(e) {
+				return true
+			}
+		}
+	case map[interface{}]interface{}:
+		for _, e := range v {
+			if hasSecretHandle(e) {
+				return true
+			}
+		}
+	case []map[string]interface{}:
+		for _, e := range v {
+			if hasSecretHandle(e) {
+				return true
+			}
+		}
+	case map[string]string:
+		for _, e := range v {
+			if secrets.IsEnc(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // EnvVarAreSetAndNotEqual returns true if two given variables are set in environment and are not equal.
 func EnvVarAreSetAndNotEqual(lhsName string, rhsName string) bool {
 	lhsValue, lhsIsSet := os.LookupEnv(lhsName)
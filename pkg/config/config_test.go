@@ -1114,6 +1114,38 @@ func TestPrometheusScrapeChecksTransformer(t *testing.T) {
 	assert.EqualValues(t, PrometheusScrapeChecksTransformer(input), expected)
 }
 
+func TestExternalMetricsEndpointsFromEnv(t *testing.T) {
+	t.Setenv("DD_EXTERNAL_METRICS_PROVIDER_ENDPOINTS", `[{"url":"https://api.datadoghq.eu","api_key":"ENC[api_key]","app_key":"ENC[app_key]"}]`)
+	config := setupConf()
+
+	var endpoints []Endpoint
+	require.NoError(t, config.UnmarshalKey("external_metrics_provider.endpoints", &endpoints))
+	assert.Equal(t, []Endpoint{{URL: "https://api.datadoghq.eu", APIKey: "ENC[api_key]", APPKey: "ENC[app_key]"}}, endpoints)
+}
+
+func TestUnresolvedSecretKeys(t *testing.T) {
+	t.Setenv("DD_ADMISSION_CONTROLLER_SERVICE_NAME", "ENC[service_name]")
+	config := setupConfFromYAML(`
+api_key: ENC[api_key]
+external_metrics_provider:
+  app_key: ENC[app_key]
+  endpoints:
+    - url: https://api.datadoghq.eu
+      api_key: ENC[eu_api_key]
+      app_key: eu_app_key
+cluster_checks:
+  extra_tags: ["team:ENC[team]", "ENC[tag]"]
+`)
+
+	keys := UnresolvedSecretKeys(config, "external_metrics_provider", "admission_controller", "cluster_checks")
+	assert.Equal(t, []string{
+		"admission_controller.service_name",
+		"cluster_checks.extra_tags",
+		"external_metrics_provider.app_key",
+		"external_metrics_provider.endpoints",
+	}, keys)
+}
+
 func TestUsePodmanLogsAndDockerPathOverride(t *testing.T) {
 	// If use_podman_logs is true and docker_path_override is set, the config should return an error
 	datadogYaml := `
@@ -71,10 +71,12 @@ type Client struct {
 	state *state.Repository
 
 	// Listeners
-	apmListeners        []func(update map[string]state.APMSamplingConfig)
-	cwsListeners        []func(update map[string]state.ConfigCWSDD)
-	cwsCustomListeners  []func(update map[string]state.ConfigCWSCustom)
-	apmTracingListeners []func(update map[string]state.APMTracingConfig)
+	apmListeners            []func(update map[string]state.APMSamplingConfig)
+	cwsListeners            []func(update map[string]state.ConfigCWSDD)
+	cwsCustomListeners      []func(update map[string]state.ConfigCWSCustom)
+	apmTracingListeners     []func(update map[string]state.APMTracingConfig)
+	cspmBundleListeners     []func(update map[string]state.ConfigCSPMBundle)
+	cspmExceptionsListeners []func(update map[string]state.ConfigCSPMExceptions)
 }
 
 // agentGRPCConfigFetcher defines how to retrieve config updates over a
@@ -178,22 +180,24 @@ func newClient(agentName string, updater ConfigUpdater, doTufVerification bool,
 	ctx, close := context.WithCancel(context.Background())
 
 	return &Client{
-		ID:                  generateID(),
-		startupSync:         sync.Once{},
-		ctx:                 ctx,
-		close:               close,
-		agentName:           agentName,
-		agentVersion:        agentVersion,
-		clusterName:         clusterName,
-		products:            data.ProductListToString(products),
-		state:               repository,
-		pollInterval:        pollInterval,
-		backoffPolicy:       backoffPolicy,
-		apmListeners:        make([]func(update map[string]state.APMSamplingConfig), 0),
-		cwsListeners:        make([]func(update map[string]state.ConfigCWSDD), 0),
-		cwsCustomListeners:  make([]func(update map[string]state.ConfigCWSCustom), 0),
-		apmTracingListeners: make([]func(update map[string]state.APMTracingConfig), 0),
-		updater:             updater,
+		ID:                      generateID(),
+		startupSync:             sync.Once{},
+		ctx:                     ctx,
+		close:                   close,
+		agentName:               agentName,
+		agentVersion:            agentVersion,
+		clusterName:             clusterName,
+		products:                data.ProductListToString(products),
+		state:                   repository,
+		pollInterval:            pollInterval,
+		backoffPolicy:           backoffPolicy,
+		apmListeners:            make([]func(update map[string]state.APMSamplingConfig), 0),
+		cwsListeners:            make([]func(update map[string]state.ConfigCWSDD), 0),
+		cwsCustomListeners:      make([]func(update map[string]state.ConfigCWSCustom), 0),
+		apmTracingListeners:     make([]func(update map[string]state.APMTracingConfig), 0),
+		cspmBundleListeners:     make([]func(update map[string]state.ConfigCSPMBundle), 0),
+		cspmExceptionsListeners: make([]func(update map[string]state.ConfigCSPMExceptions), 0),
+		updater:                 updater,
 	}, nil
 }
 
@@ -289,6 +293,16 @@ func (c *Client) update() error {
 			listener(c.state.APMTracingConfigs())
 		}
 	}
+	if containsProduct(changedProducts, state.ProductCSPMBundles) {
+		for _, listener := range c.cspmBundleListeners {
+			listener(c.state.CSPMBundleConfigs())
+		}
+	}
+	if containsProduct(changedProducts, state.ProductCSPMExceptions) {
+		for _, listener := range c.cspmExceptionsListeners {
+			listener(c.state.CSPMExceptionsConfigs())
+		}
+	}
 
 	return nil
 }
@@ -339,6 +353,24 @@ func (c *Client) RegisterAPMTracing(fn func(update map[string]state.APMTracingCo
 	fn(c.state.APMTracingConfigs())
 }
 
+// RegisterCSPMBundlesUpdate registers a callback function to be called after a successful client update that will
+// contain the current state of the CSPM_BUNDLES product.
+func (c *Client) RegisterCSPMBundlesUpdate(fn func(update map[string]state.ConfigCSPMBundle)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.cspmBundleListeners = append(c.cspmBundleListeners, fn)
+	fn(c.state.CSPMBundleConfigs())
+}
+
+// RegisterCSPMExceptionsUpdate registers a callback function to be called after a successful client update that will
+// contain the current state of the CSPM_EXCEPTIONS product.
+func (c *Client) RegisterCSPMExceptionsUpdate(fn func(update map[string]state.ConfigCSPMExceptions)) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.cspmExceptionsListeners = append(c.cspmExceptionsListeners, fn)
+	fn(c.state.CSPMExceptionsConfigs())
+}
+
 // APMTracingConfigs returns the current set of valid APM Tracing configs
 func (c *Client) APMTracingConfigs() map[string]state.APMTracingConfig {
 	c.m.Lock()
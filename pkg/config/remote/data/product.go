@@ -17,6 +17,10 @@ const (
 	ProductCWSCustom Product = "CWS_CUSTOM"
 	// ProductAPMTracing is the apm tracing product
 	ProductAPMTracing Product = "APM_TRACING"
+	// ProductCSPMBundles is the compliance rule bundles product
+	ProductCSPMBundles Product = "CSPM_BUNDLES"
+	// ProductCSPMExceptions is the compliance exceptions product
+	ProductCSPMExceptions Product = "CSPM_EXCEPTIONS"
 	// ProductTesting1 is a testing product
 	ProductTesting1 Product = "TESTING1"
 )
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -110,6 +111,10 @@ func InitSystemProbeConfig(cfg Config) {
 	cfg.BindEnvAndSetDefault(join(spNS, "memory_controller.pressure_levels"), map[string]string{})
 	cfg.BindEnvAndSetDefault(join(spNS, "memory_controller.thresholds"), map[string]string{})
 
+	cfg.BindEnvAndSetDefault(join(spNS, "drop_privileges.enabled"), false, "DD_SYSTEM_PROBE_DROP_PRIVILEGES_ENABLED")
+	cfg.BindEnvAndSetDefault(join(spNS, "drop_privileges.keep_capabilities"), []string{}, "DD_SYSTEM_PROBE_DROP_PRIVILEGES_KEEP_CAPABILITIES")
+	cfg.BindEnvAndSetDefault(join(spNS, "drop_privileges.seccomp_profile"), "", "DD_SYSTEM_PROBE_DROP_PRIVILEGES_SECCOMP_PROFILE")
+
 	// ebpf general settings
 	cfg.BindEnvAndSetDefault(join(spNS, "bpf_debug"), false)
 	cfg.BindEnvAndSetDefault(join(spNS, "bpf_dir"), defaultSystemProbeBPFDir, "DD_SYSTEM_PROBE_BPF_DIR")
@@ -118,10 +123,14 @@ func InitSystemProbeConfig(cfg Config) {
 	cfg.BindEnvAndSetDefault(join(spNS, "enable_tracepoints"), false)
 	cfg.BindEnvAndSetDefault(join(spNS, "enable_co_re"), true, "DD_ENABLE_CO_RE")
 	cfg.BindEnvAndSetDefault(join(spNS, "btf_path"), "", "DD_SYSTEM_PROBE_BTF_PATH")
+	cfg.BindEnvAndSetDefault(join(spNS, "btf_dir"), "", "DD_SYSTEM_PROBE_BTF_DIR")
 	cfg.BindEnv(join(spNS, "enable_runtime_compiler"), "DD_ENABLE_RUNTIME_COMPILER")
 	cfg.BindEnvAndSetDefault(join(spNS, "allow_precompiled_fallback"), true, "DD_ALLOW_PRECOMPILED_FALLBACK")
 	cfg.BindEnvAndSetDefault(join(spNS, "allow_runtime_compiled_fallback"), true, "DD_ALLOW_RUNTIME_COMPILED_FALLBACK")
 	cfg.BindEnvAndSetDefault(join(spNS, "runtime_compiler_output_dir"), defaultRuntimeCompilerOutputDir, "DD_RUNTIME_COMPILER_OUTPUT_DIR")
+	cfg.BindEnvAndSetDefault(join(spNS, "runtime_compiler_cache_dir"), "", "DD_RUNTIME_COMPILER_CACHE_DIR")
+	cfg.BindEnvAndSetDefault(join(spNS, "runtime_compiler_cache_url"), "", "DD_RUNTIME_COMPILER_CACHE_URL")
+	cfg.BindEnvAndSetDefault(join(spNS, "runtime_compiler_cache_manifest"), "", "DD_RUNTIME_COMPILER_CACHE_MANIFEST")
 	cfg.BindEnv(join(spNS, "enable_kernel_header_download"), "DD_ENABLE_KERNEL_HEADER_DOWNLOAD")
 	cfg.BindEnvAndSetDefault(join(spNS, "kernel_header_dirs"), []string{}, "DD_KERNEL_HEADER_DIRS")
 	cfg.BindEnvAndSetDefault(join(spNS, "kernel_header_download_dir"), defaultKernelHeadersDownloadDir, "DD_KERNEL_HEADER_DOWNLOAD_DIR")
@@ -129,6 +138,10 @@ func InitSystemProbeConfig(cfg Config) {
 	cfg.BindEnvAndSetDefault(join(spNS, "yum_repos_dir"), suffixHostEtc(defaultYumReposDirSuffix), "DD_YUM_REPOS_DIR")
 	cfg.BindEnvAndSetDefault(join(spNS, "zypper_repos_dir"), suffixHostEtc(defaultZypperReposDirSuffix), "DD_ZYPPER_REPOS_DIR")
 	cfg.BindEnvAndSetDefault(join(spNS, "attach_kprobes_with_kprobe_events_abi"), false, "DD_ATTACH_KPROBES_WITH_KPROBE_EVENTS_ABI")
+	cfg.BindEnvAndSetDefault(join(spNS, "perf_buffer_max_pages"), 64, "DD_SYSTEM_PROBE_PERF_BUFFER_MAX_PAGES")
+	cfg.BindEnvAndSetDefault(join(spNS, "perf_buffer_resize_lost_threshold"), 100, "DD_SYSTEM_PROBE_PERF_BUFFER_RESIZE_LOST_THRESHOLD")
+	cfg.BindEnvAndSetDefault(join(spNS, "perf_buffer_adaptive_sizing"), false, "DD_SYSTEM_PROBE_PERF_BUFFER_ADAPTIVE_SIZING")
+	cfg.BindEnvAndSetDefault(join(spNS, "enable_ring_buffers"), true, "DD_SYSTEM_PROBE_ENABLE_RING_BUFFERS")
 
 	// network_tracer settings
 	// we cannot use BindEnvAndSetDefault for network_config.enabled because we need to know if it was manually set.
@@ -138,11 +151,16 @@ func InitSystemProbeConfig(cfg Config) {
 	cfg.BindEnvAndSetDefault(join(spNS, "disable_ipv6"), false, "DD_DISABLE_IPV6_TRACING")
 	cfg.BindEnvAndSetDefault(join(spNS, "offset_guess_threshold"), int64(defaultOffsetThreshold))
 
+	cfg.BindEnvAndSetDefault(join(netNS, "collect_ipv4_conns"), true, "DD_SYSTEM_PROBE_NETWORK_COLLECT_IPV4_CONNS")
+
 	cfg.BindEnvAndSetDefault(join(spNS, "max_tracked_connections"), 65536)
+	cfg.BindEnvAndSetDefault(join(netNS, "max_tracked_ipv6_connections"), 0, "DD_SYSTEM_PROBE_NETWORK_MAX_TRACKED_IPV6_CONNECTIONS")
 	cfg.BindEnv(join(spNS, "max_closed_connections_buffered"))
 	cfg.BindEnvAndSetDefault(join(spNS, "closed_connection_flush_threshold"), 0)
 	cfg.BindEnvAndSetDefault(join(spNS, "closed_channel_size"), 500)
 	cfg.BindEnvAndSetDefault(join(spNS, "max_connection_state_buffered"), 75000)
+	cfg.BindEnvAndSetDefault(join(netNS, "max_connections_per_client"), 0, "DD_SYSTEM_PROBE_NETWORK_MAX_CONNECTIONS_PER_CLIENT")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_connection_rollup"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_CONNECTION_ROLLUP")
 
 	cfg.BindEnvAndSetDefault(join(spNS, "disable_dns_inspection"), false, "DD_DISABLE_DNS_INSPECTION")
 	cfg.BindEnvAndSetDefault(join(spNS, "collect_dns_stats"), true, "DD_COLLECT_DNS_STATS")
@@ -158,7 +176,16 @@ func InitSystemProbeConfig(cfg Config) {
 	cfg.BindEnvAndSetDefault(join(spNS, "conntrack_rate_limit"), 500)
 	cfg.BindEnvAndSetDefault(join(spNS, "enable_conntrack_all_namespaces"), true, "DD_SYSTEM_PROBE_ENABLE_CONNTRACK_ALL_NAMESPACES")
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_protocol_classification"), true, "DD_ENABLE_PROTOCOL_CLASSIFICATION")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_tcp_drop_tracking"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_TCP_DROP_TRACKING")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_tcp_queue_length_tracking"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_TCP_QUEUE_LENGTH_TRACKING")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_tcp_congestion_tracking"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_TCP_CONGESTION_TRACKING")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_quic_stats"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_QUIC_STATS")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_ebpf_less"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_EBPF_LESS")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_kernel_connection_filters"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_KERNEL_CONNECTION_FILTERS")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_process_env_tags"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_PROCESS_ENV_TAGS")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_service_name_inference"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_SERVICE_NAME_INFERENCE")
 	cfg.BindEnvAndSetDefault(join(netNS, "ignore_conntrack_init_failure"), false, "DD_SYSTEM_PROBE_NETWORK_IGNORE_CONNTRACK_INIT_FAILURE")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_ebpf_conntracker"), true, "DD_SYSTEM_PROBE_NETWORK_ENABLE_EBPF_CONNTRACKER")
 	cfg.BindEnvAndSetDefault(join(netNS, "conntrack_init_timeout"), 10*time.Second)
 
 	cfg.BindEnvAndSetDefault(join(spNS, "source_excludes"), map[string][]string{})
@@ -169,12 +196,60 @@ func InitSystemProbeConfig(cfg Config) {
 	cfg.BindEnv(join(netNS, "enable_https_monitoring"), "DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTPS_MONITORING")
 
 	cfg.BindEnvAndSetDefault(join(smNS, "enable_go_tls_support"), false)
-
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_ktls_support"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_http2_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_kafka_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_kafka_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_postgres_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_postgres_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_mysql_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_mysql_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_redis_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_redis_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_mongo_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_mongo_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_amqp_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_amqp_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_grpc_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_grpc_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_http3_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_http3_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_cassandra_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_cassandra_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_memcached_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "max_memcached_stats_buffered"), 100000)
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_tls_handshake_monitoring"), false)
+	cfg.BindEnvAndSetDefault(join(smNS, "exclude_agent_traffic"), true)
+
+	cfg.BindEnvAndSetDefault(join(smNS, "enable_tls_library_watcher"), false, "DD_SYSTEM_PROBE_SERVICE_MONITORING_ENABLE_TLS_LIBRARY_WATCHER")
 	cfg.BindEnvAndSetDefault(join(smNS, "enable_java_tls_support"), false)
 	cfg.BindEnvAndSetDefault(join(smNS, "java_agent_args"), defaultServiceMonitoringJavaAgentArgs)
+	cfg.BindEnvAndSetDefault(join(smNS, "java_tls", "allow_regex"), "", "DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_ALLOW_REGEX")
+	cfg.BindEnvAndSetDefault(join(smNS, "java_tls", "block_regex"), "", "DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_BLOCK_REGEX")
+	cfg.BindEnvAndSetDefault(join(smNS, "java_tls", "allow_uids"), []string{}, "DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_ALLOW_UIDS")
+	cfg.BindEnvAndSetDefault(join(smNS, "java_tls", "block_uids"), []string{}, "DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_BLOCK_UIDS")
+	cfg.BindEnvAndSetDefault(join(smNS, "java_tls", "allow_container_labels"), []string{}, "DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_ALLOW_CONTAINER_LABELS")
+	cfg.BindEnvAndSetDefault(join(smNS, "java_tls", "block_container_labels"), []string{}, "DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_BLOCK_CONTAINER_LABELS")
+
+	cfg.BindEnvAndSetDefault(join(smNS, "cpu_pressure", "enabled"), false, "DD_SYSTEM_PROBE_SERVICE_MONITORING_CPU_PRESSURE_ENABLED")
+	cfg.BindEnvAndSetDefault(join(smNS, "cpu_pressure", "max_cpu_percent"), 10.0, "DD_SYSTEM_PROBE_SERVICE_MONITORING_CPU_PRESSURE_MAX_CPU_PERCENT")
+	cfg.BindEnvAndSetDefault(join(smNS, "cpu_pressure", "check_interval_in_s"), 10, "DD_SYSTEM_PROBE_SERVICE_MONITORING_CPU_PRESSURE_CHECK_INTERVAL_IN_S")
 
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_gateway_lookup"), true, "DD_SYSTEM_PROBE_NETWORK_ENABLE_GATEWAY_LOOKUP")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_fentry"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_FENTRY")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_reverse_dns_enrichment"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_REVERSE_DNS_ENRICHMENT")
+	cfg.BindEnvAndSetDefault(join(netNS, "reverse_dns_enrichment_rate_limit"), 10)
+	cfg.BindEnvAndSetDefault(join(netNS, "reverse_dns_enrichment_cache_size"), 10000, "DD_SYSTEM_PROBE_NETWORK_REVERSE_DNS_ENRICHMENT_CACHE_SIZE")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_connection_domains"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_CONNECTION_DOMAINS")
+	cfg.BindEnvAndSetDefault(join(netNS, "connection_domains_cache_size"), 100000, "DD_SYSTEM_PROBE_NETWORK_CONNECTION_DOMAINS_CACHE_SIZE")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_dns_over_tls_monitoring"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_DNS_OVER_TLS_MONITORING")
 	cfg.BindEnvAndSetDefault(join(netNS, "max_http_stats_buffered"), 100000, "DD_SYSTEM_PROBE_NETWORK_MAX_HTTP_STATS_BUFFERED")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_http_stats_by_status_code"), false, "DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTP_STATS_BY_STATUS_CODE")
+	cfg.BindEnvAndSetDefault(join(netNS, "enable_http_stats_by_method"), true, "DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTP_STATS_BY_METHOD")
+	cfg.BindEnvAndSetDefault(join(netNS, "http_path_quantization", "enabled"), false, "DD_SYSTEM_PROBE_NETWORK_HTTP_PATH_QUANTIZATION_ENABLED")
+	cfg.BindEnvAndSetDefault(join(netNS, "http_path_quantization", "min_hits"), 20, "DD_SYSTEM_PROBE_NETWORK_HTTP_PATH_QUANTIZATION_MIN_HITS")
+	cfg.BindEnvAndSetDefault(join(netNS, "http_path_quantization", "max_endpoints"), 10000, "DD_SYSTEM_PROBE_NETWORK_HTTP_PATH_QUANTIZATION_MAX_ENDPOINTS")
+	cfg.BindEnvAndSetDefault(join(netNS, "http_capture_headers"), []string{}, "DD_SYSTEM_PROBE_NETWORK_HTTP_CAPTURE_HEADERS")
 	httpRules := join(netNS, "http_replace_rules")
 	cfg.BindEnv(httpRules, "DD_SYSTEM_PROBE_NETWORK_HTTP_REPLACE_RULES")
 	cfg.SetEnvKeyTransformer(httpRules, func(in string) interface{} {
@@ -187,6 +262,7 @@ func InitSystemProbeConfig(cfg Config) {
 	cfg.BindEnvAndSetDefault(join(netNS, "max_tracked_http_connections"), 1024)
 	cfg.BindEnvAndSetDefault(join(netNS, "http_notification_threshold"), 512)
 	cfg.BindEnvAndSetDefault(join(netNS, "http_max_request_fragment"), 160)
+	cfg.BindEnvAndSetDefault(join(netNS, "http_max_path_size"), 0, "DD_SYSTEM_PROBE_NETWORK_HTTP_MAX_PATH_SIZE")
 
 	// list of DNS query types to be recorded
 	cfg.BindEnvAndSetDefault(join(netNS, "dns_recorded_query_types"), []string{})
@@ -218,6 +294,13 @@ func InitSystemProbeConfig(cfg Config) {
 	// enable/disable use of root net namespace
 	cfg.BindEnvAndSetDefault(join(netNS, "enable_root_netns"), true)
 
+	// offline capture of the connections to disk
+	cfg.BindEnvAndSetDefault(join(netNS, "offline_capture.enabled"), false, "DD_SYSTEM_PROBE_NETWORK_OFFLINE_CAPTURE_ENABLED")
+	cfg.BindEnvAndSetDefault(join(netNS, "offline_capture.dir"), filepath.Join(defaultRunPath, "system-probe", "network-captures"), "DD_SYSTEM_PROBE_NETWORK_OFFLINE_CAPTURE_DIR")
+	cfg.BindEnvAndSetDefault(join(netNS, "offline_capture.interval"), 30*time.Second)
+	cfg.BindEnvAndSetDefault(join(netNS, "offline_capture.max_files"), 120)
+	cfg.BindEnvAndSetDefault(join(netNS, "offline_capture.replay_path"), "", "DD_SYSTEM_PROBE_NETWORK_OFFLINE_CAPTURE_REPLAY_PATH")
+
 	// CWS
 	cfg.BindEnvAndSetDefault("runtime_security_config.enabled", false)
 	cfg.BindEnvAndSetDefault("runtime_security_config.fim_enabled", false)
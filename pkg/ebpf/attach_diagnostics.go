@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/cilium/ebpf/btf"
+)
+
+// lockdownPath is the file exposing the lockdown mode of the kernel, such as `none [integrity] confidentiality`
+var lockdownPath = "/sys/kernel/security/lockdown"
+
+// AttachFailure describes a probe that failed to attach, along with the likely cause of the failure and a suggested
+// remediation
+type AttachFailure struct {
+	Probe       string `json:"probe"`
+	Error       string `json:"error"`
+	Cause       string `json:"cause"`
+	Remediation string `json:"remediation"`
+}
+
+func (f AttachFailure) String() string {
+	return fmt.Sprintf("probe %s failed to attach: %s (likely cause: %s; remediation: %s)", f.Probe, f.Error, f.Cause, f.Remediation)
+}
+
+// attachFailuresByModule is a global object storing the probes that failed to attach for all modules
+var attachFailuresByModule = make(map[string][]AttachFailure)
+var attachFailuresMu sync.Mutex
+
+// RecordAttachFailures diagnoses the probes of the manager that failed to attach and stores the diagnostics for the
+// given module, replacing the ones previously stored. It returns the diagnostics.
+func RecordAttachFailures(module string, m *manager.Manager) []AttachFailure {
+	var failures []AttachFailure
+	for _, p := range m.Probes {
+		if err := p.GetLastError(); err != nil && !p.IsRunning() {
+			failures = append(failures, DiagnoseAttachError(p, err))
+		}
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Probe < failures[j].Probe
+	})
+
+	attachFailuresMu.Lock()
+	defer attachFailuresMu.Unlock()
+	if len(failures) == 0 {
+		delete(attachFailuresByModule, module)
+	} else {
+		attachFailuresByModule[module] = failures
+	}
+	return failures
+}
+
+// GetAttachFailures returns the probes that failed to attach, by module
+func GetAttachFailures() map[string][]AttachFailure {
+	attachFailuresMu.Lock()
+	defer attachFailuresMu.Unlock()
+
+	result := make(map[string][]AttachFailure, len(attachFailuresByModule))
+	for module, failures := range attachFailuresByModule {
+		result[module] = append([]AttachFailure(nil), failures...)
+	}
+	return result
+}
+
+// AttachFailuresError returns err along with the diagnostics of the probes that failed to attach, so that they are
+// surfaced instead of a generic load error
+func AttachFailuresError(err error, failures []AttachFailure) error {
+	if len(failures) == 0 {
+		return err
+	}
+	diagnostics := make([]string, 0, len(failures))
+	for _, f := range failures {
+		diagnostics = append(diagnostics, f.String())
+	}
+	return fmt.Errorf("%w: %s", err, strings.Join(diagnostics, "; "))
+}
+
+// DiagnoseAttachError returns the likely cause of the error of the probe and how to remediate it
+func DiagnoseAttachError(p *manager.Probe, err error) AttachFailure {
+	failure := AttachFailure{
+		Probe: p.EBPFFuncName,
+		Error: err.Error(),
+	}
+	if p.UID != "" {
+		failure.Probe += "_" + p.UID
+	}
+
+	kind := probeKind(p)
+	switch {
+	case errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES):
+		if mode := lockdownMode(); mode != "" && mode != "none" {
+			failure.Cause = fmt.Sprintf("the kernel is in %s lockdown mode, which prevents eBPF programs from reading kernel memory", mode)
+			failure.Remediation = "disable the kernel lockdown, which may require disabling secure boot"
+		} else {
+			failure.Cause = "system-probe lacks the privileges to load eBPF programs and open perf events"
+			failure.Remediation = "run system-probe as root, or with the CAP_SYS_ADMIN capability (CAP_BPF and CAP_PERFMON on kernels 5.8+), and make sure no seccomp or AppArmor profile blocks the bpf and perf_event_open syscalls"
+		}
+	case errors.Is(err, btf.ErrNotFound) || strings.Contains(strings.ToLower(err.Error()), "btf"):
+		failure.Cause = "the BTF information of the kernel is missing or does not match the running kernel"
+		failure.Remediation = "use a kernel built with CONFIG_DEBUG_INFO_BTF, or enable the runtime compiler with system_probe_config.enable_runtime_compiler"
+	case errors.Is(err, syscall.ENOENT) || errors.Is(err, os.ErrNotExist):
+		switch kind {
+		case "uprobe":
+			failure.Cause = fmt.Sprintf("the symbol %s was not found in %s, the binary may be stripped", p.HookFuncName, p.BinaryPath)
+			failure.Remediation = "make sure the monitored binary or library ships its symbol table"
+		case "tracepoint":
+			failure.Cause = "the tracepoint does not exist on this kernel, or tracefs is not mounted"
+			failure.Remediation = "mount tracefs on /sys/kernel/tracing, or debugfs on /sys/kernel/debug"
+		default:
+			failure.Cause = "the kernel function does not exist on this kernel, it may have been inlined or renamed"
+			failure.Remediation = "check that the function is listed in /proc/kallsyms; enabling the runtime compiler or upgrading the agent may select a probe compatible with this kernel"
+		}
+	case errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EEXIST):
+		failure.Cause = "the probe is already in use, possibly by another tracing tool or a previous system-probe instance"
+		failure.Remediation = "stop the other tracing tools and remove the leftover probes listed in /sys/kernel/debug/tracing/kprobe_events and uprobe_events"
+	case errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.ENOMEM):
+		failure.Cause = "a system resource limit was reached"
+		failure.Remediation = "raise the limits of open files (ulimit -n) and locked memory (ulimit -l) of system-probe"
+	default:
+		failure.Cause = "unknown"
+		failure.Remediation = "check the system-probe logs for the full error"
+	}
+	return failure
+}
+
+// probeKind returns the kind of the probe from the name of its eBPF function, which follows the `<kind>__<hook>`
+// convention
+func probeKind(p *manager.Probe) string {
+	if p.BinaryPath != "" {
+		return "uprobe"
+	}
+	kind, _, found := strings.Cut(p.EBPFFuncName, "__")
+	if !found {
+		return ""
+	}
+	switch kind {
+	case "kretprobe":
+		return "kprobe"
+	case "uretprobe":
+		return "uprobe"
+	case "fexit":
+		return "fentry"
+	}
+	return kind
+}
+
+// lockdownMode returns the active lockdown mode of the kernel, or an empty string if it is unknown
+func lockdownMode() string {
+	content, err := os.ReadFile(lockdownPath)
+	if err != nil {
+		return ""
+	}
+	start, end := strings.IndexByte(string(content), '['), strings.IndexByte(string(content), ']')
+	if start == -1 || end < start {
+		return ""
+	}
+	return string(content[start+1 : end])
+}
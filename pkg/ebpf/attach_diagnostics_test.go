@@ -0,0 +1,73 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnoseAttachError(t *testing.T) {
+	lockdownPath = filepath.Join(t.TempDir(), "lockdown")
+	t.Cleanup(func() { lockdownPath = "/sys/kernel/security/lockdown" })
+
+	kprobe := &manager.Probe{ProbeIdentificationPair: manager.ProbeIdentificationPair{EBPFFuncName: "kprobe__tcp_sendmsg", UID: "test"}}
+	tracepoint := &manager.Probe{ProbeIdentificationPair: manager.ProbeIdentificationPair{EBPFFuncName: "tracepoint__skb__kfree_skb"}}
+	uprobe := &manager.Probe{
+		ProbeIdentificationPair: manager.ProbeIdentificationPair{EBPFFuncName: "uprobe__SSL_read"},
+		HookFuncName:            "SSL_read",
+		BinaryPath:              "/usr/lib/libssl.so",
+	}
+
+	failure := DiagnoseAttachError(kprobe, fmt.Errorf("couldn't enable kprobe: %w", syscall.ENOENT))
+	assert.Equal(t, "kprobe__tcp_sendmsg_test", failure.Probe)
+	assert.Equal(t, "couldn't enable kprobe: no such file or directory", failure.Error)
+	assert.Contains(t, failure.Cause, "kernel function does not exist")
+
+	failure = DiagnoseAttachError(tracepoint, fmt.Errorf("couldn't activate tracepoint: %w", syscall.ENOENT))
+	assert.Contains(t, failure.Cause, "tracepoint does not exist")
+
+	failure = DiagnoseAttachError(uprobe, fmt.Errorf("couldn't find symbol: %w", os.ErrNotExist))
+	assert.Contains(t, failure.Cause, "SSL_read was not found in /usr/lib/libssl.so")
+
+	failure = DiagnoseAttachError(kprobe, fmt.Errorf("couldn't enable kprobe: %w", syscall.EPERM))
+	assert.Contains(t, failure.Cause, "lacks the privileges")
+
+	require.NoError(t, os.WriteFile(lockdownPath, []byte("none [integrity] confidentiality\n"), 0644))
+	failure = DiagnoseAttachError(kprobe, fmt.Errorf("couldn't enable kprobe: %w", syscall.EPERM))
+	assert.Contains(t, failure.Cause, "integrity lockdown mode")
+
+	failure = DiagnoseAttachError(kprobe, fmt.Errorf("loading BTF: invalid type"))
+	assert.Contains(t, failure.Cause, "BTF")
+
+	failure = DiagnoseAttachError(kprobe, fmt.Errorf("couldn't enable kprobe: %w", syscall.EBUSY))
+	assert.Contains(t, failure.Cause, "already in use")
+
+	failure = DiagnoseAttachError(kprobe, fmt.Errorf("unexpected"))
+	assert.Equal(t, "unknown", failure.Cause)
+}
+
+func TestRecordAttachFailures(t *testing.T) {
+	m := &manager.Manager{
+		Probes: []*manager.Probe{
+			{ProbeIdentificationPair: manager.ProbeIdentificationPair{EBPFFuncName: "kprobe__tcp_sendmsg"}},
+		},
+	}
+
+	// probes that were not started have no error
+	assert.Empty(t, RecordAttachFailures("test_module", m))
+	assert.NotContains(t, GetAttachFailures(), "test_module")
+}
@@ -22,7 +22,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-func GetBTF(userProvidedBtfPath, bpfDir string) (*btf.Spec, COREResult) {
+// GetBTF returns the BTF of the kernel, looked for in order in userProvidedBtfPath, in userProvidedBtfDir, in the
+// collection embedded in bpfDir, and finally in the default locations of the kernel
+func GetBTF(userProvidedBtfPath, userProvidedBtfDir, bpfDir string) (*btf.Spec, COREResult) {
 	var btfSpec *btf.Spec
 	var err error
 
@@ -34,6 +36,15 @@ func GetBTF(userProvidedBtfPath, bpfDir string) (*btf.Spec, COREResult) {
 		}
 	}
 
+	if userProvidedBtfDir != "" {
+		btfSpec, err = checkBTFDir(userProvidedBtfDir)
+		if err == nil {
+			log.Debugf("loaded BTF from directory %s", userProvidedBtfDir)
+			return btfSpec, successBTFDir
+		}
+		log.Debugf("couldn't find BTF in directory %s: %s", userProvidedBtfDir, err)
+	}
+
 	btfSpec, err = checkEmbeddedCollection(filepath.Join(bpfDir, "co-re/btf/"))
 	if err == nil {
 		log.Debugf("loaded BTF from embedded collection")
@@ -117,6 +128,46 @@ func checkEmbeddedCollection(collectionPath string) (*btf.Spec, error) {
 	return loadBTFFrom(filepath.Join(destinationFolder, btfFilename))
 }
 
+// checkBTFDir looks for the BTF of the kernel in btfDir, such as a copy of BTFHub
+func checkBTFDir(btfDir string) (*btf.Spec, error) {
+	btfSubdirectory, btfBasename, err := getBTFDirAndFilename()
+	if err != nil {
+		return nil, err
+	}
+	return findBTFInDir(btfDir, btfSubdirectory, btfBasename)
+}
+
+// findBTFInDir loads the BTF named after the kernel release btfBasename, either at the root of btfDir or in the
+// subdirectory of the platform, as in the embedded collection. The BTF can be archived as a .btf.tar.xz, which is
+// extracted to a temporary directory, as btfDir may not be writable.
+func findBTFInDir(btfDir, btfSubdirectory, btfBasename string) (*btf.Spec, error) {
+	btfFilename := btfBasename + ".btf"
+	btfTarballFilename := btfBasename + ".btf.tar.xz"
+
+	for _, dir := range []string{btfDir, filepath.Join(btfDir, btfSubdirectory)} {
+		btfPath := filepath.Join(dir, btfFilename)
+		if _, err := os.Stat(btfPath); err == nil {
+			return loadBTFFrom(btfPath)
+		}
+
+		btfTarball := filepath.Join(dir, btfTarballFilename)
+		if _, err := os.Stat(btfTarball); err != nil {
+			continue
+		}
+		tmpDir, err := os.MkdirTemp("", "btf")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		if err := archiver.NewTarXz().Unarchive(btfTarball, tmpDir); err != nil {
+			return nil, fmt.Errorf("could not extract %s: %w", btfTarball, err)
+		}
+		return loadBTFFrom(filepath.Join(tmpDir, btfFilename))
+	}
+	return nil, fmt.Errorf("no BTF found for kernel %s: %w", btfBasename, fs.ErrNotExist)
+}
+
 func loadBTFFrom(path string) (*btf.Spec, error) {
 	data, err := os.Open(path)
 	if err != nil {
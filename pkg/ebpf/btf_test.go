@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mholt/archiver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKernelRelease = "4.18.0-147.el8.x86_64"
+
+func TestFindBTFInDir(t *testing.T) {
+	vmlinux, err := os.ReadFile("/sys/kernel/btf/vmlinux")
+	if err != nil {
+		t.Skip("the kernel doesn't expose its BTF")
+	}
+
+	t.Run("root", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, testKernelRelease+".btf"), vmlinux, 0644))
+
+		spec, err := findBTFInDir(dir, "redhat", testKernelRelease)
+		require.NoError(t, err)
+		assert.NotNil(t, spec)
+	})
+
+	t.Run("platform archive", func(t *testing.T) {
+		dir := t.TempDir()
+		btfPath := filepath.Join(t.TempDir(), testKernelRelease+".btf")
+		require.NoError(t, os.WriteFile(btfPath, vmlinux, 0644))
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "redhat"), 0755))
+		require.NoError(t, archiver.NewTarXz().Archive([]string{btfPath}, filepath.Join(dir, "redhat", testKernelRelease+".btf.tar.xz")))
+
+		spec, err := findBTFInDir(dir, "redhat", testKernelRelease)
+		require.NoError(t, err)
+		assert.NotNil(t, spec)
+
+		// the archive is extracted out of the directory
+		_, err = os.Stat(filepath.Join(dir, "redhat", testKernelRelease+".btf"))
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+}
+
+func TestFindBTFInDirNotFound(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5.4.0-1.el8.x86_64.btf"), nil, 0644))
+
+	_, err := findBTFInDir(dir, "redhat", testKernelRelease)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
@@ -61,10 +61,8 @@ func (a *asset) Compile(config *ebpf.Config, additionalFlags []string, client st
 		YumReposDir:     config.YumReposDir,
 		ZypperReposDir:  config.ZypperReposDir,
 	}
-	kernelHeaders := kernel.GetKernelHeaders(opts, client)
-	if len(kernelHeaders) == 0 {
-		a.tm.compilationResult = headerFetchErr
-		return nil, fmt.Errorf("unable to find kernel headers")
+	getKernelHeaders := func() []string {
+		return kernel.GetKernelHeaders(opts, client)
 	}
 
 	outputDir := config.RuntimeCompilerOutputDir
@@ -75,7 +73,7 @@ func (a *asset) Compile(config *ebpf.Config, additionalFlags []string, client st
 		return nil, fmt.Errorf("error reading input file: %s", err)
 	}
 
-	out, result, err := compileToObjectFile(inputReader, outputDir, a.filename, a.hash, additionalFlags, kernelHeaders)
+	out, result, err := compileToObjectFile(inputReader, outputDir, a.filename, a.hash, additionalFlags, getKernelHeaders, newOutputCache(config))
 	a.tm.compilationResult = result
 
 	return out, err
@@ -0,0 +1,251 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package runtime
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// cacheFetchTimeout bounds the download of a compiled output from the cache endpoint
+	cacheFetchTimeout = 30 * time.Second
+	// maxCachedOutputSize bounds the size of a compiled output downloaded from the cache endpoint
+	maxCachedOutputSize = 64 * 1024 * 1024
+)
+
+// errCachedOutputTooLarge is returned when a cached output is larger than maxCachedOutputSize
+var errCachedOutputTooLarge = errors.New("cached output is too large")
+
+// outputCache is where the compiled outputs are looked up before compiling them, so that the hosts running the same
+// kernel with the same configuration compile each program once. The name of a compiled output is made of the hashes
+// of the kernel, of the input and of the flags, so that it is the same on all these hosts.
+//
+// As the shared directory and the endpoint may be written to by other hosts, a cached output is only used when its
+// sha256 is pinned in the manifest, a local file which must be owned and only writable by root.
+type outputCache struct {
+	// dir is a directory shared by the hosts, such as a network volume, the compiled outputs are copied to
+	dir string
+	// url is the base URL of an HTTPS endpoint serving the compiled outputs by name
+	url string
+	// manifest is the path of the file pinning the sha256 of the cached outputs, in the format of sha256sum
+	manifest string
+	client   *http.Client
+}
+
+func newOutputCache(config *ebpf.Config) *outputCache {
+	c := &outputCache{
+		dir:      config.RuntimeCompilerCacheDir,
+		url:      strings.TrimSuffix(config.RuntimeCompilerCacheURL, "/"),
+		manifest: config.RuntimeCompilerCacheManifest,
+		client:   &http.Client{Timeout: cacheFetchTimeout},
+	}
+
+	if c.url != "" {
+		if u, err := url.Parse(c.url); err != nil || u.Scheme != "https" {
+			log.Warnf("ignoring runtime_compiler_cache_url %s: it must be an https URL", c.url)
+			c.url = ""
+		}
+	}
+	return c
+}
+
+// fetch copies the compiled output of the given name from the shared directory or the endpoint to outputFile, and
+// returns whether either had it. The outputs which are not pinned in the manifest are never fetched.
+func (c *outputCache) fetch(name, outputFile string) (bool, error) {
+	if c.dir == "" && c.url == "" {
+		return false, nil
+	}
+	if c.manifest == "" {
+		return false, fmt.Errorf("runtime_compiler_cache_manifest must be set to use the runtime compilation cache")
+	}
+
+	hashes, err := readCacheManifest(c.manifest)
+	if err != nil {
+		return false, err
+	}
+	expected, ok := hashes[name]
+	if !ok {
+		log.Debugf("%s is not pinned in the runtime compilation cache manifest", name)
+		return false, nil
+	}
+
+	if c.dir != "" {
+		found, err := c.fetchFromDir(name, outputFile, expected)
+		if found || err != nil {
+			return found, err
+		}
+	}
+	if c.url != "" {
+		return c.fetchFromURL(name, outputFile, expected)
+	}
+	return false, nil
+}
+
+func (c *outputCache) fetchFromDir(name, outputFile, expected string) (bool, error) {
+	f, err := os.Open(filepath.Join(c.dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	if err := writeVerifiedFile(outputFile, f, -1, expected); err != nil {
+		return false, fmt.Errorf("invalid cached output %s: %w", name, err)
+	}
+	return true, nil
+}
+
+func (c *outputCache) fetchFromURL(name, outputFile, expected string) (bool, error) {
+	resp, err := c.client.Get(c.url + "/" + name)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("unexpected status %s fetching %s", resp.Status, name)
+	case resp.ContentLength > maxCachedOutputSize:
+		return false, fmt.Errorf("%s: %w: %d bytes", name, errCachedOutputTooLarge, resp.ContentLength)
+	}
+
+	if err := writeVerifiedFile(outputFile, resp.Body, resp.ContentLength, expected); err != nil {
+		return false, fmt.Errorf("invalid cached output %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// readCacheManifest returns the sha256 of the cached outputs by name, from a file in the format of sha256sum
+func readCacheManifest(path string) (map[string]string, error) {
+	if err := bytecode.VerifyAssetPermissions(path); err != nil {
+		return nil, fmt.Errorf("invalid runtime compilation cache manifest: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid runtime compilation cache manifest %s: line %d", path, line)
+		}
+		if _, err := hex.DecodeString(fields[0]); err != nil {
+			return nil, fmt.Errorf("invalid runtime compilation cache manifest %s: line %d: %w", path, line, err)
+		}
+		// sha256sum prefixes the names of the files read in binary mode with *
+		hashes[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return hashes, scanner.Err()
+}
+
+// writeVerifiedFile writes the content of r to path once checked that it is not larger than maxCachedOutputSize,
+// that it is size bytes long when size is known, and that its sha256 is the expected one
+func writeVerifiedFile(path string, r io.Reader, size int64, expected string) error {
+	h := sha256.New()
+	// one more byte than the limit is read, to tell the oversized outputs from the ones of the maximum size
+	lr := &io.LimitedReader{R: io.TeeReader(r, h), N: maxCachedOutputSize + 1}
+	return writeFileAtomicCheck(path, lr, func(written int64) error {
+		if written > maxCachedOutputSize {
+			return errCachedOutputTooLarge
+		}
+		if size >= 0 && written != size {
+			return fmt.Errorf("truncated output: got %d bytes out of %d", written, size)
+		}
+		return checkHash(h, expected)
+	})
+}
+
+func checkHash(h hash.Hash, expected string) error {
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return fmt.Errorf("sha256 mismatch: got %s, expected %s", actual, expected)
+	}
+	return nil
+}
+
+// store copies the compiled output to the shared directory, so that the other hosts don't have to compile it
+func (c *outputCache) store(outputFile string) {
+	if c.dir == "" {
+		return
+	}
+
+	f, err := os.Open(outputFile)
+	if err != nil {
+		log.Debugf("unable to store %s in the runtime compilation cache: %s", outputFile, err)
+		return
+	}
+	defer f.Close()
+
+	if err := writeFileAtomic(filepath.Join(c.dir, filepath.Base(outputFile)), f); err != nil {
+		log.Debugf("unable to store %s in the runtime compilation cache: %s", outputFile, err)
+	}
+}
+
+// writeFileAtomic writes the content of r to path through a temporary file, so that a partially written file is
+// never read, be it by another host sharing the directory or after a crash
+func writeFileAtomic(path string, r io.Reader) error {
+	return writeFileAtomicCheck(path, r, nil)
+}
+
+// writeFileAtomicCheck is writeFileAtomic, with check called with the number of bytes written before the file is
+// renamed to path. The file is discarded when check fails.
+func writeFileAtomicCheck(path string, r io.Reader, check func(written int64) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if check != nil {
+		if err := check(written); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
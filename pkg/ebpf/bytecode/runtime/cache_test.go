@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package runtime
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
+)
+
+const cachedOutputName = "tracer-hash.o"
+
+// writeManifest writes a manifest pinning the sha256 of the given contents by name
+func writeManifest(t *testing.T, contents map[string]string) string {
+	var b strings.Builder
+	for name, content := range contents {
+		sum := sha256.Sum256([]byte(content))
+		fmt.Fprintf(&b, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+
+	path := filepath.Join(t.TempDir(), "manifest")
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0644))
+	return path
+}
+
+func TestOutputCacheDir(t *testing.T) {
+	cacheDir, outputDir := t.TempDir(), t.TempDir()
+	manifest := writeManifest(t, map[string]string{cachedOutputName: "compiled", "tampered.o": "compiled"})
+	cache := newOutputCache(&ebpf.Config{RuntimeCompilerCacheDir: cacheDir, RuntimeCompilerCacheManifest: manifest})
+	outputFile := filepath.Join(outputDir, cachedOutputName)
+
+	found, err := cache.fetch(cachedOutputName, outputFile)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, os.WriteFile(outputFile, []byte("compiled"), 0644))
+	cache.store(outputFile)
+	require.NoError(t, os.Remove(outputFile))
+
+	found, err = cache.fetch(cachedOutputName, outputFile)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "compiled", string(content))
+
+	info, err := os.Stat(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+	t.Run("tampered output", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "tampered.o"), []byte("malicious"), 0644))
+		_, err := cache.fetch("tampered.o", filepath.Join(outputDir, "tampered.o"))
+		assert.Error(t, err)
+		_, err = os.Stat(filepath.Join(outputDir, "tampered.o"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("output not pinned", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(cacheDir, "unpinned.o"), []byte("compiled"), 0644))
+		found, err := cache.fetch("unpinned.o", filepath.Join(outputDir, "unpinned.o"))
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("no manifest", func(t *testing.T) {
+		cache := newOutputCache(&ebpf.Config{RuntimeCompilerCacheDir: cacheDir})
+		found, err := cache.fetch(cachedOutputName, filepath.Join(t.TempDir(), cachedOutputName))
+		assert.Error(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("manifest writable by others", func(t *testing.T) {
+		require.NoError(t, os.Chmod(manifest, 0666))
+		defer os.Chmod(manifest, 0644)
+		_, err := cache.fetch(cachedOutputName, filepath.Join(t.TempDir(), cachedOutputName))
+		assert.Error(t, err)
+	})
+}
+
+func TestOutputCacheURL(t *testing.T) {
+	oversized := strings.Repeat("a", maxCachedOutputSize+1)
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cache/" + cachedOutputName:
+			_, _ = w.Write([]byte("compiled"))
+		case "/cache/broken.o":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/cache/truncated.o":
+			w.Header().Set("Content-Length", strconv.Itoa(len("compiled")+10))
+			_, _ = w.Write([]byte("compiled"))
+		case "/cache/oversized.o":
+			// no Content-Length is sent, so that the size is only known once read
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte(oversized))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	outputDir := t.TempDir()
+	manifest := writeManifest(t, map[string]string{
+		cachedOutputName: "compiled",
+		"missing.o":      "compiled",
+		"broken.o":       "compiled",
+		"truncated.o":    "compiled",
+		"oversized.o":    oversized,
+	})
+	cache := newOutputCache(&ebpf.Config{RuntimeCompilerCacheURL: server.URL + "/cache/", RuntimeCompilerCacheManifest: manifest})
+	cache.client = server.Client()
+
+	found, err := cache.fetch("missing.o", filepath.Join(outputDir, "missing.o"))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	for _, name := range []string{"broken.o", "truncated.o", "oversized.o"} {
+		_, err = cache.fetch(name, filepath.Join(outputDir, name))
+		assert.Error(t, err, name)
+		_, err = os.Stat(filepath.Join(outputDir, name))
+		assert.True(t, os.IsNotExist(err), name)
+	}
+
+	outputFile := filepath.Join(outputDir, cachedOutputName)
+	found, err = cache.fetch(cachedOutputName, outputFile)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	content, err := os.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "compiled", string(content))
+
+	// no temporary file is left behind
+	entries, err := os.ReadDir(outputDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	t.Run("plain http", func(t *testing.T) {
+		cache := newOutputCache(&ebpf.Config{RuntimeCompilerCacheURL: "http://cache.example/", RuntimeCompilerCacheManifest: manifest})
+		assert.Empty(t, cache.url)
+	})
+}
@@ -57,10 +57,8 @@ func (a *generatedAsset) Compile(config *ebpf.Config, inputCode string, addition
 		YumReposDir:     config.YumReposDir,
 		ZypperReposDir:  config.ZypperReposDir,
 	}
-	kernelHeaders := kernel.GetKernelHeaders(opts, client)
-	if len(kernelHeaders) == 0 {
-		a.tm.compilationResult = headerFetchErr
-		return nil, fmt.Errorf("unable to find kernel headers")
+	getKernelHeaders := func() []string {
+		return kernel.GetKernelHeaders(opts, client)
 	}
 
 	outputDir := config.RuntimeCompilerOutputDir
@@ -72,7 +70,7 @@ func (a *generatedAsset) Compile(config *ebpf.Config, inputCode string, addition
 		return nil, fmt.Errorf("error hashing input: %w", err)
 	}
 
-	out, result, err := compileToObjectFile(inputReader, outputDir, a.filename, inputHash, additionalFlags, kernelHeaders)
+	out, result, err := compileToObjectFile(inputReader, outputDir, a.filename, inputHash, additionalFlags, getKernelHeaders, newOutputCache(config))
 	a.tm.compilationResult = result
 
 	return out, err
@@ -54,8 +54,10 @@ var defaultFlags = []string{
 	"-nostdinc",
 }
 
-// compileToObjectFile compiles the input ebpf program & returns the compiled output
-func compileToObjectFile(in io.Reader, outputDir, filename, inHash string, additionalFlags, kernelHeaders []string) (CompiledOutput, CompilationResult, error) {
+// compileToObjectFile compiles the input ebpf program & returns the compiled output. The output of a previous
+// compilation is used when found in the output directory or in the cache, in which case the kernel headers aren't
+// needed.
+func compileToObjectFile(in io.Reader, outputDir, filename, inHash string, additionalFlags []string, getKernelHeaders func() []string, cache *outputCache) (CompiledOutput, CompilationResult, error) {
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return nil, outputDirErr, fmt.Errorf("unable to create compiler output directory %s: %w", outputDir, err)
 	}
@@ -73,32 +75,19 @@ func compileToObjectFile(in io.Reader, outputDir, filename, inHash string, addit
 			return nil, outputFileErr, fmt.Errorf("error stat-ing output file %s: %w", outputFile, err)
 		}
 
-		kv, err := kernel.HostVersion()
+		found, err := cache.fetch(filepath.Base(outputFile), outputFile)
 		if err != nil {
-			return nil, kernelVersionErr, fmt.Errorf("unable to get kernel version: %w", err)
+			log.Warnf("unable to fetch runtime version of %s from the cache: %s", filename, err)
 		}
-		_, family, _, err := host.PlatformInformation()
-		if err != nil {
-			return nil, kernelVersionErr, fmt.Errorf("unable to get kernel family: %w", err)
-		}
-
-		// RHEL platforms back-ported the __BPF_FUNC_MAPPER macro, so we can always use the dynamic method there
-		if kv >= kernel.VersionCode(4, 10, 0) || family == "rhel" {
-			var helperPath string
-			helperPath, err = includeHelperAvailability(kernelHeaders)
-			if err != nil {
-				return nil, compilationErr, fmt.Errorf("error getting helper availability: %w", err)
+		if found {
+			log.Infof("found runtime version of %s in the cache", filename)
+			result = compiledOutputFound
+		} else {
+			if result, err = compile(in, outputFile, filename, flags, getKernelHeaders()); err != nil {
+				return nil, result, err
 			}
-			defer os.Remove(helperPath)
-			flags = append(flags, fmt.Sprintf("-include%s", helperPath))
+			cache.store(outputFile)
 		}
-
-		if err := compiler.CompileToObjectFile(in, outputFile, flags, kernelHeaders); err != nil {
-			return nil, compilationErr, fmt.Errorf("failed to compile runtime version of %s: %s", filename, err)
-		}
-
-		log.Infof("successfully compiled runtime version of %s", filename)
-		result = compilationSuccess
 	} else {
 		log.Infof("found previously compiled runtime version of %s", filename)
 		result = compiledOutputFound
@@ -116,6 +105,45 @@ func compileToObjectFile(in io.Reader, outputDir, filename, inHash string, addit
 	return out, result, nil
 }
 
+// compile compiles the input ebpf program to outputFile, which is written once the compilation succeeded
+func compile(in io.Reader, outputFile, filename string, flags, kernelHeaders []string) (CompilationResult, error) {
+	if len(kernelHeaders) == 0 {
+		return headerFetchErr, fmt.Errorf("unable to find kernel headers")
+	}
+
+	kv, err := kernel.HostVersion()
+	if err != nil {
+		return kernelVersionErr, fmt.Errorf("unable to get kernel version: %w", err)
+	}
+	_, family, _, err := host.PlatformInformation()
+	if err != nil {
+		return kernelVersionErr, fmt.Errorf("unable to get kernel family: %w", err)
+	}
+
+	// RHEL platforms back-ported the __BPF_FUNC_MAPPER macro, so we can always use the dynamic method there
+	if kv >= kernel.VersionCode(4, 10, 0) || family == "rhel" {
+		var helperPath string
+		helperPath, err = includeHelperAvailability(kernelHeaders)
+		if err != nil {
+			return compilationErr, fmt.Errorf("error getting helper availability: %w", err)
+		}
+		defer os.Remove(helperPath)
+		flags = append(flags, fmt.Sprintf("-include%s", helperPath))
+	}
+
+	tmpOutputFile := outputFile + ".tmp"
+	defer os.Remove(tmpOutputFile)
+	if err := compiler.CompileToObjectFile(in, tmpOutputFile, flags, kernelHeaders); err != nil {
+		return compilationErr, fmt.Errorf("failed to compile runtime version of %s: %s", filename, err)
+	}
+	if err := os.Rename(tmpOutputFile, outputFile); err != nil {
+		return outputFileErr, fmt.Errorf("unable to write runtime version of %s: %w", filename, err)
+	}
+
+	log.Infof("successfully compiled runtime version of %s", filename)
+	return compilationSuccess, nil
+}
+
 func computeFlagsAndHash(additionalFlags []string) ([]string, string) {
 	flags := make([]string, 0, len(defaultFlags)+len(additionalFlags)+1)
 	flags = append(flags, fmt.Sprintf("-D__TARGET_ARCH_%s", kernel.Arch()))
@@ -133,7 +161,8 @@ func computeFlagsAndHash(additionalFlags []string) ([]string, string) {
 
 func getOutputFilePath(outputDir, filename, inputHash, flagHash string) (string, error) {
 	// filename includes uname hash, input file hash, and cflags hash
-	// this ensures we re-compile when either of the input changes
+	// this ensures we re-compile when either of the input changes, and that the hosts running the same kernel with
+	// the same configuration share the name of the compiled output in the cache
 	baseName := strings.TrimSuffix(filename, filepath.Ext(filename))
 
 	unameHash, err := getUnameHash()
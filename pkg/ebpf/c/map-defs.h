@@ -38,6 +38,16 @@
 #define BPF_LRU_MAP_PINNED(name, key_type, value_type, max_entries) \
     BPF_MAP(name, BPF_MAP_TYPE_LRU_HASH, key_type, value_type, max_entries, 1)
 
+// The LPM tries must be created without preallocation
+#define BPF_LPM_TRIE_MAP(_name, _key_type, _value_type, _max_entries) \
+    struct {                                                        \
+        __uint(type, BPF_MAP_TYPE_LPM_TRIE);                        \
+        __uint(max_entries, _max_entries);                          \
+        __uint(map_flags, BPF_F_NO_PREALLOC);                       \
+        __type(key, _key_type);                                     \
+        __type(value, _value_type);                                 \
+    } _name SEC(".maps");
+
 #define BPF_PERCPU_HASH_MAP(name, key_type, value_type, max_entries) \
     BPF_MAP(name, BPF_MAP_TYPE_PERCPU_HASH, key_type, value_type, max_entries, 0)
 
@@ -27,6 +27,26 @@ func main() {
 	convertHTTPTransactionRegex := regexp.MustCompile(`(Request_fragment)(\s+)\[(\d+)\]u?int8`)
 	b = convertHTTPTransactionRegex.ReplaceAll(b, []byte("$1$2[$3]byte"))
 
+	// Convert [80]int8 to [80]byte in kafka_transaction_t members to simplify
+	// conversion to string; see golang.org/issue/20753
+	convertKafkaTransactionRegex := regexp.MustCompile(`(Topic_name)(\s+)\[(\d+)\]u?int8`)
+	b = convertKafkaTransactionRegex.ReplaceAll(b, []byte("$1$2[$3]byte"))
+
+	// Convert [32]int8 to [32]byte in postgres_transaction_t members to simplify
+	// conversion to string; see golang.org/issue/20753
+	convertPostgresTransactionRegex := regexp.MustCompile(`(Response_tail)(\s+)\[(\d+)\]u?int8`)
+	b = convertPostgresTransactionRegex.ReplaceAll(b, []byte("$1$2[$3]byte"))
+
+	// Convert [16]int8 to [16]byte in mysql_transaction_t members to simplify
+	// conversion to string; see golang.org/issue/20753
+	convertMySQLTransactionRegex := regexp.MustCompile(`(Response_fragment)(\s+)\[(\d+)\]u?int8`)
+	b = convertMySQLTransactionRegex.ReplaceAll(b, []byte("$1$2[$3]byte"))
+
+	// Convert [128]int8 to [128]byte in amqp_transaction_t members to simplify
+	// conversion to string; see golang.org/issue/20753
+	convertAMQPTransactionRegex := regexp.MustCompile(`\b(Fragment)(\s+)\[(\d+)\]u?int8`)
+	b = convertAMQPTransactionRegex.ReplaceAll(b, []byte("$1$2[$3]byte"))
+
 	// Convert [120]int8 to [120]byte in lib_path_t members to simplify
 	// conversion to string; see golang.org/issue/20753
 	convertLibraryRegex := regexp.MustCompile(`(Buf)(\s+)\[(\d+)\]u?int8`)
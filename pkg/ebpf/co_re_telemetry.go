@@ -23,6 +23,8 @@ const (
 	AssetReadError
 	VerifierError
 	LoaderError
+	// successBTFDir comes last as the results are reported by value
+	successBTFDir
 )
 
 // coreTelemetryByAsset is a global object which is responsible for storing CO-RE telemetry for all ebpf assets
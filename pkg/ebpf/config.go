@@ -42,6 +42,10 @@ type Config struct {
 	// BTFPath is the path to BTF data for the current kernel
 	BTFPath string
 
+	// BTFDir is a directory holding the BTF data of kernels, named after their release such as
+	// 4.18.0-147.el8.x86_64.btf, for the kernels not exposing /sys/kernel/btf/vmlinux
+	BTFDir string
+
 	// EnableRuntimeCompiler enables the use of the embedded compiler to build eBPF programs on-host
 	EnableRuntimeCompiler bool
 
@@ -57,6 +61,18 @@ type Config struct {
 	// RuntimeCompilerOutputDir is the directory where the runtime compiler will store compiled programs
 	RuntimeCompilerOutputDir string
 
+	// RuntimeCompilerCacheDir is a directory shared by the hosts, such as a network volume, where the compiled programs
+	// are looked up before compiling them, and copied to once compiled
+	RuntimeCompilerCacheDir string
+
+	// RuntimeCompilerCacheURL is the base URL of an HTTPS endpoint serving the compiled programs by name, where they
+	// are looked up before compiling them
+	RuntimeCompilerCacheURL string
+
+	// RuntimeCompilerCacheManifest is a local file pinning the sha256 of the compiled programs which can be used from
+	// RuntimeCompilerCacheDir or RuntimeCompilerCacheURL, in the format of sha256sum
+	RuntimeCompilerCacheManifest string
+
 	// AptConfigDir is the path to the apt config directory
 	AptConfigDir string
 
@@ -74,6 +90,21 @@ type Config struct {
 
 	// AttachKprobesWithKprobeEventsABI uses the kprobe_events ABI to attach kprobes rather than the newer perf ABI.
 	AttachKprobesWithKprobeEventsABI bool
+
+	// PerfBufferMaxPages is the maximum number of pages the per-CPU ring buffers of a perf map can be grown to.
+	PerfBufferMaxPages int
+
+	// PerfBufferResizeLostThreshold is the number of samples a perf buffer can lose before it is grown the next time it is loaded.
+	// A value of 0 disables the resizing of the perf buffers.
+	PerfBufferResizeLostThreshold int
+
+	// PerfBufferAdaptiveSizing reloads the programs whose perf buffers keep losing samples, so that their buffers are
+	// grown without waiting for the next restart.
+	PerfBufferAdaptiveSizing bool
+
+	// EnableRingBuffers sends the events of the eBPF programs to user space through BPF ring buffers rather than perf
+	// buffers, when the kernel supports them.
+	EnableRingBuffers bool
 }
 
 func key(pieces ...string) string {
@@ -93,9 +124,13 @@ func NewConfig() *Config {
 
 		EnableCORE: cfg.GetBool(key(spNS, "enable_co_re")),
 		BTFPath:    cfg.GetString(key(spNS, "btf_path")),
+		BTFDir:     cfg.GetString(key(spNS, "btf_dir")),
 
 		EnableRuntimeCompiler:        cfg.GetBool(key(spNS, "enable_runtime_compiler")),
 		RuntimeCompilerOutputDir:     cfg.GetString(key(spNS, "runtime_compiler_output_dir")),
+		RuntimeCompilerCacheDir:      cfg.GetString(key(spNS, "runtime_compiler_cache_dir")),
+		RuntimeCompilerCacheURL:      cfg.GetString(key(spNS, "runtime_compiler_cache_url")),
+		RuntimeCompilerCacheManifest: cfg.GetString(key(spNS, "runtime_compiler_cache_manifest")),
 		EnableKernelHeaderDownload:   cfg.GetBool(key(spNS, "enable_kernel_header_download")),
 		KernelHeadersDirs:            cfg.GetStringSlice(key(spNS, "kernel_header_dirs")),
 		KernelHeadersDownloadDir:     cfg.GetString(key(spNS, "kernel_header_download_dir")),
@@ -106,5 +141,10 @@ func NewConfig() *Config {
 		AllowRuntimeCompiledFallback: cfg.GetBool(key(spNS, "allow_runtime_compiled_fallback")),
 
 		AttachKprobesWithKprobeEventsABI: cfg.GetBool(key(spNS, "attach_kprobes_with_kprobe_events_abi")),
+
+		PerfBufferMaxPages:            cfg.GetInt(key(spNS, "perf_buffer_max_pages")),
+		PerfBufferResizeLostThreshold: cfg.GetInt(key(spNS, "perf_buffer_resize_lost_threshold")),
+		PerfBufferAdaptiveSizing:      cfg.GetBool(key(spNS, "perf_buffer_adaptive_sizing")),
+		EnableRingBuffers:             cfg.GetBool(key(spNS, "enable_ring_buffers")),
 	}
 }
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"runtime"
+	"sync"
+
+	bpflib "github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/features"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	fentrySupportOnce sync.Once
+	fentrySupported   bool
+)
+
+// IsFentrySupported returns true if the host supports attaching fentry/fexit programs to the functions of the
+// kernel. This requires the BTF of the running kernel, the tracing program type (5.5+), and BPF trampolines, which
+// are only available on arm64 since 6.0.
+func IsFentrySupported() bool {
+	fentrySupportOnce.Do(func() {
+		fentrySupported = isFentrySupported()
+	})
+	return fentrySupported
+}
+
+func isFentrySupported() bool {
+	kv, err := kernel.HostVersion()
+	if err != nil {
+		log.Warnf("could not determine the current kernel version, fentry is not supported: %s", err)
+		return false
+	}
+
+	minVersion := kernel.VersionCode(5, 5, 0)
+	if runtime.GOARCH == "arm64" {
+		minVersion = kernel.VersionCode(6, 0, 0)
+	}
+	if kv < minVersion {
+		log.Debugf("fentry is not supported on kernel %s, %s or newer is required", kv, minVersion)
+		return false
+	}
+
+	// the feature probe loads a fentry program, and therefore also fails if the BTF of the kernel is not available
+	if err := features.HaveProgramType(bpflib.Tracing); err != nil {
+		log.Debugf("fentry is not supported: %s", err)
+		return false
+	}
+	return true
+}
@@ -59,6 +59,9 @@ func (c *PerfHandler) LostHandler(CPU int, lostCount uint64, perfMap *manager.Pe
 	if c.closed {
 		return
 	}
+	if perfMap != nil {
+		recordPerfBufferLoss(perfMap.Name, lostCount)
+	}
 	c.LostChannel <- lostCount
 }
 
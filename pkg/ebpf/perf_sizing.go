@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"os"
+	"sync"
+
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// perfBufferSustainedLossChecks is the number of consecutive checks a perf buffer must lose samples in to be grown
+// by the adaptive sizing
+const perfBufferSustainedLossChecks = 3
+
+var perfBufferTelemetry = struct {
+	lost    telemetry.Counter
+	size    telemetry.Gauge
+	resizes telemetry.Counter
+}{
+	lost:    telemetry.NewCounter("ebpf__perf_buffer", "lost_samples", []string{"map_name"}, "Samples lost by the perf buffer of a perf map."),
+	size:    telemetry.NewGauge("ebpf__perf_buffer", "size_bytes", []string{"map_name"}, "Size in bytes of the per-CPU ring buffers of a perf map."),
+	resizes: telemetry.NewCounter("ebpf__perf_buffer", "resizes", []string{"map_name"}, "Times the ring buffers of a perf map were grown."),
+}
+
+// PerfBufferStats is the size of the per-CPU ring buffers of a perf map, and the samples it lost
+type PerfBufferStats struct {
+	// Size is the size in bytes of the per-CPU ring buffers
+	Size int
+	// Lost is the number of samples lost since the buffer was last loaded
+	Lost uint64
+	// TotalLost is the number of samples lost since the buffer was first loaded
+	TotalLost uint64
+	// Resizes is the number of times the buffer was grown
+	Resizes int
+}
+
+type perfBufferState struct {
+	size      int
+	lost      *atomic.Uint64
+	totalLost uint64
+	resizes   int
+
+	// checkedLost is the loss of the buffer at the last check of SustainedPerfBufferLoss, and lossyChecks the number
+	// of consecutive checks it lost samples in
+	checkedLost uint64
+	lossyChecks int
+}
+
+// perfBuffers holds the state of the perf buffers by map name. It outlives the managers loading the perf maps, so
+// the loss of a buffer is taken into account when its programs are loaded again, such as when a module is restarted.
+var perfBuffers = struct {
+	sync.Mutex
+	states map[string]*perfBufferState
+}{
+	states: make(map[string]*perfBufferState),
+}
+
+// PerfBufferSize returns the size of the per-CPU ring buffers to use for the given perf map. The default size is
+// used the first time the map is loaded. Afterwards, if the buffer lost more samples than the configured threshold
+// since it was last loaded, its size is doubled, up to the configured maximum size.
+func PerfBufferSize(cfg *Config, mapName string, defaultSize int) int {
+	perfBuffers.Lock()
+	defer perfBuffers.Unlock()
+
+	state, ok := perfBuffers.states[mapName]
+	if !ok {
+		perfBuffers.states[mapName] = &perfBufferState{size: defaultSize, lost: atomic.NewUint64(0)}
+		perfBufferTelemetry.size.Set(float64(defaultSize), mapName)
+		return defaultSize
+	}
+
+	lost := state.lost.Swap(0)
+	state.totalLost += lost
+	state.checkedLost = 0
+	state.lossyChecks = 0
+
+	maxSize := cfg.PerfBufferMaxPages * os.Getpagesize()
+	if cfg.PerfBufferResizeLostThreshold <= 0 || lost < uint64(cfg.PerfBufferResizeLostThreshold) || state.size >= maxSize {
+		return state.size
+	}
+
+	size := state.size * 2
+	if size > maxSize {
+		size = maxSize
+	}
+	log.Infof("growing the ring buffers of perf map %s from %d to %d bytes, as %d samples were lost", mapName, state.size, size, lost)
+	state.size = size
+	state.resizes++
+	perfBufferTelemetry.size.Set(float64(size), mapName)
+	perfBufferTelemetry.resizes.Inc(mapName)
+	return size
+}
+
+// SustainedPerfBufferLoss returns the perf maps whose buffers lost more samples than the configured threshold
+// between each of the last calls, and can still be grown. It is meant to be called periodically by the adaptive
+// sizing, the returned buffers being grown the next time they are loaded.
+func SustainedPerfBufferLoss(cfg *Config) []string {
+	perfBuffers.Lock()
+	defer perfBuffers.Unlock()
+
+	if cfg.PerfBufferResizeLostThreshold <= 0 {
+		return nil
+	}
+
+	var lossy []string
+	maxSize := cfg.PerfBufferMaxPages * os.Getpagesize()
+	for name, state := range perfBuffers.states {
+		lost := state.lost.Load()
+		if lost-state.checkedLost >= uint64(cfg.PerfBufferResizeLostThreshold) {
+			state.lossyChecks++
+		} else {
+			state.lossyChecks = 0
+		}
+		state.checkedLost = lost
+
+		if state.lossyChecks >= perfBufferSustainedLossChecks && state.size < maxSize {
+			lossy = append(lossy, name)
+			state.lossyChecks = 0
+		}
+	}
+	return lossy
+}
+
+// GetPerfBufferStats returns the stats of the perf buffers, by map name
+func GetPerfBufferStats() map[string]PerfBufferStats {
+	perfBuffers.Lock()
+	defer perfBuffers.Unlock()
+
+	stats := make(map[string]PerfBufferStats, len(perfBuffers.states))
+	for name, state := range perfBuffers.states {
+		lost := state.lost.Load()
+		stats[name] = PerfBufferStats{
+			Size:      state.size,
+			Lost:      lost,
+			TotalLost: state.totalLost + lost,
+			Resizes:   state.resizes,
+		}
+	}
+	return stats
+}
+
+func recordPerfBufferLoss(mapName string, lostCount uint64) {
+	perfBuffers.Lock()
+	state, ok := perfBuffers.states[mapName]
+	perfBuffers.Unlock()
+
+	if ok {
+		state.lost.Add(lostCount)
+	}
+	perfBufferTelemetry.lost.Add(float64(lostCount), mapName)
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerfBufferSize(t *testing.T) {
+	pageSize := os.Getpagesize()
+	cfg := &Config{
+		PerfBufferMaxPages:            20,
+		PerfBufferResizeLostThreshold: 10,
+	}
+
+	t.Run("grows on loss", func(t *testing.T) {
+		const mapName = "test_grows_on_loss"
+		assert.Equal(t, 8*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+
+		recordPerfBufferLoss(mapName, 5)
+		assert.Equal(t, 8*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+
+		recordPerfBufferLoss(mapName, 10)
+		assert.Equal(t, 16*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+
+		// the loss is reset on every load
+		assert.Equal(t, 16*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+
+		recordPerfBufferLoss(mapName, 10)
+		assert.Equal(t, 20*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+
+		recordPerfBufferLoss(mapName, 10)
+		assert.Equal(t, 20*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+
+		stats := GetPerfBufferStats()[mapName]
+		assert.Equal(t, 20*pageSize, stats.Size)
+		assert.Equal(t, uint64(0), stats.Lost)
+		assert.Equal(t, uint64(35), stats.TotalLost)
+		assert.Equal(t, 2, stats.Resizes)
+	})
+
+	t.Run("sustained loss", func(t *testing.T) {
+		const mapName = "test_sustained_loss"
+		assert.Equal(t, 8*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+
+		// a loss burst isn't sustained
+		recordPerfBufferLoss(mapName, 100)
+		assert.NotContains(t, SustainedPerfBufferLoss(cfg), mapName)
+		assert.NotContains(t, SustainedPerfBufferLoss(cfg), mapName)
+
+		for i := 0; i < perfBufferSustainedLossChecks-1; i++ {
+			recordPerfBufferLoss(mapName, 10)
+			assert.NotContains(t, SustainedPerfBufferLoss(cfg), mapName)
+		}
+		recordPerfBufferLoss(mapName, 10)
+		assert.Contains(t, SustainedPerfBufferLoss(cfg), mapName)
+
+		// the buffer is grown once loaded again, which resets its checks
+		assert.Equal(t, 16*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+		recordPerfBufferLoss(mapName, 10)
+		assert.NotContains(t, SustainedPerfBufferLoss(cfg), mapName)
+	})
+
+	t.Run("resizing disabled", func(t *testing.T) {
+		const mapName = "test_resizing_disabled"
+		cfg := &Config{PerfBufferMaxPages: 20}
+		assert.Equal(t, 8*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+
+		recordPerfBufferLoss(mapName, 1000)
+		assert.Equal(t, 8*pageSize, PerfBufferSize(cfg, mapName, 8*pageSize))
+	})
+}
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/features"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ringBuffersEnabledConstant is the constant telling the eBPF programs to output their events to ring buffers
+const ringBuffersEnabledConstant = "ring_buffers_enabled"
+
+// ringBufferMaxCPUs is the number of CPUs whose perf buffers a ring buffer holds at most. The ring buffer being shared
+// by the CPUs, whose bursts of events rarely coincide, it doesn't need to be as large as all the per-CPU buffers.
+const ringBufferMaxCPUs = 16
+
+var ringBuffersSupported struct {
+	once      sync.Once
+	supported bool
+}
+
+// RingBuffersSupported returns whether the kernel supports the BPF ring buffers, which are available as of 5.8
+func RingBuffersSupported() bool {
+	ringBuffersSupported.once.Do(func() {
+		err := features.HaveMapType(ebpf.RingBuf)
+		ringBuffersSupported.supported = err == nil
+		if err != nil {
+			log.Debugf("ring buffers are not supported, falling back to perf buffers: %s", err)
+		}
+	})
+	return ringBuffersSupported.supported
+}
+
+// UseRingBuffers returns whether the eBPF programs send their events to user space through ring buffers rather than
+// perf buffers
+func UseRingBuffers(cfg *Config) bool {
+	return cfg.EnableRingBuffers && RingBuffersSupported()
+}
+
+// RingBufferSize returns the size of the ring buffer replacing a perf map whose per-CPU buffers are of perCPUSize. It is
+// rounded up to a power of 2 number of pages, as required by the kernel.
+func RingBufferSize(perCPUSize int) int {
+	cpus := runtime.NumCPU()
+	if cpus > ringBufferMaxCPUs {
+		cpus = ringBufferMaxCPUs
+	}
+
+	size := os.Getpagesize()
+	for size < perCPUSize*cpus {
+		size <<= 1
+	}
+	return size
+}
+
+// EditRingBuffers creates the ring buffers of the manager and tells the eBPF programs to output their events to them.
+// The programs declare them as perf event arrays, so that they can be loaded by the kernels not supporting ring
+// buffers, and as the key and value sizes of a map can't be edited, the ring buffers are created beforehand and passed
+// to the manager as map editors. Must be called before manager.InitWithOptions.
+func EditRingBuffers(m *manager.Manager, o *manager.Options) error {
+	if len(m.RingBuffers) == 0 {
+		return nil
+	}
+
+	if o.MapEditors == nil {
+		o.MapEditors = make(map[string]*ebpf.Map)
+	}
+	for _, rb := range m.RingBuffers {
+		if _, ok := o.MapEditors[rb.Name]; ok {
+			continue
+		}
+		ringBuffer, err := ebpf.NewMap(&ebpf.MapSpec{
+			Name:       rb.Name,
+			Type:       ebpf.RingBuf,
+			MaxEntries: uint32(rb.RingBufferSize),
+		})
+		if err != nil {
+			return fmt.Errorf("could not create ring buffer %s: %w", rb.Name, err)
+		}
+		o.MapEditors[rb.Name] = ringBuffer
+	}
+
+	for _, c := range o.ConstantEditors {
+		if c.Name == ringBuffersEnabledConstant {
+			return nil
+		}
+	}
+	constants := o.ConstantEditors
+	o.ConstantEditors = append(constants[:len(constants):len(constants)], manager.ConstantEditor{
+		Name:  ringBuffersEnabledConstant,
+		Value: uint64(1),
+	})
+	return nil
+}
+
+// RingBufferHandler is the callback intended to be used when configuring RingBufferOptions. The data being reused by
+// the reader of the ring buffer once the callback returns, it is copied to a record of the pool.
+func (c *PerfHandler) RingBufferHandler(CPU int, data []byte, ringBuffer *manager.RingBuffer, manager *manager.Manager) {
+	if c.closed {
+		return
+	}
+
+	record := c.RecordGetter()
+	record.CPU = CPU
+	record.RawSample = append(record.RawSample[:0], data...)
+	c.DataChannel <- &DataEvent{CPU: CPU, Data: record.RawSample, r: record}
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package ebpf
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRingBufferSize(t *testing.T) {
+	pageSize := os.Getpagesize()
+	cpus := runtime.NumCPU()
+	if cpus > ringBufferMaxCPUs {
+		cpus = ringBufferMaxCPUs
+	}
+
+	for _, perCPUSize := range []int{1, pageSize, 3 * pageSize, 8 * pageSize} {
+		size := RingBufferSize(perCPUSize)
+		assert.Zero(t, size&(size-1), "%d is not a power of 2", size)
+		assert.Zero(t, size%pageSize, "%d is not a multiple of the page size", size)
+		assert.GreaterOrEqual(t, size, perCPUSize*cpus)
+		assert.Less(t, size, 2*perCPUSize*cpus+pageSize)
+	}
+}
+
+func TestEditRingBuffersWithoutRingBuffers(t *testing.T) {
+	m := &manager.Manager{}
+	o := &manager.Options{}
+	assert.NoError(t, EditRingBuffers(m, o))
+	assert.Empty(t, o.MapEditors)
+	assert.Empty(t, o.ConstantEditors)
+}
@@ -96,6 +96,8 @@ const (
 	AgentLogsEnabled                   AgentMetadataName = "feature_logs_enabled"
 	AgentCSPMEnabled                   AgentMetadataName = "feature_cspm_enabled"
 	AgentAPMEnabled                    AgentMetadataName = "feature_apm_enabled"
+	AgentProcessOpenFiles              AgentMetadataName = "process_open_files"
+	AgentProcessMaxOpenFiles           AgentMetadataName = "process_max_open_files"
 
 	// Those are reserved fields for the agentMetadata payload.
 	agentProvidedConf AgentMetadataName = "provided_configuration"
@@ -276,6 +278,7 @@ func createPayload(ctx context.Context, hostname string, coll CollectorInterface
 	for k, v := range agentMetadata {
 		payloadAgentMeta[k] = v
 	}
+	setProcessFileStats(payloadAgentMeta)
 
 	if withConfigs {
 		if fullConf, err := getFullAgentConfiguration(); err == nil {
@@ -10,6 +10,8 @@ package inventories
 import (
 	"context"
 	"fmt"
+	"os"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -91,6 +93,9 @@ func TestGetPayloadForExpvar(t *testing.T) {
 	timeNow = func() time.Time { return startNow } // time of the first run
 	defer func() { timeNow = time.Now }()
 
+	processFileStatsGet = func() (*ProcessFileStats, error) { return nil, fmt.Errorf("not collected") }
+	defer func() { processFileStatsGet = getProcessFileStats }()
+
 	coll := mockCollector{[]check.Info{
 		check.MockInfo{
 			Name:         "check1",
@@ -132,6 +137,9 @@ func TestGetPayload(t *testing.T) {
 	timeNow = func() time.Time { return startNow } // time of the first run
 	defer func() { timeNow = time.Now }()
 
+	processFileStatsGet = func() (*ProcessFileStats, error) { return nil, fmt.Errorf("not collected") }
+	defer func() { processFileStatsGet = getProcessFileStats }()
+
 	coll := mockCollector{[]check.Info{
 		check.MockInfo{
 			Name:         "check1",
@@ -309,6 +317,47 @@ func TestGetPayload(t *testing.T) {
 
 }
 
+func TestGetPayloadProcessFileStats(t *testing.T) {
+	ctx := context.Background()
+	defer func() { clearMetadata() }()
+
+	processFileStatsGet = func() (*ProcessFileStats, error) {
+		return &ProcessFileStats{OpenFiles: 42, MaxOpenFiles: 1024}, nil
+	}
+	defer func() { processFileStatsGet = getProcessFileStats }()
+
+	p := GetPayload(ctx, "testHostname", nil, false)
+
+	agentMetadata := *p.AgentMetadata
+	assert.Equal(t, uint64(42), agentMetadata["process_open_files"])
+	assert.Equal(t, uint64(1024), agentMetadata["process_max_open_files"])
+
+	// the stats are collected anew for every payload
+	processFileStatsGet = func() (*ProcessFileStats, error) {
+		return &ProcessFileStats{OpenFiles: 43, MaxOpenFiles: 1024}, nil
+	}
+	p = GetPayload(ctx, "testHostname", nil, false)
+	assert.Equal(t, uint64(43), (*p.AgentMetadata)["process_open_files"])
+}
+
+func TestGetProcessFileStats(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the file descriptor usage is only collected on Linux")
+	}
+
+	before, err := getProcessFileStats()
+	require.NoError(t, err)
+	assert.NotZero(t, before.MaxOpenFiles)
+
+	f, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer f.Close()
+
+	after, err := getProcessFileStats()
+	require.NoError(t, err)
+	assert.Equal(t, before.OpenFiles+1, after.OpenFiles)
+}
+
 func TestSetup(t *testing.T) {
 	defer func() { clearMetadata() }()
 
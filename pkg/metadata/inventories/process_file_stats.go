@@ -0,0 +1,30 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package inventories
+
+// ProcessFileStats contains the file descriptor usage of the agent process
+type ProcessFileStats struct {
+	// OpenFiles is the number of file descriptors opened by the process
+	OpenFiles uint64
+	// MaxOpenFiles is the soft limit on the number of file descriptors the process can open
+	MaxOpenFiles uint64
+}
+
+// for testing purpose
+var processFileStatsGet = getProcessFileStats
+
+// setProcessFileStats adds the file descriptor usage of the agent process to the agent metadata of a payload. It is
+// collected every time a payload is created, so that the file descriptor pressure of the agents can be followed without
+// running a status command on each host.
+func setProcessFileStats(metadata AgentMetadata) {
+	stats, err := processFileStatsGet()
+	if err != nil {
+		logInfof("could not collect the file descriptor usage of the agent: %s", err)
+		return
+	}
+	metadata[string(AgentProcessOpenFiles)] = stats.OpenFiles
+	metadata[string(AgentProcessMaxOpenFiles)] = stats.MaxOpenFiles
+}
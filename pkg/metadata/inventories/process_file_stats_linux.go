@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package inventories
+
+import (
+	"os"
+	"syscall"
+)
+
+func getProcessFileStats() (*ProcessFileStats, error) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return nil, err
+	}
+
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return nil, err
+	}
+
+	return &ProcessFileStats{
+		// the directory being read holds a file descriptor itself
+		OpenFiles:    uint64(len(fds) - 1),
+		MaxOpenFiles: limit.Cur,
+	}, nil
+}
@@ -29,6 +29,17 @@ const (
 	maxOffsetThreshold     = 3000
 
 	defaultMaxProcessesTracked = 1024
+
+	defaultReverseDNSEnrichmentRateLimit = 10
+	defaultReverseDNSEnrichmentCacheSize = 10000
+
+	defaultConnectionDomainsCacheSize = 100000
+
+	defaultOfflineCaptureInterval = 30 * time.Second
+	defaultOfflineCaptureMaxFiles = 120
+
+	defaultHTTPPathQuantizationMinHits      = 20
+	defaultHTTPPathQuantizationMaxEndpoints = 10000
 )
 
 // Config stores all flags used by the network eBPF tracer
@@ -47,6 +58,9 @@ type Config struct {
 	// CollectUDPConns specifies whether the tracer should collect traffic statistics for UDP connections
 	CollectUDPConns bool
 
+	// CollectIPv4Conns specifics whether the tracer should capture traffic for IPv4 TCP/UDP connections
+	CollectIPv4Conns bool
+
 	// CollectIPv6Conns specifics whether the tracer should capture traffic for IPv6 TCP/UDP connections
 	CollectIPv6Conns bool
 
@@ -68,6 +82,28 @@ type Config struct {
 	// DNSTimeout determines the length of time to wait before considering a DNS Query to have timed out
 	DNSTimeout time.Duration
 
+	// EnableReverseDNSEnrichment enables resolving the addresses which could not be resolved by inspecting DNS traffic
+	// through reverse (PTR) DNS lookups
+	EnableReverseDNSEnrichment bool
+
+	// ReverseDNSEnrichmentRateLimit is the maximum number of reverse DNS lookups performed per second
+	ReverseDNSEnrichmentRateLimit int
+
+	// ReverseDNSEnrichmentCacheSize is the maximum number of addresses whose reverse DNS lookup result is cached
+	ReverseDNSEnrichmentCacheSize int
+
+	// EnableConnectionDomains enables joining the DNS responses observed to the outgoing connections, so that each
+	// connection carries the domain its destination was resolved from
+	EnableConnectionDomains bool
+
+	// ConnectionDomainsCacheSize is the maximum number of resolved addresses kept, across the network namespaces, to
+	// join the DNS responses to the connections
+	ConnectionDomainsCacheSize int
+
+	// EnableDNSOverTLSMonitoring enables the inspection of the plaintext of the DNS over TLS connections, which is read
+	// through the TLS hooks of the HTTPS monitoring, as the DNS traffic
+	EnableDNSOverTLSMonitoring bool
+
 	// MaxDNSStats determines the number of separate DNS Stats objects DNSStatkeeper can have at any given time
 	// These stats objects get flushed on every client request (default 30s check interval)
 	MaxDNSStats int
@@ -76,7 +112,7 @@ type Config struct {
 	EnableHTTPMonitoring bool
 
 	// EnableHTTPMonitoring specifies whether the tracer should monitor HTTPS traffic
-	// Supported libraries: OpenSSL
+	// Supported libraries: OpenSSL, BoringSSL, LibreSSL and GnuTLS
 	EnableHTTPSMonitoring bool
 
 	// EnableGoTLSSupport specifies whether the tracer should monitor HTTPS
@@ -87,6 +123,107 @@ type Config struct {
 	// traffic done through Java's TLS implementation
 	EnableJavaTLSSupport bool
 
+	// EnableKTLSSupport specifies whether the tracer should monitor HTTPS
+	// traffic done through the kernel TLS (kTLS) sockets
+	EnableKTLSSupport bool
+
+	// EnableTLSLibraryWatcher enables hooking the TLS libraries as soon as they are installed in the library directories
+	// of the host or of the layers of the container images being pulled, rather than once a process opens them
+	EnableTLSLibraryWatcher bool
+
+	// EnableHTTP2Monitoring specifies whether the tracer should account for the frames of the HTTP/2 connections
+	// relevant to diagnose their performance, such as the server pushes, the priorities and the flow control
+	EnableHTTP2Monitoring bool
+
+	// EnableKafkaMonitoring specifies whether the tracer should decode the Kafka produce and fetch requests, and
+	// aggregate them by topic
+	EnableKafkaMonitoring bool
+
+	// MaxKafkaStatsBuffered represents the maximum number of Kafka stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxKafkaStatsBuffered int
+
+	// EnablePostgresMonitoring specifies whether the tracer should decode the Postgres queries, and aggregate them
+	// by query fingerprint
+	EnablePostgresMonitoring bool
+
+	// MaxPostgresStatsBuffered represents the maximum number of Postgres stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxPostgresStatsBuffered int
+
+	// EnableMySQLMonitoring specifies whether the tracer should decode the MySQL statements, and aggregate them
+	// by query fingerprint
+	EnableMySQLMonitoring bool
+
+	// MaxMySQLStatsBuffered represents the maximum number of MySQL stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxMySQLStatsBuffered int
+
+	// EnableRedisMonitoring specifies whether the tracer should decode the Redis commands, and aggregate them
+	// by command name
+	EnableRedisMonitoring bool
+
+	// MaxRedisStatsBuffered represents the maximum number of Redis stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxRedisStatsBuffered int
+
+	// EnableMongoMonitoring specifies whether the tracer should decode the Mongo commands, and aggregate them
+	// by command name and collection
+	EnableMongoMonitoring bool
+
+	// MaxMongoStatsBuffered represents the maximum number of Mongo stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxMongoStatsBuffered int
+
+	// EnableAMQPMonitoring specifies whether the tracer should decode the AMQP basic.publish and basic.deliver
+	// messages, and aggregate them by connection, exchange, routing key and method
+	EnableAMQPMonitoring bool
+
+	// MaxAMQPStatsBuffered represents the maximum number of AMQP stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxAMQPStatsBuffered int
+
+	// EnableGRPCMonitoring specifies whether the tracer should decode the gRPC calls from the HEADERS frames of the
+	// HTTP/2 connections, and aggregate them by connection, service and method
+	EnableGRPCMonitoring bool
+
+	// MaxGRPCStatsBuffered represents the maximum number of gRPC stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxGRPCStatsBuffered int
+
+	// EnableHTTP3Monitoring specifies whether the tracer should decrypt the server names of the ClientHello carried by
+	// the QUIC Initial packets, and count the HTTP/3 connections by connection and server name
+	EnableHTTP3Monitoring bool
+
+	// MaxHTTP3StatsBuffered represents the maximum number of HTTP/3 stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxHTTP3StatsBuffered int
+
+	// EnableCassandraMonitoring specifies whether the tracer should decode the CQL requests of the Cassandra
+	// connections, and aggregate them by connection and keyspace
+	EnableCassandraMonitoring bool
+
+	// MaxCassandraStatsBuffered represents the maximum number of Cassandra stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxCassandraStatsBuffered int
+
+	// EnableMemcachedMonitoring specifies whether the tracer should decode the requests of the memcached connections,
+	// using either the text or the binary protocol, and aggregate them by connection and operation
+	EnableMemcachedMonitoring bool
+
+	// MaxMemcachedStatsBuffered represents the maximum number of memcached stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxMemcachedStatsBuffered int
+
+	// EnableTLSHandshakeMonitoring specifies whether the tracer should parse the ClientHello and ServerHello messages
+	// of the TLS connections, and tag the connections with their server name, negotiated version and cipher suite, and
+	// JA3 and JA3S fingerprints
+	EnableTLSHandshakeMonitoring bool
+
+	// ExcludeAgentTraffic specifies whether the HTTP transactions of the agent processes themselves, such as the
+	// payloads sent to the intake, are excluded from the USM stats. The connections of the agent are still reported.
+	ExcludeAgentTraffic bool
+
 	// MaxTrackedHTTPConnections max number of http(s) flows that will be concurrently tracked.
 	// value is currently Windows only
 	MaxTrackedHTTPConnections int64
@@ -99,9 +236,29 @@ type Config struct {
 	// Currently Windows only
 	HTTPMaxRequestFragment int64
 
+	// HTTPMaxPathSize is the maximum size of the HTTP paths, the longer ones being truncated. It is bounded by the
+	// size of the request fragment captured by eBPF, which is used when it is 0. Currently Linux only
+	HTTPMaxPathSize int
+
 	// JavaAgentArgs arguments pass through injected USM agent
 	JavaAgentArgs string
 
+	// JavaTLSAllowRegex and JavaTLSBlockRegex are the regexes of the command lines of the java processes the USM agent
+	// is respectively restricted to and not injected in
+	JavaTLSAllowRegex string
+	JavaTLSBlockRegex string
+
+	// JavaTLSAllowUIDs and JavaTLSBlockUIDs are the user IDs of the java processes the USM agent is respectively
+	// restricted to and not injected in
+	JavaTLSAllowUIDs []string
+	JavaTLSBlockUIDs []string
+
+	// JavaTLSAllowContainerLabels and JavaTLSBlockContainerLabels are the labels, formatted as "key" or "key=value", of
+	// the containers of the java processes the USM agent is respectively restricted to and not injected in. The block
+	// lists take precedence over the allow lists, a process being allowed when it matches any of the allow lists.
+	JavaTLSAllowContainerLabels []string
+	JavaTLSBlockContainerLabels []string
+
 	// UDPConnTimeout determines the length of traffic inactivity between two
 	// (IP, port)-pairs before declaring a UDP connection as inactive. This is
 	// set to /proc/sys/net/netfilter/nf_conntrack_udp_timeout on Linux by
@@ -125,6 +282,11 @@ type Config struct {
 	// MaxTrackedConnections specifies the maximum number of connections we can track. This determines the size of the eBPF Maps
 	MaxTrackedConnections uint
 
+	// MaxTrackedIPv6Connections specifies the maximum number of IPv6 connections we can track. The IPv6 connections are
+	// tracked apart from the IPv4 ones, which are bounded by MaxTrackedConnections, so that the churn of one family
+	// doesn't prevent the connections of the other one from being tracked. It defaults to MaxTrackedConnections.
+	MaxTrackedIPv6Connections uint
+
 	// MaxClosedConnectionsBuffered represents the maximum number of closed connections we'll buffer in memory. These closed connections
 	// get flushed on every client request (default 30s check interval)
 	MaxClosedConnectionsBuffered int
@@ -141,10 +303,44 @@ type Config struct {
 	// get flushed on every client request (default 30s check interval)
 	MaxHTTPStatsBuffered int
 
+	// EnableHTTPStatsByStatusCode aggregates the HTTP stats by exact response status code (eg. 404, 429) rather than
+	// by status class (eg. 4XX)
+	EnableHTTPStatsByStatusCode bool
+
+	// EnableHTTPStatsByMethod aggregates the HTTP stats by request method (eg. GET, POST) along with their path. When it
+	// is disabled, the stats of all the methods of a path are aggregated together to reduce their cardinality.
+	EnableHTTPStatsByMethod bool
+
+	// EnableHTTPPathQuantization collapses the high-cardinality HTTP paths into the endpoints they belong to, eg.
+	// /users/123 becomes /users/*, before they are aggregated
+	EnableHTTPPathQuantization bool
+
+	// HTTPPathQuantizationMinHits is the number of distinct segments seen after the same prefix of the HTTP paths
+	// from which the position is considered variable, and collapsed into a wildcard
+	HTTPPathQuantizationMinHits int
+
+	// HTTPPathQuantizationMaxEndpoints bounds the number of segments of the HTTP paths learnt by the quantization,
+	// the positions of the paths exceeding it being collapsed into a wildcard
+	HTTPPathQuantizationMaxEndpoints int
+
+	// HTTPCaptureHeaders is the allowlist of the request headers (eg. user-agent, content-type) whose values are
+	// captured for each aggregated HTTP endpoint. The names are case-insensitive, and the capture is disabled when
+	// the list is empty.
+	HTTPCaptureHeaders []string
+
 	// MaxConnectionsStateBuffered represents the maximum number of state objects that we'll store in memory. These state objects store
 	// the stats for a connection so we can accurately determine traffic change between client requests.
 	MaxConnectionsStateBuffered int
 
+	// MaxConnectionsPerClient is the default maximum number of connections returned to a client on each request, the
+	// connections with the lowest traffic being evicted first. Clients can set their own limit when registering. A
+	// value of 0 disables the limit.
+	MaxConnectionsPerClient int
+
+	// EnableConnectionRollup enables rolling up the connections which only differ by their ephemeral port, to reduce
+	// the size of the payloads of the hosts with many short-lived connections
+	EnableConnectionRollup bool
+
 	// ClientStateExpiry specifies the max time a client (e.g. process-agent)'s state will be stored in memory before being evicted.
 	ClientStateExpiry time.Duration
 
@@ -155,6 +351,11 @@ type Config struct {
 	// will fail to start if there is a conntrack initialization failure.
 	IgnoreConntrackInitFailure bool
 
+	// EnableEbpfConntracker enables tracking the NAT entries of conntrack with eBPF probes, rather than by consuming the
+	// netlink events, when runtime compilation is enabled. It scales to the hosts with very large conntrack tables. The
+	// netlink conntracker is used if the eBPF one fails to initialize and the precompiled fallback is allowed.
+	EnableEbpfConntracker bool
+
 	// ConntrackMaxStateSize specifies the maximum number of connections with NAT we can track
 	ConntrackMaxStateSize int
 
@@ -181,6 +382,11 @@ type Config struct {
 	// ExcludedDestinationConnections is a map of destination connections to blacklist
 	ExcludedDestinationConnections map[string][]string
 
+	// EnableKernelConnectionFilters enables filtering the excluded source and destination connections in the eBPF
+	// programs, so that their traffic, such as the one of the loopback interface, is neither tracked nor sent to user
+	// space. The connections are still filtered in user space, on the kernels not supporting it.
+	EnableKernelConnectionFilters bool
+
 	// OffsetGuessThreshold is the size of the byte threshold we will iterate over when guessing offsets
 	OffsetGuessThreshold uint64
 
@@ -202,6 +408,15 @@ type Config struct {
 	// MaxProcessesTracked is the maximum number of processes whose information is stored in the network module
 	MaxProcessesTracked int
 
+	// EnableProcessEnvTags enables tagging the connections with the DD_SERVICE, DD_ENV and DD_VERSION environment
+	// variables of their process, read from procfs. It doesn't require the process event monitoring, which tags the
+	// connections of the processes it saw starting.
+	EnableProcessEnvTags bool
+
+	// EnableServiceNameInference enables tagging the connections with the service name inferred from the command line
+	// and the Kubernetes pod of their process, for the processes which don't set DD_SERVICE
+	EnableServiceNameInference bool
+
 	// EnableRootNetNs disables using the network namespace of the root process (1)
 	// for things like creating netlink sockets for conntrack updates, etc.
 	EnableRootNetNs bool
@@ -215,6 +430,60 @@ type Config struct {
 	// ProtocolClassificationEnabled specifies whether the tracer should enhance connection data with protocols names by
 	// classifying the L7 protocols being used.
 	ProtocolClassificationEnabled bool
+
+	// EnableTCPDropTracking enables counting the packets of the TCP connections dropped by the kernel, per connection
+	// and drop reason
+	EnableTCPDropTracking bool
+
+	// EnableTCPQueueLengthTracking enables sampling the send and receive queue lengths of the TCP connections.
+	// It is only supported by the runtime compiled tracer.
+	EnableTCPQueueLengthTracking bool
+
+	// EnableTCPCongestionTracking enables recording the congestion control algorithm and state of the TCP connections
+	// when they are closed. It is only supported by the runtime compiled tracer.
+	EnableTCPCongestionTracking bool
+
+	// EnableQUICStats enables measuring the RTT and the retransmitted Initial packets of the UDP flows carrying QUIC.
+	// It requires the protocol classification, which identifies these flows.
+	EnableQUICStats bool
+
+	// EnableFentry enables attaching fentry/fexit programs rather than kprobes, on the hosts supporting them
+	EnableFentry bool
+
+	// EnableEbpfLess tracks the connections and monitors the HTTP traffic from the packets read through an AF_PACKET
+	// ring rather than with eBPF programs, for the environments forbidding them. The features relying on kernel hooks,
+	// such as the HTTPS monitoring, the protocol classification or the eBPF conntracker, are disabled in this mode.
+	EnableEbpfLess bool
+
+	// EnableUSMCPUPressureControl enables progressively reducing the work of the HTTP monitoring when the CPU usage
+	// of the system-probe goes over USMMaxCPUPercent
+	EnableUSMCPUPressureControl bool
+
+	// USMMaxCPUPercent is the percentage of the total CPU capacity of the host the system-probe can use before the
+	// work of the HTTP monitoring is reduced
+	USMMaxCPUPercent float64
+
+	// USMCPUPressureCheckInterval is the interval at which the CPU usage of the system-probe is checked
+	USMCPUPressureCheckInterval time.Duration
+
+	// EnableOfflineCapture enables writing periodic snapshots of the connections, including their USM stats, to
+	// compressed files in OfflineCaptureDir, for environments where the data cannot be sent and for post-incident
+	// analysis
+	EnableOfflineCapture bool
+
+	// OfflineCaptureDir is the directory the snapshots of the connections are written to
+	OfflineCaptureDir string
+
+	// OfflineCaptureInterval is the interval at which the snapshots of the connections are written
+	OfflineCaptureInterval time.Duration
+
+	// OfflineCaptureMaxFiles is the number of snapshots kept in OfflineCaptureDir, the oldest ones being removed
+	OfflineCaptureMaxFiles int
+
+	// OfflineCaptureReplayPath is a snapshot, or a directory of snapshots, written by the offline capture. When set,
+	// the network module serves these snapshots in order instead of the connections of the tracer, so that payload
+	// issues can be reproduced without access to the host they were captured on.
+	OfflineCaptureReplayPath string
 }
 
 func join(pieces ...string) string {
@@ -239,16 +508,20 @@ func New() *Config {
 		UDPConnTimeout:   defaultUDPTimeoutSeconds * time.Second,
 		UDPStreamTimeout: defaultUDPStreamTimeoutSeconds * time.Second,
 
+		CollectIPv4Conns:               cfg.GetBool(join(netNS, "collect_ipv4_conns")),
 		CollectIPv6Conns:               !cfg.GetBool(join(spNS, "disable_ipv6")),
 		OffsetGuessThreshold:           uint64(cfg.GetInt64(join(spNS, "offset_guess_threshold"))),
 		ExcludedSourceConnections:      cfg.GetStringMapStringSlice(join(spNS, "source_excludes")),
 		ExcludedDestinationConnections: cfg.GetStringMapStringSlice(join(spNS, "dest_excludes")),
 
 		MaxTrackedConnections:          uint(cfg.GetInt(join(spNS, "max_tracked_connections"))),
+		MaxTrackedIPv6Connections:      uint(cfg.GetInt(join(netNS, "max_tracked_ipv6_connections"))),
 		MaxClosedConnectionsBuffered:   cfg.GetInt(join(spNS, "max_closed_connections_buffered")),
 		ClosedConnectionFlushThreshold: cfg.GetInt(join(spNS, "closed_connection_flush_threshold")),
 		ClosedChannelSize:              cfg.GetInt(join(spNS, "closed_channel_size")),
 		MaxConnectionsStateBuffered:    cfg.GetInt(join(spNS, "max_connection_state_buffered")),
+		MaxConnectionsPerClient:        cfg.GetInt(join(netNS, "max_connections_per_client")),
+		EnableConnectionRollup:         cfg.GetBool(join(netNS, "enable_connection_rollup")),
 		ClientStateExpiry:              2 * time.Minute,
 
 		DNSInspection:       !cfg.GetBool(join(spNS, "disable_dns_inspection")),
@@ -259,15 +532,39 @@ func New() *Config {
 		MaxDNSStatsBuffered: 75000,
 		DNSTimeout:          time.Duration(cfg.GetInt(join(spNS, "dns_timeout_in_s"))) * time.Second,
 
+		EnableReverseDNSEnrichment:    cfg.GetBool(join(netNS, "enable_reverse_dns_enrichment")),
+		ReverseDNSEnrichmentRateLimit: cfg.GetInt(join(netNS, "reverse_dns_enrichment_rate_limit")),
+		ReverseDNSEnrichmentCacheSize: cfg.GetInt(join(netNS, "reverse_dns_enrichment_cache_size")),
+
+		EnableConnectionDomains:    cfg.GetBool(join(netNS, "enable_connection_domains")),
+		ConnectionDomainsCacheSize: cfg.GetInt(join(netNS, "connection_domains_cache_size")),
+
+		EnableDNSOverTLSMonitoring: cfg.GetBool(join(netNS, "enable_dns_over_tls_monitoring")),
+
 		ProtocolClassificationEnabled: cfg.GetBool(join(netNS, "enable_protocol_classification")),
+		EnableTCPDropTracking:         cfg.GetBool(join(netNS, "enable_tcp_drop_tracking")),
+		EnableTCPQueueLengthTracking:  cfg.GetBool(join(netNS, "enable_tcp_queue_length_tracking")),
+		EnableTCPCongestionTracking:   cfg.GetBool(join(netNS, "enable_tcp_congestion_tracking")),
+		EnableQUICStats:               cfg.GetBool(join(netNS, "enable_quic_stats")),
+		EnableEbpfLess:                cfg.GetBool(join(netNS, "enable_ebpf_less")),
+		EnableKernelConnectionFilters: cfg.GetBool(join(netNS, "enable_kernel_connection_filters")),
 
 		EnableHTTPMonitoring:  cfg.GetBool(join(netNS, "enable_http_monitoring")),
 		EnableHTTPSMonitoring: cfg.GetBool(join(netNS, "enable_https_monitoring")),
 		MaxHTTPStatsBuffered:  cfg.GetInt(join(netNS, "max_http_stats_buffered")),
 
+		EnableHTTPStatsByStatusCode: cfg.GetBool(join(netNS, "enable_http_stats_by_status_code")),
+		EnableHTTPStatsByMethod:     cfg.GetBool(join(netNS, "enable_http_stats_by_method")),
+		HTTPCaptureHeaders:          cfg.GetStringSlice(join(netNS, "http_capture_headers")),
+
+		EnableHTTPPathQuantization:       cfg.GetBool(join(netNS, "http_path_quantization", "enabled")),
+		HTTPPathQuantizationMinHits:      cfg.GetInt(join(netNS, "http_path_quantization", "min_hits")),
+		HTTPPathQuantizationMaxEndpoints: cfg.GetInt(join(netNS, "http_path_quantization", "max_endpoints")),
+
 		MaxTrackedHTTPConnections: cfg.GetInt64(join(netNS, "max_tracked_http_connections")),
 		HTTPNotificationThreshold: cfg.GetInt64(join(netNS, "http_notification_threshold")),
 		HTTPMaxRequestFragment:    cfg.GetInt64(join(netNS, "http_max_request_fragment")),
+		HTTPMaxPathSize:           cfg.GetInt(join(netNS, "http_max_path_size")),
 
 		EnableConntrack:              cfg.GetBool(join(spNS, "enable_conntrack")),
 		ConntrackMaxStateSize:        cfg.GetInt(join(spNS, "conntrack_max_state_size")),
@@ -275,16 +572,21 @@ func New() *Config {
 		ConntrackRateLimitInterval:   3 * time.Second,
 		EnableConntrackAllNamespaces: cfg.GetBool(join(spNS, "enable_conntrack_all_namespaces")),
 		IgnoreConntrackInitFailure:   cfg.GetBool(join(netNS, "ignore_conntrack_init_failure")),
+		EnableEbpfConntracker:        cfg.GetBool(join(netNS, "enable_ebpf_conntracker")),
 		ConntrackInitTimeout:         cfg.GetDuration(join(netNS, "conntrack_init_timeout")),
 
 		EnableGatewayLookup: cfg.GetBool(join(netNS, "enable_gateway_lookup")),
 
+		EnableFentry: cfg.GetBool(join(netNS, "enable_fentry")),
+
 		EnableMonotonicCount: cfg.GetBool(join(spNS, "windows.enable_monotonic_count")),
 
 		RecordedQueryTypes: cfg.GetStringSlice(join(netNS, "dns_recorded_query_types")),
 
 		EnableProcessEventMonitoring: cfg.GetBool(join(evNS, "network_process", "enabled")),
 		MaxProcessesTracked:          cfg.GetInt(join(evNS, "network_process", "max_processes_tracked")),
+		EnableProcessEnvTags:         cfg.GetBool(join(netNS, "enable_process_env_tags")),
+		EnableServiceNameInference:   cfg.GetBool(join(netNS, "enable_service_name_inference")),
 
 		EnableRootNetNs: cfg.GetBool(join(netNS, "enable_root_netns")),
 
@@ -295,6 +597,60 @@ func New() *Config {
 		EnableJavaTLSSupport: cfg.GetBool(join(smNS, "enable_java_tls_support")),
 		JavaAgentArgs:        cfg.GetString(join(smNS, "java_agent_args")),
 		EnableGoTLSSupport:   cfg.GetBool(join(smNS, "enable_go_tls_support")),
+		EnableKTLSSupport:    cfg.GetBool(join(smNS, "enable_ktls_support")),
+
+		JavaTLSAllowRegex:           cfg.GetString(join(smNS, "java_tls", "allow_regex")),
+		JavaTLSBlockRegex:           cfg.GetString(join(smNS, "java_tls", "block_regex")),
+		JavaTLSAllowUIDs:            cfg.GetStringSlice(join(smNS, "java_tls", "allow_uids")),
+		JavaTLSBlockUIDs:            cfg.GetStringSlice(join(smNS, "java_tls", "block_uids")),
+		JavaTLSAllowContainerLabels: cfg.GetStringSlice(join(smNS, "java_tls", "allow_container_labels")),
+		JavaTLSBlockContainerLabels: cfg.GetStringSlice(join(smNS, "java_tls", "block_container_labels")),
+
+		EnableTLSLibraryWatcher: cfg.GetBool(join(smNS, "enable_tls_library_watcher")),
+
+		EnableHTTP2Monitoring: cfg.GetBool(join(smNS, "enable_http2_monitoring")),
+		EnableKafkaMonitoring: cfg.GetBool(join(smNS, "enable_kafka_monitoring")),
+		MaxKafkaStatsBuffered: cfg.GetInt(join(smNS, "max_kafka_stats_buffered")),
+		ExcludeAgentTraffic:   cfg.GetBool(join(smNS, "exclude_agent_traffic")),
+
+		EnablePostgresMonitoring: cfg.GetBool(join(smNS, "enable_postgres_monitoring")),
+		MaxPostgresStatsBuffered: cfg.GetInt(join(smNS, "max_postgres_stats_buffered")),
+
+		EnableMySQLMonitoring: cfg.GetBool(join(smNS, "enable_mysql_monitoring")),
+		MaxMySQLStatsBuffered: cfg.GetInt(join(smNS, "max_mysql_stats_buffered")),
+
+		EnableRedisMonitoring: cfg.GetBool(join(smNS, "enable_redis_monitoring")),
+		MaxRedisStatsBuffered: cfg.GetInt(join(smNS, "max_redis_stats_buffered")),
+
+		EnableMongoMonitoring: cfg.GetBool(join(smNS, "enable_mongo_monitoring")),
+		MaxMongoStatsBuffered: cfg.GetInt(join(smNS, "max_mongo_stats_buffered")),
+
+		EnableAMQPMonitoring: cfg.GetBool(join(smNS, "enable_amqp_monitoring")),
+		MaxAMQPStatsBuffered: cfg.GetInt(join(smNS, "max_amqp_stats_buffered")),
+
+		EnableGRPCMonitoring: cfg.GetBool(join(smNS, "enable_grpc_monitoring")),
+		MaxGRPCStatsBuffered: cfg.GetInt(join(smNS, "max_grpc_stats_buffered")),
+
+		EnableHTTP3Monitoring: cfg.GetBool(join(smNS, "enable_http3_monitoring")),
+		MaxHTTP3StatsBuffered: cfg.GetInt(join(smNS, "max_http3_stats_buffered")),
+
+		EnableCassandraMonitoring: cfg.GetBool(join(smNS, "enable_cassandra_monitoring")),
+		MaxCassandraStatsBuffered: cfg.GetInt(join(smNS, "max_cassandra_stats_buffered")),
+
+		EnableMemcachedMonitoring: cfg.GetBool(join(smNS, "enable_memcached_monitoring")),
+		MaxMemcachedStatsBuffered: cfg.GetInt(join(smNS, "max_memcached_stats_buffered")),
+
+		EnableTLSHandshakeMonitoring: cfg.GetBool(join(smNS, "enable_tls_handshake_monitoring")),
+
+		EnableUSMCPUPressureControl: cfg.GetBool(join(smNS, "cpu_pressure", "enabled")),
+		USMMaxCPUPercent:            cfg.GetFloat64(join(smNS, "cpu_pressure", "max_cpu_percent")),
+		USMCPUPressureCheckInterval: time.Duration(cfg.GetInt(join(smNS, "cpu_pressure", "check_interval_in_s"))) * time.Second,
+
+		EnableOfflineCapture:     cfg.GetBool(join(netNS, "offline_capture", "enabled")),
+		OfflineCaptureDir:        cfg.GetString(join(netNS, "offline_capture", "dir")),
+		OfflineCaptureInterval:   cfg.GetDuration(join(netNS, "offline_capture", "interval")),
+		OfflineCaptureMaxFiles:   cfg.GetInt(join(netNS, "offline_capture", "max_files")),
+		OfflineCaptureReplayPath: cfg.GetString(join(netNS, "offline_capture", "replay_path")),
 	}
 
 	if runtime.GOOS == "windows" {
@@ -324,6 +680,24 @@ func New() *Config {
 		log.Warnf("Max HTTP fragment too large (%d) resetting to (%d) ", c.HTTPMaxRequestFragment, maxHTTPFrag)
 		c.HTTPMaxRequestFragment = int64(maxHTTPFrag)
 	}
+	if c.HTTPMaxPathSize < 0 {
+		log.Warnf("http_max_path_size must be positive, resetting it to 0 (the size of the captured request fragment)")
+		c.HTTPMaxPathSize = 0
+	}
+
+	if c.HTTPPathQuantizationMinHits <= 0 {
+		log.Warnf("http_path_quantization.min_hits must be positive, resetting it to %d", defaultHTTPPathQuantizationMinHits)
+		c.HTTPPathQuantizationMinHits = defaultHTTPPathQuantizationMinHits
+	}
+	if c.HTTPPathQuantizationMaxEndpoints <= 0 {
+		log.Warnf("http_path_quantization.max_endpoints must be positive, resetting it to %d", defaultHTTPPathQuantizationMaxEndpoints)
+		c.HTTPPathQuantizationMaxEndpoints = defaultHTTPPathQuantizationMaxEndpoints
+	}
+
+	for i, header := range c.HTTPCaptureHeaders {
+		c.HTTPCaptureHeaders[i] = strings.ToLower(strings.TrimSpace(header))
+	}
+
 	httpRRKey := join(netNS, "http_replace_rules")
 	rr, err := parseReplaceRules(cfg, httpRRKey)
 	if err != nil {
@@ -332,6 +706,30 @@ func New() *Config {
 		c.HTTPReplaceRules = rr
 	}
 
+	if c.ReverseDNSEnrichmentRateLimit <= 0 {
+		log.Warnf("reverse_dns_enrichment_rate_limit must be positive, resetting it to %d", defaultReverseDNSEnrichmentRateLimit)
+		c.ReverseDNSEnrichmentRateLimit = defaultReverseDNSEnrichmentRateLimit
+	}
+
+	if c.ReverseDNSEnrichmentCacheSize <= 0 {
+		log.Warnf("reverse_dns_enrichment_cache_size must be positive, resetting it to %d", defaultReverseDNSEnrichmentCacheSize)
+		c.ReverseDNSEnrichmentCacheSize = defaultReverseDNSEnrichmentCacheSize
+	}
+
+	if c.ConnectionDomainsCacheSize <= 0 {
+		log.Warnf("connection_domains_cache_size must be positive, resetting it to %d", defaultConnectionDomainsCacheSize)
+		c.ConnectionDomainsCacheSize = defaultConnectionDomainsCacheSize
+	}
+
+	if c.OfflineCaptureInterval <= 0 {
+		log.Warnf("offline_capture.interval must be positive, resetting it to %s", defaultOfflineCaptureInterval)
+		c.OfflineCaptureInterval = defaultOfflineCaptureInterval
+	}
+	if c.OfflineCaptureMaxFiles <= 0 {
+		log.Warnf("offline_capture.max_files must be positive, resetting it to %d", defaultOfflineCaptureMaxFiles)
+		c.OfflineCaptureMaxFiles = defaultOfflineCaptureMaxFiles
+	}
+
 	if c.OffsetGuessThreshold > maxOffsetThreshold {
 		log.Warn("offset_guess_threshold exceeds maximum of 3000. Setting it to the default of 400")
 		c.OffsetGuessThreshold = defaultOffsetThreshold
@@ -343,6 +741,16 @@ func New() *Config {
 	} else if !c.CollectIPv6Conns {
 		log.Info("network tracer IPv6 tracing disabled by configuration")
 	}
+	if !c.CollectIPv4Conns {
+		log.Info("network tracer IPv4 tracing disabled by configuration")
+	}
+	if c.EnableQUICStats && !c.ProtocolClassificationEnabled {
+		log.Warn("network_config.enable_quic_stats requires the protocol classification, disabling it")
+		c.EnableQUICStats = false
+	}
+	if c.MaxTrackedIPv6Connections == 0 {
+		c.MaxTrackedIPv6Connections = c.MaxTrackedConnections
+	}
 
 	if !c.CollectUDPConns {
 		log.Info("network tracer UDP tracing disabled by configuration")
@@ -373,6 +781,26 @@ func New() *Config {
 		}
 	}
 
+	if c.EnableEbpfLess {
+		log.Info("network tracer running without eBPF, the connections and the http traffic are read from the packets")
+		c.EnableHTTPSMonitoring = false
+		c.EnableEbpfConntracker = false
+		c.ProtocolClassificationEnabled = false
+		c.EnableQUICStats = false
+		c.EnableTCPDropTracking = false
+		c.EnableTCPQueueLengthTracking = false
+		c.EnableTCPCongestionTracking = false
+		c.EnableKernelConnectionFilters = false
+		// the connections have no PID in this mode
+		c.EnableProcessEnvTags = false
+		c.EnableServiceNameInference = false
+	}
+
+	if c.EnableDNSOverTLSMonitoring && (!c.DNSInspection || !c.EnableHTTPSMonitoring) {
+		log.Warn("dns over tls monitoring requires both the dns inspection and the https monitoring, disabling it")
+		c.EnableDNSOverTLSMonitoring = false
+	}
+
 	if c.EnableProcessEventMonitoring {
 		log.Info("network process event monitoring enabled")
 
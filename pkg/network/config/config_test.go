@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -92,87 +93,998 @@ func TestEnableGoTLSSupport(t *testing.T) {
 	})
 }
 
+func TestEnableHTTP2Monitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableHTTP2Monitoring)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_HTTP2_MONITORING", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableHTTP2Monitoring)
+	})
+}
+
+func TestEnableKafkaMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableKafkaMonitoring)
+		assert.Equal(t, 100000, cfg.MaxKafkaStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_KAFKA_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_KAFKA_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableKafkaMonitoring)
+		assert.Equal(t, 50000, cfg.MaxKafkaStatsBuffered)
+	})
+}
+
+func TestEnablePostgresMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnablePostgresMonitoring)
+		assert.Equal(t, 100000, cfg.MaxPostgresStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_POSTGRES_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_POSTGRES_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnablePostgresMonitoring)
+		assert.Equal(t, 50000, cfg.MaxPostgresStatsBuffered)
+	})
+}
+
+func TestEnableMySQLMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableMySQLMonitoring)
+		assert.Equal(t, 100000, cfg.MaxMySQLStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_MYSQL_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_MYSQL_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableMySQLMonitoring)
+		assert.Equal(t, 50000, cfg.MaxMySQLStatsBuffered)
+	})
+}
+
+func TestEnableRedisMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableRedisMonitoring)
+		assert.Equal(t, 100000, cfg.MaxRedisStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_REDIS_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_REDIS_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableRedisMonitoring)
+		assert.Equal(t, 50000, cfg.MaxRedisStatsBuffered)
+	})
+}
+
+func TestEnableMongoMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableMongoMonitoring)
+		assert.Equal(t, 100000, cfg.MaxMongoStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_MONGO_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_MONGO_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableMongoMonitoring)
+		assert.Equal(t, 50000, cfg.MaxMongoStatsBuffered)
+	})
+}
+
+func TestEnableAMQPMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableAMQPMonitoring)
+		assert.Equal(t, 100000, cfg.MaxAMQPStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_AMQP_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_AMQP_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableAMQPMonitoring)
+		assert.Equal(t, 50000, cfg.MaxAMQPStatsBuffered)
+	})
+}
+
+func TestEnableGRPCMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableGRPCMonitoring)
+		assert.Equal(t, 100000, cfg.MaxGRPCStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_GRPC_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_GRPC_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableGRPCMonitoring)
+		assert.Equal(t, 50000, cfg.MaxGRPCStatsBuffered)
+	})
+}
+
+func TestEnableHTTP3Monitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableHTTP3Monitoring)
+		assert.Equal(t, 100000, cfg.MaxHTTP3StatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_HTTP3_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_HTTP3_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableHTTP3Monitoring)
+		assert.Equal(t, 50000, cfg.MaxHTTP3StatsBuffered)
+	})
+}
+
+func TestEnableCassandraMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableCassandraMonitoring)
+		assert.Equal(t, 100000, cfg.MaxCassandraStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_CASSANDRA_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_CASSANDRA_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableCassandraMonitoring)
+		assert.Equal(t, 50000, cfg.MaxCassandraStatsBuffered)
+	})
+}
+
+func TestEnableMemcachedMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableMemcachedMonitoring)
+		assert.Equal(t, 100000, cfg.MaxMemcachedStatsBuffered)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_MEMCACHED_MONITORING", "true")
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_MAX_MEMCACHED_STATS_BUFFERED", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableMemcachedMonitoring)
+		assert.Equal(t, 50000, cfg.MaxMemcachedStatsBuffered)
+	})
+}
+
+func TestEnableTLSHandshakeMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableTLSHandshakeMonitoring)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_TLS_HANDSHAKE_MONITORING", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableTLSHandshakeMonitoring)
+	})
+}
+
+func TestEnableKTLSSupport(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableKTLSSupport)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_KTLS_SUPPORT", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableKTLSSupport)
+	})
+}
+
+func TestExcludeAgentTraffic(t *testing.T) {
+	t.Run("enabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.ExcludeAgentTraffic)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_EXCLUDE_AGENT_TRAFFIC", "false")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.ExcludeAgentTraffic)
+	})
+}
+
+func TestMaxConnectionsPerClient(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, 0, cfg.MaxConnectionsPerClient)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_MAX_CONNECTIONS_PER_CLIENT", "50000")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, 50000, cfg.MaxConnectionsPerClient)
+	})
+}
+
+func TestOfflineCapture(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableOfflineCapture)
+		assert.NotEmpty(t, cfg.OfflineCaptureDir)
+		assert.Equal(t, 30*time.Second, cfg.OfflineCaptureInterval)
+		assert.Equal(t, 120, cfg.OfflineCaptureMaxFiles)
+		assert.Empty(t, cfg.OfflineCaptureReplayPath)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_OFFLINE_CAPTURE_ENABLED", "true")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_OFFLINE_CAPTURE_DIR", "/tmp/captures")
+		t.Setenv("DD_NETWORK_CONFIG_OFFLINE_CAPTURE_INTERVAL", "1m")
+		t.Setenv("DD_NETWORK_CONFIG_OFFLINE_CAPTURE_MAX_FILES", "10")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_OFFLINE_CAPTURE_REPLAY_PATH", "/tmp/replay")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableOfflineCapture)
+		assert.Equal(t, "/tmp/captures", cfg.OfflineCaptureDir)
+		assert.Equal(t, time.Minute, cfg.OfflineCaptureInterval)
+		assert.Equal(t, 10, cfg.OfflineCaptureMaxFiles)
+		assert.Equal(t, "/tmp/replay", cfg.OfflineCaptureReplayPath)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_NETWORK_CONFIG_OFFLINE_CAPTURE_INTERVAL", "0s")
+		t.Setenv("DD_NETWORK_CONFIG_OFFLINE_CAPTURE_MAX_FILES", "-1")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, defaultOfflineCaptureInterval, cfg.OfflineCaptureInterval)
+		assert.Equal(t, defaultOfflineCaptureMaxFiles, cfg.OfflineCaptureMaxFiles)
+	})
+}
+
 func TestEnableHTTPMonitoring(t *testing.T) {
 	t.Run("via YAML", func(t *testing.T) {
 		newConfig(t)
-		_, err := sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-EnableHTTP.yaml")
+		_, err := sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-EnableHTTP.yaml")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableHTTPMonitoring)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTP_MONITORING", "true")
+
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableHTTPMonitoring)
+	})
+}
+
+func TestEnableJavaTLSSupport(t *testing.T) {
+	t.Run("via YAML", func(t *testing.T) {
+		newConfig(t)
+
+		_, err := sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-EnableJavaTLS.yaml")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableJavaTLSSupport)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+
+		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_JAVA_TLS_SUPPORT", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableJavaTLSSupport)
+	})
+}
+
+func TestDefaultDisabledJavaTLSSupport(t *testing.T) {
+	newConfig(t)
+
+	_, err := sysconfig.New("")
+	require.NoError(t, err)
+	cfg := New()
+
+	assert.False(t, cfg.EnableJavaTLSSupport)
+}
+
+func TestDisableGatewayLookup(t *testing.T) {
+	t.Run("via YAML", func(t *testing.T) {
+		newConfig(t)
+		// default config
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableGatewayLookup)
+
+		newConfig(t)
+		_, err = sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-DisableGwLookup.yaml")
+		require.NoError(t, err)
+		cfg = New()
+
+		assert.False(t, cfg.EnableGatewayLookup)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_GATEWAY_LOOKUP", "false")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableGatewayLookup)
+	})
+}
+
+func TestReverseDNSEnrichment(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableReverseDNSEnrichment)
+		assert.Equal(t, defaultReverseDNSEnrichmentRateLimit, cfg.ReverseDNSEnrichmentRateLimit)
+		assert.Equal(t, defaultReverseDNSEnrichmentCacheSize, cfg.ReverseDNSEnrichmentCacheSize)
+	})
+
+	t.Run("via YAML", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-ReverseDNSEnrichment.yaml")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableReverseDNSEnrichment)
+		assert.Equal(t, 500, cfg.ReverseDNSEnrichmentCacheSize)
+		// invalid rate limits are reset to the default
+		assert.Equal(t, defaultReverseDNSEnrichmentRateLimit, cfg.ReverseDNSEnrichmentRateLimit)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_REVERSE_DNS_ENRICHMENT", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableReverseDNSEnrichment)
+	})
+
+	t.Run("invalid cache size", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_REVERSE_DNS_ENRICHMENT_CACHE_SIZE", "0")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, defaultReverseDNSEnrichmentCacheSize, cfg.ReverseDNSEnrichmentCacheSize)
+	})
+}
+
+func TestEbpfConntracker(t *testing.T) {
+	t.Run("enabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableEbpfConntracker)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_EBPF_CONNTRACKER", "false")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableEbpfConntracker)
+	})
+}
+
+func TestConnectionRollup(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableConnectionRollup)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_CONNECTION_ROLLUP", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableConnectionRollup)
+	})
+}
+
+func TestPerFamilyConnections(t *testing.T) {
+	t.Run("by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.CollectIPv4Conns)
+		assert.Equal(t, cfg.MaxTrackedConnections, cfg.MaxTrackedIPv6Connections)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_COLLECT_IPV4_CONNS", "false")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_MAX_TRACKED_IPV6_CONNECTIONS", "1024")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.CollectIPv4Conns)
+		assert.Equal(t, uint(1024), cfg.MaxTrackedIPv6Connections)
+	})
+}
+
+func TestQUICStats(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
 		require.NoError(t, err)
 		cfg := New()
 
-		assert.True(t, cfg.EnableHTTPMonitoring)
+		assert.False(t, cfg.EnableQUICStats)
 	})
 
 	t.Run("via ENV variable", func(t *testing.T) {
 		newConfig(t)
-		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTP_MONITORING", "true")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_QUIC_STATS", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableQUICStats)
+	})
+
+	t.Run("requires protocol classification", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_QUIC_STATS", "true")
+		t.Setenv("DD_ENABLE_PROTOCOL_CLASSIFICATION", "false")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableQUICStats)
+	})
+}
+
+func TestEbpfLess(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
 
+		assert.False(t, cfg.EnableEbpfLess)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_EBPF_LESS", "true")
+		t.Setenv("DD_SYSTEM_PROBE_SERVICE_MONITORING_ENABLED", "true")
 		_, err := sysconfig.New("")
 		require.NoError(t, err)
 		cfg := New()
 
+		assert.True(t, cfg.EnableEbpfLess)
 		assert.True(t, cfg.EnableHTTPMonitoring)
+		assert.False(t, cfg.EnableHTTPSMonitoring)
+		assert.False(t, cfg.EnableEbpfConntracker)
+		assert.False(t, cfg.ProtocolClassificationEnabled)
 	})
 }
 
-func TestEnableJavaTLSSupport(t *testing.T) {
-	t.Run("via YAML", func(t *testing.T) {
+func TestKernelConnectionFilters(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
 		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
 
-		_, err := sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-EnableJavaTLS.yaml")
+		assert.False(t, cfg.EnableKernelConnectionFilters)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_KERNEL_CONNECTION_FILTERS", "true")
+		_, err := sysconfig.New("")
 		require.NoError(t, err)
 		cfg := New()
 
-		assert.True(t, cfg.EnableJavaTLSSupport)
+		assert.True(t, cfg.EnableKernelConnectionFilters)
+	})
+
+	t.Run("disabled without eBPF", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_KERNEL_CONNECTION_FILTERS", "true")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_EBPF_LESS", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableKernelConnectionFilters)
+	})
+}
+
+func TestProcessEnvTags(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableProcessEnvTags)
 	})
 
 	t.Run("via ENV variable", func(t *testing.T) {
 		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_PROCESS_ENV_TAGS", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
 
-		t.Setenv("DD_SERVICE_MONITORING_CONFIG_ENABLE_JAVA_TLS_SUPPORT", "true")
+		assert.True(t, cfg.EnableProcessEnvTags)
+		assert.False(t, cfg.EnableProcessEventMonitoring)
+	})
+}
+
+func TestServiceNameInference(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
 		_, err := sysconfig.New("")
 		require.NoError(t, err)
 		cfg := New()
 
-		assert.True(t, cfg.EnableJavaTLSSupport)
+		assert.False(t, cfg.EnableServiceNameInference)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_SERVICE_NAME_INFERENCE", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableServiceNameInference)
 	})
 }
 
-func TestDefaultDisabledJavaTLSSupport(t *testing.T) {
-	newConfig(t)
+func TestConnectionDomains(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
 
-	_, err := sysconfig.New("")
-	require.NoError(t, err)
-	cfg := New()
+		assert.False(t, cfg.EnableConnectionDomains)
+		assert.Equal(t, defaultConnectionDomainsCacheSize, cfg.ConnectionDomainsCacheSize)
+	})
 
-	assert.False(t, cfg.EnableJavaTLSSupport)
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_CONNECTION_DOMAINS", "true")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_CONNECTION_DOMAINS_CACHE_SIZE", "500")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableConnectionDomains)
+		assert.Equal(t, 500, cfg.ConnectionDomainsCacheSize)
+	})
+
+	t.Run("invalid cache size", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_CONNECTION_DOMAINS_CACHE_SIZE", "-1")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, defaultConnectionDomainsCacheSize, cfg.ConnectionDomainsCacheSize)
+	})
 }
 
-func TestDisableGatewayLookup(t *testing.T) {
-	t.Run("via YAML", func(t *testing.T) {
+func TestDNSOverTLSMonitoring(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
 		newConfig(t)
-		// default config
 		_, err := sysconfig.New("")
 		require.NoError(t, err)
 		cfg := New()
 
-		assert.True(t, cfg.EnableGatewayLookup)
+		assert.False(t, cfg.EnableDNSOverTLSMonitoring)
+	})
 
+	t.Run("via ENV variable", func(t *testing.T) {
 		newConfig(t)
-		_, err = sysconfig.New("./testdata/TestDDAgentConfigYamlAndSystemProbeConfig-DisableGwLookup.yaml")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_DNS_OVER_TLS_MONITORING", "true")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTPS_MONITORING", "true")
+		_, err := sysconfig.New("")
 		require.NoError(t, err)
-		cfg = New()
+		cfg := New()
 
-		assert.False(t, cfg.EnableGatewayLookup)
+		assert.True(t, cfg.EnableDNSOverTLSMonitoring)
+	})
+
+	t.Run("requires https monitoring", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_DNS_OVER_TLS_MONITORING", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableDNSOverTLSMonitoring)
+	})
+}
+
+func TestEnableFentry(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableFentry)
 	})
 
 	t.Run("via ENV variable", func(t *testing.T) {
 		newConfig(t)
-		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_GATEWAY_LOOKUP", "false")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_FENTRY", "true")
 		_, err := sysconfig.New("")
 		require.NoError(t, err)
 		cfg := New()
 
-		assert.False(t, cfg.EnableGatewayLookup)
+		assert.True(t, cfg.EnableFentry)
+	})
+}
+
+func TestEnableHTTPStatsByStatusCode(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableHTTPStatsByStatusCode)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTP_STATS_BY_STATUS_CODE", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableHTTPStatsByStatusCode)
+	})
+}
+
+func TestEnableHTTPStatsByMethod(t *testing.T) {
+	t.Run("enabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableHTTPStatsByMethod)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_ENABLE_HTTP_STATS_BY_METHOD", "false")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableHTTPStatsByMethod)
+	})
+}
+
+func TestHTTPCaptureHeaders(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Empty(t, cfg.HTTPCaptureHeaders)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_HTTP_CAPTURE_HEADERS", "X-Request-Id User-Agent")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, []string{"x-request-id", "user-agent"}, cfg.HTTPCaptureHeaders)
+	})
+}
+
+func TestTLSLibraryWatcher(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableTLSLibraryWatcher)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_SERVICE_MONITORING_ENABLE_TLS_LIBRARY_WATCHER", "true")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableTLSLibraryWatcher)
+	})
+}
+
+func TestJavaTLSInjectionRules(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Empty(t, cfg.JavaTLSAllowRegex)
+		assert.Empty(t, cfg.JavaTLSBlockRegex)
+		assert.Empty(t, cfg.JavaTLSAllowUIDs)
+		assert.Empty(t, cfg.JavaTLSBlockUIDs)
+		assert.Empty(t, cfg.JavaTLSAllowContainerLabels)
+		assert.Empty(t, cfg.JavaTLSBlockContainerLabels)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_ALLOW_REGEX", "-jar .*shop")
+		t.Setenv("DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_BLOCK_REGEX", "trading")
+		t.Setenv("DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_ALLOW_UIDS", "1000 1001")
+		t.Setenv("DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_BLOCK_UIDS", "0")
+		t.Setenv("DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_ALLOW_CONTAINER_LABELS", "team=payments")
+		t.Setenv("DD_SYSTEM_PROBE_SERVICE_MONITORING_JAVA_TLS_BLOCK_CONTAINER_LABELS", "usm=off latency-sensitive")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, "-jar .*shop", cfg.JavaTLSAllowRegex)
+		assert.Equal(t, "trading", cfg.JavaTLSBlockRegex)
+		assert.Equal(t, []string{"1000", "1001"}, cfg.JavaTLSAllowUIDs)
+		assert.Equal(t, []string{"0"}, cfg.JavaTLSBlockUIDs)
+		assert.Equal(t, []string{"team=payments"}, cfg.JavaTLSAllowContainerLabels)
+		assert.Equal(t, []string{"usm=off", "latency-sensitive"}, cfg.JavaTLSBlockContainerLabels)
+	})
+}
+
+func TestHTTPMaxPathSize(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, 0, cfg.HTTPMaxPathSize)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_HTTP_MAX_PATH_SIZE", "64")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, 64, cfg.HTTPMaxPathSize)
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_HTTP_MAX_PATH_SIZE", "-1")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, 0, cfg.HTTPMaxPathSize)
+	})
+}
+
+func TestHTTPPathQuantization(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		newConfig(t)
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.False(t, cfg.EnableHTTPPathQuantization)
+		assert.Equal(t, defaultHTTPPathQuantizationMinHits, cfg.HTTPPathQuantizationMinHits)
+		assert.Equal(t, defaultHTTPPathQuantizationMaxEndpoints, cfg.HTTPPathQuantizationMaxEndpoints)
+	})
+
+	t.Run("via ENV variable", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_HTTP_PATH_QUANTIZATION_ENABLED", "true")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_HTTP_PATH_QUANTIZATION_MIN_HITS", "5")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_HTTP_PATH_QUANTIZATION_MAX_ENDPOINTS", "100")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.True(t, cfg.EnableHTTPPathQuantization)
+		assert.Equal(t, 5, cfg.HTTPPathQuantizationMinHits)
+		assert.Equal(t, 100, cfg.HTTPPathQuantizationMaxEndpoints)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		newConfig(t)
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_HTTP_PATH_QUANTIZATION_MIN_HITS", "0")
+		t.Setenv("DD_SYSTEM_PROBE_NETWORK_HTTP_PATH_QUANTIZATION_MAX_ENDPOINTS", "-1")
+		_, err := sysconfig.New("")
+		require.NoError(t, err)
+		cfg := New()
+
+		assert.Equal(t, defaultHTTPPathQuantizationMinHits, cfg.HTTPPathQuantizationMinHits)
+		assert.Equal(t, defaultHTTPPathQuantizationMaxEndpoints, cfg.HTTPPathQuantizationMaxEndpoints)
 	})
 }
 
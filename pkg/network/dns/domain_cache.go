@@ -0,0 +1,260 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows || linux_bpf
+// +build windows linux_bpf
+
+package dns
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// domainPendingTimeout is how long the responses received by a DNS client whose network namespace is unknown are
+	// kept, waiting for the client to be registered by the next query of the connections
+	domainPendingTimeout = 2 * time.Minute
+	// domainClientTimeout is how long the network namespace of a registered DNS client is remembered, so that its
+	// next responses are attributed to it directly
+	domainClientTimeout = 2 * time.Minute
+)
+
+type dnsClient struct {
+	ip   util.Address
+	port uint16
+}
+
+type dnsClientVal struct {
+	netns   uint32
+	expires time.Time
+}
+
+type pendingResponse struct {
+	domain   Hostname
+	ips      map[util.Address]time.Time
+	received time.Time
+}
+
+type domainCacheVal struct {
+	domain   Hostname
+	deadline time.Time
+	// inUse keeps track of whether the record was returned for a connection since the last query of the connections,
+	// in which case it is not expired out, similarly to dnsCacheVal.
+	inUse bool
+}
+
+// domainCache maps the addresses resolved by the DNS responses to the domains they were resolved from, within the
+// network namespace of the DNS client which received them. The snooper only knows the address and port of the client
+// a response was sent to, so the responses are kept pending until the connection of the client, whose network
+// namespace is known, is registered. Entries expire along with the TTL of their DNS records.
+type domainCache struct {
+	// Telemetry
+	length   *atomic.Int64
+	lookups  *atomic.Int64
+	resolved *atomic.Int64
+	added    *atomic.Int64
+	expired  *atomic.Int64
+	dropped  *atomic.Int64
+
+	mux          sync.Mutex
+	data         map[DomainKey]*domainCacheVal
+	clients      map[dnsClient]*dnsClientVal
+	pending      map[dnsClient][]pendingResponse
+	pendingCount int
+	size         int
+	exit         chan struct{}
+}
+
+func newDomainCache(size int, expirationPeriod time.Duration) *domainCache {
+	cache := &domainCache{
+		length:   atomic.NewInt64(0),
+		lookups:  atomic.NewInt64(0),
+		resolved: atomic.NewInt64(0),
+		added:    atomic.NewInt64(0),
+		expired:  atomic.NewInt64(0),
+		dropped:  atomic.NewInt64(0),
+		data:     make(map[DomainKey]*domainCacheVal),
+		clients:  make(map[dnsClient]*dnsClientVal),
+		pending:  make(map[dnsClient][]pendingResponse),
+		size:     size,
+		exit:     make(chan struct{}),
+	}
+
+	ticker := time.NewTicker(expirationPeriod)
+	go func() {
+		for {
+			select {
+			case now := <-ticker.C:
+				cache.Expire(now)
+			case <-cache.exit:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return cache
+}
+
+// Add adds the translation of a DNS response received by the given client. The translation is copied, so it can be
+// recycled by the caller.
+func (c *domainCache) Add(clientIP util.Address, clientPort uint16, translation *translation, now time.Time) {
+	if translation == nil || len(translation.ips) == 0 {
+		return
+	}
+
+	client := dnsClient{ip: clientIP, port: clientPort}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	if val, ok := c.clients[client]; ok && now.Before(val.expires) {
+		for addr, deadline := range translation.ips {
+			c.set(DomainKey{NetNS: val.netns, Addr: addr}, translation.dns, deadline)
+		}
+		return
+	}
+
+	if c.pendingCount >= c.size {
+		c.dropped.Inc()
+		return
+	}
+	ips := make(map[util.Address]time.Time, len(translation.ips))
+	for addr, deadline := range translation.ips {
+		ips[addr] = deadline
+	}
+	c.pending[client] = append(c.pending[client], pendingResponse{domain: translation.dns, ips: ips, received: now})
+	c.pendingCount++
+}
+
+// Resolve registers the network namespaces of the given DNS clients, then returns the domains the given destinations
+// were resolved from. The destinations which were not resolved are not part of the returned map.
+func (c *domainCache) Resolve(clients []ClientKey, dests []DomainKey, now time.Time) map[DomainKey]Hostname {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	for _, val := range c.data {
+		val.inUse = false
+	}
+
+	for _, key := range clients {
+		client := dnsClient{ip: key.IP, port: key.Port}
+		c.clients[client] = &dnsClientVal{netns: key.NetNS, expires: now.Add(domainClientTimeout)}
+		for _, response := range c.pending[client] {
+			for addr, deadline := range response.ips {
+				if deadline.Before(now) {
+					continue
+				}
+				c.set(DomainKey{NetNS: key.NetNS, Addr: addr}, response.domain, deadline)
+			}
+		}
+		c.pendingCount -= len(c.pending[client])
+		delete(c.pending, client)
+	}
+	c.length.Store(int64(len(c.data)))
+
+	if len(dests) == 0 {
+		return nil
+	}
+
+	domains := make(map[DomainKey]Hostname)
+	for _, key := range dests {
+		if _, ok := domains[key]; ok {
+			continue
+		}
+		c.lookups.Inc()
+		val, ok := c.data[key]
+		if !ok {
+			continue
+		}
+		val.inUse = true
+		domains[key] = val.domain
+		c.resolved.Inc()
+	}
+	return domains
+}
+
+// set maps the address to the domain, the latest response prevailing. Must be called with mux held.
+func (c *domainCache) set(key DomainKey, domain Hostname, deadline time.Time) {
+	if val, ok := c.data[key]; ok {
+		val.domain = domain
+		if deadline.After(val.deadline) {
+			val.deadline = deadline
+		}
+		val.inUse = true
+		return
+	}
+	if len(c.data) >= c.size {
+		c.dropped.Inc()
+		return
+	}
+
+	c.added.Inc()
+	// flag as in use, so mapping survives until next time connections are queried, in case TTL is shorter
+	c.data[key] = &domainCacheVal{domain: domain, deadline: deadline, inUse: true}
+}
+
+// Expire removes the entries whose TTL expired and which are not in use, along with the pending responses and the
+// DNS clients which timed out
+func (c *domainCache) Expire(now time.Time) {
+	expired := 0
+	c.mux.Lock()
+	for key, val := range c.data {
+		if val.inUse || !val.deadline.Before(now) {
+			continue
+		}
+		expired++
+		delete(c.data, key)
+	}
+	for client, responses := range c.pending {
+		kept := responses[:0]
+		for _, response := range responses {
+			if now.Sub(response.received) < domainPendingTimeout {
+				kept = append(kept, response)
+			}
+		}
+		c.pendingCount -= len(responses) - len(kept)
+		if len(kept) == 0 {
+			delete(c.pending, client)
+			continue
+		}
+		c.pending[client] = kept
+	}
+	for client, val := range c.clients {
+		if val.expires.Before(now) {
+			delete(c.clients, client)
+		}
+	}
+	total := len(c.data)
+	c.mux.Unlock()
+
+	c.expired.Store(int64(expired))
+	c.length.Store(int64(total))
+	log.Debugf("dns domain entries expired. took=%s total=%d expired=%d", time.Now().Sub(now), total, expired)
+}
+
+func (c *domainCache) Stats() map[string]int64 {
+	c.mux.Lock()
+	pending := c.pendingCount
+	c.mux.Unlock()
+
+	return map[string]int64{
+		"domain_lookups":  c.lookups.Load(),
+		"domain_resolved": c.resolved.Load(),
+		"domain_added":    c.added.Load(),
+		"domain_expired":  c.expired.Load(),
+		"domain_dropped":  c.dropped.Load(),
+		"domain_ips":      c.length.Load(),
+		"domain_pending":  int64(pending),
+	}
+}
+
+func (c *domainCache) Close() {
+	c.exit <- struct{}{}
+}
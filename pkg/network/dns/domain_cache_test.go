@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows || linux_bpf
+// +build windows linux_bpf
+
+package dns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestDomainCachePendingUntilClientRegistered(t *testing.T) {
+	cache := newDomainCache(100, disableAutomaticExpiration)
+	defer cache.Close()
+
+	clientIP := util.AddressFromString("10.0.0.2")
+	dest := util.AddressFromString("52.85.98.155")
+	tr := newTranslation("datadoghq.com")
+	tr.add(dest, time.Minute)
+
+	now := time.Now()
+	cache.Add(clientIP, 40000, tr, now)
+
+	// the network namespace of the client is not known yet
+	key := DomainKey{NetNS: 1, Addr: dest}
+	assert.Empty(t, cache.Resolve(nil, []DomainKey{key}, now))
+
+	clients := []ClientKey{{NetNS: 1, IP: clientIP, Port: 40000}}
+	domains := cache.Resolve(clients, []DomainKey{key, {NetNS: 2, Addr: dest}}, now)
+	assert.Equal(t, map[DomainKey]Hostname{key: ToHostname("datadoghq.com")}, domains)
+	assert.Zero(t, cache.pendingCount)
+}
+
+func TestDomainCacheRegisteredClient(t *testing.T) {
+	cache := newDomainCache(100, disableAutomaticExpiration)
+	defer cache.Close()
+
+	clientIP := util.AddressFromString("10.0.0.2")
+	now := time.Now()
+	cache.Resolve([]ClientKey{{NetNS: 1, IP: clientIP, Port: 40000}}, nil, now)
+
+	dest := util.AddressFromString("52.85.98.155")
+	tr := newTranslation("datadoghq.com")
+	tr.add(dest, time.Minute)
+	cache.Add(clientIP, 40000, tr, now)
+
+	// the responses of a registered client are not kept pending
+	assert.Zero(t, cache.pendingCount)
+	key := DomainKey{NetNS: 1, Addr: dest}
+	assert.Equal(t, map[DomainKey]Hostname{key: ToHostname("datadoghq.com")}, cache.Resolve(nil, []DomainKey{key}, now))
+
+	// the latest response prevails
+	tr = newTranslation("app.datadoghq.com")
+	tr.add(dest, time.Minute)
+	cache.Add(clientIP, 40000, tr, now)
+	assert.Equal(t, map[DomainKey]Hostname{key: ToHostname("app.datadoghq.com")}, cache.Resolve(nil, []DomainKey{key}, now))
+}
+
+func TestDomainCacheExpiration(t *testing.T) {
+	cache := newDomainCache(100, disableAutomaticExpiration)
+	defer cache.Close()
+
+	clientIP := util.AddressFromString("10.0.0.2")
+	dest := util.AddressFromString("52.85.98.155")
+	tr := newTranslation("datadoghq.com")
+	tr.add(dest, time.Second)
+
+	now := time.Now()
+	cache.Resolve([]ClientKey{{NetNS: 1, IP: clientIP, Port: 40000}}, nil, now)
+	cache.Add(clientIP, 40000, tr, now)
+
+	// the entry is in use until the next query of the connections, even though its TTL expired
+	later := now.Add(time.Minute)
+	cache.Expire(later)
+	key := DomainKey{NetNS: 1, Addr: dest}
+	require.Len(t, cache.Resolve(nil, []DomainKey{key}, later), 1)
+
+	// the entry is still used by a connection
+	cache.Expire(later)
+	require.Len(t, cache.Resolve(nil, nil, later), 0)
+	cache.Expire(later)
+	assert.Empty(t, cache.Resolve(nil, []DomainKey{key}, later))
+	assert.Equal(t, int64(1), cache.Stats()["domain_expired"])
+}
+
+func TestDomainCachePendingTimeout(t *testing.T) {
+	cache := newDomainCache(100, disableAutomaticExpiration)
+	defer cache.Close()
+
+	clientIP := util.AddressFromString("10.0.0.2")
+	tr := newTranslation("datadoghq.com")
+	tr.add(util.AddressFromString("52.85.98.155"), time.Hour)
+
+	now := time.Now()
+	cache.Add(clientIP, 40000, tr, now)
+	require.Equal(t, 1, cache.pendingCount)
+
+	cache.Expire(now.Add(domainPendingTimeout))
+	assert.Zero(t, cache.pendingCount)
+	assert.Empty(t, cache.pending)
+}
+
+func TestDomainCacheSize(t *testing.T) {
+	cache := newDomainCache(1, disableAutomaticExpiration)
+	defer cache.Close()
+
+	clientIP := util.AddressFromString("10.0.0.2")
+	tr := newTranslation("datadoghq.com")
+	tr.add(util.AddressFromString("52.85.98.155"), time.Minute)
+	tr.add(util.AddressFromString("52.85.98.143"), time.Minute)
+
+	now := time.Now()
+	cache.Add(clientIP, 40000, tr, now)
+	cache.Add(clientIP, 40001, tr, now)
+	assert.Equal(t, 1, cache.pendingCount)
+
+	cache.Resolve([]ClientKey{{NetNS: 1, IP: clientIP, Port: 40000}}, nil, now)
+	assert.Len(t, cache.data, 1)
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(2), stats["domain_dropped"])
+	assert.Equal(t, int64(1), stats["domain_ips"])
+}
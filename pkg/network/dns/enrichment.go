@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows || linux_bpf
+// +build windows linux_bpf
+
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// ptrLookupTimeout is the maximum time spent on a single reverse DNS lookup
+	ptrLookupTimeout = 2 * time.Second
+	// ptrCacheTTL is how long the result of a reverse DNS lookup, successful or not, is cached
+	ptrCacheTTL = 10 * time.Minute
+	// ptrQueueSize is the maximum number of addresses awaiting a reverse DNS lookup
+	ptrQueueSize = 1024
+)
+
+type ptrCacheVal struct {
+	names   []Hostname
+	expires time.Time
+}
+
+// enrichedReverseDNS resolves the addresses which could not be resolved from the DNS traffic observed by the wrapped
+// ReverseDNS with reverse (PTR) DNS lookups. Lookups are performed in the background at a limited rate, so an address
+// is only enriched once its lookup completed, usually by the next time connections are queried. The results of the
+// lookups are kept in an LRU cache, which evicts the least recently used addresses when full.
+type enrichedReverseDNS struct {
+	ReverseDNS
+
+	lookupAddr func(ctx context.Context, addr string) ([]string, error)
+	limiter    *rate.Limiter
+	queue      chan util.Address
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+
+	mux    sync.Mutex
+	cache  *simplelru.LRU
+	queued map[util.Address]struct{}
+
+	// Telemetry
+	lookups  *atomic.Int64
+	resolved *atomic.Int64
+	failed   *atomic.Int64
+	dropped  *atomic.Int64
+	evicted  *atomic.Int64
+}
+
+// NewEnrichedReverseDNS returns a ReverseDNS falling back to reverse DNS lookups for the addresses rdns can't resolve
+func NewEnrichedReverseDNS(rdns ReverseDNS, cfg *config.Config) ReverseDNS {
+	return newEnrichedReverseDNS(rdns, net.DefaultResolver.LookupAddr, cfg.ReverseDNSEnrichmentRateLimit, cfg.ReverseDNSEnrichmentCacheSize)
+}
+
+func newEnrichedReverseDNS(rdns ReverseDNS, lookupAddr func(context.Context, string) ([]string, error), rateLimit int, size int) *enrichedReverseDNS {
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &enrichedReverseDNS{
+		ReverseDNS: rdns,
+		lookupAddr: lookupAddr,
+		limiter:    rate.NewLimiter(rate.Limit(rateLimit), 1),
+		queue:      make(chan util.Address, ptrQueueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+		queued:     make(map[util.Address]struct{}),
+		lookups:    atomic.NewInt64(0),
+		resolved:   atomic.NewInt64(0),
+		failed:     atomic.NewInt64(0),
+		dropped:    atomic.NewInt64(0),
+		evicted:    atomic.NewInt64(0),
+	}
+	e.cache, _ = simplelru.NewLRU(size, func(_, _ interface{}) {
+		e.evicted.Inc()
+	})
+	return e
+}
+
+func (e *enrichedReverseDNS) Start() error {
+	if err := e.ReverseDNS.Start(); err != nil {
+		return err
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		for {
+			select {
+			case addr := <-e.queue:
+				if err := e.limiter.Wait(e.ctx); err != nil {
+					return
+				}
+				e.lookup(addr)
+			case <-e.ctx.Done():
+				return
+			}
+		}
+	}()
+	log.Infof("reverse dns enrichment enabled with rate_limit=%v lookups/sec", e.limiter.Limit())
+	return nil
+}
+
+func (e *enrichedReverseDNS) Resolve(ips []util.Address) map[util.Address][]Hostname {
+	names := e.ReverseDNS.Resolve(ips)
+
+	now := time.Now()
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	for _, addr := range ips {
+		if _, ok := names[addr]; ok || !shouldEnrich(addr) {
+			continue
+		}
+
+		cached, ok := e.cache.Get(addr)
+		if !ok {
+			e.enqueue(addr)
+			continue
+		}
+		val := cached.(*ptrCacheVal)
+		if now.After(val.expires) {
+			// the entry is replaced once the address is looked up again
+			e.enqueue(addr)
+			continue
+		}
+		if len(val.names) == 0 {
+			continue
+		}
+		if names == nil {
+			names = make(map[util.Address][]Hostname)
+		}
+		names[addr] = val.names
+	}
+	return names
+}
+
+func (e *enrichedReverseDNS) GetStats() map[string]int64 {
+	stats := e.ReverseDNS.GetStats()
+	if stats == nil {
+		stats = make(map[string]int64)
+	}
+
+	e.mux.Lock()
+	stats["ptr_cache_size"] = int64(e.cache.Len())
+	e.mux.Unlock()
+	stats["ptr_lookups"] = e.lookups.Load()
+	stats["ptr_resolved"] = e.resolved.Load()
+	stats["ptr_failed"] = e.failed.Load()
+	stats["ptr_dropped"] = e.dropped.Load()
+	stats["ptr_evicted"] = e.evicted.Load()
+	return stats
+}
+
+func (e *enrichedReverseDNS) Close() {
+	e.cancel()
+	e.wg.Wait()
+	e.ReverseDNS.Close()
+}
+
+// enqueue schedules the lookup of the given address, unless it is already scheduled. Must be called with mux held.
+func (e *enrichedReverseDNS) enqueue(addr util.Address) {
+	if _, ok := e.queued[addr]; ok {
+		return
+	}
+
+	select {
+	case e.queue <- addr:
+		e.queued[addr] = struct{}{}
+	default:
+		e.dropped.Inc()
+	}
+}
+
+func (e *enrichedReverseDNS) lookup(addr util.Address) {
+	ctx, cancel := context.WithTimeout(e.ctx, ptrLookupTimeout)
+	defer cancel()
+
+	e.lookups.Inc()
+	hosts, err := e.lookupAddr(ctx, addr.String())
+	val := &ptrCacheVal{expires: time.Now().Add(ptrCacheTTL)}
+	if err != nil {
+		// failed lookups are cached as well, so they are not retried until the entry expires
+		e.failed.Inc()
+		log.Tracef("reverse dns lookup of %s failed: %s", addr, err)
+	} else {
+		e.resolved.Inc()
+		for _, host := range hosts {
+			val.names = append(val.names, ToHostname(strings.TrimSuffix(host, ".")))
+		}
+	}
+
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	delete(e.queued, addr)
+	e.cache.Add(addr, val)
+}
+
+// shouldEnrich returns false for the addresses reverse DNS lookups are pointless for
+func shouldEnrich(addr util.Address) bool {
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsUnspecified() && !addr.IsMulticast() && !addr.IsLinkLocalUnicast()
+}
+
+var _ ReverseDNS = &enrichedReverseDNS{}
@@ -0,0 +1,115 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows || linux_bpf
+// +build windows linux_bpf
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+type observedReverseDNS struct {
+	nullReverseDNS
+	names map[util.Address][]Hostname
+}
+
+func (o observedReverseDNS) Resolve(ips []util.Address) map[util.Address][]Hostname {
+	resolved := make(map[util.Address][]Hostname)
+	for _, ip := range ips {
+		if names, ok := o.names[ip]; ok {
+			resolved[ip] = names
+		}
+	}
+	return resolved
+}
+
+func TestEnrichedReverseDNS(t *testing.T) {
+	observed := util.AddressFromString("10.0.0.1")
+	unobserved := util.AddressFromString("10.0.0.2")
+	unknown := util.AddressFromString("10.0.0.3")
+	loopback := util.AddressFromString("127.0.0.1")
+
+	lookups := atomic.NewInt64(0)
+	lookupAddr := func(_ context.Context, addr string) ([]string, error) {
+		lookups.Inc()
+		if addr == unobserved.String() {
+			return []string{"host.example.com."}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	inner := observedReverseDNS{names: map[util.Address][]Hostname{observed: {ToHostname("observed.example.com")}}}
+	rdns := newEnrichedReverseDNS(inner, lookupAddr, 1000, 100)
+	require.NoError(t, rdns.Start())
+	t.Cleanup(rdns.Close)
+
+	ips := []util.Address{observed, unobserved, unknown, loopback}
+	names := rdns.Resolve(ips)
+	assert.Equal(t, []Hostname{ToHostname("observed.example.com")}, names[observed])
+	assert.NotContains(t, names, unobserved)
+
+	// the lookups are performed in the background
+	require.Eventually(t, func() bool {
+		return rdns.GetStats()["ptr_cache_size"] == 2
+	}, 3*time.Second, 10*time.Millisecond)
+
+	names = rdns.Resolve(ips)
+	assert.Equal(t, []Hostname{ToHostname("observed.example.com")}, names[observed])
+	assert.Equal(t, []Hostname{ToHostname("host.example.com")}, names[unobserved])
+	assert.NotContains(t, names, unknown)
+	assert.NotContains(t, names, loopback)
+
+	// neither the observed addresses, the loopback address nor the failed lookups are looked up again
+	assert.Equal(t, int64(2), lookups.Load())
+	stats := rdns.GetStats()
+	assert.Equal(t, int64(1), stats["ptr_resolved"])
+	assert.Equal(t, int64(1), stats["ptr_failed"])
+}
+
+func TestEnrichedReverseDNSCacheEviction(t *testing.T) {
+	lookupAddr := func(_ context.Context, addr string) ([]string, error) {
+		return []string{addr + ".example.com"}, nil
+	}
+
+	rdns := newEnrichedReverseDNS(nullReverseDNS{}, lookupAddr, 1000, 2)
+	require.NoError(t, rdns.Start())
+	t.Cleanup(rdns.Close)
+
+	first := util.AddressFromString("10.0.0.1")
+	second := util.AddressFromString("10.0.0.2")
+	third := util.AddressFromString("10.0.0.3")
+	resolve := func(addr util.Address, lookups int64) {
+		rdns.Resolve([]util.Address{addr})
+		require.Eventually(t, func() bool {
+			return rdns.GetStats()["ptr_lookups"] == lookups
+		}, 3*time.Second, 10*time.Millisecond)
+	}
+
+	resolve(first, 1)
+	resolve(second, 2)
+	// the first address is used again, so the second one is the least recently used when the cache is full
+	assert.Contains(t, rdns.Resolve([]util.Address{first}), first)
+	resolve(third, 3)
+
+	require.Eventually(t, func() bool {
+		return rdns.GetStats()["ptr_evicted"] == 1
+	}, 3*time.Second, 10*time.Millisecond)
+	names := rdns.Resolve([]util.Address{first, second, third})
+	assert.Equal(t, []Hostname{ToHostname("10.0.0.1.example.com")}, names[first])
+	assert.Equal(t, []Hostname{ToHostname("10.0.0.3.example.com")}, names[third])
+	assert.NotContains(t, names, second)
+	assert.Equal(t, int64(2), rdns.GetStats()["ptr_cache_size"])
+}
@@ -44,7 +44,8 @@ func NewReverseDNS(cfg *config.Config) (ReverseDNS, error) {
 	var p *ebpfProgram
 	var filter *manager.Probe
 	var bpfFilter []bpf.RawInstruction
-	if pre410Kernel {
+	// the kernels older than 4.1 can't attach eBPF socket filters, which are forbidden in eBPF-less mode
+	if pre410Kernel || cfg.EnableEbpfLess {
 		bpfFilter, err = generateBPFFilter(cfg)
 		if err != nil {
 			return nil, fmt.Errorf("error creating bpf classic filter: %w", err)
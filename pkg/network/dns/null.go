@@ -42,6 +42,12 @@ func (nullReverseDNS) GetStats() map[string]int64 {
 	}
 }
 
+func (nullReverseDNS) ResolveDomains(_ []ClientKey, _ []DomainKey) map[DomainKey]Hostname {
+	return nil
+}
+
+func (nullReverseDNS) ProcessTLSSegment(_ TLSSegment) {}
+
 func (nullReverseDNS) Start() error {
 	return nil
 }
@@ -10,6 +10,9 @@ package dns
 
 import (
 	"bytes"
+	"net"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -22,7 +25,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
-const maxIPBufferSize = 200
+const (
+	maxIPBufferSize = 200
+
+	// the domains under which the names of the reverse (PTR) lookups of the IPv4 and IPv6 addresses are
+	ipv4ReverseLookupSuffix = ".in-addr.arpa"
+	ipv6ReverseLookupSuffix = ".ip6.arpa"
+)
 
 var (
 	errTruncated      = errors.New("the packet is truncated")
@@ -60,16 +69,26 @@ var (
 )
 
 type dnsParser struct {
-	decoder            *gopacket.DecodingLayerParser
-	layers             []gopacket.LayerType
-	ipv4Payload        *layers.IPv4
-	ipv6Payload        *layers.IPv6
-	udpPayload         *layers.UDP
-	tcpPayload         *tcpWithDNSSupport
-	dnsPayload         *layers.DNS
-	collectDNSStats    bool
-	collectDNSDomains  bool
+	decoder           *gopacket.DecodingLayerParser
+	layers            []gopacket.LayerType
+	ipv4Payload       *layers.IPv4
+	ipv6Payload       *layers.IPv6
+	udpPayload        *layers.UDP
+	tcpPayload        *tcpWithDNSSupport
+	dnsPayload        *layers.DNS
+	collectDNSStats   bool
+	collectDNSDomains bool
+	// fillKeys is set if the clients and servers of the DNS messages are needed, by the stats or by the resolution of
+	// the connection domains
+	fillKeys           bool
 	recordedQueryTypes map[layers.DNSType]struct{}
+	// collectReverseLookups is set if the responses to the reverse (PTR) lookups are parsed into translations, even if
+	// their query type is not recorded, so the names they observed are reused by the reverse DNS enrichment
+	collectReverseLookups bool
+
+	tcpStreams *tcpReassembler
+	// messages are the DNS messages completed by the last TCP segment parsed, which are still to be parsed
+	messages [][]byte
 }
 
 func newDNSParser(layerType gopacket.LayerType, cfg *config.Config) *dnsParser {
@@ -97,25 +116,43 @@ func newDNSParser(layerType gopacket.LayerType, cfg *config.Config) *dnsParser {
 	}
 	log.Infof("Recording dns query types: %v", qtypelist)
 	return &dnsParser{
-		decoder:            gopacket.NewDecodingLayerParser(layerType, stack...),
-		ipv4Payload:        ipv4Payload,
-		ipv6Payload:        ipv6Payload,
-		udpPayload:         udpPayload,
-		tcpPayload:         tcpPayload,
-		dnsPayload:         dnsPayload,
-		collectDNSStats:    cfg.CollectDNSStats,
-		collectDNSDomains:  cfg.CollectDNSDomains,
-		recordedQueryTypes: queryTypes,
+		decoder:               gopacket.NewDecodingLayerParser(layerType, stack...),
+		ipv4Payload:           ipv4Payload,
+		ipv6Payload:           ipv6Payload,
+		udpPayload:            udpPayload,
+		tcpPayload:            tcpPayload,
+		dnsPayload:            dnsPayload,
+		collectDNSStats:       cfg.CollectDNSStats,
+		fillKeys:              cfg.CollectDNSStats || cfg.EnableConnectionDomains,
+		collectDNSDomains:     cfg.CollectDNSDomains,
+		recordedQueryTypes:    queryTypes,
+		collectReverseLookups: cfg.EnableReverseDNSEnrichment,
+		tcpStreams:            newTCPReassembler(),
 	}
 }
 
+// ParseInto parses the DNS message of the packet into t and pktInfo. The DNS messages sent over TCP are reassembled
+// from the segments, which may hold several of them: the first message completed by the segment is parsed by ParseInto,
+// and the following ones by ParseNextInto.
 func (p *dnsParser) ParseInto(data []byte, t *translation, pktInfo *dnsPacketInfo) error {
+	p.messages = nil
 	err := p.decoder.DecodeLayers(data, &p.layers)
 
 	if p.decoder.Truncated {
 		return errTruncated
 	}
 
+	if len(p.layers) > 0 && p.layers[len(p.layers)-1] == layers.LayerTypeTCP {
+		if _, ok := err.(gopacket.UnsupportedLayerType); err != nil && !ok {
+			return err
+		}
+		p.messages = p.reassemble()
+		if len(p.messages) == 0 {
+			return errSkippedPayload
+		}
+		return p.ParseNextInto(t, pktInfo)
+	}
+
 	if err != nil {
 		return err
 	}
@@ -128,11 +165,73 @@ func (p *dnsParser) ParseInto(data []byte, t *translation, pktInfo *dnsPacketInf
 	if err := p.parseAnswerInto(p.dnsPayload, t, pktInfo); err != nil {
 		return err
 	}
+	if p.fillKeys {
+		p.fillKey(pktInfo)
+	}
+	return nil
+}
 
-	if !p.collectDNSStats {
-		return nil
+// HasNextMessage returns true if the last TCP segment parsed completed DNS messages which were not parsed yet
+func (p *dnsParser) HasNextMessage() bool {
+	return len(p.messages) > 0
+}
+
+// ParseNextInto parses the next DNS message completed by the last TCP segment parsed
+func (p *dnsParser) ParseNextInto(t *translation, pktInfo *dnsPacketInfo) error {
+	if len(p.messages) == 0 {
+		return errSkippedPayload
 	}
+	message := p.messages[0]
+	p.messages = p.messages[1:]
 
+	if err := p.ParseMessageInto(message, t, pktInfo); err != nil {
+		return err
+	}
+	if p.fillKeys {
+		p.fillKey(pktInfo)
+	}
+	return nil
+}
+
+// ParseMessageInto parses a DNS message sent over TCP, stripped of its length field, such as the messages of the
+// plaintext of the DNS over TLS connections. The key of pktInfo is left for the caller to fill.
+func (p *dnsParser) ParseMessageInto(message []byte, t *translation, pktInfo *dnsPacketInfo) error {
+	if err := p.dnsPayload.DecodeFromBytes(message, gopacket.NilDecodeFeedback); err != nil {
+		return err
+	}
+	return p.parseAnswerInto(p.dnsPayload, t, pktInfo)
+}
+
+// reassemble returns the DNS messages completed by the TCP segment decoded
+func (p *dnsParser) reassemble() [][]byte {
+	tcp := p.tcpPayload
+	key := tcpStreamKey{sport: uint16(tcp.SrcPort), dport: uint16(tcp.DstPort)}
+	for _, layer := range p.layers {
+		switch layer {
+		case layers.LayerTypeIPv4:
+			key.src = util.AddressFromNetIP(p.ipv4Payload.SrcIP)
+			key.dst = util.AddressFromNetIP(p.ipv4Payload.DstIP)
+		case layers.LayerTypeIPv6:
+			key.src = util.AddressFromNetIP(p.ipv6Payload.SrcIP)
+			key.dst = util.AddressFromNetIP(p.ipv6Payload.DstIP)
+		}
+	}
+
+	seq := tcp.Seq
+	if tcp.SYN {
+		// a new connection, whose data begins after the SYN
+		p.tcpStreams.Close(key)
+		seq++
+	}
+	messages := p.tcpStreams.Segment(key, seq, tcp.Payload, time.Now())
+	if tcp.FIN || tcp.RST {
+		p.tcpStreams.Close(key)
+	}
+	return messages
+}
+
+// fillKey fills the key of pktInfo from the layers of the packet decoded
+func (p *dnsParser) fillKey(pktInfo *dnsPacketInfo) {
 	for _, layer := range p.layers {
 		switch layer {
 		case layers.LayerTypeIPv4:
@@ -169,9 +268,6 @@ func (p *dnsParser) ParseInto(data []byte, t *translation, pktInfo *dnsPacketInf
 			pktInfo.key.Protocol = syscall.IPPROTO_TCP
 		}
 	}
-
-	pktInfo.transactionID = p.dnsPayload.ID
-	return nil
 }
 
 // source: https://github.com/weaveworks/scope
@@ -180,15 +276,28 @@ func (p *dnsParser) parseAnswerInto(
 	t *translation,
 	pktInfo *dnsPacketInfo,
 ) error {
+	pktInfo.transactionID = dns.ID
+
 	// Only consider singleton, A-record questions
 	if len(dns.Questions) != 1 {
 		return errSkippedPayload
 	}
 
 	question := dns.Questions[0]
-	if question.Class != layers.DNSClassIN || !p.isWantedQueryType(question.Type) {
+	if question.Class != layers.DNSClassIN {
 		return errSkippedPayload
 	}
+	if !p.isWantedQueryType(question.Type) {
+		if !p.isReverseLookup(question.Type) || !dns.QR || dns.ResponseCode != 0 {
+			return errSkippedPayload
+		}
+		if !p.extractPTRInto(question.Name, dns.Answers, t) {
+			return errSkippedPayload
+		}
+		pktInfo.pktType = reverseResponse
+		pktInfo.queryType = QueryType(question.Type)
+		return nil
+	}
 
 	// Only consider responses
 	if !dns.QR {
@@ -209,10 +318,12 @@ func (p *dnsParser) parseAnswerInto(
 	}
 
 	pktInfo.queryType = QueryType(question.Type)
-	alias := p.extractCNAME(question.Name, dns.Answers)
-	p.extractIPsInto(alias, dns.Answers, t)
-	inplaceASCIILower(question.Name)
-	t.dns = HostnameFromBytes(question.Name)
+	if !p.isReverseLookup(question.Type) || !p.extractPTRInto(question.Name, dns.Answers, t) {
+		alias := p.extractCNAME(question.Name, dns.Answers)
+		p.extractIPsInto(alias, dns.Answers, t)
+		inplaceASCIILower(question.Name)
+		t.dns = HostnameFromBytes(question.Name)
+	}
 
 	pktInfo.pktType = successfulResponse
 	return nil
@@ -245,6 +356,31 @@ func (*dnsParser) extractIPsInto(alias []byte, records []layers.DNSResourceRecor
 	}
 }
 
+// extractPTRInto fills t with the address of a reverse lookup and the first name it is mapped to by the records. It
+// returns false if the name queried isn't the one of a reverse lookup or if no record maps it.
+func (*dnsParser) extractPTRInto(domainQueried []byte, records []layers.DNSResourceRecord, t *translation) bool {
+	addr, ok := parseReverseLookupName(string(domainQueried))
+	if !ok {
+		return false
+	}
+	for _, record := range records {
+		if record.Class != layers.DNSClassIN || record.Type != layers.DNSTypePTR || len(record.PTR) == 0 {
+			continue
+		}
+		if bytes.EqualFold(domainQueried, record.Name) {
+			inplaceASCIILower(record.PTR)
+			t.dns = HostnameFromBytes(record.PTR)
+			t.add(addr, time.Duration(record.TTL)*time.Second)
+			return true
+		}
+	}
+	return false
+}
+
+func (p *dnsParser) isReverseLookup(checktype layers.DNSType) bool {
+	return p.collectReverseLookups && checktype == layers.DNSTypePTR
+}
+
 func (p *dnsParser) isWantedQueryType(checktype layers.DNSType) bool {
 	_, ok := p.recordedQueryTypes[checktype]
 	return ok
@@ -286,3 +422,48 @@ func inplaceASCIILower(s []byte) {
 		s[i] = c
 	}
 }
+
+// parseReverseLookupName returns the address whose reverse lookup queries the given name, such as
+// 4.3.2.1.in-addr.arpa for 1.2.3.4
+func parseReverseLookupName(name string) (util.Address, bool) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ipv4ReverseLookupSuffix):
+		labels := strings.Split(strings.TrimSuffix(name, ipv4ReverseLookupSuffix), ".")
+		if len(labels) != net.IPv4len {
+			return util.Address{}, false
+		}
+		ip := make(net.IP, net.IPv4len)
+		for i, label := range labels {
+			b, err := strconv.ParseUint(label, 10, 8)
+			if err != nil {
+				return util.Address{}, false
+			}
+			ip[net.IPv4len-1-i] = byte(b)
+		}
+		return util.AddressFromNetIP(ip), true
+	case strings.HasSuffix(name, ipv6ReverseLookupSuffix):
+		// one label per nibble, starting from the last one
+		labels := strings.Split(strings.TrimSuffix(name, ipv6ReverseLookupSuffix), ".")
+		if len(labels) != 2*net.IPv6len {
+			return util.Address{}, false
+		}
+		ip := make(net.IP, net.IPv6len)
+		for i, label := range labels {
+			if len(label) != 1 {
+				return util.Address{}, false
+			}
+			nibble, err := strconv.ParseUint(label, 16, 8)
+			if err != nil {
+				return util.Address{}, false
+			}
+			pos := len(labels) - 1 - i
+			if pos%2 == 0 {
+				nibble <<= 4
+			}
+			ip[pos/2] |= byte(nibble)
+		}
+		return util.AddressFromNetIP(ip), true
+	}
+	return util.Address{}, false
+}
@@ -0,0 +1,102 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build (windows && npm) || linux_bpf
+// +build windows,npm linux_bpf
+
+package dns
+
+import (
+	"testing"
+
+	"github.com/google/gopacket/layers"
+	mdns "github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestParseReverseLookupName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "4.3.2.1.in-addr.arpa", expected: "1.2.3.4"},
+		{name: "4.3.2.1.IN-ADDR.ARPA", expected: "1.2.3.4"},
+		{name: "b.a.9.8.7.6.5.0.4.0.0.0.3.0.0.0.2.0.0.0.1.0.0.0.0.0.0.0.1.2.3.4.ip6.arpa", expected: "4321:0:1:2:3:4:567:89ab"},
+		{name: "3.2.1.in-addr.arpa"},
+		{name: "256.3.2.1.in-addr.arpa"},
+		{name: "1.ip6.arpa"},
+		{name: "example.com"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr, ok := parseReverseLookupName(test.name)
+			if test.expected == "" {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			assert.Equal(t, util.AddressFromString(test.expected), addr)
+		})
+	}
+}
+
+func packReverseLookupResponse(t *testing.T, name string, ptr string) []byte {
+	query := new(mdns.Msg)
+	query.SetQuestion(name, mdns.TypePTR)
+	response := new(mdns.Msg)
+	response.SetReply(query)
+	response.Answer = append(response.Answer, &mdns.PTR{
+		Hdr: mdns.RR_Header{Name: name, Rrtype: mdns.TypePTR, Class: mdns.ClassINET, Ttl: 60},
+		Ptr: ptr,
+	})
+	message, err := response.Pack()
+	require.NoError(t, err)
+	return message
+}
+
+func TestParseReverseLookupResponse(t *testing.T) {
+	message := packReverseLookupResponse(t, "4.3.2.1.in-addr.arpa.", "Host.Example.com.")
+
+	t.Run("reverse dns enrichment disabled", func(t *testing.T) {
+		cfg := config.New()
+		cfg.EnableReverseDNSEnrichment = false
+		p := newDNSParser(layers.LayerTypeIPv4, cfg)
+
+		err := p.ParseMessageInto(message, newTranslation(""), &dnsPacketInfo{})
+		assert.Equal(t, errSkippedPayload, err)
+	})
+
+	t.Run("reverse dns enrichment enabled", func(t *testing.T) {
+		cfg := config.New()
+		cfg.EnableReverseDNSEnrichment = true
+		p := newDNSParser(layers.LayerTypeIPv4, cfg)
+
+		tr := newTranslation("")
+		pktInfo := dnsPacketInfo{}
+		require.NoError(t, p.ParseMessageInto(message, tr, &pktInfo))
+		assert.Equal(t, reverseResponse, pktInfo.pktType)
+		assert.Equal(t, ToHostname("host.example.com"), tr.dns)
+		assert.Contains(t, tr.ips, util.AddressFromString("1.2.3.4"))
+	})
+
+	t.Run("recorded query type", func(t *testing.T) {
+		cfg := config.New()
+		cfg.EnableReverseDNSEnrichment = true
+		cfg.RecordedQueryTypes = []string{layers.DNSTypePTR.String()}
+		p := newDNSParser(layers.LayerTypeIPv4, cfg)
+
+		tr := newTranslation("")
+		pktInfo := dnsPacketInfo{}
+		require.NoError(t, p.ParseMessageInto(message, tr, &pktInfo))
+		assert.Equal(t, successfulResponse, pktInfo.pktType)
+		assert.Equal(t, ToHostname("host.example.com"), tr.dns)
+		assert.Contains(t, tr.ips, util.AddressFromString("1.2.3.4"))
+	})
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
 	"go.uber.org/atomic"
 
 	"github.com/DataDog/datadog-agent/pkg/network/config"
@@ -41,6 +42,7 @@ type socketFilterSnooper struct {
 	source          packetSource
 	parser          *dnsParser
 	cache           *reverseDNSCache
+	domains         *domainCache
 	statKeeper      *dnsStatKeeper
 	exit            chan struct{}
 	wg              sync.WaitGroup
@@ -48,6 +50,13 @@ type socketFilterSnooper struct {
 
 	// cache translation object to avoid allocations
 	translation *translation
+
+	// the plaintext of the DNS over TLS connections is processed concurrently to the packets, hence with its own
+	// parser, streams and translation
+	tlsMux         sync.Mutex
+	tlsParser      *dnsParser
+	tlsStreams     *tcpReassembler
+	tlsTranslation *translation
 }
 
 // packetSource reads raw packet data
@@ -82,6 +91,11 @@ func newSocketFilterSnooper(cfg *config.Config, source packetSource) (*socketFil
 	} else {
 		log.Infof("DNS Stats Collection has been disabled.")
 	}
+	var domains *domainCache
+	if cfg.EnableConnectionDomains {
+		domains = newDomainCache(cfg.ConnectionDomainsCacheSize, dnsCacheExpirationPeriod)
+		log.Infof("DNS resolution of the connection domains has been enabled. Maximum number of addresses: %d", cfg.ConnectionDomainsCacheSize)
+	}
 	snooper := &socketFilterSnooper{
 		decodingErrors: atomic.NewInt64(0),
 		truncatedPkts:  atomic.NewInt64(0),
@@ -92,11 +106,18 @@ func newSocketFilterSnooper(cfg *config.Config, source packetSource) (*socketFil
 		source:          source,
 		parser:          newDNSParser(source.PacketType(), cfg),
 		cache:           cache,
+		domains:         domains,
 		statKeeper:      statKeeper,
 		translation:     new(translation),
 		exit:            make(chan struct{}),
 		collectLocalDNS: cfg.CollectLocalDNS,
 	}
+	if cfg.EnableDNSOverTLSMonitoring {
+		snooper.tlsParser = newDNSParser(source.PacketType(), cfg)
+		snooper.tlsStreams = newTCPReassembler()
+		snooper.tlsTranslation = new(translation)
+		log.Infof("DNS over TLS monitoring has been enabled")
+	}
 
 	// Start consuming packets
 	snooper.wg.Add(1)
@@ -119,6 +140,14 @@ func (s *socketFilterSnooper) Resolve(ips []util.Address) map[util.Address][]Hos
 	return s.cache.Get(ips)
 }
 
+// ResolveDomains returns the domains the destinations of the connections were resolved from
+func (s *socketFilterSnooper) ResolveDomains(clients []ClientKey, dests []DomainKey) map[DomainKey]Hostname {
+	if s.domains == nil {
+		return nil
+	}
+	return s.domains.Resolve(clients, dests, time.Now())
+}
+
 // GetDNSStats gets the latest Stats keyed by unique Key, and domain
 func (s *socketFilterSnooper) GetDNSStats() StatsByKeyByNameByType {
 	if s.statKeeper == nil {
@@ -137,6 +166,20 @@ func (s *socketFilterSnooper) GetStats() map[string]int64 {
 
 	stats["decoding_errors"] = s.decodingErrors.Load()
 	stats["truncated_packets"] = s.truncatedPkts.Load()
+	if s.domains != nil {
+		for key, value := range s.domains.Stats() {
+			stats[key] = value
+		}
+	}
+	for key, value := range s.parser.tcpStreams.Stats() {
+		stats[key] = value
+	}
+	if s.tlsStreams != nil {
+		for key, value := range s.tlsStreams.Stats() {
+			stats["tls_"+key] = value
+		}
+	}
+
 	stats["timestamp_micro_secs"] = time.Now().UnixNano() / 1000
 	stats["queries"] = s.queries.Load()
 	stats["successes"] = s.successes.Load()
@@ -160,6 +203,9 @@ func (s *socketFilterSnooper) Close() {
 	s.wg.Wait()
 	s.source.Close()
 	s.cache.Close()
+	if s.domains != nil {
+		s.domains.Close()
+	}
 	if s.statKeeper != nil {
 		s.statKeeper.Close()
 	}
@@ -175,7 +221,48 @@ func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
 	t := s.getCachedTranslation()
 	pktInfo := dnsPacketInfo{}
 
-	if err := s.parser.ParseInto(data, t, &pktInfo); err != nil {
+	err := s.parser.ParseInto(data, t, &pktInfo)
+	s.processMessage(t, pktInfo, err, ts)
+
+	// a TCP segment may complete several DNS messages
+	for s.parser.HasNextMessage() {
+		t = s.getCachedTranslation()
+		pktInfo = dnsPacketInfo{}
+		err = s.parser.ParseNextInto(t, &pktInfo)
+		s.processMessage(t, pktInfo, err, ts)
+	}
+
+	return nil
+}
+
+// ProcessTLSSegment retrieves DNS information from the plaintext of a DNS over TLS connection, in the same way as
+// processPacket. It is safe for concurrent use.
+func (s *socketFilterSnooper) ProcessTLSSegment(segment TLSSegment) {
+	if s.tlsParser == nil {
+		return
+	}
+
+	s.tlsMux.Lock()
+	defer s.tlsMux.Unlock()
+
+	key := tcpStreamKey{
+		src:   segment.Key.ClientIP,
+		dst:   segment.Key.ServerIP,
+		sport: segment.Key.ClientPort,
+		pid:   segment.Pid,
+		write: segment.Write,
+	}
+	for _, message := range s.tlsStreams.Stream(key, segment.Payload, segment.Truncated, segment.Timestamp) {
+		t := recycleTranslation(s.tlsTranslation)
+		pktInfo := dnsPacketInfo{key: segment.Key}
+		err := s.tlsParser.ParseMessageInto(message, t, &pktInfo)
+		s.processMessage(t, pktInfo, err, segment.Timestamp)
+	}
+}
+
+// processMessage adds the DNS information parsed from a message to the reverse DNS cache and to the stats
+func (s *socketFilterSnooper) processMessage(t *translation, pktInfo dnsPacketInfo, err error, ts time.Time) {
+	if err != nil {
 		switch err {
 		case errSkippedPayload: // no need to count or log cases where the packet is valid but has no relevant content
 		case errTruncated:
@@ -183,7 +270,13 @@ func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
 		default:
 			s.decodingErrors.Inc()
 		}
-		return nil
+		return
+	}
+
+	if pktInfo.pktType == reverseResponse {
+		// the names observed in the reverse lookups whose query type is not recorded only feed the cache
+		s.cache.Add(t)
+		return
 	}
 
 	if s.statKeeper != nil && (s.collectLocalDNS || !pktInfo.key.ServerIP.IsLoopback()) {
@@ -192,14 +285,16 @@ func (s *socketFilterSnooper) processPacket(data []byte, ts time.Time) error {
 
 	if pktInfo.pktType == successfulResponse {
 		s.cache.Add(t)
+		// the names of the reverse lookups are not the domains the clients connect to
+		if s.domains != nil && pktInfo.queryType != QueryType(layers.DNSTypePTR) {
+			s.domains.Add(pktInfo.key.ClientIP, pktInfo.key.ClientPort, t, ts)
+		}
 		s.successes.Inc()
 	} else if pktInfo.pktType == failedResponse {
 		s.errors.Inc()
 	} else {
 		s.queries.Inc()
 	}
-
-	return nil
 }
 
 func (s *socketFilterSnooper) pollPackets() {
@@ -248,8 +343,10 @@ func (s *socketFilterSnooper) logDNSStats() {
 }
 
 func (s *socketFilterSnooper) getCachedTranslation() *translation {
-	t := s.translation
+	return recycleTranslation(s.translation)
+}
 
+func recycleTranslation(t *translation) *translation {
 	// Recycle buffer if necessary
 	if t.ips == nil || len(t.ips) > maxIPBufferSize {
 		t.ips = make(map[util.Address]time.Time, 30)
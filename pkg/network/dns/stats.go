@@ -27,6 +27,9 @@ const (
 	failedResponse
 	// query means the packet contains a DNS query
 	query
+	// reverseResponse means the packet contains a successful response to a reverse (PTR) lookup, whose query type is
+	// not recorded, parsed for the names it maps the addresses to
+	reverseResponse
 )
 
 // This const limits the maximum size of the state map. Benchmark results show that allocated space is less than 3MB
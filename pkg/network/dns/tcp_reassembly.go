@@ -0,0 +1,183 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows || linux_bpf
+// +build windows linux_bpf
+
+package dns
+
+import (
+	"encoding/binary"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const (
+	// maxTCPStreams is the maximum number of DNS over TCP streams whose messages are being reassembled
+	maxTCPStreams = 1024
+	// tcpStreamTimeout is how long a stream is kept once no segment was received for it
+	tcpStreamTimeout = 30 * time.Second
+	// dnsLengthFieldSize is the size of the length field preceding the DNS messages sent over TCP, see RFC 1035 4.2.2
+	dnsLengthFieldSize = 2
+)
+
+// tcpStreamKey identifies one direction of a DNS over TCP connection. The streams of the plaintext of the DNS over
+// TLS connections are identified by the client and the server of the connection instead, along with the process
+// reading or writing the plaintext, as both ends of the connection may be hooked.
+type tcpStreamKey struct {
+	src, dst     util.Address
+	sport, dport uint16
+	pid          uint32
+	write        bool
+}
+
+type tcpStream struct {
+	buf      []byte
+	nextSeq  uint32
+	lastSeen time.Time
+}
+
+// tcpReassembler reassembles the DNS messages sent over TCP, which are preceded by their length and may span several
+// TCP segments, a single segment holding several messages as well. The segments must be received in order: the bytes
+// buffered for a stream are dropped on a gap, the segment following it being assumed to begin a message.
+//
+// A tcpReassembler is not safe for concurrent use.
+type tcpReassembler struct {
+	streams map[tcpStreamKey]*tcpStream
+
+	// Telemetry
+	length  *atomic.Int64
+	gaps    *atomic.Int64
+	dropped *atomic.Int64
+}
+
+func newTCPReassembler() *tcpReassembler {
+	return &tcpReassembler{
+		streams: make(map[tcpStreamKey]*tcpStream),
+		length:  atomic.NewInt64(0),
+		gaps:    atomic.NewInt64(0),
+		dropped: atomic.NewInt64(0),
+	}
+}
+
+// Segment processes the payload of a TCP segment, whose sequence number is seq, and returns the DNS messages it
+// completes, stripped of their length field. The returned messages are only valid until the next call.
+func (r *tcpReassembler) Segment(key tcpStreamKey, seq uint32, payload []byte, now time.Time) [][]byte {
+	stream := r.streams[key]
+	if stream != nil && stream.nextSeq != seq {
+		// drop the bytes already received (retransmissions) and reset the stream on a gap
+		delta := int32(stream.nextSeq - seq)
+		if delta > 0 && int(delta) < len(payload) {
+			payload = payload[delta:]
+			seq = stream.nextSeq
+		} else if delta > 0 {
+			return nil
+		} else {
+			r.gaps.Inc()
+			stream.buf = stream.buf[:0]
+		}
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+
+	stream = r.getStream(key, stream, now)
+	if stream == nil {
+		return nil
+	}
+	stream.nextSeq = seq + uint32(len(payload))
+	return stream.append(payload)
+}
+
+// Stream processes the next bytes of an ordered stream, such as the plaintext of a DNS over TLS connection, in the
+// same way as Segment. A truncated payload resets the stream, as the bytes following it are missing.
+func (r *tcpReassembler) Stream(key tcpStreamKey, payload []byte, truncated bool, now time.Time) [][]byte {
+	stream := r.getStream(key, r.streams[key], now)
+	if stream == nil {
+		return nil
+	}
+	messages := stream.append(payload)
+	if truncated {
+		r.gaps.Inc()
+		r.Close(key)
+	}
+	return messages
+}
+
+// Close removes the stream, once its connection was closed
+func (r *tcpReassembler) Close(key tcpStreamKey) {
+	delete(r.streams, key)
+	r.length.Store(int64(len(r.streams)))
+}
+
+func (r *tcpReassembler) getStream(key tcpStreamKey, stream *tcpStream, now time.Time) *tcpStream {
+	if stream == nil {
+		if len(r.streams) >= maxTCPStreams {
+			r.expire(now)
+			if len(r.streams) >= maxTCPStreams {
+				r.dropped.Inc()
+				return nil
+			}
+		}
+		stream = &tcpStream{}
+		r.streams[key] = stream
+		r.length.Store(int64(len(r.streams)))
+	}
+	stream.lastSeen = now
+	return stream
+}
+
+// append appends the payload to the bytes buffered for the stream, and returns the complete messages
+func (stream *tcpStream) append(payload []byte) [][]byte {
+	data := payload
+	if len(stream.buf) > 0 {
+		stream.buf = append(stream.buf, payload...)
+		data = stream.buf
+	}
+
+	var messages [][]byte
+	for len(data) >= dnsLengthFieldSize {
+		length := int(binary.BigEndian.Uint16(data))
+		if len(data) < dnsLengthFieldSize+length {
+			break
+		}
+		messages = append(messages, data[dnsLengthFieldSize:dnsLengthFieldSize+length])
+		data = data[dnsLengthFieldSize+length:]
+	}
+
+	if len(data) == 0 {
+		if len(stream.buf) > 0 {
+			// the messages point to the buffer, which is only reused by the next call
+			stream.buf = stream.buf[:0]
+		}
+		return messages
+	}
+
+	// keep the beginning of the next message, which must be copied as the payload is reused by the caller
+	stream.buf = append([]byte(nil), data...)
+	return messages
+}
+
+// expire removes the streams which did not receive a segment for tcpStreamTimeout
+func (r *tcpReassembler) expire(now time.Time) {
+	for key, stream := range r.streams {
+		if now.Sub(stream.lastSeen) >= tcpStreamTimeout {
+			delete(r.streams, key)
+		}
+	}
+	r.length.Store(int64(len(r.streams)))
+}
+
+// Stats returns telemetry about the reassembly of the DNS messages
+func (r *tcpReassembler) Stats() map[string]int64 {
+	return map[string]int64{
+		"tcp_streams":         r.length.Load(),
+		"tcp_stream_gaps":     r.gaps.Load(),
+		"tcp_streams_dropped": r.dropped.Load(),
+	}
+}
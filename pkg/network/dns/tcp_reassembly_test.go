@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build windows || linux_bpf
+// +build windows linux_bpf
+
+package dns
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func frameDNSMessage(message []byte) []byte {
+	framed := make([]byte, dnsLengthFieldSize, dnsLengthFieldSize+len(message))
+	binary.BigEndian.PutUint16(framed, uint16(len(message)))
+	return append(framed, message...)
+}
+
+func testStreamKey() tcpStreamKey {
+	return tcpStreamKey{
+		src:   util.AddressFromString("10.0.0.2"),
+		dst:   util.AddressFromString("8.8.8.8"),
+		sport: 40000,
+		dport: 53,
+	}
+}
+
+func TestTCPReassemblySplitMessage(t *testing.T) {
+	r := newTCPReassembler()
+	key := testStreamKey()
+	now := time.Now()
+	framed := frameDNSMessage([]byte("first message"))
+
+	// the length field itself is split
+	assert.Empty(t, r.Segment(key, 100, framed[:1], now))
+	assert.Empty(t, r.Segment(key, 101, framed[1:6], now))
+	messages := r.Segment(key, 106, framed[6:], now)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "first message", string(messages[0]))
+}
+
+func TestTCPReassemblyMultipleMessages(t *testing.T) {
+	r := newTCPReassembler()
+	key := testStreamKey()
+	now := time.Now()
+
+	payload := append(frameDNSMessage([]byte("first")), frameDNSMessage([]byte("second"))...)
+	payload = append(payload, frameDNSMessage([]byte("third"))[:3]...)
+	messages := r.Segment(key, 1, payload, now)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "first", string(messages[0]))
+	assert.Equal(t, "second", string(messages[1]))
+
+	messages = r.Segment(key, 1+uint32(len(payload)), frameDNSMessage([]byte("third"))[3:], now)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "third", string(messages[0]))
+}
+
+func TestTCPReassemblyRetransmission(t *testing.T) {
+	r := newTCPReassembler()
+	key := testStreamKey()
+	now := time.Now()
+	framed := frameDNSMessage([]byte("message"))
+
+	assert.Empty(t, r.Segment(key, 1, framed[:4], now))
+	// the retransmitted bytes are ignored
+	assert.Empty(t, r.Segment(key, 1, framed[:4], now))
+	messages := r.Segment(key, 3, framed[2:], now)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "message", string(messages[0]))
+	assert.Zero(t, r.gaps.Load())
+}
+
+func TestTCPReassemblyGap(t *testing.T) {
+	r := newTCPReassembler()
+	key := testStreamKey()
+	now := time.Now()
+	framed := frameDNSMessage([]byte("lost message"))
+
+	assert.Empty(t, r.Segment(key, 1, framed[:4], now))
+	// the end of the first message is lost, the next segment beginning a message
+	messages := r.Segment(key, 100, frameDNSMessage([]byte("next message")), now)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "next message", string(messages[0]))
+	assert.Equal(t, int64(1), r.gaps.Load())
+}
+
+func TestTCPReassemblyStream(t *testing.T) {
+	r := newTCPReassembler()
+	key := testStreamKey()
+	key.pid = 42
+	now := time.Now()
+	framed := frameDNSMessage([]byte("message"))
+
+	assert.Empty(t, r.Stream(key, framed[:5], false, now))
+	messages := r.Stream(key, framed[5:], false, now)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "message", string(messages[0]))
+
+	// a truncated payload ends the stream
+	assert.Empty(t, r.Stream(key, framed[:5], true, now))
+	assert.Empty(t, r.streams)
+}
+
+func TestTCPReassemblyMaxStreams(t *testing.T) {
+	r := newTCPReassembler()
+	now := time.Now()
+	partial := frameDNSMessage([]byte("message"))[:4]
+
+	key := testStreamKey()
+	for i := 0; i < maxTCPStreams; i++ {
+		key.sport = uint16(i + 1)
+		r.Segment(key, 1, partial, now)
+	}
+	key.sport = 0
+	r.Segment(key, 1, partial, now)
+	assert.Equal(t, int64(1), r.dropped.Load())
+	assert.Equal(t, int64(maxTCPStreams), r.Stats()["tcp_streams"])
+
+	// the idle streams are expired to make room for the new ones
+	r.Segment(key, 1, partial, now.Add(tcpStreamTimeout))
+	assert.Equal(t, int64(1), r.Stats()["tcp_streams"])
+}
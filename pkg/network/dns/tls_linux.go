@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package dns
+
+import (
+	"syscall"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// dnsTLSPort is the port of the DNS over TLS servers, see RFC 7858
+const dnsTLSPort = 853
+
+// TLSSegment returns the plaintext of the DNS over TLS connection, which was captured at ts. The payload points to the
+// fragment of the segment, so it is only valid as long as the segment is.
+func (s *EbpfTLSSegment) TLSSegment(ts time.Time) TLSSegment {
+	key := Key{
+		ClientIP:   util.FromLowHigh(s.Tup.Saddr_l, s.Tup.Saddr_h),
+		ServerIP:   util.FromLowHigh(s.Tup.Daddr_l, s.Tup.Daddr_h),
+		ClientPort: s.Tup.Sport,
+		Protocol:   syscall.IPPROTO_TCP,
+	}
+	if s.Tup.Sport == dnsTLSPort && s.Tup.Dport != dnsTLSPort {
+		key.ClientIP, key.ServerIP = key.ServerIP, key.ClientIP
+		key.ClientPort = s.Tup.Dport
+	}
+
+	size := int(s.Fragment_size)
+	if size > len(s.Fragment) {
+		size = len(s.Fragment)
+	}
+	return TLSSegment{
+		Key:       key,
+		Pid:       s.Pid,
+		Write:     s.Is_write != 0,
+		Payload:   s.Fragment[:size],
+		Truncated: int(s.Len) > size,
+		Timestamp: ts,
+	}
+}
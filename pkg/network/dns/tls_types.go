@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ignore
+// +build ignore
+
+package dns
+
+/*
+#include "../ebpf/c/tracer.h"
+#include "../ebpf/c/protocols/dns/defs.h"
+#include "../ebpf/c/protocols/dns/types.h"
+*/
+import "C"
+
+type ConnTuple C.conn_tuple_t
+
+type EbpfTLSSegment C.dns_tls_segment_t
+
+const (
+	TLSBufferSize = C.DNS_TLS_BUFFER_SIZE
+)
@@ -6,6 +6,8 @@
 package dns
 
 import (
+	"time"
+
 	"github.com/google/gopacket/layers"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
@@ -68,10 +70,28 @@ type ReverseDNS interface {
 	Resolve([]util.Address) map[util.Address][]Hostname
 	GetDNSStats() StatsByKeyByNameByType
 	GetStats() map[string]int64
+	// ResolveDomains registers the network namespaces of the given DNS clients, then returns the domains the given
+	// destinations were resolved from by the DNS responses the clients of their network namespace received
+	ResolveDomains(clients []ClientKey, dests []DomainKey) map[DomainKey]Hostname
+	// ProcessTLSSegment processes the plaintext read from, or written to, a DNS over TLS connection
+	ProcessTLSSegment(segment TLSSegment)
 	Start() error
 	Close()
 }
 
+// ClientKey identifies the socket a DNS client sends its queries from, within its network namespace
+type ClientKey struct {
+	NetNS uint32
+	IP    util.Address
+	Port  uint16
+}
+
+// DomainKey identifies an address within a network namespace
+type DomainKey struct {
+	NetNS uint32
+	Addr  util.Address
+}
+
 // Key is an identifier for a set of DNS connections
 type Key struct {
 	ServerIP   util.Address
@@ -81,6 +101,20 @@ type Key struct {
 	Protocol uint8
 }
 
+// TLSSegment is the plaintext read from, or written to, a DNS over TLS connection by a process. The plaintext of
+// each direction of the connection is an ordered stream of DNS messages preceded by their length, as over TCP.
+type TLSSegment struct {
+	// Key identifies the connection, whose client is the side using an ephemeral port
+	Key Key
+	Pid uint32
+	// Write is set if the plaintext was written to the connection by the process, rather than read from it
+	Write   bool
+	Payload []byte
+	// Truncated is set if the plaintext is longer than Payload, in which case the rest of the stream is lost
+	Truncated bool
+	Timestamp time.Time
+}
+
 // Stats holds statistics corresponding to a particular domain
 type Stats struct {
 	Timeouts          uint32
@@ -40,6 +40,11 @@ func ReadHTTPModule(bpfDir string, debug bool) (bytecode.AssetReader, error) {
 	return readModule(bpfDir, "http", debug)
 }
 
+// ReadHTTPFallbackModule from the asset file
+func ReadHTTPFallbackModule(bpfDir string, debug bool) (bytecode.AssetReader, error) {
+	return readModule(bpfDir, "http-fallback", debug)
+}
+
 // ReadDNSModule from the asset file
 func ReadDNSModule(bpfDir string, debug bool) (bytecode.AssetReader, error) {
 	return readModule(bpfDir, "dns", debug)
@@ -14,12 +14,42 @@
 #include "port.h"
 #include "sock.h"
 #include "tcp-recv.h"
+#include "skb.h"
+
+#include "protocols/classification/tracer-maps.h"
+#include "protocols/classification/protocol-classification.h"
 
 #define MSG_PEEK 2
 
 BPF_PERCPU_HASH_MAP(udp6_send_skb_args, u64, u64, 1024)
 BPF_PERCPU_HASH_MAP(udp_send_skb_args, u64, conn_tuple_t, 1024)
 
+// The socket filters of the protocol classification, which are the same as the ones of the kprobe tracer. The kernels
+// supporting fentry are all recent enough for the classification, so the programs are not guarded by kernel versions.
+SEC("socket/classifier_entry")
+int socket__classifier_entry(struct __sk_buff *skb) {
+    bpf_tail_call_compat(skb, &classification_progs, CLASSIFICATION_DISPATCHER_PROG);
+    return 0;
+}
+
+SEC("socket/classifier")
+int socket__classifier(struct __sk_buff *skb) {
+    protocol_classifier_entrypoint(skb);
+    return 0;
+}
+
+SEC("socket/classifier_queues")
+int socket__classifier_queues(struct __sk_buff *skb) {
+    protocol_classifier_queues_entrypoint(skb);
+    return 0;
+}
+
+SEC("socket/classifier_dbs")
+int socket__classifier_dbs(struct __sk_buff *skb) {
+    protocol_classifier_dbs_entrypoint(skb);
+    return 0;
+}
+
 static __always_inline int read_conn_tuple_partial_from_flowi4(conn_tuple_t *t, struct flowi4 *fl4, u64 pid_tgid, metadata_mask_t type) {
     t->pid = pid_tgid >> 32;
     t->metadata = type;
@@ -138,6 +168,16 @@ int BPF_PROG(tcp_recvmsg_exit, struct sock *sk, struct msghdr *msg, size_t len,
     return handle_tcp_recv(pid_tgid, sk, copied);
 }
 
+SEC("fexit/tcp_read_sock")
+int BPF_PROG(tcp_read_sock_exit, struct sock *sk, void *desc, void *recv_actor, int copied) {
+    if (copied < 0) { // error
+        return 0;
+    }
+
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    return handle_tcp_recv(pid_tgid, sk, copied);
+}
+
 SEC("fentry/tcp_close")
 int BPF_PROG(tcp_close, struct sock *sk, long timeout) {
     conn_tuple_t t = {};
@@ -181,10 +221,8 @@ static __always_inline int handle_udp_send(struct sock *sk, int sent) {
     return 0;
 }
 
-SEC("kprobe/udp_v6_send_skb")
-int kprobe__udp_v6_send_skb(struct pt_regs *ctx) {
-    struct sk_buff *skb = (struct sk_buff*) PT_REGS_PARM1(ctx);
-    struct flowi6 *fl6 = (struct flowi6*) PT_REGS_PARM2(ctx);
+SEC("fentry/udp_v6_send_skb")
+int BPF_PROG(udp_v6_send_skb, struct sk_buff *skb, struct flowi6 *fl6) {
     u64 pid_tgid = bpf_get_current_pid_tgid();
     struct sock *sk = BPF_CORE_READ(skb, sk);
     conn_tuple_t t;
@@ -206,10 +244,8 @@ int BPF_PROG(udpv6_sendmsg_exit, struct sock *sk, struct msghdr *msg, size_t len
     return handle_udp_send(sk, sent);
 }
 
-SEC("kprobe/udp_send_skb")
-int kprobe__udp_send_skb(struct pt_regs *ctx) {
-    struct sk_buff *skb = (struct sk_buff*) PT_REGS_PARM1(ctx);
-    struct flowi4 *fl4 = (struct flowi4*) PT_REGS_PARM2(ctx);
+SEC("fentry/udp_send_skb")
+int BPF_PROG(udp_send_skb, struct sk_buff *skb, struct flowi4 *fl4) {
     u64 pid_tgid = bpf_get_current_pid_tgid();
     struct sock *sk = BPF_CORE_READ(skb, sk);
     conn_tuple_t t;
@@ -285,6 +321,11 @@ int BPF_PROG(tcp_retransmit_skb, struct sock *sk, struct sk_buff *skb, int segs,
 
 SEC("fentry/tcp_set_state")
 int BPF_PROG(tcp_set_state, struct sock *sk, int state) {
+    if (state == TCP_CLOSE) {
+        handle_tcp_close_state(sk);
+        return 0;
+    }
+
     // For now we're tracking only TCP_ESTABLISHED
     if (state != TCP_ESTABLISHED) {
         return 0;
@@ -415,6 +456,41 @@ int BPF_PROG(udp_destroy_sock_exit, struct sock *sk) {
     return 0;
 }
 
+// Maps the tuple of the packets sent by the TCP sockets to the tuple of their socket, which differ when the packets
+// are NATed, so that the protocol classified from the packets is found for the connection of the socket. This is the
+// BTF-enabled version of the net/net_dev_queue tracepoint of the kprobe tracer.
+SEC("tp_btf/net_dev_queue")
+int BPF_PROG(net_dev_queue, struct sk_buff *skb) {
+    struct sock *sk = BPF_CORE_READ(skb, sk);
+    if (!sk) {
+        return 0;
+    }
+
+    conn_tuple_t skb_tup;
+    bpf_memset(&skb_tup, 0, sizeof(conn_tuple_t));
+    if (sk_buff_to_tuple(skb, &skb_tup) <= 0) {
+        return 0;
+    }
+
+    if (!(skb_tup.metadata&CONN_TYPE_TCP)) {
+        return 0;
+    }
+
+    conn_tuple_t sock_tup;
+    bpf_memset(&sock_tup, 0, sizeof(conn_tuple_t));
+    if (!read_conn_tuple(&sock_tup, sk, 0, CONN_TYPE_TCP)) {
+        return 0;
+    }
+    sock_tup.netns = 0;
+    sock_tup.pid = 0;
+
+    if (!is_equal(&skb_tup, &sock_tup)) {
+        bpf_map_update_with_telemetry(conn_tuple_to_socket_skb_conn_tuple, &sock_tup, &skb_tup, BPF_NOEXIST);
+    }
+
+    return 0;
+}
+
 //region sys_exit_bind
 
 static __always_inline int sys_exit_bind(struct socket *sock, struct sockaddr *addr, int rc) {
@@ -107,6 +107,15 @@ static __always_inline void increment_telemetry_registers_count() {
     __sync_fetch_and_add(&val->registers, 1);
 }
 
+static __always_inline void increment_telemetry_deletes_count() {
+    u64 key = 0;
+    conntrack_telemetry_t *val = bpf_map_lookup_elem(&conntrack_telemetry, &key);
+    if (val == NULL) {
+        return;
+    }
+    __sync_fetch_and_add(&val->deletes, 1);
+}
+
 static __always_inline int nf_conn_to_conntrack_tuples(struct nf_conn* ct, conntrack_tuple_t* orig, conntrack_tuple_t* reply) {
     struct nf_conntrack_tuple_hash tuplehash[IP_CT_DIR_MAX];
     bpf_memset(tuplehash, 0, sizeof(tuplehash));
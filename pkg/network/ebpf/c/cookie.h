@@ -3,10 +3,29 @@
 
 #include "ktypes.h"
 #include "bpf_helpers.h"
+#include "bpf_core_read.h"
 
 #include "defs.h"
 
+// Returns the socket cookie of the kernel (sk_cookie), which identifies sk among all the sockets created since boot,
+// or 0 if it is unknown. The cookie is only generated on demand, such as by sock_diag or by the programs calling
+// bpf_get_socket_cookie, which the kprobes can't do, so it is unknown until then, but doesn't change once generated.
+// Its offset isn't guessed for the prebuilt programs, and it doesn't exist before 4.1, for which it is always unknown.
+static __always_inline __u64 get_socket_cookie(struct sock *sk) {
+    __u64 cookie = 0;
+#if defined(COMPILE_CORE) || (defined(COMPILE_RUNTIME) && LINUX_VERSION_CODE >= KERNEL_VERSION(4, 1, 0))
+    BPF_CORE_READ_INTO(&cookie, sk, __sk_common.skc_cookie.counter);
+#endif
+    return cookie;
+}
+
+// Returns the cookie of the stats of the connection of sk, which identifies them in userspace. It is derived from the
+// socket cookie when it is known, so that the connections reusing a tuple are kept apart, and is random otherwise.
 static __always_inline u32 get_sk_cookie(struct sock *sk) {
+    __u64 sk_cookie = get_socket_cookie(sk);
+    if (sk_cookie != 0) {
+        return (u32)(sk_cookie ^ (sk_cookie >> 32));
+    }
 #if defined(COMPILE_RUNTIME) && LINUX_VERSION_CODE >= KERNEL_VERSION(4, 9, 0)
     return bpf_get_prandom_u32();
 #else
@@ -48,4 +48,18 @@ static __maybe_unused __always_inline bool is_ipv6_enabled() {
 #endif
 }
 
+static __maybe_unused __always_inline bool is_ipv4_enabled() {
+#ifdef COMPILE_RUNTIME
+#ifdef FEATURE_IPV4_ENABLED
+    return true;
+#else
+    return false;
+#endif
+#else
+    __u64 val = 0;
+    LOAD_CONSTANT("ipv4_enabled", val);
+    return val == ENABLED;
+#endif
+}
+
 #endif
@@ -0,0 +1,120 @@
+#include "kconfig.h"
+#include "bpf_helpers.h"
+#include "bpf_builtins.h"
+#include "map-defs.h"
+
+#include <net/sock.h>
+#include <uapi/linux/if_ether.h>
+#include <uapi/linux/ip.h>
+#include <uapi/linux/ipv6.h>
+#include <uapi/linux/udp.h>
+
+#include "tracer.h"
+#include "ip.h"
+#include "port_range.h"
+
+#include "protocols/http/types.h"
+#include "protocols/http/parsing.h"
+
+// This program is the HTTP monitoring of the kernels older than 4.14, which can't load the programs of the USM
+// (per-cpu maps, tracepoints, tail calls of large programs). It only uses what the socket filters of the 4.4 kernels
+// support: the legacy packet access instructions and the hash and array maps. As the socket filters of these kernels
+// can't send perf events either, the completed transactions are stored in a map polled from userspace.
+// The fidelity is reduced compared to the USM: no TLS, no pipelining, no headers, and the latency of a transaction is
+// the time to the first byte of its response.
+
+// The length of the beginning of the payload read to tell requests and responses apart: the request line of an OPTIONS
+// request must be read up to its path, and the status line up to its status code.
+#define HTTP_FALLBACK_PREFIX_SIZE 16
+
+// The transactions awaiting their response, keyed by the normalized tuple of their connection
+BPF_HASH_MAP(http_fallback_in_flight, conn_tuple_t, http_transaction_t, 0)
+
+// The transactions whose response was seen, until they are polled from userspace
+BPF_HASH_MAP(http_fallback_completed, http_headers_key_t, http_transaction_t, 0)
+
+// Reads up to size bytes of the payload starting at the given offset, with the legacy packet access instructions.
+// The bytes past the end of the packet are left untouched, as reading them would abort the program.
+static __always_inline void http_fallback_read(char *buffer, const u32 size, struct __sk_buff *skb, u32 offset) {
+#pragma unroll
+    for (u32 i = 0; i < size; i++) {
+        if (offset + i >= skb->len) {
+            return;
+        }
+        buffer[i] = load_byte(skb, offset + i);
+    }
+}
+
+static __always_inline void http_fallback_request(struct __sk_buff *skb, skb_info_t *skb_info, http_transaction_t *http, http_method_t method) {
+    http->request_method = method;
+    http->request_started = bpf_ktime_get_ns();
+    http->request_size = skb->len - skb_info->data_off;
+    http_fallback_read(http->request_fragment, HTTP_BUFFER_SIZE, skb, skb_info->data_off);
+
+    // a request sent before the response of the previous one replaces it, as the requests are not pipelined
+    bpf_map_update_elem(&http_fallback_in_flight, &http->tup, http, BPF_ANY);
+}
+
+static __always_inline void http_fallback_response(struct __sk_buff *skb, skb_info_t *skb_info, http_transaction_t *http, const char *prefix) {
+    http_transaction_t *in_flight = bpf_map_lookup_elem(&http_fallback_in_flight, &http->tup);
+    if (in_flight == NULL) {
+        return;
+    }
+    // the map values can only be passed to the helpers from the stack on these kernels
+    bpf_memcpy(http, in_flight, sizeof(http_transaction_t));
+    bpf_map_delete_elem(&http_fallback_in_flight, &http->tup);
+
+    http->response_status_code = http_parse_status_code(prefix);
+    http->response_first_seen = bpf_ktime_get_ns();
+    http->response_last_seen = http->response_first_seen;
+    http->response_size = skb->len - skb_info->data_off;
+
+    http_headers_key_t key;
+    bpf_memset(&key, 0, sizeof(key));
+    key.tup = http->tup;
+    key.request_started = http->request_started;
+    bpf_map_update_elem(&http_fallback_completed, &key, http, BPF_NOEXIST);
+}
+
+// This function is meant to be used as a BPF_PROG_TYPE_SOCKET_FILTER.
+// It matches the requests and the responses of the plaintext HTTP/1.x connections, the packets are never captured.
+SEC("socket/http_fallback_filter")
+int socket__http_fallback_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    http_transaction_t http;
+    bpf_memset(&http, 0, sizeof(http));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &http.tup)) {
+        return 0;
+    }
+    if (!(http.tup.metadata & CONN_TYPE_TCP) || skb->len <= skb_info.data_off) {
+        return 0;
+    }
+
+    char prefix[HTTP_FALLBACK_PREFIX_SIZE];
+    bpf_memset(prefix, 0, sizeof(prefix));
+    http_fallback_read(prefix, HTTP_FALLBACK_PREFIX_SIZE, skb, skb_info.data_off);
+
+    http_packet_t packet_type = HTTP_PACKET_UNKNOWN;
+    http_method_t method = HTTP_METHOD_UNKNOWN;
+    http_parse_data(prefix, &packet_type, &method);
+    if (packet_type == HTTP_PACKET_UNKNOWN) {
+        return 0;
+    }
+
+    // the segments of the localhost connections are seen twice: the second request replaces the first one, and the
+    // second response is ignored as its transaction was already completed
+    normalize_tuple(&http.tup);
+
+    if (packet_type == HTTP_REQUEST) {
+        http_fallback_request(skb, &skb_info, &http, method);
+    } else {
+        http_fallback_response(skb, &skb_info, &http, prefix);
+    }
+    return 0;
+}
+
+// This number will be interpreted by elf-loader to set the current running kernel version
+__u32 _version SEC("version") = 0xFFFFFFFE; // NOLINT(bugprone-reserved-identifier)
+
+char _license[] SEC("license") = "GPL"; // NOLINT(bugprone-reserved-identifier)
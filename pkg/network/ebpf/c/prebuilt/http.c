@@ -19,8 +19,26 @@
 
 #include "protocols/classification/dispatcher-helpers.h"
 #include "protocols/http/http.h"
+#include "protocols/http2/http2.h"
+#include "protocols/grpc/grpc.h"
+#include "protocols/dns/dns-tls.h"
+#include "protocols/kafka/kafka.h"
+#include "protocols/postgres/postgres.h"
+#include "protocols/mysql/mysql.h"
+#include "protocols/redis/redis.h"
+#include "protocols/mongo/mongo.h"
+#include "protocols/amqp/amqp.h"
+#include "protocols/http3/http3.h"
+#include "protocols/tls/tls-handshake.h"
+#include "protocols/cassandra/cassandra.h"
+#include "protocols/memcached/memcached.h"
 #include "protocols/http/buffer.h"
 #include "protocols/tls/https.h"
+#include "protocols/tls/java-tls-erpc.h"
+#include "protocols/tls/go-tls-types.h"
+#include "protocols/tls/go-tls-goid.h"
+#include "protocols/tls/go-tls-location.h"
+#include "protocols/tls/go-tls-conn.h"
 #include "protocols/tls/tags-types.h"
 
 #define SO_SUFFIX_SIZE 3
@@ -53,10 +71,170 @@ int socket__http_filter(struct __sk_buff* skb) {
     normalize_tuple(&http.tup);
 
     read_into_buffer_skb((char *)http.request_fragment, skb, &skb_info);
+    read_tail_into_buffer_skb((char *)http.segment_tail, skb, &skb_info);
+    http_read_headers_skb(skb, &skb_info, (char *)http.request_fragment);
+    http.segment_size = skb->len - skb_info.data_off;
     http_process(&http, &skb_info, NO_TAGS);
     return 0;
 }
 
+SEC("socket/http2_filter")
+int socket__http2_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    http2_headers_frames_t headers;
+    bpf_memset(&headers, 0, sizeof(headers));
+    http2_count_frames(skb, &skb_info, &tup, &headers);
+    grpc_process_headers(skb, &headers);
+    return 0;
+}
+
+SEC("socket/kafka_filter")
+int socket__kafka_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    kafka_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/postgres_filter")
+int socket__postgres_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    postgres_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/mysql_filter")
+int socket__mysql_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    mysql_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/redis_filter")
+int socket__redis_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    redis_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/mongo_filter")
+int socket__mongo_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    mongo_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/amqp_filter")
+int socket__amqp_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    amqp_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/http3_filter")
+int socket__http3_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    http3_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/tls_handshake_filter")
+int socket__tls_handshake_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    tls_handshake_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/cassandra_filter")
+int socket__cassandra_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    cassandra_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/memcached_filter")
+int socket__memcached_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    memcached_process(skb, &skb_info, &tup);
+    return 0;
+}
+
 SEC("kprobe/tcp_sendmsg")
 int kprobe__tcp_sendmsg(struct pt_regs* ctx) {
     log_debug("kprobe/tcp_sendmsg: sk=%llx\n", PT_REGS_PARM1(ctx));
@@ -71,6 +249,18 @@ int tracepoint__net__netif_receive_skb(struct pt_regs* ctx) {
     // flush batch to userspace
     // because perf events can't be sent from socket filter programs
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
     return 0;
 }
 
@@ -195,8 +385,20 @@ int uretprobe__SSL_read(struct pt_regs* ctx) {
         goto cleanup;
     }
 
-    https_process(t, args->buf, len, LIBSSL);
+    https_process(t, args->buf, len, false, ssl_library_tag());
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_read_args, &pid_tgid);
     return 0;
@@ -232,8 +434,20 @@ int uretprobe__SSL_write(struct pt_regs* ctx) {
         goto cleanup;
     }
 
-    https_process(t, args->buf, write_len, LIBSSL);
+    https_process(t, args->buf, write_len, true, ssl_library_tag());
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_write_args, &pid_tgid);
     return 0;
@@ -285,8 +499,20 @@ int uretprobe__SSL_read_ex(struct pt_regs* ctx) {
         goto cleanup;
     }
 
-    https_process(conn_tuple, args->buf, bytes_count, LIBSSL);
+    https_process(conn_tuple, args->buf, bytes_count, false, ssl_library_tag());
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_read_ex_args, &pid_tgid);
     return 0;
@@ -335,8 +561,20 @@ int uretprobe__SSL_write_ex(struct pt_regs* ctx) {
         goto cleanup;
     }
 
-    https_process(conn_tuple, args->buf, bytes_count, LIBSSL);
+    https_process(conn_tuple, args->buf, bytes_count, true, ssl_library_tag());
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_write_ex_args, &pid_tgid);
     return 0;
@@ -456,8 +694,20 @@ int uretprobe__gnutls_record_recv(struct pt_regs *ctx) {
         goto cleanup;
     }
 
-    https_process(t, args->buf, read_len, LIBGNUTLS);
+    https_process(t, args->buf, read_len, false, LIBGNUTLS);
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_read_args, &pid_tgid);
     return 0;
@@ -494,8 +744,20 @@ int uretprobe__gnutls_record_send(struct pt_regs *ctx) {
         goto cleanup;
     }
 
-    https_process(t, args->buf, write_len, LIBGNUTLS);
+    https_process(t, args->buf, write_len, true, LIBGNUTLS);
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_write_args, &pid_tgid);
     return 0;
@@ -627,6 +889,263 @@ int kretprobe__do_sys_openat2(struct pt_regs* ctx) {
     return do_sys_open_helper_exit(ctx);
 }
 
+// JAVA TLS PROBES
+
+// int do_vfs_ioctl(struct file *filp, unsigned int fd, unsigned int cmd, unsigned long arg)
+SEC("kprobe/do_vfs_ioctl")
+int kprobe__do_vfs_ioctl(struct pt_regs *ctx) {
+    if (!java_tls_erpc((__u32)PT_REGS_PARM3(ctx), (void *)PT_REGS_PARM4(ctx))) {
+        return 0;
+    }
+    http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
+    return 0;
+}
+
+// GO TLS PROBES
+
+// func (c *Conn) Write(b []byte) (int, error)
+SEC("uprobe/crypto/tls.(*Conn).Write")
+int uprobe__crypto_tls_Conn_Write(struct pt_regs* ctx) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    u64 pid = pid_tgid >> 32;
+    tls_offsets_data_t* od = get_offsets_data();
+    if (od == NULL) {
+        log_debug("[go-tls-write] no offsets data in map for pid %d\n", pid);
+        return 1;
+    }
+
+    // Read the PID and goroutine ID to make the partial call key
+    go_tls_function_args_key_t call_key = {0};
+    call_key.pid = pid;
+    if (read_goroutine_id(ctx, &od->goroutine_id, &call_key.goroutine_id)) {
+        log_debug("[go-tls-write] failed reading go routine id for pid %d\n", pid);
+        return 1;
+    }
+
+    // Read the parameters to make the partial call data
+    // (since the parameters might not be live by the time the return probe is hit).
+    go_tls_write_args_data_t call_data = {0};
+    if (read_location(ctx, &od->write_conn_pointer, sizeof(call_data.conn_pointer), &call_data.conn_pointer)) {
+        log_debug("[go-tls-write] failed reading conn pointer for pid %d\n", pid);
+        return 1;
+    }
+
+    if (read_location(ctx, &od->write_buffer.ptr, sizeof(call_data.b_data), &call_data.b_data)) {
+        log_debug("[go-tls-write] failed reading buffer pointer for pid %d\n", pid);
+        return 1;
+    }
+
+    if (read_location(ctx, &od->write_buffer.len, sizeof(call_data.b_len), &call_data.b_len)) {
+        log_debug("[go-tls-write] failed reading buffer length for pid %d\n", pid);
+        return 1;
+    }
+
+    bpf_map_update_elem(&go_tls_write_args, &call_key, &call_data, BPF_ANY);
+    return 0;
+}
+
+// func (c *Conn) Write(b []byte) (int, error)
+SEC("uprobe/crypto/tls.(*Conn).Write/return")
+int uprobe__crypto_tls_Conn_Write__return(struct pt_regs* ctx) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    u64 pid = pid_tgid >> 32;
+    tls_offsets_data_t* od = get_offsets_data();
+    if (od == NULL) {
+        log_debug("[go-tls-write-return] no offsets data in map for pid %d\n", pid);
+        return 1;
+    }
+
+    // Read the PID and goroutine ID to make the partial call key
+    go_tls_function_args_key_t call_key = {0};
+    call_key.pid = pid;
+
+    uint64_t bytes_written = 0;
+    if (read_location(ctx, &od->write_return_bytes, sizeof(bytes_written), &bytes_written)) {
+        log_debug("[go-tls-write-return] failed reading write return bytes location for pid %d\n", pid);
+        return 1;
+    }
+
+    if (bytes_written <= 0) {
+        log_debug("[go-tls-write-return] write returned non-positive for amount of bytes written for pid: %d\n", pid);
+        return 1;
+    }
+
+    uint64_t err_ptr = 0;
+    if (read_location(ctx, &od->write_return_error, sizeof(err_ptr), &err_ptr)) {
+        log_debug("[go-tls-write-return] failed reading write return error location for pid %d\n", pid);
+        return 1;
+    }
+
+    // check if err != nil
+    if (err_ptr != 0) {
+        log_debug("[go-tls-write-return] error in write for pid %d: data will be ignored\n", pid);
+        return 1;
+    }
+
+    if (read_goroutine_id(ctx, &od->goroutine_id, &call_key.goroutine_id)) {
+        log_debug("[go-tls-write-return] failed reading go routine id for pid %d\n", pid);
+        return 1;
+    }
+
+
+    go_tls_write_args_data_t* call_data_ptr = bpf_map_lookup_elem(&go_tls_write_args, &call_key);
+    if (call_data_ptr == NULL) {
+        log_debug("[go-tls-write-return] no write information in write-return for pid %d\n", pid);
+        return 1;
+    }
+
+    conn_tuple_t* t = conn_tup_from_tls_conn(od, (void*) call_data_ptr->conn_pointer, pid_tgid);
+    if (t == NULL) {
+        bpf_map_delete_elem(&go_tls_write_args, &call_key);
+        return 1;
+    }
+
+    log_debug("[go-tls-write] processing %s\n", call_data_ptr->b_data);
+    https_process(t, (void*) call_data_ptr->b_data, bytes_written, true, GO);
+
+    bpf_map_delete_elem(&go_tls_write_args, &call_key);
+    return 0;
+}
+
+// func (c *Conn) Read(b []byte) (int, error)
+SEC("uprobe/crypto/tls.(*Conn).Read")
+int uprobe__crypto_tls_Conn_Read(struct pt_regs* ctx) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    u64 pid = pid_tgid >> 32;
+    tls_offsets_data_t* od = get_offsets_data();
+    if (od == NULL) {
+        log_debug("[go-tls-read] no offsets data in map for pid %d\n", pid_tgid >> 32);
+        return 1;
+    }
+
+    // Read the PID and goroutine ID to make the partial call key
+    go_tls_function_args_key_t call_key = {0};
+    call_key.pid = pid;
+    if (read_goroutine_id(ctx, &od->goroutine_id, &call_key.goroutine_id)) {
+        log_debug("[go-tls-read] failed reading go routine id for pid %d\n", pid_tgid >> 32);
+        return 1;
+    }
+
+    // Read the parameters to make the partial call data
+    // (since the parameters might not be live by the time the return probe is hit).
+    go_tls_read_args_data_t call_data = {0};
+    if (read_location(ctx, &od->read_conn_pointer, sizeof(call_data.conn_pointer), &call_data.conn_pointer)) {
+        log_debug("[go-tls-read] failed reading conn pointer for pid %d\n", pid_tgid >> 32);
+        return 1;
+    }
+    if (read_location(ctx, &od->read_buffer.ptr, sizeof(call_data.b_data), &call_data.b_data)) {
+        log_debug("[go-tls-read] failed reading buffer pointer for pid %d\n", pid_tgid >> 32);
+        return 1;
+    }
+
+    bpf_map_update_elem(&go_tls_read_args, &call_key, &call_data, BPF_ANY);
+    return 0;
+}
+
+// func (c *Conn) Read(b []byte) (int, error)
+SEC("uprobe/crypto/tls.(*Conn).Read/return")
+int uprobe__crypto_tls_Conn_Read__return(struct pt_regs* ctx) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    u64 pid = pid_tgid >> 32;
+    tls_offsets_data_t* od = get_offsets_data();
+    if (od == NULL) {
+        log_debug("[go-tls-read-return] no offsets data in map for pid %d\n", pid);
+        return 1;
+    }
+
+    // Read the PID and goroutine ID to make the partial call key
+    go_tls_function_args_key_t call_key = {0};
+    call_key.pid = pid;
+
+    uint64_t bytes_read = 0;
+    if (read_location(ctx, &od->read_return_bytes, sizeof(bytes_read), &bytes_read)) {
+        log_debug("[go-tls-read-return] failed reading return bytes location for pid %d\n", pid);
+        return 1;
+    }
+
+    if (bytes_read <= 0) {
+        log_debug("[go-tls-read-return] read returned non-positive for amount of bytes read for pid: %d\n", pid);
+        return 1;
+    }
+
+    // Errors like "EOF" of "unexpected EOF" can be treated as no error by the hooked program.
+    // Therefore, if we choose to ignore data if read had returned these errors we may have accuracy issues.
+    // For now for success validation we chose to check only the amount of bytes read
+    // and make sure it's greater than zero.
+
+    if (read_goroutine_id(ctx, &od->goroutine_id, &call_key.goroutine_id)) {
+        log_debug("[go-tls-read-return] failed reading go routine id for pid %d\n", pid);
+        return 1;
+    }
+
+    go_tls_read_args_data_t* call_data_ptr = bpf_map_lookup_elem(&go_tls_read_args, &call_key);
+    if (call_data_ptr == NULL) {
+        log_debug("[go-tls-read-return] no read information in read-return for pid %d\n", pid);
+        return 1;
+    }
+
+    conn_tuple_t* t = conn_tup_from_tls_conn(od, (void*) call_data_ptr->conn_pointer, pid_tgid);
+    if (t == NULL) {
+        bpf_map_delete_elem(&go_tls_read_args, &call_key);
+        return 1;
+    }
+
+    log_debug("[go-tls-read] processing %s\n", call_data_ptr->b_data);
+    https_process(t, (void*) call_data_ptr->b_data, bytes_read, false, GO);
+
+    bpf_map_delete_elem(&go_tls_read_args, &call_key);
+    return 0;
+}
+
+// func (c *Conn) Close(b []byte) (int, error)
+SEC("uprobe/crypto/tls.(*Conn).Close")
+int uprobe__crypto_tls_Conn_Close(struct pt_regs* ctx) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    tls_offsets_data_t* od = get_offsets_data();
+    if (od == NULL) {
+        log_debug("[go-tls-close] no offsets data in map for pid %d\n", pid_tgid >> 32);
+        return 1;
+    }
+
+    void* conn_pointer = NULL;
+    if (read_location(ctx, &od->close_conn_pointer, sizeof(conn_pointer), &conn_pointer)) {
+        log_debug("[go-tls-close] failed reading close conn pointer for pid %d\n", pid_tgid >> 32);
+        return 1;
+    }
+
+    conn_tuple_t* t = conn_tup_from_tls_conn(od, conn_pointer, pid_tgid);
+    if (t == NULL) {
+        log_debug("[go-tls-close] failed getting conn tup from tls conn for pid %d\n", pid_tgid >> 32);
+        return 1;
+    }
+
+    https_finish(t);
+
+    // Clear the element in the map since this connection is closed
+    bpf_map_delete_elem(&conn_tup_by_go_tls_conn, &conn_pointer);
+
+    return 0;
+}
+
+static __always_inline void* get_tls_base(struct task_struct* task) {
+    // The offset of the thread local storage base in the task_struct is unknown to the
+    // prebuilt programs. Userspace only hooks the binaries passing the runtime.g
+    // pointer in a register (Go >= 1.17 register ABI), which don't need it.
+    return NULL;
+}
+
 // This number will be interpreted by elf-loader to set the current running kernel version
 __u32 _version SEC("version") = 0xFFFFFFFE; // NOLINT(bugprone-reserved-identifier)
 
@@ -41,17 +41,29 @@
 // by using tail call.
 SEC("socket/classifier_entry")
 int socket__classifier_entry(struct __sk_buff *skb) {
-    bpf_tail_call_compat(skb, &classification_progs, CLASSIFICATION_PROG);
+    bpf_tail_call_compat(skb, &classification_progs, CLASSIFICATION_DISPATCHER_PROG);
     return 0;
 }
 
-// The entrypoint for all packets.
+// The entrypoint for all packets, dispatching the classification to the programs below.
 SEC("socket/classifier")
 int socket__classifier(struct __sk_buff *skb) {
     protocol_classifier_entrypoint(skb);
     return 0;
 }
 
+SEC("socket/classifier_queues")
+int socket__classifier_queues(struct __sk_buff *skb) {
+    protocol_classifier_queues_entrypoint(skb);
+    return 0;
+}
+
+SEC("socket/classifier_dbs")
+int socket__classifier_dbs(struct __sk_buff *skb) {
+    protocol_classifier_dbs_entrypoint(skb);
+    return 0;
+}
+
 SEC("kprobe/tcp_sendmsg")
 int kprobe__tcp_sendmsg(struct pt_regs *ctx) {
     u64 pid_tgid = bpf_get_current_pid_tgid();
@@ -471,13 +483,18 @@ int kprobe__tcp_retransmit_skb_pre_4_7_0(struct pt_regs *ctx) {
 SEC("kprobe/tcp_set_state")
 int kprobe__tcp_set_state(struct pt_regs *ctx) {
     u8 state = (u8)PT_REGS_PARM2(ctx);
+    struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+
+    if (state == TCP_CLOSE) {
+        handle_tcp_close_state(sk);
+        return 0;
+    }
 
     // For now we're tracking only TCP_ESTABLISHED
     if (state != TCP_ESTABLISHED) {
         return 0;
     }
 
-    struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
     u64 pid_tgid = bpf_get_current_pid_tgid();
     conn_tuple_t t = {};
     if (!read_conn_tuple(&t, sk, pid_tgid, CONN_TYPE_TCP)) {
@@ -877,6 +894,57 @@ int tracepoint__net__net_dev_queue(struct net_dev_queue_ctx* ctx) {
     return 0;
 }
 
+// The layout of the parameters being passed to the tracepoint skb/kfree_skb changes across kernel versions (the
+// reason was added in 5.17, and the receiving socket before the protocol in 6.11), so the offsets of its fields are
+// read from the format of the tracepoint, see getKfreeSkbLayout
+static __always_inline __u64 kfree_skb_skbaddr_offset() {
+    __u64 val = 0;
+    LOAD_CONSTANT("kfree_skb_skbaddr_offset", val);
+    return val;
+}
+
+static __always_inline __u64 kfree_skb_protocol_offset() {
+    __u64 val = 0;
+    LOAD_CONSTANT("kfree_skb_protocol_offset", val);
+    return val;
+}
+
+// 0 on kernels < 5.17, whose tracepoint doesn't report the reason of the drops
+static __always_inline __u64 kfree_skb_reason_offset() {
+    __u64 val = 0;
+    LOAD_CONSTANT("kfree_skb_reason_offset", val);
+    return val;
+}
+
+SEC("tracepoint/skb/kfree_skb")
+int tracepoint__skb__kfree_skb(void* ctx) {
+    unsigned short protocol = 0;
+    bpf_probe_read(&protocol, sizeof(protocol), ctx + kfree_skb_protocol_offset());
+    if (protocol != ETH_P_IP && protocol != ETH_P_IPV6) {
+        return 0;
+    }
+
+    void* skb;
+    bpf_probe_read(&skb, sizeof(skb), ctx + kfree_skb_skbaddr_offset());
+    if (!skb) {
+        return 0;
+    }
+    // the packets dropped before being associated to a socket can't be attributed to a connection
+    struct sock* sk;
+    bpf_probe_read(&sk, sizeof(struct sock*), skb + offset_sk_buff_sock());
+    if (!sk) {
+        return 0;
+    }
+
+    __u32 reason = 0;
+    __u64 reason_offset = kfree_skb_reason_offset();
+    if (reason_offset > 0) {
+        bpf_probe_read(&reason, sizeof(reason), ctx + reason_offset);
+    }
+    handle_tcp_drop(sk, reason);
+    return 0;
+}
+
 //endregion
 
 // This number will be interpreted by elf-loader to set the current running kernel version
@@ -0,0 +1,102 @@
+#ifndef __AMQP_H
+#define __AMQP_H
+
+#include "bpf_builtins.h"
+#include "bpf_endian.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/events.h"
+#include "protocols/amqp/defs.h"
+#include "protocols/amqp/maps.h"
+#include "protocols/amqp/types.h"
+
+USM_EVENTS_INIT(amqp, amqp_transaction_t, AMQP_BATCH_SIZE);
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to AMQP_BUFFER_SIZE bytes. The
+// bytes are read in blocks of AMQP_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the
+// verifiers of the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 amqp_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer) {
+    __u32 read = 0;
+#pragma unroll(AMQP_BUFFER_SIZE / AMQP_BLK_SIZE)
+    for (int i = 0; i < AMQP_BUFFER_SIZE / AMQP_BLK_SIZE; i++) {
+        if (offset + AMQP_BLK_SIZE > end) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], AMQP_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += AMQP_BLK_SIZE;
+        read += AMQP_BLK_SIZE;
+    }
+    if (read == AMQP_BUFFER_SIZE) {
+        return read;
+    }
+
+#define AMQP_READ_CHUNK(size)                                                                       \
+    if (offset + size <= end && read + size <= AMQP_BUFFER_SIZE) {                                  \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    AMQP_READ_CHUNK(8);
+    AMQP_READ_CHUNK(4);
+    AMQP_READ_CHUNK(2);
+    AMQP_READ_CHUNK(1);
+#undef AMQP_READ_CHUNK
+
+    return read;
+}
+
+// Processes a TCP segment of an AMQP connection, and sends the segments starting with a basic.publish or a
+// basic.deliver method frame to userspace. The other frames, such as the content header and body frames following
+// the method frames, are ignored. As the messages are not answered, no latency is measured.
+static __always_inline void amqp_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (skb->len - skb_info->data_off < AMQP_MIN_PAYLOAD_LENGTH) {
+        return;
+    }
+
+    __u8 frame_type = 0;
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb_info->data_off, &frame_type, sizeof(frame_type)) < 0) {
+        return;
+    }
+    if (frame_type != AMQP_FRAME_METHOD_TYPE) {
+        return;
+    }
+
+    amqp_header hdr = {0};
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb_info->data_off + AMQP_METHOD_OFFSET, &hdr, sizeof(hdr)) < 0) {
+        return;
+    }
+    if (bpf_ntohs(hdr.class_id) != AMQP_BASIC_CLASS) {
+        return;
+    }
+
+    const __u32 zero = 0;
+    amqp_transaction_t *tx = bpf_map_lookup_elem(&amqp_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(amqp_transaction_t));
+
+    tx->tup = *tup;
+    switch (bpf_ntohs(hdr.method_id)) {
+    case AMQP_METHOD_PUBLISH:
+        break;
+    case AMQP_METHOD_DELIVER:
+        // the messages are delivered by the server, while the transactions hold the tuple of the client side
+        flip_tuple(&tx->tup);
+        break;
+    default:
+        return;
+    }
+
+    tx->fragment_size = amqp_read_into_buffer(skb, skb_info->data_off, skb->len, tx->fragment);
+    amqp_batch_enqueue(tx);
+}
+
+#endif
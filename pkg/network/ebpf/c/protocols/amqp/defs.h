@@ -25,6 +25,15 @@
 #define AMQP_MIN_FRAME_LENGTH 8
 #define AMQP_MIN_PAYLOAD_LENGTH 11
 
+// The offset of the class id of the method frames, which follows the frame type, the channel and the size of the frame.
+#define AMQP_METHOD_OFFSET 7
+
+// The size of the beginning of the basic.publish and basic.deliver frames sent to userspace, which holds the name of
+// the exchange and the routing key.
+#define AMQP_BUFFER_SIZE 128
+#define AMQP_BLK_SIZE 16
+#define AMQP_BATCH_SIZE 15
+
 typedef struct {
     __u16 class_id;
     __u16 method_id;
@@ -0,0 +1,12 @@
+#ifndef __AMQP_MAPS_H
+#define __AMQP_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/amqp/types.h"
+
+/* This map is used as a scratch buffer to build the AMQP transactions, as they are too large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(amqp_heap, __u32, amqp_transaction_t, 1)
+
+#endif
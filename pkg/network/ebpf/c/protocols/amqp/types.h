@@ -0,0 +1,16 @@
+#ifndef __AMQP_TYPES_H
+#define __AMQP_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/amqp/defs.h"
+
+// AMQP basic.publish or basic.deliver method frame, from the client side of the connection. The exchange and the
+// routing key of the message are decoded in userspace.
+typedef struct {
+    conn_tuple_t tup;
+    __u16 fragment_size;
+    char fragment[AMQP_BUFFER_SIZE];
+} amqp_transaction_t;
+
+#endif
@@ -0,0 +1,136 @@
+#ifndef __CASSANDRA_H
+#define __CASSANDRA_H
+
+#include "bpf_builtins.h"
+#include "bpf_endian.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/events.h"
+#include "protocols/cassandra/defs.h"
+#include "protocols/cassandra/maps.h"
+#include "protocols/cassandra/types.h"
+
+USM_EVENTS_INIT(cassandra, cassandra_transaction_t, CASSANDRA_BATCH_SIZE);
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to max bytes. The bytes are read
+// in blocks of CASSANDRA_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the verifiers of
+// the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 cassandra_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer, const __u32 max) {
+    __u32 read = 0;
+#pragma unroll(CASSANDRA_BUFFER_SIZE / CASSANDRA_BLK_SIZE)
+    for (int i = 0; i < CASSANDRA_BUFFER_SIZE / CASSANDRA_BLK_SIZE; i++) {
+        if (offset + CASSANDRA_BLK_SIZE > end || read + CASSANDRA_BLK_SIZE > max) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], CASSANDRA_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += CASSANDRA_BLK_SIZE;
+        read += CASSANDRA_BLK_SIZE;
+    }
+
+#define CASSANDRA_READ_CHUNK(size)                                                                  \
+    if (offset + size <= end && read + size <= max) {                                               \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    CASSANDRA_READ_CHUNK(8);
+    CASSANDRA_READ_CHUNK(4);
+    CASSANDRA_READ_CHUNK(2);
+    CASSANDRA_READ_CHUNK(1);
+#undef CASSANDRA_READ_CHUNK
+
+    return read;
+}
+
+// Reads the header of the CQL frame starting the segment.
+static __always_inline bool cassandra_read_header(struct __sk_buff *skb, skb_info_t *skb_info, cql_header_t *hdr) {
+    if (skb->len < skb_info->data_off + sizeof(cql_header_t)) {
+        return false;
+    }
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb_info->data_off, hdr, sizeof(cql_header_t)) < 0) {
+        return false;
+    }
+    __u8 version = hdr->version & CQL_VERSION_MASK;
+    return version >= CQL_MIN_VERSION && version <= CQL_MAX_VERSION;
+}
+
+// Completes the request awaiting the response started by the frame, and sends it to userspace along with the
+// beginning of the response, which tells whether the request succeeded. The latency is measured up to the first
+// packet of the response.
+static __always_inline void cassandra_process_response(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, cql_header_t *hdr) {
+    // the requests are stored with the tuple of the client side of the connection
+    cassandra_key_t key;
+    bpf_memset(&key, 0, sizeof(key));
+    key.tup = *tup;
+    flip_tuple(&key.tup);
+    key.stream_id = hdr->stream_id;
+    cassandra_transaction_t *tx = bpf_map_lookup_elem(&cassandra_in_flight, &key);
+    if (tx == NULL) {
+        return;
+    }
+
+    tx->response_received = bpf_ktime_get_ns();
+    tx->response_fragment_size = cassandra_read_into_buffer(skb, skb_info->data_off, skb->len, tx->response_fragment, CASSANDRA_RESPONSE_SIZE);
+
+    cassandra_batch_enqueue(tx);
+    bpf_map_delete_elem(&cassandra_in_flight, &key);
+}
+
+// Stores the frames starting a QUERY, PREPARE, EXECUTE or BATCH request until their response is seen.
+static __always_inline void cassandra_process_request(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, cql_header_t *hdr) {
+    switch (hdr->opcode) {
+    case CQL_OPCODE_QUERY:
+    case CQL_OPCODE_PREPARE:
+    case CQL_OPCODE_EXECUTE:
+    case CQL_OPCODE_BATCH:
+        break;
+    default:
+        return;
+    }
+
+    const __u32 zero = 0;
+    cassandra_transaction_t *tx = bpf_map_lookup_elem(&cassandra_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(cassandra_transaction_t));
+
+    tx->tup = *tup;
+    tx->request_started = bpf_ktime_get_ns();
+    tx->request_fragment_size = cassandra_read_into_buffer(skb, skb_info->data_off, skb->len, tx->request_fragment, CASSANDRA_BUFFER_SIZE);
+
+    cassandra_key_t key;
+    bpf_memset(&key, 0, sizeof(key));
+    key.tup = *tup;
+    key.stream_id = hdr->stream_id;
+    // a request whose response was not seen is replaced once its stream is reused
+    bpf_map_update_with_telemetry(cassandra_in_flight, &key, tx, BPF_ANY);
+}
+
+// Processes a TCP segment of a Cassandra connection. The segments which don't start a frame, such as the
+// continuation of the large result sets, are ignored, as well as the frames of the version 5 of the protocol once
+// the connection is established, as they are wrapped in segments which may hold several frames.
+static __always_inline void cassandra_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (is_payload_empty(skb, skb_info)) {
+        return;
+    }
+
+    cql_header_t hdr;
+    if (!cassandra_read_header(skb, skb_info, &hdr)) {
+        return;
+    }
+    if (hdr.version & CQL_RESPONSE_DIRECTION) {
+        cassandra_process_response(skb, skb_info, tup, &hdr);
+        return;
+    }
+    cassandra_process_request(skb, skb_info, tup, &hdr);
+}
+
+#endif
@@ -0,0 +1,54 @@
+#ifndef __CASSANDRA_DEFS_H
+#define __CASSANDRA_DEFS_H
+
+// The frames of the CQL native protocol start with a 9 bytes header: the version, whose most significant bit is set
+// for the responses, the flags, the stream id, the opcode and the length of the body.
+// Checkout https://github.com/apache/cassandra/blob/trunk/doc/native_protocol_v4.spec#L101
+#define CQL_HEADER_SIZE 9
+#define CQL_RESPONSE_DIRECTION 0x80
+#define CQL_VERSION_MASK 0x7f
+// The versions 3 to 5 of the protocol share the same header, the older ones having a 1 byte stream id.
+#define CQL_MIN_VERSION 3
+#define CQL_MAX_VERSION 5
+// The flags defined by the versions 3 to 5: compression, tracing, custom payload, warning and beta.
+#define CQL_FLAGS_MASK 0x1f
+// The bodies of the frames are limited to 256MB.
+#define CQL_MAX_FRAME_LENGTH (256 * 1024 * 1024)
+
+// Checkout https://github.com/apache/cassandra/blob/trunk/doc/native_protocol_v4.spec#L177
+#define CQL_OPCODE_ERROR 0x00
+#define CQL_OPCODE_STARTUP 0x01
+#define CQL_OPCODE_READY 0x02
+#define CQL_OPCODE_AUTHENTICATE 0x03
+#define CQL_OPCODE_OPTIONS 0x05
+#define CQL_OPCODE_SUPPORTED 0x06
+#define CQL_OPCODE_QUERY 0x07
+#define CQL_OPCODE_RESULT 0x08
+#define CQL_OPCODE_PREPARE 0x09
+#define CQL_OPCODE_EXECUTE 0x0a
+#define CQL_OPCODE_REGISTER 0x0b
+#define CQL_OPCODE_EVENT 0x0c
+#define CQL_OPCODE_BATCH 0x0d
+#define CQL_OPCODE_AUTH_CHALLENGE 0x0e
+#define CQL_OPCODE_AUTH_RESPONSE 0x0f
+#define CQL_OPCODE_AUTH_SUCCESS 0x10
+
+// The size of the beginning of the requests sent to userspace, which holds the query of the QUERY and PREPARE
+// requests, or the id of the prepared statement of the EXECUTE requests.
+#define CASSANDRA_BUFFER_SIZE 160
+// The size of the beginning of the responses sent to userspace, which holds the error code of the ERROR responses, or
+// the id assigned to the statements by the RESULT responses of the PREPARE requests.
+#define CASSANDRA_RESPONSE_SIZE 32
+#define CASSANDRA_BLK_SIZE 16
+#define CASSANDRA_BATCH_SIZE 15
+
+// CQL header format. The stream id and the length are big endian integers.
+typedef struct {
+    __u8 version;
+    __u8 flags;
+    __s16 stream_id;
+    __u8 opcode;
+    __u32 length;
+} __attribute__((packed)) cql_header_t;
+
+#endif
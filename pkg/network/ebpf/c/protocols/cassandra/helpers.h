@@ -0,0 +1,66 @@
+#ifndef __CASSANDRA_HELPERS_H
+#define __CASSANDRA_HELPERS_H
+
+#include "bpf_endian.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/cassandra/defs.h"
+
+// Checks the opcode of a request frame, sent by the clients.
+static __always_inline bool is_cql_request_opcode(__u8 opcode) {
+    switch (opcode) {
+    case CQL_OPCODE_STARTUP:
+    case CQL_OPCODE_OPTIONS:
+    case CQL_OPCODE_QUERY:
+    case CQL_OPCODE_PREPARE:
+    case CQL_OPCODE_EXECUTE:
+    case CQL_OPCODE_REGISTER:
+    case CQL_OPCODE_BATCH:
+    case CQL_OPCODE_AUTH_RESPONSE:
+        return true;
+    default:
+        return false;
+    }
+}
+
+// Checks the opcode of a response frame, sent by the servers.
+static __always_inline bool is_cql_response_opcode(__u8 opcode) {
+    switch (opcode) {
+    case CQL_OPCODE_ERROR:
+    case CQL_OPCODE_READY:
+    case CQL_OPCODE_AUTHENTICATE:
+    case CQL_OPCODE_SUPPORTED:
+    case CQL_OPCODE_RESULT:
+    case CQL_OPCODE_EVENT:
+    case CQL_OPCODE_AUTH_CHALLENGE:
+    case CQL_OPCODE_AUTH_SUCCESS:
+        return true;
+    default:
+        return false;
+    }
+}
+
+// Checks if the given buffer starts with the header of a CQL frame. The requests must be sent on one of the streams
+// of the clients, whose ids are positive, the negative ones being used by the servers for the events.
+static __always_inline bool is_cassandra(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, CQL_HEADER_SIZE);
+
+    cql_header_t hdr = *((cql_header_t *)buf);
+    __u8 version = hdr.version & CQL_VERSION_MASK;
+    if (version < CQL_MIN_VERSION || version > CQL_MAX_VERSION) {
+        return false;
+    }
+    if (hdr.flags & ~CQL_FLAGS_MASK) {
+        return false;
+    }
+    if (bpf_ntohl(hdr.length) > CQL_MAX_FRAME_LENGTH) {
+        return false;
+    }
+
+    if (hdr.version & CQL_RESPONSE_DIRECTION) {
+        return is_cql_response_opcode(hdr.opcode);
+    }
+    return (__s16)bpf_ntohs(hdr.stream_id) >= 0 && is_cql_request_opcode(hdr.opcode);
+}
+
+#endif
@@ -0,0 +1,15 @@
+#ifndef __CASSANDRA_MAPS_H
+#define __CASSANDRA_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/cassandra/types.h"
+
+/* This map is used to keep track of the Cassandra requests awaiting their response, by connection and stream */
+BPF_LRU_MAP(cassandra_in_flight, cassandra_key_t, cassandra_transaction_t, 0)
+
+/* This map is used as a scratch buffer to build the Cassandra transactions, as they are too large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(cassandra_heap, __u32, cassandra_transaction_t, 1)
+
+#endif
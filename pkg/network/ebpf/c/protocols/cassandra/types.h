@@ -0,0 +1,27 @@
+#ifndef __CASSANDRA_TYPES_H
+#define __CASSANDRA_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/cassandra/defs.h"
+
+// The requests awaiting their response are identified by their stream, as the requests of a connection are
+// multiplexed and may be answered out of order.
+typedef struct {
+    conn_tuple_t tup;
+    __s16 stream_id;
+} cassandra_key_t;
+
+// Cassandra request, from the client side of the connection, along with the beginning of its response. The requests
+// and the responses are decoded in userspace.
+typedef struct {
+    conn_tuple_t tup;
+    __u64 request_started;
+    __u64 response_received;
+    __u16 request_fragment_size;
+    __u16 response_fragment_size;
+    char request_fragment[CASSANDRA_BUFFER_SIZE];
+    char response_fragment[CASSANDRA_RESPONSE_SIZE];
+} cassandra_transaction_t;
+
+#endif
@@ -4,12 +4,18 @@
 #include "ktypes.h"
 
 #include "protocols/amqp/defs.h"
+#include "protocols/cassandra/defs.h"
 #include "protocols/http/classification-defs.h"
 #include "protocols/http2/defs.h"
+#include "protocols/http3/defs.h"
+#include "protocols/kafka/defs.h"
+#include "protocols/memcached/defs.h"
 #include "protocols/mongo/defs.h"
 #include "protocols/mysql/defs.h"
+#include "protocols/rdp/defs.h"
 #include "protocols/redis/defs.h"
 #include "protocols/sql/defs.h"
+#include "protocols/ssh/defs.h"
 
 // Represents the max buffer size required to classify protocols .
 // We need to round it to be multiplication of 16 since we are reading blocks of 16 bytes in read_into_buffer_skb_all_kernels.
@@ -26,14 +32,33 @@ typedef enum {
     PROTOCOL_HTTP,
     PROTOCOL_HTTP2,
     PROTOCOL_TLS,
-    PROTOCOL_MONGO = 6,
+    PROTOCOL_KAFKA,
+    PROTOCOL_MONGO,
     PROTOCOL_POSTGRES,
     PROTOCOL_AMQP,
     PROTOCOL_REDIS,
     PROTOCOL_MYSQL,
+    PROTOCOL_HTTP3,
+    PROTOCOL_SSH,
+    PROTOCOL_RDP,
+    PROTOCOL_CASSANDRA,
+    PROTOCOL_MEMCACHED,
     //  Add new protocols before that line.
     MAX_PROTOCOLS,
     __MAX_UINT8 = 255,
 } __attribute__ ((packed)) protocol_t;
 
+// The enum below represents the programs of the protocol classification, which are the keys of the
+// classification_progs map. The classification starts in the dispatcher program, which classifies the application
+// layer protocols, and then tail calls the next program of the chain as long as the connection was not classified.
+// Each program classifies a family of protocols, so more protocols can be added without hitting the instructions and
+// complexity limits of the verifier.
+typedef enum {
+    CLASSIFICATION_DISPATCHER_PROG = 0,
+    CLASSIFICATION_QUEUES_PROG,
+    CLASSIFICATION_DBS_PROG,
+    //  Add new programs before that line.
+    CLASSIFICATION_PROG_MAX,
+} classification_prog_t;
+
 #endif
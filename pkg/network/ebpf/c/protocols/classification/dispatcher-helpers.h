@@ -11,6 +11,16 @@
 #include "protocols/classification/dispatcher-maps.h"
 #include "protocols/http/classification-helpers.h"
 #include "protocols/http2/helpers.h"
+#include "protocols/http3/helpers.h"
+#include "protocols/kafka/helpers.h"
+#include "protocols/postgres/helpers.h"
+#include "protocols/mysql/helpers.h"
+#include "protocols/redis/helpers.h"
+#include "protocols/mongo/helpers.h"
+#include "protocols/amqp/helpers.h"
+#include "protocols/cassandra/helpers.h"
+#include "protocols/memcached/helpers.h"
+#include "protocols/tls/tls-handshake-helpers.h"
 
 // Returns true if the payload represents a TCP termination by checking if the tcp flags contains TCPHDR_FIN or TCPHDR_RST.
 static __always_inline bool is_tcp_termination(skb_info_t *skb_info) {
@@ -51,6 +61,22 @@ static __always_inline void classify_protocol_for_dispatcher(protocol_t *protoco
         *protocol = PROTOCOL_HTTP;
     } else if (is_http2(buf, size)) {
         *protocol = PROTOCOL_HTTP2;
+    } else if (is_kafka(buf, size)) {
+        *protocol = PROTOCOL_KAFKA;
+    } else if (is_postgres(buf, size)) {
+        *protocol = PROTOCOL_POSTGRES;
+    } else if (is_mysql(tup, buf, size)) {
+        *protocol = PROTOCOL_MYSQL;
+    } else if (is_redis(buf, size)) {
+        *protocol = PROTOCOL_REDIS;
+    } else if (is_mongo(tup, buf, size)) {
+        *protocol = PROTOCOL_MONGO;
+    } else if (is_amqp(buf, size)) {
+        *protocol = PROTOCOL_AMQP;
+    } else if (is_cassandra(buf, size)) {
+        *protocol = PROTOCOL_CASSANDRA;
+    } else if (is_memcached(buf, size)) {
+        *protocol = PROTOCOL_MEMCACHED;
     } else {
         *protocol = PROTOCOL_UNKNOWN;
     }
@@ -58,6 +84,24 @@ static __always_inline void classify_protocol_for_dispatcher(protocol_t *protoco
     log_debug("[protocol_dispatcher_classifier]: Classified protocol as %d %d; %s\n", *protocol, size, buf);
 }
 
+// Dispatches the QUIC Initial packets to the HTTP/3 program. The UDP flows are not tracked by the dispatcher, as only
+// the Initial packets opening the connections are decoded.
+static __always_inline void dispatch_quic_initial(struct __sk_buff *skb, skb_info_t *skb_info) {
+    // the smaller datagrams can't carry the Initial packets of the clients, and are not read
+    if (skb->len - skb_info->data_off < QUIC_MIN_INITIAL_DATAGRAM_SIZE) {
+        return;
+    }
+
+    char request_fragment[CLASSIFICATION_MAX_BUFFER];
+    bpf_memset(request_fragment, 0, sizeof(request_fragment));
+    read_into_buffer_for_classification((char *)request_fragment, skb, skb_info);
+    const size_t payload_length = skb->len - skb_info->data_off;
+    const size_t final_fragment_size = payload_length < CLASSIFICATION_MAX_BUFFER ? payload_length : CLASSIFICATION_MAX_BUFFER;
+    if (is_quic_initial(request_fragment, final_fragment_size, payload_length)) {
+        bpf_tail_call_compat(skb, &protocols_progs, PROTOCOL_HTTP3);
+    }
+}
+
 // A shared implementation for the runtime & prebuilt socket filter that classifies & dispatches the protocols of the connections.
 static __always_inline void protocol_dispatcher_entrypoint(struct __sk_buff *skb) {
     skb_info_t skb_info = {0};
@@ -68,8 +112,13 @@ static __always_inline void protocol_dispatcher_entrypoint(struct __sk_buff *skb
         return;
     }
 
-    // We don't process non tcp packets, nor empty tcp packets which are not tcp termination packets, nor ACK only packets.
-    if (!is_tcp(&skb_tup) || is_tcp_ack(&skb_info) || (is_payload_empty(skb, &skb_info) && !is_tcp_termination(&skb_info))) {
+    if (!is_tcp(&skb_tup)) {
+        dispatch_quic_initial(skb, &skb_info);
+        return;
+    }
+
+    // We don't process empty tcp packets which are not tcp termination packets, nor ACK only packets.
+    if (is_tcp_ack(&skb_info) || (is_payload_empty(skb, &skb_info) && !is_tcp_termination(&skb_info))) {
         return;
     }
 
@@ -89,6 +138,12 @@ static __always_inline void protocol_dispatcher_entrypoint(struct __sk_buff *skb
         read_into_buffer_for_classification((char *)request_fragment, skb, &skb_info);
         const size_t payload_length = skb->len - skb_info.data_off;
         const size_t final_fragment_size = payload_length < CLASSIFICATION_MAX_BUFFER ? payload_length : CLASSIFICATION_MAX_BUFFER;
+        // The handshakes of the TLS connections are sent to userspace, without classifying the connections: their
+        // plaintext is classified by the uprobes of the TLS libraries, which share the classification of the
+        // dispatcher. The tail call only succeeds if the TLS handshake monitoring is enabled.
+        if (is_tls_hello(request_fragment, final_fragment_size)) {
+            bpf_tail_call_compat(skb, &protocols_progs, PROTOCOL_TLS);
+        }
         classify_protocol_for_dispatcher(&cur_fragment_protocol, &skb_tup, request_fragment, final_fragment_size);
         log_debug("[protocol_dispatcher_entrypoint]: %p Classifying protocol as: %d\n", skb, cur_fragment_protocol);
         // If there has been a change in the classification, save the new protocol.
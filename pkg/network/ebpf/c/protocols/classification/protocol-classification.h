@@ -6,89 +6,194 @@
 #include "ip.h"
 
 #include "protocols/amqp/helpers.h"
+#include "protocols/cassandra/helpers.h"
 #include "protocols/classification/common.h"
 #include "protocols/classification/defs.h"
 #include "protocols/classification/maps.h"
 #include "protocols/classification/structs.h"
 #include "protocols/http/classification-helpers.h"
 #include "protocols/http2/helpers.h"
+#include "protocols/http3/helpers.h"
+#include "protocols/http3/quic-stats.h"
+#include "protocols/kafka/helpers.h"
+#include "protocols/memcached/helpers.h"
 #include "protocols/mongo/helpers.h"
 #include "protocols/mysql/helpers.h"
+#include "protocols/rdp/helpers.h"
 #include "protocols/redis/helpers.h"
 #include "protocols/postgres/helpers.h"
+#include "protocols/ssh/helpers.h"
 
-// Determines the protocols of the given buffer. If we already classified the payload (a.k.a protocol out param
-// has a known protocol), then we do nothing.
-static __always_inline void classify_protocol(protocol_t *protocol, conn_tuple_t *tup, const char *buf, __u32 size) {
-    if (protocol == NULL || *protocol != PROTOCOL_UNKNOWN) {
-        return;
+// Classifies the application layer protocols of the given buffer, along with the remote access protocols.
+static __always_inline protocol_t classify_applayer_protocols(const char *buf, __u32 size) {
+    if (is_http(buf, size)) {
+        return PROTOCOL_HTTP;
+    }
+    if (is_http2(buf, size)) {
+        return PROTOCOL_HTTP2;
+    }
+    if (is_ssh(buf, size)) {
+        return PROTOCOL_SSH;
+    }
+    if (is_rdp(buf, size)) {
+        return PROTOCOL_RDP;
     }
+    return PROTOCOL_UNKNOWN;
+}
 
-    if (is_http(buf, size)) {
-        *protocol = PROTOCOL_HTTP;
-    } else if (is_http2(buf, size)) {
-        *protocol = PROTOCOL_HTTP2;
-    } else if (is_amqp(buf, size)) {
-        *protocol = PROTOCOL_AMQP;
-    } else if (is_redis(buf, size)) {
-        *protocol = PROTOCOL_REDIS;
-    } else if (is_mongo(tup, buf, size)) {
-        *protocol = PROTOCOL_MONGO;
-    } else if (is_postgres(buf, size)) {
-        *protocol = PROTOCOL_POSTGRES;
-    } else if (is_mysql(tup, buf, size)) {
-        *protocol = PROTOCOL_MYSQL;
-    } else {
-        *protocol = PROTOCOL_UNKNOWN;
-    }
-
-    log_debug("[protocol classification]: Classified protocol as %d %d; %s\n", *protocol, size, buf);
+// Classifies the message queues protocols of the given buffer.
+static __always_inline protocol_t classify_queue_protocols(const char *buf, __u32 size) {
+    if (is_amqp(buf, size)) {
+        return PROTOCOL_AMQP;
+    }
+    if (is_redis(buf, size)) {
+        return PROTOCOL_REDIS;
+    }
+    if (is_memcached(buf, size)) {
+        return PROTOCOL_MEMCACHED;
+    }
+    if (is_kafka(buf, size)) {
+        return PROTOCOL_KAFKA;
+    }
+    return PROTOCOL_UNKNOWN;
 }
 
-// A shared implementation for the runtime & prebuilt socket filter that classifies the protocols of the connections.
-__maybe_unused static __always_inline void protocol_classifier_entrypoint(struct __sk_buff *skb) {
-    skb_info_t skb_info = {0};
-    conn_tuple_t skb_tup = {0};
+// Classifies the databases protocols of the given buffer.
+static __always_inline protocol_t classify_db_protocols(conn_tuple_t *tup, const char *buf, __u32 size) {
+    if (is_mongo(tup, buf, size)) {
+        return PROTOCOL_MONGO;
+    }
+    if (is_postgres(buf, size)) {
+        return PROTOCOL_POSTGRES;
+    }
+    if (is_mysql(tup, buf, size)) {
+        return PROTOCOL_MYSQL;
+    }
+    if (is_cassandra(buf, size)) {
+        return PROTOCOL_CASSANDRA;
+    }
+    return PROTOCOL_UNKNOWN;
+}
 
+// Reads the connection tuple and the fragment of the packet shared by the classification programs. The fragment is
+// read into a per-cpu buffer by the dispatcher program, and is left untouched for the programs it tail calls, which
+// run on the same CPU. Returns NULL if the packet should not be classified.
+static __always_inline char *get_classification_fragment(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *skb_tup, size_t *size) {
     // Exporting the conn tuple from the skb, alongside couple of relevant fields from the skb.
-    if (!read_conn_tuple_skb(skb, &skb_info, &skb_tup)) {
+    if (!read_conn_tuple_skb(skb, skb_info, skb_tup)) {
+        return NULL;
+    }
+
+    // We support non empty payloads for classification at the moment. The UDP datagrams are only classified by the
+    // dispatcher program, as QUIC.
+    if (is_payload_empty(skb, skb_info)) {
+        return NULL;
+    }
+
+    // Get the buffer the fragment will be read into from a per-cpu array map.
+    // This will avoid doing unaligned stack access while parsing the protocols,
+    // which is forbidden and will make the verifier fail.
+    const u32 key = 0;
+    char *request_fragment = bpf_map_lookup_elem(&classification_buf, &key);
+    if (request_fragment == NULL) {
+        log_debug("could not get classification buffer from map");
+        return NULL;
+    }
+
+    const size_t payload_length = skb->len - skb_info->data_off;
+    *size = payload_length < CLASSIFICATION_MAX_BUFFER ? payload_length : CLASSIFICATION_MAX_BUFFER;
+    return request_fragment;
+}
+
+// Saves the protocol the connection has been classified with, for both directions of the connection.
+static __always_inline void mark_classified_protocol(conn_tuple_t *skb_tup, protocol_t protocol) {
+    log_debug("[protocol classification]: Classified protocol as %d\n", protocol);
+    bpf_map_update_with_telemetry(connection_protocol, skb_tup, &protocol, BPF_NOEXIST);
+    conn_tuple_t inverse_skb_conn_tup = *skb_tup;
+    flip_tuple(&inverse_skb_conn_tup);
+    bpf_map_update_with_telemetry(connection_protocol, &inverse_skb_conn_tup, &protocol, BPF_NOEXIST);
+}
+
+// Either saves the protocol the connection has been classified with, or tail calls the next classification program.
+// The tail call does not return if it succeeds.
+static __always_inline void classification_next_program(struct __sk_buff *skb, conn_tuple_t *skb_tup, protocol_t protocol, classification_prog_t next) {
+    if (protocol != PROTOCOL_UNKNOWN) {
+        mark_classified_protocol(skb_tup, protocol);
         return;
     }
+    if (next < CLASSIFICATION_PROG_MAX) {
+        bpf_tail_call_compat(skb, &classification_progs, next);
+    }
+}
 
-    // We support non empty TCP payloads for classification at the moment.
-    if (!is_tcp(&skb_tup) || is_payload_empty(skb, &skb_info)) {
+// A shared implementation for the runtime & prebuilt socket filter that dispatches the protocol classification of
+// the connections: the fragment is read once, the application layer protocols are classified, and the connections
+// which could not be classified are handed to the next classification program. The UDP flows are classified as
+// HTTP/3 from the QUIC Initial packets opening them, and are not handed to the next programs: the packets following
+// them update the stats of the QUIC flows, see quic_stats_process.
+__maybe_unused static __always_inline void protocol_classifier_entrypoint(struct __sk_buff *skb) {
+    skb_info_t skb_info = {0};
+    conn_tuple_t skb_tup = {0};
+    size_t size = 0;
+    char *request_fragment = get_classification_fragment(skb, &skb_info, &skb_tup, &size);
+    if (request_fragment == NULL) {
         return;
     }
 
     protocol_t *cur_fragment_protocol_ptr = bpf_map_lookup_elem(&connection_protocol, &skb_tup);
     if (cur_fragment_protocol_ptr) {
+        if (!is_tcp(&skb_tup) && *cur_fragment_protocol_ptr == PROTOCOL_HTTP3) {
+            quic_stats_process(skb, &skb_info, &skb_tup);
+        }
         return;
     }
 
-    protocol_t cur_fragment_protocol = PROTOCOL_UNKNOWN;
+    // the smaller datagrams can't carry the Initial packets of the QUIC clients, and are not read
+    if (!is_tcp(&skb_tup) && skb->len - skb_info.data_off < QUIC_MIN_INITIAL_DATAGRAM_SIZE) {
+        return;
+    }
 
-    // Get the buffer the fragment will be read into from a per-cpu array map.
-    // This will avoid doing unaligned stack access while parsing the protocols,
-    // which is forbidden and will make the verifier fail.
-    const u32 key = 0;
-    char *request_fragment = bpf_map_lookup_elem(&classification_buf, &key);
+    bpf_memset(request_fragment, 0, CLASSIFICATION_MAX_BUFFER);
+    read_into_buffer_for_classification(request_fragment, skb, &skb_info);
+
+    if (!is_tcp(&skb_tup)) {
+        if (is_quic_initial(request_fragment, size, skb->len - skb_info.data_off)) {
+            mark_classified_protocol(&skb_tup, PROTOCOL_HTTP3);
+            quic_stats_init(&skb_tup);
+        }
+        return;
+    }
+
+    protocol_t protocol = classify_applayer_protocols(request_fragment, size);
+    classification_next_program(skb, &skb_tup, protocol, CLASSIFICATION_QUEUES_PROG);
+}
+
+// Classifies the message queues protocols of the connections the dispatcher could not classify.
+__maybe_unused static __always_inline void protocol_classifier_queues_entrypoint(struct __sk_buff *skb) {
+    skb_info_t skb_info = {0};
+    conn_tuple_t skb_tup = {0};
+    size_t size = 0;
+    char *request_fragment = get_classification_fragment(skb, &skb_info, &skb_tup, &size);
     if (request_fragment == NULL) {
-        log_debug("could not get classification buffer from map");
         return;
     }
 
-    bpf_memset(request_fragment, 0, sizeof(request_fragment));
-    read_into_buffer_for_classification((char *)request_fragment, skb, &skb_info);
-    const size_t payload_length = skb->len - skb_info.data_off;
-    const size_t final_fragment_size = payload_length < CLASSIFICATION_MAX_BUFFER ? payload_length : CLASSIFICATION_MAX_BUFFER;
-    classify_protocol(&cur_fragment_protocol, &skb_tup, request_fragment, final_fragment_size);
-    // If there has been a change in the classification, save the new protocol.
-    if (cur_fragment_protocol != PROTOCOL_UNKNOWN) {
-        bpf_map_update_with_telemetry(connection_protocol, &skb_tup, &cur_fragment_protocol, BPF_NOEXIST);
-        conn_tuple_t inverse_skb_conn_tup = skb_tup;
-        flip_tuple(&inverse_skb_conn_tup);
-        bpf_map_update_with_telemetry(connection_protocol, &inverse_skb_conn_tup, &cur_fragment_protocol, BPF_NOEXIST);
+    protocol_t protocol = classify_queue_protocols(request_fragment, size);
+    classification_next_program(skb, &skb_tup, protocol, CLASSIFICATION_DBS_PROG);
+}
+
+// Classifies the databases protocols of the connections the previous programs could not classify.
+__maybe_unused static __always_inline void protocol_classifier_dbs_entrypoint(struct __sk_buff *skb) {
+    skb_info_t skb_info = {0};
+    conn_tuple_t skb_tup = {0};
+    size_t size = 0;
+    char *request_fragment = get_classification_fragment(skb, &skb_info, &skb_tup, &size);
+    if (request_fragment == NULL) {
+        return;
     }
+
+    protocol_t protocol = classify_db_protocols(&skb_tup, request_fragment, size);
+    classification_next_program(skb, &skb_tup, protocol, CLASSIFICATION_PROG_MAX);
 }
 
 #endif
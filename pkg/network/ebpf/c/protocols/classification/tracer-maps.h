@@ -3,6 +3,8 @@
 
 #include "map-defs.h"
 
+#include "protocols/classification/defs.h"
+
 // Maps skb connection tuple to socket connection tuple.
 // On ingress, skb connection tuple is pre NAT, and socket connection tuple is post NAT, and on egress, the opposite.
 // We track the lifecycle of socket using tracepoint net/net_dev_queue.
@@ -17,4 +19,8 @@ BPF_HASH_MAP(conn_tuple_to_socket_skb_conn_tuple, conn_tuple_t, conn_tuple_t, 0)
 // connection. Assumption: each connection has a single protocol.
 BPF_HASH_MAP(connection_protocol, conn_tuple_t, protocol_t, 0)
 
+// Map used to store the programs of the protocol classification, tail called by the dispatcher program (see
+// classification_prog_t).
+BPF_PROG_ARRAY(classification_progs, CLASSIFICATION_PROG_MAX)
+
 #endif
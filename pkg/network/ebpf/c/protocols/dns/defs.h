@@ -0,0 +1,14 @@
+#ifndef __DNS_TLS_DEFS_H
+#define __DNS_TLS_DEFS_H
+
+// The port of the DNS over TLS servers, see RFC 7858
+#define DNS_TLS_PORT 853
+
+// The size of the beginning of the plaintext sent to userspace, which holds the DNS messages of most of the queries
+// and responses. The plaintext of each direction of a connection being a stream of DNS messages, the messages
+// following a truncated plaintext are lost until the next connection.
+#define DNS_TLS_BUFFER_SIZE 512
+#define DNS_TLS_BLK_SIZE 16
+#define DNS_TLS_BATCH_SIZE 6
+
+#endif
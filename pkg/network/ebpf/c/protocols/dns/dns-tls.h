@@ -0,0 +1,81 @@
+#ifndef __DNS_TLS_H
+#define __DNS_TLS_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "defs.h"
+
+#include "protocols/events.h"
+#include "protocols/dns/defs.h"
+#include "protocols/dns/maps.h"
+#include "protocols/dns/types.h"
+
+USM_EVENTS_INIT(dns_tls, dns_tls_segment_t, DNS_TLS_BATCH_SIZE);
+
+static __always_inline bool dns_tls_monitoring_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("dns_tls_monitoring_enabled", val);
+    return val == ENABLED;
+}
+
+// Reads the beginning of the plaintext into buffer, which holds up to DNS_TLS_BUFFER_SIZE bytes. The bytes are read in
+// blocks of DNS_TLS_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the verifiers of the
+// older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 dns_tls_read_into_buffer(char *buffer, char *data, size_t data_size) {
+    __u32 read = 0;
+#pragma unroll(DNS_TLS_BUFFER_SIZE / DNS_TLS_BLK_SIZE)
+    for (int i = 0; i < DNS_TLS_BUFFER_SIZE / DNS_TLS_BLK_SIZE; i++) {
+        if (read + DNS_TLS_BLK_SIZE > data_size) {
+            break;
+        }
+        if (bpf_probe_read_user_with_telemetry(&buffer[read], DNS_TLS_BLK_SIZE, data + read) < 0) {
+            return read;
+        }
+        read += DNS_TLS_BLK_SIZE;
+    }
+    if (read == DNS_TLS_BUFFER_SIZE) {
+        return read;
+    }
+
+#define DNS_TLS_READ_CHUNK(size)                                                                    \
+    if (read + size <= data_size && read + size <= DNS_TLS_BUFFER_SIZE) {                           \
+        if (bpf_probe_read_user_with_telemetry(&buffer[read], size, data + read) < 0) {             \
+            return read;                                                                            \
+        }                                                                                           \
+        read += size;                                                                               \
+    }
+
+    DNS_TLS_READ_CHUNK(8);
+    DNS_TLS_READ_CHUNK(4);
+    DNS_TLS_READ_CHUNK(2);
+    DNS_TLS_READ_CHUNK(1);
+#undef DNS_TLS_READ_CHUNK
+
+    return read;
+}
+
+// Sends the plaintext of a DNS over TLS connection to userspace. Returns true if the connection is a DNS over TLS one,
+// in which case its plaintext is not to be processed as HTTP.
+static __always_inline bool dns_tls_process(conn_tuple_t *t, char *buffer, size_t len, bool is_write) {
+    if (!dns_tls_monitoring_enabled() || (t->dport != DNS_TLS_PORT && t->sport != DNS_TLS_PORT)) {
+        return false;
+    }
+
+    const __u32 zero = 0;
+    dns_tls_segment_t *segment = bpf_map_lookup_elem(&dns_tls_heap, &zero);
+    if (segment == NULL) {
+        return true;
+    }
+
+    bpf_memset(segment, 0, sizeof(dns_tls_segment_t));
+    segment->tup = *t;
+    segment->timestamp = bpf_ktime_get_ns();
+    segment->pid = bpf_get_current_pid_tgid() >> 32;
+    segment->len = len;
+    segment->is_write = is_write;
+    segment->fragment_size = dns_tls_read_into_buffer(segment->fragment, buffer, len);
+    dns_tls_batch_enqueue(segment);
+    return true;
+}
+
+#endif
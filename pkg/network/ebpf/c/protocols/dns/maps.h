@@ -0,0 +1,13 @@
+#ifndef __DNS_TLS_MAPS_H
+#define __DNS_TLS_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/dns/types.h"
+
+/* This map is used as a scratch buffer to build the segments sent to userspace, as they are too large for the eBPF
+   stack */
+BPF_PERCPU_ARRAY_MAP(dns_tls_heap, __u32, dns_tls_segment_t, 1)
+
+#endif
@@ -0,0 +1,23 @@
+#ifndef __DNS_TLS_TYPES_H
+#define __DNS_TLS_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/dns/defs.h"
+
+// Plaintext read from, or written to, a DNS over TLS connection, which is reassembled into DNS messages and parsed by
+// the DNS snooper in userspace.
+typedef struct {
+    // the tuple of the connection, the client being the source
+    conn_tuple_t tup;
+    __u64 timestamp;
+    // the process reading or writing the plaintext, as both ends of the connection may be hooked
+    __u32 pid;
+    // the length of the plaintext, which tells whether it was truncated
+    __u32 len;
+    __u16 fragment_size;
+    __u8 is_write;
+    char fragment[DNS_TLS_BUFFER_SIZE];
+} dns_tls_segment_t;
+
+#endif
@@ -31,6 +31,8 @@ typedef struct {
     __u16 cap;
     __u16 event_size;
     __u16 dropped_events;
+    // cpu is the core the batch belongs to, as the batches of all cores are sent to the same ring buffer
+    __u16 cpu;
     char data[BATCH_BUFFER_SIZE];
 } batch_data_t;
 
@@ -2,6 +2,7 @@
 #define __USM_EVENTS_H
 
 #include "bpf_telemetry.h"
+#include "ring-buffer.h"
 
 #include "protocols/events-types.h"
 
@@ -40,11 +41,19 @@
                     return;                                                             \
                 }                                                                       \
                                                                                         \
-                long ret = bpf_perf_event_output_with_telemetry(ctx,                    \
-                                                                &name##_batch_events,   \
-                                                                key.cpu,                \
-                                                                batch,                  \
-                                                                sizeof(batch_data_t));  \
+                long ret = 0;                                                           \
+                if (ring_buffers_enabled()) {                                           \
+                    ret = bpf_ringbuf_output(&name##_batch_events,                      \
+                                             batch,                                     \
+                                             sizeof(batch_data_t),                      \
+                                             0);                                        \
+                } else {                                                                \
+                    ret = bpf_perf_event_output_with_telemetry(ctx,                     \
+                                                               &name##_batch_events,    \
+                                                               key.cpu,                 \
+                                                               batch,                   \
+                                                               sizeof(batch_data_t));   \
+                }                                                                       \
                 if (ret < 0) {                                                          \
                     _LOG(name, "batch flush error: cpu: %d idx: %d err:%d",             \
                          key.cpu, batch->idx, ret);                                     \
@@ -90,6 +99,7 @@
         batch->cap = batch_size;                                                        \
         batch->event_size = sizeof(value);                                              \
         batch->idx = batch_state->idx;                                                  \
+        batch->cpu = key.cpu;                                                           \
                                                                                         \
         _LOG(name, "event enqueued: cpu: %d batch_idx: %d len: %d",                     \
              key.cpu, batch_state->idx, batch->len);                                    \
@@ -0,0 +1,11 @@
+#ifndef __GRPC_DEFS_H
+#define __GRPC_DEFS_H
+
+// The size of the beginning of the header blocks sent to userspace, which is expected to hold the whole header block
+// of most of the gRPC requests and responses. The HPACK dynamic table of a connection can only be rebuilt in
+// userspace from the header blocks which are not truncated.
+#define GRPC_BUFFER_SIZE 256
+#define GRPC_BLK_SIZE 16
+#define GRPC_BATCH_SIZE 10
+
+#endif
@@ -0,0 +1,145 @@
+#ifndef __GRPC_H
+#define __GRPC_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "defs.h"
+
+#include "protocols/events.h"
+#include "protocols/grpc/defs.h"
+#include "protocols/grpc/maps.h"
+#include "protocols/grpc/types.h"
+#include "protocols/http2/defs.h"
+
+USM_EVENTS_INIT(grpc, grpc_headers_t, GRPC_BATCH_SIZE);
+
+static __always_inline bool grpc_monitoring_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("grpc_monitoring_enabled", val);
+    return val == ENABLED;
+}
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to GRPC_BUFFER_SIZE bytes. The
+// bytes are read in blocks of GRPC_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the
+// verifiers of the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 grpc_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer) {
+    __u32 read = 0;
+#pragma unroll(GRPC_BUFFER_SIZE / GRPC_BLK_SIZE)
+    for (int i = 0; i < GRPC_BUFFER_SIZE / GRPC_BLK_SIZE; i++) {
+        if (offset + GRPC_BLK_SIZE > end) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], GRPC_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += GRPC_BLK_SIZE;
+        read += GRPC_BLK_SIZE;
+    }
+    if (read == GRPC_BUFFER_SIZE) {
+        return read;
+    }
+
+#define GRPC_READ_CHUNK(size)                                                                       \
+    if (offset + size <= end && read + size <= GRPC_BUFFER_SIZE) {                                  \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    GRPC_READ_CHUNK(8);
+    GRPC_READ_CHUNK(4);
+    GRPC_READ_CHUNK(2);
+    GRPC_READ_CHUNK(1);
+#undef GRPC_READ_CHUNK
+
+    return read;
+}
+
+// Sends the header blocks of the HEADERS frames of the TCP segment to userspace, where the names of the gRPC methods
+// and the status of their calls are decoded. The header blocks continued in CONTINUATION frames are truncated.
+static __always_inline void grpc_process_headers(struct __sk_buff *skb, http2_headers_frames_t *headers) {
+    if (!grpc_monitoring_enabled() || headers->count == 0) {
+        return;
+    }
+
+    const __u32 zero = 0;
+    grpc_headers_t *tx = bpf_map_lookup_elem(&grpc_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+
+    __u64 now = bpf_ktime_get_ns();
+#pragma unroll(HTTP2_MAX_HEADERS_PER_SEGMENT)
+    for (int i = 0; i < HTTP2_MAX_HEADERS_PER_SEGMENT; i++) {
+        if (i >= headers->count) {
+            break;
+        }
+
+        bpf_memset(tx, 0, sizeof(grpc_headers_t));
+        tx->tup = headers->tup;
+        tx->timestamp = now;
+        tx->stream_id = headers->stream_ids[i];
+        tx->frame_length = headers->lengths[i];
+        tx->flags = headers->flags[i];
+        tx->from_client = headers->from_client;
+
+        __u32 end = headers->offsets[i] + headers->lengths[i];
+        if (end > skb->len) {
+            end = skb->len;
+        }
+        tx->fragment_size = grpc_read_into_buffer(skb, headers->offsets[i], end, tx->fragment);
+        grpc_batch_enqueue(tx);
+    }
+}
+
+// Sends the header blocks of the HEADERS frames of the plaintext of an HTTP/2 connection over TLS to userspace, in
+// the same way as grpc_process_headers, along with the static tags of the TLS library.
+static __always_inline void grpc_process_tls_headers(char *buffer, __u32 len, http2_headers_frames_t *headers, __u64 tags) {
+    if (!grpc_monitoring_enabled() || headers->count == 0) {
+        return;
+    }
+
+    const __u32 zero = 0;
+    grpc_headers_t *tx = bpf_map_lookup_elem(&grpc_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+
+    __u64 now = bpf_ktime_get_ns();
+#pragma unroll(HTTP2_MAX_HEADERS_PER_SEGMENT)
+    for (int i = 0; i < HTTP2_MAX_HEADERS_PER_SEGMENT; i++) {
+        if (i >= headers->count) {
+            break;
+        }
+
+        bpf_memset(tx, 0, sizeof(grpc_headers_t));
+        tx->tup = headers->tup;
+        tx->timestamp = now;
+        tx->tags = tags;
+        tx->stream_id = headers->stream_ids[i];
+        tx->frame_length = headers->lengths[i];
+        tx->flags = headers->flags[i];
+        tx->from_client = headers->from_client;
+
+        __u32 offset = headers->offsets[i];
+        if (offset >= len) {
+            break;
+        }
+        __u32 size = len - offset;
+        if (size > headers->lengths[i]) {
+            size = headers->lengths[i];
+        }
+        if (size > GRPC_BUFFER_SIZE) {
+            size = GRPC_BUFFER_SIZE;
+        }
+        if (bpf_probe_read_user_with_telemetry(tx->fragment, size, buffer + offset) < 0) {
+            continue;
+        }
+        tx->fragment_size = size;
+        grpc_batch_enqueue(tx);
+    }
+}
+
+#endif
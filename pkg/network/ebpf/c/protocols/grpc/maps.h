@@ -0,0 +1,13 @@
+#ifndef __GRPC_MAPS_H
+#define __GRPC_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/grpc/types.h"
+
+/* This map is used as a scratch buffer to build the header blocks sent to userspace, as they are too large for the
+   eBPF stack */
+BPF_PERCPU_ARRAY_MAP(grpc_heap, __u32, grpc_headers_t, 1)
+
+#endif
@@ -0,0 +1,25 @@
+#ifndef __GRPC_TYPES_H
+#define __GRPC_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/grpc/defs.h"
+
+// Header block of an HTTP/2 HEADERS frame, which is decoded in userspace along with the other header blocks sent by
+// the same side of the connection, as they share the same HPACK dynamic table.
+typedef struct {
+    // the normalized tuple of the connection, the client being the source
+    conn_tuple_t tup;
+    __u64 timestamp;
+    // the static tags of the TLS library through which the header block was read or written, if any
+    __u64 tags;
+    __u32 stream_id;
+    // the length of the frame, which tells whether the header block was truncated
+    __u32 frame_length;
+    __u16 fragment_size;
+    __u8 flags;
+    __u8 from_client;
+    char fragment[GRPC_BUFFER_SIZE];
+} grpc_headers_t;
+
+#endif
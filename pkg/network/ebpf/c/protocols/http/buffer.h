@@ -33,12 +33,65 @@ static __always_inline void read_into_buffer(char *buffer, char *data, size_t da
 }
 
 
+// This function reads the last HTTP_SEGMENT_TAIL_SIZE bytes of the data into buffer. If there are fewer of them, all
+// of them are read, at the end of the buffer, so that a last chunk or the trailers written on their own are seen too.
+// The bytes are read in blocks of the sizes of the bits of data_size, so that the verifier only sees constant sizes.
+//
+// This function is used for the uprobe-based HTTPS monitoring (eg. OpenSSL, GnuTLS etc)
+static __always_inline void read_tail_into_buffer(char *buffer, char *data, size_t data_size) {
+    if (data_size >= HTTP_SEGMENT_TAIL_SIZE) {
+        bpf_probe_read_user_with_telemetry(buffer, HTTP_SEGMENT_TAIL_SIZE, data + data_size - HTTP_SEGMENT_TAIL_SIZE);
+        return;
+    }
+
+    u32 offset = HTTP_SEGMENT_TAIL_SIZE;
+#pragma unroll
+    for (u32 block = HTTP_SEGMENT_TAIL_SIZE / 2; block > 0; block /= 2) {
+        if (data_size & block) {
+            offset -= block;
+            bpf_probe_read_user_with_telemetry(buffer + offset, block, data + data_size - (HTTP_SEGMENT_TAIL_SIZE - offset));
+        }
+    }
+}
+
+// This function is used for the socket-filter HTTP monitoring. The last HTTP_SEGMENT_TAIL_SIZE bytes of the packet are
+// read even if its payload is shorter, in which case they begin with the bytes of its headers. Shorter packets are
+// read whole, at the end of the buffer, as done by read_tail_into_buffer.
+static __always_inline void read_tail_into_buffer_skb(char *buffer, struct __sk_buff *skb, skb_info_t *info) {
+    if (skb->len >= HTTP_SEGMENT_TAIL_SIZE) {
+        bpf_skb_load_bytes_with_telemetry(skb, skb->len - HTTP_SEGMENT_TAIL_SIZE, buffer, HTTP_SEGMENT_TAIL_SIZE);
+        return;
+    }
+    if (skb->len <= info->data_off) {
+        return;
+    }
+
+    const u32 len = skb->len;
+    u32 offset = HTTP_SEGMENT_TAIL_SIZE;
+#pragma unroll
+    for (u32 block = HTTP_SEGMENT_TAIL_SIZE / 2; block > 0; block /= 2) {
+        if (len & block) {
+            offset -= block;
+            bpf_skb_load_bytes_with_telemetry(skb, len - (HTTP_SEGMENT_TAIL_SIZE - offset), buffer + offset, block);
+        }
+    }
+}
+
+// Returns the number of bytes of the request fragment to read, which is set from userspace when the HTTP paths are
+// configured to be shorter than the fragment, so that the bytes of the paths beyond it are not read.
+static __always_inline u32 http_max_fragment_size() {
+    __u64 val = 0;
+    LOAD_CONSTANT("http_max_fragment_size", val);
+    return val > 0 && val < HTTP_BUFFER_SIZE ? (u32)val : HTTP_BUFFER_SIZE;
+}
+
 // This function is used for the socket-filter HTTP monitoring
 static __always_inline void read_into_buffer_skb(char *buffer, struct __sk_buff *skb, skb_info_t *info) {
     u64 offset = (u64)info->data_off;
 
 #define BLK_SIZE (16)
-    const u32 len = HTTP_BUFFER_SIZE < (skb->len - (u32)offset) ? (u32)offset + HTTP_BUFFER_SIZE : skb->len;
+    const u32 max = http_max_fragment_size();
+    const u32 len = max < (skb->len - (u32)offset) ? (u32)offset + max : skb->len;
 
     unsigned i = 0;
 
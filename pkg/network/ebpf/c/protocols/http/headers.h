@@ -0,0 +1,144 @@
+#ifndef __HTTP_HEADERS_H
+#define __HTTP_HEADERS_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "defs.h"
+
+#include "protocols/http/types.h"
+#include "protocols/http/maps.h"
+
+static __always_inline bool http_capture_headers_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("http_capture_headers_enabled", val);
+    return val == ENABLED;
+}
+
+// Returns true if the fragment may be the beginning of a request, whose headers are then worth reading. Responses and
+// the other segments are left out cheaply, the exact parsing of the fragment being done by http_process.
+static __always_inline bool http_headers_candidate(const char *fragment) {
+    return fragment[0] >= 'A' && fragment[0] <= 'Z' && !(fragment[0] == 'H' && fragment[1] == 'T');
+}
+
+// Returns the per-CPU scratch buffer holding the headers of the segment being processed, or NULL if the capture of
+// the headers is disabled.
+static __always_inline http_headers_t *http_headers_scratch() {
+    if (!http_capture_headers_enabled()) {
+        return NULL;
+    }
+    __u32 zero = 0;
+    return bpf_map_lookup_elem(&http_headers_heap, &zero);
+}
+
+// Reads the beginning of the segment into the scratch buffer of the headers. The bytes are read in blocks of
+// HTTP_HEADERS_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the verifiers of the older
+// kernels only accept reads of a constant size.
+//
+// This function is used for the socket-filter HTTP monitoring
+static __always_inline void http_read_headers_skb(struct __sk_buff *skb, skb_info_t *info, const char *fragment) {
+    if (!http_headers_candidate(fragment)) {
+        return;
+    }
+    http_headers_t *headers = http_headers_scratch();
+    if (headers == NULL) {
+        return;
+    }
+
+    __u32 offset = info->data_off;
+    __u32 end = skb->len;
+    __u32 read = 0;
+    headers->len = 0;
+#pragma unroll(HTTP_HEADERS_BUFFER_SIZE / HTTP_HEADERS_BLK_SIZE)
+    for (int i = 0; i < HTTP_HEADERS_BUFFER_SIZE / HTTP_HEADERS_BLK_SIZE; i++) {
+        if (offset + HTTP_HEADERS_BLK_SIZE > end) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &headers->buffer[read], HTTP_HEADERS_BLK_SIZE) < 0) {
+            headers->len = read;
+            return;
+        }
+        offset += HTTP_HEADERS_BLK_SIZE;
+        read += HTTP_HEADERS_BLK_SIZE;
+    }
+
+#define HTTP_HEADERS_READ_CHUNK(size)                                                                       \
+    if (offset + size <= end && read + size <= HTTP_HEADERS_BUFFER_SIZE) {                                  \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &headers->buffer[read], size) < 0) {             \
+            headers->len = read;                                                                            \
+            return;                                                                                         \
+        }                                                                                                   \
+        offset += size;                                                                                     \
+        read += size;                                                                                       \
+    }
+
+    HTTP_HEADERS_READ_CHUNK(8);
+    HTTP_HEADERS_READ_CHUNK(4);
+    HTTP_HEADERS_READ_CHUNK(2);
+    HTTP_HEADERS_READ_CHUNK(1);
+#undef HTTP_HEADERS_READ_CHUNK
+
+    headers->len = read;
+}
+
+// Reads the beginning of the plaintext into the scratch buffer of the headers, similarly to http_read_headers_skb.
+//
+// This function is used for the uprobe-based HTTPS monitoring (eg. OpenSSL, GnuTLS etc)
+static __always_inline void http_read_headers_user(char *data, size_t data_size, const char *fragment) {
+    if (!http_headers_candidate(fragment)) {
+        return;
+    }
+    http_headers_t *headers = http_headers_scratch();
+    if (headers == NULL) {
+        return;
+    }
+
+    __u32 read = 0;
+    headers->len = 0;
+#pragma unroll(HTTP_HEADERS_BUFFER_SIZE / HTTP_HEADERS_BLK_SIZE)
+    for (int i = 0; i < HTTP_HEADERS_BUFFER_SIZE / HTTP_HEADERS_BLK_SIZE; i++) {
+        if (read + HTTP_HEADERS_BLK_SIZE > data_size) {
+            break;
+        }
+        if (bpf_probe_read_user_with_telemetry(&headers->buffer[read], HTTP_HEADERS_BLK_SIZE, data + read) < 0) {
+            headers->len = read;
+            return;
+        }
+        read += HTTP_HEADERS_BLK_SIZE;
+    }
+
+#define HTTP_HEADERS_READ_CHUNK(size)                                                                       \
+    if (read + size <= data_size && read + size <= HTTP_HEADERS_BUFFER_SIZE) {                              \
+        if (bpf_probe_read_user_with_telemetry(&headers->buffer[read], size, data + read) < 0) {            \
+            headers->len = read;                                                                            \
+            return;                                                                                         \
+        }                                                                                                   \
+        read += size;                                                                                       \
+    }
+
+    HTTP_HEADERS_READ_CHUNK(8);
+    HTTP_HEADERS_READ_CHUNK(4);
+    HTTP_HEADERS_READ_CHUNK(2);
+    HTTP_HEADERS_READ_CHUNK(1);
+#undef HTTP_HEADERS_READ_CHUNK
+
+    headers->len = read;
+}
+
+// Stores the headers read from the segment beginning the request, for userspace to find them along with its
+// transaction. Nothing is stored if the headers of the segment were not read.
+static __always_inline void http_store_headers(conn_tuple_t *tup, __u64 request_started) {
+    http_headers_t *headers = http_headers_scratch();
+    if (headers == NULL || headers->len == 0) {
+        return;
+    }
+
+    http_headers_key_t key;
+    bpf_memset(&key, 0, sizeof(key));
+    key.tup = *tup;
+    key.request_started = request_started;
+    bpf_map_update_with_telemetry(http_request_headers, &key, headers, BPF_ANY);
+    // the scratch buffer is consumed, so that it is not attributed to a later request
+    headers->len = 0;
+}
+
+#endif
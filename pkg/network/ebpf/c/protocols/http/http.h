@@ -7,60 +7,50 @@
 
 #include "protocols/events.h"
 #include "protocols/http/types.h"
+#include "protocols/http/headers.h"
 #include "protocols/http/maps.h"
+#include "protocols/http/parsing.h"
 #include "protocols/tls/https.h"
 
 USM_EVENTS_INIT(http, http_transaction_t, HTTP_BATCH_SIZE);
 
+// http_interim_response returns true if the transaction only got an informational (1xx) response so far, such as the
+// 100 Continue sent to a client waiting for it before uploading the request body. Such a response is followed by the
+// final response of the request, except for 101 Switching Protocols which ends the HTTP exchange.
+static __always_inline bool http_interim_response(http_transaction_t *http) {
+    return http->response_status_code >= 100 && http->response_status_code < 200 && http->response_status_code != 101;
+}
+
+// http_responding returns true once the final response of the transaction has begun
 static __always_inline int http_responding(http_transaction_t *http) {
-    return (http != NULL && http->response_status_code != 0);
+    return (http != NULL && http->response_status_code != 0 && !http_interim_response(http));
+}
+
+// http_requesting returns true while the request of the transaction is being sent, until its final response begins
+static __always_inline bool http_requesting(http_transaction_t *http) {
+    return (http != NULL && http->request_started != 0 && !http_responding(http));
 }
 
-static __always_inline void http_begin_request(http_transaction_t *http, http_method_t method, char *buffer) {
+static __always_inline void http_begin_request(http_transaction_t *http, http_method_t method, char *buffer, __u32 size) {
     http->request_method = method;
     http->request_started = bpf_ktime_get_ns();
+    http->request_size = size;
+    http->response_first_seen = 0;
     http->response_last_seen = 0;
     http->response_status_code = 0;
+    http->response_size = 0;
     bpf_memcpy(&http->request_fragment, buffer, HTTP_BUFFER_SIZE);
+    http_store_headers(&http->tup, http->request_started);
     log_debug("http_begin_request: htx=%llx method=%d start=%llx\n", http, http->request_method, http->request_started);
 }
 
 static __always_inline void http_begin_response(http_transaction_t *http, const char *buffer) {
-    u16 status_code = 0;
-    status_code += (buffer[HTTP_STATUS_OFFSET+0]-'0') * 100;
-    status_code += (buffer[HTTP_STATUS_OFFSET+1]-'0') * 10;
-    status_code += (buffer[HTTP_STATUS_OFFSET+2]-'0') * 1;
+    u16 status_code = http_parse_status_code(buffer);
     http->response_status_code = status_code;
+    http->response_first_seen = bpf_ktime_get_ns();
     log_debug("http_begin_response: htx=%llx status=%d\n", http, status_code);
 }
 
-static __always_inline void http_parse_data(char const *p, http_packet_t *packet_type, http_method_t *method) {
-    if ((p[0] == 'H') && (p[1] == 'T') && (p[2] == 'T') && (p[3] == 'P')) {
-        *packet_type = HTTP_RESPONSE;
-    } else if ((p[0] == 'G') && (p[1] == 'E') && (p[2] == 'T') && (p[3]  == ' ') && (p[4] == '/')) {
-        *packet_type = HTTP_REQUEST;
-        *method = HTTP_GET;
-    } else if ((p[0] == 'P') && (p[1] == 'O') && (p[2] == 'S') && (p[3] == 'T') && (p[4]  == ' ') && (p[5] == '/')) {
-        *packet_type = HTTP_REQUEST;
-        *method = HTTP_POST;
-    } else if ((p[0] == 'P') && (p[1] == 'U') && (p[2] == 'T') && (p[3]  == ' ') && (p[4] == '/')) {
-        *packet_type = HTTP_REQUEST;
-        *method = HTTP_PUT;
-    } else if ((p[0] == 'D') && (p[1] == 'E') && (p[2] == 'L') && (p[3] == 'E') && (p[4] == 'T') && (p[5] == 'E') && (p[6]  == ' ') && (p[7] == '/')) {
-        *packet_type = HTTP_REQUEST;
-        *method = HTTP_DELETE;
-    } else if ((p[0] == 'H') && (p[1] == 'E') && (p[2] == 'A') && (p[3] == 'D') && (p[4]  == ' ') && (p[5] == '/')) {
-        *packet_type = HTTP_REQUEST;
-        *method = HTTP_HEAD;
-    } else if ((p[0] == 'O') && (p[1] == 'P') && (p[2] == 'T') && (p[3] == 'I') && (p[4] == 'O') && (p[5] == 'N') && (p[6] == 'S') && (p[7]  == ' ') && ((p[8] == '/') || (p[8] == '*'))) {
-        *packet_type = HTTP_REQUEST;
-        *method = HTTP_OPTIONS;
-    } else if ((p[0] == 'P') && (p[1] == 'A') && (p[2] == 'T') && (p[3] == 'C') && (p[4] == 'H') && (p[5]  == ' ') && (p[6] == '/')) {
-        *packet_type = HTTP_REQUEST;
-        *method = HTTP_PATCH;
-    }
-}
-
 static __always_inline bool http_seen_before(http_transaction_t *http, skb_info_t *skb_info) {
     if (!skb_info || !skb_info->tcp_seq) {
         return false;
@@ -95,7 +85,146 @@ static __always_inline http_transaction_t *http_fetch_state(http_transaction_t *
 
 static __always_inline bool http_should_flush_previous_state(http_transaction_t *http, http_packet_t packet_type) {
     return (packet_type == HTTP_REQUEST && http->request_started) ||
-        (packet_type == HTTP_RESPONSE && http->response_status_code);
+        (packet_type == HTTP_RESPONSE && http_responding(http));
+}
+
+static __always_inline http_pipeline_t *http_fetch_pipeline(conn_tuple_t *tup) {
+    http_pipeline_t *pipeline = bpf_map_lookup_elem(&http_pipelined_requests, tup);
+    if (pipeline) {
+        return pipeline;
+    }
+
+    u32 zero = 0;
+    http_pipeline_t *empty = bpf_map_lookup_elem(&http_pipeline_heap, &zero);
+    if (!empty) {
+        return NULL;
+    }
+
+    bpf_map_update_with_telemetry(http_pipelined_requests, tup, empty, BPF_NOEXIST);
+    return bpf_map_lookup_elem(&http_pipelined_requests, tup);
+}
+
+// http_pipeline_pop replaces the request of the given transaction by the oldest pending request of the connection,
+// and returns false if there is none.
+static __always_inline bool http_pipeline_pop(http_transaction_t *http, http_pipeline_t *pipeline) {
+    if (!pipeline || pipeline->len == 0) {
+        return false;
+    }
+
+    http_pending_request_t *pending = &pipeline->requests[pipeline->head & (HTTP_MAX_PIPELINED_REQUESTS - 1)];
+    http->request_method = pending->request_method;
+    http->request_started = pending->request_started;
+    http->request_size = pending->request_size;
+    http->response_first_seen = 0;
+    http->response_last_seen = 0;
+    http->response_status_code = 0;
+    http->response_size = 0;
+    bpf_memcpy(&http->request_fragment, pending->request_fragment, HTTP_BUFFER_SIZE);
+
+    pipeline->head = (pipeline->head + 1) & (HTTP_MAX_PIPELINED_REQUESTS - 1);
+    pipeline->len--;
+    log_debug("http_pipeline_pop: htx=%llx pending=%d\n", http, pipeline->len);
+    return true;
+}
+
+// http_pipeline_request queues the given request behind the in-flight transaction if the latter is still awaiting
+// its response (or if other requests are already queued), and returns true if it did so.
+// When too many requests are queued, the in-flight transaction is flushed without waiting for its response. This is
+// what happens with NAT, where the responses of a connection are seen on a different tuple and requests would
+// otherwise pile up until the connection is closed; such requests are joined with their response in userspace.
+static __always_inline bool http_pipeline_request(http_transaction_t *http, http_transaction_t *http_stack, http_method_t method) {
+    if (!http->request_started) {
+        return false;
+    }
+
+    http_pipeline_t *pipeline = bpf_map_lookup_elem(&http_pipelined_requests, &http->tup);
+    bool pending = pipeline && pipeline->len > 0;
+    if (http_responding(http) && !pending) {
+        // this is a regular keep-alive request: the in-flight transaction is done
+        return false;
+    }
+
+    if (!pipeline) {
+        pipeline = http_fetch_pipeline(&http->tup);
+        if (!pipeline) {
+            return false;
+        }
+    }
+
+    if (pipeline->len >= HTTP_MAX_PIPELINED_REQUESTS) {
+        http_batch_enqueue(http);
+        http_pipeline_pop(http, pipeline);
+    }
+
+    http_pending_request_t *next = &pipeline->requests[(pipeline->head + pipeline->len) & (HTTP_MAX_PIPELINED_REQUESTS - 1)];
+    next->request_method = method;
+    next->request_started = bpf_ktime_get_ns();
+    next->request_size = http_stack->segment_size;
+    bpf_memcpy(&next->request_fragment, http_stack->request_fragment, HTTP_BUFFER_SIZE);
+    http_store_headers(&http->tup, next->request_started);
+    pipeline->len++;
+    log_debug("http_pipeline_request: htx=%llx method=%d pending=%d\n", http, method, pipeline->len);
+    return true;
+}
+
+// http_pipeline_flush flushes the requests still awaiting a response when the connection is closed
+static __always_inline void http_pipeline_flush(http_transaction_t *http) {
+    http_pipeline_t *pipeline = bpf_map_lookup_elem(&http_pipelined_requests, &http->tup);
+    if (!pipeline) {
+        return;
+    }
+
+#pragma unroll
+    for (int i = 0; i < HTTP_MAX_PIPELINED_REQUESTS; i++) {
+        if (!http_pipeline_pop(http, pipeline)) {
+            break;
+        }
+        http_batch_enqueue(http);
+    }
+
+    bpf_map_delete_elem(&http_pipelined_requests, &http->tup);
+}
+
+// http_last_chunk returns true if the segment ends with the last chunk of a chunked response, which is the zero-sized
+// chunk followed by the empty line ending the message, "\r\n0\r\n\r\n", or by trailer fields and the empty line, as
+// long as they fit in the tail of the segment. The zero-sized chunk follows the CRLF of the previous chunk, or begins
+// the segment when it is flushed on its own, as done by the servers streaming their responses. Otherwise, the response
+// is flushed when the next transaction begins or when the connection is closed.
+static __always_inline bool http_last_chunk(http_transaction_t *http_stack) {
+    const char *tail = http_stack->segment_tail;
+    const int end = HTTP_SEGMENT_TAIL_SIZE;
+    if (tail[end-4] != '\r' || tail[end-3] != '\n' || tail[end-2] != '\r' || tail[end-1] != '\n') {
+        return false;
+    }
+
+    // index of the beginning of the segment in its tail, if it is shorter than it
+    int start = end - (int)http_stack->segment_size;
+#pragma unroll
+    for (int i = end - HTTP_LAST_CHUNK_SIZE; i >= 0; i--) {
+        if (tail[i+2] == '0' && tail[i+3] == '\r' && tail[i+4] == '\n' &&
+            ((tail[i] == '\r' && tail[i+1] == '\n') || i + 2 == start)) {
+            return true;
+        }
+    }
+    return false;
+}
+
+// http_end_response flushes the transaction as soon as its response is known to be complete, rather than when the
+// next transaction begins or the connection is closed, and moves on to the next pipelined request, if any.
+static __always_inline void http_end_response(http_transaction_t *http) {
+    log_debug("http_end_response: htx=%llx size=%d\n", http, http->response_size);
+    http_batch_enqueue(http);
+    if (http_pipeline_pop(http, bpf_map_lookup_elem(&http_pipelined_requests, &http->tup))) {
+        return;
+    }
+
+    http->request_started = 0;
+    http->request_method = HTTP_METHOD_UNKNOWN;
+    http->request_size = 0;
+    http->response_first_seen = 0;
+    http->response_last_seen = 0;
+    http->response_status_code = 0;
+    http->response_size = 0;
 }
 
 static __always_inline bool http_closed(http_transaction_t *http, skb_info_t *skb_info, u16 pre_norm_src_port) {
@@ -126,14 +255,27 @@ static __always_inline int http_process(http_transaction_t *http_stack, skb_info
         return 0;
     }
 
-    if (http_should_flush_previous_state(http, packet_type)) {
+    // requests sent while the in-flight transaction is awaiting its response are queued, instead of replacing it
+    bool pipelined = packet_type == HTTP_REQUEST && http_pipeline_request(http, http_stack, method);
+
+    if (!pipelined && http_should_flush_previous_state(http, packet_type)) {
         http_batch_enqueue(http);
         bpf_memcpy(http, http_stack, sizeof(http_transaction_t));
+        if (packet_type == HTTP_RESPONSE) {
+            // the response belongs to the oldest request awaiting a response, if any
+            http_pipeline_pop(http, bpf_map_lookup_elem(&http_pipelined_requests, &http->tup));
+        }
     }
 
-    log_debug("http_process: type=%d method=%d\n", packet_type, method);
-    if (packet_type == HTTP_REQUEST) {
-        http_begin_request(http, method, buffer);
+    log_debug("http_process: type=%d method=%d pipelined=%d\n", packet_type, method, pipelined);
+    if (pipelined) {
+        http_update_seen_before(http, skb_info);
+    } else if (packet_type == HTTP_REQUEST) {
+        http_begin_request(http, method, buffer, http_stack->segment_size);
+        http_update_seen_before(http, skb_info);
+    } else if (packet_type == HTTP_PACKET_UNKNOWN && http_requesting(http)) {
+        // the body of the request, possibly uploaded after an interim 100 Continue response
+        http->request_size += http_stack->segment_size;
         http_update_seen_before(http, skb_info);
     } else if (packet_type == HTTP_RESPONSE) {
         http_begin_response(http, buffer);
@@ -142,12 +284,22 @@ static __always_inline int http_process(http_transaction_t *http_stack, skb_info
 
     http->tags |= tags;
 
-    if (http_responding(http)) {
+    if (!pipelined && http_responding(http)) {
         http->response_last_seen = bpf_ktime_get_ns();
+        http->response_size += http_stack->segment_size;
+        if (packet_type == HTTP_PACKET_UNKNOWN) {
+            http_update_seen_before(http, skb_info);
+        }
+        if (http_last_chunk(http_stack)) {
+            http_end_response(http);
+        }
     }
 
     if (http_closed(http, skb_info, http_stack->owned_by_src_port)) {
-        http_batch_enqueue(http);
+        if (http->request_started || http->response_status_code) {
+            http_batch_enqueue(http);
+        }
+        http_pipeline_flush(http);
         bpf_map_delete_elem(&http_in_flight, &http_stack->tup);
     }
 
@@ -11,8 +11,41 @@
 /* This map is used to keep track of in-flight HTTP transactions for each TCP connection */
 BPF_LRU_MAP(http_in_flight, conn_tuple_t, http_transaction_t, 0)
 
+/* This map holds, for each TCP connection, the requests sent while the in-flight transaction is still awaiting its response.
+   Its size is overwritten from userspace. As pipelining is rare and its values are large, it is not sized after the number
+   of tracked connections, and the LRU eviction bounds its memory instead */
+BPF_LRU_MAP(http_pipelined_requests, conn_tuple_t, http_pipeline_t, 0)
+
+/* This map is used as an empty template to create the entries of http_pipelined_requests, as they are too large for the
+   eBPF stack. It is never written to */
+BPF_PERCPU_ARRAY_MAP(http_pipeline_heap, __u32, http_pipeline_t, 1)
+
+/* This map holds the beginning of the requests, for userspace to extract the values of the allowlisted headers. Its size
+   is set to 1 as the capture of the headers is optional, and overwritten from userspace when it is enabled. The LRU
+   eviction bounds the memory used by the requests whose transactions are never read */
+BPF_LRU_MAP(http_request_headers, http_headers_key_t, http_headers_t, 1)
+
+/* This map is used as a per-CPU scratch buffer holding the headers of the request being processed, as they are too
+   large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(http_headers_heap, __u32, http_headers_t, 1)
+
 BPF_LRU_MAP(ssl_sock_by_ctx, void *, ssl_sock_t, 1)
 
+/* This map holds, for each TCP connection, the number of bytes read and written through the TLS hooks */
+BPF_LRU_MAP(tls_conn_bytes, conn_tuple_t, __u64, 0)
+
+/* This map holds, for each HTTP/2 connection over TLS and each process reading or writing it, whether the process is
+   the client of the connection. The key is the normalized tuple of the connection, along with the PID of the process */
+BPF_LRU_MAP(tls_http2_local_client, conn_tuple_t, __u8, 0)
+
+/* This map holds, for each HTTP/1.1 connection over TLS and each process reading or writing it, whether the process is
+   the client of the connection, the same way tls_http2_local_client does for the HTTP/2 connections */
+BPF_LRU_MAP(tls_http_local_client, conn_tuple_t, __u8, 0)
+
+/* This map holds the static tag of the variant of OpenSSL used by each process, such as BoringSSL or LibreSSL. It is
+   filled by userspace when the library is hooked, and the processes which are not part of it use OpenSSL */
+BPF_HASH_MAP(ssl_library_by_pid, __u32, __u64, 1024)
+
 BPF_LRU_MAP(ssl_read_args, u64, ssl_read_args_t, 1024)
 
 BPF_LRU_MAP(ssl_read_ex_args, u64, ssl_read_ex_args_t, 1024)
@@ -29,9 +62,23 @@ BPF_LRU_MAP(ssl_ctx_by_pid_tgid, __u64, void *, 1024)
 
 BPF_LRU_MAP(open_at_args, __u64, lib_path_t, 1024)
 
+/* java_tls_connections holds the connections whose plaintext was sent by the USM java agent, until they are closed */
+BPF_LRU_MAP(java_tls_connections, conn_tuple_t, __u8, 1)
+
+/* java_conn_tuple_by_engine holds the tuple of the connections of the SSLEngine objects, by engine, as registered by
+   the USM java agent */
+BPF_LRU_MAP(java_conn_tuple_by_engine, java_tls_engine_t, conn_tuple_t, 1)
+
+/* ktls_args holds the socket and the user buffer of the kTLS calls in flight, by pid_tgid, until they return */
+BPF_LRU_MAP(ktls_args, __u64, ktls_args_t, 1024)
+
 // offsets_data map contains the information about the locations of structs in the inspected binary, mapped by the binary's inode number.
 BPF_HASH_MAP(offsets_data, go_tls_offsets_data_key_t, tls_offsets_data_t, 1024)
 
+/* go_tls_binary_by_pid maps the PIDs of the hooked Go processes to the key of their binary in offsets_data.
+   It is filled from userspace and used by the prebuilt probes, which can't read the inode of the task binary. */
+BPF_HASH_MAP(go_tls_binary_by_pid, __u32, go_tls_offsets_data_key_t, 4096)
+
 /* go_tls_read_args is used to get the read function info when running in the read-return uprobe.
    The key contains the go routine id and the pid. */
 BPF_HASH_MAP(go_tls_read_args, go_tls_function_args_key_t, go_tls_read_args_data_t, 1024)
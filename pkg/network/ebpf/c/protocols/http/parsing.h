@@ -0,0 +1,44 @@
+#ifndef __HTTP_PARSING_H
+#define __HTTP_PARSING_H
+
+#include "protocols/http/types.h"
+
+// Parses the beginning of the payload of a TCP segment, which is either the status line of a response or the request
+// line of a request of the given method. The given buffer must hold at least the first 9 bytes of the payload.
+static __always_inline void http_parse_data(char const *p, http_packet_t *packet_type, http_method_t *method) {
+    if ((p[0] == 'H') && (p[1] == 'T') && (p[2] == 'T') && (p[3] == 'P')) {
+        *packet_type = HTTP_RESPONSE;
+    } else if ((p[0] == 'G') && (p[1] == 'E') && (p[2] == 'T') && (p[3]  == ' ') && (p[4] == '/')) {
+        *packet_type = HTTP_REQUEST;
+        *method = HTTP_GET;
+    } else if ((p[0] == 'P') && (p[1] == 'O') && (p[2] == 'S') && (p[3] == 'T') && (p[4]  == ' ') && (p[5] == '/')) {
+        *packet_type = HTTP_REQUEST;
+        *method = HTTP_POST;
+    } else if ((p[0] == 'P') && (p[1] == 'U') && (p[2] == 'T') && (p[3]  == ' ') && (p[4] == '/')) {
+        *packet_type = HTTP_REQUEST;
+        *method = HTTP_PUT;
+    } else if ((p[0] == 'D') && (p[1] == 'E') && (p[2] == 'L') && (p[3] == 'E') && (p[4] == 'T') && (p[5] == 'E') && (p[6]  == ' ') && (p[7] == '/')) {
+        *packet_type = HTTP_REQUEST;
+        *method = HTTP_DELETE;
+    } else if ((p[0] == 'H') && (p[1] == 'E') && (p[2] == 'A') && (p[3] == 'D') && (p[4]  == ' ') && (p[5] == '/')) {
+        *packet_type = HTTP_REQUEST;
+        *method = HTTP_HEAD;
+    } else if ((p[0] == 'O') && (p[1] == 'P') && (p[2] == 'T') && (p[3] == 'I') && (p[4] == 'O') && (p[5] == 'N') && (p[6] == 'S') && (p[7]  == ' ') && ((p[8] == '/') || (p[8] == '*'))) {
+        *packet_type = HTTP_REQUEST;
+        *method = HTTP_OPTIONS;
+    } else if ((p[0] == 'P') && (p[1] == 'A') && (p[2] == 'T') && (p[3] == 'C') && (p[4] == 'H') && (p[5]  == ' ') && (p[6] == '/')) {
+        *packet_type = HTTP_REQUEST;
+        *method = HTTP_PATCH;
+    }
+}
+
+// Parses the status code of the given status line, which must hold at least its first HTTP_STATUS_OFFSET + 3 bytes.
+static __always_inline u16 http_parse_status_code(const char *buffer) {
+    u16 status_code = 0;
+    status_code += (buffer[HTTP_STATUS_OFFSET+0]-'0') * 100;
+    status_code += (buffer[HTTP_STATUS_OFFSET+1]-'0') * 10;
+    status_code += (buffer[HTTP_STATUS_OFFSET+2]-'0') * 1;
+    return status_code;
+}
+
+#endif
@@ -5,8 +5,25 @@
 
 // This determines the size of the payload fragment that is captured for each HTTP request
 #define HTTP_BUFFER_SIZE (8 * 20)
-// This controls the number of HTTP transactions read from userspace at a time
-#define HTTP_BATCH_SIZE 15
+// This determines the number of bytes of the requests, starting with their request line, that are captured for
+// userspace to extract the values of the allowlisted headers
+#define HTTP_HEADERS_BUFFER_SIZE (8 * 48)
+#define HTTP_HEADERS_BLK_SIZE 16
+// This controls the number of HTTP transactions read from userspace at a time. The batches of transactions must fit in
+// BATCH_BUFFER_SIZE bytes.
+#define HTTP_BATCH_SIZE 12
+
+// This controls the number of requests that can be awaiting a response behind the in-flight transaction of a
+// connection (HTTP/1.1 pipelining). It must be a power of 2.
+#define HTTP_MAX_PIPELINED_REQUESTS 4
+
+// Chunked responses end with a zero-sized chunk, which immediately follows the CRLF of the previous chunk (or of the
+// headers): "\r\n0\r\n\r\n"
+#define HTTP_LAST_CHUNK_SIZE 7
+
+// The zero-sized chunk may be followed by trailer fields, such as "\r\n0\r\nServer-Timing: db;dur=53\r\n\r\n", so
+// the last bytes of the segments are kept to find it before the trailers, as long as they fit in them
+#define HTTP_SEGMENT_TAIL_SIZE 64
 
 // HTTP/1.1 XXX
 // _________^
@@ -15,6 +32,9 @@
 // This is needed to reduce code size on multiple copy opitmizations that were made in
 // the http eBPF program.
 _Static_assert((HTTP_BUFFER_SIZE % 8) == 0, "HTTP_BUFFER_SIZE must be a multiple of 8.");
+_Static_assert((HTTP_HEADERS_BUFFER_SIZE % HTTP_HEADERS_BLK_SIZE) == 0, "HTTP_HEADERS_BUFFER_SIZE must be a multiple of HTTP_HEADERS_BLK_SIZE.");
+_Static_assert(HTTP_SEGMENT_TAIL_SIZE >= HTTP_LAST_CHUNK_SIZE, "HTTP_SEGMENT_TAIL_SIZE must hold the last chunk.");
+_Static_assert((HTTP_MAX_PIPELINED_REQUESTS & (HTTP_MAX_PIPELINED_REQUESTS - 1)) == 0, "HTTP_MAX_PIPELINED_REQUESTS must be a power of 2.");
 
 typedef enum
 {
@@ -41,6 +61,7 @@ typedef struct {
     __u64 request_started;
     __u8  request_method;
     __u16 response_status_code;
+    __u64 response_first_seen;
     __u64 response_last_seen;
     char request_fragment[HTTP_BUFFER_SIZE] __attribute__ ((aligned (8)));
 
@@ -55,8 +76,72 @@ typedef struct {
     __u32 tcp_seq;
 
     __u64 tags;
+
+    // number of bytes of the request and of the response seen so far, headers included
+    __u32 request_size;
+    __u32 response_size;
+
+    // these fields are used exclusively in the kernel side to describe the TCP segment being processed:
+    // the size of its payload, and its last bytes, which are used to detect the end of chunked responses
+    __u32 segment_size;
+    char segment_tail[HTTP_SEGMENT_TAIL_SIZE];
 } http_transaction_t;
 
+// Request sent on a connection while the response to a previous request is still expected
+typedef struct {
+    __u64 request_started;
+    __u8  request_method;
+    // only the first segment of a pending request is accounted for
+    __u32 request_size;
+    char request_fragment[HTTP_BUFFER_SIZE] __attribute__ ((aligned (8)));
+} http_pending_request_t;
+
+// Requests awaiting a response behind the in-flight transaction of a connection, stored as a ring buffer in the order
+// they were sent. Since HTTP/1.1 servers must respond in order, the oldest pending request is the one the next
+// response belongs to.
+typedef struct {
+    __u32 head;
+    __u32 len;
+    http_pending_request_t requests[HTTP_MAX_PIPELINED_REQUESTS];
+} http_pipeline_t;
+
+// Identifies a request whose headers were captured: requests of the same connection are told apart by the time they
+// started, which is part of the transactions sent to userspace
+typedef struct {
+    conn_tuple_t tup;
+    __u64 request_started;
+} http_headers_key_t;
+
+// Beginning of a request captured for its headers, of which only the first len bytes are valid
+typedef struct {
+    char buffer[HTTP_HEADERS_BUFFER_SIZE] __attribute__ ((aligned (8)));
+    __u16 len;
+} http_headers_t;
+
+// The USM java agent, injected in the java processes, sends the plaintext of their TLS connections through eRPC
+// requests: ioctl calls whose command is USM_IOCTL_ID, and whose argument points to the request.
+#define USM_IOCTL_ID 0xda7ad09
+
+typedef enum {
+    // the plaintext of a connection whose tuple is known, such as the ones of the SSLSocket streams
+    JAVA_TLS_SYNCHRONOUS_PAYLOAD,
+    JAVA_TLS_CLOSE_CONNECTION,
+    // the tuple of the connection of a SSLEngine, sent once its socket is known, such as when the channel of a Netty
+    // SSLEngine is connected or accepted
+    JAVA_TLS_CONNECTION_BY_ENGINE,
+    // the plaintext of a SSLEngine, which isn't bound to a socket, and is identified by the engine instead
+    JAVA_TLS_ENGINE_PAYLOAD,
+    // the closure of a SSLEngine, and of its connection
+    JAVA_TLS_CLOSE_ENGINE,
+} java_tls_erpc_op_t;
+
+// SSLEngine object, as identified by its System.identityHashCode within the process of the engine. Unlike the peer of
+// the engine, it tells apart the pooled connections to the same host, and is known for the server-side engines too
+typedef struct {
+    __u32 pid;
+    __u32 engine_id;
+} java_tls_engine_t;
+
 // OpenSSL types
 typedef struct {
     void *ctx;
@@ -85,6 +170,13 @@ typedef struct {
     __u32 fd;
 } ssl_sock_t;
 
+// kTLS types
+typedef struct {
+    void *sk;
+    void *buf;
+    __u64 len;
+} ktls_args_t;
+
 #define LIB_PATH_MAX_SIZE 120
 
 typedef struct {
@@ -2,6 +2,7 @@
 #define __HTTP2_DEFS_H
 
 #include "ktypes.h"
+#include "tracer.h"
 
 // Checkout https://datatracker.ietf.org/doc/html/rfc7540 under "HTTP/2 Connection Preface" section
 #define HTTP2_MARKER_SIZE 24
@@ -9,6 +10,20 @@
 // Checkout https://datatracker.ietf.org/doc/html/rfc7540 under "Frame Format" section
 #define HTTP2_FRAME_HEADER_SIZE 9
 #define HTTP2_SETTINGS_SIZE 6
+#define HTTP2_WINDOW_UPDATE_SIZE 4
+
+// The initial size of the flow-control window of a connection, which can only be increased by WINDOW_UPDATE frames.
+// Checkout https://datatracker.ietf.org/doc/html/rfc7540#section-6.9.2
+#define HTTP2_DEFAULT_WINDOW_SIZE 65535
+
+// The maximum number of frames accounted for in a single TCP segment, to bound the complexity of the program.
+#define HTTP2_MAX_FRAMES_PER_SEGMENT 8
+
+// The flag set on the HEADERS frames carrying the priority of their stream.
+#define HTTP2_FLAG_PRIORITY 0x20
+
+// The maximum number of HEADERS frames of a single TCP segment whose header blocks are sent to userspace.
+#define HTTP2_MAX_HEADERS_PER_SEGMENT 4
 
 // All types of http2 frames exist in the protocol.
 // Checkout https://datatracker.ietf.org/doc/html/rfc7540 under "Frame Type Registry" section.
@@ -35,4 +50,45 @@ struct http2_frame {
     __u32 stream_id : 31;
 } __attribute__ ((packed));
 
+// The frames sent by one of the sides of an HTTP/2 connection, which are relevant to diagnose its performance.
+typedef struct {
+    // The length of the DATA frames, which is subject to flow control.
+    __u64 data_bytes;
+    // The increments of the connection flow-control window granted to the other side by the WINDOW_UPDATE frames.
+    __u64 window_update_increment;
+    __u32 window_update_frames;
+    // The number of DATA frames which exhausted the connection flow-control window granted by the other side.
+    __u32 window_exhaustions;
+    __u32 push_promise_frames;
+    // The number of PRIORITY frames, along with the HEADERS frames carrying a priority.
+    __u32 priority_frames;
+    __u32 settings_frames;
+    __u32 rst_stream_frames;
+    __u32 goaway_frames;
+    // The number of bytes of the last frame which are expected in the next TCP segments.
+    __u32 remainder;
+} http2_frame_counters_t;
+
+typedef struct {
+    // The frames sent by the client, which is the source of the normalized connection tuple.
+    http2_frame_counters_t client;
+    http2_frame_counters_t server;
+    // The static tags of the TLS libraries through which the frames of the connection were read or written.
+    __u64 tags;
+} http2_frame_stats_t;
+
+// The HEADERS frames of a TCP segment of an HTTP/2 connection, whose header blocks are decoded by the gRPC monitoring.
+typedef struct {
+    // The normalized tuple of the connection, the client being the source.
+    conn_tuple_t tup;
+    // The offsets of the header blocks in the segment, or in the plaintext of the TLS connection, following the
+    // headers of the frames.
+    __u32 offsets[HTTP2_MAX_HEADERS_PER_SEGMENT];
+    __u32 lengths[HTTP2_MAX_HEADERS_PER_SEGMENT];
+    __u32 stream_ids[HTTP2_MAX_HEADERS_PER_SEGMENT];
+    __u8 flags[HTTP2_MAX_HEADERS_PER_SEGMENT];
+    __u8 count;
+    bool from_client;
+} http2_headers_frames_t;
+
 #endif
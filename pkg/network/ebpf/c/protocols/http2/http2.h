@@ -0,0 +1,239 @@
+#ifndef __HTTP2_H
+#define __HTTP2_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "bpf_endian.h"
+#include "defs.h"
+
+#include "port_range.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/classification/dispatcher-helpers.h"
+#include "protocols/http2/defs.h"
+#include "protocols/http2/helpers.h"
+#include "protocols/http2/maps.h"
+
+static __always_inline bool http2_monitoring_enabled() {
+    __u64 val = 0;
+    LOAD_CONSTANT("http2_monitoring_enabled", val);
+    return val == ENABLED;
+}
+
+// Accounts for the given frame in the counters of the side of the connection which sent it. peer holds the counters
+// of the other side, which granted the flow-control window the DATA frames are subject to. increment is the increment
+// of the connection flow-control window carried by the WINDOW_UPDATE frames of stream 0, which is read by the caller.
+static __always_inline void http2_count_frame(struct http2_frame *frame, __u32 increment, http2_frame_counters_t *counters, http2_frame_counters_t *peer) {
+    switch (frame->type) {
+    case kDataFrame:
+        __sync_fetch_and_add(&counters->data_bytes, frame->length);
+        if (counters->data_bytes >= HTTP2_DEFAULT_WINDOW_SIZE + peer->window_update_increment) {
+            __sync_fetch_and_add(&counters->window_exhaustions, 1);
+        }
+        break;
+    case kHeadersFrame:
+        if (frame->flags & HTTP2_FLAG_PRIORITY) {
+            __sync_fetch_and_add(&counters->priority_frames, 1);
+        }
+        break;
+    case kPriorityFrame:
+        __sync_fetch_and_add(&counters->priority_frames, 1);
+        break;
+    case kRSTStreamFrame:
+        __sync_fetch_and_add(&counters->rst_stream_frames, 1);
+        break;
+    case kSettingsFrame:
+        __sync_fetch_and_add(&counters->settings_frames, 1);
+        break;
+    case kPushPromiseFrame:
+        __sync_fetch_and_add(&counters->push_promise_frames, 1);
+        break;
+    case kGoAwayFrame:
+        __sync_fetch_and_add(&counters->goaway_frames, 1);
+        break;
+    case kWindowUpdateFrame:
+        __sync_fetch_and_add(&counters->window_update_frames, 1);
+        __sync_fetch_and_add(&counters->window_update_increment, increment);
+        break;
+    default:
+        break;
+    }
+}
+
+// Returns the frames of the connection of the given normalized tuple, which are created if needed.
+static __always_inline http2_frame_stats_t *http2_get_frame_stats(conn_tuple_t *key) {
+    http2_frame_stats_t *stats = bpf_map_lookup_elem(&http2_frame_stats, key);
+    if (stats != NULL) {
+        return stats;
+    }
+    http2_frame_stats_t empty = {0};
+    bpf_map_update_with_telemetry(http2_frame_stats, key, &empty, BPF_NOEXIST);
+    return bpf_map_lookup_elem(&http2_frame_stats, key);
+}
+
+// Returns the increment of the connection flow-control window carried by the WINDOW_UPDATE frame at the given offset
+// of the segment. Only the increments of the connection window (stream 0) are accounted for, as they bound all the
+// streams.
+static __always_inline __u32 http2_window_increment(struct __sk_buff *skb, __u32 offset, struct http2_frame *frame) {
+    __u32 increment = 0;
+    if (frame->type != kWindowUpdateFrame || frame->stream_id != 0 || offset + HTTP2_FRAME_HEADER_SIZE + HTTP2_WINDOW_UPDATE_SIZE > skb->len) {
+        return 0;
+    }
+    if (bpf_skb_load_bytes_with_telemetry(skb, offset + HTTP2_FRAME_HEADER_SIZE, &increment, sizeof(increment)) < 0) {
+        return 0;
+    }
+    return bpf_ntohl(increment) & 0x7fffffff;
+}
+
+// Accounts for the frames of the TCP segment of an HTTP/2 connection. The frames spanning multiple segments are
+// tracked through the number of bytes remaining in the next segments. This is done on a best effort basis: at most
+// HTTP2_MAX_FRAMES_PER_SEGMENT frames are accounted for per segment, and the frame headers split across segments
+// are skipped, after which the frames of the side of the connection are not accounted for accurately anymore.
+// The entry of the connection is deleted once it is terminated. The first HTTP2_MAX_HEADERS_PER_SEGMENT HEADERS
+// frames of the segment are stored in headers.
+static __always_inline void http2_count_frames(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, http2_headers_frames_t *headers) {
+    conn_tuple_t key = *tup;
+    normalize_tuple(&key);
+    headers->tup = key;
+    headers->count = 0;
+    if (is_tcp_termination(skb_info)) {
+        bpf_map_delete_elem(&http2_frame_stats, &key);
+        return;
+    }
+    if (is_payload_empty(skb, skb_info)) {
+        return;
+    }
+
+    bool from_client = key.sport == tup->sport && key.saddr_l == tup->saddr_l && key.saddr_h == tup->saddr_h;
+    headers->from_client = from_client;
+
+    http2_frame_stats_t *stats = http2_get_frame_stats(&key);
+    if (stats == NULL) {
+        return;
+    }
+    http2_frame_counters_t *counters = from_client ? &stats->client : &stats->server;
+    http2_frame_counters_t *peer = from_client ? &stats->server : &stats->client;
+
+    __u32 offset = skb_info->data_off;
+    __u32 payload_size = skb->len - offset;
+    __u32 remainder = counters->remainder;
+    if (remainder >= payload_size) {
+        // the segment is part of a frame started in a previous segment
+        counters->remainder = remainder - payload_size;
+        return;
+    }
+    offset += remainder;
+
+    // the connection preface of the client is not followed by a frame header
+    if (offset == skb_info->data_off && offset + HTTP2_MARKER_SIZE <= skb->len) {
+        char preface[HTTP2_MARKER_SIZE];
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, preface, HTTP2_MARKER_SIZE) >= 0 && is_http2_preface(preface, HTTP2_MARKER_SIZE)) {
+            offset += HTTP2_MARKER_SIZE;
+        }
+    }
+
+    char frame_buf[HTTP2_FRAME_HEADER_SIZE];
+    struct http2_frame frame;
+#pragma unroll(HTTP2_MAX_FRAMES_PER_SEGMENT)
+    for (int i = 0; i < HTTP2_MAX_FRAMES_PER_SEGMENT; i++) {
+        if (offset + HTTP2_FRAME_HEADER_SIZE > skb->len) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, frame_buf, HTTP2_FRAME_HEADER_SIZE) < 0) {
+            break;
+        }
+        if (!read_http2_frame_header(frame_buf, HTTP2_FRAME_HEADER_SIZE, &frame)) {
+            break;
+        }
+        http2_count_frame(&frame, http2_window_increment(skb, offset, &frame), counters, peer);
+        __u8 n = headers->count;
+        if (frame.type == kHeadersFrame && n < HTTP2_MAX_HEADERS_PER_SEGMENT) {
+            headers->offsets[n] = offset + HTTP2_FRAME_HEADER_SIZE;
+            headers->lengths[n] = frame.length;
+            headers->stream_ids[n] = frame.stream_id;
+            headers->flags[n] = frame.flags;
+            headers->count++;
+        }
+        offset += HTTP2_FRAME_HEADER_SIZE + frame.length;
+    }
+
+    counters->remainder = offset > skb->len ? offset - skb->len : 0;
+}
+
+// Returns the increment of the connection flow-control window carried by the WINDOW_UPDATE frame at the given offset
+// of the plaintext of a TLS connection, in the same way as http2_window_increment.
+static __always_inline __u32 http2_tls_window_increment(char *buffer, __u32 len, __u32 offset, struct http2_frame *frame) {
+    __u32 increment = 0;
+    if (frame->type != kWindowUpdateFrame || frame->stream_id != 0 || offset + HTTP2_FRAME_HEADER_SIZE + HTTP2_WINDOW_UPDATE_SIZE > len) {
+        return 0;
+    }
+    if (bpf_probe_read_user_with_telemetry(&increment, sizeof(increment), buffer + offset + HTTP2_FRAME_HEADER_SIZE) < 0) {
+        return 0;
+    }
+    return bpf_ntohl(increment) & 0x7fffffff;
+}
+
+// Accounts for the frames of the plaintext of an HTTP/2 connection read from or written to a TLS connection, in the
+// same way as http2_count_frames does for the TCP segments. key is the normalized tuple of the connection, and
+// from_client tells which side of the connection sent the plaintext. The given static tags of the TLS library are
+// added to the ones of the connection.
+static __always_inline void http2_count_tls_frames(conn_tuple_t *key, char *buffer, __u32 len, bool from_client, __u64 tags, http2_headers_frames_t *headers) {
+    headers->tup = *key;
+    headers->count = 0;
+    headers->from_client = from_client;
+    if (len == 0) {
+        return;
+    }
+
+    http2_frame_stats_t *stats = http2_get_frame_stats(key);
+    if (stats == NULL) {
+        return;
+    }
+    stats->tags |= tags;
+    http2_frame_counters_t *counters = from_client ? &stats->client : &stats->server;
+    http2_frame_counters_t *peer = from_client ? &stats->server : &stats->client;
+
+    __u32 remainder = counters->remainder;
+    if (remainder >= len) {
+        // the plaintext is part of a frame started in a previous one
+        counters->remainder = remainder - len;
+        return;
+    }
+    __u32 offset = remainder;
+
+    if (offset == 0 && HTTP2_MARKER_SIZE <= len) {
+        char preface[HTTP2_MARKER_SIZE];
+        if (bpf_probe_read_user_with_telemetry(preface, HTTP2_MARKER_SIZE, buffer) >= 0 && is_http2_preface(preface, HTTP2_MARKER_SIZE)) {
+            offset += HTTP2_MARKER_SIZE;
+        }
+    }
+
+    char frame_buf[HTTP2_FRAME_HEADER_SIZE];
+    struct http2_frame frame;
+#pragma unroll(HTTP2_MAX_FRAMES_PER_SEGMENT)
+    for (int i = 0; i < HTTP2_MAX_FRAMES_PER_SEGMENT; i++) {
+        if (offset + HTTP2_FRAME_HEADER_SIZE > len) {
+            break;
+        }
+        if (bpf_probe_read_user_with_telemetry(frame_buf, HTTP2_FRAME_HEADER_SIZE, buffer + offset) < 0) {
+            break;
+        }
+        if (!read_http2_frame_header(frame_buf, HTTP2_FRAME_HEADER_SIZE, &frame)) {
+            break;
+        }
+        http2_count_frame(&frame, http2_tls_window_increment(buffer, len, offset, &frame), counters, peer);
+        __u8 n = headers->count;
+        if (frame.type == kHeadersFrame && n < HTTP2_MAX_HEADERS_PER_SEGMENT) {
+            headers->offsets[n] = offset + HTTP2_FRAME_HEADER_SIZE;
+            headers->lengths[n] = frame.length;
+            headers->stream_ids[n] = frame.stream_id;
+            headers->flags[n] = frame.flags;
+            headers->count++;
+        }
+        offset += HTTP2_FRAME_HEADER_SIZE + frame.length;
+    }
+
+    counters->remainder = offset > len ? offset - len : 0;
+}
+
+#endif
@@ -0,0 +1,13 @@
+#ifndef __HTTP2_MAPS_H
+#define __HTTP2_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+#include "tracer.h"
+
+#include "protocols/http2/defs.h"
+
+/* This map holds, for each HTTP/2 connection, the frames sent by each of its sides */
+BPF_LRU_MAP(http2_frame_stats, conn_tuple_t, http2_frame_stats_t, 0)
+
+#endif
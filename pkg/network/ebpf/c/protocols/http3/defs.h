@@ -0,0 +1,48 @@
+#ifndef __HTTP3_DEFS_H
+#define __HTTP3_DEFS_H
+
+// The bits of the first byte of the QUIC long header packets, and the type of the Initial packets.
+// Checkout https://datatracker.ietf.org/doc/html/rfc9000#section-17.2
+#define QUIC_LONG_HEADER_FORM 0x80
+#define QUIC_FIXED_BIT 0x40
+#define QUIC_PACKET_TYPE_SHIFT 4
+#define QUIC_PACKET_TYPE_MASK 0x3
+#define QUIC_V1_INITIAL_TYPE 0x0
+// QUIC version 2 shuffles the types of the long header packets.
+// Checkout https://datatracker.ietf.org/doc/html/rfc9369#section-3.2
+#define QUIC_V2_INITIAL_TYPE 0x1
+
+#define QUIC_VERSION_1 0x00000001
+#define QUIC_VERSION_2 0x6b3343cf
+#define QUIC_VERSION_DRAFT_29 0xff00001d
+
+// The size of the first byte, the version and the length of the destination connection ID of the long header packets.
+#define QUIC_LONG_HEADER_MIN_SIZE 6
+#define QUIC_MAX_CID_LENGTH 20
+
+// The datagrams carrying the Initial packets of the clients are padded to at least 1200 bytes.
+// Checkout https://datatracker.ietf.org/doc/html/rfc9000#section-14.1
+#define QUIC_MIN_INITIAL_DATAGRAM_SIZE 1200
+
+// The bits of the first byte of the QUIC short header packets.
+// Checkout https://datatracker.ietf.org/doc/html/rfc9000#section-17.3.1
+#define QUIC_SPIN_BIT 0x20
+#define QUIC_SPIN_UNSET 0xff
+
+// The directions of the QUIC flows, the client sending the first Initial packet
+#define QUIC_CLIENT 0
+#define QUIC_SERVER 1
+
+// The Initial packets the client sends at least QUIC_MIN_PTO_NS after the previous one, while the server didn't answer,
+// are counted as retransmitted. The probe timeout of the clients which don't know the RTT of the path yet is about 1
+// second, while the Initial packets carrying the parts of a large ClientHello are sent back-to-back.
+// Checkout https://datatracker.ietf.org/doc/html/rfc9002#section-6.2.2
+#define QUIC_MIN_PTO_NS 100000000
+
+// The size of the beginning of the Initial packets sent to userspace, which is expected to hold the beginning of the
+// ClientHello up to its server_name extension.
+#define HTTP3_BUFFER_SIZE 512
+#define HTTP3_BLK_SIZE 16
+#define HTTP3_BATCH_SIZE 7
+
+#endif
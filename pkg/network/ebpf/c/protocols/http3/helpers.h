@@ -0,0 +1,45 @@
+#ifndef __HTTP3_HELPERS_H
+#define __HTTP3_HELPERS_H
+
+#include "bpf_endian.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/http3/defs.h"
+
+// Checks if the given buffer holds the beginning of a QUIC Initial packet, which opens the HTTP/3 connections. The
+// payload of the Initial packets is encrypted, so only their long header is checked, along with the size of the
+// datagram, which the clients pad to at least QUIC_MIN_INITIAL_DATAGRAM_SIZE bytes.
+static __always_inline bool is_quic_initial(const char *buf, __u32 buf_size, __u32 payload_length) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, QUIC_LONG_HEADER_MIN_SIZE);
+
+    if (payload_length < QUIC_MIN_INITIAL_DATAGRAM_SIZE) {
+        return false;
+    }
+
+    __u8 first = buf[0];
+    if ((first & (QUIC_LONG_HEADER_FORM | QUIC_FIXED_BIT)) != (QUIC_LONG_HEADER_FORM | QUIC_FIXED_BIT)) {
+        return false;
+    }
+
+    __u8 type = (first >> QUIC_PACKET_TYPE_SHIFT) & QUIC_PACKET_TYPE_MASK;
+    __u32 version = bpf_ntohl(*(__u32 *)&buf[1]);
+    switch (version) {
+    case QUIC_VERSION_1:
+    case QUIC_VERSION_DRAFT_29:
+        if (type != QUIC_V1_INITIAL_TYPE) {
+            return false;
+        }
+        break;
+    case QUIC_VERSION_2:
+        if (type != QUIC_V2_INITIAL_TYPE) {
+            return false;
+        }
+        break;
+    default:
+        return false;
+    }
+
+    return (__u8)buf[5] <= QUIC_MAX_CID_LENGTH;
+}
+
+#endif
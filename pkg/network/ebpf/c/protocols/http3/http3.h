@@ -0,0 +1,71 @@
+#ifndef __HTTP3_H
+#define __HTTP3_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "protocols/events.h"
+#include "protocols/http3/defs.h"
+#include "protocols/http3/maps.h"
+#include "protocols/http3/types.h"
+
+USM_EVENTS_INIT(http3, http3_initial_t, HTTP3_BATCH_SIZE);
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to HTTP3_BUFFER_SIZE bytes. The
+// bytes are read in blocks of HTTP3_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the
+// verifiers of the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 http3_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer) {
+    __u32 read = 0;
+#pragma unroll(HTTP3_BUFFER_SIZE / HTTP3_BLK_SIZE)
+    for (int i = 0; i < HTTP3_BUFFER_SIZE / HTTP3_BLK_SIZE; i++) {
+        if (offset + HTTP3_BLK_SIZE > end) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], HTTP3_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += HTTP3_BLK_SIZE;
+        read += HTTP3_BLK_SIZE;
+    }
+    if (read == HTTP3_BUFFER_SIZE) {
+        return read;
+    }
+
+#define HTTP3_READ_CHUNK(size)                                                                      \
+    if (offset + size <= end && read + size <= HTTP3_BUFFER_SIZE) {                                 \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    HTTP3_READ_CHUNK(8);
+    HTTP3_READ_CHUNK(4);
+    HTTP3_READ_CHUNK(2);
+    HTTP3_READ_CHUNK(1);
+#undef HTTP3_READ_CHUNK
+
+    return read;
+}
+
+// Sends the beginning of a QUIC Initial packet to userspace, where the server name of the ClientHello of the HTTP/3
+// connection is decrypted. The Initial packets of the servers are sent as well, and are discarded in userspace as
+// they don't carry a ClientHello.
+static __always_inline void http3_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    const __u32 zero = 0;
+    http3_initial_t *tx = bpf_map_lookup_elem(&http3_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(http3_initial_t));
+
+    tx->tup = *tup;
+    tx->timestamp = bpf_ktime_get_ns();
+    tx->packet_length = skb->len - skb_info->data_off;
+    tx->fragment_size = http3_read_into_buffer(skb, skb_info->data_off, skb->len, tx->fragment);
+    http3_batch_enqueue(tx);
+}
+
+#endif
@@ -0,0 +1,13 @@
+#ifndef __HTTP3_MAPS_H
+#define __HTTP3_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/http3/types.h"
+
+/* This map is used as a scratch buffer to build the Initial packets sent to userspace, as they are too large for the
+   eBPF stack */
+BPF_PERCPU_ARRAY_MAP(http3_heap, __u32, http3_initial_t, 1)
+
+#endif
@@ -0,0 +1,126 @@
+#ifndef __HTTP3_QUIC_STATS_H
+#define __HTTP3_QUIC_STATS_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "compiler.h"
+#include "defs.h"
+#include "ip.h"
+
+#include "tracer.h"
+#include "tracer-maps.h"
+#include "protocols/http3/defs.h"
+
+static __always_inline bool is_quic_stats_enabled() {
+#ifdef COMPILE_RUNTIME
+#ifdef FEATURE_QUIC_STATS_ENABLED
+    return true;
+#else
+    return false;
+#endif
+#else
+    __u64 val = 0;
+    LOAD_CONSTANT("quic_stats_enabled", val);
+    return val == ENABLED;
+#endif
+}
+
+// Updates the smoothed RTT of the flow with a sample in nanoseconds, the same way TCP does.
+// Checkout https://datatracker.ietf.org/doc/html/rfc6298#section-2
+static __always_inline void quic_update_rtt(quic_stats_t *stats, __u64 sample_ns) {
+    __u32 sample = sample_ns / 1000;
+    if (stats->rtt == 0) {
+        stats->rtt = sample;
+        stats->rtt_var = sample / 2;
+        return;
+    }
+
+    __u32 diff = stats->rtt > sample ? stats->rtt - sample : sample - stats->rtt;
+    stats->rtt_var = (3 * stats->rtt_var + diff) / 4;
+    stats->rtt = (7 * stats->rtt + sample) / 8;
+}
+
+// Starts tracking the stats of a QUIC flow, from the first Initial packet of its client.
+static __always_inline void quic_stats_init(conn_tuple_t *skb_tup) {
+    if (!is_quic_stats_enabled()) {
+        return;
+    }
+
+    quic_stats_t empty = {};
+    bpf_memset(&empty, 0, sizeof(quic_stats_t));
+    empty.initial_ts = bpf_ktime_get_ns();
+    empty.last_initial_ts = empty.initial_ts;
+    empty.spin[QUIC_CLIENT] = QUIC_SPIN_UNSET;
+    empty.spin[QUIC_SERVER] = QUIC_SPIN_UNSET;
+    bpf_map_update_with_telemetry(quic_stats, skb_tup, &empty, BPF_NOEXIST);
+}
+
+// Updates the stats of a QUIC flow with one of its packets. Only the first byte of the packets is read, as their
+// remainder is encrypted:
+// - the first long header packet of the server gives an RTT sample from the first Initial packet of the client,
+//   unless the client retransmitted it, in which case the sample would be ambiguous
+// - the long header packets the client sends until then are counted as retransmitted when sent after its probe timeout
+// - the spin bit of the short header packets flips once per RTT in each direction, the time between two of its edges
+//   giving an RTT sample, unless the endpoints disabled it
+static __always_inline void quic_stats_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *skb_tup) {
+    if (!is_quic_stats_enabled()) {
+        return;
+    }
+
+    __u8 dir = QUIC_CLIENT;
+    quic_stats_t *stats = bpf_map_lookup_elem(&quic_stats, skb_tup);
+    if (stats == NULL) {
+        conn_tuple_t server_tup = *skb_tup;
+        flip_tuple(&server_tup);
+        stats = bpf_map_lookup_elem(&quic_stats, &server_tup);
+        dir = QUIC_SERVER;
+    }
+    if (stats == NULL) {
+        return;
+    }
+
+    __u8 first = 0;
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb_info->data_off, &first, sizeof(first)) < 0) {
+        return;
+    }
+    __u64 now = bpf_ktime_get_ns();
+
+    if (first & QUIC_LONG_HEADER_FORM) {
+        if (stats->handshake_done) {
+            return;
+        }
+        if (dir == QUIC_SERVER) {
+            stats->handshake_done = 1;
+            if (stats->retransmits == 0) {
+                quic_update_rtt(stats, now - stats->initial_ts);
+            }
+            return;
+        }
+        if (skb->len - skb_info->data_off >= QUIC_MIN_INITIAL_DATAGRAM_SIZE) {
+            if (now - stats->last_initial_ts >= QUIC_MIN_PTO_NS) {
+                stats->retransmits++;
+            }
+            stats->last_initial_ts = now;
+        }
+        return;
+    }
+
+    if (!(first & QUIC_FIXED_BIT)) {
+        return;
+    }
+
+    __u8 spin = (first & QUIC_SPIN_BIT) ? 1 : 0;
+    if (stats->spin[dir] != QUIC_SPIN_UNSET && stats->spin[dir] != spin) {
+        if (stats->spin_edge_ts[dir] != 0) {
+            __u64 sample = now - stats->spin_edge_ts[dir];
+            // the edges of the packets reordered or sent with a random spin bit give too small samples
+            if (sample / 1000 >= stats->rtt / 8) {
+                quic_update_rtt(stats, sample);
+            }
+        }
+        stats->spin_edge_ts[dir] = now;
+    }
+    stats->spin[dir] = spin;
+}
+
+#endif
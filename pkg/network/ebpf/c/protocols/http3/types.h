@@ -0,0 +1,20 @@
+#ifndef __HTTP3_TYPES_H
+#define __HTTP3_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/http3/defs.h"
+
+// Beginning of a QUIC Initial packet, whose payload is decrypted in userspace to read the server name of the
+// ClientHello it carries. The keys of the Initial packets are derived from their destination connection ID.
+typedef struct {
+    // the tuple of the datagram, the sender being the source
+    conn_tuple_t tup;
+    __u64 timestamp;
+    // the length of the UDP payload, which tells whether the packet was truncated
+    __u16 packet_length;
+    __u16 fragment_size;
+    char fragment[HTTP3_BUFFER_SIZE];
+} http3_initial_t;
+
+#endif
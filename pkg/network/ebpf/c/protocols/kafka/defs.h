@@ -0,0 +1,47 @@
+#ifndef __KAFKA_DEFS_H
+#define __KAFKA_DEFS_H
+
+// Kafka API keys of the requests which are decoded.
+// Ref: https://kafka.apache.org/protocol.html#protocol_api_keys
+#define KAFKA_PRODUCE 0
+#define KAFKA_FETCH 1
+
+// Highest versions of the requests which are decoded. The later versions use the flexible encoding (compact strings
+// and arrays, tagged fields), which is not supported.
+#define KAFKA_MAX_PRODUCE_VERSION 8
+#define KAFKA_MAX_FETCH_VERSION 11
+
+// Size of the request header up to the size of the client id: message_size (int32), api_key (int16),
+// api_version (int16), correlation_id (int32) and client_id size (int16).
+#define KAFKA_REQUEST_HEADER_SIZE 14
+// Size of the response header: message_size (int32) and correlation_id (int32).
+#define KAFKA_RESPONSE_HEADER_SIZE 8
+#define KAFKA_MIN_LENGTH KAFKA_REQUEST_HEADER_SIZE
+// The message size does not account for the message_size field itself.
+#define KAFKA_MIN_MESSAGE_SIZE (KAFKA_REQUEST_HEADER_SIZE - 4)
+
+// Client ids longer than this are not expected, and are used to eliminate false positives.
+#define KAFKA_MAX_CLIENT_ID_SIZE 255
+
+// Topic names are limited to 249 characters by the brokers.
+#define KAFKA_MAX_TOPIC_NAME_SIZE 249
+// Number of bytes of the topic name captured for each request.
+#define TOPIC_NAME_MAX_STRING_SIZE 80
+#define KAFKA_TOPIC_BLK_SIZE 16
+
+_Static_assert((TOPIC_NAME_MAX_STRING_SIZE % KAFKA_TOPIC_BLK_SIZE) == 0, "TOPIC_NAME_MAX_STRING_SIZE must be a multiple of KAFKA_TOPIC_BLK_SIZE.");
+
+// This controls the number of Kafka transactions read from userspace at a time
+#define KAFKA_BATCH_SIZE 25
+
+// Request header, whose fields are in big endian on the wire. Only the first KAFKA_REQUEST_HEADER_SIZE bytes are
+// read from the packets.
+typedef struct {
+    __s32 message_size;
+    __s16 api_key;
+    __s16 api_version;
+    __s32 correlation_id;
+    __s16 client_id_size;
+} kafka_header_t;
+
+#endif
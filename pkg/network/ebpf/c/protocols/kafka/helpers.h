@@ -0,0 +1,76 @@
+#ifndef __KAFKA_HELPERS_H
+#define __KAFKA_HELPERS_H
+
+#include "bpf_builtins.h"
+#include "bpf_endian.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/kafka/defs.h"
+
+// Checks the fields of a request header, converted to host byte order.
+static __always_inline bool is_valid_kafka_request_header(const kafka_header_t *header) {
+    if (header->message_size < KAFKA_MIN_MESSAGE_SIZE || header->correlation_id < 0) {
+        return false;
+    }
+
+    if (header->client_id_size < -1 || header->client_id_size > KAFKA_MAX_CLIENT_ID_SIZE) {
+        return false;
+    }
+
+    switch (header->api_key) {
+    case KAFKA_PRODUCE:
+        return header->api_version >= 0 && header->api_version <= KAFKA_MAX_PRODUCE_VERSION;
+    case KAFKA_FETCH:
+        return header->api_version >= 0 && header->api_version <= KAFKA_MAX_FETCH_VERSION;
+    default:
+        return false;
+    }
+}
+
+// Reads the request header at the beginning of the buffer, converting its fields to host byte order. The header is
+// copied first, as the buffer may not be aligned.
+static __always_inline void read_kafka_request_header(const char *buf, kafka_header_t *header) {
+    bpf_memcpy(header, buf, KAFKA_REQUEST_HEADER_SIZE);
+    header->message_size = bpf_ntohl(header->message_size);
+    header->api_key = bpf_ntohs(header->api_key);
+    header->api_version = bpf_ntohs(header->api_version);
+    header->correlation_id = bpf_ntohl(header->correlation_id);
+    header->client_id_size = bpf_ntohs(header->client_id_size);
+}
+
+// Checks that the bytes of the client id found in the buffer are made of the characters commonly used by the
+// clients: alphanumerics, '.', '_' and '-'.
+static __always_inline bool is_valid_kafka_client_id(const char *buf, __u32 buf_size, __s16 client_id_size) {
+    if (client_id_size <= 0) {
+        return true;
+    }
+
+    const __u32 start = KAFKA_REQUEST_HEADER_SIZE;
+#pragma unroll(CLASSIFICATION_MAX_BUFFER - KAFKA_REQUEST_HEADER_SIZE)
+    for (__u32 i = start; i < CLASSIFICATION_MAX_BUFFER; i++) {
+        if (i >= buf_size || i - start >= (__u32)client_id_size) {
+            break;
+        }
+        char c = buf[i];
+        if (('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '.' || c == '_' || c == '-') {
+            continue;
+        }
+        return false;
+    }
+    return true;
+}
+
+// The method checks if the given buffer starts with a Kafka produce or fetch request.
+// Ref: https://kafka.apache.org/protocol.html#protocol_messages
+static __always_inline bool is_kafka(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, KAFKA_MIN_LENGTH);
+
+    kafka_header_t header;
+    read_kafka_request_header(buf, &header);
+    if (!is_valid_kafka_request_header(&header)) {
+        return false;
+    }
+    return is_valid_kafka_client_id(buf, buf_size, header.client_id_size);
+}
+
+#endif
@@ -0,0 +1,197 @@
+#ifndef __KAFKA_H
+#define __KAFKA_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "bpf_endian.h"
+#include "ip.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/events.h"
+#include "protocols/kafka/defs.h"
+#include "protocols/kafka/helpers.h"
+#include "protocols/kafka/maps.h"
+#include "protocols/kafka/types.h"
+
+USM_EVENTS_INIT(kafka, kafka_transaction_t, KAFKA_BATCH_SIZE);
+
+static __always_inline bool kafka_read_s16(struct __sk_buff *skb, __u32 offset, __s16 *out) {
+    __s16 val = 0;
+    if (bpf_skb_load_bytes_with_telemetry(skb, offset, &val, sizeof(val)) < 0) {
+        return false;
+    }
+    *out = bpf_ntohs(val);
+    return true;
+}
+
+static __always_inline bool kafka_read_s32(struct __sk_buff *skb, __u32 offset, __s32 *out) {
+    __s32 val = 0;
+    if (bpf_skb_load_bytes_with_telemetry(skb, offset, &val, sizeof(val)) < 0) {
+        return false;
+    }
+    *out = bpf_ntohl(val);
+    return true;
+}
+
+// Reads the topic name at the given offset into the transaction, in blocks of KAFKA_TOPIC_BLK_SIZE bytes. The last
+// block may hold the bytes following the topic name, which are ignored in userspace thanks to topic_name_size.
+// Returns false if the topic name is not entirely part of the packet.
+static __always_inline bool kafka_read_topic_name(struct __sk_buff *skb, __u32 offset, kafka_transaction_t *tx) {
+    __u32 read = 0;
+#pragma unroll(TOPIC_NAME_MAX_STRING_SIZE / KAFKA_TOPIC_BLK_SIZE)
+    for (int i = 0; i < TOPIC_NAME_MAX_STRING_SIZE / KAFKA_TOPIC_BLK_SIZE; i++) {
+        if (read >= tx->topic_name_size || offset + KAFKA_TOPIC_BLK_SIZE > skb->len) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &tx->topic_name[i * KAFKA_TOPIC_BLK_SIZE], KAFKA_TOPIC_BLK_SIZE) < 0) {
+            break;
+        }
+        offset += KAFKA_TOPIC_BLK_SIZE;
+        read += KAFKA_TOPIC_BLK_SIZE;
+    }
+    return read >= tx->topic_name_size;
+}
+
+// Matches the packet with a request awaiting its response, using the correlation id of the response header. The
+// transaction is sent to userspace once its response is seen.
+static __always_inline bool kafka_process_response(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    kafka_transaction_key_t key;
+    bpf_memset(&key, 0, sizeof(key));
+    if (!kafka_read_s32(skb, skb_info->data_off + sizeof(__s32), &key.correlation_id)) {
+        return false;
+    }
+    // the requests are stored with the tuple of the client side of the connection
+    key.tup = *tup;
+    flip_tuple(&key.tup);
+
+    kafka_transaction_t *tx = bpf_map_lookup_elem(&kafka_in_flight, &key);
+    if (tx == NULL) {
+        return false;
+    }
+
+    tx->response_received = bpf_ktime_get_ns();
+    kafka_batch_enqueue(tx);
+    bpf_map_delete_elem(&kafka_in_flight, &key);
+    return true;
+}
+
+// Decodes the produce and fetch requests up to the name of their first topic, and stores them until their response
+// is seen. The produce requests sent with acks=0 don't get a response, and are sent to userspace right away.
+// Ref: https://kafka.apache.org/protocol.html#The_Messages_Produce
+// Ref: https://kafka.apache.org/protocol.html#The_Messages_Fetch
+static __always_inline bool kafka_process_request(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    __u32 offset = skb_info->data_off;
+    if (offset + KAFKA_REQUEST_HEADER_SIZE > skb->len) {
+        return false;
+    }
+
+    char header_buf[KAFKA_REQUEST_HEADER_SIZE];
+    if (bpf_skb_load_bytes_with_telemetry(skb, offset, header_buf, KAFKA_REQUEST_HEADER_SIZE) < 0) {
+        return false;
+    }
+    kafka_header_t header;
+    read_kafka_request_header(header_buf, &header);
+    if (!is_valid_kafka_request_header(&header)) {
+        return false;
+    }
+    offset += KAFKA_REQUEST_HEADER_SIZE;
+    if (header.client_id_size > 0) {
+        offset += header.client_id_size;
+    }
+
+    __s16 acks = -1;
+    switch (header.api_key) {
+    case KAFKA_PRODUCE:
+        if (header.api_version >= 3) {
+            __s16 transactional_id_size = 0;
+            if (!kafka_read_s16(skb, offset, &transactional_id_size)) {
+                return false;
+            }
+            offset += sizeof(transactional_id_size);
+            if (transactional_id_size > 0) {
+                offset += transactional_id_size;
+            }
+        }
+        if (!kafka_read_s16(skb, offset, &acks)) {
+            return false;
+        }
+        // acks, timeout_ms
+        offset += sizeof(__s16) + sizeof(__s32);
+        break;
+    case KAFKA_FETCH:
+        // replica_id, max_wait_ms, min_bytes
+        offset += 3 * sizeof(__s32);
+        if (header.api_version >= 3) {
+            // max_bytes
+            offset += sizeof(__s32);
+        }
+        if (header.api_version >= 4) {
+            // isolation_level
+            offset += sizeof(__s8);
+        }
+        if (header.api_version >= 7) {
+            // session_id, session_epoch
+            offset += 2 * sizeof(__s32);
+        }
+        break;
+    default:
+        return false;
+    }
+
+    __s32 topics_count = 0;
+    if (!kafka_read_s32(skb, offset, &topics_count) || topics_count <= 0) {
+        return false;
+    }
+    offset += sizeof(topics_count);
+
+    __s16 topic_name_size = 0;
+    if (!kafka_read_s16(skb, offset, &topic_name_size) || topic_name_size <= 0 || topic_name_size > KAFKA_MAX_TOPIC_NAME_SIZE) {
+        return false;
+    }
+    offset += sizeof(topic_name_size);
+
+    const __u32 zero = 0;
+    kafka_transaction_t *tx = bpf_map_lookup_elem(&kafka_heap, &zero);
+    if (tx == NULL) {
+        return false;
+    }
+    bpf_memset(tx, 0, sizeof(kafka_transaction_t));
+
+    tx->topic_name_size = topic_name_size < TOPIC_NAME_MAX_STRING_SIZE ? topic_name_size : TOPIC_NAME_MAX_STRING_SIZE;
+    if (!kafka_read_topic_name(skb, offset, tx)) {
+        return false;
+    }
+
+    tx->tup = *tup;
+    tx->request_started = bpf_ktime_get_ns();
+    tx->correlation_id = header.correlation_id;
+    tx->request_api_key = header.api_key;
+    tx->request_api_version = header.api_version;
+
+    if (header.api_key == KAFKA_PRODUCE && acks == 0) {
+        kafka_batch_enqueue(tx);
+        return true;
+    }
+
+    kafka_transaction_key_t key;
+    bpf_memset(&key, 0, sizeof(key));
+    key.tup = *tup;
+    key.correlation_id = header.correlation_id;
+    bpf_map_update_with_telemetry(kafka_in_flight, &key, tx, BPF_ANY);
+    return true;
+}
+
+// Processes a TCP segment of a Kafka connection. The segments which neither start a request nor a response, such as
+// the following segments of the large requests and responses, are ignored.
+static __always_inline void kafka_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (is_payload_empty(skb, skb_info) || skb_info->data_off + KAFKA_RESPONSE_HEADER_SIZE > skb->len) {
+        return;
+    }
+
+    if (kafka_process_response(skb, skb_info, tup)) {
+        return;
+    }
+    kafka_process_request(skb, skb_info, tup);
+}
+
+#endif
@@ -0,0 +1,15 @@
+#ifndef __KAFKA_MAPS_H
+#define __KAFKA_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/kafka/types.h"
+
+/* This map is used to keep track of the Kafka requests awaiting their response */
+BPF_LRU_MAP(kafka_in_flight, kafka_transaction_key_t, kafka_transaction_t, 0)
+
+/* This map is used as a scratch buffer to build the Kafka transactions, as they are too large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(kafka_heap, __u32, kafka_transaction_t, 1)
+
+#endif
@@ -0,0 +1,29 @@
+#ifndef __KAFKA_TYPES_H
+#define __KAFKA_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/kafka/defs.h"
+
+// Kafka request, from the client side of the connection, along with the time its response was received. Only the
+// first topic of the request is captured.
+typedef struct {
+    conn_tuple_t tup;
+    __u64 request_started;
+    // 0 for the produce requests sent with acks=0, which the broker does not respond to
+    __u64 response_received;
+    __s32 correlation_id;
+    __u16 request_api_key;
+    __u16 request_api_version;
+    __u16 topic_name_size;
+    char topic_name[TOPIC_NAME_MAX_STRING_SIZE];
+} kafka_transaction_t;
+
+// Requests are matched with their response by correlation id, as a client may send several requests on a connection
+// before receiving their responses.
+typedef struct {
+    conn_tuple_t tup;
+    __s32 correlation_id;
+} kafka_transaction_key_t;
+
+#endif
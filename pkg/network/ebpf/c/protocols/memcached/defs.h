@@ -0,0 +1,69 @@
+#ifndef __MEMCACHED_DEFS_H
+#define __MEMCACHED_DEFS_H
+
+// The requests of the binary protocol start with a 24 bytes header: the magic byte, the opcode, the length of the
+// key, the length of the extras, the data type, the vbucket id (or the status of the responses), the length of the
+// body, the opaque value and the CAS value.
+// Checkout https://github.com/memcached/memcached/wiki/BinaryProtocolRevamped#packet-structure
+#define MEMCACHED_BINARY_HEADER_SIZE 24
+#define MEMCACHED_BINARY_REQUEST_MAGIC 0x80
+#define MEMCACHED_BINARY_RESPONSE_MAGIC 0x81
+#define MEMCACHED_BINARY_RAW_BYTES 0x00
+// The values are limited to 1MB by default, and to 1GB by the item_size_max option.
+#define MEMCACHED_MAX_BODY_LENGTH (1024 * 1024 * 1024)
+
+// The opcodes implemented by memcached range from GET (0x00) to GATKQ (0x24).
+// Checkout https://github.com/memcached/memcached/wiki/BinaryProtocolRevamped#command-opcodes
+#define MEMCACHED_BINARY_MAX_OPCODE 0x24
+
+// The commands of the text protocol are lower cased words followed by a space and a key, the line being terminated
+// by \r\n. The minimal frame is the "END\r\n" response of the get commands missing their keys.
+// Checkout https://github.com/memcached/memcached/blob/master/doc/protocol.txt
+#define MEMCACHED_MIN_FRAME_LENGTH 5
+#define MEMCACHED_TEXT_GET "get "
+#define MEMCACHED_TEXT_GETS "gets "
+#define MEMCACHED_TEXT_GAT "gat "
+#define MEMCACHED_TEXT_GATS "gats "
+#define MEMCACHED_TEXT_SET "set "
+#define MEMCACHED_TEXT_ADD "add "
+#define MEMCACHED_TEXT_REPLACE "replace "
+#define MEMCACHED_TEXT_APPEND "append "
+#define MEMCACHED_TEXT_PREPEND "prepend "
+#define MEMCACHED_TEXT_CAS "cas "
+#define MEMCACHED_TEXT_DELETE "delete "
+#define MEMCACHED_TEXT_INCR "incr "
+#define MEMCACHED_TEXT_DECR "decr "
+#define MEMCACHED_TEXT_TOUCH "touch "
+
+// The responses of the text protocol recognized by the classification, the others being too generic.
+#define MEMCACHED_TEXT_VALUE "VALUE "
+#define MEMCACHED_TEXT_END "END\r\n"
+#define MEMCACHED_TEXT_STORED "STORED\r\n"
+#define MEMCACHED_TEXT_NOT_STORED "NOT_STORED\r\n"
+#define MEMCACHED_TEXT_DELETED "DELETED\r\n"
+#define MEMCACHED_TEXT_NOT_FOUND "NOT_FOUND\r\n"
+#define MEMCACHED_TEXT_TOUCHED "TOUCHED\r\n"
+
+// The size of the beginning of the requests sent to userspace, which holds the name of the text commands, or the
+// header of the binary ones.
+#define MEMCACHED_BUFFER_SIZE 32
+// The size of the beginning of the responses sent to userspace, which holds the first word of the text responses, or
+// the header of the binary ones.
+#define MEMCACHED_RESPONSE_SIZE 24
+#define MEMCACHED_BLK_SIZE 8
+#define MEMCACHED_BATCH_SIZE 30
+
+typedef struct {
+    __u8 magic;
+    __u8 opcode;
+    __u16 key_length;
+    __u8 extras_length;
+    __u8 data_type;
+    // the vbucket id of the requests, or the status of the responses
+    __u16 vbucket_or_status;
+    __u32 total_body_length;
+    __u32 opaque;
+    __u64 cas;
+} __attribute__((packed)) memcached_binary_header_t;
+
+#endif
@@ -0,0 +1,113 @@
+#ifndef __MEMCACHED_HELPERS_H
+#define __MEMCACHED_HELPERS_H
+
+#include "bpf_builtins.h"
+#include "bpf_endian.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/memcached/defs.h"
+
+// Returns true if the buffer starts with the given string literal.
+#define MEMCACHED_STARTS_WITH(buf, buf_size, literal) \
+    ((buf_size) >= sizeof(literal) - 1 && !bpf_memcmp(buf, literal, sizeof(literal) - 1))
+
+// Checks the line starting at index_to_start_from holds printable characters, and is terminated by \r\n. The lines
+// exceeding the buffer, such as the lines of the long keys, are accepted once the buffer is full.
+static __always_inline bool check_memcached_line(const char *buf, __u32 buf_size, int index_to_start_from) {
+    char current_char;
+    int i = index_to_start_from;
+#pragma unroll(CLASSIFICATION_MAX_BUFFER)
+    for (; i < CLASSIFICATION_MAX_BUFFER; i++) {
+        current_char = buf[i];
+        if (current_char == '\r') {
+            break;
+        } else if (' ' <= current_char && current_char <= '~') {
+            continue;
+        }
+        return false;
+    }
+
+    if (i + 1 >= buf_size) {
+        return buf_size >= CLASSIFICATION_MAX_BUFFER;
+    }
+    return buf[i + 1] == '\n';
+}
+
+// Checks the buffer starts with a storage, retrieval, deletion, arithmetic or touch command of the text protocol.
+static __always_inline bool is_memcached_text_request(const char *buf, __u32 buf_size) {
+    if (buf[0] < 'a' || buf[0] > 'z') {
+        return false;
+    }
+
+#define MEMCACHED_CHECK_COMMAND(command)                                          \
+    if (MEMCACHED_STARTS_WITH(buf, buf_size, command)) {                          \
+        return check_memcached_line(buf, buf_size, sizeof(command) - 1);          \
+    }
+
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_GET);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_GETS);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_GAT);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_GATS);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_SET);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_ADD);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_REPLACE);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_APPEND);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_PREPEND);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_CAS);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_DELETE);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_INCR);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_DECR);
+    MEMCACHED_CHECK_COMMAND(MEMCACHED_TEXT_TOUCH);
+#undef MEMCACHED_CHECK_COMMAND
+
+    return false;
+}
+
+// Checks the buffer starts with one of the responses of the text protocol specific enough to tell memcached apart
+// from the other text protocols.
+static __always_inline bool is_memcached_text_response(const char *buf, __u32 buf_size) {
+    if (MEMCACHED_STARTS_WITH(buf, buf_size, MEMCACHED_TEXT_VALUE)) {
+        return check_memcached_line(buf, buf_size, sizeof(MEMCACHED_TEXT_VALUE) - 1);
+    }
+    return MEMCACHED_STARTS_WITH(buf, buf_size, MEMCACHED_TEXT_END)
+        || MEMCACHED_STARTS_WITH(buf, buf_size, MEMCACHED_TEXT_STORED)
+        || MEMCACHED_STARTS_WITH(buf, buf_size, MEMCACHED_TEXT_NOT_STORED)
+        || MEMCACHED_STARTS_WITH(buf, buf_size, MEMCACHED_TEXT_DELETED)
+        || MEMCACHED_STARTS_WITH(buf, buf_size, MEMCACHED_TEXT_NOT_FOUND)
+        || MEMCACHED_STARTS_WITH(buf, buf_size, MEMCACHED_TEXT_TOUCHED);
+}
+
+// Checks the buffer starts with the header of a request or a response of the binary protocol.
+static __always_inline bool is_memcached_binary(const char *buf, __u32 buf_size) {
+    if (buf_size < MEMCACHED_BINARY_HEADER_SIZE) {
+        return false;
+    }
+
+    memcached_binary_header_t hdr = *((memcached_binary_header_t *)buf);
+    if (hdr.magic != MEMCACHED_BINARY_REQUEST_MAGIC && hdr.magic != MEMCACHED_BINARY_RESPONSE_MAGIC) {
+        return false;
+    }
+    if (hdr.opcode > MEMCACHED_BINARY_MAX_OPCODE || hdr.data_type != MEMCACHED_BINARY_RAW_BYTES) {
+        return false;
+    }
+    __u32 body_length = bpf_ntohl(hdr.total_body_length);
+    return body_length <= MEMCACHED_MAX_BODY_LENGTH && hdr.extras_length + bpf_ntohs(hdr.key_length) <= body_length;
+}
+
+// Checks if the given buffer starts with a request of either the text or the binary protocol of memcached.
+static __always_inline bool is_memcached_request(const char *buf, __u32 buf_size) {
+    if (buf_size >= MEMCACHED_BINARY_HEADER_SIZE && (__u8)buf[0] == MEMCACHED_BINARY_REQUEST_MAGIC) {
+        return is_memcached_binary(buf, buf_size);
+    }
+    return is_memcached_text_request(buf, buf_size);
+}
+
+// Checks if the given buffer starts with a request or a response of either the text or the binary protocol of
+// memcached.
+static __always_inline bool is_memcached(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, MEMCACHED_MIN_FRAME_LENGTH);
+
+    return is_memcached_binary(buf, buf_size) || is_memcached_text_request(buf, buf_size) || is_memcached_text_response(buf, buf_size);
+}
+
+#endif
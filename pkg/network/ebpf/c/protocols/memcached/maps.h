@@ -0,0 +1,17 @@
+#ifndef __MEMCACHED_MAPS_H
+#define __MEMCACHED_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/memcached/types.h"
+
+/* This map is used to keep track of the memcached requests awaiting their response. As the requests of a connection
+   are answered in order, one request is tracked per connection, the previous ones of the pipelined requests being
+   replaced. */
+BPF_LRU_MAP(memcached_in_flight, conn_tuple_t, memcached_transaction_t, 0)
+
+/* This map is used as a scratch buffer to build the memcached transactions, as they are too large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(memcached_heap, __u32, memcached_transaction_t, 1)
+
+#endif
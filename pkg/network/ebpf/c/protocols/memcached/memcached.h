@@ -0,0 +1,105 @@
+#ifndef __MEMCACHED_H
+#define __MEMCACHED_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/events.h"
+#include "protocols/memcached/defs.h"
+#include "protocols/memcached/helpers.h"
+#include "protocols/memcached/maps.h"
+#include "protocols/memcached/types.h"
+
+USM_EVENTS_INIT(memcached, memcached_transaction_t, MEMCACHED_BATCH_SIZE);
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to max bytes. The bytes are read
+// in blocks of MEMCACHED_BLK_SIZE bytes, and the remaining ones in chunks of 4, 2 and 1 bytes, as the verifiers of
+// the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 memcached_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer, const __u32 max) {
+    __u32 read = 0;
+#pragma unroll(MEMCACHED_BUFFER_SIZE / MEMCACHED_BLK_SIZE)
+    for (int i = 0; i < MEMCACHED_BUFFER_SIZE / MEMCACHED_BLK_SIZE; i++) {
+        if (offset + MEMCACHED_BLK_SIZE > end || read + MEMCACHED_BLK_SIZE > max) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], MEMCACHED_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += MEMCACHED_BLK_SIZE;
+        read += MEMCACHED_BLK_SIZE;
+    }
+
+#define MEMCACHED_READ_CHUNK(size)                                                                  \
+    if (offset + size <= end && read + size <= max) {                                               \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    MEMCACHED_READ_CHUNK(4);
+    MEMCACHED_READ_CHUNK(2);
+    MEMCACHED_READ_CHUNK(1);
+#undef MEMCACHED_READ_CHUNK
+
+    return read;
+}
+
+// Completes the request awaiting the response started by the packet, and sends it to userspace along with the
+// beginning of the response, which tells whether the request hit or missed its key. The latency is measured up to the
+// first packet of the response.
+static __always_inline bool memcached_process_response(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    // the requests are stored with the tuple of the client side of the connection
+    conn_tuple_t key = *tup;
+    flip_tuple(&key);
+    memcached_transaction_t *tx = bpf_map_lookup_elem(&memcached_in_flight, &key);
+    if (tx == NULL) {
+        return false;
+    }
+
+    tx->response_received = bpf_ktime_get_ns();
+    tx->response_fragment_size = memcached_read_into_buffer(skb, skb_info->data_off, skb->len, tx->response_fragment, MEMCACHED_RESPONSE_SIZE);
+
+    memcached_batch_enqueue(tx);
+    bpf_map_delete_elem(&memcached_in_flight, &key);
+    return true;
+}
+
+// Stores the packets starting with a request until their response is seen.
+static __always_inline void memcached_process_request(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    const __u32 zero = 0;
+    memcached_transaction_t *tx = bpf_map_lookup_elem(&memcached_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(memcached_transaction_t));
+
+    tx->request_fragment_size = memcached_read_into_buffer(skb, skb_info->data_off, skb->len, tx->request_fragment, MEMCACHED_BUFFER_SIZE);
+    // the continuation of the values, such as the data blocks of the large items, don't start a request
+    if (!is_memcached_request(tx->request_fragment, tx->request_fragment_size)) {
+        return;
+    }
+    tx->tup = *tup;
+    tx->request_started = bpf_ktime_get_ns();
+
+    // a request whose response was not seen is replaced, as the server answers the requests of a connection in order
+    bpf_map_update_with_telemetry(memcached_in_flight, tup, tx, BPF_ANY);
+}
+
+// Processes a TCP segment of a memcached connection. The requests sent with the noreply option, or with the quiet
+// opcodes of the binary protocol, are replaced by the next request of their connection, and are not reported.
+static __always_inline void memcached_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (is_payload_empty(skb, skb_info)) {
+        return;
+    }
+
+    if (memcached_process_response(skb, skb_info, tup)) {
+        return;
+    }
+    memcached_process_request(skb, skb_info, tup);
+}
+
+#endif
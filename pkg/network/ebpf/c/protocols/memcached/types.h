@@ -0,0 +1,20 @@
+#ifndef __MEMCACHED_TYPES_H
+#define __MEMCACHED_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/memcached/defs.h"
+
+// Memcached request, from the client side of the connection, along with the beginning of its response. The requests
+// and the responses are decoded in userspace.
+typedef struct {
+    conn_tuple_t tup;
+    __u64 request_started;
+    __u64 response_received;
+    __u16 request_fragment_size;
+    __u16 response_fragment_size;
+    char request_fragment[MEMCACHED_BUFFER_SIZE];
+    char response_fragment[MEMCACHED_RESPONSE_SIZE];
+} memcached_transaction_t;
+
+#endif
@@ -18,4 +18,13 @@
 
 #define MONGO_HEADER_LENGTH 16
 
+// The size of the beginning of the OP_MSG and OP_QUERY requests sent to userspace, which holds the name of the
+// command and of its collection.
+#define MONGO_BUFFER_SIZE 128
+// The size of the beginning of the OP_MSG and OP_REPLY responses sent to userspace, which tells whether the
+// command failed.
+#define MONGO_RESPONSE_SIZE 64
+#define MONGO_BLK_SIZE 16
+#define MONGO_BATCH_SIZE 15
+
 #endif
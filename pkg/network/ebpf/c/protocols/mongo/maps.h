@@ -0,0 +1,16 @@
+#ifndef __MONGO_MAPS_H
+#define __MONGO_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/mongo/types.h"
+
+/* This map is used to keep track of the Mongo commands awaiting their response. As the drivers send one command at a
+   time on a connection, one command is tracked per connection. */
+BPF_LRU_MAP(mongo_in_flight, conn_tuple_t, mongo_transaction_t, 0)
+
+/* This map is used as a scratch buffer to build the Mongo transactions, as they are too large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(mongo_heap, __u32, mongo_transaction_t, 1)
+
+#endif
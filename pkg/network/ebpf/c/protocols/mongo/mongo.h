@@ -0,0 +1,125 @@
+#ifndef __MONGO_H
+#define __MONGO_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/classification/structs.h"
+#include "protocols/events.h"
+#include "protocols/mongo/defs.h"
+#include "protocols/mongo/maps.h"
+#include "protocols/mongo/types.h"
+
+USM_EVENTS_INIT(mongo, mongo_transaction_t, MONGO_BATCH_SIZE);
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to max bytes. The bytes are read
+// in blocks of MONGO_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the verifiers of
+// the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 mongo_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer, const __u32 max) {
+    __u32 read = 0;
+#pragma unroll(MONGO_BUFFER_SIZE / MONGO_BLK_SIZE)
+    for (int i = 0; i < MONGO_BUFFER_SIZE / MONGO_BLK_SIZE; i++) {
+        if (offset + MONGO_BLK_SIZE > end || read + MONGO_BLK_SIZE > max) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], MONGO_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += MONGO_BLK_SIZE;
+        read += MONGO_BLK_SIZE;
+    }
+
+#define MONGO_READ_CHUNK(size)                                                                      \
+    if (offset + size <= end && read + size <= max) {                                               \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    MONGO_READ_CHUNK(8);
+    MONGO_READ_CHUNK(4);
+    MONGO_READ_CHUNK(2);
+    MONGO_READ_CHUNK(1);
+#undef MONGO_READ_CHUNK
+
+    return read;
+}
+
+// Completes the command answered by the packet, and sends it to userspace along with the beginning of the response.
+static __always_inline void mongo_process_response(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, mongo_msg_header *hdr) {
+    // the commands are stored with the tuple of the client side of the connection
+    conn_tuple_t key = *tup;
+    flip_tuple(&key);
+    mongo_transaction_t *tx = bpf_map_lookup_elem(&mongo_in_flight, &key);
+    if (tx == NULL || tx->request_id != hdr->response_to) {
+        return;
+    }
+
+    tx->response_received = bpf_ktime_get_ns();
+    tx->response_fragment_size = mongo_read_into_buffer(skb, skb_info->data_off, skb->len, tx->response_fragment, MONGO_RESPONSE_SIZE);
+
+    mongo_batch_enqueue(tx);
+    bpf_map_delete_elem(&mongo_in_flight, &key);
+}
+
+// Stores the packets starting with an OP_MSG or OP_QUERY request until their response is seen.
+static __always_inline void mongo_process_request(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, mongo_msg_header *hdr) {
+    const __u32 zero = 0;
+    mongo_transaction_t *tx = bpf_map_lookup_elem(&mongo_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(mongo_transaction_t));
+
+    tx->tup = *tup;
+    tx->request_started = bpf_ktime_get_ns();
+    tx->request_id = hdr->request_id;
+    tx->request_fragment_size = mongo_read_into_buffer(skb, skb_info->data_off, skb->len, tx->request_fragment, MONGO_BUFFER_SIZE);
+
+    // a command whose response was not seen, such as the unacknowledged writes, is replaced
+    bpf_map_update_with_telemetry(mongo_in_flight, tup, tx, BPF_ANY);
+}
+
+// Processes a TCP segment of a Mongo connection. The segments which don't start a message, such as the continuation
+// of the large documents, as well as the compressed messages, are ignored.
+// Ref: https://www.mongodb.com/docs/manual/reference/mongodb-wire-protocol/
+static __always_inline void mongo_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (is_payload_empty(skb, skb_info)) {
+        return;
+    }
+
+    mongo_msg_header hdr;
+    if (skb->len < skb_info->data_off + sizeof(hdr)) {
+        return;
+    }
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb_info->data_off, &hdr, sizeof(hdr)) < 0) {
+        return;
+    }
+    if (hdr.message_length < MONGO_HEADER_LENGTH) {
+        return;
+    }
+
+    switch (hdr.op_code) {
+    case MONGO_OP_MSG:
+        if (hdr.response_to != 0) {
+            mongo_process_response(skb, skb_info, tup, &hdr);
+            return;
+        }
+        mongo_process_request(skb, skb_info, tup, &hdr);
+        return;
+    case MONGO_OP_QUERY:
+        if (hdr.response_to == 0) {
+            mongo_process_request(skb, skb_info, tup, &hdr);
+        }
+        return;
+    case MONGO_OP_REPLY:
+        mongo_process_response(skb, skb_info, tup, &hdr);
+        return;
+    }
+}
+
+#endif
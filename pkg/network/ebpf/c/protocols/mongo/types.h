@@ -0,0 +1,22 @@
+#ifndef __MONGO_TYPES_H
+#define __MONGO_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/mongo/defs.h"
+
+// Mongo command, from the client side of the connection, along with the beginning of its response. The commands
+// and the responses are decoded in userspace.
+typedef struct {
+    conn_tuple_t tup;
+    __u64 request_started;
+    __u64 response_received;
+    // the request id of the command, which is referenced by the response_to field of its response
+    __s32 request_id;
+    __u16 request_fragment_size;
+    __u16 response_fragment_size;
+    char request_fragment[MONGO_BUFFER_SIZE];
+    char response_fragment[MONGO_RESPONSE_SIZE];
+} mongo_transaction_t;
+
+#endif
@@ -8,6 +8,8 @@
 #define MYSQL_COMMAND_QUERY 0x3
 // Taken from https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_com_stmt_prepare.html
 #define MYSQL_PREPARE_QUERY 0x16
+// Taken from https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_com_stmt_execute.html
+#define MYSQL_STMT_EXECUTE 0x17
 // Taken from https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_connection_phase_packets_protocol_handshake_v10.html.
 #define MYSQL_SERVER_GREETING_V10 0xa
 // Taken from https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_connection_phase_packets_protocol_handshake_v9.html.
@@ -21,6 +23,15 @@
 // Minium version string is <digit>.<digit>.<digit>
 #define MIN_VERSION_SIZE 5
 
+// The size of the beginning of the commands sent to userspace, which holds the query of the COM_QUERY and
+// COM_STMT_PREPARE commands, or the statement id of the COM_STMT_EXECUTE commands.
+#define MYSQL_BUFFER_SIZE 160
+// The size of the beginning of the responses sent to userspace, which holds the error code of the ERR packets,
+// or the statement id of the COM_STMT_PREPARE_OK packets.
+#define MYSQL_RESPONSE_SIZE 16
+#define MYSQL_BLK_SIZE 16
+#define MYSQL_BATCH_SIZE 15
+
 // MySQL header format. Starts with 24 bits (3 bytes) of the length of the payload, a one byte of sequence id,
 // a one byte to represent the message type.
 typedef struct {
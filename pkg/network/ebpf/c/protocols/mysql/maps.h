@@ -0,0 +1,16 @@
+#ifndef __MYSQL_MAPS_H
+#define __MYSQL_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/mysql/types.h"
+
+/* This map is used to keep track of the MySQL commands awaiting their response. As the commands of a connection are
+   answered in order, one command is tracked per connection. */
+BPF_LRU_MAP(mysql_in_flight, conn_tuple_t, mysql_transaction_t, 0)
+
+/* This map is used as a scratch buffer to build the MySQL transactions, as they are too large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(mysql_heap, __u32, mysql_transaction_t, 1)
+
+#endif
@@ -0,0 +1,131 @@
+#ifndef __MYSQL_H
+#define __MYSQL_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/events.h"
+#include "protocols/mysql/defs.h"
+#include "protocols/mysql/maps.h"
+#include "protocols/mysql/types.h"
+
+USM_EVENTS_INIT(mysql, mysql_transaction_t, MYSQL_BATCH_SIZE);
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to max bytes. The bytes are read
+// in blocks of MYSQL_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the verifiers of
+// the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 mysql_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer, const __u32 max) {
+    __u32 read = 0;
+#pragma unroll(MYSQL_BUFFER_SIZE / MYSQL_BLK_SIZE)
+    for (int i = 0; i < MYSQL_BUFFER_SIZE / MYSQL_BLK_SIZE; i++) {
+        if (offset + MYSQL_BLK_SIZE > end || read + MYSQL_BLK_SIZE > max) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], MYSQL_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += MYSQL_BLK_SIZE;
+        read += MYSQL_BLK_SIZE;
+    }
+
+#define MYSQL_READ_CHUNK(size)                                                                      \
+    if (offset + size <= end && read + size <= max) {                                               \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    MYSQL_READ_CHUNK(8);
+    MYSQL_READ_CHUNK(4);
+    MYSQL_READ_CHUNK(2);
+    MYSQL_READ_CHUNK(1);
+#undef MYSQL_READ_CHUNK
+
+    return read;
+}
+
+// Reads the header of the MySQL packet starting the segment, along with the first byte of its payload, which is the
+// command type of the commands.
+static __always_inline bool mysql_read_header(struct __sk_buff *skb, skb_info_t *skb_info, mysql_hdr *hdr) {
+    if (skb->len < skb_info->data_off + sizeof(mysql_hdr)) {
+        return false;
+    }
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb_info->data_off, hdr, sizeof(mysql_hdr)) < 0) {
+        return false;
+    }
+    return hdr->payload_length > 0;
+}
+
+// Completes the command awaiting the response started by the packet, and sends it to userspace along with the
+// beginning of the response, which tells whether the command succeeded. The latency is measured up to the first
+// packet of the response.
+static __always_inline bool mysql_process_response(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, mysql_hdr *hdr) {
+    // the commands are stored with the tuple of the client side of the connection
+    conn_tuple_t key = *tup;
+    flip_tuple(&key);
+    mysql_transaction_t *tx = bpf_map_lookup_elem(&mysql_in_flight, &key);
+    if (tx == NULL) {
+        return false;
+    }
+    // the packets of the responses follow the one of the command in the sequence
+    if (hdr->seq_id == 0) {
+        return true;
+    }
+
+    tx->response_received = bpf_ktime_get_ns();
+    tx->response_fragment_size = mysql_read_into_buffer(skb, skb_info->data_off, skb->len, tx->response_fragment, MYSQL_RESPONSE_SIZE);
+
+    mysql_batch_enqueue(tx);
+    bpf_map_delete_elem(&mysql_in_flight, &key);
+    return true;
+}
+
+// Stores the packets starting with a COM_QUERY, COM_STMT_PREPARE or COM_STMT_EXECUTE command until their response
+// is seen.
+// Ref: https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_command_phase.html
+static __always_inline void mysql_process_request(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, mysql_hdr *hdr) {
+    // the commands reset the sequence id
+    if (hdr->seq_id != 0) {
+        return;
+    }
+    if (hdr->command_type != MYSQL_COMMAND_QUERY && hdr->command_type != MYSQL_PREPARE_QUERY && hdr->command_type != MYSQL_STMT_EXECUTE) {
+        return;
+    }
+
+    const __u32 zero = 0;
+    mysql_transaction_t *tx = bpf_map_lookup_elem(&mysql_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(mysql_transaction_t));
+
+    tx->tup = *tup;
+    tx->request_started = bpf_ktime_get_ns();
+    tx->request_fragment_size = mysql_read_into_buffer(skb, skb_info->data_off, skb->len, tx->request_fragment, MYSQL_BUFFER_SIZE);
+
+    // a command whose response was not seen is replaced, as the server answers the commands of a connection in order
+    bpf_map_update_with_telemetry(mysql_in_flight, tup, tx, BPF_ANY);
+}
+
+// Processes a TCP segment of a MySQL connection. The segments which neither start a command nor the response to a
+// command, such as the rows of the result sets, are ignored.
+static __always_inline void mysql_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (is_payload_empty(skb, skb_info)) {
+        return;
+    }
+
+    mysql_hdr hdr;
+    if (!mysql_read_header(skb, skb_info, &hdr)) {
+        return;
+    }
+    if (mysql_process_response(skb, skb_info, tup, &hdr)) {
+        return;
+    }
+    mysql_process_request(skb, skb_info, tup, &hdr);
+}
+
+#endif
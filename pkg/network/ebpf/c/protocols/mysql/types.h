@@ -0,0 +1,20 @@
+#ifndef __MYSQL_TYPES_H
+#define __MYSQL_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/mysql/defs.h"
+
+// MySQL command, from the client side of the connection, along with the beginning of its response. The
+// commands and the first packet of the responses are decoded in userspace.
+typedef struct {
+    conn_tuple_t tup;
+    __u64 request_started;
+    __u64 response_received;
+    __u16 request_fragment_size;
+    __u16 response_fragment_size;
+    char request_fragment[MYSQL_BUFFER_SIZE];
+    char response_fragment[MYSQL_RESPONSE_SIZE];
+} mysql_transaction_t;
+
+#endif
@@ -28,6 +28,24 @@
 
 #define POSTGRES_QUERY_MAGIC_BYTE 'Q'
 #define POSTGRES_COMMAND_COMPLETE_MAGIC_BYTE 'C'
+#define POSTGRES_PARSE_MAGIC_BYTE 'P'
+#define POSTGRES_BIND_MAGIC_BYTE 'B'
+#define POSTGRES_READY_FOR_QUERY_MAGIC_BYTE 'Z'
+
+// The ReadyForQuery message ends the response of the server to a simple query, or to a
+// sequence of extended query messages ended with Sync. It is made of the message tag, the
+// length, which is always 4, and the transaction status indicator.
+#define POSTGRES_READY_FOR_QUERY_SIZE 6
+#define POSTGRES_READY_FOR_QUERY_LEN 4
+
+// The size of the beginning of the query messages sent to userspace, which holds the query
+// of the simple query and Parse messages, or the statement name of the Bind messages.
+#define POSTGRES_BUFFER_SIZE 160
+// The size of the end of the responses sent to userspace, preceding the ReadyForQuery message,
+// which holds the CommandComplete message and its command tag.
+#define POSTGRES_RESPONSE_TAIL_SIZE 32
+#define POSTGRES_BLK_SIZE 16
+#define POSTGRES_BATCH_SIZE 15
 
 // Regular format of postgres message: | byte tag | int32_t len | string payload |
 // From https://www.postgresql.org/docs/current/protocol-overview.html:
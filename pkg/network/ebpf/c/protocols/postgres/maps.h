@@ -0,0 +1,16 @@
+#ifndef __POSTGRES_MAPS_H
+#define __POSTGRES_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/postgres/types.h"
+
+/* This map is used to keep track of the Postgres queries awaiting their response. As the queries of a connection are
+   answered in order, one query is tracked per connection. */
+BPF_LRU_MAP(postgres_in_flight, conn_tuple_t, postgres_transaction_t, 0)
+
+/* This map is used as a scratch buffer to build the Postgres transactions, as they are too large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(postgres_heap, __u32, postgres_transaction_t, 1)
+
+#endif
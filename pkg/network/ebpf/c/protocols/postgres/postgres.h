@@ -0,0 +1,138 @@
+#ifndef __POSTGRES_H
+#define __POSTGRES_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "bpf_endian.h"
+#include "ip.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/events.h"
+#include "protocols/postgres/defs.h"
+#include "protocols/postgres/maps.h"
+#include "protocols/postgres/types.h"
+
+USM_EVENTS_INIT(postgres, postgres_transaction_t, POSTGRES_BATCH_SIZE);
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to max bytes. The bytes are read
+// in blocks of POSTGRES_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the verifiers of
+// the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 postgres_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer, const __u32 max) {
+    __u32 read = 0;
+#pragma unroll(POSTGRES_BUFFER_SIZE / POSTGRES_BLK_SIZE)
+    for (int i = 0; i < POSTGRES_BUFFER_SIZE / POSTGRES_BLK_SIZE; i++) {
+        if (offset + POSTGRES_BLK_SIZE > end || read + POSTGRES_BLK_SIZE > max) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], POSTGRES_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += POSTGRES_BLK_SIZE;
+        read += POSTGRES_BLK_SIZE;
+    }
+
+#define POSTGRES_READ_CHUNK(size)                                                                   \
+    if (offset + size <= end && read + size <= max) {                                               \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    POSTGRES_READ_CHUNK(8);
+    POSTGRES_READ_CHUNK(4);
+    POSTGRES_READ_CHUNK(2);
+    POSTGRES_READ_CHUNK(1);
+#undef POSTGRES_READ_CHUNK
+
+    return read;
+}
+
+// Returns true if the packet ends with a ReadyForQuery message, which ends the response of the server to a query.
+static __always_inline bool postgres_is_response_end(struct __sk_buff *skb, skb_info_t *skb_info) {
+    if (skb->len < skb_info->data_off + POSTGRES_READY_FOR_QUERY_SIZE) {
+        return false;
+    }
+
+    struct pg_message_header hdr;
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb->len - POSTGRES_READY_FOR_QUERY_SIZE, &hdr, sizeof(hdr)) < 0) {
+        return false;
+    }
+    return hdr.message_tag == POSTGRES_READY_FOR_QUERY_MAGIC_BYTE && bpf_ntohl(hdr.message_len) == POSTGRES_READY_FOR_QUERY_LEN;
+}
+
+// Completes the query awaiting the response ended by the packet, and sends it to userspace along with the end of the
+// response, which holds the command tag of the CommandComplete message.
+static __always_inline bool postgres_process_response(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (!postgres_is_response_end(skb, skb_info)) {
+        return false;
+    }
+
+    // the queries are stored with the tuple of the client side of the connection
+    conn_tuple_t key = *tup;
+    flip_tuple(&key);
+    postgres_transaction_t *tx = bpf_map_lookup_elem(&postgres_in_flight, &key);
+    if (tx == NULL) {
+        return true;
+    }
+
+    tx->response_received = bpf_ktime_get_ns();
+    const __u32 end = skb->len - POSTGRES_READY_FOR_QUERY_SIZE;
+    const __u32 tail_size = end - skb_info->data_off < POSTGRES_RESPONSE_TAIL_SIZE ? end - skb_info->data_off : POSTGRES_RESPONSE_TAIL_SIZE;
+    tx->response_tail_size = postgres_read_into_buffer(skb, end - tail_size, end, tx->response_tail, POSTGRES_RESPONSE_TAIL_SIZE);
+
+    postgres_batch_enqueue(tx);
+    bpf_map_delete_elem(&postgres_in_flight, &key);
+    return true;
+}
+
+// Stores the packets starting with a simple query, Parse or Bind message until their response is seen.
+// Ref: https://www.postgresql.org/docs/current/protocol-flow.html#PROTOCOL-FLOW-SIMPLE-QUERY
+// Ref: https://www.postgresql.org/docs/current/protocol-flow.html#PROTOCOL-FLOW-EXT-QUERY
+static __always_inline void postgres_process_request(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    struct pg_message_header hdr;
+    if (skb->len < skb_info->data_off + sizeof(hdr)) {
+        return;
+    }
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb_info->data_off, &hdr, sizeof(hdr)) < 0) {
+        return;
+    }
+
+    if (hdr.message_tag != POSTGRES_QUERY_MAGIC_BYTE && hdr.message_tag != POSTGRES_PARSE_MAGIC_BYTE && hdr.message_tag != POSTGRES_BIND_MAGIC_BYTE) {
+        return;
+    }
+    const __u32 message_len = bpf_ntohl(hdr.message_len);
+    if (message_len < POSTGRES_MIN_PAYLOAD_LEN || message_len > POSTGRES_MAX_PAYLOAD_LEN) {
+        return;
+    }
+
+    const __u32 zero = 0;
+    postgres_transaction_t *tx = bpf_map_lookup_elem(&postgres_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(postgres_transaction_t));
+
+    tx->tup = *tup;
+    tx->request_started = bpf_ktime_get_ns();
+    tx->request_fragment_size = postgres_read_into_buffer(skb, skb_info->data_off, skb->len, tx->request_fragment, POSTGRES_BUFFER_SIZE);
+
+    // a query whose response was not seen is replaced, as the server answers the queries of a connection in order
+    bpf_map_update_with_telemetry(postgres_in_flight, tup, tx, BPF_ANY);
+}
+
+// Processes a TCP segment of a Postgres connection. The segments which neither start a query nor end a response, such
+// as the data rows of the large responses, are ignored.
+static __always_inline void postgres_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (is_payload_empty(skb, skb_info)) {
+        return;
+    }
+
+    if (postgres_process_response(skb, skb_info, tup)) {
+        return;
+    }
+    postgres_process_request(skb, skb_info, tup);
+}
+
+#endif
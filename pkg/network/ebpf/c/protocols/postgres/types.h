@@ -0,0 +1,20 @@
+#ifndef __POSTGRES_TYPES_H
+#define __POSTGRES_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/postgres/defs.h"
+
+// Postgres query, from the client side of the connection, along with the end of its response. The
+// query messages and the command tags of the responses are decoded in userspace.
+typedef struct {
+    conn_tuple_t tup;
+    __u64 request_started;
+    __u64 response_received;
+    __u16 request_fragment_size;
+    __u16 response_tail_size;
+    char request_fragment[POSTGRES_BUFFER_SIZE];
+    char response_tail[POSTGRES_RESPONSE_TAIL_SIZE];
+} postgres_transaction_t;
+
+#endif
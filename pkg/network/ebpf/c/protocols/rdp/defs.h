@@ -0,0 +1,21 @@
+#ifndef __RDP_DEFS_H
+#define __RDP_DEFS_H
+
+// The RDP clients open the connections with an X.224 Connection Request, carried by a TPKT packet.
+// Checkout https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-rdpbcgr/18a27ef9-6f9a-4501-b000-94b1fe3c2c10
+
+// The TPKT header holds the version, a reserved byte and the length of the packet, header included.
+// Checkout https://datatracker.ietf.org/doc/html/rfc1006#section-6
+#define TPKT_VERSION 0x03
+#define TPKT_HEADER_SIZE 4
+
+// The X.224 header holds the length of the header, excluding the length indicator itself, followed by the code of the
+// TPDU in the upper 4 bits of its second byte. The fixed part of the header of the Connection Request is 7 bytes long.
+// Checkout https://www.itu.int/rec/T-REC-X.224-199511-I/en, section 13.3
+#define X224_CONNECTION_REQUEST 0xe0
+#define X224_TPDU_CODE_MASK 0xf0
+#define X224_CONNECTION_REQUEST_MIN_SIZE 7
+
+#define RDP_MIN_LENGTH (TPKT_HEADER_SIZE + X224_CONNECTION_REQUEST_MIN_SIZE)
+
+#endif
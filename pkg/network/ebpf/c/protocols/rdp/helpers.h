@@ -0,0 +1,31 @@
+#ifndef __RDP_HELPERS_H
+#define __RDP_HELPERS_H
+
+#include "bpf_endian.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/rdp/defs.h"
+
+// Checks if the given buffer holds the beginning of the X.224 Connection Request opening an RDP connection. The
+// length indicator of the X.224 header must match the length of the TPKT packet.
+static __always_inline bool is_rdp(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, RDP_MIN_LENGTH);
+
+    if (buf[0] != TPKT_VERSION || buf[1] != 0) {
+        return false;
+    }
+
+    __u16 length = bpf_ntohs(*(__u16 *)&buf[2]);
+    if (length < RDP_MIN_LENGTH) {
+        return false;
+    }
+
+    __u8 length_indicator = buf[TPKT_HEADER_SIZE];
+    if (length_indicator + 1 != length - TPKT_HEADER_SIZE) {
+        return false;
+    }
+
+    return ((__u8)buf[TPKT_HEADER_SIZE + 1] & X224_TPDU_CODE_MASK) == X224_CONNECTION_REQUEST;
+}
+
+#endif
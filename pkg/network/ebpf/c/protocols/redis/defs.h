@@ -3,4 +3,13 @@
 
 #define REDIS_MIN_FRAME_LENGTH 3
 
+// The commands are sent by the clients as arrays of bulk strings, the first one being the name of the command.
+// Ref: https://redis.io/docs/reference/protocol-spec/#sending-commands-to-a-redis-server
+#define REDIS_ARRAY_PREFIX '*'
+
+// The size of the beginning of the commands sent to userspace, which holds the name of the command.
+#define REDIS_BUFFER_SIZE 48
+#define REDIS_BLK_SIZE 16
+#define REDIS_BATCH_SIZE 30
+
 #endif
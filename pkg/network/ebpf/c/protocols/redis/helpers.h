@@ -36,12 +36,14 @@ static __always_inline bool check_supported_ascii_and_crlf(const char* buf, __u3
 static __always_inline bool check_err_prefix(const char* buf, __u32 buf_size) {
 #define ERR "-ERR "
 #define WRONGTYPE "-WRONGTYPE "
+#define NOPROTO "-NOPROTO "
 
     // memcmp returns
     // 0 when s1 == s2,
     // !0 when s1 != s2.
     bool match = !(bpf_memcmp(buf, ERR, sizeof(ERR)-1)
-        && bpf_memcmp(buf, WRONGTYPE, sizeof(WRONGTYPE)-1));
+        && bpf_memcmp(buf, WRONGTYPE, sizeof(WRONGTYPE)-1)
+        && bpf_memcmp(buf, NOPROTO, sizeof(NOPROTO)-1));
 
     return match;
 }
@@ -78,9 +80,20 @@ static __always_inline bool is_redis(const char* buf, __u32 buf_size) {
         return check_supported_ascii_and_crlf(buf, buf_size, 1);
     case '-':
         return check_err_prefix(buf, buf_size);
+    // The RESP3 aggregate and length-prefixed frames (https://github.com/redis/redis-specifications/blob/master/protocol/RESP3.md)
+    // are recognized along with the RESP2 ones, so that the connections of the clients using HELLO 3, whose reply is a
+    // map, and the push messages sent for client-side caching are classified as well. The RESP3 simple types (null,
+    // boolean, double) are too short to be told apart from other protocols, and are not expected to open a connection.
     case ':':
     case '$':
     case '*':
+    case '%': // map
+    case '~': // set
+    case '>': // push
+    case '|': // attribute
+    case '!': // blob error
+    case '=': // verbatim string
+    case '(': // big number
         return check_integer_and_crlf(buf, buf_size, 1);
     default:
         return false;
@@ -0,0 +1,17 @@
+#ifndef __REDIS_MAPS_H
+#define __REDIS_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/redis/types.h"
+
+/* This map is used to keep track of the Redis commands awaiting their reply. As the commands of a connection are
+   answered in order, one command is tracked per connection, the previous ones of the pipelined commands being
+   replaced. */
+BPF_LRU_MAP(redis_in_flight, conn_tuple_t, redis_transaction_t, 0)
+
+/* This map is used as a scratch buffer to build the Redis transactions, as they are too large for the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(redis_heap, __u32, redis_transaction_t, 1)
+
+#endif
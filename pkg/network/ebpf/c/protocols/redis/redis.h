@@ -0,0 +1,112 @@
+#ifndef __REDIS_H
+#define __REDIS_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/events.h"
+#include "protocols/redis/defs.h"
+#include "protocols/redis/maps.h"
+#include "protocols/redis/types.h"
+
+USM_EVENTS_INIT(redis, redis_transaction_t, REDIS_BATCH_SIZE);
+
+// Reads the bytes of the packet between offset and end into buffer, which holds up to REDIS_BUFFER_SIZE bytes. The
+// bytes are read in blocks of REDIS_BLK_SIZE bytes, and the remaining ones in chunks of 8, 4, 2 and 1 bytes, as the
+// verifiers of the older kernels only accept reads of a constant size. Returns the number of bytes read.
+static __always_inline __u16 redis_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer) {
+    __u32 read = 0;
+#pragma unroll(REDIS_BUFFER_SIZE / REDIS_BLK_SIZE)
+    for (int i = 0; i < REDIS_BUFFER_SIZE / REDIS_BLK_SIZE; i++) {
+        if (offset + REDIS_BLK_SIZE > end) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], REDIS_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += REDIS_BLK_SIZE;
+        read += REDIS_BLK_SIZE;
+    }
+    if (read == REDIS_BUFFER_SIZE) {
+        return read;
+    }
+
+#define REDIS_READ_CHUNK(size)                                                                      \
+    if (offset + size <= end && read + size <= REDIS_BUFFER_SIZE) {                                 \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    REDIS_READ_CHUNK(8);
+    REDIS_READ_CHUNK(4);
+    REDIS_READ_CHUNK(2);
+    REDIS_READ_CHUNK(1);
+#undef REDIS_READ_CHUNK
+
+    return read;
+}
+
+// Completes the command awaiting the reply started by the packet, and sends it to userspace along with the type of
+// the reply. The latency is measured up to the first packet of the reply.
+static __always_inline bool redis_process_response(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, char first_byte) {
+    // the commands are stored with the tuple of the client side of the connection
+    conn_tuple_t key = *tup;
+    flip_tuple(&key);
+    redis_transaction_t *tx = bpf_map_lookup_elem(&redis_in_flight, &key);
+    if (tx == NULL) {
+        return false;
+    }
+
+    tx->response_received = bpf_ktime_get_ns();
+    tx->response_type = first_byte;
+
+    redis_batch_enqueue(tx);
+    bpf_map_delete_elem(&redis_in_flight, &key);
+    return true;
+}
+
+// Stores the packets starting with a command until their reply is seen.
+static __always_inline void redis_process_request(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup, char first_byte) {
+    // the inline commands, which are not sent by the client libraries, are ignored
+    if (first_byte != REDIS_ARRAY_PREFIX) {
+        return;
+    }
+
+    const __u32 zero = 0;
+    redis_transaction_t *tx = bpf_map_lookup_elem(&redis_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(redis_transaction_t));
+
+    tx->tup = *tup;
+    tx->request_started = bpf_ktime_get_ns();
+    tx->request_fragment_size = redis_read_into_buffer(skb, skb_info->data_off, skb->len, tx->request_fragment);
+
+    // a command whose reply was not seen is replaced, as the server answers the commands of a connection in order
+    bpf_map_update_with_telemetry(redis_in_flight, tup, tx, BPF_ANY);
+}
+
+// Processes a TCP segment of a Redis connection. The segments which neither start a command nor a reply, such as the
+// continuation of the large values, are ignored.
+static __always_inline void redis_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    if (is_payload_empty(skb, skb_info)) {
+        return;
+    }
+
+    char first_byte = 0;
+    if (bpf_skb_load_bytes_with_telemetry(skb, skb_info->data_off, &first_byte, sizeof(first_byte)) < 0) {
+        return;
+    }
+    if (redis_process_response(skb, skb_info, tup, first_byte)) {
+        return;
+    }
+    redis_process_request(skb, skb_info, tup, first_byte);
+}
+
+#endif
@@ -0,0 +1,20 @@
+#ifndef __REDIS_TYPES_H
+#define __REDIS_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/redis/defs.h"
+
+// Redis command, from the client side of the connection, along with the type of its reply. The commands are
+// decoded in userspace.
+typedef struct {
+    conn_tuple_t tup;
+    __u64 request_started;
+    __u64 response_received;
+    __u16 request_fragment_size;
+    // the first byte of the reply, which is the RESP type of the reply, such as '-' for the errors
+    __u8 response_type;
+    char request_fragment[REDIS_BUFFER_SIZE];
+} redis_transaction_t;
+
+#endif
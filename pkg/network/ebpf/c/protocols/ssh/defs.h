@@ -0,0 +1,13 @@
+#ifndef __SSH_DEFS_H
+#define __SSH_DEFS_H
+
+// Both sides of an SSH connection open it with their identification string, which starts with the version of the
+// protocol. The servers supporting the version 1 of the protocol along with the version 2 identify themselves with
+// the version 1.99.
+// Checkout https://datatracker.ietf.org/doc/html/rfc4253#section-4.2 and https://datatracker.ietf.org/doc/html/rfc4253#section-5.1
+#define SSH_V2_BANNER "SSH-2.0-"
+#define SSH_V1_99_BANNER "SSH-1.99-"
+
+#define SSH_MIN_BANNER_LENGTH (sizeof(SSH_V2_BANNER) - 1)
+
+#endif
@@ -0,0 +1,18 @@
+#ifndef __SSH_HELPERS_H
+#define __SSH_HELPERS_H
+
+#include "protocols/classification/common.h"
+#include "protocols/ssh/defs.h"
+
+// Checks if the given buffer starts with the identification string of an SSH client or server.
+static __always_inline bool is_ssh(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, SSH_MIN_BANNER_LENGTH);
+
+    // memcmp returns
+    // 0 when s1 == s2,
+    // !0 when s1 != s2.
+    return !(bpf_memcmp(buf, SSH_V2_BANNER, sizeof(SSH_V2_BANNER) - 1)
+        && bpf_memcmp(buf, SSH_V1_99_BANNER, sizeof(SSH_V1_99_BANNER) - 1));
+}
+
+#endif
@@ -14,7 +14,7 @@
 #include "protocols/tls/go-tls-types.h"
 #include "protocols/tls/go-tls-location.h"
 
-// Implemented either in c/runtime/runtime-get-tls-base.h or in ____ (TODO add offset-guessed version)
+// Implemented either in c/runtime/http.c or in c/prebuilt/http.c
 static void* get_tls_base(struct task_struct* task);
 
 // This function was adapted from https://github.com/go-delve/delve:
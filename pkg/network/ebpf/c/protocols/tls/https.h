@@ -22,8 +22,12 @@
 #include "protocols/classification/dispatcher-maps.h"
 #include "protocols/http/buffer.h"
 #include "protocols/http/types.h"
+#include "protocols/http/headers.h"
 #include "protocols/http/maps.h"
 #include "protocols/http/http.h"
+#include "protocols/http2/http2.h"
+#include "protocols/grpc/grpc.h"
+#include "protocols/dns/dns-tls.h"
 #include "protocols/tls/tags-types.h"
 #include "protocols/tls/go-tls-types.h"
 
@@ -32,17 +36,98 @@
 static __always_inline int read_conn_tuple(conn_tuple_t* t, struct sock* skp, u64 pid_tgid, metadata_mask_t type);
 static __always_inline int http_process(http_transaction_t *http_stack, skb_info_t *skb_info, __u64 tags);
 
-static __always_inline void https_process(conn_tuple_t *t, void *buffer, size_t len, __u64 tags) {
+// count_tls_bytes records the bytes of the connection that went through the TLS hooks, so that the share of encrypted
+// traffic of the connection can be computed in userspace
+static __always_inline void count_tls_bytes(conn_tuple_t *t, size_t len) {
+    __u64 *bytes = bpf_map_lookup_elem(&tls_conn_bytes, t);
+    if (bytes != NULL) {
+        __sync_fetch_and_add(bytes, len);
+        return;
+    }
+    __u64 initial = len;
+    bpf_map_update_with_telemetry(tls_conn_bytes, t, &initial, BPF_NOEXIST);
+}
+
+// Processes the plaintext of an HTTP/2 connection over TLS. The tuple of the connection doesn't tell which of its
+// sides the process reading or writing the plaintext is, which is learnt from the connection preface instead, as it is
+// sent by the client. As a result, the connections established before the TLS library of the process was hooked are
+// not accounted for.
+static __always_inline void https2_process(conn_tuple_t *t, char *buffer, __u32 len, bool is_write, __u64 tags) {
+    if (!http2_monitoring_enabled()) {
+        return;
+    }
+
+    conn_tuple_t key = *t;
+    normalize_tuple(&key);
+    conn_tuple_t side_key = key;
+    side_key.pid = bpf_get_current_pid_tgid() >> 32;
+
+    bool from_client = false;
+    __u8 *local_client = bpf_map_lookup_elem(&tls_http2_local_client, &side_key);
+    if (local_client != NULL) {
+        from_client = (*local_client != 0) == is_write;
+    } else {
+        char preface[HTTP2_MARKER_SIZE];
+        if (len < HTTP2_MARKER_SIZE || bpf_probe_read_user_with_telemetry(preface, HTTP2_MARKER_SIZE, buffer) < 0 || !is_http2_preface(preface, HTTP2_MARKER_SIZE)) {
+            return;
+        }
+        from_client = true;
+        __u8 is_client = is_write;
+        bpf_map_update_with_telemetry(tls_http2_local_client, &side_key, &is_client, BPF_ANY);
+    }
+
+    http2_headers_frames_t headers;
+    bpf_memset(&headers, 0, sizeof(headers));
+    http2_count_tls_frames(&key, buffer, len, from_client, tags, &headers);
+    grpc_process_tls_headers(buffer, len, &headers, tags);
+}
+
+// https_local_direction returns the side of the current process in the HTTP/1.1 connection t, CONN_HTTP_CLIENT or
+// CONN_HTTP_SERVER, or 0 if it is unknown yet. It is learnt from the first request or response read or written by the
+// process, as the client writes the requests and the server reads them.
+static __always_inline __u32 https_local_direction(conn_tuple_t *t, const char *fragment, bool is_write) {
+    conn_tuple_t side_key = *t;
+    normalize_tuple(&side_key);
+    side_key.pid = bpf_get_current_pid_tgid() >> 32;
+
+    __u8 *local_client = bpf_map_lookup_elem(&tls_http_local_client, &side_key);
+    if (local_client != NULL) {
+        return *local_client ? CONN_HTTP_CLIENT : CONN_HTTP_SERVER;
+    }
+
+    http_packet_t packet_type = HTTP_PACKET_UNKNOWN;
+    http_method_t method = HTTP_METHOD_UNKNOWN;
+    http_parse_data(fragment, &packet_type, &method);
+    if (packet_type == HTTP_PACKET_UNKNOWN) {
+        return 0;
+    }
+    __u8 is_client = (packet_type == HTTP_REQUEST) == is_write;
+    bpf_map_update_with_telemetry(tls_http_local_client, &side_key, &is_client, BPF_ANY);
+    return is_client ? CONN_HTTP_CLIENT : CONN_HTTP_SERVER;
+}
+
+// https_process processes the plaintext read from (or written to, if is_write is set) a TLS connection. The DNS over
+// TLS connections are sent to the DNS snooper, the HTTP/2 connections are accounted for along with their gRPC calls,
+// and the other ones are parsed as HTTP/1.1.
+static __always_inline void https_process(conn_tuple_t *t, void *buffer, size_t len, bool is_write, __u64 tags) {
+    count_tls_bytes(t, len);
+    if (dns_tls_process(t, buffer, len, is_write)) {
+        return;
+    }
+
     http_transaction_t http;
     bpf_memset(&http, 0, sizeof(http));
     bpf_memcpy(&http.tup, t, sizeof(conn_tuple_t));
     read_into_buffer(http.request_fragment, buffer, len);
+    read_tail_into_buffer(http.segment_tail, buffer, len);
+    http_read_headers_user(buffer, len, http.request_fragment);
+    http.segment_size = len;
     http.owned_by_src_port = http.tup.sport;
     log_debug("https_process: htx=%llx sport=%d\n", &http, http.owned_by_src_port);
 
+    protocol_t cur_fragment_protocol = PROTOCOL_UNKNOWN;
     protocol_t *cur_fragment_protocol_ptr = bpf_map_lookup_elem(&dispatcher_connection_protocol, &http.tup);
     if (cur_fragment_protocol_ptr == NULL) {
-        protocol_t cur_fragment_protocol = PROTOCOL_UNKNOWN;
         conn_tuple_t inverse_conn_tup = http.tup;
         flip_tuple(&inverse_conn_tup);
 
@@ -58,15 +143,50 @@ static __always_inline void https_process(conn_tuple_t *t, void *buffer, size_t
             }
         }
     }
+    if (cur_fragment_protocol_ptr != NULL) {
+        cur_fragment_protocol = *cur_fragment_protocol_ptr;
+    }
+
+    if (cur_fragment_protocol == PROTOCOL_HTTP2) {
+        https2_process(t, buffer, len, is_write, tags);
+        return;
+    }
+    // Both ends of a local connection see its transactions through the TLS hooks, which are kept apart by the side of
+    // the process seeing them
+    http.tup.metadata |= https_local_direction(t, http.request_fragment, is_write);
     http_process(&http, NULL, tags);
 }
 
+// Returns the static tag of the OpenSSL-compatible library used by the current process, which is OpenSSL unless
+// userspace found one of its variants, as they are all hooked by the same probes.
+static __always_inline __u64 ssl_library_tag() {
+    __u32 pid = bpf_get_current_pid_tgid() >> 32;
+    __u64 *tag = bpf_map_lookup_elem(&ssl_library_by_pid, &pid);
+    if (tag == NULL) {
+        return LIBSSL;
+    }
+    return *tag;
+}
+
 static __always_inline void https_finish(conn_tuple_t *t) {
+    conn_tuple_t key = *t;
+    normalize_tuple(&key);
+    bpf_map_delete_elem(&http2_frame_stats, &key);
+    key.pid = bpf_get_current_pid_tgid() >> 32;
+    bpf_map_delete_elem(&tls_http2_local_client, &key);
+
     http_transaction_t http;
     bpf_memset(&http, 0, sizeof(http));
     bpf_memcpy(&http.tup, t, sizeof(conn_tuple_t));
     http.owned_by_src_port = http.tup.sport;
 
+    // the transaction is the one seen by the current process, if its side is known
+    __u8 *local_client = bpf_map_lookup_elem(&tls_http_local_client, &key);
+    if (local_client != NULL) {
+        http.tup.metadata |= *local_client ? CONN_HTTP_CLIENT : CONN_HTTP_SERVER;
+        bpf_map_delete_elem(&tls_http_local_client, &key);
+    }
+
     skb_info_t skb_info = {0};
     skb_info.tcp_flags |= TCPHDR_FIN;
     http_process(&http, &skb_info, NO_TAGS);
@@ -160,6 +280,18 @@ static __always_inline void map_ssl_ctx_to_sock(struct sock *skp) {
  * current task binary's inode number.
  */
 static __always_inline tls_offsets_data_t* get_offsets_data() {
+#ifdef COMPILE_PREBUILT
+    // The layout of the task_struct is unknown to the prebuilt programs,
+    // so userspace provides the binary of each hooked process instead.
+    u32 pid = bpf_get_current_pid_tgid() >> 32;
+    go_tls_offsets_data_key_t *binary_key = bpf_map_lookup_elem(&go_tls_binary_by_pid, &pid);
+    if (binary_key == NULL) {
+        log_debug("get_offsets_data: no binary known for pid %d\n", pid);
+        return NULL;
+    }
+
+    return bpf_map_lookup_elem(&offsets_data, binary_key);
+#else
     struct task_struct *t = (struct task_struct *) bpf_get_current_task();
     struct inode *inode;
     go_tls_offsets_data_key_t key;
@@ -190,6 +322,7 @@ static __always_inline tls_offsets_data_t* get_offsets_data() {
     log_debug("get_offsets_data: task binary inode number: %ld; device ID %x:%x\n", key.ino, key.device_id_major, key.device_id_minor);
 
     return bpf_map_lookup_elem(&offsets_data, &key);
+#endif
 }
 
 #endif
@@ -0,0 +1,152 @@
+#ifndef __JAVA_TLS_ERPC_H
+#define __JAVA_TLS_ERPC_H
+
+#include "bpf_helpers.h"
+#include "bpf_telemetry.h"
+#include "port_range.h"
+
+#include "protocols/http/maps.h"
+#include "protocols/http/types.h"
+#include "protocols/tls/https.h"
+#include "protocols/tls/tags-types.h"
+
+// The USM java agent hooks the TLS streams of the java processes, such as the SSLSocket ones, as well as the
+// SSLEngine objects used by the asynchronous frameworks (Netty, Vert.x, Akka HTTP), and sends their plaintext through
+// ioctl calls, which are caught here. The requests have the following packed layout:
+//
+// struct {
+//     __u64 auth_id;   // the dd.usm.authID argument of the agent, which authenticates the requests
+//     __u8  operation; // java_tls_erpc_op_t
+//     __u8  data[];
+// }
+//
+// and the data of each operation is:
+// - JAVA_TLS_SYNCHRONOUS_PAYLOAD: { conn_tuple_t connection; __u8 is_write; __u32 len; __u8 payload[len]; }
+// - JAVA_TLS_CLOSE_CONNECTION:    { conn_tuple_t connection; }
+// - JAVA_TLS_CONNECTION_BY_ENGINE: { __u32 engine_id; conn_tuple_t connection; }
+// - JAVA_TLS_ENGINE_PAYLOAD:       { __u32 engine_id; __u8 is_write; __u32 len; __u8 payload[len]; }
+// - JAVA_TLS_CLOSE_ENGINE:         { __u32 engine_id; }
+//
+// The tuple of the connections is the one of the local end of the socket, whose IPv4 addresses are in saddr_l and
+// daddr_l, and whose metadata tells IPv4 from IPv6 connections. The engine_id is the System.identityHashCode of the
+// SSLEngine.
+
+#define JAVA_TLS_ERPC_HEADER_SIZE (sizeof(__u64) + sizeof(__u8))
+#define JAVA_TLS_ENGINE_ID_SIZE sizeof(__u32)
+
+static __always_inline __u64 java_tls_auth_id() {
+    __u64 val = 0;
+    LOAD_CONSTANT("java_tls_auth_id", val);
+    return val;
+}
+
+// java_tls_read_tuple reads the tuple of a connection sent by the agent, and normalizes it the same way as the tuples of
+// the other TLS hooks
+static __always_inline bool java_tls_read_tuple(conn_tuple_t *t, void *data) {
+    if (bpf_probe_read_user(t, sizeof(conn_tuple_t), data) != 0) {
+        return false;
+    }
+    t->netns = 0;
+    t->pid = 0;
+    t->metadata |= CONN_TYPE_TCP;
+    if (!is_ephemeral_port(t->sport)) {
+        flip_tuple(t);
+    }
+    return true;
+}
+
+// java_tls_read_engine reads the identifier of a SSLEngine sent by the agent, which is scoped to the current process
+static __always_inline bool java_tls_read_engine(java_tls_engine_t *engine, void *data) {
+    bpf_memset(engine, 0, sizeof(java_tls_engine_t));
+    engine->pid = bpf_get_current_pid_tgid() >> 32;
+    return bpf_probe_read_user(&engine->engine_id, sizeof(engine->engine_id), data) == 0;
+}
+
+// java_tls_process processes the payload following the tuple or the engine of the request, of the form
+// { __u8 is_write; __u32 len; __u8 payload[len]; }
+static __always_inline void java_tls_process(conn_tuple_t *t, void *data) {
+    __u8 is_write = 0;
+    __u32 len = 0;
+    if (bpf_probe_read_user(&is_write, sizeof(is_write), data) != 0 ||
+        bpf_probe_read_user(&len, sizeof(len), data + sizeof(is_write)) != 0 || len == 0) {
+        return;
+    }
+
+    __u8 tracked = 1;
+    bpf_map_update_with_telemetry(java_tls_connections, t, &tracked, BPF_ANY);
+    https_process(t, data + sizeof(is_write) + sizeof(len), len, is_write, JAVA_TLS);
+}
+
+static __always_inline void java_tls_close_connection(conn_tuple_t *t) {
+    if (bpf_map_lookup_elem(&java_tls_connections, t) == NULL) {
+        return;
+    }
+    https_finish(t);
+    bpf_map_delete_elem(&java_tls_connections, t);
+}
+
+// java_tls_erpc handles the ioctl call of the given command and argument, and returns false if it isn't an eRPC
+// request of the USM java agent
+static __always_inline bool java_tls_erpc(__u32 cmd, void *req) {
+    if (cmd != USM_IOCTL_ID) {
+        return false;
+    }
+
+    __u64 auth_id = 0;
+    if (bpf_probe_read_user(&auth_id, sizeof(auth_id), req) != 0 || auth_id == 0 || auth_id != java_tls_auth_id()) {
+        log_debug("java_tls_erpc: unauthenticated request\n");
+        return false;
+    }
+
+    __u8 op = 0;
+    if (bpf_probe_read_user(&op, sizeof(op), req + sizeof(auth_id)) != 0) {
+        return false;
+    }
+    void *data = req + JAVA_TLS_ERPC_HEADER_SIZE;
+
+    conn_tuple_t t;
+    bpf_memset(&t, 0, sizeof(t));
+    java_tls_engine_t engine;
+    conn_tuple_t *engine_tuple = NULL;
+    switch (op) {
+    case JAVA_TLS_SYNCHRONOUS_PAYLOAD:
+        if (java_tls_read_tuple(&t, data)) {
+            java_tls_process(&t, data + sizeof(conn_tuple_t));
+        }
+        break;
+    case JAVA_TLS_CLOSE_CONNECTION:
+        if (java_tls_read_tuple(&t, data)) {
+            java_tls_close_connection(&t);
+        }
+        break;
+    case JAVA_TLS_CONNECTION_BY_ENGINE:
+        if (java_tls_read_engine(&engine, data) && java_tls_read_tuple(&t, data + JAVA_TLS_ENGINE_ID_SIZE)) {
+            bpf_map_update_with_telemetry(java_conn_tuple_by_engine, &engine, &t, BPF_ANY);
+        }
+        break;
+    case JAVA_TLS_ENGINE_PAYLOAD:
+    case JAVA_TLS_CLOSE_ENGINE:
+        if (!java_tls_read_engine(&engine, data)) {
+            break;
+        }
+        engine_tuple = bpf_map_lookup_elem(&java_conn_tuple_by_engine, &engine);
+        if (engine_tuple == NULL) {
+            log_debug("java_tls_erpc: no connection for the engine of the request\n");
+            break;
+        }
+        // copy map value to stack. required for older kernels
+        bpf_memcpy(&t, engine_tuple, sizeof(conn_tuple_t));
+        if (op == JAVA_TLS_ENGINE_PAYLOAD) {
+            java_tls_process(&t, data + JAVA_TLS_ENGINE_ID_SIZE);
+            break;
+        }
+        java_tls_close_connection(&t);
+        bpf_map_delete_elem(&java_conn_tuple_by_engine, &engine);
+        break;
+    default:
+        log_debug("java_tls_erpc: unsupported operation %d\n", op);
+    }
+    return true;
+}
+
+#endif
@@ -0,0 +1,165 @@
+#ifndef __KTLS_H
+#define __KTLS_H
+
+#include "ktypes.h"
+#ifndef COMPILE_CORE
+#include <linux/uio.h>
+#include <linux/socket.h>
+#endif
+
+#include "bpf_core_read.h"
+#include "bpf_telemetry.h"
+#include "port_range.h"
+
+#include "protocols/http/maps.h"
+#include "protocols/http/types.h"
+#include "protocols/tls/https.h"
+
+// The sockets using the kernel TLS (kTLS) hand their plaintext to the kernel, which encrypts it in tls_sw_sendmsg and
+// decrypts it in tls_sw_recvmsg. The plaintext is read from the user buffer of the msghdr given to these calls.
+
+#ifdef COMPILE_CORE
+// The kernels older than 5.14 stored the type of the iterator along with its direction
+struct iov_iter___old {
+    unsigned int type;
+};
+
+#define ITER_IOVEC___old 4
+#define ITER_DIRECTION_MASK 1
+
+// The kernel 6.0 added the iterators over a single user buffer, and the kernel 6.4 renamed iov into __iov
+struct iov_iter___ubuf {
+    void *ubuf;
+};
+
+struct iov_iter___iov {
+    const struct iovec *__iov;
+};
+
+enum iter_type___ubuf {
+    ITER_UBUF = 6,
+};
+#endif // COMPILE_CORE
+
+// ktls_user_buffer sets buf to the first user buffer of the msghdr, and returns its size. Returns 0 if the msghdr
+// doesn't describe user buffers, such as when it is used by the kernel itself.
+static __always_inline __u64 ktls_user_buffer(struct msghdr *msg, void **buf) {
+    struct iov_iter *iter = &msg->msg_iter;
+    const struct iovec *iov_ptr = NULL;
+    size_t offset = 0;
+
+#ifdef COMPILE_CORE
+    offset = BPF_CORE_READ(iter, iov_offset);
+    if (bpf_core_field_exists(iter->iter_type)) {
+        u8 iter_type = BPF_CORE_READ(iter, iter_type);
+        if (bpf_core_enum_value_exists(enum iter_type___ubuf, ITER_UBUF) && iter_type == bpf_core_enum_value(enum iter_type___ubuf, ITER_UBUF)) {
+            *buf = BPF_CORE_READ((struct iov_iter___ubuf *)iter, ubuf) + offset;
+            return BPF_CORE_READ(iter, count);
+        }
+        if (iter_type != ITER_IOVEC) {
+            return 0;
+        }
+    } else {
+        // the iterators of the kernels older than 4.20, whose ITER_IOVEC was 0, are not supported
+        unsigned int type = BPF_CORE_READ((struct iov_iter___old *)iter, type);
+        if ((type & ~ITER_DIRECTION_MASK) != ITER_IOVEC___old) {
+            return 0;
+        }
+    }
+
+    if (bpf_core_field_exists(((struct iov_iter___iov *)iter)->__iov)) {
+        iov_ptr = BPF_CORE_READ((struct iov_iter___iov *)iter, __iov);
+    } else {
+        iov_ptr = BPF_CORE_READ(iter, iov);
+    }
+#else
+    struct iov_iter iter_copy;
+    if (bpf_probe_read_kernel_with_telemetry(&iter_copy, sizeof(iter_copy), iter) < 0) {
+        return 0;
+    }
+    offset = iter_copy.iov_offset;
+
+#if LINUX_VERSION_CODE >= KERNEL_VERSION(6, 0, 0)
+    if (iter_is_ubuf(&iter_copy)) {
+        *buf = iter_copy.ubuf + offset;
+        return iter_copy.count;
+    }
+#endif
+    if (!iter_is_iovec(&iter_copy)) {
+        return 0;
+    }
+
+#if LINUX_VERSION_CODE >= KERNEL_VERSION(6, 4, 0)
+    iov_ptr = iter_iov(&iter_copy);
+#else
+    iov_ptr = iter_copy.iov;
+#endif
+#endif // COMPILE_CORE
+
+    struct iovec iov;
+    bpf_memset(&iov, 0, sizeof(iov));
+    if (iov_ptr == NULL || bpf_probe_read_kernel_with_telemetry(&iov, sizeof(iov), iov_ptr) < 0) {
+        return 0;
+    }
+    if (iov.iov_len <= offset) {
+        return 0;
+    }
+
+    *buf = iov.iov_base + offset;
+    return iov.iov_len - offset;
+}
+
+// ktls_in_ssl_call returns true if the thread is in a call of the TLS libraries hooked by the uprobes, such as OpenSSL
+// running with kTLS, whose plaintext is already processed by them
+static __always_inline bool ktls_in_ssl_call(u64 pid_tgid) {
+    return bpf_map_lookup_elem(&ssl_read_args, &pid_tgid) != NULL ||
+        bpf_map_lookup_elem(&ssl_write_args, &pid_tgid) != NULL ||
+        bpf_map_lookup_elem(&ssl_read_ex_args, &pid_tgid) != NULL ||
+        bpf_map_lookup_elem(&ssl_write_ex_args, &pid_tgid) != NULL;
+}
+
+static __always_inline void ktls_call_enter(struct sock *sk, struct msghdr *msg) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    if (ktls_in_ssl_call(pid_tgid)) {
+        return;
+    }
+
+    ktls_args_t args = {0};
+    args.sk = sk;
+    args.len = ktls_user_buffer(msg, &args.buf);
+    if (args.len == 0) {
+        return;
+    }
+    bpf_map_update_with_telemetry(ktls_args, &pid_tgid, &args, BPF_ANY);
+}
+
+// ktls_call_exit processes the plaintext of the kTLS call, of which ret bytes were sent or received
+static __always_inline void ktls_call_exit(int ret, bool is_write) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    ktls_args_t *args = bpf_map_lookup_elem(&ktls_args, &pid_tgid);
+    if (args == NULL) {
+        return;
+    }
+    if (ret <= 0) {
+        goto cleanup;
+    }
+
+    conn_tuple_t t = {0};
+    if (!read_conn_tuple(&t, (struct sock *)args->sk, pid_tgid, CONN_TYPE_TCP)) {
+        goto cleanup;
+    }
+    // see tup_from_ssl_ctx
+    t.netns = 0;
+    t.pid = 0;
+    if (!is_ephemeral_port(t.sport)) {
+        flip_tuple(&t);
+    }
+
+    __u64 len = (__u64)ret < args->len ? (__u64)ret : args->len;
+    log_debug("ktls_call_exit: pid_tgid=%llx len=%llu is_write=%d\n", pid_tgid, len, is_write);
+    https_process(&t, args->buf, len, is_write, KTLS);
+cleanup:
+    bpf_map_delete_elem(&ktls_args, &pid_tgid);
+}
+
+#endif
@@ -7,6 +7,17 @@ enum static_tags {
     LIBGNUTLS = (1<<0),
     LIBSSL = (1<<1),
     GO = (1<<2),
+    // set by user-space for the HTTP transactions carrying gRPC-Web
+    GRPC_WEB = (1<<3),
+    // variants of OpenSSL sharing its API, hooked by the same probes as OpenSSL
+    BORINGSSL = (1<<4),
+    LIBRESSL = (1<<5),
+    // set by user-space for the processes of the envoy sidecars of Istio
+    ISTIO = (1<<6),
+    // plaintext read and written through the kernel TLS sockets
+    KTLS = (1<<7),
+    // plaintext sent by the USM java agent
+    JAVA_TLS = (1<<8),
 };
 
 #endif
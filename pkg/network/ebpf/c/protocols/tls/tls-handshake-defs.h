@@ -0,0 +1,24 @@
+#ifndef __TLS_HANDSHAKE_DEFS_H
+#define __TLS_HANDSHAKE_DEFS_H
+
+// The content type of the TLS records carrying the handshake messages, and the types of the first messages of the
+// clients and of the servers.
+// Checkout https://datatracker.ietf.org/doc/html/rfc8446#section-5.1 and https://datatracker.ietf.org/doc/html/rfc8446#section-4
+#define TLS_RECORD_HANDSHAKE 0x16
+#define TLS_HANDSHAKE_CLIENT_HELLO 0x01
+#define TLS_HANDSHAKE_SERVER_HELLO 0x02
+
+// The major version of the records of SSL 3.0 up to TLS 1.3, whose records claim to be TLS 1.2 ones.
+#define TLS_RECORD_VERSION_MAJOR 0x03
+#define TLS_RECORD_VERSION_MINOR_MAX 0x04
+// The size of the header of the records, followed by the type of the handshake message.
+#define TLS_RECORD_HEADER_SIZE 5
+#define TLS_MAX_RECORD_LENGTH (1 << 14)
+
+// The size of the beginning of the segments sent to userspace, which is expected to hold the ServerHello messages
+// and the ClientHello messages of most clients, up to their last extensions.
+#define TLS_HANDSHAKE_BUFFER_SIZE 1024
+#define TLS_HANDSHAKE_BLK_SIZE 16
+#define TLS_HANDSHAKE_BATCH_SIZE 3
+
+#endif
@@ -0,0 +1,26 @@
+#ifndef __TLS_HANDSHAKE_HELPERS_H
+#define __TLS_HANDSHAKE_HELPERS_H
+
+#include "bpf_endian.h"
+
+#include "protocols/classification/common.h"
+#include "protocols/tls/tls-handshake-defs.h"
+
+// Checks if the given buffer holds the beginning of a TLS record carrying a ClientHello or a ServerHello, which are
+// the first messages sent by the clients and the servers of the TLS connections.
+static __always_inline bool is_tls_hello(const char *buf, __u32 buf_size) {
+    CHECK_PRELIMINARY_BUFFER_CONDITIONS(buf, buf_size, TLS_RECORD_HEADER_SIZE + 1);
+
+    if (buf[0] != TLS_RECORD_HANDSHAKE || buf[1] != TLS_RECORD_VERSION_MAJOR || buf[2] > TLS_RECORD_VERSION_MINOR_MAX) {
+        return false;
+    }
+
+    __u16 length = bpf_ntohs(*(__u16 *)&buf[3]);
+    if (length == 0 || length > TLS_MAX_RECORD_LENGTH) {
+        return false;
+    }
+
+    return buf[5] == TLS_HANDSHAKE_CLIENT_HELLO || buf[5] == TLS_HANDSHAKE_SERVER_HELLO;
+}
+
+#endif
@@ -0,0 +1,13 @@
+#ifndef __TLS_HANDSHAKE_MAPS_H
+#define __TLS_HANDSHAKE_MAPS_H
+
+#include "bpf_helpers.h"
+#include "map-defs.h"
+
+#include "protocols/tls/tls-handshake-types.h"
+
+/* This map is used as a scratch buffer to build the handshake segments sent to userspace, as they are too large for
+   the eBPF stack */
+BPF_PERCPU_ARRAY_MAP(tls_handshake_heap, __u32, tls_handshake_t, 1)
+
+#endif
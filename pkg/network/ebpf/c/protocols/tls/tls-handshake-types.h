@@ -0,0 +1,19 @@
+#ifndef __TLS_HANDSHAKE_TYPES_H
+#define __TLS_HANDSHAKE_TYPES_H
+
+#include "tracer.h"
+
+#include "protocols/tls/tls-handshake-defs.h"
+
+// Beginning of a TCP segment carrying a ClientHello or a ServerHello, whose fields are parsed in userspace.
+typedef struct {
+    // the tuple of the segment, the sender being the source
+    conn_tuple_t tup;
+    __u64 timestamp;
+    // the length of the TCP payload, which tells whether the segment was truncated
+    __u16 segment_length;
+    __u16 fragment_size;
+    char fragment[TLS_HANDSHAKE_BUFFER_SIZE];
+} tls_handshake_t;
+
+#endif
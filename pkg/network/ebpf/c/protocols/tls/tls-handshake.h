@@ -0,0 +1,69 @@
+#ifndef __TLS_HANDSHAKE_H
+#define __TLS_HANDSHAKE_H
+
+#include "bpf_builtins.h"
+#include "bpf_telemetry.h"
+#include "ip.h"
+
+#include "protocols/events.h"
+#include "protocols/tls/tls-handshake-defs.h"
+#include "protocols/tls/tls-handshake-maps.h"
+#include "protocols/tls/tls-handshake-types.h"
+
+USM_EVENTS_INIT(tls_handshake, tls_handshake_t, TLS_HANDSHAKE_BATCH_SIZE);
+
+// Reads the bytes of the segment between offset and end into buffer, which holds up to TLS_HANDSHAKE_BUFFER_SIZE
+// bytes, the same way http3_read_into_buffer does. Returns the number of bytes read.
+static __always_inline __u16 tls_handshake_read_into_buffer(struct __sk_buff *skb, __u32 offset, __u32 end, char *buffer) {
+    __u32 read = 0;
+#pragma unroll(TLS_HANDSHAKE_BUFFER_SIZE / TLS_HANDSHAKE_BLK_SIZE)
+    for (int i = 0; i < TLS_HANDSHAKE_BUFFER_SIZE / TLS_HANDSHAKE_BLK_SIZE; i++) {
+        if (offset + TLS_HANDSHAKE_BLK_SIZE > end) {
+            break;
+        }
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], TLS_HANDSHAKE_BLK_SIZE) < 0) {
+            return read;
+        }
+        offset += TLS_HANDSHAKE_BLK_SIZE;
+        read += TLS_HANDSHAKE_BLK_SIZE;
+    }
+    if (read == TLS_HANDSHAKE_BUFFER_SIZE) {
+        return read;
+    }
+
+#define TLS_HANDSHAKE_READ_CHUNK(size)                                                              \
+    if (offset + size <= end && read + size <= TLS_HANDSHAKE_BUFFER_SIZE) {                         \
+        if (bpf_skb_load_bytes_with_telemetry(skb, offset, &buffer[read], size) < 0) {              \
+            return read;                                                                            \
+        }                                                                                           \
+        offset += size;                                                                             \
+        read += size;                                                                               \
+    }
+
+    TLS_HANDSHAKE_READ_CHUNK(8);
+    TLS_HANDSHAKE_READ_CHUNK(4);
+    TLS_HANDSHAKE_READ_CHUNK(2);
+    TLS_HANDSHAKE_READ_CHUNK(1);
+#undef TLS_HANDSHAKE_READ_CHUNK
+
+    return read;
+}
+
+// Sends the beginning of a segment carrying a ClientHello or a ServerHello to userspace, where the server name, the
+// negotiated version and cipher suite, and the JA3 fingerprints of the connection are parsed.
+static __always_inline void tls_handshake_process(struct __sk_buff *skb, skb_info_t *skb_info, conn_tuple_t *tup) {
+    const __u32 zero = 0;
+    tls_handshake_t *tx = bpf_map_lookup_elem(&tls_handshake_heap, &zero);
+    if (tx == NULL) {
+        return;
+    }
+    bpf_memset(tx, 0, sizeof(tls_handshake_t));
+
+    tx->tup = *tup;
+    tx->timestamp = bpf_ktime_get_ns();
+    tx->segment_length = skb->len - skb_info->data_off;
+    tx->fragment_size = tls_handshake_read_into_buffer(skb, skb_info->data_off, skb->len, tx->fragment);
+    tls_handshake_batch_enqueue(tx);
+}
+
+#endif
@@ -0,0 +1,25 @@
+#ifndef __RING_BUFFER_H
+#define __RING_BUFFER_H
+
+#include "bpf_helpers.h"
+
+#include "defs.h"
+
+// ring_buffers_enabled returns whether the events are sent to user space through BPF ring buffers rather than perf
+// buffers. The maps of the events are declared as perf event arrays, and replaced by ring buffers at load time on the
+// kernels supporting them (5.8+). The verifier prunes the branch which doesn't match the type of the map.
+static __maybe_unused __always_inline bool ring_buffers_enabled() {
+#ifdef COMPILE_RUNTIME
+#ifdef FEATURE_RING_BUFFERS_ENABLED
+    return true;
+#else
+    return false;
+#endif
+#else
+    __u64 val = 0;
+    LOAD_CONSTANT("ring_buffers_enabled", val);
+    return val == ENABLED;
+#endif
+}
+
+#endif
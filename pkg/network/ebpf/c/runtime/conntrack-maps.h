@@ -14,6 +14,14 @@ BPF_LRU_MAP(conntrack, conntrack_tuple_t, conntrack_tuple_t, 1024)
 BPF_HASH_MAP(conntrack, conntrack_tuple_t, conntrack_tuple_t, 1024)
 #endif
 
+/* These maps are used to track the connections whose destination is translated at connect time by a cgroup/connect4
+ * program rather than by conntrack, as done by the socket-level load balancing of Cilium's kube-proxy replacement.
+ * sock_lb maps the tuple of such connections to the tuple they were initially connected to.
+ */
+BPF_HASH_MAP(sock_lb_connect_args, __u64, sock_lb_connect_args_t, 1024)
+BPF_LRU_MAP(sock_lb_pending, void *, sock_lb_dest_t, 1024)
+BPF_LRU_MAP(sock_lb, conntrack_tuple_t, conntrack_tuple_t, 1024)
+
 /* This map is used for conntrack telemetry in kernelspace
  * only key 0 is used
  * value is a telemetry object
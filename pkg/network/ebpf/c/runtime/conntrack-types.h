@@ -22,7 +22,22 @@ typedef struct {
 
 typedef struct {
     __u64 registers;
+    __u64 deletes;
 } conntrack_telemetry_t;
 
+// Arguments of tcp_v4_pre_connect, along with the destination the socket was initially connected to
+typedef struct {
+    void *sk;
+    void *uaddr;
+    __u32 daddr;
+    __u16 dport;
+} sock_lb_connect_args_t;
+
+// Destination of a socket before it was translated by a cgroup/connect4 program
+typedef struct {
+    __u32 daddr;
+    __u16 dport;
+} sock_lb_dest_t;
+
 
 #endif
@@ -8,6 +8,8 @@
 #include <uapi/linux/ip.h>
 #include <uapi/linux/ipv6.h>
 #include <uapi/linux/udp.h>
+#include <uapi/linux/in.h>
+#include <net/inet_sock.h>
 
 #include "defs.h"
 #include "conntrack.h"
@@ -78,6 +80,138 @@ int kprobe_ctnetlink_fill_info(struct pt_regs* ctx) {
     return 0;
 }
 
+// The NAT entries are removed once deleted from conntrack, so that the map doesn't fill up with the entries which are
+// never matched to a connection on the hosts with very large conntrack tables. A TCP entry outlives its connection for
+// the TIME_WAIT timeout, so the translation of a closed connection is still found.
+SEC("kprobe/nf_ct_delete")
+int kprobe_nf_ct_delete(struct pt_regs* ctx) {
+    struct nf_conn *ct = (struct nf_conn*)PT_REGS_PARM1(ctx);
+
+    u32 status = ct_status(ct);
+    if (!(status&IPS_CONFIRMED) || !(status&IPS_NAT_MASK)) {
+        return 0;
+    }
+
+    log_debug("kprobe/nf_ct_delete: netns: %u, status: %x\n", get_netns(&ct->ct_net), status);
+
+    conntrack_tuple_t orig = {}, reply = {};
+    if (nf_conn_to_conntrack_tuples(ct, &orig, &reply) != 0) {
+        return 0;
+    }
+
+    bpf_map_delete_elem(&conntrack, &orig);
+    bpf_map_delete_elem(&conntrack, &reply);
+    increment_telemetry_deletes_count();
+
+    return 0;
+}
+
+// The socket-level load balancing of Cilium's kube-proxy replacement translates the destination of the connections
+// to a service in a cgroup/connect4 program, run by tcp_v4_pre_connect, so they never show up in conntrack.
+// The destination passed to connect() is saved on entry, and compared to the one left by the program on return.
+static __always_inline void sock_lb_save_connect_args(struct sock *sk, struct sockaddr_in *uaddr) {
+    sock_lb_connect_args_t args = {
+        .sk = sk,
+        .uaddr = uaddr,
+    };
+    bpf_probe_read_kernel_with_telemetry(&args.daddr, sizeof(args.daddr), &uaddr->sin_addr.s_addr);
+    bpf_probe_read_kernel_with_telemetry(&args.dport, sizeof(args.dport), &uaddr->sin_port);
+
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    bpf_map_update_with_telemetry(sock_lb_connect_args, &pid_tgid, &args, BPF_ANY);
+}
+
+static __always_inline void sock_lb_check_connect_translation(int rc) {
+    u64 pid_tgid = bpf_get_current_pid_tgid();
+    sock_lb_connect_args_t *args = bpf_map_lookup_elem(&sock_lb_connect_args, &pid_tgid);
+    if (!args) {
+        return;
+    }
+    sock_lb_connect_args_t orig = *args;
+    bpf_map_delete_elem(&sock_lb_connect_args, &pid_tgid);
+
+    if (rc != 0) {
+        return;
+    }
+
+    struct sockaddr_in *uaddr = (struct sockaddr_in *)orig.uaddr;
+    __u32 daddr = 0;
+    __u16 dport = 0;
+    bpf_probe_read_kernel_with_telemetry(&daddr, sizeof(daddr), &uaddr->sin_addr.s_addr);
+    bpf_probe_read_kernel_with_telemetry(&dport, sizeof(dport), &uaddr->sin_port);
+    if (daddr == orig.daddr && dport == orig.dport) {
+        return;
+    }
+
+    // the source port of the connection is only known once tcp_connect is called
+    sock_lb_dest_t dest = { .daddr = orig.daddr, .dport = orig.dport };
+    bpf_map_update_with_telemetry(sock_lb_pending, &orig.sk, &dest, BPF_ANY);
+}
+
+static __always_inline void sock_lb_record_connection(struct sock *sk) {
+    sock_lb_dest_t *dest = bpf_map_lookup_elem(&sock_lb_pending, &sk);
+    if (!dest) {
+        return;
+    }
+
+    conntrack_tuple_t wire = {}, orig = {};
+    wire.metadata = CONN_TYPE_TCP | CONN_V4;
+    wire.netns = get_netns(&sk->__sk_common.skc_net);
+    bpf_probe_read_kernel_with_telemetry(&wire.saddr_l, sizeof(__be32), &sk->__sk_common.skc_rcv_saddr);
+    bpf_probe_read_kernel_with_telemetry(&wire.daddr_l, sizeof(__be32), &sk->__sk_common.skc_daddr);
+    bpf_probe_read_kernel_with_telemetry(&wire.sport, sizeof(wire.sport), &inet_sk(sk)->inet_sport);
+    bpf_probe_read_kernel_with_telemetry(&wire.dport, sizeof(wire.dport), &sk->__sk_common.skc_dport);
+    wire.sport = bpf_ntohs(wire.sport);
+    wire.dport = bpf_ntohs(wire.dport);
+
+    orig = wire;
+    orig.daddr_l = dest->daddr;
+    orig.dport = bpf_ntohs(dest->dport);
+    bpf_map_delete_elem(&sock_lb_pending, &sk);
+
+    log_debug("tcp_connect: socket-level load balancing\n");
+    print_translation(&orig);
+    print_translation(&wire);
+    bpf_map_update_with_telemetry(sock_lb, &wire, &orig, BPF_ANY);
+}
+
+SEC("kprobe/tcp_v4_pre_connect")
+int kprobe__tcp_v4_pre_connect(struct pt_regs* ctx) {
+    sock_lb_save_connect_args((struct sock *)PT_REGS_PARM1(ctx), (struct sockaddr_in *)PT_REGS_PARM2(ctx));
+    return 0;
+}
+
+SEC("kretprobe/tcp_v4_pre_connect")
+int kretprobe__tcp_v4_pre_connect(struct pt_regs* ctx) {
+    sock_lb_check_connect_translation((int)PT_REGS_RC(ctx));
+    return 0;
+}
+
+SEC("kprobe/tcp_connect")
+int kprobe__tcp_connect_sock_lb(struct pt_regs* ctx) {
+    sock_lb_record_connection((struct sock *)PT_REGS_PARM1(ctx));
+    return 0;
+}
+
+// The fentry/fexit variants of the programs above, used on the hosts supporting them.
+SEC("fentry/tcp_v4_pre_connect")
+int BPF_PROG(fentry__tcp_v4_pre_connect, struct sock *sk, struct sockaddr *uaddr) {
+    sock_lb_save_connect_args(sk, (struct sockaddr_in *)uaddr);
+    return 0;
+}
+
+SEC("fexit/tcp_v4_pre_connect")
+int BPF_PROG(fexit__tcp_v4_pre_connect, struct sock *sk, struct sockaddr *uaddr, int addr_len, int rc) {
+    sock_lb_check_connect_translation(rc);
+    return 0;
+}
+
+SEC("fentry/tcp_connect")
+int BPF_PROG(fentry__tcp_connect_sock_lb, struct sock *sk) {
+    sock_lb_record_connection(sk);
+    return 0;
+}
+
 // This number will be interpreted by elf-loader to set the current running kernel version
 __u32 _version SEC("version") = 0xFFFFFFFE; // NOLINT(bugprone-reserved-identifier)
 
@@ -16,8 +16,23 @@
 
 #include "protocols/classification/dispatcher-helpers.h"
 #include "protocols/http/http.h"
+#include "protocols/http2/http2.h"
+#include "protocols/grpc/grpc.h"
+#include "protocols/dns/dns-tls.h"
+#include "protocols/kafka/kafka.h"
+#include "protocols/postgres/postgres.h"
+#include "protocols/mysql/mysql.h"
+#include "protocols/redis/redis.h"
+#include "protocols/mongo/mongo.h"
+#include "protocols/amqp/amqp.h"
+#include "protocols/http3/http3.h"
+#include "protocols/tls/tls-handshake.h"
+#include "protocols/cassandra/cassandra.h"
+#include "protocols/memcached/memcached.h"
 #include "protocols/http/buffer.h"
 #include "protocols/tls/https.h"
+#include "protocols/tls/java-tls-erpc.h"
+#include "protocols/tls/ktls.h"
 #include "protocols/tls/go-tls-types.h"
 #include "protocols/tls/go-tls-goid.h"
 #include "protocols/tls/go-tls-location.h"
@@ -53,10 +68,170 @@ int socket__http_filter(struct __sk_buff *skb) {
     normalize_tuple(&http.tup);
 
     read_into_buffer_skb((char *)http.request_fragment, skb, &skb_info);
+    read_tail_into_buffer_skb((char *)http.segment_tail, skb, &skb_info);
+    http_read_headers_skb(skb, &skb_info, (char *)http.request_fragment);
+    http.segment_size = skb->len - skb_info.data_off;
     http_process(&http, &skb_info, NO_TAGS);
     return 0;
 }
 
+SEC("socket/http2_filter")
+int socket__http2_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    http2_headers_frames_t headers;
+    bpf_memset(&headers, 0, sizeof(headers));
+    http2_count_frames(skb, &skb_info, &tup, &headers);
+    grpc_process_headers(skb, &headers);
+    return 0;
+}
+
+SEC("socket/kafka_filter")
+int socket__kafka_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    kafka_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/postgres_filter")
+int socket__postgres_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    postgres_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/mysql_filter")
+int socket__mysql_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    mysql_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/redis_filter")
+int socket__redis_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    redis_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/mongo_filter")
+int socket__mongo_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    mongo_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/amqp_filter")
+int socket__amqp_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    amqp_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/http3_filter")
+int socket__http3_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    http3_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/tls_handshake_filter")
+int socket__tls_handshake_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    tls_handshake_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/cassandra_filter")
+int socket__cassandra_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    cassandra_process(skb, &skb_info, &tup);
+    return 0;
+}
+
+SEC("socket/memcached_filter")
+int socket__memcached_filter(struct __sk_buff *skb) {
+    skb_info_t skb_info;
+    conn_tuple_t tup;
+    bpf_memset(&tup, 0, sizeof(tup));
+
+    if (!read_conn_tuple_skb(skb, &skb_info, &tup)) {
+        return 0;
+    }
+
+    memcached_process(skb, &skb_info, &tup);
+    return 0;
+}
+
 SEC("kprobe/tcp_sendmsg")
 int BPF_KPROBE(kprobe__tcp_sendmsg, struct sock *sk) {
     log_debug("kprobe/tcp_sendmsg: sk=%llx\n", sk);
@@ -66,12 +241,33 @@ int BPF_KPROBE(kprobe__tcp_sendmsg, struct sock *sk) {
     return 0;
 }
 
+SEC("fentry/tcp_sendmsg")
+int BPF_PROG(fentry__tcp_sendmsg, struct sock *sk) {
+    log_debug("fentry/tcp_sendmsg: sk=%llx\n", sk);
+    // map connection tuple during SSL_do_handshake(ctx)
+    map_ssl_ctx_to_sock(sk);
+
+    return 0;
+}
+
 SEC("tracepoint/net/netif_receive_skb")
 int tracepoint__net__netif_receive_skb(struct pt_regs* ctx) {
     log_debug("tracepoint/net/netif_receive_skb\n");
     // flush batch to userspace
     // because perf events can't be sent from socket filter programs
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
     return 0;
 }
 
@@ -195,8 +391,20 @@ int uretprobe__SSL_read(struct pt_regs *ctx) {
         goto cleanup;
     }
 
-    https_process(t, args->buf, len, LIBSSL);
+    https_process(t, args->buf, len, false, ssl_library_tag());
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_read_args, &pid_tgid);
     return 0;
@@ -232,8 +440,20 @@ int uretprobe__SSL_write(struct pt_regs* ctx) {
         goto cleanup;
     }
 
-    https_process(t, args->buf, write_len, LIBSSL);
+    https_process(t, args->buf, write_len, true, ssl_library_tag());
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_write_args, &pid_tgid);
     return 0;
@@ -284,8 +504,20 @@ int uretprobe__SSL_read_ex(struct pt_regs* ctx) {
         goto cleanup;
     }
 
-    https_process(conn_tuple, args->buf, bytes_count, LIBSSL);
+    https_process(conn_tuple, args->buf, bytes_count, false, ssl_library_tag());
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_read_ex_args, &pid_tgid);
     return 0;
@@ -336,8 +568,20 @@ int uretprobe__SSL_write_ex(struct pt_regs* ctx) {
         goto cleanup;
     }
 
-    https_process(conn_tuple, args->buf, bytes_count, LIBSSL);
+    https_process(conn_tuple, args->buf, bytes_count, true, ssl_library_tag());
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_write_ex_args, &pid_tgid);
     return 0;
@@ -457,8 +701,20 @@ int uretprobe__gnutls_record_recv(struct pt_regs *ctx) {
         goto cleanup;
     }
 
-    https_process(t, args->buf, read_len, LIBGNUTLS);
+    https_process(t, args->buf, read_len, false, LIBGNUTLS);
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_read_args, &pid_tgid);
     return 0;
@@ -495,8 +751,20 @@ int uretprobe__gnutls_record_send(struct pt_regs *ctx) {
         goto cleanup;
     }
 
-    https_process(t, args->buf, write_len, LIBGNUTLS);
+    https_process(t, args->buf, write_len, true, LIBGNUTLS);
     http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
 cleanup:
     bpf_map_delete_elem(&ssl_write_args, &pid_tgid);
     return 0;
@@ -628,6 +896,84 @@ int kretprobe__do_sys_openat2(struct pt_regs *ctx) {
     return do_sys_open_helper_exit(ctx);
 }
 
+// kTLS PROBES
+
+// int tls_sw_sendmsg(struct sock *sk, struct msghdr *msg, size_t size)
+SEC("kprobe/tls_sw_sendmsg")
+int BPF_KPROBE(kprobe__tls_sw_sendmsg, struct sock *sk, struct msghdr *msg) {
+    ktls_call_enter(sk, msg);
+    return 0;
+}
+
+SEC("kretprobe/tls_sw_sendmsg")
+int BPF_KRETPROBE(kretprobe__tls_sw_sendmsg, int ret) {
+    ktls_call_exit(ret, true);
+    http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
+    return 0;
+}
+
+// int tls_sw_recvmsg(struct sock *sk, struct msghdr *msg, size_t len, int flags, int *addr_len)
+SEC("kprobe/tls_sw_recvmsg")
+int BPF_KPROBE(kprobe__tls_sw_recvmsg, struct sock *sk, struct msghdr *msg) {
+    ktls_call_enter(sk, msg);
+    return 0;
+}
+
+SEC("kretprobe/tls_sw_recvmsg")
+int BPF_KRETPROBE(kretprobe__tls_sw_recvmsg, int ret) {
+    ktls_call_exit(ret, false);
+    http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
+    return 0;
+}
+
+// JAVA TLS PROBES
+
+// int do_vfs_ioctl(struct file *filp, unsigned int fd, unsigned int cmd, unsigned long arg)
+SEC("kprobe/do_vfs_ioctl")
+int kprobe__do_vfs_ioctl(struct pt_regs *ctx) {
+    if (!java_tls_erpc((__u32)PT_REGS_PARM3(ctx), (void *)PT_REGS_PARM4(ctx))) {
+        return 0;
+    }
+    http_flush_batch(ctx);
+    kafka_flush_batch(ctx);
+    postgres_flush_batch(ctx);
+    mysql_flush_batch(ctx);
+    redis_flush_batch(ctx);
+    mongo_flush_batch(ctx);
+    amqp_flush_batch(ctx);
+    grpc_flush_batch(ctx);
+    dns_tls_flush_batch(ctx);
+    http3_flush_batch(ctx);
+    tls_handshake_flush_batch(ctx);
+    cassandra_flush_batch(ctx);
+    memcached_flush_batch(ctx);
+    return 0;
+}
+
 // GO TLS PROBES
 
 // func (c *Conn) Write(b []byte) (int, error)
@@ -728,7 +1074,7 @@ int uprobe__crypto_tls_Conn_Write__return(struct pt_regs *ctx) {
     }
 
     log_debug("[go-tls-write] processing %s\n", call_data_ptr->b_data);
-    https_process(t, (void*) call_data_ptr->b_data, bytes_written, GO);
+    https_process(t, (void*) call_data_ptr->b_data, bytes_written, true, GO);
 
     bpf_map_delete_elem(&go_tls_write_args, &call_key);
     return 0;
@@ -818,7 +1164,7 @@ int uprobe__crypto_tls_Conn_Read__return(struct pt_regs *ctx) {
     }
 
     log_debug("[go-tls-read] processing %s\n", call_data_ptr->b_data);
-    https_process(t, (void*) call_data_ptr->b_data, bytes_read, GO);
+    https_process(t, (void*) call_data_ptr->b_data, bytes_read, false, GO);
 
     bpf_map_delete_elem(&go_tls_read_args, &call_key);
     return 0;
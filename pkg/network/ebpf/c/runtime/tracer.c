@@ -49,11 +49,11 @@
 // by using tail call.
 SEC("socket/classifier_entry")
 int socket__classifier_entry(struct __sk_buff *skb) {
-    bpf_tail_call_compat(skb, &classification_progs, CLASSIFICATION_PROG);
+    bpf_tail_call_compat(skb, &classification_progs, CLASSIFICATION_DISPATCHER_PROG);
     return 0;
 }
 
-// The entrypoint for all packets.
+// The entrypoint for all packets, dispatching the classification to the programs below.
 SEC("socket/classifier")
 int socket__classifier(struct __sk_buff *skb) {
     #if LINUX_VERSION_CODE >= KERNEL_VERSION(4, 6, 0)
@@ -62,6 +62,22 @@ int socket__classifier(struct __sk_buff *skb) {
     return 0;
 }
 
+SEC("socket/classifier_queues")
+int socket__classifier_queues(struct __sk_buff *skb) {
+    #if LINUX_VERSION_CODE >= KERNEL_VERSION(4, 6, 0)
+    protocol_classifier_queues_entrypoint(skb);
+    #endif
+    return 0;
+}
+
+SEC("socket/classifier_dbs")
+int socket__classifier_dbs(struct __sk_buff *skb) {
+    #if LINUX_VERSION_CODE >= KERNEL_VERSION(4, 6, 0)
+    protocol_classifier_dbs_entrypoint(skb);
+    #endif
+    return 0;
+}
+
 SEC("kprobe/tcp_sendmsg")
 int kprobe__tcp_sendmsg(struct pt_regs *ctx) {
     u64 pid_tgid = bpf_get_current_pid_tgid();
@@ -131,6 +147,10 @@ int kprobe__tcp_close(struct pt_regs *ctx) {
     }
     log_debug("kprobe/tcp_close: netns: %u, sport: %u, dport: %u\n", t.netns, t.sport, t.dport);
 
+#ifdef FEATURE_TCP_CONGESTION_ENABLED
+    handle_tcp_congestion(&t, sk);
+#endif
+
     cleanup_conn(&t, sk);
     return 0;
 }
@@ -428,13 +448,18 @@ int kprobe__tcp_retransmit_skb(struct pt_regs *ctx) {
 SEC("kprobe/tcp_set_state")
 int kprobe__tcp_set_state(struct pt_regs *ctx) {
     u8 state = (u8)PT_REGS_PARM2(ctx);
+    struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+
+    if (state == TCP_CLOSE) {
+        handle_tcp_close_state(sk);
+        return 0;
+    }
 
     // For now we're tracking only TCP_ESTABLISHED
     if (state != TCP_ESTABLISHED) {
         return 0;
     }
 
-    struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
     u64 pid_tgid = bpf_get_current_pid_tgid();
     conn_tuple_t t = {};
     if (!read_conn_tuple(&t, sk, pid_tgid, CONN_TYPE_TCP)) {
@@ -827,6 +852,45 @@ int tracepoint__net__net_dev_queue(struct net_dev_queue_ctx* ctx) {
     return 0;
 }
 
+// Represents the parameters being passed to the tracepoint skb/kfree_skb
+struct kfree_skb_ctx {
+    u64 unused;
+    struct sk_buff* skb;
+    void* location;
+#if LINUX_VERSION_CODE >= KERNEL_VERSION(6, 11, 0)
+    struct sock* rx_sk;
+#endif
+    unsigned short protocol;
+#if LINUX_VERSION_CODE >= KERNEL_VERSION(5, 17, 0)
+    enum skb_drop_reason reason;
+#endif
+};
+
+SEC("tracepoint/skb/kfree_skb")
+int tracepoint__skb__kfree_skb(struct kfree_skb_ctx* ctx) {
+    if (ctx->protocol != ETH_P_IP && ctx->protocol != ETH_P_IPV6) {
+        return 0;
+    }
+
+    struct sk_buff* skb = ctx->skb;
+    if (!skb) {
+        return 0;
+    }
+    // the packets dropped before being associated to a socket can't be attributed to a connection
+    struct sock* sk;
+    bpf_probe_read(&sk, sizeof(struct sock*), &skb->sk);
+    if (!sk) {
+        return 0;
+    }
+
+    __u32 reason = 0;
+#if LINUX_VERSION_CODE >= KERNEL_VERSION(5, 17, 0)
+    reason = ctx->reason;
+#endif
+    handle_tcp_drop(sk, reason);
+    return 0;
+}
+
 //endregion
 
 // This number will be interpreted by elf-loader to set the current running kernel version
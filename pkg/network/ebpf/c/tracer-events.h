@@ -11,11 +11,92 @@
 #include "cookie.h"
 #include "protocols/classification/tracer-maps.h"
 #include "ip.h"
+#include "ipv6.h"
+#include "ring-buffer.h"
 
 static __always_inline int get_proto(conn_tuple_t *t) {
     return (t->metadata & CONN_TYPE_TCP) ? CONN_TYPE_TCP : CONN_TYPE_UDP;
 }
 
+// is_family_enabled returns whether the connections of the family of the tuple are collected
+static __always_inline bool is_family_enabled(conn_tuple_t *t) {
+    return (t->metadata & CONN_V6) || is_ipv4_enabled();
+}
+
+static __always_inline bool is_conn_filtering_enabled() {
+#ifdef COMPILE_RUNTIME
+#ifdef FEATURE_CONN_FILTERS_ENABLED
+    return true;
+#else
+    return false;
+#endif
+#else
+    __u64 val = 0;
+    LOAD_CONSTANT("conn_filters_enabled", val);
+    return val == ENABLED;
+#endif
+}
+
+// is_conn_side_excluded returns whether the address and port of the given side of the connection match one of the
+// filters of conn_filters. Only the most specific address range is looked up, user space merging the ports of the
+// ranges it contains into it.
+static __always_inline bool is_conn_side_excluded(conn_tuple_t *t, __u8 side) {
+    conn_filter_key_t key = {};
+    key.prefixlen = CONN_FILTER_MAX_PREFIXLEN;
+    key.side = side;
+    key.family = t->metadata & CONN_V6;
+
+    __u64 addr_h = side == CONN_FILTER_SOURCE ? t->saddr_h : t->daddr_h;
+    __u64 addr_l = side == CONN_FILTER_SOURCE ? t->saddr_l : t->daddr_l;
+    if (t->metadata & CONN_V6) {
+        bpf_memcpy(&key.addr[0], &addr_h, sizeof(addr_h));
+        bpf_memcpy(&key.addr[8], &addr_l, sizeof(addr_l));
+    } else {
+        __u32 addr = (__u32)addr_l;
+        bpf_memcpy(&key.addr[0], &addr, sizeof(addr));
+    }
+
+    conn_filter_t *filter = bpf_map_lookup_elem(&conn_filters, &key);
+    if (filter == NULL) {
+        return false;
+    }
+
+    __u8 type = get_proto(t);
+    if ((type == CONN_TYPE_TCP && filter->all_tcp_ports) || (type == CONN_TYPE_UDP && filter->all_udp_ports)) {
+        return true;
+    }
+    conn_filter_port_t port = {};
+    port.id = filter->id;
+    port.port = side == CONN_FILTER_SOURCE ? t->sport : t->dport;
+    port.type = type;
+    return bpf_map_lookup_elem(&conn_filter_ports, &port) != NULL;
+}
+
+// is_conn_excluded returns whether the connection matches the excluded source or destination connections, in which
+// case it is neither tracked nor sent to user space
+static __always_inline bool is_conn_excluded(conn_tuple_t *t) {
+    if (!is_conn_filtering_enabled()) {
+        return false;
+    }
+    return is_conn_side_excluded(t, CONN_FILTER_SOURCE) || is_conn_side_excluded(t, CONN_FILTER_DEST);
+}
+
+// The stats of the IPv4 and IPv6 connections are stored in distinct maps, see conn_stats_v6
+static __always_inline conn_stats_ts_t *lookup_conn_stats(conn_tuple_t *t) {
+    if (t->metadata & CONN_V6) {
+        return bpf_map_lookup_elem(&conn_stats_v6, t);
+    }
+    return bpf_map_lookup_elem(&conn_stats, t);
+}
+
+static __always_inline void delete_conn_stats(conn_tuple_t *t) {
+    if (t->metadata & CONN_V6) {
+        bpf_map_delete_elem(&conn_stats_v6, t);
+        return;
+    }
+    bpf_map_delete_elem(&conn_stats, t);
+}
+
 static __always_inline void clean_protocol_classification(conn_tuple_t *tup) {
     conn_tuple_t conn_tuple = *tup;
     conn_tuple.pid = 0;
@@ -36,8 +117,35 @@ static __always_inline void clean_protocol_classification(conn_tuple_t *tup) {
     bpf_map_delete_elem(&conn_tuple_to_socket_skb_conn_tuple, &conn_tuple);
 }
 
+// Moves the stats of a closed UDP flow carrying QUIC into its TCP stats, which are otherwise unused for the UDP
+// connections, and counts its handshake as failed if the server never answered.
+static __always_inline void flush_quic_stats(conn_t *conn) {
+    conn_tuple_t quic_tup = conn->tup;
+    quic_tup.pid = 0;
+    quic_tup.netns = 0;
+    quic_stats_t *stats = bpf_map_lookup_elem(&quic_stats, &quic_tup);
+    if (stats == NULL) {
+        flip_tuple(&quic_tup);
+        stats = bpf_map_lookup_elem(&quic_stats, &quic_tup);
+    }
+    if (stats == NULL) {
+        return;
+    }
+
+    conn->tcp_stats.retransmits = stats->retransmits;
+    conn->tcp_stats.rtt = stats->rtt;
+    conn->tcp_stats.rtt_var = stats->rtt_var;
+    if (!stats->handshake_done) {
+        increment_telemetry_count(quic_failed_handshakes);
+    }
+    bpf_map_delete_elem(&quic_stats, &quic_tup);
+}
+
 static __always_inline void cleanup_conn(conn_tuple_t *tup, struct sock *sk) {
     clean_protocol_classification(tup);
+    if (!is_family_enabled(tup)) {
+        return;
+    }
 
     u32 cpu = bpf_get_smp_processor_id();
 
@@ -55,12 +163,19 @@ static __always_inline void cleanup_conn(conn_tuple_t *tup, struct sock *sk) {
             conn.tcp_stats = *tst;
             bpf_map_delete_elem(&tcp_stats, &(conn.tup));
         }
+        bpf_map_delete_elem(&tcp_drop_reasons, &(conn.tup));
         conn.tup.pid = tup->pid;
 
         conn.tcp_stats.state_transitions |= (1 << TCP_CLOSE);
+    } else {
+        flush_quic_stats(&conn);
     }
 
-    cst = bpf_map_lookup_elem(&conn_stats, &(conn.tup));
+    if (is_conn_excluded(&conn.tup)) {
+        return;
+    }
+
+    cst = lookup_conn_stats(&(conn.tup));
     if (!cst && is_udp) {
         increment_telemetry_count(udp_dropped_conns);
         return; // nothing to report
@@ -68,7 +183,7 @@ static __always_inline void cleanup_conn(conn_tuple_t *tup, struct sock *sk) {
 
     if (cst) {
         conn.conn_stats = *cst;
-        bpf_map_delete_elem(&conn_stats, &(conn.tup));
+        delete_conn_stats(&(conn.tup));
     } else {
         // we don't have any stats for the connection,
         // so cookie is not set, set it here
@@ -128,11 +243,17 @@ static __always_inline void flush_conn_close_if_full(void *ctx) {
         // since you can't directly write a map entry to the perf buffer.
         batch_t batch_copy = {};
         bpf_memcpy(&batch_copy, batch_ptr, sizeof(batch_copy));
+        // the ring buffer being shared by the CPUs, the batch tells which one it comes from
+        batch_copy.cpu = cpu;
         batch_ptr->len = 0;
         batch_ptr->id++;
 
         // we cannot use the telemetry macro here because of stack size constraints
-        bpf_perf_event_output(ctx, &conn_close_event, cpu, &batch_copy, sizeof(batch_copy));
+        if (ring_buffers_enabled()) {
+            bpf_ringbuf_output(&conn_close_event, &batch_copy, sizeof(batch_copy), 0);
+        } else {
+            bpf_perf_event_output(ctx, &conn_close_event, cpu, &batch_copy, sizeof(batch_copy));
+        }
     }
 }
 
@@ -10,11 +10,27 @@
  */
 BPF_HASH_MAP(conn_stats, conn_tuple_t, conn_stats_ts_t, 0)
 
+/* Same as conn_stats, for the IPv6 connections. The connections of both families are kept apart, so that the churn
+ * of one family can't fill the map of the other one, and that both maps can be sized independently.
+ */
+BPF_HASH_MAP(conn_stats_v6, conn_tuple_t, conn_stats_ts_t, 0)
+
 /* This is a key/value store with the keys being a conn_tuple_t (but without the PID being used)
  * and the values being a tcp_stats_t *.
  */
 BPF_HASH_MAP(tcp_stats, conn_tuple_t, tcp_stats_t, 0)
 
+/* This is a key/value store with the keys being the conn_tuple_t of the first Initial packet of the QUIC flows, without
+ * the PID and the network namespace, and the values being their quic_stats_t.
+ */
+BPF_HASH_MAP(quic_stats, conn_tuple_t, quic_stats_t, 0)
+
+/* This map counts the packets of the TCP connections dropped by the kernel, per drop reason. The keys are the
+ * conn_tuple_t of the connections without the PID, whose entries are deleted when they are closed.
+ * It is an LRU map so the entries of the connections never seen closing eventually get evicted.
+ */
+BPF_LRU_MAP(tcp_drop_reasons, conn_tuple_t, tcp_drops_t, 0)
+
 /* Will hold the PIDs initiating TCP connections */
 BPF_HASH_MAP(tcp_ongoing_connect_pid, struct sock *, __u64, 1024)
 
@@ -88,15 +104,15 @@ BPF_ARRAY_MAP(telemetry, telemetry_t, 1)
 // * Value is (struct sock*)
 BPF_HASH_MAP(do_sendfile_args, __u64, struct sock *, 1024)
 
+// Holds the address ranges of the excluded source and destination connections, which are neither tracked nor sent to
+// user space. The size of the maps is set from user space, and they are left empty unless the filters are enabled.
+BPF_LPM_TRIE_MAP(conn_filters, conn_filter_key_t, conn_filter_t, 1)
+
+// Holds the ports excluded by the filters of conn_filters which don't exclude all of them
+BPF_HASH_MAP(conn_filter_ports, conn_filter_port_t, __u8, 1)
+
 // Used to store ip(6)_make_skb args to be used in the
 // corresponding kretprobes
 BPF_HASH_MAP(ip_make_skb_args, __u64, ip_make_skb_args_t, 1024)
 
-// This entry point is needed to bypass a memory limit on socket filters.
-// There is a limitation on number of instructions can be attached to a socket filter,
-// as we classify more protocols, we reached that limit, thus we workaround it
-// by using tail call.
-#define CLASSIFICATION_PROG 0
-BPF_PROG_ARRAY(classification_progs, 1)
-
 #endif
@@ -7,6 +7,7 @@
 #include "tracer.h"
 #include "tracer-maps.h"
 #include "tracer-telemetry.h"
+#include "tracer-events.h"
 #include "cookie.h"
 #include "sock.h"
 
@@ -16,13 +17,42 @@ static __always_inline __u64 offset_rtt_var();
 #endif
 
 static __always_inline conn_stats_ts_t *get_conn_stats(conn_tuple_t *t, struct sock *sk) {
+    if (!is_family_enabled(t) || is_conn_excluded(t)) {
+        return NULL;
+    }
+
+    __u64 sk_cookie = get_socket_cookie(sk);
+    __u64 flags = BPF_NOEXIST;
+    conn_stats_ts_t *val = lookup_conn_stats(t);
+    if (val != NULL) {
+        if (!(t->metadata & CONN_TYPE_TCP) || val->sk_cookie == 0 || sk_cookie == 0 || val->sk_cookie == sk_cookie) {
+            // the stats are only told apart when the cookies of both sockets are known, see get_socket_cookie
+            if (val->sk_cookie == 0) {
+                val->sk_cookie = sk_cookie;
+            }
+            return val;
+        }
+        // The tuple is reused by another socket, which happens with the TIME_WAIT reuse, or when the stats of the
+        // previous socket were created again after it was closed. The stats left over by the previous socket are
+        // replaced rather than merged with the ones of the new socket, and get a new cookie so that userspace doesn't
+        // merge them either. The previous connection isn't flushed as closed, since the TCP stats and the protocol of
+        // the tuple now belong to the new socket.
+        increment_telemetry_count(tcp_reused_tuples);
+        flags = BPF_ANY;
+    }
+
     // initialize-if-no-exist the connection stat, and load it
     conn_stats_ts_t empty = {};
     bpf_memset(&empty, 0, sizeof(conn_stats_ts_t));
     empty.cookie = get_sk_cookie(sk);
+    empty.sk_cookie = sk_cookie;
     empty.protocol = PROTOCOL_UNKNOWN;
-    bpf_map_update_with_telemetry(conn_stats, t, &empty, BPF_NOEXIST);
-    return bpf_map_lookup_elem(&conn_stats, t);
+    if (t->metadata & CONN_V6) {
+        bpf_map_update_with_telemetry(conn_stats_v6, t, &empty, flags);
+    } else {
+        bpf_map_update_with_telemetry(conn_stats, t, &empty, flags);
+    }
+    return lookup_conn_stats(t);
 }
 
 static __always_inline void update_conn_state(conn_tuple_t *t, conn_stats_ts_t *stats, size_t sent_bytes, size_t recv_bytes) {
@@ -141,6 +171,10 @@ static __always_inline void update_conn_stats(conn_tuple_t *t, size_t sent_bytes
 }
 
 static __always_inline void update_tcp_stats(conn_tuple_t *t, tcp_stats_t stats) {
+    if (!is_family_enabled(t) || is_conn_excluded(t)) {
+        return;
+    }
+
     // query stats without the PID from the tuple
     __u32 pid = t->pid;
     t->pid = 0;
@@ -169,8 +203,44 @@ static __always_inline void update_tcp_stats(conn_tuple_t *t, tcp_stats_t stats)
     if (stats.state_transitions > 0) {
         val->state_transitions |= stats.state_transitions;
     }
+
+    if (stats.queue_samples > 0) {
+        if (stats.rcv_queue_max > val->rcv_queue_max) {
+            val->rcv_queue_max = stats.rcv_queue_max;
+        }
+        if (stats.snd_queue_max > val->snd_queue_max) {
+            val->snd_queue_max = stats.snd_queue_max;
+        }
+        __sync_fetch_and_add(&val->rcv_queue_sum, stats.rcv_queue_sum);
+        __sync_fetch_and_add(&val->snd_queue_sum, stats.snd_queue_sum);
+        __sync_fetch_and_add(&val->queue_samples, stats.queue_samples);
+    }
 }
 
+#if defined(COMPILE_RUNTIME) && defined(FEATURE_TCP_CONGESTION_ENABLED)
+// handle_tcp_congestion samples the congestion control state of a TCP socket, so that the throughput of the
+// connection can be correlated with its congestion behavior
+static __always_inline void handle_tcp_congestion(conn_tuple_t *t, struct sock *sk) {
+    // query stats without the PID from the tuple
+    __u32 pid = t->pid;
+    t->pid = 0;
+    tcp_stats_t *val = bpf_map_lookup_elem(&tcp_stats, t);
+    t->pid = pid;
+    if (val == NULL) {
+        return;
+    }
+
+    BPF_CORE_READ_INTO(&val->snd_cwnd, tcp_sk(sk), snd_cwnd);
+    BPF_CORE_READ_INTO(&val->snd_ssthresh, tcp_sk(sk), snd_ssthresh);
+
+    const struct tcp_congestion_ops *ca_ops = NULL;
+    BPF_CORE_READ_INTO(&ca_ops, inet_csk(sk), icsk_ca_ops);
+    if (ca_ops != NULL) {
+        bpf_probe_read_kernel_with_telemetry(val->ca_name, sizeof(val->ca_name), (void *)ca_ops->name);
+    }
+}
+#endif
+
 static __always_inline int handle_message(conn_tuple_t *t, size_t sent_bytes, size_t recv_bytes, conn_direction_t dir,
     __u32 packets_out, __u32 packets_in, packet_count_increment_t segs_type, struct sock *sk) {
     u64 ts = bpf_ktime_get_ns();
@@ -178,6 +248,22 @@ static __always_inline int handle_message(conn_tuple_t *t, size_t sent_bytes, si
     return 0;
 }
 
+// handle_tcp_close_state is called when a TCP socket moves to the TCP_CLOSE state. A socket which is still connecting
+// at that point failed to connect: it was reset, or the kernel received an ICMP or ICMPv6 error for it, such as a
+// destination unreachable, and closed it right away. The sockets closed by the user while connecting were already
+// removed from tcp_ongoing_connect_pid by tcp_close.
+static __always_inline void handle_tcp_close_state(struct sock *sk) {
+    if (bpf_map_delete_elem(&tcp_ongoing_connect_pid, &sk) != 0) {
+        return;
+    }
+    increment_telemetry_count(tcp_failed_connect);
+
+    conn_tuple_t t = {};
+    if (read_conn_tuple(&t, sk, 0, CONN_TYPE_TCP) && (t.metadata & CONN_V6)) {
+        increment_telemetry_count(tcp_failed_connect_v6);
+    }
+}
+
 static __always_inline int handle_retransmit(struct sock *sk, int segs) {
     conn_tuple_t t = {};
     u64 zero = 0;
@@ -192,6 +278,42 @@ static __always_inline int handle_retransmit(struct sock *sk, int segs) {
     return 0;
 }
 
+// handle_tcp_drop records a packet of a TCP connection dropped by the kernel. Only the drops of the connections
+// already tracked are recorded, so the drops of unrelated sockets don't create any entry.
+static __always_inline void handle_tcp_drop(struct sock *sk, __u32 reason) {
+    conn_tuple_t t = {};
+    if (!read_conn_tuple(&t, sk, 0, CONN_TYPE_TCP)) {
+        return;
+    }
+
+    tcp_stats_t *val = bpf_map_lookup_elem(&tcp_stats, &t);
+    if (val == NULL) {
+        return;
+    }
+    __sync_fetch_and_add(&val->drops, 1);
+
+    tcp_drops_t *drops = bpf_map_lookup_elem(&tcp_drop_reasons, &t);
+    if (drops == NULL) {
+        tcp_drops_t empty;
+        bpf_memset(&empty, 0, sizeof(tcp_drops_t));
+        empty.reasons[0] = reason;
+        empty.counts[0] = 1;
+        bpf_map_update_with_telemetry(tcp_drop_reasons, &t, &empty, BPF_NOEXIST);
+        return;
+    }
+
+#pragma unroll
+    for (int i = 0; i < TCP_DROP_REASONS_MAX; i++) {
+        if (drops->counts[i] == 0) {
+            drops->reasons[i] = reason;
+        } else if (drops->reasons[i] != reason) {
+            continue;
+        }
+        __sync_fetch_and_add(&drops->counts[i], 1);
+        return;
+    }
+}
+
 static __always_inline void handle_tcp_stats(conn_tuple_t* t, struct sock* sk, u8 state) {
     u32 rtt = 0, rtt_var = 0;
 #ifdef COMPILE_PREBUILT
@@ -206,6 +328,25 @@ static __always_inline void handle_tcp_stats(conn_tuple_t* t, struct sock* sk, u
     if (state > 0) {
         stats.state_transitions = (1 << state);
     }
+
+#if defined(COMPILE_RUNTIME) && defined(FEATURE_TCP_QUEUE_LENGTH_ENABLED)
+    // The queue lengths are computed the same way as the ones reported by inet_diag: the receive queue holds the
+    // data not yet read by the application, and the send queue the data not yet acknowledged by the peer
+    u32 rcv_nxt = 0, copied_seq = 0, write_seq = 0, snd_una = 0;
+    BPF_CORE_READ_INTO(&rcv_nxt, tcp_sk(sk), rcv_nxt);
+    BPF_CORE_READ_INTO(&copied_seq, tcp_sk(sk), copied_seq);
+    BPF_CORE_READ_INTO(&write_seq, tcp_sk(sk), write_seq);
+    BPF_CORE_READ_INTO(&snd_una, tcp_sk(sk), snd_una);
+
+    __s32 rcv_queue = (__s32)(rcv_nxt - copied_seq);
+    __s32 snd_queue = (__s32)(write_seq - snd_una);
+    stats.rcv_queue_max = rcv_queue > 0 ? rcv_queue : 0;
+    stats.snd_queue_max = snd_queue > 0 ? snd_queue : 0;
+    stats.rcv_queue_sum = stats.rcv_queue_max;
+    stats.snd_queue_sum = stats.snd_queue_max;
+    stats.queue_samples = 1;
+#endif
+
     update_tcp_stats(t, stats);
 }
 
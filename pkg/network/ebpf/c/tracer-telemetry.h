@@ -20,6 +20,9 @@ enum telemetry_counter
     udp_send_processed,
     udp_send_missed,
     udp_dropped_conns,
+    tcp_reused_tuples,
+    tcp_failed_connect_v6,
+    quic_failed_handshakes,
 };
 
 static __always_inline void increment_telemetry_count(enum telemetry_counter counter_name) {
@@ -49,6 +52,15 @@ static __always_inline void increment_telemetry_count(enum telemetry_counter cou
     case udp_dropped_conns:
         __sync_fetch_and_add(&val->udp_dropped_conns, 1);
         break;
+    case tcp_reused_tuples:
+        __sync_fetch_and_add(&val->tcp_reused_tuples, 1);
+        break;
+    case tcp_failed_connect_v6:
+        __sync_fetch_and_add(&val->tcp_failed_connect_v6, 1);
+        break;
+    case quic_failed_handshakes:
+        __sync_fetch_and_add(&val->quic_failed_handshakes, 1);
+        break;
     }
 }
 
@@ -39,6 +39,11 @@ typedef struct {
     // This is not the same as a TCP cookie or
     // the cookie in struct sock in the kernel
     __u32 cookie;
+    // identifies the socket the stats belong to, so that
+    // a TCP connection reusing the tuple of another one
+    // (e.g. TIME_WAIT reuse) gets its own stats, see
+    // get_socket_cookie
+    __u64 sk_cookie;
     __u64 sent_packets;
     __u64 recv_packets;
     __u8 direction;
@@ -64,6 +69,11 @@ typedef enum
     // Connection family
     CONN_V4 = 0 << 1,
     CONN_V6 = 1 << 1,
+
+    // Side of the local process in the HTTP transactions of a connection seen through the TLS hooks, which tells apart
+    // the transactions seen by both ends of a local connection
+    CONN_HTTP_CLIENT = 1 << 3,
+    CONN_HTTP_SERVER = 1 << 4,
 } metadata_mask_t;
 
 typedef struct {
@@ -86,11 +96,56 @@ typedef struct {
     __u32 retransmits;
     __u32 rtt;
     __u32 rtt_var;
+    // Number of packets of the connection dropped by the kernel, see tracepoint skb/kfree_skb
+    __u32 drops;
+    // Send and receive queue lengths of the socket in bytes, sampled on the TCP events of the connection.
+    // Only sampled by the runtime compiled tracer, see handle_tcp_stats
+    __u32 rcv_queue_max;
+    __u32 snd_queue_max;
+    __u64 rcv_queue_sum;
+    __u64 snd_queue_sum;
+    __u32 queue_samples;
+    // Congestion window (in segments), slow start threshold and name of the congestion control algorithm (eg. cubic
+    // or bbr) of the socket, sampled when the connection is closed.
+    // Only sampled by the runtime compiled tracer, see handle_tcp_congestion
+    __u32 snd_cwnd;
+    __u32 snd_ssthresh;
+    char ca_name[16];
 
     // Bit mask containing all TCP state transitions tracked by our tracer
     __u16 state_transitions;
 } tcp_stats_t;
 
+// Stats of the UDP flows carrying QUIC, whose packets are encrypted. The RTT is measured from the handshake, and from
+// the spin bit of the short header packets. The loss is estimated from the Initial packets the client retransmits
+// while the server didn't answer. Checkout https://datatracker.ietf.org/doc/html/rfc9312#section-3.8
+typedef struct {
+    // time of the first and of the latest Initial packets of the client
+    __u64 initial_ts;
+    __u64 last_initial_ts;
+    // time of the latest spin bit edge of the packets of the client (QUIC_CLIENT) and of the server (QUIC_SERVER)
+    __u64 spin_edge_ts[2];
+    __u32 retransmits;
+    // smoothed RTT and its variation, in microseconds
+    __u32 rtt;
+    __u32 rtt_var;
+    // latest spin bit of the packets of each direction, QUIC_SPIN_UNSET until one is seen
+    __u8 spin[2];
+    // set once the server answered the Initial packets of the client
+    __u8 handshake_done;
+} quic_stats_t;
+
+#define TCP_DROP_REASONS_MAX 4
+
+// Packet drops of a connection (without the PID) per drop reason, for the first TCP_DROP_REASONS_MAX reasons of its
+// drops. The drops of the other reasons are only counted by tcp_stats_t.
+// The drop reason is the value of `enum skb_drop_reason` on kernels >= 5.17, 0 otherwise. A slot is free as long as
+// its count is 0.
+typedef struct {
+    __u32 reasons[TCP_DROP_REASONS_MAX];
+    __u32 counts[TCP_DROP_REASONS_MAX];
+} tcp_drops_t;
+
 // Full data for a tcp connection
 typedef struct {
     conn_tuple_t tup;
@@ -130,6 +185,7 @@ typedef struct {
     conn_t c2;
     conn_t c3;
     __u16 len;
+    __u16 cpu;
     __u64 id;
 } batch_t;
 
@@ -142,6 +198,9 @@ typedef struct {
     __u64 udp_sends_processed;
     __u64 udp_sends_missed;
     __u64 udp_dropped_conns;
+    __u64 tcp_reused_tuples;
+    __u64 tcp_failed_connect_v6;
+    __u64 quic_failed_handshakes;
 } telemetry_t;
 
 typedef struct {
@@ -164,6 +223,41 @@ typedef struct {
     __u32 fd;
 } pid_fd_t;
 
+// Sides of the connections matched by the filters, see conn_filters
+#define CONN_FILTER_SOURCE 0
+#define CONN_FILTER_DEST 1
+
+// The side, the family and the padding are always part of the prefix of the filters, so that the address ranges of
+// the sides and families don't overlap
+#define CONN_FILTER_HEADER_PREFIXLEN 32
+#define CONN_FILTER_MAX_PREFIXLEN (CONN_FILTER_HEADER_PREFIXLEN + 128)
+
+typedef struct {
+    __u32 prefixlen;
+    __u8 side;
+    // CONN_V4 or CONN_V6
+    __u8 family;
+    __u16 pad;
+    // in network byte order, the IPv4 addresses being held by the first 4 bytes
+    __u8 addr[16];
+} conn_filter_key_t;
+
+typedef struct {
+    // identifies the ports of the filter in conn_filter_ports
+    __u32 id;
+    __u8 all_tcp_ports;
+    __u8 all_udp_ports;
+    __u16 pad;
+} conn_filter_t;
+
+typedef struct {
+    __u32 id;
+    __u16 port;
+    // CONN_TYPE_TCP or CONN_TYPE_UDP
+    __u8 type;
+    __u8 pad;
+} conn_filter_port_t;
+
 typedef struct {
     struct sock *sk;
     size_t len;
@@ -17,4 +17,5 @@ type ConntrackTuple struct {
 
 type ConntrackTelemetry struct {
 	Registers uint64
+	Deletes   uint64
 }
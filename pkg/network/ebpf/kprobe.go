@@ -81,6 +81,42 @@ func (cs ConnStats) IsAssured() bool {
 	return cs.Flags&uint32(Assured) != 0
 }
 
+// tcpInfiniteSsthresh is the slow start threshold of the TCP connections which never left slow start
+const tcpInfiniteSsthresh = 0x7fffffff
+
+// CongestionAlgorithm returns the name of the congestion control algorithm of the connection, or an empty string if
+// it was not sampled. The names of the most common algorithms are returned without allocating.
+func (s TCPStats) CongestionAlgorithm() string {
+	var name [len(s.Ca_name)]byte
+	n := 0
+	for ; n < len(s.Ca_name) && s.Ca_name[n] != 0; n++ {
+		name[n] = byte(s.Ca_name[n])
+	}
+
+	switch string(name[:n]) {
+	case "":
+		return ""
+	case "cubic":
+		return "cubic"
+	case "bbr":
+		return "bbr"
+	case "reno":
+		return "reno"
+	case "dctcp":
+		return "dctcp"
+	default:
+		return string(name[:n])
+	}
+}
+
+// SndSsthresh returns the slow start threshold of the connection, or 0 if the connection is still in slow start
+func (s TCPStats) SndSsthresh() uint32 {
+	if s.Snd_ssthresh >= tcpInfiniteSsthresh {
+		return 0
+	}
+	return s.Snd_ssthresh
+}
+
 // ToBatch converts a byte slice to a Batch pointer.
 func ToBatch(data []byte) *Batch {
 	return (*Batch)(unsafe.Pointer(&data[0]))
@@ -17,6 +17,8 @@ import "C"
 
 type ConnTuple C.conn_tuple_t
 type TCPStats C.tcp_stats_t
+type QUICStats C.quic_stats_t
+type TCPDrops C.tcp_drops_t
 type ConnStats C.conn_stats_ts_t
 type Conn C.conn_t
 type Batch C.batch_t
@@ -25,6 +27,9 @@ type PortBinding C.port_binding_t
 type PIDFD C.pid_fd_t
 type UDPRecvSock C.udp_recv_sock_t
 type BindSyscallArgs C.bind_syscall_args_t
+type ConnFilterKey C.conn_filter_key_t
+type ConnFilter C.conn_filter_t
+type ConnFilterPort C.conn_filter_port_t
 
 // udp_recv_sock_t have *sock and *msghdr struct members, we make them opaque here
 type _Ctype_struct_sock uint64
@@ -46,5 +51,13 @@ const (
 	Assured ConnFlags = C.CONN_ASSURED
 )
 
+const (
+	ConnFilterSource          = C.CONN_FILTER_SOURCE
+	ConnFilterDest            = C.CONN_FILTER_DEST
+	ConnFilterHeaderPrefixLen = C.CONN_FILTER_HEADER_PREFIXLEN
+)
+
 const BatchSize = C.CONN_CLOSED_BATCH_SIZE
 const SizeofBatch = C.sizeof_batch_t
+
+const TCPDropReasonsMax = C.TCP_DROP_REASONS_MAX
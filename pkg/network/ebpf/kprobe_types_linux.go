@@ -18,15 +18,40 @@ type TCPStats struct {
 	Retransmits       uint32
 	Rtt               uint32
 	Rtt_var           uint32
+	Drops             uint32
+	Rcv_queue_max     uint32
+	Snd_queue_max     uint32
+	Rcv_queue_sum     uint64
+	Snd_queue_sum     uint64
+	Queue_samples     uint32
+	Snd_cwnd          uint32
+	Snd_ssthresh      uint32
+	Ca_name           [16]int8
 	State_transitions uint16
 	Pad_cgo_0         [2]byte
 }
+type QUICStats struct {
+	Initial_ts      uint64
+	Last_initial_ts uint64
+	Spin_edge_ts    [2]uint64
+	Retransmits     uint32
+	Rtt             uint32
+	Rtt_var         uint32
+	Spin            [2]uint8
+	Handshake_done  uint8
+	Pad_cgo_0       [1]byte
+}
+type TCPDrops struct {
+	Reasons [4]uint32
+	Counts  [4]uint32
+}
 type ConnStats struct {
 	Sent_bytes   uint64
 	Recv_bytes   uint64
 	Timestamp    uint64
 	Flags        uint32
 	Cookie       uint32
+	Sk_cookie    uint64
 	Sent_packets uint64
 	Recv_packets uint64
 	Direction    uint8
@@ -44,16 +69,20 @@ type Batch struct {
 	C2  Conn
 	C3  Conn
 	Len uint16
+	Cpu uint16
 	Id  uint64
 }
 type Telemetry struct {
-	Tcp_failed_connect  uint64
-	Tcp_sent_miscounts  uint64
-	Missed_tcp_close    uint64
-	Missed_udp_close    uint64
-	Udp_sends_processed uint64
-	Udp_sends_missed    uint64
-	Udp_dropped_conns   uint64
+	Tcp_failed_connect     uint64
+	Tcp_sent_miscounts     uint64
+	Missed_tcp_close       uint64
+	Missed_udp_close       uint64
+	Udp_sends_processed    uint64
+	Udp_sends_missed       uint64
+	Udp_dropped_conns      uint64
+	Tcp_reused_tuples      uint64
+	Tcp_failed_connect_v6  uint64
+	Quic_failed_handshakes uint64
 }
 type PortBinding struct {
 	Netns     uint32
@@ -72,6 +101,25 @@ type BindSyscallArgs struct {
 	Addr *_Ctype_struct_sockaddr
 	Sk   *_Ctype_struct_sock
 }
+type ConnFilterKey struct {
+	Prefixlen uint32
+	Side      uint8
+	Family    uint8
+	Pad       uint16
+	Addr      [16]uint8
+}
+type ConnFilter struct {
+	Id            uint32
+	All_tcp_ports uint8
+	All_udp_ports uint8
+	Pad           uint16
+}
+type ConnFilterPort struct {
+	Id   uint32
+	Port uint16
+	Type uint8
+	Pad  uint8
+}
 
 type _Ctype_struct_sock uint64
 type _Ctype_struct_msghdr uint64
@@ -92,5 +140,13 @@ const (
 	Assured ConnFlags = 0x4
 )
 
+const (
+	ConnFilterSource          = 0x0
+	ConnFilterDest            = 0x1
+	ConnFilterHeaderPrefixLen = 0x20
+)
+
 const BatchSize = 0x4
-const SizeofBatch = 0x1f0
+const SizeofBatch = 0x2f0
+
+const TCPDropReasonsMax = 0x4
@@ -29,11 +29,18 @@ const (
 	ProtocolClassifierEntrySocketFilter ProbeFuncName = "socket__classifier_entry"
 	// ProtocolClassifierSocketFilter runs a classifier algorithm as a socket filter
 	ProtocolClassifierSocketFilter ProbeFuncName = "socket__classifier"
+	// ProtocolClassifierQueuesSocketFilter runs the classification of the message queues protocols
+	ProtocolClassifierQueuesSocketFilter ProbeFuncName = "socket__classifier_queues"
+	// ProtocolClassifierDBsSocketFilter runs the classification of the databases protocols
+	ProtocolClassifierDBsSocketFilter ProbeFuncName = "socket__classifier_dbs"
 
 	// NetDevQueue runs a tracepoint that allows us to correlate __sk_buf (in a socket filter) with the `struct sock*`
 	// belongs (but hidden) for it.
 	NetDevQueue ProbeFuncName = "tracepoint__net__net_dev_queue"
 
+	// KfreeSkb runs a tracepoint counting the packets of the TCP connections dropped by the kernel
+	KfreeSkb ProbeFuncName = "tracepoint__skb__kfree_skb"
+
 	// TCPSendMsg traces the tcp_sendmsg() system call
 	TCPSendMsg ProbeFuncName = "kprobe__tcp_sendmsg"
 
@@ -140,6 +147,22 @@ const (
 	// ConntrackFillInfo is the probe for dumping existing conntrack entries
 	ConntrackFillInfo ProbeFuncName = "kprobe_ctnetlink_fill_info"
 
+	// ConntrackDelete is the probe for deleted conntrack entries
+	ConntrackDelete ProbeFuncName = "kprobe_nf_ct_delete"
+
+	// ConntrackTCPv4PreConnect is the kprobe of the function running the cgroup/connect4 programs of a TCP connection
+	ConntrackTCPv4PreConnect ProbeFuncName = "kprobe__tcp_v4_pre_connect"
+	// ConntrackTCPv4PreConnectReturn is the kretprobe of the function running the cgroup/connect4 programs of a TCP connection
+	ConntrackTCPv4PreConnectReturn ProbeFuncName = "kretprobe__tcp_v4_pre_connect"
+	// ConntrackTCPConnectSockLB is the kprobe recording the connections translated by a cgroup/connect4 program
+	ConntrackTCPConnectSockLB ProbeFuncName = "kprobe__tcp_connect_sock_lb"
+	// ConntrackTCPv4PreConnectFentry is the fentry variant of ConntrackTCPv4PreConnect
+	ConntrackTCPv4PreConnectFentry ProbeFuncName = "fentry__tcp_v4_pre_connect"
+	// ConntrackTCPv4PreConnectFexit is the fexit variant of ConntrackTCPv4PreConnectReturn
+	ConntrackTCPv4PreConnectFexit ProbeFuncName = "fexit__tcp_v4_pre_connect"
+	// ConntrackTCPConnectSockLBFentry is the fentry variant of ConntrackTCPConnectSockLB
+	ConntrackTCPConnectSockLBFentry ProbeFuncName = "fentry__tcp_connect_sock_lb"
+
 	// SockFDLookup is the kprobe used for mapping socket FDs to kernel sock structs
 	SockFDLookup ProbeFuncName = "kprobe__sockfd_lookup_light"
 
@@ -159,7 +182,12 @@ type BPFMapName = string
 // constants for the map names
 const (
 	ConnMap                           BPFMapName = "conn_stats"
+	ConnMapV6                         BPFMapName = "conn_stats_v6"
 	TCPStatsMap                       BPFMapName = "tcp_stats"
+	QUICStatsMap                      BPFMapName = "quic_stats"
+	TCPDropReasonsMap                 BPFMapName = "tcp_drop_reasons"
+	ConnFiltersMap                    BPFMapName = "conn_filters"
+	ConnFilterPortsMap                BPFMapName = "conn_filter_ports"
 	TCPConnectSockPidMap              BPFMapName = "tcp_ongoing_connect_pid"
 	ConnCloseEventMap                 BPFMapName = "conn_close_event"
 	TracerStatusMap                   BPFMapName = "tracer_status"
@@ -169,6 +197,7 @@ const (
 	ConnCloseBatchMap                 BPFMapName = "conn_close_batch"
 	ConntrackMap                      BPFMapName = "conntrack"
 	ConntrackTelemetryMap             BPFMapName = "conntrack_telemetry"
+	SockLBMap                         BPFMapName = "sock_lb"
 	SockFDLookupArgsMap               BPFMapName = "sockfd_lookup_args"
 	DoSendfileArgsMap                 BPFMapName = "do_sendfile_args"
 	SockByPidFDMap                    BPFMapName = "sock_by_pid_fd"
@@ -183,3 +212,16 @@ const (
 	ConnectionTupleToSocketSKBConnMap BPFMapName = "conn_tuple_to_socket_skb_conn_tuple"
 	ClassificationProgsMap            BPFMapName = "classification_progs"
 )
+
+// ClassificationProgramType is the key of a program of the ClassificationProgsMap (classification_prog_t)
+type ClassificationProgramType uint32
+
+const (
+	// ClassificationDispatcher is the program classifying the application layer protocols, which then tail calls the
+	// other classification programs
+	ClassificationDispatcher ClassificationProgramType = iota
+	// ClassificationQueues is the program classifying the message queues protocols
+	ClassificationQueues
+	// ClassificationDBs is the program classifying the databases protocols
+	ClassificationDBs
+)
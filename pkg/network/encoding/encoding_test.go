@@ -34,7 +34,7 @@ const (
 	tagOpenSSL connTag = 2 // netebpf.OpenSSL
 )
 
-func newConfig(t *testing.T) {
+func newConfig(t testing.TB) {
 	originalConfig := config.SystemProbe
 	t.Cleanup(func() {
 		config.SystemProbe = originalConfig
@@ -596,3 +596,72 @@ func TestPooledObjectGarbageRegression(t *testing.T) {
 		}
 	}
 }
+
+const (
+	benchmarkConns     = 100000
+	benchmarkEndpoints = 10000
+)
+
+// generateBenchmarkPayload generates a payload with numConns connections and numEndpoints HTTP endpoints spread across
+// them, which is the cardinality we see on busy hosts.
+func generateBenchmarkPayload(numConns, numEndpoints int) *network.Connections {
+	conns := make([]network.ConnectionStats, numConns)
+	for i := range conns {
+		conns[i] = network.ConnectionStats{
+			Source:    util.AddressFromString(fmt.Sprintf("10.0.%d.%d", (i/256)%256, i%256)),
+			Dest:      util.AddressFromString(fmt.Sprintf("10.1.%d.%d", (i/256)%256, i%256)),
+			SPort:     uint16(1024 + i%60000),
+			DPort:     8080,
+			Pid:       uint32(i % 1000),
+			Type:      network.TCP,
+			Family:    network.AFINET,
+			Direction: network.OUTGOING,
+			Monotonic: network.StatCounters{
+				SentBytes:   uint64(i),
+				RecvBytes:   uint64(i * 2),
+				SentPackets: uint64(i),
+				RecvPackets: uint64(i),
+			},
+			Last: network.StatCounters{
+				SentBytes:   uint64(i),
+				RecvBytes:   uint64(i * 2),
+				SentPackets: uint64(i),
+				RecvPackets: uint64(i),
+			},
+		}
+	}
+
+	httpStats := make(map[http.Key]*http.RequestStats, numEndpoints)
+	for i := 0; i < numEndpoints; i++ {
+		conn := conns[i%numConns]
+		key := http.NewKey(conn.Source, conn.Dest, conn.SPort, conn.DPort, fmt.Sprintf("/api/v1/endpoint-%d", i), true, http.MethodGet)
+		stats := new(http.RequestStats)
+		for j := 0; j < 10; j++ {
+			stats.AddRequest(100*(j%5+1), float64(i+j)*1e6, float64(i+j)*5e5, 0, nil)
+		}
+		httpStats[key] = stats
+	}
+
+	return &network.Connections{
+		BufferedData: network.BufferedData{Conns: conns},
+		HTTP:         httpStats,
+	}
+}
+
+func BenchmarkConnectionsMarshal(b *testing.B) {
+	newConfig(b)
+	payload := generateBenchmarkPayload(benchmarkConns, benchmarkEndpoints)
+
+	for name, contentType := range map[string]string{"protobuf": ContentTypeProtobuf, "json": ContentTypeJSON} {
+		b.Run(name, func(b *testing.B) {
+			marshaler := GetMarshaler(contentType)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := marshaler.Marshal(payload); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
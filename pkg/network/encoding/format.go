@@ -8,6 +8,7 @@ package encoding
 import (
 	"math"
 	"reflect"
+	"strconv"
 	"sync"
 	"unsafe"
 
@@ -16,6 +17,7 @@ import (
 
 	model "github.com/DataDog/agent-payload/v5/process"
 	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
@@ -50,6 +52,7 @@ func FormatConnection(
 	conn network.ConnectionStats,
 	routes map[string]RouteIdx,
 	httpEncoder *httpEncoder,
+	kafkaEncoder *kafkaEncoder,
 	dnsFormatter *dnsFormatter,
 	ipc ipCache,
 	tagsSet *network.TagsSet,
@@ -85,7 +88,10 @@ func FormatConnection(
 	dnsFormatter.FormatConnectionDNS(conn, c)
 	httpStats, staticTags, dynamicTags := httpEncoder.GetHTTPAggregationsAndTags(conn)
 	if httpStats != nil {
-		c.HttpAggregations, _ = proto.Marshal(httpStats)
+		c.HttpAggregations, _ = httpEncoder.MarshalHTTPAggregations(httpStats)
+	}
+	if kafkaStats := kafkaEncoder.GetKafkaAggregations(conn); kafkaStats != nil {
+		c.DataStreamsAggregations, _ = proto.Marshal(kafkaStats)
 	}
 
 	conn.StaticTags |= staticTags
@@ -267,6 +273,22 @@ func formatTags(tagsSet *network.TagsSet, c network.ConnectionStats, connDynamic
 		tagsIdx = append(tagsIdx, tagsSet.Add(tag))
 	}
 
+	if c.Domain != nil {
+		tag := "domain:" + dns.ToString(c.Domain)
+		mm.Reset()
+		_, _ = mm.Write(unsafeStringSlice(tag))
+		checksum ^= mm.Sum32()
+		tagsIdx = append(tagsIdx, tagsSet.Add(tag))
+	}
+
+	if c.RollupCount > 0 {
+		tag := "connection_count:" + strconv.FormatUint(uint64(c.RollupCount), 10)
+		mm.Reset()
+		_, _ = mm.Write(unsafeStringSlice(tag))
+		checksum ^= mm.Sum32()
+		tagsIdx = append(tagsIdx, tagsSet.Add(tag))
+	}
+
 	// other tags, e.g., from process env vars like DD_ENV, etc.
 	for tag := range c.Tags {
 		mm.Reset()
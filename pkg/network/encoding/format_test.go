@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
 )
 
 func TestFormatRouteIdx(t *testing.T) {
@@ -81,6 +82,20 @@ func TestFormatRouteIdx(t *testing.T) {
 	}
 }
 
+func TestFormatTagsDomain(t *testing.T) {
+	tagsSet := network.NewTagsSet()
+	conn := network.ConnectionStats{Direction: network.OUTGOING}
+	idx, checksum := formatTags(tagsSet, conn, nil)
+	require.Empty(t, idx)
+	require.Zero(t, checksum)
+
+	conn.Domain = dns.ToHostname("datadoghq.com")
+	idx, checksum = formatTags(tagsSet, conn, nil)
+	require.Len(t, idx, 1)
+	require.NotZero(t, checksum)
+	require.Equal(t, []string{"domain:datadoghq.com"}, tagsSet.GetStrings())
+}
+
 func BenchmarkConnectionReset(b *testing.B) {
 	c := new(model.Connection)
 	b.ReportAllocs()
@@ -6,7 +6,11 @@
 package encoding
 
 import (
+	"math"
+
+	"github.com/DataDog/sketches-go/ddsketch"
 	"github.com/gogo/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	model "github.com/DataDog/agent-payload/v5/process"
 
@@ -14,10 +18,40 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 )
 
+// httpAggregationKey identifies the HTTP aggregations of a key tuple. The transactions whose direction is known are only
+// attached to the connection of the end which saw them, while the other ones are attached to both ends.
+type httpAggregationKey struct {
+	http.KeyTuple
+	direction http.Direction
+}
+
+// The sizes of the transactions aren't part of the HTTPStats.Data message of the payload schema yet. They are appended
+// to the encoding of the messages holding them as the following fields, which the decoders built from the current
+// schema skip. The sizes are encoded the same way as the latencies: a protobuf encoded sketch, or the only sample when
+// there is a single one.
+const (
+	httpDataRequestBytesField            protowire.Number = 5
+	httpDataResponseBytesField           protowire.Number = 6
+	httpDataRequestSizesField            protowire.Number = 7
+	httpDataFirstRequestSizeSampleField  protowire.Number = 8
+	httpDataResponseSizesField           protowire.Number = 9
+	httpDataFirstResponseSizeSampleField protowire.Number = 10
+
+	// field numbers of the messages holding the HTTPStats.Data ones
+	httpAggregationsEndpointsField  protowire.Number = 2
+	httpStatsByResponseStatusField  protowire.Number = 1
+	httpStatsByStatusCodeField      protowire.Number = 2
+	httpStatsByStatusCodeKeyField   protowire.Number = 1
+	httpStatsByStatusCodeValueField protowire.Number = 2
+)
+
 type httpEncoder struct {
-	aggregations   map[http.KeyTuple]*aggregationWrapper
-	staticTags     map[http.KeyTuple]uint64
-	dynamicTagsSet map[http.KeyTuple]map[string]struct{}
+	aggregations   map[httpAggregationKey]*aggregationWrapper
+	staticTags     map[httpAggregationKey]uint64
+	dynamicTagsSet map[httpAggregationKey]map[string]struct{}
+
+	// sizes holds the stats of the encoded data whose transactions have a known size
+	sizes map[*model.HTTPStats_Data]*http.RequestStat
 
 	// pre-allocated objects
 	dataPool []model.HTTPStats_Data
@@ -80,9 +114,10 @@ func newHTTPEncoder(payload *network.Connections) *httpEncoder {
 	}
 
 	encoder := &httpEncoder{
-		aggregations:   make(map[http.KeyTuple]*aggregationWrapper, len(payload.Conns)),
-		staticTags:     make(map[http.KeyTuple]uint64, len(payload.Conns)),
-		dynamicTagsSet: make(map[http.KeyTuple]map[string]struct{}, len(payload.Conns)),
+		aggregations:   make(map[httpAggregationKey]*aggregationWrapper, len(payload.Conns)),
+		staticTags:     make(map[httpAggregationKey]uint64, len(payload.Conns)),
+		dynamicTagsSet: make(map[httpAggregationKey]map[string]struct{}, len(payload.Conns)),
+		sizes:          make(map[*model.HTTPStats_Data]*http.RequestStat),
 
 		// pre-allocate all data objects at once
 		dataPool: make([]model.HTTPStats_Data, len(payload.HTTP)*http.NumStatusClasses),
@@ -93,8 +128,9 @@ func newHTTPEncoder(payload *network.Connections) *httpEncoder {
 	// pre-populate aggregation map with keys for all existent connections
 	// this allows us to skip encoding orphan HTTP objects that can't be matched to a connection
 	for _, conn := range payload.Conns {
-		for _, key := range network.HTTPKeyTuplesFromConn(conn) {
-			encoder.aggregations[key] = nil
+		for i, key := range network.HTTPKeyTuplesFromConn(conn) {
+			encoder.aggregations[httpAggregationKey{KeyTuple: key}] = nil
+			encoder.aggregations[httpAggregationKey{KeyTuple: key, direction: localDirection(i)}] = nil
 		}
 	}
 
@@ -108,22 +144,171 @@ func (e *httpEncoder) GetHTTPAggregationsAndTags(c network.ConnectionStats) (*mo
 	}
 
 	keyTuples := network.HTTPKeyTuplesFromConn(c)
-	for _, key := range keyTuples {
-		if aggregation := e.aggregations[key]; aggregation != nil {
-			return e.aggregations[key].ValueFor(c), e.staticTags[key], e.dynamicTagsSet[key]
+	for i, key := range keyTuples {
+		shared := httpAggregationKey{KeyTuple: key}
+		local := httpAggregationKey{KeyTuple: key, direction: localDirection(i)}
+		sharedAggregation, localAggregation := e.aggregations[shared], e.aggregations[local]
+		if sharedAggregation == nil && localAggregation == nil {
+			continue
 		}
+
+		aggregations := mergeHTTPAggregations(sharedAggregation.ValueFor(c), localAggregation.ValueFor(c))
+		staticTags := e.staticTags[shared] | e.staticTags[local]
+		dynamicTags := mergeDynamicTags(e.dynamicTagsSet[shared], e.dynamicTagsSet[local])
+		return aggregations, staticTags, dynamicTags
 	}
 	return nil, 0, nil
 }
 
+// MarshalHTTPAggregations encodes the aggregations returned by GetHTTPAggregationsAndTags, along with the sizes of
+// their transactions
+func (e *httpEncoder) MarshalHTTPAggregations(aggregations *model.HTTPAggregations) ([]byte, error) {
+	if len(e.sizes) == 0 {
+		return proto.Marshal(aggregations)
+	}
+
+	var b []byte
+	for _, stats := range aggregations.EndpointAggregations {
+		blob, err := e.marshalHTTPStats(stats)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, httpAggregationsEndpointsField, protowire.BytesType)
+		b = protowire.AppendBytes(b, blob)
+	}
+	return b, nil
+}
+
+func (e *httpEncoder) marshalHTTPStats(stats *model.HTTPStats) ([]byte, error) {
+	// the fields other than the data are encoded as they are
+	b, err := proto.Marshal(&model.HTTPStats{
+		Path:     stats.Path,
+		Method:   stats.Method,
+		FullPath: stats.FullPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, data := range stats.StatsByResponseStatus {
+		blob, err := e.marshalHTTPData(data)
+		if err != nil {
+			return nil, err
+		}
+		b = protowire.AppendTag(b, httpStatsByResponseStatusField, protowire.BytesType)
+		b = protowire.AppendBytes(b, blob)
+	}
+
+	for code, data := range stats.StatsByStatusCode {
+		blob, err := e.marshalHTTPData(data)
+		if err != nil {
+			return nil, err
+		}
+		var entry []byte
+		entry = protowire.AppendTag(entry, httpStatsByStatusCodeKeyField, protowire.VarintType)
+		entry = protowire.AppendVarint(entry, uint64(code))
+		entry = protowire.AppendTag(entry, httpStatsByStatusCodeValueField, protowire.BytesType)
+		entry = protowire.AppendBytes(entry, blob)
+		b = protowire.AppendTag(b, httpStatsByStatusCodeField, protowire.BytesType)
+		b = protowire.AppendBytes(b, entry)
+	}
+	return b, nil
+}
+
+func (e *httpEncoder) marshalHTTPData(data *model.HTTPStats_Data) ([]byte, error) {
+	b, err := proto.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := e.sizes[data]
+	if !ok {
+		return b, nil
+	}
+	if s.RequestBytes > 0 {
+		b = protowire.AppendTag(b, httpDataRequestBytesField, protowire.VarintType)
+		b = protowire.AppendVarint(b, s.RequestBytes)
+	}
+	if s.ResponseBytes > 0 {
+		b = protowire.AppendTag(b, httpDataResponseBytesField, protowire.VarintType)
+		b = protowire.AppendVarint(b, s.ResponseBytes)
+	}
+	b = appendSizes(b, httpDataRequestSizesField, httpDataFirstRequestSizeSampleField, s.RequestSizes, s.FirstRequestSizeSample)
+	b = appendSizes(b, httpDataResponseSizesField, httpDataFirstResponseSizeSampleField, s.ResponseSizes, s.FirstResponseSizeSample)
+	return b, nil
+}
+
+// appendSizes appends the distribution of sizes to b, as the sketch of the sizes if it is created, or as the only
+// sample otherwise
+func appendSizes(b []byte, sketchField, sampleField protowire.Number, sizes *ddsketch.DDSketch, firstSample float64) []byte {
+	if sizes != nil {
+		blob, err := proto.Marshal(sizes.ToProto())
+		if err != nil {
+			return b
+		}
+		b = protowire.AppendTag(b, sketchField, protowire.BytesType)
+		return protowire.AppendBytes(b, blob)
+	}
+	if firstSample > 0 {
+		b = protowire.AppendTag(b, sampleField, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(firstSample))
+	}
+	return b
+}
+
+// localDirection returns the direction of the transactions seen by the local end of a connection, given the index of
+// the key tuple of the connection matching the transactions in the ones returned by network.HTTPKeyTuplesFromConn,
+// the first of which is the one of the connections whose local end is the client
+func localDirection(keyTupleIndex int) http.Direction {
+	if keyTupleIndex == 0 {
+		return http.DirectionClient
+	}
+	return http.DirectionServer
+}
+
+// mergeHTTPAggregations returns the endpoints of both a and b, either of which may be nil
+func mergeHTTPAggregations(a, b *model.HTTPAggregations) *model.HTTPAggregations {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	endpoints := make([]*model.HTTPStats, 0, len(a.EndpointAggregations)+len(b.EndpointAggregations))
+	endpoints = append(endpoints, a.EndpointAggregations...)
+	endpoints = append(endpoints, b.EndpointAggregations...)
+	return &model.HTTPAggregations{EndpointAggregations: endpoints}
+}
+
+// mergeDynamicTags returns the tags of both a and b, either of which may be nil
+func mergeDynamicTags(a, b map[string]struct{}) map[string]struct{} {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+
+	tags := make(map[string]struct{}, len(a)+len(b))
+	for tag := range a {
+		tags[tag] = struct{}{}
+	}
+	for tag := range b {
+		tags[tag] = struct{}{}
+	}
+	return tags
+}
+
 func (e *httpEncoder) buildAggregations(payload *network.Connections) {
-	aggrSize := make(map[http.KeyTuple]int)
+	aggrSize := make(map[httpAggregationKey]int)
 	for key := range payload.HTTP {
-		aggrSize[key.KeyTuple]++
+		aggrSize[httpAggregationKey{KeyTuple: key.KeyTuple, direction: key.Direction}]++
 	}
 
 	for key, stats := range payload.HTTP {
-		aggregation, ok := e.aggregations[key.KeyTuple]
+		aggrKey := httpAggregationKey{KeyTuple: key.KeyTuple, direction: key.Direction}
+		aggregation, ok := e.aggregations[aggrKey]
 		if !ok {
 			// if there is no matching connection don't even bother to serialize HTTP data
 			e.orphanEntries++
@@ -133,27 +318,21 @@ func (e *httpEncoder) buildAggregations(payload *network.Connections) {
 		if aggregation == nil {
 			aggregation = &aggregationWrapper{
 				HTTPAggregations: &model.HTTPAggregations{
-					EndpointAggregations: make([]*model.HTTPStats, 0, aggrSize[key.KeyTuple]),
+					EndpointAggregations: make([]*model.HTTPStats, 0, aggrSize[aggrKey]),
 				},
 			}
-			e.aggregations[key.KeyTuple] = aggregation
+			e.aggregations[aggrKey] = aggregation
 		}
 
 		ms := &model.HTTPStats{
-			Path:                  key.Path.Content,
-			FullPath:              key.Path.FullPath,
-			Method:                model.HTTPMethod(key.Method),
-			StatsByResponseStatus: e.getDataSlice(),
+			Path:     key.Path.Content,
+			FullPath: key.Path.FullPath,
+			Method:   model.HTTPMethod(key.Method),
 		}
 
-		staticTags := e.staticTags[key.KeyTuple]
+		staticTags := e.staticTags[aggrKey]
 		var dynamicTags map[string]struct{}
-		for i, data := range ms.StatsByResponseStatus {
-			class := (i + 1) * 100
-			if !stats.HasStats(class) {
-				continue
-			}
-			s := stats.Stats(class)
+		encodeData := func(data *model.HTTPStats_Data, s *http.RequestStat) {
 			data.Count = uint32(s.Count)
 
 			if latencies := s.Latencies; latencies != nil {
@@ -163,6 +342,10 @@ func (e *httpEncoder) buildAggregations(payload *network.Connections) {
 				data.FirstLatencySample = s.FirstLatencySample
 			}
 
+			if s.RequestBytes > 0 || s.ResponseBytes > 0 {
+				e.sizes[data] = s
+			}
+
 			staticTags |= s.StaticTags
 
 			// It is a map to aggregate the same tag
@@ -177,8 +360,27 @@ func (e *httpEncoder) buildAggregations(payload *network.Connections) {
 			}
 		}
 
-		e.staticTags[key.KeyTuple] = staticTags
-		e.dynamicTagsSet[key.KeyTuple] = dynamicTags
+		if stats.AggregatedByStatusCode() {
+			codes := stats.StatusCodes()
+			ms.StatsByStatusCode = make(map[int32]*model.HTTPStats_Data, len(codes))
+			for _, code := range codes {
+				data := new(model.HTTPStats_Data)
+				encodeData(data, stats.Stats(int(code)))
+				ms.StatsByStatusCode[int32(code)] = data
+			}
+		} else {
+			ms.StatsByResponseStatus = e.getDataSlice()
+			for i, data := range ms.StatsByResponseStatus {
+				class := (i + 1) * 100
+				if !stats.HasStats(class) {
+					continue
+				}
+				encodeData(data, stats.Stats(class))
+			}
+		}
+
+		e.staticTags[aggrKey] = staticTags
+		e.dynamicTagsSet[aggrKey] = dynamicTags
 
 		aggregation.EndpointAggregations = append(aggregation.EndpointAggregations, ms)
 	}
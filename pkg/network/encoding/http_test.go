@@ -6,6 +6,7 @@
 package encoding
 
 import (
+	"math"
 	"testing"
 
 	model "github.com/DataDog/agent-payload/v5/process"
@@ -14,6 +15,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
 
 	"github.com/DataDog/datadog-agent/pkg/network"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
@@ -38,7 +40,7 @@ func TestFormatHTTPStats(t *testing.T) {
 	)
 	var httpStats1 http.RequestStats
 	for i := 100; i <= 500; i += 100 {
-		httpStats1.AddRequest(i, 10, 1<<(i/100-1), nil)
+		httpStats1.AddRequest(i, 10, 0, 1<<(i/100-1), nil)
 	}
 
 	httpKey2 := httpKey1
@@ -48,7 +50,7 @@ func TestFormatHTTPStats(t *testing.T) {
 	}
 	var httpStats2 http.RequestStats
 	for i := 100; i <= 500; i += 100 {
-		httpStats2.AddRequest(i, 20, 1<<(i/100-1), nil)
+		httpStats2.AddRequest(i, 20, 0, 1<<(i/100-1), nil)
 	}
 
 	in := &network.Connections{
@@ -109,10 +111,10 @@ func TestFormatHTTPStats(t *testing.T) {
 
 func TestFormatHTTPStatsByPath(t *testing.T) {
 	var httpReqStats http.RequestStats
-	httpReqStats.AddRequest(100, 12.5, 0, nil)
-	httpReqStats.AddRequest(100, 12.5, tagGnuTLS, nil)
-	httpReqStats.AddRequest(405, 3.5, tagOpenSSL, nil)
-	httpReqStats.AddRequest(405, 3.5, 0, nil)
+	httpReqStats.AddRequest(100, 12.5, 0, 0, nil)
+	httpReqStats.AddRequest(100, 12.5, 0, tagGnuTLS, nil)
+	httpReqStats.AddRequest(405, 3.5, 0, tagOpenSSL, nil)
+	httpReqStats.AddRequest(405, 3.5, 0, 0, nil)
 
 	// Verify the latency data is correct prior to serialization
 	latencies := httpReqStats.Stats(int(model.HTTPResponseStatus_Info+1) * 100).Latencies
@@ -177,6 +179,195 @@ func TestFormatHTTPStatsByPath(t *testing.T) {
 	assert.Nil(t, serializedLatencies)
 }
 
+func TestFormatHTTPStatsCombinedLatencies(t *testing.T) {
+	connection := network.ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		SPort:  60000,
+		Dest:   util.AddressFromString("2.2.2.2"),
+		DPort:  80,
+	}
+	httpKey := http.NewKey(connection.Source, connection.Dest, connection.SPort, connection.DPort, "/", true, http.MethodGet)
+
+	// the stats of the same endpoint collected over several intervals, or connections, are merged along with their
+	// latency distributions, including the ones holding a single sample
+	httpStats := http.NewRequestStats(false)
+	httpStats.AddRequest(200, 10.0, 0, 0, nil)
+	for _, latency := range []float64{20.0, 30.0, 40.0} {
+		other := http.NewRequestStats(false)
+		other.AddRequest(200, latency, 0, 0, nil)
+		other.AddRequest(200, latency, 0, 0, nil)
+		httpStats.CombineWith(other)
+	}
+
+	payload := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{connection},
+		},
+		HTTP: map[http.Key]*http.RequestStats{
+			httpKey: httpStats,
+		},
+	}
+	httpEncoder := newHTTPEncoder(payload)
+	aggregations, _, _ := httpEncoder.GetHTTPAggregationsAndTags(connection)
+	require.NotNil(t, aggregations)
+	require.Len(t, aggregations.EndpointAggregations, 1)
+
+	data := aggregations.EndpointAggregations[0].StatsByResponseStatus[model.HTTPResponseStatus_Success]
+	assert.Equal(t, uint32(7), data.Count)
+	sketch := unmarshalSketch(t, data.Latencies)
+	assert.Equal(t, 7.0, sketch.GetCount())
+	verifyQuantile(t, sketch, 0.0, 10.0)
+	verifyQuantile(t, sketch, 0.5, 30.0)
+	verifyQuantile(t, sketch, 1.0, 40.0)
+}
+
+func TestFormatHTTPStatsByStatusCode(t *testing.T) {
+	connection := network.ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		SPort:  60000,
+		Dest:   util.AddressFromString("2.2.2.2"),
+		DPort:  80,
+	}
+	httpKey := http.NewKey(connection.Source, connection.Dest, connection.SPort, connection.DPort, "/", true, http.MethodGet)
+	httpStats := http.NewRequestStats(true)
+	httpStats.AddRequest(404, 1.0, 0, 0, nil)
+	httpStats.AddRequest(404, 2.0, 0, 0, nil)
+	httpStats.AddRequest(429, 3.0, 0, 0, nil)
+
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{connection},
+		},
+		HTTP: map[http.Key]*http.RequestStats{
+			httpKey: httpStats,
+		},
+	}
+
+	aggregations, _, _ := newHTTPEncoder(in).GetHTTPAggregationsAndTags(connection)
+	require.NotNil(t, aggregations)
+	require.Len(t, aggregations.EndpointAggregations, 1)
+
+	endpointAggregation := aggregations.EndpointAggregations[0]
+	assert.Nil(t, endpointAggregation.StatsByResponseStatus)
+	require.Len(t, endpointAggregation.StatsByStatusCode, 2)
+	assert.Equal(t, uint32(2), endpointAggregation.StatsByStatusCode[404].Count)
+	verifyQuantile(t, unmarshalSketch(t, endpointAggregation.StatsByStatusCode[404].Latencies), 1.0, 2.0)
+	assert.Equal(t, uint32(1), endpointAggregation.StatsByStatusCode[429].Count)
+	assert.Equal(t, 3.0, endpointAggregation.StatsByStatusCode[429].FirstLatencySample)
+}
+
+func TestFormatHTTPStatsSizes(t *testing.T) {
+	connection := network.ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		SPort:  60000,
+		Dest:   util.AddressFromString("2.2.2.2"),
+		DPort:  80,
+	}
+	httpKey := http.NewKey(connection.Source, connection.Dest, connection.SPort, connection.DPort, "/", true, http.MethodGet)
+	httpStats := http.NewRequestStats(true)
+	httpStats.AddRequest(200, 1.0, 0, 0, nil)
+	httpStats.AddBytes(200, 100, 1000)
+	httpStats.AddRequest(200, 2.0, 0, 0, nil)
+	httpStats.AddBytes(200, 0, 3000)
+	httpStats.AddRequest(404, 3.0, 0, 0, nil)
+
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{connection},
+		},
+		HTTP: map[http.Key]*http.RequestStats{
+			httpKey: httpStats,
+		},
+	}
+
+	encoder := newHTTPEncoder(in)
+	aggregations, _, _ := encoder.GetHTTPAggregationsAndTags(connection)
+	require.NotNil(t, aggregations)
+	blob, err := encoder.MarshalHTTPAggregations(aggregations)
+	require.NoError(t, err)
+
+	// the sizes are skipped by the decoders built from the payload schema
+	decoded := new(model.HTTPAggregations)
+	require.NoError(t, proto.Unmarshal(blob, decoded))
+	require.Len(t, decoded.EndpointAggregations, 1)
+	require.Len(t, decoded.EndpointAggregations[0].StatsByStatusCode, 2)
+	assert.Equal(t, uint32(2), decoded.EndpointAggregations[0].StatsByStatusCode[200].Count)
+
+	data := httpDataByStatusCode(t, blob)
+	require.Len(t, data, 2)
+	assert.Empty(t, httpDataFields(t, data[404]))
+
+	fields := httpDataFields(t, data[200])
+	assert.Equal(t, uint64(100), fields[httpDataRequestBytesField])
+	assert.Equal(t, uint64(4000), fields[httpDataResponseBytesField])
+	// only the size of the first request is known
+	assert.Equal(t, 100.0, math.Float64frombits(fields[httpDataFirstRequestSizeSampleField].(uint64)))
+	assert.NotContains(t, fields, httpDataRequestSizesField)
+	assert.NotContains(t, fields, httpDataFirstResponseSizeSampleField)
+	responseSizes := unmarshalSketch(t, fields[httpDataResponseSizesField].([]byte))
+	assert.Equal(t, 2.0, responseSizes.GetCount())
+	verifyQuantile(t, responseSizes, 1.0, 3000.0)
+}
+
+// httpDataByStatusCode returns the encoded data of the single endpoint of the encoded aggregations, by status code
+func httpDataByStatusCode(t *testing.T, blob []byte) map[uint64][]byte {
+	endpoints := protoFields(t, blob)
+	require.Len(t, endpoints, 1)
+	require.Equal(t, httpAggregationsEndpointsField, endpoints[0].num)
+
+	data := make(map[uint64][]byte)
+	for _, field := range protoFields(t, endpoints[0].value.([]byte)) {
+		if field.num != httpStatsByStatusCodeField {
+			continue
+		}
+		entry := protoFields(t, field.value.([]byte))
+		require.Len(t, entry, 2)
+		data[entry[0].value.(uint64)] = entry[1].value.([]byte)
+	}
+	return data
+}
+
+// httpDataFields returns the fields of the encoded data which aren't part of the payload schema
+func httpDataFields(t *testing.T, blob []byte) map[protowire.Number]interface{} {
+	fields := make(map[protowire.Number]interface{})
+	for _, field := range protoFields(t, blob) {
+		if field.num >= httpDataRequestBytesField {
+			fields[field.num] = field.value
+		}
+	}
+	return fields
+}
+
+type protoField struct {
+	num   protowire.Number
+	value interface{}
+}
+
+func protoFields(t *testing.T, b []byte) []protoField {
+	var fields []protoField
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+
+		var value interface{}
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			value, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		default:
+			require.Failf(t, "unexpected wire type", "%v", typ)
+		}
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		fields = append(fields, protoField{num: num, value: value})
+	}
+	return fields
+}
+
 func TestIDCollisionRegression(t *testing.T) {
 	assert := assert.New(t)
 	connections := []network.ConnectionStats{
@@ -206,7 +397,7 @@ func TestIDCollisionRegression(t *testing.T) {
 		true,
 		http.MethodGet,
 	)
-	httpStats.AddRequest(100, 1.0, 0, nil)
+	httpStats.AddRequest(100, 1.0, 0, 0, nil)
 
 	in := &network.Connections{
 		BufferedData: network.BufferedData{
@@ -262,7 +453,7 @@ func TestLocalhostScenario(t *testing.T) {
 		true,
 		http.MethodGet,
 	)
-	httpStats.AddRequest(100, 1.0, 0, nil)
+	httpStats.AddRequest(100, 1.0, 0, 0, nil)
 
 	in := &network.Connections{
 		BufferedData: network.BufferedData{
@@ -276,7 +467,8 @@ func TestLocalhostScenario(t *testing.T) {
 	httpEncoder := newHTTPEncoder(in)
 
 	// assert that both ends (client:server, server:client) of the connection
-	// will have HTTP stats
+	// will have HTTP stats, as the plain HTTP transactions seen from the
+	// network have no direction
 	aggregations, _, _ := httpEncoder.GetHTTPAggregationsAndTags(connections[0])
 	assert.NotNil(aggregations)
 	assert.Equal("/", aggregations.EndpointAggregations[0].Path)
@@ -288,6 +480,54 @@ func TestLocalhostScenario(t *testing.T) {
 	assert.Equal(uint32(1), aggregations.EndpointAggregations[0].StatsByResponseStatus[0].Count)
 }
 
+func TestLocalhostScenarioWithDirections(t *testing.T) {
+	localhost := util.AddressFromString("127.0.0.1")
+	connections := []network.ConnectionStats{
+		{Source: localhost, SPort: 60000, Dest: localhost, DPort: 443, Pid: 1},
+		{Source: localhost, SPort: 443, Dest: localhost, DPort: 60000, Pid: 2},
+	}
+
+	sharedKey := http.NewKey(localhost, localhost, 60000, 443, "/shared", true, http.MethodGet)
+	clientKey := http.NewKey(localhost, localhost, 60000, 443, "/", true, http.MethodGet)
+	clientKey.Direction = http.DirectionClient
+	serverKey := clientKey
+	serverKey.Direction = http.DirectionServer
+
+	var sharedStats, clientStats, serverStats http.RequestStats
+	sharedStats.AddRequest(200, 1.0, 0, 0, nil)
+	clientStats.AddRequest(500, 1.0, 0, 0, nil)
+	serverStats.AddRequest(500, 1.0, 0, 0, nil)
+	serverStats.AddRequest(500, 1.0, 0, 0, nil)
+
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: connections,
+		},
+		HTTP: map[http.Key]*http.RequestStats{
+			sharedKey: &sharedStats,
+			clientKey: &clientStats,
+			serverKey: &serverStats,
+		},
+	}
+
+	httpEncoder := newHTTPEncoder(in)
+
+	// each end of the connection gets the transactions it saw, along with the ones seen once for both ends
+	counts := func(c network.ConnectionStats) map[string]uint32 {
+		aggregations, _, _ := httpEncoder.GetHTTPAggregationsAndTags(c)
+		require.NotNil(t, aggregations)
+		counts := make(map[string]uint32)
+		for _, endpoint := range aggregations.EndpointAggregations {
+			for _, data := range endpoint.StatsByResponseStatus {
+				counts[endpoint.Path] += data.Count
+			}
+		}
+		return counts
+	}
+	assert.Equal(t, map[string]uint32{"/shared": 1, "/": 1}, counts(connections[0]))
+	assert.Equal(t, map[string]uint32{"/shared": 1, "/": 2}, counts(connections[1]))
+}
+
 func unmarshalSketch(t *testing.T, bytes []byte) *ddsketch.DDSketch {
 	var sketchPb sketchpb.DDSketch
 	err := proto.Unmarshal(bytes, &sketchPb)
@@ -307,3 +547,16 @@ func verifyQuantile(t *testing.T, sketch *ddsketch.DDSketch, q float64, expected
 	assert.True(t, val >= expectedValue-acceptableError)
 	assert.True(t, val <= expectedValue+acceptableError)
 }
+
+func BenchmarkHTTPEncoder(b *testing.B) {
+	payload := generateBenchmarkPayload(benchmarkConns, benchmarkEndpoints)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		encoder := newHTTPEncoder(payload)
+		for _, conn := range payload.Conns {
+			encoder.GetHTTPAggregationsAndTags(conn)
+		}
+	}
+}
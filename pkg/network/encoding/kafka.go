@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package encoding
+
+import (
+	model "github.com/DataDog/agent-payload/v5/process"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+)
+
+type kafkaEncoder struct {
+	aggregations map[kafka.KeyTuple]*kafkaAggregationWrapper
+
+	orphanEntries int
+}
+
+// kafkaAggregationWrapper handles the collisions of multiple `ConnectionStats` claiming the same
+// `DataStreamsAggregations` object, the same way aggregationWrapper does for the HTTP aggregations
+type kafkaAggregationWrapper struct {
+	*model.DataStreamsAggregations
+
+	// the counts of the topics, by API key, before their conversion to model.DataStreamsAggregations_TopicStats
+	produceCounts map[string]uint32
+	fetchCounts   map[string]uint32
+
+	// we keep track of the source and destination ports of the first
+	// `ConnectionStats` to claim this `DataStreamsAggregations` object
+	sport, dport uint16
+}
+
+func (a *kafkaAggregationWrapper) ValueFor(c network.ConnectionStats) *model.DataStreamsAggregations {
+	if a == nil {
+		return nil
+	}
+
+	if a.sport == 0 && a.dport == 0 {
+		a.sport = c.SPort
+		a.dport = c.DPort
+		return a.DataStreamsAggregations
+	}
+
+	// both ends of the same connection, the client and the broker being in the same host
+	if c.SPort == a.dport && c.DPort == a.sport {
+		return a.DataStreamsAggregations
+	}
+
+	return nil
+}
+
+func newKafkaEncoder(payload *network.Connections) *kafkaEncoder {
+	if len(payload.Kafka) == 0 {
+		return nil
+	}
+
+	encoder := &kafkaEncoder{
+		aggregations: make(map[kafka.KeyTuple]*kafkaAggregationWrapper, len(payload.Conns)),
+	}
+
+	// pre-populate aggregation map with keys for all existent connections
+	// this allows us to skip encoding orphan Kafka objects that can't be matched to a connection
+	for _, conn := range payload.Conns {
+		for _, key := range network.KafkaKeyTuplesFromConn(conn) {
+			encoder.aggregations[key] = nil
+		}
+	}
+
+	encoder.buildAggregations(payload)
+	return encoder
+}
+
+func (e *kafkaEncoder) GetKafkaAggregations(c network.ConnectionStats) *model.DataStreamsAggregations {
+	if e == nil {
+		return nil
+	}
+
+	for _, key := range network.KafkaKeyTuplesFromConn(c) {
+		if aggregation := e.aggregations[key]; aggregation != nil {
+			return aggregation.ValueFor(c)
+		}
+	}
+	return nil
+}
+
+func (e *kafkaEncoder) buildAggregations(payload *network.Connections) {
+	for key, stats := range payload.Kafka {
+		aggregation, ok := e.aggregations[key.KeyTuple]
+		if !ok {
+			// if there is no matching connection don't even bother to serialize Kafka data
+			e.orphanEntries++
+			continue
+		}
+
+		if aggregation == nil {
+			aggregation = &kafkaAggregationWrapper{
+				DataStreamsAggregations: &model.DataStreamsAggregations{},
+				produceCounts:           make(map[string]uint32),
+				fetchCounts:             make(map[string]uint32),
+			}
+			e.aggregations[key.KeyTuple] = aggregation
+		}
+
+		// the requests of the different API versions are reported together
+		switch key.RequestAPIKey {
+		case kafka.ProduceAPIKey:
+			aggregation.produceCounts[key.TopicName] += uint32(stats.Count)
+		case kafka.FetchAPIKey:
+			aggregation.fetchCounts[key.TopicName] += uint32(stats.Count)
+		}
+	}
+
+	for _, aggregation := range e.aggregations {
+		if aggregation == nil {
+			continue
+		}
+		if len(aggregation.produceCounts) > 0 {
+			aggregation.KafkaProduceAggregations = &model.DataStreamsAggregations_KafkaProduceAggregations{
+				Stats: formatTopicStats(aggregation.produceCounts),
+			}
+		}
+		if len(aggregation.fetchCounts) > 0 {
+			aggregation.KafkaFetchAggregations = &model.DataStreamsAggregations_KafkaFetchAggregations{
+				Stats: formatTopicStats(aggregation.fetchCounts),
+			}
+		}
+	}
+}
+
+func formatTopicStats(counts map[string]uint32) []*model.DataStreamsAggregations_TopicStats {
+	stats := make([]*model.DataStreamsAggregations_TopicStats, 0, len(counts))
+	for topic, count := range counts {
+		stats = append(stats, &model.DataStreamsAggregations_TopicStats{
+			Topic: topic,
+			Count: count,
+		})
+	}
+	return stats
+}
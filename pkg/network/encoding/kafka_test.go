@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package encoding
+
+import (
+	"testing"
+
+	model "github.com/DataDog/agent-payload/v5/process"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestFormatKafkaStats(t *testing.T) {
+	var (
+		clientPort = uint16(52800)
+		brokerPort = uint16(9092)
+		localhost  = util.AddressFromString("127.0.0.1")
+	)
+
+	newStats := func(count int) *kafka.RequestStat {
+		stats := new(kafka.RequestStat)
+		for i := 0; i < count; i++ {
+			stats.AddRequest(10)
+		}
+		return stats
+	}
+
+	in := &network.Connections{
+		BufferedData: network.BufferedData{
+			Conns: []network.ConnectionStats{
+				{
+					Source: localhost,
+					Dest:   localhost,
+					SPort:  clientPort,
+					DPort:  brokerPort,
+				},
+			},
+		},
+		Kafka: map[kafka.Key]*kafka.RequestStat{
+			kafka.NewKey(localhost, localhost, clientPort, brokerPort, "topic-1", kafka.ProduceAPIKey, 7): newStats(3),
+			kafka.NewKey(localhost, localhost, clientPort, brokerPort, "topic-1", kafka.ProduceAPIKey, 8): newStats(2),
+			kafka.NewKey(localhost, localhost, clientPort, brokerPort, "topic-2", kafka.FetchAPIKey, 11):  newStats(4),
+			// orphan entry, which doesn't match any connection
+			kafka.NewKey(localhost, localhost, 1234, brokerPort, "topic-1", kafka.FetchAPIKey, 11): newStats(1),
+		},
+	}
+
+	encoder := newKafkaEncoder(in)
+	aggregations := encoder.GetKafkaAggregations(in.Conns[0])
+	require.NotNil(t, aggregations)
+	require.NotNil(t, aggregations.KafkaProduceAggregations)
+	require.NotNil(t, aggregations.KafkaFetchAggregations)
+	assert.ElementsMatch(t, []*model.DataStreamsAggregations_TopicStats{{Topic: "topic-1", Count: 5}}, aggregations.KafkaProduceAggregations.Stats)
+	assert.ElementsMatch(t, []*model.DataStreamsAggregations_TopicStats{{Topic: "topic-2", Count: 4}}, aggregations.KafkaFetchAggregations.Stats)
+	assert.Equal(t, 1, encoder.orphanEntries)
+
+	// the broker side of the same connection gets the same aggregations
+	brokerConn := network.ConnectionStats{
+		Source: localhost,
+		Dest:   localhost,
+		SPort:  brokerPort,
+		DPort:  clientPort,
+	}
+	assert.Equal(t, aggregations, encoder.GetKafkaAggregations(brokerConn))
+
+	// a connection of another process, with the same tuple, doesn't claim them a second time
+	otherConn := in.Conns[0]
+	otherConn.Pid = 42
+	assert.Nil(t, encoder.GetKafkaAggregations(otherConn))
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package encoding
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// maxStreamFrameSize bounds the size of the frames read from a connections stream, so that a corrupted length can't
+// make the reader allocate an arbitrary amount of memory
+const maxStreamFrameSize = 256 * 1024 * 1024
+
+// WriteStreamFrame writes an encoded connections delta to a connections stream, preceded by its length as a 4-byte
+// big-endian integer
+func WriteStreamFrame(w io.Writer, buf []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(buf)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadStreamFrame reads the next encoded connections delta of a connections stream. It returns io.EOF once the stream
+// ended between two frames.
+func ReadStreamFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(size[:])
+	if length > maxStreamFrameSize {
+		return nil, fmt.Errorf("connections stream frame of %d bytes exceeds the maximum of %d bytes", length, maxStreamFrameSize)
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf, nil
+}
@@ -14,7 +14,17 @@ import (
 	"github.com/dustin/go-humanize"
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/cassandra"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http3"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/memcached"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
@@ -131,6 +141,16 @@ type Connections struct {
 	KernelHeaderFetchResult     int32
 	CORETelemetryByAsset        map[string]int32
 	HTTP                        map[http.Key]*http.RequestStats
+	Kafka                       map[kafka.Key]*kafka.RequestStat
+	Postgres                    map[postgres.Key]*postgres.RequestStat
+	MySQL                       map[mysql.Key]*mysql.RequestStat
+	Redis                       map[redis.Key]*redis.RequestStat
+	Mongo                       map[mongo.Key]*mongo.RequestStat
+	AMQP                        map[amqp.Key]*amqp.RequestStat
+	GRPC                        map[grpc.Key]*grpc.RequestStat
+	HTTP3                       map[http3.Key]*http3.RequestStat
+	Cassandra                   map[cassandra.Key]*cassandra.RequestStat
+	Memcached                   map[memcached.Key]*memcached.RequestStat
 	DNSStats                    dns.StatsByKeyByNameByType
 }
 
@@ -155,6 +175,7 @@ const (
 	ConntrackSamplingPercent        ConnTelemetryType = "conntrack_sampling_percent"
 	NPMDriverFlowsMissedMaxExceeded ConnTelemetryType = "driver_flows_missed_max_exceeded"
 	MonotonicDNSPacketsDropped      ConnTelemetryType = "dns_packets_dropped"
+	ConnsEvictedMaxPerClient        ConnTelemetryType = "conns_evicted_max_per_client"
 )
 
 //revive:enable
@@ -199,7 +220,12 @@ type StatCounters struct {
 	RecvBytes   uint64
 	SentPackets uint64
 	RecvPackets uint64
+	// TLSBytes is the number of bytes read and written by the application through the TLS libraries hooked by USM.
+	// Compared to SentBytes and RecvBytes, it shows the share of the connection's traffic that was encrypted.
+	TLSBytes    uint64
 	Retransmits uint32
+	// TCPDrops is the number of packets of the TCP connection dropped by the kernel
+	TCPDrops uint32
 	// TCPEstablished indicates whether the TCP connection was established
 	// after system-probe initialization.
 	// * A value of 0 means that this connection was established before system-probe was initialized;
@@ -235,6 +261,20 @@ type ConnectionStats struct {
 	RTT    uint32 // Stored in µs
 	RTTVar uint32
 
+	// Maximum and average send and receive queue lengths of the TCP connection, in bytes. The receive queue holds the
+	// data not yet read by the application, and the send queue the data not yet acknowledged by the peer.
+	RecvQueueMax uint32
+	RecvQueueAvg uint32
+	SendQueueMax uint32
+	SendQueueAvg uint32
+
+	// Congestion control algorithm of the TCP connection (cubic, bbr...), and its congestion window and slow start
+	// threshold, in segments, sampled when the connection was closed. SndSsthresh is 0 while the connection is still
+	// in slow start.
+	CongestionAlgorithm string
+	SndCwnd             uint32
+	SndSsthresh         uint32
+
 	Pid   uint32
 	NetNS uint32
 
@@ -252,7 +292,15 @@ type ConnectionStats struct {
 
 	ContainerID *string
 
+	// Domain is the domain the destination of the outgoing connection was resolved from, as observed in the DNS
+	// traffic of its network namespace
+	Domain dns.Hostname
+
 	Protocol ProtocolType
+
+	// RollupCount is the number of connections rolled up into this one when the connection rollup is enabled, and 0
+	// if the connection was not rolled up
+	RollupCount uint32
 }
 
 // Via has info about the routing decision for a flow
@@ -372,15 +420,29 @@ func ConnectionSummary(c *ConnectionStats, names map[util.Address][]dns.Hostname
 		humanize.Bytes(c.Monotonic.RecvBytes), humanize.Bytes(c.Last.RecvBytes),
 	)
 
+	if c.Monotonic.TLSBytes > 0 {
+		str += fmt.Sprintf(", %s through TLS (+%s)", humanize.Bytes(c.Monotonic.TLSBytes), humanize.Bytes(c.Last.TLSBytes))
+	}
+
 	if c.Type == TCP {
 		str += fmt.Sprintf(
-			", %d retransmits (+%d), RTT %s (± %s), %d established (+%d), %d closed (+%d)",
+			", %d retransmits (+%d), %d drops (+%d), RTT %s (± %s), %d established (+%d), %d closed (+%d)",
 			c.Monotonic.Retransmits, c.Last.Retransmits,
+			c.Monotonic.TCPDrops, c.Last.TCPDrops,
 			time.Duration(c.RTT)*time.Microsecond,
 			time.Duration(c.RTTVar)*time.Microsecond,
 			c.Monotonic.TCPEstablished, c.Last.TCPEstablished,
 			c.Monotonic.TCPClosed, c.Last.TCPClosed,
 		)
+		if c.RecvQueueMax > 0 || c.SendQueueMax > 0 {
+			str += fmt.Sprintf(", recv queue %s (max %s), send queue %s (max %s)",
+				humanize.Bytes(uint64(c.RecvQueueAvg)), humanize.Bytes(uint64(c.RecvQueueMax)),
+				humanize.Bytes(uint64(c.SendQueueAvg)), humanize.Bytes(uint64(c.SendQueueMax)),
+			)
+		}
+		if c.CongestionAlgorithm != "" {
+			str += fmt.Sprintf(", congestion %s (cwnd %d, ssthresh %d)", c.CongestionAlgorithm, c.SndCwnd, c.SndSsthresh)
+		}
 	}
 
 	str += fmt.Sprintf(", last update epoch: %d, cookie: %d", c.LastUpdateEpoch, c.Cookie)
@@ -418,6 +480,18 @@ func HTTPKeyTuplesFromConn(c ConnectionStats) [2]http.KeyTuple {
 	}
 }
 
+// KafkaKeyTuplesFromConn build the key for the kafka map based on whether the local or remote side is the client.
+func KafkaKeyTuplesFromConn(c ConnectionStats) [2]kafka.KeyTuple {
+	laddr, lport := GetNATLocalAddress(c)
+	raddr, rport := GetNATRemoteAddress(c)
+
+	// like the HTTP data, the Kafka data is indexed as (client, server)
+	return [2]kafka.KeyTuple{
+		kafka.NewKeyTuple(laddr, raddr, lport, rport),
+		kafka.NewKeyTuple(raddr, laddr, rport, lport),
+	}
+}
+
 func generateConnectionKey(c ConnectionStats, buf []byte, useNAT bool) []byte {
 	laddr, sport := c.Source, c.SPort
 	raddr, dport := c.Dest, c.DPort
@@ -448,7 +522,9 @@ func (s StatCounters) Add(other StatCounters) StatCounters {
 	return StatCounters{
 		RecvBytes:      s.RecvBytes + other.RecvBytes,
 		RecvPackets:    s.RecvPackets + other.RecvPackets,
+		TLSBytes:       s.TLSBytes + other.TLSBytes,
 		Retransmits:    s.Retransmits + other.Retransmits,
+		TCPDrops:       s.TCPDrops + other.TCPDrops,
 		SentBytes:      s.SentBytes + other.SentBytes,
 		SentPackets:    s.SentPackets + other.SentPackets,
 		TCPClosed:      s.TCPClosed + other.TCPClosed,
@@ -477,7 +553,9 @@ func (s StatCounters) Max(other StatCounters) StatCounters {
 	return StatCounters{
 		RecvBytes:      maxUint64(s.RecvBytes, other.RecvBytes),
 		RecvPackets:    maxUint64(s.RecvPackets, other.RecvPackets),
+		TLSBytes:       maxUint64(s.TLSBytes, other.TLSBytes),
 		Retransmits:    maxUint32(s.Retransmits, other.Retransmits),
+		TCPDrops:       maxUint32(s.TCPDrops, other.TCPDrops),
 		SentBytes:      maxUint64(s.SentBytes, other.SentBytes),
 		SentPackets:    maxUint64(s.SentPackets, other.SentPackets),
 		TCPClosed:      maxUint32(s.TCPClosed, other.TCPClosed),
@@ -17,6 +17,8 @@ package network
 // need to be treated differently (see below)
 func (s StatCounters) Sub(other StatCounters) (sc StatCounters, underflow bool) {
 	if s.Retransmits < other.Retransmits && s.Retransmits > 0 ||
+		(s.TCPDrops < other.TCPDrops && s.TCPDrops > 0) ||
+		(s.TLSBytes < other.TLSBytes && s.TLSBytes > 0) ||
 		(s.TCPClosed < other.TCPClosed && s.TCPClosed > 0) ||
 		(s.TCPEstablished < other.TCPEstablished && s.TCPEstablished > 0) ||
 		isUnderflow(other.RecvBytes, s.RecvBytes, maxByteCountChange) ||
@@ -42,6 +44,12 @@ func (s StatCounters) Sub(other StatCounters) (sc StatCounters, underflow bool)
 	if s.Retransmits > 0 {
 		sc.Retransmits = s.Retransmits - other.Retransmits
 	}
+	if s.TCPDrops > 0 {
+		sc.TCPDrops = s.TCPDrops - other.TCPDrops
+	}
+	if s.TLSBytes > 0 {
+		sc.TLSBytes = s.TLSBytes - other.TLSBytes
+	}
 	if s.TCPEstablished > 0 {
 		sc.TCPEstablished = s.TCPEstablished - other.TCPEstablished
 	}
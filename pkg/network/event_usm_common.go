@@ -23,6 +23,13 @@ const (
 	ProtocolAMQP         = ProtocolType(model.ProtocolType_protocolAMQP)
 	ProtocolRedis        = ProtocolType(model.ProtocolType_protocolRedis)
 	ProtocolMySQL        = ProtocolType(model.ProtocolType_protocolMySQL)
+	// ProtocolHTTP3, ProtocolSSH, ProtocolRDP, ProtocolCassandra and ProtocolMemcached follow the protocols of the
+	// payload, which doesn't define them yet
+	ProtocolHTTP3     = ProtocolType(model.ProtocolType_protocolMySQL + 1)
+	ProtocolSSH       = ProtocolType(model.ProtocolType_protocolMySQL + 2)
+	ProtocolRDP       = ProtocolType(model.ProtocolType_protocolMySQL + 3)
+	ProtocolCassandra = ProtocolType(model.ProtocolType_protocolMySQL + 4)
+	ProtocolMemcached = ProtocolType(model.ProtocolType_protocolMySQL + 5)
 )
 
 var (
@@ -38,9 +45,54 @@ var (
 		ProtocolAMQP:         {},
 		ProtocolRedis:        {},
 		ProtocolMySQL:        {},
+		ProtocolHTTP3:        {},
+		ProtocolSSH:          {},
+		ProtocolRDP:          {},
+		ProtocolCassandra:    {},
+		ProtocolMemcached:    {},
 	}
 )
 
+// String returns the name of the protocol
+func (p ProtocolType) String() string {
+	switch p {
+	case ProtocolUnclassified:
+		return "unclassified"
+	case ProtocolUnknown:
+		return "unknown"
+	case ProtocolHTTP:
+		return "http"
+	case ProtocolHTTP2:
+		return "http2"
+	case ProtocolTLS:
+		return "tls"
+	case ProtocolKafka:
+		return "kafka"
+	case ProtocolMongo:
+		return "mongo"
+	case ProtocolPostgres:
+		return "postgres"
+	case ProtocolAMQP:
+		return "amqp"
+	case ProtocolRedis:
+		return "redis"
+	case ProtocolMySQL:
+		return "mysql"
+	case ProtocolHTTP3:
+		return "http3"
+	case ProtocolSSH:
+		return "ssh"
+	case ProtocolRDP:
+		return "rdp"
+	case ProtocolCassandra:
+		return "cassandra"
+	case ProtocolMemcached:
+		return "memcached"
+	default:
+		return "unsupported"
+	}
+}
+
 // IsValidProtocolValue checks if a given value is a valid protocol.
 func IsValidProtocolValue(val uint8) bool {
 	_, ok := supportedProtocols[ProtocolType(val)]
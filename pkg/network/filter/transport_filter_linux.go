@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package filter
+
+import (
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/bpf"
+)
+
+const (
+	etherTypeOffset   = 12
+	ipv4FragOffset    = 20
+	ipv4ProtocolOff   = 23
+	ipv6NextHeaderOff = 20
+
+	// captureLength is the number of bytes of the packets captured by the classic filters, the whole packet
+	captureLength = 262144
+)
+
+// TransportFilter returns a classic BPF filter capturing the IPv4 and IPv6 packets over Ethernet whose transport
+// protocol is one of protocols. The IPv4 fragments other than the first one are dropped, as they don't carry the
+// transport header.
+func TransportFilter(protocols ...layers.IPProtocol) ([]bpf.RawInstruction, error) {
+	n := len(protocols)
+	ipv6Start := 3
+	ipv4Start := ipv6Start + 1 + n
+	drop := ipv4Start + 3 + n
+	accept := drop + 1
+
+	// skip returns the number of instructions to skip to jump from the instruction at index from to the one at to
+	skip := func(from, to int) uint8 {
+		return uint8(to - from - 1)
+	}
+
+	insns := []bpf.Instruction{
+		bpf.LoadAbsolute{Size: 2, Off: etherTypeOffset},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(layers.EthernetTypeIPv4), SkipTrue: skip(1, ipv4Start)},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(layers.EthernetTypeIPv6), SkipFalse: skip(2, drop)},
+		bpf.LoadAbsolute{Size: 1, Off: ipv6NextHeaderOff},
+	}
+	for i, proto := range protocols {
+		idx := ipv6Start + 1 + i
+		insns = append(insns, bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(proto), SkipTrue: skip(idx, accept), SkipFalse: skip(idx, nextOrDrop(idx, i, n, drop))})
+	}
+
+	insns = append(insns,
+		bpf.LoadAbsolute{Size: 2, Off: ipv4FragOffset},
+		bpf.JumpIf{Cond: bpf.JumpBitsSet, Val: 0x1fff, SkipTrue: skip(ipv4Start+1, drop)},
+		bpf.LoadAbsolute{Size: 1, Off: ipv4ProtocolOff},
+	)
+	for i, proto := range protocols {
+		idx := ipv4Start + 3 + i
+		insns = append(insns, bpf.JumpIf{Cond: bpf.JumpEqual, Val: uint32(proto), SkipTrue: skip(idx, accept), SkipFalse: skip(idx, nextOrDrop(idx, i, n, drop))})
+	}
+
+	insns = append(insns,
+		bpf.RetConstant{Val: 0},
+		bpf.RetConstant{Val: captureLength},
+	)
+	return bpf.Assemble(insns)
+}
+
+// nextOrDrop returns the index of the instruction following the comparison of the i-th of n protocols, which is at
+// index idx, when the protocol doesn't match
+func nextOrDrop(idx, i, n, drop int) int {
+	if i == n-1 {
+		return drop
+	}
+	return idx + 1
+}
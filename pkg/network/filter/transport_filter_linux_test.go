@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package filter
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/bpf"
+)
+
+func TestTransportFilter(t *testing.T) {
+	raw, err := TransportFilter(layers.IPProtocolTCP)
+	require.NoError(t, err)
+
+	insns := make([]bpf.Instruction, 0, len(raw))
+	for _, r := range raw {
+		insns = append(insns, r.Disassemble())
+	}
+	vm, err := bpf.NewVM(insns)
+	require.NoError(t, err)
+
+	matches := func(pkt []byte) bool {
+		n, err := vm.Run(pkt)
+		require.NoError(t, err)
+		return n > 0
+	}
+
+	assert.True(t, matches(serializePacket(t, layers.EthernetTypeIPv4, layers.IPProtocolTCP, 0)))
+	assert.True(t, matches(serializePacket(t, layers.EthernetTypeIPv6, layers.IPProtocolTCP, 0)))
+	assert.False(t, matches(serializePacket(t, layers.EthernetTypeIPv4, layers.IPProtocolUDP, 0)))
+	assert.False(t, matches(serializePacket(t, layers.EthernetTypeIPv6, layers.IPProtocolUDP, 0)))
+	assert.False(t, matches(serializePacket(t, layers.EthernetTypeIPv4, layers.IPProtocolTCP, 100)))
+	assert.False(t, matches(serializePacket(t, layers.EthernetTypeARP, 0, 0)))
+
+	raw, err = TransportFilter(layers.IPProtocolTCP, layers.IPProtocolUDP)
+	require.NoError(t, err)
+	insns = insns[:0]
+	for _, r := range raw {
+		insns = append(insns, r.Disassemble())
+	}
+	vm, err = bpf.NewVM(insns)
+	require.NoError(t, err)
+
+	assert.True(t, matches(serializePacket(t, layers.EthernetTypeIPv4, layers.IPProtocolUDP, 0)))
+	assert.True(t, matches(serializePacket(t, layers.EthernetTypeIPv6, layers.IPProtocolUDP, 0)))
+	assert.True(t, matches(serializePacket(t, layers.EthernetTypeIPv6, layers.IPProtocolTCP, 0)))
+	assert.False(t, matches(serializePacket(t, layers.EthernetTypeIPv4, layers.IPProtocolICMPv4, 0)))
+}
+
+// serializePacket returns an Ethernet frame of the given type, carrying an IP packet of the given protocol whose
+// fragment offset is fragOffset for IPv4
+func serializePacket(t *testing.T, etherType layers.EthernetType, proto layers.IPProtocol, fragOffset uint16) []byte {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 5},
+		DstMAC:       net.HardwareAddr{0, 1, 2, 3, 4, 6},
+		EthernetType: etherType,
+	}
+	payload := gopacket.Payload(make([]byte, 32))
+
+	var l []gopacket.SerializableLayer
+	switch etherType {
+	case layers.EthernetTypeIPv4:
+		l = []gopacket.SerializableLayer{eth, &layers.IPv4{
+			Version:    4,
+			TTL:        64,
+			Protocol:   proto,
+			FragOffset: fragOffset,
+			SrcIP:      net.ParseIP("10.0.0.1"),
+			DstIP:      net.ParseIP("10.0.0.2"),
+		}, payload}
+	case layers.EthernetTypeIPv6:
+		l = []gopacket.SerializableLayer{eth, &layers.IPv6{
+			Version:    6,
+			HopLimit:   64,
+			NextHeader: proto,
+			SrcIP:      net.ParseIP("fd00::1"),
+			DstIP:      net.ParseIP("fd00::2"),
+		}, payload}
+	default:
+		l = []gopacket.SerializableLayer{eth, payload}
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	require.NoError(t, gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, l...))
+	return buf.Bytes()
+}
@@ -211,16 +211,49 @@ func GetAllSymbolsByName(elfFile *elf.File, symbolSet common.StringSet) (map[str
 		symbolByName[dynamicSymbol.Name] = dynamicSymbol
 	}
 
-	if len(symbolByName) != len(symbolSet) {
-		missingSymbols := make([]string, 0, len(symbolSet)-len(symbolByName))
-		for symbolName := range symbolSet {
-			if _, ok := symbolByName[symbolName]; !ok {
-				missingSymbols = append(missingSymbols, symbolName)
-			}
+	if err := checkMissingSymbols(symbolByName, symbolSet); err != nil {
+		return nil, err
+	}
+
+	return symbolByName, nil
+}
 
+// GetExportedSymbolsByName returns the given symbols from the dynamic symbol table of the ELF file, skipping the ones
+// it imports. It is meant for the stripped binaries, such as envoy, which have no regular symbol table but still
+// export the symbols of the libraries they embed statically.
+func GetExportedSymbolsByName(elfFile *elf.File, symbolSet common.StringSet) (map[string]elf.Symbol, error) {
+	dynamicSymbols, err := getSymbols(elfFile, elf.SHT_DYNSYM, symbolSet)
+	if err != nil {
+		return nil, fmt.Errorf("could not open dynamic symbol section to resolve symbol offset: %w", err)
+	}
+
+	symbolByName := make(map[string]elf.Symbol, len(dynamicSymbols))
+	for _, dynamicSymbol := range dynamicSymbols {
+		if dynamicSymbol.Section == elf.SHN_UNDEF {
+			continue
 		}
-		return nil, fmt.Errorf("failed to find symbols %#v", missingSymbols)
+		symbolByName[dynamicSymbol.Name] = dynamicSymbol
+	}
+
+	if err := checkMissingSymbols(symbolByName, symbolSet); err != nil {
+		return nil, err
 	}
 
 	return symbolByName, nil
 }
+
+// checkMissingSymbols returns an error listing the symbols of the set which were not found
+func checkMissingSymbols(symbolByName map[string]elf.Symbol, symbolSet common.StringSet) error {
+	if len(symbolByName) == len(symbolSet) {
+		return nil
+	}
+
+	missingSymbols := make([]string, 0, len(symbolSet)-len(symbolByName))
+	for symbolName := range symbolSet {
+		if _, ok := symbolByName[symbolName]; !ok {
+			missingSymbols = append(missingSymbols, symbolName)
+		}
+
+	}
+	return fmt.Errorf("failed to find symbols %#v", missingSymbols)
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+const (
+	// istioHBONEPort is the port of the HBONE (HTTP-Based Overlay Network Environment) tunnels, which carry the
+	// traffic of the pods of an Istio ambient mesh between the ztunnel proxies of the nodes, over mutual TLS
+	istioHBONEPort = 15008
+	// istioOutboundCapturePort and istioInboundCapturePort are the ports the traffic of the pods is redirected to, so
+	// it is captured by the proxy of the mesh (ztunnel in ambient mode, or the Envoy sidecar)
+	istioOutboundCapturePort = 15001
+	istioInboundCapturePort  = 15006
+
+	// IstioHBONETag is the tag of the connections of the HBONE tunnels of an Istio ambient mesh. The traffic of these
+	// connections is also reported by the connections of the pods it originates from.
+	IstioHBONETag = "tunnel:istio_hbone"
+)
+
+// AttributeIstioTraffic attributes the connections redirected through the proxies of an Istio mesh:
+//   - the connections of the pods redirected to the proxy are reported with the destination the pod connected to,
+//     rather than the capture port of the proxy the destination was translated to
+//   - the HBONE tunnels established by ztunnel are tagged, so they are not mistaken for opaque node-to-node HTTPS
+//     traffic
+func AttributeIstioTraffic(c *ConnectionStats) {
+	if c.Type != TCP {
+		return
+	}
+
+	if c.IPTranslation != nil {
+		switch c.IPTranslation.ReplSrcPort {
+		case istioOutboundCapturePort, istioInboundCapturePort:
+			c.IPTranslation = nil
+		}
+	}
+
+	_, rport := GetNATRemoteAddress(*c)
+	if rport == istioHBONEPort || c.SPort == istioHBONEPort {
+		if c.Tags == nil {
+			c.Tags = make(map[string]struct{})
+		}
+		c.Tags[IstioHBONETag] = struct{}{}
+	}
+}
@@ -0,0 +1,87 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestAttributeIstioTraffic(t *testing.T) {
+	pod := util.AddressFromString("10.244.1.5")
+	peer := util.AddressFromString("10.244.2.7")
+
+	t.Run("redirected to ztunnel", func(t *testing.T) {
+		c := ConnectionStats{
+			Source: pod,
+			Dest:   peer,
+			SPort:  40000,
+			DPort:  8080,
+			Type:   TCP,
+			IPTranslation: &IPTranslation{
+				ReplSrcIP:   pod,
+				ReplDstIP:   pod,
+				ReplSrcPort: istioOutboundCapturePort,
+				ReplDstPort: 40000,
+			},
+		}
+		AttributeIstioTraffic(&c)
+
+		raddr, rport := GetNATRemoteAddress(c)
+		assert.Equal(t, peer, raddr)
+		assert.Equal(t, uint16(8080), rport)
+		assert.NotContains(t, c.Tags, IstioHBONETag)
+	})
+
+	t.Run("outgoing hbone tunnel", func(t *testing.T) {
+		c := ConnectionStats{
+			Source:    pod,
+			Dest:      peer,
+			SPort:     40000,
+			DPort:     istioHBONEPort,
+			Type:      TCP,
+			Direction: OUTGOING,
+		}
+		AttributeIstioTraffic(&c)
+		assert.Contains(t, c.Tags, IstioHBONETag)
+	})
+
+	t.Run("incoming hbone tunnel", func(t *testing.T) {
+		c := ConnectionStats{
+			Source:    peer,
+			Dest:      pod,
+			SPort:     istioHBONEPort,
+			DPort:     40000,
+			Type:      TCP,
+			Direction: INCOMING,
+		}
+		AttributeIstioTraffic(&c)
+		assert.Contains(t, c.Tags, IstioHBONETag)
+	})
+
+	t.Run("regular connection", func(t *testing.T) {
+		translation := &IPTranslation{
+			ReplSrcIP:   peer,
+			ReplDstIP:   pod,
+			ReplSrcPort: 8080,
+			ReplDstPort: 40000,
+		}
+		c := ConnectionStats{
+			Source:        pod,
+			Dest:          util.AddressFromString("10.96.0.10"),
+			SPort:         40000,
+			DPort:         80,
+			Type:          TCP,
+			IPTranslation: translation,
+		}
+		AttributeIstioTraffic(&c)
+		assert.Equal(t, translation, c.IPTranslation)
+		assert.Nil(t, c.Tags)
+	})
+}
@@ -0,0 +1,35 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux && docker
+// +build linux,docker
+
+package java
+
+import (
+	"context"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/docker"
+)
+
+// containerLabels returns the labels of the container, as reported by the docker runtime
+func containerLabels(containerID string) (map[string]string, error) {
+	du, err := docker.GetDockerUtil()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	container, err := du.Inspect(ctx, containerID, false)
+	if err != nil {
+		return nil, err
+	}
+	if container.Config == nil {
+		return nil, nil
+	}
+	return container.Config.Labels, nil
+}
@@ -0,0 +1,16 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux && !docker
+// +build linux,!docker
+
+package java
+
+import "errors"
+
+// containerLabels is not supported without the docker runtime
+func containerLabels(_ string) (map[string]string, error) {
+	return nil, errors.New("the container labels require the docker runtime")
+}
@@ -0,0 +1,183 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package java
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/gopsutil/process"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// containerLabel matches the label of a container, any value matching when value is empty
+type containerLabel struct {
+	key   string
+	value string
+}
+
+func (l containerLabel) match(labels map[string]string) bool {
+	value, ok := labels[l.key]
+	return ok && (l.value == "" || l.value == value)
+}
+
+// injectionRules are the rules matching the java processes by their command line, their user or the labels of their
+// container, a process matching the rules if it matches any of them
+type injectionRules struct {
+	cmdline *regexp.Regexp
+	uids    map[uint32]struct{}
+	labels  []containerLabel
+}
+
+func newInjectionRules(cmdline string, uids []string, labels []string) (injectionRules, error) {
+	var r injectionRules
+	if cmdline != "" {
+		re, err := regexp.Compile(cmdline)
+		if err != nil {
+			return r, fmt.Errorf("invalid command line regex %q: %w", cmdline, err)
+		}
+		r.cmdline = re
+	}
+	for _, uid := range uids {
+		id, err := strconv.ParseUint(strings.TrimSpace(uid), 10, 32)
+		if err != nil {
+			return r, fmt.Errorf("invalid user ID %q: %w", uid, err)
+		}
+		if r.uids == nil {
+			r.uids = make(map[uint32]struct{}, len(uids))
+		}
+		r.uids[uint32(id)] = struct{}{}
+	}
+	for _, label := range labels {
+		key, value, _ := strings.Cut(strings.TrimSpace(label), "=")
+		if key == "" {
+			return r, fmt.Errorf("invalid container label %q", label)
+		}
+		r.labels = append(r.labels, containerLabel{key: key, value: value})
+	}
+	return r, nil
+}
+
+func (r injectionRules) empty() bool {
+	return r.cmdline == nil && len(r.uids) == 0 && len(r.labels) == 0
+}
+
+// match returns whether the process matches any of the rules, labels being called only when the rules use them. The
+// labels of a container which can't be retrieved match the rules when matchUnknownLabels is set.
+func (r injectionRules) match(cmdline string, uid uint32, labels func() (map[string]string, error), matchUnknownLabels bool) bool {
+	if r.cmdline != nil && r.cmdline.MatchString(cmdline) {
+		return true
+	}
+	if _, ok := r.uids[uid]; ok {
+		return true
+	}
+	if len(r.labels) == 0 {
+		return false
+	}
+
+	containerLabels, err := labels()
+	if err != nil {
+		return matchUnknownLabels
+	}
+	for _, label := range r.labels {
+		if label.match(containerLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+// InjectionFilter selects the java processes the USM agent is injected in, so that latency sensitive JVMs can be
+// excluded. The blocking rules take precedence over the allowing ones, and every process is allowed when there is no
+// allowing rule.
+type InjectionFilter struct {
+	allow injectionRules
+	block injectionRules
+}
+
+// NewInjectionFilter returns the injection filter of the java_tls rules of the configuration
+func NewInjectionFilter(c *config.Config) (*InjectionFilter, error) {
+	allow, err := newInjectionRules(c.JavaTLSAllowRegex, c.JavaTLSAllowUIDs, c.JavaTLSAllowContainerLabels)
+	if err != nil {
+		return nil, fmt.Errorf("java TLS allow rules: %w", err)
+	}
+	block, err := newInjectionRules(c.JavaTLSBlockRegex, c.JavaTLSBlockUIDs, c.JavaTLSBlockContainerLabels)
+	if err != nil {
+		return nil, fmt.Errorf("java TLS block rules: %w", err)
+	}
+	return &InjectionFilter{allow: allow, block: block}, nil
+}
+
+// Allowed returns whether the USM agent can be injected in the java process
+func (f *InjectionFilter) Allowed(pid int) bool {
+	if f.allow.empty() && f.block.empty() {
+		return true
+	}
+
+	proc, err := process.NewProcess(int32(pid))
+	if err != nil {
+		return false
+	}
+	cmdline, err := proc.Cmdline()
+	if err != nil {
+		return false
+	}
+	uids, err := proc.Uids()
+	if err != nil || len(uids) < 2 {
+		return false
+	}
+	// the effective user of the process, as reported by ps
+	uid := uint32(uids[1])
+
+	var labels map[string]string
+	var labelsErr error
+	labelsFetched := false
+	getLabels := func() (map[string]string, error) {
+		if !labelsFetched {
+			labels, labelsErr = processContainerLabels(pid)
+			labelsFetched = true
+		}
+		return labels, labelsErr
+	}
+
+	allowed := f.allowed(cmdline, uid, getLabels)
+	if !allowed {
+		log.Debugf("java TLS injection of pid %d skipped by the java_tls rules", pid)
+	}
+	return allowed
+}
+
+func (f *InjectionFilter) allowed(cmdline string, uid uint32, labels func() (map[string]string, error)) bool {
+	// a process whose container labels are unknown is blocked, rather than risking to inject an excluded JVM
+	if f.block.match(cmdline, uid, labels, true) {
+		return false
+	}
+	return f.allow.empty() || f.allow.match(cmdline, uid, labels, false)
+}
+
+// processContainerLabels returns the labels of the container of the process, or nil if it doesn't run in a container
+func processContainerLabels(pid int) (map[string]string, error) {
+	// the cgroup v1 hierarchies are keyed by controller, the cgroup v2 one has no controller
+	for _, controller := range []string{"memory", ""} {
+		containerID, err := cgroups.IdentiferFromCgroupReferences(util.HostProc(), strconv.Itoa(pid), controller, cgroups.ContainerFilter)
+		if err != nil {
+			return nil, err
+		}
+		if containerID != "" {
+			return containerLabels(containerID)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package java
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestInjectionFilter(t *testing.T) {
+	labels := func(l map[string]string) func() (map[string]string, error) {
+		return func() (map[string]string, error) { return l, nil }
+	}
+	unknownLabels := func() (map[string]string, error) { return nil, errors.New("no runtime") }
+
+	t.Run("no rules", func(t *testing.T) {
+		f, err := NewInjectionFilter(&config.Config{})
+		require.NoError(t, err)
+		assert.True(t, f.allowed("java -jar trading.jar", 1000, unknownLabels))
+	})
+
+	t.Run("block rules", func(t *testing.T) {
+		f, err := NewInjectionFilter(&config.Config{
+			JavaTLSBlockRegex:           "trading",
+			JavaTLSBlockUIDs:            []string{"1001"},
+			JavaTLSBlockContainerLabels: []string{"usm=off", "latency-sensitive"},
+		})
+		require.NoError(t, err)
+		assert.False(t, f.allowed("java -jar trading.jar", 1000, labels(nil)))
+		assert.False(t, f.allowed("java -jar shop.jar", 1001, labels(nil)))
+		assert.False(t, f.allowed("java -jar shop.jar", 1000, labels(map[string]string{"usm": "off"})))
+		assert.False(t, f.allowed("java -jar shop.jar", 1000, labels(map[string]string{"latency-sensitive": ""})))
+		assert.True(t, f.allowed("java -jar shop.jar", 1000, labels(map[string]string{"usm": "on"})))
+		// a container whose labels are unknown is blocked
+		assert.False(t, f.allowed("java -jar shop.jar", 1000, unknownLabels))
+	})
+
+	t.Run("allow rules", func(t *testing.T) {
+		f, err := NewInjectionFilter(&config.Config{
+			JavaTLSAllowRegex:           `-jar \S*shop`,
+			JavaTLSAllowUIDs:            []string{"2000"},
+			JavaTLSAllowContainerLabels: []string{"team=payments"},
+			JavaTLSBlockRegex:           "legacy",
+		})
+		require.NoError(t, err)
+		assert.True(t, f.allowed("java -jar /app/shop.jar", 1000, unknownLabels))
+		assert.True(t, f.allowed("java -jar app.jar", 2000, labels(nil)))
+		assert.True(t, f.allowed("java -jar app.jar", 1000, labels(map[string]string{"team": "payments"})))
+		assert.False(t, f.allowed("java -jar app.jar", 1000, labels(map[string]string{"team": "search"})))
+		assert.False(t, f.allowed("java -jar app.jar", 1000, unknownLabels))
+		// the block rules take precedence
+		assert.False(t, f.allowed("java -jar /app/shop-legacy.jar", 2000, labels(nil)))
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		_, err := NewInjectionFilter(&config.Config{JavaTLSAllowRegex: "("})
+		assert.Error(t, err)
+		_, err = NewInjectionFilter(&config.Config{JavaTLSBlockUIDs: []string{"root"}})
+		assert.Error(t, err)
+		_, err = NewInjectionFilter(&config.Config{JavaTLSBlockContainerLabels: []string{"=off"}})
+		assert.Error(t, err)
+	})
+}
@@ -48,8 +48,14 @@ type Hotspot struct {
 	socketPath string
 	uid        int
 	gid        int
+
+	// attachTimeout bounds the time the JVM takes to create its socket once signaled
+	attachTimeout time.Duration
 }
 
+// hotspotAttachTimeout is the time a HotSpot JVM takes at most to start its attach listener once signaled
+const hotspotAttachTimeout = 6 * time.Second
+
 // NewHotspot create an object to connect to a JVM hotspot
 // pid (host pid) and nsPid (within the namespace pid)
 //
@@ -57,8 +63,9 @@ type Hotspot struct {
 // So we can't support container on Centos 7 (kernel 3.10)
 func NewHotspot(pid int, nsPid int) (*Hotspot, error) {
 	h := &Hotspot{
-		pid:   pid,
-		nsPid: nsPid,
+		pid:           pid,
+		nsPid:         nsPid,
+		attachTimeout: hotspotAttachTimeout,
 	}
 	// Centos 7 workaround to support host environment
 	if h.nsPid == 0 {
@@ -105,9 +112,14 @@ func getPathOwner(path string) (uint32, uint32, error) {
 //	o dstPath is path to the copy of agent-usm.jar (from container perspective), this would be pass to the hotspot command
 //	o cleanup must be called to remove the created file
 func (h *Hotspot) copyAgent(agent string, uid int, gid int) (dstPath string, cleanup func(), err error) {
-	dstPath = h.cwd + "/" + filepath.Base(agent)
+	return copyAgent(h.root, h.cwd, agent, uid, gid)
+}
+
+// copyAgent copy the agent in the cwd of a java process, root being the root of the process viewed by the host
+func copyAgent(root string, cwd string, agent string, uid int, gid int) (dstPath string, cleanup func(), err error) {
+	dstPath = cwd + "/" + filepath.Base(agent)
 	// path from the host point of view pointing to the process root namespace (/proc/pid/root/usr/...)
-	nsDstPath := h.root + dstPath
+	nsDstPath := root + dstPath
 	if dst, err := os.Stat(nsDstPath); err == nil {
 		// if the destination file already exist
 		// check if it's not the source agent file
@@ -336,7 +348,7 @@ func (h *Hotspot) attachJVMProtocol(uid int, gid int) error {
 		return fmt.Errorf("process %d/%d SIGQUIT failed : %s", h.pid, h.nsPid, err)
 	}
 
-	end := time.Now().Add(6 * time.Second)
+	end := time.Now().Add(h.attachTimeout)
 	for end.After(time.Now()) {
 		time.Sleep(200 * time.Millisecond)
 		if h.isSocketExists() {
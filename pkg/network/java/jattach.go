@@ -11,6 +11,7 @@ package java
 import (
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/gopsutil/process"
 )
@@ -25,12 +26,29 @@ import (
 // SIGQUIT is sent as part of the hotspot protocol handshake
 const MINIMUM_JAVA_AGE_TO_ATTACH_MS = 10000
 
+// zingAttachTimeout is the time a Zing JVM takes at most to start its attach listener once signaled, Zing implementing
+// the HotSpot attach mechanism but creating its socket later than HotSpot
+const zingAttachTimeout = 20 * time.Second
+
 func injectAttach(pid int, agent string, args string, nsPid int, fsUid int, fsGid int) error {
+	vendor := detectJVMVendor(util.HostProc(), pid, nsPid)
+	log.Debugf("java attach pid %d/%d detected as a %s JVM", pid, nsPid, vendor)
+
+	if vendor == openJ9JVM {
+		j, err := NewOpenJ9(pid, nsPid)
+		if err != nil {
+			return err
+		}
+		return j.Attach(agent, args, fsUid, fsGid)
+	}
+
 	h, err := NewHotspot(pid, nsPid)
 	if err != nil {
 		return err
 	}
-
+	if vendor == zingJVM {
+		h.attachTimeout = zingAttachTimeout
+	}
 	return h.Attach(agent, args, fsUid, fsGid)
 }
 
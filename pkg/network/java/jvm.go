@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package java
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// jvmVendor is the implementation of a JVM, which tells the attach mechanism it supports
+type jvmVendor int
+
+const (
+	hotspotJVM jvmVendor = iota
+	openJ9JVM
+	zingJVM
+)
+
+func (v jvmVendor) String() string {
+	switch v {
+	case openJ9JVM:
+		return "openj9"
+	case zingJVM:
+		return "zing"
+	default:
+		return "hotspot"
+	}
+}
+
+// detectJVMVendor detects the implementation of the JVM of a process from procfs:
+//
+//	o OpenJ9 and IBM J9 advertise their attach listener with the attachInfo file of a directory named by their
+//	  (namespaced) pid, in the .com_ibm_tools_attach directory of their temporary directory
+//	o Zing (Azul Platform Prime) maps its libjvm.so from its installation directory, named after it
+//	o any other JVM, such as the OpenJDK builds, is assumed to be a HotSpot one
+func detectJVMVendor(procRoot string, pid int, nsPid int) jvmVendor {
+	attachInfo := fmt.Sprintf("%s/%d/root/tmp/%s/%d/attachInfo", procRoot, pid, openJ9AttachDir, nsPid)
+	if _, err := os.Stat(attachInfo); err == nil {
+		return openJ9JVM
+	}
+
+	maps, err := os.Open(fmt.Sprintf("%s/%d/maps", procRoot, pid))
+	if err != nil {
+		return hotspotJVM
+	}
+	defer maps.Close()
+
+	scanner := bufio.NewScanner(maps)
+	for scanner.Scan() {
+		// the path of the mapped file is the last field of the line
+		line := scanner.Bytes()
+		path := line[bytes.LastIndexByte(line, ' ')+1:]
+		if !bytes.HasSuffix(path, []byte("/libjvm.so")) {
+			continue
+		}
+		if bytes.Contains(bytes.ToLower(path), []byte("zing")) {
+			return zingJVM
+		}
+		return hotspotJVM
+	}
+	return hotspotJVM
+}
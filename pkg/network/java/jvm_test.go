@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package java
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectJVMVendor(t *testing.T) {
+	procRoot := t.TempDir()
+	fakeProcess := func(pid string, maps string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(procRoot, pid, "root", "tmp"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(procRoot, pid, "maps"), []byte(maps), 0644))
+	}
+
+	fakeProcess("100", "7f3c2a000000-7f3c2b000000 r-xp 00000000 08:01 1234 /usr/lib/jvm/java-17-openjdk-amd64/lib/server/libjvm.so\n")
+	assert.Equal(t, hotspotJVM, detectJVMVendor(procRoot, 100, 100))
+
+	fakeProcess("200", "7f3c2a000000-7f3c2b000000 r-xp 00000000 08:01 1234 /opt/zing/zing-jdk17/lib/server/libjvm.so\n")
+	assert.Equal(t, zingJVM, detectJVMVendor(procRoot, 200, 200))
+
+	// the directory of the attach listener is named after the namespaced pid
+	fakeProcess("300", "7f3c2a000000-7f3c2b000000 r-xp 00000000 08:01 1234 /opt/java/openjdk/lib/default/libj9vm29.so\n")
+	attachDir := filepath.Join(procRoot, "300", "root", "tmp", openJ9AttachDir, "7")
+	require.NoError(t, os.MkdirAll(attachDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(attachDir, "attachInfo"), nil, 0644))
+	assert.Equal(t, openJ9JVM, detectJVMVendor(procRoot, 300, 7))
+	assert.Equal(t, hotspotJVM, detectJVMVendor(procRoot, 300, 300))
+
+	// the process is gone
+	assert.Equal(t, hotspotJVM, detectJVMVendor(procRoot, 400, 400))
+}
@@ -0,0 +1,362 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package java
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// openJ9AttachDir is the directory of the attach mechanism of OpenJ9, in the temporary directory of the JVMs
+	openJ9AttachDir = ".com_ibm_tools_attach"
+
+	// openJ9NotifierProjID is the project identifier of the key of the notification semaphore
+	openJ9NotifierProjID = 0xa1
+
+	// openJ9AttachTimeout bounds the time the JVM takes to connect back once notified
+	openJ9AttachTimeout = 5 * time.Second
+)
+
+// OpenJ9 and IBM J9 have their own attach protocol, the JVM connecting back to the attacher:
+//
+//	o lock the _attachlock file, shared by all the attachers
+//	o listen on a TCP port of the loopback of the java process
+//	o write a random key and the port in the replyInfo file of the directory of the java process
+//	o lock the attachNotificationSync file of every JVM and post the _notifier semaphore once per JVM
+//	o the JVMs wake up, and the one finding a replyInfo file connects and sends "ATTACH_CONNECTED <key>"
+//	o we can write null terminated commands through the connection
+//
+// The directory of a java process is named after its namespaced pid, in the .com_ibm_tools_attach directory of its
+// temporary directory. The semaphore and the loopback are the ones of the IPC and network namespaces of the process.
+type OpenJ9 struct {
+	pid   int
+	nsPid int
+	root  string
+	cwd   string // viewed by the process
+
+	// attachPath is the .com_ibm_tools_attach directory viewed by the host
+	attachPath string
+}
+
+// NewOpenJ9 create an object to connect to an OpenJ9 or IBM J9 JVM
+// pid (host pid) and nsPid (within the namespace pid)
+func NewOpenJ9(pid int, nsPid int) (*OpenJ9, error) {
+	j := &OpenJ9{
+		pid:   pid,
+		nsPid: nsPid,
+	}
+	if j.nsPid == 0 {
+		j.nsPid = pid
+	}
+
+	var err error
+	procPath := fmt.Sprintf("%s/%d", util.HostProc(), pid)
+	j.root = procPath + "/root"
+	j.cwd, err = os.Readlink(procPath + "/cwd")
+	if err != nil {
+		return nil, err
+	}
+	j.attachPath = fmt.Sprintf("%s/tmp/%s", j.root, openJ9AttachDir)
+	return j, nil
+}
+
+// lockFile takes an exclusive lock on the file, which is released by closing it
+func lockFile(path string, flag int) (*os.File, error) {
+	f, err := os.OpenFile(path, flag, 0666)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(f.Fd()), unix.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// lockNotificationFiles locks the attachNotificationSync file of every JVM, so that the JVMs woken up by the
+// semaphore wait for the replyInfo file to be written
+func (j *OpenJ9) lockNotificationFiles() []*os.File {
+	entries, err := os.ReadDir(j.attachPath)
+	if err != nil {
+		return nil
+	}
+
+	var locks []*os.File
+	for _, entry := range entries {
+		// the directories of the JVMs are named after their pid, the other ones being the locks and the semaphore
+		if !entry.IsDir() || entry.Name()[0] < '1' || entry.Name()[0] > '9' {
+			continue
+		}
+		lock, err := lockFile(fmt.Sprintf("%s/%s/attachNotificationSync", j.attachPath, entry.Name()), os.O_RDWR)
+		if err == nil {
+			locks = append(locks, lock)
+		}
+	}
+	return locks
+}
+
+// sembuf is the operation of semop(2)
+type sembuf struct {
+	num uint16
+	op  int16
+	flg int16
+}
+
+// notify posts the notification semaphore count times, a value of 1 waking up the JVMs and -1 consuming the posts
+// which weren't consumed by them
+func (j *OpenJ9) notify(value int16, count int) error {
+	if count == 0 {
+		return nil
+	}
+
+	// the key of the semaphore is the one of ftok(3), the device and the inode being the same in every mount namespace
+	info, err := os.Stat(j.attachPath + "/_notifier")
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if stat == nil || !ok {
+		return fmt.Errorf("stat cast issue on path %s %T", j.attachPath+"/_notifier", info.Sys())
+	}
+	key := int32(stat.Ino&0xffff | (stat.Dev&0xff)<<16 | openJ9NotifierProjID<<24)
+
+	return withIPCNamespace(j.pid, func() error {
+		id, _, errno := unix.Syscall(unix.SYS_SEMGET, uintptr(key), 1, unix.IPC_CREAT|0666)
+		if errno != 0 {
+			return fmt.Errorf("semget of the notifier of %d/%d failed : %w", j.pid, j.nsPid, errno)
+		}
+		op := sembuf{num: 0, op: value, flg: unix.IPC_NOWAIT}
+		for ; count > 0; count-- {
+			// the operations fail with EAGAIN once the posts are consumed
+			_, _, _ = unix.Syscall(unix.SYS_SEMOP, id, uintptr(unsafe.Pointer(&op)), 1)
+		}
+		return nil
+	})
+}
+
+// withIPCNamespace executes the function within the IPC namespace of the process, the semaphores being bound to it
+func withIPCNamespace(pid int, fn func() error) error {
+	ns, err := os.Open(fmt.Sprintf("%s/%d/ns/ipc", util.HostProc(), pid))
+	if err != nil {
+		return err
+	}
+	defer ns.Close()
+
+	runtime.LockOSThread()
+	prevNS, err := os.Open(fmt.Sprintf("/proc/%d/task/%d/ns/ipc", os.Getpid(), unix.Gettid()))
+	if err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	defer prevNS.Close()
+
+	if err := unix.Setns(int(ns.Fd()), unix.CLONE_NEWIPC); err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	fnErr := fn()
+	if err := unix.Setns(int(prevNS.Fd()), unix.CLONE_NEWIPC); err != nil {
+		// the thread is left locked, so that it's terminated with the goroutine instead of being reused
+		return err
+	}
+	runtime.UnlockOSThread()
+	return fnErr
+}
+
+// listen on a port of the loopback of the network namespace of the java process
+func (j *OpenJ9) listen() (*net.TCPListener, error) {
+	ns, err := util.GetNetNamespaceFromPid(util.HostProc(), j.pid)
+	if err != nil {
+		return nil, err
+	}
+	defer ns.Close()
+
+	var l net.Listener
+	err = util.WithNS(ns, func() error {
+		var err error
+		l, err = net.Listen("tcp", "127.0.0.1:0")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l.(*net.TCPListener), nil
+}
+
+// writeReplyInfo writes the key and the port the JVM has to connect to, in a file readable by the java process
+func (j *OpenJ9) writeReplyInfo(key uint64, port int, uid int, gid int) (cleanup func(), err error) {
+	path := fmt.Sprintf("%s/%d/replyInfo", j.attachPath, j.nsPid)
+	if err := os.WriteFile(path, []byte(fmt.Sprintf("%016x\n%d\n", key, port)), 0600); err != nil {
+		return nil, err
+	}
+	if err := syscall.Chown(path, uid, gid); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return func() {
+		os.Remove(path)
+	}, nil
+}
+
+// accept the connection of the JVM, which must send the key of the replyInfo file
+func (j *OpenJ9) accept(l *net.TCPListener, key uint64) (net.Conn, error) {
+	if err := l.SetDeadline(time.Now().Add(openJ9AttachTimeout)); err != nil {
+		return nil, err
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		return nil, fmt.Errorf("the java process %d/%d didn't connect : %w", j.pid, j.nsPid, err)
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(3 * time.Second)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// "ATTACH_CONNECTED <16 hex digits of the key> "
+	buf := make([]byte, len("ATTACH_CONNECTED ")+16)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	connectedKey, err := parseConnected(buf)
+	if err != nil || connectedKey != key {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected response of the java process %d/%d : %q", j.pid, j.nsPid, buf)
+	}
+	return conn, nil
+}
+
+func parseConnected(buf []byte) (uint64, error) {
+	hexKey := strings.TrimPrefix(string(buf), "ATTACH_CONNECTED ")
+	if hexKey == string(buf) {
+		return 0, errors.New("missing ATTACH_CONNECTED")
+	}
+	return strconv.ParseUint(hexKey, 16, 64)
+}
+
+// connect runs the attach protocol, the returned connection being the one of the JVM
+func (j *OpenJ9) connect(uid int, gid int) (net.Conn, error) {
+	attachLock, err := lockFile(j.attachPath+"/_attachlock", os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		return nil, err
+	}
+	defer attachLock.Close()
+
+	l, err := j.listen()
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+
+	var rawKey [8]byte
+	if _, err := rand.Read(rawKey[:]); err != nil {
+		return nil, err
+	}
+	key := binary.LittleEndian.Uint64(rawKey[:])
+	replyCleanup, err := j.writeReplyInfo(key, l.Addr().(*net.TCPAddr).Port, uid, gid)
+	if err != nil {
+		return nil, err
+	}
+	defer replyCleanup()
+
+	locks := j.lockNotificationFiles()
+	if err := j.notify(1, len(locks)); err != nil {
+		log.Debugf("java attach openj9 pid %d/%d notification failed : %s", j.pid, j.nsPid, err)
+	}
+	conn, err := j.accept(l, key)
+
+	for _, lock := range locks {
+		lock.Close()
+	}
+	if err := j.notify(-1, len(locks)); err != nil {
+		log.Debugf("java attach openj9 pid %d/%d notification reset failed : %s", j.pid, j.nsPid, err)
+	}
+	return conn, err
+}
+
+// command sends the command and returns the response of the JVM, both being null terminated
+func (j *OpenJ9) command(conn net.Conn, cmd string) (string, error) {
+	if _, err := conn.Write(append([]byte(cmd), 0)); err != nil {
+		return "", err
+	}
+
+	var response []byte
+	buf := make([]byte, 8192)
+	for {
+		n, err := conn.Read(buf)
+		response = append(response, buf[:n]...)
+		if i := bytes.IndexByte(response, 0); i >= 0 {
+			return string(response[:i]), nil
+		}
+		if err != nil {
+			return string(response), err
+		}
+	}
+}
+
+// openJ9LoadCommand translates the hotspot load command of the agent, a .jar being loaded by the instrument agent
+func openJ9LoadCommand(agentPath string, args string) string {
+	if strings.HasSuffix(agentPath, ".jar") {
+		if args != "" {
+			agentPath += "=" + args
+		}
+		return fmt.Sprintf("ATTACH_LOADAGENT(instrument,%s)", agentPath)
+	}
+	return fmt.Sprintf("ATTACH_LOADAGENTPATH(%s,%s)", agentPath, args)
+}
+
+// Attach an agent to the OpenJ9 JVM, uid/gid must be accessible read-only by the targeted JVM
+func (j *OpenJ9) Attach(agentPath string, args string, uid int, gid int) error {
+	// copy the agent in the cwd of the process and change his owner/group
+	dstAgentPath, agentCleanup, err := copyAgent(j.root, j.cwd, agentPath, uid, gid)
+	if err != nil {
+		return err
+	}
+	defer agentCleanup()
+
+	conn, err := j.connect(uid, gid)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	loadCommand := openJ9LoadCommand(dstAgentPath, args)
+	response, err := j.command(conn, loadCommand)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(response, "ATTACH_ACK") && !strings.HasPrefix(response, "ATTACH_RESULT=") {
+		log.Debugf("java attach openj9 pid %d/%d command '%s' failed : %s", j.pid, j.nsPid, loadCommand, response)
+		return fmt.Errorf("command sent to openj9 JVM '%s' failed, response text:\n%s\n", loadCommand, response)
+	}
+
+	// the JVM waits for the next command until we detach
+	if _, err := j.command(conn, "ATTACH_DETACHED"); err != nil {
+		log.Debugf("java attach openj9 pid %d/%d detach failed : %s", j.pid, j.nsPid, err)
+	}
+	return nil
+}
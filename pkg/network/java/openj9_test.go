@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+// +build linux
+
+package java
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenJ9LoadCommand(t *testing.T) {
+	assert.Equal(t, "ATTACH_LOADAGENT(instrument,/app/agent-usm.jar=dd.debug=true)", openJ9LoadCommand("/app/agent-usm.jar", "dd.debug=true"))
+	assert.Equal(t, "ATTACH_LOADAGENT(instrument,/app/agent-usm.jar)", openJ9LoadCommand("/app/agent-usm.jar", ""))
+	assert.Equal(t, "ATTACH_LOADAGENTPATH(/app/agent.so,opt)", openJ9LoadCommand("/app/agent.so", "opt"))
+}
+
+func TestParseConnected(t *testing.T) {
+	key, err := parseConnected([]byte("ATTACH_CONNECTED 00c0ffee12345678"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0x00c0ffee12345678), key)
+
+	_, err = parseConnected([]byte("ATTACH_ERR      00c0ffee12345678"))
+	assert.Error(t, err)
+}
@@ -22,6 +22,16 @@ func GetNATLocalAddress(c ConnectionStats) (util.Address, uint16) {
 	return localIP, localPort
 }
 
+// GetNATService returns the original destination of a connection whose destination was translated by DNAT, such as
+// the ClusterIP and port of a Kubernetes Service, and false if the destination of the connection was not translated
+func GetNATService(c ConnectionStats) (util.Address, uint16, bool) {
+	raddr, rport := GetNATRemoteAddress(c)
+	if raddr == c.Dest && rport == c.DPort {
+		return util.Address{}, 0, false
+	}
+	return c.Dest, c.DPort, true
+}
+
 // GetNATRemoteAddress returns the translated (remote ip, remote port) pair
 func GetNATRemoteAddress(c ConnectionStats) (util.Address, uint16) {
 	remoteIP := c.Dest
@@ -34,3 +44,17 @@ func GetNATRemoteAddress(c ConnectionStats) (util.Address, uint16) {
 	}
 	return remoteIP, remotePort
 }
+
+// TranslateSocketLB reports a connection whose destination was translated at connect time from the given service
+// address, as done by socket-level load balancing, the same way as a connection translated by conntrack: the
+// destination is the service address, and the translation holds the backend the connection was established with
+func TranslateSocketLB(c *ConnectionStats, addr util.Address, port uint16) {
+	c.IPTranslation = &IPTranslation{
+		ReplSrcIP:   c.Dest,
+		ReplDstIP:   c.Source,
+		ReplSrcPort: c.DPort,
+		ReplDstPort: c.SPort,
+	}
+	c.Dest = addr
+	c.DPort = port
+}
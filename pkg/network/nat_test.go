@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestTranslateSocketLB(t *testing.T) {
+	pod := util.AddressFromString("10.244.1.5")
+	backend := util.AddressFromString("10.244.2.7")
+	service := util.AddressFromString("10.96.0.10")
+
+	c := ConnectionStats{
+		Source: pod,
+		Dest:   backend,
+		SPort:  41234,
+		DPort:  8080,
+		Type:   TCP,
+		Family: AFINET,
+	}
+	TranslateSocketLB(&c, service, 80)
+
+	assert.Equal(t, service, c.Dest)
+	assert.Equal(t, uint16(80), c.DPort)
+
+	raddr, rport := GetNATRemoteAddress(c)
+	assert.Equal(t, backend, raddr)
+	assert.Equal(t, uint16(8080), rport)
+
+	laddr, lport := GetNATLocalAddress(c)
+	assert.Equal(t, pod, laddr)
+	assert.Equal(t, uint16(41234), lport)
+
+	saddr, sport, ok := GetNATService(c)
+	assert.True(t, ok)
+	assert.Equal(t, service, saddr)
+	assert.Equal(t, uint16(80), sport)
+}
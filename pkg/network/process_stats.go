@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import "sort"
+
+// ProcessStats holds the network activity of a process during the interval of a client, aggregated from the stats of
+// its connections
+type ProcessStats struct {
+	Pid uint32 `json:"pid"`
+
+	SentBytes   uint64 `json:"sent_bytes"`
+	RecvBytes   uint64 `json:"recv_bytes"`
+	SentPackets uint64 `json:"sent_packets"`
+	RecvPackets uint64 `json:"recv_packets"`
+	Retransmits uint32 `json:"retransmits"`
+
+	// TCPConnections and UDPConnections are the number of connections of the process which were open during the
+	// interval
+	TCPConnections uint32 `json:"tcp_connections"`
+	UDPConnections uint32 `json:"udp_connections"`
+	// TCPEstablished and TCPClosed are the number of TCP connections the process established and closed during the
+	// interval
+	TCPEstablished uint32 `json:"tcp_established"`
+	TCPClosed      uint32 `json:"tcp_closed"`
+}
+
+// AggregateProcessStats aggregates the activity of the connections during the interval of a client per process, sorted
+// by PID. The connections without PID, such as the ones of the eBPF-less mode, are skipped.
+func AggregateProcessStats(conns []ConnectionStats) []ProcessStats {
+	byPID := make(map[uint32]*ProcessStats)
+	for i := range conns {
+		c := &conns[i]
+		if c.Pid == 0 {
+			continue
+		}
+		stats, ok := byPID[c.Pid]
+		if !ok {
+			stats = &ProcessStats{Pid: c.Pid}
+			byPID[c.Pid] = stats
+		}
+
+		stats.SentBytes += c.Last.SentBytes
+		stats.RecvBytes += c.Last.RecvBytes
+		stats.SentPackets += c.Last.SentPackets
+		stats.RecvPackets += c.Last.RecvPackets
+		stats.Retransmits += c.Last.Retransmits
+		stats.TCPEstablished += c.Last.TCPEstablished
+		stats.TCPClosed += c.Last.TCPClosed
+		if c.Type == TCP {
+			stats.TCPConnections++
+		} else {
+			stats.UDPConnections++
+		}
+	}
+
+	processes := make([]ProcessStats, 0, len(byPID))
+	for _, stats := range byPID {
+		processes = append(processes, *stats)
+	}
+	sort.Slice(processes, func(i, j int) bool { return processes[i].Pid < processes[j].Pid })
+	return processes
+}
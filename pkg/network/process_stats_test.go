@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateProcessStats(t *testing.T) {
+	conns := []ConnectionStats{
+		{Pid: 20, Type: TCP, Last: StatCounters{SentBytes: 100, RecvBytes: 10, SentPackets: 2, RecvPackets: 1, Retransmits: 1, TCPEstablished: 1}},
+		{Pid: 10, Type: UDP, Last: StatCounters{SentBytes: 50, SentPackets: 1}},
+		{Pid: 20, Type: TCP, Last: StatCounters{RecvBytes: 1000, RecvPackets: 3, TCPClosed: 1}},
+		// an idle connection
+		{Pid: 20, Type: UDP, Monotonic: StatCounters{SentBytes: 10}},
+		{Pid: 0, Type: TCP, Last: StatCounters{SentBytes: 5}},
+	}
+
+	assert.Equal(t, []ProcessStats{
+		{Pid: 10, SentBytes: 50, SentPackets: 1, UDPConnections: 1},
+		{
+			Pid:            20,
+			SentBytes:      100,
+			RecvBytes:      1010,
+			SentPackets:    2,
+			RecvPackets:    4,
+			Retransmits:    1,
+			TCPConnections: 2,
+			UDPConnections: 1,
+			TCPEstablished: 1,
+			TCPClosed:      1,
+		},
+	}, AggregateProcessStats(conns))
+	assert.Empty(t, AggregateProcessStats(nil))
+}
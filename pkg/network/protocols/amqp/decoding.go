@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package amqp
+
+import (
+	"encoding/binary"
+)
+
+// The layout of the method frames decoded in userspace.
+// Ref: https://www.rabbitmq.com/resources/specs/amqp0-9-1.pdf
+const (
+	methodFrameType = 1
+	basicClass      = 60
+
+	// methodOffset is the offset of the class id, which follows the frame type, the channel and the size of the frame
+	methodOffset = 7
+	// deliveryTagSize is the size of the delivery-tag and redelivered fields of the basic.deliver frames, which
+	// precede their exchange
+	deliveryTagSize = 9
+)
+
+// message is a basic.publish or basic.deliver method frame
+type message struct {
+	// method is either PublishMethod or DeliverMethod
+	method     uint16
+	exchange   string
+	routingKey string
+}
+
+// decodeMessage decodes the basic.publish or basic.deliver method frame at the beginning of fragment. The routing
+// key may be truncated when the frame doesn't fit in fragment.
+func decodeMessage(fragment []byte) (message, bool) {
+	if len(fragment) < methodOffset+4 || fragment[0] != methodFrameType {
+		return message{}, false
+	}
+	if binary.BigEndian.Uint16(fragment[methodOffset:]) != basicClass {
+		return message{}, false
+	}
+
+	msg := message{method: binary.BigEndian.Uint16(fragment[methodOffset+2:])}
+	args := fragment[methodOffset+4:]
+	switch msg.method {
+	case PublishMethod:
+		// the reserved ticket precedes the exchange
+		if len(args) < 2 {
+			return message{}, false
+		}
+		args = args[2:]
+	case DeliverMethod:
+		_, rest, ok := readShortString(args)
+		if !ok || len(rest) < deliveryTagSize {
+			return message{}, false
+		}
+		args = rest[deliveryTagSize:]
+	default:
+		return message{}, false
+	}
+
+	exchange, rest, ok := readShortString(args)
+	if !ok {
+		return message{}, false
+	}
+	msg.exchange = string(exchange)
+
+	if len(rest) == 0 {
+		return message{}, false
+	}
+	size := int(rest[0])
+	rest = rest[1:]
+	if size > len(rest) {
+		size = len(rest)
+	}
+	msg.routingKey = string(rest[:size])
+	return msg, true
+}
+
+// readShortString returns the short string at the beginning of b, which is prefixed by its size, and the bytes
+// following it
+func readShortString(b []byte) ([]byte, []byte, bool) {
+	if len(b) == 0 {
+		return nil, nil, false
+	}
+	size := int(b[0])
+	if 1+size > len(b) {
+		return nil, nil, false
+	}
+	return b[1 : 1+size], b[1+size:], true
+}
@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package amqp
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMethodFrame returns a method frame of the basic class with the given arguments
+func newMethodFrame(method uint16, args []byte) []byte {
+	b := make([]byte, methodOffset+4, methodOffset+4+len(args)+1)
+	b[0] = methodFrameType
+	binary.BigEndian.PutUint16(b[1:], 1)
+	binary.BigEndian.PutUint32(b[3:], uint32(4+len(args)))
+	binary.BigEndian.PutUint16(b[methodOffset:], basicClass)
+	binary.BigEndian.PutUint16(b[methodOffset+2:], method)
+	b = append(b, args...)
+	// frame end
+	return append(b, 0xce)
+}
+
+func shortString(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+func newPublish(exchange, routingKey string) []byte {
+	args := []byte{0, 0}
+	args = append(args, shortString(exchange)...)
+	args = append(args, shortString(routingKey)...)
+	// mandatory and immediate bits
+	args = append(args, 0)
+	return newMethodFrame(PublishMethod, args)
+}
+
+func newDeliver(exchange, routingKey string) []byte {
+	args := shortString("amq.ctag-0123456789abcdefghijkl")
+	args = append(args, make([]byte, deliveryTagSize)...)
+	args = append(args, shortString(exchange)...)
+	args = append(args, shortString(routingKey)...)
+	return newMethodFrame(DeliverMethod, args)
+}
+
+func TestDecodeMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment []byte
+		message  message
+		ok       bool
+	}{
+		{
+			name:     "publish",
+			fragment: newPublish("logs", "info"),
+			message:  message{method: PublishMethod, exchange: "logs", routingKey: "info"},
+			ok:       true,
+		},
+		{
+			name:     "publish to the default exchange",
+			fragment: newPublish("", "tasks"),
+			message:  message{method: PublishMethod, routingKey: "tasks"},
+			ok:       true,
+		},
+		{
+			name:     "deliver",
+			fragment: newDeliver("logs", "info"),
+			message:  message{method: DeliverMethod, exchange: "logs", routingKey: "info"},
+			ok:       true,
+		},
+		{
+			name:     "truncated routing key",
+			fragment: newPublish("logs", strings.Repeat("a", 200))[:BufferSize],
+			message:  message{method: PublishMethod, exchange: "logs", routingKey: strings.Repeat("a", BufferSize-methodOffset-4-2-5-1)},
+			ok:       true,
+		},
+		{
+			name:     "truncated exchange",
+			fragment: newPublish(strings.Repeat("a", 200), "info")[:BufferSize],
+			ok:       false,
+		},
+		{
+			name:     "consume",
+			fragment: newMethodFrame(20, shortString("tasks")),
+			ok:       false,
+		},
+		{
+			name:     "header frame",
+			fragment: append([]byte{2}, newPublish("logs", "info")[1:]...),
+			ok:       false,
+		},
+		{
+			name:     "protocol header",
+			fragment: []byte("AMQP\x00\x00\x09\x01"),
+			ok:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, ok := decodeMessage(tt.fragment)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.message, msg)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package amqp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// ConnTuple returns the network tuple of the connection the message was sent on, the client being the source
+func (tx *EbpfTx) ConnTuple() KeyTuple {
+	return KeyTuple{
+		SrcIPHigh: tx.Tup.Saddr_h,
+		SrcIPLow:  tx.Tup.Saddr_l,
+		DstIPHigh: tx.Tup.Daddr_h,
+		DstIPLow:  tx.Tup.Daddr_l,
+		SrcPort:   tx.Tup.Sport,
+		DstPort:   tx.Tup.Dport,
+	}
+}
+
+// FrameFragment returns the beginning of the method frame, which is truncated to BufferSize bytes
+func (tx *EbpfTx) FrameFragment() []byte {
+	return usmstats.Fragment(tx.Fragment[:], int(tx.Fragment_size))
+}
+
+// String returns a string representation of the transaction
+func (tx *EbpfTx) String() string {
+	var output strings.Builder
+	output.WriteString("ebpfAMQPTx{")
+	output.WriteString(fmt.Sprintf("Source: %s:%d, ", util.FromLowHigh(tx.Tup.Saddr_l, tx.Tup.Saddr_h), tx.Tup.Sport))
+	output.WriteString(fmt.Sprintf("Dest: %s:%d, ", util.FromLowHigh(tx.Tup.Daddr_l, tx.Tup.Daddr_h), tx.Tup.Dport))
+	output.WriteString(fmt.Sprintf("Frame: %q", tx.FrameFragment()))
+	output.WriteString("}")
+	return output.String()
+}
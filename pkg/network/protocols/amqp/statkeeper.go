@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package amqp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// StatKeeper aggregates the AMQP messages by connection, exchange, routing key and method
+type StatKeeper struct {
+	mux       sync.Mutex
+	stats     *usmstats.Keeper[Key, RequestStat]
+	telemetry *telemetry
+
+	malformedLogLimit *util.LogLimit
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(c *config.Config) *StatKeeper {
+	telemetry := newTelemetry()
+	return &StatKeeper{
+		stats:             usmstats.NewKeeper[Key, RequestStat](c.MaxAMQPStatsBuffered, telemetry.dropped, telemetry.Aggregations),
+		telemetry:         telemetry,
+		malformedLogLimit: util.NewLogLimit(10, time.Minute*10),
+	}
+}
+
+// Process adds a message decoded by the eBPF programs to the stats
+func (s *StatKeeper) Process(tx *EbpfTx) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	msg, ok := decodeMessage(tx.FrameFragment())
+	if !ok {
+		s.telemetry.malformed.Add(1)
+		if s.malformedLogLimit.ShouldLog() {
+			log.Debugf("amqp message malformed: %s", tx.String())
+		}
+		return
+	}
+	s.telemetry.count(msg.method)
+
+	key := Key{
+		KeyTuple:   tx.ConnTuple(),
+		Exchange:   msg.exchange,
+		RoutingKey: msg.routingKey,
+		Method:     msg.method,
+	}
+	stats := s.stats.Get(key)
+	if stats == nil {
+		return
+	}
+	stats.AddRequest()
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (s *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.telemetry.Log()
+	return s.stats.GetAndReset()
+}
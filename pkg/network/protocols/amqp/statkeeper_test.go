@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package amqp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 5672
+
+func generateAMQPTx(frame []byte) *EbpfTx {
+	var tx EbpfTx
+	tx.Tup.Saddr_l, tx.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	tx.Tup.Daddr_l, tx.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	tx.Tup.Sport = protocolsUtils.ClientPort
+	tx.Tup.Dport = serverPort
+	tx.Fragment_size = uint16(copy(tx.Fragment[:], frame))
+	return &tx
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxAMQPStatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+func TestStatKeeperProcess(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	sk.Process(generateAMQPTx(newPublish("", "tasks")))
+	sk.Process(generateAMQPTx(newPublish("", "tasks")))
+	sk.Process(generateAMQPTx(newDeliver("", "tasks")))
+	sk.Process(generateAMQPTx([]byte("AMQP\x00\x00\x09\x01")))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	publishKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "", "tasks", PublishMethod)
+	require.Contains(t, stats, publishKey)
+	assert.Equal(t, 2, stats[publishKey].Count)
+
+	deliverKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "", "tasks", DeliverMethod)
+	require.Contains(t, stats, deliverKey)
+	assert.Equal(t, 1, stats[deliverKey].Count)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
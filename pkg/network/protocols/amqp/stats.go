@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package amqp aggregates the AMQP messages decoded by the eBPF programs of the Universal Service Monitoring.
+package amqp
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const (
+	// PublishMethod is the method id of the basic.publish frames, sent by the clients publishing a message
+	PublishMethod = 40
+	// DeliverMethod is the method id of the basic.deliver frames, sent by the server to the consumers of a queue
+	DeliverMethod = 60
+)
+
+// KeyTuple represents the network tuple for a group of AMQP messages, the client being the source
+type KeyTuple = usmstats.KeyTuple
+
+// Key is an identifier for a group of AMQP messages. The messages published to the default exchange, whose name is
+// empty, are routed to the queue named after their routing key.
+type Key struct {
+	// this field order is intentional to help the GC pointer tracking
+	Exchange   string
+	RoutingKey string
+	KeyTuple
+	// Method is either PublishMethod or DeliverMethod
+	Method uint16
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, exchange, routingKey string, method uint16) Key {
+	return Key{
+		KeyTuple:   usmstats.NewKeyTuple(saddr, daddr, sport, dport),
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		Method:     method,
+	}
+}
+
+// RequestStat stores stats for the AMQP messages of a particular exchange, routing key and method. As the messages
+// are not answered, their latency is unknown.
+type RequestStat struct {
+	Count int
+}
+
+// AddRequest adds an AMQP message to the stats
+func (r *RequestStat) AddRequest() {
+	r.Count++
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	r.Count += newStats.Count
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := *r
+	return &clone
+}
@@ -0,0 +1,31 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package amqp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombineWith(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest()
+	assert.Equal(t, 1, stats.Count)
+
+	other := new(RequestStat)
+	other.AddRequest()
+	other.AddRequest()
+	clone := other.Clone()
+	stats.CombineWith(other)
+	assert.Equal(t, 3, stats.Count)
+
+	// the combined stats are left untouched
+	assert.Equal(t, 2, other.Count)
+	clone.AddRequest()
+	assert.Equal(t, 2, other.Count)
+	assert.Equal(t, 3, clone.Count)
+}
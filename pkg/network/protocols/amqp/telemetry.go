@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package amqp
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+type telemetry struct {
+	*usmstats.Telemetry
+
+	published *libtelemetry.Metric
+	delivered *libtelemetry.Metric
+	dropped   *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	malformed *libtelemetry.Metric // this happens when the exchange or the routing key can't be decoded
+}
+
+func newTelemetry() *telemetry {
+	t := usmstats.NewTelemetry("amqp")
+	return &telemetry{
+		Telemetry: t,
+		// these metrics are also exported as statsd metrics, and logged in the summary
+		published: t.NewSummaryMetric("published", "messages_published", libtelemetry.OptStatsd),
+		delivered: t.NewSummaryMetric("delivered", "messages_delivered", libtelemetry.OptStatsd),
+		dropped:   t.NewSummaryMetric("dropped", "messages_dropped", libtelemetry.OptStatsd),
+		malformed: t.NewSummaryMetric("malformed", "messages_malformed", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) count(method uint16) {
+	switch method {
+	case PublishMethod:
+		t.published.Add(1)
+	case DeliverMethod:
+		t.delivered.Add(1)
+	}
+}
@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ignore
+// +build ignore
+
+package amqp
+
+/*
+#include "../../ebpf/c/tracer.h"
+#include "../../ebpf/c/protocols/amqp/defs.h"
+#include "../../ebpf/c/protocols/amqp/types.h"
+*/
+import "C"
+
+type ConnTuple C.conn_tuple_t
+
+type EbpfTx C.amqp_transaction_t
+
+const (
+	BufferSize = C.AMQP_BUFFER_SIZE
+)
@@ -0,0 +1,343 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package cassandra
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// The frames decoded in userspace, whose header is shared by the versions 3 to 5 of the CQL native protocol.
+// Ref: https://github.com/apache/cassandra/blob/trunk/doc/native_protocol_v4.spec
+const (
+	headerSize        = 9
+	responseDirection = 0x80
+
+	flagCompression   = 0x01
+	flagTracing       = 0x02
+	flagCustomPayload = 0x04
+	flagWarning       = 0x08
+
+	opcodeError   = 0x00
+	opcodeQuery   = 0x07
+	opcodeResult  = 0x08
+	opcodePrepare = 0x09
+	opcodeExecute = 0x0a
+	opcodeBatch   = 0x0d
+
+	// resultPrepared is the kind of the RESULT responses of the PREPARE requests, holding the id of the statement
+	resultPrepared = 0x0004
+	// batchPrepared is the kind of the statements of the BATCH requests executing a prepared statement
+	batchPrepared = 1
+
+	tracingIDSize = 16
+
+	// maxKeyspaceLength is the maximum length of the names of the keyspaces, which bounds the cardinality of the stats
+	maxKeyspaceLength = 48
+)
+
+var (
+	errMalformed = errors.New("malformed frame")
+	// errCompressed is returned for the frames whose body is compressed, as negotiated by the STARTUP request of the
+	// connection, which are not decoded
+	errCompressed = errors.New("compressed frame")
+)
+
+// request is the beginning of a QUERY, PREPARE, EXECUTE or BATCH request
+type request struct {
+	opcode byte
+	// query is the query of the QUERY and PREPARE requests, or the query of the first statement of the BATCH
+	// requests
+	query []byte
+	// truncated is true if the end of the query was not captured
+	truncated bool
+	// preparedID is the id of the statement executed by the EXECUTE requests, or of the first statement of the BATCH
+	// requests
+	preparedID []byte
+}
+
+// response is the beginning of the response of the server to a request
+type response struct {
+	// failed is true for the ERROR responses, errorCode being their CQL error code
+	failed    bool
+	errorCode uint32
+	// preparedID is the id assigned to the statement by the RESULT responses of the PREPARE requests
+	preparedID []byte
+}
+
+// readFrame returns the flags, the opcode and the body of the frame at the beginning of b, which may have been
+// truncated by the eBPF programs
+func readFrame(b []byte, fromServer bool) (byte, byte, []byte, error) {
+	if len(b) < headerSize || (b[0]&responseDirection != 0) != fromServer {
+		return 0, 0, nil, errMalformed
+	}
+	flags, opcode := b[1], b[4]
+	if flags&flagCompression != 0 {
+		return 0, 0, nil, errCompressed
+	}
+
+	body := b[headerSize:]
+	if length := binary.BigEndian.Uint32(b[5:]); int64(length) < int64(len(body)) {
+		body = body[:length]
+	}
+	return flags, opcode, body, nil
+}
+
+// decodeRequest decodes the beginning of a request frame
+func decodeRequest(fragment []byte) (request, error) {
+	flags, opcode, body, err := readFrame(fragment, false)
+	if err != nil {
+		return request{}, err
+	}
+
+	r := reader{buf: body, ok: true}
+	if flags&flagCustomPayload != 0 {
+		r.skipBytesMap()
+	}
+
+	req := request{opcode: opcode}
+	switch opcode {
+	case opcodeQuery, opcodePrepare:
+		req.query, req.truncated = r.longString()
+	case opcodeExecute:
+		req.preparedID = r.shortBytes()
+	case opcodeBatch:
+		r.skip(1) // type of the batch
+		r.skip(2) // number of statements
+		if r.uint8() == batchPrepared {
+			req.preparedID = r.shortBytes()
+		} else {
+			req.query, req.truncated = r.longString()
+		}
+	default:
+		return request{}, errMalformed
+	}
+
+	if !r.ok || (len(req.query) == 0 && len(req.preparedID) == 0) {
+		return request{}, errMalformed
+	}
+	return req, nil
+}
+
+// decodeResponse decodes the beginning of a response frame. The responses other than the ERROR responses are
+// successful.
+func decodeResponse(fragment []byte) (response, error) {
+	flags, opcode, body, err := readFrame(fragment, true)
+	if err != nil {
+		return response{}, err
+	}
+
+	// the tracing id, the warnings and the custom payload precede the body of the responses
+	r := reader{buf: body, ok: true}
+	if flags&flagTracing != 0 {
+		r.skip(tracingIDSize)
+	}
+	if flags&flagWarning != 0 {
+		r.skipStringList()
+	}
+	if flags&flagCustomPayload != 0 {
+		r.skipBytesMap()
+	}
+
+	var resp response
+	switch opcode {
+	case opcodeError:
+		resp.failed = true
+		resp.errorCode = r.uint32()
+		if !r.ok {
+			return response{}, errMalformed
+		}
+	case opcodeResult:
+		// the id of the prepared statements is not captured when the response starts with large warnings
+		if kind := r.uint32(); kind == resultPrepared {
+			if id := r.shortBytes(); r.ok {
+				resp.preparedID = id
+			}
+		}
+	default:
+		return response{}, errMalformed
+	}
+	return resp, nil
+}
+
+// useKeyspace returns the keyspace selected by a USE statement
+func useKeyspace(query []byte, truncated bool) (string, bool) {
+	words := splitQuery(query, truncated)
+	if len(words) < 2 || !strings.EqualFold(words[0], "USE") {
+		return "", false
+	}
+	return identifier(words[1])
+}
+
+// queryKeyspace returns the keyspace referenced by a statement, either as the keyspace of the qualified name of the
+// table it reads or writes, or as the keyspace created, altered or dropped by the statement. It returns an empty
+// string for the statements using the keyspace of the connection.
+func queryKeyspace(query []byte, truncated bool) string {
+	words := splitQuery(query, truncated)
+	for i := 0; i < len(words)-1; i++ {
+		switch strings.ToUpper(words[i]) {
+		case "FROM", "INTO", "UPDATE", "TABLE", "TRUNCATE", "ON":
+			name := words[skipConditions(words, i+1)]
+			if dot := qualifierEnd(name); dot > 0 {
+				if keyspace, ok := identifier(name[:dot]); ok {
+					return keyspace
+				}
+			}
+		case "KEYSPACE":
+			if keyspace, ok := identifier(words[skipConditions(words, i+1)]); ok {
+				return keyspace
+			}
+		}
+	}
+	return ""
+}
+
+// splitQuery splits a query into its words, the last one being dropped if the query is truncated, as it may be
+// incomplete
+func splitQuery(query []byte, truncated bool) []string {
+	words := strings.FieldsFunc(string(query), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '(' || r == ')' || r == ',' || r == ';'
+	})
+	if truncated && len(words) > 0 {
+		words = words[:len(words)-1]
+	}
+	return words
+}
+
+// skipConditions returns the index of the word following the IF [NOT] EXISTS condition starting at words[i], or i if
+// there is no condition, bounded to the last word
+func skipConditions(words []string, i int) int {
+	for i < len(words)-1 {
+		switch strings.ToUpper(words[i]) {
+		case "IF", "NOT", "EXISTS":
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// qualifierEnd returns the index of the dot separating the keyspace from the name of a table, or -1 if the name is
+// not qualified. The keyspace may be a quoted identifier.
+func qualifierEnd(name string) int {
+	start := 0
+	if strings.HasPrefix(name, `"`) {
+		end := strings.Index(name[1:], `"`)
+		if end < 0 {
+			return -1
+		}
+		start = end + 2
+	}
+	if i := strings.IndexByte(name[start:], '.'); i >= 0 {
+		return start + i
+	}
+	return -1
+}
+
+// identifier returns the name of a keyspace, which is case insensitive unless it is quoted. The keyspaces names are
+// made of alphanumerical characters and underscores.
+func identifier(word string) (string, bool) {
+	if len(word) >= 2 && strings.HasPrefix(word, `"`) && strings.HasSuffix(word, `"`) {
+		word = word[1 : len(word)-1]
+	} else {
+		word = strings.ToLower(word)
+	}
+
+	if len(word) == 0 || len(word) > maxKeyspaceLength {
+		return "", false
+	}
+	for _, c := range word {
+		if !(('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '_') {
+			return "", false
+		}
+	}
+	return word, true
+}
+
+// reader reads the fields of a CQL frame, ok being unset once the frame is truncated
+type reader struct {
+	buf []byte
+	ok  bool
+}
+
+func (r *reader) bytes(n int) []byte {
+	if !r.ok || n < 0 || n > len(r.buf) {
+		r.ok = false
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *reader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+func (r *reader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b)
+}
+
+// longString reads a [long string], which may be truncated at the end of the captured frame
+func (r *reader) longString() ([]byte, bool) {
+	length := int32(r.uint32())
+	if !r.ok || length <= 0 {
+		r.ok = false
+		return nil, false
+	}
+	if int(length) > len(r.buf) {
+		s := r.buf
+		r.buf = nil
+		return s, true
+	}
+	return r.bytes(int(length)), false
+}
+
+// shortBytes reads [short bytes], such as the ids of the prepared statements
+func (r *reader) shortBytes() []byte {
+	return r.bytes(int(r.uint16()))
+}
+
+// skipStringList skips a [string list], such as the warnings of the responses
+func (r *reader) skipStringList() {
+	for n := int(r.uint16()); n > 0 && r.ok; n-- {
+		r.skip(int(r.uint16()))
+	}
+}
+
+// skipBytesMap skips a [bytes map], such as the custom payload of the frames
+func (r *reader) skipBytesMap() {
+	for n := int(r.uint16()); n > 0 && r.ok; n-- {
+		r.skip(int(r.uint16()))
+		// the [bytes] values are null when their length is negative
+		if length := int32(r.uint32()); length > 0 {
+			r.skip(int(length))
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package cassandra
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPreparedID = []byte{0x5e, 0x9c, 0x42, 0x1f, 0x8b, 0x0d, 0x33, 0x71, 0xa4, 0x26, 0xe8, 0x1c, 0x90, 0x57, 0x3b, 0xc2}
+
+// frame encodes a frame of the version 4 of the protocol
+func frame(response bool, flags, opcode byte, body []byte) []byte {
+	version := byte(0x04)
+	if response {
+		version |= responseDirection
+	}
+	b := []byte{version, flags, 0x00, 0x01, opcode, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(b[5:], uint32(len(body)))
+	return append(b, body...)
+}
+
+func longString(s string) []byte {
+	b := make([]byte, 4, 4+len(s))
+	binary.BigEndian.PutUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+func shortBytes(b []byte) []byte {
+	return append([]byte{byte(len(b) >> 8), byte(len(b))}, b...)
+}
+
+// queryRequest encodes a QUERY request with the ONE consistency and no parameters
+func queryRequest(query string) []byte {
+	return frame(false, 0, opcodeQuery, append(longString(query), 0x00, 0x01, 0x00))
+}
+
+func prepareRequest(query string) []byte {
+	return frame(false, 0, opcodePrepare, longString(query))
+}
+
+func executeRequest(id []byte) []byte {
+	return frame(false, 0, opcodeExecute, append(shortBytes(id), 0x00, 0x01, 0x00))
+}
+
+func errorResponse(code uint32, message string) []byte {
+	body := make([]byte, 6, 6+len(message))
+	binary.BigEndian.PutUint32(body, code)
+	binary.BigEndian.PutUint16(body[4:], uint16(len(message)))
+	return frame(true, 0, opcodeError, append(body, message...))
+}
+
+// resultResponse encodes a RESULT response of the given kind, followed by the given bytes
+func resultResponse(kind uint32, rest []byte) []byte {
+	body := make([]byte, 4, 4+len(rest))
+	binary.BigEndian.PutUint32(body, kind)
+	return frame(true, 0, opcodeResult, append(body, rest...))
+}
+
+func preparedResponse(id []byte) []byte {
+	// the metadata of the statement follow its id
+	return resultResponse(resultPrepared, append(shortBytes(id), make([]byte, 16)...))
+}
+
+func TestDecodeRequest(t *testing.T) {
+	req, err := decodeRequest(queryRequest("SELECT * FROM users"))
+	require.NoError(t, err)
+	assert.Equal(t, byte(opcodeQuery), req.opcode)
+	assert.Equal(t, "SELECT * FROM users", string(req.query))
+	assert.False(t, req.truncated)
+
+	req, err = decodeRequest(prepareRequest("INSERT INTO users (id) VALUES (?)"))
+	require.NoError(t, err)
+	assert.Equal(t, byte(opcodePrepare), req.opcode)
+	assert.Equal(t, "INSERT INTO users (id) VALUES (?)", string(req.query))
+
+	req, err = decodeRequest(executeRequest(testPreparedID))
+	require.NoError(t, err)
+	assert.Equal(t, byte(opcodeExecute), req.opcode)
+	assert.Equal(t, testPreparedID, req.preparedID)
+
+	// a logged batch starting with a prepared statement, followed by a query
+	batch := []byte{0x00, 0x00, 0x02, batchPrepared}
+	batch = append(batch, shortBytes(testPreparedID)...)
+	req, err = decodeRequest(frame(false, 0, opcodeBatch, batch))
+	require.NoError(t, err)
+	assert.Equal(t, testPreparedID, req.preparedID)
+
+	batch = append([]byte{0x00, 0x00, 0x01, 0x00}, longString("UPDATE ks.users SET name = 'x'")...)
+	req, err = decodeRequest(frame(false, 0, opcodeBatch, batch))
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE ks.users SET name = 'x'", string(req.query))
+}
+
+func TestDecodeRequestTruncated(t *testing.T) {
+	query := "SELECT * FROM ks.users WHERE id IN (" + strings.Repeat("?, ", 100) + "?)"
+	fragment := queryRequest(query)[:BufferSize]
+	req, err := decodeRequest(fragment)
+	require.NoError(t, err)
+	assert.True(t, req.truncated)
+	assert.Equal(t, query[:BufferSize-headerSize-4], string(req.query))
+}
+
+func TestDecodeRequestCustomPayload(t *testing.T) {
+	body := []byte{0x00, 0x01, 0x00, 0x03, 'k', 'e', 'y', 0x00, 0x00, 0x00, 0x02, 'v', 'v'}
+	body = append(body, longString("USE ks")...)
+	req, err := decodeRequest(frame(false, flagCustomPayload, opcodeQuery, body))
+	require.NoError(t, err)
+	assert.Equal(t, "USE ks", string(req.query))
+}
+
+func TestDecodeRequestErrors(t *testing.T) {
+	_, err := decodeRequest(queryRequest("SELECT * FROM users")[:8])
+	assert.Equal(t, errMalformed, err)
+
+	// a response
+	_, err = decodeRequest(errorResponse(0x2200, "invalid"))
+	assert.Equal(t, errMalformed, err)
+
+	// an OPTIONS request
+	_, err = decodeRequest(frame(false, 0, 0x05, nil))
+	assert.Equal(t, errMalformed, err)
+
+	_, err = decodeRequest(frame(false, 0, opcodeQuery, longString("")))
+	assert.Equal(t, errMalformed, err)
+
+	_, err = decodeRequest(frame(false, flagCompression, opcodeQuery, []byte{0x00, 0x00, 0x00, 0x1a, 0xf0}))
+	assert.Equal(t, errCompressed, err)
+}
+
+func TestDecodeResponse(t *testing.T) {
+	resp, err := decodeResponse(errorResponse(0x1200, "Operation timed out"))
+	require.NoError(t, err)
+	assert.True(t, resp.failed)
+	assert.Equal(t, uint32(0x1200), resp.errorCode)
+
+	// the rows of a SELECT statement
+	resp, err = decodeResponse(resultResponse(0x0002, make([]byte, 12)))
+	require.NoError(t, err)
+	assert.False(t, resp.failed)
+	assert.Nil(t, resp.preparedID)
+
+	resp, err = decodeResponse(preparedResponse(testPreparedID)[:ResponseSize])
+	require.NoError(t, err)
+	assert.Equal(t, testPreparedID, resp.preparedID)
+
+	// the tracing id precedes the body of the response
+	traced := frame(true, flagTracing, opcodeError, append(make([]byte, tracingIDSize), 0x00, 0x00, 0x11, 0x00))
+	resp, err = decodeResponse(traced)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x1100), resp.errorCode)
+
+	// the warnings precede the body of the response
+	warned := frame(true, flagWarning, opcodeError, []byte{0x00, 0x01, 0x00, 0x02, 'w', '!', 0x00, 0x00, 0x10, 0x00})
+	resp, err = decodeResponse(warned)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x1000), resp.errorCode)
+}
+
+func TestDecodeResponseErrors(t *testing.T) {
+	_, err := decodeResponse(queryRequest("SELECT * FROM users"))
+	assert.Equal(t, errMalformed, err)
+
+	// an ERROR response whose code was not captured
+	_, err = decodeResponse(errorResponse(0x1200, "")[:headerSize+2])
+	assert.Equal(t, errMalformed, err)
+
+	// an EVENT response
+	_, err = decodeResponse(frame(true, 0, 0x0c, nil))
+	assert.Equal(t, errMalformed, err)
+}
+
+func TestUseKeyspace(t *testing.T) {
+	tests := []struct {
+		query     string
+		truncated bool
+		keyspace  string
+		ok        bool
+	}{
+		{query: "USE ks", keyspace: "ks", ok: true},
+		{query: "use MyKeyspace;", keyspace: "mykeyspace", ok: true},
+		{query: `USE "MyKeyspace"`, keyspace: "MyKeyspace", ok: true},
+		{query: "USE ks", truncated: true, ok: false},
+		{query: "USE", ok: false},
+		{query: "SELECT * FROM ks.users", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			keyspace, ok := useKeyspace([]byte(tt.query), tt.truncated)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.keyspace, keyspace)
+		})
+	}
+}
+
+func TestQueryKeyspace(t *testing.T) {
+	tests := []struct {
+		query     string
+		truncated bool
+		keyspace  string
+	}{
+		{query: "SELECT * FROM ks.users WHERE id = ?", keyspace: "ks"},
+		{query: "select name from Shop.orders", keyspace: "shop"},
+		{query: `SELECT * FROM "Shop".orders`, keyspace: "Shop"},
+		{query: "INSERT INTO ks.users(id, name) VALUES (?, ?)", keyspace: "ks"},
+		{query: "UPDATE ks.users SET name = ? WHERE id = ?", keyspace: "ks"},
+		{query: "DELETE FROM ks.users WHERE id = ?", keyspace: "ks"},
+		{query: "TRUNCATE ks.users", keyspace: "ks"},
+		{query: "CREATE TABLE IF NOT EXISTS ks.users (id int PRIMARY KEY)", keyspace: "ks"},
+		{query: "CREATE INDEX ON ks.users (name)", keyspace: "ks"},
+		{query: "CREATE KEYSPACE IF NOT EXISTS ks WITH replication = {'class': 'SimpleStrategy'}", keyspace: "ks"},
+		{query: "DROP KEYSPACE ks", keyspace: "ks"},
+		{query: "BEGIN BATCH INSERT INTO ks.users (id) VALUES (1); APPLY BATCH", keyspace: "ks"},
+		// the statements using the keyspace of the connection
+		{query: "SELECT * FROM users", keyspace: ""},
+		{query: "SELECT * FROM ks.users", truncated: true, keyspace: ""},
+		{query: "SELECT * FROM ks.users WHERE id", truncated: true, keyspace: "ks"},
+		{query: "SELECT * FROM system_schema.keyspaces", keyspace: "system_schema"},
+		{query: "SELECT * FROM " + strings.Repeat("k", maxKeyspaceLength+1) + ".users", keyspace: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.keyspace, queryKeyspace([]byte(tt.query), tt.truncated))
+		})
+	}
+}
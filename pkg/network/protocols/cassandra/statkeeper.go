@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package cassandra
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// maxPreparedStatements is the maximum number of prepared statements remembered across all the servers. The
+	// statements are forgotten once it is reached, as those of the restarted servers are never removed otherwise.
+	maxPreparedStatements = 10000
+	// maxConnectionKeyspaces is the maximum number of connections whose keyspace, selected by a USE statement, is
+	// remembered. The keyspaces are forgotten once it is reached, as those of the closed connections are never
+	// removed otherwise.
+	maxConnectionKeyspaces = 10000
+)
+
+// statementKey identifies a prepared statement. The ids of the statements are assigned by the servers, and the
+// drivers execute the statements on all the connections to a server, regardless of the one they were prepared on.
+type statementKey struct {
+	id        string
+	DstIPHigh uint64
+	DstIPLow  uint64
+	DstPort   uint16
+}
+
+func newStatementKey(tuple KeyTuple, id []byte) statementKey {
+	return statementKey{
+		id:        string(id),
+		DstIPHigh: tuple.DstIPHigh,
+		DstIPLow:  tuple.DstIPLow,
+		DstPort:   tuple.DstPort,
+	}
+}
+
+// StatKeeper aggregates the Cassandra requests by connection and keyspace
+type StatKeeper struct {
+	mux       sync.Mutex
+	stats     *usmstats.Keeper[Key, RequestStat]
+	telemetry *telemetry
+
+	// keyspaces holds the keyspaces selected by the USE statements of the connections
+	keyspaces map[KeyTuple]string
+	// statements holds the keyspaces of the prepared statements
+	statements map[statementKey]string
+
+	malformedLogLimit *util.LogLimit
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(c *config.Config) *StatKeeper {
+	telemetry := newTelemetry()
+	return &StatKeeper{
+		stats:             usmstats.NewKeeper[Key, RequestStat](c.MaxCassandraStatsBuffered, telemetry.dropped, telemetry.Aggregations),
+		telemetry:         telemetry,
+		keyspaces:         make(map[KeyTuple]string),
+		statements:        make(map[statementKey]string),
+		malformedLogLimit: util.NewLogLimit(10, time.Minute*10),
+	}
+}
+
+// Process adds a request decoded by the eBPF programs to the stats. The keyspace of a request is the one of the
+// qualified names of the tables of its query, or the one selected on its connection by a USE statement. The executions
+// of the statements whose preparation was not seen are attributed to the keyspace of their connection.
+func (s *StatKeeper) Process(tx *EbpfTx) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	req, err := decodeRequest(tx.RequestFragment())
+	if err != nil {
+		s.undecoded(tx, err)
+		return
+	}
+	resp, err := decodeResponse(tx.ResponseFragment())
+	if err != nil {
+		s.undecoded(tx, err)
+		return
+	}
+
+	tuple := tx.ConnTuple()
+	keyspace := s.keyspaces[tuple]
+	if len(req.query) > 0 {
+		if used, ok := useKeyspace(req.query, req.truncated); ok {
+			if !resp.failed {
+				s.storeKeyspace(tuple, used)
+			}
+			keyspace = used
+		} else if qualified := queryKeyspace(req.query, req.truncated); qualified != "" {
+			keyspace = qualified
+		}
+	} else if prepared, ok := s.statements[newStatementKey(tuple, req.preparedID)]; ok {
+		keyspace = prepared
+	} else {
+		s.telemetry.unknownStatements.Add(1)
+	}
+
+	if req.opcode == opcodePrepare {
+		if !resp.failed && len(resp.preparedID) > 0 {
+			s.storeStatement(newStatementKey(tuple, resp.preparedID), keyspace)
+		}
+		// the statement is only prepared, it is counted when executed
+		return
+	}
+	s.telemetry.count(req, resp)
+
+	key := Key{
+		KeyTuple: tuple,
+		Keyspace: keyspace,
+	}
+	stats := s.stats.Get(key)
+	if stats == nil {
+		return
+	}
+	stats.AddRequest(tx.RequestLatency(), resp.failed, resp.errorCode)
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (s *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.telemetry.Log()
+	return s.stats.GetAndReset()
+}
+
+func (s *StatKeeper) storeKeyspace(tuple KeyTuple, keyspace string) {
+	if _, ok := s.keyspaces[tuple]; !ok && len(s.keyspaces) >= maxConnectionKeyspaces {
+		s.keyspaces = make(map[KeyTuple]string)
+	}
+	s.keyspaces[tuple] = keyspace
+}
+
+func (s *StatKeeper) storeStatement(key statementKey, keyspace string) {
+	if _, ok := s.statements[key]; !ok && len(s.statements) >= maxPreparedStatements {
+		s.statements = make(map[statementKey]string)
+	}
+	s.statements[key] = keyspace
+}
+
+func (s *StatKeeper) undecoded(tx *EbpfTx, err error) {
+	if err == errCompressed {
+		s.telemetry.compressed.Add(1)
+		return
+	}
+	s.telemetry.malformed.Add(1)
+	if s.malformedLogLimit.ShouldLog() {
+		log.Debugf("cassandra request malformed: %s", tx.String())
+	}
+}
@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package cassandra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 9042
+
+func generateCassandraTx(clientPort uint16, request, response []byte, latencyNS uint64) *EbpfTx {
+	var tx EbpfTx
+	tx.Tup.Saddr_l, tx.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	tx.Tup.Daddr_l, tx.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	tx.Tup.Sport = clientPort
+	tx.Tup.Dport = serverPort
+	tx.Request_started = 1
+	tx.Response_received = tx.Request_started + latencyNS
+	tx.Request_fragment_size = uint16(copy(tx.Request_fragment[:], request))
+	tx.Response_fragment_size = uint16(copy(tx.Response_fragment[:], response))
+	return &tx
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxCassandraStatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+// voidResponse is the response of the requests which return nothing, such as the INSERT queries
+var voidResponse = resultResponse(0x0001, nil)
+
+func TestStatKeeperQuery(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, queryRequest("SELECT * FROM shop.users WHERE id = 1"), resultResponse(0x0002, nil), 1000))
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, queryRequest("INSERT INTO shop.users (id) VALUES (2)"), errorResponse(0x1100, "Operation timed out"), 2000))
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, queryRequest("SELECT release_version FROM system.local"), resultResponse(0x0002, nil), 3000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	shopKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "shop")
+	require.Contains(t, stats, shopKey)
+	assert.Equal(t, 2, stats[shopKey].Count)
+	assert.Equal(t, map[uint32]int{0x1100: 1}, stats[shopKey].Errors)
+	require.NotNil(t, stats[shopKey].Latencies)
+
+	systemKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "system")
+	require.Contains(t, stats, systemKey)
+	assert.Equal(t, 1, stats[systemKey].Count)
+	assert.Empty(t, stats[systemKey].Errors)
+	assert.Equal(t, 3000.0, stats[systemKey].FirstLatencySample)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestStatKeeperUseKeyspace(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	// the tables are not qualified before the keyspace is selected
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, queryRequest("SELECT * FROM users"), errorResponse(0x2200, "No keyspace has been specified"), 1000))
+	// the keyspace doesn't exist
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, queryRequest("USE unknown"), errorResponse(0x2200, "Keyspace 'unknown' does not exist"), 1000))
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, queryRequest("SELECT * FROM users"), errorResponse(0x2200, "No keyspace has been specified"), 1000))
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, queryRequest(`USE "Shop"`), resultResponse(0x0003, nil), 1000))
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, queryRequest("SELECT * FROM users"), resultResponse(0x0002, nil), 1000))
+	// the keyspace is only selected on its connection
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort+1, queryRequest("SELECT * FROM users"), resultResponse(0x0002, nil), 1000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 4)
+
+	noKeyspaceKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "")
+	require.Contains(t, stats, noKeyspaceKey)
+	assert.Equal(t, 2, stats[noKeyspaceKey].Count)
+	assert.Equal(t, map[uint32]int{0x2200: 2}, stats[noKeyspaceKey].Errors)
+
+	unknownKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "unknown")
+	require.Contains(t, stats, unknownKey)
+	assert.Equal(t, 1, stats[unknownKey].Count)
+
+	shopKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "Shop")
+	require.Contains(t, stats, shopKey)
+	assert.Equal(t, 2, stats[shopKey].Count)
+	assert.Empty(t, stats[shopKey].Errors)
+
+	assert.Contains(t, stats, NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort+1, serverPort, ""))
+}
+
+func TestStatKeeperPreparedStatement(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	// the statement is prepared on a connection, then executed on another one
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, prepareRequest("INSERT INTO shop.users (id) VALUES (?)"), preparedResponse(testPreparedID), 1000))
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort+1, executeRequest(testPreparedID), voidResponse, 2000))
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, executeRequest(testPreparedID), errorResponse(0x1000, "Cannot achieve consistency level ONE"), 3000))
+	// the statement was prepared before the monitoring started
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, executeRequest([]byte{0x01, 0x02}), voidResponse, 4000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 3)
+
+	shopKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "shop")
+	require.Contains(t, stats, shopKey)
+	assert.Equal(t, 1, stats[shopKey].Count)
+	assert.Equal(t, map[uint32]int{0x1000: 1}, stats[shopKey].Errors)
+	assert.Contains(t, stats, NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort+1, serverPort, "shop"))
+	assert.Contains(t, stats, NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, ""))
+
+	// the prepared statements are kept across the flushes
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, executeRequest(testPreparedID), voidResponse, 2000))
+	assert.Contains(t, sk.GetAndResetAllStats(), shopKey)
+}
+
+func TestStatKeeperUndecoded(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	compressed := queryRequest("SELECT * FROM shop.users")
+	compressed[1] |= flagCompression
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, compressed, voidResponse, 1000))
+	sk.Process(generateCassandraTx(protocolsUtils.ClientPort, []byte{0x04, 0x00}, voidResponse, 1000))
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package cassandra aggregates the Cassandra requests decoded by the eBPF programs of the Universal Service Monitoring.
+package cassandra
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// KeyTuple represents the network tuple for a group of Cassandra requests, the client being the source
+type KeyTuple = usmstats.KeyTuple
+
+// Key is an identifier for a group of Cassandra requests
+type Key struct {
+	// Keyspace is the keyspace the requests were sent to, which is empty when it is unknown
+	Keyspace string
+	KeyTuple
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, keyspace string) Key {
+	return Key{
+		KeyTuple: usmstats.NewKeyTuple(saddr, daddr, sport, dport),
+		Keyspace: keyspace,
+	}
+}
+
+// RequestStat stores stats for the Cassandra requests sent to the same keyspace
+type RequestStat struct {
+	usmstats.LatencyStats
+	// Errors is the number of requests which failed, by CQL error code
+	Errors map[uint32]int
+}
+
+// AddRequest adds a Cassandra request to the stats, along with the error code of its response if it failed
+func (r *RequestStat) AddRequest(latency float64, failed bool, errorCode uint32) {
+	if failed {
+		r.addErrors(errorCode, 1)
+	}
+	r.AddLatency(latency)
+}
+
+func (r *RequestStat) addErrors(errorCode uint32, count int) {
+	if r.Errors == nil {
+		r.Errors = make(map[uint32]int)
+	}
+	r.Errors[errorCode] += count
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	for errorCode, count := range newStats.Errors {
+		r.addErrors(errorCode, count)
+	}
+	r.CombineLatencies(&newStats.LatencyStats)
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := new(RequestStat)
+	clone.CombineWith(r)
+	return clone
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package cassandra
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRequest(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, false, 0)
+	assert.Equal(t, 1, stats.Count)
+	assert.Empty(t, stats.Errors)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+	assert.Nil(t, stats.Latencies)
+
+	// the code of the server errors is 0
+	stats.AddRequest(20, true, 0x0000)
+	stats.AddRequest(30, true, 0x1200)
+	assert.Equal(t, 3, stats.Count)
+	assert.Equal(t, map[uint32]int{0x0000: 1, 0x1200: 1}, stats.Errors)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 3.0, stats.Latencies.GetCount())
+}
+
+func TestCombineWith(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, true, 0x1200)
+
+	single := new(RequestStat)
+	single.AddRequest(20, true, 0x1000)
+	stats.CombineWith(single)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, map[uint32]int{0x1200: 1, 0x1000: 1}, stats.Errors)
+	require.NotNil(t, stats.Latencies)
+
+	multiple := new(RequestStat)
+	multiple.AddRequest(30, false, 0)
+	multiple.AddRequest(40, true, 0x1200)
+	clone := multiple.Clone()
+	stats.CombineWith(multiple)
+	assert.Equal(t, 4, stats.Count)
+	assert.Equal(t, map[uint32]int{0x1200: 2, 0x1000: 1}, stats.Errors)
+	assert.Equal(t, 4.0, stats.Latencies.GetCount())
+
+	// the combined stats are left untouched
+	assert.Equal(t, 2, multiple.Count)
+	assert.Equal(t, map[uint32]int{0x1200: 1}, multiple.Errors)
+	assert.Equal(t, 2.0, multiple.Latencies.GetCount())
+	assert.Equal(t, multiple.Count, clone.Count)
+	assert.Equal(t, multiple.Errors, clone.Errors)
+	assert.Equal(t, 2.0, clone.Latencies.GetCount())
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package cassandra
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+type telemetry struct {
+	*usmstats.Telemetry
+
+	queries, executions, batches *libtelemetry.Metric
+
+	totalHits         *libtelemetry.Metric
+	errors            *libtelemetry.Metric // this happens when the server answers with an ERROR response
+	dropped           *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	malformed         *libtelemetry.Metric // this happens when the request or the response can't be decoded
+	compressed        *libtelemetry.Metric // this happens when the frames of the connection are compressed
+	unknownStatements *libtelemetry.Metric // this happens when the PREPARE request of a statement was not seen
+}
+
+func newTelemetry() *telemetry {
+	t := usmstats.NewTelemetry("cassandra")
+	return &telemetry{
+		Telemetry:         t,
+		queries:           t.NewMetric("queries"),
+		executions:        t.NewMetric("executions"),
+		batches:           t.NewMetric("batches"),
+		unknownStatements: t.NewMetric("unknown_statements"),
+
+		// these metrics are also exported as statsd metrics, and logged in the summary
+		totalHits:  t.NewSummaryMetric("total_hits", "requests_processed", libtelemetry.OptStatsd),
+		errors:     t.NewSummaryMetric("errors", "requests_failed", libtelemetry.OptStatsd),
+		dropped:    t.NewSummaryMetric("dropped", "requests_dropped", libtelemetry.OptStatsd),
+		malformed:  t.NewSummaryMetric("malformed", "requests_malformed", libtelemetry.OptStatsd),
+		compressed: t.NewSummaryMetric("compressed", "requests_compressed", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) count(req request, resp response) {
+	switch req.opcode {
+	case opcodeExecute:
+		t.executions.Add(1)
+	case opcodeBatch:
+		t.batches.Add(1)
+	default:
+		t.queries.Add(1)
+	}
+	if resp.failed {
+		t.errors.Add(1)
+	}
+	t.totalHits.Add(1)
+}
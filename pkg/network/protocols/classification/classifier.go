@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package classification is a user-space implementation of the protocol classification performed by the eBPF
+// programs (see pkg/network/ebpf/c/protocols/classification/protocol-classification.h).
+// It must be kept in sync with the kernel implementation, as it is used to validate samples against the
+// expected classification without requiring a kernel, and as the classifier of the environments where the
+// eBPF programs cannot run.
+package classification
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+)
+
+// MaxBufferSize is the number of bytes of each payload inspected by the classifier (CLASSIFICATION_MAX_BUFFER)
+const MaxBufferSize = http2MarkerSize + 8
+
+// Classifier classifies the payloads of a single connection. Similarly to the eBPF implementation, a connection keeps
+// the first protocol it has been classified with.
+type Classifier struct {
+	protocol network.ProtocolType
+	// grpcWeb is set once an HTTP or HTTP/2 connection has been seen carrying gRPC-Web
+	grpcWeb bool
+
+	// mongoRequestIDs holds the IDs of the mongo requests seen on the connection, so replies can be validated
+	mongoRequestIDs map[int32]struct{}
+}
+
+// NewClassifier returns a new Classifier for a single connection
+func NewClassifier() *Classifier {
+	return &Classifier{
+		protocol:        network.ProtocolUnknown,
+		mongoRequestIDs: make(map[int32]struct{}),
+	}
+}
+
+// Protocol returns the protocol the connection has been classified with so far
+func (c *Classifier) Protocol() network.ProtocolType {
+	return c.protocol
+}
+
+// IsGRPCWeb returns true if the connection has been classified as HTTP or HTTP/2, and carries gRPC-Web. Unlike the
+// protocol, gRPC-Web is not detected by the eBPF classification, but by the HTTP monitor from the headers of the
+// transactions (see http.IsGRPCWeb), which are inspected in full here.
+func (c *Classifier) IsGRPCWeb() bool {
+	return c.grpcWeb
+}
+
+// Classify classifies the given payload, unless the connection has already been classified, and returns the
+// protocol of the connection. Empty payloads are ignored.
+func (c *Classifier) Classify(payload []byte) network.ProtocolType {
+	if len(payload) == 0 {
+		return c.protocol
+	}
+
+	if c.protocol == network.ProtocolUnknown {
+		// The eBPF program reads the payload into a zeroed buffer of MaxBufferSize bytes, and some of the checks rely
+		// on reading past the end of the actual payload.
+		var buf [MaxBufferSize]byte
+		size := copy(buf[:], payload)
+		c.protocol = c.classify(buf[:], size)
+	}
+
+	if !c.grpcWeb && (c.protocol == network.ProtocolHTTP || c.protocol == network.ProtocolHTTP2) {
+		c.grpcWeb = http.IsGRPCWeb(payload)
+	}
+	return c.protocol
+}
+
+func (c *Classifier) classify(buf []byte, size int) network.ProtocolType {
+	switch {
+	case isHTTP(buf, size):
+		return network.ProtocolHTTP
+	case isHTTP2(buf, size):
+		return network.ProtocolHTTP2
+	case isSSH(buf, size):
+		return network.ProtocolSSH
+	case isRDP(buf, size):
+		return network.ProtocolRDP
+	case isAMQP(buf, size):
+		return network.ProtocolAMQP
+	case isRedis(buf, size):
+		return network.ProtocolRedis
+	case isMemcached(buf, size):
+		return network.ProtocolMemcached
+	case isKafka(buf, size):
+		return network.ProtocolKafka
+	case c.isMongo(buf, size):
+		return network.ProtocolMongo
+	case isPostgres(buf, size):
+		return network.ProtocolPostgres
+	case isMySQL(buf, size):
+		return network.ProtocolMySQL
+	case isCassandra(buf, size):
+		return network.ProtocolCassandra
+	default:
+		return network.ProtocolUnknown
+	}
+}
+
+// ClassifyDatagram classifies the payload of a UDP datagram, unless the flow has already been classified, and returns
+// the protocol of the flow. Similarly to the eBPF implementation, the UDP flows are only classified as HTTP/3, from the
+// QUIC Initial packets opening them.
+func (c *Classifier) ClassifyDatagram(payload []byte) network.ProtocolType {
+	if c.protocol != network.ProtocolUnknown || len(payload) == 0 {
+		return c.protocol
+	}
+
+	var buf [MaxBufferSize]byte
+	size := copy(buf[:], payload)
+	if isQUICInitial(buf[:], size, len(payload)) {
+		c.protocol = network.ProtocolHTTP3
+	}
+	return c.protocol
+}
+
+// ClassifyPayload classifies a single payload, without any connection context.
+func ClassifyPayload(payload []byte) network.ProtocolType {
+	return NewClassifier().Classify(payload)
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package classification
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+func TestGoldenCorpus(t *testing.T) {
+	samples, err := protocolsUtils.GoldenSamples()
+	require.NoError(t, err)
+
+	for _, sample := range samples {
+		for i, flow := range sample.Flows {
+			t.Run(fmt.Sprintf("%s flow %d", sample.Name, i), func(t *testing.T) {
+				classifier := NewClassifier()
+				for _, segment := range flow.Segments {
+					classifier.Classify(segment.Payload)
+				}
+				assert.Equal(t, sample.Protocol, classifier.Protocol().String())
+				// the captures of gRPC-Web are named after it, as it is classified as HTTP or HTTP/2
+				assert.Equal(t, strings.HasPrefix(filepath.Base(sample.Name), "grpc_web"), classifier.IsGRPCWeb())
+			})
+		}
+	}
+}
+
+func TestClassifyPayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected network.ProtocolType
+	}{
+		{name: "http request", payload: "OPTIONS * HTTP/1.1\r\n", expected: network.ProtocolHTTP},
+		{name: "http response", payload: "HTTP/1.1 200 OK\r\n", expected: network.ProtocolHTTP},
+		{name: "http too short", payload: "GET / HTTP/1.1", expected: network.ProtocolUnknown},
+		{name: "http2 empty settings", payload: "\x00\x00\x00\x04\x00\x00\x00\x00\x00", expected: network.ProtocolHTTP2},
+		{name: "http2 settings on a stream", payload: "\x00\x00\x00\x04\x00\x00\x00\x00\x01", expected: network.ProtocolUnknown},
+		{name: "ssh banner", payload: "SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1\r\n", expected: network.ProtocolSSH},
+		{name: "ssh 1.99 banner", payload: "SSH-1.99-Cisco-1.25\r\n", expected: network.ProtocolSSH},
+		{name: "ssh 1 banner", payload: "SSH-1.5-OpenSSH_3.4\r\n", expected: network.ProtocolUnknown},
+		{name: "rdp connection request", payload: "\x03\x00\x00\x13\x0e\xe0\x00\x00\x00\x00\x00\x01\x00\x08\x00\x03\x00\x00\x00", expected: network.ProtocolRDP},
+		{name: "rdp connection request with cookie", payload: "\x03\x00\x00\x2b\x26\xe0\x00\x00\x00\x00\x00Cookie: mstshash=user\r\n", expected: network.ProtocolRDP},
+		{name: "rdp connection confirm", payload: "\x03\x00\x00\x13\x0e\xd0\x00\x00\x12\x34\x00\x02\x00\x08\x00\x02\x00\x00\x00", expected: network.ProtocolUnknown},
+		{name: "rdp length mismatch", payload: "\x03\x00\x00\x13\x0f\xe0\x00\x00\x00\x00\x00\x01\x00\x08\x00\x03\x00\x00\x00", expected: network.ProtocolUnknown},
+		{name: "redis error", payload: "-ERR unknown command", expected: network.ProtocolRedis},
+		{name: "redis simple string without crlf", payload: "+OK", expected: network.ProtocolUnknown},
+		{name: "redis integer", payload: ":1000\r\n", expected: network.ProtocolRedis},
+		{name: "redis resp3 map", payload: "%7\r\n$6\r\nserver\r\n", expected: network.ProtocolRedis},
+		{name: "redis resp3 push", payload: ">2\r\n$10\r\ninvalidate\r\n", expected: network.ProtocolRedis},
+		{name: "redis resp3 unsupported protocol", payload: "-NOPROTO sorry, this protocol version is not supported", expected: network.ProtocolRedis},
+		{name: "redis resp3 push without crlf", payload: ">2\r", expected: network.ProtocolUnknown},
+		{name: "kafka produce", payload: "\x00\x00\x00\x50\x00\x00\x00\x07\x00\x00\x00\x02\x00\x07rdkafka\xff\xff", expected: network.ProtocolKafka},
+		{name: "kafka fetch", payload: "\x00\x00\x00\x50\x00\x01\x00\x0b\x00\x00\x00\x03\xff\xff\xff\xff\xff\xff", expected: network.ProtocolKafka},
+		{name: "kafka unsupported api", payload: "\x00\x00\x00\x50\x00\x12\x00\x03\x00\x00\x00\x01\x00\x07rdkafka", expected: network.ProtocolUnknown},
+		{name: "kafka flexible produce", payload: "\x00\x00\x00\x50\x00\x00\x00\x09\x00\x00\x00\x02\x00\x07rdkafka", expected: network.ProtocolUnknown},
+		{name: "kafka invalid client id", payload: "\x00\x00\x00\x50\x00\x00\x00\x07\x00\x00\x00\x02\x00\x07rd kafk", expected: network.ProtocolUnknown},
+		{name: "postgres lowercase query", payload: "Q\x00\x00\x00\x0eupdate t\x00", expected: network.ProtocolPostgres},
+		{name: "postgres non sql query", payload: "Q\x00\x00\x00\x0eBEGIN;\x00", expected: network.ProtocolUnknown},
+		{name: "mysql greeting", payload: "\x4a\x00\x00\x00\x0a5.7.41\x00", expected: network.ProtocolMySQL},
+		{name: "mysql invalid version", payload: "\x4a\x00\x00\x00\x0a5.7a41\x00", expected: network.ProtocolUnknown},
+		{name: "cassandra options", payload: "\x04\x00\x00\x00\x05\x00\x00\x00\x00", expected: network.ProtocolCassandra},
+		{name: "cassandra query", payload: "\x04\x00\x00\x01\x07\x00\x00\x00\x13\x00\x00\x00\x0fUSE system_auth", expected: network.ProtocolCassandra},
+		{name: "cassandra result", payload: "\x84\x00\x00\x01\x08\x00\x00\x00\x04\x00\x00\x00\x01", expected: network.ProtocolCassandra},
+		{name: "cassandra request on a server stream", payload: "\x04\x00\xff\xff\x07\x00\x00\x00\x13\x00\x00\x00\x0fUSE system_auth", expected: network.ProtocolUnknown},
+		{name: "cassandra unsupported version", payload: "\x02\x00\x00\x00\x05\x00\x00\x00\x00", expected: network.ProtocolUnknown},
+		{name: "cassandra response opcode in a request", payload: "\x04\x00\x00\x01\x08\x00\x00\x00\x04\x00\x00\x00\x01", expected: network.ProtocolUnknown},
+		{name: "memcached get", payload: "get user:1\r\n", expected: network.ProtocolMemcached},
+		{name: "memcached set", payload: "set user:1 0 3600 5\r\nhello\r\n", expected: network.ProtocolMemcached},
+		{name: "memcached long key", payload: "delete " + strings.Repeat("k", 40), expected: network.ProtocolMemcached},
+		{name: "memcached get without crlf", payload: "get user:1", expected: network.ProtocolUnknown},
+		{name: "memcached value", payload: "VALUE user:1 0 5\r\nhello\r\nEND\r\n", expected: network.ProtocolMemcached},
+		{name: "memcached miss", payload: "END\r\n", expected: network.ProtocolMemcached},
+		{name: "memcached stored", payload: "STORED\r\n", expected: network.ProtocolMemcached},
+		{name: "memcached binary get", payload: "\x80\x00\x00\x06\x00\x00\x00\x00\x00\x00\x00\x06\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00user:1", expected: network.ProtocolMemcached},
+		{name: "memcached binary key not found", payload: "\x81\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x09\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00Not found", expected: network.ProtocolMemcached},
+		{name: "memcached binary key longer than the body", payload: "\x80\x00\x00\x10\x00\x00\x00\x00\x00\x00\x00\x06\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00user:1", expected: network.ProtocolUnknown},
+		{name: "empty", payload: "", expected: network.ProtocolUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ClassifyPayload([]byte(tt.payload)))
+		})
+	}
+}
+
+func TestClassifyDatagram(t *testing.T) {
+	initial := func(first byte, version uint32, size int) []byte {
+		datagram := make([]byte, size)
+		datagram[0] = first
+		binary.BigEndian.PutUint32(datagram[1:], version)
+		datagram[5] = 8
+		return datagram
+	}
+
+	tests := []struct {
+		name     string
+		datagram []byte
+		expected network.ProtocolType
+	}{
+		{name: "quic v1 initial", datagram: initial(0xc3, 0x00000001, 1200), expected: network.ProtocolHTTP3},
+		{name: "quic draft-29 initial", datagram: initial(0xc0, 0xff00001d, 1252), expected: network.ProtocolHTTP3},
+		{name: "quic v2 initial", datagram: initial(0xd1, 0x6b3343cf, 1200), expected: network.ProtocolHTTP3},
+		{name: "quic v2 retry", datagram: initial(0xc0, 0x6b3343cf, 1200), expected: network.ProtocolUnknown},
+		{name: "quic v1 handshake", datagram: initial(0xe0, 0x00000001, 1200), expected: network.ProtocolUnknown},
+		{name: "quic short header", datagram: initial(0x43, 0x00000001, 1200), expected: network.ProtocolUnknown},
+		{name: "quic version negotiation", datagram: initial(0xc0, 0x00000000, 1200), expected: network.ProtocolUnknown},
+		{name: "unpadded quic initial", datagram: initial(0xc0, 0x00000001, 1199), expected: network.ProtocolUnknown},
+		{name: "empty", datagram: nil, expected: network.ProtocolUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewClassifier().ClassifyDatagram(tt.datagram))
+		})
+	}
+}
+
+func TestMongoReplyRequiresRequest(t *testing.T) {
+	reply := []byte{
+		0x20, 0x00, 0x00, 0x00, // message length
+		0x02, 0x00, 0x00, 0x00, // request id
+		0x01, 0x00, 0x00, 0x00, // response to
+		0x01, 0x00, 0x00, 0x00, // OP_REPLY
+	}
+	assert.Equal(t, network.ProtocolUnknown, ClassifyPayload(reply))
+
+	classifier := NewClassifier()
+	query := []byte{
+		0x20, 0x00, 0x00, 0x00, // message length
+		0x01, 0x00, 0x00, 0x00, // request id
+		0x00, 0x00, 0x00, 0x00, // response to
+		0xd4, 0x07, 0x00, 0x00, // OP_QUERY
+	}
+	require.True(t, classifier.isMongo(query, len(query)))
+	assert.True(t, classifier.isMongo(reply, len(reply)))
+	// the request must only be matched once
+	assert.False(t, classifier.isMongo(reply, len(reply)))
+}
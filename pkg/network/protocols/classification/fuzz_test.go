@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build go1.18
+// +build go1.18
+
+package classification
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+// FuzzClassify classifies raw payloads, seeded with the shared corpus of the protocols, as the first and following
+// segments of a connection.
+func FuzzClassify(f *testing.F) {
+	for _, protocol := range []string{"http", "http2", "kafka"} {
+		protocolsUtils.AddCorpus(f, protocol)
+	}
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		classifier := NewClassifier()
+		protocol := classifier.Classify(payload)
+
+		// like the eBPF programs, the classifier only inspects the first MaxBufferSize bytes of the payload
+		prefix := payload
+		if len(prefix) > MaxBufferSize {
+			prefix = prefix[:MaxBufferSize]
+		}
+		if prefixProtocol := ClassifyPayload(prefix); prefixProtocol != protocol {
+			t.Fatalf("payload classified as %s, but its first %d bytes as %s", protocol, len(prefix), prefixProtocol)
+		}
+
+		// a connection keeps the first protocol it has been classified with
+		if next := classifier.Classify(payload[len(payload)/2:]); protocol != network.ProtocolUnknown && next != protocol {
+			t.Fatalf("connection classified as %s was reclassified as %s", protocol, next)
+		}
+		if classifier.IsGRPCWeb() && classifier.Protocol() != network.ProtocolHTTP && classifier.Protocol() != network.ProtocolHTTP2 {
+			t.Fatalf("connection classified as %s carries gRPC-Web", classifier.Protocol())
+		}
+
+		if datagram := NewClassifier().ClassifyDatagram(payload); datagram != network.ProtocolUnknown && datagram != network.ProtocolHTTP3 {
+			t.Fatalf("datagram classified as %s", datagram)
+		}
+	})
+}
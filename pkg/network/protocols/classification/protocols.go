@@ -0,0 +1,688 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package classification
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// The helpers below mirror the eBPF helpers found under pkg/network/ebpf/c/protocols/<protocol>/helpers.h.
+// All of them receive a zero-padded buffer of MaxBufferSize bytes, along with the actual size of the payload.
+
+// HTTP (protocols/http/classification-helpers.h)
+
+// httpMinSize is the size of the minimal HTTP request: "GET x HTTP/1.1\r\n"
+const httpMinSize = 16
+
+var httpPrefixes = [][]byte{
+	[]byte("HTTP/"),
+	[]byte("GET /"),
+	[]byte("POST /"),
+	[]byte("PUT /"),
+	[]byte("DELETE /"),
+	[]byte("HEAD /"),
+	[]byte("OPTIONS /"),
+	[]byte("OPTIONS *"),
+	[]byte("PATCH /"),
+}
+
+func isHTTP(buf []byte, size int) bool {
+	if size < httpMinSize {
+		return false
+	}
+	for _, prefix := range httpPrefixes {
+		if bytes.HasPrefix(buf, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTP/2 (protocols/http2/helpers.h)
+
+const (
+	http2MarkerSize      = 24
+	http2FrameHeaderSize = 9
+	http2SettingsSize    = 6
+	http2SettingsFrame   = 4
+)
+
+var http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+func isHTTP2(buf []byte, size int) bool {
+	return isHTTP2Preface(buf, size) || isHTTP2ServerSettings(buf, size)
+}
+
+func isHTTP2Preface(buf []byte, size int) bool {
+	return size >= http2MarkerSize && bytes.HasPrefix(buf, http2Preface)
+}
+
+// isHTTP2ServerSettings checks if the buffer starts with the settings frame a server must reply with to the preface.
+// The frame must not be related to a stream, and its length must be a multiple of the size of a single setting.
+func isHTTP2ServerSettings(buf []byte, size int) bool {
+	if size < http2FrameHeaderSize {
+		return false
+	}
+
+	header := buf[:http2FrameHeaderSize]
+	if bytes.Equal(header, make([]byte, http2FrameHeaderSize)) {
+		return false
+	}
+
+	length := uint32(header[0])<<16 | uint32(header[1])<<8 | uint32(header[2])
+	frameType := header[3]
+	streamID := binary.BigEndian.Uint32(header[5:]) & 0x7fffffff
+	return frameType == http2SettingsFrame && streamID == 0 && length%http2SettingsSize == 0
+}
+
+// HTTP/3 (protocols/http3/helpers.h)
+
+const (
+	quicLongHeaderForm      = 0x80
+	quicFixedBit            = 0x40
+	quicV1InitialType       = 0x0
+	quicV2InitialType       = 0x1
+	quicVersion1            = 0x00000001
+	quicVersion2            = 0x6b3343cf
+	quicVersionDraft29      = 0xff00001d
+	quicLongHeaderMinSize   = 6
+	quicMaxCIDLength        = 20
+	quicMinInitialDatagram  = 1200
+	quicPacketTypeShift     = 4
+	quicPacketTypeMask      = 0x3
+	quicLongHeaderFormFixed = quicLongHeaderForm | quicFixedBit
+)
+
+// isQUICInitial checks the long header of a QUIC Initial packet, along with the length of the datagram carrying it,
+// which the clients pad to at least 1200 bytes.
+func isQUICInitial(buf []byte, size int, datagramLength int) bool {
+	if size < quicLongHeaderMinSize || datagramLength < quicMinInitialDatagram {
+		return false
+	}
+	if buf[0]&quicLongHeaderFormFixed != quicLongHeaderFormFixed {
+		return false
+	}
+
+	packetType := (buf[0] >> quicPacketTypeShift) & quicPacketTypeMask
+	switch binary.BigEndian.Uint32(buf[1:]) {
+	case quicVersion1, quicVersionDraft29:
+		if packetType != quicV1InitialType {
+			return false
+		}
+	case quicVersion2:
+		if packetType != quicV2InitialType {
+			return false
+		}
+	default:
+		return false
+	}
+	return buf[5] <= quicMaxCIDLength
+}
+
+// SSH (protocols/ssh/helpers.h)
+
+var (
+	sshV2Banner   = []byte("SSH-2.0-")
+	sshV199Banner = []byte("SSH-1.99-")
+)
+
+func isSSH(buf []byte, size int) bool {
+	if size < len(sshV2Banner) {
+		return false
+	}
+	return bytes.HasPrefix(buf, sshV2Banner) || bytes.HasPrefix(buf, sshV199Banner)
+}
+
+// RDP (protocols/rdp/helpers.h)
+
+const (
+	tpktVersion                  = 0x03
+	tpktHeaderSize               = 4
+	x224ConnectionRequest        = 0xe0
+	x224TPDUCodeMask             = 0xf0
+	x224ConnectionRequestMinSize = 7
+	rdpMinLength                 = tpktHeaderSize + x224ConnectionRequestMinSize
+)
+
+// isRDP checks if the buffer holds the beginning of the X.224 Connection Request opening an RDP connection, whose
+// length indicator must match the length of the TPKT packet.
+func isRDP(buf []byte, size int) bool {
+	if size < rdpMinLength || buf[0] != tpktVersion || buf[1] != 0 {
+		return false
+	}
+
+	length := int(binary.BigEndian.Uint16(buf[2:]))
+	if length < rdpMinLength {
+		return false
+	}
+	if int(buf[tpktHeaderSize])+1 != length-tpktHeaderSize {
+		return false
+	}
+	return buf[tpktHeaderSize+1]&x224TPDUCodeMask == x224ConnectionRequest
+}
+
+// AMQP (protocols/amqp/helpers.h)
+
+const (
+	amqpMinFrameLength   = 8
+	amqpMinPayloadLength = 11
+	amqpFrameMethodType  = 1
+
+	amqpConnectionClass = 10
+	amqpChannelClass    = 20
+	amqpBasicClass      = 60
+
+	amqpMethodConnectionStart   = 10
+	amqpMethodConnectionStartOk = 11
+	amqpMethodCloseOk           = 40
+	amqpMethodClose             = 41
+	amqpMethodConsume           = 20
+	amqpMethodPublish           = 40
+	amqpMethodDeliver           = 60
+)
+
+var amqpPreface = []byte("AMQP")
+
+func isAMQP(buf []byte, size int) bool {
+	// New connections should start with the protocol header of AMQP.
+	if size >= amqpMinFrameLength && bytes.HasPrefix(buf, amqpPreface) {
+		return true
+	}
+
+	if size < amqpMinPayloadLength || buf[0] != amqpFrameMethodType {
+		return false
+	}
+
+	classID := binary.BigEndian.Uint16(buf[7:])
+	methodID := binary.BigEndian.Uint16(buf[9:])
+	switch classID {
+	case amqpConnectionClass:
+		return methodID == amqpMethodConnectionStart || methodID == amqpMethodConnectionStartOk
+	case amqpBasicClass:
+		return methodID == amqpMethodPublish || methodID == amqpMethodDeliver || methodID == amqpMethodConsume
+	case amqpChannelClass:
+		return methodID == amqpMethodCloseOk || methodID == amqpMethodClose
+	default:
+		return false
+	}
+}
+
+// Redis (protocols/redis/helpers.h)
+
+const redisMinFrameLength = 3
+
+var redisErrorPrefixes = [][]byte{
+	[]byte("-ERR "),
+	[]byte("-WRONGTYPE "),
+	[]byte("-NOPROTO "),
+}
+
+func isRedis(buf []byte, size int) bool {
+	if size < redisMinFrameLength {
+		return false
+	}
+
+	switch buf[0] {
+	case '+':
+		return checkRedisLine(buf, size, func(c byte) bool {
+			return ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || c == '.' || c == ' ' || c == '-' || c == '_'
+		})
+	case '-':
+		for _, prefix := range redisErrorPrefixes {
+			if bytes.HasPrefix(buf, prefix) {
+				return true
+			}
+		}
+		return false
+	// RESP3 aggregate and length-prefixed frames: map, set, push, attribute, blob error, verbatim string and big number
+	case ':', '$', '*', '%', '~', '>', '|', '!', '=', '(':
+		return checkRedisLine(buf, size, func(c byte) bool {
+			return '0' <= c && c <= '9'
+		})
+	default:
+		return false
+	}
+}
+
+// checkRedisLine checks that the buffer, starting from its second byte, is made of allowed characters up to a CRLF.
+func checkRedisLine(buf []byte, size int, allowed func(byte) bool) bool {
+	i := 1
+	for ; i < MaxBufferSize; i++ {
+		if buf[i] == '\r' {
+			break
+		}
+		if !allowed(buf[i]) {
+			return false
+		}
+	}
+
+	if i == MaxBufferSize || i+1 >= size {
+		return false
+	}
+	return buf[i+1] == '\n'
+}
+
+// Memcached (protocols/memcached/helpers.h)
+
+const (
+	memcachedMinFrameLength    = 5
+	memcachedBinaryHeaderSize  = 24
+	memcachedBinaryRequest     = 0x80
+	memcachedBinaryResponse    = 0x81
+	memcachedBinaryMaxOpcode   = 0x24
+	memcachedBinaryRawBytes    = 0x00
+	memcachedMaxBodyLength     = 1024 * 1024 * 1024
+	memcachedTextValueResponse = "VALUE "
+)
+
+var (
+	memcachedTextCommands = [][]byte{
+		[]byte("get "),
+		[]byte("gets "),
+		[]byte("gat "),
+		[]byte("gats "),
+		[]byte("set "),
+		[]byte("add "),
+		[]byte("replace "),
+		[]byte("append "),
+		[]byte("prepend "),
+		[]byte("cas "),
+		[]byte("delete "),
+		[]byte("incr "),
+		[]byte("decr "),
+		[]byte("touch "),
+	}
+	memcachedTextResponses = [][]byte{
+		[]byte("END\r\n"),
+		[]byte("STORED\r\n"),
+		[]byte("NOT_STORED\r\n"),
+		[]byte("DELETED\r\n"),
+		[]byte("NOT_FOUND\r\n"),
+		[]byte("TOUCHED\r\n"),
+	}
+)
+
+// isMemcached checks if the buffer starts with a request or a response of either the text or the binary protocol of
+// memcached. The responses of the text protocol are only recognized when they are specific enough to tell memcached
+// apart from the other text protocols.
+func isMemcached(buf []byte, size int) bool {
+	if size < memcachedMinFrameLength {
+		return false
+	}
+	if isMemcachedBinary(buf, size) {
+		return true
+	}
+
+	for _, command := range memcachedTextCommands {
+		if size >= len(command) && bytes.HasPrefix(buf, command) {
+			return checkMemcachedLine(buf, size, len(command))
+		}
+	}
+	if size >= len(memcachedTextValueResponse) && bytes.HasPrefix(buf, []byte(memcachedTextValueResponse)) {
+		return checkMemcachedLine(buf, size, len(memcachedTextValueResponse))
+	}
+	for _, response := range memcachedTextResponses {
+		if size >= len(response) && bytes.HasPrefix(buf, response) {
+			return true
+		}
+	}
+	return false
+}
+
+func isMemcachedBinary(buf []byte, size int) bool {
+	if size < memcachedBinaryHeaderSize {
+		return false
+	}
+	if buf[0] != memcachedBinaryRequest && buf[0] != memcachedBinaryResponse {
+		return false
+	}
+	if buf[1] > memcachedBinaryMaxOpcode || buf[5] != memcachedBinaryRawBytes {
+		return false
+	}
+	bodyLength := binary.BigEndian.Uint32(buf[8:])
+	return bodyLength <= memcachedMaxBodyLength && uint32(buf[4])+uint32(binary.BigEndian.Uint16(buf[2:])) <= bodyLength
+}
+
+// checkMemcachedLine checks that the buffer, starting from the given offset, is made of printable characters up to a
+// CRLF. The lines exceeding the buffer, such as the lines of the long keys, are accepted once the buffer is full.
+func checkMemcachedLine(buf []byte, size int, offset int) bool {
+	i := offset
+	for ; i < MaxBufferSize; i++ {
+		if buf[i] == '\r' {
+			break
+		}
+		if buf[i] < ' ' || buf[i] > '~' {
+			return false
+		}
+	}
+
+	if i+1 >= size {
+		return size >= MaxBufferSize
+	}
+	return buf[i+1] == '\n'
+}
+
+// Kafka (protocols/kafka/helpers.h)
+
+const (
+	kafkaRequestHeaderSize = 14
+	kafkaMinMessageSize    = kafkaRequestHeaderSize - 4
+	kafkaMaxClientIDSize   = 255
+
+	kafkaProduce           = 0
+	kafkaFetch             = 1
+	kafkaMaxProduceVersion = 8
+	kafkaMaxFetchVersion   = 11
+)
+
+func isKafka(buf []byte, size int) bool {
+	if size < kafkaRequestHeaderSize {
+		return false
+	}
+
+	messageSize := int32(binary.BigEndian.Uint32(buf[0:]))
+	apiKey := int16(binary.BigEndian.Uint16(buf[4:]))
+	apiVersion := int16(binary.BigEndian.Uint16(buf[6:]))
+	correlationID := int32(binary.BigEndian.Uint32(buf[8:]))
+	clientIDSize := int16(binary.BigEndian.Uint16(buf[12:]))
+
+	if messageSize < kafkaMinMessageSize || correlationID < 0 || clientIDSize < -1 || clientIDSize > kafkaMaxClientIDSize {
+		return false
+	}
+
+	switch apiKey {
+	case kafkaProduce:
+		if apiVersion < 0 || apiVersion > kafkaMaxProduceVersion {
+			return false
+		}
+	case kafkaFetch:
+		if apiVersion < 0 || apiVersion > kafkaMaxFetchVersion {
+			return false
+		}
+	default:
+		return false
+	}
+
+	// the bytes of the client id found in the buffer must be made of the characters commonly used by the clients
+	for i := kafkaRequestHeaderSize; i < MaxBufferSize && i < size && i-kafkaRequestHeaderSize < int(clientIDSize); i++ {
+		c := buf[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '.' || c == '_' || c == '-' {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// Mongo (protocols/mongo/helpers.h)
+
+const (
+	mongoHeaderLength = 16
+
+	mongoOpReply      = 1
+	mongoOpUpdate     = 2001
+	mongoOpInsert     = 2002
+	mongoOpQuery      = 2004
+	mongoOpGetMore    = 2005
+	mongoOpDelete     = 2006
+	mongoOpCompressed = 2012
+	mongoOpMsg        = 2013
+)
+
+func (c *Classifier) isMongo(buf []byte, size int) bool {
+	if size < mongoHeaderLength {
+		return false
+	}
+
+	messageLength := int32(binary.LittleEndian.Uint32(buf[0:]))
+	requestID := int32(binary.LittleEndian.Uint32(buf[4:]))
+	responseTo := int32(binary.LittleEndian.Uint32(buf[8:]))
+	opCode := int32(binary.LittleEndian.Uint32(buf[12:]))
+
+	if messageLength < mongoHeaderLength || requestID < 0 {
+		return false
+	}
+
+	switch opCode {
+	case mongoOpUpdate, mongoOpInsert, mongoOpDelete:
+		return responseTo == 0
+	case mongoOpReply:
+		// Make sure we've seen the request of the response, to eliminate false positives.
+		return c.mongoHaveSeenRequest(responseTo)
+	case mongoOpQuery, mongoOpGetMore:
+		if responseTo == 0 {
+			c.mongoRequestIDs[requestID] = struct{}{}
+			return true
+		}
+		return false
+	case mongoOpCompressed, mongoOpMsg:
+		if responseTo == 0 {
+			c.mongoRequestIDs[requestID] = struct{}{}
+			return true
+		}
+		return c.mongoHaveSeenRequest(responseTo)
+	}
+
+	return false
+}
+
+func (c *Classifier) mongoHaveSeenRequest(responseTo int32) bool {
+	_, ok := c.mongoRequestIDs[responseTo]
+	delete(c.mongoRequestIDs, responseTo)
+	return ok
+}
+
+// SQL (protocols/sql/helpers.h)
+
+const sqlCommandMaxSize = 6
+
+var sqlCommands = [][]byte{
+	[]byte("ALTER"),
+	[]byte("CREATE"),
+	[]byte("DELETE"),
+	[]byte("DROP"),
+	[]byte("INSERT"),
+	[]byte("SELECT"),
+	[]byte("UPDATE"),
+}
+
+// isSQLCommand checks if buf starts with one of the most commonly used SQL commands, regardless of the case.
+func isSQLCommand(buf []byte) bool {
+	var command [sqlCommandMaxSize]byte
+	for i := 0; i < sqlCommandMaxSize && i < len(buf); i++ {
+		c := buf[i]
+		if 'a' <= c && c <= 'z' {
+			c = c - 'a' + 'A'
+		}
+		command[i] = c
+	}
+
+	for _, sqlCommand := range sqlCommands {
+		if bytes.HasPrefix(command[:], sqlCommand) {
+			return true
+		}
+	}
+	return false
+}
+
+// Postgres (protocols/postgres/helpers.h)
+
+const (
+	postgresStartupMinLength  = 13
+	postgresMessageHeaderSize = 5
+	postgresMinPayloadLength  = 4
+	postgresMaxPayloadLength  = 30000
+	postgresStartupVersion    = 196608
+
+	postgresQueryMagicByte           = 'Q'
+	postgresCommandCompleteMagicByte = 'C'
+)
+
+var postgresStartupUserParam = []byte("user\x00")
+
+func isPostgres(buf []byte, size int) bool {
+	return isPostgresQuery(buf, size) || isPostgresConnect(buf, size)
+}
+
+func isPostgresConnect(buf []byte, size int) bool {
+	if size < postgresStartupMinLength {
+		return false
+	}
+
+	if binary.BigEndian.Uint32(buf[4:]) != postgresStartupVersion {
+		return false
+	}
+	return bytes.HasPrefix(buf[8:], postgresStartupUserParam)
+}
+
+func isPostgresQuery(buf []byte, size int) bool {
+	if size < postgresMessageHeaderSize {
+		return false
+	}
+
+	if buf[0] != postgresQueryMagicByte && buf[0] != postgresCommandCompleteMagicByte {
+		return false
+	}
+
+	length := binary.BigEndian.Uint32(buf[1:])
+	if length < postgresMinPayloadLength || length > postgresMaxPayloadLength {
+		return false
+	}
+	return isSQLCommand(buf[postgresMessageHeaderSize:])
+}
+
+// MySQL (protocols/mysql/helpers.h)
+
+const (
+	mysqlMinLength         = 5
+	mysqlCommandQuery      = 0x3
+	mysqlPrepareQuery      = 0x16
+	mysqlServerGreetingV9  = 0x9
+	mysqlServerGreetingV10 = 0xa
+
+	mysqlMaxVersionComponent = 3
+	mysqlMinVersionSize      = 5
+)
+
+func isMySQL(buf []byte, size int) bool {
+	if size < mysqlMinLength {
+		return false
+	}
+
+	payloadLength := uint32(buf[0]) | uint32(buf[1])<<8 | uint32(buf[2])<<16
+	if payloadLength == 0 {
+		return false
+	}
+
+	switch buf[4] {
+	case mysqlCommandQuery, mysqlPrepareQuery:
+		return isSQLCommand(buf[mysqlMinLength:])
+	case mysqlServerGreetingV10, mysqlServerGreetingV9:
+		return isMySQLVersion(buf[mysqlMinLength:], size-mysqlMinLength)
+	default:
+		return false
+	}
+}
+
+// isMySQLVersion checks if buf is a null terminated string of the format <major>.<minor>.<bugfix>, where each
+// component is made of up to 2 digits.
+func isMySQLVersion(buf []byte, size int) bool {
+	if size < mysqlMinVersionSize {
+		return false
+	}
+
+	offset := 0
+	for _, delimiter := range []byte{'.', '.'} {
+		n := mysqlVersionComponent(buf, offset, size, delimiter)
+		if n == 0 {
+			return false
+		}
+		offset += n
+	}
+	return mysqlVersionComponent(buf, offset, size, 0) > 0
+}
+
+// mysqlVersionComponent validates that buf[offset:] is of the format <number><delimiter>, and returns the size of
+// the component, or 0 if it is invalid.
+func mysqlVersionComponent(buf []byte, offset, size int, delimiter byte) int {
+	for i := 0; i < mysqlMaxVersionComponent; i++ {
+		if offset+i >= size {
+			break
+		}
+		c := buf[offset+i]
+		if '0' <= c && c <= '9' {
+			continue
+		}
+		if c == delimiter && i > 0 {
+			return i + 1
+		}
+		break
+	}
+	return 0
+}
+
+// Cassandra (protocols/cassandra/helpers.h)
+
+const (
+	cqlHeaderSize        = 9
+	cqlResponseDirection = 0x80
+	cqlVersionMask       = 0x7f
+	cqlMinVersion        = 3
+	cqlMaxVersion        = 5
+	cqlFlagsMask         = 0x1f
+	cqlMaxFrameLength    = 256 * 1024 * 1024
+)
+
+var (
+	cqlRequestOpcodes = map[byte]struct{}{
+		0x01: {}, // STARTUP
+		0x05: {}, // OPTIONS
+		0x07: {}, // QUERY
+		0x09: {}, // PREPARE
+		0x0a: {}, // EXECUTE
+		0x0b: {}, // REGISTER
+		0x0d: {}, // BATCH
+		0x0f: {}, // AUTH_RESPONSE
+	}
+	cqlResponseOpcodes = map[byte]struct{}{
+		0x00: {}, // ERROR
+		0x02: {}, // READY
+		0x03: {}, // AUTHENTICATE
+		0x06: {}, // SUPPORTED
+		0x08: {}, // RESULT
+		0x0c: {}, // EVENT
+		0x0e: {}, // AUTH_CHALLENGE
+		0x10: {}, // AUTH_SUCCESS
+	}
+)
+
+// isCassandra checks if the buffer starts with the header of a CQL frame, the requests being sent on the streams of
+// the clients, whose ids are positive.
+func isCassandra(buf []byte, size int) bool {
+	if size < cqlHeaderSize {
+		return false
+	}
+
+	version := buf[0] & cqlVersionMask
+	if version < cqlMinVersion || version > cqlMaxVersion {
+		return false
+	}
+	if buf[1]&^cqlFlagsMask != 0 {
+		return false
+	}
+	if binary.BigEndian.Uint32(buf[5:]) > cqlMaxFrameLength {
+		return false
+	}
+
+	opcode := buf[4]
+	if buf[0]&cqlResponseDirection != 0 {
+		_, ok := cqlResponseOpcodes[opcode]
+		return ok
+	}
+	_, ok := cqlRequestOpcodes[opcode]
+	return ok && int16(binary.BigEndian.Uint16(buf[2:])) >= 0
+}
@@ -29,8 +29,12 @@ var handlerMux sync.Mutex
 
 // Configure event processing
 // Must be called *before* manager.InitWithOptions
-func Configure(proto string, m *manager.Manager, o *manager.Options) {
-	setupPerfMap(proto, m)
+func Configure(cfg *ddebpf.Config, proto string, m *manager.Manager, o *manager.Options) {
+	if ddebpf.UseRingBuffers(cfg) {
+		setupRingBuffer(cfg, proto, m)
+	} else {
+		setupPerfMap(cfg, proto, m)
+	}
 	onlineCPUs, err := cpupossible.Get()
 	if err != nil {
 		onlineCPUs = make([]uint, 96)
@@ -60,7 +64,7 @@ func getHandler(proto string) *ddebpf.PerfHandler {
 	return handler
 }
 
-func setupPerfMap(proto string, m *manager.Manager) {
+func setupPerfMap(cfg *ddebpf.Config, proto string, m *manager.Manager) {
 	// check if we already have configured this perf map
 	// this can happen in the context of a failed program load succeeded by another attempt
 	mapName := proto + eventsMapSuffix
@@ -74,7 +78,7 @@ func setupPerfMap(proto string, m *manager.Manager) {
 	m.PerfMaps = append(m.PerfMaps, &manager.PerfMap{
 		Map: manager.Map{Name: mapName},
 		PerfMapOptions: manager.PerfMapOptions{
-			PerfRingBufferSize: 16 * os.Getpagesize(),
+			PerfRingBufferSize: ddebpf.PerfBufferSize(cfg, mapName, 16*os.Getpagesize()),
 			Watermark:          1,
 			RecordHandler:      handler.RecordHandler,
 			LostHandler:        handler.LostHandler,
@@ -82,6 +86,34 @@ func setupPerfMap(proto string, m *manager.Manager) {
 		},
 	})
 
+	setHandler(proto, handler)
+}
+
+// setupRingBuffer sets up the ring buffer replacing the perf map of the protocol on the kernels supporting them.
+// The ring buffer is created by ddebpf.EditRingBuffers, which must be called once all protocols are configured.
+func setupRingBuffer(cfg *ddebpf.Config, proto string, m *manager.Manager) {
+	// check if we already have configured this ring buffer
+	// this can happen in the context of a failed program load succeeded by another attempt
+	mapName := proto + eventsMapSuffix
+	for _, ringBuffer := range m.RingBuffers {
+		if ringBuffer.Map.Name == mapName {
+			return
+		}
+	}
+
+	handler := ddebpf.NewPerfHandler(100)
+	m.RingBuffers = append(m.RingBuffers, &manager.RingBuffer{
+		Map: manager.Map{Name: mapName},
+		RingBufferOptions: manager.RingBufferOptions{
+			RingBufferSize: ddebpf.RingBufferSize(ddebpf.PerfBufferSize(cfg, mapName, 16*os.Getpagesize())),
+			DataHandler:    handler.RingBufferHandler,
+		},
+	})
+
+	setHandler(proto, handler)
+}
+
+func setHandler(proto string, handler *ddebpf.PerfHandler) {
 	handlerMux.Lock()
 	if handlerByProtocol == nil {
 		handlerByProtocol = make(map[string]*ddebpf.PerfHandler)
@@ -60,12 +60,12 @@ func NewConsumer(proto string, ebpf *manager.Manager, callback func([]byte)) (*C
 	}
 
 	eventsMapName := proto + eventsMapSuffix
-	eventsMap, found, _ := ebpf.GetMap(eventsMapName)
-	if !found {
+	if _, found, _ := ebpf.GetMap(eventsMapName); !found {
 		return nil, fmt.Errorf("unable to find map %s", eventsMapName)
 	}
 
-	numCPUs := int(eventsMap.MaxEntries())
+	// the events map is either a perf map holding an entry per CPU, or a ring buffer
+	numCPUs := int(batchMap.MaxEntries()) / batchPagesPerCPU
 	offsets := newOffsetManager(numCPUs)
 	batchReader, err := newBatchReader(offsets, batchMap, numCPUs)
 	if err != nil {
@@ -119,14 +119,16 @@ func (c *Consumer) Start() {
 				}
 
 				b := batchFromEventData(dataEvent.Data)
-				c.process(dataEvent.CPU, b, false)
+				// the batch tells its CPU, as the events of a ring buffer are all read as coming from CPU 0
+				c.process(int(b.Cpu), b, false)
 				dataEvent.Done()
-			case _, ok := <-c.handler.LostChannel:
+			case lostCount, ok := <-c.handler.LostChannel:
 				if !ok {
 					return
 				}
 
-				missedEvents := c.batchSize.Load()
+				// each lost sample is the notification of a full batch
+				missedEvents := c.batchSize.Load() * int64(lostCount)
 				c.missesCount.Add(missedEvents)
 			case done, ok := <-c.syncRequest:
 				if !ok {
@@ -24,6 +24,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sys/unix"
 
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	bpftelemetry "github.com/DataDog/datadog-agent/pkg/network/telemetry"
@@ -158,7 +159,10 @@ func newEBPFProgram(c *config.Config) (*manager.Manager, error) {
 		},
 	}
 
-	Configure("test", m, &options)
+	Configure(&c.Config, "test", m, &options)
+	if err := ddebpf.EditRingBuffers(m, &options); err != nil {
+		return nil, err
+	}
 	m.InstructionPatcher = func(m *manager.Manager) error {
 		return bpftelemetry.PatchEBPFTelemetry(m, true, nil)
 	}
@@ -9,7 +9,9 @@ type batch struct {
 	Cap            uint16
 	Event_size     uint16
 	Dropped_events uint16
+	Cpu            uint16
 	Data           [4096]int8
+	Pad_cgo_0      [6]byte
 }
 type batchKey struct {
 	Cpu uint32
@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package grpc
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+// The flags of the HEADERS frames.
+// Ref: https://datatracker.ietf.org/doc/html/rfc7540#section-6.2
+const (
+	flagEndStream  = 0x1
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+
+	// prioritySize is the size of the stream dependency and weight fields of the HEADERS frames carrying a priority
+	prioritySize = 5
+)
+
+const (
+	pathHeader   = ":path"
+	statusHeader = "grpc-status"
+
+	// maxDynamicTableSize is the largest HPACK dynamic table accepted, as the peers may use a larger one than the
+	// default 4096 bytes when their SETTINGS_HEADER_TABLE_SIZE setting allows it
+	maxDynamicTableSize = 64 * 1024
+)
+
+var errInvalidFrame = errors.New("invalid padding or priority")
+
+// headers are the fields of a header block relevant to the gRPC calls
+type headers struct {
+	// path is the path of the requests, which names the gRPC method
+	path string
+	// status is the gRPC status of the responses, which is sent in their trailers
+	status    uint8
+	hasStatus bool
+}
+
+// newDecoder returns an HPACK decoder, which holds the dynamic table of the header blocks sent by one of the sides of
+// a connection
+func newDecoder() *hpack.Decoder {
+	dec := hpack.NewDecoder(4096, nil)
+	dec.SetAllowedMaxDynamicTableSize(maxDynamicTableSize)
+	return dec
+}
+
+// headerBlock returns the header block of the payload of a HEADERS frame of the given length, stripping its padding
+// and priority. complete is false if the header block is truncated, either because it didn't fit in the fragment or
+// because it is continued in CONTINUATION frames.
+func headerBlock(fragment []byte, frameLength uint32, flags uint8) (block []byte, complete bool, ok bool) {
+	start, end := 0, int(frameLength)
+	if flags&flagPadded != 0 {
+		if len(fragment) < 1 {
+			return nil, false, false
+		}
+		start++
+		end -= int(fragment[0])
+	}
+	if flags&flagPriority != 0 {
+		start += prioritySize
+	}
+	if start > end || start > len(fragment) {
+		return nil, false, false
+	}
+
+	complete = flags&flagEndHeaders != 0
+	if end > len(fragment) {
+		end = len(fragment)
+		complete = false
+	}
+	return fragment[start:end], complete, true
+}
+
+// decodeHeaders decodes the header block with dec. The fields decoded before the end of a truncated header block are
+// returned, but dec must not be used anymore, as it misses the entries of the dynamic table added by the end of the
+// header block.
+func decodeHeaders(dec *hpack.Decoder, block []byte, complete bool) (headers, error) {
+	var h headers
+	dec.SetEmitFunc(func(f hpack.HeaderField) {
+		switch f.Name {
+		case pathHeader:
+			h.path = f.Value
+		case statusHeader:
+			status, err := strconv.ParseUint(f.Value, 10, 8)
+			if err == nil {
+				h.status = uint8(status)
+				h.hasStatus = true
+			}
+		}
+	})
+	defer dec.SetEmitFunc(nil)
+
+	if _, err := dec.Write(block); err != nil {
+		return h, err
+	}
+	if complete {
+		return h, dec.Close()
+	}
+	return h, nil
+}
+
+// parsePath returns the service and the method named by the path of a gRPC request, such as
+// "/helloworld.Greeter/SayHello"
+func parsePath(path string) (service string, method string, ok bool) {
+	if !strings.HasPrefix(path, "/") {
+		return "", "", false
+	}
+	service, method, ok = strings.Cut(path[1:], "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return "", "", false
+	}
+	return service, method, true
+}
@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package grpc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2/hpack"
+)
+
+// encoder encodes the header blocks sent by one of the sides of a connection
+type encoder struct {
+	buf bytes.Buffer
+	enc *hpack.Encoder
+}
+
+func newEncoder() *encoder {
+	e := new(encoder)
+	e.enc = hpack.NewEncoder(&e.buf)
+	return e
+}
+
+// encode returns the header block of the given name and value pairs
+func (e *encoder) encode(t *testing.T, fields ...string) []byte {
+	e.buf.Reset()
+	for i := 0; i+1 < len(fields); i += 2 {
+		require.NoError(t, e.enc.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]}))
+	}
+	return append([]byte(nil), e.buf.Bytes()...)
+}
+
+func newRequestHeaders(e *encoder, t *testing.T, path string) []byte {
+	return e.encode(t,
+		":method", "POST",
+		":scheme", "http",
+		":path", path,
+		":authority", "localhost:50051",
+		"content-type", "application/grpc",
+		"user-agent", "grpc-go/1.51.0",
+		"te", "trailers",
+	)
+}
+
+func TestHeaderBlock(t *testing.T) {
+	block := []byte("header block")
+	padded := append(append([]byte{3}, block...), 0, 0, 0)
+	priority := append([]byte{0, 0, 0, 1, 16}, block...)
+
+	tests := []struct {
+		name        string
+		fragment    []byte
+		frameLength uint32
+		flags       uint8
+		block       []byte
+		complete    bool
+		ok          bool
+	}{
+		{name: "complete", fragment: block, frameLength: uint32(len(block)), flags: flagEndHeaders, block: block, complete: true, ok: true},
+		{name: "continued", fragment: block, frameLength: uint32(len(block)), block: block, complete: false, ok: true},
+		{name: "truncated", fragment: block[:4], frameLength: uint32(len(block)), flags: flagEndHeaders, block: block[:4], complete: false, ok: true},
+		{name: "padded", fragment: padded, frameLength: uint32(len(padded)), flags: flagEndHeaders | flagPadded, block: block, complete: true, ok: true},
+		{name: "priority", fragment: priority, frameLength: uint32(len(priority)), flags: flagEndHeaders | flagPriority, block: block, complete: true, ok: true},
+		{name: "invalid padding", fragment: []byte{200, 1, 2}, frameLength: 3, flags: flagEndHeaders | flagPadded, ok: false},
+		{name: "invalid priority", fragment: []byte{0, 0}, frameLength: 2, flags: flagEndHeaders | flagPriority, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, complete, ok := headerBlock(tt.fragment, tt.frameLength, tt.flags)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.complete, complete)
+			assert.Equal(t, tt.block, block)
+		})
+	}
+}
+
+func TestDecodeHeaders(t *testing.T) {
+	enc := newEncoder()
+	dec := newDecoder()
+
+	h, err := decodeHeaders(dec, newRequestHeaders(enc, t, "/helloworld.Greeter/SayHello"), true)
+	require.NoError(t, err)
+	assert.Equal(t, headers{path: "/helloworld.Greeter/SayHello"}, h)
+
+	// the second request refers to the entries of the dynamic table added by the first one
+	block := newRequestHeaders(enc, t, "/helloworld.Greeter/SayHello")
+	h, err = decodeHeaders(dec, block, true)
+	require.NoError(t, err)
+	assert.Equal(t, headers{path: "/helloworld.Greeter/SayHello"}, h)
+
+	// without the dynamic table, the same header block can't be decoded
+	_, err = decodeHeaders(newDecoder(), block, true)
+	assert.Error(t, err)
+
+	h, err = decodeHeaders(dec, enc.encode(t, "grpc-status", "5", "grpc-message", "not found"), true)
+	require.NoError(t, err)
+	assert.Equal(t, headers{status: 5, hasStatus: true}, h)
+
+	// the fields preceding the end of a truncated header block are decoded
+	block = newEncoder().encode(t, ":path", "/helloworld.Greeter/SayHello", "user-agent", "grpc-go/1.51.0")
+	h, err = decodeHeaders(newDecoder(), block[:len(block)-4], false)
+	require.NoError(t, err)
+	assert.Equal(t, headers{path: "/helloworld.Greeter/SayHello"}, h)
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		service string
+		method  string
+		ok      bool
+	}{
+		{path: "/helloworld.Greeter/SayHello", service: "helloworld.Greeter", method: "SayHello", ok: true},
+		{path: "/grpc.health.v1.Health/Check", service: "grpc.health.v1.Health", method: "Check", ok: true},
+		{path: "/index.html", ok: false},
+		{path: "/a/b/c", ok: false},
+		{path: "//SayHello", ok: false},
+		{path: "helloworld.Greeter/SayHello", ok: false},
+		{path: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			service, method, ok := parsePath(tt.path)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.service, service)
+			assert.Equal(t, tt.method, method)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build go1.18
+// +build go1.18
+
+package grpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+const (
+	// frameHeaderSize is the size of the length, type, flags and stream identifier of the HTTP/2 frames
+	frameHeaderSize = 9
+	headersFrame    = 0x1
+)
+
+var preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+// FuzzHeaderBlock splits raw HTTP/2 payloads into frames, and decodes the header blocks of their HEADERS frames as the
+// statkeeper does with the fragments captured by the eBPF programs.
+func FuzzHeaderBlock(f *testing.F) {
+	protocolsUtils.AddCorpus(f, "http2")
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		data = bytes.TrimPrefix(data, preface)
+		dec := newDecoder()
+		for len(data) >= frameHeaderSize {
+			length := binary.BigEndian.Uint32(data[:4]) >> 8
+			frameType, flags := data[3], data[4]
+			fragment := data[frameHeaderSize:]
+			if uint64(length) < uint64(len(fragment)) {
+				fragment = fragment[:length]
+			}
+			data = data[frameHeaderSize+len(fragment):]
+			if frameType != headersFrame {
+				continue
+			}
+
+			block, complete, ok := headerBlock(fragment, length, flags)
+			if !ok {
+				continue
+			}
+			if len(block) > len(fragment) {
+				t.Fatalf("header block of %d bytes is larger than the fragment of %d bytes", len(block), len(fragment))
+			}
+			if complete && flags&flagEndHeaders == 0 {
+				t.Fatalf("header block continued in CONTINUATION frames reported as complete")
+			}
+
+			h, err := decodeHeaders(dec, block, complete)
+			if err != nil || !complete {
+				dec = newDecoder()
+			}
+			if service, method, ok := parsePath(h.path); ok && "/"+service+"/"+method != h.path {
+				t.Fatalf("path %q parsed as service %q and method %q", h.path, service, method)
+			}
+		}
+	})
+}
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package grpc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// ConnTuple returns the network tuple of the connection the frame was sent on, the client being the source
+func (h *EbpfHeaders) ConnTuple() KeyTuple {
+	return KeyTuple{
+		SrcIPHigh: h.Tup.Saddr_h,
+		SrcIPLow:  h.Tup.Saddr_l,
+		DstIPHigh: h.Tup.Daddr_h,
+		DstIPLow:  h.Tup.Daddr_l,
+		SrcPort:   h.Tup.Sport,
+		DstPort:   h.Tup.Dport,
+	}
+}
+
+// FrameFragment returns the beginning of the payload of the HEADERS frame, which is truncated to BufferSize bytes
+func (h *EbpfHeaders) FrameFragment() []byte {
+	return usmstats.Fragment(h.Fragment[:], int(h.Fragment_size))
+}
+
+// FromClient returns true if the frame was sent by the client
+func (h *EbpfHeaders) FromClient() bool {
+	return h.From_client != 0
+}
+
+// StaticTags returns the static tags of the TLS library through which the frame was read or written, if any
+func (h *EbpfHeaders) StaticTags() uint64 {
+	return h.Tags
+}
+
+// EndStream returns true if the frame is the last one of its stream sent by its side of the connection
+func (h *EbpfHeaders) EndStream() bool {
+	return h.Flags&flagEndStream != 0
+}
+
+// String returns a string representation of the frame
+func (h *EbpfHeaders) String() string {
+	var output strings.Builder
+	output.WriteString("ebpfGRPCHeaders{")
+	output.WriteString(fmt.Sprintf("Client: %s:%d, ", util.FromLowHigh(h.Tup.Saddr_l, h.Tup.Saddr_h), h.Tup.Sport))
+	output.WriteString(fmt.Sprintf("Server: %s:%d, ", util.FromLowHigh(h.Tup.Daddr_l, h.Tup.Daddr_h), h.Tup.Dport))
+	output.WriteString(fmt.Sprintf("FromClient: %t, ", h.FromClient()))
+	output.WriteString(fmt.Sprintf("Stream: %d, ", h.Stream_id))
+	output.WriteString(fmt.Sprintf("Flags: %#x, ", h.Flags))
+	output.WriteString(fmt.Sprintf("Length: %d, ", h.Frame_length))
+	output.WriteString(fmt.Sprintf("Fragment: %q", h.FrameFragment()))
+	output.WriteString("}")
+	return output.String()
+}
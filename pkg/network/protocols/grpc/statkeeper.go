@@ -0,0 +1,204 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// connection holds the HPACK dynamic tables of the header blocks sent by each side of an HTTP/2 connection
+type connection struct {
+	client   *hpack.Decoder
+	server   *hpack.Decoder
+	lastSeen uint64
+}
+
+type streamKey struct {
+	KeyTuple
+	streamID uint32
+}
+
+// call is a gRPC call whose trailers were not seen yet
+type call struct {
+	service    string
+	method     string
+	started    uint64
+	staticTags uint64
+}
+
+// StatKeeper aggregates the gRPC calls by connection, service and method. The calls are decoded from the header blocks
+// of the HEADERS frames, whose HPACK dynamic tables are rebuilt for each side of the connections. This is done on a
+// best effort basis: once a header block is truncated, its dynamic table is reset, and the header fields referring to
+// the entries added before can't be decoded anymore.
+type StatKeeper struct {
+	mux         sync.Mutex
+	stats       *usmstats.Keeper[Key, RequestStat]
+	connections map[KeyTuple]*connection
+	calls       map[streamKey]call
+	// maxTracked bounds the number of connections and of calls awaiting their trailers
+	maxTracked int
+	idleTTL    uint64
+	// lastSeen is the time of the latest header block, which the idle connections and calls are evicted from
+	lastSeen  uint64
+	telemetry *telemetry
+
+	malformedLogLimit *util.LogLimit
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(c *config.Config) *StatKeeper {
+	telemetry := newTelemetry()
+	return &StatKeeper{
+		stats:             usmstats.NewKeeper[Key, RequestStat](c.MaxGRPCStatsBuffered, telemetry.dropped, telemetry.Aggregations),
+		connections:       make(map[KeyTuple]*connection),
+		calls:             make(map[streamKey]call),
+		maxTracked:        int(c.MaxTrackedConnections),
+		idleTTL:           uint64(c.HTTPIdleConnectionTTL.Nanoseconds()),
+		telemetry:         telemetry,
+		malformedLogLimit: util.NewLogLimit(10, time.Minute*10),
+	}
+}
+
+// Process decodes a header block captured by the eBPF programs, and adds the gRPC call it completes to the stats
+func (s *StatKeeper) Process(h *EbpfHeaders) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	tuple := h.ConnTuple()
+	conn, ok := s.connections[tuple]
+	if !ok {
+		if len(s.connections) >= s.maxTracked {
+			return
+		}
+		conn = &connection{client: newDecoder(), server: newDecoder()}
+		s.connections[tuple] = conn
+	}
+	conn.lastSeen = h.Timestamp
+	if h.Timestamp > s.lastSeen {
+		s.lastSeen = h.Timestamp
+	}
+
+	dec := conn.server
+	if h.FromClient() {
+		dec = conn.client
+	}
+	fields, complete, err := s.decode(dec, h)
+	if err != nil || !complete {
+		// the dynamic table misses the entries added by the end of the header block
+		if h.FromClient() {
+			conn.client = newDecoder()
+		} else {
+			conn.server = newDecoder()
+		}
+	}
+	if err != nil {
+		s.telemetry.malformed.Add(1)
+		if s.malformedLogLimit.ShouldLog() {
+			log.Debugf("grpc headers malformed: %s: %s", h.String(), err)
+		}
+		return
+	}
+
+	key := streamKey{KeyTuple: tuple, streamID: h.Stream_id}
+	if h.FromClient() {
+		s.startCall(key, fields, h.Timestamp, h.StaticTags())
+		return
+	}
+	if fields.hasStatus {
+		s.endCall(key, fields.status, h.Timestamp, h.StaticTags())
+	} else if h.EndStream() {
+		// the stream is not a gRPC call
+		delete(s.calls, key)
+	}
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (s *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.evictIdle()
+	s.telemetry.Log()
+	return s.stats.GetAndReset()
+}
+
+// decode returns the header fields of the header block of the frame, and whether the header block is complete
+func (s *StatKeeper) decode(dec *hpack.Decoder, h *EbpfHeaders) (headers, bool, error) {
+	block, complete, ok := headerBlock(h.FrameFragment(), h.Frame_length, h.Flags)
+	if !ok {
+		return headers{}, false, errInvalidFrame
+	}
+	if !complete {
+		s.telemetry.truncated.Add(1)
+	}
+	fields, err := decodeHeaders(dec, block, complete)
+	return fields, complete, err
+}
+
+func (s *StatKeeper) startCall(key streamKey, fields headers, started, staticTags uint64) {
+	if fields.path == "" {
+		// the trailers of the requests streaming their messages don't start a call
+		return
+	}
+	service, method, ok := parsePath(fields.path)
+	if !ok {
+		return
+	}
+	if _, ok := s.calls[key]; !ok && len(s.calls) >= s.maxTracked {
+		return
+	}
+	s.calls[key] = call{service: service, method: method, started: started, staticTags: staticTags}
+}
+
+func (s *StatKeeper) endCall(key streamKey, statusCode uint8, ended, staticTags uint64) {
+	c, ok := s.calls[key]
+	if !ok {
+		return
+	}
+	delete(s.calls, key)
+	s.telemetry.count(statusCode)
+
+	statsKey := Key{
+		KeyTuple: key.KeyTuple,
+		Service:  c.service,
+		Method:   c.method,
+	}
+	stats := s.stats.Get(statsKey)
+	if stats == nil {
+		return
+	}
+	stats.AddRequest(usmstats.Latency(c.started, ended), statusCode, c.staticTags|staticTags)
+}
+
+// evictIdle evicts the connections and the calls which didn't see any header block for longer than the idle TTL of
+// the connections, such as the ones closed without their trailers being seen
+func (s *StatKeeper) evictIdle() {
+	if s.lastSeen <= s.idleTTL {
+		return
+	}
+	deadline := s.lastSeen - s.idleTTL
+	for tuple, conn := range s.connections {
+		if conn.lastSeen < deadline {
+			delete(s.connections, tuple)
+		}
+	}
+	for key, c := range s.calls {
+		if c.started < deadline {
+			delete(s.calls, key)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 50051
+
+func generateHeaders(fromClient bool, streamID uint32, block []byte, flags uint8, timestamp uint64) *EbpfHeaders {
+	var h EbpfHeaders
+	h.Tup.Saddr_l, h.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	h.Tup.Daddr_l, h.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	h.Tup.Sport = protocolsUtils.ClientPort
+	h.Tup.Dport = serverPort
+	h.Timestamp = timestamp
+	h.Stream_id = streamID
+	h.Frame_length = uint32(len(block))
+	h.Flags = flags | flagEndHeaders
+	if fromClient {
+		h.From_client = 1
+	}
+	h.Fragment_size = uint16(copy(h.Fragment[:], block))
+	return &h
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxGRPCStatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+func TestStatKeeperProcess(t *testing.T) {
+	sk := newTestStatKeeper()
+	client, server := newEncoder(), newEncoder()
+
+	// two calls multiplexed on the same connection, the second one reusing the dynamic tables
+	sk.Process(generateHeaders(true, 1, newRequestHeaders(client, t, "/helloworld.Greeter/SayHello"), 0, 1000))
+	sk.Process(generateHeaders(true, 3, newRequestHeaders(client, t, "/helloworld.Greeter/SayHello"), 0, 1500))
+	sk.Process(generateHeaders(false, 1, server.encode(t, ":status", "200", "content-type", "application/grpc"), 0, 2000))
+	sk.Process(generateHeaders(false, 1, server.encode(t, "grpc-status", "0"), flagEndStream, 3000))
+	sk.Process(generateHeaders(false, 3, server.encode(t, ":status", "200", "content-type", "application/grpc", "grpc-status", "14"), flagEndStream, 4500))
+
+	// a stream which is not a gRPC call
+	sk.Process(generateHeaders(true, 5, client.encode(t, ":method", "GET", ":path", "/index.html"), flagEndStream, 5000))
+	sk.Process(generateHeaders(false, 5, server.encode(t, ":status", "200"), flagEndStream, 6000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+
+	key := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "helloworld.Greeter", "SayHello")
+	require.Contains(t, stats, key)
+	assert.Equal(t, 2, stats[key].Count)
+	assert.Equal(t, map[uint8]int{14: 1}, stats[key].Errors)
+	require.NotNil(t, stats[key].Latencies)
+	assert.Empty(t, sk.calls)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestStatKeeperTruncatedHeaders(t *testing.T) {
+	sk := newTestStatKeeper()
+	client, server := newEncoder(), newEncoder()
+
+	// the path precedes the end of the truncated header block, and the call is still accounted for
+	block := newRequestHeaders(client, t, "/helloworld.Greeter/SayHello")
+	h := generateHeaders(true, 1, block[:len(block)-4], 0, 1000)
+	h.Frame_length = uint32(len(block))
+	sk.Process(h)
+	sk.Process(generateHeaders(false, 1, server.encode(t, ":status", "200", "grpc-status", "0"), flagEndStream, 2000))
+
+	// the path added to the dynamic table by the truncated header block can't be decoded anymore
+	sk.Process(generateHeaders(true, 3, newRequestHeaders(client, t, "/helloworld.Greeter/SayHello"), 0, 3000))
+	sk.Process(generateHeaders(false, 3, server.encode(t, ":status", "200", "grpc-status", "0"), flagEndStream, 4000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	key := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "helloworld.Greeter", "SayHello")
+	require.Contains(t, stats, key)
+	assert.Equal(t, 1, stats[key].Count)
+}
+
+func TestStatKeeperEvictsIdleCalls(t *testing.T) {
+	sk := newTestStatKeeper()
+	client, server := newEncoder(), newEncoder()
+
+	sk.Process(generateHeaders(true, 1, newRequestHeaders(client, t, "/helloworld.Greeter/SayHello"), 0, 1))
+	// the connection of the call is closed before its trailers are seen
+	other := generateHeaders(false, 1, server.encode(t, ":status", "200"), 0, sk.idleTTL+2)
+	other.Tup.Sport = protocolsUtils.ClientPort + 1
+	sk.Process(other)
+	require.Len(t, sk.calls, 1)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+	assert.Empty(t, sk.calls)
+	assert.Len(t, sk.connections, 1)
+}
+
+func TestStatKeeperStaticTags(t *testing.T) {
+	sk := newTestStatKeeper()
+	client, server := newEncoder(), newEncoder()
+
+	request := generateHeaders(true, 1, newRequestHeaders(client, t, "/helloworld.Greeter/SayHello"), 0, 1000)
+	request.Tags = tlsTag
+	sk.Process(request)
+	sk.Process(generateHeaders(false, 1, server.encode(t, ":status", "200", "grpc-status", "0"), flagEndStream, 2000))
+
+	stats := sk.GetAndResetAllStats()
+	key := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "helloworld.Greeter", "SayHello")
+	require.Contains(t, stats, key)
+	assert.Equal(t, uint64(tlsTag), stats[key].StaticTags)
+}
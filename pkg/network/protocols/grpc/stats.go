@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package grpc aggregates the gRPC calls decoded from the HTTP/2 frames captured by the eBPF programs of the Universal
+// Service Monitoring.
+package grpc
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// KeyTuple represents the network tuple for a group of gRPC calls, the client being the source
+type KeyTuple = usmstats.KeyTuple
+
+// Key is an identifier for a group of gRPC calls
+type Key struct {
+	// this field order is intentional to help the GC pointer tracking
+	Service string
+	Method  string
+	KeyTuple
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, service, method string) Key {
+	return Key{
+		KeyTuple: usmstats.NewKeyTuple(saddr, daddr, sport, dport),
+		Service:  service,
+		Method:   method,
+	}
+}
+
+// RequestStat stores stats for the gRPC calls of a particular method
+type RequestStat struct {
+	usmstats.LatencyStats
+	// Errors is the number of calls which failed, by gRPC status code
+	Errors map[uint8]int
+
+	// StaticTags holds the tags of the TLS libraries through which the calls were made
+	StaticTags uint64
+}
+
+// AddRequest adds a gRPC call to the stats, along with its status code, which is 0 (OK) if it succeeded
+func (r *RequestStat) AddRequest(latency float64, statusCode uint8, staticTags uint64) {
+	r.StaticTags |= staticTags
+	if statusCode != 0 {
+		r.addErrors(statusCode, 1)
+	}
+	r.AddLatency(latency)
+}
+
+func (r *RequestStat) addErrors(statusCode uint8, count int) {
+	if r.Errors == nil {
+		r.Errors = make(map[uint8]int)
+	}
+	r.Errors[statusCode] += count
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	r.StaticTags |= newStats.StaticTags
+	for statusCode, count := range newStats.Errors {
+		r.addErrors(statusCode, count)
+	}
+	r.CombineLatencies(&newStats.LatencyStats)
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := new(RequestStat)
+	clone.CombineWith(r)
+	return clone
+}
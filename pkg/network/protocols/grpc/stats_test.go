@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package grpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tlsTag is the static tag of the calls made through OpenSSL
+const tlsTag = 0x2
+
+func TestAddRequest(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, 0, 0)
+	assert.Equal(t, 1, stats.Count)
+	assert.Empty(t, stats.Errors)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+	assert.Nil(t, stats.Latencies)
+
+	stats.AddRequest(20, 14, 0)
+	stats.AddRequest(30, 14, 0)
+	assert.Equal(t, 3, stats.Count)
+	assert.Equal(t, map[uint8]int{14: 2}, stats.Errors)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 3.0, stats.Latencies.GetCount())
+}
+
+func TestCombineWith(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, 14, 0)
+
+	single := new(RequestStat)
+	single.AddRequest(20, 5, tlsTag)
+	stats.CombineWith(single)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, map[uint8]int{14: 1, 5: 1}, stats.Errors)
+	assert.Equal(t, uint64(tlsTag), stats.StaticTags)
+	require.NotNil(t, stats.Latencies)
+
+	multiple := new(RequestStat)
+	multiple.AddRequest(30, 0, 0)
+	multiple.AddRequest(40, 14, 0)
+	clone := multiple.Clone()
+	stats.CombineWith(multiple)
+	assert.Equal(t, 4, stats.Count)
+	assert.Equal(t, map[uint8]int{14: 2, 5: 1}, stats.Errors)
+	assert.Equal(t, 4.0, stats.Latencies.GetCount())
+
+	// the combined stats are left untouched
+	assert.Equal(t, 2, multiple.Count)
+	assert.Equal(t, map[uint8]int{14: 1}, multiple.Errors)
+	assert.Equal(t, 2.0, multiple.Latencies.GetCount())
+	assert.Equal(t, multiple.Count, clone.Count)
+	assert.Equal(t, multiple.Errors, clone.Errors)
+	assert.Equal(t, 2.0, clone.Latencies.GetCount())
+
+	// the errors of the clone don't share the memory of the original ones
+	clone.AddRequest(50, 14, 0)
+	assert.Equal(t, map[uint8]int{14: 1}, multiple.Errors)
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package grpc
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+type telemetry struct {
+	*usmstats.Telemetry
+
+	totalHits *libtelemetry.Metric
+	errors    *libtelemetry.Metric // this happens when the status of the call is not OK
+	dropped   *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	malformed *libtelemetry.Metric // this happens when a header block can't be decoded
+	truncated *libtelemetry.Metric // this happens when a header block doesn't fit in the eBPF buffer
+}
+
+func newTelemetry() *telemetry {
+	t := usmstats.NewTelemetry("grpc")
+	return &telemetry{
+		Telemetry: t,
+		// these metrics are also exported as statsd metrics, and logged in the summary
+		totalHits: t.NewSummaryMetric("total_hits", "calls_processed", libtelemetry.OptStatsd),
+		errors:    t.NewSummaryMetric("errors", "calls_failed", libtelemetry.OptStatsd),
+		dropped:   t.NewSummaryMetric("dropped", "calls_dropped", libtelemetry.OptStatsd),
+		malformed: t.NewSummaryMetric("malformed", "headers_malformed", libtelemetry.OptStatsd),
+		truncated: t.NewSummaryMetric("truncated", "headers_truncated", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) count(statusCode uint8) {
+	if statusCode != 0 {
+		t.errors.Add(1)
+	}
+	t.totalHits.Add(1)
+}
@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ignore
+// +build ignore
+
+package grpc
+
+/*
+#include "../../ebpf/c/tracer.h"
+#include "../../ebpf/c/protocols/grpc/defs.h"
+#include "../../ebpf/c/protocols/grpc/types.h"
+*/
+import "C"
+
+type ConnTuple C.conn_tuple_t
+
+type EbpfHeaders C.grpc_headers_t
+
+const (
+	BufferSize = C.GRPC_BUFFER_SIZE
+)
@@ -0,0 +1,127 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/go/bininspect"
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+)
+
+const (
+	buildIDSection = ".note.gnu.build-id"
+	// buildIDNoteType is the type of the GNU notes holding a build ID (NT_GNU_BUILD_ID)
+	buildIDNoteType = 3
+	// maxCachedBuilds bounds the number of builds whose symbols are cached
+	maxCachedBuilds = 64
+)
+
+var errNoBuildID = errors.New("no build id")
+
+// getBuildID returns the GNU build ID of the ELF file, as a hexadecimal string
+func getBuildID(elfFile *elf.File) (string, error) {
+	section := elfFile.Section(buildIDSection)
+	if section == nil {
+		return "", errNoBuildID
+	}
+	data, err := section.Data()
+	if err != nil {
+		return "", err
+	}
+	return parseBuildIDNote(data, elfFile.ByteOrder)
+}
+
+// parseBuildIDNote returns the build ID held by the note, whose layout is described in
+// https://refspecs.linuxfoundation.org/LSB_1.2.0/gLSB/noteobject.html
+func parseBuildIDNote(data []byte, order binary.ByteOrder) (string, error) {
+	const headerSize = 12
+	if len(data) < headerSize {
+		return "", fmt.Errorf("build id note too short: %d bytes", len(data))
+	}
+	nameSize := order.Uint32(data[0:4])
+	descSize := order.Uint32(data[4:8])
+	noteType := order.Uint32(data[8:12])
+	if noteType != buildIDNoteType {
+		return "", fmt.Errorf("unexpected build id note type %d", noteType)
+	}
+
+	// the name and the description are aligned on 4 bytes
+	descStart := uint64(headerSize) + (uint64(nameSize)+3)&^3
+	descEnd := descStart + uint64(descSize)
+	if descSize == 0 || descEnd > uint64(len(data)) {
+		return "", fmt.Errorf("invalid build id note description of %d bytes", descSize)
+	}
+	if name := string(data[headerSize : headerSize+nameSize]); strings.TrimRight(name, "\x00") != "GNU" {
+		return "", fmt.Errorf("unexpected build id note name %q", name)
+	}
+	return hex.EncodeToString(data[descStart:descEnd]), nil
+}
+
+type buildSymbols struct {
+	symbols map[string]elf.Symbol
+	err     error
+}
+
+// buildIDSymbols caches the symbols resolved in the binaries by their GNU build ID, as the same build of a large
+// statically linked binary, such as node, is often found at many paths, such as in the images of several containers
+type buildIDSymbols struct {
+	mux     sync.Mutex
+	byBuild map[string]map[string]buildSymbols
+}
+
+func newBuildIDSymbols() *buildIDSymbols {
+	return &buildIDSymbols{
+		byBuild: make(map[string]map[string]buildSymbols),
+	}
+}
+
+// resolve returns the symbols of the ELF file, in the same way as bininspect.GetAllSymbolsByName
+func (c *buildIDSymbols) resolve(elfFile *elf.File, symbolSet common.StringSet) (map[string]elf.Symbol, error) {
+	buildID, err := getBuildID(elfFile)
+	if err != nil {
+		return bininspect.GetAllSymbolsByName(elfFile, symbolSet)
+	}
+
+	names := make([]string, 0, len(symbolSet))
+	for name := range symbolSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	setKey := strings.Join(names, ",")
+
+	c.mux.Lock()
+	cached, ok := c.byBuild[buildID][setKey]
+	c.mux.Unlock()
+	if ok {
+		return cached.symbols, cached.err
+	}
+
+	symbols, err := bininspect.GetAllSymbolsByName(elfFile, symbolSet)
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	sets, ok := c.byBuild[buildID]
+	if !ok {
+		if len(c.byBuild) >= maxCachedBuilds {
+			return symbols, err
+		}
+		sets = make(map[string]buildSymbols)
+		c.byBuild[buildID] = sets
+	}
+	sets[setKey] = buildSymbols{symbols: symbols, err: err}
+	return symbols, err
+}
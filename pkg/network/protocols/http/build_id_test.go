@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildIDNote(name string, noteType uint32, desc []byte) []byte {
+	note := make([]byte, 12)
+	binary.LittleEndian.PutUint32(note[0:4], uint32(len(name)))
+	binary.LittleEndian.PutUint32(note[4:8], uint32(len(desc)))
+	binary.LittleEndian.PutUint32(note[8:12], noteType)
+	note = append(note, name...)
+	for len(note)%4 != 0 {
+		note = append(note, 0)
+	}
+	return append(note, desc...)
+}
+
+func TestParseBuildIDNote(t *testing.T) {
+	desc := []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02, 0x03, 0x04}
+
+	buildID, err := parseBuildIDNote(buildIDNote("GNU\x00", buildIDNoteType, desc), binary.LittleEndian)
+	require.NoError(t, err)
+	assert.Equal(t, "deadbeef01020304", buildID)
+
+	_, err = parseBuildIDNote(buildIDNote("GNU\x00", 1, desc), binary.LittleEndian)
+	assert.Error(t, err, "the note is not a build id")
+
+	_, err = parseBuildIDNote(buildIDNote("Go\x00\x00", buildIDNoteType, desc), binary.LittleEndian)
+	assert.Error(t, err, "the note is not a GNU one")
+
+	note := buildIDNote("GNU\x00", buildIDNoteType, desc)
+	_, err = parseBuildIDNote(note[:len(note)-1], binary.LittleEndian)
+	assert.Error(t, err, "the description is truncated")
+
+	_, err = parseBuildIDNote(note[:8], binary.LittleEndian)
+	assert.Error(t, err, "the header is truncated")
+}
@@ -9,6 +9,7 @@
 package http
 
 import (
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode/runtime"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/process/statsd"
@@ -27,6 +28,9 @@ func getCFlags(config *config.Config) []string {
 	if config.CollectIPv6Conns {
 		cflags = append(cflags, "-DFEATURE_IPV6_ENABLED")
 	}
+	if ddebpf.UseRingBuffers(&config.Config) {
+		cflags = append(cflags, "-DFEATURE_RING_BUFFERS_ENABLED")
+	}
 	if config.BPFDebug {
 		cflags = append(cflags, "-DDEBUG=1")
 	}
@@ -0,0 +1,212 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// pressureLevel is the level of degradation of the monitoring, under CPU pressure
+type pressureLevel int32
+
+const (
+	// pressureNone is the regular operation of the monitoring
+	pressureNone pressureLevel = iota
+	// pressureSampling only processes one transaction out of cpuPressureSamplingRate, which is accounted for as
+	// cpuPressureSamplingRate transactions
+	pressureSampling
+	// pressureNoBodyParsing additionally aggregates the transactions without parsing their request past its request
+	// line, such as to capture its headers
+	pressureNoBodyParsing
+	// pressureNoOptionalProtocols additionally stops the monitoring of the optional protocols, such as the TLS
+	// libraries. They remain disabled until the module is restarted.
+	pressureNoOptionalProtocols
+)
+
+const (
+	// cpuPressureSamplingRate is the rate at which the transactions are sampled under CPU pressure
+	cpuPressureSamplingRate = 4
+	// cpuPressureRecoveryRatio is the ratio of the CPU limit under which the CPU usage has to go back before the
+	// degradation is reduced, so the controller doesn't flap around the limit
+	cpuPressureRecoveryRatio = 0.5
+)
+
+func (l pressureLevel) String() string {
+	switch l {
+	case pressureNone:
+		return "none"
+	case pressureSampling:
+		return "sampling"
+	case pressureNoBodyParsing:
+		return "no_body_parsing"
+	case pressureNoOptionalProtocols:
+		return "no_optional_protocols"
+	default:
+		return "unknown"
+	}
+}
+
+// cpuPressureController monitors the CPU usage of the system-probe, and progressively reduces the work of the
+// monitoring when it goes over the configured limit, instead of competing with the workloads of saturated hosts
+type cpuPressureController struct {
+	maxCPUPercent float64
+	interval      time.Duration
+	numCPU        int
+
+	level      *atomic.Int32
+	cpuPercent *atomic.Float64
+	sampled    *atomic.Uint64
+
+	// cpuTime returns the CPU time consumed by the process
+	cpuTime func() (time.Duration, error)
+	// setBodyParsing enables or disables the parsing of the requests of the transactions past their request line
+	setBodyParsing func(enabled bool)
+	// stopOptionalProtocols stops the monitoring of the optional protocols
+	stopOptionalProtocols func()
+
+	lastCPUTime time.Duration
+	lastCheck   time.Time
+
+	levelMetric *libtelemetry.Metric
+	escalations *libtelemetry.Metric
+	sampledOut  *libtelemetry.Metric
+
+	done chan struct{}
+}
+
+func newCPUPressureController(c *config.Config, setBodyParsing func(bool), stopOptionalProtocols func()) *cpuPressureController {
+	if !c.EnableUSMCPUPressureControl {
+		return nil
+	}
+
+	metricGroup := libtelemetry.NewMetricGroup("usm.cpu_pressure", libtelemetry.OptExpvar)
+	return &cpuPressureController{
+		maxCPUPercent:         c.USMMaxCPUPercent,
+		interval:              c.USMCPUPressureCheckInterval,
+		numCPU:                runtime.NumCPU(),
+		level:                 atomic.NewInt32(int32(pressureNone)),
+		cpuPercent:            atomic.NewFloat64(0),
+		sampled:               atomic.NewUint64(0),
+		cpuTime:               processCPUTime,
+		setBodyParsing:        setBodyParsing,
+		stopOptionalProtocols: stopOptionalProtocols,
+		levelMetric:           metricGroup.NewMetric("level", libtelemetry.OptGauge, libtelemetry.OptStatsd),
+		escalations:           metricGroup.NewMetric("escalations", libtelemetry.OptMonotonic, libtelemetry.OptStatsd),
+		sampledOut:            metricGroup.NewMetric("sampled_out", libtelemetry.OptMonotonic, libtelemetry.OptStatsd),
+		done:                  make(chan struct{}),
+	}
+}
+
+// Start starts monitoring the CPU usage
+func (p *cpuPressureController) Start() {
+	if p == nil {
+		return
+	}
+
+	p.lastCPUTime, _ = p.cpuTime()
+	p.lastCheck = time.Now()
+
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				p.check(now)
+			case <-p.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops monitoring the CPU usage
+func (p *cpuPressureController) Stop() {
+	if p == nil {
+		return
+	}
+	close(p.done)
+}
+
+// Sample returns the number of transactions a transaction stands for at the current level of degradation, so that
+// the counts of the sampled transactions remain representative of all of them, or 0 if it is sampled out
+func (p *cpuPressureController) Sample() int {
+	if p == nil || pressureLevel(p.level.Load()) < pressureSampling {
+		return 1
+	}
+
+	if p.sampled.Inc()%cpuPressureSamplingRate == 0 {
+		return cpuPressureSamplingRate
+	}
+	p.sampledOut.Add(1)
+	return 0
+}
+
+// GetStats returns the current level of degradation and CPU usage
+func (p *cpuPressureController) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"level":       pressureLevel(p.level.Load()).String(),
+		"cpu_percent": p.cpuPercent.Load(),
+	}
+}
+
+func (p *cpuPressureController) check(now time.Time) {
+	cpuTime, err := p.cpuTime()
+	if err != nil {
+		log.Debugf("could not get the cpu usage of the process: %s", err)
+		return
+	}
+
+	elapsed := now.Sub(p.lastCheck)
+	if elapsed <= 0 {
+		return
+	}
+	cpuPercent := 100 * float64(cpuTime-p.lastCPUTime) / float64(elapsed) / float64(p.numCPU)
+	p.lastCPUTime = cpuTime
+	p.lastCheck = now
+	p.cpuPercent.Store(cpuPercent)
+
+	current := pressureLevel(p.level.Load())
+	switch {
+	case cpuPercent > p.maxCPUPercent && current < pressureNoOptionalProtocols:
+		p.escalations.Add(1)
+		p.setLevel(current+1, cpuPercent)
+	case cpuPercent < p.maxCPUPercent*cpuPressureRecoveryRatio && current > pressureNone:
+		p.setLevel(current-1, cpuPercent)
+	}
+}
+
+func (p *cpuPressureController) setLevel(level pressureLevel, cpuPercent float64) {
+	previous := pressureLevel(p.level.Swap(int32(level)))
+	p.levelMetric.Set(int64(level))
+	log.Warnf("usm cpu usage is %.2f%% (limit %.2f%%), changing the degradation of the monitoring from %s to %s", cpuPercent, p.maxCPUPercent, previous, level)
+
+	p.setBodyParsing(level < pressureNoBodyParsing)
+	if level == pressureNoOptionalProtocols {
+		log.Warn("stopping the monitoring of the optional protocols until the module is restarted")
+		p.stopOptionalProtocols()
+	}
+}
+
+func processCPUTime() (time.Duration, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, err
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), nil
+}
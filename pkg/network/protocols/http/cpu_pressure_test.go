@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+func TestCPUPressureController(t *testing.T) {
+	cfg := config.New()
+	cfg.EnableUSMCPUPressureControl = true
+	cfg.USMMaxCPUPercent = 10
+	cfg.USMCPUPressureCheckInterval = time.Second
+
+	bodyParsing := true
+	protocolsStopped := false
+	p := newCPUPressureController(cfg, func(enabled bool) { bodyParsing = enabled }, func() { protocolsStopped = true })
+	require.NotNil(t, p)
+
+	// a single CPU, so the CPU time consumed each second is the usage of the host
+	p.numCPU = 1
+	var cpuTime time.Duration
+	p.cpuTime = func() (time.Duration, error) { return cpuTime, nil }
+	now := time.Now()
+	p.lastCheck = now
+
+	tick := func(used time.Duration) {
+		cpuTime += used
+		now = now.Add(time.Second)
+		p.check(now)
+	}
+
+	tick(50 * time.Millisecond)
+	assert.Equal(t, pressureNone, pressureLevel(p.level.Load()))
+	assert.Equal(t, 1, p.Sample())
+
+	tick(200 * time.Millisecond)
+	assert.Equal(t, pressureSampling, pressureLevel(p.level.Load()))
+	assert.True(t, bodyParsing)
+	sampled, weight := 0, 0
+	for i := 0; i < 4*cpuPressureSamplingRate; i++ {
+		if w := p.Sample(); w > 0 {
+			sampled++
+			weight += w
+		}
+	}
+	assert.Equal(t, 4, sampled)
+	// the sampled transactions account for all of them
+	assert.Equal(t, 4*cpuPressureSamplingRate, weight)
+
+	tick(200 * time.Millisecond)
+	assert.Equal(t, pressureNoBodyParsing, pressureLevel(p.level.Load()))
+	assert.False(t, bodyParsing)
+	assert.False(t, protocolsStopped)
+
+	tick(200 * time.Millisecond)
+	assert.Equal(t, pressureNoOptionalProtocols, pressureLevel(p.level.Load()))
+	assert.True(t, protocolsStopped)
+
+	// the level doesn't change until the usage goes below the recovery threshold
+	tick(80 * time.Millisecond)
+	assert.Equal(t, pressureNoOptionalProtocols, pressureLevel(p.level.Load()))
+
+	tick(10 * time.Millisecond)
+	assert.Equal(t, pressureNoBodyParsing, pressureLevel(p.level.Load()))
+	tick(10 * time.Millisecond)
+	assert.Equal(t, pressureSampling, pressureLevel(p.level.Load()))
+	assert.True(t, bodyParsing)
+	tick(10 * time.Millisecond)
+	assert.Equal(t, pressureNone, pressureLevel(p.level.Load()))
+	assert.Equal(t, 1, p.Sample())
+	assert.Equal(t, "none", p.GetStats()["level"])
+}
+
+func TestCPUPressureControllerDisabled(t *testing.T) {
+	cfg := config.New()
+	cfg.EnableUSMCPUPressureControl = false
+
+	p := newCPUPressureController(cfg, func(bool) {}, func() {})
+	assert.Nil(t, p)
+	assert.Equal(t, 1, p.Sample())
+}
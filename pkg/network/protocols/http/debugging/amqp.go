@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// MessageSummary represents a (debug-friendly) aggregated view of the AMQP messages
+// matching a (client, server, exchange, routing key, method) tuple
+type MessageSummary struct {
+	Client     Address
+	Server     Address
+	DNS        string
+	Exchange   string
+	RoutingKey string
+	Method     string
+
+	Count int
+}
+
+// AMQP returns a debug-friendly representation of map[amqp.Key]amqp.RequestStat
+func AMQP(stats map[amqp.Key]*amqp.RequestStat, dns map[util.Address][]dns.Hostname) []MessageSummary {
+	all := make([]MessageSummary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, MessageSummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS:        getDNS(dns, serverAddr),
+			Exchange:   k.Exchange,
+			RoutingKey: k.RoutingKey,
+			Method:     amqpMethodName(k.Method),
+
+			Count: v.Count,
+		})
+	}
+
+	return all
+}
+
+func amqpMethodName(method uint16) string {
+	switch method {
+	case amqp.PublishMethod:
+		return "basic.publish"
+	case amqp.DeliverMethod:
+		return "basic.deliver"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/cassandra"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// KeyspaceSummary represents a (debug-friendly) aggregated view of the Cassandra requests
+// matching a (client, server, keyspace) tuple
+type KeyspaceSummary struct {
+	Client   Address
+	Server   Address
+	DNS      string
+	Keyspace string
+
+	Count int
+	// Errors is the number of failed requests by CQL error code
+	Errors map[uint32]int
+
+	FirstLatencySample float64
+	LatencyP50         float64
+	LatencyP95         float64
+	LatencyP99         float64
+}
+
+// Cassandra returns a debug-friendly representation of map[cassandra.Key]cassandra.RequestStat
+func Cassandra(stats map[cassandra.Key]*cassandra.RequestStat, dns map[util.Address][]dns.Hostname) []KeyspaceSummary {
+	all := make([]KeyspaceSummary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, KeyspaceSummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS:      getDNS(dns, serverAddr),
+			Keyspace: k.Keyspace,
+
+			Count:  v.Count,
+			Errors: v.Errors,
+
+			FirstLatencySample: v.FirstLatencySample,
+			LatencyP50:         getSketchQuantile(v.Latencies, 0.5),
+			LatencyP95:         getSketchQuantile(v.Latencies, 0.95),
+			LatencyP99:         getSketchQuantile(v.Latencies, 0.99),
+		})
+	}
+
+	return all
+}
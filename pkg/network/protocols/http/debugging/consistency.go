@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// AccessLogEntry represents a single HTTP transaction as seen by a server.
+// Access logs are consumed as JSON lines, one entry per line.
+type AccessLogEntry struct {
+	Client Address
+	Server Address
+	Method string
+	Path   string
+	Status int
+}
+
+// Discrepancy represents a group of transactions for which the number of requests reported by USM differs from the
+// number of requests logged by the server
+type Discrepancy struct {
+	Client   Address
+	Server   Address
+	Method   string
+	Path     string
+	Status   int
+	Expected int
+	Reported int
+}
+
+// ConsistencyReport is the result of comparing USM-reported HTTP stats against a server access log
+type ConsistencyReport struct {
+	// Matched is the number of transactions found both in the access log and in the USM stats
+	Matched int
+	// Missing holds the transactions present in the access log that were not reported by USM
+	Missing []Discrepancy
+	// Extra holds the transactions reported by USM that are not present in the access log
+	Extra []Discrepancy
+}
+
+// Consistent returns true if USM reported exactly the transactions present in the access log
+func (r *ConsistencyReport) Consistent() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+// String returns a human-readable version of the report, listing every discrepancy with its tuple
+func (r *ConsistencyReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "matched: %d, missing: %d, extra: %d\n", r.Matched, len(r.Missing), len(r.Extra))
+	for _, d := range r.Missing {
+		fmt.Fprintf(&b, "missing %s\n", d)
+	}
+	for _, d := range r.Extra {
+		fmt.Fprintf(&b, "extra %s\n", d)
+	}
+	return b.String()
+}
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("%s:%d -> %s:%d %s %s %dXX (expected: %d, reported: %d)",
+		d.Client.IP, d.Client.Port, d.Server.IP, d.Server.Port, d.Method, d.Path, d.Status/100, d.Expected, d.Reported)
+}
+
+type transactionKey struct {
+	client, server Address
+	method, path   string
+	statusClass    int
+}
+
+// ParseAccessLog reads an access log made of JSON-encoded AccessLogEntry objects
+func ParseAccessLog(r io.Reader) ([]AccessLogEntry, error) {
+	var entries []AccessLogEntry
+	decoder := json.NewDecoder(r)
+	for {
+		var entry AccessLogEntry
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid access log entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// CheckConsistency compares the requests reported by USM against the requests logged by a server.
+// Transactions are matched by client and server addresses, method, path (without the query string) and
+// status class, which is the granularity of the USM stats.
+func CheckConsistency(summaries []RequestSummary, accessLog []AccessLogEntry) *ConsistencyReport {
+	expected := make(map[transactionKey]int)
+	for _, entry := range accessLog {
+		path := entry.Path
+		if i := strings.IndexByte(path, '?'); i != -1 {
+			path = path[:i]
+		}
+		key := transactionKey{
+			client:      entry.Client,
+			server:      entry.Server,
+			method:      entry.Method,
+			path:        path,
+			statusClass: (entry.Status / 100) * 100,
+		}
+		expected[key]++
+	}
+
+	reported := make(map[transactionKey]int)
+	for _, summary := range summaries {
+		for status, stats := range summary.ByStatus {
+			key := transactionKey{
+				client:      summary.Client,
+				server:      summary.Server,
+				method:      summary.Method,
+				path:        summary.Path,
+				statusClass: status,
+			}
+			reported[key] += stats.Count
+		}
+	}
+
+	report := new(ConsistencyReport)
+	for key, expectedCount := range expected {
+		reportedCount := reported[key]
+		switch {
+		case reportedCount < expectedCount:
+			report.Matched += reportedCount
+			report.Missing = append(report.Missing, newDiscrepancy(key, expectedCount, reportedCount))
+		case reportedCount > expectedCount:
+			report.Matched += expectedCount
+			report.Extra = append(report.Extra, newDiscrepancy(key, expectedCount, reportedCount))
+		default:
+			report.Matched += expectedCount
+		}
+	}
+	for key, reportedCount := range reported {
+		if _, ok := expected[key]; !ok {
+			report.Extra = append(report.Extra, newDiscrepancy(key, 0, reportedCount))
+		}
+	}
+
+	sortDiscrepancies(report.Missing)
+	sortDiscrepancies(report.Extra)
+	return report
+}
+
+func newDiscrepancy(key transactionKey, expected, reported int) Discrepancy {
+	return Discrepancy{
+		Client:   key.client,
+		Server:   key.server,
+		Method:   key.method,
+		Path:     key.path,
+		Status:   key.statusClass,
+		Expected: expected,
+		Reported: reported,
+	}
+}
+
+func sortDiscrepancies(discrepancies []Discrepancy) {
+	sort.Slice(discrepancies, func(i, j int) bool {
+		return discrepancies[i].String() < discrepancies[j].String()
+	})
+}
@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	nethttp "net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http/testutil"
+)
+
+var (
+	client = Address{IP: "127.0.0.1", Port: 40000}
+	server = Address{IP: "127.0.0.1", Port: 8080}
+)
+
+func TestCheckConsistency(t *testing.T) {
+	accessLog := []AccessLogEntry{
+		{Client: client, Server: server, Method: "GET", Path: "/200/foo?bar=baz", Status: 200},
+		{Client: client, Server: server, Method: "GET", Path: "/200/foo", Status: 201},
+		{Client: client, Server: server, Method: "POST", Path: "/404/missing", Status: 404},
+	}
+	summaries := []RequestSummary{
+		{
+			Client:   client,
+			Server:   server,
+			Method:   "GET",
+			Path:     "/200/foo",
+			ByStatus: map[int]Stats{200: {Count: 2}},
+		},
+		{
+			Client:   client,
+			Server:   server,
+			Method:   "PUT",
+			Path:     "/500/extra",
+			ByStatus: map[int]Stats{500: {Count: 1}},
+		},
+	}
+
+	report := CheckConsistency(summaries, accessLog)
+	assert.False(t, report.Consistent())
+	assert.Equal(t, 2, report.Matched)
+	assert.Equal(t, []Discrepancy{
+		{Client: client, Server: server, Method: "POST", Path: "/404/missing", Status: 400, Expected: 1, Reported: 0},
+	}, report.Missing)
+	assert.Equal(t, []Discrepancy{
+		{Client: client, Server: server, Method: "PUT", Path: "/500/extra", Status: 500, Expected: 0, Reported: 1},
+	}, report.Extra)
+	assert.Contains(t, report.String(), "missing 127.0.0.1:40000 -> 127.0.0.1:8080 POST /404/missing 4XX")
+}
+
+func TestServerAccessLog(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	var accessLog bytes.Buffer
+	srvDoneFn := testutil.HTTPServer(t, addr, testutil.Options{AccessLog: &accessLog})
+
+	const numRequests = 5
+	for i := 0; i < numRequests; i++ {
+		resp, err := nethttp.Get(fmt.Sprintf("http://%s/%d/request-%d?query=1", addr, 200+i, i))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	srvDoneFn()
+
+	entries, err := ParseAccessLog(strings.NewReader(accessLog.String()))
+	require.NoError(t, err)
+	require.Len(t, entries, numRequests)
+	for i, entry := range entries {
+		assert.Equal(t, "127.0.0.1", entry.Client.IP)
+		assert.NotZero(t, entry.Client.Port)
+		assert.Equal(t, addr, net.JoinHostPort(entry.Server.IP, fmt.Sprint(entry.Server.Port)))
+		assert.Equal(t, "GET", entry.Method)
+		assert.Equal(t, fmt.Sprintf("/%d/request-%d", 200+i, i), entry.Path)
+		assert.Equal(t, 200+i, entry.Status)
+	}
+}
@@ -18,9 +18,12 @@ import (
 type RequestSummary struct {
 	Client      Address
 	Server      Address
+	Service     *Address
+	ContainerID string `json:",omitempty"`
 	DNS         string
 	Path        string
 	Method      string
+	Direction   string `json:",omitempty"`
 	ByStatus    map[int]Stats
 	StaticTags  uint64
 	DynamicTags []string
@@ -37,6 +40,26 @@ type Stats struct {
 	Count              int
 	FirstLatencySample float64
 	LatencyP50         float64
+
+	// FirstByteCount is the number of requests for which the time to first byte is known
+	FirstByteCount         int
+	FirstByteLatencySample float64
+	FirstByteLatencyP50    float64
+
+	RequestBytes  uint64
+	ResponseBytes uint64
+
+	// RequestSizeCount and ResponseSizeCount are the number of requests for which the size of the request and the
+	// size of the response are known
+	RequestSizeCount  int
+	RequestSizeP50    float64
+	RequestSizeP99    float64
+	ResponseSizeCount int
+	ResponseSizeP50   float64
+	ResponseSizeP99   float64
+
+	// Headers holds the captured values of the allowlisted request headers
+	Headers map[string][]string `json:",omitempty"`
 }
 
 // HTTP returns a debug-friendly representation of map[http.Key]http.RequestStats
@@ -55,16 +78,26 @@ func HTTP(stats map[http.Key]*http.RequestStats, dns map[util.Address][]dns.Host
 				IP:   serverAddr.String(),
 				Port: k.DstPort,
 			},
-			DNS:      getDNS(dns, serverAddr),
-			Path:     k.Path.Content,
-			Method:   k.Method.String(),
-			ByStatus: make(map[int]Stats),
+			ContainerID: k.ContainerID,
+			DNS:         getDNS(dns, serverAddr),
+			Path:        k.Path.Content,
+			Method:      k.Method.String(),
+			ByStatus:    make(map[int]Stats),
+		}
+
+		if k.Direction != http.DirectionUnknown {
+			debug.Direction = k.Direction.String()
 		}
 
-		for status := 100; status <= 500; status += 100 {
-			if !v.HasStats(status) {
-				continue
+		if !k.Service.IsZero() {
+			debug.Service = &Address{
+				IP:   formatIP(k.Service.IPLow, k.Service.IPHigh).String(),
+				Port: k.Service.Port,
 			}
+		}
+
+		for _, code := range v.StatusCodes() {
+			status := int(code)
 			stat := v.Stats(status)
 			debug.StaticTags = stat.StaticTags
 			debug.DynamicTags = stat.DynamicTags
@@ -73,6 +106,22 @@ func HTTP(stats map[http.Key]*http.RequestStats, dns map[util.Address][]dns.Host
 				Count:              stat.Count,
 				FirstLatencySample: stat.FirstLatencySample,
 				LatencyP50:         getSketchQuantile(stat.Latencies, 0.5),
+
+				FirstByteCount:         stat.FirstByteCount,
+				FirstByteLatencySample: stat.FirstByteLatencySample,
+				FirstByteLatencyP50:    getSketchQuantile(stat.FirstByteLatencies, 0.5),
+
+				RequestBytes:  stat.RequestBytes,
+				ResponseBytes: stat.ResponseBytes,
+
+				RequestSizeCount:  stat.RequestSizeCount,
+				RequestSizeP50:    sizeQuantile(stat.RequestSizes, stat.FirstRequestSizeSample, 0.5),
+				RequestSizeP99:    sizeQuantile(stat.RequestSizes, stat.FirstRequestSizeSample, 0.99),
+				ResponseSizeCount: stat.ResponseSizeCount,
+				ResponseSizeP50:   sizeQuantile(stat.ResponseSizes, stat.FirstResponseSizeSample, 0.5),
+				ResponseSizeP99:   sizeQuantile(stat.ResponseSizes, stat.FirstResponseSizeSample, 0.99),
+
+				Headers: stat.Headers,
 			}
 		}
 
@@ -101,6 +150,14 @@ func getDNS(dnsData map[util.Address][]dns.Hostname, addr util.Address) string {
 	return ""
 }
 
+// sizeQuantile returns the quantile of the sizes, which is the first sample when the sketch isn't created yet
+func sizeQuantile(sketch *ddsketch.DDSketch, firstSample float64, percentile float64) float64 {
+	if sketch == nil {
+		return firstSample
+	}
+	return getSketchQuantile(sketch, percentile)
+}
+
 func getSketchQuantile(sketch *ddsketch.DDSketch, percentile float64) float64 {
 	if sketch == nil {
 		return 0.0
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// CallSummary represents a (debug-friendly) aggregated view of the gRPC calls
+// matching a (client, server, service, method) tuple
+type CallSummary struct {
+	Client  Address
+	Server  Address
+	DNS     string
+	Service string
+	Method  string
+
+	Count int
+	// Errors is the number of failed calls by gRPC status code
+	Errors map[uint8]int
+
+	FirstLatencySample float64
+	LatencyP50         float64
+	LatencyP95         float64
+	LatencyP99         float64
+
+	StaticTags uint64
+}
+
+// GRPC returns a debug-friendly representation of map[grpc.Key]grpc.RequestStat
+func GRPC(stats map[grpc.Key]*grpc.RequestStat, dns map[util.Address][]dns.Hostname) []CallSummary {
+	all := make([]CallSummary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, CallSummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS:     getDNS(dns, serverAddr),
+			Service: k.Service,
+			Method:  k.Method,
+
+			Count:  v.Count,
+			Errors: v.Errors,
+
+			FirstLatencySample: v.FirstLatencySample,
+			LatencyP50:         getSketchQuantile(v.Latencies, 0.5),
+			LatencyP95:         getSketchQuantile(v.Latencies, 0.95),
+			LatencyP99:         getSketchQuantile(v.Latencies, 0.99),
+
+			StaticTags: v.StaticTags,
+		})
+	}
+
+	return all
+}
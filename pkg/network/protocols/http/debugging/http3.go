@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http3"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// HTTP3Summary represents a (debug-friendly) aggregated view of the HTTP/3 requests
+// matching a (client, server, server name) tuple
+type HTTP3Summary struct {
+	Client     Address
+	Server     Address
+	DNS        string
+	ServerName string
+
+	Count int
+}
+
+// HTTP3 returns a debug-friendly representation of map[http3.Key]http3.RequestStat
+func HTTP3(stats map[http3.Key]*http3.RequestStat, dns map[util.Address][]dns.Hostname) []HTTP3Summary {
+	all := make([]HTTP3Summary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, HTTP3Summary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS:        getDNS(dns, serverAddr),
+			ServerName: k.ServerName,
+
+			Count: v.Count,
+		})
+	}
+
+	return all
+}
@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/memcached"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// MemcachedOpSummary represents a (debug-friendly) aggregated view of the memcached requests
+// matching a (client, server, operation) tuple
+type MemcachedOpSummary struct {
+	Client Address
+	Server Address
+	DNS    string
+	Op     string
+
+	Count  int
+	Hits   int
+	Misses int
+	Errors int
+
+	FirstLatencySample float64
+	LatencyP50         float64
+	LatencyP95         float64
+	LatencyP99         float64
+}
+
+// Memcached returns a debug-friendly representation of map[memcached.Key]memcached.RequestStat
+func Memcached(stats map[memcached.Key]*memcached.RequestStat, dns map[util.Address][]dns.Hostname) []MemcachedOpSummary {
+	all := make([]MemcachedOpSummary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, MemcachedOpSummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS: getDNS(dns, serverAddr),
+			Op:  k.Op,
+
+			Count:  v.Count,
+			Hits:   v.Hits,
+			Misses: v.Misses,
+			Errors: v.Errors,
+
+			FirstLatencySample: v.FirstLatencySample,
+			LatencyP50:         getSketchQuantile(v.Latencies, 0.5),
+			LatencyP95:         getSketchQuantile(v.Latencies, 0.95),
+			LatencyP99:         getSketchQuantile(v.Latencies, 0.99),
+		})
+	}
+
+	return all
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// MongoCommandSummary represents a (debug-friendly) aggregated view of the Mongo commands
+// matching a (client, server, command name, collection) tuple
+type MongoCommandSummary struct {
+	Client     Address
+	Server     Address
+	DNS        string
+	Command    string
+	Collection string
+
+	Count      int
+	ErrorCount int
+
+	FirstLatencySample float64
+	LatencyP50         float64
+}
+
+// Mongo returns a debug-friendly representation of map[mongo.Key]mongo.RequestStat
+func Mongo(stats map[mongo.Key]*mongo.RequestStat, dns map[util.Address][]dns.Hostname) []MongoCommandSummary {
+	all := make([]MongoCommandSummary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, MongoCommandSummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS:        getDNS(dns, serverAddr),
+			Command:    k.Command,
+			Collection: k.Collection,
+
+			Count:      v.Count,
+			ErrorCount: v.ErrorCount,
+
+			FirstLatencySample: v.FirstLatencySample,
+			LatencyP50:         getSketchQuantile(v.Latencies, 0.5),
+		})
+	}
+
+	return all
+}
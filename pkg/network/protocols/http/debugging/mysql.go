@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// StatementSummary represents a (debug-friendly) aggregated view of the MySQL statements
+// matching a (client, server, query fingerprint) tuple
+type StatementSummary struct {
+	Client Address
+	Server Address
+	DNS    string
+	Query  string
+
+	Count int
+	// Errors is the number of failed statements by MySQL error code
+	Errors map[uint16]int
+
+	FirstLatencySample float64
+	LatencyP50         float64
+	LatencyP95         float64
+	LatencyP99         float64
+}
+
+// MySQL returns a debug-friendly representation of map[mysql.Key]mysql.RequestStat
+func MySQL(stats map[mysql.Key]*mysql.RequestStat, dns map[util.Address][]dns.Hostname) []StatementSummary {
+	all := make([]StatementSummary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, StatementSummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS:   getDNS(dns, serverAddr),
+			Query: k.Query,
+
+			Count:  v.Count,
+			Errors: v.Errors,
+
+			FirstLatencySample: v.FirstLatencySample,
+			LatencyP50:         getSketchQuantile(v.Latencies, 0.5),
+			LatencyP95:         getSketchQuantile(v.Latencies, 0.95),
+			LatencyP99:         getSketchQuantile(v.Latencies, 0.99),
+		})
+	}
+
+	return all
+}
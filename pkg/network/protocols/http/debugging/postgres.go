@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// QuerySummary represents a (debug-friendly) aggregated view of the Postgres queries
+// matching a (client, server, query fingerprint) tuple
+type QuerySummary struct {
+	Client Address
+	Server Address
+	DNS    string
+	Query  string
+
+	Count              int
+	Rows               int
+	FirstLatencySample float64
+	LatencyP50         float64
+}
+
+// Postgres returns a debug-friendly representation of map[postgres.Key]postgres.RequestStat
+func Postgres(stats map[postgres.Key]*postgres.RequestStat, dns map[util.Address][]dns.Hostname) []QuerySummary {
+	all := make([]QuerySummary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, QuerySummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS:   getDNS(dns, serverAddr),
+			Query: k.Query,
+
+			Count:              v.Count,
+			Rows:               v.Rows,
+			FirstLatencySample: v.FirstLatencySample,
+			LatencyP50:         getSketchQuantile(v.Latencies, 0.5),
+		})
+	}
+
+	return all
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package debugging
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// CommandSummary represents a (debug-friendly) aggregated view of the Redis commands
+// matching a (client, server, command name) tuple
+type CommandSummary struct {
+	Client  Address
+	Server  Address
+	DNS     string
+	Command string
+
+	Count      int
+	ErrorCount int
+
+	FirstLatencySample float64
+	LatencyP50         float64
+}
+
+// Redis returns a debug-friendly representation of map[redis.Key]redis.RequestStat
+func Redis(stats map[redis.Key]*redis.RequestStat, dns map[util.Address][]dns.Hostname) []CommandSummary {
+	all := make([]CommandSummary, 0, len(stats))
+	for k, v := range stats {
+		clientAddr := formatIP(k.SrcIPLow, k.SrcIPHigh)
+		serverAddr := formatIP(k.DstIPLow, k.DstIPHigh)
+
+		all = append(all, CommandSummary{
+			Client: Address{
+				IP:   clientAddr.String(),
+				Port: k.SrcPort,
+			},
+			Server: Address{
+				IP:   serverAddr.String(),
+				Port: k.DstPort,
+			},
+			DNS:     getDNS(dns, serverAddr),
+			Command: k.Command,
+
+			Count:      v.Count,
+			ErrorCount: v.ErrorCount,
+
+			FirstLatencySample: v.FirstLatencySample,
+			LatencyP50:         getSketchQuantile(v.Latencies, 0.5),
+		})
+	}
+
+	return all
+}
@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+
+	manager "github.com/DataDog/ebpf-manager"
+)
+
+// dispatchedProtocol is a protocol decoded by a program tail called by the protocol dispatcher, which can be disabled
+// and enabled again at runtime by removing its program from the program array of the dispatcher
+type dispatchedProtocol struct {
+	tailCall manager.TailCallRoute
+	// inFlightMaps hold the state of the connections of the protocol, they are cleared when it is disabled so that
+	// the requests seen before are not matched with the responses seen once it is enabled again
+	inFlightMaps []string
+}
+
+// dispatchedProtocols are the protocols which can be disabled at runtime, by name. The TLS hooks are not tail called
+// by the dispatcher, so only the plaintext traffic of a disabled protocol is not decoded anymore. Disabling http2 also
+// disables the gRPC monitoring, which decodes the frames of the HTTP/2 connections.
+var dispatchedProtocols = map[string]dispatchedProtocol{
+	"http": {
+		tailCall:     tailCalls[0],
+		inFlightMaps: []string{httpInFlightMap, httpPipelinedRequestsMap},
+	},
+	"http2":              {tailCall: http2TailCall},
+	kafkaProtocol:        {tailCall: kafkaTailCall, inFlightMaps: []string{kafkaInFlightMap}},
+	postgresProtocol:     {tailCall: postgresTailCall, inFlightMaps: []string{postgresInFlightMap}},
+	mysqlProtocol:        {tailCall: mysqlTailCall, inFlightMaps: []string{mysqlInFlightMap}},
+	redisProtocol:        {tailCall: redisTailCall, inFlightMaps: []string{redisInFlightMap}},
+	mongoProtocol:        {tailCall: mongoTailCall, inFlightMaps: []string{mongoInFlightMap}},
+	amqpProtocol:         {tailCall: amqpTailCall},
+	http3Protocol:        {tailCall: http3TailCall},
+	tlsHandshakeProtocol: {tailCall: tlsHandshakeTailCall},
+	cassandraProtocol:    {tailCall: cassandraTailCall, inFlightMaps: []string{cassandraInFlightMap}},
+	memcachedProtocol:    {tailCall: memcachedTailCall, inFlightMaps: []string{memcachedInFlightMap}},
+}
+
+// ErrProtocolNotLoaded is returned when toggling a protocol whose program was not loaded, as it was disabled when
+// the monitoring started. Such a protocol can only be enabled by restarting system-probe.
+var ErrProtocolNotLoaded = errors.New("protocol monitoring was disabled at startup")
+
+// initDispatchedProtocols records the protocols whose program is routed by the given tail calls, which are the ones
+// that can be toggled at runtime
+func (e *ebpfProgram) initDispatchedProtocols(routes []manager.TailCallRoute) {
+	e.protocolsMux.Lock()
+	defer e.protocolsMux.Unlock()
+
+	e.protocols = make(map[string]bool)
+	for name, p := range dispatchedProtocols {
+		for _, route := range routes {
+			if route.ProgArrayName == p.tailCall.ProgArrayName && route.Key == p.tailCall.Key {
+				e.protocols[name] = true
+				break
+			}
+		}
+	}
+}
+
+// setProtocolEnabled attaches the program of the given protocol to the dispatcher, or detaches it and clears the
+// state of its connections
+func (e *ebpfProgram) setProtocolEnabled(name string, enabled bool) error {
+	p, ok := dispatchedProtocols[name]
+	if !ok {
+		return fmt.Errorf("unknown protocol %q", name)
+	}
+
+	e.protocolsMux.Lock()
+	defer e.protocolsMux.Unlock()
+
+	current, loaded := e.protocols[name]
+	if !loaded {
+		return fmt.Errorf("%s: %w", name, ErrProtocolNotLoaded)
+	}
+	if current == enabled {
+		return nil
+	}
+
+	if enabled {
+		if err := e.UpdateTailCallRoutes(p.tailCall); err != nil {
+			return fmt.Errorf("error enabling %s monitoring: %w", name, err)
+		}
+		e.protocols[name] = true
+		return nil
+	}
+
+	progs, _, err := e.GetMap(p.tailCall.ProgArrayName)
+	if err != nil {
+		return fmt.Errorf("error disabling %s monitoring: %w", name, err)
+	}
+	key := p.tailCall.Key
+	if err := progs.Delete(unsafe.Pointer(&key)); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return fmt.Errorf("error disabling %s monitoring: %w", name, err)
+	}
+	e.protocols[name] = false
+
+	// the program is not tail called anymore, the state of its connections can't be updated concurrently
+	for _, mapName := range p.inFlightMaps {
+		m, _, err := e.GetMap(mapName)
+		if err != nil {
+			return fmt.Errorf("error clearing %s: %w", mapName, err)
+		}
+		if err := clearMap(m); err != nil {
+			return fmt.Errorf("error clearing %s: %w", mapName, err)
+		}
+	}
+	return nil
+}
+
+// enabledProtocols returns whether each protocol that can be toggled at runtime is currently enabled
+func (e *ebpfProgram) enabledProtocols() map[string]bool {
+	e.protocolsMux.Lock()
+	defer e.protocolsMux.Unlock()
+
+	protocols := make(map[string]bool, len(e.protocols))
+	for name, enabled := range e.protocols {
+		protocols[name] = enabled
+	}
+	return protocols
+}
+
+// clearMap removes all the entries of the given map
+func clearMap(m *ebpf.Map) error {
+	var keys [][]byte
+	key, value := make([]byte, m.KeySize()), make([]byte, m.ValueSize())
+	entries := m.Iterate()
+	for entries.Next(&key, &value) {
+		keys = append(keys, append([]byte(nil), key...))
+	}
+	if err := entries.Err(); err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := m.Delete(k); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetProtocolEnabled enables or disables the monitoring of the given protocol at runtime, without restarting the
+// monitor. Only the protocols enabled when the monitor started can be toggled.
+func (m *Monitor) SetProtocolEnabled(name string, enabled bool) error {
+	if m == nil {
+		return errors.New("usm is not enabled")
+	}
+	if m.ebpfProgram == nil {
+		return errors.New("the protocols can't be toggled when usm falls back to the http socket filter")
+	}
+	return m.ebpfProgram.setProtocolEnabled(name, enabled)
+}
+
+// GetProtocols returns whether each protocol that can be toggled at runtime is currently enabled
+func (m *Monitor) GetProtocols() map[string]bool {
+	if m == nil || m.ebpfProgram == nil {
+		return nil
+	}
+	return m.ebpfProgram.enabledProtocols()
+}
@@ -32,6 +32,15 @@ func dumpMapsHandler(manager *manager.Manager, mapName string, currentMap *ebpf.
 			output.WriteString(spew.Sdump(key, value))
 		}
 
+	case httpPipelinedRequestsMap: // maps/http_pipelined_requests (BPF_MAP_TYPE_HASH), key ConnTuple, value httpPipeline
+		output.WriteString("Map: '" + mapName + "', key: 'ConnTuple', value: 'httpPipeline'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value httpPipeline
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			output.WriteString(spew.Sdump(key, value))
+		}
+
 	case sslSockByCtxMap: // maps/ssl_sock_by_ctx (BPF_MAP_TYPE_HASH), key uintptr // C.void *, value C.ssl_sock_t
 		output.WriteString("Map: '" + mapName + "', key: 'uintptr // C.void *', value: 'C.ssl_sock_t'\n")
 		iter := currentMap.Iterate()
@@ -76,6 +85,15 @@ func dumpMapsHandler(manager *manager.Manager, mapName string, currentMap *ebpf.
 		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
 			output.WriteString(spew.Sdump(key, value))
 		}
+
+	case http2FrameStatsMap: // maps/http2_frame_stats (BPF_MAP_TYPE_LRU_HASH), key ConnTuple, value http2FrameStats
+		output.WriteString("Map: '" + mapName + "', key: 'ConnTuple', value: 'http2FrameStats'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value http2FrameStats
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			output.WriteString(spew.Sdump(key, value))
+		}
 	}
 	return output.String()
 }
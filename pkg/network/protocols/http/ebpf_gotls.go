@@ -15,6 +15,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"sync"
 	"syscall"
@@ -41,8 +42,11 @@ const (
 	goTLSReadArgsMap          = "go_tls_read_args"
 	goTLSWriteArgsMap         = "go_tls_write_args"
 	connectionTupleByGoTLSMap = "conn_tup_by_go_tls_conn"
+	goTLSBinaryByPIDMap       = "go_tls_binary_by_pid"
 )
 
+var errProcessExited = errors.New("process exited before hooks could be attached")
+
 type uprobeInfo struct {
 	ebpfFunctionName string
 	ebpfSection      string
@@ -123,6 +127,11 @@ type runningBinary struct {
 	// Reference counter for the number of currently running processes for
 	// this binary.
 	processCount int32
+
+	// Path of the binary and time of hooking, reported by the uprobe
+	// attachments.
+	path  string
+	since time.Time
 }
 
 type GoTLSProgram struct {
@@ -144,6 +153,14 @@ type GoTLSProgram struct {
 	// inodes.
 	offsetsDataMap *ebpf.Map
 
+	// eBPF map holding the binary of each registered process, used by the
+	// prebuilt probes to find the offsets data of the current process.
+	binaryByPIDMap *ebpf.Map
+
+	// prebuilt is set when the probes are loaded from the prebuilt asset,
+	// which can only read the goroutine ID from a register.
+	prebuilt bool
+
 	// binaries keeps track of the currently hooked binary.
 	binaries map[binaryID]*runningBinary
 
@@ -154,6 +171,10 @@ type GoTLSProgram struct {
 	// binAnalysisMetric handles telemetry on the time spent doing binary
 	// analysis
 	binAnalysisMetric *libtelemetry.Metric
+
+	// failures keeps the last error hooking each binary, up to
+	// maxGoTLSFailures binaries.
+	failures map[binaryID]uprobeAttachment
 }
 
 // Static evaluation to make sure we are not breaking the interface.
@@ -173,16 +194,12 @@ func newGoTLSProgram(c *config.Config) *GoTLSProgram {
 		return nil
 	}
 
-	if !c.EnableRuntimeCompiler && !c.EnableCORE {
-		log.Errorf("goTLS support requires runtime-compilation or CO-RE to be enabled")
-		return nil
-	}
-
 	p := &GoTLSProgram{
 		cfg:       c,
 		procRoot:  c.ProcRoot,
 		binaries:  make(map[binaryID]*runningBinary),
 		processes: make(map[pid]binaryID),
+		failures:  make(map[binaryID]uprobeAttachment),
 	}
 
 	p.binAnalysisMetric = libtelemetry.NewMetric("gotls.analysis_time", libtelemetry.OptStatsd)
@@ -196,6 +213,7 @@ func (p *GoTLSProgram) ConfigureManager(m *errtelemetry.Manager) {
 		{Name: offsetsDataMap},
 		{Name: goTLSReadArgsMap},
 		{Name: goTLSWriteArgsMap},
+		{Name: goTLSBinaryByPIDMap},
 	}...)
 	// Hooks will be added in runtime for each binary
 }
@@ -230,6 +248,11 @@ func (p *GoTLSProgram) Start() {
 		log.Errorf("could not get offsets_data map: %s", err)
 		return
 	}
+	p.binaryByPIDMap, _, err = p.manager.GetMap(goTLSBinaryByPIDMap)
+	if err != nil {
+		log.Errorf("could not get %s map: %s", goTLSBinaryByPIDMap, err)
+		return
+	}
 
 	mon := monitor.GetProcessMonitor()
 	p.procMonitor.cleanupExec, err = mon.Subscribe(&monitor.ProcessCallback{
@@ -321,6 +344,9 @@ func (p *GoTLSProgram) hookNewBinary(binID binaryID, binPath string, pid pid, bi
 			// report hooking issue only if we detect properly a golang binary
 			if !errors.Is(err, binversion.ErrNotGoExe) {
 				log.Debugf("could not hook new binary %q for process %d: %s", binPath, pid, err)
+				if !errors.Is(err, errProcessExited) {
+					p.recordFailure(binID, binPath, err)
+				}
 			}
 			p.unregisterProcess(pid)
 			return
@@ -348,11 +374,16 @@ func (p *GoTLSProgram) hookNewBinary(binID binaryID, binPath string, pid pid, bi
 		return
 	}
 
+	if p.prebuilt && !inspectionResult.GoroutineIDMetadata.RuntimeGInRegister {
+		err = fmt.Errorf("reading the goroutine ID from thread local storage requires runtime compilation or CO-RE")
+		return
+	}
+
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
 	if bin.processCount == 0 {
-		err = fmt.Errorf("process exited before hooks could be attached")
+		err = errProcessExited
 		return
 	}
 
@@ -368,6 +399,10 @@ func (p *GoTLSProgram) hookNewBinary(binID binaryID, binPath string, pid pid, bi
 	}
 
 	bin.probeIDs = probeIDs
+	bin.path = binPath
+	bin.since = time.Now()
+	delete(p.failures, binID)
+	uprobeTelemetry.attached.Add(1)
 
 	elapsed := time.Since(start)
 
@@ -394,6 +429,9 @@ func (p *GoTLSProgram) registerProcess(binID binaryID, pid pid, mTime syscall.Ti
 	bin.processCount += 1
 
 	p.processes[pid] = binID
+	if err := p.binaryByPIDMap.Put(pid, binID); err != nil {
+		log.Debugf("could not write binary of process %d to map: %s", pid, err)
+	}
 
 	return old, bin, nil
 }
@@ -407,6 +445,9 @@ func (p *GoTLSProgram) unregisterProcess(pid pid) {
 		return
 	}
 	delete(p.processes, pid)
+	if err := p.binaryByPIDMap.Delete(pid); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+		log.Debugf("could not remove binary of process %d from map: %s", pid, err)
+	}
 
 	bin, found := p.binaries[binID]
 	if !found {
@@ -510,6 +551,7 @@ func (p *GoTLSProgram) unhookBinary(bin *runningBinary) {
 
 	p.detachHooks(bin.probeIDs)
 	p.removeInspectionResultFromMap(bin.binID)
+	uprobeTelemetry.attached.Add(-1)
 
 	log.Debugf("detached hooks on ino %v", bin.binID)
 }
@@ -523,6 +565,58 @@ func (p *GoTLSProgram) detachHooks(probeIDs []manager.ProbeIdentificationPair) {
 	}
 }
 
+// recordFailure keeps the error hooking the binary for the uprobe attachments
+func (p *GoTLSProgram) recordFailure(binID binaryID, binPath string, err error) {
+	uprobeTelemetry.failures.Add(1)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if _, found := p.failures[binID]; !found && len(p.failures) >= maxGoTLSFailures {
+		// drop any of the failures, the most recent ones being the most relevant
+		for id := range p.failures {
+			delete(p.failures, id)
+			break
+		}
+	}
+	failure := newUprobeFailure(binPath, binaryPathID(binID), time.Now(), err)
+	failure.Program = "go-tls"
+	p.failures[binID] = failure
+}
+
+func (p *GoTLSProgram) uprobeAttachments() (attached []uprobeAttachment, failed []uprobeAttachment) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	pids := make(map[binaryID][]uint32, len(p.binaries))
+	for pid, binID := range p.processes {
+		pids[binID] = append(pids[binID], pid)
+	}
+	for binID, bin := range p.binaries {
+		if bin.probeIDs == nil {
+			// the binary is being hooked, or isn't a Go binary
+			continue
+		}
+		a := newUprobeAttachment(bin.path, binaryPathID(binID), bin.since, bin.probeIDs)
+		a.Program = "go-tls"
+		a.PIDs = pids[binID]
+		sort.Slice(a.PIDs, func(i, j int) bool { return a.PIDs[i] < a.PIDs[j] })
+		attached = append(attached, a)
+	}
+	for _, failure := range p.failures {
+		failed = append(failed, failure)
+	}
+	return attached, failed
+}
+
+// binaryPathID returns the path identifier of the binary
+func binaryPathID(binID binaryID) pathIdentifier {
+	return pathIdentifier{
+		dev:   unix.Mkdev(binID.Id_major, binID.Id_minor),
+		inode: binID.Ino,
+	}
+}
+
 func (i *uprobeInfo) getIdentificationPair() manager.ProbeIdentificationPair {
 	return manager.ProbeIdentificationPair{
 		EBPFFuncName: i.ebpfFunctionName,
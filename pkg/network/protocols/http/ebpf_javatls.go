@@ -22,10 +22,21 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/process/monitor"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	manager "github.com/DataDog/ebpf-manager"
+	"github.com/cilium/ebpf"
 )
 
 const (
+	// AgentUSMJar is the USM java agent injected in the java processes. The file in the source tree is a placeholder,
+	// replaced by the build of the java agent, which is the one sending the eRPC requests handled here.
 	AgentUSMJar = "agent-usm.jar"
+
+	// javaTLSERPCProbe receives the eRPC requests of the injected agent-usm.jar, which carry the plaintext of the TLS
+	// connections of the java process, including the ones of the SSLEngine objects of the asynchronous frameworks
+	javaTLSERPCProbe = "kprobe__do_vfs_ioctl"
+
+	javaTLSConnectionsMap    = "java_tls_connections"
+	javaConnTupleByEngineMap = "java_conn_tuple_by_engine"
+	javaTLSAuthIDConstant    = "java_tls_auth_id"
 )
 
 var (
@@ -37,9 +48,13 @@ var (
 	// authID is used here as an identifier, simple proof of authenticity
 	// between the injected java process and the ebpf ioctl that receive the payload
 	authID = int64(0)
+
+	// injectionFilter selects the java processes the agent-usm.jar is injected in
+	injectionFilter *java.InjectionFilter
 )
 
 type JavaTLSProgram struct {
+	cfg            *config.Config
 	processMonitor *monitor.ProcessMonitor
 	cleanupExec    func()
 }
@@ -61,8 +76,15 @@ func newJavaTLSProgram(c *config.Config) *JavaTLSProgram {
 	}
 	jar.Close()
 
+	injectionFilter, err = java.NewInjectionFilter(c)
+	if err != nil {
+		log.Errorf("java TLS disabled: %s", err)
+		return nil
+	}
+
 	mon := monitor.GetProcessMonitor()
 	return &JavaTLSProgram{
+		cfg:            c,
 		processMonitor: mon,
 	}
 }
@@ -70,15 +92,49 @@ func newJavaTLSProgram(c *config.Config) *JavaTLSProgram {
 func (p *JavaTLSProgram) ConfigureManager(m *nettelemetry.Manager) {
 	rand.Seed(int64(os.Getpid()) + time.Now().UnixMicro())
 	authID = rand.Int63()
+
+	m.Probes = append(m.Probes, &manager.Probe{
+		ProbeIdentificationPair: manager.ProbeIdentificationPair{
+			EBPFFuncName: javaTLSERPCProbe,
+			UID:          probeUID,
+		},
+	})
 }
 
-func (p *JavaTLSProgram) ConfigureOptions(options *manager.Options) {}
+func (p *JavaTLSProgram) ConfigureOptions(options *manager.Options) {
+	options.ActivatedProbes = append(options.ActivatedProbes, &manager.ProbeSelector{
+		ProbeIdentificationPair: manager.ProbeIdentificationPair{
+			EBPFFuncName: javaTLSERPCProbe,
+			UID:          probeUID,
+		},
+	})
+
+	for _, name := range []string{javaTLSConnectionsMap, javaConnTupleByEngineMap} {
+		options.MapSpecEditors[name] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(p.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
 
-func (p *JavaTLSProgram) GetAllUndefinedProbes() (probeList []manager.ProbeIdentificationPair) {
-	return
+	// the requests of the agent are authenticated by the identifier it is given when injected. The offsets are shared
+	// with the tracer, so the constants are copied rather than appended to in place.
+	constants := options.ConstantEditors
+	options.ConstantEditors = append(constants[:len(constants):len(constants)], manager.ConstantEditor{
+		Name:  javaTLSAuthIDConstant,
+		Value: uint64(authID),
+	})
+}
+
+func (*JavaTLSProgram) GetAllUndefinedProbes() []manager.ProbeIdentificationPair {
+	return []manager.ProbeIdentificationPair{{EBPFFuncName: javaTLSERPCProbe}}
 }
 
 func newJavaProcess(pid uint32) {
+	if !injectionFilter.Allowed(int(pid)) {
+		return
+	}
+
 	args := javaUSMAgentArgs
 	if len(args) > 0 {
 		args += " "
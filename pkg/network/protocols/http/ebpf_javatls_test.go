@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"testing"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	nettelemetry "github.com/DataDog/datadog-agent/pkg/network/telemetry"
+)
+
+func TestJavaTLSProgramOptions(t *testing.T) {
+	// the eRPC probe is excluded from the programs when java TLS is disabled
+	var disabled *JavaTLSProgram
+	assert.Equal(t, []manager.ProbeIdentificationPair{{EBPFFuncName: javaTLSERPCProbe}}, disabled.GetAllUndefinedProbes())
+
+	cfg := config.New()
+	p := &JavaTLSProgram{cfg: cfg}
+	m := &nettelemetry.Manager{Manager: &manager.Manager{}}
+	p.ConfigureManager(m)
+	require.Len(t, m.Probes, 1)
+	assert.Equal(t, javaTLSERPCProbe, m.Probes[0].EBPFFuncName)
+
+	offsets := []manager.ConstantEditor{{Name: "offset_saddr", Value: uint64(4)}}
+	options := manager.Options{
+		MapSpecEditors:  make(map[string]manager.MapSpecEditor),
+		ConstantEditors: offsets,
+	}
+	p.ConfigureOptions(&options)
+
+	require.Len(t, options.ActivatedProbes, 1)
+	for _, name := range []string{javaTLSConnectionsMap, javaConnTupleByEngineMap} {
+		assert.Equal(t, uint32(cfg.MaxTrackedConnections), options.MapSpecEditors[name].MaxEntries, name)
+	}
+
+	// the requests of the agent are authenticated by the identifier it is given when injected
+	require.NotZero(t, authID)
+	assert.Contains(t, options.ConstantEditors, manager.ConstantEditor{Name: javaTLSAuthIDConstant, Value: uint64(authID)})
+	assert.Len(t, offsets, 1)
+}
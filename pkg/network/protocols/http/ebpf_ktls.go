@@ -0,0 +1,95 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	manager "github.com/DataDog/ebpf-manager"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	errtelemetry "github.com/DataDog/datadog-agent/pkg/network/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// kTLSProbes trace the functions of the tls kernel module encrypting and decrypting the plaintext of the sockets
+// using the kernel TLS, whose TLS records are not handled by a library hooked by the uprobes
+var kTLSProbes = []string{
+	"kprobe__tls_sw_sendmsg",
+	"kretprobe__tls_sw_sendmsg",
+	"kprobe__tls_sw_recvmsg",
+	"kretprobe__tls_sw_recvmsg",
+}
+
+// KTLSProgram monitors the HTTPS traffic of the kTLS sockets. Its probes are only part of the runtime-compiled and
+// CO-RE programs, as the prebuilt ones can't read the iterators of the messages, whose layout depends on the kernel.
+type KTLSProgram struct {
+	cfg     *config.Config
+	manager *errtelemetry.Manager
+}
+
+// Static evaluation to make sure we are not breaking the interface.
+var _ subprogram = &KTLSProgram{}
+
+func newKTLSProgram(c *config.Config) *KTLSProgram {
+	if !c.EnableHTTPSMonitoring || !c.EnableKTLSSupport {
+		return nil
+	}
+
+	if !HTTPSSupported(c) {
+		log.Warnf("kTLS monitoring is enabled but https monitoring is not supported on this host")
+		return nil
+	}
+
+	return &KTLSProgram{cfg: c}
+}
+
+func (p *KTLSProgram) ConfigureManager(m *errtelemetry.Manager) {
+	p.manager = m
+	// Hooks are added once the program is loaded, as they are missing from the prebuilt program
+}
+
+func (p *KTLSProgram) ConfigureOptions(options *manager.Options) {}
+
+func (*KTLSProgram) GetAllUndefinedProbes() []manager.ProbeIdentificationPair {
+	probeList := make([]manager.ProbeIdentificationPair, 0, len(kTLSProbes))
+	for _, probe := range kTLSProbes {
+		probeList = append(probeList, manager.ProbeIdentificationPair{EBPFFuncName: probe})
+	}
+	return probeList
+}
+
+func (p *KTLSProgram) Start() {
+	attached := make([]manager.ProbeIdentificationPair, 0, len(kTLSProbes))
+	for _, probe := range kTLSProbes {
+		probeID := manager.ProbeIdentificationPair{
+			EBPFFuncName: probe,
+			UID:          probeUID,
+		}
+		err := p.manager.AddHook("", &manager.Probe{
+			ProbeIdentificationPair: probeID,
+			KProbeMaxActive:         maxActive,
+		})
+		if err != nil {
+			// the functions are part of the tls kernel module, which is only loaded once a socket uses kTLS
+			log.Warnf("could not attach %s, kTLS traffic won't be monitored (it requires runtime compilation or CO-RE, and the tls kernel module): %s", probe, err)
+			p.detachHooks(attached)
+			return
+		}
+		attached = append(attached, probeID)
+	}
+}
+
+func (p *KTLSProgram) Stop() {}
+
+func (p *KTLSProgram) detachHooks(probeIDs []manager.ProbeIdentificationPair) {
+	for _, probeID := range probeIDs {
+		if err := p.manager.DetachHook(probeID); err != nil {
+			log.Errorf("failed detaching hook %s: %s", probeID.EBPFFuncName, err)
+		}
+	}
+}
@@ -11,6 +11,7 @@ package http
 import (
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/cilium/ebpf"
 	"golang.org/x/sys/unix"
@@ -21,13 +22,58 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/cassandra"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/events"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/memcached"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	errtelemetry "github.com/DataDog/datadog-agent/pkg/network/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const (
-	httpInFlightMap = "http_in_flight"
+	httpInFlightMap          = "http_in_flight"
+	httpPipelinedRequestsMap = "http_pipelined_requests"
+	httpRequestHeadersMap    = "http_request_headers"
+	tlsConnBytesMap          = "tls_conn_bytes"
+	http2FrameStatsMap       = "http2_frame_stats"
+	tlsHTTP2LocalClientMap   = "tls_http2_local_client"
+	tlsHTTPLocalClientMap    = "tls_http_local_client"
+	kafkaInFlightMap         = "kafka_in_flight"
+	postgresInFlightMap      = "postgres_in_flight"
+	mysqlInFlightMap         = "mysql_in_flight"
+	redisInFlightMap         = "redis_in_flight"
+	mongoInFlightMap         = "mongo_in_flight"
+	cassandraInFlightMap     = "cassandra_in_flight"
+	memcachedInFlightMap     = "memcached_in_flight"
+
+	// kafkaProtocol is the name of the event stream of the Kafka transactions
+	kafkaProtocol = "kafka"
+	// postgresProtocol is the name of the event stream of the Postgres transactions
+	postgresProtocol = "postgres"
+	// mysqlProtocol is the name of the event stream of the MySQL transactions
+	mysqlProtocol = "mysql"
+	// redisProtocol is the name of the event stream of the Redis transactions
+	redisProtocol = "redis"
+	// mongoProtocol is the name of the event stream of the Mongo transactions
+	mongoProtocol = "mongo"
+	// amqpProtocol is the name of the event stream of the AMQP messages
+	amqpProtocol = "amqp"
+	// grpcProtocol is the name of the event stream of the header blocks of the HTTP/2 connections
+	grpcProtocol = "grpc"
+	// dnsTLSProtocol is the name of the event stream of the plaintext of the DNS over TLS connections
+	dnsTLSProtocol = "dns_tls"
+	// http3Protocol is the name of the event stream of the QUIC Initial packets
+	http3Protocol = "http3"
+	// tlsHandshakeProtocol is the name of the event stream of the ClientHello and ServerHello messages
+	tlsHandshakeProtocol = "tls_handshake"
+	// cassandraProtocol is the name of the event stream of the Cassandra transactions
+	cassandraProtocol = "cassandra"
+	// memcachedProtocol is the name of the event stream of the memcached transactions
+	memcachedProtocol = "memcached"
 
 	// ELF section of the BPF_PROG_TYPE_SOCKET_FILTER program used
 	// to classify protocols and dispatch the correct handlers.
@@ -42,15 +88,43 @@ const (
 	// the accept syscall).
 	maxActive = 128
 	probeUID  = "http"
+
+	// tcpSendMsgKprobe and tcpSendMsgFentry are the variants of the program tracing tcp_sendmsg, only one of them
+	// is loaded
+	tcpSendMsgKprobe = "kprobe__tcp_sendmsg"
+	tcpSendMsgFentry = "fentry__tcp_sendmsg"
+
+	// maxCapturedRequestHeaders bounds the number of requests whose headers are kept in eBPF until their
+	// transaction is processed in userspace
+	maxCapturedRequestHeaders = 4096
+
+	// maxPipelinedConnections bounds the number of connections whose pipelined requests are queued in eBPF
+	maxPipelinedConnections = 1024
 )
 
 type ebpfProgram struct {
 	*errtelemetry.Manager
 	cfg             *config.Config
 	offsets         []manager.ConstantEditor
+	tcpSendMsgProbe string
 	subprograms     []subprogram
 	probesResolvers []probeResolver
 	mapCleaner      *ddebpf.MapCleaner
+
+	stopSubprogramsOnce sync.Once
+
+	pipelineMapCleaner  *ddebpf.MapCleaner
+	kafkaMapCleaner     *ddebpf.MapCleaner
+	postgresMapCleaner  *ddebpf.MapCleaner
+	mysqlMapCleaner     *ddebpf.MapCleaner
+	redisMapCleaner     *ddebpf.MapCleaner
+	mongoMapCleaner     *ddebpf.MapCleaner
+	cassandraMapCleaner *ddebpf.MapCleaner
+	memcachedMapCleaner *ddebpf.MapCleaner
+
+	// protocols holds whether each dispatched protocol whose program was loaded is currently enabled
+	protocols    map[string]bool
+	protocolsMux sync.Mutex
 }
 
 type probeResolver interface {
@@ -96,11 +170,152 @@ var tailCalls = []manager.TailCallRoute{
 	},
 }
 
+// http2TailCall is the program accounting for the frames of the HTTP/2 connections, which is only dispatched to when
+// the HTTP/2 monitoring is enabled
+var http2TailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolHTTP2),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__http2_filter",
+	},
+}
+
+// kafkaTailCall is the program decoding the Kafka requests, which is only dispatched to when the Kafka monitoring is
+// enabled
+var kafkaTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolKafka),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__kafka_filter",
+	},
+}
+
+// postgresTailCall is the program decoding the Postgres queries, which is only dispatched to when the Postgres
+// monitoring is enabled
+var postgresTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolPostgres),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__postgres_filter",
+	},
+}
+
+// mysqlTailCall is the program decoding the MySQL commands, which is only dispatched to when the MySQL monitoring is
+// enabled
+var mysqlTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolMySQL),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__mysql_filter",
+	},
+}
+
+// redisTailCall is the program decoding the Redis commands, which is only dispatched to when the Redis monitoring is
+// enabled
+var redisTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolRedis),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__redis_filter",
+	},
+}
+
+// mongoTailCall is the program decoding the Mongo commands, which is only dispatched to when the Mongo monitoring is
+// enabled
+var mongoTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolMONGO),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__mongo_filter",
+	},
+}
+
+// amqpTailCall is the program decoding the AMQP messages, which is only dispatched to when the AMQP monitoring is
+// enabled
+var amqpTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolAMQP),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__amqp_filter",
+	},
+}
+
+// http3TailCall is the program sending the QUIC Initial packets to userspace, which is only dispatched to when the
+// HTTP/3 monitoring is enabled
+var http3TailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolHTTP3),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__http3_filter",
+	},
+}
+
+// tlsHandshakeTailCall is the program sending the TLS hello messages to userspace, which is only dispatched to when the
+// TLS handshake monitoring is enabled
+var tlsHandshakeTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolTLS),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__tls_handshake_filter",
+	},
+}
+
+// cassandraTailCall is the program decoding the CQL requests, which is only dispatched to when the Cassandra
+// monitoring is enabled
+var cassandraTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolCassandra),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__cassandra_filter",
+	},
+}
+
+// memcachedTailCall is the program decoding the memcached requests, which is only dispatched to when the memcached
+// monitoring is enabled
+var memcachedTailCall = manager.TailCallRoute{
+	ProgArrayName: protocolDispatcherProgramsMap,
+	Key:           uint32(ProtocolMemcached),
+	ProbeIdentificationPair: manager.ProbeIdentificationPair{
+		EBPFFuncName: "socket__memcached_filter",
+	},
+}
+
 func newEBPFProgram(c *config.Config, offsets []manager.ConstantEditor, sockFD *ebpf.Map, bpfTelemetry *errtelemetry.EBPFTelemetry) (*ebpfProgram, error) {
+	tcpSendMsgProbe := tcpSendMsgKprobe
+	if c.EnableFentry && ddebpf.IsFentrySupported() {
+		tcpSendMsgProbe = tcpSendMsgFentry
+	}
+
 	mgr := &manager.Manager{
 		Maps: []*manager.Map{
 			{Name: httpInFlightMap},
+			{Name: httpPipelinedRequestsMap},
+			{Name: "http_pipeline_heap"},
 			{Name: sslSockByCtxMap},
+			{Name: sslLibraryByPIDMap},
+			{Name: tlsConnBytesMap},
+			{Name: http2FrameStatsMap},
+			{Name: tlsHTTP2LocalClientMap},
+			{Name: tlsHTTPLocalClientMap},
+			{Name: kafkaInFlightMap},
+			{Name: "kafka_heap"},
+			{Name: postgresInFlightMap},
+			{Name: "postgres_heap"},
+			{Name: mysqlInFlightMap},
+			{Name: "mysql_heap"},
+			{Name: redisInFlightMap},
+			{Name: "redis_heap"},
+			{Name: mongoInFlightMap},
+			{Name: "mongo_heap"},
+			{Name: "amqp_heap"},
+			{Name: "grpc_heap"},
+			{Name: "dns_tls_heap"},
+			{Name: "http3_heap"},
+			{Name: "tls_handshake_heap"},
+			{Name: cassandraInFlightMap},
+			{Name: "cassandra_heap"},
+			{Name: memcachedInFlightMap},
+			{Name: "memcached_heap"},
 			{Name: protocolDispatcherProgramsMap},
 			{Name: "ssl_read_args"},
 			{Name: "bio_new_socket_args"},
@@ -111,7 +326,7 @@ func newEBPFProgram(c *config.Config, offsets []manager.ConstantEditor, sockFD *
 		Probes: []*manager.Probe{
 			{
 				ProbeIdentificationPair: manager.ProbeIdentificationPair{
-					EBPFFuncName: "kprobe__tcp_sendmsg",
+					EBPFFuncName: tcpSendMsgProbe,
 					UID:          probeUID,
 				},
 				KProbeMaxActive: maxActive,
@@ -131,8 +346,8 @@ func newEBPFProgram(c *config.Config, offsets []manager.ConstantEditor, sockFD *
 		},
 	}
 
-	subprogramProbesResolvers := make([]probeResolver, 0, 3)
-	subprograms := make([]subprogram, 0, 3)
+	subprogramProbesResolvers := make([]probeResolver, 0, 4)
+	subprograms := make([]subprogram, 0, 4)
 
 	goTLSProg := newGoTLSProgram(c)
 	subprogramProbesResolvers = append(subprogramProbesResolvers, goTLSProg)
@@ -149,10 +364,16 @@ func newEBPFProgram(c *config.Config, offsets []manager.ConstantEditor, sockFD *
 	if openSSLProg != nil {
 		subprograms = append(subprograms, openSSLProg)
 	}
+	kTLSProg := newKTLSProgram(c)
+	subprogramProbesResolvers = append(subprogramProbesResolvers, kTLSProg)
+	if kTLSProg != nil {
+		subprograms = append(subprograms, kTLSProg)
+	}
 	program := &ebpfProgram{
 		Manager:         errtelemetry.NewManager(mgr, bpfTelemetry),
 		cfg:             c,
 		offsets:         offsets,
+		tcpSendMsgProbe: tcpSendMsgProbe,
 		subprograms:     subprograms,
 		probesResolvers: subprogramProbesResolvers,
 	}
@@ -165,10 +386,15 @@ func (e *ebpfProgram) Init() error {
 	for _, tc := range tailCalls {
 		undefinedProbes = append(undefinedProbes, tc.ProbeIdentificationPair)
 	}
+	undefinedProbes = append(undefinedProbes, http2TailCall.ProbeIdentificationPair, kafkaTailCall.ProbeIdentificationPair, postgresTailCall.ProbeIdentificationPair, mysqlTailCall.ProbeIdentificationPair, redisTailCall.ProbeIdentificationPair, mongoTailCall.ProbeIdentificationPair, amqpTailCall.ProbeIdentificationPair, http3TailCall.ProbeIdentificationPair, tlsHandshakeTailCall.ProbeIdentificationPair, cassandraTailCall.ProbeIdentificationPair, memcachedTailCall.ProbeIdentificationPair)
 
 	for _, s := range e.probesResolvers {
 		undefinedProbes = append(undefinedProbes, s.GetAllUndefinedProbes()...)
 	}
+	undefinedProbes = append(undefinedProbes, manager.ProbeIdentificationPair{
+		EBPFFuncName: e.unusedTCPSendMsgProbe(),
+		UID:          probeUID,
+	})
 
 	e.DumpHandler = dumpMapsHandler
 	e.InstructionPatcher = func(m *manager.Manager) error {
@@ -208,8 +434,12 @@ func (e *ebpfProgram) Init() error {
 
 func (e *ebpfProgram) Start() error {
 	err := e.Manager.Start()
+	failures := ddebpf.RecordAttachFailures("usm", e.Manager.Manager)
 	if err != nil {
-		return err
+		return ddebpf.AttachFailuresError(err, failures)
+	}
+	for _, f := range failures {
+		log.Warn(f)
 	}
 
 	for _, s := range e.subprograms {
@@ -223,13 +453,29 @@ func (e *ebpfProgram) Start() error {
 
 func (e *ebpfProgram) Close() error {
 	e.mapCleaner.Stop()
+	e.pipelineMapCleaner.Stop()
+	e.kafkaMapCleaner.Stop()
+	e.postgresMapCleaner.Stop()
+	e.mysqlMapCleaner.Stop()
+	e.redisMapCleaner.Stop()
+	e.mongoMapCleaner.Stop()
+	e.cassandraMapCleaner.Stop()
+	e.memcachedMapCleaner.Stop()
 	err := e.Stop(manager.CleanAll)
-	for _, s := range e.subprograms {
-		s.Stop()
-	}
+	e.stopSubprograms()
 	return err
 }
 
+// stopSubprograms stops the subprograms monitoring the optional protocols, such as the TLS libraries. They can't be
+// started again, and are stopped only once.
+func (e *ebpfProgram) stopSubprograms() {
+	e.stopSubprogramsOnce.Do(func() {
+		for _, s := range e.subprograms {
+			s.Stop()
+		}
+	})
+}
+
 func (e *ebpfProgram) initCORE() error {
 	assetName := getAssetName("http", e.cfg.BPFDebug)
 	return ddebpf.LoadCOREAsset(&e.cfg.Config, assetName, e.init)
@@ -250,6 +496,12 @@ func (e *ebpfProgram) initPrebuilt() error {
 		return err
 	}
 	defer bc.Close()
+
+	for _, s := range e.subprograms {
+		if goTLSProg, ok := s.(*GoTLSProgram); ok {
+			goTLSProg.prebuilt = true
+		}
+	}
 	return e.init(bc, manager.Options{})
 }
 
@@ -277,6 +529,221 @@ func (e *ebpfProgram) setupMapCleaner() {
 	})
 
 	e.mapCleaner = httpMapCleaner
+
+	// the requests awaiting a response are normally flushed along with the in-flight transaction of their connection,
+	// so we only need to evict the entries of the connections that were never closed
+	pipelineMap, _, _ := e.GetMap(httpPipelinedRequestsMap)
+	pipelineMapCleaner, err := ddebpf.NewMapCleaner(pipelineMap, new(netebpf.ConnTuple), new(httpPipeline))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	pipelineMapCleaner.Clean(e.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		pipeline, ok := val.(*httpPipeline)
+		if !ok {
+			return false
+		}
+
+		if pipeline.Len == 0 {
+			return true
+		}
+
+		started := int64(pipeline.Requests[pipeline.Head%maxPipelinedRequests].Request_started)
+		return (now - started) > ttl
+	})
+
+	e.pipelineMapCleaner = pipelineMapCleaner
+
+	if e.cfg.EnableKafkaMonitoring {
+		e.setupKafkaMapCleaner()
+	}
+	if e.cfg.EnablePostgresMonitoring {
+		e.setupPostgresMapCleaner()
+	}
+	if e.cfg.EnableMySQLMonitoring {
+		e.setupMySQLMapCleaner()
+	}
+	if e.cfg.EnableRedisMonitoring {
+		e.setupRedisMapCleaner()
+	}
+	if e.cfg.EnableMongoMonitoring {
+		e.setupMongoMapCleaner()
+	}
+	if e.cfg.EnableCassandraMonitoring {
+		e.setupCassandraMapCleaner()
+	}
+	if e.cfg.EnableMemcachedMonitoring {
+		e.setupMemcachedMapCleaner()
+	}
+}
+
+// setupKafkaMapCleaner evicts the Kafka requests which never got a response, such as the requests of the connections
+// closed before the broker responded
+func (e *ebpfProgram) setupKafkaMapCleaner() {
+	kafkaMap, _, _ := e.GetMap(kafkaInFlightMap)
+	kafkaMapCleaner, err := ddebpf.NewMapCleaner(kafkaMap, new(kafka.EbpfTxKey), new(kafka.EbpfTx))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	ttl := e.cfg.HTTPIdleConnectionTTL.Nanoseconds()
+	kafkaMapCleaner.Clean(e.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		kafkaTxn, ok := val.(*kafka.EbpfTx)
+		if !ok {
+			return false
+		}
+
+		started := int64(kafkaTxn.Request_started)
+		return started > 0 && (now-started) > ttl
+	})
+
+	e.kafkaMapCleaner = kafkaMapCleaner
+}
+
+// setupPostgresMapCleaner evicts the Postgres queries which never got a response, such as the queries of the
+// connections closed before the server responded
+func (e *ebpfProgram) setupPostgresMapCleaner() {
+	postgresMap, _, _ := e.GetMap(postgresInFlightMap)
+	postgresMapCleaner, err := ddebpf.NewMapCleaner(postgresMap, new(postgres.ConnTuple), new(postgres.EbpfTx))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	ttl := e.cfg.HTTPIdleConnectionTTL.Nanoseconds()
+	postgresMapCleaner.Clean(e.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		postgresTxn, ok := val.(*postgres.EbpfTx)
+		if !ok {
+			return false
+		}
+
+		started := int64(postgresTxn.Request_started)
+		return started > 0 && (now-started) > ttl
+	})
+
+	e.postgresMapCleaner = postgresMapCleaner
+}
+
+// setupMySQLMapCleaner evicts the MySQL commands which never got a response, such as the commands of the
+// connections closed before the server responded
+func (e *ebpfProgram) setupMySQLMapCleaner() {
+	mysqlMap, _, _ := e.GetMap(mysqlInFlightMap)
+	mysqlMapCleaner, err := ddebpf.NewMapCleaner(mysqlMap, new(mysql.ConnTuple), new(mysql.EbpfTx))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	ttl := e.cfg.HTTPIdleConnectionTTL.Nanoseconds()
+	mysqlMapCleaner.Clean(e.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		mysqlTxn, ok := val.(*mysql.EbpfTx)
+		if !ok {
+			return false
+		}
+
+		started := int64(mysqlTxn.Request_started)
+		return started > 0 && (now-started) > ttl
+	})
+
+	e.mysqlMapCleaner = mysqlMapCleaner
+}
+
+// setupRedisMapCleaner evicts the Redis commands which never got a reply, such as the commands of the
+// connections closed before the server responded
+func (e *ebpfProgram) setupRedisMapCleaner() {
+	redisMap, _, _ := e.GetMap(redisInFlightMap)
+	redisMapCleaner, err := ddebpf.NewMapCleaner(redisMap, new(redis.ConnTuple), new(redis.EbpfTx))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	ttl := e.cfg.HTTPIdleConnectionTTL.Nanoseconds()
+	redisMapCleaner.Clean(e.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		redisTxn, ok := val.(*redis.EbpfTx)
+		if !ok {
+			return false
+		}
+
+		started := int64(redisTxn.Request_started)
+		return started > 0 && (now-started) > ttl
+	})
+
+	e.redisMapCleaner = redisMapCleaner
+}
+
+// setupMongoMapCleaner evicts the Mongo commands which never got a response, such as the commands of the
+// connections closed before the server responded
+func (e *ebpfProgram) setupMongoMapCleaner() {
+	mongoMap, _, _ := e.GetMap(mongoInFlightMap)
+	mongoMapCleaner, err := ddebpf.NewMapCleaner(mongoMap, new(mongo.ConnTuple), new(mongo.EbpfTx))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	ttl := e.cfg.HTTPIdleConnectionTTL.Nanoseconds()
+	mongoMapCleaner.Clean(e.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		mongoTxn, ok := val.(*mongo.EbpfTx)
+		if !ok {
+			return false
+		}
+
+		started := int64(mongoTxn.Request_started)
+		return started > 0 && (now-started) > ttl
+	})
+
+	e.mongoMapCleaner = mongoMapCleaner
+}
+
+// setupCassandraMapCleaner evicts the Cassandra requests which never got a response, such as the requests of the
+// connections closed before the server responded
+func (e *ebpfProgram) setupCassandraMapCleaner() {
+	cassandraMap, _, _ := e.GetMap(cassandraInFlightMap)
+	cassandraMapCleaner, err := ddebpf.NewMapCleaner(cassandraMap, new(cassandra.EbpfKey), new(cassandra.EbpfTx))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	ttl := e.cfg.HTTPIdleConnectionTTL.Nanoseconds()
+	cassandraMapCleaner.Clean(e.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		cassandraTxn, ok := val.(*cassandra.EbpfTx)
+		if !ok {
+			return false
+		}
+
+		started := int64(cassandraTxn.Request_started)
+		return started > 0 && (now-started) > ttl
+	})
+
+	e.cassandraMapCleaner = cassandraMapCleaner
+}
+
+// setupMemcachedMapCleaner evicts the memcached requests which never got a response, such as the requests sent with the
+// noreply option which are not followed by another request on their connection
+func (e *ebpfProgram) setupMemcachedMapCleaner() {
+	memcachedMap, _, _ := e.GetMap(memcachedInFlightMap)
+	memcachedMapCleaner, err := ddebpf.NewMapCleaner(memcachedMap, new(memcached.ConnTuple), new(memcached.EbpfTx))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	ttl := e.cfg.HTTPIdleConnectionTTL.Nanoseconds()
+	memcachedMapCleaner.Clean(e.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		memcachedTxn, ok := val.(*memcached.EbpfTx)
+		if !ok {
+			return false
+		}
+
+		started := int64(memcachedTxn.Request_started)
+		return started > 0 && (now-started) > ttl
+	})
+
+	e.memcachedMapCleaner = memcachedMapCleaner
 }
 
 func (e *ebpfProgram) init(buf bytecode.AssetReader, options manager.Options) error {
@@ -296,6 +763,31 @@ func (e *ebpfProgram) init(buf bytecode.AssetReader, options manager.Options) er
 			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
 			EditorFlag: manager.EditMaxEntries,
 		},
+		httpPipelinedRequestsMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: maxPipelinedConnections,
+			EditorFlag: manager.EditMaxEntries,
+		},
+		tlsConnBytesMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		},
+		http2FrameStatsMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		},
+		tlsHTTP2LocalClientMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		},
+		tlsHTTPLocalClientMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		},
 		connectionStatesMap: {
 			Type:       ebpf.Hash,
 			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
@@ -306,9 +798,177 @@ func (e *ebpfProgram) init(buf bytecode.AssetReader, options manager.Options) er
 			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
 			EditorFlag: manager.EditMaxEntries,
 		},
+		// the headers of the requests are only captured when an allowlist of headers is configured
+		httpRequestHeadersMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		},
+		// the requests awaiting a response are only tracked when the Kafka monitoring is enabled
+		kafkaInFlightMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		},
+		// the queries awaiting a response are only tracked when the Postgres monitoring is enabled
+		postgresInFlightMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		},
+		// the commands awaiting a response are only tracked when the MySQL monitoring is enabled
+		mysqlInFlightMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		},
+		// the commands awaiting a reply are only tracked when the Redis monitoring is enabled
+		redisInFlightMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		},
+		// the commands awaiting a response are only tracked when the Mongo monitoring is enabled
+		mongoInFlightMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		},
+		// the requests awaiting a response are only tracked when the Cassandra monitoring is enabled
+		cassandraInFlightMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		},
+		// the requests awaiting a response are only tracked when the memcached monitoring is enabled
+		memcachedInFlightMap: {
+			Type:       ebpf.LRUHash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		},
 	}
 
 	options.TailCallRouter = tailCalls
+	// the gRPC calls are decoded from the HEADERS frames seen by the program accounting for the HTTP/2 frames
+	if e.cfg.EnableHTTP2Monitoring || e.cfg.EnableGRPCMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, http2TailCall)
+		// the plaintext of the HTTP/2 connections over TLS is accounted for by the TLS hooks
+		constants := options.ConstantEditors
+		options.ConstantEditors = append(constants[:len(constants):len(constants)], manager.ConstantEditor{
+			Name:  "http2_monitoring_enabled",
+			Value: uint64(1),
+		})
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, http2TailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.HTTPMaxPathSize > HTTPBufferSize {
+		log.Warnf("http_max_path_size (%d) is larger than the captured request fragment, the paths are truncated to %d bytes", e.cfg.HTTPMaxPathSize, HTTPBufferSize)
+	}
+	if size := getMaxFragmentSize(e.cfg); size > 0 {
+		constants := options.ConstantEditors
+		options.ConstantEditors = append(constants[:len(constants):len(constants)], manager.ConstantEditor{
+			Name:  "http_max_fragment_size",
+			Value: size,
+		})
+	}
+	if len(e.cfg.HTTPCaptureHeaders) > 0 {
+		options.MapSpecEditors[httpRequestHeadersMap] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: maxCapturedRequestHeaders,
+			EditorFlag: manager.EditMaxEntries,
+		}
+		constants := options.ConstantEditors
+		options.ConstantEditors = append(constants[:len(constants):len(constants)], manager.ConstantEditor{
+			Name:  "http_capture_headers_enabled",
+			Value: uint64(1),
+		})
+	}
+	if e.cfg.EnableKafkaMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, kafkaTailCall)
+		options.MapSpecEditors[kafkaInFlightMap] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, kafkaTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnablePostgresMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, postgresTailCall)
+		options.MapSpecEditors[postgresInFlightMap] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, postgresTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnableMySQLMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, mysqlTailCall)
+		options.MapSpecEditors[mysqlInFlightMap] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, mysqlTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnableRedisMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, redisTailCall)
+		options.MapSpecEditors[redisInFlightMap] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, redisTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnableMongoMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, mongoTailCall)
+		options.MapSpecEditors[mongoInFlightMap] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, mongoTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnableAMQPMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, amqpTailCall)
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, amqpTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnableHTTP3Monitoring {
+		options.TailCallRouter = append(options.TailCallRouter, http3TailCall)
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, http3TailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnableTLSHandshakeMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, tlsHandshakeTailCall)
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, tlsHandshakeTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnableCassandraMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, cassandraTailCall)
+		options.MapSpecEditors[cassandraInFlightMap] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, cassandraTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	if e.cfg.EnableMemcachedMonitoring {
+		options.TailCallRouter = append(options.TailCallRouter, memcachedTailCall)
+		options.MapSpecEditors[memcachedInFlightMap] = manager.MapSpecEditor{
+			Type:       ebpf.LRUHash,
+			MaxEntries: uint32(e.cfg.MaxTrackedConnections),
+			EditorFlag: manager.EditMaxEntries,
+		}
+	} else {
+		options.ExcludedFunctions = append(options.ExcludedFunctions, memcachedTailCall.ProbeIdentificationPair.EBPFFuncName)
+	}
+	e.initDispatchedProtocols(options.TailCallRouter)
 	options.ActivatedProbes = []manager.ProbesSelector{
 		&manager.ProbeSelector{
 			ProbeIdentificationPair: manager.ProbeIdentificationPair{
@@ -318,7 +978,7 @@ func (e *ebpfProgram) init(buf bytecode.AssetReader, options manager.Options) er
 		},
 		&manager.ProbeSelector{
 			ProbeIdentificationPair: manager.ProbeIdentificationPair{
-				EBPFFuncName: "kprobe__tcp_sendmsg",
+				EBPFFuncName: e.tcpSendMsgProbe,
 				UID:          probeUID,
 			},
 		},
@@ -329,6 +989,8 @@ func (e *ebpfProgram) init(buf bytecode.AssetReader, options manager.Options) er
 			},
 		},
 	}
+	// the fentry variant cannot be loaded on the hosts not supporting it
+	options.ExcludedFunctions = append(options.ExcludedFunctions, e.unusedTCPSendMsgProbe())
 	options.ConstantEditors = e.offsets
 	options.DefaultKprobeAttachMethod = kprobeAttachMethod
 	options.VerifierOptions.Programs.LogSize = 2 * 1024 * 1024
@@ -338,11 +1000,159 @@ func (e *ebpfProgram) init(buf bytecode.AssetReader, options manager.Options) er
 	}
 
 	// configure event stream
-	events.Configure("http", e.Manager.Manager, &options)
+	events.Configure(&e.cfg.Config, "http", e.Manager.Manager, &options)
+	if e.cfg.EnableKafkaMonitoring {
+		events.Configure(&e.cfg.Config, kafkaProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the Kafka transactions are never filled, but the map must still be created
+		options.MapSpecEditors[kafkaProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+	if e.cfg.EnablePostgresMonitoring {
+		events.Configure(&e.cfg.Config, postgresProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the Postgres transactions are never filled, but the map must still be created
+		options.MapSpecEditors[postgresProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+	if e.cfg.EnableMySQLMonitoring {
+		events.Configure(&e.cfg.Config, mysqlProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the MySQL transactions are never filled, but the map must still be created
+		options.MapSpecEditors[mysqlProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+	if e.cfg.EnableRedisMonitoring {
+		events.Configure(&e.cfg.Config, redisProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the Redis transactions are never filled, but the map must still be created
+		options.MapSpecEditors[redisProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+	if e.cfg.EnableMongoMonitoring {
+		events.Configure(&e.cfg.Config, mongoProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the Mongo transactions are never filled, but the map must still be created
+		options.MapSpecEditors[mongoProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+	if e.cfg.EnableAMQPMonitoring {
+		events.Configure(&e.cfg.Config, amqpProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the AMQP transactions are never filled, but the map must still be created
+		options.MapSpecEditors[amqpProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+	if e.cfg.EnableGRPCMonitoring {
+		events.Configure(&e.cfg.Config, grpcProtocol, e.Manager.Manager, &options)
+		// the offsets are shared with the tracer, so the constants are copied rather than appended to in place
+		constants := options.ConstantEditors
+		options.ConstantEditors = append(constants[:len(constants):len(constants)], manager.ConstantEditor{
+			Name:  "grpc_monitoring_enabled",
+			Value: uint64(1),
+		})
+	} else {
+		// the batches of the header blocks are never filled, but the map must still be created
+		options.MapSpecEditors[grpcProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+	if e.cfg.EnableDNSOverTLSMonitoring {
+		events.Configure(&e.cfg.Config, dnsTLSProtocol, e.Manager.Manager, &options)
+		// the offsets are shared with the tracer, so the constants are copied rather than appended to in place
+		constants := options.ConstantEditors
+		options.ConstantEditors = append(constants[:len(constants):len(constants)], manager.ConstantEditor{
+			Name:  "dns_tls_monitoring_enabled",
+			Value: uint64(1),
+		})
+	} else {
+		// the batches of the plaintext of the DNS over TLS connections are never filled, but the map must still be
+		// created
+		options.MapSpecEditors[dnsTLSProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+
+	if e.cfg.EnableHTTP3Monitoring {
+		events.Configure(&e.cfg.Config, http3Protocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the QUIC Initial packets are never filled, but the map must still be created
+		options.MapSpecEditors[http3Protocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+
+	if e.cfg.EnableTLSHandshakeMonitoring {
+		events.Configure(&e.cfg.Config, tlsHandshakeProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the TLS hello messages are never filled, but the map must still be created
+		options.MapSpecEditors[tlsHandshakeProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+
+	if e.cfg.EnableCassandraMonitoring {
+		events.Configure(&e.cfg.Config, cassandraProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the Cassandra transactions are never filled, but the map must still be created
+		options.MapSpecEditors[cassandraProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+
+	if e.cfg.EnableMemcachedMonitoring {
+		events.Configure(&e.cfg.Config, memcachedProtocol, e.Manager.Manager, &options)
+	} else {
+		// the batches of the memcached transactions are never filled, but the map must still be created
+		options.MapSpecEditors[memcachedProtocol+"_batches"] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditMaxEntries,
+		}
+	}
+	if err := ddebpf.EditRingBuffers(e.Manager.Manager, &options); err != nil {
+		return err
+	}
 
 	return e.InitWithOptions(buf, options)
 }
 
+// unusedTCPSendMsgProbe returns the variant of the program tracing tcp_sendmsg which is not used
+func (e *ebpfProgram) unusedTCPSendMsgProbe() string {
+	if e.tcpSendMsgProbe == tcpSendMsgFentry {
+		return tcpSendMsgKprobe
+	}
+	return tcpSendMsgFentry
+}
+
 func getAssetName(module string, debug bool) string {
 	if debug {
 		return fmt.Sprintf("%s-debug.o", module)
@@ -11,8 +11,13 @@ package http
 import (
 	"debug/elf"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"unsafe"
 
 	manager "github.com/DataDog/ebpf-manager"
 	"github.com/cilium/ebpf"
@@ -193,9 +198,41 @@ var gnuTLSProbes = []manager.ProbesSelector{
 
 const (
 	sslSockByCtxMap        = "ssl_sock_by_ctx"
+	sslLibraryByPIDMap     = "ssl_library_by_pid"
 	sharedLibrariesPerfMap = "shared_libraries"
 )
 
+// sslVariantSymbols holds, for each variant of OpenSSL sharing its API, a symbol which is only exported by the variant
+var sslVariantSymbols = map[ConnTag]string{
+	BoringSSL: "BORINGSSL_self_test",
+	LibreSSL:  "SSL_CTX_load_verify_mem",
+}
+
+var (
+	// libSSLLibraries and libCryptoLibraries match the shared libraries of OpenSSL, including the ones vendored by the
+	// Python wheels installed in virtualenvs, whose names are suffixed with a hash, such as libssl-1a2b3c4d.so.1.1
+	libSSLLibraries    = regexp.MustCompile(`libssl(-[0-9a-f]+)?\.so`)
+	libCryptoLibraries = regexp.MustCompile(`libcrypto(-[0-9a-f]+)?\.so`)
+
+	// pythonSSLBinaries matches the ssl module of CPython, along with libpython and the interpreters, which embed
+	// OpenSSL statically in some builds of CPython, such as the standalone ones installed by pyenv or uv
+	pythonSSLBinaries = regexp.MustCompile(`/(_ssl(\.cpython-[^/]+)?\.so|libpython[0-9.]+\.so[^/]*|python[0-9.]*)$`)
+)
+
+// nodeJSBinaries matches the executables of node, which embed OpenSSL statically and export its symbols
+var nodeJSBinaries = regexp.MustCompile(`/node$`)
+
+// boringSSLBinaries matches the executables known to embed BoringSSL statically, which are hooked like the shared
+// libraries of OpenSSL
+var boringSSLBinaries = regexp.MustCompile(`/chrome$`)
+
+// envoyBinaries matches the executables of envoy, which embed BoringSSL statically. Their release builds are
+// stripped, so the symbols of BoringSSL are only found in their dynamic symbol table.
+var envoyBinaries = regexp.MustCompile(`/envoy$`)
+
+// istioProxyConfig is part of the path of the bootstrap configuration given to the envoy sidecars by the Istio agent
+const istioProxyConfig = "istio/proxy/"
+
 type ebpfSectionFunction struct {
 	section  string
 	function string
@@ -214,12 +251,147 @@ type sslProgram struct {
 	perfHandler *ddebpf.PerfHandler
 	watcher     *soWatcher
 	manager     *errtelemetry.Manager
+	libraries   *sslLibraries
+	// nodeSymbols caches the OpenSSL symbols of the builds of node, whose binaries are large
+	nodeSymbols *buildIDSymbols
+}
+
+// sslLibraries tells the eBPF programs which variant of OpenSSL each process uses, as they are all hooked by the same
+// probes. The processes which are not part of libraryByPID use OpenSSL.
+type sslLibraries struct {
+	mux          sync.Mutex
+	tagByID      map[pathIdentifier]ConnTag
+	libraryByPID *ebpf.Map
+}
+
+func newSSLLibraries(libraryByPID *ebpf.Map) *sslLibraries {
+	return &sslLibraries{
+		tagByID:      make(map[pathIdentifier]ConnTag),
+		libraryByPID: libraryByPID,
+	}
+}
+
+// register hooks the library, and records which variant of OpenSSL it is
+func (l *sslLibraries) register(m *errtelemetry.Manager, probes []manager.ProbesSelector) func(pathIdentifier, string, string) error {
+	return l.registerWith(addHooks(m, probes), sslLibraryTag)
+}
+
+// registerEnvoy hooks the envoy binaries through the symbols they export, and records them as using BoringSSL
+func (l *sslLibraries) registerEnvoy(m *errtelemetry.Manager, probes []manager.ProbesSelector) func(pathIdentifier, string, string) error {
+	hook := addHooksWithResolver(m, probes, bininspect.GetExportedSymbolsByName)
+	return l.registerWith(hook, func(*elf.File) ConnTag { return BoringSSL })
+}
+
+func (l *sslLibraries) registerWith(hook func(pathIdentifier, string, string) error, libraryTag func(*elf.File) ConnTag) func(pathIdentifier, string, string) error {
+	return func(id pathIdentifier, root string, path string) error {
+		if err := hook(id, root, path); err != nil {
+			return err
+		}
+
+		elfFile, err := elf.Open(root + path)
+		if err != nil {
+			return err
+		}
+		defer elfFile.Close()
+
+		tag := libraryTag(elfFile)
+		l.mux.Lock()
+		l.tagByID[id] = tag
+		l.mux.Unlock()
+		return nil
+	}
+}
+
+func (l *sslLibraries) unregister(m *errtelemetry.Manager, probes []manager.ProbesSelector) func(pathIdentifier) error {
+	unhook := removeHooks(m, probes)
+	return func(id pathIdentifier) error {
+		l.mux.Lock()
+		delete(l.tagByID, id)
+		l.mux.Unlock()
+		return unhook(id)
+	}
+}
+
+func (l *sslLibraries) attachPID(pid uint32, id pathIdentifier) {
+	l.mux.Lock()
+	tag, ok := l.tagByID[id]
+	l.mux.Unlock()
+	if !ok || tag == OpenSSL {
+		return
+	}
+	l.setPIDTag(pid, tag)
+}
+
+// attachEnvoyPID records the processes of envoy as using BoringSSL, and tags the sidecars of Istio with their mesh
+func (l *sslLibraries) attachEnvoyPID(procRoot string) func(uint32, pathIdentifier) {
+	return func(pid uint32, id pathIdentifier) {
+		l.mux.Lock()
+		tag, ok := l.tagByID[id]
+		l.mux.Unlock()
+		if !ok {
+			return
+		}
+
+		if isIstioSidecar(procRoot, pid) {
+			tag |= Istio
+		}
+		l.setPIDTag(pid, tag)
+	}
+}
+
+func (l *sslLibraries) setPIDTag(pid uint32, tag ConnTag) {
+	if l.libraryByPID == nil {
+		return
+	}
+
+	if err := l.libraryByPID.Put(unsafe.Pointer(&pid), unsafe.Pointer(&tag)); err != nil {
+		log.Debugf("could not record the ssl library of pid %d: %s", pid, err)
+	}
+}
+
+func (l *sslLibraries) detachPID(pid uint32) {
+	if l.libraryByPID == nil {
+		return
+	}
+	_ = l.libraryByPID.Delete(unsafe.Pointer(&pid))
+}
+
+// isIstioSidecar returns true if the envoy process was started by the Istio agent, which gives it a bootstrap
+// configuration of its own, such as etc/istio/proxy/envoy-rev.json
+func isIstioSidecar(procRoot string, pid uint32) bool {
+	cmdline, err := os.ReadFile(filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10), "cmdline"))
+	if err != nil {
+		return false
+	}
+
+	for _, arg := range strings.Split(string(cmdline), "\x00") {
+		if strings.Contains(arg, istioProxyConfig) {
+			return true
+		}
+	}
+	return false
+}
+
+// sslLibraryTag returns the static tag of the OpenSSL-compatible library of the ELF file, whose variants are told
+// apart from OpenSSL by the symbols only they export
+func sslLibraryTag(elfFile *elf.File) ConnTag {
+	for tag, symbol := range sslVariantSymbols {
+		if _, err := bininspect.GetAllSymbolsByName(elfFile, common.StringSet{symbol: struct{}{}}); err == nil {
+			return tag
+		}
+	}
+	return OpenSSL
 }
 
 var _ subprogram = &sslProgram{}
 
 func newSSLProgram(c *config.Config, sockFDMap *ebpf.Map) *sslProgram {
-	if !c.EnableHTTPSMonitoring || !HTTPSSupported(c) {
+	if !c.EnableHTTPSMonitoring {
+		return nil
+	}
+
+	if !HTTPSSupported(c) {
+		log.Warnf("https monitoring is enabled but not supported on this host, it requires a kernel >= %s on %s", minimumHTTPSKernelVersion(runningOnARM()), runtime.GOARCH)
 		return nil
 	}
 
@@ -227,6 +399,7 @@ func newSSLProgram(c *config.Config, sockFDMap *ebpf.Map) *sslProgram {
 		cfg:         c,
 		sockFDMap:   sockFDMap,
 		perfHandler: ddebpf.NewPerfHandler(100),
+		nodeSymbols: newBuildIDSymbols(),
 	}
 }
 
@@ -237,7 +410,7 @@ func (o *sslProgram) ConfigureManager(m *errtelemetry.Manager) {
 	m.PerfMaps = append(m.PerfMaps, &manager.PerfMap{
 		Map: manager.Map{Name: sharedLibrariesPerfMap},
 		PerfMapOptions: manager.PerfMapOptions{
-			PerfRingBufferSize: 8 * os.Getpagesize(),
+			PerfRingBufferSize: ddebpf.PerfBufferSize(&o.cfg.Config, sharedLibrariesPerfMap, 8*os.Getpagesize()),
 			Watermark:          1,
 			RecordHandler:      o.perfHandler.RecordHandler,
 			LostHandler:        o.perfHandler.LostHandler,
@@ -292,25 +465,68 @@ func (o *sslProgram) ConfigureOptions(options *manager.Options) {
 }
 
 func (o *sslProgram) Start() {
+	libraryByPID, _, err := o.manager.GetMap(sslLibraryByPIDMap)
+	if err != nil {
+		log.Warnf("could not get %s map, the variants of OpenSSL will be tagged as OpenSSL: %s", sslLibraryByPIDMap, err)
+	}
+	o.libraries = newSSLLibraries(libraryByPID)
+
 	// Setup shared library watcher and configure the appropriate callbacks
 	o.watcher = newSOWatcher(o.perfHandler,
+		// the shared libraries of BoringSSL and LibreSSL share the name of the ones of OpenSSL
+		soRule{
+			re:           libSSLLibraries,
+			registerCB:   o.libraries.register(o.manager, openSSLProbes),
+			unregisterCB: o.libraries.unregister(o.manager, openSSLProbes),
+			attachPIDCB:  o.libraries.attachPID,
+			detachPIDCB:  o.libraries.detachPID,
+			probes:       openSSLProbes,
+		},
+		soRule{
+			re:           boringSSLBinaries,
+			registerCB:   o.libraries.register(o.manager, openSSLProbes),
+			unregisterCB: o.libraries.unregister(o.manager, openSSLProbes),
+			attachPIDCB:  o.libraries.attachPID,
+			detachPIDCB:  o.libraries.detachPID,
+			probes:       openSSLProbes,
+		},
+		soRule{
+			re:           envoyBinaries,
+			registerCB:   o.libraries.registerEnvoy(o.manager, openSSLProbes),
+			unregisterCB: o.libraries.unregister(o.manager, openSSLProbes),
+			attachPIDCB:  o.libraries.attachEnvoyPID(o.cfg.ProcRoot),
+			detachPIDCB:  o.libraries.detachPID,
+			probes:       openSSLProbes,
+		},
+		// node uses the OpenSSL it embeds, so its processes don't need to be tagged as using a variant of it
 		soRule{
-			re:           regexp.MustCompile(`libssl.so`),
-			registerCB:   addHooks(o.manager, openSSLProbes),
+			re:           nodeJSBinaries,
+			registerCB:   addHooksWithResolver(o.manager, openSSLProbes, o.nodeSymbols.resolve),
 			unregisterCB: removeHooks(o.manager, openSSLProbes),
+			probes:       openSSLProbes,
 		},
+		// most builds of CPython load the shared library of OpenSSL, which is hooked on its own
 		soRule{
-			re:           regexp.MustCompile(`libcrypto.so`),
+			re:           pythonSSLBinaries,
+			registerCB:   addEmbeddedHooks(o.manager, openSSLProbes),
+			unregisterCB: removeHooks(o.manager, openSSLProbes),
+			probes:       openSSLProbes,
+		},
+		soRule{
+			re:           libCryptoLibraries,
 			registerCB:   addHooks(o.manager, cryptoProbes),
 			unregisterCB: removeHooks(o.manager, cryptoProbes),
+			probes:       cryptoProbes,
 		},
 		soRule{
 			re:           regexp.MustCompile(`libgnutls.so`),
 			registerCB:   addHooks(o.manager, gnuTLSProbes),
 			unregisterCB: removeHooks(o.manager, gnuTLSProbes),
+			probes:       gnuTLSProbes,
 		},
 	)
 
+	o.watcher.watchLibraryDirs = o.cfg.EnableTLSLibraryWatcher
 	o.watcher.Start()
 }
 
@@ -318,7 +534,56 @@ func (o *sslProgram) Stop() {
 	o.perfHandler.Stop()
 }
 
+func (o *sslProgram) uprobeAttachments() (attached []uprobeAttachment, failed []uprobeAttachment) {
+	if o.watcher == nil {
+		return nil, nil
+	}
+	attached, failed = o.watcher.registry.attachments()
+	for i := range attached {
+		attached[i].Program = "openssl"
+	}
+	for i := range failed {
+		failed[i].Program = "openssl"
+	}
+	return attached, failed
+}
+
+// addEmbeddedHooks hooks the binaries which embed OpenSSL statically in some of their builds only. The ones importing
+// the symbols of OpenSSL from its shared library are left as they are, as the shared library is hooked on its own.
+func addEmbeddedHooks(m *errtelemetry.Manager, probes []manager.ProbesSelector) func(pathIdentifier, string, string) error {
+	hook := addHooks(m, probes)
+	return func(id pathIdentifier, root string, path string) error {
+		elfFile, err := elf.Open(root + path)
+		if err != nil {
+			return err
+		}
+		embedded := definesSymbol(elfFile, "SSL_read")
+		elfFile.Close()
+
+		if !embedded {
+			return nil
+		}
+		return hook(id, root, path)
+	}
+}
+
+// definesSymbol returns true if the ELF file defines the symbol, rather than importing it from a shared library
+func definesSymbol(elfFile *elf.File, symbol string) bool {
+	symbols, err := bininspect.GetAllSymbolsByName(elfFile, common.StringSet{symbol: struct{}{}})
+	if err != nil {
+		return false
+	}
+	return symbols[symbol].Section != elf.SHN_UNDEF
+}
+
+// symbolResolver returns the given symbols of the ELF file, or an error if any of them is missing
+type symbolResolver func(elfFile *elf.File, symbolSet common.StringSet) (map[string]elf.Symbol, error)
+
 func addHooks(m *errtelemetry.Manager, probes []manager.ProbesSelector) func(pathIdentifier, string, string) error {
+	return addHooksWithResolver(m, probes, bininspect.GetAllSymbolsByName)
+}
+
+func addHooksWithResolver(m *errtelemetry.Manager, probes []manager.ProbesSelector, resolve symbolResolver) func(pathIdentifier, string, string) error {
 	return func(id pathIdentifier, root string, path string) error {
 		uid := getUID(id)
 
@@ -344,12 +609,12 @@ func addHooks(m *errtelemetry.Manager, probes []manager.ProbesSelector) func(pat
 				}
 			}
 		}
-		symbolMap, err := bininspect.GetAllSymbolsByName(elfFile, symbolsSet)
+		symbolMap, err := resolve(elfFile, symbolsSet)
 		if err != nil {
 			return err
 		}
 		/* Best effort to resolve symbols, so we don't care about the error */
-		symbolMapBestEffort, _ := bininspect.GetAllSymbolsByName(elfFile, symbolsSetBestEffort)
+		symbolMapBestEffort, _ := resolve(elfFile, symbolsSetBestEffort)
 
 		for _, singleProbe := range probes {
 			_, isBestEffort := singleProbe.(*manager.BestEffort)
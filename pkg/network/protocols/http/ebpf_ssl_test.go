@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/go/bininspect"
+	"github.com/DataDog/datadog-agent/pkg/util/common"
+)
+
+func TestSSLLibraryPaths(t *testing.T) {
+	tests := []struct {
+		path    string
+		libSSL  bool
+		pythonS bool
+	}{
+		{path: "/usr/lib/x86_64-linux-gnu/libssl.so.3", libSSL: true},
+		{path: "/venv/lib/python3.11/site-packages/psycopg2_binary.libs/libssl-1a2b3c4d.so.1.1", libSSL: true},
+		{path: "/usr/lib/python3.11/lib-dynload/_ssl.cpython-311-x86_64-linux-gnu.so", pythonS: true},
+		{path: "/usr/lib/python2.7/lib-dynload/_ssl.so", pythonS: true},
+		{path: "/root/.pyenv/versions/3.10.4/lib/libpython3.10.so.1.0", pythonS: true},
+		{path: "/root/.local/share/uv/python/cpython-3.12.1-linux-x86_64-gnu/bin/python3.12", pythonS: true},
+		{path: "/venv/bin/python", pythonS: true},
+		{path: "/usr/bin/python3-config"},
+		{path: "/usr/lib/python3.11/lib-dynload/_hashlib.cpython-311-x86_64-linux-gnu.so"},
+		{path: "/usr/lib/x86_64-linux-gnu/libssl3.so"},
+	}
+
+	for _, test := range tests {
+		assert.Equalf(t, test.libSSL, libSSLLibraries.MatchString(test.path), "libssl %s", test.path)
+		assert.Equalf(t, test.pythonS, pythonSSLBinaries.MatchString(test.path), "python %s", test.path)
+	}
+}
+
+func TestDefinesSymbol(t *testing.T) {
+	libs, err := filepath.Glob("/usr/lib/*/libssl.so.*")
+	require.NoError(t, err)
+	if len(libs) == 0 {
+		t.Skip("libssl not found")
+	}
+
+	elfFile, err := elf.Open(libs[0])
+	require.NoError(t, err)
+	defer elfFile.Close()
+
+	assert.True(t, definesSymbol(elfFile, "SSL_read"))
+	assert.False(t, definesSymbol(elfFile, "BORINGSSL_self_test"))
+}
+
+func TestExportedSymbols(t *testing.T) {
+	libs, err := filepath.Glob("/usr/lib/*/libssl.so.*")
+	require.NoError(t, err)
+	if len(libs) == 0 {
+		t.Skip("libssl not found")
+	}
+
+	elfFile, err := elf.Open(libs[0])
+	require.NoError(t, err)
+	defer elfFile.Close()
+
+	symbols, err := bininspect.GetExportedSymbolsByName(elfFile, common.StringSet{"SSL_read": {}, "SSL_write": {}})
+	require.NoError(t, err)
+	assert.Contains(t, symbols, "SSL_read")
+	assert.Contains(t, symbols, "SSL_write")
+
+	// libssl imports the allocator of libc
+	_, err = bininspect.GetExportedSymbolsByName(elfFile, common.StringSet{"SSL_read": {}, "malloc": {}})
+	assert.Error(t, err)
+}
+
+func TestIsIstioSidecar(t *testing.T) {
+	procRoot := t.TempDir()
+	writeCmdline := func(pid uint32, args ...string) {
+		dir := filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10))
+		require.NoError(t, os.Mkdir(dir, 0755))
+		cmdline := strings.Join(args, "\x00") + "\x00"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644))
+	}
+
+	writeCmdline(1, "/usr/local/bin/envoy", "-c", "etc/istio/proxy/envoy-rev.json", "--drain-time-s", "45")
+	writeCmdline(2, "/usr/local/bin/envoy", "-c", "/etc/envoy/envoy.yaml")
+
+	assert.True(t, isIstioSidecar(procRoot, 1))
+	assert.False(t, isIstioSidecar(procRoot, 2))
+	assert.False(t, isIstioSidecar(procRoot, 3))
+}
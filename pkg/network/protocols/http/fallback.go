@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"golang.org/x/sys/unix"
+
+	manager "github.com/DataDog/ebpf-manager"
+
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	filterpkg "github.com/DataDog/datadog-agent/pkg/network/filter"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	fallbackSocketFilterFunction = "socket__http_fallback_filter"
+	fallbackInFlightMap          = "http_fallback_in_flight"
+	fallbackCompletedMap         = "http_fallback_completed"
+
+	// fallbackPollInterval is the interval at which the completed transactions are read, it must be short enough
+	// for the map of the completed transactions not to fill up between two reads
+	fallbackPollInterval = time.Second
+)
+
+// fallbackMonitor monitors the HTTP traffic in place of the eBPF programs of the Monitor, when they can't be loaded
+type fallbackMonitor interface {
+	start() error
+	// poll processes the transactions completed since the last call
+	poll()
+	stop()
+	DumpMaps(maps ...string) (string, error)
+}
+
+// socketFilterFallback monitors the HTTP traffic on the kernels older than MinimumKernelVersion, with a single socket
+// filter matching the requests and the responses of the plaintext connections. The socket filters of these kernels
+// can't send perf events, so the completed transactions are polled from an eBPF map.
+type socketFilterFallback struct {
+	*manager.Manager
+	cfg *config.Config
+
+	completed     *ebpf.Map
+	mapCleaner    *ddebpf.MapCleaner
+	closeFilterFn func()
+
+	// process is called with each completed transaction, from a single goroutine at a time
+	process func(httpTX)
+	pollMux sync.Mutex
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newSocketFilterFallback(c *config.Config, process func(httpTX)) (*socketFilterFallback, error) {
+	bc, err := netebpf.ReadHTTPFallbackModule(c.BPFDir, c.BPFDebug)
+	if err != nil {
+		return nil, err
+	}
+	defer bc.Close()
+
+	probeID := manager.ProbeIdentificationPair{
+		EBPFFuncName: fallbackSocketFilterFunction,
+		UID:          probeUID,
+	}
+	mgr := &manager.Manager{
+		Maps: []*manager.Map{
+			{Name: fallbackInFlightMap},
+			{Name: fallbackCompletedMap},
+		},
+		Probes: []*manager.Probe{
+			{ProbeIdentificationPair: probeID},
+		},
+	}
+
+	err = mgr.InitWithOptions(bc, manager.Options{
+		RLimit: &unix.Rlimit{
+			Cur: math.MaxUint64,
+			Max: math.MaxUint64,
+		},
+		MapSpecEditors: map[string]manager.MapSpecEditor{
+			fallbackInFlightMap: {
+				Type:       ebpf.Hash,
+				MaxEntries: uint32(c.MaxTrackedConnections),
+				EditorFlag: manager.EditMaxEntries,
+			},
+			fallbackCompletedMap: {
+				Type:       ebpf.Hash,
+				MaxEntries: uint32(c.MaxHTTPStatsBuffered),
+				EditorFlag: manager.EditMaxEntries,
+			},
+		},
+		ActivatedProbes: []manager.ProbesSelector{
+			&manager.ProbeSelector{ProbeIdentificationPair: probeID},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error initializing http fallback ebpf program: %w", err)
+	}
+
+	completed, _, err := mgr.GetMap(fallbackCompletedMap)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving the map of the completed http transactions: %w", err)
+	}
+
+	filter, _ := mgr.GetProbe(probeID)
+	if filter == nil {
+		return nil, fmt.Errorf("error retrieving http fallback socket filter")
+	}
+
+	closeFilterFn, err := filterpkg.HeadlessSocketFilter(c, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error enabling HTTP traffic inspection: %s", err)
+	}
+
+	return &socketFilterFallback{
+		Manager:       mgr,
+		cfg:           c,
+		completed:     completed,
+		closeFilterFn: closeFilterFn,
+		process:       process,
+		done:          make(chan struct{}),
+	}, nil
+}
+
+func (f *socketFilterFallback) start() error {
+	if err := f.Start(); err != nil {
+		return err
+	}
+	f.setupMapCleaner()
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+
+		ticker := time.NewTicker(fallbackPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				f.poll()
+			case <-f.done:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// poll processes the transactions completed since the last call, and removes them from the map
+func (f *socketFilterFallback) poll() {
+	f.pollMux.Lock()
+	defer f.pollMux.Unlock()
+
+	var (
+		key  httpHeadersKey
+		keys []httpHeadersKey
+	)
+	tx := new(ebpfHttpTx)
+	entries := f.completed.Iterate()
+	for entries.Next(unsafe.Pointer(&key), unsafe.Pointer(tx)) {
+		f.process(tx)
+		keys = append(keys, key)
+		tx = new(ebpfHttpTx)
+	}
+	if err := entries.Err(); err != nil {
+		log.Debugf("error iterating the completed http transactions: %s", err)
+	}
+
+	// the entries are removed once iterated, as deleting the current key would restart the iteration
+	for i := range keys {
+		_ = f.completed.Delete(unsafe.Pointer(&keys[i]))
+	}
+}
+
+// setupMapCleaner evicts the requests whose response was never seen
+func (f *socketFilterFallback) setupMapCleaner() {
+	inFlight, _, _ := f.GetMap(fallbackInFlightMap)
+	mapCleaner, err := ddebpf.NewMapCleaner(inFlight, new(netebpf.ConnTuple), new(ebpfHttpTx))
+	if err != nil {
+		log.Errorf("error creating map cleaner: %s", err)
+		return
+	}
+
+	ttl := f.cfg.HTTPIdleConnectionTTL.Nanoseconds()
+	mapCleaner.Clean(f.cfg.HTTPMapCleanerInterval, func(now int64, key, val interface{}) bool {
+		httpTxn, ok := val.(*ebpfHttpTx)
+		if !ok {
+			return false
+		}
+
+		started := int64(httpTxn.RequestStarted())
+		return started > 0 && (now-started) > ttl
+	})
+
+	f.mapCleaner = mapCleaner
+}
+
+func (f *socketFilterFallback) stop() {
+	close(f.done)
+	f.wg.Wait()
+	f.mapCleaner.Stop()
+	_ = f.Stop(manager.CleanAll)
+	f.closeFilterFn()
+}
@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf && go1.18
+// +build linux_bpf,go1.18
+
+package http
+
+import (
+	"bytes"
+	"testing"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+func FuzzPath(f *testing.F) {
+	protocolsUtils.AddCorpus(f, "http")
+
+	f.Fuzz(func(t *testing.T, fragment []byte) {
+		var tx ebpfHttpTx
+		tx.Request_fragment = requestFragment(fragment)
+
+		buffer := make([]byte, HTTPBufferSize)
+		path, _ := tx.Path(buffer)
+		if path == nil {
+			return
+		}
+
+		if len(path) == 0 || (path[0] != '/' && path[0] != '*') {
+			t.Fatalf("path %q does not start with '/' or '*'", path)
+		}
+		if bytes.IndexByte(path, ' ') != -1 || bytes.IndexByte(path, '?') != -1 {
+			t.Fatalf("path %q contains a delimiter", path)
+		}
+		if !bytes.Contains(tx.Request_fragment[:], path) {
+			t.Fatalf("path %q is not part of the request fragment", path)
+		}
+	})
+}
+
+// FuzzProcess feeds raw events, as they would be read from the perf buffer by Monitor.process, to the statkeeper.
+func FuzzProcess(f *testing.F) {
+	protocolsUtils.AddCorpus(f, "http")
+
+	cfg := config.New()
+	cfg.MaxHTTPStatsBuffered = 1000
+	tel, err := newTelemetry()
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sk := newHTTPStatkeeper(cfg, tel)
+
+		event := make([]byte, unsafe.Sizeof(ebpfHttpTx{}))
+		copy(event, data)
+		tx := (*ebpfHttpTx)(unsafe.Pointer(&event[0]))
+		sk.Process(tx)
+
+		for key := range sk.GetAndResetAllStats() {
+			if pathIsMalformed([]byte(key.Path.Content)) {
+				t.Fatalf("malformed path %q was not rejected", key.Path.Content)
+			}
+		}
+	})
+}
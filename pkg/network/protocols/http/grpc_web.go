@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	http2FrameHeaderSize = 9
+	http2HeadersFrame    = 1
+	http2FlagPadded      = 0x8
+	http2FlagPriority    = 0x20
+	// http2PrioritySize is the size of the stream dependency and weight of the HEADERS frames carrying a priority
+	http2PrioritySize = 5
+)
+
+var (
+	http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+	contentTypeHeader = []byte("content-type:")
+	// grpcWebContentType is the prefix of the content types of gRPC-Web, such as application/grpc-web+proto or
+	// application/grpc-web-text
+	grpcWebContentType = "application/grpc-web"
+)
+
+// IsGRPCWeb returns true if the payload holds the headers of a gRPC-Web message, either as an HTTP/1.1 header block
+// or as HTTP/2 HEADERS frames. gRPC-Web is carried over plain HTTP, so it is only told apart by its content type.
+// The payload may be truncated, in which case only the complete headers are inspected.
+func IsGRPCWeb(payload []byte) bool {
+	return isGRPCWebHTTP1(payload) || isGRPCWebHTTP2(payload)
+}
+
+// isGRPCWebHTTP1 looks for the content type of gRPC-Web in the header lines of an HTTP/1.1 message
+func isGRPCWebHTTP1(payload []byte) bool {
+	lines := bytes.Split(payload, []byte("\n"))
+	// the last line is skipped, as it is either empty or truncated
+	for i := 1; i < len(lines)-1; i++ {
+		line := bytes.TrimSuffix(lines[i], []byte("\r"))
+		if len(line) == 0 {
+			// end of the headers
+			return false
+		}
+		if len(line) < len(contentTypeHeader) || !bytes.EqualFold(line[:len(contentTypeHeader)], contentTypeHeader) {
+			continue
+		}
+		return isGRPCWebContentType(string(bytes.TrimSpace(line[len(contentTypeHeader):])))
+	}
+	return false
+}
+
+// isGRPCWebHTTP2 looks for the content type of gRPC-Web in the HEADERS frames of an HTTP/2 segment. The header blocks
+// are decoded without the dynamic table of the connection, so only the headers sent as literals are found, which is
+// always the case of the first request of a connection.
+func isGRPCWebHTTP2(payload []byte) bool {
+	payload = bytes.TrimPrefix(payload, http2Preface)
+	for len(payload) >= http2FrameHeaderSize {
+		length := int(payload[0])<<16 | int(payload[1])<<8 | int(payload[2])
+		frameType, flags := payload[3], payload[4]
+		streamID := binary.BigEndian.Uint32(payload[5:]) & 0x7fffffff
+		payload = payload[http2FrameHeaderSize:]
+
+		frame := payload
+		if length < len(frame) {
+			frame = frame[:length]
+		}
+		payload = payload[len(frame):]
+
+		if frameType != http2HeadersFrame || streamID == 0 {
+			continue
+		}
+		if flags&http2FlagPadded != 0 {
+			if len(frame) == 0 {
+				return false
+			}
+			// the padding is at the end of the frame, after the header block
+			blockLength := length - 1 - int(frame[0])
+			if blockLength < 0 {
+				return false
+			}
+			frame = frame[1:]
+			if blockLength < len(frame) {
+				frame = frame[:blockLength]
+			}
+		}
+		if flags&http2FlagPriority != 0 {
+			if len(frame) < http2PrioritySize {
+				return false
+			}
+			frame = frame[http2PrioritySize:]
+		}
+
+		found := false
+		decoder := hpack.NewDecoder(4096, func(f hpack.HeaderField) {
+			if f.Name == "content-type" && isGRPCWebContentType(f.Value) {
+				found = true
+			}
+		})
+		// errors are expected when the block is truncated or refers to the dynamic table of the connection, in which
+		// case the headers decoded so far are still inspected
+		_, _ = decoder.Write(frame)
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func isGRPCWebContentType(contentType string) bool {
+	return strings.HasPrefix(strings.ToLower(contentType), grpcWebContentType)
+}
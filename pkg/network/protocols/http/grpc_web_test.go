@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2/hpack"
+)
+
+func TestIsGRPCWebHTTP1(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		expected bool
+	}{
+		{name: "proto", payload: "POST /helloworld.Greeter/SayHello HTTP/1.1\r\nHost: example.com\r\nContent-Type: application/grpc-web+proto\r\n\r\n", expected: true},
+		{name: "text lowercase", payload: "POST /helloworld.Greeter/SayHello HTTP/1.1\ncontent-type:application/grpc-web-text\n\n", expected: true},
+		{name: "response", payload: "HTTP/1.1 200 OK\r\nContent-Type: application/grpc-web+proto\r\n\r\n", expected: true},
+		{name: "grpc", payload: "POST /helloworld.Greeter/SayHello HTTP/1.1\r\nContent-Type: application/grpc\r\n\r\n", expected: false},
+		{name: "body", payload: "POST /upload HTTP/1.1\r\nContent-Type: text/plain\r\n\r\nContent-Type: application/grpc-web\r\n", expected: false},
+		{name: "truncated", payload: "POST /helloworld.Greeter/SayHello HTTP/1.1\r\nContent-Type: application/grpc-w", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsGRPCWeb([]byte(tt.payload)))
+		})
+	}
+}
+
+func TestIsGRPCWebHTTP2(t *testing.T) {
+	var block bytes.Buffer
+	encoder := hpack.NewEncoder(&block)
+	for _, f := range []hpack.HeaderField{
+		{Name: ":method", Value: "POST"},
+		{Name: ":path", Value: "/helloworld.Greeter/SayHello"},
+		{Name: "content-type", Value: "application/grpc-web+proto"},
+	} {
+		require.NoError(t, encoder.WriteField(f))
+	}
+
+	frame := func(flags byte, payload []byte) []byte {
+		header := []byte{byte(len(payload) >> 16), byte(len(payload) >> 8), byte(len(payload)), http2HeadersFrame, flags, 0, 0, 0, 1}
+		return append(header, payload...)
+	}
+	settings := []byte{0, 0, 0, 4, 0, 0, 0, 0, 0}
+
+	assert.True(t, IsGRPCWeb(bytes.Join([][]byte{http2Preface, settings, frame(0x4, block.Bytes())}, nil)))
+
+	padded := append([]byte{3}, block.Bytes()...)
+	padded = append(padded, 0, 0, 0)
+	assert.True(t, IsGRPCWeb(frame(0x4|http2FlagPadded, padded)))
+
+	prioritized := append([]byte{0, 0, 0, 0, 16}, block.Bytes()...)
+	assert.True(t, IsGRPCWeb(frame(0x4|http2FlagPriority, prioritized)))
+
+	// the content type is past the end of the truncated frame
+	truncated := frame(0x4, block.Bytes())
+	assert.False(t, IsGRPCWeb(truncated[:len(truncated)-4]))
+
+	block.Reset()
+	require.NoError(t, encoder.WriteField(hpack.HeaderField{Name: "content-type", Value: "application/grpc"}))
+	assert.False(t, IsGRPCWeb(frame(0x4, block.Bytes())))
+}
@@ -0,0 +1,91 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"errors"
+	"unsafe"
+
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// HTTP2Frames holds the frames sent by one of the sides of an HTTP/2 connection, which are relevant to diagnose its
+// performance
+type HTTP2Frames struct {
+	// DataBytes is the length of the DATA frames, which is subject to flow control
+	DataBytes uint64
+	// WindowUpdateIncrement is the sum of the increments of the connection flow-control window granted to the other
+	// side
+	WindowUpdateIncrement uint64
+	WindowUpdateFrames    uint32
+	// WindowExhaustions is the number of DATA frames which exhausted the connection flow-control window granted by the
+	// other side, after which the side had to wait for a WINDOW_UPDATE frame to send more data
+	WindowExhaustions uint32
+	PushPromiseFrames uint32
+	// PriorityFrames is the number of PRIORITY frames, along with the HEADERS frames carrying a priority
+	PriorityFrames  uint32
+	SettingsFrames  uint32
+	RSTStreamFrames uint32
+	GoAwayFrames    uint32
+}
+
+// HTTP2Connection holds the frames sent by the client and the server of an active HTTP/2 connection
+type HTTP2Connection struct {
+	Client       util.Address
+	ClientPort   uint16
+	Server       util.Address
+	ServerPort   uint16
+	ClientFrames HTTP2Frames
+	ServerFrames HTTP2Frames
+	// StaticTags holds the tags of the TLS libraries through which the frames were read or written, such as OpenSSL
+	StaticTags uint64
+}
+
+// GetHTTP2Connections returns the frames of the active HTTP/2 connections
+func (m *Monitor) GetHTTP2Connections() ([]HTTP2Connection, error) {
+	if m != nil {
+		if f, ok := m.fallback.(*packetSourceFallback); ok {
+			return f.http2Connections(), nil
+		}
+	}
+	if m == nil || m.http2FrameStats == nil {
+		return nil, errors.New("http2 monitoring is not enabled")
+	}
+
+	var connections []HTTP2Connection
+	key, stats := &netebpf.ConnTuple{}, &http2FrameStats{}
+	entries := m.http2FrameStats.Iterate()
+	for entries.Next(unsafe.Pointer(key), unsafe.Pointer(stats)) {
+		connections = append(connections, HTTP2Connection{
+			Client:       key.SourceAddress(),
+			ClientPort:   key.Sport,
+			Server:       key.DestAddress(),
+			ServerPort:   key.Dport,
+			ClientFrames: newHTTP2Frames(stats.Client),
+			ServerFrames: newHTTP2Frames(stats.Server),
+			StaticTags:   stats.Tags,
+		})
+	}
+	return connections, entries.Err()
+}
+
+func newHTTP2Frames(counters http2FrameCounters) HTTP2Frames {
+	return HTTP2Frames{
+		DataBytes:             counters.Data_bytes,
+		WindowUpdateIncrement: counters.Window_update_increment,
+		WindowUpdateFrames:    counters.Window_update_frames,
+		WindowExhaustions:     counters.Window_exhaustions,
+		PushPromiseFrames:     counters.Push_promise_frames,
+		PriorityFrames:        counters.Priority_frames,
+		SettingsFrames:        counters.Settings_frames,
+		RSTStreamFrames:       counters.Rst_stream_frames,
+		GoAwayFrames:          counters.Goaway_frames,
+	}
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"bytes"
+	"strings"
+)
+
+const (
+	// MaxHeaderValues is the maximum number of distinct values kept for each captured header of an endpoint. Once it
+	// is reached, the other values of the header are ignored.
+	MaxHeaderValues = 8
+
+	// maxHeaderValueLength is the maximum length of the captured header values, which are truncated beyond it
+	maxHeaderValueLength = 128
+)
+
+var crlf = []byte("\r\n")
+
+// extractHeaders returns the values of the allowlisted headers found in the beginning of a request, starting with its
+// request line. The header names of the allowlist must be lowercase, and are the keys of the returned map. Only the
+// first value of a header is returned, and the headers truncated by the end of the captured request are ignored.
+func extractHeaders(request []byte, allowlist []string) map[string]string {
+	if len(request) == 0 || len(allowlist) == 0 {
+		return nil
+	}
+
+	// skip the request line
+	i := bytes.Index(request, crlf)
+	if i == -1 {
+		return nil
+	}
+	request = request[i+len(crlf):]
+
+	var headers map[string]string
+	for {
+		i = bytes.Index(request, crlf)
+		if i <= 0 {
+			// either the end of the headers, or the end of the captured request
+			return headers
+		}
+		line := request[:i]
+		request = request[i+len(crlf):]
+
+		colon := bytes.IndexByte(line, ':')
+		if colon <= 0 {
+			continue
+		}
+		name := string(bytes.TrimSpace(line[:colon]))
+		for _, allowed := range allowlist {
+			if !strings.EqualFold(name, allowed) {
+				continue
+			}
+			if _, ok := headers[allowed]; ok {
+				break
+			}
+			value := bytes.TrimSpace(line[colon+1:])
+			if len(value) > maxHeaderValueLength {
+				value = value[:maxHeaderValueLength]
+			}
+			if headers == nil {
+				headers = make(map[string]string, len(allowlist))
+			}
+			headers[allowed] = string(value)
+			break
+		}
+	}
+}
+
+// AddHeaders adds the values of the captured headers of a HTTP transaction to the request stats
+func (r *RequestStats) AddHeaders(statusCode int, headers map[string]string) {
+	if !r.isValid(statusCode) || len(headers) == 0 {
+		return
+	}
+	stats := r.stat(statusCode)
+	for name, value := range headers {
+		stats.addHeaderValue(name, value)
+	}
+}
+
+func (r *RequestStat) addHeaderValue(name, value string) {
+	values := r.Headers[name]
+	if len(values) >= MaxHeaderValues {
+		return
+	}
+	for _, v := range values {
+		if v == value {
+			return
+		}
+	}
+	if r.Headers == nil {
+		r.Headers = make(map[string][]string)
+	}
+	r.Headers[name] = append(values, value)
+}
+
+func (r *RequestStat) combineHeaders(newStats *RequestStat) {
+	for name, values := range newStats.Headers {
+		for _, value := range values {
+			r.addHeaderValue(name, value)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractHeaders(t *testing.T) {
+	allowlist := []string{"x-request-id", "user-agent", "content-type"}
+
+	tests := []struct {
+		name     string
+		request  string
+		expected map[string]string
+	}{
+		{
+			name:     "allowlisted headers",
+			request:  "GET /foo HTTP/1.1\r\nHost: example.com\r\nUser-Agent: curl/7.81.0\r\nX-Request-ID:  abc-123 \r\n\r\n",
+			expected: map[string]string{"user-agent": "curl/7.81.0", "x-request-id": "abc-123"},
+		},
+		{
+			name:     "first value wins",
+			request:  "POST /foo HTTP/1.1\r\nContent-Type: application/json\r\ncontent-type: text/plain\r\n\r\n",
+			expected: map[string]string{"content-type": "application/json"},
+		},
+		{
+			name:     "truncated header",
+			request:  "GET /foo HTTP/1.1\r\nUser-Agent: curl/7.81.0\r\nX-Request-Id: abc",
+			expected: map[string]string{"user-agent": "curl/7.81.0"},
+		},
+		{
+			name:     "body is ignored",
+			request:  "POST /foo HTTP/1.1\r\nHost: example.com\r\n\r\nuser-agent: body\r\n",
+			expected: nil,
+		},
+		{
+			name:     "truncated request line",
+			request:  "GET /foo",
+			expected: nil,
+		},
+		{
+			name:     "malformed header",
+			request:  "GET /foo HTTP/1.1\r\n: empty\r\nuser-agent\r\nUser-Agent: curl\r\n\r\n",
+			expected: map[string]string{"user-agent": "curl"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, extractHeaders([]byte(tt.request), allowlist))
+		})
+	}
+
+	t.Run("long value", func(t *testing.T) {
+		value := strings.Repeat("a", 2*maxHeaderValueLength)
+		headers := extractHeaders([]byte("GET / HTTP/1.1\r\nUser-Agent: "+value+"\r\n\r\n"), allowlist)
+		assert.Equal(t, value[:maxHeaderValueLength], headers["user-agent"])
+	})
+
+	t.Run("empty allowlist", func(t *testing.T) {
+		assert.Nil(t, extractHeaders([]byte("GET / HTTP/1.1\r\nUser-Agent: curl\r\n\r\n"), nil))
+	})
+}
+
+func TestAddHeaders(t *testing.T) {
+	stats := NewRequestStats(false)
+	for i := 0; i < 2*MaxHeaderValues; i++ {
+		stats.AddRequest(200, 10.0, 0, 0, nil)
+		stats.AddHeaders(200, map[string]string{
+			"user-agent":   "agent-" + strconv.Itoa(i),
+			"content-type": "application/json",
+		})
+	}
+
+	s := stats.Stats(200)
+	assert.Len(t, s.Headers["user-agent"], MaxHeaderValues)
+	assert.Equal(t, []string{"application/json"}, s.Headers["content-type"])
+
+	// invalid status codes are ignored
+	stats.AddHeaders(0, map[string]string{"user-agent": "other"})
+	assert.Nil(t, stats.Stats(0))
+}
+
+func TestCombineHeaders(t *testing.T) {
+	stats := NewRequestStats(false)
+	stats.AddRequest(200, 10.0, 0, 0, nil)
+	stats.AddHeaders(200, map[string]string{"user-agent": "curl"})
+
+	single := NewRequestStats(false)
+	single.AddRequest(200, 15.0, 0, 0, nil)
+	single.AddHeaders(200, map[string]string{"user-agent": "wget"})
+
+	multiple := NewRequestStats(false)
+	multiple.AddRequest(200, 15.0, 0, 0, nil)
+	multiple.AddRequest(200, 20.0, 0, 0, nil)
+	multiple.AddHeaders(200, map[string]string{"user-agent": "curl", "x-request-id": "abc"})
+
+	stats.CombineWith(single)
+	stats.CombineWith(multiple)
+
+	s := stats.Stats(200)
+	assert.Equal(t, 4, s.Count)
+	assert.Equal(t, []string{"curl", "wget"}, s.Headers["user-agent"])
+	assert.Equal(t, []string{"abc"}, s.Headers["x-request-id"])
+
+	// the combined stats are not modified
+	assert.Equal(t, []string{"wget"}, single.Stats(200).Headers["user-agent"])
+}
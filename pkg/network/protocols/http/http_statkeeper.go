@@ -13,6 +13,8 @@ import (
 	"sync"
 	"time"
 
+	"go.uber.org/atomic"
+
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -36,6 +38,31 @@ type httpStatKeeper struct {
 	interned map[string]string
 
 	oversizedLogLimit *util.LogLimit
+
+	// bodyParsingDisabled aggregates the transactions without parsing their request past its request line, such as
+	// to capture its headers, to reduce the CPU usage
+	bodyParsingDisabled *atomic.Bool
+
+	// sample returns the number of transactions a transaction stands for when they are sampled, or 0 if it is sampled
+	// out. It is nil when the transactions are not sampled.
+	sample func() int
+
+	// aggregateByStatusCode aggregates the transactions by exact status code rather than by status class
+	aggregateByStatusCode bool
+
+	// aggregateByMethod aggregates the transactions by request method, otherwise the transactions of all the methods
+	// of a path are aggregated under MethodUnknown
+	aggregateByMethod bool
+
+	// quantizer collapses the high-cardinality paths into the endpoints they belong to, it is nil when the
+	// quantization of the paths is disabled
+	quantizer *pathQuantizer
+
+	// captureHeaders is the allowlist of the request headers whose values are captured, and requestHeaders returns
+	// the beginning of the request of a transaction, from which they are extracted. requestHeaders is nil when the
+	// capture of the headers is disabled.
+	captureHeaders []string
+	requestHeaders func(tx httpTX) []byte
 }
 
 func newHTTPStatkeeper(c *config.Config, telemetry *telemetry) *httpStatKeeper {
@@ -49,6 +76,12 @@ func newHTTPStatkeeper(c *config.Config, telemetry *telemetry) *httpStatKeeper {
 		interned:          make(map[string]string),
 		telemetry:         telemetry,
 		oversizedLogLimit: util.NewLogLimit(10, time.Minute*10),
+
+		bodyParsingDisabled:   atomic.NewBool(false),
+		aggregateByStatusCode: c.EnableHTTPStatsByStatusCode,
+		aggregateByMethod:     c.EnableHTTPStatsByMethod,
+		quantizer:             newPathQuantizer(c),
+		captureHeaders:        c.HTTPCaptureHeaders,
 	}
 }
 
@@ -72,13 +105,57 @@ func (h *httpStatKeeper) GetAndResetAllStats() map[Key]*RequestStats {
 		h.add(tx)
 	}
 
+	if h.quantizer != nil {
+		h.quantizeStats()
+	}
+
 	ret := h.stats // No deep copy needed since `h.stats` gets reset
 	h.stats = make(map[Key]*RequestStats)
 	h.interned = make(map[string]string)
 	return ret
 }
 
+// quantizeStats merges the stats of the paths collapsed by the quantizer since they were aggregated, as the variable
+// positions of the paths may be learnt after their first transactions
+func (h *httpStatKeeper) quantizeStats() {
+	for key, stats := range h.stats {
+		path := h.quantizer.quantize(key.Path.Content)
+		if path == key.Path.Content {
+			continue
+		}
+
+		delete(h.stats, key)
+		key.Path.Content = path
+		if existing, ok := h.stats[key]; ok {
+			existing.CombineWith(stats)
+			continue
+		}
+		h.stats[key] = stats
+	}
+}
+
+// setBodyParsing enables or disables the parsing of the requests of the transactions past their request line
+func (h *httpStatKeeper) setBodyParsing(enabled bool) {
+	h.bodyParsingDisabled.Store(!enabled)
+}
+
 func (h *httpStatKeeper) add(tx httpTX) {
+	// the headers are fetched first, so that they are released even if the transaction is rejected
+	var headers map[string]string
+	if h.requestHeaders != nil {
+		requestHeaders := h.requestHeaders(tx)
+		if !h.bodyParsingDisabled.Load() {
+			headers = extractHeaders(requestHeaders, h.captureHeaders)
+		}
+	}
+
+	weight := 1
+	if h.sample != nil {
+		if weight = h.sample(); weight == 0 {
+			return
+		}
+	}
+
 	rawPath, fullPath := tx.Path(h.buffer)
 	if rawPath == nil {
 		h.telemetry.malformed.Add(1)
@@ -88,6 +165,13 @@ func (h *httpStatKeeper) add(tx httpTX) {
 	if rejected {
 		return
 	}
+	if !fullPath {
+		h.telemetry.truncated.Add(1)
+	}
+	if h.quantizer != nil {
+		h.quantizer.learn(path)
+		path = h.quantizer.quantize(path)
+	}
 
 	if tx.Method() == MethodUnknown {
 		h.telemetry.malformed.Add(1)
@@ -114,21 +198,30 @@ func (h *httpStatKeeper) add(tx httpTX) {
 			return
 		}
 		h.telemetry.aggregations.Add(1)
-		stats = new(RequestStats)
+		stats = NewRequestStats(h.aggregateByStatusCode)
 		h.stats[key] = stats
 	}
 
-	stats.AddRequest(tx.StatusClass(), latency, tx.StaticTags(), tx.DynamicTags())
+	statusCode := int(tx.StatusCode())
+	stats.addSampledRequest(statusCode, weight, latency, tx.FirstByteLatency(), tx.StaticTags(), tx.DynamicTags())
+	stats.addSampledBytes(statusCode, weight, uint64(tx.RequestSize()), uint64(tx.ResponseSize()))
+	stats.AddHeaders(statusCode, headers)
 }
 
 func (h *httpStatKeeper) newKey(tx httpTX, path string, fullPath bool) Key {
+	method := tx.Method()
+	if !h.aggregateByMethod {
+		method = MethodUnknown
+	}
+
 	return Key{
 		KeyTuple: tx.ConnTuple(),
 		Path: Path{
 			Content:  path,
 			FullPath: fullPath,
 		},
-		Method: tx.Method(),
+		Method:    method,
+		Direction: tx.Direction(),
 	}
 }
 
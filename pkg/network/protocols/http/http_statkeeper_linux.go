@@ -9,9 +9,58 @@
 package http
 
 import (
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 )
 
+// maxMethodPrefixSize is the size of the longest method of the request line preceding the path, along with its space
+const maxMethodPrefixSize = len("OPTIONS ")
+
+// getPathBufferSize returns the maximum size of the paths, which is bounded by the size of the request fragment
 func getPathBufferSize(c *config.Config) int {
+	if c.HTTPMaxPathSize > 0 && c.HTTPMaxPathSize < HTTPBufferSize {
+		return c.HTTPMaxPathSize
+	}
 	return int(HTTPBufferSize)
 }
+
+// getMaxFragmentSize returns the number of bytes of the request fragment to read in eBPF to capture the paths up to
+// their maximum size, or 0 if the whole fragment is needed
+func getMaxFragmentSize(c *config.Config) uint64 {
+	size := getPathBufferSize(c) + maxMethodPrefixSize
+	if size >= HTTPBufferSize {
+		return 0
+	}
+	return uint64(size)
+}
+
+// newRequestHeadersReader returns a function reading the beginning of the request of a transaction from the map of
+// the requests captured by eBPF for their headers, starting with the request line. The entry of the request is
+// removed once read. The returned slice is only valid until the next call.
+func newRequestHeadersReader(requestHeaders *ebpf.Map) func(tx httpTX) []byte {
+	var headers httpHeaders
+	return func(tx httpTX) []byte {
+		ebpfTx, ok := tx.(*ebpfHttpTx)
+		if !ok || ebpfTx.Request_started == 0 {
+			return nil
+		}
+
+		key := httpHeadersKey{
+			Tup:             ebpfTx.Tup,
+			Request_started: ebpfTx.Request_started,
+		}
+		if err := requestHeaders.Lookup(unsafe.Pointer(&key), unsafe.Pointer(&headers)); err != nil {
+			return nil
+		}
+		_ = requestHeaders.Delete(unsafe.Pointer(&key))
+
+		n := int(headers.Len)
+		if n > len(headers.Buffer) {
+			n = len(headers.Buffer)
+		}
+		return headers.Buffer[:n]
+	}
+}
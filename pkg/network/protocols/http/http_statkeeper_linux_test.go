@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestMaxPathSize(t *testing.T) {
+	cfg := config.New()
+	assert.Equal(t, HTTPBufferSize, getPathBufferSize(cfg))
+	assert.Zero(t, getMaxFragmentSize(cfg))
+
+	cfg.HTTPMaxPathSize = 64
+	assert.Equal(t, 64, getPathBufferSize(cfg))
+	assert.Equal(t, uint64(64+maxMethodPrefixSize), getMaxFragmentSize(cfg))
+
+	cfg.HTTPMaxPathSize = HTTPBufferSize - 1
+	assert.Equal(t, HTTPBufferSize-1, getPathBufferSize(cfg))
+	assert.Zero(t, getMaxFragmentSize(cfg))
+
+	cfg.HTTPMaxPathSize = 2 * HTTPBufferSize
+	assert.Equal(t, HTTPBufferSize, getPathBufferSize(cfg))
+	assert.Zero(t, getMaxFragmentSize(cfg))
+}
+
+func TestProcessTruncatedPaths(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxHTTPStatsBuffered = 1000
+	cfg.HTTPMaxPathSize = 8
+	tel, err := newTelemetry()
+	require.NoError(t, err)
+	sk := newHTTPStatkeeper(cfg, tel)
+
+	sourceIP := util.AddressFromString("1.1.1.1")
+	destIP := util.AddressFromString("2.2.2.2")
+	sk.Process(generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/foo", 200, time.Millisecond))
+	sk.Process(generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/foo/bar/baz", 200, time.Millisecond))
+	sk.Process(generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/foo/bar/qux", 200, time.Millisecond))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+	for key, stats := range stats {
+		switch key.Path.Content {
+		case "/foo":
+			assert.True(t, key.Path.FullPath)
+			assert.Equal(t, 1, stats.Stats(200).Count)
+		case "/foo/bar":
+			assert.False(t, key.Path.FullPath)
+			assert.Equal(t, 2, stats.Stats(200).Count)
+		default:
+			t.Errorf("unexpected path %q", key.Path.Content)
+		}
+	}
+	assert.Equal(t, int64(2), tel.truncated.Get())
+}
@@ -69,6 +69,174 @@ func TestProcessHTTPTransactions(t *testing.T) {
 	}
 }
 
+func TestProcessHTTPTransactionsByStatusCode(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxHTTPStatsBuffered = 1000
+	cfg.EnableHTTPStatsByStatusCode = true
+	tel, err := newTelemetry()
+	require.NoError(t, err)
+	sk := newHTTPStatkeeper(cfg, tel)
+
+	sourceIP := util.AddressFromString("1.1.1.1")
+	destIP := util.AddressFromString("2.2.2.2")
+	for _, statusCode := range []int{400, 404, 404, 429, 500} {
+		tx := generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/testpath", statusCode, time.Millisecond)
+		sk.Process(tx)
+	}
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	for _, stats := range stats {
+		assert.True(t, stats.AggregatedByStatusCode())
+		assert.Equal(t, []uint16{400, 404, 429, 500}, stats.StatusCodes())
+		assert.Equal(t, 2, stats.Stats(404).Count)
+		assert.Equal(t, 1, stats.Stats(429).Count)
+	}
+}
+
+func TestProcessHTTPTransactionsByMethod(t *testing.T) {
+	sourceIP := util.AddressFromString("1.1.1.1")
+	destIP := util.AddressFromString("2.2.2.2")
+	process := func(sk *httpStatKeeper) map[Key]*RequestStats {
+		for _, method := range []Method{MethodGet, MethodGet, MethodPost, MethodDelete} {
+			tx := generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/testpath", 500, time.Millisecond)
+			tx.SetRequestMethod(method)
+			sk.Process(tx)
+		}
+		return sk.GetAndResetAllStats()
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		cfg := config.New()
+		cfg.MaxHTTPStatsBuffered = 1000
+		tel, err := newTelemetry()
+		require.NoError(t, err)
+
+		stats := process(newHTTPStatkeeper(cfg, tel))
+		require.Len(t, stats, 3)
+		counts := make(map[Method]int)
+		for key, stats := range stats {
+			counts[key.Method] = stats.Stats(500).Count
+		}
+		assert.Equal(t, map[Method]int{MethodGet: 2, MethodPost: 1, MethodDelete: 1}, counts)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		cfg := config.New()
+		cfg.MaxHTTPStatsBuffered = 1000
+		cfg.EnableHTTPStatsByMethod = false
+		tel, err := newTelemetry()
+		require.NoError(t, err)
+
+		stats := process(newHTTPStatkeeper(cfg, tel))
+		require.Len(t, stats, 1)
+		for key, stats := range stats {
+			assert.Equal(t, MethodUnknown, key.Method)
+			assert.Equal(t, 4, stats.Stats(500).Count)
+		}
+	})
+}
+
+func TestProcessHTTPTransactionsWithoutBodyParsing(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxHTTPStatsBuffered = 1000
+	cfg.HTTPCaptureHeaders = []string{"user-agent"}
+	tel, err := newTelemetry()
+	require.NoError(t, err)
+	sk := newHTTPStatkeeper(cfg, tel)
+	sk.setBodyParsing(false)
+
+	var read int
+	sk.requestHeaders = func(tx httpTX) []byte {
+		read++
+		return []byte("GET /testpath HTTP/1.1\r\nUser-Agent: agent\r\n\r\n")
+	}
+
+	sourceIP := util.AddressFromString("1.1.1.1")
+	destIP := util.AddressFromString("2.2.2.2")
+	for i := 0; i < 10; i++ {
+		tx := generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/testpath"+strconv.Itoa(i%2), 200, time.Millisecond)
+		sk.Process(tx)
+	}
+	// the headers are still read, so that they are released
+	assert.Equal(t, 10, read)
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+	for key, stats := range stats {
+		assert.Contains(t, []string{"/testpath0", "/testpath1"}, key.Path.Content)
+		assert.Equal(t, 5, stats.Stats(200).Count)
+		assert.Empty(t, stats.Stats(200).Headers)
+	}
+}
+
+func TestProcessSampledHTTPTransactions(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxHTTPStatsBuffered = 1000
+	tel, err := newTelemetry()
+	require.NoError(t, err)
+	sk := newHTTPStatkeeper(cfg, tel)
+
+	// one transaction out of 4 is kept, and stands for the 4 of them
+	var sampled int
+	sk.sample = func() int {
+		sampled++
+		if sampled%4 == 0 {
+			return 4
+		}
+		return 0
+	}
+
+	sourceIP := util.AddressFromString("1.1.1.1")
+	destIP := util.AddressFromString("2.2.2.2")
+	for i := 0; i < 20; i++ {
+		tx := generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/testpath", 200, time.Millisecond)
+		sk.Process(tx)
+	}
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	for _, stats := range stats {
+		s := stats.Stats(200)
+		assert.Equal(t, 20, s.Count)
+		require.NotNil(t, s.Latencies)
+		assert.Equal(t, float64(20), s.Latencies.GetCount())
+	}
+}
+
+func TestProcessHTTPTransactionsWithHeaders(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxHTTPStatsBuffered = 1000
+	cfg.HTTPCaptureHeaders = []string{"user-agent"}
+	tel, err := newTelemetry()
+	require.NoError(t, err)
+	sk := newHTTPStatkeeper(cfg, tel)
+
+	var read int
+	sk.requestHeaders = func(tx httpTX) []byte {
+		read++
+		return []byte("GET /testpath HTTP/1.1\r\nUser-Agent: agent-" + strconv.Itoa(read%2) + "\r\n\r\n")
+	}
+
+	sourceIP := util.AddressFromString("1.1.1.1")
+	destIP := util.AddressFromString("2.2.2.2")
+	for i := 0; i < 10; i++ {
+		tx := generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/testpath", 200, time.Millisecond)
+		sk.Process(tx)
+	}
+	// the headers of the rejected transactions are read as well, so that they are released
+	sk.Process(generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/testpath", 200, 0))
+	assert.Equal(t, 11, read)
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	for _, stats := range stats {
+		s := stats.Stats(200)
+		assert.Equal(t, 10, s.Count)
+		assert.ElementsMatch(t, []string{"agent-0", "agent-1"}, s.Headers["user-agent"])
+	}
+}
+
 func BenchmarkProcessSameConn(b *testing.B) {
 	cfg := &config.Config{MaxHTTPStatsBuffered: 1000}
 	tel, err := newTelemetry()
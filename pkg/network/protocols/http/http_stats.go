@@ -6,6 +6,8 @@
 package http
 
 import (
+	"sort"
+
 	"github.com/DataDog/sketches-go/ddsketch"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
@@ -61,6 +63,33 @@ func (m Method) String() string {
 	}
 }
 
+// Direction is the side of the local process in a group of HTTP transactions
+type Direction uint8
+
+const (
+	// DirectionUnknown represents the transactions seen from the network, which are shared by both ends of the local
+	// connections. This includes the plain HTTP transactions of the loopback connections: the socket filter sees a
+	// single copy of their traffic, which is the same for the client and the server, so there is no separate view of
+	// either end to tell apart
+	DirectionUnknown Direction = iota
+	// DirectionClient represents the transactions seen by their client, such as the responses it received
+	DirectionClient
+	// DirectionServer represents the transactions seen by their server, such as the responses it served
+	DirectionServer
+)
+
+// String returns a string representing the side of the local process
+func (d Direction) String() string {
+	switch d {
+	case DirectionClient:
+		return "client"
+	case DirectionServer:
+		return "server"
+	default:
+		return "unknown"
+	}
+}
+
 // Path represents the HTTP path
 type Path struct {
 	Content  string
@@ -80,12 +109,45 @@ type KeyTuple struct {
 	DstPort uint16
 }
 
+// ServiceTuple represents the address and port of a service whose connections are translated to its backends by
+// DNAT, such as a Kubernetes Service ClusterIP
+type ServiceTuple struct {
+	IPHigh uint64
+	IPLow  uint64
+	Port   uint16
+}
+
+// NewServiceTuple generates a new ServiceTuple
+func NewServiceTuple(addr util.Address, port uint16) ServiceTuple {
+	low, high := util.ToLowHigh(addr)
+	return ServiceTuple{
+		IPHigh: high,
+		IPLow:  low,
+		Port:   port,
+	}
+}
+
+// IsZero returns true if the ServiceTuple is empty
+func (s ServiceTuple) IsZero() bool {
+	return s == ServiceTuple{}
+}
+
 // Key is an identifier for a group of HTTP transactions
 type Key struct {
 	// this field order is intentional to help the GC pointer tracking
 	Path Path
+	// ContainerID is the container of the process the transactions were seen on, and is empty if the process is not
+	// containerized or if its connection is unknown
+	ContainerID string
 	KeyTuple
 	Method Method
+	// Direction tells apart the transactions of a local connection seen by its client from the ones seen by its server,
+	// and is DirectionUnknown when they are seen once for both ends
+	Direction Direction
+
+	// Service is the original destination of the transactions when the client connection was translated to the
+	// server by DNAT, and is empty otherwise. This allows aggregating the stats of all the backends of a service.
+	Service ServiceTuple
 }
 
 // NewKey generates a new Key
@@ -117,10 +179,24 @@ func NewKeyTuple(saddr, daddr util.Address, sport, dport uint16) KeyTuple {
 // NumStatusClasses represents the number of HTTP status classes (1XX, 2XX, 3XX, 4XX, 5XX)
 const NumStatusClasses = 5
 
+// MaxStatusCodesPerEndpoint is the maximum number of distinct status codes tracked for an endpoint when aggregating
+// by status code. Once it is reached, the transactions with other status codes are accounted for under their status
+// class, eg. an unseen 4XX code is accounted for as 400.
+const MaxStatusCodesPerEndpoint = 16
+
 // RequestStats stores stats for HTTP requests to a particular path, organized by the class
-// of the response code (1XX, 2XX, 3XX, 4XX, 5XX)
+// of the response code (1XX, 2XX, 3XX, 4XX, 5XX), or by the exact response code if aggregateByStatusCode is set
 type RequestStats struct {
-	data [NumStatusClasses]*RequestStat
+	aggregateByStatusCode bool
+	data                  map[uint16]*RequestStat
+}
+
+// NewRequestStats creates a new RequestStats object, aggregating the stats by exact status code rather than by
+// status class if aggregateByStatusCode is set
+func NewRequestStats(aggregateByStatusCode bool) *RequestStats {
+	return &RequestStats{
+		aggregateByStatusCode: aggregateByStatusCode,
+	}
 }
 
 // RequestStat stores stats for HTTP requests to a particular path
@@ -138,130 +214,374 @@ type RequestStat struct {
 	// keep-alives where a short-lived TCP connection is used for a single request.
 	FirstLatencySample float64
 
+	// FirstByteLatencies holds the time elapsed between the beginning of the requests and the beginning of their
+	// response, which allows separating the processing time of the server from the time spent transferring the
+	// response. Only the transactions with a known time to first byte are accounted for in FirstByteCount.
+	// Similarly to Latencies, the sketch is only created once a second sample is added.
+	FirstByteLatencies     *ddsketch.DDSketch
+	FirstByteCount         int
+	FirstByteLatencySample float64
+
+	// RequestBytes and ResponseBytes hold the total size of the requests and of the responses, headers included,
+	// which allows computing the bandwidth used by an endpoint. They are 0 when the sizes are unknown.
+	RequestBytes  uint64
+	ResponseBytes uint64
+
+	// RequestSizes and ResponseSizes hold the distribution of the sizes of the requests and of the responses, which
+	// allows finding the endpoints serving large payloads even when most of their transactions are small. The sizes
+	// of the requests and of the responses are known independently, and only the known ones are accounted for in
+	// RequestSizeCount and ResponseSizeCount. Similarly to Latencies, the sketches are only created once a second
+	// sample is added.
+	RequestSizes            *ddsketch.DDSketch
+	RequestSizeCount        int
+	FirstRequestSizeSample  float64
+	ResponseSizes           *ddsketch.DDSketch
+	ResponseSizeCount       int
+	FirstResponseSizeSample float64
+
 	// Tags bitfields from tags-types.h
 	StaticTags uint64
 
 	// Dynamic tags (if attached)
 	DynamicTags []string
-}
 
-func (r *RequestStats) idx(status int) int {
-	return status/100 - 1
+	// Headers holds the distinct values of the allowlisted request headers, by lowercase header name, up to
+	// MaxHeaderValues values per header. It is nil when the capture of the headers is disabled.
+	Headers map[string][]string
 }
 
 func (r *RequestStats) isValid(status int) bool {
-	i := r.idx(status)
-	return i >= 0 && i < len(r.data)
+	return status >= 100 && status < 600
 }
 
-func (r *RequestStats) init(status int) {
-	r.data[r.idx(status)] = new(RequestStat)
+// normalizeStatusCode returns the key of the stats of the status
+func (r *RequestStats) normalizeStatusCode(status int) uint16 {
+	if r.aggregateByStatusCode {
+		return uint16(status)
+	}
+	return uint16(status / 100 * 100)
+}
+
+// stat returns the RequestStat object for the status, creating it if needed
+func (r *RequestStats) stat(status int) *RequestStat {
+	key := r.normalizeStatusCode(status)
+	if stats, ok := r.data[key]; ok {
+		return stats
+	}
+
+	if r.aggregateByStatusCode && len(r.data) >= MaxStatusCodesPerEndpoint {
+		// cardinality guard: fall back on the status class
+		key = uint16(status / 100 * 100)
+		if stats, ok := r.data[key]; ok {
+			return stats
+		}
+	}
+
+	if r.data == nil {
+		r.data = make(map[uint16]*RequestStat)
+	}
+	stats := new(RequestStat)
+	r.data[key] = stats
+	return stats
+}
+
+// AggregatedByStatusCode returns true if the stats are aggregated by exact status code rather than by status class
+func (r *RequestStats) AggregatedByStatusCode() bool {
+	return r.aggregateByStatusCode
 }
 
 // Stats returns the RequestStat object for the provided status.
 // If no stats exist, or the status code is invalid, it will return nil.
 func (r *RequestStats) Stats(status int) *RequestStat {
-	i := r.idx(status)
-	if i < 0 || i >= len(r.data) {
+	if !r.isValid(status) {
 		return nil
 	}
-	return r.data[i]
+	return r.data[r.normalizeStatusCode(status)]
 }
 
-// HasStats returns true if there is data for that status class
+// HasStats returns true if there is data for that status class, or status code when aggregating by status code
 func (r *RequestStats) HasStats(status int) bool {
-	i := r.idx(status)
-	if i < 0 || i >= len(r.data) {
-		return false
+	stats := r.Stats(status)
+	return stats != nil && stats.Count > 0
+}
+
+// StatusCodes returns the sorted status codes, or status classes, for which there is data
+func (r *RequestStats) StatusCodes() []uint16 {
+	codes := make([]uint16, 0, len(r.data))
+	for code, stats := range r.data {
+		if stats.Count > 0 {
+			codes = append(codes, code)
+		}
 	}
-	return r.data[i] != nil && r.data[i].Count > 0
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i] < codes[j]
+	})
+	return codes
 }
 
 // CombineWith merges the data in 2 RequestStats objects
 // newStats is kept as it is, while the method receiver gets mutated
 func (r *RequestStats) CombineWith(newStats *RequestStats) {
-	for statusClass := 100; statusClass <= 500; statusClass += 100 {
-		if !newStats.HasStats(statusClass) {
+	for status, newStatsData := range newStats.data {
+		if newStatsData.Count == 0 {
 			// Nothing to do in this case
 			continue
 		}
+		r.stat(int(status)).combineWith(newStatsData)
+	}
+}
 
-		newStatsData := newStats.Stats(statusClass)
-		if newStatsData.Count == 1 {
-			// The other bucket has a single latency sample, so we "manually" add it
-			r.AddRequest(statusClass, newStatsData.FirstLatencySample, newStatsData.StaticTags, newStatsData.DynamicTags)
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStats) Clone() *RequestStats {
+	clone := NewRequestStats(r.aggregateByStatusCode)
+	for status, stats := range r.data {
+		if stats.Count == 0 {
 			continue
 		}
-
-		stats := r.Stats(statusClass)
-		if stats == nil {
-			r.init(statusClass)
-			stats = r.Stats(statusClass)
+		if clone.data == nil {
+			clone.data = make(map[uint16]*RequestStat, len(r.data))
 		}
+		// the stats are copied as they are rather than through stat() so that they are not subject to the
+		// cardinality guard a second time
+		statsCopy := new(RequestStat)
+		statsCopy.combineWith(stats)
+		clone.data[status] = statsCopy
+	}
+	return clone
+}
+
+func (r *RequestStat) combineWith(newStats *RequestStat) {
+	if newStats.Count == 1 {
+		// The other bucket has a single latency sample, so we "manually" add it
+		r.addRequest(1, newStats.FirstLatencySample, newStats.FirstByteLatencySample, newStats.StaticTags, newStats.DynamicTags)
+		r.RequestBytes += newStats.RequestBytes
+		r.ResponseBytes += newStats.ResponseBytes
+		r.combineSizes(newStats)
+		r.combineHeaders(newStats)
+		return
+	}
 
-		// The other bucket (newStats) has multiple samples and therefore a DDSketch object
-		// We first ensure that the bucket we're merging to has a DDSketch object
-		if stats.Latencies == nil {
-			stats.Latencies = newStatsData.Latencies.Copy()
-
-			// If we have a latency sample in this bucket we now add it to the DDSketch
-			if stats.Count == 1 {
-				err := stats.Latencies.Add(stats.FirstLatencySample)
-				if err != nil {
-					log.Debugf("could not add request latency to ddsketch: %v", err)
-				}
+	// The other bucket (newStats) has multiple samples and therefore a DDSketch object
+	// We first ensure that the bucket we're merging to has a DDSketch object
+	if r.Latencies == nil {
+		r.Latencies = newStats.Latencies.Copy()
+
+		// If we have a latency sample in this bucket we now add it to the DDSketch
+		if r.Count == 1 {
+			err := r.Latencies.Add(r.FirstLatencySample)
+			if err != nil {
+				log.Debugf("could not add request latency to ddsketch: %v", err)
 			}
-		} else {
-			err := stats.Latencies.MergeWith(newStatsData.Latencies)
+		}
+	} else {
+		err := r.Latencies.MergeWith(newStats.Latencies)
+		if err != nil {
+			log.Debugf("error merging http transactions: %v", err)
+		}
+	}
+	r.Count += newStats.Count
+	r.combineFirstByteLatencies(newStats)
+	r.RequestBytes += newStats.RequestBytes
+	r.ResponseBytes += newStats.ResponseBytes
+	r.combineSizes(newStats)
+	r.StaticTags |= newStats.StaticTags
+	if len(newStats.DynamicTags) != 0 {
+		r.DynamicTags = append(r.DynamicTags, newStats.DynamicTags...)
+	}
+	r.combineHeaders(newStats)
+}
+
+func (r *RequestStat) combineFirstByteLatencies(newStats *RequestStat) {
+	switch newStats.FirstByteCount {
+	case 0:
+		return
+	case 1:
+		r.addFirstByteLatency(1, newStats.FirstByteLatencySample)
+		return
+	}
+
+	if r.FirstByteLatencies == nil {
+		r.FirstByteLatencies = newStats.FirstByteLatencies.Copy()
+		if r.FirstByteCount == 1 {
+			err := r.FirstByteLatencies.Add(r.FirstByteLatencySample)
 			if err != nil {
-				log.Debugf("error merging http transactions: %v", err)
+				log.Debugf("could not add time to first byte to ddsketch: %v", err)
 			}
 		}
-		stats.Count += newStatsData.Count
+	} else {
+		err := r.FirstByteLatencies.MergeWith(newStats.FirstByteLatencies)
+		if err != nil {
+			log.Debugf("error merging time to first byte: %v", err)
+		}
 	}
+	r.FirstByteCount += newStats.FirstByteCount
+}
+
+func (r *RequestStat) combineSizes(newStats *RequestStat) {
+	combineSizes(&r.RequestSizes, &r.RequestSizeCount, &r.FirstRequestSizeSample,
+		newStats.RequestSizes, newStats.RequestSizeCount, newStats.FirstRequestSizeSample)
+	combineSizes(&r.ResponseSizes, &r.ResponseSizeCount, &r.FirstResponseSizeSample,
+		newStats.ResponseSizes, newStats.ResponseSizeCount, newStats.FirstResponseSizeSample)
 }
 
-// AddRequest takes information about a HTTP transaction and adds it to the request stats
-func (r *RequestStats) AddRequest(statusClass int, latency float64, staticTags uint64, dynamicTags []string) {
-	if !r.isValid(statusClass) {
+// combineSizes merges the distribution of sizes described by newSizes, newCount and newFirstSample into the one
+// described by sizes, count and firstSample
+func combineSizes(sizes **ddsketch.DDSketch, count *int, firstSample *float64, newSizes *ddsketch.DDSketch, newCount int, newFirstSample float64) {
+	switch newCount {
+	case 0:
+		return
+	case 1:
+		addSize(sizes, count, firstSample, newFirstSample, 1)
 		return
 	}
-	stats := r.Stats(statusClass)
-	if stats == nil {
-		r.init(statusClass)
-		stats = r.Stats(statusClass)
+
+	if *sizes == nil {
+		*sizes = newSizes.Copy()
+		if *count == 1 {
+			addToSketch(*sizes, *firstSample, 1)
+		}
+	} else if err := (*sizes).MergeWith(newSizes); err != nil {
+		log.Debugf("error merging http transaction sizes: %v", err)
 	}
+	*count += newCount
+}
 
-	stats.StaticTags |= staticTags
+// AddRequest takes information about a HTTP transaction and adds it to the request stats.
+// firstByteLatency is the time to first byte of the transaction, and is ignored if it is 0 (unknown).
+func (r *RequestStats) AddRequest(statusCode int, latency, firstByteLatency float64, staticTags uint64, dynamicTags []string) {
+	r.addSampledRequest(statusCode, 1, latency, firstByteLatency, staticTags, dynamicTags)
+}
+
+// addSampledRequest adds a HTTP transaction standing for weight transactions, when only one out of weight of them is
+// processed, so that the counts and the distributions of the stats account for all of them
+func (r *RequestStats) addSampledRequest(statusCode, weight int, latency, firstByteLatency float64, staticTags uint64, dynamicTags []string) {
+	if !r.isValid(statusCode) {
+		return
+	}
+	r.stat(statusCode).addRequest(weight, latency, firstByteLatency, staticTags, dynamicTags)
+}
+
+func (r *RequestStat) addRequest(weight int, latency, firstByteLatency float64, staticTags uint64, dynamicTags []string) {
+	r.StaticTags |= staticTags
 	if len(dynamicTags) != 0 {
-		stats.DynamicTags = append(stats.DynamicTags, dynamicTags...)
+		r.DynamicTags = append(r.DynamicTags, dynamicTags...)
+	}
+
+	if firstByteLatency > 0 {
+		r.addFirstByteLatency(weight, firstByteLatency)
 	}
 
-	stats.Count++
-	if stats.Count == 1 {
+	r.Count += weight
+	if r.Count == 1 {
 		// We postpone the creation of histograms when we have only one latency sample
-		stats.FirstLatencySample = latency
+		r.FirstLatencySample = latency
 		return
 	}
 
-	if stats.Latencies == nil {
-		if err := stats.initSketch(); err != nil {
+	if r.Latencies == nil {
+		if err := r.initSketch(); err != nil {
 			return
 		}
 
-		// Add the deferred latency sample
-		err := stats.Latencies.Add(stats.FirstLatencySample)
-		if err != nil {
-			log.Debugf("could not add request latency to ddsketch: %v", err)
+		// Add the deferred latency sample, if any
+		if r.Count-weight == 1 {
+			err := r.Latencies.Add(r.FirstLatencySample)
+			if err != nil {
+				log.Debugf("could not add request latency to ddsketch: %v", err)
+			}
 		}
 	}
 
-	err := stats.Latencies.Add(latency)
+	err := r.Latencies.AddWithCount(latency, float64(weight))
 	if err != nil {
 		log.Debugf("could not add request latency to ddsketch: %v", err)
 	}
 }
 
+// AddBytes adds the size of the request and of the response of a HTTP transaction to the request stats
+func (r *RequestStats) AddBytes(statusCode int, requestBytes, responseBytes uint64) {
+	r.addSampledBytes(statusCode, 1, requestBytes, responseBytes)
+}
+
+// addSampledBytes adds the sizes of a HTTP transaction standing for weight transactions, as done by addSampledRequest.
+// The sizes of the request and of the response are recorded separately, and are ignored when they are 0 (unknown).
+func (r *RequestStats) addSampledBytes(statusCode, weight int, requestBytes, responseBytes uint64) {
+	if !r.isValid(statusCode) {
+		return
+	}
+	stats := r.stat(statusCode)
+	stats.RequestBytes += requestBytes * uint64(weight)
+	stats.ResponseBytes += responseBytes * uint64(weight)
+	if requestBytes > 0 {
+		addSize(&stats.RequestSizes, &stats.RequestSizeCount, &stats.FirstRequestSizeSample, float64(requestBytes), weight)
+	}
+	if responseBytes > 0 {
+		addSize(&stats.ResponseSizes, &stats.ResponseSizeCount, &stats.FirstResponseSizeSample, float64(responseBytes), weight)
+	}
+}
+
+// addSize adds a size standing for weight transactions to the distribution of sizes described by sizes, count and
+// firstSample. Like the latencies, the first sample is held until a second one is added.
+func addSize(sizes **ddsketch.DDSketch, count *int, firstSample *float64, size float64, weight int) {
+	*count += weight
+	if *count == 1 {
+		*firstSample = size
+		return
+	}
+
+	if *sizes == nil {
+		sketch, err := ddsketch.NewDefaultDDSketch(RelativeAccuracy)
+		if err != nil {
+			log.Debugf("error recording http transaction sizes: could not create new ddsketch: %v", err)
+			return
+		}
+		*sizes = sketch
+
+		// Add the deferred size sample, if any
+		if *count-weight == 1 {
+			addToSketch(sketch, *firstSample, 1)
+		}
+	}
+
+	addToSketch(*sizes, size, weight)
+}
+
+func addToSketch(sketch *ddsketch.DDSketch, size float64, weight int) {
+	if err := sketch.AddWithCount(size, float64(weight)); err != nil {
+		log.Debugf("could not add http transaction size to ddsketch: %v", err)
+	}
+}
+
+func (r *RequestStat) addFirstByteLatency(weight int, latency float64) {
+	r.FirstByteCount += weight
+	if r.FirstByteCount == 1 {
+		r.FirstByteLatencySample = latency
+		return
+	}
+
+	if r.FirstByteLatencies == nil {
+		var err error
+		r.FirstByteLatencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
+		if err != nil {
+			log.Debugf("error recording time to first byte: could not create new ddsketch: %v", err)
+			return
+		}
+
+		// Add the deferred sample, if any
+		if r.FirstByteCount-weight == 1 {
+			err = r.FirstByteLatencies.Add(r.FirstByteLatencySample)
+			if err != nil {
+				log.Debugf("could not add time to first byte to ddsketch: %v", err)
+			}
+		}
+	}
+
+	err := r.FirstByteLatencies.AddWithCount(latency, float64(weight))
+	if err != nil {
+		log.Debugf("could not add time to first byte to ddsketch: %v", err)
+	}
+}
+
 func (r *RequestStat) initSketch() (err error) {
 	r.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
 	if err != nil {
@@ -273,9 +593,7 @@ func (r *RequestStat) initSketch() (err error) {
 // HalfAllCounts sets the count of all stats for each status class to half their current value.
 // This is used to remove duplicates from the count in the context of Windows localhost traffic.
 func (r *RequestStats) HalfAllCounts() {
-	for i := 0; i < NumStatusClasses; i++ {
-		if r.data[i] != nil {
-			r.data[i].Count = r.data[i].Count / 2
-		}
+	for _, stats := range r.data {
+		stats.Count = stats.Count / 2
 	}
 }
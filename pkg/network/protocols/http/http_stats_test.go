@@ -9,17 +9,21 @@
 package http
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/DataDog/sketches-go/ddsketch"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
 func TestAddRequest(t *testing.T) {
 	var stats RequestStats
-	stats.AddRequest(400, 10.0, 1, nil)
-	stats.AddRequest(404, 15.0, 2, nil)
-	stats.AddRequest(405, 20.0, 3, nil)
+	stats.AddRequest(400, 10.0, 0, 1, nil)
+	stats.AddRequest(404, 15.0, 0, 2, nil)
+	stats.AddRequest(405, 20.0, 0, 3, nil)
 
 	for i := 100; i <= 500; i += 100 {
 		s := stats.Stats(i)
@@ -38,6 +42,88 @@ func TestAddRequest(t *testing.T) {
 	}
 }
 
+func TestAddSampledRequest(t *testing.T) {
+	var stats RequestStats
+	stats.AddRequest(200, 10.0, 5.0, 0, nil)
+	stats.AddBytes(200, 100, 1000)
+	stats.addSampledRequest(200, 4, 20.0, 5.0, 0, nil)
+	stats.addSampledBytes(200, 4, 100, 1000)
+
+	s := stats.Stats(200)
+	require.NotNil(t, s)
+	assert.Equal(t, 5, s.Count)
+	require.NotNil(t, s.Latencies)
+	assert.Equal(t, float64(5), s.Latencies.GetCount())
+	assert.Equal(t, 5, s.FirstByteCount)
+	assert.Equal(t, float64(5), s.FirstByteLatencies.GetCount())
+	assert.Equal(t, uint64(500), s.RequestBytes)
+	assert.Equal(t, uint64(5000), s.ResponseBytes)
+	assert.Equal(t, 5, s.RequestSizeCount)
+	assert.Equal(t, float64(5), s.RequestSizes.GetCount())
+	assert.Equal(t, 5, s.ResponseSizeCount)
+	assert.Equal(t, float64(5), s.ResponseSizes.GetCount())
+
+	// a sampled request is never held as a single sample, so that it can be combined with other stats
+	var sampled RequestStats
+	sampled.addSampledRequest(200, 4, 20.0, 0, 0, nil)
+	require.NotNil(t, sampled.Stats(200).Latencies)
+	stats.CombineWith(&sampled)
+	assert.Equal(t, 9, stats.Stats(200).Count)
+	assert.Equal(t, float64(9), stats.Stats(200).Latencies.GetCount())
+}
+
+func TestAddRequestByStatusCode(t *testing.T) {
+	stats := NewRequestStats(true)
+	stats.AddRequest(400, 10.0, 0, 1, nil)
+	stats.AddRequest(404, 15.0, 0, 2, nil)
+	stats.AddRequest(404, 20.0, 0, 3, nil)
+	stats.AddRequest(600, 20.0, 0, 3, nil)
+
+	assert.Equal(t, []uint16{400, 404}, stats.StatusCodes())
+	assert.Equal(t, 1, stats.Stats(400).Count)
+	assert.Equal(t, 2, stats.Stats(404).Count)
+	assert.Nil(t, stats.Stats(405))
+
+	// the stats are aggregated by status code when combined with stats aggregated by status code
+	other := NewRequestStats(true)
+	other.AddRequest(429, 10.0, 0, 1, nil)
+	stats.CombineWith(other)
+	assert.Equal(t, []uint16{400, 404, 429}, stats.StatusCodes())
+
+	// and by status class when combined into stats aggregated by status class
+	var classes RequestStats
+	classes.CombineWith(stats)
+	assert.Equal(t, []uint16{400}, classes.StatusCodes())
+	assert.Equal(t, 4, classes.Stats(404).Count)
+}
+
+func TestStatusCodeCardinalityGuard(t *testing.T) {
+	stats := NewRequestStats(true)
+	for i := 0; i < MaxStatusCodesPerEndpoint; i++ {
+		stats.AddRequest(201+i, 10.0, 0, 0, nil)
+	}
+	require.Len(t, stats.StatusCodes(), MaxStatusCodesPerEndpoint)
+
+	// the codes seen after the limit is reached are accounted for under their status class
+	stats.AddRequest(404, 10.0, 0, 0, nil)
+	stats.AddRequest(429, 10.0, 0, 0, nil)
+	stats.AddBytes(429, 10, 20)
+	assert.Len(t, stats.StatusCodes(), MaxStatusCodesPerEndpoint+1)
+	assert.Nil(t, stats.Stats(404))
+	assert.Nil(t, stats.Stats(429))
+	if s := stats.Stats(400); assert.NotNil(t, s) {
+		assert.Equal(t, 2, s.Count)
+		assert.Equal(t, uint64(10), s.RequestBytes)
+	}
+
+	// the codes seen before the limit was reached are still tracked
+	stats.AddRequest(201, 10.0, 0, 0, nil)
+	assert.Equal(t, 2, stats.Stats(201).Count)
+
+	// cloning the stats keeps the same status codes
+	assert.Equal(t, stats.StatusCodes(), stats.Clone().StatusCodes())
+}
+
 func TestCombineWith(t *testing.T) {
 	var stats RequestStats
 	for i := 100; i <= 500; i += 100 {
@@ -46,9 +132,9 @@ func TestCombineWith(t *testing.T) {
 	}
 
 	var stats2, stats3, stats4 RequestStats
-	stats2.AddRequest(400, 10.0, 2, nil)
-	stats3.AddRequest(404, 15.0, 3, nil)
-	stats4.AddRequest(405, 20.0, 4, nil)
+	stats2.AddRequest(400, 10.0, 0, 2, nil)
+	stats3.AddRequest(404, 15.0, 0, 3, nil)
+	stats4.AddRequest(405, 20.0, 0, 4, nil)
 
 	stats.CombineWith(&stats2)
 	stats.CombineWith(&stats3)
@@ -69,6 +155,194 @@ func TestCombineWith(t *testing.T) {
 	}
 }
 
+func TestClone(t *testing.T) {
+	var stats RequestStats
+	stats.AddRequest(200, 10.0, 5.0, 1, []string{"tag:a"})
+	stats.AddRequest(200, 20.0, 15.0, 2, []string{"tag:b"})
+	stats.AddBytes(200, 100, 200)
+
+	clone := stats.Clone()
+	s := clone.Stats(200)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, 2, s.Count)
+		assert.Equal(t, 2, s.FirstByteCount)
+		assert.Equal(t, uint64(100), s.RequestBytes)
+		assert.Equal(t, uint64(200), s.ResponseBytes)
+		assert.Equal(t, uint64(3), s.StaticTags)
+		assert.Equal(t, []string{"tag:a", "tag:b"}, s.DynamicTags)
+		verifyQuantile(t, s.Latencies, 0.0, 10.0)
+		verifyQuantile(t, s.Latencies, 1.0, 20.0)
+	}
+
+	// combining other stats with the clone leaves the original stats untouched
+	var other RequestStats
+	other.AddRequest(200, 30.0, 0, 0, nil)
+	clone.CombineWith(&other)
+	assert.Equal(t, 3, clone.Stats(200).Count)
+	assert.Equal(t, 2, stats.Stats(200).Count)
+	assert.Equal(t, 2, int(stats.Stats(200).Latencies.GetCount()))
+}
+
+func TestCombineFirstByteLatency(t *testing.T) {
+	var stats RequestStats
+	stats.AddRequest(200, 30.0, 10.0, 0, nil)
+	// transactions with an unknown time to first byte must not be accounted for
+	stats.AddRequest(200, 40.0, 0, 0, nil)
+
+	s := stats.Stats(200)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, 2, s.Count)
+		assert.Equal(t, 1, s.FirstByteCount)
+		assert.Equal(t, 10.0, s.FirstByteLatencySample)
+		assert.Nil(t, s.FirstByteLatencies)
+	}
+
+	var other RequestStats
+	other.AddRequest(204, 50.0, 20.0, 0, nil)
+	other.AddRequest(204, 60.0, 30.0, 0, nil)
+	stats.CombineWith(&other)
+
+	if assert.NotNil(t, s) {
+		assert.Equal(t, 4, s.Count)
+		assert.Equal(t, 3, s.FirstByteCount)
+		assert.Equal(t, 3.0, s.FirstByteLatencies.GetCount())
+		verifyQuantile(t, s.FirstByteLatencies, 0.0, 10.0)
+		verifyQuantile(t, s.FirstByteLatencies, 0.5, 20.0)
+		verifyQuantile(t, s.FirstByteLatencies, 1.0, 30.0)
+	}
+}
+
+func TestCombineBytes(t *testing.T) {
+	var stats RequestStats
+	stats.AddRequest(200, 30.0, 0, 0, nil)
+	stats.AddBytes(200, 100, 1000)
+
+	var single RequestStats
+	single.AddRequest(200, 40.0, 0, 0, nil)
+	single.AddBytes(200, 200, 2000)
+
+	var multiple RequestStats
+	multiple.AddRequest(200, 50.0, 0, 0, nil)
+	multiple.AddBytes(200, 300, 3000)
+	multiple.AddRequest(200, 60.0, 0, 0, nil)
+	multiple.AddBytes(200, 400, 4000)
+
+	stats.CombineWith(&single)
+	stats.CombineWith(&multiple)
+
+	s := stats.Stats(200)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, 4, s.Count)
+		assert.Equal(t, uint64(1000), s.RequestBytes)
+		assert.Equal(t, uint64(10000), s.ResponseBytes)
+	}
+}
+
+func TestSizeDistribution(t *testing.T) {
+	var stats RequestStats
+	stats.AddRequest(200, 10.0, 0, 0, nil)
+	stats.AddBytes(200, 100, 1000)
+	// transactions with unknown sizes must not be accounted for
+	stats.AddRequest(200, 20.0, 0, 0, nil)
+	stats.AddBytes(200, 0, 0)
+
+	s := stats.Stats(200)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, 2, s.Count)
+		assert.Equal(t, 1, s.RequestSizeCount)
+		assert.Equal(t, 1, s.ResponseSizeCount)
+		assert.Equal(t, 100.0, s.FirstRequestSizeSample)
+		assert.Equal(t, 1000.0, s.FirstResponseSizeSample)
+		assert.Nil(t, s.RequestSizes)
+		assert.Nil(t, s.ResponseSizes)
+	}
+
+	stats.AddRequest(200, 30.0, 0, 0, nil)
+	stats.AddBytes(200, 200, 2000)
+	stats.AddRequest(200, 40.0, 0, 0, nil)
+	stats.AddBytes(200, 300, 300000)
+
+	if assert.NotNil(t, s) {
+		assert.Equal(t, 3, s.RequestSizeCount)
+		assert.Equal(t, 3, s.ResponseSizeCount)
+		assert.Equal(t, uint64(600), s.RequestBytes)
+		assert.Equal(t, uint64(303000), s.ResponseBytes)
+		verifyQuantile(t, s.RequestSizes, 0.0, 100.0)
+		verifyQuantile(t, s.RequestSizes, 1.0, 300.0)
+		verifyQuantile(t, s.ResponseSizes, 0.5, 2000.0)
+		verifyQuantile(t, s.ResponseSizes, 1.0, 300000.0)
+	}
+}
+
+func TestSizesKnownSeparately(t *testing.T) {
+	var stats RequestStats
+	// only the size of the response is known, such as when the request was sent before the connection was tracked
+	stats.AddRequest(200, 10.0, 0, 0, nil)
+	stats.AddBytes(200, 0, 1000)
+	stats.AddRequest(200, 20.0, 0, 0, nil)
+	stats.AddBytes(200, 0, 2000)
+	stats.AddRequest(200, 30.0, 0, 0, nil)
+	stats.AddBytes(200, 300, 3000)
+
+	s := stats.Stats(200)
+	require.NotNil(t, s)
+	assert.Equal(t, 1, s.RequestSizeCount)
+	assert.Equal(t, 300.0, s.FirstRequestSizeSample)
+	assert.Nil(t, s.RequestSizes)
+	assert.Equal(t, 3, s.ResponseSizeCount)
+	require.NotNil(t, s.ResponseSizes)
+	verifyQuantile(t, s.ResponseSizes, 0.0, 1000.0)
+
+	var other RequestStats
+	other.AddRequest(200, 40.0, 0, 0, nil)
+	other.AddBytes(200, 400, 0)
+	other.AddRequest(200, 50.0, 0, 0, nil)
+	other.AddBytes(200, 500, 0)
+	stats.CombineWith(&other)
+
+	assert.Equal(t, 3, s.RequestSizeCount)
+	require.NotNil(t, s.RequestSizes)
+	verifyQuantile(t, s.RequestSizes, 0.0, 300.0)
+	verifyQuantile(t, s.RequestSizes, 1.0, 500.0)
+	assert.Equal(t, 3, s.ResponseSizeCount)
+}
+
+func TestCombineSizes(t *testing.T) {
+	var stats RequestStats
+	stats.AddRequest(200, 10.0, 0, 0, nil)
+	stats.AddBytes(200, 100, 1000)
+
+	var single RequestStats
+	single.AddRequest(200, 20.0, 0, 0, nil)
+	single.AddBytes(200, 200, 2000)
+
+	var multiple RequestStats
+	multiple.AddRequest(200, 30.0, 0, 0, nil)
+	multiple.AddBytes(200, 300, 3000)
+	multiple.AddRequest(200, 40.0, 0, 0, nil)
+	multiple.AddBytes(200, 400, 4000)
+
+	stats.CombineWith(&single)
+	s := stats.Stats(200)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, 2, s.RequestSizeCount)
+		verifyQuantile(t, s.RequestSizes, 0.0, 100.0)
+		verifyQuantile(t, s.RequestSizes, 1.0, 200.0)
+	}
+
+	stats.CombineWith(&multiple)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, 4, s.RequestSizeCount)
+		assert.Equal(t, 4.0, s.ResponseSizes.GetCount())
+		verifyQuantile(t, s.RequestSizes, 1.0, 400.0)
+		verifyQuantile(t, s.ResponseSizes, 0.0, 1000.0)
+		verifyQuantile(t, s.ResponseSizes, 1.0, 4000.0)
+	}
+
+	// the sketches of the combined stats are copied rather than shared
+	assert.Equal(t, 2.0, multiple.Stats(200).RequestSizes.GetCount())
+}
+
 func verifyQuantile(t *testing.T, sketch *ddsketch.DDSketch, q float64, expectedValue float64) {
 	val, err := sketch.GetValueAtQuantile(q)
 	assert.Nil(t, err)
@@ -77,3 +351,96 @@ func verifyQuantile(t *testing.T, sketch *ddsketch.DDSketch, q float64, expected
 	assert.True(t, val >= expectedValue-acceptableError)
 	assert.True(t, val <= expectedValue+acceptableError)
 }
+
+// benchmarkEndpoints is the number of distinct endpoints used by the aggregation benchmarks, matching the cardinality
+// we see on busy hosts.
+const benchmarkEndpoints = 10000
+
+func generateBenchmarkKeys(n int) []Key {
+	keys := make([]Key, n)
+	for i := range keys {
+		keys[i] = NewKey(
+			util.AddressFromString(fmt.Sprintf("10.0.%d.%d", (i/256)%256, i%256)),
+			util.AddressFromString("10.1.0.1"),
+			uint16(1024+i%60000),
+			8080,
+			fmt.Sprintf("/api/v1/endpoint-%d", i),
+			true,
+			MethodGet,
+		)
+	}
+	return keys
+}
+
+func BenchmarkAddRequest(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var stats RequestStats
+		for j := 0; j < 100; j++ {
+			stats.AddRequest(100*(j%5+1), float64(j)*1e6, float64(j)*5e5, 0, nil)
+		}
+	}
+}
+
+func BenchmarkCombineWith(b *testing.B) {
+	newStats := make([]*RequestStats, benchmarkEndpoints)
+	for i := range newStats {
+		newStats[i] = new(RequestStats)
+		for j := 0; j < 10; j++ {
+			newStats[i].AddRequest(100*(j%5+1), float64(i+j)*1e6, float64(i+j)*5e5, 0, nil)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		aggregated := make([]RequestStats, benchmarkEndpoints)
+		for j := range aggregated {
+			aggregated[j].CombineWith(newStats[j])
+			aggregated[j].CombineWith(newStats[j])
+		}
+	}
+}
+
+func BenchmarkKeyHashing(b *testing.B) {
+	keys := generateBenchmarkKeys(benchmarkEndpoints)
+	stats := make(map[Key]*RequestStats, len(keys))
+	for _, key := range keys {
+		stats[key] = new(RequestStats)
+	}
+
+	b.Run("insert", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m := make(map[Key]*RequestStats, len(keys))
+			for _, key := range keys {
+				m[key] = nil
+			}
+		}
+	})
+
+	b.Run("lookup", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, key := range keys {
+				_ = stats[key]
+			}
+		}
+	})
+
+	b.Run("lookup tuple", func(b *testing.B) {
+		tuples := make(map[KeyTuple]struct{}, len(keys))
+		for _, key := range keys {
+			tuples[key.KeyTuple] = struct{}{}
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for _, key := range keys {
+				_ = tuples[key.KeyTuple]
+			}
+		}
+	})
+}
@@ -13,6 +13,7 @@ package http
 #include "../../ebpf/c/protocols/tls/tags-types.h"
 #include "../../ebpf/c/protocols/http/types.h"
 #include "../../ebpf/c/protocols/classification/defs.h"
+#include "../../ebpf/c/protocols/http2/defs.h"
 */
 import "C"
 
@@ -21,43 +22,75 @@ type sslSock C.ssl_sock_t
 type sslReadArgs C.ssl_read_args_t
 
 type ebpfHttpTx C.http_transaction_t
+type httpPendingRequest C.http_pending_request_t
+type httpPipeline C.http_pipeline_t
+type httpHeadersKey C.http_headers_key_t
+type httpHeaders C.http_headers_t
 
 type libPath C.lib_path_t
 
+type http2FrameCounters C.http2_frame_counters_t
+type http2FrameStats C.http2_frame_stats_t
+
 type ProtocolType C.protocol_t
 
 // Add tests to TestProtocolValue
 const (
-	ProtocolUnknown  ProtocolType = C.PROTOCOL_UNKNOWN
-	ProtocolHTTP     ProtocolType = C.PROTOCOL_HTTP
-	ProtocolHTTP2    ProtocolType = C.PROTOCOL_HTTP2
-	ProtocolTLS      ProtocolType = C.PROTOCOL_TLS
-	ProtocolMONGO    ProtocolType = C.PROTOCOL_MONGO
-	ProtocolPostgres ProtocolType = C.PROTOCOL_POSTGRES
-	ProtocolAMQP     ProtocolType = C.PROTOCOL_AMQP
-	ProtocolRedis    ProtocolType = C.PROTOCOL_REDIS
-	ProtocolMySQL    ProtocolType = C.PROTOCOL_MYSQL
-	ProtocolMax      ProtocolType = C.MAX_PROTOCOLS
+	ProtocolUnknown   ProtocolType = C.PROTOCOL_UNKNOWN
+	ProtocolHTTP      ProtocolType = C.PROTOCOL_HTTP
+	ProtocolHTTP2     ProtocolType = C.PROTOCOL_HTTP2
+	ProtocolTLS       ProtocolType = C.PROTOCOL_TLS
+	ProtocolKafka     ProtocolType = C.PROTOCOL_KAFKA
+	ProtocolMONGO     ProtocolType = C.PROTOCOL_MONGO
+	ProtocolPostgres  ProtocolType = C.PROTOCOL_POSTGRES
+	ProtocolAMQP      ProtocolType = C.PROTOCOL_AMQP
+	ProtocolRedis     ProtocolType = C.PROTOCOL_REDIS
+	ProtocolMySQL     ProtocolType = C.PROTOCOL_MYSQL
+	ProtocolHTTP3     ProtocolType = C.PROTOCOL_HTTP3
+	ProtocolSSH       ProtocolType = C.PROTOCOL_SSH
+	ProtocolRDP       ProtocolType = C.PROTOCOL_RDP
+	ProtocolCassandra ProtocolType = C.PROTOCOL_CASSANDRA
+	ProtocolMemcached ProtocolType = C.PROTOCOL_MEMCACHED
+	ProtocolMax       ProtocolType = C.MAX_PROTOCOLS
 )
 
 const (
 	HTTPBufferSize = C.HTTP_BUFFER_SIZE
 
+	httpHeadersBufferSize = C.HTTP_HEADERS_BUFFER_SIZE
+
+	maxPipelinedRequests = C.HTTP_MAX_PIPELINED_REQUESTS
+
 	libPathMaxSize = C.LIB_PATH_MAX_SIZE
+
+	connHTTPClient = C.CONN_HTTP_CLIENT
+	connHTTPServer = C.CONN_HTTP_SERVER
 )
 
 type ConnTag = uint64
 
 const (
-	GnuTLS  ConnTag = C.LIBGNUTLS
-	OpenSSL ConnTag = C.LIBSSL
-	Go      ConnTag = C.GO
+	GnuTLS    ConnTag = C.LIBGNUTLS
+	OpenSSL   ConnTag = C.LIBSSL
+	Go        ConnTag = C.GO
+	GRPCWeb   ConnTag = C.GRPC_WEB
+	BoringSSL ConnTag = C.BORINGSSL
+	LibreSSL  ConnTag = C.LIBRESSL
+	Istio     ConnTag = C.ISTIO
+	KTLS      ConnTag = C.KTLS
+	JavaTLS   ConnTag = C.JAVA_TLS
 )
 
 var (
 	StaticTags = map[ConnTag]string{
-		GnuTLS:  "tls.library:gnutls",
-		OpenSSL: "tls.library:openssl",
-		Go:      "tls.library:go",
+		GnuTLS:    "tls.library:gnutls",
+		OpenSSL:   "tls.library:openssl",
+		Go:        "tls.library:go",
+		GRPCWeb:   "http.protocol:grpc-web",
+		BoringSSL: "tls.library:boringssl",
+		LibreSSL:  "tls.library:libressl",
+		Istio:     "mesh:istio",
+		KTLS:      "tls.library:ktls",
+		JavaTLS:   "tls.library:java",
 	}
 )
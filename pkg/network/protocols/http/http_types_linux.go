@@ -29,11 +29,39 @@ type ebpfHttpTx struct {
 	Request_started      uint64
 	Request_method       uint8
 	Response_status_code uint16
+	Response_first_seen  uint64
 	Response_last_seen   uint64
 	Request_fragment     [160]byte
 	Owned_by_src_port    uint16
 	Tcp_seq              uint32
 	Tags                 uint64
+	Request_size         uint32
+	Response_size        uint32
+	Segment_size         uint32
+	Segment_tail         [64]byte
+	Pad_cgo_0            [4]byte
+}
+
+type httpPendingRequest struct {
+	Request_started  uint64
+	Request_method   uint8
+	Pad_cgo_0        [3]byte
+	Request_size     uint32
+	Request_fragment [160]byte
+}
+type httpPipeline struct {
+	Head     uint32
+	Len      uint32
+	Requests [4]httpPendingRequest
+}
+type httpHeadersKey struct {
+	Tup             httpConnTuple
+	Request_started uint64
+}
+type httpHeaders struct {
+	Buffer    [384]byte
+	Len       uint16
+	Pad_cgo_0 [6]byte
 }
 
 type libPath struct {
@@ -42,39 +70,82 @@ type libPath struct {
 	Buf [120]byte
 }
 
+type http2FrameCounters struct {
+	Data_bytes              uint64
+	Window_update_increment uint64
+	Window_update_frames    uint32
+	Window_exhaustions      uint32
+	Push_promise_frames     uint32
+	Priority_frames         uint32
+	Settings_frames         uint32
+	Rst_stream_frames       uint32
+	Goaway_frames           uint32
+	Remainder               uint32
+}
+type http2FrameStats struct {
+	Client http2FrameCounters
+	Server http2FrameCounters
+	Tags   uint64
+}
+
 type ProtocolType uint8
 
 const (
-	ProtocolUnknown  ProtocolType = 0x1
-	ProtocolHTTP     ProtocolType = 0x2
-	ProtocolHTTP2    ProtocolType = 0x3
-	ProtocolTLS      ProtocolType = 0x4
-	ProtocolMONGO    ProtocolType = 0x6
-	ProtocolPostgres ProtocolType = 0x7
-	ProtocolAMQP     ProtocolType = 0x8
-	ProtocolRedis    ProtocolType = 0x9
-	ProtocolMySQL    ProtocolType = 0xa
-	ProtocolMax      ProtocolType = 0xb
+	ProtocolUnknown   ProtocolType = 0x1
+	ProtocolHTTP      ProtocolType = 0x2
+	ProtocolHTTP2     ProtocolType = 0x3
+	ProtocolTLS       ProtocolType = 0x4
+	ProtocolKafka     ProtocolType = 0x5
+	ProtocolMONGO     ProtocolType = 0x6
+	ProtocolPostgres  ProtocolType = 0x7
+	ProtocolAMQP      ProtocolType = 0x8
+	ProtocolRedis     ProtocolType = 0x9
+	ProtocolMySQL     ProtocolType = 0xa
+	ProtocolHTTP3     ProtocolType = 0xb
+	ProtocolSSH       ProtocolType = 0xc
+	ProtocolRDP       ProtocolType = 0xd
+	ProtocolCassandra ProtocolType = 0xe
+	ProtocolMemcached ProtocolType = 0xf
+	ProtocolMax       ProtocolType = 0x10
 )
 
 const (
 	HTTPBufferSize = 0xa0
 
+	httpHeadersBufferSize = 0x180
+
+	maxPipelinedRequests = 0x4
+
 	libPathMaxSize = 0x78
+
+	connHTTPClient = 0x8
+	connHTTPServer = 0x10
 )
 
 type ConnTag = uint64
 
 const (
-	GnuTLS  ConnTag = 0x1
-	OpenSSL ConnTag = 0x2
-	Go      ConnTag = 0x4
+	GnuTLS    ConnTag = 0x1
+	OpenSSL   ConnTag = 0x2
+	Go        ConnTag = 0x4
+	GRPCWeb   ConnTag = 0x8
+	BoringSSL ConnTag = 0x10
+	LibreSSL  ConnTag = 0x20
+	Istio     ConnTag = 0x40
+	KTLS      ConnTag = 0x80
+	JavaTLS   ConnTag = 0x100
 )
 
 var (
 	StaticTags = map[ConnTag]string{
-		GnuTLS:  "tls.library:gnutls",
-		OpenSSL: "tls.library:openssl",
-		Go:      "tls.library:go",
+		GnuTLS:    "tls.library:gnutls",
+		OpenSSL:   "tls.library:openssl",
+		Go:        "tls.library:go",
+		GRPCWeb:   "http.protocol:grpc-web",
+		BoringSSL: "tls.library:boringssl",
+		LibreSSL:  "tls.library:libressl",
+		Istio:     "mesh:istio",
+		KTLS:      "tls.library:ktls",
+		JavaTLS:   "tls.library:java",
 	}
 )
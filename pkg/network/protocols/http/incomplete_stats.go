@@ -133,6 +133,8 @@ func (b *incompleteBuffer) Flush(now time.Time) []httpTX {
 
 			// Merge response into request
 			request.SetStatusCode(response.StatusCode())
+			request.SetResponseFirstSeen(response.ResponseFirstSeen())
+			request.SetResponseSize(response.ResponseSize())
 			request.SetResponseLastSeen(response.ResponseLastSeen())
 			joined = append(joined, request)
 			i++
@@ -38,6 +38,7 @@ func TestOrphanEntries(t *testing.T) {
 		response := &ebpfHttpTx{
 			Response_status_code: 200,
 			Response_last_seen:   uint64(now.UnixNano()),
+			Response_size:        1024,
 		}
 		response.Tup.Sport = 60000
 		buffer.Add(response)
@@ -48,6 +49,7 @@ func TestOrphanEntries(t *testing.T) {
 		path, _ := completeTX.Path(make([]byte, 256))
 		assert.Equal(t, "/foo/bar", string(path))
 		assert.Equal(t, 200, completeTX.StatusClass())
+		assert.Equal(t, uint32(1024), completeTX.ResponseSize())
 	})
 
 	t.Run("orphan entries are not kept indefinitely", func(t *testing.T) {
@@ -22,8 +22,25 @@ import (
 // MinimumKernelVersion indicates the minimum kernel version required for HTTP monitoring
 var MinimumKernelVersion kernel.Version
 
+// MinimumARMHTTPSKernelVersion indicates the minimum kernel version required for HTTPS monitoring on ARM, as reading
+// the buffers of the monitored libraries requires bpf_probe_read_user (5.5+) on architectures with overlapping user
+// and kernel address spaces
+var MinimumARMHTTPSKernelVersion kernel.Version
+
+// MinimumFallbackKernelVersion indicates the minimum kernel version required for the socket filter fallback of the
+// HTTP monitoring, used on the kernels older than MinimumKernelVersion
+var MinimumFallbackKernelVersion kernel.Version
+
 func init() {
 	MinimumKernelVersion = kernel.VersionCode(4, 14, 0)
+	MinimumARMHTTPSKernelVersion = kernel.VersionCode(5, 5, 0)
+	MinimumFallbackKernelVersion = kernel.VersionCode(4, 4, 0)
+}
+
+// FallbackSupported returns true if the HTTP monitoring falls back to the socket filter on the given kernel version,
+// which only monitors the plaintext HTTP traffic
+func FallbackSupported(kversion kernel.Version) bool {
+	return kversion >= MinimumFallbackKernelVersion && kversion < MinimumKernelVersion
 }
 
 // ErrNotSupported indicates that the current host doesn't fullfil the
@@ -40,7 +57,8 @@ func runningOnARM() bool {
 	return strings.HasPrefix(runtime.GOARCH, "arm")
 }
 
-// We only support ARM with kernel >= 5.5.0 and with runtime compilation enabled
+// HTTPSSupported returns true if the current host supports the uprobe-based HTTPS monitoring. The prebuilt, CO-RE
+// and runtime compiled programs are all supported, on ARM only with a kernel >= 5.5.0.
 func HTTPSSupported(c *config.Config) bool {
 	kversion, err := kernel.HostVersion()
 	if err != nil {
@@ -48,11 +66,14 @@ func HTTPSSupported(c *config.Config) bool {
 		return false
 	}
 
-	if runningOnARM() {
-		return kversion >= kernel.VersionCode(5, 5, 0) && c.EnableRuntimeCompiler
-	}
+	return kversion >= minimumHTTPSKernelVersion(runningOnARM())
+}
 
-	return kversion >= MinimumKernelVersion
+func minimumHTTPSKernelVersion(arm bool) kernel.Version {
+	if arm {
+		return MinimumARMHTTPSKernelVersion
+	}
+	return MinimumKernelVersion
 }
 
 func sysOpenAt2Supported(c *config.Config) bool {
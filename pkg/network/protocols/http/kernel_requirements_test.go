@@ -0,0 +1,27 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+func TestFallbackSupported(t *testing.T) {
+	for _, kv := range []kernel.Version{kernel.VersionCode(4, 4, 0), kernel.VersionCode(4, 9, 0), kernel.VersionCode(4, 13, 16)} {
+		assert.True(t, FallbackSupported(kv), kv.String())
+	}
+	// the kernels supporting the USM don't fall back, and the older ones don't support the socket filter
+	for _, kv := range []kernel.Version{kernel.VersionCode(3, 10, 0), kernel.VersionCode(4, 14, 0), kernel.VersionCode(5, 15, 0)} {
+		assert.False(t, FallbackSupported(kv), kv.String())
+	}
+}
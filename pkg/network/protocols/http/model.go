@@ -11,7 +11,9 @@ package http
 type httpTX interface {
 	StatusClass() int
 	RequestLatency() float64
+	FirstByteLatency() float64
 	ConnTuple() KeyTuple
+	Direction() Direction
 	Method() Method
 	SetRequestMethod(Method)
 	StatusCode() uint16
@@ -21,6 +23,11 @@ type httpTX interface {
 	String() string
 	Incomplete() bool
 	Path(buffer []byte) ([]byte, bool)
+	RequestSize() uint32
+	ResponseSize() uint32
+	SetResponseSize(size uint32)
+	ResponseFirstSeen() uint64
+	SetResponseFirstSeen(fs uint64)
 	ResponseLastSeen() uint64
 	SetResponseLastSeen(ls uint64)
 	RequestStarted() uint64
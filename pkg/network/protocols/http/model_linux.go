@@ -40,8 +40,9 @@ func (tx *ebpfHttpTx) Path(buffer []byte) ([]byte, bool) {
 	for j = 0; j < len(b) && b[j] != ' ' && b[j] != '?'; j++ {
 	}
 	n := copy(buffer, b[:j])
-	// indicate if we knowingly captured the entire path
-	fullPath := n < len(b)
+	// indicate if we knowingly captured the entire path, which is not the case if it was truncated either by the end
+	// of the fragment or by the size of the buffer
+	fullPath := j < len(b) && n == j
 	return buffer[:n], fullPath
 }
 
@@ -59,6 +60,15 @@ func (tx *ebpfHttpTx) RequestLatency() float64 {
 	return nsTimestampToFloat(tx.Response_last_seen - tx.Request_started)
 }
 
+// FirstByteLatency returns the time elapsed (in nanoseconds) between the beginning of the request and the beginning
+// of the response, or 0 if it is unknown.
+func (tx *ebpfHttpTx) FirstByteLatency() float64 {
+	if uint64(tx.Request_started) == 0 || uint64(tx.Response_first_seen) == 0 {
+		return 0
+	}
+	return nsTimestampToFloat(tx.Response_first_seen - tx.Request_started)
+}
+
 // Incomplete returns true if the transaction contains only the request or response information
 // This happens in the context of localhost with NAT, in which case we join the two parts in userspace
 func (tx *ebpfHttpTx) Incomplete() bool {
@@ -76,6 +86,20 @@ func (tx *ebpfHttpTx) ConnTuple() KeyTuple {
 	}
 }
 
+// Direction returns the side of the local process in the transaction, which is only known when it was seen through
+// the TLS hooks. The transactions of the socket filter, including the loopback ones, are seen once for both ends and
+// are DirectionUnknown
+func (tx *ebpfHttpTx) Direction() Direction {
+	switch {
+	case tx.Tup.Metadata&connHTTPClient != 0:
+		return DirectionClient
+	case tx.Tup.Metadata&connHTTPServer != 0:
+		return DirectionServer
+	default:
+		return DirectionUnknown
+	}
+}
+
 func (tx *ebpfHttpTx) Method() Method {
 	return Method(tx.Request_method)
 }
@@ -88,6 +112,28 @@ func (tx *ebpfHttpTx) SetStatusCode(code uint16) {
 	tx.Response_status_code = code
 }
 
+// RequestSize returns the number of bytes of the request, headers included
+func (tx *ebpfHttpTx) RequestSize() uint32 {
+	return tx.Request_size
+}
+
+// ResponseSize returns the number of bytes of the response, headers included
+func (tx *ebpfHttpTx) ResponseSize() uint32 {
+	return tx.Response_size
+}
+
+func (tx *ebpfHttpTx) SetResponseSize(size uint32) {
+	tx.Response_size = size
+}
+
+func (tx *ebpfHttpTx) ResponseFirstSeen() uint64 {
+	return tx.Response_first_seen
+}
+
+func (tx *ebpfHttpTx) SetResponseFirstSeen(firstSeen uint64) {
+	tx.Response_first_seen = firstSeen
+}
+
 func (tx *ebpfHttpTx) ResponseLastSeen() uint64 {
 	return tx.Response_last_seen
 }
@@ -107,7 +153,11 @@ func (tx *ebpfHttpTx) SetRequestMethod(m Method) {
 // StaticTags returns an uint64 representing the tags bitfields
 // Tags are defined here : pkg/network/ebpf/kprobe_types.go
 func (tx *ebpfHttpTx) StaticTags() uint64 {
-	return tx.Tags
+	tags := tx.Tags
+	if IsGRPCWeb(tx.Request_fragment[:]) {
+		tags |= GRPCWeb
+	}
+	return tags
 }
 
 func (tx *ebpfHttpTx) DynamicTags() []string {
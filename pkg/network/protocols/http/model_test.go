@@ -62,6 +62,19 @@ func TestFullPath(t *testing.T) {
 	assert.True(t, fullPath)
 }
 
+func TestPathLongerThanBuffer(t *testing.T) {
+	tx := ebpfHttpTx{
+		Request_fragment: requestFragment(
+			[]byte("GET /foo/bar/baz HTTP/1.1\nHost: example.com"),
+		),
+	}
+
+	b := make([]byte, 8)
+	path, fullPath := tx.Path(b)
+	assert.Equal(t, "/foo/bar", string(path))
+	assert.False(t, fullPath)
+}
+
 func TestPathHandlesNullTerminator(t *testing.T) {
 	tx := ebpfHttpTx{
 		Request_fragment: requestFragment(
@@ -88,6 +101,43 @@ func TestLatency(t *testing.T) {
 	assert.Equal(t, 999424.0, tx.RequestLatency())
 }
 
+func TestTXFirstByteLatency(t *testing.T) {
+	tx := ebpfHttpTx{
+		Request_started:     1e6,
+		Response_first_seen: 1.5e6,
+		Response_last_seen:  2e6,
+	}
+	assert.Equal(t, 499712.0, tx.FirstByteLatency())
+
+	// the response hasn't been seen
+	tx.Response_first_seen = 0
+	assert.Zero(t, tx.FirstByteLatency())
+}
+
+func TestStaticTagsGRPCWeb(t *testing.T) {
+	tx := ebpfHttpTx{
+		Request_fragment: requestFragment(
+			[]byte("POST /helloworld.Greeter/SayHello HTTP/1.1\r\nContent-Type: application/grpc-web+proto\r\n\r\n"),
+		),
+		Tags: OpenSSL,
+	}
+	assert.Equal(t, OpenSSL|GRPCWeb, tx.StaticTags())
+
+	tx.Request_fragment = requestFragment([]byte("POST /upload HTTP/1.1\r\nContent-Type: application/json\r\n\r\n"))
+	assert.Equal(t, OpenSSL, tx.StaticTags())
+}
+
+func TestTXDirection(t *testing.T) {
+	tx := ebpfHttpTx{Tup: httpConnTuple{Metadata: 1}}
+	assert.Equal(t, DirectionUnknown, tx.Direction())
+
+	tx.Tup.Metadata |= connHTTPClient
+	assert.Equal(t, DirectionClient, tx.Direction())
+
+	tx.Tup.Metadata = 1 | connHTTPServer
+	assert.Equal(t, DirectionServer, tx.Direction())
+}
+
 func BenchmarkPath(b *testing.B) {
 	tx := ebpfHttpTx{
 		Request_fragment: requestFragment(
@@ -80,6 +80,11 @@ func (tx *WinHttpTransaction) RequestLatency() float64 {
 	return requestLatency(tx.Txn.ResponseLastSeen, tx.Txn.RequestStarted)
 }
 
+// FirstByteLatency always returns 0, as the driver does not report when the response started
+func (tx *WinHttpTransaction) FirstByteLatency() float64 {
+	return 0
+}
+
 func (tx *WinHttpTransaction) ConnTuple() KeyTuple {
 	return KeyTuple{
 		SrcIPHigh: srcIPHigh(&tx.Txn.Tup),
@@ -95,6 +100,12 @@ func (tx *WinHttpTransaction) Method() Method {
 	return Method(tx.Txn.RequestMethod)
 }
 
+// Direction returns DirectionUnknown, as the transactions of the driver are seen once for both ends of the local
+// connections
+func (tx *WinHttpTransaction) Direction() Direction {
+	return DirectionUnknown
+}
+
 func (tx *WinHttpTransaction) StatusCode() uint16 {
 	return tx.Txn.ResponseStatusCode
 }
@@ -164,6 +175,25 @@ func (tx *WinHttpTransaction) SetStatusCode(code uint16) {
 	tx.Txn.ResponseStatusCode = code
 }
 
+// RequestSize always returns 0, as the driver does not report the size of the request
+func (tx *WinHttpTransaction) RequestSize() uint32 {
+	return 0
+}
+
+// ResponseSize always returns 0, as the driver does not report the size of the response
+func (tx *WinHttpTransaction) ResponseSize() uint32 {
+	return 0
+}
+
+func (tx *WinHttpTransaction) SetResponseSize(size uint32) {}
+
+// ResponseFirstSeen always returns 0, as the driver does not report when the response started
+func (tx *WinHttpTransaction) ResponseFirstSeen() uint64 {
+	return 0
+}
+
+func (tx *WinHttpTransaction) SetResponseFirstSeen(fs uint64) {}
+
 func (tx *WinHttpTransaction) ResponseLastSeen() uint64 {
 	return tx.Txn.ResponseLastSeen
 }
@@ -12,18 +12,34 @@ import (
 	"errors"
 	"fmt"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
 
 	manager "github.com/DataDog/ebpf-manager"
 
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
 	filterpkg "github.com/DataDog/datadog-agent/pkg/network/filter"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/cassandra"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/events"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http3"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/memcached"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/tls"
 	errtelemetry "github.com/DataDog/datadog-agent/pkg/network/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/process/monitor"
 	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var (
@@ -40,6 +56,78 @@ type Monitor struct {
 	telemetry      *telemetry
 	statkeeper     *httpStatKeeper
 	processMonitor *monitor.ProcessMonitor
+	cpuPressure    *cpuPressureController
+
+	// tlsBytes holds the number of bytes of each connection that went through the TLS hooks, it is nil when no TLS
+	// library is monitored
+	tlsBytes *ebpf.Map
+
+	// http2FrameStats holds the frames of each HTTP/2 connection, it is nil when the HTTP/2 monitoring is disabled
+	http2FrameStats *ebpf.Map
+
+	// kafkaConsumer and kafkaStatkeeper process the Kafka transactions, they are nil when the Kafka monitoring is
+	// disabled
+	kafkaConsumer   *events.Consumer
+	kafkaStatkeeper *kafka.StatKeeper
+
+	// postgresConsumer and postgresStatkeeper process the Postgres transactions, they are nil when the Postgres
+	// monitoring is disabled
+	postgresConsumer   *events.Consumer
+	postgresStatkeeper *postgres.StatKeeper
+
+	// mysqlConsumer and mysqlStatkeeper process the MySQL transactions, they are nil when the MySQL monitoring is
+	// disabled
+	mysqlConsumer   *events.Consumer
+	mysqlStatkeeper *mysql.StatKeeper
+
+	// redisConsumer and redisStatkeeper process the Redis transactions, they are nil when the Redis monitoring is
+	// disabled
+	redisConsumer   *events.Consumer
+	redisStatkeeper *redis.StatKeeper
+
+	// mongoConsumer and mongoStatkeeper process the Mongo transactions, they are nil when the Mongo monitoring is
+	// disabled
+	mongoConsumer   *events.Consumer
+	mongoStatkeeper *mongo.StatKeeper
+
+	// amqpConsumer and amqpStatkeeper process the AMQP messages, they are nil when the AMQP monitoring is
+	// disabled
+	amqpConsumer   *events.Consumer
+	amqpStatkeeper *amqp.StatKeeper
+
+	// grpcConsumer and grpcStatkeeper process the header blocks of the HTTP/2 connections, they are nil when the gRPC
+	// monitoring is disabled
+	grpcConsumer   *events.Consumer
+	grpcStatkeeper *grpc.StatKeeper
+
+	// http3Consumer and http3Statkeeper process the QUIC Initial packets, they are nil when the HTTP/3 monitoring is
+	// disabled
+	http3Consumer   *events.Consumer
+	http3Statkeeper *http3.StatKeeper
+
+	// tlsHandshakeConsumer and tlsHandshakes process the TLS hello messages, they are nil when the TLS handshake
+	// monitoring is disabled
+	tlsHandshakeConsumer *events.Consumer
+	tlsHandshakes        *tls.HandshakeCache
+
+	// cassandraConsumer and cassandraStatkeeper process the Cassandra transactions, they are nil when the Cassandra
+	// monitoring is disabled
+	cassandraConsumer   *events.Consumer
+	cassandraStatkeeper *cassandra.StatKeeper
+
+	// memcachedConsumer and memcachedStatkeeper process the memcached transactions, they are nil when the memcached
+	// monitoring is disabled
+	memcachedConsumer   *events.Consumer
+	memcachedStatkeeper *memcached.StatKeeper
+
+	// dnsTLSConsumer processes the plaintext of the DNS over TLS connections, which is handed to dnsTLSHandler. It is
+	// nil when the DNS over TLS monitoring is disabled, or when no handler was set.
+	dnsTLSConsumer *events.Consumer
+	dnsTLSHandler  func(dns.TLSSegment)
+
+	// fallback monitors the HTTP traffic on the kernels older than MinimumKernelVersion, or without eBPF when
+	// network_config.enable_ebpf_less is set. The eBPF program and the consumers are nil when it is set.
+	fallback fallbackMonitor
 
 	// termination
 	closeFilterFn func()
@@ -59,14 +147,22 @@ func NewMonitor(c *config.Config, offsets []manager.ConstantEditor, sockFD *ebpf
 		return nil, fmt.Errorf("http monitoring is disabled")
 	}
 
+	if c.EnableEbpfLess {
+		return newEbpfLessMonitor(c)
+	}
+
 	kversion, err := kernel.HostVersion()
 	if err != nil {
 		return nil, &ErrNotSupported{fmt.Errorf("couldn't determine current kernel version: %w", err)}
 	}
 
+	if FallbackSupported(kversion) {
+		return newFallbackMonitor(c)
+	}
+
 	if kversion < MinimumKernelVersion {
 		return nil, &ErrNotSupported{
-			fmt.Errorf("http feature not available on pre %s kernels", MinimumKernelVersion.String()),
+			fmt.Errorf("http feature not available on pre %s kernels", MinimumFallbackKernelVersion.String()),
 		}
 	}
 
@@ -96,23 +192,165 @@ func NewMonitor(c *config.Config, offsets []manager.ConstantEditor, sockFD *ebpf
 	}
 
 	statkeeper := newHTTPStatkeeper(c, telemetry)
+	if len(c.HTTPCaptureHeaders) > 0 {
+		if requestHeaders, _, err := mgr.GetMap(httpRequestHeadersMap); err == nil {
+			statkeeper.requestHeaders = newRequestHeadersReader(requestHeaders)
+		} else {
+			log.Warnf("error retrieving the map of the request headers, they won't be captured: %s", err)
+		}
+	}
+	cpuPressure := newCPUPressureController(c, statkeeper.setBodyParsing, mgr.stopSubprograms)
+	if cpuPressure != nil {
+		statkeeper.sample = cpuPressure.Sample
+	}
 	processMonitor := monitor.GetProcessMonitor()
 
+	var tlsBytes *ebpf.Map
+	if c.EnableHTTPSMonitoring || c.EnableGoTLSSupport {
+		tlsBytes, _, _ = mgr.GetMap(tlsConnBytesMap)
+	}
+
+	var http2FrameStats *ebpf.Map
+	if c.EnableHTTP2Monitoring {
+		http2FrameStats, _, _ = mgr.GetMap(http2FrameStatsMap)
+	}
+
+	var kafkaStatkeeper *kafka.StatKeeper
+	if c.EnableKafkaMonitoring {
+		kafkaStatkeeper = kafka.NewStatKeeper(c)
+	}
+
+	var postgresStatkeeper *postgres.StatKeeper
+	if c.EnablePostgresMonitoring {
+		postgresStatkeeper = postgres.NewStatKeeper(c)
+	}
+
+	var mysqlStatkeeper *mysql.StatKeeper
+	if c.EnableMySQLMonitoring {
+		mysqlStatkeeper = mysql.NewStatKeeper(c)
+	}
+
+	var redisStatkeeper *redis.StatKeeper
+	if c.EnableRedisMonitoring {
+		redisStatkeeper = redis.NewStatKeeper(c)
+	}
+
+	var mongoStatkeeper *mongo.StatKeeper
+	if c.EnableMongoMonitoring {
+		mongoStatkeeper = mongo.NewStatKeeper(c)
+	}
+
+	var amqpStatkeeper *amqp.StatKeeper
+	if c.EnableAMQPMonitoring {
+		amqpStatkeeper = amqp.NewStatKeeper(c)
+	}
+
+	var grpcStatkeeper *grpc.StatKeeper
+	if c.EnableGRPCMonitoring {
+		grpcStatkeeper = grpc.NewStatKeeper(c)
+	}
+
+	var http3Statkeeper *http3.StatKeeper
+	if c.EnableHTTP3Monitoring {
+		http3Statkeeper = http3.NewStatKeeper(c)
+	}
+
+	var tlsHandshakes *tls.HandshakeCache
+	if c.EnableTLSHandshakeMonitoring {
+		tlsHandshakes = tls.NewHandshakeCache(c)
+	}
+
+	var cassandraStatkeeper *cassandra.StatKeeper
+	if c.EnableCassandraMonitoring {
+		cassandraStatkeeper = cassandra.NewStatKeeper(c)
+	}
+
+	var memcachedStatkeeper *memcached.StatKeeper
+	if c.EnableMemcachedMonitoring {
+		memcachedStatkeeper = memcached.NewStatKeeper(c)
+	}
+
 	return &Monitor{
 		ebpfProgram:    mgr,
 		telemetry:      telemetry,
 		closeFilterFn:  closeFilterFn,
 		statkeeper:     statkeeper,
 		processMonitor: processMonitor,
+		cpuPressure:    cpuPressure,
+		tlsBytes:       tlsBytes,
+
+		http2FrameStats: http2FrameStats,
+		kafkaStatkeeper: kafkaStatkeeper,
+
+		postgresStatkeeper:  postgresStatkeeper,
+		mysqlStatkeeper:     mysqlStatkeeper,
+		redisStatkeeper:     redisStatkeeper,
+		mongoStatkeeper:     mongoStatkeeper,
+		amqpStatkeeper:      amqpStatkeeper,
+		grpcStatkeeper:      grpcStatkeeper,
+		http3Statkeeper:     http3Statkeeper,
+		tlsHandshakes:       tlsHandshakes,
+		cassandraStatkeeper: cassandraStatkeeper,
+		memcachedStatkeeper: memcachedStatkeeper,
 	}, nil
 }
 
+// newFallbackMonitor returns a Monitor of the plaintext HTTP traffic only, for the kernels older than
+// MinimumKernelVersion
+func newFallbackMonitor(c *config.Config) (*Monitor, error) {
+	log.Infof("kernel older than %s, http monitoring falls back to a socket filter: https and the other protocols are not monitored", MinimumKernelVersion)
+
+	telemetry, err := newTelemetry()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Monitor{
+		telemetry:  telemetry,
+		statkeeper: newHTTPStatkeeper(c, telemetry),
+	}
+	m.fallback, err = newSocketFilterFallback(c, m.processFallback)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up http fallback ebpf program: %w", err)
+	}
+	return m, nil
+}
+
+// newEbpfLessMonitor returns a Monitor of the plaintext HTTP and HTTP/2 traffic only, which parses the packets read
+// from a packet socket rather than loading eBPF programs
+func newEbpfLessMonitor(c *config.Config) (*Monitor, error) {
+	log.Info("http monitoring running without eBPF: https and the protocols other than http and http2 are not monitored")
+
+	telemetry, err := newTelemetry()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Monitor{
+		telemetry:  telemetry,
+		statkeeper: newHTTPStatkeeper(c, telemetry),
+	}
+	m.fallback, err = newPacketSourceFallback(c, m.processFallback)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up http packet source: %w", err)
+	}
+	return m, nil
+}
+
 // Start consuming HTTP events
 func (m *Monitor) Start() error {
 	if m == nil {
 		return nil
 	}
 
+	if m.fallback != nil {
+		if err := m.fallback.start(); err != nil {
+			startupError = fmt.Errorf("could not enable http monitoring: %s", err)
+			return startupError
+		}
+		return nil
+	}
+
 	var err error
 
 	defer func() {
@@ -138,6 +376,150 @@ func (m *Monitor) Start() error {
 	}
 	m.consumer.Start()
 
+	if m.kafkaStatkeeper != nil {
+		m.kafkaConsumer, err = events.NewConsumer(
+			kafkaProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processKafka,
+		)
+		if err != nil {
+			return err
+		}
+		m.kafkaConsumer.Start()
+	}
+
+	if m.postgresStatkeeper != nil {
+		m.postgresConsumer, err = events.NewConsumer(
+			postgresProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processPostgres,
+		)
+		if err != nil {
+			return err
+		}
+		m.postgresConsumer.Start()
+	}
+
+	if m.mysqlStatkeeper != nil {
+		m.mysqlConsumer, err = events.NewConsumer(
+			mysqlProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processMySQL,
+		)
+		if err != nil {
+			return err
+		}
+		m.mysqlConsumer.Start()
+	}
+
+	if m.redisStatkeeper != nil {
+		m.redisConsumer, err = events.NewConsumer(
+			redisProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processRedis,
+		)
+		if err != nil {
+			return err
+		}
+		m.redisConsumer.Start()
+	}
+
+	if m.mongoStatkeeper != nil {
+		m.mongoConsumer, err = events.NewConsumer(
+			mongoProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processMongo,
+		)
+		if err != nil {
+			return err
+		}
+		m.mongoConsumer.Start()
+	}
+
+	if m.amqpStatkeeper != nil {
+		m.amqpConsumer, err = events.NewConsumer(
+			amqpProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processAMQP,
+		)
+		if err != nil {
+			return err
+		}
+		m.amqpConsumer.Start()
+	}
+
+	if m.grpcStatkeeper != nil {
+		m.grpcConsumer, err = events.NewConsumer(
+			grpcProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processGRPC,
+		)
+		if err != nil {
+			return err
+		}
+		m.grpcConsumer.Start()
+	}
+
+	if m.http3Statkeeper != nil {
+		m.http3Consumer, err = events.NewConsumer(
+			http3Protocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processHTTP3,
+		)
+		if err != nil {
+			return err
+		}
+		m.http3Consumer.Start()
+	}
+
+	if m.tlsHandshakes != nil {
+		m.tlsHandshakeConsumer, err = events.NewConsumer(
+			tlsHandshakeProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processTLSHandshake,
+		)
+		if err != nil {
+			return err
+		}
+		m.tlsHandshakeConsumer.Start()
+	}
+
+	if m.cassandraStatkeeper != nil {
+		m.cassandraConsumer, err = events.NewConsumer(
+			cassandraProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processCassandra,
+		)
+		if err != nil {
+			return err
+		}
+		m.cassandraConsumer.Start()
+	}
+
+	if m.memcachedStatkeeper != nil {
+		m.memcachedConsumer, err = events.NewConsumer(
+			memcachedProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processMemcached,
+		)
+		if err != nil {
+			return err
+		}
+		m.memcachedConsumer.Start()
+	}
+
+	if m.dnsTLSHandler != nil {
+		m.dnsTLSConsumer, err = events.NewConsumer(
+			dnsTLSProtocol,
+			m.ebpfProgram.Manager.Manager,
+			m.processDNSTLS,
+		)
+		if err != nil {
+			return err
+		}
+		m.dnsTLSConsumer.Start()
+	}
+
 	err = m.ebpfProgram.Start()
 	if err != nil {
 		return err
@@ -145,7 +527,12 @@ func (m *Monitor) Start() error {
 
 	// Need to explicitly save the error in `err` so the defer function could save the startup error.
 	err = m.processMonitor.Initialize()
-	return err
+	if err != nil {
+		return err
+	}
+
+	m.cpuPressure.Start()
+	return nil
 }
 
 func (m *Monitor) GetUSMStats() map[string]interface{} {
@@ -154,9 +541,14 @@ func (m *Monitor) GetUSMStats() map[string]interface{} {
 			"Error": startupError.Error(),
 		}
 	}
-	return map[string]interface{}{
-		"last_check": m.telemetry.then,
+	stats := map[string]interface{}{
+		"last_check":      m.telemetry.then,
+		"truncated_paths": m.telemetry.truncated.Get(),
+	}
+	if m.cpuPressure != nil {
+		stats["cpu_pressure"] = m.cpuPressure.GetStats()
 	}
+	return stats
 }
 
 // GetHTTPStats returns a map of HTTP stats stored in the following format:
@@ -166,20 +558,235 @@ func (m *Monitor) GetHTTPStats() map[Key]*RequestStats {
 		return nil
 	}
 
-	m.consumer.Sync()
+	if m.fallback != nil {
+		m.fallback.poll()
+	} else {
+		m.consumer.Sync()
+	}
 	m.telemetry.log()
 	return m.statkeeper.GetAndResetAllStats()
 }
 
+// GetKafkaStats returns a map of Kafka stats stored in the following format:
+// [source, dest tuple, topic, API key and version] -> RequestStat object
+func (m *Monitor) GetKafkaStats() map[kafka.Key]*kafka.RequestStat {
+	if m == nil || m.kafkaConsumer == nil {
+		return nil
+	}
+
+	m.kafkaConsumer.Sync()
+	return m.kafkaStatkeeper.GetAndResetAllStats()
+}
+
+// GetPostgresStats returns a map of Postgres stats stored in the following format:
+// [source, dest tuple, query fingerprint] -> RequestStat object
+func (m *Monitor) GetPostgresStats() map[postgres.Key]*postgres.RequestStat {
+	if m == nil || m.postgresConsumer == nil {
+		return nil
+	}
+
+	m.postgresConsumer.Sync()
+	return m.postgresStatkeeper.GetAndResetAllStats()
+}
+
+// GetMySQLStats returns a map of MySQL stats stored in the following format:
+// [source, dest tuple, query fingerprint] -> RequestStat object
+func (m *Monitor) GetMySQLStats() map[mysql.Key]*mysql.RequestStat {
+	if m == nil || m.mysqlConsumer == nil {
+		return nil
+	}
+
+	m.mysqlConsumer.Sync()
+	return m.mysqlStatkeeper.GetAndResetAllStats()
+}
+
+// GetRedisStats returns a map of Redis stats stored in the following format:
+// [source, dest tuple, command name] -> RequestStat object
+func (m *Monitor) GetRedisStats() map[redis.Key]*redis.RequestStat {
+	if m == nil || m.redisConsumer == nil {
+		return nil
+	}
+
+	m.redisConsumer.Sync()
+	return m.redisStatkeeper.GetAndResetAllStats()
+}
+
+// GetMongoStats returns a map of Mongo stats stored in the following format:
+// [source, dest tuple, command name, collection] -> RequestStat object
+func (m *Monitor) GetMongoStats() map[mongo.Key]*mongo.RequestStat {
+	if m == nil || m.mongoConsumer == nil {
+		return nil
+	}
+
+	m.mongoConsumer.Sync()
+	return m.mongoStatkeeper.GetAndResetAllStats()
+}
+
+// GetAMQPStats returns a map of AMQP stats stored in the following format:
+// [source, dest tuple, exchange, routing key, method] -> RequestStat object
+func (m *Monitor) GetAMQPStats() map[amqp.Key]*amqp.RequestStat {
+	if m == nil || m.amqpConsumer == nil {
+		return nil
+	}
+
+	m.amqpConsumer.Sync()
+	return m.amqpStatkeeper.GetAndResetAllStats()
+}
+
+// SetDNSOverTLSHandler sets the handler of the plaintext of the DNS over TLS connections, which is only read when the
+// DNS over TLS monitoring is enabled. It must be called before Start, and the handler must be safe for concurrent use.
+func (m *Monitor) SetDNSOverTLSHandler(handler func(dns.TLSSegment)) {
+	if m == nil || m.ebpfProgram == nil || !m.ebpfProgram.cfg.EnableDNSOverTLSMonitoring {
+		return
+	}
+	m.dnsTLSHandler = handler
+}
+
+// GetGRPCStats returns a map of gRPC stats stored in the following format:
+// [source, dest tuple, service, method] -> RequestStat object
+func (m *Monitor) GetGRPCStats() map[grpc.Key]*grpc.RequestStat {
+	if m == nil || m.grpcConsumer == nil {
+		return nil
+	}
+
+	m.grpcConsumer.Sync()
+	return m.grpcStatkeeper.GetAndResetAllStats()
+}
+
+// GetHTTP3Stats returns a map of HTTP/3 stats stored in the following format:
+// [source, dest tuple, server name] -> RequestStat object
+func (m *Monitor) GetHTTP3Stats() map[http3.Key]*http3.RequestStat {
+	if m == nil || m.http3Consumer == nil {
+		return nil
+	}
+
+	m.http3Consumer.Sync()
+	return m.http3Statkeeper.GetAndResetAllStats()
+}
+
+// GetTLSHandshakes returns the handshakes of the given TCP connections, whose tuples may be in either direction. The
+// connections whose handshake was not seen are not part of the returned map.
+func (m *Monitor) GetTLSHandshakes(tuples []tls.KeyTuple) map[tls.KeyTuple]tls.Handshake {
+	if m == nil || m.tlsHandshakeConsumer == nil {
+		return nil
+	}
+
+	m.tlsHandshakeConsumer.Sync()
+	return m.tlsHandshakes.Get(tuples, time.Now())
+}
+
+// GetCassandraStats returns a map of Cassandra stats stored in the following format:
+// [source, dest tuple, keyspace] -> RequestStat object
+func (m *Monitor) GetCassandraStats() map[cassandra.Key]*cassandra.RequestStat {
+	if m == nil || m.cassandraConsumer == nil {
+		return nil
+	}
+
+	m.cassandraConsumer.Sync()
+	return m.cassandraStatkeeper.GetAndResetAllStats()
+}
+
+// GetMemcachedStats returns a map of memcached stats stored in the following format:
+// [source, dest tuple, operation] -> RequestStat object
+func (m *Monitor) GetMemcachedStats() map[memcached.Key]*memcached.RequestStat {
+	if m == nil || m.memcachedConsumer == nil {
+		return nil
+	}
+
+	m.memcachedConsumer.Sync()
+	return m.memcachedStatkeeper.GetAndResetAllStats()
+}
+
+// GetTLSBytes returns the number of bytes read and written by the application through the TLS hooks for the given TCP
+// connection. The TLS hooks don't know the PID nor the network namespace of the connection, and keep its tuple with
+// the ephemeral port as source port, so both directions of the tuple are looked up.
+func (m *Monitor) GetTLSBytes(tuple netebpf.ConnTuple) uint64 {
+	if m == nil || m.tlsBytes == nil {
+		return 0
+	}
+
+	tuple.Pid, tuple.Netns = 0, 0
+	var bytes uint64
+	if err := m.tlsBytes.Lookup(unsafe.Pointer(&tuple), unsafe.Pointer(&bytes)); err == nil {
+		return bytes
+	}
+	flipped := flipConnTuple(tuple)
+	if err := m.tlsBytes.Lookup(unsafe.Pointer(&flipped), unsafe.Pointer(&bytes)); err == nil {
+		return bytes
+	}
+	return 0
+}
+
+// DeleteTLSBytes removes the TLS bytes entry of the given TCP connection, once it is closed
+func (m *Monitor) DeleteTLSBytes(tuple netebpf.ConnTuple) {
+	if m == nil || m.tlsBytes == nil {
+		return
+	}
+
+	tuple.Pid, tuple.Netns = 0, 0
+	_ = m.tlsBytes.Delete(unsafe.Pointer(&tuple))
+	flipped := flipConnTuple(tuple)
+	_ = m.tlsBytes.Delete(unsafe.Pointer(&flipped))
+}
+
+func flipConnTuple(tuple netebpf.ConnTuple) netebpf.ConnTuple {
+	tuple.Sport, tuple.Dport = tuple.Dport, tuple.Sport
+	tuple.Saddr_h, tuple.Daddr_h = tuple.Daddr_h, tuple.Saddr_h
+	tuple.Saddr_l, tuple.Daddr_l = tuple.Daddr_l, tuple.Saddr_l
+	return tuple
+}
+
 // Stop HTTP monitoring
 func (m *Monitor) Stop() {
 	if m == nil {
 		return
 	}
 
+	if m.fallback != nil {
+		m.fallback.stop()
+		return
+	}
+
+	m.cpuPressure.Stop()
 	m.processMonitor.Stop()
 	m.ebpfProgram.Close()
 	m.consumer.Stop()
+	if m.kafkaConsumer != nil {
+		m.kafkaConsumer.Stop()
+	}
+	if m.postgresConsumer != nil {
+		m.postgresConsumer.Stop()
+	}
+	if m.mysqlConsumer != nil {
+		m.mysqlConsumer.Stop()
+	}
+	if m.redisConsumer != nil {
+		m.redisConsumer.Stop()
+	}
+	if m.mongoConsumer != nil {
+		m.mongoConsumer.Stop()
+	}
+	if m.amqpConsumer != nil {
+		m.amqpConsumer.Stop()
+	}
+	if m.grpcConsumer != nil {
+		m.grpcConsumer.Stop()
+	}
+	if m.http3Consumer != nil {
+		m.http3Consumer.Stop()
+	}
+	if m.tlsHandshakeConsumer != nil {
+		m.tlsHandshakeConsumer.Stop()
+	}
+	if m.cassandraConsumer != nil {
+		m.cassandraConsumer.Stop()
+	}
+	if m.memcachedConsumer != nil {
+		m.memcachedConsumer.Stop()
+	}
+	if m.dnsTLSConsumer != nil {
+		m.dnsTLSConsumer.Stop()
+	}
 	m.closeFilterFn()
 }
 
@@ -189,7 +796,80 @@ func (m *Monitor) process(data []byte) {
 	m.statkeeper.Process(tx)
 }
 
+func (m *Monitor) processFallback(tx httpTX) {
+	m.telemetry.count(tx)
+	m.statkeeper.Process(tx)
+}
+
+func (m *Monitor) processKafka(data []byte) {
+	tx := (*kafka.EbpfTx)(unsafe.Pointer(&data[0]))
+	m.kafkaStatkeeper.Process(tx)
+}
+
+func (m *Monitor) processPostgres(data []byte) {
+	tx := (*postgres.EbpfTx)(unsafe.Pointer(&data[0]))
+	m.postgresStatkeeper.Process(tx)
+}
+
+func (m *Monitor) processMySQL(data []byte) {
+	tx := (*mysql.EbpfTx)(unsafe.Pointer(&data[0]))
+	m.mysqlStatkeeper.Process(tx)
+}
+
+func (m *Monitor) processRedis(data []byte) {
+	tx := (*redis.EbpfTx)(unsafe.Pointer(&data[0]))
+	m.redisStatkeeper.Process(tx)
+}
+
+func (m *Monitor) processMongo(data []byte) {
+	tx := (*mongo.EbpfTx)(unsafe.Pointer(&data[0]))
+	m.mongoStatkeeper.Process(tx)
+}
+
+func (m *Monitor) processAMQP(data []byte) {
+	tx := (*amqp.EbpfTx)(unsafe.Pointer(&data[0]))
+	m.amqpStatkeeper.Process(tx)
+}
+
+func (m *Monitor) processGRPC(data []byte) {
+	headers := (*grpc.EbpfHeaders)(unsafe.Pointer(&data[0]))
+	m.grpcStatkeeper.Process(headers)
+}
+
+func (m *Monitor) processHTTP3(data []byte) {
+	initial := (*http3.EbpfInitial)(unsafe.Pointer(&data[0]))
+	m.http3Statkeeper.Process(initial)
+}
+
+func (m *Monitor) processTLSHandshake(data []byte) {
+	handshake := (*tls.EbpfHandshake)(unsafe.Pointer(&data[0]))
+	m.tlsHandshakes.Process(handshake)
+}
+
+func (m *Monitor) processCassandra(data []byte) {
+	tx := (*cassandra.EbpfTx)(unsafe.Pointer(&data[0]))
+	m.cassandraStatkeeper.Process(tx)
+}
+
+func (m *Monitor) processMemcached(data []byte) {
+	tx := (*memcached.EbpfTx)(unsafe.Pointer(&data[0]))
+	m.memcachedStatkeeper.Process(tx)
+}
+
+func (m *Monitor) processDNSTLS(data []byte) {
+	segment := (*dns.EbpfTLSSegment)(unsafe.Pointer(&data[0]))
+	// the timestamps of the segments are monotonic, while the DNS snooper expects the time of the segments
+	ts := time.Now()
+	if now, err := ddebpf.NowNanoseconds(); err == nil && uint64(now) > segment.Timestamp {
+		ts = ts.Add(-time.Duration(uint64(now) - segment.Timestamp))
+	}
+	m.dnsTLSHandler(segment.TLSSegment(ts))
+}
+
 // DumpMaps dumps the maps associated with the monitor
 func (m *Monitor) DumpMaps(maps ...string) (string, error) {
+	if m.fallback != nil {
+		return m.fallback.DumpMaps(maps...)
+	}
 	return m.ebpfProgram.DumpMaps(maps...)
 }
@@ -9,7 +9,9 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -22,13 +24,16 @@ import (
 	"sync"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/network/config"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
 	netlink "github.com/DataDog/datadog-agent/pkg/network/netlink/testutil"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http/testutil"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/memcached"
 )
 
 const (
@@ -398,6 +403,284 @@ func TestKeepAliveWithIncompleteResponseRegression(t *testing.T) {
 	includesRequest(t, stats, &nethttp.Request{URL: url, Method: "GET"})
 }
 
+// TestHTTPMonitorPipelining sends multiple requests on a connection before reading any response (HTTP/1.1
+// pipelining), and ensures every response is matched with the right request.
+func TestHTTPMonitorPipelining(t *testing.T) {
+	monitor := newHTTPMonitor(t)
+
+	serverAddr := "127.0.0.1:8080"
+	// the server is slow to respond, so all the requests are sent before the first response
+	srvDoneFn := testutil.HTTPServer(t, serverAddr, testutil.Options{
+		EnableKeepAlives: true,
+		SlowResponse:     100 * time.Millisecond,
+	})
+	t.Cleanup(srvDoneFn)
+
+	c, err := net.DialTimeout("tcp", serverAddr, 5*time.Second)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// one request in-flight and as many requests as can be queued in eBPF
+	paths := []string{"/200/first", "/201/second", "/404/third", "/500/fourth", "/202/fifth"}
+	require.Len(t, paths, maxPipelinedRequests+1)
+	for _, path := range paths {
+		// the requests are sent in separate segments, as only the beginning of a segment is inspected
+		_, err = c.Write([]byte(fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\n\r\n", path, serverAddr)))
+		require.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	reader := bufio.NewReader(c)
+	var requests []*nethttp.Request
+	for _, path := range paths {
+		req, err := nethttp.NewRequest(nethttp.MethodGet, fmt.Sprintf("http://%s%s", serverAddr, path), nil)
+		require.NoError(t, err)
+		resp, err := nethttp.ReadResponse(reader, req)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		require.Equal(t, testutil.StatusFromPath(path), resp.StatusCode)
+		requests = append(requests, req)
+	}
+
+	// closing the connection flushes the last transaction
+	c.Close()
+	assertAllRequestsExists(t, monitor, requests)
+}
+
+// TestHTTPMonitorChunkedResponse ensures that a chunked response is reported as soon as its last chunk is seen, with
+// a latency accounting for all its chunks, even though the connection is kept alive. The last chunk is either written
+// along with the previous one, or on its own, as done by the servers streaming their responses.
+func TestHTTPMonitorChunkedResponse(t *testing.T) {
+	monitor := newHTTPMonitor(t)
+
+	const serverAddr = "127.0.0.1:8080"
+	const chunkDelay = 100 * time.Millisecond
+	tests := []struct {
+		name   string
+		path   string
+		chunks []string
+	}{
+		{
+			name: "last chunk with the previous one",
+			path: "/200/chunked",
+			chunks: []string{
+				"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n",
+				"5\r\nhello\r\n",
+				"6\r\n world\r\n0\r\n\r\n",
+			},
+		},
+		{
+			name: "last chunk on its own",
+			path: "/200/last-chunk",
+			chunks: []string{
+				"HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n",
+				"5\r\nhello\r\n",
+				"6\r\n world\r\n",
+				"0\r\n\r\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srvErr := make(chan error, 1)
+			srvDone := make(chan struct{})
+			srv := testutil.NewTCPServer(serverAddr, func(c net.Conn) {
+				defer c.Close()
+				srvErr <- func() error {
+					if _, err := nethttp.ReadRequest(bufio.NewReader(c)); err != nil {
+						return err
+					}
+					for i, chunk := range tt.chunks {
+						if i > 0 {
+							time.Sleep(chunkDelay)
+						}
+						if _, err := c.Write([]byte(chunk)); err != nil {
+							return err
+						}
+					}
+					return nil
+				}()
+				// keep the connection open until the end of the test
+				<-srvDone
+			})
+			done := make(chan struct{})
+			srv.Run(done)
+			t.Cleanup(func() { close(done) })
+			t.Cleanup(func() { close(srvDone) })
+
+			c, err := net.DialTimeout("tcp", serverAddr, 5*time.Second)
+			require.NoError(t, err)
+			t.Cleanup(func() { c.Close() })
+
+			req, err := nethttp.NewRequest(nethttp.MethodGet, fmt.Sprintf("http://%s%s", serverAddr, tt.path), nil)
+			require.NoError(t, err)
+			require.NoError(t, req.Write(c))
+			resp, err := nethttp.ReadResponse(bufio.NewReader(c), req)
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, <-srvErr)
+			require.Equal(t, "hello world", string(body))
+
+			var stats *RequestStat
+			require.Eventually(t, func() bool {
+				for key, s := range monitor.GetHTTPStats() {
+					if key.Path.Content == req.URL.Path && s.HasStats(200) {
+						stats = s.Stats(200)
+						return true
+					}
+				}
+				return false
+			}, 3*time.Second, 100*time.Millisecond, "chunked transaction not reported while the connection is still open")
+
+			assert.Equal(t, 1, stats.Count)
+			assert.GreaterOrEqual(t, stats.FirstLatencySample, float64((time.Duration(len(tt.chunks)-1) * chunkDelay).Nanoseconds()))
+		})
+	}
+}
+
+// TestHTTPMonitorExpectContinue uploads a request body after an interim 100 Continue response, and ensures the
+// transaction is reported once, with the final status code and a latency covering the upload.
+func TestHTTPMonitorExpectContinue(t *testing.T) {
+	monitor := newHTTPMonitor(t)
+
+	const serverAddr = "127.0.0.1:8080"
+	const uploadDelay = 300 * time.Millisecond
+	const body = "hello world"
+
+	srvDone := make(chan struct{})
+	srv := testutil.NewTCPServer(serverAddr, func(c net.Conn) {
+		defer c.Close()
+		reader := bufio.NewReader(c)
+		req, err := nethttp.ReadRequest(reader)
+		require.NoError(t, err)
+		_, err = c.Write([]byte("HTTP/1.1 100 Continue\r\n\r\n"))
+		require.NoError(t, err)
+		_, err = io.ReadAll(io.LimitReader(reader, req.ContentLength))
+		require.NoError(t, err)
+		_, err = c.Write([]byte("HTTP/1.1 201 Created\r\nContent-Length: 0\r\n\r\n"))
+		require.NoError(t, err)
+		// keep the connection open until the end of the test
+		<-srvDone
+	})
+	done := make(chan struct{})
+	srv.Run(done)
+	t.Cleanup(func() { close(done) })
+	t.Cleanup(func() { close(srvDone) })
+
+	c, err := net.DialTimeout("tcp", serverAddr, 5*time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() { c.Close() })
+
+	path := "/201/upload"
+	_, err = fmt.Fprintf(c, "POST %s HTTP/1.1\r\nHost: %s\r\nExpect: 100-continue\r\nContent-Length: %d\r\n\r\n", path, serverAddr, len(body))
+	require.NoError(t, err)
+	reader := bufio.NewReader(c)
+	resp, err := nethttp.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	require.Equal(t, nethttp.StatusContinue, resp.StatusCode)
+
+	time.Sleep(uploadDelay)
+	_, err = c.Write([]byte(body))
+	require.NoError(t, err)
+	resp, err = nethttp.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	require.Equal(t, nethttp.StatusCreated, resp.StatusCode)
+
+	var stats *RequestStat
+	require.Eventually(t, func() bool {
+		for key, s := range monitor.GetHTTPStats() {
+			if key.Path.Content == path && s.HasStats(201) {
+				stats = s.Stats(201)
+				return true
+			}
+		}
+		return false
+	}, 3*time.Second, 100*time.Millisecond, "transaction not reported with its final status code")
+
+	assert.Equal(t, 1, stats.Count)
+	assert.GreaterOrEqual(t, stats.FirstLatencySample, float64(uploadDelay.Nanoseconds()))
+	for key, s := range monitor.GetHTTPStats() {
+		assert.False(t, s.HasStats(100), "interim response reported for %s", key.Path.Content)
+	}
+}
+
+// TestHTTPMonitorServerKilledMidRequest kills the server while requests are in flight, and ensures the half-completed
+// transactions are neither reported nor leaked in the in-flight map.
+func TestHTTPMonitorServerKilledMidRequest(t *testing.T) {
+	monitor := newHTTPMonitor(t)
+
+	serverAddr := "127.0.0.1:8080"
+	srv := testutil.HTTPServerWithControl(t, serverAddr, testutil.Options{
+		SlowResponse: time.Second,
+	})
+
+	requests := make(chan *nethttp.Request, 10)
+	wg := sync.WaitGroup{}
+	for i := 0; i < cap(requests); i++ {
+		// requestGenerator isn't safe for concurrent use, so every goroutine gets its own
+		killedRequestFn := requestGenerator(t, fmt.Sprintf("%s/ignore", serverAddr), emptyBody)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			requests <- killedRequestFn()
+		}()
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	srv.Kill()
+	wg.Wait()
+	close(requests)
+
+	stats := monitor.GetHTTPStats()
+	for req := range requests {
+		requestNotIncluded(t, stats, req)
+	}
+	require.Eventually(t, func() bool {
+		return countInFlightEntries(t, monitor) == 0
+	}, 3*time.Second, 100*time.Millisecond, "in-flight transactions leaked after the server was killed")
+}
+
+// TestHTTPMonitorAbortedResponse ensures that transactions for which the server reset the connection in the middle of
+// the response are not leaked in the in-flight map.
+func TestHTTPMonitorAbortedResponse(t *testing.T) {
+	monitor := newHTTPMonitor(t)
+
+	serverAddr := "127.0.0.1:8080"
+	srvDoneFn := testutil.HTTPServer(t, serverAddr, testutil.Options{
+		AbortMidResponse: true,
+	})
+	t.Cleanup(srvDoneFn)
+
+	resp, err := nethttp.Get(fmt.Sprintf("http://%s/200/aborted", serverAddr))
+	if err == nil {
+		resp.Body.Close()
+	}
+	require.Error(t, err)
+
+	require.Eventually(t, func() bool {
+		monitor.GetHTTPStats()
+		return countInFlightEntries(t, monitor) == 0
+	}, 3*time.Second, 100*time.Millisecond, "in-flight transactions leaked after the response was aborted")
+}
+
+func countInFlightEntries(t *testing.T, monitor *Monitor) int {
+	inFlightMap, _, err := monitor.ebpfProgram.GetMap(httpInFlightMap)
+	require.NoError(t, err)
+
+	var key netebpf.ConnTuple
+	var value ebpfHttpTx
+	count := 0
+	iter := inFlightMap.Iterate()
+	for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+		count++
+	}
+	require.NoError(t, iter.Err())
+	return count
+}
+
 func assertAllRequestsExists(t *testing.T, monitor *Monitor, requests []*nethttp.Request) {
 	requestsExist := make([]bool, len(requests))
 	for i := 0; i < 10; i++ {
@@ -543,6 +826,78 @@ func countRequestOccurrences(allStats map[Key]*RequestStats, req *nethttp.Reques
 	return occurrences
 }
 
+func TestMemcachedMonitoring(t *testing.T) {
+	const serverPort = "11211"
+	memcached.RunServer(t, "127.0.0.1", serverPort)
+
+	cfg := config.New()
+	cfg.EnableHTTPMonitoring = true
+	cfg.EnableMemcachedMonitoring = true
+	monitor, err := NewMonitor(cfg, nil, nil, nil)
+	skipIfNotSupported(t, err)
+	require.NoError(t, err)
+	t.Cleanup(monitor.Stop)
+	err = monitor.Start()
+	skipIfNotSupported(t, err)
+	require.NoError(t, err)
+
+	c, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", serverPort))
+	require.NoError(t, err)
+	defer c.Close()
+	clientPort := uint16(c.LocalAddr().(*net.TCPAddr).Port)
+	reader := bufio.NewReader(c)
+
+	// the text commands are answered by a line, or by the items found followed by END
+	textCommand := func(command, lastLine string) {
+		_, err := c.Write([]byte(command))
+		require.NoError(t, err)
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if line == lastLine {
+				return
+			}
+		}
+	}
+	textCommand("set key 0 0 5\r\nvalue\r\n", "STORED\r\n")
+	textCommand("get key\r\n", "END\r\n")
+	textCommand("get missing\r\n", "END\r\n")
+	textCommand("delete missing\r\n", "NOT_FOUND\r\n")
+
+	// the GET request of the binary protocol, which is answered with the key not found status and its message
+	missingKey := "missing"
+	request := make([]byte, 24, 24+len(missingKey))
+	request[0] = 0x80
+	binary.BigEndian.PutUint16(request[2:], uint16(len(missingKey)))
+	binary.BigEndian.PutUint32(request[8:], uint32(len(missingKey)))
+	_, err = c.Write(append(request, missingKey...))
+	require.NoError(t, err)
+	header := make([]byte, 24)
+	_, err = io.ReadFull(reader, header)
+	require.NoError(t, err)
+	_, err = io.ReadFull(reader, make([]byte, binary.BigEndian.Uint32(header[8:])))
+	require.NoError(t, err)
+
+	stats := make(map[string]*memcached.RequestStat)
+	require.Eventually(t, func() bool {
+		for key, stat := range monitor.GetMemcachedStats() {
+			if key.SrcPort != clientPort {
+				continue
+			}
+			if _, ok := stats[key.Op]; !ok {
+				stats[key.Op] = new(memcached.RequestStat)
+			}
+			stats[key.Op].CombineWith(stat)
+		}
+		return stats["get"] != nil && stats["get"].Count == 3 && stats["set"] != nil && stats["delete"] != nil
+	}, 3*time.Second, 100*time.Millisecond, "memcached requests not found: %v", stats)
+
+	assert.Equal(t, 1, stats["get"].Hits)
+	assert.Equal(t, 2, stats["get"].Misses)
+	assert.Equal(t, 1, stats["set"].Hits)
+	assert.Equal(t, 1, stats["delete"].Misses)
+}
+
 func newHTTPMonitor(t *testing.T) *Monitor {
 	cfg := config.New()
 	cfg.EnableHTTPMonitoring = true
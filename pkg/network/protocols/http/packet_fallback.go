@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/vishvananda/netns"
+
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	filterpkg "github.com/DataDog/datadog-agent/pkg/network/filter"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// packetSourceFallback monitors the HTTP and HTTP/2 traffic without any eBPF program, for the environments forbidding
+// them: the TCP segments are read from a packet socket of the root network namespace, and parsed in user space.
+type packetSourceFallback struct {
+	cfg    *config.Config
+	source *filterpkg.AFPacketSource
+
+	decoder  *gopacket.DecodingLayerParser
+	decoded  []gopacket.LayerType
+	ipv4     layers.IPv4
+	ipv6     layers.IPv6
+	tcp      layers.TCP
+	ethernet layers.Ethernet
+
+	// parser is shared by the goroutine reading the packets and the callers of poll
+	parser    *packetParser
+	parserMux sync.Mutex
+
+	// the timestamps of the packets are converted to the monotonic clock of the eBPF programs, relatively to startTime
+	startTime      time.Time
+	startMonotonic int64
+
+	exit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newPacketSourceFallback(c *config.Config, process func(httpTX)) (*packetSourceFallback, error) {
+	filter, err := filterpkg.TransportFilter(layers.IPProtocolTCP)
+	if err != nil {
+		return nil, fmt.Errorf("error creating bpf classic filter: %w", err)
+	}
+
+	startMonotonic, err := ddebpf.NowNanoseconds()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		source *filterpkg.AFPacketSource
+		ns     netns.NsHandle
+	)
+	if ns, err = c.GetRootNetNs(); err != nil {
+		return nil, err
+	}
+	defer ns.Close()
+
+	err = util.WithNS(ns, func() error {
+		var srcErr error
+		source, srcErr = filterpkg.NewPacketSource(nil, filter)
+		return srcErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f := &packetSourceFallback{
+		cfg:            c,
+		source:         source,
+		parser:         newPacketParser(process),
+		startTime:      time.Now(),
+		startMonotonic: startMonotonic,
+		exit:           make(chan struct{}),
+	}
+	f.decoder = gopacket.NewDecodingLayerParser(source.PacketType(), &f.ethernet, &f.ipv4, &f.ipv6, &f.tcp)
+	f.decoder.IgnoreUnsupported = true
+	return f, nil
+}
+
+func (f *packetSourceFallback) start() error {
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		for {
+			select {
+			case <-f.exit:
+				return
+			default:
+			}
+
+			// VisitPackets returns once no packet was read for the poll timeout of the socket
+			if err := f.source.VisitPackets(f.exit, f.visit); err != nil {
+				log.Errorf("error reading packets: %s", err)
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (f *packetSourceFallback) visit(data []byte, ts time.Time) error {
+	if err := f.decoder.DecodeLayers(data, &f.decoded); err != nil {
+		return nil
+	}
+
+	var (
+		tup    httpConnTuple
+		tcpSet bool
+	)
+	for _, layer := range f.decoded {
+		switch layer {
+		case layers.LayerTypeIPv4:
+			tup.Saddr_l, tup.Saddr_h = util.ToLowHigh(util.AddressFromNetIP(f.ipv4.SrcIP))
+			tup.Daddr_l, tup.Daddr_h = util.ToLowHigh(util.AddressFromNetIP(f.ipv4.DstIP))
+			tup.Metadata = uint32(netebpf.TCP) | uint32(netebpf.IPv4)
+		case layers.LayerTypeIPv6:
+			tup.Saddr_l, tup.Saddr_h = util.ToLowHigh(util.AddressFromNetIP(f.ipv6.SrcIP))
+			tup.Daddr_l, tup.Daddr_h = util.ToLowHigh(util.AddressFromNetIP(f.ipv6.DstIP))
+			tup.Metadata = uint32(netebpf.TCP) | uint32(netebpf.IPv6)
+		case layers.LayerTypeTCP:
+			tcpSet = true
+		}
+	}
+	if !tcpSet {
+		return nil
+	}
+	tup.Sport, tup.Dport = uint16(f.tcp.SrcPort), uint16(f.tcp.DstPort)
+
+	f.parserMux.Lock()
+	f.parser.segment(tup, f.tcp.Seq, f.tcp.FIN || f.tcp.RST, f.tcp.Payload, f.monotonic(ts))
+	f.parserMux.Unlock()
+	return nil
+}
+
+// monotonic converts the timestamp of a packet to the monotonic clock
+func (f *packetSourceFallback) monotonic(ts time.Time) uint64 {
+	return uint64(f.startMonotonic + int64(ts.Sub(f.startTime)))
+}
+
+// poll flushes the complete transactions of the connections idle for more than the idle connection TTL, and stops
+// tracking these connections
+func (f *packetSourceFallback) poll() {
+	before := f.monotonic(time.Now().Add(-f.cfg.HTTPIdleConnectionTTL))
+
+	f.parserMux.Lock()
+	defer f.parserMux.Unlock()
+	f.parser.expire(before)
+}
+
+// http2Connections returns the frames of the active HTTP/2 connections
+func (f *packetSourceFallback) http2Connections() []HTTP2Connection {
+	f.parserMux.Lock()
+	defer f.parserMux.Unlock()
+
+	var connections []HTTP2Connection
+	f.parser.http2Connections(func(tup httpConnTuple, stats *http2FrameStats) {
+		key := netebpf.ConnTuple(tup)
+		connections = append(connections, HTTP2Connection{
+			Client:       key.SourceAddress(),
+			ClientPort:   key.Sport,
+			Server:       key.DestAddress(),
+			ServerPort:   key.Dport,
+			ClientFrames: newHTTP2Frames(stats.Client),
+			ServerFrames: newHTTP2Frames(stats.Server),
+		})
+	})
+	return connections
+}
+
+// DumpMaps returns an error, as no eBPF map is loaded
+func (f *packetSourceFallback) DumpMaps(_ ...string) (string, error) {
+	return "", errors.New("http monitoring is running without eBPF, there is no map to dump")
+}
+
+func (f *packetSourceFallback) stop() {
+	close(f.exit)
+	f.wg.Wait()
+	f.source.Close()
+}
@@ -0,0 +1,349 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	// httpSegmentTailSize is the number of bytes at the end of the segments searched for the last chunk of the
+	// chunked responses, which may be followed by trailer fields
+	httpSegmentTailSize = 64
+
+	http2DefaultWindow    = 65535
+	http2WindowUpdateSize = 4
+
+	http2DataFrame         = 0x0
+	http2PriorityFrame     = 0x2
+	http2RSTStreamFrame    = 0x3
+	http2SettingsFrame     = 0x4
+	http2PushPromiseFrame  = 0x5
+	http2GoAwayFrame       = 0x7
+	http2WindowUpdateFrame = 0x8
+)
+
+var (
+	httpResponsePrefix = []byte("HTTP/1.")
+	httpZeroChunk      = []byte("\r\n0\r\n")
+	httpEmptyLine      = []byte("\r\n\r\n")
+
+	httpRequestPrefixes = []struct {
+		prefix []byte
+		method Method
+	}{
+		{[]byte("GET "), MethodGet},
+		{[]byte("POST "), MethodPost},
+		{[]byte("PUT "), MethodPut},
+		{[]byte("DELETE "), MethodDelete},
+		{[]byte("HEAD "), MethodHead},
+		{[]byte("OPTIONS "), MethodOptions},
+		{[]byte("PATCH "), MethodPatch},
+	}
+)
+
+// packetFlow holds the state of a TCP connection carrying HTTP or HTTP/2, whose key is the tuple of its client
+type packetFlow struct {
+	// tx is the in-flight HTTP transaction of the connection
+	tx ebpfHttpTx
+	// http2 holds the frames of the connection, it is nil unless the client sent the HTTP/2 connection preface
+	http2 *http2FrameStats
+
+	// nextSeq holds the sequence number following the last byte seen from the client and the server, to skip the
+	// retransmitted segments and the ones captured twice, such as on the loopback interface
+	nextSeq [2]uint32
+	seqSet  [2]bool
+
+	lastSeen uint64
+}
+
+// packetParser parses the HTTP and HTTP/2 traffic from the TCP segments captured by a packet socket, in the same way
+// as the eBPF programs do: a single transaction is in flight per connection, which is flushed once its response is
+// known to be complete, when the next request begins, or when the connection is closed.
+//
+// A packetParser is not safe for concurrent use.
+type packetParser struct {
+	flows   map[httpConnTuple]*packetFlow
+	process func(httpTX)
+}
+
+func newPacketParser(process func(httpTX)) *packetParser {
+	return &packetParser{
+		flows:   make(map[httpConnTuple]*packetFlow),
+		process: process,
+	}
+}
+
+// segment processes a TCP segment of the given tuple, seen at the monotonic time now. fin is set for the segments
+// terminating the connection.
+func (p *packetParser) segment(tup httpConnTuple, seq uint32, fin bool, payload []byte, now uint64) {
+	fromClient := true
+	flow := p.flows[tup]
+	if flow == nil {
+		flow = p.flows[flipHTTPTuple(tup)]
+		fromClient = false
+	}
+	if flow == nil {
+		// the connections are tracked from the first request or HTTP/2 preface of their client
+		if len(payload) == 0 || (httpRequestMethod(payload) == MethodUnknown && !bytes.HasPrefix(payload, http2Preface)) {
+			return
+		}
+		flow = &packetFlow{}
+		p.flows[tup] = flow
+		fromClient = true
+	}
+	flow.lastSeen = now
+
+	if len(payload) > 0 && flow.seenBefore(fromClient, seq, uint32(len(payload))) {
+		return
+	}
+
+	switch {
+	case flow.http2 != nil:
+		flow.countHTTP2Frames(fromClient, payload)
+	case fromClient && bytes.HasPrefix(payload, http2Preface):
+		flow.http2 = &http2FrameStats{}
+		flow.countHTTP2Frames(fromClient, payload)
+	case len(payload) > 0:
+		p.processHTTP(flow, tup, fromClient, payload, now)
+	}
+
+	if fin {
+		if flow.tx.Request_started != 0 && flow.tx.Response_status_code != 0 {
+			p.flush(flow)
+		}
+		if fromClient {
+			delete(p.flows, tup)
+		} else {
+			delete(p.flows, flipHTTPTuple(tup))
+		}
+	}
+}
+
+// processHTTP processes a segment of an HTTP/1 connection, tup being its tuple as seen by the packet
+func (p *packetParser) processHTTP(flow *packetFlow, tup httpConnTuple, fromClient bool, payload []byte, now uint64) {
+	tx := &flow.tx
+	if fromClient {
+		if method := httpRequestMethod(payload); method != MethodUnknown {
+			if tx.Request_started != 0 && tx.Response_status_code != 0 {
+				p.flush(flow)
+			}
+			// a request pipelined before the response of the previous one replaces it
+			*tx = ebpfHttpTx{
+				Tup:             tup,
+				Request_started: now,
+				Request_method:  uint8(method),
+				Request_size:    uint32(len(payload)),
+			}
+			copy(tx.Request_fragment[:], payload)
+			return
+		}
+		if tx.Request_started != 0 && tx.Response_status_code == 0 {
+			// the body of the request
+			tx.Request_size += uint32(len(payload))
+		}
+		return
+	}
+
+	if tx.Request_started == 0 {
+		return
+	}
+	if bytes.HasPrefix(payload, httpResponsePrefix) {
+		code, ok := httpStatusCode(payload)
+		if !ok || httpInterimStatus(code) {
+			// the final response follows the informational ones, such as 100 Continue or 103 Early Hints
+			return
+		}
+		tx.Response_status_code = code
+		if tx.Response_first_seen == 0 {
+			tx.Response_first_seen = now
+		}
+	}
+	if tx.Response_status_code == 0 {
+		return
+	}
+
+	tx.Response_last_seen = now
+	tx.Response_size += uint32(len(payload))
+	if httpLastChunk(payload) {
+		p.flush(flow)
+	}
+}
+
+// flush processes the in-flight transaction of the connection, and resets it
+func (p *packetParser) flush(flow *packetFlow) {
+	tx := new(ebpfHttpTx)
+	*tx = flow.tx
+	p.process(tx)
+	flow.tx = ebpfHttpTx{}
+}
+
+// expire removes the connections idle since before the given monotonic time, after flushing their complete
+// transaction
+func (p *packetParser) expire(before uint64) {
+	for tup, flow := range p.flows {
+		if flow.lastSeen >= before {
+			continue
+		}
+		if flow.tx.Request_started != 0 && flow.tx.Response_status_code != 0 {
+			p.flush(flow)
+		}
+		delete(p.flows, tup)
+	}
+}
+
+// http2Connections calls fn with the frames of each active HTTP/2 connection and the tuple of its client
+func (p *packetParser) http2Connections(fn func(httpConnTuple, *http2FrameStats)) {
+	for tup, flow := range p.flows {
+		if flow.http2 != nil {
+			fn(tup, flow.http2)
+		}
+	}
+}
+
+// seenBefore returns whether the bytes of the segment of the given side were already seen, and records them
+// otherwise
+func (flow *packetFlow) seenBefore(fromClient bool, seq, length uint32) bool {
+	side := 0
+	if !fromClient {
+		side = 1
+	}
+	end := seq + length
+	if flow.seqSet[side] && int32(end-flow.nextSeq[side]) <= 0 {
+		return true
+	}
+	flow.nextSeq[side] = end
+	flow.seqSet[side] = true
+	return false
+}
+
+// countHTTP2Frames accounts for the frames of a segment of an HTTP/2 connection, in the same way as the eBPF program
+// does, the frames spanning several segments being tracked through the number of bytes remaining in the next segments
+func (flow *packetFlow) countHTTP2Frames(fromClient bool, payload []byte) {
+	counters, peer := &flow.http2.Client, &flow.http2.Server
+	if !fromClient {
+		counters, peer = peer, counters
+	}
+
+	if uint32(len(payload)) <= counters.Remainder {
+		counters.Remainder -= uint32(len(payload))
+		return
+	}
+	offset := int(counters.Remainder)
+	if offset == 0 && bytes.HasPrefix(payload, http2Preface) {
+		offset += len(http2Preface)
+	}
+
+	for offset+http2FrameHeaderSize <= len(payload) {
+		header := payload[offset : offset+http2FrameHeaderSize]
+		length := uint32(header[0])<<16 | uint32(header[1])<<8 | uint32(header[2])
+		frameType, flags := header[3], header[4]
+		streamID := binary.BigEndian.Uint32(header[5:]) & 0x7fffffff
+
+		var increment uint32
+		start := offset + http2FrameHeaderSize
+		if frameType == http2WindowUpdateFrame && streamID == 0 && start+http2WindowUpdateSize <= len(payload) {
+			increment = binary.BigEndian.Uint32(payload[start:]) & 0x7fffffff
+		}
+		countHTTP2Frame(frameType, flags, length, increment, counters, peer)
+		offset = start + int(length)
+	}
+
+	counters.Remainder = 0
+	if offset > len(payload) {
+		counters.Remainder = uint32(offset - len(payload))
+	}
+}
+
+// countHTTP2Frame accounts for a frame in the counters of the side of the connection which sent it, peer holding the
+// counters of the other side
+func countHTTP2Frame(frameType, flags uint8, length, increment uint32, counters, peer *http2FrameCounters) {
+	switch frameType {
+	case http2DataFrame:
+		counters.Data_bytes += uint64(length)
+		if counters.Data_bytes >= http2DefaultWindow+peer.Window_update_increment {
+			counters.Window_exhaustions++
+		}
+	case http2HeadersFrame:
+		if flags&http2FlagPriority != 0 {
+			counters.Priority_frames++
+		}
+	case http2PriorityFrame:
+		counters.Priority_frames++
+	case http2RSTStreamFrame:
+		counters.Rst_stream_frames++
+	case http2SettingsFrame:
+		counters.Settings_frames++
+	case http2PushPromiseFrame:
+		counters.Push_promise_frames++
+	case http2GoAwayFrame:
+		counters.Goaway_frames++
+	case http2WindowUpdateFrame:
+		counters.Window_update_frames++
+		counters.Window_update_increment += uint64(increment)
+	}
+}
+
+// httpRequestMethod returns the method of the request beginning the payload, or MethodUnknown if it doesn't begin
+// a request
+func httpRequestMethod(payload []byte) Method {
+	for _, m := range httpRequestPrefixes {
+		if bytes.HasPrefix(payload, m.prefix) {
+			return m.method
+		}
+	}
+	return MethodUnknown
+}
+
+// httpStatusCode returns the status code of the response beginning the payload, such as "HTTP/1.1 200 OK"
+func httpStatusCode(payload []byte) (uint16, bool) {
+	if len(payload) < 12 || payload[8] != ' ' {
+		return 0, false
+	}
+	var code uint16
+	for _, c := range payload[9:12] {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		code = code*10 + uint16(c-'0')
+	}
+	return code, true
+}
+
+// httpInterimStatus returns true for the informational status codes, which are followed by the final response of the
+// request, except for 101 Switching Protocols which ends the HTTP exchange
+func httpInterimStatus(code uint16) bool {
+	return code >= 100 && code < 200 && code != 101
+}
+
+// httpLastChunk returns true if the payload ends with the last chunk of a chunked response, possibly followed by trailer
+// fields. As in the eBPF programs, the zero-sized chunk is searched in the last httpSegmentTailSize bytes of the
+// payload, after the CRLF of the previous chunk or at the beginning of the payload.
+func httpLastChunk(payload []byte) bool {
+	if !bytes.HasSuffix(payload, httpEmptyLine) {
+		return false
+	}
+
+	tail := payload
+	if len(tail) > httpSegmentTailSize {
+		tail = tail[len(tail)-httpSegmentTailSize:]
+	}
+	if bytes.Contains(tail[:len(tail)-2], httpZeroChunk) {
+		return true
+	}
+	return len(payload) <= httpSegmentTailSize-2 && bytes.HasPrefix(payload, httpZeroChunk[2:])
+}
+
+func flipHTTPTuple(tup httpConnTuple) httpConnTuple {
+	tup.Saddr_h, tup.Daddr_h = tup.Daddr_h, tup.Saddr_h
+	tup.Saddr_l, tup.Daddr_l = tup.Daddr_l, tup.Saddr_l
+	tup.Sport, tup.Dport = tup.Dport, tup.Sport
+	return tup
+}
@@ -0,0 +1,208 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var packetClientTuple = httpConnTuple{
+	Saddr_l: 0x0100007f,
+	Daddr_l: 0x0200007f,
+	Sport:   45678,
+	Dport:   8080,
+}
+
+func TestPacketParserHTTP(t *testing.T) {
+	var txs []httpTX
+	p := newPacketParser(func(tx httpTX) { txs = append(txs, tx) })
+	server := flipHTTPTuple(packetClientTuple)
+
+	request := []byte("GET /foo/bar?id=1 HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	response := []byte("HTTP/1.1 404 Not Found\r\nContent-Length: 5\r\n\r\nfound")
+	p.segment(packetClientTuple, 1000, false, request, 10)
+	// the segments captured twice are skipped
+	p.segment(packetClientTuple, 1000, false, request, 11)
+	p.segment(server, 5000, false, response, 20)
+	p.segment(server, 5000+uint32(len(response)), false, []byte("!"), 30)
+	require.Empty(t, txs)
+
+	p.segment(packetClientTuple, 1000+uint32(len(request)), true, nil, 40)
+	require.Len(t, txs, 1)
+	assert.Empty(t, p.flows)
+
+	tx := txs[0]
+	path, fullPath := tx.Path(make([]byte, 64))
+	assert.Equal(t, "/foo/bar", string(path))
+	assert.True(t, fullPath)
+	assert.Equal(t, MethodGet, tx.Method())
+	assert.Equal(t, uint16(404), tx.StatusCode())
+	assert.Equal(t, uint32(len(request)), tx.RequestSize())
+	assert.Equal(t, uint32(len(response)+1), tx.ResponseSize())
+	assert.Equal(t, uint64(10), tx.RequestStarted())
+	assert.Equal(t, uint64(20), tx.ResponseFirstSeen())
+	assert.Equal(t, uint64(30), tx.ResponseLastSeen())
+	assert.Equal(t, packetClientTuple.Sport, tx.ConnTuple().SrcPort)
+	assert.False(t, tx.Incomplete())
+}
+
+func TestPacketParserKeepAlive(t *testing.T) {
+	var txs []httpTX
+	p := newPacketParser(func(tx httpTX) { txs = append(txs, tx) })
+	server := flipHTTPTuple(packetClientTuple)
+
+	p.segment(packetClientTuple, 1, false, []byte("POST /a HTTP/1.1\r\n\r\n"), 10)
+	p.segment(packetClientTuple, 100, false, []byte("body"), 11)
+	p.segment(server, 1, false, []byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n"), 20)
+	p.segment(packetClientTuple, 200, false, []byte("GET /b HTTP/1.1\r\n\r\n"), 30)
+	require.Len(t, txs, 1)
+	assert.Equal(t, MethodPost, txs[0].Method())
+	assert.Equal(t, uint32(len("POST /a HTTP/1.1\r\n\r\n")+4), txs[0].RequestSize())
+
+	// the chunked responses are flushed once their last chunk is seen
+	p.segment(server, 100, false, []byte("HTTP/1.1 500 Internal Server Error\r\nTransfer-Encoding: chunked\r\n\r\n"), 40)
+	p.segment(server, 200, false, []byte("2\r\nok\r\n0\r\n\r\n"), 50)
+	require.Len(t, txs, 2)
+	assert.Equal(t, MethodGet, txs[1].Method())
+	assert.Equal(t, uint16(500), txs[1].StatusCode())
+	assert.Equal(t, uint64(50), txs[1].ResponseLastSeen())
+
+	// the responses without request are ignored, and the idle connections expire
+	p.segment(server, 300, false, []byte("HTTP/1.1 200 OK\r\n\r\n"), 60)
+	p.expire(100)
+	assert.Len(t, txs, 2)
+	assert.Empty(t, p.flows)
+}
+
+func TestPacketParserInterimResponses(t *testing.T) {
+	var txs []httpTX
+	p := newPacketParser(func(tx httpTX) { txs = append(txs, tx) })
+	server := flipHTTPTuple(packetClientTuple)
+
+	request := []byte("PUT /upload HTTP/1.1\r\nExpect: 100-continue\r\nContent-Length: 4\r\n\r\n")
+	response := []byte("HTTP/1.1 201 Created\r\nContent-Length: 0\r\n\r\n")
+	p.segment(packetClientTuple, 1, false, request, 10)
+	p.segment(server, 1, false, []byte("HTTP/1.1 100 Continue\r\n\r\n"), 20)
+	// the body of the request is uploaded once the server is willing to accept it
+	p.segment(packetClientTuple, 100, false, []byte("data"), 30)
+	p.segment(server, 100, false, []byte("HTTP/1.1 103 Early Hints\r\nLink: </style.css>; rel=preload\r\n\r\n"), 40)
+	p.segment(server, 200, false, response, 50)
+	p.segment(packetClientTuple, 200, true, nil, 60)
+
+	require.Len(t, txs, 1)
+	tx := txs[0]
+	assert.Equal(t, MethodPut, tx.Method())
+	assert.Equal(t, uint16(201), tx.StatusCode())
+	assert.Equal(t, uint32(len(request)+4), tx.RequestSize())
+	assert.Equal(t, uint32(len(response)), tx.ResponseSize())
+	assert.Equal(t, uint64(50), tx.ResponseFirstSeen())
+}
+
+func TestPacketParserTrailers(t *testing.T) {
+	var txs []httpTX
+	p := newPacketParser(func(tx httpTX) { txs = append(txs, tx) })
+	server := flipHTTPTuple(packetClientTuple)
+
+	p.segment(packetClientTuple, 1, false, []byte("GET /stream HTTP/1.1\r\nTE: trailers\r\n\r\n"), 10)
+	p.segment(server, 1, false, []byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\nTrailer: Server-Timing\r\n\r\n"), 20)
+	p.segment(server, 100, false, []byte("5\r\nhello\r\n"), 30)
+	require.Empty(t, txs)
+
+	// the response is complete once the trailers following its last chunk are seen
+	p.segment(server, 200, false, []byte("6\r\n world\r\n0\r\nServer-Timing: db;dur=53\r\n\r\n"), 40)
+	require.Len(t, txs, 1)
+	assert.Equal(t, uint16(200), txs[0].StatusCode())
+	assert.Equal(t, uint64(40), txs[0].ResponseLastSeen())
+
+	// including when the last chunk is flushed on its own
+	p.segment(packetClientTuple, 100, false, []byte("GET /stream HTTP/1.1\r\nTE: trailers\r\n\r\n"), 50)
+	p.segment(server, 300, false, []byte("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n"), 60)
+	p.segment(server, 400, false, []byte("0\r\nServer-Timing: db;dur=53\r\n\r\n"), 70)
+	require.Len(t, txs, 2)
+	assert.Equal(t, uint64(70), txs[1].ResponseLastSeen())
+}
+
+func TestHTTPLastChunk(t *testing.T) {
+	for _, tc := range []struct {
+		payload  string
+		expected bool
+	}{
+		{"5\r\nhello\r\n0\r\n\r\n", true},
+		{"\r\n0\r\n\r\n", true},
+		{"5\r\nhello\r\n0\r\nExpires: 0\r\nServer-Timing: db;dur=53\r\n\r\n", true},
+		{"5\r\nhello\r\n", false},
+		{"5\r\nhello\r\n0\r\nServer-Timing: db;dur=53\r\n", false},
+		// the zero-sized chunk must be within the tail of the segment
+		{"5\r\nhello\r\n0\r\nX-Checksum: " + strings.Repeat("a", httpSegmentTailSize) + "\r\n\r\n", false},
+		// the zero-sized chunk may be flushed on its own
+		{"0\r\n\r\n", true},
+		{"0\r\nServer-Timing: db;dur=53\r\n\r\n", true},
+		{"Content-Length: 0\r\n\r\n", false},
+	} {
+		assert.Equal(t, tc.expected, httpLastChunk([]byte(tc.payload)), "%q", tc.payload)
+	}
+}
+
+func TestPacketParserIgnoresUnknownConnections(t *testing.T) {
+	p := newPacketParser(func(tx httpTX) { t.Fatal("unexpected transaction") })
+
+	p.segment(packetClientTuple, 1, false, []byte("HTTP/1.1 200 OK\r\n\r\n"), 10)
+	p.segment(packetClientTuple, 100, false, []byte("\x16\x03\x01\x02\x00\x01"), 20)
+	assert.Empty(t, p.flows)
+}
+
+func TestPacketParserHTTP2Frames(t *testing.T) {
+	p := newPacketParser(func(tx httpTX) { t.Fatal("unexpected transaction") })
+	server := flipHTTPTuple(packetClientTuple)
+
+	client := append([]byte{}, http2Preface...)
+	client = append(client, http2Frame(http2SettingsFrame, 0, 0, make([]byte, 6))...)
+	client = append(client, http2Frame(http2HeadersFrame, http2FlagPriority, 1, make([]byte, 10))...)
+	p.segment(packetClientTuple, 1, false, client, 10)
+
+	increment := make([]byte, 4)
+	binary.BigEndian.PutUint32(increment, 1000)
+	serverFrames := http2Frame(http2SettingsFrame, 0, 0, nil)
+	serverFrames = append(serverFrames, http2Frame(http2WindowUpdateFrame, 0, 0, increment)...)
+	// a DATA frame split across two segments
+	data := http2Frame(http2DataFrame, 0, 1, make([]byte, 100))
+	serverFrames = append(serverFrames, data[:50]...)
+	p.segment(server, 1, false, serverFrames, 20)
+	p.segment(server, 1+uint32(len(serverFrames)), false, append(data[50:], http2Frame(http2GoAwayFrame, 0, 0, make([]byte, 8))...), 30)
+
+	var connections int
+	p.http2Connections(func(tup httpConnTuple, stats *http2FrameStats) {
+		connections++
+		assert.Equal(t, packetClientTuple, tup)
+
+		assert.Equal(t, uint32(1), stats.Client.Settings_frames)
+		assert.Equal(t, uint32(1), stats.Client.Priority_frames)
+
+		assert.Equal(t, uint32(1), stats.Server.Settings_frames)
+		assert.Equal(t, uint32(1), stats.Server.Window_update_frames)
+		assert.Equal(t, uint64(1000), stats.Server.Window_update_increment)
+		assert.Equal(t, uint64(100), stats.Server.Data_bytes)
+		assert.Equal(t, uint32(1), stats.Server.Goaway_frames)
+		assert.Equal(t, uint32(0), stats.Server.Remainder)
+	})
+	assert.Equal(t, 1, connections)
+}
+
+func http2Frame(frameType, flags uint8, streamID uint32, payload []byte) []byte {
+	frame := make([]byte, http2FrameHeaderSize, http2FrameHeaderSize+len(payload))
+	frame[0], frame[1], frame[2] = byte(len(payload)>>16), byte(len(payload)>>8), byte(len(payload))
+	frame[3], frame[4] = frameType, flags
+	binary.BigEndian.PutUint32(frame[5:], streamID)
+	return append(frame, payload...)
+}
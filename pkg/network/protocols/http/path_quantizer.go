@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build (windows && npm) || linux_bpf
+// +build windows,npm linux_bpf
+
+package http
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+)
+
+// pathWildcard replaces the variable segments of the quantized paths, eg. /users/123 becomes /users/*
+const pathWildcard = "*"
+
+// pathQuantizer collapses the high-cardinality paths, such as the ones containing identifiers, into the endpoints
+// they belong to. It learns the segments of the paths in a trie, whose nodes are the segments seen after a given
+// prefix: the segments which look like identifiers, and the positions taking at least minHits distinct values after
+// the same prefix, are collapsed into a wildcard along with their subtrees. The trie holds at most maxEndpoints nodes,
+// the positions of the paths which would exceed it being collapsed as well.
+//
+// The trie is kept across the flushes of the stats, so that the variable positions learnt are applied from the
+// beginning of the next intervals.
+type pathQuantizer struct {
+	minHits      int
+	maxEndpoints int
+
+	root  *pathNode
+	nodes int
+}
+
+type pathNode struct {
+	children map[string]*pathNode
+	// wildcard is set once the segments following the node have been collapsed, in which case there are no children
+	wildcard *pathNode
+}
+
+// newPathQuantizer returns a pathQuantizer, or nil if the quantization of the paths is disabled
+func newPathQuantizer(c *config.Config) *pathQuantizer {
+	if !c.EnableHTTPPathQuantization {
+		return nil
+	}
+	return &pathQuantizer{
+		minHits:      c.HTTPPathQuantizationMinHits,
+		maxEndpoints: c.HTTPPathQuantizationMaxEndpoints,
+		root:         &pathNode{},
+		nodes:        1,
+	}
+}
+
+// learn adds the segments of the path to the trie, collapsing the positions found to be variable
+func (q *pathQuantizer) learn(path string) {
+	node := q.root
+	for _, segment := range strings.Split(path, "/") {
+		if node.wildcard != nil {
+			node = node.wildcard
+			continue
+		}
+		if child, ok := node.children[segment]; ok {
+			node = child
+			continue
+		}
+		if isVariableSegment(segment) || len(node.children)+1 >= q.minHits || q.nodes >= q.maxEndpoints {
+			q.collapse(node)
+			node = node.wildcard
+			continue
+		}
+
+		child := &pathNode{}
+		if node.children == nil {
+			node.children = make(map[string]*pathNode)
+		}
+		node.children[segment] = child
+		q.nodes++
+		node = child
+	}
+}
+
+// quantize returns the path with its variable segments replaced by a wildcard. The segments of the path which were
+// never learnt are kept as they are, unless they look like identifiers.
+func (q *pathQuantizer) quantize(path string) string {
+	segments := strings.Split(path, "/")
+	quantized := false
+	node := q.root
+	for i, segment := range segments {
+		switch {
+		case node == nil:
+			if isVariableSegment(segment) {
+				segments[i] = pathWildcard
+				quantized = true
+			}
+		case node.wildcard != nil:
+			segments[i] = pathWildcard
+			quantized = true
+			node = node.wildcard
+		default:
+			node = node.children[segment]
+			if node == nil && isVariableSegment(segment) {
+				segments[i] = pathWildcard
+				quantized = true
+			}
+		}
+	}
+
+	if !quantized {
+		return path
+	}
+	return strings.Join(segments, "/")
+}
+
+// collapse replaces the children of the node by a wildcard, into which their subtrees are merged
+func (q *pathQuantizer) collapse(node *pathNode) {
+	wildcard := &pathNode{}
+	for _, child := range node.children {
+		mergePathNodes(wildcard, child)
+	}
+	node.children = nil
+	node.wildcard = wildcard
+	q.nodes = countPathNodes(q.root)
+}
+
+// mergePathNodes merges the subtree of src into dst
+func mergePathNodes(dst, src *pathNode) {
+	if src.wildcard != nil || dst.wildcard != nil {
+		// the segments following either node are variable, so are the ones of the merged node
+		if dst.wildcard == nil {
+			dst.wildcard = &pathNode{}
+		}
+		for _, child := range dst.children {
+			mergePathNodes(dst.wildcard, child)
+		}
+		for _, child := range src.children {
+			mergePathNodes(dst.wildcard, child)
+		}
+		if src.wildcard != nil {
+			mergePathNodes(dst.wildcard, src.wildcard)
+		}
+		dst.children = nil
+		return
+	}
+
+	for segment, child := range src.children {
+		existing, ok := dst.children[segment]
+		if !ok {
+			if dst.children == nil {
+				dst.children = make(map[string]*pathNode)
+			}
+			dst.children[segment] = child
+			continue
+		}
+		mergePathNodes(existing, child)
+	}
+}
+
+func countPathNodes(node *pathNode) int {
+	count := 1
+	if node.wildcard != nil {
+		count += countPathNodes(node.wildcard)
+	}
+	for _, child := range node.children {
+		count += countPathNodes(child)
+	}
+	return count
+}
+
+// isVariableSegment returns true if the segment looks like an identifier, ie. a number, a UUID or a long hexadecimal
+// string such as a hash
+func isVariableSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+
+	digits, hex := 0, 0
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F'):
+			hex++
+		case c == '-' && len(segment) == 36:
+			// UUIDs, whose hyphens are checked below
+		default:
+			return false
+		}
+	}
+
+	switch {
+	case digits == len(segment):
+		return true
+	case len(segment) == 36:
+		return segment[8] == '-' && segment[13] == '-' && segment[18] == '-' && segment[23] == '-' && digits+hex == 32
+	default:
+		// long hexadecimal strings with at least a digit, as the words made of the letters a to f are not identifiers
+		return len(segment) >= 16 && digits > 0
+	}
+}
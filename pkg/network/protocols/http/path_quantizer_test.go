@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func newTestPathQuantizer(minHits, maxEndpoints int) *pathQuantizer {
+	cfg := config.New()
+	cfg.EnableHTTPPathQuantization = true
+	cfg.HTTPPathQuantizationMinHits = minHits
+	cfg.HTTPPathQuantizationMaxEndpoints = maxEndpoints
+	return newPathQuantizer(cfg)
+}
+
+func TestPathQuantizerDisabled(t *testing.T) {
+	assert.Nil(t, newPathQuantizer(config.New()))
+}
+
+func TestIsVariableSegment(t *testing.T) {
+	for _, segment := range []string{"123", "0", "5f0c6b3e-8a2d-4c1e-9b7f-3d2a1c0e4b6a", "a3f2c9e1d7b4058612ab"} {
+		assert.True(t, isVariableSegment(segment), segment)
+	}
+	for _, segment := range []string{"", "users", "v1", "deadbeefdeadbeefdeadbeef", "5f0c6b3e-8a2d-4c1e-9b7f-3d2a1c0e4b6", "*"} {
+		assert.False(t, isVariableSegment(segment), segment)
+	}
+}
+
+func TestPathQuantizerIdentifiers(t *testing.T) {
+	q := newTestPathQuantizer(10, 100)
+	for _, path := range []string{"/users/123", "/users/456/orders", "/users/5f0c6b3e-8a2d-4c1e-9b7f-3d2a1c0e4b6a/orders", "/health"} {
+		q.learn(path)
+	}
+
+	assert.Equal(t, "/users/*", q.quantize("/users/123"))
+	assert.Equal(t, "/users/*/orders", q.quantize("/users/456/orders"))
+	assert.Equal(t, "/users/*/orders", q.quantize("/users/5f0c6b3e-8a2d-4c1e-9b7f-3d2a1c0e4b6a/orders"))
+	assert.Equal(t, "/users/*", q.quantize("/users/john"))
+	assert.Equal(t, "/health", q.quantize("/health"))
+	// the segments never learnt are kept, unless they look like identifiers
+	assert.Equal(t, "/status", q.quantize("/status"))
+	assert.Equal(t, "/items/*", q.quantize("/items/42"))
+}
+
+func TestPathQuantizerMinHits(t *testing.T) {
+	q := newTestPathQuantizer(3, 100)
+	q.learn("/users/alice/profile")
+	q.learn("/users/bob/settings")
+	assert.Equal(t, "/users/alice/profile", q.quantize("/users/alice/profile"))
+
+	// the third distinct value of the position collapses it, along with the subtrees of the values
+	q.learn("/users/carol")
+	assert.Equal(t, "/users/*/profile", q.quantize("/users/alice/profile"))
+	assert.Equal(t, "/users/*/settings", q.quantize("/users/bob/settings"))
+	assert.Equal(t, "/users/*", q.quantize("/users/dave"))
+	assert.Equal(t, 6, q.nodes)
+}
+
+func TestPathQuantizerMaxEndpoints(t *testing.T) {
+	q := newTestPathQuantizer(100, 4)
+	q.learn("/a")
+	q.learn("/b")
+	assert.Equal(t, 4, q.nodes)
+
+	// the trie is full, so the new segments collapse their position
+	q.learn("/c/d")
+	assert.Equal(t, "/*", q.quantize("/a"))
+	assert.Equal(t, "/*/d", q.quantize("/c/d"))
+	assert.LessOrEqual(t, q.nodes, 4)
+}
+
+func TestPathQuantizerMergesWildcards(t *testing.T) {
+	q := newTestPathQuantizer(3, 100)
+	q.learn("/api/x/1/details")
+	q.learn("/api/y/names")
+
+	q.learn("/api/z")
+	assert.Equal(t, "/api/*/*/details", q.quantize("/api/x/1/details"))
+	assert.Equal(t, "/api/*/*/details", q.quantize("/api/y/2/details"))
+	assert.Equal(t, "/api/*/*", q.quantize("/api/y/names"))
+}
+
+func TestProcessHTTPTransactionsWithPathQuantization(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxHTTPStatsBuffered = 1000
+	cfg.EnableHTTPPathQuantization = true
+	cfg.HTTPPathQuantizationMinHits = 5
+	cfg.HTTPPathQuantizationMaxEndpoints = 100
+	tel, err := newTelemetry()
+	require.NoError(t, err)
+	sk := newHTTPStatkeeper(cfg, tel)
+
+	sourceIP := util.AddressFromString("1.1.1.1")
+	destIP := util.AddressFromString("2.2.2.2")
+	for _, name := range []string{"alice", "bob", "carol", "dave", "erin", "frank"} {
+		sk.Process(generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/users/"+name, 200, time.Millisecond))
+	}
+	for i := 0; i < 3; i++ {
+		sk.Process(generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/orders/"+strconv.Itoa(i), 200, time.Millisecond))
+	}
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+	for key, stats := range stats {
+		switch key.Path.Content {
+		case "/users/*":
+			// the paths aggregated before the position was found to be variable are merged
+			assert.Equal(t, 6, stats.Stats(200).Count)
+		case "/orders/*":
+			assert.Equal(t, 3, stats.Stats(200).Count)
+		default:
+			t.Errorf("unexpected path %q", key.Path.Content)
+		}
+	}
+
+	// the learnt positions are kept for the next intervals
+	sk.Process(generateIPv4HTTPTransaction(sourceIP, destIP, 1234, 8080, "/users/grace", 200, time.Millisecond))
+	stats = sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	for key := range stats {
+		assert.Equal(t, "/users/*", key.Path.Content)
+	}
+}
@@ -14,11 +14,14 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 
+	manager "github.com/DataDog/ebpf-manager"
 	"github.com/DataDog/gopsutil/process"
 	"github.com/twmb/murmur3"
 	"golang.org/x/sys/unix"
@@ -90,6 +93,13 @@ type soRule struct {
 	re           *regexp.Regexp
 	registerCB   func(id pathIdentifier, root string, path string) error
 	unregisterCB func(id pathIdentifier) error
+	// attachPIDCB is called, if set, for each process using the library once it is registered, and detachPIDCB once
+	// the process exits
+	attachPIDCB func(pid uint32, id pathIdentifier)
+	detachPIDCB func(pid uint32)
+	// probes are the probes registerCB may attach to the libraries, the attached ones being reported by the uprobe
+	// attachments
+	probes []manager.ProbesSelector
 }
 
 // soWatcher provides a way to tie callback functions to the lifecycle of shared libraries
@@ -100,15 +110,28 @@ type soWatcher struct {
 	loadEvents     *ddebpf.PerfHandler
 	processMonitor *monitor.ProcessMonitor
 	registry       *soRegistry
+
+	// watchLibraryDirs enables registering the libraries as soon as they are installed in the library directories of
+	// the host or of the container images, rather than once they are opened
+	watchLibraryDirs bool
 }
 
 type soRegistry struct {
 	m     sync.Mutex
 	byID  map[pathIdentifier]*soRegistration
 	byPID map[uint32]*soRegistration
+	// byPath holds the registrations of the installed libraries, by their path viewed by the host
+	byPath map[string]*soRegistration
 
 	// if we can't register a uprobe we don't try more than once
-	blocklistByID map[pathIdentifier]struct{}
+	blocklistByID map[pathIdentifier]soFailure
+}
+
+// soFailure is a library which couldn't be registered
+type soFailure struct {
+	hostPath string
+	err      error
+	since    time.Time
 }
 
 func newSOWatcher(perfHandler *ddebpf.PerfHandler, rules ...soRule) *soWatcher {
@@ -127,7 +150,7 @@ func newSOWatcher(perfHandler *ddebpf.PerfHandler, rules ...soRule) *soWatcher {
 		registry: &soRegistry{
 			byID:          make(map[pathIdentifier]*soRegistration),
 			byPID:         make(map[uint32]*soRegistration),
-			blocklistByID: make(map[pathIdentifier]struct{}),
+			blocklistByID: make(map[pathIdentifier]soFailure),
 		},
 	}
 }
@@ -136,6 +159,12 @@ type soRegistration struct {
 	pathID       pathIdentifier
 	refcount     int
 	unregisterCB func(pathIdentifier) error
+	detachPIDCB  func(uint32)
+
+	// hostPath is the path of the library it was registered with, viewed by the host
+	hostPath string
+	since    time.Time
+	probeIDs []manager.ProbeIdentificationPair
 }
 
 // Unregister return true if there are no more reference to this registration
@@ -152,11 +181,15 @@ func (r *soRegistration) Unregister() bool {
 	return true
 }
 
-func newRegistration(pathID pathIdentifier, unregister func(pathIdentifier) error) *soRegistration {
+func newRegistration(pathID pathIdentifier, hostPath string, rule soRule) *soRegistration {
 	return &soRegistration{
 		pathID:       pathID,
-		unregisterCB: unregister,
+		unregisterCB: rule.unregisterCB,
+		detachPIDCB:  rule.detachPIDCB,
 		refcount:     1,
+		hostPath:     hostPath,
+		since:        time.Now(),
+		probeIDs:     probeIDsOf(rule.probes, getUID(pathID)),
 	}
 }
 
@@ -164,6 +197,35 @@ func (w *soWatcher) processExit(pid uint32) {
 	w.registry.Unregister(pid)
 }
 
+// processExec registers the executable of the process if it matches one of the rules, such as the binaries embedding
+// a TLS library statically, which are never opened as shared libraries
+func (w *soWatcher) processExec(pid uint32) {
+	procPid := fmt.Sprintf("%s/%d", w.procRoot, pid)
+	exePath, err := os.Readlink(procPid + "/exe")
+	if err != nil {
+		// the process may have already exited
+		log.Tracef("process %d executable can't be resolved %s", pid, err)
+		return
+	}
+
+	for _, r := range w.rules {
+		if r.re.MatchString(exePath) {
+			w.registry.Register(procPid+"/root", exePath, pid, r)
+			break
+		}
+	}
+}
+
+// registerInstalledLibrary registers the library installed at root/libPath if it matches one of the rules
+func (w *soWatcher) registerInstalledLibrary(root string, libPath string) {
+	for _, r := range w.rules {
+		if r.re.MatchString(libPath) {
+			w.registry.RegisterFile(root, libPath, r)
+			break
+		}
+	}
+}
+
 // Start consuming shared-library events
 func (w *soWatcher) Start() {
 	thisPID, err := util.GetRootNSPID()
@@ -215,12 +277,36 @@ func (w *soWatcher) Start() {
 		log.Errorf("can't subscribe to process monitor exit event %s", err)
 		return
 	}
+	cleanupExec, err := w.processMonitor.Subscribe(&monitor.ProcessCallback{
+		Event:    monitor.EXEC,
+		Metadata: monitor.ANY,
+		Callback: w.processExec,
+	})
+	if err != nil {
+		cleanupExit()
+		log.Errorf("can't subscribe to process monitor exec event %s", err)
+		return
+	}
+
+	var dirWatcher *libraryDirWatcher
+	if w.watchLibraryDirs {
+		dirWatcher, err = newLibraryDirWatcher(w.procRoot+"/1/root", w.registerInstalledLibrary, w.registry.UnregisterPath)
+		if err != nil {
+			log.Warnf("can't watch the library directories, the libraries will be registered once opened: %s", err)
+		} else {
+			dirWatcher.Start()
+		}
+	}
 
 	go func() {
 		defer cleanupExit()
+		defer cleanupExec()
 		defer w.processMonitor.Stop()
 		// cleanup all the uprobes
 		defer w.registry.cleanup()
+		if dirWatcher != nil {
+			defer dirWatcher.Stop()
+		}
 
 		for {
 			select {
@@ -276,6 +362,7 @@ func (r *soRegistry) cleanup() {
 	for _, reg := range r.byID {
 		reg.Unregister()
 	}
+	uprobeTelemetry.attached.Add(-int64(len(r.byID)))
 }
 
 // Unregister a pid if exist, unregisterCB will be called if his refcount == 0
@@ -287,10 +374,11 @@ func (r *soRegistry) Unregister(pid uint32) {
 	if !found {
 		return
 	}
-	if reg.Unregister() == true {
-		// we need to cleanup our entries as there are no more processes using this ELF
-		delete(r.byID, reg.pathID)
+	if reg.detachPIDCB != nil {
+		reg.detachPIDCB(pid)
 	}
+	// we need to cleanup our entries as there are no more processes using this ELF
+	r.release(reg)
 	delete(r.byPID, pid)
 }
 
@@ -308,18 +396,94 @@ func (r *soRegistry) Register(root string, libPath string, pid uint32, rule soRu
 
 	r.m.Lock()
 	defer r.m.Unlock()
-	if _, found := r.blocklistByID[pathID]; found {
+
+	reg, created := r.register(pathID, root, libPath, rule)
+	if reg == nil {
+		return
+	}
+	r.byPID[pid] = reg
+	if rule.attachPIDCB != nil {
+		rule.attachPIDCB(pid, pathID)
+	}
+
+	if created {
+		log.Debugf("registering library %s path %s by pid %d", pathID.String(), hostLibPath, pid)
+	}
+}
+
+// RegisterFile registers the ELF library root/libPath as soon as it is installed, before any process uses it, such as
+// the libraries of the container images being pulled. The registration is held until UnregisterPath is called.
+func (r *soRegistry) RegisterFile(root string, libPath string, rule soRule) {
+	hostLibPath := root + libPath
+	pathID, err := newPathIdentifier(hostLibPath)
+	if err != nil {
+		log.Tracef("can't create path identifier %s", err)
+		return
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.byPath == nil {
+		r.byPath = make(map[string]*soRegistration)
+	}
+	if prev, found := r.byPath[hostLibPath]; found {
+		if prev.pathID == pathID {
+			return
+		}
+		// the file was replaced by another one
+		r.release(prev)
+		delete(r.byPath, hostLibPath)
+	}
+
+	reg, created := r.register(pathID, root, libPath, rule)
+	if reg == nil {
 		return
 	}
+	r.byPath[hostLibPath] = reg
+
+	if created {
+		log.Debugf("registering installed library %s path %s", pathID.String(), hostLibPath)
+	}
+}
+
+// UnregisterPath releases the registrations of the installed library at hostPath, or of the ones under the directory
+// at hostPath, once they are removed
+func (r *soRegistry) UnregisterPath(hostPath string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for path, reg := range r.byPath {
+		if path == hostPath || strings.HasPrefix(path, hostPath+"/") {
+			r.release(reg)
+			delete(r.byPath, path)
+		}
+	}
+}
+
+// release drops a reference to the registration, unregisterCB being called if there are no more
+func (r *soRegistry) release(reg *soRegistration) {
+	if reg.Unregister() {
+		delete(r.byID, reg.pathID)
+		uprobeTelemetry.attached.Add(-1)
+	}
+}
+
+// register returns the registration of the library, taking a reference to it, and whether it was created. It returns
+// nil if the library is blocklisted or can't be registered.
+func (r *soRegistry) register(pathID pathIdentifier, root string, libPath string, rule soRule) (*soRegistration, bool) {
+	if _, found := r.blocklistByID[pathID]; found {
+		return nil, false
+	}
 
 	if reg, found := r.byID[pathID]; found {
 		reg.refcount++
-		r.byPID[pid] = reg
-		return
+		return reg, false
 	}
 
+	hostLibPath := root + libPath
 	if err := rule.registerCB(pathID, root, libPath); err != nil {
-		log.Debugf("error registering library (adding to blocklist) %s path %s by pid %d : %s", pathID.String(), hostLibPath, pid, err)
+		log.Debugf("error registering library (adding to blocklist) %s path %s : %s", pathID.String(), hostLibPath, err)
 		// we calling unregisterCB here as some uprobe could be already attached, unregisterCB will cleanup those entries
 		if rule.unregisterCB != nil {
 			if err := rule.unregisterCB(pathID); err != nil {
@@ -328,13 +492,35 @@ func (r *soRegistry) Register(root string, libPath string, pid uint32, rule soRu
 		}
 		// save sentinel value so we don't attempt to re-register shared
 		// libraries that are problematic for some reason
-		r.blocklistByID[pathID] = struct{}{}
-		return
+		r.blocklistByID[pathID] = soFailure{hostPath: hostLibPath, err: err, since: time.Now()}
+		uprobeTelemetry.failures.Add(1)
+		return nil, false
 	}
 
-	reg := newRegistration(pathID, rule.unregisterCB)
+	reg := newRegistration(pathID, hostLibPath, rule)
 	r.byID[pathID] = reg
-	r.byPID[pid] = reg
+	uprobeTelemetry.attached.Add(1)
+	return reg, true
+}
+
+// attachments returns the registered libraries along with the processes using them, and the ones which couldn't be
+// registered
+func (r *soRegistry) attachments() (attached []uprobeAttachment, failed []uprobeAttachment) {
+	r.m.Lock()
+	defer r.m.Unlock()
 
-	log.Debugf("registering library %s path %s by pid %d", pathID.String(), hostLibPath, pid)
+	pids := make(map[*soRegistration][]uint32, len(r.byID))
+	for pid, reg := range r.byPID {
+		pids[reg] = append(pids[reg], pid)
+	}
+	for _, reg := range r.byID {
+		a := newUprobeAttachment(reg.hostPath, reg.pathID, reg.since, reg.probeIDs)
+		a.PIDs = pids[reg]
+		sort.Slice(a.PIDs, func(i, j int) bool { return a.PIDs[i] < a.PIDs[j] })
+		attached = append(attached, a)
+	}
+	for pathID, failure := range r.blocklistByID {
+		failed = append(failed, newUprobeFailure(failure.hostPath, pathID, failure.since, failure.err))
+	}
+	return attached, failed
 }
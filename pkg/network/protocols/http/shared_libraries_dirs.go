@@ -0,0 +1,334 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// libraryDirs are the directories the shared libraries are installed in, relative to the root of the host or of the
+// layers of the container images
+var libraryDirs = []string{
+	"lib",
+	"lib64",
+	"lib/x86_64-linux-gnu",
+	"lib/aarch64-linux-gnu",
+	"usr/lib",
+	"usr/lib64",
+	"usr/lib/x86_64-linux-gnu",
+	"usr/lib/aarch64-linux-gnu",
+	"usr/local/lib",
+	"usr/local/lib64",
+}
+
+// overlayLayerDirs are the directories of the layers of the container images relative to the root of the host, along
+// with the directory of each layer holding its files
+var overlayLayerDirs = []struct {
+	dir     string
+	content string
+}{
+	{dir: "var/lib/docker/overlay2", content: "diff"},
+	{dir: "var/lib/containerd/io.containerd.snapshotter.v1.overlayfs/snapshots", content: "fs"},
+}
+
+const (
+	// layerWatchTTL is the time the layers of the container images are watched for, their files being extracted once
+	// when the image is pulled
+	layerWatchTTL = 10 * time.Minute
+
+	dirWatchMask = unix.IN_CREATE | unix.IN_MOVED_TO | unix.IN_CLOSE_WRITE | unix.IN_DELETE | unix.IN_MOVED_FROM |
+		unix.IN_ONLYDIR | unix.IN_DONT_FOLLOW
+)
+
+type dirWatchKind int
+
+const (
+	// treeWatch is a directory of a tree holding library directories, such as the root of the host or of a layer
+	treeWatch dirWatchKind = iota
+	// layersWatch is a directory holding the layers of the container images
+	layersWatch
+	// layerWatch is a layer, holding the tree of its files in its content directory
+	layerWatch
+)
+
+type dirWatch struct {
+	kind dirWatchKind
+	// path is the directory viewed by the host
+	path string
+	// root and rel are the root of the tree of a treeWatch and the path of the directory relative to it
+	root string
+	rel  string
+	// content is the content directory of the layers of a layersWatch and a layerWatch
+	content string
+	// layer is the layer of the watch, if any
+	layer string
+}
+
+// libraryDirWatcher watches the library directories of the host, and the ones of the layers of the container images
+// being pulled, with inotify, so that the libraries are registered as soon as they are installed rather than once a
+// process opens them
+type libraryDirWatcher struct {
+	hostRoot string
+	// inotify is read through the runtime poller, so that closing it interrupts the read, fd being used for the
+	// watches as os.File.Fd would make it blocking
+	inotify *os.File
+	fd      int
+
+	// register is called with the root and the path of the files written in a library directory, and unregister with
+	// the path viewed by the host of the files and the directories removed
+	register   func(root string, libPath string)
+	unregister func(hostPath string)
+
+	mux     sync.Mutex
+	watches map[int32]*dirWatch
+	// layers holds the creation time of the watched layers, and their watches
+	layers map[string]*layerWatches
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type layerWatches struct {
+	created time.Time
+	wds     []int32
+}
+
+func newLibraryDirWatcher(hostRoot string, register func(root string, libPath string), unregister func(hostPath string)) (*libraryDirWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &libraryDirWatcher{
+		hostRoot:   hostRoot,
+		inotify:    os.NewFile(uintptr(fd), "inotify"),
+		fd:         fd,
+		register:   register,
+		unregister: unregister,
+		watches:    make(map[int32]*dirWatch),
+		layers:     make(map[string]*layerWatches),
+		done:       make(chan struct{}),
+	}
+
+	w.mux.Lock()
+	w.addTree(hostRoot, "", "", false)
+	for _, layers := range overlayLayerDirs {
+		// the existing layers are already extracted, so only the new ones are watched
+		w.add(&dirWatch{kind: layersWatch, path: filepath.Join(hostRoot, layers.dir), content: layers.content})
+	}
+	w.mux.Unlock()
+	return w, nil
+}
+
+// Start consuming the inotify events
+func (w *libraryDirWatcher) Start() {
+	w.wg.Add(2)
+	go func() {
+		defer w.wg.Done()
+		w.readEvents()
+	}()
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(layerWatchTTL / 10)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				w.expireLayers(now)
+			case <-w.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop watching the directories
+func (w *libraryDirWatcher) Stop() {
+	close(w.done)
+	w.inotify.Close()
+	w.wg.Wait()
+}
+
+// add watches the directory, and returns false if it can't be watched
+func (w *libraryDirWatcher) add(watch *dirWatch) bool {
+	wd, err := unix.InotifyAddWatch(w.fd, watch.path, dirWatchMask)
+	if err != nil {
+		if errors.Is(err, unix.ENOSPC) {
+			log.Warnf("can't watch the library directory %s, the maximum number of inotify watches is reached", watch.path)
+		}
+		return false
+	}
+
+	w.watches[int32(wd)] = watch
+	if watch.layer != "" {
+		if layer, ok := w.layers[watch.layer]; ok {
+			layer.wds = append(layer.wds, int32(wd))
+		}
+	}
+	return true
+}
+
+// addTree watches the directory of a tree if it is a library directory or holds one, along with the directories it
+// already holds. The libraries it holds are registered if registerExisting is set, when the directory is new, as they
+// may have been written before the watch. They may then be registered twice, as the events of the directory are
+// received as well.
+func (w *libraryDirWatcher) addTree(root string, rel string, layer string, registerExisting bool) {
+	libDir, holdsLibDir := classifyLibraryDir(rel)
+	if !libDir && !holdsLibDir {
+		return
+	}
+
+	path := filepath.Join(root, rel)
+	if !w.add(&dirWatch{kind: treeWatch, path: path, root: root, rel: rel, layer: layer}) {
+		return
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir():
+			w.addTree(root, filepath.Join(rel, entry.Name()), layer, registerExisting)
+		case libDir && registerExisting && entry.Type().IsRegular():
+			w.register(root, "/"+filepath.Join(rel, entry.Name()))
+		}
+	}
+}
+
+// classifyLibraryDir returns whether the directory relative to the root of its tree is a library directory, or one of
+// their parents
+func classifyLibraryDir(rel string) (libDir bool, holdsLibDir bool) {
+	if rel == "" {
+		return false, true
+	}
+	for _, dir := range libraryDirs {
+		if dir == rel {
+			libDir = true
+		} else if strings.HasPrefix(dir, rel+"/") {
+			holdsLibDir = true
+		}
+	}
+	return libDir, holdsLibDir
+}
+
+func (w *libraryDirWatcher) readEvents() {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.inotify.Read(buf)
+		if err != nil {
+			select {
+			case <-w.done:
+			default:
+				log.Errorf("library directories watcher stopped: %s", err)
+			}
+			return
+		}
+
+		w.mux.Lock()
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + unix.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+int(event.Len)]), "\x00")
+			offset = nameStart + int(event.Len)
+
+			w.handleEvent(event.Wd, event.Mask, name)
+		}
+		w.mux.Unlock()
+	}
+}
+
+func (w *libraryDirWatcher) handleEvent(wd int32, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		log.Debugf("library directories watcher overflowed, some libraries will be registered once opened")
+		return
+	}
+	watch, ok := w.watches[wd]
+	if !ok {
+		return
+	}
+	if mask&unix.IN_IGNORED != 0 {
+		// the directory was removed, or its watch expired
+		delete(w.watches, wd)
+		return
+	}
+
+	isDir := mask&unix.IN_ISDIR != 0
+	created := mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0
+	removed := mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0
+	if isDir && removed {
+		// such as a layer being removed, along with its image
+		w.unregister(filepath.Join(watch.path, name))
+		return
+	}
+
+	switch watch.kind {
+	case layersWatch:
+		if isDir && created {
+			layer := filepath.Join(watch.path, name)
+			w.layers[layer] = &layerWatches{created: time.Now()}
+			if !w.add(&dirWatch{kind: layerWatch, path: layer, content: watch.content, layer: layer}) {
+				return
+			}
+			// the content directory may have been created before the watch
+			if info, err := os.Lstat(filepath.Join(layer, watch.content)); err == nil && info.IsDir() {
+				w.addTree(filepath.Join(layer, watch.content), "", layer, true)
+			}
+		}
+	case layerWatch:
+		if isDir && created && name == watch.content {
+			w.addTree(filepath.Join(watch.path, name), "", watch.layer, true)
+		}
+	case treeWatch:
+		if isDir {
+			if created {
+				w.addTree(watch.root, filepath.Join(watch.rel, name), watch.layer, true)
+			}
+			return
+		}
+		if libDir, _ := classifyLibraryDir(watch.rel); !libDir {
+			return
+		}
+		libPath := "/" + filepath.Join(watch.rel, name)
+		// the libraries are registered once they are fully written, or moved in place by the package managers
+		if mask&(unix.IN_CLOSE_WRITE|unix.IN_MOVED_TO) != 0 {
+			w.register(watch.root, libPath)
+		} else if removed {
+			w.unregister(watch.root + libPath)
+		}
+	}
+}
+
+// expireLayers stops watching the layers whose files were extracted
+func (w *libraryDirWatcher) expireLayers(now time.Time) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	for path, layer := range w.layers {
+		if now.Sub(layer.created) < layerWatchTTL {
+			continue
+		}
+		for _, wd := range layer.wds {
+			// the IN_IGNORED event removes the watch from the watches
+			_, _ = unix.InotifyRmWatch(w.fd, uint32(wd))
+		}
+		delete(w.layers, path)
+	}
+}
@@ -9,6 +9,7 @@
 package http
 
 import (
+	"debug/elf"
 	"fmt"
 	"math"
 	"os"
@@ -169,6 +170,199 @@ func TestSameInodeRegression(t *testing.T) {
 	require.Equal(t, int64(1), registers.Load())
 }
 
+func TestSharedLibraryPIDCallbacks(t *testing.T) {
+	fpath := filepath.Join(t.TempDir(), "foo.so")
+	f, err := os.Create(fpath)
+	require.NoError(t, err)
+	f.Close()
+
+	attached := make(map[uint32]pathIdentifier)
+	rule := soRule{
+		re:         regexp.MustCompile(`foo.so`),
+		registerCB: func(pathIdentifier, string, string) error { return nil },
+		attachPIDCB: func(pid uint32, id pathIdentifier) {
+			attached[pid] = id
+		},
+		detachPIDCB: func(pid uint32) {
+			delete(attached, pid)
+		},
+	}
+	registry := &soRegistry{
+		byID:          make(map[pathIdentifier]*soRegistration),
+		byPID:         make(map[uint32]*soRegistration),
+		blocklistByID: make(map[pathIdentifier]soFailure),
+	}
+
+	// each process using the library is attached, even though the library is registered once
+	registry.Register("", fpath, 1, rule)
+	registry.Register("", fpath, 2, rule)
+	id, err := newPathIdentifier(fpath)
+	require.NoError(t, err)
+	require.Equal(t, map[uint32]pathIdentifier{1: id, 2: id}, attached)
+
+	registry.Unregister(1)
+	require.Equal(t, map[uint32]pathIdentifier{2: id}, attached)
+	registry.Unregister(2)
+	require.Empty(t, attached)
+	require.Empty(t, registry.byID)
+}
+
+func TestSharedLibraryInstalledRegistration(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "foo.so")
+	require.NoError(t, os.WriteFile(fpath, nil, 0644))
+
+	var registered, unregistered int
+	rule := soRule{
+		re: regexp.MustCompile(`foo.so`),
+		registerCB: func(pathIdentifier, string, string) error {
+			registered++
+			return nil
+		},
+		unregisterCB: func(pathIdentifier) error {
+			unregistered++
+			return nil
+		},
+	}
+	registry := &soRegistry{
+		byID:          make(map[pathIdentifier]*soRegistration),
+		byPID:         make(map[uint32]*soRegistration),
+		blocklistByID: make(map[pathIdentifier]soFailure),
+	}
+
+	// the installed library stays registered while the processes using it come and go
+	registry.RegisterFile("", fpath, rule)
+	registry.RegisterFile("", fpath, rule)
+	registry.Register("", fpath, 1, rule)
+	registry.Unregister(1)
+	require.Equal(t, 1, registered)
+	require.Zero(t, unregistered)
+
+	// the removal of its directory releases it
+	registry.UnregisterPath(dir)
+	require.Equal(t, 1, unregistered)
+	require.Empty(t, registry.byID)
+	require.Empty(t, registry.byPath)
+}
+
+func TestSharedLibraryAttachments(t *testing.T) {
+	dir := t.TempDir()
+	fooPath := filepath.Join(dir, "foo.so")
+	barPath := filepath.Join(dir, "bar.so")
+	require.NoError(t, os.WriteFile(fooPath, nil, 0644))
+	require.NoError(t, os.WriteFile(barPath, nil, 0644))
+
+	rule := soRule{
+		re: regexp.MustCompile(`(foo|bar).so`),
+		registerCB: func(_ pathIdentifier, _ string, path string) error {
+			if path == barPath {
+				return fmt.Errorf("failed to find symbols %#v", []string{"SSL_read"})
+			}
+			return nil
+		},
+	}
+	registry := &soRegistry{
+		byID:          make(map[pathIdentifier]*soRegistration),
+		byPID:         make(map[uint32]*soRegistration),
+		blocklistByID: make(map[pathIdentifier]soFailure),
+	}
+	registry.Register("", fooPath, 2, rule)
+	registry.Register("", fooPath, 1, rule)
+	registry.Register("", barPath, 3, rule)
+
+	attached, failed := registry.attachments()
+	require.Len(t, attached, 1)
+	require.Equal(t, fooPath, attached[0].Path)
+	require.Equal(t, []uint32{1, 2}, attached[0].PIDs)
+	require.Len(t, failed, 1)
+	require.Equal(t, barPath, failed[0].Path)
+	require.Equal(t, attachCauseSymbolNotFound, failed[0].Cause)
+
+	_, err := elf.Open(fooPath)
+	require.Equal(t, attachCauseInvalidELF, uprobeAttachCause(err))
+	_, err = os.Open(filepath.Join(dir, "missing.so"))
+	require.Equal(t, attachCauseFileNotFound, uprobeAttachCause(err))
+	require.Equal(t, attachCausePermission, uprobeAttachCause(fmt.Errorf("open: %w", unix.EACCES)))
+}
+
+func TestLibraryDirWatcher(t *testing.T) {
+	hostRoot := t.TempDir()
+	layers := filepath.Join(hostRoot, "var/lib/docker/overlay2")
+	require.NoError(t, os.MkdirAll(filepath.Join(hostRoot, "usr/lib"), 0755))
+	require.NoError(t, os.MkdirAll(layers, 0755))
+	// the libraries already installed are registered once opened
+	require.NoError(t, os.WriteFile(filepath.Join(hostRoot, "usr/lib/libssl.so.1.1"), nil, 0644))
+
+	var (
+		mux          sync.Mutex
+		registered   []string
+		unregistered []string
+	)
+	w, err := newLibraryDirWatcher(hostRoot,
+		func(root string, libPath string) {
+			mux.Lock()
+			defer mux.Unlock()
+			registered = append(registered, root+libPath)
+		},
+		func(hostPath string) {
+			mux.Lock()
+			defer mux.Unlock()
+			unregistered = append(unregistered, hostPath)
+		},
+	)
+	require.NoError(t, err)
+	w.Start()
+	t.Cleanup(w.Stop)
+
+	// the libraries of the new directories may be reported twice, by the scan of the directory and by its events
+	hasPaths := func(paths *[]string, expected ...string) func() bool {
+		return func() bool {
+			mux.Lock()
+			defer mux.Unlock()
+			for _, path := range expected {
+				if !contains(*paths, path) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	// a library installed on the host
+	hostLib := filepath.Join(hostRoot, "usr/lib/libssl.so.3")
+	require.NoError(t, os.WriteFile(hostLib, []byte("ELF"), 0644))
+	require.Eventually(t, hasPaths(&registered, hostLib), time.Second, 10*time.Millisecond)
+
+	// a library extracted from the layer of an image being pulled, its directories being created along
+	layerLib := filepath.Join(layers, "f3a1/diff/lib/x86_64-linux-gnu/libssl.so.3")
+	require.NoError(t, os.MkdirAll(filepath.Dir(layerLib), 0755))
+	require.NoError(t, os.WriteFile(layerLib, []byte("ELF"), 0644))
+	require.Eventually(t, hasPaths(&registered, hostLib, layerLib), time.Second, 10*time.Millisecond)
+
+	// the files out of the library directories are ignored
+	require.NoError(t, os.MkdirAll(filepath.Join(layers, "f3a1/diff/etc"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(layers, "f3a1/diff/etc/libssl.so.conf"), nil, 0644))
+
+	require.NoError(t, os.Remove(hostLib))
+	require.NoError(t, os.RemoveAll(filepath.Join(layers, "f3a1")))
+	require.Eventually(t, hasPaths(&unregistered, hostLib, filepath.Join(layers, "f3a1")), time.Second, 10*time.Millisecond)
+
+	mux.Lock()
+	defer mux.Unlock()
+	for _, path := range registered {
+		require.Contains(t, []string{hostLib, layerLib}, path)
+	}
+}
+
+func contains(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
 // we use this helper to open files for two reasons:
 // * `touch` calls openat(2) which is what we trace in the shared library eBPF program;
 // * `exec.Command` spawns a separate process; we need to do that because we filter out
@@ -271,6 +465,7 @@ func initEBPFProgram(t *testing.T) (*ddebpf.PerfHandler, func()) {
 		"socket__http_filter",
 		"socket__protocol_dispatcher",
 		"kprobe__tcp_sendmsg",
+		"fentry__tcp_sendmsg",
 		"kretprobe__security_sock_rcv_skb",
 		"tracepoint__net__netif_receive_skb",
 		"kprobe__" + excludeSysOpen,
@@ -26,6 +26,7 @@ type telemetry struct {
 	dropped      *libtelemetry.Metric // this happens when httpStatKeeper reaches capacity
 	rejected     *libtelemetry.Metric // this happens when an user-defined reject-filter matches a request
 	malformed    *libtelemetry.Metric // this happens when the request doesn't have the expected format
+	truncated    *libtelemetry.Metric // this happens when the path doesn't fit in the captured request fragment
 	aggregations *libtelemetry.Metric
 }
 
@@ -50,6 +51,7 @@ func newTelemetry() (*telemetry, error) {
 		dropped:   metricGroup.NewMetric("dropped", libtelemetry.OptStatsd),
 		rejected:  metricGroup.NewMetric("rejected", libtelemetry.OptStatsd),
 		malformed: metricGroup.NewMetric("malformed", libtelemetry.OptStatsd),
+		truncated: metricGroup.NewMetric("truncated_paths", libtelemetry.OptStatsd),
 	}
 
 	return t, nil
@@ -79,11 +81,12 @@ func (t *telemetry) log() {
 	dropped := t.dropped.Delta()
 	rejected := t.rejected.Delta()
 	malformed := t.malformed.Delta()
+	truncated := t.truncated.Delta()
 	aggregations := t.aggregations.Delta()
 	elapsed := now - then
 
 	log.Debugf(
-		"http stats summary: requests_processed=%d(%.2f/s) requests_dropped=%d(%.2f/s) requests_rejected=%d(%.2f/s) requests_malformed=%d(%.2f/s) aggregations=%d",
+		"http stats summary: requests_processed=%d(%.2f/s) requests_dropped=%d(%.2f/s) requests_rejected=%d(%.2f/s) requests_malformed=%d(%.2f/s) paths_truncated=%d(%.2f/s) aggregations=%d",
 		totalRequests,
 		float64(totalRequests)/float64(elapsed),
 		dropped,
@@ -92,6 +95,8 @@ func (t *telemetry) log() {
 		float64(rejected)/float64(elapsed),
 		malformed,
 		float64(malformed)/float64(elapsed),
+		truncated,
+		float64(truncated)/float64(elapsed),
 		aggregations,
 	)
 }
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	nodeJSHTTPSClientFormat = `const https = require('https');
+
+const agent = new https.Agent({ keepAlive: true, rejectUnauthorized: false });
+
+function get(remaining) {
+    if (remaining === 0) {
+        agent.destroy();
+        return;
+    }
+    https.get('%s', { agent: agent }, (res) => {
+        res.resume();
+        res.on('end', () => get(remaining - 1));
+    }).on('error', (err) => {
+        console.error(err.message);
+        process.exit(1);
+    });
+}
+
+// the requests are delayed so that the node binary is hooked by the time they are issued
+setTimeout(() => get(%d), 2000);
+`
+)
+
+// NodeJSHTTPSClient issues numRequests GET requests to the given HTTPS URL over a single connection, through the
+// OpenSSL embedded in node. The certificate of the server is not verified.
+func NodeJSHTTPSClient(t *testing.T, url string, numRequests int) {
+	t.Helper()
+
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not found; skipping test.")
+	}
+
+	scriptFile, err := writeTempFile("nodejs_https_client", fmt.Sprintf(nodeJSHTTPSClientFormat, url, numRequests))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(scriptFile.Name()) })
+
+	out, err := exec.Command(node, scriptFile.Name()).CombinedOutput()
+	require.NoErrorf(t, err, "node https client failed: %s", out)
+}
@@ -30,6 +30,17 @@ class RequestHandler(http.server.BaseHTTPRequestHandler):
 
     def do_GET(self):
         status_code = int(self.path.split("/")[1])
+        if self.path.endswith("/chunked"):
+            # the last chunk is written on its own, as done by the servers streaming their responses
+            self.send_response(status_code)
+            self.send_header('Content-type', 'application/octet-stream')
+            self.send_header('Transfer-Encoding', 'chunked')
+            self.send_header('Connection', 'keep-alive')
+            self.end_headers()
+            self.wfile.write(b'5\r\nhello\r\n')
+            self.wfile.write(b'0\r\n\r\n')
+            return
+
         self.send_response(status_code)
         self.send_header('Content-type', 'application/octet-stream')
         self.send_header('Content-Length', '0')
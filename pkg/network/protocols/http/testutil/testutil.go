@@ -7,6 +7,8 @@ package testutil
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -17,6 +19,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -28,6 +31,106 @@ type Options struct {
 	ReadTimeout      time.Duration
 	WriteTimeout     time.Duration
 	SlowResponse     time.Duration
+	// AbortBeforeResponse makes the server close the connection right after reading the request headers, without
+	// sending any response.
+	AbortBeforeResponse bool
+	// AbortMidResponse makes the server reset the connection after sending the status line and part of the headers
+	// of the response, leaving the transaction half-completed.
+	AbortMidResponse bool
+	// AccessLog, if set, receives one JSON line per request served, in the format expected by
+	// debugging.ParseAccessLog. It can be used to verify the transactions reported by USM.
+	AccessLog io.Writer
+}
+
+type accessLogAddress struct {
+	IP   string
+	Port uint16
+}
+
+type accessLogEntry struct {
+	Client accessLogAddress
+	Server accessLogAddress
+	Method string
+	Path   string
+	Status int
+}
+
+func toAccessLogAddress(addr string) accessLogAddress {
+	host, port, _ := net.SplitHostPort(addr)
+	p, _ := strconv.ParseUint(port, 10, 16)
+	return accessLogAddress{IP: host, Port: uint16(p)}
+}
+
+// ServerControl allows tests to tear down a running HTTPServer, either gracefully or abruptly.
+type ServerControl struct {
+	srv *http.Server
+	ln  net.Listener
+
+	mux   sync.Mutex
+	conns map[net.Conn]struct{}
+
+	accessLogMux sync.Mutex
+	accessLog    *json.Encoder
+}
+
+func (c *ServerControl) logRequest(req *http.Request, status int) {
+	if c.accessLog == nil {
+		return
+	}
+
+	entry := accessLogEntry{
+		Client: toAccessLogAddress(req.RemoteAddr),
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Status: status,
+	}
+	if localAddr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+		entry.Server = toAccessLogAddress(localAddr.String())
+	}
+
+	c.accessLogMux.Lock()
+	defer c.accessLogMux.Unlock()
+	_ = c.accessLog.Encode(entry)
+}
+
+func (c *ServerControl) trackConn(conn net.Conn, state http.ConnState) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	switch state {
+	case http.StateNew:
+		c.conns[conn] = struct{}{}
+	case http.StateHijacked, http.StateClosed:
+		delete(c.conns, conn)
+	}
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests to complete.
+func (c *ServerControl) Shutdown() {
+	_ = c.srv.Shutdown(context.Background())
+}
+
+// Kill stops the server immediately. The listener is closed and all open connections, including those in the
+// middle of a request, are reset.
+func (c *ServerControl) Kill() {
+	_ = c.ln.Close()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	for conn := range c.conns {
+		resetConn(conn)
+		delete(c.conns, conn)
+	}
+}
+
+// resetConn closes conn with a RST instead of the regular FIN handshake.
+func resetConn(conn net.Conn) {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		_ = tcpConn.SetLinger(0)
+	}
+	_ = conn.Close()
 }
 
 // HTTPServer spins up a HTTP test server that returns the status code included in the URL
@@ -37,12 +140,46 @@ type Options struct {
 // Optional TLS support using a self-signed certificate can be enabled trough the `enableTLS` argument
 // nolint
 func HTTPServer(t *testing.T, addr string, options Options) func() {
+	return HTTPServerWithControl(t, addr, options).Shutdown
+}
+
+// HTTPServerWithControl is identical to HTTPServer, but it returns a ServerControl which allows the caller to kill the
+// server on demand, for instance while requests are still in flight.
+func HTTPServerWithControl(t *testing.T, addr string, options Options) *ServerControl {
+	control := &ServerControl{
+		conns: make(map[net.Conn]struct{}),
+	}
+	if options.AccessLog != nil {
+		control.accessLog = json.NewEncoder(options.AccessLog)
+	}
+
 	handler := func(w http.ResponseWriter, req *http.Request) {
 		if options.SlowResponse != 0 {
 			time.Sleep(options.SlowResponse)
 		}
 		statusCode := StatusFromPath(req.URL.Path)
+
+		if options.AbortBeforeResponse || options.AbortMidResponse {
+			hijacker, ok := w.(http.Hijacker)
+			if !ok {
+				t.Logf("connection can't be hijacked")
+				return
+			}
+			conn, buf, err := hijacker.Hijack()
+			if err != nil {
+				t.Logf("could not hijack connection: %s", err)
+				return
+			}
+			if options.AbortMidResponse {
+				_, _ = buf.WriteString(fmt.Sprintf("HTTP/1.1 %d %s\r\nContent-Len", statusCode, http.StatusText(statusCode)))
+				_ = buf.Flush()
+			}
+			resetConn(conn)
+			return
+		}
+
 		w.WriteHeader(statusCode)
+		control.logRequest(req, statusCode)
 
 		defer req.Body.Close()
 		io.Copy(w, req.Body)
@@ -55,14 +192,10 @@ func HTTPServer(t *testing.T, addr string, options Options) func() {
 		WriteTimeout: time.Second,
 	}
 	srv.SetKeepAlivesEnabled(options.EnableKeepAlives)
+	control.srv = srv
+	srv.ConnState = control.trackConn
 
-	listenFn := func() error {
-		ln, err := net.Listen("tcp", srv.Addr)
-		if err == nil {
-			go func() { _ = srv.Serve(ln) }()
-		}
-		return err
-	}
+	serveFn := func(ln net.Listener) error { return srv.Serve(ln) }
 	if options.ReadTimeout != 0 {
 		srv.ReadTimeout = options.ReadTimeout
 	}
@@ -76,19 +209,17 @@ func HTTPServer(t *testing.T, addr string, options Options) func() {
 		curDir, _ := CurDir()
 		crtPath := filepath.Join(curDir, "testdata/cert.pem.0")
 		keyPath := filepath.Join(curDir, "testdata/server.key")
-		listenFn = func() error {
-			ln, err := net.Listen("tcp", srv.Addr)
-			if err == nil {
-				go func() { _ = srv.ServeTLS(ln, crtPath, keyPath) }()
-			}
-			return err
-		}
+		serveFn = func(ln net.Listener) error { return srv.ServeTLS(ln, crtPath, keyPath) }
 	}
-	err := listenFn()
+
+	ln, err := net.Listen("tcp", srv.Addr)
 	if err != nil {
 		t.Fatalf("server listen: %s", err)
 	}
-	return func() { srv.Shutdown(context.Background()) }
+	control.ln = ln
+	go func() { _ = serveFn(ln) }()
+
+	return control
 }
 
 var pathParser = regexp.MustCompile(`/(\d{3})/.+`)
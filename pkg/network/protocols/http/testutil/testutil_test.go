@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestServerKillMidRequest(t *testing.T) {
+	addr := freeAddr(t)
+	srv := HTTPServerWithControl(t, addr, Options{SlowResponse: time.Second})
+
+	errs := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/200/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		errs <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	srv.Kill()
+
+	select {
+	case err := <-errs:
+		require.Error(t, err)
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("request was not interrupted by Kill")
+	}
+
+	_, err := net.DialTimeout("tcp", addr, time.Second)
+	require.Error(t, err, "server should not accept connections after Kill")
+}
+
+func TestServerAbort(t *testing.T) {
+	for name, options := range map[string]Options{
+		"before response": {AbortBeforeResponse: true},
+		"mid response":    {AbortMidResponse: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			addr := freeAddr(t)
+			srvDoneFn := HTTPServer(t, addr, options)
+			t.Cleanup(srvDoneFn)
+
+			resp, err := http.Get("http://" + addr + "/200/aborted")
+			if err == nil {
+				resp.Body.Close()
+			}
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http
+
+import (
+	"context"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	manager "github.com/DataDog/ebpf-manager"
+	"github.com/cilium/ebpf"
+	"golang.org/x/sys/unix"
+
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+)
+
+// the causes of the uprobe attach failures
+const (
+	attachCausePermission     = "permission"
+	attachCauseSymbolNotFound = "symbol_not_found"
+	attachCauseFileNotFound   = "file_not_found"
+	attachCauseInvalidELF     = "invalid_elf"
+	attachCauseUnknown        = "unknown"
+)
+
+// maxGoTLSFailures bounds the number of Go binaries whose attach failure is kept for the attachments endpoint
+const maxGoTLSFailures = 256
+
+var uprobeMetricGroup = libtelemetry.NewMetricGroup("usm.uprobes", libtelemetry.OptExpvar)
+
+// uprobeTelemetry counts the binaries whose uprobes are attached, and the ones which failed to attach
+var uprobeTelemetry = struct {
+	attached *libtelemetry.Metric
+	failures *libtelemetry.Metric
+}{
+	attached: uprobeMetricGroup.NewMetric("attached", libtelemetry.OptGauge, libtelemetry.OptStatsd),
+	failures: uprobeMetricGroup.NewMetric("attach_failures", libtelemetry.OptMonotonic, libtelemetry.OptStatsd),
+}
+
+// UprobeAttachment is a binary or a shared library whose uprobes are attached, or failed to attach
+type UprobeAttachment struct {
+	// Path is the path of the binary viewed by system-probe, the first one it was registered with
+	Path string `json:"path"`
+	// PathID is the device and the inode of the binary
+	PathID  string `json:"path_id"`
+	Program string `json:"program"`
+	// PIDs are the processes using the binary, a library installed but not used yet having none
+	PIDs   []uint32  `json:"pids,omitempty"`
+	Probes []string  `json:"probes,omitempty"`
+	Since  time.Time `json:"since"`
+
+	Error string `json:"error,omitempty"`
+	// Cause is the kind of the error, such as symbol_not_found or permission
+	Cause string `json:"cause,omitempty"`
+}
+
+// UprobeAttachments is the state of the uprobes of USM
+type UprobeAttachments struct {
+	Attached []UprobeAttachment `json:"attached"`
+	Failed   []UprobeAttachment `json:"failed"`
+
+	// Hits is the number of times each hook ran, summed over the binaries it is attached to as they share its
+	// program. The kernel only counts them while its BPF statistics are enabled, either by the
+	// kernel.bpf_stats_enabled sysctl or during HitsWindow.
+	Hits       map[string]uint64 `json:"hits,omitempty"`
+	HitsWindow string            `json:"hits_window,omitempty"`
+	HitsError  string            `json:"hits_error,omitempty"`
+}
+
+// uprobeAttachment is an attachment along with the probes which may be attached to the binary, the ones attached
+// being reported as its Probes
+type uprobeAttachment struct {
+	UprobeAttachment
+	probeIDs []manager.ProbeIdentificationPair
+}
+
+func newUprobeAttachment(path string, pathID pathIdentifier, since time.Time, probeIDs []manager.ProbeIdentificationPair) uprobeAttachment {
+	return uprobeAttachment{
+		UprobeAttachment: UprobeAttachment{
+			Path:   path,
+			PathID: pathID.String(),
+			Since:  since,
+		},
+		probeIDs: probeIDs,
+	}
+}
+
+func newUprobeFailure(path string, pathID pathIdentifier, since time.Time, err error) uprobeAttachment {
+	return uprobeAttachment{
+		UprobeAttachment: UprobeAttachment{
+			Path:   path,
+			PathID: pathID.String(),
+			Since:  since,
+			Error:  err.Error(),
+			Cause:  uprobeAttachCause(err),
+		},
+	}
+}
+
+// uprobeAttachmentsReporter is implemented by the subprograms attaching uprobes to binaries
+type uprobeAttachmentsReporter interface {
+	uprobeAttachments() (attached []uprobeAttachment, failed []uprobeAttachment)
+}
+
+// uprobeAttachCause returns the kind of an error registering a binary
+func uprobeAttachCause(err error) string {
+	var formatErr *elf.FormatError
+	switch {
+	case errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES):
+		return attachCausePermission
+	case errors.Is(err, syscall.ENOENT) || errors.Is(err, os.ErrNotExist):
+		return attachCauseFileNotFound
+	case errors.As(err, &formatErr):
+		return attachCauseInvalidELF
+	case strings.Contains(err.Error(), "failed to find symbols") || strings.Contains(err.Error(), "symbol section"):
+		// the errors of bininspect, such as a stripped binary
+		return attachCauseSymbolNotFound
+	}
+	return attachCauseUnknown
+}
+
+// probeIDsOf returns the identifiers of the probes which may be attached to the binary of uid
+func probeIDsOf(probes []manager.ProbesSelector, uid string) []manager.ProbeIdentificationPair {
+	var ids []manager.ProbeIdentificationPair
+	for _, singleProbe := range probes {
+		for _, selector := range singleProbe.GetProbesIdentificationPairList() {
+			ids = append(ids, manager.ProbeIdentificationPair{EBPFFuncName: selector.EBPFFuncName, UID: uid})
+		}
+	}
+	return ids
+}
+
+// GetUprobeAttachments returns the binaries and the shared libraries whose uprobes are attached, or failed to attach,
+// along with the hits of the hooks counted over hitsWindow when it is set
+func (m *Monitor) GetUprobeAttachments(ctx context.Context, hitsWindow time.Duration) (*UprobeAttachments, error) {
+	if m == nil || m.ebpfProgram == nil {
+		return nil, errors.New("usm is not enabled")
+	}
+	return m.ebpfProgram.uprobeAttachments(ctx, hitsWindow)
+}
+
+// uprobeAttachments returns the binaries the subprograms attached uprobes to, or failed to. When hitsWindow is set,
+// the BPF statistics of the kernel are enabled for its duration, and the hits of the hooks are counted over it.
+func (e *ebpfProgram) uprobeAttachments(ctx context.Context, hitsWindow time.Duration) (*UprobeAttachments, error) {
+	result := &UprobeAttachments{
+		Attached: []UprobeAttachment{},
+		Failed:   []UprobeAttachment{},
+	}
+	// one probe of each hook, as the probes of a hook share its program
+	hooks := make(map[string]manager.ProbeIdentificationPair)
+	for _, s := range e.subprograms {
+		reporter, ok := s.(uprobeAttachmentsReporter)
+		if !ok {
+			continue
+		}
+		attached, failed := reporter.uprobeAttachments()
+		for _, a := range attached {
+			for _, id := range a.probeIDs {
+				// such as the best effort probes whose symbol isn't defined by the binary
+				if probe, found := e.GetProbe(id); !found || !probe.IsRunning() {
+					continue
+				}
+				a.Probes = append(a.Probes, id.EBPFFuncName)
+				hooks[id.EBPFFuncName] = id
+			}
+			result.Attached = append(result.Attached, a.UprobeAttachment)
+		}
+		for _, a := range failed {
+			result.Failed = append(result.Failed, a.UprobeAttachment)
+		}
+	}
+	sortUprobeAttachments(result.Attached)
+	sortUprobeAttachments(result.Failed)
+
+	if len(hooks) == 0 {
+		return result, nil
+	}
+
+	if hitsWindow <= 0 {
+		result.Hits = e.hookRunCounts(hooks)
+		return result, nil
+	}
+
+	stats, err := ebpf.EnableStats(uint32(unix.BPF_STATS_RUN_TIME))
+	if err != nil {
+		result.HitsError = fmt.Sprintf("could not enable the BPF statistics: %s", err)
+		return result, nil
+	}
+	defer stats.Close()
+
+	before := e.hookRunCounts(hooks)
+	select {
+	case <-time.After(hitsWindow):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	result.Hits = e.hookRunCounts(hooks)
+	for hook, count := range result.Hits {
+		// the run count restarts when the program of the hook is loaded again during the window
+		if count >= before[hook] {
+			result.Hits[hook] = count - before[hook]
+		}
+	}
+	result.HitsWindow = hitsWindow.String()
+	return result, nil
+}
+
+// hookRunCounts returns the run counts of the programs of the hooks
+func (e *ebpfProgram) hookRunCounts(hooks map[string]manager.ProbeIdentificationPair) map[string]uint64 {
+	counts := make(map[string]uint64, len(hooks))
+	for hook, id := range hooks {
+		probe, found := e.GetProbe(id)
+		if !found || probe.Program() == nil {
+			continue
+		}
+		info, err := probe.Program().Info()
+		if err != nil {
+			continue
+		}
+		if count, ok := info.RunCount(); ok {
+			counts[hook] = count
+		}
+	}
+	return counts
+}
+
+func sortUprobeAttachments(attachments []UprobeAttachment) {
+	sort.Slice(attachments, func(i, j int) bool {
+		return attachments[i].Path < attachments[j].Path
+	})
+}
@@ -0,0 +1,51 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http3
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// ConnTuple returns the network tuple of the datagram carrying the packet, the sender being the source
+func (e *EbpfInitial) ConnTuple() KeyTuple {
+	return KeyTuple{
+		SrcIPHigh: e.Tup.Saddr_h,
+		SrcIPLow:  e.Tup.Saddr_l,
+		DstIPHigh: e.Tup.Daddr_h,
+		DstIPLow:  e.Tup.Daddr_l,
+		SrcPort:   e.Tup.Sport,
+		DstPort:   e.Tup.Dport,
+	}
+}
+
+// Packet returns the beginning of the Initial packet, which is truncated to BufferSize bytes
+func (e *EbpfInitial) Packet() []byte {
+	return usmstats.Fragment(e.Fragment[:], int(e.Fragment_size))
+}
+
+// PacketLength returns the length of the UDP payload carrying the packet
+func (e *EbpfInitial) PacketLength() int {
+	return int(e.Packet_length)
+}
+
+// String returns a string representation of the packet
+func (e *EbpfInitial) String() string {
+	var output strings.Builder
+	output.WriteString("ebpfHTTP3Initial{")
+	output.WriteString(fmt.Sprintf("Source: %s:%d, ", util.FromLowHigh(e.Tup.Saddr_l, e.Tup.Saddr_h), e.Tup.Sport))
+	output.WriteString(fmt.Sprintf("Destination: %s:%d, ", util.FromLowHigh(e.Tup.Daddr_l, e.Tup.Daddr_h), e.Tup.Dport))
+	output.WriteString(fmt.Sprintf("Length: %d, ", e.Packet_length))
+	output.WriteString(fmt.Sprintf("Captured: %d", e.Fragment_size))
+	output.WriteString("}")
+	return output.String()
+}
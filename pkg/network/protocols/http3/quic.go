@@ -0,0 +1,462 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http3
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// The QUIC versions whose Initial packets are decrypted.
+// Ref: https://datatracker.ietf.org/doc/html/rfc9001#section-5.2 and https://datatracker.ietf.org/doc/html/rfc9369
+const (
+	versionDraft29 = 0xff00001d
+	version1       = 0x00000001
+	version2       = 0x6b3343cf
+)
+
+const (
+	longHeaderForm    = 0x80
+	fixedBit          = 0x40
+	maxCIDLength      = 20
+	packetNumberShift = 4
+
+	// sampleSize is the size of the sample of the ciphertext the header protection mask is computed from, which is
+	// taken 4 bytes after the beginning of the packet number
+	sampleSize   = 16
+	sampleOffset = 4
+	tagSize      = 16
+)
+
+// The types of the frames found in the Initial packets of the clients.
+// Ref: https://datatracker.ietf.org/doc/html/rfc9000#section-12.4
+const (
+	framePadding         = 0x00
+	framePing            = 0x01
+	frameAck             = 0x02
+	frameAckECN          = 0x03
+	frameCrypto          = 0x06
+	frameConnectionClose = 0x1c
+)
+
+const (
+	handshakeClientHello = 0x01
+	extensionServerName  = 0x0000
+	serverNameHostName   = 0x00
+)
+
+var (
+	errNotInitial   = errors.New("not a QUIC Initial packet")
+	errTruncated    = errors.New("truncated packet")
+	errMalformed    = errors.New("malformed packet")
+	errNoServerName = errors.New("no server name in the ClientHello")
+)
+
+// quicVersion holds the parameters used to derive the keys of the Initial packets of a QUIC version
+type quicVersion struct {
+	initialType byte
+	salt        []byte
+	keyLabel    string
+	ivLabel     string
+	hpLabel     string
+}
+
+var quicVersions = map[uint32]quicVersion{
+	version1: {
+		initialType: 0x0,
+		salt:        []byte{0x38, 0x76, 0x2c, 0xf7, 0xf5, 0x59, 0x34, 0xb3, 0x4d, 0x17, 0x9a, 0xe6, 0xa4, 0xc8, 0x0c, 0xad, 0xcc, 0xbb, 0x7f, 0x0a},
+		keyLabel:    "quic key",
+		ivLabel:     "quic iv",
+		hpLabel:     "quic hp",
+	},
+	versionDraft29: {
+		initialType: 0x0,
+		salt:        []byte{0xaf, 0xbf, 0xec, 0x28, 0x99, 0x93, 0xd2, 0x4c, 0x9e, 0x97, 0x86, 0xf1, 0x9c, 0x61, 0x11, 0xe0, 0x43, 0x90, 0xa8, 0x99},
+		keyLabel:    "quic key",
+		ivLabel:     "quic iv",
+		hpLabel:     "quic hp",
+	},
+	version2: {
+		initialType: 0x1,
+		salt:        []byte{0x0d, 0xed, 0xe3, 0xde, 0xf7, 0x00, 0xa6, 0xdb, 0x81, 0x93, 0x81, 0xbe, 0x6e, 0x26, 0x9d, 0xcb, 0xf9, 0xbd, 0x2e, 0xd9},
+		keyLabel:    "quicv2 key",
+		ivLabel:     "quicv2 iv",
+		hpLabel:     "quicv2 hp",
+	},
+}
+
+// initialKeys are the keys protecting the Initial packets sent by the client, which are derived from the destination
+// connection ID of its first Initial packet
+type initialKeys struct {
+	key []byte
+	iv  []byte
+	hp  []byte
+}
+
+func newInitialKeys(v quicVersion, dcid []byte) initialKeys {
+	initialSecret := hkdfExtract(v.salt, dcid)
+	clientSecret := hkdfExpandLabel(initialSecret, "client in", sha256.Size)
+	return initialKeys{
+		key: hkdfExpandLabel(clientSecret, v.keyLabel, 16),
+		iv:  hkdfExpandLabel(clientSecret, v.ivLabel, 12),
+		hp:  hkdfExpandLabel(clientSecret, v.hpLabel, 16),
+	}
+}
+
+// initialPacket is a decrypted client Initial packet
+type initialPacket struct {
+	dcid []byte
+	// crypto is the beginning of the CRYPTO stream carried by the packet, which holds the ClientHello
+	crypto []byte
+}
+
+// parseServerName returns the server name of the ClientHello carried by a client Initial packet, and the destination
+// connection ID of the packet. The packet may be truncated, in which case its payload is decrypted without being
+// authenticated, as long as the ClientHello is complete up to its server_name extension.
+func parseServerName(packet []byte, packetLength int) (string, []byte, error) {
+	initial, err := decryptInitial(packet, packetLength)
+	if err != nil {
+		return "", nil, err
+	}
+	serverName, err := clientHelloServerName(initial.crypto)
+	return serverName, initial.dcid, err
+}
+
+// decryptInitial removes the header protection of a client Initial packet, and decrypts its payload. packet holds the
+// beginning of the UDP payload, whose full length is packetLength.
+// Ref: https://datatracker.ietf.org/doc/html/rfc9001#section-5
+func decryptInitial(packet []byte, packetLength int) (*initialPacket, error) {
+	if len(packet) < 7 || packet[0]&(longHeaderForm|fixedBit) != longHeaderForm|fixedBit {
+		return nil, errNotInitial
+	}
+	v, ok := quicVersions[binary.BigEndian.Uint32(packet[1:])]
+	if !ok || (packet[0]>>packetNumberShift)&0x3 != v.initialType {
+		return nil, errNotInitial
+	}
+
+	offset := 5
+	dcidLength := int(packet[offset])
+	offset++
+	if dcidLength > maxCIDLength || offset+dcidLength >= len(packet) {
+		return nil, errMalformed
+	}
+	dcid := packet[offset : offset+dcidLength]
+	offset += dcidLength
+
+	scidLength := int(packet[offset])
+	offset += 1 + scidLength
+	if scidLength > maxCIDLength || offset > len(packet) {
+		return nil, errMalformed
+	}
+
+	tokenLength, n := readVarint(packet[offset:])
+	if n == 0 || tokenLength > uint64(len(packet)) {
+		return nil, errMalformed
+	}
+	offset += n + int(tokenLength)
+	if offset > len(packet) {
+		return nil, errMalformed
+	}
+
+	length, n := readVarint(packet[offset:])
+	if n == 0 {
+		return nil, errMalformed
+	}
+	pnOffset := offset + n
+	end := pnOffset + int(length)
+	if length > uint64(packetLength) || end > packetLength {
+		return nil, errMalformed
+	}
+	if pnOffset+sampleOffset+sampleSize > len(packet) {
+		return nil, errTruncated
+	}
+
+	keys := newInitialKeys(v, dcid)
+
+	// remove the header protection, on a copy of the header as the packet is shared with the caller
+	hp, err := aes.NewCipher(keys.hp)
+	if err != nil {
+		return nil, err
+	}
+	var mask [aes.BlockSize]byte
+	hp.Encrypt(mask[:], packet[pnOffset+sampleOffset:pnOffset+sampleOffset+sampleSize])
+	first := packet[0] ^ (mask[0] & 0x0f)
+	pnLength := int(first&0x3) + 1
+	header := make([]byte, pnOffset+pnLength)
+	copy(header, packet)
+	header[0] = first
+	var pn uint64
+	for i := 0; i < pnLength; i++ {
+		header[pnOffset+i] ^= mask[1+i]
+		pn = pn<<8 | uint64(header[pnOffset+i])
+	}
+
+	nonce := make([]byte, len(keys.iv))
+	copy(nonce, keys.iv)
+	for i := 0; i < 8; i++ {
+		nonce[len(nonce)-1-i] ^= byte(pn >> (8 * i))
+	}
+
+	block, err := aes.NewCipher(keys.key)
+	if err != nil {
+		return nil, err
+	}
+	payloadOffset := pnOffset + pnLength
+	if end <= payloadOffset+tagSize {
+		return nil, errMalformed
+	}
+
+	var plaintext []byte
+	if end <= len(packet) {
+		// the whole packet was captured, so it is authenticated
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err = aead.Open(nil, nonce, packet[payloadOffset:end], header)
+		if err != nil {
+			return nil, errMalformed
+		}
+	} else {
+		// AES-GCM encrypts the payload in counter mode, the first block of the payload using the counter 2
+		counter := make([]byte, aes.BlockSize)
+		copy(counter, nonce)
+		binary.BigEndian.PutUint32(counter[len(nonce):], 2)
+		plaintext = make([]byte, len(packet)-payloadOffset)
+		cipher.NewCTR(block, counter).XORKeyStream(plaintext, packet[payloadOffset:])
+	}
+
+	crypto, err := cryptoStream(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &initialPacket{dcid: append([]byte(nil), dcid...), crypto: crypto}, nil
+}
+
+type cryptoFrame struct {
+	offset uint64
+	data   []byte
+}
+
+// cryptoStream returns the beginning of the CRYPTO stream carried by the frames of the payload, whose CRYPTO frames
+// may be out of order. A truncated frame ends the payload, as the payloads of the truncated packets are decrypted up
+// to the end of the capture.
+func cryptoStream(payload []byte) ([]byte, error) {
+	var frames []cryptoFrame
+	for len(payload) > 0 {
+		frameType, n := readVarint(payload)
+		if n == 0 {
+			break
+		}
+		payload = payload[n:]
+
+		switch frameType {
+		case framePadding, framePing:
+			continue
+		case frameAck, frameAckECN:
+			var ok bool
+			if payload, ok = skipAck(payload, frameType == frameAckECN); !ok {
+				payload = nil
+			}
+		case frameCrypto:
+			offset, n := readVarint(payload)
+			if n == 0 {
+				payload = nil
+				break
+			}
+			payload = payload[n:]
+			length, n := readVarint(payload)
+			if n == 0 {
+				payload = nil
+				break
+			}
+			payload = payload[n:]
+			size := length
+			if size > uint64(len(payload)) {
+				size = uint64(len(payload))
+			}
+			frames = append(frames, cryptoFrame{offset: offset, data: payload[:size]})
+			payload = payload[size:]
+		case frameConnectionClose:
+			payload = nil
+		default:
+			return nil, errMalformed
+		}
+	}
+
+	// only the bytes following each other from the beginning of the stream are kept
+	sort.Slice(frames, func(i, j int) bool { return frames[i].offset < frames[j].offset })
+	var stream []byte
+	for _, frame := range frames {
+		end := frame.offset + uint64(len(frame.data))
+		if frame.offset > uint64(len(stream)) {
+			break
+		}
+		if end > uint64(len(stream)) {
+			stream = append(stream, frame.data[uint64(len(stream))-frame.offset:]...)
+		}
+	}
+	if len(stream) == 0 {
+		return nil, errTruncated
+	}
+	return stream, nil
+}
+
+// skipAck skips the fields of an ACK frame
+func skipAck(payload []byte, ecn bool) ([]byte, bool) {
+	// largest acknowledged, ack delay, ack range count and first ack range
+	var fields [4]uint64
+	for i := range fields {
+		value, n := readVarint(payload)
+		if n == 0 {
+			return nil, false
+		}
+		fields[i] = value
+		payload = payload[n:]
+	}
+	// each range holds a gap and a length, followed by the 3 ECN counts
+	count := fields[2] * 2
+	if ecn {
+		count += 3
+	}
+	for i := uint64(0); i < count; i++ {
+		_, n := readVarint(payload)
+		if n == 0 {
+			return nil, false
+		}
+		payload = payload[n:]
+	}
+	return payload, true
+}
+
+// clientHelloServerName returns the host name of the server_name extension of the ClientHello at the beginning of the
+// CRYPTO stream, which may be truncated after the extension.
+// Ref: https://datatracker.ietf.org/doc/html/rfc8446#section-4.1.2 and https://datatracker.ietf.org/doc/html/rfc6066#section-3
+func clientHelloServerName(hello []byte) (string, error) {
+	if len(hello) < 4 || hello[0] != handshakeClientHello {
+		return "", errMalformed
+	}
+	r := reader{buf: hello, ok: true}
+	r.skip(4 + 2 + 32)      // handshake header, legacy version and random
+	r.skip(int(r.uint8()))  // legacy session id
+	r.skip(int(r.uint16())) // cipher suites
+	r.skip(int(r.uint8()))  // legacy compression methods
+	extensionsLength := int(r.uint16())
+	if !r.ok {
+		return "", errTruncated
+	}
+	if extensionsLength < len(r.buf) {
+		r.buf = r.buf[:extensionsLength]
+	}
+
+	for len(r.buf) > 0 {
+		extensionType := r.uint16()
+		extensionLength := int(r.uint16())
+		if !r.ok {
+			return "", errTruncated
+		}
+		if extensionType != extensionServerName {
+			r.skip(extensionLength)
+			continue
+		}
+
+		r.uint16() // server name list length
+		nameType := r.uint8()
+		name := r.bytes(int(r.uint16()))
+		if !r.ok {
+			return "", errTruncated
+		}
+		if nameType != serverNameHostName || len(name) == 0 {
+			return "", errMalformed
+		}
+		return string(name), nil
+	}
+	if !r.ok {
+		return "", errTruncated
+	}
+	return "", errNoServerName
+}
+
+// reader reads the fields of a TLS message, ok being unset once the message is truncated
+type reader struct {
+	buf []byte
+	ok  bool
+}
+
+func (r *reader) bytes(n int) []byte {
+	if !r.ok || n > len(r.buf) {
+		r.ok = false
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *reader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
+
+// readVarint reads a variable-length integer, and returns it along with its size, which is 0 if buf is too short.
+// Ref: https://datatracker.ietf.org/doc/html/rfc9000#section-16
+func readVarint(buf []byte) (uint64, int) {
+	if len(buf) == 0 {
+		return 0, 0
+	}
+	size := 1 << (buf[0] >> 6)
+	if len(buf) < size {
+		return 0, 0
+	}
+	value := uint64(buf[0] & 0x3f)
+	for i := 1; i < size; i++ {
+		value = value<<8 | uint64(buf[i])
+	}
+	return value, size
+}
+
+// hkdfExtract and hkdfExpandLabel implement the HKDF functions of TLS 1.3 used to derive the keys of the Initial
+// packets, whose outputs are never larger than a SHA-256 digest.
+// Ref: https://datatracker.ietf.org/doc/html/rfc8446#section-7.1
+func hkdfExtract(salt, secret []byte) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(secret)
+	return mac.Sum(nil)
+}
+
+func hkdfExpandLabel(secret []byte, label string, length int) []byte {
+	label = "tls13 " + label
+	info := make([]byte, 2, 2+1+len(label)+1+1)
+	binary.BigEndian.PutUint16(info, uint16(length))
+	info = append(info, byte(len(label)))
+	info = append(info, label...)
+	// empty context, and the counter of the first block
+	info = append(info, 0, 1)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(info)
+	return mac.Sum(nil)[:length]
+}
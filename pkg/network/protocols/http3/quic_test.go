@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http3
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testServerName = "www.datadoghq.com"
+
+var testDCID = []byte{0x83, 0x94, 0xc8, 0xf0, 0x3e, 0x51, 0x57, 0x08}
+
+// clientHello builds a ClientHello, holding a supported_versions extension followed by a server_name extension if
+// serverName is not empty
+func clientHello(serverName string) []byte {
+	var extensions []byte
+	extensions = append(extensions, 0x00, 0x2b, 0x00, 0x03, 0x02, 0x03, 0x04)
+	if serverName != "" {
+		ext := make([]byte, 9, 9+len(serverName))
+		binary.BigEndian.PutUint16(ext[2:], uint16(5+len(serverName)))
+		binary.BigEndian.PutUint16(ext[4:], uint16(3+len(serverName)))
+		ext[6] = serverNameHostName
+		binary.BigEndian.PutUint16(ext[7:], uint16(len(serverName)))
+		extensions = append(extensions, append(ext, serverName...)...)
+	}
+
+	// legacy version, random, empty legacy session id, TLS_AES_128_GCM_SHA256 and the null compression method
+	body := []byte{0x03, 0x03}
+	body = append(body, make([]byte, 32)...)
+	body = append(body, 0x00, 0x00, 0x02, 0x13, 0x01, 0x01, 0x00)
+	body = append(body, byte(len(extensions)>>8), byte(len(extensions)))
+	body = append(body, extensions...)
+
+	hello := []byte{handshakeClientHello, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	return append(hello, body...)
+}
+
+// cryptoFrames splits the data into CRYPTO frames, the second half of the data being sent first if split is set
+func cryptoFrames(data []byte, split bool) []byte {
+	frame := func(offset int, data []byte) []byte {
+		return append([]byte{frameCrypto, 0x40 | byte(offset>>8), byte(offset), 0x40 | byte(len(data)>>8), byte(len(data))}, data...)
+	}
+	if !split {
+		return frame(0, data)
+	}
+	half := len(data) / 2
+	return append(frame(half, data[half:]), frame(0, data[:half])...)
+}
+
+// sealInitial builds a client Initial packet carrying the given frames, padded to the minimum size of the datagrams
+// carrying the Initial packets, the way a QUIC client sends it.
+// Ref: https://datatracker.ietf.org/doc/html/rfc9001#appendix-A.2
+func sealInitial(t *testing.T, version uint32, frames []byte) []byte {
+	v, ok := quicVersions[version]
+	require.True(t, ok)
+	keys := newInitialKeys(v, testDCID)
+
+	const pnLength = 2
+	const pn = 2
+	header := []byte{longHeaderForm | fixedBit | v.initialType<<packetNumberShift | (pnLength - 1), 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[1:], version)
+	header = append(header, byte(len(testDCID)))
+	header = append(header, testDCID...)
+	// empty source connection ID and token
+	header = append(header, 0x00, 0x00)
+
+	// the length of the packet is encoded on 2 bytes, which leaves 1200 bytes to the header and the payload
+	payloadLength := 1200 - len(header) - 2 - pnLength - tagSize
+	require.GreaterOrEqual(t, payloadLength, len(frames))
+	payload := append(frames, make([]byte, payloadLength-len(frames))...)
+	length := pnLength + len(payload) + tagSize
+	header = append(header, 0x40|byte(length>>8), byte(length), 0x00, pn)
+	pnOffset := len(header) - pnLength
+
+	nonce := append([]byte(nil), keys.iv...)
+	nonce[len(nonce)-1] ^= pn
+	block, err := aes.NewCipher(keys.key)
+	require.NoError(t, err)
+	aead, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	packet := aead.Seal(append([]byte(nil), header...), nonce, payload, header)
+
+	hp, err := aes.NewCipher(keys.hp)
+	require.NoError(t, err)
+	var mask [aes.BlockSize]byte
+	hp.Encrypt(mask[:], packet[pnOffset+sampleOffset:pnOffset+sampleOffset+sampleSize])
+	packet[0] ^= mask[0] & 0x0f
+	for i := 0; i < pnLength; i++ {
+		packet[pnOffset+i] ^= mask[1+i]
+	}
+	return packet
+}
+
+func TestInitialKeys(t *testing.T) {
+	// the keys of the client Initial packets of the example of RFC 9001
+	keys := newInitialKeys(quicVersions[version1], testDCID)
+	assert.Equal(t, "1f369613dd76d5467730efcbe3b1a22d", hex.EncodeToString(keys.key))
+	assert.Equal(t, "fa044b2f42a3fd3b46fb255c", hex.EncodeToString(keys.iv))
+	assert.Equal(t, "9f50449e04a0e810283a1e9933adedd2", hex.EncodeToString(keys.hp))
+}
+
+func TestParseServerName(t *testing.T) {
+	for name, version := range map[string]uint32{"v1": version1, "v2": version2, "draft-29": versionDraft29} {
+		t.Run(name, func(t *testing.T) {
+			packet := sealInitial(t, version, cryptoFrames(clientHello(testServerName), false))
+			require.Len(t, packet, 1200)
+
+			serverName, dcid, err := parseServerName(packet, len(packet))
+			require.NoError(t, err)
+			assert.Equal(t, testServerName, serverName)
+			assert.Equal(t, testDCID, dcid)
+		})
+	}
+}
+
+func TestParseServerNameTruncated(t *testing.T) {
+	// only the beginning of the packets is captured, so their payload is decrypted without being authenticated
+	packet := sealInitial(t, version1, cryptoFrames(clientHello(testServerName), false))
+	serverName, _, err := parseServerName(packet[:BufferSize], len(packet))
+	require.NoError(t, err)
+	assert.Equal(t, testServerName, serverName)
+
+	_, _, err = parseServerName(packet[:40], len(packet))
+	assert.Equal(t, errTruncated, err)
+}
+
+func TestParseServerNameOutOfOrderFrames(t *testing.T) {
+	frames := append([]byte{framePing}, cryptoFrames(clientHello(testServerName), true)...)
+	packet := sealInitial(t, version1, frames)
+	serverName, _, err := parseServerName(packet, len(packet))
+	require.NoError(t, err)
+	assert.Equal(t, testServerName, serverName)
+}
+
+func TestParseServerNameErrors(t *testing.T) {
+	packet := sealInitial(t, version1, cryptoFrames(clientHello(""), false))
+	_, _, err := parseServerName(packet, len(packet))
+	assert.Equal(t, errNoServerName, err)
+
+	// the authentication of the packet fails
+	packet = sealInitial(t, version1, cryptoFrames(clientHello(testServerName), false))
+	packet[len(packet)-1] ^= 0xff
+	_, _, err = parseServerName(packet, len(packet))
+	assert.Equal(t, errMalformed, err)
+
+	// a short header packet
+	packet[0] = fixedBit
+	_, _, err = parseServerName(packet, len(packet))
+	assert.Equal(t, errNotInitial, err)
+
+	// the Handshake packets of the version 1 have the type of the Initial packets of the version 2
+	packet = sealInitial(t, version2, cryptoFrames(clientHello(testServerName), false))
+	binary.BigEndian.PutUint32(packet[1:], version1)
+	_, _, err = parseServerName(packet, len(packet))
+	assert.Equal(t, errNotInitial, err)
+}
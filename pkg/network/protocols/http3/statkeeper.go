@@ -0,0 +1,119 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http3
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// handshakeKey identifies the handshake of a connection by the destination connection ID chosen by its client, which
+// the Initial packets carrying its ClientHello share
+type handshakeKey struct {
+	KeyTuple
+	dcid string
+}
+
+// StatKeeper aggregates the HTTP/3 requests by connection and server name. The server name is decrypted from the
+// ClientHello carried by the Initial packets of the clients, whose keys are derived from their destination connection
+// ID. The retransmissions of these packets are counted once, but a handshake restarted after a Retry packet of the
+// server is counted again, as it uses a new connection ID.
+type StatKeeper struct {
+	mux   sync.Mutex
+	stats *usmstats.Keeper[Key, RequestStat]
+	// handshakes holds the time of the latest Initial packet of the handshakes already counted
+	handshakes map[handshakeKey]uint64
+	// maxTracked bounds the number of handshakes tracked to ignore the retransmitted Initial packets
+	maxTracked int
+	idleTTL    uint64
+	// lastSeen is the time of the latest Initial packet, which the idle handshakes are evicted from
+	lastSeen  uint64
+	telemetry *telemetry
+
+	undecodedLogLimit *util.LogLimit
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(c *config.Config) *StatKeeper {
+	telemetry := newTelemetry()
+	return &StatKeeper{
+		stats:             usmstats.NewKeeper[Key, RequestStat](c.MaxHTTP3StatsBuffered, telemetry.dropped, telemetry.Aggregations),
+		handshakes:        make(map[handshakeKey]uint64),
+		maxTracked:        int(c.MaxTrackedConnections),
+		idleTTL:           uint64(c.HTTPIdleConnectionTTL.Nanoseconds()),
+		telemetry:         telemetry,
+		undecodedLogLimit: util.NewLogLimit(10, time.Minute*10),
+	}
+}
+
+// Process decrypts an Initial packet captured by the eBPF programs, and adds the request of the connection it opens
+// to the stats
+func (s *StatKeeper) Process(e *EbpfInitial) {
+	serverName, dcid, err := parseServerName(e.Packet(), e.PacketLength())
+	if err != nil {
+		s.telemetry.undecoded(err)
+		if s.undecodedLogLimit.ShouldLog() {
+			log.Debugf("http3 initial packet not decoded: %s: %s", e.String(), err)
+		}
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if e.Timestamp > s.lastSeen {
+		s.lastSeen = e.Timestamp
+	}
+	tuple := e.ConnTuple()
+	handshake := handshakeKey{KeyTuple: tuple, dcid: string(dcid)}
+	if _, ok := s.handshakes[handshake]; ok {
+		s.handshakes[handshake] = e.Timestamp
+		s.telemetry.retransmitted.Add(1)
+		return
+	}
+	if len(s.handshakes) < s.maxTracked {
+		s.handshakes[handshake] = e.Timestamp
+	}
+	s.telemetry.totalHits.Add(1)
+
+	key := Key{KeyTuple: tuple, ServerName: serverName}
+	stats := s.stats.Get(key)
+	if stats == nil {
+		return
+	}
+	stats.AddRequest()
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (s *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.evictIdle()
+	s.telemetry.Log()
+	return s.stats.GetAndReset()
+}
+
+// evictIdle evicts the handshakes which didn't see any Initial packet for longer than the idle TTL of the connections
+func (s *StatKeeper) evictIdle() {
+	if s.lastSeen <= s.idleTTL {
+		return
+	}
+	deadline := s.lastSeen - s.idleTTL
+	for handshake, lastSeen := range s.handshakes {
+		if lastSeen < deadline {
+			delete(s.handshakes, handshake)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 443
+
+func generateInitial(packet []byte, sport uint16, timestamp uint64) *EbpfInitial {
+	var e EbpfInitial
+	e.Tup.Saddr_l, e.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	e.Tup.Daddr_l, e.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	e.Tup.Sport = sport
+	e.Tup.Dport = serverPort
+	e.Timestamp = timestamp
+	e.Packet_length = uint16(len(packet))
+	e.Fragment_size = uint16(copy(e.Fragment[:], packet))
+	return &e
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxHTTP3StatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+func TestStatKeeperProcess(t *testing.T) {
+	sk := newTestStatKeeper()
+	packet := sealInitial(t, version1, cryptoFrames(clientHello(testServerName), false))
+
+	// the retransmission of the Initial packet is counted once
+	sk.Process(generateInitial(packet, protocolsUtils.ClientPort, 1000))
+	sk.Process(generateInitial(packet, protocolsUtils.ClientPort, 2000))
+	// a second connection to the same server
+	sk.Process(generateInitial(packet, protocolsUtils.ClientPort+1, 3000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+	key := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, testServerName)
+	require.Contains(t, stats, key)
+	assert.Equal(t, 1, stats[key].Count)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestStatKeeperUndecoded(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	// a ClientHello without server name, and a packet which is not a QUIC Initial packet
+	sk.Process(generateInitial(sealInitial(t, version1, cryptoFrames(clientHello(""), false)), protocolsUtils.ClientPort, 1000))
+	sk.Process(generateInitial(make([]byte, 1200), protocolsUtils.ClientPort, 1000))
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
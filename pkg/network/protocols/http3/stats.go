@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package http3 aggregates the HTTP/3 connections decoded from the QUIC Initial packets captured by the eBPF programs
+// of the Universal Service Monitoring. The packets following the Initial ones are encrypted with keys which are never
+// exchanged in the clear, so the requests are counted from the handshakes of the connections, by server name.
+package http3
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// KeyTuple represents the network tuple for a group of HTTP/3 requests, the client being the source
+type KeyTuple = usmstats.KeyTuple
+
+// Key is an identifier for a group of HTTP/3 requests
+type Key struct {
+	// this field order is intentional to help the GC pointer tracking
+	// ServerName is the server name indication (SNI) of the ClientHello of the connections
+	ServerName string
+	KeyTuple
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, serverName string) Key {
+	return Key{
+		KeyTuple:   usmstats.NewKeyTuple(saddr, daddr, sport, dport),
+		ServerName: serverName,
+	}
+}
+
+// RequestStat stores stats for the HTTP/3 requests sent to a particular server name
+type RequestStat struct {
+	// Count is the number of requests, which are counted from the handshakes of the connections: the requests
+	// multiplexed on a connection are encrypted, and only its handshake is seen
+	Count int
+}
+
+// AddRequest adds a request to the stats
+func (r *RequestStat) AddRequest() {
+	r.Count++
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	r.Count += newStats.Count
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := *r
+	return &clone
+}
@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package http3
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+type telemetry struct {
+	*usmstats.Telemetry
+
+	totalHits     *libtelemetry.Metric
+	retransmitted *libtelemetry.Metric // this happens when the Initial packet carrying a ClientHello is sent again
+	dropped       *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	truncated     *libtelemetry.Metric // this happens when the server name doesn't fit in the eBPF buffer
+	withoutHello  *libtelemetry.Metric // this happens for the Initial packets of the servers, or which continue a ClientHello
+	withoutSNI    *libtelemetry.Metric // this happens when the ClientHello has no server_name extension
+}
+
+func newTelemetry() *telemetry {
+	t := usmstats.NewTelemetry("http3")
+	return &telemetry{
+		Telemetry: t,
+
+		// these metrics are logged in the summary, and all but retransmitted are also exported as statsd metrics
+		totalHits:     t.NewSummaryMetric("total_hits", "handshakes_processed", libtelemetry.OptStatsd),
+		retransmitted: t.NewSummaryMetric("retransmitted", "handshakes_retransmitted"),
+		dropped:       t.NewSummaryMetric("dropped", "handshakes_dropped", libtelemetry.OptStatsd),
+		truncated:     t.NewSummaryMetric("truncated", "initials_truncated", libtelemetry.OptStatsd),
+		withoutHello:  t.NewSummaryMetric("without_client_hello", "initials_without_client_hello", libtelemetry.OptStatsd),
+		withoutSNI:    t.NewSummaryMetric("without_sni", "client_hellos_without_sni", libtelemetry.OptStatsd),
+	}
+}
+
+// undecoded counts an Initial packet whose server name could not be decoded
+func (t *telemetry) undecoded(err error) {
+	switch err {
+	case errTruncated:
+		t.truncated.Add(1)
+	case errNoServerName:
+		t.withoutSNI.Add(1)
+	default:
+		t.withoutHello.Add(1)
+	}
+}
@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ignore
+// +build ignore
+
+package http3
+
+/*
+#include "../../ebpf/c/tracer.h"
+#include "../../ebpf/c/protocols/http3/defs.h"
+#include "../../ebpf/c/protocols/http3/types.h"
+*/
+import "C"
+
+type ConnTuple C.conn_tuple_t
+
+type EbpfInitial C.http3_initial_t
+
+const (
+	BufferSize = C.HTTP3_BUFFER_SIZE
+)
@@ -0,0 +1,43 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf && go1.18
+// +build linux_bpf,go1.18
+
+package kafka
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+// FuzzProcess feeds raw events, as they would be read from the perf buffer by the monitor, to the statkeeper.
+func FuzzProcess(f *testing.F) {
+	protocolsUtils.AddCorpus(f, "kafka")
+
+	cfg := config.New()
+	cfg.MaxKafkaStatsBuffered = 1000
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sk := NewStatKeeper(cfg)
+
+		event := make([]byte, unsafe.Sizeof(EbpfTx{}))
+		copy(event, data)
+		tx := (*EbpfTx)(unsafe.Pointer(&event[0]))
+		sk.Process(tx)
+
+		for key, stats := range sk.GetAndResetAllStats() {
+			if len(key.TopicName) > TopicNameMaxSize || !isValidTopicName([]byte(key.TopicName)) {
+				t.Fatalf("malformed topic %q was not rejected", key.TopicName)
+			}
+			if stats.Count != 1 {
+				t.Fatalf("expected a single request, got %d", stats.Count)
+			}
+		}
+	})
+}
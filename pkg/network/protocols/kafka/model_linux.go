@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// ConnTuple returns the network tuple of the connection the request was sent on, the client being the source
+func (tx *EbpfTx) ConnTuple() KeyTuple {
+	return KeyTuple{
+		SrcIPHigh: tx.Tup.Saddr_h,
+		SrcIPLow:  tx.Tup.Saddr_l,
+		DstIPHigh: tx.Tup.Daddr_h,
+		DstIPLow:  tx.Tup.Daddr_l,
+		SrcPort:   tx.Tup.Sport,
+		DstPort:   tx.Tup.Dport,
+	}
+}
+
+// APIKey returns the API key of the request, either ProduceAPIKey or FetchAPIKey
+func (tx *EbpfTx) APIKey() uint16 {
+	return tx.Request_api_key
+}
+
+// APIVersion returns the version of the request
+func (tx *EbpfTx) APIVersion() uint16 {
+	return tx.Request_api_version
+}
+
+// Topic returns the name of the first topic of the request, which is truncated to TopicNameMaxSize bytes
+func (tx *EbpfTx) Topic() []byte {
+	size := int(tx.Topic_name_size)
+	if size > len(tx.Topic_name) {
+		size = len(tx.Topic_name)
+	}
+	return tx.Topic_name[:size]
+}
+
+// RequestLatency returns the latency of the request in nanoseconds, or 0 if the request did not get a response
+func (tx *EbpfTx) RequestLatency() float64 {
+	if tx.Request_started == 0 || tx.Response_received == 0 || tx.Response_received < tx.Request_started {
+		return 0
+	}
+	return float64(tx.Response_received - tx.Request_started)
+}
+
+// String returns a string representation of the transaction
+func (tx *EbpfTx) String() string {
+	var output strings.Builder
+	output.WriteString("ebpfKafkaTx{")
+	output.WriteString(fmt.Sprintf("Source: %s:%d, ", util.FromLowHigh(tx.Tup.Saddr_l, tx.Tup.Saddr_h), tx.Tup.Sport))
+	output.WriteString(fmt.Sprintf("Dest: %s:%d, ", util.FromLowHigh(tx.Tup.Daddr_l, tx.Tup.Daddr_h), tx.Tup.Dport))
+	output.WriteString(fmt.Sprintf("APIKey: %d, APIVersion: %d, ", tx.APIKey(), tx.APIVersion()))
+	output.WriteString(fmt.Sprintf("CorrelationID: %d, ", tx.Correlation_id))
+	output.WriteString(fmt.Sprintf("Topic: %q, ", tx.Topic()))
+	output.WriteString(fmt.Sprintf("Latency: %.0fns", tx.RequestLatency()))
+	output.WriteString("}")
+	return output.String()
+}
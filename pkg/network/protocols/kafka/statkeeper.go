@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package kafka
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// StatKeeper aggregates the Kafka requests by connection, topic and API key
+type StatKeeper struct {
+	mux        sync.Mutex
+	stats      map[Key]*RequestStat
+	maxEntries int
+	telemetry  *telemetry
+
+	// map containing interned topic names
+	// this is rotated with the stats map
+	topicNames map[string]string
+
+	malformedLogLimit *util.LogLimit
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(c *config.Config) *StatKeeper {
+	return &StatKeeper{
+		stats:             make(map[Key]*RequestStat),
+		maxEntries:        c.MaxKafkaStatsBuffered,
+		telemetry:         newTelemetry(),
+		topicNames:        make(map[string]string),
+		malformedLogLimit: util.NewLogLimit(10, time.Minute*10),
+	}
+}
+
+// Process adds a request decoded by the eBPF programs to the stats
+func (s *StatKeeper) Process(tx *EbpfTx) {
+	s.telemetry.count(tx)
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	topic := tx.Topic()
+	if !isValidTopicName(topic) {
+		s.telemetry.malformed.Add(1)
+		if s.malformedLogLimit.ShouldLog() {
+			log.Debugf("kafka topic name malformed: %s", tx.String())
+		}
+		return
+	}
+
+	key := Key{
+		KeyTuple:       tx.ConnTuple(),
+		TopicName:      s.intern(topic),
+		RequestAPIKey:  tx.APIKey(),
+		RequestVersion: tx.APIVersion(),
+	}
+	stats, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= s.maxEntries {
+			s.telemetry.dropped.Add(1)
+			return
+		}
+		s.telemetry.aggregations.Add(1)
+		stats = new(RequestStat)
+		s.stats[key] = stats
+	}
+	stats.AddRequest(tx.RequestLatency())
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (s *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.telemetry.log()
+	ret := s.stats // No deep copy needed since `s.stats` gets reset
+	s.stats = make(map[Key]*RequestStat)
+	s.topicNames = make(map[string]string)
+	return ret
+}
+
+func (s *StatKeeper) intern(b []byte) string {
+	v, ok := s.topicNames[string(b)]
+	if !ok {
+		v = string(b)
+		s.topicNames[v] = v
+	}
+	return v
+}
+
+// isValidTopicName checks that the topic name is only made of the characters allowed by the brokers: alphanumerics,
+// '.', '_' and '-'
+func isValidTopicName(topic []byte) bool {
+	if len(topic) == 0 {
+		return false
+	}
+	for _, c := range topic {
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '.' || c == '_' || c == '-' {
+			continue
+		}
+		return false
+	}
+	return true
+}
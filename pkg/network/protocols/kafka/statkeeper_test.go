@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func generateKafkaTx(source, dest util.Address, sourcePort, destPort int, topic string, apiKey, apiVersion uint16, latencyNS uint64) *EbpfTx {
+	var tx EbpfTx
+	tx.Tup.Saddr_l, tx.Tup.Saddr_h = util.ToLowHigh(source)
+	tx.Tup.Daddr_l, tx.Tup.Daddr_h = util.ToLowHigh(dest)
+	tx.Tup.Sport = uint16(sourcePort)
+	tx.Tup.Dport = uint16(destPort)
+	tx.Request_started = 1
+	if latencyNS > 0 {
+		tx.Response_received = tx.Request_started + latencyNS
+	}
+	tx.Request_api_key = apiKey
+	tx.Request_api_version = apiVersion
+	tx.Topic_name_size = uint16(len(topic))
+	copy(tx.Topic_name[:], topic)
+	return &tx
+}
+
+func TestStatKeeperProcess(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxKafkaStatsBuffered = 1000
+	sk := NewStatKeeper(cfg)
+
+	source := util.AddressFromString("1.1.1.1")
+	dest := util.AddressFromString("2.2.2.2")
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "orders", ProduceAPIKey, 7, 1000))
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "orders", ProduceAPIKey, 7, 3000))
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "orders", ProduceAPIKey, 7, 0))
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "orders", FetchAPIKey, 11, 2000))
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "payments", ProduceAPIKey, 7, 2000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 3)
+
+	produceKey := NewKey(source, dest, 60000, 9092, "orders", ProduceAPIKey, 7)
+	require.Contains(t, stats, produceKey)
+	assert.Equal(t, 3, stats[produceKey].Count)
+	assert.Equal(t, 2, stats[produceKey].LatencyCount)
+	require.NotNil(t, stats[produceKey].Latencies)
+
+	fetchKey := NewKey(source, dest, 60000, 9092, "orders", FetchAPIKey, 11)
+	require.Contains(t, stats, fetchKey)
+	assert.Equal(t, 1, stats[fetchKey].Count)
+	assert.Equal(t, 2000.0, stats[fetchKey].FirstLatencySample)
+
+	assert.Contains(t, stats, NewKey(source, dest, 60000, 9092, "payments", ProduceAPIKey, 7))
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestStatKeeperMaxEntries(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxKafkaStatsBuffered = 1
+	sk := NewStatKeeper(cfg)
+
+	source := util.AddressFromString("1.1.1.1")
+	dest := util.AddressFromString("2.2.2.2")
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "orders", ProduceAPIKey, 7, 1000))
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "payments", ProduceAPIKey, 7, 1000))
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "orders", ProduceAPIKey, 7, 1000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, 2, stats[NewKey(source, dest, 60000, 9092, "orders", ProduceAPIKey, 7)].Count)
+}
+
+func TestStatKeeperMalformedTopic(t *testing.T) {
+	cfg := config.New()
+	cfg.MaxKafkaStatsBuffered = 1000
+	sk := NewStatKeeper(cfg)
+
+	source := util.AddressFromString("1.1.1.1")
+	dest := util.AddressFromString("2.2.2.2")
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "orders\x00\x01", ProduceAPIKey, 7, 1000))
+	sk.Process(generateKafkaTx(source, dest, 60000, 9092, "", ProduceAPIKey, 7, 1000))
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestTopicTruncation(t *testing.T) {
+	topic := "a-very-long-topic-name-which-does-not-fit-in-the-buffer-of-the-ebpf-programs-at-all"
+	require.Greater(t, len(topic), TopicNameMaxSize)
+
+	var tx EbpfTx
+	tx.Topic_name_size = uint16(len(topic))
+	copy(tx.Topic_name[:], topic)
+	assert.Equal(t, topic[:TopicNameMaxSize], string(tx.Topic()))
+}
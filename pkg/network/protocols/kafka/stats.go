@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package kafka aggregates the Kafka requests decoded by the eBPF programs of the Universal Service Monitoring.
+package kafka
+
+import (
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RelativeAccuracy defines the acceptable error in quantile values calculated by DDSketch.
+// For example, if the actual value at p50 is 100, with a relative accuracy of 0.01 the value calculated
+// will be between 99 and 101
+const RelativeAccuracy = 0.01
+
+const (
+	// ProduceAPIKey is the API key of the produce requests
+	ProduceAPIKey = 0
+	// FetchAPIKey is the API key of the fetch requests
+	FetchAPIKey = 1
+)
+
+// KeyTuple represents the network tuple for a group of Kafka requests, the client being the source
+type KeyTuple struct {
+	SrcIPHigh uint64
+	SrcIPLow  uint64
+
+	DstIPHigh uint64
+	DstIPLow  uint64
+
+	// ports separated for alignment/size optimization
+	SrcPort uint16
+	DstPort uint16
+}
+
+// NewKeyTuple generates a new KeyTuple
+func NewKeyTuple(saddr, daddr util.Address, sport, dport uint16) KeyTuple {
+	saddrl, saddrh := util.ToLowHigh(saddr)
+	daddrl, daddrh := util.ToLowHigh(daddr)
+	return KeyTuple{
+		SrcIPHigh: saddrh,
+		SrcIPLow:  saddrl,
+		SrcPort:   sport,
+		DstIPHigh: daddrh,
+		DstIPLow:  daddrl,
+		DstPort:   dport,
+	}
+}
+
+// Key is an identifier for a group of Kafka requests
+type Key struct {
+	// this field order is intentional to help the GC pointer tracking
+	TopicName string
+	KeyTuple
+	RequestAPIKey  uint16
+	RequestVersion uint16
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, topicName string, requestAPIKey, requestVersion uint16) Key {
+	return Key{
+		KeyTuple:       NewKeyTuple(saddr, daddr, sport, dport),
+		TopicName:      topicName,
+		RequestAPIKey:  requestAPIKey,
+		RequestVersion: requestVersion,
+	}
+}
+
+// RequestStat stores stats for the Kafka requests of a particular topic and API key
+type RequestStat struct {
+	// this field order is intentional to help the GC pointer tracking
+	Latencies *ddsketch.DDSketch
+	// Count is the number of requests, which includes the produce requests sent with acks=0. These don't get a
+	// response, so their latency is unknown and is not accounted for in Latencies nor in LatencyCount.
+	Count        int
+	LatencyCount int
+
+	// This field holds the value (in nanoseconds) of the first latency sample. We do this as optimization to avoid
+	// creating sketches with a single value.
+	FirstLatencySample float64
+}
+
+// AddRequest adds a Kafka request to the stats. latency is ignored if it is 0 (unknown).
+func (r *RequestStat) AddRequest(latency float64) {
+	r.Count++
+	if latency > 0 {
+		r.addLatency(latency)
+	}
+}
+
+func (r *RequestStat) addLatency(latency float64) {
+	r.LatencyCount++
+	if r.LatencyCount == 1 {
+		// We postpone the creation of histograms when we have only one latency sample
+		r.FirstLatencySample = latency
+		return
+	}
+
+	if r.Latencies == nil {
+		var err error
+		r.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
+		if err != nil {
+			log.Debugf("error recording kafka request latency: could not create new ddsketch: %v", err)
+			return
+		}
+
+		// Add the deferred latency sample
+		if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+			log.Debugf("could not add kafka request latency to ddsketch: %v", err)
+		}
+	}
+
+	if err := r.Latencies.Add(latency); err != nil {
+		log.Debugf("could not add kafka request latency to ddsketch: %v", err)
+	}
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	r.Count += newStats.Count - newStats.LatencyCount
+	switch newStats.LatencyCount {
+	case 0:
+		return
+	case 1:
+		// The other bucket has a single latency sample, so we "manually" add it
+		r.Count++
+		r.addLatency(newStats.FirstLatencySample)
+		return
+	}
+
+	// The other bucket (newStats) has multiple samples and therefore a DDSketch object
+	// We first ensure that the bucket we're merging to has a DDSketch object
+	if r.Latencies == nil {
+		r.Latencies = newStats.Latencies.Copy()
+
+		// If we have a latency sample in this bucket we now add it to the DDSketch
+		if r.LatencyCount == 1 {
+			if err := r.Latencies.Add(r.FirstLatencySample); err != nil {
+				log.Debugf("could not add kafka request latency to ddsketch: %v", err)
+			}
+		}
+	} else if err := r.Latencies.MergeWith(newStats.Latencies); err != nil {
+		log.Debugf("error merging kafka requests: %v", err)
+	}
+	r.Count += newStats.LatencyCount
+	r.LatencyCount += newStats.LatencyCount
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := new(RequestStat)
+	clone.CombineWith(r)
+	return clone
+}
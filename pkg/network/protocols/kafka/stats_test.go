@@ -0,0 +1,92 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRequest(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10.0)
+	assert.Equal(t, 1, stats.Count)
+	assert.Equal(t, 1, stats.LatencyCount)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+	assert.Nil(t, stats.Latencies)
+
+	// a produce request sent with acks=0 doesn't have a latency
+	stats.AddRequest(0)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 1, stats.LatencyCount)
+	assert.Nil(t, stats.Latencies)
+
+	stats.AddRequest(15.0)
+	stats.AddRequest(20.0)
+	assert.Equal(t, 4, stats.Count)
+	assert.Equal(t, 3, stats.LatencyCount)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 3.0, stats.Latencies.GetCount())
+
+	verifyQuantile(t, stats, 0.0, 10.0)
+	verifyQuantile(t, stats, 1.0, 20.0)
+}
+
+func TestCombineWith(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(0)
+
+	single := new(RequestStat)
+	single.AddRequest(10.0)
+	stats.CombineWith(single)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 1, stats.LatencyCount)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+
+	multiple := new(RequestStat)
+	multiple.AddRequest(15.0)
+	multiple.AddRequest(20.0)
+	multiple.AddRequest(0)
+	stats.CombineWith(multiple)
+	assert.Equal(t, 5, stats.Count)
+	assert.Equal(t, 3, stats.LatencyCount)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 3.0, stats.Latencies.GetCount())
+	verifyQuantile(t, stats, 0.0, 10.0)
+	verifyQuantile(t, stats, 1.0, 20.0)
+
+	// the combined stats are left untouched
+	assert.Equal(t, 3, multiple.Count)
+	assert.Equal(t, 2.0, multiple.Latencies.GetCount())
+}
+
+func TestClone(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10.0)
+	stats.AddRequest(20.0)
+	stats.AddRequest(0)
+
+	clone := stats.Clone()
+	assert.Equal(t, stats.Count, clone.Count)
+	assert.Equal(t, stats.LatencyCount, clone.LatencyCount)
+	require.NotNil(t, clone.Latencies)
+	assert.Equal(t, 2.0, clone.Latencies.GetCount())
+
+	clone.AddRequest(30.0)
+	assert.Equal(t, 3, stats.Count)
+	assert.Equal(t, 2.0, stats.Latencies.GetCount())
+}
+
+func verifyQuantile(t *testing.T, stats *RequestStat, q float64, expectedValue float64) {
+	val, err := stats.Latencies.GetValueAtQuantile(q)
+	require.NoError(t, err)
+
+	acceptableError := expectedValue * stats.Latencies.IndexMapping.RelativeAccuracy()
+	assert.GreaterOrEqual(t, val, expectedValue-acceptableError)
+	assert.LessOrEqual(t, val, expectedValue+acceptableError)
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package kafka
+
+import (
+	"time"
+
+	"go.uber.org/atomic"
+
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+type telemetry struct {
+	then *atomic.Int64
+
+	produceHits, fetchHits *libtelemetry.Metric
+
+	totalHits    *libtelemetry.Metric
+	dropped      *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	malformed    *libtelemetry.Metric // this happens when the request doesn't have the expected format
+	aggregations *libtelemetry.Metric
+}
+
+func newTelemetry() *telemetry {
+	metricGroup := libtelemetry.NewMetricGroup(
+		"usm.kafka",
+		libtelemetry.OptExpvar,
+		libtelemetry.OptMonotonic,
+	)
+
+	return &telemetry{
+		then:         atomic.NewInt64(time.Now().Unix()),
+		produceHits:  metricGroup.NewMetric("produce_hits"),
+		fetchHits:    metricGroup.NewMetric("fetch_hits"),
+		aggregations: metricGroup.NewMetric("aggregations"),
+
+		// these metrics are also exported as statsd metrics
+		totalHits: metricGroup.NewMetric("total_hits", libtelemetry.OptStatsd),
+		dropped:   metricGroup.NewMetric("dropped", libtelemetry.OptStatsd),
+		malformed: metricGroup.NewMetric("malformed", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) count(tx *EbpfTx) {
+	switch tx.APIKey() {
+	case ProduceAPIKey:
+		t.produceHits.Add(1)
+	case FetchAPIKey:
+		t.fetchHits.Add(1)
+	}
+	t.totalHits.Add(1)
+}
+
+func (t *telemetry) log() {
+	now := time.Now().Unix()
+	then := t.then.Swap(now)
+
+	totalRequests := t.totalHits.Delta()
+	dropped := t.dropped.Delta()
+	malformed := t.malformed.Delta()
+	aggregations := t.aggregations.Delta()
+	elapsed := now - then
+
+	log.Debugf(
+		"kafka stats summary: requests_processed=%d(%.2f/s) requests_dropped=%d(%.2f/s) requests_malformed=%d(%.2f/s) aggregations=%d",
+		totalRequests,
+		float64(totalRequests)/float64(elapsed),
+		dropped,
+		float64(dropped)/float64(elapsed),
+		malformed,
+		float64(malformed)/float64(elapsed),
+		aggregations,
+	)
+}
@@ -0,0 +1,177 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package memcached
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// The header of the binary protocol, of which only the magic byte, the opcode and the status of the responses are
+// decoded.
+// Ref: https://github.com/memcached/memcached/wiki/BinaryProtocolRevamped#packet-structure
+const (
+	binaryRequestMagic  = 0x80
+	binaryResponseMagic = 0x81
+	binaryStatusOffset  = 6
+
+	binaryRequestMinSize  = 2
+	binaryResponseMinSize = binaryStatusOffset + 2
+
+	statusNoError       = 0x0000
+	statusKeyNotFound   = 0x0001
+	statusKeyExists     = 0x0002
+	statusItemNotStored = 0x0005
+)
+
+var (
+	errMalformed = errors.New("malformed memcached message")
+	// errUnsupported is returned for the requests which are not reported, such as the quiet requests of the binary
+	// protocol, which are only answered when they fail, or the administrative ones
+	errUnsupported = errors.New("unsupported memcached request")
+)
+
+// textOps maps the commands of the text protocol to their operation.
+// Ref: https://github.com/memcached/memcached/blob/master/doc/protocol.txt
+var textOps = map[string]string{
+	"get":     "get",
+	"gets":    "gets",
+	"gat":     "gat",
+	"gats":    "gats",
+	"set":     "set",
+	"add":     "add",
+	"replace": "replace",
+	"append":  "append",
+	"prepend": "prepend",
+	"cas":     "cas",
+	"delete":  "delete",
+	"incr":    "incr",
+	"decr":    "decr",
+	"touch":   "touch",
+}
+
+// binaryOps maps the opcodes of the binary protocol to their operation, the variants returning the key of the items
+// being reported as their base operation.
+// Ref: https://github.com/memcached/memcached/wiki/BinaryProtocolRevamped#command-opcodes
+var binaryOps = map[byte]string{
+	0x00: "get",
+	0x01: "set",
+	0x02: "add",
+	0x03: "replace",
+	0x04: "delete",
+	0x05: "incr",
+	0x06: "decr",
+	0x0c: "get",
+	0x0e: "append",
+	0x0f: "prepend",
+	0x1c: "touch",
+	0x1d: "gat",
+	0x23: "gat",
+}
+
+// textResults maps the first word of the responses of the text protocol to the result of their request. The get
+// commands are answered with a VALUE line for each item found, followed by END, so the requests of several keys hit
+// as soon as one of them is found. The responses of the incr and decr commands holding the new value are decoded
+// separately.
+var textResults = map[string]Result{
+	"VALUE":        Hit,
+	"STORED":       Hit,
+	"DELETED":      Hit,
+	"TOUCHED":      Hit,
+	"END":          Miss,
+	"NOT_STORED":   Miss,
+	"EXISTS":       Miss,
+	"NOT_FOUND":    Miss,
+	"ERROR":        Error,
+	"CLIENT_ERROR": Error,
+	"SERVER_ERROR": Error,
+}
+
+// request is the beginning of a memcached request
+type request struct {
+	op string
+	// binary is true if the request uses the binary protocol, whose response must use it as well
+	binary bool
+}
+
+// decodeRequest returns the operation of a request of the text or the binary protocol
+func decodeRequest(fragment []byte) (request, error) {
+	if len(fragment) == 0 {
+		return request{}, errMalformed
+	}
+
+	if fragment[0] == binaryRequestMagic {
+		if len(fragment) < binaryRequestMinSize {
+			return request{}, errMalformed
+		}
+		op, ok := binaryOps[fragment[1]]
+		if !ok {
+			return request{}, errUnsupported
+		}
+		return request{op: op, binary: true}, nil
+	}
+
+	word, ok := firstWord(fragment)
+	if !ok {
+		return request{}, errMalformed
+	}
+	op, ok := textOps[string(word)]
+	if !ok {
+		return request{}, errUnsupported
+	}
+	return request{op: op}, nil
+}
+
+// decodeResponse returns the result of the request answered by a response, which uses the same protocol as the request
+func decodeResponse(req request, fragment []byte) (Result, error) {
+	if req.binary {
+		if len(fragment) < binaryResponseMinSize || fragment[0] != binaryResponseMagic {
+			return 0, errMalformed
+		}
+		switch binary.BigEndian.Uint16(fragment[binaryStatusOffset:]) {
+		case statusNoError:
+			return Hit, nil
+		case statusKeyNotFound, statusKeyExists, statusItemNotStored:
+			return Miss, nil
+		default:
+			return Error, nil
+		}
+	}
+
+	word, ok := firstWord(fragment)
+	if !ok {
+		return 0, errMalformed
+	}
+	if result, ok := textResults[string(word)]; ok {
+		return result, nil
+	}
+	if (req.op == "incr" || req.op == "decr") && isNumber(word) {
+		return Hit, nil
+	}
+	return 0, errMalformed
+}
+
+// firstWord returns the first word of a line of the text protocol, which may be truncated
+func firstWord(fragment []byte) ([]byte, bool) {
+	end := bytes.IndexAny(fragment, " \r")
+	if end < 0 {
+		end = len(fragment)
+	}
+	if end == 0 {
+		return nil, false
+	}
+	return fragment[:end], true
+}
+
+func isNumber(word []byte) bool {
+	for _, c := range word {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
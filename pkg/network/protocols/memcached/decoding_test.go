@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package memcached
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// binaryHeader encodes the header of a message of the binary protocol
+func binaryHeader(magic, opcode byte, status uint16) []byte {
+	b := make([]byte, 24)
+	b[0] = magic
+	b[1] = opcode
+	binary.BigEndian.PutUint16(b[6:], status)
+	return b
+}
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		op       string
+		binary   bool
+		err      error
+	}{
+		{name: "get", fragment: "get key\r\n", op: "get"},
+		{name: "gets several keys", fragment: "gets key1 key2 key3\r\n", op: "gets"},
+		{name: "set", fragment: "set key 0 0 5\r\nvalue\r\n", op: "set"},
+		{name: "truncated key", fragment: "delete a-very-long-key-which-is-tr", op: "delete"},
+		{name: "truncated command", fragment: "prepend", op: "prepend"},
+		{name: "administrative command", fragment: "stats\r\n", err: errUnsupported},
+		{name: "empty line", fragment: "\r\n", err: errMalformed},
+		{name: "empty", fragment: "", err: errMalformed},
+		{name: "binary get", fragment: string(binaryHeader(binaryRequestMagic, 0x00, 0)), op: "get", binary: true},
+		{name: "binary getk", fragment: string(binaryHeader(binaryRequestMagic, 0x0c, 0)), op: "get", binary: true},
+		{name: "binary increment", fragment: string(binaryHeader(binaryRequestMagic, 0x05, 0)), op: "incr", binary: true},
+		{name: "binary quiet set", fragment: string(binaryHeader(binaryRequestMagic, 0x11, 0)), err: errUnsupported},
+		{name: "binary noop", fragment: string(binaryHeader(binaryRequestMagic, 0x0a, 0)), err: errUnsupported},
+		{name: "binary truncated", fragment: string([]byte{binaryRequestMagic}), err: errMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := decodeRequest([]byte(tt.fragment))
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.op, req.op)
+			assert.Equal(t, tt.binary, req.binary)
+		})
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      request
+		fragment string
+		result   Result
+		err      error
+	}{
+		{name: "get hit", req: request{op: "get"}, fragment: "VALUE key 0 5\r\nvalue\r\nEND\r\n", result: Hit},
+		{name: "get miss", req: request{op: "get"}, fragment: "END\r\n", result: Miss},
+		{name: "set stored", req: request{op: "set"}, fragment: "STORED\r\n", result: Hit},
+		{name: "add not stored", req: request{op: "add"}, fragment: "NOT_STORED\r\n", result: Miss},
+		{name: "cas exists", req: request{op: "cas"}, fragment: "EXISTS\r\n", result: Miss},
+		{name: "delete deleted", req: request{op: "delete"}, fragment: "DELETED\r\n", result: Hit},
+		{name: "delete not found", req: request{op: "delete"}, fragment: "NOT_FOUND\r\n", result: Miss},
+		{name: "incr value", req: request{op: "incr"}, fragment: "42\r\n", result: Hit},
+		{name: "client error", req: request{op: "set"}, fragment: "CLIENT_ERROR bad data chunk\r\n", result: Error},
+		{name: "server error", req: request{op: "set"}, fragment: "SERVER_ERROR out of memory storing object\r\n", result: Error},
+		{name: "value of a set", req: request{op: "set"}, fragment: "42\r\n", err: errMalformed},
+		{name: "unknown", req: request{op: "get"}, fragment: "HELLO\r\n", err: errMalformed},
+		{name: "binary hit", req: request{op: "get", binary: true}, fragment: string(binaryHeader(binaryResponseMagic, 0x00, statusNoError)), result: Hit},
+		{name: "binary miss", req: request{op: "get", binary: true}, fragment: string(binaryHeader(binaryResponseMagic, 0x00, statusKeyNotFound)), result: Miss},
+		{name: "binary not stored", req: request{op: "add", binary: true}, fragment: string(binaryHeader(binaryResponseMagic, 0x02, statusKeyExists)), result: Miss},
+		{name: "binary error", req: request{op: "set", binary: true}, fragment: string(binaryHeader(binaryResponseMagic, 0x01, 0x0003)), result: Error},
+		{name: "binary request answered in text", req: request{op: "get", binary: true}, fragment: "END\r\n", err: errMalformed},
+		{name: "text request answered in binary", req: request{op: "get"}, fragment: string(binaryHeader(binaryResponseMagic, 0x00, statusNoError)), err: errMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := decodeResponse(tt.req, []byte(tt.fragment))
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.result, result)
+		})
+	}
+}
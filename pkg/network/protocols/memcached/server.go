@@ -0,0 +1,25 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package memcached
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http/testutil"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+)
+
+func RunServer(t *testing.T, serverAddr, serverPort string) *protocolsUtils.DockerServer {
+	env := []string{
+		"MEMCACHED_ADDR=" + serverAddr,
+		"MEMCACHED_PORT=" + serverPort,
+	}
+
+	t.Helper()
+	dir, _ := testutil.CurDir()
+	return protocolsUtils.RunDockerServer(t, "memcached", dir+"/testdata/docker-compose.yml", env, regexp.MustCompile(".*server listening.*"))
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package memcached
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// StatKeeper aggregates the memcached requests by connection and operation
+type StatKeeper struct {
+	mux       sync.Mutex
+	stats     *usmstats.Keeper[Key, RequestStat]
+	telemetry *telemetry
+
+	malformedLogLimit *util.LogLimit
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(c *config.Config) *StatKeeper {
+	telemetry := newTelemetry()
+	return &StatKeeper{
+		stats:             usmstats.NewKeeper[Key, RequestStat](c.MaxMemcachedStatsBuffered, telemetry.dropped, telemetry.Aggregations),
+		telemetry:         telemetry,
+		malformedLogLimit: util.NewLogLimit(10, time.Minute*10),
+	}
+}
+
+// Process adds a request decoded by the eBPF programs to the stats
+func (s *StatKeeper) Process(tx *EbpfTx) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	req, err := decodeRequest(tx.RequestFragment())
+	if err != nil {
+		s.undecoded(tx, err)
+		return
+	}
+	result, err := decodeResponse(req, tx.ResponseFragment())
+	if err != nil {
+		s.undecoded(tx, err)
+		return
+	}
+	s.telemetry.count(req, result)
+
+	key := Key{
+		KeyTuple: tx.ConnTuple(),
+		Op:       req.op,
+	}
+	stats := s.stats.Get(key)
+	if stats == nil {
+		return
+	}
+	stats.AddRequest(tx.RequestLatency(), result)
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (s *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.telemetry.Log()
+	return s.stats.GetAndReset()
+}
+
+func (s *StatKeeper) undecoded(tx *EbpfTx, err error) {
+	if err == errUnsupported {
+		s.telemetry.unsupported.Add(1)
+		return
+	}
+	s.telemetry.malformed.Add(1)
+	if s.malformedLogLimit.ShouldLog() {
+		log.Debugf("memcached request malformed: %s", tx.String())
+	}
+}
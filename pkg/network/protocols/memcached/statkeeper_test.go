@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package memcached
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 11211
+
+func generateMemcachedTx(clientPort uint16, request, response []byte, latencyNS uint64) *EbpfTx {
+	var tx EbpfTx
+	tx.Tup.Saddr_l, tx.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	tx.Tup.Daddr_l, tx.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	tx.Tup.Sport = clientPort
+	tx.Tup.Dport = serverPort
+	tx.Request_started = 1
+	tx.Response_received = tx.Request_started + latencyNS
+	tx.Request_fragment_size = uint16(copy(tx.Request_fragment[:], request))
+	tx.Response_fragment_size = uint16(copy(tx.Response_fragment[:], response))
+	return &tx
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxMemcachedStatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+func TestStatKeeperText(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, []byte("get key\r\n"), []byte("VALUE key 0 5\r\nvalue\r\nEND\r\n"), 1000))
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, []byte("get missing\r\n"), []byte("END\r\n"), 2000))
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, []byte("set key 0 0 5\r\nvalue\r\n"), []byte("STORED\r\n"), 3000))
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, []byte("delete key\r\n"), []byte("SERVER_ERROR out of memory\r\n"), 4000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 3)
+
+	getKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "get")
+	require.Contains(t, stats, getKey)
+	assert.Equal(t, 2, stats[getKey].Count)
+	assert.Equal(t, 1, stats[getKey].Hits)
+	assert.Equal(t, 1, stats[getKey].Misses)
+	require.NotNil(t, stats[getKey].Latencies)
+
+	setKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "set")
+	require.Contains(t, stats, setKey)
+	assert.Equal(t, 1, stats[setKey].Hits)
+	assert.Equal(t, 3000.0, stats[setKey].FirstLatencySample)
+
+	deleteKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "delete")
+	require.Contains(t, stats, deleteKey)
+	assert.Equal(t, 1, stats[deleteKey].Errors)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestStatKeeperBinary(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	// the GETK requests are reported as get requests
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, binaryHeader(binaryRequestMagic, 0x00, 0), binaryHeader(binaryResponseMagic, 0x00, statusNoError), 1000))
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, binaryHeader(binaryRequestMagic, 0x0c, 0), binaryHeader(binaryResponseMagic, 0x0c, statusKeyNotFound), 2000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+
+	getKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "get")
+	require.Contains(t, stats, getKey)
+	assert.Equal(t, 2, stats[getKey].Count)
+	assert.Equal(t, 1, stats[getKey].Hits)
+	assert.Equal(t, 1, stats[getKey].Misses)
+}
+
+func TestStatKeeperUndecoded(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, []byte("version\r\n"), []byte("VERSION 1.6.21\r\n"), 1000))
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, binaryHeader(binaryRequestMagic, 0x09, 0), binaryHeader(binaryResponseMagic, 0x09, statusNoError), 1000))
+	sk.Process(generateMemcachedTx(protocolsUtils.ClientPort, []byte("get key\r\n"), []byte("HELLO\r\n"), 1000))
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
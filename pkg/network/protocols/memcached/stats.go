@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package memcached aggregates the memcached requests decoded by the eBPF programs of the Universal Service Monitoring.
+package memcached
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// Result is the outcome of a memcached request
+type Result uint8
+
+const (
+	// Hit is the result of the requests which found the item, or stored it
+	Hit Result = iota
+	// Miss is the result of the requests which did not find the item, or did not store it
+	Miss
+	// Error is the result of the requests answered with an error
+	Error
+)
+
+// KeyTuple represents the network tuple for a group of memcached requests, the client being the source
+type KeyTuple = usmstats.KeyTuple
+
+// Key is an identifier for a group of memcached requests
+type Key struct {
+	// Op is the name of the operation of the requests, such as get, set or delete
+	Op string
+	KeyTuple
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, op string) Key {
+	return Key{
+		KeyTuple: usmstats.NewKeyTuple(saddr, daddr, sport, dport),
+		Op:       op,
+	}
+}
+
+// RequestStat stores stats for the memcached requests of the same operation
+type RequestStat struct {
+	usmstats.LatencyStats
+	Hits   int
+	Misses int
+	Errors int
+}
+
+// AddRequest adds a memcached request to the stats, along with the result of its response
+func (r *RequestStat) AddRequest(latency float64, result Result) {
+	switch result {
+	case Hit:
+		r.Hits++
+	case Miss:
+		r.Misses++
+	case Error:
+		r.Errors++
+	}
+	r.AddLatency(latency)
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	r.Hits += newStats.Hits
+	r.Misses += newStats.Misses
+	r.Errors += newStats.Errors
+	r.CombineLatencies(&newStats.LatencyStats)
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := new(RequestStat)
+	clone.CombineWith(r)
+	return clone
+}
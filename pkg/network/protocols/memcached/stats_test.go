@@ -0,0 +1,70 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package memcached
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+func TestAddRequest(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, Hit)
+	assert.Equal(t, 1, stats.Count)
+	assert.Equal(t, 1, stats.Hits)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+	assert.Nil(t, stats.Latencies)
+
+	stats.AddRequest(20, Miss)
+	stats.AddRequest(30, Error)
+	assert.Equal(t, 3, stats.Count)
+	assert.Equal(t, 1, stats.Hits)
+	assert.Equal(t, 1, stats.Misses)
+	assert.Equal(t, 1, stats.Errors)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 3.0, stats.Latencies.GetCount())
+}
+
+func TestCombineWith(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, Hit)
+
+	single := new(RequestStat)
+	single.AddRequest(20, Miss)
+	stats.CombineWith(single)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 1, stats.Hits)
+	assert.Equal(t, 1, stats.Misses)
+	require.NotNil(t, stats.Latencies)
+
+	multiple := new(RequestStat)
+	multiple.AddRequest(30, Hit)
+	multiple.AddRequest(40, Error)
+	clone := multiple.Clone()
+	stats.CombineWith(multiple)
+	assert.Equal(t, 4, stats.Count)
+	assert.Equal(t, 2, stats.Hits)
+	assert.Equal(t, 1, stats.Misses)
+	assert.Equal(t, 1, stats.Errors)
+	assert.Equal(t, 4.0, stats.Latencies.GetCount())
+
+	// the combined stats are left untouched
+	assert.Equal(t, 2, multiple.Count)
+	assert.Equal(t, 2.0, multiple.Latencies.GetCount())
+	assert.Equal(t, *multiple, RequestStat{
+		LatencyStats: usmstats.LatencyStats{Latencies: multiple.Latencies, Count: 2, FirstLatencySample: 30},
+		Hits:         1,
+		Errors:       1,
+	})
+	assert.Equal(t, multiple.Count, clone.Count)
+	assert.Equal(t, multiple.Hits, clone.Hits)
+	assert.Equal(t, multiple.Errors, clone.Errors)
+	assert.Equal(t, 2.0, clone.Latencies.GetCount())
+}
@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package memcached
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+type telemetry struct {
+	*usmstats.Telemetry
+
+	text, binary *libtelemetry.Metric
+
+	totalHits   *libtelemetry.Metric
+	misses      *libtelemetry.Metric // this happens when the item of the request is not found, or not stored
+	errors      *libtelemetry.Metric // this happens when the server answers with an error
+	dropped     *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	malformed   *libtelemetry.Metric // this happens when the request or the response can't be decoded
+	unsupported *libtelemetry.Metric // this happens for the quiet and the administrative requests
+}
+
+func newTelemetry() *telemetry {
+	t := usmstats.NewTelemetry("memcached")
+	return &telemetry{
+		Telemetry:   t,
+		text:        t.NewMetric("text"),
+		binary:      t.NewMetric("binary"),
+		unsupported: t.NewMetric("unsupported"),
+
+		// these metrics are also exported as statsd metrics, and logged in the summary
+		totalHits: t.NewSummaryMetric("total_hits", "requests_processed", libtelemetry.OptStatsd),
+		misses:    t.NewSummaryMetric("misses", "requests_missed", libtelemetry.OptStatsd),
+		errors:    t.NewSummaryMetric("errors", "requests_failed", libtelemetry.OptStatsd),
+		dropped:   t.NewSummaryMetric("dropped", "requests_dropped", libtelemetry.OptStatsd),
+		malformed: t.NewSummaryMetric("malformed", "requests_malformed", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) count(req request, result Result) {
+	if req.binary {
+		t.binary.Add(1)
+	} else {
+		t.text.Add(1)
+	}
+	switch result {
+	case Miss:
+		t.misses.Add(1)
+	case Error:
+		t.errors.Add(1)
+	}
+	t.totalHits.Add(1)
+}
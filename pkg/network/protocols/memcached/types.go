@@ -0,0 +1,25 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ignore
+// +build ignore
+
+package memcached
+
+/*
+#include "../../ebpf/c/tracer.h"
+#include "../../ebpf/c/protocols/memcached/defs.h"
+#include "../../ebpf/c/protocols/memcached/types.h"
+*/
+import "C"
+
+type ConnTuple C.conn_tuple_t
+
+type EbpfTx C.memcached_transaction_t
+
+const (
+	BufferSize   = C.MEMCACHED_BUFFER_SIZE
+	ResponseSize = C.MEMCACHED_RESPONSE_SIZE
+)
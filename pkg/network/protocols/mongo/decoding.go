@@ -0,0 +1,271 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mongo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// The opcodes of the messages decoded in userspace.
+// Ref: https://www.mongodb.com/docs/manual/reference/mongodb-wire-protocol/
+const (
+	opReply = 1
+	opQuery = 2004
+	opMsg   = 2013
+
+	// headerSize is the size of the standard message header
+	headerSize = 16
+
+	// the kinds of the sections of the OP_MSG messages
+	bodySection             = 0
+	documentSequenceSection = 1
+
+	// queryFailureFlag is the bit of the flags of the OP_REPLY messages set when the query failed
+	queryFailureFlag = 1 << 1
+	// replyPrefixSize is the size of the fields of the OP_REPLY messages preceding their documents
+	replyPrefixSize = 20
+	// queryFieldsSize is the size of the numberToSkip and numberToReturn fields of the OP_QUERY messages
+	queryFieldsSize = 8
+
+	// commandsCollection is the pseudo collection the commands are sent to with OP_QUERY messages
+	commandsCollection = "$cmd"
+	// legacyQueryCommand is the command reported for the OP_QUERY messages querying a collection
+	legacyQueryCommand = "query"
+
+	// maxCommandSize is the size of the longest command name accepted, which bounds the cardinality of the stats
+	maxCommandSize = 64
+)
+
+// The types of the BSON values decoded in userspace.
+// Ref: https://bsonspec.org/spec.html
+const (
+	bsonDouble  = 0x01
+	bsonString  = 0x02
+	bsonBoolean = 0x08
+	bsonInt32   = 0x10
+	bsonInt64   = 0x12
+)
+
+// request is a command sent by the client
+type request struct {
+	// command is the name of the command, such as "find" or "insert"
+	command string
+	// collection is the collection the command applies to, empty for the commands not applying to a collection,
+	// such as "hello" or "ping"
+	collection string
+}
+
+// element is the first element of a BSON document
+type element struct {
+	name  []byte
+	kind  byte
+	value []byte
+}
+
+// decodeRequest decodes the beginning of an OP_MSG or OP_QUERY message. The command is the first element of the
+// command document, whose value is the name of the collection for the commands applying to a collection.
+func decodeRequest(fragment []byte) (request, bool) {
+	opCode, body, ok := readHeader(fragment)
+	if !ok {
+		return request{}, false
+	}
+
+	var document []byte
+	switch opCode {
+	case opMsg:
+		document, ok = readBody(body)
+		if !ok {
+			return request{}, false
+		}
+	case opQuery:
+		// the flags precede the full name of the collection, which is prefixed by the name of the database
+		if len(body) < 4 {
+			return request{}, false
+		}
+		name, rest, ok := readCString(body[4:])
+		if !ok {
+			return request{}, false
+		}
+		i := bytes.IndexByte(name, '.')
+		if i < 0 {
+			return request{}, false
+		}
+		if collection := string(name[i+1:]); collection != commandsCollection {
+			return request{command: legacyQueryCommand, collection: collection}, true
+		}
+		if len(rest) < queryFieldsSize {
+			return request{}, false
+		}
+		document = rest[queryFieldsSize:]
+	default:
+		return request{}, false
+	}
+
+	elem, ok := readFirstElement(document)
+	if !ok || len(elem.name) == 0 || len(elem.name) > maxCommandSize {
+		return request{}, false
+	}
+	for _, c := range elem.name {
+		if !isCommandChar(c) {
+			return request{}, false
+		}
+	}
+
+	req := request{command: string(elem.name)}
+	if elem.kind == bsonString {
+		req.collection = string(elem.value)
+	}
+	return req, true
+}
+
+// decodeResponse decodes the beginning of an OP_MSG or OP_REPLY message, and returns true if the command failed,
+// which is the case of the responses whose document starts with a zero "ok" field.
+func decodeResponse(fragment []byte) (bool, bool) {
+	opCode, body, ok := readHeader(fragment)
+	if !ok {
+		return false, false
+	}
+
+	var document []byte
+	switch opCode {
+	case opMsg:
+		document, ok = readBody(body)
+		if !ok {
+			return false, false
+		}
+	case opReply:
+		if len(body) < replyPrefixSize {
+			return false, false
+		}
+		if binary.LittleEndian.Uint32(body)&queryFailureFlag != 0 {
+			return true, true
+		}
+		document = body[replyPrefixSize:]
+	default:
+		return false, false
+	}
+
+	elem, ok := readFirstElement(document)
+	if !ok {
+		// the documents of the responses are not expected to be truncated before their first element
+		return false, false
+	}
+	return string(elem.name) == "ok" && isZero(elem), true
+}
+
+// readHeader returns the opcode of the message at the beginning of b, and the bytes following its header
+func readHeader(b []byte) (int32, []byte, bool) {
+	if len(b) < headerSize {
+		return 0, nil, false
+	}
+	if length := int32(binary.LittleEndian.Uint32(b)); length < headerSize {
+		return 0, nil, false
+	}
+	return int32(binary.LittleEndian.Uint32(b[12:])), b[headerSize:], true
+}
+
+// readBody returns the document of the body section of an OP_MSG message, skipping the document sequence sections
+// preceding it
+func readBody(b []byte) ([]byte, bool) {
+	// the flags precede the sections
+	if len(b) < 4 {
+		return nil, false
+	}
+	b = b[4:]
+	for len(b) > 0 {
+		switch b[0] {
+		case bodySection:
+			return b[1:], true
+		case documentSequenceSection:
+			if len(b) < 5 {
+				return nil, false
+			}
+			size := int(binary.LittleEndian.Uint32(b[1:]))
+			if size < 4 || 1+size > len(b) {
+				return nil, false
+			}
+			b = b[1+size:]
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// readFirstElement returns the first element of the BSON document at the beginning of b. The value of the strings
+// is returned without its length and null terminator, and is empty for the types which are not decoded.
+func readFirstElement(b []byte) (element, bool) {
+	// the size of the document precedes its elements
+	if len(b) < 5 {
+		return element{}, false
+	}
+	elem := element{kind: b[4]}
+	name, rest, ok := readCString(b[5:])
+	if !ok {
+		return element{}, false
+	}
+	elem.name = name
+
+	switch elem.kind {
+	case bsonString:
+		if len(rest) < 4 {
+			return element{}, false
+		}
+		size := int(binary.LittleEndian.Uint32(rest))
+		if size < 1 || 4+size > len(rest) {
+			return element{}, false
+		}
+		elem.value = rest[4 : 4+size-1]
+	case bsonDouble, bsonInt64:
+		if len(rest) < 8 {
+			return element{}, false
+		}
+		elem.value = rest[:8]
+	case bsonInt32:
+		if len(rest) < 4 {
+			return element{}, false
+		}
+		elem.value = rest[:4]
+	case bsonBoolean:
+		if len(rest) < 1 {
+			return element{}, false
+		}
+		elem.value = rest[:1]
+	}
+	return elem, true
+}
+
+// isZero returns true if the value of the element is a zero number or false
+func isZero(elem element) bool {
+	switch elem.kind {
+	case bsonDouble:
+		return math.Float64frombits(binary.LittleEndian.Uint64(elem.value)) == 0
+	case bsonInt64:
+		return binary.LittleEndian.Uint64(elem.value) == 0
+	case bsonInt32:
+		return binary.LittleEndian.Uint32(elem.value) == 0
+	case bsonBoolean:
+		return elem.value[0] == 0
+	default:
+		return false
+	}
+}
+
+// readCString returns the null terminated string at the beginning of b, and the bytes following it
+func readCString(b []byte) ([]byte, []byte, bool) {
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return nil, nil, false
+	}
+	return b[:end], b[end+1:], true
+}
+
+// isCommandChar returns true if c may be part of the name of a command, such as "find" or "listCollections"
+func isCommandChar(c byte) bool {
+	return ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '_'
+}
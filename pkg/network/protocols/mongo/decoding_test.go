@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mongo
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// newMessage returns a message made of a standard header followed by the given body
+func newMessage(opCode int32, body []byte) []byte {
+	b := make([]byte, headerSize, headerSize+len(body))
+	binary.LittleEndian.PutUint32(b, uint32(headerSize+len(body)))
+	binary.LittleEndian.PutUint32(b[4:], 1)
+	binary.LittleEndian.PutUint32(b[12:], uint32(opCode))
+	return append(b, body...)
+}
+
+func marshal(t *testing.T, doc bson.D) []byte {
+	b, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	return b
+}
+
+// newOpMsg returns an OP_MSG message whose body section is preceded by the given document sequence sections
+func newOpMsg(t *testing.T, doc bson.D, sequences ...[]byte) []byte {
+	body := make([]byte, 4)
+	for _, seq := range sequences {
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(4+len(seq)))
+		body = append(body, documentSequenceSection)
+		body = append(body, size...)
+		body = append(body, seq...)
+	}
+	body = append(body, bodySection)
+	body = append(body, marshal(t, doc)...)
+	return newMessage(opMsg, body)
+}
+
+func newOpQuery(t *testing.T, collection string, doc bson.D) []byte {
+	body := make([]byte, 4)
+	body = append(body, collection...)
+	body = append(body, 0)
+	body = append(body, make([]byte, queryFieldsSize)...)
+	body = append(body, marshal(t, doc)...)
+	return newMessage(opQuery, body)
+}
+
+func newOpReply(t *testing.T, flags uint32, doc bson.D) []byte {
+	body := make([]byte, replyPrefixSize)
+	binary.LittleEndian.PutUint32(body, flags)
+	body = append(body, marshal(t, doc)...)
+	return newMessage(opReply, body)
+}
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment []byte
+		request  request
+		ok       bool
+	}{
+		{
+			name:     "find",
+			fragment: newOpMsg(t, bson.D{{Key: "find", Value: "users"}, {Key: "$db", Value: "test"}}),
+			request:  request{command: "find", collection: "users"},
+			ok:       true,
+		},
+		{
+			name:     "no collection",
+			fragment: newOpMsg(t, bson.D{{Key: "hello", Value: 1}, {Key: "$db", Value: "admin"}}),
+			request:  request{command: "hello"},
+			ok:       true,
+		},
+		{
+			name:     "document sequence",
+			fragment: newOpMsg(t, bson.D{{Key: "insert", Value: "users"}}, append([]byte("documents\x00"), marshal(t, bson.D{{Key: "a", Value: 1}})...)),
+			request:  request{command: "insert", collection: "users"},
+			ok:       true,
+		},
+		{
+			name:     "truncated document",
+			fragment: newOpMsg(t, bson.D{{Key: "find", Value: "users"}, {Key: "filter", Value: bson.D{{Key: "name", Value: "foo"}}}})[:45],
+			request:  request{command: "find", collection: "users"},
+			ok:       true,
+		},
+		{
+			name:     "legacy command",
+			fragment: newOpQuery(t, "admin.$cmd", bson.D{{Key: "isMaster", Value: 1}}),
+			request:  request{command: "isMaster"},
+			ok:       true,
+		},
+		{
+			name:     "legacy query",
+			fragment: newOpQuery(t, "test.users", bson.D{{Key: "name", Value: "foo"}}),
+			request:  request{command: legacyQueryCommand, collection: "users"},
+			ok:       true,
+		},
+		{
+			name:     "truncated name",
+			fragment: newOpMsg(t, bson.D{{Key: "find", Value: "users"}})[:24],
+			ok:       false,
+		},
+		{
+			name:     "invalid name",
+			fragment: newOpMsg(t, bson.D{{Key: "fi nd", Value: "users"}}),
+			ok:       false,
+		},
+		{
+			name:     "reply",
+			fragment: newOpReply(t, 0, bson.D{{Key: "ok", Value: 1.0}}),
+			ok:       false,
+		},
+		{
+			name:     "short header",
+			fragment: []byte{0x10, 0, 0, 0},
+			ok:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, ok := decodeRequest(tt.fragment)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.request, req)
+		})
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment []byte
+		failed   bool
+		ok       bool
+	}{
+		{name: "success", fragment: newOpMsg(t, bson.D{{Key: "ok", Value: 1.0}}), failed: false, ok: true},
+		{name: "failure", fragment: newOpMsg(t, bson.D{{Key: "ok", Value: 0.0}, {Key: "errmsg", Value: "ns not found"}}), failed: true, ok: true},
+		{name: "int32 failure", fragment: newOpMsg(t, bson.D{{Key: "ok", Value: int32(0)}}), failed: true, ok: true},
+		{name: "cursor", fragment: newOpMsg(t, bson.D{{Key: "cursor", Value: bson.D{{Key: "id", Value: int64(0)}}}, {Key: "ok", Value: 1.0}}), failed: false, ok: true},
+		{name: "legacy success", fragment: newOpReply(t, 0, bson.D{{Key: "ismaster", Value: true}, {Key: "ok", Value: 1.0}}), failed: false, ok: true},
+		{name: "legacy query failure", fragment: newOpReply(t, queryFailureFlag, bson.D{{Key: "$err", Value: "failed"}}), failed: true, ok: true},
+		{name: "legacy command failure", fragment: newOpReply(t, 0, bson.D{{Key: "ok", Value: 0.0}}), failed: true, ok: true},
+		{name: "query", fragment: newOpQuery(t, "test.users", bson.D{{Key: "name", Value: "foo"}}), ok: false},
+		{name: "truncated", fragment: newOpMsg(t, bson.D{{Key: "ok", Value: 1.0}})[:22], ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failed, ok := decodeResponse(tt.fragment)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.failed, failed)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 27017
+
+func generateMongoTx(request, response []byte, latencyNS uint64) *EbpfTx {
+	var tx EbpfTx
+	tx.Tup.Saddr_l, tx.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	tx.Tup.Daddr_l, tx.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	tx.Tup.Sport = protocolsUtils.ClientPort
+	tx.Tup.Dport = serverPort
+	tx.Request_started = 1
+	tx.Response_received = tx.Request_started + latencyNS
+	tx.Request_id = 1
+	tx.Request_fragment_size = uint16(copy(tx.Request_fragment[:], request))
+	tx.Response_fragment_size = uint16(copy(tx.Response_fragment[:], response))
+	return &tx
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxMongoStatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+func TestStatKeeperProcess(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	success := newOpMsg(t, bson.D{{Key: "ok", Value: 1.0}})
+	failure := newOpMsg(t, bson.D{{Key: "ok", Value: 0.0}})
+	sk.Process(generateMongoTx(newOpMsg(t, bson.D{{Key: "find", Value: "users"}}), success, 1000))
+	sk.Process(generateMongoTx(newOpMsg(t, bson.D{{Key: "find", Value: "users"}}), success, 2000))
+	sk.Process(generateMongoTx(newOpMsg(t, bson.D{{Key: "drop", Value: "users"}}), failure, 3000))
+	sk.Process(generateMongoTx(newOpMsg(t, bson.D{{Key: "find", Value: "users"}}), []byte{0x10}, 3000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	findKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "find", "users")
+	require.Contains(t, stats, findKey)
+	assert.Equal(t, 2, stats[findKey].Count)
+	assert.Equal(t, 0, stats[findKey].ErrorCount)
+	require.NotNil(t, stats[findKey].Latencies)
+
+	dropKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "drop", "users")
+	require.Contains(t, stats, dropKey)
+	assert.Equal(t, 1, stats[dropKey].Count)
+	assert.Equal(t, 1, stats[dropKey].ErrorCount)
+	assert.Equal(t, 3000.0, stats[dropKey].FirstLatencySample)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package mongo aggregates the Mongo commands decoded by the eBPF programs of the Universal Service Monitoring.
+package mongo
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// KeyTuple represents the network tuple for a group of Mongo commands, the client being the source
+type KeyTuple = usmstats.KeyTuple
+
+// Key is an identifier for a group of Mongo commands
+type Key struct {
+	// Command is the name of the commands, such as "find" or "insert"
+	Command string
+	// Collection is the collection the commands apply to, empty for the commands not applying to a collection
+	Collection string
+	KeyTuple
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, command, collection string) Key {
+	return Key{
+		KeyTuple:   usmstats.NewKeyTuple(saddr, daddr, sport, dport),
+		Command:    command,
+		Collection: collection,
+	}
+}
+
+// RequestStat stores stats for the Mongo commands sharing the same name and collection
+type RequestStat struct {
+	usmstats.LatencyStats
+	// ErrorCount is the number of commands which failed
+	ErrorCount int
+}
+
+// AddRequest adds a Mongo command to the stats
+func (r *RequestStat) AddRequest(latency float64, isError bool) {
+	if isError {
+		r.ErrorCount++
+	}
+	r.AddLatency(latency)
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	r.ErrorCount += newStats.ErrorCount
+	r.CombineLatencies(&newStats.LatencyStats)
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := new(RequestStat)
+	clone.CombineWith(r)
+	return clone
+}
@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mongo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRequest(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, false)
+	assert.Equal(t, 1, stats.Count)
+	assert.Equal(t, 0, stats.ErrorCount)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+	assert.Nil(t, stats.Latencies)
+
+	stats.AddRequest(20, true)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 1, stats.ErrorCount)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 2.0, stats.Latencies.GetCount())
+}
+
+func TestCombineWith(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, true)
+
+	single := new(RequestStat)
+	single.AddRequest(20, false)
+	stats.CombineWith(single)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 1, stats.ErrorCount)
+	require.NotNil(t, stats.Latencies)
+
+	multiple := new(RequestStat)
+	multiple.AddRequest(30, true)
+	multiple.AddRequest(40, false)
+	clone := multiple.Clone()
+	stats.CombineWith(multiple)
+	assert.Equal(t, 4, stats.Count)
+	assert.Equal(t, 2, stats.ErrorCount)
+	assert.Equal(t, 4.0, stats.Latencies.GetCount())
+
+	// the combined stats are left untouched
+	assert.Equal(t, 2, multiple.Count)
+	assert.Equal(t, 2.0, multiple.Latencies.GetCount())
+	assert.Equal(t, multiple.Count, clone.Count)
+	assert.Equal(t, multiple.ErrorCount, clone.ErrorCount)
+	assert.Equal(t, 2.0, clone.Latencies.GetCount())
+}
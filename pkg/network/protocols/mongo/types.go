@@ -0,0 +1,25 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ignore
+// +build ignore
+
+package mongo
+
+/*
+#include "../../ebpf/c/tracer.h"
+#include "../../ebpf/c/protocols/mongo/defs.h"
+#include "../../ebpf/c/protocols/mongo/types.h"
+*/
+import "C"
+
+type ConnTuple C.conn_tuple_t
+
+type EbpfTx C.mongo_transaction_t
+
+const (
+	BufferSize   = C.MONGO_BUFFER_SIZE
+	ResponseSize = C.MONGO_RESPONSE_SIZE
+)
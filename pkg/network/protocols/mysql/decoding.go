@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"encoding/binary"
+)
+
+// The commands and the response packets decoded in userspace.
+// Ref: https://dev.mysql.com/doc/dev/mysql-server/latest/page_protocol_command_phase.html
+const (
+	comQuery       = 0x03
+	comStmtPrepare = 0x16
+	comStmtExecute = 0x17
+
+	okPacket  = 0x00
+	errPacket = 0xff
+
+	// packetHeaderSize is the size of the payload length and of the sequence id of the packets
+	packetHeaderSize = 4
+	// statementIDSize is the size of the statement ids of the COM_STMT_EXECUTE and COM_STMT_PREPARE_OK packets
+	statementIDSize = 4
+	// errorCodeSize is the size of the error code of the ERR packets
+	errorCodeSize = 2
+)
+
+// request is a command sent by the client, either a text query, or the preparation or execution of a statement
+type request struct {
+	command byte
+	// query is the query of the COM_QUERY and COM_STMT_PREPARE commands
+	query []byte
+	// truncated is true if the end of the query was not captured
+	truncated bool
+	// statementID is the id of the statement executed by the COM_STMT_EXECUTE commands
+	statementID uint32
+}
+
+// response is the beginning of the response of the server to a command
+type response struct {
+	// errorCode is the error code of the ERR packets, 0 for the other responses
+	errorCode uint16
+	// statementID is the id assigned to the statement by the COM_STMT_PREPARE_OK packets
+	statementID uint32
+}
+
+// readPacket returns the payload of the packet at the beginning of b, which may have been truncated by the eBPF
+// programs, along with whether it was truncated
+func readPacket(b []byte) ([]byte, bool, bool) {
+	if len(b) <= packetHeaderSize {
+		return nil, false, false
+	}
+
+	// the payload length is a 3 bytes little endian integer
+	length := int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+	payload := b[packetHeaderSize:]
+	if length == 0 {
+		return nil, false, false
+	}
+	if length < len(payload) {
+		return payload[:length], false, true
+	}
+	return payload, length > len(payload), true
+}
+
+// decodeRequest decodes the beginning of a command packet
+func decodeRequest(fragment []byte) (request, bool) {
+	payload, truncated, ok := readPacket(fragment)
+	if !ok {
+		return request{}, false
+	}
+
+	req := request{command: payload[0]}
+	switch req.command {
+	case comQuery, comStmtPrepare:
+		req.query, req.truncated = payload[1:], truncated
+		return req, len(req.query) > 0
+	case comStmtExecute:
+		if len(payload) < 1+statementIDSize {
+			return request{}, false
+		}
+		req.statementID = binary.LittleEndian.Uint32(payload[1:])
+		return req, true
+	default:
+		return request{}, false
+	}
+}
+
+// decodeResponse decodes the beginning of the first packet of a response. The other responses than the OK and ERR
+// packets, such as the result sets, are successful.
+func decodeResponse(fragment []byte) (response, bool) {
+	payload, _, ok := readPacket(fragment)
+	if !ok {
+		return response{}, false
+	}
+
+	var resp response
+	switch payload[0] {
+	case errPacket:
+		if len(payload) < 1+errorCodeSize {
+			return response{}, false
+		}
+		resp.errorCode = binary.LittleEndian.Uint16(payload[1:])
+	case okPacket:
+		// the COM_STMT_PREPARE_OK packets start as the OK packets, followed by the id of the statement
+		if len(payload) >= 1+statementIDSize {
+			resp.statementID = binary.LittleEndian.Uint32(payload[1:])
+		}
+	}
+	return resp, true
+}
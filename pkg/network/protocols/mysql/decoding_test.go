@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPacket returns a packet with the given sequence id, whose payload is made of the given bytes
+func newPacket(seqID byte, payload ...byte) []byte {
+	packet := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), seqID}
+	return append(packet, payload...)
+}
+
+// newCommand returns the packet of a command carrying a query
+func newCommand(command byte, query string) []byte {
+	return newPacket(0, append([]byte{command}, query...)...)
+}
+
+// newExecute returns the packet of a COM_STMT_EXECUTE command
+func newExecute(statementID uint32) []byte {
+	payload := []byte{comStmtExecute, 0, 0, 0, 0, 0, 1, 0, 0, 0}
+	binary.LittleEndian.PutUint32(payload[1:], statementID)
+	return newPacket(0, payload...)
+}
+
+// newPrepareOK returns the COM_STMT_PREPARE_OK packet of a statement
+func newPrepareOK(statementID uint32) []byte {
+	payload := []byte{okPacket, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(payload[1:], statementID)
+	return newPacket(1, payload...)
+}
+
+// newError returns the ERR packet of the given error code
+func newError(errorCode uint16) []byte {
+	payload := append([]byte{errPacket, 0, 0}, "#42S02Table doesn't exist"...)
+	binary.LittleEndian.PutUint16(payload[1:], errorCode)
+	return newPacket(1, payload...)
+}
+
+// resultSet is the beginning of a result set of a single column
+var resultSet = newPacket(1, 1)
+
+func TestDecodeRequest(t *testing.T) {
+	t.Run("query", func(t *testing.T) {
+		req, ok := decodeRequest(newCommand(comQuery, "SELECT * FROM dummy"))
+		require.True(t, ok)
+		assert.Equal(t, byte(comQuery), req.command)
+		assert.Equal(t, "SELECT * FROM dummy", string(req.query))
+		assert.False(t, req.truncated)
+	})
+
+	t.Run("truncated query", func(t *testing.T) {
+		packet := newCommand(comQuery, "SELECT * FROM dummy WHERE foo = 'bar'")
+		req, ok := decodeRequest(packet[:20])
+		require.True(t, ok)
+		assert.Equal(t, "SELECT * FROM d", string(req.query))
+		assert.True(t, req.truncated)
+	})
+
+	t.Run("prepare", func(t *testing.T) {
+		req, ok := decodeRequest(newCommand(comStmtPrepare, "SELECT * FROM dummy WHERE id = ?"))
+		require.True(t, ok)
+		assert.Equal(t, byte(comStmtPrepare), req.command)
+		assert.Equal(t, "SELECT * FROM dummy WHERE id = ?", string(req.query))
+	})
+
+	t.Run("execute", func(t *testing.T) {
+		req, ok := decodeRequest(newExecute(42))
+		require.True(t, ok)
+		assert.Equal(t, byte(comStmtExecute), req.command)
+		assert.Equal(t, uint32(42), req.statementID)
+		assert.Empty(t, req.query)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		// COM_PING
+		_, ok := decodeRequest(newPacket(0, 0x0e))
+		assert.False(t, ok)
+		_, ok = decodeRequest(newCommand(comQuery, ""))
+		assert.False(t, ok)
+		_, ok = decodeRequest(newExecute(42)[:6])
+		assert.False(t, ok)
+		_, ok = decodeRequest(nil)
+		assert.False(t, ok)
+	})
+}
+
+func TestDecodeResponse(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		resp, ok := decodeResponse(newError(1146))
+		require.True(t, ok)
+		assert.Equal(t, uint16(1146), resp.errorCode)
+	})
+
+	t.Run("prepare ok", func(t *testing.T) {
+		resp, ok := decodeResponse(newPrepareOK(42))
+		require.True(t, ok)
+		assert.Zero(t, resp.errorCode)
+		assert.Equal(t, uint32(42), resp.statementID)
+	})
+
+	t.Run("result set", func(t *testing.T) {
+		resp, ok := decodeResponse(resultSet)
+		require.True(t, ok)
+		assert.Zero(t, resp.errorCode)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, ok := decodeResponse(newError(1146)[:6])
+		assert.False(t, ok)
+		_, ok = decodeResponse(newPacket(1))
+		assert.False(t, ok)
+	})
+}
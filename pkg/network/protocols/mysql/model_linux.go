@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// ConnTuple returns the network tuple of the connection the command was sent on, the client being the source
+func (tx *EbpfTx) ConnTuple() KeyTuple {
+	return KeyTuple{
+		SrcIPHigh: tx.Tup.Saddr_h,
+		SrcIPLow:  tx.Tup.Saddr_l,
+		DstIPHigh: tx.Tup.Daddr_h,
+		DstIPLow:  tx.Tup.Daddr_l,
+		SrcPort:   tx.Tup.Sport,
+		DstPort:   tx.Tup.Dport,
+	}
+}
+
+// RequestFragment returns the beginning of the command packet, which is truncated to BufferSize bytes
+func (tx *EbpfTx) RequestFragment() []byte {
+	return usmstats.Fragment(tx.Request_fragment[:], int(tx.Request_fragment_size))
+}
+
+// ResponseFragment returns the beginning of the first packet of the response, which is truncated to ResponseSize bytes
+func (tx *EbpfTx) ResponseFragment() []byte {
+	return usmstats.Fragment(tx.Response_fragment[:], int(tx.Response_fragment_size))
+}
+
+// RequestLatency returns the latency of the command in nanoseconds, up to the first packet of its response
+func (tx *EbpfTx) RequestLatency() float64 {
+	return usmstats.Latency(tx.Request_started, tx.Response_received)
+}
+
+// String returns a string representation of the transaction
+func (tx *EbpfTx) String() string {
+	var output strings.Builder
+	output.WriteString("ebpfMySQLTx{")
+	output.WriteString(fmt.Sprintf("Source: %s:%d, ", util.FromLowHigh(tx.Tup.Saddr_l, tx.Tup.Saddr_h), tx.Tup.Sport))
+	output.WriteString(fmt.Sprintf("Dest: %s:%d, ", util.FromLowHigh(tx.Tup.Daddr_l, tx.Tup.Daddr_h), tx.Tup.Dport))
+	output.WriteString(fmt.Sprintf("Request: %q, ", tx.RequestFragment()))
+	output.WriteString(fmt.Sprintf("Response: %q, ", tx.ResponseFragment()))
+	output.WriteString(fmt.Sprintf("Latency: %.0fns", tx.RequestLatency()))
+	output.WriteString("}")
+	return output.String()
+}
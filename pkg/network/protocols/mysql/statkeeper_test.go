@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 3306
+
+func generateMySQLTx(request, response []byte, latencyNS uint64) *EbpfTx {
+	var tx EbpfTx
+	tx.Tup.Saddr_l, tx.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	tx.Tup.Daddr_l, tx.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	tx.Tup.Sport = protocolsUtils.ClientPort
+	tx.Tup.Dport = serverPort
+	tx.Request_started = 1
+	tx.Response_received = tx.Request_started + latencyNS
+	tx.Request_fragment_size = uint16(copy(tx.Request_fragment[:], request))
+	tx.Response_fragment_size = uint16(copy(tx.Response_fragment[:], response))
+	return &tx
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxMySQLStatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+func TestStatKeeperQuery(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	sk.Process(generateMySQLTx(newCommand(comQuery, "SELECT * FROM dummy WHERE id = 1"), resultSet, 1000))
+	sk.Process(generateMySQLTx(newCommand(comQuery, "SELECT * FROM dummy WHERE id = 2"), newError(1146), 2000))
+	sk.Process(generateMySQLTx(newCommand(comQuery, "UPDATE dummy SET foo = 'bar'"), newPacket(1, okPacket, 4, 0), 3000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	selectKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "SELECT * FROM dummy WHERE id = ?")
+	require.Contains(t, stats, selectKey)
+	assert.Equal(t, 2, stats[selectKey].Count)
+	assert.Equal(t, map[uint16]int{1146: 1}, stats[selectKey].Errors)
+	require.NotNil(t, stats[selectKey].Latencies)
+
+	updateKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "UPDATE dummy SET foo = ?")
+	require.Contains(t, stats, updateKey)
+	assert.Equal(t, 1, stats[updateKey].Count)
+	assert.Empty(t, stats[updateKey].Errors)
+	assert.Equal(t, 3000.0, stats[updateKey].FirstLatencySample)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestStatKeeperPreparedStatement(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	// the statement is prepared, then executed twice
+	sk.Process(generateMySQLTx(newCommand(comStmtPrepare, "DELETE FROM dummy WHERE id = ?"), newPrepareOK(1), 1000))
+	sk.Process(generateMySQLTx(newExecute(1), newPacket(1, okPacket, 1, 0), 2000))
+	sk.Process(generateMySQLTx(newExecute(1), newError(1213), 3000))
+	// the preparation of the statement failed
+	sk.Process(generateMySQLTx(newCommand(comStmtPrepare, "SELECT * FROM unknown"), newError(1146), 4000))
+	// the statement was prepared before the monitoring started
+	sk.Process(generateMySQLTx(newExecute(2), resultSet, 5000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+
+	deleteKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "DELETE FROM dummy WHERE id = ?")
+	require.Contains(t, stats, deleteKey)
+	assert.Equal(t, 2, stats[deleteKey].Count)
+	assert.Equal(t, map[uint16]int{1213: 1}, stats[deleteKey].Errors)
+
+	// the prepared statements are kept across the flushes
+	sk.Process(generateMySQLTx(newExecute(1), newPacket(1, okPacket, 1, 0), 2000))
+	assert.Contains(t, sk.GetAndResetAllStats(), deleteKey)
+}
+
+func TestStatKeeperTruncatedQuery(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	query := "SELECT * FROM dummy WHERE foo = 'a very long string literal'"
+	request := newCommand(comQuery, query)
+	sk.Process(generateMySQLTx(request[:packetHeaderSize+1+40], resultSet, 1000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	assert.Contains(t, stats, NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "SELECT * FROM dummy WHERE foo ="))
+}
@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package mysql aggregates the MySQL statements decoded by the eBPF programs of the Universal Service Monitoring.
+package mysql
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// KeyTuple represents the network tuple for a group of MySQL statements, the client being the source
+type KeyTuple = usmstats.KeyTuple
+
+// Key is an identifier for a group of MySQL statements
+type Key struct {
+	// Query is the fingerprint of the statements, whose literals are replaced with '?'
+	Query string
+	KeyTuple
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, query string) Key {
+	return Key{
+		KeyTuple: usmstats.NewKeyTuple(saddr, daddr, sport, dport),
+		Query:    query,
+	}
+}
+
+// RequestStat stores stats for the MySQL statements sharing the same fingerprint
+type RequestStat struct {
+	usmstats.LatencyStats
+	// Errors is the number of statements which failed, by MySQL error code
+	Errors map[uint16]int
+}
+
+// AddRequest adds a MySQL statement to the stats, along with the error code of its response, which is 0 if it
+// succeeded
+func (r *RequestStat) AddRequest(latency float64, errorCode uint16) {
+	if errorCode != 0 {
+		r.addErrors(errorCode, 1)
+	}
+	r.AddLatency(latency)
+}
+
+func (r *RequestStat) addErrors(errorCode uint16, count int) {
+	if r.Errors == nil {
+		r.Errors = make(map[uint16]int)
+	}
+	r.Errors[errorCode] += count
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	for errorCode, count := range newStats.Errors {
+		r.addErrors(errorCode, count)
+	}
+	r.CombineLatencies(&newStats.LatencyStats)
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := new(RequestStat)
+	clone.CombineWith(r)
+	return clone
+}
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package mysql
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+type telemetry struct {
+	*usmstats.Telemetry
+
+	queries, statementExecutions *libtelemetry.Metric
+
+	totalHits         *libtelemetry.Metric
+	errors            *libtelemetry.Metric // this happens when the server answers with an ERR packet
+	dropped           *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	malformed         *libtelemetry.Metric // this happens when the command can't be decoded or normalized
+	unknownStatements *libtelemetry.Metric // this happens when the COM_STMT_PREPARE command of a statement was not seen
+}
+
+func newTelemetry() *telemetry {
+	t := usmstats.NewTelemetry("mysql")
+	return &telemetry{
+		Telemetry:           t,
+		queries:             t.NewMetric("queries"),
+		statementExecutions: t.NewMetric("statement_executions"),
+		unknownStatements:   t.NewMetric("unknown_statements"),
+
+		// these metrics are also exported as statsd metrics, and logged in the summary
+		totalHits: t.NewSummaryMetric("total_hits", "statements_processed", libtelemetry.OptStatsd),
+		errors:    t.NewSummaryMetric("errors", "statements_failed", libtelemetry.OptStatsd),
+		dropped:   t.NewSummaryMetric("dropped", "statements_dropped", libtelemetry.OptStatsd),
+		malformed: t.NewSummaryMetric("malformed", "statements_malformed", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) count(req request, resp response) {
+	switch req.command {
+	case comStmtExecute:
+		t.statementExecutions.Add(1)
+	default:
+		t.queries.Add(1)
+	}
+	if resp.errorCode != 0 {
+		t.errors.Add(1)
+	}
+	t.totalHits.Add(1)
+}
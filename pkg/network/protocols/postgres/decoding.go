@@ -0,0 +1,124 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+)
+
+// The tags of the messages decoded in userspace.
+// Ref: https://www.postgresql.org/docs/current/protocol-message-formats.html
+const (
+	queryMessage           = 'Q'
+	parseMessage           = 'P'
+	bindMessage            = 'B'
+	commandCompleteMessage = 'C'
+
+	// messageHeaderSize is the size of the tag and the length of the messages
+	messageHeaderSize = 5
+)
+
+// request is a query message sent by the client, either a simple query or the Parse or Bind message of the extended
+// query protocol
+type request struct {
+	kind byte
+	// statement is the name of the prepared statement of the Parse and Bind messages, empty for the unnamed statement
+	statement string
+	// query is the query of the simple query and Parse messages
+	query []byte
+	// truncated is true if the end of the query was not captured
+	truncated bool
+}
+
+// decodeRequest decodes the beginning of a query message
+func decodeRequest(fragment []byte) (request, bool) {
+	if len(fragment) < messageHeaderSize {
+		return request{}, false
+	}
+
+	req := request{kind: fragment[0]}
+	payload := fragment[messageHeaderSize:]
+	switch req.kind {
+	case queryMessage:
+		req.query, req.truncated = readQuery(payload)
+	case parseMessage:
+		name, rest, ok := readCString(payload)
+		if !ok {
+			return request{}, false
+		}
+		req.statement = string(name)
+		req.query, req.truncated = readQuery(rest)
+	case bindMessage:
+		// the name of the portal precedes the name of the statement
+		_, rest, ok := readCString(payload)
+		if !ok {
+			return request{}, false
+		}
+		name, _, ok := readCString(rest)
+		if !ok {
+			return request{}, false
+		}
+		req.statement = string(name)
+		return req, true
+	default:
+		return request{}, false
+	}
+
+	return req, len(req.query) > 0
+}
+
+// readCString returns the null terminated string at the beginning of b, and the bytes following it
+func readCString(b []byte) ([]byte, []byte, bool) {
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return nil, nil, false
+	}
+	return b[:end], b[end+1:], true
+}
+
+// readQuery returns the query at the beginning of b, which may have been truncated by the eBPF programs
+func readQuery(b []byte) ([]byte, bool) {
+	if query, _, ok := readCString(b); ok {
+		return query, false
+	}
+	return b, true
+}
+
+// decodeCommandTag returns the command tag of the CommandComplete message ending the tail of a response. Returns false
+// if the response doesn't end with a CommandComplete message, which is the case of the errors and of the responses
+// to the Parse messages which are not followed by an Execute message.
+func decodeCommandTag(tail []byte) ([]byte, bool) {
+	if len(tail) == 0 || tail[len(tail)-1] != 0 {
+		return nil, false
+	}
+
+	// the length of the message includes itself, but not the tag
+	for i := len(tail) - messageHeaderSize - 1; i >= 0; i-- {
+		if tail[i] != commandCompleteMessage {
+			continue
+		}
+		if int(binary.BigEndian.Uint32(tail[i+1:i+messageHeaderSize])) == len(tail)-i-1 {
+			return tail[i+messageHeaderSize : len(tail)-1], true
+		}
+	}
+	return nil, false
+}
+
+// rowsFromCommandTag returns the number of rows of a command tag, such as "SELECT 5" or "INSERT 0 1", which is its
+// last word. Returns 0 for the commands which don't report a number of rows.
+func rowsFromCommandTag(tag []byte) int {
+	i := bytes.LastIndexByte(tag, ' ')
+	if i < 0 {
+		return 0
+	}
+	rows, err := strconv.Atoi(string(tag[i+1:]))
+	if err != nil || rows < 0 {
+		return 0
+	}
+	return rows
+}
@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMessage returns a message made of the given tag and null terminated strings
+func newMessage(tag byte, strs ...string) []byte {
+	var payload []byte
+	for _, s := range strs {
+		payload = append(payload, s...)
+		payload = append(payload, 0)
+	}
+	msg := []byte{tag, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(msg[1:], uint32(len(payload)+4))
+	return append(msg, payload...)
+}
+
+func TestDecodeRequest(t *testing.T) {
+	t.Run("simple query", func(t *testing.T) {
+		req, ok := decodeRequest(newMessage(queryMessage, "SELECT * FROM dummy"))
+		require.True(t, ok)
+		assert.Equal(t, byte(queryMessage), req.kind)
+		assert.Equal(t, "SELECT * FROM dummy", string(req.query))
+		assert.False(t, req.truncated)
+	})
+
+	t.Run("truncated simple query", func(t *testing.T) {
+		msg := newMessage(queryMessage, "SELECT * FROM dummy WHERE foo = 'bar'")
+		req, ok := decodeRequest(msg[:20])
+		require.True(t, ok)
+		assert.Equal(t, "SELECT * FROM d", string(req.query))
+		assert.True(t, req.truncated)
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		req, ok := decodeRequest(newMessage(parseMessage, "stmt1", "SELECT * FROM dummy WHERE id = $1"))
+		require.True(t, ok)
+		assert.Equal(t, byte(parseMessage), req.kind)
+		assert.Equal(t, "stmt1", req.statement)
+		assert.Equal(t, "SELECT * FROM dummy WHERE id = $1", string(req.query))
+	})
+
+	t.Run("bind", func(t *testing.T) {
+		req, ok := decodeRequest(newMessage(bindMessage, "", "stmt1"))
+		require.True(t, ok)
+		assert.Equal(t, byte(bindMessage), req.kind)
+		assert.Equal(t, "stmt1", req.statement)
+		assert.Empty(t, req.query)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, ok := decodeRequest(newMessage(queryMessage, ""))
+		assert.False(t, ok)
+		_, ok = decodeRequest(newMessage('X'))
+		assert.False(t, ok)
+		_, ok = decodeRequest(newMessage(bindMessage, "portal")[:8])
+		assert.False(t, ok)
+		_, ok = decodeRequest([]byte{queryMessage, 0})
+		assert.False(t, ok)
+	})
+}
+
+func TestDecodeCommandTag(t *testing.T) {
+	rowDescription := []byte{'T', 0, 0, 0, 6, 0, 0}
+	dataRow := []byte{'D', 0, 0, 0, 10, 0, 1, 0, 0, 0, 0}
+
+	for _, tc := range []struct {
+		name     string
+		tail     []byte
+		expected string
+		ok       bool
+	}{
+		{name: "select", tail: append(append(rowDescription, dataRow...), newMessage(commandCompleteMessage, "SELECT 1")...), expected: "SELECT 1", ok: true},
+		{name: "insert", tail: newMessage(commandCompleteMessage, "INSERT 0 3"), expected: "INSERT 0 3", ok: true},
+		{name: "partial tail", tail: append(dataRow[4:], newMessage(commandCompleteMessage, "UPDATE 2")...), expected: "UPDATE 2", ok: true},
+		{name: "parse complete", tail: []byte{'1', 0, 0, 0, 4}},
+		{name: "error", tail: newMessage('E', "SERROR", "Mrelation does not exist", "")},
+		{name: "empty", tail: nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tag, ok := decodeCommandTag(tc.tail)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, string(tag))
+		})
+	}
+}
+
+func TestRowsFromCommandTag(t *testing.T) {
+	assert.Equal(t, 5, rowsFromCommandTag([]byte("SELECT 5")))
+	assert.Equal(t, 3, rowsFromCommandTag([]byte("INSERT 0 3")))
+	assert.Equal(t, 0, rowsFromCommandTag([]byte("CREATE TABLE")))
+	assert.Equal(t, 0, rowsFromCommandTag([]byte("BEGIN")))
+}
@@ -13,7 +13,7 @@ import (
 	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
 )
 
-func RunPostgresServer(t *testing.T, serverAddr string, serverPort string) {
+func RunPostgresServer(t *testing.T, serverAddr string, serverPort string) *protocolsUtils.DockerServer {
 	t.Helper()
 
 	env := []string{
@@ -23,5 +23,5 @@ func RunPostgresServer(t *testing.T, serverAddr string, serverPort string) {
 
 	dir, _ := testutil.CurDir()
 
-	protocolsUtils.RunDockerServer(t, "postgres", dir+"/testdata/docker-compose.yml", env, regexp.MustCompile(".*\\[1].*database system is ready to accept connections"))
+	return protocolsUtils.RunDockerServer(t, "postgres", dir+"/testdata/docker-compose.yml", env, regexp.MustCompile(".*\\[1].*database system is ready to accept connections"))
 }
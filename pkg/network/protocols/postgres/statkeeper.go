@@ -0,0 +1,154 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package postgres
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// maxPreparedStatements is the maximum number of prepared statements remembered across all the connections. The
+// statements are forgotten once it is reached, as those of the closed connections are never removed otherwise.
+const maxPreparedStatements = 10000
+
+// statementKey identifies a prepared statement, whose name is scoped to its connection
+type statementKey struct {
+	name string
+	KeyTuple
+}
+
+// StatKeeper aggregates the Postgres queries by connection and query fingerprint
+type StatKeeper struct {
+	mux       sync.Mutex
+	stats     *usmstats.Keeper[Key, RequestStat]
+	telemetry *telemetry
+
+	// statements holds the queries of the prepared statements, which are executed with Bind messages referencing them
+	statements map[statementKey][]byte
+
+	obfuscator *obfuscate.Obfuscator
+	// map containing the fingerprints of the queries
+	// this is rotated with the stats map
+	fingerprints map[string]string
+
+	malformedLogLimit *util.LogLimit
+}
+
+// NewStatKeeper returns a new StatKeeper
+func NewStatKeeper(c *config.Config) *StatKeeper {
+	telemetry := newTelemetry()
+	return &StatKeeper{
+		stats:             usmstats.NewKeeper[Key, RequestStat](c.MaxPostgresStatsBuffered, telemetry.dropped, telemetry.Aggregations),
+		telemetry:         telemetry,
+		statements:        make(map[statementKey][]byte),
+		obfuscator:        obfuscate.NewObfuscator(obfuscate.Config{SQL: obfuscate.SQLConfig{DBMS: "postgresql"}}),
+		fingerprints:      make(map[string]string),
+		malformedLogLimit: util.NewLogLimit(10, time.Minute*10),
+	}
+}
+
+// Process adds a query decoded by the eBPF programs to the stats
+func (s *StatKeeper) Process(tx *EbpfTx) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	req, ok := decodeRequest(tx.RequestFragment())
+	if !ok {
+		s.malformed(tx)
+		return
+	}
+
+	tuple := tx.ConnTuple()
+	tag, executed := decodeCommandTag(tx.ResponseTail())
+	query := req.query
+	switch req.kind {
+	case parseMessage:
+		s.storeStatement(statementKey{name: req.statement, KeyTuple: tuple}, query)
+		// the statement is only prepared, unless the Parse message is followed by an Execute message
+		if !executed {
+			return
+		}
+	case bindMessage:
+		query, ok = s.statements[statementKey{name: req.statement, KeyTuple: tuple}]
+		if !ok {
+			s.telemetry.unknownStatements.Add(1)
+			return
+		}
+	}
+	s.telemetry.count(req)
+
+	fingerprint, ok := s.fingerprint(query, req.truncated)
+	if !ok {
+		s.malformed(tx)
+		return
+	}
+
+	key := Key{
+		KeyTuple: tuple,
+		Query:    fingerprint,
+	}
+	stats := s.stats.Get(key)
+	if stats == nil {
+		return
+	}
+	stats.AddRequest(tx.RequestLatency(), rowsFromCommandTag(tag))
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (s *StatKeeper) GetAndResetAllStats() map[Key]*RequestStat {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.telemetry.Log()
+	s.fingerprints = make(map[string]string)
+	return s.stats.GetAndReset()
+}
+
+func (s *StatKeeper) storeStatement(key statementKey, query []byte) {
+	if _, ok := s.statements[key]; !ok && len(s.statements) >= maxPreparedStatements {
+		s.statements = make(map[statementKey][]byte)
+	}
+	// the query is copied, as it points to the memory of the eBPF event
+	s.statements[key] = append([]byte(nil), query...)
+}
+
+// fingerprint returns the query with its literals replaced with '?', as well as its comments and aliases removed
+func (s *StatKeeper) fingerprint(query []byte, truncated bool) (string, bool) {
+	if v, ok := s.fingerprints[string(query)]; ok {
+		return v, true
+	}
+
+	oq, err := s.obfuscator.ObfuscateSQLString(string(query))
+	if err != nil && truncated {
+		// the truncation may have happened in the middle of a string literal, which is dropped
+		if i := bytes.LastIndexByte(query, '\''); i > 0 {
+			oq, err = s.obfuscator.ObfuscateSQLString(string(query[:i]))
+		}
+	}
+	if err != nil {
+		return "", false
+	}
+
+	s.fingerprints[string(query)] = oq.Query
+	return oq.Query, true
+}
+
+func (s *StatKeeper) malformed(tx *EbpfTx) {
+	s.telemetry.malformed.Add(1)
+	if s.malformedLogLimit.ShouldLog() {
+		log.Debugf("postgres query malformed: %s", tx.String())
+	}
+}
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 5432
+
+func generatePostgresTx(request, response []byte, latencyNS uint64) *EbpfTx {
+	var tx EbpfTx
+	tx.Tup.Saddr_l, tx.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	tx.Tup.Daddr_l, tx.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	tx.Tup.Sport = protocolsUtils.ClientPort
+	tx.Tup.Dport = serverPort
+	tx.Request_started = 1
+	tx.Response_received = tx.Request_started + latencyNS
+	tx.Request_fragment_size = uint16(copy(tx.Request_fragment[:], request))
+	if len(response) > len(tx.Response_tail) {
+		response = response[len(response)-len(tx.Response_tail):]
+	}
+	tx.Response_tail_size = uint16(copy(tx.Response_tail[:], response))
+	return &tx
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxPostgresStatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+func TestStatKeeperSimpleQuery(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	sk.Process(generatePostgresTx(newMessage(queryMessage, "SELECT * FROM dummy WHERE id = 1"), newMessage(commandCompleteMessage, "SELECT 1"), 1000))
+	sk.Process(generatePostgresTx(newMessage(queryMessage, "SELECT * FROM dummy WHERE id = 2"), newMessage(commandCompleteMessage, "SELECT 0"), 2000))
+	sk.Process(generatePostgresTx(newMessage(queryMessage, "UPDATE dummy SET foo = 'bar'"), newMessage(commandCompleteMessage, "UPDATE 4"), 3000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	selectKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "SELECT * FROM dummy WHERE id = ?")
+	require.Contains(t, stats, selectKey)
+	assert.Equal(t, 2, stats[selectKey].Count)
+	assert.Equal(t, 1, stats[selectKey].Rows)
+	require.NotNil(t, stats[selectKey].Latencies)
+
+	updateKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "UPDATE dummy SET foo = ?")
+	require.Contains(t, stats, updateKey)
+	assert.Equal(t, 1, stats[updateKey].Count)
+	assert.Equal(t, 4, stats[updateKey].Rows)
+	assert.Equal(t, 3000.0, stats[updateKey].FirstLatencySample)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
+
+func TestStatKeeperPreparedStatement(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	// the statement is only prepared, then executed twice
+	parseComplete := []byte{'1', 0, 0, 0, 4}
+	sk.Process(generatePostgresTx(newMessage(parseMessage, "stmt1", "DELETE FROM dummy WHERE id = $1"), parseComplete, 1000))
+	sk.Process(generatePostgresTx(newMessage(bindMessage, "", "stmt1"), newMessage(commandCompleteMessage, "DELETE 1"), 2000))
+	sk.Process(generatePostgresTx(newMessage(bindMessage, "", "stmt1"), newMessage(commandCompleteMessage, "DELETE 0"), 3000))
+	// the unnamed statement is prepared and executed at once
+	sk.Process(generatePostgresTx(newMessage(parseMessage, "", "INSERT INTO dummy VALUES ($1, $2)"), newMessage(commandCompleteMessage, "INSERT 0 1"), 4000))
+	// the statement was prepared before the monitoring started
+	sk.Process(generatePostgresTx(newMessage(bindMessage, "", "stmt2"), newMessage(commandCompleteMessage, "SELECT 1"), 5000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	deleteKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "DELETE FROM dummy WHERE id = ?")
+	require.Contains(t, stats, deleteKey)
+	assert.Equal(t, 2, stats[deleteKey].Count)
+	assert.Equal(t, 1, stats[deleteKey].Rows)
+
+	insertKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "INSERT INTO dummy VALUES ( ? )")
+	require.Contains(t, stats, insertKey)
+	assert.Equal(t, 1, stats[insertKey].Count)
+	assert.Equal(t, 1, stats[insertKey].Rows)
+
+	// the prepared statements are kept across the flushes
+	sk.Process(generatePostgresTx(newMessage(bindMessage, "", "stmt1"), newMessage(commandCompleteMessage, "DELETE 1"), 2000))
+	assert.Contains(t, sk.GetAndResetAllStats(), deleteKey)
+}
+
+func TestStatKeeperTruncatedQuery(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	query := "SELECT * FROM dummy WHERE foo = 'a very long string literal'"
+	request := newMessage(queryMessage, query)
+	sk.Process(generatePostgresTx(request[:messageHeaderSize+40], newMessage(commandCompleteMessage, "SELECT 1"), 1000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 1)
+	assert.Contains(t, stats, NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "SELECT * FROM dummy WHERE foo ="))
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package postgres aggregates the Postgres queries decoded by the eBPF programs of the Universal Service Monitoring.
+package postgres
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// KeyTuple represents the network tuple for a group of Postgres queries, the client being the source
+type KeyTuple = usmstats.KeyTuple
+
+// Key is an identifier for a group of Postgres queries
+type Key struct {
+	// Query is the fingerprint of the queries, whose literals are replaced with '?'
+	Query string
+	KeyTuple
+}
+
+// NewKey generates a new Key
+func NewKey(saddr, daddr util.Address, sport, dport uint16, query string) Key {
+	return Key{
+		KeyTuple: usmstats.NewKeyTuple(saddr, daddr, sport, dport),
+		Query:    query,
+	}
+}
+
+// RequestStat stores stats for the Postgres queries sharing the same fingerprint
+type RequestStat struct {
+	usmstats.LatencyStats
+	// Rows is the number of rows returned or affected by the queries, as reported by the server
+	Rows int
+}
+
+// AddRequest adds a Postgres query to the stats
+func (r *RequestStat) AddRequest(latency float64, rows int) {
+	r.Rows += rows
+	r.AddLatency(latency)
+}
+
+// CombineWith merges the data in 2 RequestStat objects
+// newStats is kept as it is, while the method receiver gets mutated
+func (r *RequestStat) CombineWith(newStats *RequestStat) {
+	r.Rows += newStats.Rows
+	r.CombineLatencies(&newStats.LatencyStats)
+}
+
+// Clone returns a deep copy of the stats, which can be combined with other stats without modifying the original ones
+func (r *RequestStat) Clone() *RequestStat {
+	clone := new(RequestStat)
+	clone.CombineWith(r)
+	return clone
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRequest(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, 1)
+	assert.Equal(t, 1, stats.Count)
+	assert.Equal(t, 1, stats.Rows)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+	assert.Nil(t, stats.Latencies)
+
+	stats.AddRequest(20, 4)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 5, stats.Rows)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 2.0, stats.Latencies.GetCount())
+}
+
+func TestCombineWith(t *testing.T) {
+	stats := new(RequestStat)
+	stats.AddRequest(10, 1)
+
+	single := new(RequestStat)
+	single.AddRequest(20, 2)
+	stats.CombineWith(single)
+	assert.Equal(t, 2, stats.Count)
+	assert.Equal(t, 3, stats.Rows)
+	require.NotNil(t, stats.Latencies)
+
+	multiple := new(RequestStat)
+	multiple.AddRequest(30, 0)
+	multiple.AddRequest(40, 0)
+	clone := multiple.Clone()
+	stats.CombineWith(multiple)
+	assert.Equal(t, 4, stats.Count)
+	assert.Equal(t, 4.0, stats.Latencies.GetCount())
+
+	// the combined stats are left untouched
+	assert.Equal(t, 2, multiple.Count)
+	assert.Equal(t, 2.0, multiple.Latencies.GetCount())
+	assert.Equal(t, multiple.Count, clone.Count)
+	assert.Equal(t, 2.0, clone.Latencies.GetCount())
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package postgres
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+type telemetry struct {
+	*usmstats.Telemetry
+
+	simpleQueries, extendedQueries *libtelemetry.Metric
+
+	totalHits         *libtelemetry.Metric
+	dropped           *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	malformed         *libtelemetry.Metric // this happens when the query can't be decoded or normalized
+	unknownStatements *libtelemetry.Metric // this happens when the Parse message of a prepared statement was not seen
+}
+
+func newTelemetry() *telemetry {
+	t := usmstats.NewTelemetry("postgres")
+	return &telemetry{
+		Telemetry:         t,
+		simpleQueries:     t.NewMetric("simple_queries"),
+		extendedQueries:   t.NewMetric("extended_queries"),
+		unknownStatements: t.NewMetric("unknown_statements"),
+
+		// these metrics are also exported as statsd metrics, and logged in the summary
+		totalHits: t.NewSummaryMetric("total_hits", "queries_processed", libtelemetry.OptStatsd),
+		dropped:   t.NewSummaryMetric("dropped", "queries_dropped", libtelemetry.OptStatsd),
+		malformed: t.NewSummaryMetric("malformed", "queries_malformed", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) count(req request) {
+	switch req.kind {
+	case queryMessage:
+		t.simpleQueries.Add(1)
+	default:
+		t.extendedQueries.Add(1)
+	}
+	t.totalHits.Add(1)
+}
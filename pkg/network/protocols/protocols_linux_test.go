@@ -43,6 +43,11 @@ func TestProtocolValue(t *testing.T) {
 			kernelValue: http.ProtocolTLS,
 			expected:    network.ProtocolTLS,
 		},
+		{
+			name:        "ProtocolKafka",
+			kernelValue: http.ProtocolKafka,
+			expected:    network.ProtocolKafka,
+		},
 		{
 			name:        "ProtocolAMQP",
 			kernelValue: http.ProtocolAMQP,
@@ -53,6 +58,31 @@ func TestProtocolValue(t *testing.T) {
 			kernelValue: http.ProtocolRedis,
 			expected:    network.ProtocolRedis,
 		},
+		{
+			name:        "ProtocolHTTP3",
+			kernelValue: http.ProtocolHTTP3,
+			expected:    network.ProtocolHTTP3,
+		},
+		{
+			name:        "ProtocolSSH",
+			kernelValue: http.ProtocolSSH,
+			expected:    network.ProtocolSSH,
+		},
+		{
+			name:        "ProtocolRDP",
+			kernelValue: http.ProtocolRDP,
+			expected:    network.ProtocolRDP,
+		},
+		{
+			name:        "ProtocolCassandra",
+			kernelValue: http.ProtocolCassandra,
+			expected:    network.ProtocolCassandra,
+		},
+		{
+			name:        "ProtocolMemcached",
+			kernelValue: http.ProtocolMemcached,
+			expected:    network.ProtocolMemcached,
+		},
 	}
 
 	for _, test := range tests {
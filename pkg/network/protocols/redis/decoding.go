@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// The RESP types decoded in userspace.
+// Ref: https://redis.io/docs/reference/protocol-spec/
+const (
+	arrayPrefix      = '*'
+	bulkStringPrefix = '$'
+	simpleErrorType  = '-'
+	// blobErrorType is the type of the RESP3 errors whose message is length-prefixed
+	blobErrorType = '!'
+
+	// maxCommandSize is the size of the longest command name accepted, which bounds the cardinality of the stats
+	maxCommandSize = 32
+)
+
+var crlf = []byte("\r\n")
+
+// decodeCommand returns the name of the command at the beginning of fragment, which is sent by the clients as an
+// array of bulk strings, the first one being the name of the command: *<count>\r\n$<length>\r\n<name>\r\n...
+// The name is upper cased, as the commands are case insensitive.
+func decodeCommand(fragment []byte) (string, bool) {
+	count, rest, ok := readLength(fragment, arrayPrefix)
+	if !ok || count <= 0 {
+		return "", false
+	}
+	length, rest, ok := readLength(rest, bulkStringPrefix)
+	if !ok || length <= 0 || length > maxCommandSize || len(rest) < length {
+		return "", false
+	}
+
+	name := rest[:length]
+	for _, c := range name {
+		if !isCommandChar(c) {
+			return "", false
+		}
+	}
+	return string(bytes.ToUpper(name)), true
+}
+
+// readLength returns the length of the array or bulk string starting b with the given prefix, and the bytes
+// following it
+func readLength(b []byte, prefix byte) (int, []byte, bool) {
+	if len(b) == 0 || b[0] != prefix {
+		return 0, nil, false
+	}
+	end := bytes.Index(b, crlf)
+	if end < 0 {
+		return 0, nil, false
+	}
+	length, err := strconv.Atoi(string(b[1:end]))
+	if err != nil {
+		return 0, nil, false
+	}
+	return length, b[end+len(crlf):], true
+}
+
+// isCommandChar returns true if c may be part of the name of a command, such as "GET", "GEORADIUS_RO" or the
+// "JSON.GET" command of the modules
+func isCommandChar(c byte) bool {
+	return ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') || c == '.' || c == '_'
+}
+
+// isErrorReply returns true if the reply whose RESP type is the given one is an error
+func isErrorReply(replyType byte) bool {
+	return replyType == simpleErrorType || replyType == blobErrorType
+}
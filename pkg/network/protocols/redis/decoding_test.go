@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package redis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCommand returns a command encoded as an array of bulk strings
+func newCommand(args ...string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return []byte(b.String())
+}
+
+func TestDecodeCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment []byte
+		command  string
+		ok       bool
+	}{
+		{name: "get", fragment: newCommand("GET", "key"), command: "GET", ok: true},
+		{name: "lower case", fragment: newCommand("hgetall", "key"), command: "HGETALL", ok: true},
+		{name: "module", fragment: newCommand("JSON.GET", "key", "$"), command: "JSON.GET", ok: true},
+		{name: "truncated arguments", fragment: newCommand("SET", "key", strings.Repeat("a", 100))[:20], command: "SET", ok: true},
+		{name: "truncated name", fragment: newCommand("HGETALL", "key")[:10], ok: false},
+		{name: "inline command", fragment: []byte("PING\r\n"), ok: false},
+		{name: "empty array", fragment: []byte("*0\r\n"), ok: false},
+		{name: "invalid name", fragment: newCommand("GET\x00", "key"), ok: false},
+		{name: "name too long", fragment: newCommand(strings.Repeat("A", maxCommandSize+1)), ok: false},
+		{name: "not a command", fragment: []byte("+OK\r\n"), ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, ok := decodeCommand(tt.fragment)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.command, command)
+		})
+	}
+}
+
+func TestIsErrorReply(t *testing.T) {
+	assert.True(t, isErrorReply('-'))
+	assert.True(t, isErrorReply('!'))
+	assert.False(t, isErrorReply('+'))
+	assert.False(t, isErrorReply('$'))
+	assert.False(t, isErrorReply(0))
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const serverPort = 6379
+
+func generateRedisTx(request []byte, replyType byte, latencyNS uint64) *EbpfTx {
+	var tx EbpfTx
+	tx.Tup.Saddr_l, tx.Tup.Saddr_h = util.ToLowHigh(protocolsUtils.ClientAddr)
+	tx.Tup.Daddr_l, tx.Tup.Daddr_h = util.ToLowHigh(protocolsUtils.ServerAddr)
+	tx.Tup.Sport = protocolsUtils.ClientPort
+	tx.Tup.Dport = serverPort
+	tx.Request_started = 1
+	tx.Response_received = tx.Request_started + latencyNS
+	tx.Request_fragment_size = uint16(copy(tx.Request_fragment[:], request))
+	tx.Response_type = replyType
+	return &tx
+}
+
+func newTestStatKeeper() *StatKeeper {
+	cfg := config.New()
+	cfg.MaxRedisStatsBuffered = 1000
+	return NewStatKeeper(cfg)
+}
+
+func TestStatKeeperProcess(t *testing.T) {
+	sk := newTestStatKeeper()
+
+	sk.Process(generateRedisTx(newCommand("GET", "foo"), '$', 1000))
+	sk.Process(generateRedisTx(newCommand("get", "bar"), '$', 2000))
+	sk.Process(generateRedisTx(newCommand("HGETALL", "foo"), '-', 3000))
+	sk.Process(generateRedisTx([]byte("PING\r\n"), '+', 3000))
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats, 2)
+
+	getKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "GET")
+	require.Contains(t, stats, getKey)
+	assert.Equal(t, 2, stats[getKey].Count)
+	assert.Equal(t, 0, stats[getKey].ErrorCount)
+	require.NotNil(t, stats[getKey].Latencies)
+
+	hgetallKey := NewKey(protocolsUtils.ClientAddr, protocolsUtils.ServerAddr, protocolsUtils.ClientPort, serverPort, "HGETALL")
+	require.Contains(t, stats, hgetallKey)
+	assert.Equal(t, 1, stats[hgetallKey].Count)
+	assert.Equal(t, 1, stats[hgetallKey].ErrorCount)
+	assert.Equal(t, 3000.0, stats[hgetallKey].FirstLatencySample)
+
+	assert.Empty(t, sk.GetAndResetAllStats())
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package redis
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/usmstats"
+)
+
+type telemetry struct {
+	*usmstats.Telemetry
+
+	totalHits *libtelemetry.Metric
+	errors    *libtelemetry.Metric // this happens when the reply to the command is an error
+	dropped   *libtelemetry.Metric // this happens when the StatKeeper reaches capacity
+	malformed *libtelemetry.Metric // this happens when the name of the command can't be decoded
+}
+
+func newTelemetry() *telemetry {
+	t := usmstats.NewTelemetry("redis")
+	return &telemetry{
+		Telemetry: t,
+		// these metrics are also exported as statsd metrics, and logged in the summary
+		totalHits: t.NewSummaryMetric("total_hits", "commands_processed", libtelemetry.OptStatsd),
+		errors:    t.NewSummaryMetric("errors", "commands_failed", libtelemetry.OptStatsd),
+		dropped:   t.NewSummaryMetric("dropped", "commands_dropped", libtelemetry.OptStatsd),
+		malformed: t.NewSummaryMetric("malformed", "commands_malformed", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) count(tx *EbpfTx) {
+	if tx.IsError() {
+		t.errors.Add(1)
+	}
+	t.totalHits.Add(1)
+}
@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ignore
+// +build ignore
+
+package redis
+
+/*
+#include "../../ebpf/c/tracer.h"
+#include "../../ebpf/c/protocols/redis/defs.h"
+#include "../../ebpf/c/protocols/redis/types.h"
+*/
+import "C"
+
+type ConnTuple C.conn_tuple_t
+
+type EbpfTx C.redis_transaction_t
+
+const (
+	BufferSize = C.REDIS_BUFFER_SIZE
+)
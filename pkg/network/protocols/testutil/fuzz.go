@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// CorpusDir returns the path of the shared corpus directory of the given protocol. Each file in that directory holds
+// a raw payload, as it would be seen on the wire, and can be used as a seed by any fuzz target parsing that protocol.
+func CorpusDir(protocol string) string {
+	_, curFile, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(curFile), "..", "testdata", "corpus", protocol)
+}
+
+// AddCorpus adds every file of the shared corpus of the given protocol as a seed of the fuzz target.
+func AddCorpus(f *testing.F, protocol string) {
+	f.Helper()
+
+	dir := CorpusDir(protocol)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		f.Fatalf("could not read corpus directory %s: %s", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		payload, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			f.Fatalf("could not read corpus file %s: %s", entry.Name(), err)
+		}
+		f.Add(payload)
+	}
+}
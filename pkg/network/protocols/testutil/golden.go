@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// GoldenSample is a capture of the golden classification corpus, along with the protocol its flows are expected to be
+// classified as.
+type GoldenSample struct {
+	// Name is the path of the capture, relative to the golden corpus directory.
+	Name string
+	// Protocol is the expected classification of every flow of the capture, as returned by ProtocolType.String().
+	Protocol string
+	Flows    []*Flow
+}
+
+// GoldenDir returns the path of the golden classification corpus. Captures are stored in a sub-directory named after
+// the protocol their flows are expected to be classified as, so adding a regression case for a misclassification only
+// requires dropping a capture of the offending flow in the right directory.
+func GoldenDir() string {
+	_, curFile, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(curFile), "..", "testdata", "golden")
+}
+
+// GoldenSamples reads every capture of the golden classification corpus, sorted by name.
+func GoldenSamples() ([]GoldenSample, error) {
+	paths, err := filepath.Glob(filepath.Join(GoldenDir(), "*", "*.pcap"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	samples := make([]GoldenSample, 0, len(paths))
+	for _, path := range paths {
+		flows, err := ReadPCAPFlows(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read golden sample %s: %w", path, err)
+		}
+		if len(flows) == 0 {
+			return nil, fmt.Errorf("golden sample %s does not contain any TCP flow", path)
+		}
+
+		protocol := filepath.Base(filepath.Dir(path))
+		samples = append(samples, GoldenSample{
+			Name:     filepath.Join(protocol, filepath.Base(path)),
+			Protocol: protocol,
+			Flows:    flows,
+		})
+	}
+
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no golden sample found in %s: %w", GoldenDir(), os.ErrNotExist)
+	}
+	return samples, nil
+}
@@ -0,0 +1,296 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// pcapngMagic is the block type of the section header block, which is the first block of any pcapng file.
+var pcapngMagic = []byte{0x0a, 0x0d, 0x0d, 0x0a}
+
+// Segment is a single TCP payload captured in a pcap file.
+type Segment struct {
+	// FromClient is true if the payload was sent by the client side of the flow.
+	FromClient bool
+	Payload    []byte
+}
+
+// Flow is a TCP conversation reconstructed from a pcap file.
+type Flow struct {
+	// Client and Server are the original endpoints of the conversation, as seen in the capture.
+	Client string
+	Server string
+	// Segments holds the non-empty payloads of the conversation, in capture order.
+	Segments []Segment
+}
+
+type flowDirection struct {
+	src, dst string
+}
+
+// ReadPCAPFlows reads a pcap (or pcapng) file and returns the TCP flows it contains, in the order in which they were
+// first seen. The client of a flow is the sender of the SYN packet, or the sender of the first captured packet if the
+// handshake is missing from the capture. Retransmitted segments are dropped.
+func ReadPCAPFlows(path string) ([]*Flow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParsePCAPFlows(f)
+}
+
+// ParsePCAPFlows is identical to ReadPCAPFlows, but it reads the capture from r.
+func ParsePCAPFlows(r io.Reader) ([]*Flow, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(pcapngMagic))
+	if err != nil {
+		return nil, fmt.Errorf("could not read pcap header: %w", err)
+	}
+
+	var source gopacket.PacketDataSource
+	var linkType layers.LinkType
+	if bytes.Equal(magic, pcapngMagic) {
+		ngReader, err := pcapgo.NewNgReader(br, pcapgo.DefaultNgReaderOptions)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse pcapng header: %w", err)
+		}
+		source, linkType = ngReader, ngReader.LinkType()
+	} else {
+		reader, err := pcapgo.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse pcap header: %w", err)
+		}
+		source, linkType = reader, reader.LinkType()
+	}
+
+	var flows []*Flow
+	flowsByDirection := make(map[flowDirection]*Flow)
+	// seen holds the sequence numbers already processed for a given direction, so retransmissions can be skipped.
+	seen := make(map[flowDirection]map[uint32]struct{})
+
+	packets := gopacket.NewPacketSource(source, linkType)
+	for {
+		packet, err := packets.NextPacket()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read packet: %w", err)
+		}
+
+		networkLayer := packet.NetworkLayer()
+		tcp, ok := packet.TransportLayer().(*layers.TCP)
+		if networkLayer == nil || !ok {
+			continue
+		}
+
+		srcIP, dstIP := networkLayer.NetworkFlow().Endpoints()
+		direction := flowDirection{
+			src: net.JoinHostPort(srcIP.String(), fmt.Sprint(uint16(tcp.SrcPort))),
+			dst: net.JoinHostPort(dstIP.String(), fmt.Sprint(uint16(tcp.DstPort))),
+		}
+
+		flow, ok := flowsByDirection[direction]
+		if !ok {
+			flow = &Flow{Client: direction.src, Server: direction.dst}
+			if tcp.SYN && tcp.ACK {
+				flow.Client, flow.Server = direction.dst, direction.src
+			}
+			flows = append(flows, flow)
+			flowsByDirection[direction] = flow
+			flowsByDirection[flowDirection{src: direction.dst, dst: direction.src}] = flow
+			seen[direction] = make(map[uint32]struct{})
+			seen[flowDirection{src: direction.dst, dst: direction.src}] = make(map[uint32]struct{})
+		}
+
+		if len(tcp.Payload) == 0 {
+			continue
+		}
+		if _, retransmitted := seen[direction][tcp.Seq]; retransmitted {
+			continue
+		}
+		seen[direction][tcp.Seq] = struct{}{}
+
+		flow.Segments = append(flow.Segments, Segment{
+			FromClient: direction.src == flow.Client,
+			Payload:    append([]byte(nil), tcp.Payload...),
+		})
+	}
+
+	return flows, nil
+}
+
+// ReplayFlow replays the payloads of flow over a real TCP connection, dialed with dialer to targetAddr, and accepted by
+// ln. Each payload is written by the side that originally sent it and fully read by the other side before the next
+// payload is sent, so the kernel sees the exact same sequence of send and receive calls as in the original
+// conversation. Both ends of the connection are closed once the flow has been replayed.
+func ReplayFlow(flow *Flow, ln net.Listener, dialer *net.Dialer, targetAddr string) error {
+	accepted := make(chan net.Conn, 1)
+	acceptErr := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			acceptErr <- err
+			return
+		}
+		accepted <- conn
+	}()
+
+	client, err := dialer.Dial("tcp", targetAddr)
+	if err != nil {
+		return fmt.Errorf("could not dial %s: %w", targetAddr, err)
+	}
+	defer client.Close()
+
+	var server net.Conn
+	select {
+	case server = <-accepted:
+	case err := <-acceptErr:
+		return fmt.Errorf("could not accept connection: %w", err)
+	case <-time.After(5 * time.Second):
+		return errors.New("timed out waiting for the replayed connection to be accepted")
+	}
+	defer server.Close()
+
+	buffer := make([]byte, 0, 4096)
+	for i, segment := range flow.Segments {
+		writer, reader := client, server
+		if !segment.FromClient {
+			writer, reader = server, client
+		}
+
+		if _, err := writer.Write(segment.Payload); err != nil {
+			return fmt.Errorf("could not write segment %d: %w", i, err)
+		}
+
+		if cap(buffer) < len(segment.Payload) {
+			buffer = make([]byte, 0, len(segment.Payload))
+		}
+		_ = reader.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := io.ReadFull(reader, buffer[:len(segment.Payload)]); err != nil {
+			return fmt.Errorf("could not read segment %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+type synthesizedPacket struct {
+	fromClient bool
+	flags      func(*layers.TCP)
+	payload    []byte
+}
+
+// WriteFlowPCAP synthesizes a pcap file out of flow, including the TCP handshake and teardown. It is meant to make
+// adding hand-crafted samples as cheap as dropping captures taken from real-world traffic.
+func WriteFlowPCAP(w io.Writer, flow *Flow) error {
+	client, err := net.ResolveTCPAddr("tcp", flow.Client)
+	if err != nil {
+		return fmt.Errorf("invalid client address %q: %w", flow.Client, err)
+	}
+	server, err := net.ResolveTCPAddr("tcp", flow.Server)
+	if err != nil {
+		return fmt.Errorf("invalid server address %q: %w", flow.Server, err)
+	}
+	isIPv6 := client.IP.To4() == nil
+
+	writer := pcapgo.NewWriter(w)
+	if err := writer.WriteFileHeader(65535, layers.LinkTypeEthernet); err != nil {
+		return err
+	}
+
+	var clientSeq, serverSeq uint32 = 1000, 5000
+	timestamp := time.Unix(0, 0)
+	writePacket := func(fromClient bool, flags func(*layers.TCP), payload []byte) error {
+		src, dst := client, server
+		seq, ack := clientSeq, serverSeq
+		if !fromClient {
+			src, dst = server, client
+			seq, ack = serverSeq, clientSeq
+		}
+
+		tcp := &layers.TCP{
+			SrcPort: layers.TCPPort(src.Port),
+			DstPort: layers.TCPPort(dst.Port),
+			Seq:     seq,
+			Ack:     ack,
+			Window:  65535,
+		}
+		flags(tcp)
+
+		ethernet := &layers.Ethernet{
+			SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+			DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+			EthernetType: layers.EthernetTypeIPv4,
+		}
+		var networkLayer gopacket.SerializableLayer
+		if isIPv6 {
+			ethernet.EthernetType = layers.EthernetTypeIPv6
+			ip := &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolTCP, SrcIP: src.IP, DstIP: dst.IP}
+			_ = tcp.SetNetworkLayerForChecksum(ip)
+			networkLayer = ip
+		} else {
+			ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: src.IP.To4(), DstIP: dst.IP.To4()}
+			_ = tcp.SetNetworkLayerForChecksum(ip)
+			networkLayer = ip
+		}
+
+		buf := gopacket.NewSerializeBuffer()
+		opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+		if err := gopacket.SerializeLayers(buf, opts, ethernet, networkLayer, tcp, gopacket.Payload(payload)); err != nil {
+			return err
+		}
+
+		advance := uint32(len(payload))
+		if tcp.SYN || tcp.FIN {
+			advance++
+		}
+		if fromClient {
+			clientSeq += advance
+		} else {
+			serverSeq += advance
+		}
+
+		timestamp = timestamp.Add(time.Millisecond)
+		data := buf.Bytes()
+		return writer.WritePacket(gopacket.CaptureInfo{Timestamp: timestamp, CaptureLength: len(data), Length: len(data)}, data)
+	}
+
+	packets := []synthesizedPacket{
+		{fromClient: true, flags: func(tcp *layers.TCP) { tcp.SYN, tcp.Ack = true, 0 }},
+		{fromClient: false, flags: func(tcp *layers.TCP) { tcp.SYN, tcp.ACK = true, true }},
+		{fromClient: true, flags: func(tcp *layers.TCP) { tcp.ACK = true }},
+	}
+	for _, segment := range flow.Segments {
+		packets = append(packets, synthesizedPacket{
+			fromClient: segment.FromClient,
+			flags:      func(tcp *layers.TCP) { tcp.PSH, tcp.ACK = true, true },
+			payload:    segment.Payload,
+		})
+	}
+	packets = append(packets, synthesizedPacket{fromClient: true, flags: func(tcp *layers.TCP) { tcp.FIN, tcp.ACK = true, true }})
+
+	for _, packet := range packets {
+		if err := writePacket(packet.fromClient, packet.flags, packet.payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
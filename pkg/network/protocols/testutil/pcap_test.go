@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPCAPRoundTrip(t *testing.T) {
+	flow := &Flow{
+		Client: "10.0.0.1:41234",
+		Server: "10.0.0.2:8080",
+		Segments: []Segment{
+			{FromClient: true, Payload: []byte("GET /index.html HTTP/1.1\r\nHost: example.com\r\n\r\n")},
+			{FromClient: false, Payload: []byte("HTTP/1.1 200 OK\r\nContent-Length: 0\r\n\r\n")},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFlowPCAP(&buf, flow))
+
+	flows, err := ParsePCAPFlows(&buf)
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+	assert.Equal(t, flow, flows[0])
+}
+
+func TestPCAPRoundTripIPv6(t *testing.T) {
+	flow := &Flow{
+		Client: "[::1]:41234",
+		Server: "[::1]:6379",
+		Segments: []Segment{
+			{FromClient: true, Payload: []byte("*1\r\n$4\r\nPING\r\n")},
+			{FromClient: false, Payload: []byte("+PONG\r\n")},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteFlowPCAP(&buf, flow))
+
+	flows, err := ParsePCAPFlows(&buf)
+	require.NoError(t, err)
+	require.Len(t, flows, 1)
+	assert.Equal(t, flow.Segments, flows[0].Segments)
+}
+
+func TestReplayFlow(t *testing.T) {
+	flow := &Flow{
+		Segments: []Segment{
+			{FromClient: true, Payload: []byte("*1\r\n$4\r\nPING\r\n")},
+			{FromClient: false, Payload: []byte("+PONG\r\n")},
+			{FromClient: true, Payload: bytes.Repeat([]byte("a"), 10000)},
+		},
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	require.NoError(t, ReplayFlow(flow, ln, &net.Dialer{}, ln.Addr().String()))
+}
@@ -14,12 +14,55 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// DockerServer allows tests to disrupt a server started by RunDockerServer, for instance to validate how in-flight
+// transactions are handled when the server goes away in the middle of a request.
+type DockerServer struct {
+	t          *testing.T
+	serverName string
+	dockerPath string
+	env        []string
+}
+
+func (s *DockerServer) compose(args ...string) {
+	s.t.Helper()
+	c := exec.Command("docker-compose", append([]string{"-f", s.dockerPath}, args...)...)
+	c.Env = append(c.Env, s.env...)
+	if out, err := c.CombinedOutput(); err != nil {
+		s.t.Fatalf("could not %s %s server: %s: %s", args[0], s.serverName, err, out)
+	}
+}
+
+// Kill sends a SIGKILL to the server, so it dies without closing its connections gracefully.
+func (s *DockerServer) Kill() {
+	s.t.Helper()
+	s.compose("kill", "-s", "SIGKILL")
+}
+
+// Stop gracefully stops the server.
+func (s *DockerServer) Stop() {
+	s.t.Helper()
+	s.compose("stop")
+}
+
+// Pause freezes the server, so requests sent from now on are left without a response until Unpause is called.
+func (s *DockerServer) Pause() {
+	s.t.Helper()
+	s.compose("pause")
+}
+
+// Unpause resumes a server frozen by Pause.
+func (s *DockerServer) Unpause() {
+	s.t.Helper()
+	s.compose("unpause")
+}
+
 // RunDockerServer is a template for running a protocols server in a docker.
 // - serverName is a friendly name of the server we are setting (AMQP, mongo, etc.).
 // - dockerPath is the path for the docker-compose.
 // - env is any environment variable required for running the server.
 // - serverStartRegex is a regex to be matched on the server logs to ensure it started correctly.
-func RunDockerServer(t *testing.T, serverName, dockerPath string, env []string, serverStartRegex *regexp.Regexp) {
+// The returned DockerServer can be used to disrupt the server on demand.
+func RunDockerServer(t *testing.T, serverName, dockerPath string, env []string, serverStartRegex *regexp.Regexp) *DockerServer {
 	t.Helper()
 
 	cmd := exec.Command("docker-compose", "-f", dockerPath, "up")
@@ -42,11 +85,11 @@ func RunDockerServer(t *testing.T, serverName, dockerPath string, env []string,
 		select {
 		case <-patternScanner.DoneChan:
 			t.Logf("%s server is ready", serverName)
-			return
+			return &DockerServer{t: t, serverName: serverName, dockerPath: dockerPath, env: env}
 		case <-time.After(time.Second * 60):
 			patternScanner.PrintLogs(t)
 			t.Fatalf("failed to start %s server", serverName)
-			return
+			return nil
 		}
 	}
 }
@@ -0,0 +1,21 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package testutil
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// The endpoints of the connection the transactions generated by the StatKeeper tests of the protocols are sent on,
+// the server port being the default one of each protocol.
+var (
+	ClientAddr = util.AddressFromString("1.1.1.1")
+	ServerAddr = util.AddressFromString("2.2.2.2")
+)
+
+// ClientPort is the ephemeral port of the client of the connection the transactions generated by the StatKeeper tests
+// of the protocols are sent on.
+const ClientPort = 60000
@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tls
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// handshakeTimeout is how long the handshake of a connection is kept once it was neither updated by a hello message
+// nor returned for a connection
+const handshakeTimeout = 2 * time.Minute
+
+type handshakeVal struct {
+	Handshake
+	lastSeen time.Time
+}
+
+// HandshakeCache holds the metadata of the handshakes of the TLS connections, parsed from their ClientHello and
+// ServerHello messages, until the connections are no longer reported.
+type HandshakeCache struct {
+	mux        sync.Mutex
+	handshakes map[KeyTuple]*handshakeVal
+	maxEntries int
+	telemetry  *telemetry
+
+	malformedLogLimit *util.LogLimit
+}
+
+// NewHandshakeCache returns a new HandshakeCache
+func NewHandshakeCache(c *config.Config) *HandshakeCache {
+	return &HandshakeCache{
+		handshakes:        make(map[KeyTuple]*handshakeVal),
+		maxEntries:        int(c.MaxTrackedConnections),
+		telemetry:         newTelemetry(),
+		malformedLogLimit: util.NewLogLimit(10, time.Minute*10),
+	}
+}
+
+// Process parses a hello message captured by the eBPF programs, and adds its fields to the handshake of its connection
+func (c *HandshakeCache) Process(e *EbpfHandshake) {
+	c.process(e, time.Now())
+}
+
+func (c *HandshakeCache) process(e *EbpfHandshake, now time.Time) {
+	h, err := parseHello(e.Segment())
+	if err != nil {
+		c.telemetry.malformed.Add(1)
+		if c.malformedLogLimit.ShouldLog() {
+			log.Debugf("tls hello message not parsed: %s: %s", e, err)
+		}
+		return
+	}
+	if h.truncated {
+		c.telemetry.truncated.Add(1)
+	}
+
+	key := e.ConnTuple()
+	if h.client {
+		c.telemetry.clientHellos.Add(1)
+	} else {
+		c.telemetry.serverHellos.Add(1)
+		key = key.flip()
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	val, ok := c.handshakes[key]
+	if !ok {
+		if len(c.handshakes) >= c.maxEntries {
+			c.telemetry.dropped.Add(1)
+			return
+		}
+		val = new(handshakeVal)
+		c.handshakes[key] = val
+	}
+	val.lastSeen = now
+
+	if h.client {
+		// a new ClientHello on the same tuple opens a new connection
+		val.Handshake = Handshake{ServerName: h.serverName, JA3: h.fingerprint}
+		return
+	}
+	val.Version = h.version
+	val.CipherSuite = h.cipherSuite
+	val.JA3S = h.fingerprint
+}
+
+// Get returns the handshakes of the given connections, whose tuples may be in either direction, and expires the
+// handshakes which were not seen for handshakeTimeout. The connections without a known handshake are not part of the
+// returned map.
+func (c *HandshakeCache) Get(tuples []KeyTuple, now time.Time) map[KeyTuple]Handshake {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	handshakes := make(map[KeyTuple]Handshake)
+	for _, tuple := range tuples {
+		val, ok := c.handshakes[tuple]
+		if !ok {
+			val, ok = c.handshakes[tuple.flip()]
+		}
+		if !ok {
+			continue
+		}
+		val.lastSeen = now
+		handshakes[tuple] = val.Handshake
+	}
+
+	for key, val := range c.handshakes {
+		if now.Sub(val.lastSeen) >= handshakeTimeout {
+			delete(c.handshakes, key)
+			c.telemetry.expired.Add(1)
+		}
+	}
+	c.telemetry.log()
+	return handshakes
+}
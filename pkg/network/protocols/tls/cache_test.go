@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tls
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+var (
+	clientAddr = util.AddressFromString("1.1.1.1")
+	serverAddr = util.AddressFromString("2.2.2.2")
+)
+
+const (
+	clientPort = 60000
+	serverPort = 443
+)
+
+func generateHandshake(segment []byte, fromClient bool) *EbpfHandshake {
+	var e EbpfHandshake
+	src, dst, sport, dport := clientAddr, serverAddr, uint16(clientPort), uint16(serverPort)
+	if !fromClient {
+		src, dst, sport, dport = dst, src, dport, sport
+	}
+	e.Tup.Saddr_l, e.Tup.Saddr_h = util.ToLowHigh(src)
+	e.Tup.Daddr_l, e.Tup.Daddr_h = util.ToLowHigh(dst)
+	e.Tup.Sport = sport
+	e.Tup.Dport = dport
+	e.Segment_length = uint16(len(segment))
+	e.Fragment_size = uint16(copy(e.Fragment[:], segment))
+	return &e
+}
+
+func newTestHandshakeCache(maxEntries int) *HandshakeCache {
+	cfg := config.New()
+	cfg.MaxTrackedConnections = uint(maxEntries)
+	return NewHandshakeCache(cfg)
+}
+
+func TestHandshakeCache(t *testing.T) {
+	cache := newTestHandshakeCache(100)
+	now := time.Now()
+	cache.process(generateHandshake(clientHello(testServerName), true), now)
+	cache.process(generateHandshake(serverHello(true), false), now)
+
+	// the handshake is returned for both ends of the connection
+	outgoing := NewKeyTuple(clientAddr, serverAddr, clientPort, serverPort)
+	incoming := NewKeyTuple(serverAddr, clientAddr, serverPort, clientPort)
+	handshakes := cache.Get([]KeyTuple{outgoing, incoming}, now)
+	require.Len(t, handshakes, 2)
+	expected := Handshake{
+		ServerName:  testServerName,
+		Version:     0x0304,
+		CipherSuite: 0x1301,
+		JA3:         md5Hex(testClientJA3),
+		JA3S:        md5Hex("771,4865,43-51"),
+	}
+	assert.Equal(t, expected, handshakes[outgoing])
+	assert.Equal(t, expected, handshakes[incoming])
+
+	// a new ClientHello on the same tuple opens a new connection
+	cache.process(generateHandshake(clientHello(""), true), now)
+	handshakes = cache.Get([]KeyTuple{outgoing}, now)
+	assert.Equal(t, Handshake{JA3: md5Hex("771,4865-49199,10-11-43,29-23,0")}, handshakes[outgoing])
+}
+
+func TestHandshakeCacheExpiration(t *testing.T) {
+	cache := newTestHandshakeCache(100)
+	now := time.Now()
+	cache.process(generateHandshake(clientHello(testServerName), true), now)
+
+	// the handshake is kept as long as its connection is reported
+	tuple := NewKeyTuple(clientAddr, serverAddr, clientPort, serverPort)
+	later := now.Add(handshakeTimeout / 2)
+	require.Len(t, cache.Get([]KeyTuple{tuple}, later), 1)
+	require.Len(t, cache.Get(nil, later.Add(handshakeTimeout/2)), 0)
+	assert.Len(t, cache.handshakes, 1)
+
+	cache.Get(nil, later.Add(handshakeTimeout))
+	assert.Empty(t, cache.handshakes)
+}
+
+func TestHandshakeCacheMaxEntries(t *testing.T) {
+	cache := newTestHandshakeCache(1)
+	now := time.Now()
+	cache.process(generateHandshake(clientHello(testServerName), true), now)
+
+	other := generateHandshake(clientHello(testServerName), true)
+	other.Tup.Sport++
+	cache.process(other, now)
+	assert.Len(t, cache.handshakes, 1)
+
+	// the messages which are not hello messages are ignored
+	cache.process(generateHandshake([]byte{0x17, 0x03, 0x03, 0x00, 0x10}, true), now)
+	assert.Len(t, cache.handshakes, 1)
+}
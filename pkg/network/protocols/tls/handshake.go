@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package tls collects the metadata of the handshakes of the TLS connections, from the ClientHello and ServerHello
+// messages captured by the eBPF programs of the Universal Service Monitoring: the server name, the negotiated version
+// and cipher suite, and the JA3 and JA3S fingerprints of the clients and servers.
+package tls
+
+import (
+	gotls "crypto/tls"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// The prefixes of the tags of the connections holding the metadata of their TLS handshake
+const (
+	ServerNameTag  = "tls_server_name:"
+	VersionTag     = "tls_version:"
+	CipherSuiteTag = "tls_cipher_suite:"
+	JA3Tag         = "tls_ja3:"
+	JA3STag        = "tls_ja3s:"
+)
+
+// KeyTuple represents the network tuple of a TLS connection, the client being the source
+type KeyTuple struct {
+	SrcIPHigh uint64
+	SrcIPLow  uint64
+
+	DstIPHigh uint64
+	DstIPLow  uint64
+
+	// ports separated for alignment/size optimization
+	SrcPort uint16
+	DstPort uint16
+}
+
+// NewKeyTuple generates a new KeyTuple
+func NewKeyTuple(saddr, daddr util.Address, sport, dport uint16) KeyTuple {
+	saddrl, saddrh := util.ToLowHigh(saddr)
+	daddrl, daddrh := util.ToLowHigh(daddr)
+	return KeyTuple{
+		SrcIPHigh: saddrh,
+		SrcIPLow:  saddrl,
+		SrcPort:   sport,
+		DstIPHigh: daddrh,
+		DstIPLow:  daddrl,
+		DstPort:   dport,
+	}
+}
+
+// flip returns the tuple of the other direction of the connection
+func (t KeyTuple) flip() KeyTuple {
+	return KeyTuple{
+		SrcIPHigh: t.DstIPHigh,
+		SrcIPLow:  t.DstIPLow,
+		SrcPort:   t.DstPort,
+		DstIPHigh: t.SrcIPHigh,
+		DstIPLow:  t.SrcIPLow,
+		DstPort:   t.SrcPort,
+	}
+}
+
+// Handshake holds the metadata of the handshake of a TLS connection. The fields parsed from the ServerHello are unset
+// until it is seen, and the JA3 fingerprints are unset when the hello messages didn't fit in the eBPF buffer.
+type Handshake struct {
+	// ServerName is the server name indication (SNI) of the ClientHello
+	ServerName string
+	// Version and CipherSuite are the TLS version and cipher suite selected by the server
+	Version     uint16
+	CipherSuite uint16
+	// JA3 and JA3S are the MD5 hashes of the JA3 fingerprints of the ClientHello and of the ServerHello
+	JA3  string
+	JA3S string
+}
+
+// Tags returns the tags of the connection holding the metadata of its handshake
+func (h *Handshake) Tags() []string {
+	tags := make([]string, 0, 5)
+	if h.ServerName != "" {
+		tags = append(tags, ServerNameTag+h.ServerName)
+	}
+	if h.Version != 0 {
+		tags = append(tags, VersionTag+VersionName(h.Version))
+	}
+	if h.CipherSuite != 0 {
+		tags = append(tags, CipherSuiteTag+gotls.CipherSuiteName(h.CipherSuite))
+	}
+	if h.JA3 != "" {
+		tags = append(tags, JA3Tag+h.JA3)
+	}
+	if h.JA3S != "" {
+		tags = append(tags, JA3STag+h.JA3S)
+	}
+	return tags
+}
+
+// VersionName returns the name of a TLS version, such as tls_1.2
+func VersionName(version uint16) string {
+	switch version {
+	case 0x0300:
+		return "ssl_3.0"
+	case 0x0301:
+		return "tls_1.0"
+	case 0x0302:
+		return "tls_1.1"
+	case 0x0303:
+		return "tls_1.2"
+	case 0x0304:
+		return "tls_1.3"
+	default:
+		return fmt.Sprintf("0x%04x", version)
+	}
+}
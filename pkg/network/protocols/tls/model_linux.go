@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tls
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// ConnTuple returns the tuple of the segment, the sender being the source
+func (e *EbpfHandshake) ConnTuple() KeyTuple {
+	return KeyTuple{
+		SrcIPHigh: e.Tup.Saddr_h,
+		SrcIPLow:  e.Tup.Saddr_l,
+		DstIPHigh: e.Tup.Daddr_h,
+		DstIPLow:  e.Tup.Daddr_l,
+		SrcPort:   e.Tup.Sport,
+		DstPort:   e.Tup.Dport,
+	}
+}
+
+// Segment returns the beginning of the segment captured by the eBPF program
+func (e *EbpfHandshake) Segment() []byte {
+	size := int(e.Fragment_size)
+	if size > len(e.Fragment) {
+		size = len(e.Fragment)
+	}
+	return e.Fragment[:size]
+}
+
+func (e *EbpfHandshake) String() string {
+	var output strings.Builder
+	output.WriteString("ebpfTLSHandshake{")
+	output.WriteString(fmt.Sprintf("Source: %s:%d, ", util.FromLowHigh(e.Tup.Saddr_l, e.Tup.Saddr_h), e.Tup.Sport))
+	output.WriteString(fmt.Sprintf("Destination: %s:%d, ", util.FromLowHigh(e.Tup.Daddr_l, e.Tup.Daddr_h), e.Tup.Dport))
+	output.WriteString(fmt.Sprintf("Length: %d, ", e.Segment_length))
+	output.WriteString(fmt.Sprintf("Captured: %d", e.Fragment_size))
+	output.WriteString("}")
+	return output.String()
+}
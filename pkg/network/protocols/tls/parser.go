@@ -0,0 +1,267 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tls
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	recordHandshake     = 0x16
+	recordHeaderSize    = 5
+	handshakeHeaderSize = 4
+
+	handshakeClientHello = 0x01
+	handshakeServerHello = 0x02
+)
+
+// The extensions of the hello messages read by the parser.
+// Ref: https://www.iana.org/assignments/tls-extensiontype-values/tls-extensiontype-values.xhtml
+const (
+	extensionServerName        = 0x0000
+	extensionSupportedGroups   = 0x000a
+	extensionECPointFormats    = 0x000b
+	extensionSupportedVersions = 0x002b
+
+	serverNameHostName = 0x00
+)
+
+var (
+	errNotHello  = errors.New("not a TLS hello message")
+	errTruncated = errors.New("truncated hello message")
+	errMalformed = errors.New("malformed hello message")
+)
+
+// hello holds the fields of a ClientHello or of a ServerHello
+type hello struct {
+	client bool
+	// serverName is the server name of a ClientHello
+	serverName string
+	// version and cipherSuite are the version and cipher suite selected by a ServerHello
+	version     uint16
+	cipherSuite uint16
+	// fingerprint is the JA3 fingerprint of a ClientHello, or the JA3S fingerprint of a ServerHello, which is unset
+	// when the message is truncated
+	fingerprint string
+	truncated   bool
+}
+
+// parseHello parses the ClientHello or the ServerHello carried by the TLS record at the beginning of a TCP segment.
+// The message may be truncated, either by the capture or as it spans several segments, in which case the fields found
+// before the end of the segment are returned.
+// Ref: https://datatracker.ietf.org/doc/html/rfc8446#section-4.1.2 and https://datatracker.ietf.org/doc/html/rfc8446#section-4.1.3
+func parseHello(segment []byte) (*hello, error) {
+	if len(segment) < recordHeaderSize+handshakeHeaderSize || segment[0] != recordHandshake {
+		return nil, errNotHello
+	}
+	record := segment[recordHeaderSize:]
+	if recordLength := int(binary.BigEndian.Uint16(segment[3:])); recordLength < len(record) {
+		record = record[:recordLength]
+	}
+	if len(record) < handshakeHeaderSize {
+		return nil, errMalformed
+	}
+
+	messageType := record[0]
+	messageLength := int(record[1])<<16 | int(record[2])<<8 | int(record[3])
+	body := record[handshakeHeaderSize:]
+	complete := len(body) >= messageLength
+	if len(body) > messageLength {
+		body = body[:messageLength]
+	}
+
+	switch messageType {
+	case handshakeClientHello:
+		return parseClientHello(body, complete)
+	case handshakeServerHello:
+		return parseServerHello(body, complete)
+	default:
+		return nil, errNotHello
+	}
+}
+
+func parseClientHello(body []byte, complete bool) (*hello, error) {
+	r := reader{buf: body, ok: true}
+	version := r.uint16()
+	r.skip(32)             // random
+	r.skip(int(r.uint8())) // legacy session id
+	ciphers := r.bytes(int(r.uint16()))
+	r.skip(int(r.uint8())) // legacy compression methods
+	if !r.ok {
+		return nil, errTruncated
+	}
+
+	h := &hello{client: true}
+	var extensions, groups, pointFormats []uint16
+	r, truncated := extensionsReader(r)
+	for len(r.buf) > 0 {
+		extensionType := r.uint16()
+		data := reader{buf: r.bytes(int(r.uint16())), ok: true}
+		if !r.ok {
+			truncated = true
+			break
+		}
+		if !isGREASE(extensionType) {
+			extensions = append(extensions, extensionType)
+		}
+
+		switch extensionType {
+		case extensionServerName:
+			data.uint16() // server name list length
+			nameType := data.uint8()
+			name := data.bytes(int(data.uint16()))
+			if !data.ok || nameType != serverNameHostName || len(name) == 0 {
+				return nil, errMalformed
+			}
+			h.serverName = string(name)
+		case extensionSupportedGroups:
+			list := data.bytes(int(data.uint16()))
+			for i := 0; i+1 < len(list); i += 2 {
+				if group := binary.BigEndian.Uint16(list[i:]); !isGREASE(group) {
+					groups = append(groups, group)
+				}
+			}
+		case extensionECPointFormats:
+			for _, format := range data.bytes(int(data.uint8())) {
+				pointFormats = append(pointFormats, uint16(format))
+			}
+		}
+	}
+
+	h.truncated = truncated || !complete
+	if !h.truncated {
+		var cipherSuites []uint16
+		for i := 0; i+1 < len(ciphers); i += 2 {
+			if cipher := binary.BigEndian.Uint16(ciphers[i:]); !isGREASE(cipher) {
+				cipherSuites = append(cipherSuites, cipher)
+			}
+		}
+		h.fingerprint = fingerprint(strconv.Itoa(int(version)), joinValues(cipherSuites), joinValues(extensions), joinValues(groups), joinValues(pointFormats))
+	}
+	return h, nil
+}
+
+func parseServerHello(body []byte, complete bool) (*hello, error) {
+	r := reader{buf: body, ok: true}
+	version := r.uint16()
+	r.skip(32)             // random
+	r.skip(int(r.uint8())) // legacy session id echo
+	cipherSuite := r.uint16()
+	r.skip(1) // legacy compression method
+	if !r.ok {
+		return nil, errTruncated
+	}
+
+	h := &hello{version: version, cipherSuite: cipherSuite}
+	var extensions []uint16
+	r, truncated := extensionsReader(r)
+	for len(r.buf) > 0 {
+		extensionType := r.uint16()
+		data := reader{buf: r.bytes(int(r.uint16())), ok: true}
+		if !r.ok {
+			truncated = true
+			break
+		}
+		extensions = append(extensions, extensionType)
+
+		// the versions from TLS 1.3 are negotiated with the supported_versions extension, the legacy version being
+		// TLS 1.2
+		if extensionType == extensionSupportedVersions {
+			if selected := data.uint16(); data.ok {
+				h.version = selected
+			}
+		}
+	}
+
+	h.truncated = truncated || !complete
+	if !h.truncated {
+		h.fingerprint = fingerprint(strconv.Itoa(int(version)), strconv.Itoa(int(cipherSuite)), joinValues(extensions))
+	}
+	return h, nil
+}
+
+// extensionsReader returns the reader of the extensions following the fields of a hello message, which are optional,
+// and whether they are truncated
+func extensionsReader(r reader) (reader, bool) {
+	if len(r.buf) == 0 {
+		return r, false
+	}
+	length := int(r.uint16())
+	if !r.ok {
+		return r, true
+	}
+	if length <= len(r.buf) {
+		r.buf = r.buf[:length]
+		return r, false
+	}
+	return r, true
+}
+
+// fingerprint returns the MD5 hash of the fields of a JA3 or JA3S fingerprint.
+// Ref: https://github.com/salesforce/ja3
+func fingerprint(fields ...string) string {
+	hash := md5.Sum([]byte(strings.Join(fields, ",")))
+	return hex.EncodeToString(hash[:])
+}
+
+func joinValues(values []uint16) string {
+	var b strings.Builder
+	for i, value := range values {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(strconv.Itoa(int(value)))
+	}
+	return b.String()
+}
+
+// isGREASE returns true for the reserved values sent by the clients to check the tolerance of the servers to unknown
+// values, which are ignored by the JA3 fingerprints.
+// Ref: https://datatracker.ietf.org/doc/html/rfc8701
+func isGREASE(value uint16) bool {
+	return value&0x0f0f == 0x0a0a && value>>8 == value&0xff
+}
+
+// reader reads the fields of a TLS message, ok being unset once the message is truncated
+type reader struct {
+	buf []byte
+	ok  bool
+}
+
+func (r *reader) bytes(n int) []byte {
+	if !r.ok || n > len(r.buf) {
+		r.ok = false
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *reader) skip(n int) {
+	r.bytes(n)
+}
+
+func (r *reader) uint8() uint8 {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+func (r *reader) uint16() uint16 {
+	b := r.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b)
+}
@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tls
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testServerName = "www.datadoghq.com"
+
+func md5Hex(s string) string {
+	hash := md5.Sum([]byte(s))
+	return hex.EncodeToString(hash[:])
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func extension(extensionType uint16, data []byte) []byte {
+	ext := appendUint16(nil, extensionType)
+	ext = appendUint16(ext, uint16(len(data)))
+	return append(ext, data...)
+}
+
+func serverNameExtension(serverName string) []byte {
+	data := appendUint16(nil, uint16(3+len(serverName)))
+	data = append(data, serverNameHostName)
+	data = appendUint16(data, uint16(len(serverName)))
+	return extension(extensionServerName, append(data, serverName...))
+}
+
+// record wraps the body of a handshake message into a TLS record
+func record(messageType byte, body []byte) []byte {
+	message := []byte{messageType, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	message = append(message, body...)
+	rec := []byte{recordHandshake, 0x03, 0x01}
+	rec = appendUint16(rec, uint16(len(message)))
+	return append(rec, message...)
+}
+
+// clientHello builds a ClientHello of a client sending GREASE values, whose JA3 fingerprint is testClientJA3
+func clientHello(serverName string) []byte {
+	body := appendUint16(nil, 0x0303)
+	body = append(body, make([]byte, 32)...)
+	body = append(body, 0x00)
+	// GREASE, TLS_AES_128_GCM_SHA256 and TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256
+	body = append(body, 0x00, 0x06, 0x3a, 0x3a, 0x13, 0x01, 0xc0, 0x2f)
+	body = append(body, 0x01, 0x00)
+
+	var extensions []byte
+	extensions = append(extensions, extension(0x2a2a, nil)...)
+	if serverName != "" {
+		extensions = append(extensions, serverNameExtension(serverName)...)
+	}
+	// GREASE, x25519 and secp256r1
+	extensions = append(extensions, extension(extensionSupportedGroups, []byte{0x00, 0x06, 0x4a, 0x4a, 0x00, 0x1d, 0x00, 0x17})...)
+	extensions = append(extensions, extension(extensionECPointFormats, []byte{0x01, 0x00})...)
+	extensions = append(extensions, extension(extensionSupportedVersions, []byte{0x04, 0x03, 0x04, 0x03, 0x03})...)
+	body = appendUint16(body, uint16(len(extensions)))
+	body = append(body, extensions...)
+	return record(handshakeClientHello, body)
+}
+
+const testClientJA3 = "771,4865-49199,0-10-11-43,29-23,0"
+
+// serverHello builds the ServerHello of a TLS 1.3 server, or of a TLS 1.2 server which sends no extension
+func serverHello(tls13 bool) []byte {
+	body := appendUint16(nil, 0x0303)
+	body = append(body, make([]byte, 32)...)
+	body = append(body, 0x00)
+	if !tls13 {
+		return record(handshakeServerHello, append(body, 0xc0, 0x2f, 0x00))
+	}
+
+	body = append(body, 0x13, 0x01, 0x00)
+	extensions := extension(extensionSupportedVersions, []byte{0x03, 0x04})
+	extensions = append(extensions, extension(0x0033, make([]byte, 36))...)
+	body = appendUint16(body, uint16(len(extensions)))
+	return record(handshakeServerHello, append(body, extensions...))
+}
+
+func TestParseClientHello(t *testing.T) {
+	h, err := parseHello(clientHello(testServerName))
+	require.NoError(t, err)
+	assert.True(t, h.client)
+	assert.Equal(t, testServerName, h.serverName)
+	assert.False(t, h.truncated)
+	assert.Equal(t, md5Hex(testClientJA3), h.fingerprint)
+
+	// the server name is optional
+	h, err = parseHello(clientHello(""))
+	require.NoError(t, err)
+	assert.Empty(t, h.serverName)
+	assert.Equal(t, md5Hex("771,4865-49199,10-11-43,29-23,0"), h.fingerprint)
+}
+
+func TestParseClientHelloTruncated(t *testing.T) {
+	hello := clientHello(testServerName)
+
+	// the extensions following the server name are missing, so there is no fingerprint
+	h, err := parseHello(hello[:len(hello)-10])
+	require.NoError(t, err)
+	assert.Equal(t, testServerName, h.serverName)
+	assert.True(t, h.truncated)
+	assert.Empty(t, h.fingerprint)
+
+	_, err = parseHello(hello[:30])
+	assert.Equal(t, errTruncated, err)
+}
+
+func TestParseServerHello(t *testing.T) {
+	h, err := parseHello(serverHello(true))
+	require.NoError(t, err)
+	assert.False(t, h.client)
+	assert.Equal(t, uint16(0x0304), h.version)
+	assert.Equal(t, uint16(0x1301), h.cipherSuite)
+	assert.Equal(t, md5Hex("771,4865,43-51"), h.fingerprint)
+
+	h, err = parseHello(serverHello(false))
+	require.NoError(t, err)
+	assert.Equal(t, uint16(0x0303), h.version)
+	assert.Equal(t, uint16(0xc02f), h.cipherSuite)
+	assert.Equal(t, md5Hex("771,49199,"), h.fingerprint)
+}
+
+func TestParseHelloErrors(t *testing.T) {
+	// application data
+	_, err := parseHello([]byte{0x17, 0x03, 0x03, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00})
+	assert.Equal(t, errNotHello, err)
+
+	// a Certificate message
+	certificate := record(0x0b, make([]byte, 16))
+	_, err = parseHello(certificate)
+	assert.Equal(t, errNotHello, err)
+
+	// the length of the record is too short for the handshake header
+	hello := serverHello(true)
+	binary.BigEndian.PutUint16(hello[3:], 2)
+	_, err = parseHello(hello)
+	assert.Equal(t, errMalformed, err)
+}
+
+func TestHandshakeTags(t *testing.T) {
+	h := Handshake{
+		ServerName:  testServerName,
+		Version:     0x0303,
+		CipherSuite: 0xc02f,
+		JA3:         md5Hex(testClientJA3),
+	}
+	assert.ElementsMatch(t, []string{
+		"tls_server_name:www.datadoghq.com",
+		"tls_version:tls_1.2",
+		"tls_cipher_suite:TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		"tls_ja3:" + md5Hex(testClientJA3),
+	}, h.Tags())
+
+	assert.Empty(t, (&Handshake{}).Tags())
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tls
+
+import (
+	"time"
+
+	"go.uber.org/atomic"
+
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+type telemetry struct {
+	then *atomic.Int64
+
+	clientHellos *libtelemetry.Metric
+	serverHellos *libtelemetry.Metric
+	truncated    *libtelemetry.Metric // this happens when the hello message doesn't fit in the eBPF buffer, so it has no fingerprint
+	malformed    *libtelemetry.Metric // this happens when the segment can't be parsed as a hello message
+	dropped      *libtelemetry.Metric // this happens when the HandshakeCache reaches capacity
+	expired      *libtelemetry.Metric
+}
+
+func newTelemetry() *telemetry {
+	metricGroup := libtelemetry.NewMetricGroup(
+		"usm.tls_handshake",
+		libtelemetry.OptExpvar,
+		libtelemetry.OptMonotonic,
+	)
+
+	return &telemetry{
+		then:    atomic.NewInt64(time.Now().Unix()),
+		expired: metricGroup.NewMetric("expired"),
+
+		// these metrics are also exported as statsd metrics
+		clientHellos: metricGroup.NewMetric("client_hellos", libtelemetry.OptStatsd),
+		serverHellos: metricGroup.NewMetric("server_hellos", libtelemetry.OptStatsd),
+		truncated:    metricGroup.NewMetric("truncated", libtelemetry.OptStatsd),
+		malformed:    metricGroup.NewMetric("malformed", libtelemetry.OptStatsd),
+		dropped:      metricGroup.NewMetric("dropped", libtelemetry.OptStatsd),
+	}
+}
+
+func (t *telemetry) log() {
+	now := time.Now().Unix()
+	then := t.then.Swap(now)
+
+	clientHellos := t.clientHellos.Delta()
+	serverHellos := t.serverHellos.Delta()
+	truncated := t.truncated.Delta()
+	malformed := t.malformed.Delta()
+	dropped := t.dropped.Delta()
+	expired := t.expired.Delta()
+	elapsed := now - then
+
+	log.Debugf(
+		"tls handshake stats summary: client_hellos=%d(%.2f/s) server_hellos=%d(%.2f/s) hellos_truncated=%d(%.2f/s) hellos_malformed=%d(%.2f/s) handshakes_dropped=%d(%.2f/s) handshakes_expired=%d",
+		clientHellos,
+		float64(clientHellos)/float64(elapsed),
+		serverHellos,
+		float64(serverHellos)/float64(elapsed),
+		truncated,
+		float64(truncated)/float64(elapsed),
+		malformed,
+		float64(malformed)/float64(elapsed),
+		dropped,
+		float64(dropped)/float64(elapsed),
+		expired,
+	)
+}
@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build ignore
+// +build ignore
+
+package tls
+
+/*
+#include "../../ebpf/c/tracer.h"
+#include "../../ebpf/c/protocols/tls/tls-handshake-defs.h"
+#include "../../ebpf/c/protocols/tls/tls-handshake-types.h"
+*/
+import "C"
+
+type ConnTuple C.conn_tuple_t
+
+type EbpfHandshake C.tls_handshake_t
+
+const (
+	BufferSize = C.TLS_HANDSHAKE_BUFFER_SIZE
+)
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package usmstats
+
+import (
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+)
+
+// Keeper aggregates the stats of the requests of a protocol by key, up to a maximum number of keys. It isn't safe for
+// concurrent use, which is left to the StatKeeper of the protocol.
+type Keeper[K comparable, S any] struct {
+	stats      map[K]*S
+	maxEntries int
+
+	dropped      *libtelemetry.Metric
+	aggregations *libtelemetry.Metric
+}
+
+// NewKeeper returns a new Keeper holding up to maxEntries keys. The requests of the new keys are counted in dropped
+// once it is full, and the new keys are counted in aggregations otherwise.
+func NewKeeper[K comparable, S any](maxEntries int, dropped, aggregations *libtelemetry.Metric) *Keeper[K, S] {
+	return &Keeper[K, S]{
+		stats:        make(map[K]*S),
+		maxEntries:   maxEntries,
+		dropped:      dropped,
+		aggregations: aggregations,
+	}
+}
+
+// Get returns the stats of the given key, which are created if needed, or nil if the keeper is full
+func (k *Keeper[K, S]) Get(key K) *S {
+	stats, ok := k.stats[key]
+	if ok {
+		return stats
+	}
+
+	if len(k.stats) >= k.maxEntries {
+		k.dropped.Add(1)
+		return nil
+	}
+	k.aggregations.Add(1)
+	stats = new(S)
+	k.stats[key] = stats
+	return stats
+}
+
+// GetAndReset returns the stats aggregated since the last call
+func (k *Keeper[K, S]) GetAndReset() map[K]*S {
+	ret := k.stats // No deep copy needed since `k.stats` gets reset
+	k.stats = make(map[K]*S)
+	return ret
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package usmstats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+)
+
+func newTestKeeper(maxEntries int) (*Keeper[string, LatencyStats], *Telemetry, *libtelemetry.Metric) {
+	libtelemetry.Clear()
+	t := NewTelemetry("test")
+	dropped := t.NewMetric("dropped")
+	return NewKeeper[string, LatencyStats](maxEntries, dropped, t.Aggregations), t, dropped
+}
+
+func TestKeeperGet(t *testing.T) {
+	k, telemetry, _ := newTestKeeper(10)
+
+	foo := k.Get("foo")
+	require.NotNil(t, foo)
+	foo.AddLatency(10)
+	assert.Same(t, foo, k.Get("foo"))
+	assert.NotSame(t, foo, k.Get("bar"))
+	assert.Equal(t, int64(2), telemetry.Aggregations.Get())
+}
+
+func TestKeeperMaxEntries(t *testing.T) {
+	k, telemetry, dropped := newTestKeeper(1)
+
+	require.NotNil(t, k.Get("foo"))
+	assert.Nil(t, k.Get("bar"))
+	// the keys already aggregated are still updated once the keeper is full
+	assert.NotNil(t, k.Get("foo"))
+	assert.Equal(t, int64(1), telemetry.Aggregations.Get())
+	assert.Equal(t, int64(1), dropped.Get())
+
+	stats := k.GetAndReset()
+	require.Len(t, stats, 1)
+	assert.Contains(t, stats, "foo")
+}
+
+func TestKeeperGetAndReset(t *testing.T) {
+	k, _, _ := newTestKeeper(1)
+
+	k.Get("foo").AddLatency(10)
+	stats := k.GetAndReset()
+	require.Contains(t, stats, "foo")
+	assert.Equal(t, 1, stats["foo"].Count)
+
+	// the keeper is emptied, and the returned stats are left untouched
+	assert.Empty(t, k.GetAndReset())
+	require.NotNil(t, k.Get("bar"))
+	assert.Equal(t, 1, stats["foo"].Count)
+	assert.Len(t, stats, 1)
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package usmstats holds the building blocks shared by the stat keepers of the protocols monitored by the Universal
+// Service Monitoring: the network tuple and the latency distribution of a group of requests, the bounded map the
+// groups are aggregated in, and the telemetry summarizing their processing.
+package usmstats
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// KeyTuple represents the network tuple for a group of requests, the client being the source
+type KeyTuple struct {
+	SrcIPHigh uint64
+	SrcIPLow  uint64
+
+	DstIPHigh uint64
+	DstIPLow  uint64
+
+	// ports separated for alignment/size optimization
+	SrcPort uint16
+	DstPort uint16
+}
+
+// NewKeyTuple generates a new KeyTuple
+func NewKeyTuple(saddr, daddr util.Address, sport, dport uint16) KeyTuple {
+	saddrl, saddrh := util.ToLowHigh(saddr)
+	daddrl, daddrh := util.ToLowHigh(daddr)
+	return KeyTuple{
+		SrcIPHigh: saddrh,
+		SrcIPLow:  saddrl,
+		SrcPort:   sport,
+		DstIPHigh: daddrh,
+		DstIPLow:  daddrl,
+		DstPort:   dport,
+	}
+}
+
+// Fragment returns the first size bytes of the buffer of an eBPF transaction, size being bounded by the length of the
+// buffer
+func Fragment(buffer []byte, size int) []byte {
+	if size > len(buffer) {
+		size = len(buffer)
+	}
+	return buffer[:size]
+}
+
+// Latency returns the time elapsed (in nanoseconds) between the timestamps of a request and of its response, or 0 if
+// it is unknown
+func Latency(requestStarted, responseReceived uint64) float64 {
+	if requestStarted == 0 || responseReceived == 0 || responseReceived < requestStarted {
+		return 0
+	}
+	return float64(responseReceived - requestStarted)
+}
@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package usmstats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatency(t *testing.T) {
+	assert.Equal(t, 1000.0, Latency(1000, 2000))
+	assert.Equal(t, 0.0, Latency(0, 2000))
+	assert.Equal(t, 0.0, Latency(1000, 0))
+	assert.Equal(t, 0.0, Latency(2000, 1000))
+}
+
+func TestFragment(t *testing.T) {
+	buffer := []byte("0123456789")
+	assert.Equal(t, []byte("0123"), Fragment(buffer, 4))
+	assert.Equal(t, buffer, Fragment(buffer, 20))
+	assert.Empty(t, Fragment(buffer, 0))
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package usmstats
+
+import (
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RelativeAccuracy defines the acceptable error in quantile values calculated by DDSketch.
+// For example, if the actual value at p50 is 100, with a relative accuracy of 0.01 the value calculated
+// will be between 99 and 101
+const RelativeAccuracy = 0.01
+
+// LatencyStats holds the number and the latency distribution of a group of requests. It is meant to be embedded in
+// the RequestStat of the protocols, next to their own stats.
+type LatencyStats struct {
+	// this field order is intentional to help the GC pointer tracking
+	Latencies *ddsketch.DDSketch
+	Count     int
+
+	// This field holds the value (in nanoseconds) of the first latency sample. We do this as optimization to avoid
+	// creating sketches with a single value.
+	FirstLatencySample float64
+}
+
+// AddLatency adds the latency of a request to the stats
+func (l *LatencyStats) AddLatency(latency float64) {
+	l.Count++
+	if l.Count == 1 {
+		// We postpone the creation of histograms when we have only one latency sample
+		l.FirstLatencySample = latency
+		return
+	}
+
+	if l.Latencies == nil {
+		var err error
+		l.Latencies, err = ddsketch.NewDefaultDDSketch(RelativeAccuracy)
+		if err != nil {
+			log.Debugf("error recording request latency: could not create new ddsketch: %v", err)
+			return
+		}
+
+		// Add the deferred latency sample
+		if err := l.Latencies.Add(l.FirstLatencySample); err != nil {
+			log.Debugf("could not add request latency to ddsketch: %v", err)
+		}
+	}
+
+	if err := l.Latencies.Add(latency); err != nil {
+		log.Debugf("could not add request latency to ddsketch: %v", err)
+	}
+}
+
+// CombineLatencies merges the latencies of newStats into the ones of the receiver
+// newStats is kept as it is, while the method receiver gets mutated
+func (l *LatencyStats) CombineLatencies(newStats *LatencyStats) {
+	switch newStats.Count {
+	case 0:
+		return
+	case 1:
+		// The other bucket has a single latency sample, so we "manually" add it
+		l.AddLatency(newStats.FirstLatencySample)
+		return
+	}
+
+	// The other bucket (newStats) has multiple samples and therefore a DDSketch object
+	// We first ensure that the bucket we're merging to has a DDSketch object
+	if l.Latencies == nil {
+		l.Latencies = newStats.Latencies.Copy()
+
+		// If we have a latency sample in this bucket we now add it to the DDSketch
+		if l.Count == 1 {
+			if err := l.Latencies.Add(l.FirstLatencySample); err != nil {
+				log.Debugf("could not add request latency to ddsketch: %v", err)
+			}
+		}
+	} else if err := l.Latencies.MergeWith(newStats.Latencies); err != nil {
+		log.Debugf("error merging request latencies: %v", err)
+	}
+	l.Count += newStats.Count
+}
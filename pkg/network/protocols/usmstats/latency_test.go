@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package usmstats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddLatency(t *testing.T) {
+	stats := new(LatencyStats)
+	stats.AddLatency(10)
+	assert.Equal(t, 1, stats.Count)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+	assert.Nil(t, stats.Latencies)
+
+	stats.AddLatency(20)
+	assert.Equal(t, 2, stats.Count)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 2.0, stats.Latencies.GetCount())
+}
+
+func TestCombineLatencies(t *testing.T) {
+	stats := new(LatencyStats)
+	stats.CombineLatencies(new(LatencyStats))
+	assert.Equal(t, 0, stats.Count)
+	assert.Nil(t, stats.Latencies)
+
+	single := new(LatencyStats)
+	single.AddLatency(10)
+	stats.CombineLatencies(single)
+	assert.Equal(t, 1, stats.Count)
+	assert.Equal(t, 10.0, stats.FirstLatencySample)
+	assert.Nil(t, stats.Latencies)
+
+	multiple := new(LatencyStats)
+	multiple.AddLatency(20)
+	multiple.AddLatency(30)
+	stats.CombineLatencies(multiple)
+	assert.Equal(t, 3, stats.Count)
+	require.NotNil(t, stats.Latencies)
+	assert.Equal(t, 3.0, stats.Latencies.GetCount())
+
+	stats.CombineLatencies(multiple)
+	assert.Equal(t, 5, stats.Count)
+	assert.Equal(t, 5.0, stats.Latencies.GetCount())
+
+	// the combined stats are left untouched
+	assert.Equal(t, 2, multiple.Count)
+	assert.Equal(t, 2.0, multiple.Latencies.GetCount())
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package usmstats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/atomic"
+
+	libtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Telemetry holds the metrics of the stat keeper of a protocol, under the usm.<protocol> namespace, and periodically
+// logs a summary of them
+type Telemetry struct {
+	protocol    string
+	then        *atomic.Int64
+	metricGroup *libtelemetry.MetricGroup
+	summary     []summaryMetric
+
+	// Aggregations is the number of keys the requests were aggregated by
+	Aggregations *libtelemetry.Metric
+}
+
+type summaryMetric struct {
+	name   string
+	metric *libtelemetry.Metric
+}
+
+// NewTelemetry returns the telemetry of the given protocol
+func NewTelemetry(protocol string) *Telemetry {
+	metricGroup := libtelemetry.NewMetricGroup(
+		"usm."+protocol,
+		libtelemetry.OptExpvar,
+		libtelemetry.OptMonotonic,
+	)
+
+	return &Telemetry{
+		protocol:     protocol,
+		then:         atomic.NewInt64(time.Now().Unix()),
+		metricGroup:  metricGroup,
+		Aggregations: metricGroup.NewMetric("aggregations"),
+	}
+}
+
+// NewMetric returns a new metric of the protocol
+func (t *Telemetry) NewMetric(name string, tagsAndOptions ...string) *libtelemetry.Metric {
+	return t.metricGroup.NewMetric(name, tagsAndOptions...)
+}
+
+// NewSummaryMetric returns a new metric of the protocol, whose delta is logged in the summary as summaryName, in the
+// order the metrics are created
+func (t *Telemetry) NewSummaryMetric(name, summaryName string, tagsAndOptions ...string) *libtelemetry.Metric {
+	metric := t.metricGroup.NewMetric(name, tagsAndOptions...)
+	t.summary = append(t.summary, summaryMetric{name: summaryName, metric: metric})
+	return metric
+}
+
+// Log logs the delta of the summary metrics since the last call, along with their rate
+func (t *Telemetry) Log() {
+	now := time.Now().Unix()
+	then := t.then.Swap(now)
+	elapsed := now - then
+
+	var summary strings.Builder
+	for _, m := range t.summary {
+		delta := m.metric.Delta()
+		fmt.Fprintf(&summary, "%s=%d(%.2f/s) ", m.name, delta, float64(delta)/float64(elapsed))
+	}
+	log.Debugf("%s stats summary: %saggregations=%d", t.protocol, summary.String(), t.Aggregations.Delta())
+}
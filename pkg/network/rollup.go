@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// rollupKey identifies the connections rolled up together: the connections of a container between the same addresses
+// and to the same server port, which only differ by their ephemeral port
+type rollupKey struct {
+	laddr util.Address
+	raddr util.Address
+	// the translated remote address, so that the connections to the different backends of a service are kept apart
+	natRaddr util.Address
+	natRport uint16
+
+	port        uint16
+	connType    ConnectionType
+	direction   ConnectionDirection
+	containerID string
+}
+
+// RollupConnections aggregates the connections which only differ by their ephemeral port, which are the bulk of the
+// connections of the hosts opening many short-lived connections to the same services. The rolled up connection has
+// its ephemeral port set to 0, the sum of the traffic of its connections, and the other metrics of the most recently
+// updated one. RollupCount is the number of connections it stands for.
+// The connections carrying DNS, HTTP or Kafka stats are never rolled up, as these stats are matched to the
+// connections by their ports. The connections are rolled up in place, and the updated slice is returned.
+func RollupConnections(conns []ConnectionStats, httpStats map[http.Key]*http.RequestStats, kafkaStats map[kafka.Key]*kafka.RequestStat) []ConnectionStats {
+	httpTuples := make(map[http.KeyTuple]struct{}, len(httpStats))
+	for key := range httpStats {
+		httpTuples[key.KeyTuple] = struct{}{}
+	}
+	kafkaTuples := make(map[kafka.KeyTuple]struct{}, len(kafkaStats))
+	for key := range kafkaStats {
+		kafkaTuples[key.KeyTuple] = struct{}{}
+	}
+
+	rolledUp := make(map[rollupKey]int)
+	result := conns[:0]
+	for _, c := range conns {
+		key, ok := newRollupKey(c)
+		if !ok || hasUSMStats(c, httpTuples, kafkaTuples) {
+			result = append(result, c)
+			continue
+		}
+
+		idx, ok := rolledUp[key]
+		if !ok {
+			rolledUp[key] = len(result)
+			result = append(result, rollupConnection(c))
+			result[len(result)-1].RollupCount = 1
+			continue
+		}
+		mergeRolledUpConnection(&result[idx], c)
+	}
+	return result
+}
+
+// newRollupKey returns the rollup key of the given connection, if one of its ports is ephemeral
+func newRollupKey(c ConnectionStats) (rollupKey, bool) {
+	if _, isDNS := DNSKey(&c); isDNS {
+		return rollupKey{}, false
+	}
+
+	key := rollupKey{
+		laddr:     c.Source,
+		raddr:     c.Dest,
+		connType:  c.Type,
+		direction: c.Direction,
+	}
+	key.natRaddr, key.natRport = GetNATRemoteAddress(c)
+	if c.ContainerID != nil {
+		key.containerID = *c.ContainerID
+	}
+
+	switch {
+	case c.Direction == INCOMING:
+		key.port = c.SPort
+		key.natRport = 0
+	case c.Direction == OUTGOING || c.SPortIsEphemeral == EphemeralTrue:
+		key.port = c.DPort
+	default:
+		return rollupKey{}, false
+	}
+	return key, true
+}
+
+func hasUSMStats(c ConnectionStats, httpTuples map[http.KeyTuple]struct{}, kafkaTuples map[kafka.KeyTuple]struct{}) bool {
+	for _, tuple := range HTTPKeyTuplesFromConn(c) {
+		if _, ok := httpTuples[tuple]; ok {
+			return true
+		}
+	}
+	for _, tuple := range KafkaKeyTuplesFromConn(c) {
+		if _, ok := kafkaTuples[tuple]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// rollupConnection returns the given connection with its ephemeral port set to 0
+func rollupConnection(c ConnectionStats) ConnectionStats {
+	if c.IPTranslation != nil {
+		translation := *c.IPTranslation
+		c.IPTranslation = &translation
+	}
+
+	if c.Direction == INCOMING {
+		c.DPort = 0
+		if c.IPTranslation != nil {
+			c.IPTranslation.ReplSrcPort = 0
+		}
+	} else {
+		c.SPort = 0
+		if c.IPTranslation != nil {
+			c.IPTranslation.ReplDstPort = 0
+		}
+	}
+	return c
+}
+
+// mergeRolledUpConnection adds the given connection to the rolled up one
+func mergeRolledUpConnection(rolled *ConnectionStats, c ConnectionStats) {
+	monotonic, last := rolled.Monotonic.Add(c.Monotonic), rolled.Last.Add(c.Last)
+	count := rolled.RollupCount + 1
+	staticTags := rolled.StaticTags | c.StaticTags
+	isAssured := rolled.IsAssured || c.IsAssured
+	recvQueueMax, sendQueueMax := maxUint32(rolled.RecvQueueMax, c.RecvQueueMax), maxUint32(rolled.SendQueueMax, c.SendQueueMax)
+
+	// the tags maps of the connections are shared with the network state, so they are copied before being merged
+	var tags map[string]struct{}
+	if len(rolled.Tags) > 0 || len(c.Tags) > 0 {
+		tags = make(map[string]struct{}, len(rolled.Tags)+len(c.Tags))
+		for tag := range rolled.Tags {
+			tags[tag] = struct{}{}
+		}
+		for tag := range c.Tags {
+			tags[tag] = struct{}{}
+		}
+	}
+
+	if c.LastUpdateEpoch > rolled.LastUpdateEpoch {
+		*rolled = rollupConnection(c)
+	}
+	rolled.Monotonic, rolled.Last = monotonic, last
+	rolled.RollupCount = count
+	rolled.StaticTags = staticTags
+	rolled.IsAssured = isAssured
+	rolled.RecvQueueMax, rolled.SendQueueMax = recvQueueMax, sendQueueMax
+	rolled.Tags = tags
+}
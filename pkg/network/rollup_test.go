@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package network
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestRollupConnections(t *testing.T) {
+	container := "container"
+	client := util.AddressFromString("10.0.0.1")
+	server := util.AddressFromString("10.0.0.2")
+	outgoing := func(sport uint16, sent uint64, lastUpdate uint64) ConnectionStats {
+		return ConnectionStats{
+			Source:          client,
+			Dest:            server,
+			SPort:           sport,
+			DPort:           443,
+			Type:            TCP,
+			Direction:       OUTGOING,
+			ContainerID:     &container,
+			Monotonic:       StatCounters{SentBytes: sent, TCPEstablished: 1},
+			Last:            StatCounters{SentBytes: sent, TCPEstablished: 1},
+			LastUpdateEpoch: lastUpdate,
+			RTT:             uint32(lastUpdate),
+			Tags:            map[string]struct{}{"sport:" + strconv.Itoa(int(sport)): {}},
+		}
+	}
+	incoming := ConnectionStats{
+		Source:    server,
+		Dest:      client,
+		SPort:     8080,
+		DPort:     50000,
+		Type:      TCP,
+		Direction: INCOMING,
+	}
+	otherPort := outgoing(50003, 10, 1)
+	otherPort.DPort = 80
+	dns := ConnectionStats{Source: client, Dest: server, SPort: 50004, DPort: 53, Type: UDP, Direction: OUTGOING}
+	withHTTP := outgoing(50005, 10, 1)
+	withHTTP.DPort = 8000
+
+	conns := []ConnectionStats{
+		outgoing(50000, 10, 1),
+		incoming,
+		outgoing(50001, 20, 3),
+		otherPort,
+		outgoing(50002, 30, 2),
+		dns,
+		withHTTP,
+	}
+	httpStats := map[http.Key]*http.RequestStats{
+		http.NewKey(client, server, 50005, 8000, "/", true, http.MethodGet): {},
+	}
+
+	rolledUp := RollupConnections(conns, httpStats, nil)
+	require.Len(t, rolledUp, 5)
+
+	// the three connections to server:443 are rolled up into the first one
+	rolled := rolledUp[0]
+	assert.Equal(t, uint32(3), rolled.RollupCount)
+	assert.Zero(t, rolled.SPort)
+	assert.Equal(t, uint16(443), rolled.DPort)
+	assert.Equal(t, uint64(60), rolled.Monotonic.SentBytes)
+	assert.Equal(t, uint32(3), rolled.Last.TCPEstablished)
+	// the other metrics are the ones of the most recently updated connection
+	assert.Equal(t, uint64(3), rolled.LastUpdateEpoch)
+	assert.Equal(t, uint32(3), rolled.RTT)
+	assert.Len(t, rolled.Tags, 3)
+
+	// the incoming connection is alone on its server port, it is rolled up with its remote port cleared
+	assert.Equal(t, uint32(1), rolledUp[1].RollupCount)
+	assert.Equal(t, uint16(8080), rolledUp[1].SPort)
+	assert.Zero(t, rolledUp[1].DPort)
+
+	assert.Equal(t, uint32(1), rolledUp[2].RollupCount)
+	assert.Equal(t, uint16(80), rolledUp[2].DPort)
+
+	// the connections carrying DNS and HTTP stats are left untouched
+	assert.Equal(t, dns, rolledUp[3])
+	assert.Equal(t, withHTTP, rolledUp[4])
+}
+
+func TestRollupConnectionsNAT(t *testing.T) {
+	client := util.AddressFromString("10.0.0.1")
+	service := util.AddressFromString("10.96.0.10")
+	translated := func(sport uint16, backend string) ConnectionStats {
+		return ConnectionStats{
+			Source:    client,
+			Dest:      service,
+			SPort:     sport,
+			DPort:     80,
+			Type:      TCP,
+			Direction: OUTGOING,
+			IPTranslation: &IPTranslation{
+				ReplSrcIP:   util.AddressFromString(backend),
+				ReplDstIP:   client,
+				ReplSrcPort: 8080,
+				ReplDstPort: sport,
+			},
+		}
+	}
+
+	conns := []ConnectionStats{
+		translated(50000, "10.244.1.5"),
+		translated(50001, "10.244.1.6"),
+		translated(50002, "10.244.1.5"),
+	}
+	first := conns[0].IPTranslation
+
+	// the connections to the different backends of the service are kept apart
+	rolledUp := RollupConnections(conns, nil, nil)
+	require.Len(t, rolledUp, 2)
+	assert.Equal(t, uint32(2), rolledUp[0].RollupCount)
+	assert.Zero(t, rolledUp[0].IPTranslation.ReplDstPort)
+	assert.Equal(t, uint16(8080), rolledUp[0].IPTranslation.ReplSrcPort)
+	assert.Equal(t, uint32(1), rolledUp[1].RollupCount)
+	// the translations of the connections are not modified
+	assert.Equal(t, uint16(50000), first.ReplDstPort)
+}
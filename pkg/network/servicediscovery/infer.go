@@ -0,0 +1,290 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package servicediscovery infers the name of the service of a process from its metadata, so that the connections of
+// the processes which don't set DD_SERVICE can still be grouped by service.
+package servicediscovery
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// EnvNames holds the names of the environment variables used to infer the service names
+var EnvNames = []string{
+	"HOSTNAME",
+	"KUBERNETES_SERVICE_HOST",
+}
+
+// maxServiceNameLength bounds the length of the inferred service names, which are reported as tags
+const maxServiceNameLength = 100
+
+var (
+	pythonExecutable = regexp.MustCompile(`^python[0-9.]*$`)
+	// pythonApp matches the applications of the WSGI and ASGI servers, such as "myproject.wsgi:application"
+	pythonApp  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*(:.*)?$`)
+	jarVersion = regexp.MustCompile(`[-_]v?[0-9]+(\.[0-9]+)*([-.][A-Za-z0-9.]+)?$`)
+
+	// the random suffixes of the pods use the alphabet of the Kubernetes name generator, which has no vowel
+	deploymentPod  = regexp.MustCompile(`^(.+)-[bcdfghjklmnpqrstvwxz2456789]{5,10}-[bcdfghjklmnpqrstvwxz2456789]{5}$`)
+	daemonSetPod   = regexp.MustCompile(`^(.+)-[bcdfghjklmnpqrstvwxz2456789]{5}$`)
+	statefulSetPod = regexp.MustCompile(`^(.+)-[0-9]+$`)
+
+	invalidServiceChars = regexp.MustCompile(`[^a-z0-9_.\-]+`)
+)
+
+// interpreters are the executables whose name doesn't tell the service they run
+var interpreters = map[string]struct{}{
+	"java":   {},
+	"node":   {},
+	"nodejs": {},
+	"ruby":   {},
+	"php":    {},
+	"perl":   {},
+	"sh":     {},
+	"bash":   {},
+	"dotnet": {},
+}
+
+// genericScripts are the names of the scripts which don't tell the service they run, their directory being used instead
+var genericScripts = map[string]struct{}{
+	"app":      {},
+	"index":    {},
+	"main":     {},
+	"manage":   {},
+	"run":      {},
+	"server":   {},
+	"start":    {},
+	"wsgi":     {},
+	"asgi":     {},
+	"__main__": {},
+}
+
+// buildDirectories are the directories holding the scripts of a project, whose name doesn't tell the service
+var buildDirectories = map[string]struct{}{
+	"bin":   {},
+	"build": {},
+	"dist":  {},
+	"lib":   {},
+	"out":   {},
+	"src":   {},
+}
+
+// pythonServerOptions are the options of gunicorn and uvicorn taking a value
+var pythonServerOptions = map[string]struct{}{
+	"-b": {}, "--bind": {},
+	"-c": {}, "--config": {},
+	"-e": {}, "--env": {},
+	"-g": {}, "--group": {},
+	"-k": {}, "--worker-class": {},
+	"-p": {}, "--pid": {},
+	"-t": {}, "--timeout": {},
+	"-u": {}, "--user": {},
+	"-w": {}, "--workers": {},
+	"--access-logfile": {},
+	"--app-dir":        {},
+	"--chdir":          {},
+	"--error-logfile":  {},
+	"--host":           {},
+	"--log-config":     {},
+	"--log-file":       {},
+	"--log-level":      {},
+	"--port":           {},
+	"--pythonpath":     {},
+	"--root-path":      {},
+	"--threads":        {},
+}
+
+// javaOptions are the options of the java launcher taking a value in the next argument
+var javaOptions = map[string]struct{}{
+	"-cp":           {},
+	"-classpath":    {},
+	"--class-path":  {},
+	"-p":            {},
+	"--module-path": {},
+	"--add-modules": {},
+}
+
+// Infer returns the name of the service of a process, inferred from its command line and its environment variables,
+// or an empty string if none could be inferred. The heuristics are, in order:
+//   - the application module of gunicorn and uvicorn, or their process name
+//   - the module or the script run by python, and the script run by node
+//   - the jar run by java, or its main class, unless the dd.service property is set
+//   - the workload of the Kubernetes pods, from their name
+//   - the executable, unless it is an interpreter
+func Infer(cmdline []string, envs map[string]string) string {
+	// the processes rewriting their command line, such as nginx, have their arguments in a single one
+	if len(cmdline) == 1 && strings.ContainsRune(cmdline[0], ' ') {
+		cmdline = strings.Fields(cmdline[0])
+	}
+	if len(cmdline) == 0 {
+		// a kernel thread
+		return ""
+	}
+
+	exe := strings.TrimSuffix(filepath.Base(cmdline[0]), ":")
+	args := cmdline[1:]
+
+	var name string
+	switch {
+	case exe == "gunicorn" || exe == "uvicorn":
+		name = pythonServerService(args)
+	case pythonExecutable.MatchString(exe):
+		name = pythonService(args)
+		exe = "python"
+	case exe == "java":
+		name = javaService(args)
+	case exe == "node" || exe == "nodejs":
+		name = scriptService(firstPositional(args, nil))
+	}
+
+	if name == "" {
+		name = kubernetesWorkload(envs)
+	}
+	if name == "" {
+		if _, ok := interpreters[exe]; !ok && exe != "python" {
+			name = exe
+		}
+	}
+	return normalize(name)
+}
+
+// pythonService returns the module or the script run by python
+func pythonService(args []string) string {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-m" && i+1 < len(args):
+			module := args[i+1]
+			if module == "gunicorn" || module == "uvicorn" {
+				return pythonServerService(args[i+2:])
+			}
+			return strings.Split(module, ".")[0]
+		case arg == "-c":
+			// an inline program
+			return ""
+		case !strings.HasPrefix(arg, "-"):
+			return scriptService(arg)
+		}
+	}
+	return ""
+}
+
+// pythonServerService returns the process name or the application module of gunicorn or uvicorn
+func pythonServerService(args []string) string {
+	for i, arg := range args {
+		if arg == "-n" || arg == "--name" {
+			if i+1 < len(args) {
+				return args[i+1]
+			}
+		}
+		if name := strings.TrimPrefix(arg, "--name="); name != arg {
+			return name
+		}
+	}
+
+	app := firstPositional(args, pythonServerOptions)
+	if !pythonApp.MatchString(app) {
+		return ""
+	}
+	module, _, _ := strings.Cut(app, ":")
+	return strings.Split(module, ".")[0]
+}
+
+// javaService returns the service name set through the dd.service property, the jar or the main class run by java
+func javaService(args []string) string {
+	for _, arg := range args {
+		if name := strings.TrimPrefix(arg, "-Ddd.service="); name != arg {
+			return name
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-jar" && i+1 < len(args):
+			jar := strings.TrimSuffix(filepath.Base(args[i+1]), ".jar")
+			return jarVersion.ReplaceAllString(jar, "")
+		case (arg == "-m" || arg == "--module") && i+1 < len(args):
+			// the main class of the module follows its name, such as "com.example/com.example.Main"
+			module := args[i+1]
+			if _, class, ok := strings.Cut(module, "/"); ok {
+				return javaClassName(class)
+			}
+			return javaClassName(module)
+		case strings.HasPrefix(arg, "-"):
+			if _, ok := javaOptions[arg]; ok {
+				i++
+			}
+		default:
+			return javaClassName(arg)
+		}
+	}
+	return ""
+}
+
+func javaClassName(class string) string {
+	if i := strings.LastIndexByte(class, '.'); i >= 0 {
+		return class[i+1:]
+	}
+	return class
+}
+
+// scriptService returns the name of a script, or the one of its project when the name of the script is generic
+func scriptService(script string) string {
+	if script == "" {
+		return ""
+	}
+	name := strings.TrimSuffix(filepath.Base(script), filepath.Ext(script))
+	if _, ok := genericScripts[name]; !ok {
+		return name
+	}
+
+	dir := filepath.Dir(script)
+	if _, ok := buildDirectories[filepath.Base(dir)]; ok {
+		dir = filepath.Dir(dir)
+	}
+	if dir == "." || dir == "/" {
+		return name
+	}
+	return filepath.Base(dir)
+}
+
+// kubernetesWorkload returns the name of the workload of the pod of the process, from the name of the pod
+func kubernetesWorkload(envs map[string]string) string {
+	pod := envs["HOSTNAME"]
+	if envs["KUBERNETES_SERVICE_HOST"] == "" || pod == "" {
+		return ""
+	}
+	for _, re := range []*regexp.Regexp{deploymentPod, daemonSetPod, statefulSetPod} {
+		if m := re.FindStringSubmatch(pod); m != nil {
+			return m[1]
+		}
+	}
+	return pod
+}
+
+// firstPositional returns the first argument which isn't an option or the value of one of the given options
+func firstPositional(args []string, options map[string]struct{}) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+		if _, ok := options[arg]; ok {
+			i++
+		}
+	}
+	return ""
+}
+
+func normalize(name string) string {
+	name = invalidServiceChars.ReplaceAllString(strings.ToLower(name), "_")
+	name = strings.Trim(name, "_.-")
+	if len(name) > maxServiceNameLength {
+		name = name[:maxServiceNameLength]
+	}
+	return name
+}
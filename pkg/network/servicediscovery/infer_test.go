@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package servicediscovery
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfer(t *testing.T) {
+	kubernetes := func(pod string) map[string]string {
+		return map[string]string{"KUBERNETES_SERVICE_HOST": "10.96.0.1", "HOSTNAME": pod}
+	}
+
+	tests := []struct {
+		name    string
+		cmdline string
+		envs    map[string]string
+		service string
+	}{
+		{name: "kernel thread", cmdline: "", service: ""},
+		{name: "executable", cmdline: "/usr/sbin/redis-server *:6379", service: "redis-server"},
+		{name: "rewritten command line", cmdline: "nginx: master process /usr/sbin/nginx", service: "nginx"},
+
+		{name: "gunicorn app", cmdline: "/usr/local/bin/gunicorn -b 0.0.0.0:8000 -k uvicorn.workers.UvicornWorker shop.wsgi:application", service: "shop"},
+		{name: "gunicorn name", cmdline: "gunicorn --name=billing app:app", service: "billing"},
+		{name: "python gunicorn", cmdline: "python3 -m gunicorn --workers 4 orders.main:app", service: "orders"},
+		{name: "uvicorn", cmdline: "uvicorn --host 0.0.0.0 --port 80 api:app", service: "api"},
+		{name: "python module", cmdline: "/usr/bin/python3.11 -u -m celery.worker", service: "celery"},
+		{name: "python script", cmdline: "python /srv/jobs/reporting.py --daily", service: "reporting"},
+		{name: "django", cmdline: "python /srv/inventory/manage.py runserver", service: "inventory"},
+		{name: "python inline program", cmdline: "python -c print(1)", service: ""},
+
+		{name: "java jar", cmdline: "java -Xmx1g -jar /app/payments-service-2.3.1-SNAPSHOT.jar", service: "payments-service"},
+		{name: "java main class", cmdline: "java -cp /app/lib/* com.example.Checkout --port 8080", service: "checkout"},
+		{name: "java module", cmdline: "java --module-path mods -m com.example.cart/com.example.cart.Cart", service: "cart"},
+		{name: "java property", cmdline: "java -Ddd.service=Ledger -jar app.jar", service: "ledger"},
+
+		{name: "node script", cmdline: "node /srv/notifier/dist/index.js", service: "notifier"},
+		{name: "node project", cmdline: "node gateway.mjs", service: "gateway"},
+
+		{name: "deployment pod", cmdline: "java -version", envs: kubernetes("frontend-7d9f8c6b54-x2k4z"), service: "frontend"},
+		{name: "daemonset pod", cmdline: "node", envs: kubernetes("log-shipper-w4v9q"), service: "log-shipper"},
+		{name: "statefulset pod", cmdline: "ruby", envs: kubernetes("postgres-replica-2"), service: "postgres-replica"},
+		{name: "command line over pod", cmdline: "gunicorn search:app", envs: kubernetes("frontend-7d9f8c6b54-x2k4z"), service: "search"},
+		{name: "hostname outside kubernetes", cmdline: "node", envs: map[string]string{"HOSTNAME": "web-1"}, service: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cmdline []string
+			if tt.cmdline != "" {
+				cmdline = strings.Split(tt.cmdline, " ")
+			}
+			assert.Equal(t, tt.service, Infer(cmdline, tt.envs))
+		})
+	}
+
+	// the processes rewriting their command line have their arguments in a single one
+	assert.Equal(t, "nginx", Infer([]string{"nginx: worker process"}, nil))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "my_service", normalize("My Service"))
+	assert.Equal(t, "api", normalize("__api__"))
+	assert.Len(t, normalize(strings.Repeat("a", 200)), maxServiceNameLength)
+}
@@ -8,12 +8,23 @@ package network
 import (
 	"bytes"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/cassandra"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http3"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/memcached"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -45,7 +56,7 @@ type State interface {
 		latestTime uint64,
 		active []ConnectionStats,
 		dns dns.StatsByKeyByNameByType,
-		http map[http.Key]*http.RequestStats,
+		usmStats USMStats,
 	) Delta
 
 	// GetTelemetryDelta returns the telemetry delta since last time the given client requested telemetry data.
@@ -58,6 +69,10 @@ type State interface {
 	// If the client is already registered, it does nothing.
 	RegisterClient(clientID string)
 
+	// SetClientMaxConnections sets the maximum number of connections returned to the given client, the connections
+	// with the lowest traffic being evicted first. A limit of 0 or less disables it.
+	SetClientMaxConnections(clientID string, maxConns int)
+
 	// RemoveClient stops tracking stateful data for a given client
 	RemoveClient(clientID string)
 
@@ -80,10 +95,41 @@ type State interface {
 // Delta represents a delta of network data compared to the last call to State.
 type Delta struct {
 	BufferedData
-	HTTP     map[http.Key]*http.RequestStats
+	USMStats
 	DNSStats dns.StatsByKeyByNameByType
 }
 
+// USMStats are the stats of the protocols monitored by USM, by protocol
+type USMStats struct {
+	HTTP      map[http.Key]*http.RequestStats
+	Kafka     map[kafka.Key]*kafka.RequestStat
+	Postgres  map[postgres.Key]*postgres.RequestStat
+	MySQL     map[mysql.Key]*mysql.RequestStat
+	Redis     map[redis.Key]*redis.RequestStat
+	Mongo     map[mongo.Key]*mongo.RequestStat
+	AMQP      map[amqp.Key]*amqp.RequestStat
+	GRPC      map[grpc.Key]*grpc.RequestStat
+	HTTP3     map[http3.Key]*http3.RequestStat
+	Cassandra map[cassandra.Key]*cassandra.RequestStat
+	Memcached map[memcached.Key]*memcached.RequestStat
+}
+
+func newUSMStats() USMStats {
+	return USMStats{
+		HTTP:      make(map[http.Key]*http.RequestStats),
+		Kafka:     make(map[kafka.Key]*kafka.RequestStat),
+		Postgres:  make(map[postgres.Key]*postgres.RequestStat),
+		MySQL:     make(map[mysql.Key]*mysql.RequestStat),
+		Redis:     make(map[redis.Key]*redis.RequestStat),
+		Mongo:     make(map[mongo.Key]*mongo.RequestStat),
+		AMQP:      make(map[amqp.Key]*amqp.RequestStat),
+		GRPC:      make(map[grpc.Key]*grpc.RequestStat),
+		HTTP3:     make(map[http3.Key]*http3.RequestStat),
+		Cassandra: make(map[cassandra.Key]*cassandra.RequestStat),
+		Memcached: make(map[memcached.Key]*memcached.RequestStat),
+	}
+}
+
 type telemetry struct {
 	closedConnDropped     int64
 	connDropped           int64
@@ -92,7 +138,18 @@ type telemetry struct {
 	timeSyncCollisions    int64
 	dnsStatsDropped       int64
 	httpStatsDropped      int64
+	kafkaStatsDropped     int64
+	postgresStatsDropped  int64
+	mysqlStatsDropped     int64
+	redisStatsDropped     int64
+	mongoStatsDropped     int64
+	amqpStatsDropped      int64
+	grpcStatsDropped      int64
+	http3StatsDropped     int64
+	cassandraStatsDropped int64
+	memcachedStatsDropped int64
 	dnsPidCollisions      int64
+	connsEvicted          int64
 }
 
 const minClosedCapacity = 1024
@@ -106,8 +163,13 @@ type client struct {
 	stats             map[uint32]StatCounters
 	// maps by dns key the domain (string) to stats structure
 	dnsStats        dns.StatsByKeyByNameByType
-	httpStatsDelta  map[http.Key]*http.RequestStats
+	usmStatsDelta   USMStats
 	lastTelemetries map[ConnTelemetryType]int64
+
+	// maxConns is the maximum number of connections returned to the client, or 0 if unlimited
+	maxConns int
+	// connsEvicted is the number of connections evicted since the telemetry was last returned to the client
+	connsEvicted int64
 }
 
 func (c *client) Reset(active map[uint32]*ConnectionStats) {
@@ -119,7 +181,7 @@ func (c *client) Reset(active map[uint32]*ConnectionStats) {
 	c.closedConnections = c.closedConnections[:0]
 	c.closedConnectionsKeys = make(map[uint32]int)
 	c.dnsStats = make(dns.StatsByKeyByNameByType)
-	c.httpStatsDelta = make(map[http.Key]*http.RequestStats)
+	c.usmStatsDelta = newUSMStats()
 
 	// XXX: we should change the way we clean this map once
 	// https://github.com/golang/go/issues/20135 is solved
@@ -143,24 +205,47 @@ type networkState struct {
 
 	latestTimeEpoch uint64
 
-	// Network state configuration
-	clientExpiry   time.Duration
-	maxClosedConns int
-	maxClientStats int
-	maxDNSStats    int
-	maxHTTPStats   int
+	// httpContainers are the containers of the recently seen connections, by HTTP key tuple
+	httpContainers map[http.KeyTuple]*httpContainers
+
+	config StateConfig
+}
+
+// StateConfig is the configuration of the network state
+type StateConfig struct {
+	// ClientExpiry is how long a client is kept once it last fetched its connections
+	ClientExpiry time.Duration
+	// MaxClosedConns is the maximum number of closed connections kept for each client
+	MaxClosedConns int
+	// MaxClientStats is the maximum number of connection stats kept for each client
+	MaxClientStats int
+	// MaxDNSStats is the maximum number of DNS stats kept for each client
+	MaxDNSStats int
+	// MaxHTTPStats is the maximum number of HTTP stats kept for each client, and of connections whose containers are
+	// remembered
+	MaxHTTPStats int
+	// The following are the maximum numbers of stats of the other USM protocols kept for each client
+	MaxKafkaStats     int
+	MaxPostgresStats  int
+	MaxMySQLStats     int
+	MaxRedisStats     int
+	MaxMongoStats     int
+	MaxAMQPStats      int
+	MaxGRPCStats      int
+	MaxHTTP3Stats     int
+	MaxCassandraStats int
+	MaxMemcachedStats int
+	// MaxClientConns is the default maximum number of connections returned to a client, or 0 if unlimited
+	MaxClientConns int
 }
 
 // NewState creates a new network state
-func NewState(clientExpiry time.Duration, maxClosedConns, maxClientStats int, maxDNSStats int, maxHTTPStats int) State {
+func NewState(config StateConfig) State {
 	return &networkState{
 		clients:        map[string]*client{},
 		telemetry:      telemetry{},
-		clientExpiry:   clientExpiry,
-		maxClosedConns: maxClosedConns,
-		maxClientStats: maxClientStats,
-		maxDNSStats:    maxDNSStats,
-		maxHTTPStats:   maxHTTPStats,
+		httpContainers: map[http.KeyTuple]*httpContainers{},
+		config:         config,
 	}
 }
 
@@ -200,7 +285,7 @@ func (ns *networkState) GetDelta(
 	latestTime uint64,
 	active []ConnectionStats,
 	dnsStats dns.StatsByKeyByNameByType,
-	httpStats map[http.Key]*http.RequestStats,
+	usmStats USMStats,
 ) Delta {
 	ns.Lock()
 	defer ns.Unlock()
@@ -215,22 +300,24 @@ func (ns *networkState) GetDelta(
 
 	// Update all connections with relevant up-to-date stats for client
 	ns.mergeConnections(id, connsByKey, clientBuffer)
+	ns.evictConnections(id, client, clientBuffer)
 
 	conns := clientBuffer.Connections()
 	ns.determineConnectionIntraHost(conns)
 	if len(dnsStats) > 0 {
 		ns.storeDNSStats(dnsStats)
 	}
-	if len(httpStats) > 0 {
-		ns.storeHTTPStats(httpStats)
+	if usmStats.HTTP != nil {
+		usmStats.HTTP = attachHTTPServices(conns, ns.attachHTTPContainers(conns, usmStats.HTTP))
 	}
+	ns.storeUSMStats(usmStats)
 
 	return Delta{
 		BufferedData: BufferedData{
 			Conns:  conns,
 			buffer: clientBuffer,
 		},
-		HTTP:     client.httpStatsDelta,
+		USMStats: client.usmStatsDelta,
 		DNSStats: client.dnsStats,
 	}
 }
@@ -264,6 +351,11 @@ func (ns *networkState) getTelemetryDelta(id string, telemetry map[ConnTelemetry
 		}
 	}
 
+	if client.connsEvicted > 0 {
+		res[ConnsEvictedMaxPerClient] = client.connsEvicted
+		client.connsEvicted = 0
+	}
+
 	for _, telType := range ConnTelemetryTypes {
 		if _, ok := client.lastTelemetries[telType]; ok {
 			res[telType] = client.lastTelemetries[telType]
@@ -283,12 +375,23 @@ func (ns *networkState) logTelemetry() {
 		timeSyncCollisions:    ns.telemetry.timeSyncCollisions - ns.lastTelemetry.timeSyncCollisions,
 		dnsStatsDropped:       ns.telemetry.dnsStatsDropped - ns.lastTelemetry.dnsStatsDropped,
 		httpStatsDropped:      ns.telemetry.httpStatsDropped - ns.lastTelemetry.httpStatsDropped,
+		kafkaStatsDropped:     ns.telemetry.kafkaStatsDropped - ns.lastTelemetry.kafkaStatsDropped,
+		postgresStatsDropped:  ns.telemetry.postgresStatsDropped - ns.lastTelemetry.postgresStatsDropped,
+		mysqlStatsDropped:     ns.telemetry.mysqlStatsDropped - ns.lastTelemetry.mysqlStatsDropped,
+		redisStatsDropped:     ns.telemetry.redisStatsDropped - ns.lastTelemetry.redisStatsDropped,
+		mongoStatsDropped:     ns.telemetry.mongoStatsDropped - ns.lastTelemetry.mongoStatsDropped,
+		amqpStatsDropped:      ns.telemetry.amqpStatsDropped - ns.lastTelemetry.amqpStatsDropped,
+		grpcStatsDropped:      ns.telemetry.grpcStatsDropped - ns.lastTelemetry.grpcStatsDropped,
+		http3StatsDropped:     ns.telemetry.http3StatsDropped - ns.lastTelemetry.http3StatsDropped,
+		cassandraStatsDropped: ns.telemetry.cassandraStatsDropped - ns.lastTelemetry.cassandraStatsDropped,
+		memcachedStatsDropped: ns.telemetry.memcachedStatsDropped - ns.lastTelemetry.memcachedStatsDropped,
 		dnsPidCollisions:      ns.telemetry.dnsPidCollisions - ns.lastTelemetry.dnsPidCollisions,
+		connsEvicted:          ns.telemetry.connsEvicted - ns.lastTelemetry.connsEvicted,
 	}
 
 	// Flush log line if any metric is non-zero
 	if delta.statsUnderflows > 0 || delta.statsCookieCollisions > 0 || delta.closedConnDropped > 0 || delta.connDropped > 0 || delta.timeSyncCollisions > 0 ||
-		delta.dnsStatsDropped > 0 || delta.httpStatsDropped > 0 || delta.dnsPidCollisions > 0 {
+		delta.dnsStatsDropped > 0 || delta.httpStatsDropped > 0 || delta.kafkaStatsDropped > 0 || delta.postgresStatsDropped > 0 || delta.mysqlStatsDropped > 0 || delta.redisStatsDropped > 0 || delta.mongoStatsDropped > 0 || delta.amqpStatsDropped > 0 || delta.grpcStatsDropped > 0 || delta.http3StatsDropped > 0 || delta.cassandraStatsDropped > 0 || delta.memcachedStatsDropped > 0 || delta.dnsPidCollisions > 0 || delta.connsEvicted > 0 {
 		s := "state telemetry: "
 		s += " [%d stats stats_underflows]"
 		s += " [%d stats cookie collisions]"
@@ -296,8 +399,19 @@ func (ns *networkState) logTelemetry() {
 		s += " [%d closed connections dropped]"
 		s += " [%d dns stats dropped]"
 		s += " [%d HTTP stats dropped]"
+		s += " [%d Kafka stats dropped]"
+		s += " [%d Postgres stats dropped]"
+		s += " [%d MySQL stats dropped]"
+		s += " [%d Redis stats dropped]"
+		s += " [%d Mongo stats dropped]"
+		s += " [%d AMQP stats dropped]"
+		s += " [%d GRPC stats dropped]"
+		s += " [%d HTTP/3 stats dropped]"
+		s += " [%d Cassandra stats dropped]"
+		s += " [%d memcached stats dropped]"
 		s += " [%d DNS pid collisions]"
 		s += " [%d time sync collisions]"
+		s += " [%d connections evicted due to the client limits]"
 		log.Warnf(s,
 			delta.statsUnderflows,
 			delta.statsCookieCollisions,
@@ -305,8 +419,19 @@ func (ns *networkState) logTelemetry() {
 			delta.closedConnDropped,
 			delta.dnsStatsDropped,
 			delta.httpStatsDropped,
+			delta.kafkaStatsDropped,
+			delta.postgresStatsDropped,
+			delta.mysqlStatsDropped,
+			delta.redisStatsDropped,
+			delta.mongoStatsDropped,
+			delta.amqpStatsDropped,
+			delta.grpcStatsDropped,
+			delta.http3StatsDropped,
+			delta.cassandraStatsDropped,
+			delta.memcachedStatsDropped,
 			delta.dnsPidCollisions,
-			delta.timeSyncCollisions)
+			delta.timeSyncCollisions,
+			delta.connsEvicted)
 	}
 
 	ns.lastTelemetry = ns.telemetry
@@ -366,7 +491,7 @@ func (ns *networkState) storeClosedConnections(conns []ConnectionStats) {
 				continue
 			}
 
-			if len(client.closedConnections) >= ns.maxClosedConns {
+			if len(client.closedConnections) >= ns.config.MaxClosedConns {
 				ns.telemetry.closedConnDropped++
 				continue
 			}
@@ -406,14 +531,14 @@ func (ns *networkState) storeDNSStats(stats dns.StatsByKeyByNameByType) {
 				for qtype, dnsStats := range statsByQtype {
 
 					if _, ok := client.dnsStats[key]; !ok {
-						if dnsStatsThisClient >= ns.maxDNSStats {
+						if dnsStatsThisClient >= ns.config.MaxDNSStats {
 							ns.telemetry.dnsStatsDropped++
 							continue
 						}
 						client.dnsStats[key] = make(map[dns.Hostname]map[dns.QueryType]dns.Stats)
 					}
 					if _, ok := client.dnsStats[key][domain]; !ok {
-						if dnsStatsThisClient >= ns.maxDNSStats {
+						if dnsStatsThisClient >= ns.config.MaxDNSStats {
 							ns.telemetry.dnsStatsDropped++
 							continue
 						}
@@ -430,7 +555,7 @@ func (ns *networkState) storeDNSStats(stats dns.StatsByKeyByNameByType) {
 						}
 						client.dnsStats[key][domain][qtype] = prev
 					} else {
-						if dnsStatsThisClient >= ns.maxDNSStats {
+						if dnsStatsThisClient >= ns.config.MaxDNSStats {
 							ns.telemetry.dnsStatsDropped++
 							continue
 						}
@@ -443,37 +568,178 @@ func (ns *networkState) storeDNSStats(stats dns.StatsByKeyByNameByType) {
 	}
 }
 
-// storeHTTPStats stores the latest HTTP stats for all clients
-func (ns *networkState) storeHTTPStats(allStats map[http.Key]*http.RequestStats) {
+// storeUSMStats stores the latest USM stats for all clients
+func (ns *networkState) storeUSMStats(stats USMStats) {
+	storeUSMStats(ns, stats.HTTP, func(c *client) *map[http.Key]*http.RequestStats { return &c.usmStatsDelta.HTTP }, ns.config.MaxHTTPStats, &ns.telemetry.httpStatsDropped)
+	storeUSMStats(ns, stats.Kafka, func(c *client) *map[kafka.Key]*kafka.RequestStat { return &c.usmStatsDelta.Kafka }, ns.config.MaxKafkaStats, &ns.telemetry.kafkaStatsDropped)
+	storeUSMStats(ns, stats.Postgres, func(c *client) *map[postgres.Key]*postgres.RequestStat { return &c.usmStatsDelta.Postgres }, ns.config.MaxPostgresStats, &ns.telemetry.postgresStatsDropped)
+	storeUSMStats(ns, stats.MySQL, func(c *client) *map[mysql.Key]*mysql.RequestStat { return &c.usmStatsDelta.MySQL }, ns.config.MaxMySQLStats, &ns.telemetry.mysqlStatsDropped)
+	storeUSMStats(ns, stats.Redis, func(c *client) *map[redis.Key]*redis.RequestStat { return &c.usmStatsDelta.Redis }, ns.config.MaxRedisStats, &ns.telemetry.redisStatsDropped)
+	storeUSMStats(ns, stats.Mongo, func(c *client) *map[mongo.Key]*mongo.RequestStat { return &c.usmStatsDelta.Mongo }, ns.config.MaxMongoStats, &ns.telemetry.mongoStatsDropped)
+	storeUSMStats(ns, stats.AMQP, func(c *client) *map[amqp.Key]*amqp.RequestStat { return &c.usmStatsDelta.AMQP }, ns.config.MaxAMQPStats, &ns.telemetry.amqpStatsDropped)
+	storeUSMStats(ns, stats.GRPC, func(c *client) *map[grpc.Key]*grpc.RequestStat { return &c.usmStatsDelta.GRPC }, ns.config.MaxGRPCStats, &ns.telemetry.grpcStatsDropped)
+	storeUSMStats(ns, stats.HTTP3, func(c *client) *map[http3.Key]*http3.RequestStat { return &c.usmStatsDelta.HTTP3 }, ns.config.MaxHTTP3Stats, &ns.telemetry.http3StatsDropped)
+	storeUSMStats(ns, stats.Cassandra, func(c *client) *map[cassandra.Key]*cassandra.RequestStat { return &c.usmStatsDelta.Cassandra }, ns.config.MaxCassandraStats, &ns.telemetry.cassandraStatsDropped)
+	storeUSMStats(ns, stats.Memcached, func(c *client) *map[memcached.Key]*memcached.RequestStat { return &c.usmStatsDelta.Memcached }, ns.config.MaxMemcachedStats, &ns.telemetry.memcachedStatsDropped)
+}
+
+// storeUSMStats stores the latest stats of a USM protocol for all clients. The stats of each client are combined in
+// place with the ones of the following calls, so a given stats object is only ever stored for a single client and the
+// other clients get a copy of it. Otherwise, the stats not yet fetched by one client would change when another client
+// fetches new ones. The stats of new keys are dropped once a client holds maxStats keys.
+func storeUSMStats[K comparable, V interface {
+	CombineWith(V)
+	Clone() V
+}](ns *networkState, allStats map[K]V, clientStats func(*client) *map[K]V, maxStats int, dropped *int64) {
+	if len(allStats) == 0 {
+		return
+	}
+
 	if len(ns.clients) == 1 {
 		for _, client := range ns.clients {
-			if len(client.httpStatsDelta) == 0 {
+			if delta := clientStats(client); len(*delta) == 0 {
 				// optimization for the common case:
 				// if there is only one client and no previous state, no memory allocation is needed
-				client.httpStatsDelta = allStats
+				*delta = allStats
 				return
 			}
 		}
 	}
 
 	for key, stats := range allStats {
+		stored := false
 		for _, client := range ns.clients {
-			prevStats, ok := client.httpStatsDelta[key]
-			if !ok && len(client.httpStatsDelta) >= ns.maxHTTPStats {
-				ns.telemetry.httpStatsDropped++
+			delta := *clientStats(client)
+			prevStats, ok := delta[key]
+			if !ok && len(delta) >= maxStats {
+				*dropped++
 				continue
 			}
 
-			if prevStats != nil {
+			if ok {
 				prevStats.CombineWith(stats)
-				client.httpStatsDelta[key] = prevStats
+			} else if !stored {
+				delta[key] = stats
+				stored = true
 			} else {
-				client.httpStatsDelta[key] = stats
+				delta[key] = stats.Clone()
 			}
 		}
 	}
 }
 
+// attachHTTPServices sets the Service of the HTTP stats keys whose client connection was translated to the server by
+// DNAT, using the conntrack data of the given connections. The stats of keys colliding once their Service is set are
+// combined.
+func attachHTTPServices(conns []ConnectionStats, stats map[http.Key]*http.RequestStats) map[http.Key]*http.RequestStats {
+	var services map[http.KeyTuple]http.ServiceTuple
+	for _, c := range conns {
+		addr, port, ok := GetNATService(c)
+		if !ok {
+			continue
+		}
+		if services == nil {
+			services = make(map[http.KeyTuple]http.ServiceTuple)
+		}
+
+		laddr, lport := GetNATLocalAddress(c)
+		raddr, rport := GetNATRemoteAddress(c)
+		services[http.NewKeyTuple(laddr, raddr, lport, rport)] = http.NewServiceTuple(addr, port)
+	}
+
+	if len(services) == 0 {
+		return stats
+	}
+
+	for key, keyStats := range stats {
+		service, ok := services[key.KeyTuple]
+		if !ok || key.Service == service {
+			continue
+		}
+
+		delete(stats, key)
+		key.Service = service
+		if prevStats, ok := stats[key]; ok {
+			prevStats.CombineWith(keyStats)
+			continue
+		}
+		stats[key] = keyStats
+	}
+	return stats
+}
+
+// httpContainerTTL is how long the containers of a connection are remembered once it was last seen, so that the HTTP
+// transactions completed after the connection was closed and reported are still attributed to its container
+const httpContainerTTL = 2 * time.Minute
+
+// httpContainers are the containers of the client and the server ends of the connections of an HTTP key tuple
+type httpContainers struct {
+	client, server string
+	lastSeen       uint64
+}
+
+// attachHTTPContainers sets the ContainerID of the HTTP stats keys from the containers of the processes of their
+// connections. The connections are remembered for httpContainerTTL, as the transactions of the short-lived connections
+// are often flushed after the connection was closed. When both ends are local, the server container is used as the
+// stats are those of its endpoints. The stats of keys colliding once their ContainerID is set are combined.
+func (ns *networkState) attachHTTPContainers(conns []ConnectionStats, stats map[http.Key]*http.RequestStats) map[http.Key]*http.RequestStats {
+	for _, c := range conns {
+		if c.ContainerID == nil || *c.ContainerID == "" {
+			continue
+		}
+
+		// the first tuple is the one of the connections whose local end is the client
+		for i, tuple := range HTTPKeyTuplesFromConn(c) {
+			containers, ok := ns.httpContainers[tuple]
+			if !ok {
+				if len(ns.httpContainers) >= ns.config.MaxHTTPStats {
+					continue
+				}
+				containers = &httpContainers{}
+				ns.httpContainers[tuple] = containers
+			}
+
+			if i == 0 {
+				containers.client = *c.ContainerID
+			} else {
+				containers.server = *c.ContainerID
+			}
+			if c.LastUpdateEpoch > containers.lastSeen {
+				containers.lastSeen = c.LastUpdateEpoch
+			}
+		}
+	}
+
+	for key, keyStats := range stats {
+		containers, ok := ns.httpContainers[key.KeyTuple]
+		if !ok {
+			continue
+		}
+		containerID := containers.server
+		if containerID == "" {
+			containerID = containers.client
+		}
+		if key.ContainerID == containerID {
+			continue
+		}
+
+		delete(stats, key)
+		key.ContainerID = containerID
+		if prevStats, ok := stats[key]; ok {
+			prevStats.CombineWith(keyStats)
+			continue
+		}
+		stats[key] = keyStats
+	}
+
+	for tuple, containers := range ns.httpContainers {
+		if ns.latestTimeEpoch > containers.lastSeen && ns.latestTimeEpoch-containers.lastSeen > uint64(httpContainerTTL.Nanoseconds()) {
+			delete(ns.httpContainers, tuple)
+		}
+	}
+	return stats
+}
+
 func (ns *networkState) getClient(clientID string) *client {
 	if c, ok := ns.clients[clientID]; ok {
 		return c
@@ -485,13 +751,45 @@ func (ns *networkState) getClient(clientID string) *client {
 		closedConnections:     make([]ConnectionStats, 0, minClosedCapacity),
 		closedConnectionsKeys: make(map[uint32]int),
 		dnsStats:              dns.StatsByKeyByNameByType{},
-		httpStatsDelta:        map[http.Key]*http.RequestStats{},
+		usmStatsDelta:         newUSMStats(),
 		lastTelemetries:       make(map[ConnTelemetryType]int64),
+		maxConns:              ns.config.MaxClientConns,
 	}
 	ns.clients[clientID] = c
 	return c
 }
 
+// SetClientMaxConnections sets the maximum number of connections returned to the given client, registering it if
+// needed
+func (ns *networkState) SetClientMaxConnections(id string, maxConns int) {
+	ns.Lock()
+	defer ns.Unlock()
+
+	if maxConns < 0 {
+		maxConns = 0
+	}
+	ns.getClient(id).maxConns = maxConns
+}
+
+// evictConnections removes the connections with the lowest traffic from the buffer of the client, when it holds more
+// connections than the limit of the client
+func (ns *networkState) evictConnections(id string, client *client, buffer *clientBuffer) {
+	conns := buffer.Connections()
+	if client.maxConns <= 0 || len(conns) <= client.maxConns {
+		return
+	}
+
+	sort.SliceStable(conns, func(i, j int) bool {
+		return conns[i].Last.SentBytes+conns[i].Last.RecvBytes > conns[j].Last.SentBytes+conns[j].Last.RecvBytes
+	})
+
+	evicted := len(conns) - client.maxConns
+	buffer.Reclaim(evicted)
+	client.connsEvicted += int64(evicted)
+	ns.telemetry.connsEvicted += int64(evicted)
+	log.Debugf("evicted %d connections with the lowest traffic for client %s, exceeding its limit of %d connections", evicted, id, client.maxConns)
+}
+
 // mergeConnections return the connections and takes care of updating their last stat counters
 func (ns *networkState) mergeConnections(id string, active map[uint32]*ConnectionStats, buffer *clientBuffer) {
 	now := time.Now()
@@ -569,7 +867,7 @@ func (ns *networkState) updateConnWithStats(client *client, cookie uint32, c *Co
 // createStatsForCookie will create a new stats object for a key if it doesn't already exist.
 func (ns *networkState) createStatsForCookie(client *client, cookie uint32) {
 	if _, ok := client.stats[cookie]; !ok {
-		if len(client.stats) >= ns.maxClientStats {
+		if len(client.stats) >= ns.config.MaxClientStats {
 			ns.telemetry.connDropped++
 			return
 		}
@@ -590,7 +888,7 @@ func (ns *networkState) RemoveExpiredClients(now time.Time) {
 	defer ns.Unlock()
 
 	for id, c := range ns.clients {
-		if c.lastFetch.Add(ns.clientExpiry).Before(now) {
+		if c.lastFetch.Add(ns.config.ClientExpiry).Before(now) {
 			log.Debugf("expiring client: %s, had %d stats and %d closed connections", id, len(c.stats), len(c.closedConnections))
 			delete(ns.clients, id)
 			clientPool.RemoveExpiredClient(id)
@@ -620,6 +918,7 @@ func (ns *networkState) GetStats() map[string]interface{} {
 			"stats":              len(c.stats),
 			"closed_connections": len(c.closedConnections),
 			"last_fetch":         int(c.lastFetch.Unix()),
+			"max_connections":    c.maxConns,
 		}
 	}
 
@@ -633,7 +932,18 @@ func (ns *networkState) GetStats() map[string]interface{} {
 			"time_sync_collisions":    ns.telemetry.timeSyncCollisions,
 			"dns_stats_dropped":       ns.telemetry.dnsStatsDropped,
 			"http_stats_dropped":      ns.telemetry.httpStatsDropped,
+			"kafka_stats_dropped":     ns.telemetry.kafkaStatsDropped,
+			"postgres_stats_dropped":  ns.telemetry.postgresStatsDropped,
+			"mysql_stats_dropped":     ns.telemetry.mysqlStatsDropped,
+			"redis_stats_dropped":     ns.telemetry.redisStatsDropped,
+			"mongo_stats_dropped":     ns.telemetry.mongoStatsDropped,
+			"amqp_stats_dropped":      ns.telemetry.amqpStatsDropped,
+			"grpc_stats_dropped":      ns.telemetry.grpcStatsDropped,
+			"http3_stats_dropped":     ns.telemetry.http3StatsDropped,
+			"cassandra_stats_dropped": ns.telemetry.cassandraStatsDropped,
+			"memcached_stats_dropped": ns.telemetry.memcachedStatsDropped,
 			"dns_pid_collisions":      ns.telemetry.dnsPidCollisions,
+			"conns_evicted":           ns.telemetry.connsEvicted,
 		},
 		"current_time":       time.Now().Unix(),
 		"latest_bpf_time_ns": ns.latestTimeEpoch,
@@ -652,6 +962,7 @@ func (ns *networkState) DumpState(clientID string) map[string]interface{} {
 				"total_sent":            s.SentBytes,
 				"total_recv":            s.RecvBytes,
 				"total_retransmits":     uint64(s.Retransmits),
+				"total_tcp_drops":       uint64(s.TCPDrops),
 				"total_tcp_established": uint64(s.TCPEstablished),
 				"total_tcp_closed":      uint64(s.TCPClosed),
 			}
@@ -721,8 +1032,9 @@ func (ns *networkState) determineConnectionIntraHost(connections []ConnectionSta
 type connectionAggregator struct {
 	conns map[string]*struct {
 		*ConnectionStats
-		rttSum, rttVarSum uint64
-		count             uint32
+		rttSum, rttVarSum                uint64
+		recvQueueAvgSum, sendQueueAvgSum uint64
+		count                            uint32
 	}
 	buf []byte
 }
@@ -731,9 +1043,9 @@ func newConnectionAggregator(size int) *connectionAggregator {
 	return &connectionAggregator{
 		conns: make(map[string]*struct {
 			*ConnectionStats
-			rttSum    uint64
-			rttVarSum uint64
-			count     uint32
+			rttSum, rttVarSum                uint64
+			recvQueueAvgSum, sendQueueAvgSum uint64
+			count                            uint32
 		}, size),
 		buf: make([]byte, ConnectionByteKeyMaxLen),
 	}
@@ -752,13 +1064,15 @@ func (a *connectionAggregator) Aggregate(c *ConnectionStats) bool {
 	if !ok {
 		a.conns[key] = &struct {
 			*ConnectionStats
-			rttSum    uint64
-			rttVarSum uint64
-			count     uint32
+			rttSum, rttVarSum                uint64
+			recvQueueAvgSum, sendQueueAvgSum uint64
+			count                            uint32
 		}{
 			ConnectionStats: c,
 			rttSum:          uint64(c.RTT),
 			rttVarSum:       uint64(c.RTTVar),
+			recvQueueAvgSum: uint64(c.RecvQueueAvg),
+			sendQueueAvgSum: uint64(c.SendQueueAvg),
 			count:           1,
 		}
 
@@ -775,7 +1089,21 @@ func (a *connectionAggregator) Aggregate(c *ConnectionStats) bool {
 	aggrConn.Last = aggrConn.Last.Add(c.Last)
 	aggrConn.rttSum += uint64(c.RTT)
 	aggrConn.rttVarSum += uint64(c.RTTVar)
+	aggrConn.recvQueueAvgSum += uint64(c.RecvQueueAvg)
+	aggrConn.sendQueueAvgSum += uint64(c.SendQueueAvg)
+	if c.RecvQueueMax > aggrConn.RecvQueueMax {
+		aggrConn.RecvQueueMax = c.RecvQueueMax
+	}
+	if c.SendQueueMax > aggrConn.SendQueueMax {
+		aggrConn.SendQueueMax = c.SendQueueMax
+	}
 	aggrConn.count++
+	// keep the congestion state of the most recently closed connection
+	if c.CongestionAlgorithm != "" && (aggrConn.CongestionAlgorithm == "" || aggrConn.LastUpdateEpoch < c.LastUpdateEpoch) {
+		aggrConn.CongestionAlgorithm = c.CongestionAlgorithm
+		aggrConn.SndCwnd = c.SndCwnd
+		aggrConn.SndSsthresh = c.SndSsthresh
+	}
 	if aggrConn.LastUpdateEpoch < c.LastUpdateEpoch {
 		aggrConn.LastUpdateEpoch = c.LastUpdateEpoch
 	}
@@ -787,12 +1115,14 @@ func (a *connectionAggregator) Aggregate(c *ConnectionStats) bool {
 }
 
 // WriteTo writes the aggregated connections to a clientBuffer,
-// computing an average for RTT and RTTVar for each
-// connection
+// computing an average for RTT, RTTVar and the queue
+// lengths for each connection
 func (a connectionAggregator) WriteTo(buffer *clientBuffer) {
 	for _, c := range a.conns {
 		c.RTT = uint32(c.rttSum / uint64(c.count))
 		c.RTTVar = uint32(c.rttVarSum / uint64(c.count))
+		c.RecvQueueAvg = uint32(c.recvQueueAvgSum / uint64(c.count))
+		c.SendQueueAvg = uint32(c.sendQueueAvgSum / uint64(c.count))
 		*buffer.Next() = *c.ConnectionStats
 	}
 }
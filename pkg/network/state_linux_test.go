@@ -37,7 +37,7 @@ func TestStatsOverflow(t *testing.T) {
 	state.RegisterClient(client)
 
 	// Get the connections once to register stats
-	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 	require.Len(t, conns, 1)
 
 	// Expect Last.SentPackets to be math.MaxUint32-1
@@ -50,8 +50,73 @@ func TestStatsOverflow(t *testing.T) {
 	conn.Monotonic.SentPackets = 10
 	conn.Monotonic.RecvPackets = 11
 
-	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 	require.Len(t, conns, 1)
 	assert.Equal(t, uint64(12), conns[0].Last.SentPackets)
 	assert.Equal(t, uint64(14), conns[0].Last.RecvPackets)
 }
+
+func TestLastTCPDrops(t *testing.T) {
+	client := "1"
+	state := newDefaultState()
+	state.RegisterClient(client)
+
+	conn := ConnectionStats{
+		Pid:       123,
+		Type:      TCP,
+		Family:    AFINET,
+		Source:    util.AddressFromString("127.0.0.1"),
+		Dest:      util.AddressFromString("127.0.0.1"),
+		SPort:     31890,
+		DPort:     80,
+		Monotonic: StatCounters{SentBytes: 36, TCPDrops: 3},
+	}
+
+	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
+	require.Len(t, conns, 1)
+	assert.Equal(t, uint32(3), conns[0].Last.TCPDrops)
+
+	conn.Monotonic.SentBytes += 42
+	conn.Monotonic.TCPDrops += 5
+	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
+	require.Len(t, conns, 1)
+	assert.Equal(t, uint32(5), conns[0].Last.TCPDrops)
+	assert.Equal(t, uint32(8), conns[0].Monotonic.TCPDrops)
+}
+
+func TestLastTLSBytes(t *testing.T) {
+	client := "1"
+	state := newDefaultState()
+	state.RegisterClient(client)
+
+	// a STARTTLS connection: the first bytes are sent in plaintext before the TLS handshake
+	conn := ConnectionStats{
+		Pid:       123,
+		Type:      TCP,
+		Family:    AFINET,
+		Source:    util.AddressFromString("127.0.0.1"),
+		Dest:      util.AddressFromString("127.0.0.1"),
+		SPort:     31890,
+		DPort:     25,
+		Monotonic: StatCounters{SentBytes: 100},
+	}
+
+	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
+	require.Len(t, conns, 1)
+	assert.Zero(t, conns[0].Last.TLSBytes)
+
+	conn.Monotonic.SentBytes += 500
+	conn.Monotonic.TLSBytes = 450
+	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
+	require.Len(t, conns, 1)
+	assert.Equal(t, uint64(450), conns[0].Last.TLSBytes)
+	assert.Equal(t, uint64(500), conns[0].Last.SentBytes)
+
+	// the entry of the TLS hooks was evicted, the bytes already reported are kept
+	conn.Monotonic.SentBytes += 10
+	conn.Monotonic.TLSBytes = 0
+	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
+	require.Len(t, conns, 1)
+	assert.Zero(t, conns[0].Last.TLSBytes)
+	assert.Equal(t, uint64(10), conns[0].Last.SentBytes)
+}
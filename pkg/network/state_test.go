@@ -19,7 +19,17 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/DataDog/datadog-agent/pkg/network/dns"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/cassandra"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/grpc"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http3"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/kafka"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/memcached"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
@@ -84,7 +94,7 @@ func BenchmarkConnectionsGet(b *testing.B) {
 			ns := newDefaultState()
 
 			// Initial fetch to set up client
-			ns.GetDelta(DEBUGCLIENT, latestTime.Load(), nil, nil, nil)
+			ns.GetDelta(DEBUGCLIENT, latestTime.Load(), nil, nil, USMStats{})
 
 			for _, c := range closed[:bench.closedCount] {
 				ns.StoreClosedConnections([]ConnectionStats{c})
@@ -94,7 +104,7 @@ func BenchmarkConnectionsGet(b *testing.B) {
 			b.ReportAllocs()
 
 			for n := 0; n < b.N; n++ {
-				ns.GetDelta(DEBUGCLIENT, latestTime.Load(), conns[:bench.connCount], nil, nil)
+				ns.GetDelta(DEBUGCLIENT, latestTime.Load(), conns[:bench.connCount], nil, USMStats{})
 			}
 		})
 	}
@@ -125,10 +135,10 @@ func TestRemoveConnections(t *testing.T) {
 
 	clientID := "1"
 	state := newDefaultState()
-	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, USMStats{}).Conns
 	assert.Equal(t, 0, len(conns))
 
-	conns = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, conn, conns[0])
 
@@ -167,7 +177,7 @@ func TestRetrieveClosedConnection(t *testing.T) {
 	t.Run("without prior registration", func(t *testing.T) {
 		state := newDefaultState()
 		state.StoreClosedConnections([]ConnectionStats{conn})
-		conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, USMStats{}).Conns
 
 		assert.Equal(t, 0, len(conns))
 	})
@@ -179,16 +189,16 @@ func TestRetrieveClosedConnection(t *testing.T) {
 
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
-		conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, conn, conns[0])
 
 		// An other client that is not registered should not have the closed connection
-		conns = state.GetDelta("2", latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta("2", latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// It should no more have connections stored
-		conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 	})
 }
@@ -276,7 +286,7 @@ func TestNoPriorRegistrationActiveConnections(t *testing.T) {
 		Cookie: 0,
 	}
 
-	delta := state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil)
+	delta := state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{})
 	require.NotEmpty(t, delta.Conns)
 	require.Equal(t, 1, len(delta.Conns))
 }
@@ -284,7 +294,23 @@ func TestNoPriorRegistrationActiveConnections(t *testing.T) {
 func TestCleanupClient(t *testing.T) {
 	clientID := "1"
 
-	state := NewState(100*time.Millisecond, 50000, 75000, 75000, 75000)
+	state := NewState(StateConfig{
+		ClientExpiry:      100 * time.Millisecond,
+		MaxClosedConns:    50000,
+		MaxClientStats:    75000,
+		MaxDNSStats:       75000,
+		MaxHTTPStats:      75000,
+		MaxKafkaStats:     75000,
+		MaxPostgresStats:  75000,
+		MaxMySQLStats:     75000,
+		MaxRedisStats:     75000,
+		MaxMongoStats:     75000,
+		MaxAMQPStats:      75000,
+		MaxGRPCStats:      75000,
+		MaxHTTP3Stats:     75000,
+		MaxCassandraStats: 75000,
+		MaxMemcachedStats: 75000,
+	})
 	clients := state.(*networkState).getClients()
 	assert.Equal(t, 0, len(clients))
 
@@ -345,15 +371,15 @@ func TestLastStats(t *testing.T) {
 	state.RegisterClient(client2)
 
 	// First get, we should not have any connections stored
-	conns := state.GetDelta(client1, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(client1, latestEpochTime(), nil, nil, USMStats{}).Conns
 	assert.Equal(t, 0, len(conns))
 
 	// Same for an other client
-	conns = state.GetDelta(client2, latestEpochTime(), nil, nil, nil).Conns
+	conns = state.GetDelta(client2, latestEpochTime(), nil, nil, USMStats{}).Conns
 	assert.Equal(t, 0, len(conns))
 
 	// We should have only one connection but with last stats equal to monotonic
-	conns = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, conn.Monotonic.SentBytes, conns[0].Last.SentBytes)
 	assert.Equal(t, conn.Monotonic.RecvBytes, conns[0].Last.RecvBytes)
@@ -363,7 +389,7 @@ func TestLastStats(t *testing.T) {
 	assert.Equal(t, conn.Monotonic.Retransmits, conns[0].Monotonic.Retransmits)
 
 	// This client didn't collect the first connection so last stats = monotonic
-	conns = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{conn2}, nil, nil).Conns
+	conns = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{conn2}, nil, USMStats{}).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, conn2.Monotonic.SentBytes, conns[0].Last.SentBytes)
 	assert.Equal(t, conn2.Monotonic.RecvBytes, conns[0].Last.RecvBytes)
@@ -373,7 +399,7 @@ func TestLastStats(t *testing.T) {
 	assert.Equal(t, conn2.Monotonic.Retransmits, conns[0].Monotonic.Retransmits)
 
 	// client 1 should have conn3 - conn1 since it did not collected conn2
-	conns = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{conn3}, nil, nil).Conns
+	conns = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{conn3}, nil, USMStats{}).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, 2*dSent, conns[0].Last.SentBytes)
 	assert.Equal(t, 2*dRecv, conns[0].Last.RecvBytes)
@@ -383,7 +409,7 @@ func TestLastStats(t *testing.T) {
 	assert.Equal(t, conn3.Monotonic.Retransmits, conns[0].Monotonic.Retransmits)
 
 	// client 2 should have conn3 - conn2
-	conns = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{conn3}, nil, nil).Conns
+	conns = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{conn3}, nil, USMStats{}).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, dSent, conns[0].Last.SentBytes)
 	assert.Equal(t, dRecv, conns[0].Last.RecvBytes)
@@ -426,11 +452,11 @@ func TestLastStatsForClosedConnection(t *testing.T) {
 	state.RegisterClient(clientID)
 
 	// First get, we should not have any connections stored
-	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(clientID, latestEpochTime(), nil, nil, USMStats{}).Conns
 	assert.Equal(t, 0, len(conns))
 
 	// We should have one connection with last stats equal to monotonic stats
-	conns = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(clientID, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, conn.Monotonic.SentBytes, conns[0].Last.SentBytes)
 	assert.Equal(t, conn.Monotonic.RecvBytes, conns[0].Last.RecvBytes)
@@ -442,7 +468,7 @@ func TestLastStatsForClosedConnection(t *testing.T) {
 	state.StoreClosedConnections([]ConnectionStats{conn2})
 
 	// We should have one connection with last stats
-	conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, nil).Conns
+	conns = state.GetDelta(clientID, latestEpochTime(), nil, nil, USMStats{}).Conns
 
 	assert.Equal(t, 1, len(conns))
 	assert.Equal(t, dSent, conns[0].Last.SentBytes)
@@ -495,7 +521,7 @@ func TestRaceConditions(t *testing.T) {
 				case <-timer.C:
 					return
 				default:
-					state.GetDelta(c, latestEpochTime(), genConns(nConns), nil, nil)
+					state.GetDelta(c, latestEpochTime(), genConns(nConns), nil, USMStats{})
 				}
 			}
 		}(fmt.Sprintf("%d", i))
@@ -538,14 +564,14 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.RegisterClient(client)
 
 		// First get, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as closed
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
 		// Second get, we should have monotonic and last stats = 3
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -573,7 +599,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.RegisterClient(client)
 
 		// First get, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as closed
@@ -587,7 +613,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn2})
 
 		// Second get, we should have monotonic and last stats = 8
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 8, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 8, int(conns[0].Last.SentBytes))
@@ -617,7 +643,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.RegisterClient(client)
 
 		// First get for client c, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Len(t, conns, 0)
 
 		conn := ConnectionStats{
@@ -633,7 +659,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		}
 
 		// Simulate this connection starting
-		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 		require.Len(t, conns, 1)
 		assert.EqualValues(t, 1, conns[0].Last.SentBytes)
 		assert.EqualValues(t, 1, conns[0].Monotonic.SentBytes)
@@ -651,7 +677,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		conn2.Cookie = 2
 		conn2.LastUpdateEpoch = latestEpochTime()
 		// Retrieve the connections
-		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn2}, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn2}, nil, USMStats{}).Conns
 		require.Len(t, conns, 2)
 		assert.EqualValues(t, uint64(1), conns[0].Last.SentBytes)
 		assert.EqualValues(t, uint64(2), conns[0].Monotonic.SentBytes)
@@ -664,7 +690,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		// Store the connection as closed
 		state.StoreClosedConnections([]ConnectionStats{conn2})
 
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		require.Len(t, conns, 1)
 		assert.EqualValues(t, 1, conns[0].Last.SentBytes)
 		assert.EqualValues(t, 2, conns[0].Monotonic.SentBytes)
@@ -693,7 +719,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.RegisterClient(client)
 
 		// First get, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as closed
@@ -707,7 +733,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs := []ConnectionStats{conn2}
 
 		// Second get, we should have monotonic and last stats = 5
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, USMStats{}).Conns
 		require.Equal(t, 2, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -730,7 +756,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn3}
 
 		// Third get, we should have monotonic = 6 and last stats = 4
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 2, len(conns))
 		assert.Equal(t, 5, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -745,7 +771,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn3})
 
 		// 4th get, we should have monotonic = 3 and last stats = 2
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 2, int(conns[0].Last.SentBytes))
@@ -772,14 +798,14 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.RegisterClient(client)
 
 		// First get we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as opened
 		cs := []ConnectionStats{conn}
 
 		// First get, we should have monotonic = 3 and last seen = 3
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -792,7 +818,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn2})
 
 		// Second get, we should have monotonic = 8 and last stats = 5
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 8, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 5, int(conns[0].Last.SentBytes))
@@ -836,18 +862,18 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.RegisterClient(client)
 
 		// First get for client c, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// First get for client d, we should have nothing
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection as closed
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
 		// Second get for client d we should have monotonic and last stats = 3
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -861,7 +887,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs := []ConnectionStats{conn2}
 
 		// Second get, for client c we should have monotonic and last stats = 5
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 2, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -876,7 +902,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn2}
 
 		// Third get, for client d we should have monotonic = 3 and last stats = 3
-		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -896,7 +922,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn3}
 
 		// Third get, for client c, we should have monotonic = 6 and last stats = 4
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 2, len(conns))
 		assert.Equal(t, 5, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -911,7 +937,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn3}
 
 		// 4th get, for client d, we should have monotonic = 7 and last stats = 4
-		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 2, len(conns))
 		assert.Equal(t, 5, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 2, int(conns[0].Last.SentBytes))
@@ -926,14 +952,14 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn3})
 
 		// 4th get, for client c we should have monotonic = 3 and last stats = 2
-		conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 2, int(conns[0].Last.SentBytes))
 		assert.Empty(t, state.clients["c"].stats)
 
 		// 5th get, for client d we should have monotonic = 3 and last stats = 1
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 1, int(conns[0].Last.SentBytes))
@@ -987,15 +1013,15 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.RegisterClient(clientE)
 
 		// First get for client c, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// First get for client d, we should have nothing
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// First get for client e, we should have nothing
-		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Store the connection
@@ -1004,7 +1030,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs := []ConnectionStats{conn}
 
 		// Second get for client e we should have monotonic and last stats = 2
-		conns = state.GetDelta(clientE, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(clientE, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 2, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 2, int(conns[0].Last.SentBytes))
@@ -1017,14 +1043,14 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn})
 
 		// Second get for client d we should have monotonic and last stats = 3
-		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
 		assert.Empty(t, state.clients["d"].stats)
 
 		// Third get for client e we should have monotonic = 3and last stats = 1
-		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 1, int(conns[0].Last.SentBytes))
@@ -1038,7 +1064,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn2}
 
 		// Second get, for client c we should have monotonic and last stats = 5
-		conns = state.GetDelta(client, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 2, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -1053,7 +1079,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		cs = []ConnectionStats{conn2}
 
 		// Third get, for client d we should have monotonic = 3 and last stats = 3
-		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), cs, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -1066,7 +1092,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state.StoreClosedConnections([]ConnectionStats{conn2})
 
 		// 4th get, for client e we should have monotonic = 5 and last stats = 5
-		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, nil).Conns
+		conns = state.GetDelta(clientE, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 1, len(conns))
 		assert.Equal(t, 5, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 5, int(conns[0].Last.SentBytes))
@@ -1103,11 +1129,11 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		state := newDefaultState()
 
 		// First get for client c, we should have nothing
-		conns := state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+		conns := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 		assert.Equal(t, 0, len(conns))
 
 		// Second get for client c we should have monotonic and last stats = 3
-		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 		assert.Len(t, conns, 1)
 		assert.Equal(t, 3, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 3, int(conns[0].Last.SentBytes))
@@ -1119,7 +1145,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		conn2.LastUpdateEpoch++
 
 		// First get for client d we should have monotonic = 4 and last bytes = 4
-		conns = state.GetDelta(clientD, latestEpochTime(), []ConnectionStats{conn2}, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), []ConnectionStats{conn2}, nil, USMStats{}).Conns
 		assert.Len(t, conns, 1)
 		assert.Equal(t, 4, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 4, int(conns[0].Last.SentBytes))
@@ -1131,7 +1157,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		conn3.LastUpdateEpoch++
 
 		// Third get for client c we should have monotonic = 7 and last bytes = 4
-		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn3}, nil, nil).Conns
+		conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn3}, nil, USMStats{}).Conns
 		assert.Len(t, conns, 1)
 		assert.Equal(t, 7, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 4, int(conns[0].Last.SentBytes))
@@ -1143,7 +1169,7 @@ func TestSameKeyEdgeCases(t *testing.T) {
 		conn4.LastUpdateEpoch++
 
 		// Second get for client d we should have monotonic = 9 and last bytes = 5
-		conns = state.GetDelta(clientD, latestEpochTime(), []ConnectionStats{conn4}, nil, nil).Conns
+		conns = state.GetDelta(clientD, latestEpochTime(), []ConnectionStats{conn4}, nil, USMStats{}).Conns
 		assert.Len(t, conns, 1)
 		assert.Equal(t, 9, int(conns[0].Monotonic.SentBytes))
 		assert.Equal(t, 5, int(conns[0].Last.SentBytes))
@@ -1171,7 +1197,7 @@ func TestStatsResetOnUnderflow(t *testing.T) {
 	state.RegisterClient(client)
 
 	// Get the connections once to register stats
-	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 	require.Len(t, conns, 1)
 
 	// Expect LastStats to be 3
@@ -1181,7 +1207,7 @@ func TestStatsResetOnUnderflow(t *testing.T) {
 	// Get the connections again but by simulating an underflow
 	conn.Monotonic.SentBytes--
 
-	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns = state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 	require.Len(t, conns, 0) // dropped because last stats are zero
 }
 
@@ -1212,12 +1238,12 @@ func TestDoubleCloseOnTwoClients(t *testing.T) {
 	state.StoreClosedConnections([]ConnectionStats{conn})
 
 	// Get the connections for client1 we should have only one with stats counted only once
-	conns := state.GetDelta(client1, latestEpochTime(), nil, nil, nil).Conns
+	conns := state.GetDelta(client1, latestEpochTime(), nil, nil, USMStats{}).Conns
 	require.Len(t, conns, 1)
 	assert.Equal(t, conn, conns[0])
 
 	// Same for client2
-	conns = state.GetDelta(client2, latestEpochTime(), nil, nil, nil).Conns
+	conns = state.GetDelta(client2, latestEpochTime(), nil, nil, USMStats{}).Conns
 	require.Len(t, conns, 1)
 	assert.Equal(t, conn, conns[0])
 }
@@ -1248,22 +1274,22 @@ func TestUnorderedCloseEvent(t *testing.T) {
 	conn.LastUpdateEpoch--
 	conn.Monotonic.SentBytes--
 	conn.Monotonic.RecvBytes = 0
-	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, nil).Conns
+	conns := state.GetDelta(client, latestEpochTime(), []ConnectionStats{conn}, nil, USMStats{}).Conns
 	require.Len(t, conns, 1)
 	assert.EqualValues(t, 4, conns[0].Last.SentBytes)
 	assert.EqualValues(t, 1, conns[0].Last.RecvBytes)
 
 	// Simulate some other gets
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns, 0)
 
 	// Simulate having the connection getting active again
 	conn.LastUpdateEpoch = latestEpochTime()
 	conn.Monotonic.SentBytes--
 	state.StoreClosedConnections([]ConnectionStats{conn})
 
-	conns = state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns
+	conns = state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns
 	require.Len(t, conns, 1)
 	assert.EqualValues(t, 2, conns[0].Last.SentBytes)
 	assert.EqualValues(t, 0, conns[0].Last.RecvBytes)
@@ -1271,7 +1297,7 @@ func TestUnorderedCloseEvent(t *testing.T) {
 	// Ensure we don't have underflows / unordered conns
 	assert.Zero(t, state.telemetry.statsUnderflows)
 
-	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{}).Conns, 0)
 }
 
 func TestAggregateClosedConnectionsTimestamp(t *testing.T) {
@@ -1300,7 +1326,7 @@ func TestAggregateClosedConnectionsTimestamp(t *testing.T) {
 	state.StoreClosedConnections([]ConnectionStats{conn})
 
 	// Make sure the connections we get has the latest timestamp
-	delta := state.GetDelta(client, latestEpochTime(), nil, nil, nil)
+	delta := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{})
 	assert.Equal(t, conn.LastUpdateEpoch, delta.Conns[0].LastUpdateEpoch)
 }
 
@@ -1353,28 +1379,28 @@ func TestDNSStatsWithMultipleClients(t *testing.T) {
 	state.RegisterClient(client2)
 
 	// We should have nothing on first call
-	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, nil).Conns, 0)
-	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, nil).Conns, 0)
+	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, USMStats{}).Conns, 0)
+	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, USMStats{}).Conns, 0)
 
 	c.Monotonic = StatCounters{SentBytes: 100, RecvBytes: 200}
 	c.Cookie = 1
 	c.LastUpdateEpoch = latestEpochTime()
 
-	delta := state.GetDelta(client1, latestEpochTime(), []ConnectionStats{c}, getStats(), nil)
+	delta := state.GetDelta(client1, latestEpochTime(), []ConnectionStats{c}, getStats(), USMStats{})
 	require.Len(t, delta.Conns, 1)
 
 	rcode := getRCodeFrom(delta, delta.Conns[0], "foo.com", dns.TypeA, DNSResponseCodeNoError)
 	assert.EqualValues(t, 1, rcode)
 
 	// Register the third client but also pass in dns stats
-	delta = state.GetDelta(client3, latestEpochTime(), []ConnectionStats{c}, getStats(), nil)
+	delta = state.GetDelta(client3, latestEpochTime(), []ConnectionStats{c}, getStats(), USMStats{})
 	require.Len(t, delta.Conns, 1)
 
 	// DNS stats should be available for the new client
 	rcode = getRCodeFrom(delta, delta.Conns[0], "foo.com", dns.TypeA, DNSResponseCodeNoError)
 	assert.EqualValues(t, 1, rcode)
 
-	delta = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{c}, getStats(), nil)
+	delta = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{c}, getStats(), USMStats{})
 	require.Len(t, delta.Conns, 1)
 
 	// 2nd client should get accumulated stats
@@ -1398,16 +1424,416 @@ func TestHTTPStats(t *testing.T) {
 
 	// Register client & pass in HTTP stats
 	state := newDefaultState()
-	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, httpStats)
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{HTTP: httpStats})
 
 	// Verify connection has HTTP data embedded in it
 	assert.Len(t, delta.HTTP, 1)
 
 	// Verify HTTP data has been flushed
-	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, nil)
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
 	assert.Len(t, delta.HTTP, 0)
 }
 
+func TestKafkaStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  9092,
+	}
+
+	getStats := func(count int) map[kafka.Key]*kafka.RequestStat {
+		key := kafka.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "my-topic", kafka.ProduceAPIKey, 8)
+		rs := new(kafka.RequestStat)
+		for i := 0; i < count; i++ {
+			rs.AddRequest(10)
+		}
+		return map[kafka.Key]*kafka.RequestStat{key: rs}
+	}
+
+	client1 := "client1"
+	client2 := "client2"
+	state := newDefaultState()
+	state.RegisterClient(client1)
+	state.RegisterClient(client2)
+
+	// Pass in Kafka stats to the first client
+	delta := state.GetDelta(client1, latestEpochTime(), []ConnectionStats{c}, nil, USMStats{Kafka: getStats(2)})
+	require.Len(t, delta.Kafka, 1)
+	for _, stats := range delta.Kafka {
+		assert.Equal(t, 2, stats.Count)
+	}
+
+	// Verify Kafka data has been flushed for the first client
+	delta = state.GetDelta(client1, latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.Kafka, 0)
+
+	// The second client gets the stats combined with the new ones
+	delta = state.GetDelta(client2, latestEpochTime(), []ConnectionStats{c}, nil, USMStats{Kafka: getStats(3)})
+	require.Len(t, delta.Kafka, 1)
+	for _, stats := range delta.Kafka {
+		assert.Equal(t, 5, stats.Count)
+		assert.Equal(t, 5, stats.LatencyCount)
+	}
+}
+
+func TestPostgresStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  5432,
+	}
+
+	key := postgres.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "SELECT * FROM dummy WHERE id = ?")
+	rs := new(postgres.RequestStat)
+	rs.AddRequest(10, 1)
+	pgStats := map[postgres.Key]*postgres.RequestStat{key: rs}
+
+	// Register client & pass in Postgres stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{Postgres: pgStats})
+
+	// Verify connection has Postgres data embedded in it
+	require.Len(t, delta.Postgres, 1)
+	assert.Equal(t, 1, delta.Postgres[key].Rows)
+
+	// Verify Postgres data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.Postgres, 0)
+}
+
+func TestMySQLStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  3306,
+	}
+
+	key := mysql.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "SELECT * FROM dummy WHERE id = ?")
+	rs := new(mysql.RequestStat)
+	rs.AddRequest(10, 0)
+	rs.AddRequest(20, 1146)
+	mysqlStats := map[mysql.Key]*mysql.RequestStat{key: rs}
+
+	// Register client & pass in MySQL stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{MySQL: mysqlStats})
+
+	// Verify connection has MySQL data embedded in it
+	require.Len(t, delta.MySQL, 1)
+	assert.Equal(t, 2, delta.MySQL[key].Count)
+	assert.Equal(t, map[uint16]int{1146: 1}, delta.MySQL[key].Errors)
+
+	// Verify MySQL data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.MySQL, 0)
+}
+
+func TestRedisStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  6379,
+	}
+
+	key := redis.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "GET")
+	rs := new(redis.RequestStat)
+	rs.AddRequest(10, false)
+	rs.AddRequest(20, true)
+	redisStats := map[redis.Key]*redis.RequestStat{key: rs}
+
+	// Register client & pass in Redis stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{Redis: redisStats})
+
+	// Verify connection has Redis data embedded in it
+	require.Len(t, delta.Redis, 1)
+	assert.Equal(t, 2, delta.Redis[key].Count)
+	assert.Equal(t, 1, delta.Redis[key].ErrorCount)
+
+	// Verify Redis data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.Redis, 0)
+}
+
+func TestMongoStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  27017,
+	}
+
+	key := mongo.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "find", "dummy")
+	rs := new(mongo.RequestStat)
+	rs.AddRequest(10, false)
+	rs.AddRequest(20, true)
+	mongoStats := map[mongo.Key]*mongo.RequestStat{key: rs}
+
+	// Register client & pass in Mongo stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{Mongo: mongoStats})
+
+	// Verify connection has Mongo data embedded in it
+	require.Len(t, delta.Mongo, 1)
+	assert.Equal(t, 2, delta.Mongo[key].Count)
+	assert.Equal(t, 1, delta.Mongo[key].ErrorCount)
+
+	// Verify Mongo data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.Mongo, 0)
+}
+
+func TestAMQPStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  5672,
+	}
+
+	key := amqp.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "", "dummy", amqp.PublishMethod)
+	rs := new(amqp.RequestStat)
+	rs.AddRequest()
+	rs.AddRequest()
+	amqpStats := map[amqp.Key]*amqp.RequestStat{key: rs}
+
+	// Register client & pass in AMQP stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{AMQP: amqpStats})
+
+	// Verify connection has AMQP data embedded in it
+	require.Len(t, delta.AMQP, 1)
+	assert.Equal(t, 2, delta.AMQP[key].Count)
+
+	// Verify AMQP data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.AMQP, 0)
+}
+
+func TestGRPCStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  50051,
+	}
+
+	key := grpc.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "helloworld.Greeter", "SayHello")
+	rs := new(grpc.RequestStat)
+	rs.AddRequest(10, 0, 0)
+	rs.AddRequest(20, 14, 0)
+	grpcStats := map[grpc.Key]*grpc.RequestStat{key: rs}
+
+	// Register client & pass in gRPC stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{GRPC: grpcStats})
+
+	// Verify connection has gRPC data embedded in it
+	require.Len(t, delta.GRPC, 1)
+	assert.Equal(t, 2, delta.GRPC[key].Count)
+	assert.Equal(t, map[uint8]int{14: 1}, delta.GRPC[key].Errors)
+
+	// Verify gRPC data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.GRPC, 0)
+}
+
+func TestHTTP3Stats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  443,
+		Type:   UDP,
+	}
+
+	key := http3.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "www.datadoghq.com")
+	rs := new(http3.RequestStat)
+	rs.AddRequest()
+	rs.AddRequest()
+	http3Stats := map[http3.Key]*http3.RequestStat{key: rs}
+
+	// Register client & pass in HTTP/3 stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{HTTP3: http3Stats})
+
+	// Verify connection has HTTP/3 data embedded in it
+	require.Len(t, delta.HTTP3, 1)
+	assert.Equal(t, 2, delta.HTTP3[key].Count)
+
+	// Verify HTTP/3 data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.HTTP3, 0)
+}
+
+func TestCassandraStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  9042,
+	}
+
+	key := cassandra.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "shop")
+	rs := new(cassandra.RequestStat)
+	rs.AddRequest(1000, false, 0)
+	rs.AddRequest(2000, true, 0x1100)
+	cassandraStats := map[cassandra.Key]*cassandra.RequestStat{key: rs}
+
+	// Register client & pass in Cassandra stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{Cassandra: cassandraStats})
+
+	// Verify connection has Cassandra data embedded in it
+	require.Len(t, delta.Cassandra, 1)
+	assert.Equal(t, 2, delta.Cassandra[key].Count)
+	assert.Equal(t, map[uint32]int{0x1100: 1}, delta.Cassandra[key].Errors)
+
+	// Verify Cassandra data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.Cassandra, 0)
+}
+
+func TestMemcachedStats(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  11211,
+	}
+
+	key := memcached.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "get")
+	rs := new(memcached.RequestStat)
+	rs.AddRequest(1000, memcached.Hit)
+	rs.AddRequest(2000, memcached.Miss)
+	memcachedStats := map[memcached.Key]*memcached.RequestStat{key: rs}
+
+	// Register client & pass in memcached stats
+	state := newDefaultState()
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{Memcached: memcachedStats})
+
+	// Verify connection has memcached data embedded in it
+	require.Len(t, delta.Memcached, 1)
+	assert.Equal(t, 2, delta.Memcached[key].Count)
+	assert.Equal(t, 1, delta.Memcached[key].Hits)
+	assert.Equal(t, 1, delta.Memcached[key].Misses)
+
+	// Verify memcached data has been flushed
+	delta = state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
+	assert.Len(t, delta.Memcached, 0)
+}
+
+func TestHTTPStatsServiceAttribution(t *testing.T) {
+	client := util.AddressFromString("10.0.0.1")
+	service := util.AddressFromString("10.96.0.10")
+	backend := util.AddressFromString("10.244.1.5")
+	c := ConnectionStats{
+		Source: client,
+		Dest:   service,
+		SPort:  1000,
+		DPort:  80,
+		Monotonic: StatCounters{
+			SentBytes: 100,
+		},
+		IPTranslation: &IPTranslation{
+			ReplSrcIP:   backend,
+			ReplDstIP:   client,
+			ReplSrcPort: 8080,
+			ReplDstPort: 1000,
+		},
+	}
+
+	var rs http.RequestStats
+	rs.AddRequest(200, 10.0, 0, 0, nil)
+	key := http.NewKey(client, backend, 1000, 8080, "/testpath", true, http.MethodGet)
+	other := http.NewKey(client, util.AddressFromString("10.0.0.2"), 1001, 80, "/testpath", true, http.MethodGet)
+	httpStats := map[http.Key]*http.RequestStats{
+		key:   &rs,
+		other: {},
+	}
+
+	state := newDefaultState()
+	state.RegisterClient("client")
+	delta := state.GetDelta("client", latestEpochTime(), []ConnectionStats{c}, nil, USMStats{HTTP: httpStats})
+	require.Len(t, delta.HTTP, 2)
+
+	key.Service = http.NewServiceTuple(service, 80)
+	require.Contains(t, delta.HTTP, key)
+	assert.Equal(t, 1, delta.HTTP[key].Stats(200).Count)
+	// connections which were not translated are left untouched
+	assert.Contains(t, delta.HTTP, other)
+}
+
+func TestHTTPStatsContainerAttribution(t *testing.T) {
+	clientContainer, serverContainer := "client-container", "server-container"
+	client := ConnectionStats{
+		Source:          util.AddressFromString("10.0.0.1"),
+		Dest:            util.AddressFromString("10.0.0.2"),
+		SPort:           1000,
+		DPort:           80,
+		Cookie:          1,
+		Monotonic:       StatCounters{SentBytes: 100},
+		ContainerID:     &clientContainer,
+		LastUpdateEpoch: latestEpochTime(),
+	}
+	external := ConnectionStats{
+		Source:          util.AddressFromString("10.0.0.1"),
+		Dest:            util.AddressFromString("8.8.8.8"),
+		SPort:           1001,
+		DPort:           80,
+		Cookie:          2,
+		Monotonic:       StatCounters{SentBytes: 100},
+		ContainerID:     &clientContainer,
+		LastUpdateEpoch: latestEpochTime(),
+	}
+	// the server end of the first connection
+	server := ConnectionStats{
+		Source:          client.Dest,
+		Dest:            client.Source,
+		SPort:           client.DPort,
+		DPort:           client.SPort,
+		Cookie:          3,
+		Monotonic:       StatCounters{SentBytes: 100},
+		ContainerID:     &serverContainer,
+		LastUpdateEpoch: latestEpochTime(),
+	}
+
+	state := newDefaultState()
+	state.RegisterClient("client")
+	state.GetDelta("client", latestEpochTime(), []ConnectionStats{client, external, server}, nil, USMStats{HTTP: map[http.Key]*http.RequestStats{}})
+
+	// the connections are closed and were already reported when their transactions are flushed
+	var rs http.RequestStats
+	rs.AddRequest(200, 10.0, 0, 0, nil)
+	local := http.NewKey(client.Source, client.Dest, client.SPort, client.DPort, "/testpath", true, http.MethodGet)
+	outgoing := http.NewKey(external.Source, external.Dest, external.SPort, external.DPort, "/testpath", true, http.MethodGet)
+	unknown := http.NewKey(client.Source, client.Dest, 1002, client.DPort, "/testpath", true, http.MethodGet)
+	httpStats := map[http.Key]*http.RequestStats{
+		local:    &rs,
+		outgoing: {},
+		unknown:  {},
+	}
+	delta := state.GetDelta("client", latestEpochTime(), nil, nil, USMStats{HTTP: httpStats})
+	require.Len(t, delta.HTTP, 3)
+
+	// both ends are local, the stats are attributed to the server
+	local.ContainerID = serverContainer
+	require.Contains(t, delta.HTTP, local)
+	assert.Equal(t, 1, delta.HTTP[local].Stats(200).Count)
+	outgoing.ContainerID = clientContainer
+	assert.Contains(t, delta.HTTP, outgoing)
+	assert.Contains(t, delta.HTTP, unknown)
+
+	// the containers are forgotten once the connections expire
+	later := latestTime.Add(uint64(httpContainerTTL.Nanoseconds()) + 1)
+	state.GetDelta("client", later, nil, nil, USMStats{HTTP: map[http.Key]*http.RequestStats{}})
+	assert.Empty(t, state.httpContainers)
+}
+
 func TestHTTPStatsWithMultipleClients(t *testing.T) {
 	c := ConnectionStats{
 		Source: util.AddressFromString("1.1.1.1"),
@@ -1434,40 +1860,73 @@ func TestHTTPStatsWithMultipleClients(t *testing.T) {
 	state.RegisterClient(client2)
 
 	// We should have nothing on first call
-	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, nil).HTTP, 0)
-	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, nil).HTTP, 0)
+	assert.Len(t, state.GetDelta(client1, latestEpochTime(), nil, nil, USMStats{}).HTTP, 0)
+	assert.Len(t, state.GetDelta(client2, latestEpochTime(), nil, nil, USMStats{}).HTTP, 0)
 
 	// Store the connection to both clients & pass HTTP stats to the first client
 	c.LastUpdateEpoch = latestEpochTime()
 	state.StoreClosedConnections([]ConnectionStats{c})
 
-	delta := state.GetDelta(client1, latestEpochTime(), nil, nil, getStats("/testpath"))
+	delta := state.GetDelta(client1, latestEpochTime(), nil, nil, USMStats{HTTP: getStats("/testpath")})
 	assert.Len(t, delta.HTTP, 1)
 
 	// Verify that the HTTP stats were also stored in the second client
-	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, nil)
+	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, USMStats{})
 	assert.Len(t, delta.HTTP, 1)
 
 	// Register a third client & verify that it does not have the HTTP stats
-	delta = state.GetDelta(client3, latestEpochTime(), []ConnectionStats{c}, nil, nil)
+	delta = state.GetDelta(client3, latestEpochTime(), []ConnectionStats{c}, nil, USMStats{})
 	assert.Len(t, delta.HTTP, 0)
 
 	c.LastUpdateEpoch = latestEpochTime()
 	state.StoreClosedConnections([]ConnectionStats{c})
 
 	// Pass in new HTTP stats to the first client
-	delta = state.GetDelta(client1, latestEpochTime(), nil, nil, getStats("/testpath2"))
+	delta = state.GetDelta(client1, latestEpochTime(), nil, nil, USMStats{HTTP: getStats("/testpath2")})
 	assert.Len(t, delta.HTTP, 1)
 
 	// And the second client
-	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, getStats("/testpath3"))
+	delta = state.GetDelta(client2, latestEpochTime(), nil, nil, USMStats{HTTP: getStats("/testpath3")})
 	assert.Len(t, delta.HTTP, 2)
 
 	// Verify that the third client also accumulated both new HTTP stats
-	delta = state.GetDelta(client3, latestEpochTime(), nil, nil, nil)
+	delta = state.GetDelta(client3, latestEpochTime(), nil, nil, USMStats{})
 	assert.Len(t, delta.HTTP, 2)
 }
 
+func TestHTTPStatsIndependentClients(t *testing.T) {
+	c := ConnectionStats{
+		Source: util.AddressFromString("1.1.1.1"),
+		Dest:   util.AddressFromString("0.0.0.0"),
+		SPort:  1000,
+		DPort:  80,
+	}
+	key := http.NewKey(c.Source, c.Dest, c.SPort, c.DPort, "/testpath", true, http.MethodGet)
+	getStats := func(requests int) map[http.Key]*http.RequestStats {
+		var rs http.RequestStats
+		for i := 0; i < requests; i++ {
+			rs.AddRequest(200, 10.0, 0, 0, nil)
+		}
+		return map[http.Key]*http.RequestStats{key: &rs}
+	}
+
+	client1, client2, client3 := "client1", "client2", "client3"
+	state := newDefaultState()
+	state.RegisterClient(client1)
+	state.RegisterClient(client2)
+	state.RegisterClient(client3)
+
+	// the third client fetches the stats twice, while the two others accumulate them
+	assert.Len(t, state.GetDelta(client3, latestEpochTime(), nil, nil, USMStats{HTTP: getStats(1)}).HTTP, 1)
+	assert.Len(t, state.GetDelta(client3, latestEpochTime(), nil, nil, USMStats{HTTP: getStats(2)}).HTTP, 1)
+
+	for _, client := range []string{client1, client2} {
+		delta := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{})
+		require.Contains(t, delta.HTTP, key)
+		assert.Equal(t, 3, delta.HTTP[key].Stats(200).Count, client)
+	}
+}
+
 func TestDetermineConnectionIntraHost(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -1729,7 +2188,7 @@ func TestClosedMergingWithAddressCollision(t *testing.T) {
 		// these two connections will be treated as distinct and won't be aggregated.
 		// also pass in an active connection with the same (non-nat) tuple; this
 		// should aggregated into the first closed connection c1 only
-		delta := state.GetDelta(client, latestEpochTime(), []ConnectionStats{active}, nil, nil)
+		delta := state.GetDelta(client, latestEpochTime(), []ConnectionStats{active}, nil, USMStats{})
 		connections := delta.Conns
 
 		assert.Len(t, delta.Conns, 2)
@@ -1765,12 +2224,12 @@ func TestClosedMergingWithAddressCollision(t *testing.T) {
 		// *limitation* in our connection tracking code and should be revisited
 		// once we find a way to reliably get the NAT translation the *first*
 		// time a connection is seen
-		_ = state.GetDelta(client, latestEpochTime(), []ConnectionStats{c1}, nil, nil)
+		_ = state.GetDelta(client, latestEpochTime(), []ConnectionStats{c1}, nil, USMStats{})
 		c2.Cookie = c1.Cookie
 		state.StoreClosedConnections([]ConnectionStats{c2})
 
 		// assert that the value returned by the second call to `GetDelta` represents c2 - c1
-		delta := state.GetDelta(client, latestEpochTime(), nil, nil, nil)
+		delta := state.GetDelta(client, latestEpochTime(), nil, nil, USMStats{})
 		assert.Len(t, delta.Conns, 1)
 		assert.Equal(t, uint64(50), delta.Conns[0].Last.SentBytes)
 	})
@@ -1804,9 +2263,84 @@ func latestEpochTime() uint64 {
 	return latestTime.Inc()
 }
 
+func TestMaxConnectionsPerClient(t *testing.T) {
+	state := NewState(StateConfig{
+		ClientExpiry:      2 * time.Minute,
+		MaxClosedConns:    50000,
+		MaxClientStats:    75000,
+		MaxDNSStats:       75000,
+		MaxHTTPStats:      7500,
+		MaxKafkaStats:     7500,
+		MaxPostgresStats:  7500,
+		MaxMySQLStats:     7500,
+		MaxRedisStats:     7500,
+		MaxMongoStats:     7500,
+		MaxAMQPStats:      7500,
+		MaxGRPCStats:      7500,
+		MaxHTTP3Stats:     7500,
+		MaxCassandraStats: 7500,
+		MaxMemcachedStats: 7500,
+		MaxClientConns:    2,
+	})
+	state.RegisterClient("1")
+	state.SetClientMaxConnections("2", 0)
+
+	var conns []ConnectionStats
+	for i, sent := range []uint64{10, 300, 50} {
+		conns = append(conns, ConnectionStats{
+			Pid:       123,
+			Type:      TCP,
+			Family:    AFINET,
+			Source:    util.AddressFromString("10.0.0.1"),
+			Dest:      util.AddressFromString("10.0.0.2"),
+			SPort:     uint16(9000 + i),
+			DPort:     80,
+			Monotonic: StatCounters{SentBytes: sent},
+			Cookie:    uint32(i),
+		})
+	}
+
+	// the connection with the lowest traffic is evicted for the client with the default limit
+	delta := state.GetDelta("1", latestEpochTime(), conns, nil, USMStats{})
+	require.Len(t, delta.Conns, 2)
+	ports := []uint16{delta.Conns[0].SPort, delta.Conns[1].SPort}
+	assert.ElementsMatch(t, []uint16{9001, 9002}, ports)
+
+	telem := state.GetTelemetryDelta("1", buildBasicTelemetry())
+	assert.Equal(t, int64(1), telem[ConnsEvictedMaxPerClient])
+	telem = state.GetTelemetryDelta("1", buildBasicTelemetry())
+	assert.NotContains(t, telem, ConnsEvictedMaxPerClient)
+
+	// the limit is disabled for the second client
+	delta = state.GetDelta("2", latestEpochTime(), conns, nil, USMStats{})
+	require.Len(t, delta.Conns, 3)
+	telem = state.GetTelemetryDelta("2", buildBasicTelemetry())
+	assert.NotContains(t, telem, ConnsEvictedMaxPerClient)
+
+	stats := state.GetStats()
+	assert.Equal(t, int64(1), stats["telemetry"].(map[string]int64)["conns_evicted"])
+	assert.Equal(t, 2, stats["clients"].(map[string]interface{})["1"].(map[string]int)["max_connections"])
+}
+
 func newDefaultState() *networkState {
 	// Using values from ebpf.NewConfig()
-	return NewState(2*time.Minute, 50000, 75000, 75000, 7500).(*networkState)
+	return NewState(StateConfig{
+		ClientExpiry:      2 * time.Minute,
+		MaxClosedConns:    50000,
+		MaxClientStats:    75000,
+		MaxDNSStats:       75000,
+		MaxHTTPStats:      7500,
+		MaxKafkaStats:     7500,
+		MaxPostgresStats:  7500,
+		MaxMySQLStats:     7500,
+		MaxRedisStats:     7500,
+		MaxMongoStats:     7500,
+		MaxAMQPStats:      7500,
+		MaxGRPCStats:      7500,
+		MaxHTTP3Stats:     7500,
+		MaxCassandraStats: 7500,
+		MaxMemcachedStats: 7500,
+	}).(*networkState)
 }
 
 func getIPProtocol(nt ConnectionType) uint8 {
@@ -1819,3 +2353,65 @@ func getIPProtocol(nt ConnectionType) uint8 {
 		panic("unknown connection type")
 	}
 }
+
+func TestAggregateQueueLengths(t *testing.T) {
+	conn := ConnectionStats{
+		Pid:       123,
+		Type:      TCP,
+		Family:    AFINET,
+		Source:    util.AddressFromString("127.0.0.1"),
+		Dest:      util.AddressFromString("127.0.0.1"),
+		SPort:     31890,
+		DPort:     80,
+		Monotonic: StatCounters{SentBytes: 1},
+		Last:      StatCounters{SentBytes: 1},
+	}
+	c1, c2 := conn, conn
+	c1.RecvQueueMax, c1.RecvQueueAvg, c1.SendQueueMax, c1.SendQueueAvg = 100, 10, 50, 20
+	c2.RecvQueueMax, c2.RecvQueueAvg, c2.SendQueueMax, c2.SendQueueAvg = 40, 30, 80, 40
+
+	aggr := newConnectionAggregator(2)
+	require.True(t, aggr.Aggregate(&c1))
+	require.True(t, aggr.Aggregate(&c2))
+
+	buffer := &clientBuffer{ConnectionBuffer: NewConnectionBuffer(2, 2)}
+	aggr.WriteTo(buffer)
+	conns := buffer.Connections()
+	require.Len(t, conns, 1)
+	assert.Equal(t, uint32(100), conns[0].RecvQueueMax)
+	assert.Equal(t, uint32(20), conns[0].RecvQueueAvg)
+	assert.Equal(t, uint32(80), conns[0].SendQueueMax)
+	assert.Equal(t, uint32(30), conns[0].SendQueueAvg)
+}
+
+func TestAggregateCongestionState(t *testing.T) {
+	conn := ConnectionStats{
+		Pid:       123,
+		Type:      TCP,
+		Family:    AFINET,
+		Source:    util.AddressFromString("127.0.0.1"),
+		Dest:      util.AddressFromString("127.0.0.1"),
+		SPort:     31890,
+		DPort:     80,
+		Monotonic: StatCounters{SentBytes: 1},
+		Last:      StatCounters{SentBytes: 1},
+	}
+	c1, c2, c3 := conn, conn, conn
+	c1.LastUpdateEpoch, c1.CongestionAlgorithm, c1.SndCwnd, c1.SndSsthresh = 2, "cubic", 10, 7
+	c2.LastUpdateEpoch, c2.CongestionAlgorithm, c2.SndCwnd, c2.SndSsthresh = 1, "bbr", 20, 0
+	// not closed yet, no congestion state
+	c3.LastUpdateEpoch = 3
+
+	aggr := newConnectionAggregator(3)
+	require.True(t, aggr.Aggregate(&c1))
+	require.True(t, aggr.Aggregate(&c2))
+	require.True(t, aggr.Aggregate(&c3))
+
+	buffer := &clientBuffer{ConnectionBuffer: NewConnectionBuffer(2, 2)}
+	aggr.WriteTo(buffer)
+	conns := buffer.Connections()
+	require.Len(t, conns, 1)
+	assert.Equal(t, "cubic", conns[0].CongestionAlgorithm)
+	assert.Equal(t, uint32(10), conns[0].SndCwnd)
+	assert.Equal(t, uint32(7), conns[0].SndSsthresh)
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+)
+
+// agentInstallDir is the installation directory of the agent, which holds the executables of all its processes,
+// including in the agent container images
+const agentInstallDir = "/opt/datadog-agent/"
+
+// agentExecutables are the names of the executables of the agent processes
+var agentExecutables = map[string]struct{}{
+	"agent":          {},
+	"process-agent":  {},
+	"security-agent": {},
+	"system-probe":   {},
+	"trace-agent":    {},
+}
+
+// agentProcesses identifies the agent processes from the executables of the processes of the connections, so that the
+// traffic of the agent itself can be excluded from the USM stats
+type agentProcesses struct {
+	procRoot string
+	selfPID  uint32
+
+	// pids caches whether the processes of the connections of the last check are agent processes
+	pids map[uint32]bool
+}
+
+func newAgentProcesses(procRoot string) *agentProcesses {
+	return &agentProcesses{
+		procRoot: procRoot,
+		selfPID:  uint32(os.Getpid()),
+		pids:     make(map[uint32]bool),
+	}
+}
+
+// isAgent returns true if the process is an agent process
+func (a *agentProcesses) isAgent(pid uint32) bool {
+	if pid == a.selfPID {
+		return true
+	}
+	if pid == 0 {
+		return false
+	}
+	if isAgent, ok := a.pids[pid]; ok {
+		return isAgent
+	}
+
+	exe, err := os.Readlink(filepath.Join(a.procRoot, strconv.FormatUint(uint64(pid), 10), "exe"))
+	if err != nil {
+		// the process is gone, or is a kernel thread
+		return false
+	}
+	exe = strings.TrimSuffix(exe, " (deleted)")
+	_, isAgentExecutable := agentExecutables[filepath.Base(exe)]
+	isAgent := isAgentExecutable && strings.HasPrefix(exe, agentInstallDir)
+	a.pids[pid] = isAgent
+	return isAgent
+}
+
+// excludeHTTPStats removes the HTTP stats of the connections of the agent processes, and returns the number of stats
+// removed
+func (a *agentProcesses) excludeHTTPStats(conns []network.ConnectionStats, stats map[http.Key]*http.RequestStats) int {
+	seen := make(map[uint32]struct{})
+	agentTuples := make(map[http.KeyTuple]struct{})
+	for _, c := range conns {
+		seen[c.Pid] = struct{}{}
+		if !a.isAgent(c.Pid) {
+			continue
+		}
+		for _, tuple := range network.HTTPKeyTuplesFromConn(c) {
+			agentTuples[tuple] = struct{}{}
+		}
+	}
+
+	// the PIDs of the processes which are gone may be reused by other processes
+	for pid := range a.pids {
+		if _, ok := seen[pid]; !ok {
+			delete(a.pids, pid)
+		}
+	}
+
+	if len(agentTuples) == 0 {
+		return 0
+	}
+	removed := 0
+	for key := range stats {
+		if _, ok := agentTuples[key.KeyTuple]; ok {
+			delete(stats, key)
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+func TestExcludeAgentHTTPStats(t *testing.T) {
+	procRoot := t.TempDir()
+	for pid, exe := range map[int]string{
+		100: "/opt/datadog-agent/embedded/bin/trace-agent",
+		200: "/usr/bin/curl",
+		300: "/usr/local/bin/agent",
+		400: "/opt/datadog-agent/bin/agent/agent (deleted)",
+	} {
+		dir := filepath.Join(procRoot, strconv.Itoa(pid))
+		require.NoError(t, os.Mkdir(dir, 0755))
+		require.NoError(t, os.Symlink(exe, filepath.Join(dir, "exe")))
+	}
+	a := newAgentProcesses(procRoot)
+
+	assert.True(t, a.isAgent(a.selfPID))
+	assert.True(t, a.isAgent(100))
+	assert.False(t, a.isAgent(200))
+	assert.False(t, a.isAgent(300), "only the executables of the agent installation are agent processes")
+	assert.True(t, a.isAgent(400))
+	assert.False(t, a.isAgent(500), "the process is gone")
+
+	local := util.AddressFromString("10.0.0.1")
+	intake := util.AddressFromString("10.0.0.2")
+	server := util.AddressFromString("10.0.0.3")
+	conns := []network.ConnectionStats{
+		{Pid: 100, Source: local, SPort: 40000, Dest: intake, DPort: 443},
+		{Pid: 200, Source: local, SPort: 40001, Dest: server, DPort: 80},
+	}
+	agentKey := http.NewKey(local, intake, 40000, 443, "/api/v0.2/traces", true, http.MethodPost)
+	curlKey := http.NewKey(local, server, 40001, 80, "/", true, http.MethodGet)
+	stats := map[http.Key]*http.RequestStats{
+		agentKey: http.NewRequestStats(false),
+		curlKey:  http.NewRequestStats(false),
+	}
+
+	assert.Equal(t, 1, a.excludeHTTPStats(conns, stats))
+	assert.NotContains(t, stats, agentKey)
+	assert.Contains(t, stats, curlKey)
+
+	// the processes which are not seen anymore are forgotten
+	assert.NotContains(t, a.pids, uint32(300))
+	assert.Contains(t, a.pids, uint32(100))
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf || (windows && npm)
+// +build linux_bpf windows,npm
+
+package tracer
+
+import "sync"
+
+// closedConnectionsNotifier signals its subscribers when closed connections are stored, so that the streaming clients
+// receive them without waiting for their next delta. The signals are coalesced: a subscriber which didn't consume the
+// previous signal yet is not signaled again.
+type closedConnectionsNotifier struct {
+	mux         sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+// subscribe returns the channel the signals are sent to, and the function removing the subscription
+func (n *closedConnectionsNotifier) subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	if n.subscribers == nil {
+		n.subscribers = make(map[chan struct{}]struct{})
+	}
+	n.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		n.mux.Lock()
+		defer n.mux.Unlock()
+		delete(n.subscribers, ch)
+	}
+}
+
+func (n *closedConnectionsNotifier) notify() {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	for ch := range n.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
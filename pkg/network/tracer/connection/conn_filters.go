@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package connection
+
+import (
+	"fmt"
+	"net/netip"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	manager "github.com/DataDog/ebpf-manager"
+)
+
+// KernelConnectionFiltersMinimumKernelVersion is the kernel version introducing the LPM trie maps, which hold the
+// address ranges of the connections filtered in the eBPF programs
+var KernelConnectionFiltersMinimumKernelVersion = kernel.VersionCode(4, 11, 0)
+
+// maxConnFilterPorts bounds the size of the map of the excluded ports. The ports of the filters going over it are only
+// filtered in user space.
+const maxConnFilterPorts = 65536
+
+var (
+	allIPv4 = netip.PrefixFrom(netip.IPv4Unspecified(), 0)
+	allIPv6 = netip.PrefixFrom(netip.IPv6Unspecified(), 0)
+)
+
+// connFilters holds the entries of the conn_filters and conn_filter_ports maps, which exclude connections in the eBPF
+// programs. The connections are still filtered in user space, the kernel filters being a subset of them.
+type connFilters struct {
+	ranges map[netebpf.ConnFilterKey]netebpf.ConnFilter
+	ports  map[netebpf.ConnFilterPort]uint8
+}
+
+// newConnFilters builds the entries of the maps filtering the excluded source and destination connections.
+//
+// The eBPF programs only look up the most specific address range matching a connection, so the ports excluded by the
+// ranges containing another one are merged into it.
+func newConnFilters(sourceExcludes, destExcludes []*network.ConnectionFilter) *connFilters {
+	cf := &connFilters{
+		ranges: make(map[netebpf.ConnFilterKey]netebpf.ConnFilter),
+		ports:  make(map[netebpf.ConnFilterPort]uint8),
+	}
+
+	var id uint32
+	sides := []struct {
+		side    uint8
+		filters []*network.ConnectionFilter
+	}{
+		{side: netebpf.ConnFilterSource, filters: sourceExcludes},
+		{side: netebpf.ConnFilterDest, filters: destExcludes},
+	}
+	for _, s := range sides {
+		for _, prefix := range connFilterPrefixes(s.filters) {
+			id++
+			value := netebpf.ConnFilter{Id: id}
+			var ports []netebpf.ConnFilterPort
+			for _, f := range s.filters {
+				if !connFilterContains(f.IP, prefix) {
+					continue
+				}
+				if f.AllPorts.TCP {
+					value.All_tcp_ports = 1
+				}
+				if f.AllPorts.UDP {
+					value.All_udp_ports = 1
+				}
+				for port, types := range f.Ports {
+					if types.TCP {
+						ports = append(ports, netebpf.ConnFilterPort{Id: id, Port: port, Type: uint8(netebpf.TCP)})
+					}
+					if types.UDP {
+						ports = append(ports, netebpf.ConnFilterPort{Id: id, Port: port, Type: uint8(netebpf.UDP)})
+					}
+				}
+			}
+
+			if len(cf.ports)+len(ports) > maxConnFilterPorts {
+				log.Warnf("too many excluded ports to filter the connections of %s in the kernel, they are filtered in user space", prefix)
+				ports = nil
+				if value.All_tcp_ports == 0 && value.All_udp_ports == 0 {
+					continue
+				}
+			}
+			for _, p := range ports {
+				if (p.Type == uint8(netebpf.TCP) && value.All_tcp_ports == 1) || (p.Type == uint8(netebpf.UDP) && value.All_udp_ports == 1) {
+					continue
+				}
+				cf.ports[p] = 1
+			}
+			cf.ranges[connFilterKey(s.side, prefix)] = value
+		}
+	}
+	return cf
+}
+
+// connFilterPrefixes returns the distinct address ranges of the filters, the wildcard matching both IPv4 and IPv6
+func connFilterPrefixes(filters []*network.ConnectionFilter) []netip.Prefix {
+	var prefixes []netip.Prefix
+	seen := make(map[netip.Prefix]struct{})
+	add := func(prefix netip.Prefix) {
+		if _, ok := seen[prefix]; !ok {
+			seen[prefix] = struct{}{}
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	for _, f := range filters {
+		if !f.IP.IsValid() {
+			add(allIPv4)
+			add(allIPv6)
+			continue
+		}
+		add(f.IP.Masked())
+	}
+	return prefixes
+}
+
+// connFilterContains returns whether the address range of a filter contains the given one
+func connFilterContains(filter, prefix netip.Prefix) bool {
+	if !filter.IsValid() {
+		return true
+	}
+	return filter.Addr().Is4() == prefix.Addr().Is4() && filter.Bits() <= prefix.Bits() && filter.Contains(prefix.Addr())
+}
+
+func connFilterKey(side uint8, prefix netip.Prefix) netebpf.ConnFilterKey {
+	key := netebpf.ConnFilterKey{
+		Prefixlen: uint32(netebpf.ConnFilterHeaderPrefixLen + prefix.Bits()),
+		Side:      side,
+		Family:    uint8(netebpf.IPv4),
+	}
+	if prefix.Addr().Is4() {
+		addr := prefix.Addr().As4()
+		copy(key.Addr[:], addr[:])
+	} else {
+		key.Family = uint8(netebpf.IPv6)
+		key.Addr = prefix.Addr().As16()
+	}
+	return key
+}
+
+// mapSpecEditors returns the editors of the specs of the filter maps. The LPM trie is turned into a hash map when the
+// filters are disabled, as the kernels older than 4.11 don't support it.
+func (cf *connFilters) mapSpecEditors(config *config.Config) map[string]manager.MapSpecEditor {
+	if !config.EnableKernelConnectionFilters {
+		return map[string]manager.MapSpecEditor{
+			probes.ConnFiltersMap:     {Type: ebpf.Hash, MaxEntries: 1, Flags: 0, EditorFlag: manager.EditType | manager.EditMaxEntries | manager.EditFlags},
+			probes.ConnFilterPortsMap: {Type: ebpf.Hash, MaxEntries: 1, EditorFlag: manager.EditMaxEntries},
+		}
+	}
+	return map[string]manager.MapSpecEditor{
+		probes.ConnFiltersMap:     {Type: ebpf.LPMTrie, MaxEntries: uint32(len(cf.ranges) + 1), EditorFlag: manager.EditMaxEntries},
+		probes.ConnFilterPortsMap: {Type: ebpf.Hash, MaxEntries: uint32(len(cf.ports) + 1), EditorFlag: manager.EditMaxEntries},
+	}
+}
+
+// load writes the filters to their maps
+func (cf *connFilters) load(m *manager.Manager) error {
+	ranges, _, err := m.GetMap(probes.ConnFiltersMap)
+	if err != nil {
+		return fmt.Errorf("error retrieving the bpf %s map: %s", probes.ConnFiltersMap, err)
+	}
+	for key, value := range cf.ranges {
+		key, value := key, value
+		if err := ranges.Put(unsafe.Pointer(&key), unsafe.Pointer(&value)); err != nil {
+			return fmt.Errorf("error updating the bpf %s map: %s", probes.ConnFiltersMap, err)
+		}
+	}
+
+	ports, _, err := m.GetMap(probes.ConnFilterPortsMap)
+	if err != nil {
+		return fmt.Errorf("error retrieving the bpf %s map: %s", probes.ConnFilterPortsMap, err)
+	}
+	for key, value := range cf.ports {
+		key, value := key, value
+		if err := ports.Put(unsafe.Pointer(&key), unsafe.Pointer(&value)); err != nil {
+			return fmt.Errorf("error updating the bpf %s map: %s", probes.ConnFilterPortsMap, err)
+		}
+	}
+	return nil
+}
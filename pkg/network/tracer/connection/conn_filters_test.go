@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package connection
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+)
+
+func TestConnFilters(t *testing.T) {
+	dest := network.ParseConnectionFilters(map[string][]string{
+		"127.0.0.0/8": {"*"},
+		"10.0.0.0/8":  {"tcp 80"},
+		"10.1.2.3/16": {"udp 53"},
+		"*":           {"tcp 22"},
+	})
+	source := network.ParseConnectionFilters(map[string][]string{
+		"2001:db8::/32": {"udp *"},
+	})
+	cf := newConnFilters(source, dest)
+
+	lookup := func(side uint8, prefix string) netebpf.ConnFilter {
+		value, ok := cf.ranges[connFilterKey(side, netip.MustParsePrefix(prefix))]
+		require.True(t, ok, "missing filter of %s", prefix)
+		return value
+	}
+	hasPort := func(filter netebpf.ConnFilter, port uint16, typ netebpf.ConnType) bool {
+		_, ok := cf.ports[netebpf.ConnFilterPort{Id: filter.Id, Port: port, Type: uint8(typ)}]
+		return ok
+	}
+
+	// the wildcard matches both families, and its ports are merged into the other ranges
+	require.Len(t, cf.ranges, 6)
+	for _, prefix := range []string{"0.0.0.0/0", "::/0"} {
+		all := lookup(netebpf.ConnFilterDest, prefix)
+		assert.Zero(t, all.All_tcp_ports)
+		assert.True(t, hasPort(all, 22, netebpf.TCP))
+		assert.False(t, hasPort(all, 22, netebpf.UDP))
+	}
+
+	loopback := lookup(netebpf.ConnFilterDest, "127.0.0.0/8")
+	assert.Equal(t, uint8(1), loopback.All_tcp_ports)
+	assert.Equal(t, uint8(1), loopback.All_udp_ports)
+	assert.False(t, hasPort(loopback, 22, netebpf.TCP))
+
+	private := lookup(netebpf.ConnFilterDest, "10.0.0.0/8")
+	assert.True(t, hasPort(private, 80, netebpf.TCP))
+	assert.True(t, hasPort(private, 22, netebpf.TCP))
+	assert.False(t, hasPort(private, 53, netebpf.UDP))
+
+	// the host bits of the ranges are ignored
+	subnet := lookup(netebpf.ConnFilterDest, "10.1.0.0/16")
+	assert.True(t, hasPort(subnet, 53, netebpf.UDP))
+	assert.True(t, hasPort(subnet, 80, netebpf.TCP))
+	assert.True(t, hasPort(subnet, 22, netebpf.TCP))
+
+	documentation := lookup(netebpf.ConnFilterSource, "2001:db8::/32")
+	assert.Zero(t, documentation.All_tcp_ports)
+	assert.Equal(t, uint8(1), documentation.All_udp_ports)
+	assert.False(t, hasPort(documentation, 22, netebpf.TCP))
+}
+
+func TestConnFilterKey(t *testing.T) {
+	key := connFilterKey(netebpf.ConnFilterDest, netip.MustParsePrefix("127.0.0.0/8"))
+	assert.Equal(t, uint32(netebpf.ConnFilterHeaderPrefixLen+8), key.Prefixlen)
+	assert.Equal(t, uint8(netebpf.ConnFilterDest), key.Side)
+	assert.Equal(t, uint8(netebpf.IPv4), key.Family)
+	assert.Equal(t, [16]uint8{127}, key.Addr)
+
+	key = connFilterKey(netebpf.ConnFilterSource, netip.MustParsePrefix("2001:db8::/32"))
+	assert.Equal(t, uint32(netebpf.ConnFilterHeaderPrefixLen+32), key.Prefixlen)
+	assert.Equal(t, uint8(netebpf.IPv6), key.Family)
+	assert.Equal(t, [16]uint8{0x20, 0x01, 0x0d, 0xb8}, key.Addr)
+}
@@ -89,7 +89,7 @@ func dumpMapsHandler(manager *manager.Manager, mapName string, currentMap *ebpf.
 			output.WriteString(spew.Sdump(key, value))
 		}
 
-	case probes.ConnMap: // maps/conn_stats (BPF_MAP_TYPE_HASH), key ConnTuple, value ConnStatsWithTimestamp
+	case probes.ConnMap, probes.ConnMapV6: // maps/conn_stats and maps/conn_stats_v6 (BPF_MAP_TYPE_HASH), key ConnTuple, value ConnStatsWithTimestamp
 		output.WriteString("Map: '" + mapName + "', key: 'ConnTuple', value: 'ConnStatsWithTimestamp'\n")
 		iter := currentMap.Iterate()
 		var key ddebpf.ConnTuple
@@ -107,6 +107,42 @@ func dumpMapsHandler(manager *manager.Manager, mapName string, currentMap *ebpf.
 			output.WriteString(spew.Sdump(key, value))
 		}
 
+	case probes.QUICStatsMap: // maps/quic_stats (BPF_MAP_TYPE_HASH), key ConnTuple, value QUICStats
+		output.WriteString("Map: '" + mapName + "', key: 'ConnTuple', value: 'QUICStats'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value ddebpf.QUICStats
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			output.WriteString(spew.Sdump(key, value))
+		}
+
+	case probes.TCPDropReasonsMap: // maps/tcp_drop_reasons (BPF_MAP_TYPE_LRU_HASH), key ConnTuple, value TCPDrops
+		output.WriteString("Map: '" + mapName + "', key: 'ConnTuple', value: 'TCPDrops'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnTuple
+		var value ddebpf.TCPDrops
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			output.WriteString(spew.Sdump(key, value))
+		}
+
+	case probes.ConnFiltersMap: // maps/conn_filters (BPF_MAP_TYPE_LPM_TRIE), key ConnFilterKey, value ConnFilter
+		output.WriteString("Map: '" + mapName + "', key: 'ConnFilterKey', value: 'ConnFilter'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnFilterKey
+		var value ddebpf.ConnFilter
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			output.WriteString(spew.Sdump(key, value))
+		}
+
+	case probes.ConnFilterPortsMap: // maps/conn_filter_ports (BPF_MAP_TYPE_HASH), key ConnFilterPort, value C.__u8
+		output.WriteString("Map: '" + mapName + "', key: 'ConnFilterPort', value: 'C.__u8'\n")
+		iter := currentMap.Iterate()
+		var key ddebpf.ConnFilterPort
+		var value uint8
+		for iter.Next(unsafe.Pointer(&key), unsafe.Pointer(&value)) {
+			output.WriteString(spew.Sdump(key, value))
+		}
+
 	case probes.ConnCloseBatchMap: // maps/conn_close_batch (BPF_MAP_TYPE_HASH), key C.__u32, value batch
 		output.WriteString("Map: '" + mapName + "', key: 'C.__u32', value: 'batch'\n")
 		iter := currentMap.Iterate()
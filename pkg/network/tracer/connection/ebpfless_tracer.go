@@ -0,0 +1,556 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package connection
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"go.uber.org/atomic"
+
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	filterpkg "github.com/DataDog/datadog-agent/pkg/network/filter"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// ebpfLessFlushInterval is the interval at which the closed connections are handed to the callback
+	ebpfLessFlushInterval = time.Second
+	// ebpfLessAddrsRefreshInterval is the interval at which the addresses of the host are refreshed
+	ebpfLessAddrsRefreshInterval = 30 * time.Second
+)
+
+// ebpfLessKey identifies a connection, from the side of its local endpoint
+type ebpfLessKey struct {
+	src, dst     util.Address
+	sport, dport uint16
+	typ          network.ConnectionType
+}
+
+func (k ebpfLessKey) flip() ebpfLessKey {
+	return ebpfLessKey{src: k.dst, dst: k.src, sport: k.dport, dport: k.sport, typ: k.typ}
+}
+
+// ebpfLessPacket holds the fields of a packet the connections are tracked from
+type ebpfLessPacket struct {
+	key    ebpfLessKey
+	family network.ConnectionFamily
+	length uint32
+
+	// fields of the TCP segments
+	syn, ack, fin, rst bool
+	seq                uint32
+	// checksum identifies, along with the other fields, the copies of a packet captured on several interfaces
+	checksum uint16
+}
+
+// ebpfLessConn holds the state of a connection tracked from its packets
+type ebpfLessConn struct {
+	stats network.ConnectionStats
+
+	// nextSeq holds the sequence number following the last byte sent by the local and the remote endpoints of a TCP
+	// connection, the bytes are counted once their sequence number advances
+	nextSeq [2]uint32
+	seqSet  [2]bool
+	finSeen [2]bool
+	// synTime and synAckTime are the times the SYN segment and the SYN-ACK segment of the local endpoint were seen,
+	// to measure the RTT of the handshake
+	synTime    uint64
+	synAckTime uint64
+
+	// last identifies the last packet of the connection, as the packets routed through several interfaces of the host,
+	// or through the loopback interface, are captured once per interface
+	last ebpfLessPacket
+}
+
+// ebpfLessTracer tracks the TCP and UDP connections from the packets read through a packet socket of the root
+// network namespace, for the environments forbidding eBPF programs. The process and the network namespace of the
+// connections are unknown: their local endpoint is the one whose address belongs to the host, or their client for
+// the connections routed through the host, such as the ones of its containers.
+type ebpfLessTracer struct {
+	config *config.Config
+	source *filterpkg.AFPacketSource
+
+	decoder  *gopacket.DecodingLayerParser
+	decoded  []gopacket.LayerType
+	ethernet layers.Ethernet
+	ipv4     layers.IPv4
+	ipv6     layers.IPv6
+	tcp      layers.TCP
+	udp      layers.UDP
+
+	mux    sync.Mutex
+	conns  map[ebpfLessKey]*ebpfLessConn
+	closed []network.ConnectionStats
+	// localAddrs holds the addresses of the interfaces of the root network namespace
+	localAddrs map[util.Address]struct{}
+
+	closedCallback func([]network.ConnectionStats)
+
+	// the timestamps of the packets are converted to the monotonic clock, relatively to startTime
+	startTime      time.Time
+	startMonotonic int64
+
+	exit     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+
+	packets      *atomic.Int64
+	skipped      *atomic.Int64
+	duplicates   *atomic.Int64
+	droppedConns *atomic.Int64
+}
+
+// NewEbpfLessTracer returns a Tracer of the connections which doesn't load any eBPF program
+func NewEbpfLessTracer(cfg *config.Config) (Tracer, error) {
+	var protocols []layers.IPProtocol
+	if cfg.CollectTCPConns {
+		protocols = append(protocols, layers.IPProtocolTCP)
+	}
+	if cfg.CollectUDPConns {
+		protocols = append(protocols, layers.IPProtocolUDP)
+	}
+	filter, err := filterpkg.TransportFilter(protocols...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating bpf classic filter: %w", err)
+	}
+
+	t, err := newEbpfLessTracer(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := cfg.GetRootNetNs()
+	if err != nil {
+		return nil, err
+	}
+	defer ns.Close()
+
+	err = util.WithNS(ns, func() error {
+		var srcErr error
+		t.source, srcErr = filterpkg.NewPacketSource(nil, filter)
+		if srcErr != nil {
+			return srcErr
+		}
+		t.refreshLocalAddrs()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	t.decoder = gopacket.NewDecodingLayerParser(t.source.PacketType(), &t.ethernet, &t.ipv4, &t.ipv6, &t.tcp, &t.udp)
+	t.decoder.IgnoreUnsupported = true
+	return t, nil
+}
+
+func newEbpfLessTracer(cfg *config.Config) (*ebpfLessTracer, error) {
+	startMonotonic, err := ddebpf.NowNanoseconds()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ebpfLessTracer{
+		config:         cfg,
+		conns:          make(map[ebpfLessKey]*ebpfLessConn),
+		localAddrs:     make(map[util.Address]struct{}),
+		startTime:      time.Now(),
+		startMonotonic: startMonotonic,
+		exit:           make(chan struct{}),
+		packets:        atomic.NewInt64(0),
+		skipped:        atomic.NewInt64(0),
+		duplicates:     atomic.NewInt64(0),
+		droppedConns:   atomic.NewInt64(0),
+	}, nil
+}
+
+// Start begins reading the packets, the closed connections being handed to callback
+func (t *ebpfLessTracer) Start(callback func([]network.ConnectionStats)) error {
+	t.closedCallback = callback
+
+	t.wg.Add(2)
+	go func() {
+		defer t.wg.Done()
+		for {
+			select {
+			case <-t.exit:
+				return
+			default:
+			}
+
+			// VisitPackets returns once no packet was read for the poll timeout of the socket
+			if err := t.source.VisitPackets(t.exit, t.visit); err != nil {
+				log.Errorf("error reading packets: %s", err)
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer t.wg.Done()
+		flushTicker := time.NewTicker(ebpfLessFlushInterval)
+		defer flushTicker.Stop()
+		addrsTicker := time.NewTicker(ebpfLessAddrsRefreshInterval)
+		defer addrsTicker.Stop()
+		for {
+			select {
+			case <-flushTicker.C:
+				t.FlushPending()
+			case <-addrsTicker.C:
+				t.refreshRootNsLocalAddrs()
+			case <-t.exit:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop halts the reading of the packets
+func (t *ebpfLessTracer) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.exit)
+		t.wg.Wait()
+		t.source.Close()
+	})
+}
+
+// GetConnections returns the connections tracked, using the buffer provided
+func (t *ebpfLessTracer) GetConnections(buffer *network.ConnectionBuffer, filter func(*network.ConnectionStats) bool) error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	for _, conn := range t.conns {
+		if filter != nil && !filter(&conn.stats) {
+			continue
+		}
+		*buffer.Next() = conn.stats
+	}
+	return nil
+}
+
+// FlushPending hands the connections closed since the last call to the callback
+func (t *ebpfLessTracer) FlushPending() {
+	t.mux.Lock()
+	closed := t.closed
+	t.closed = nil
+	t.mux.Unlock()
+
+	if len(closed) > 0 && t.closedCallback != nil {
+		t.closedCallback(closed)
+	}
+}
+
+// Remove stops tracking the connection
+func (t *ebpfLessTracer) Remove(conn *network.ConnectionStats) error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	delete(t.conns, ebpfLessKey{src: conn.Source, dst: conn.Dest, sport: conn.SPort, dport: conn.DPort, typ: conn.Type})
+	return nil
+}
+
+// GetTelemetry returns the telemetry of the packets read
+func (t *ebpfLessTracer) GetTelemetry() map[string]int64 {
+	stats := map[string]int64{
+		"packets":           t.packets.Load(),
+		"skipped_packets":   t.skipped.Load(),
+		"duplicate_packets": t.duplicates.Load(),
+		"dropped_conns":     t.droppedConns.Load(),
+	}
+	for k, v := range t.source.Stats() {
+		stats[k] = v
+	}
+	return stats
+}
+
+// GetMap returns nil, as no eBPF map is loaded
+func (t *ebpfLessTracer) GetMap(string) *ebpf.Map {
+	return nil
+}
+
+// DumpMaps returns an error, as no eBPF map is loaded
+func (t *ebpfLessTracer) DumpMaps(_ ...string) (string, error) {
+	return "", errors.New("the connections are tracked without eBPF, there is no map to dump")
+}
+
+// Type returns EBPFLess
+func (t *ebpfLessTracer) Type() TracerType {
+	return EBPFLess
+}
+
+func (t *ebpfLessTracer) visit(data []byte, ts time.Time) error {
+	t.packets.Inc()
+	if err := t.decoder.DecodeLayers(data, &t.decoded); err != nil {
+		t.skipped.Inc()
+		return nil
+	}
+
+	var pkt ebpfLessPacket
+	var transport bool
+	for _, layer := range t.decoded {
+		switch layer {
+		case layers.LayerTypeIPv4:
+			pkt.key.src = util.AddressFromNetIP(t.ipv4.SrcIP)
+			pkt.key.dst = util.AddressFromNetIP(t.ipv4.DstIP)
+			pkt.family = network.AFINET
+		case layers.LayerTypeIPv6:
+			pkt.key.src = util.AddressFromNetIP(t.ipv6.SrcIP)
+			pkt.key.dst = util.AddressFromNetIP(t.ipv6.DstIP)
+			pkt.family = network.AFINET6
+		case layers.LayerTypeTCP:
+			transport = true
+			pkt.key.typ = network.TCP
+			pkt.key.sport, pkt.key.dport = uint16(t.tcp.SrcPort), uint16(t.tcp.DstPort)
+			pkt.length = uint32(len(t.tcp.Payload))
+			pkt.syn, pkt.ack, pkt.fin, pkt.rst = t.tcp.SYN, t.tcp.ACK, t.tcp.FIN, t.tcp.RST
+			pkt.seq = t.tcp.Seq
+			pkt.checksum = t.tcp.Checksum
+		case layers.LayerTypeUDP:
+			transport = true
+			pkt.key.typ = network.UDP
+			pkt.key.sport, pkt.key.dport = uint16(t.udp.SrcPort), uint16(t.udp.DstPort)
+			pkt.length = uint32(len(t.udp.Payload))
+			pkt.checksum = t.udp.Checksum
+		}
+	}
+	if !transport {
+		t.skipped.Inc()
+		return nil
+	}
+	if (pkt.family == network.AFINET && !t.config.CollectIPv4Conns) || (pkt.family == network.AFINET6 && !t.config.CollectIPv6Conns) {
+		t.skipped.Inc()
+		return nil
+	}
+
+	t.processPacket(&pkt, uint64(t.startMonotonic+int64(ts.Sub(t.startTime))))
+	return nil
+}
+
+// processPacket accounts for a packet in the stats of its connection, seen at the monotonic time now
+func (t *ebpfLessTracer) processPacket(pkt *ebpfLessPacket, now uint64) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	conn, outgoing := t.conns[pkt.key], true
+	if conn == nil {
+		conn, outgoing = t.conns[pkt.key.flip()], false
+	}
+	if conn == nil {
+		if pkt.key.typ == network.TCP && (pkt.fin || pkt.rst) {
+			// the end of a connection tracked before it was seen
+			return
+		}
+		if len(t.conns) >= int(t.config.MaxTrackedConnections) {
+			t.droppedConns.Inc()
+			return
+		}
+		conn, outgoing = t.newConn(pkt)
+	}
+
+	if conn.last == *pkt {
+		t.duplicates.Inc()
+		return
+	}
+	conn.last = *pkt
+
+	counters := &conn.stats.Monotonic
+	side := 0
+	if !outgoing {
+		side = 1
+	}
+	if outgoing {
+		counters.SentPackets++
+	} else {
+		counters.RecvPackets++
+	}
+	conn.stats.LastUpdateEpoch = now
+
+	if pkt.key.typ == network.UDP {
+		if outgoing {
+			counters.SentBytes += uint64(pkt.length)
+		} else {
+			counters.RecvBytes += uint64(pkt.length)
+		}
+		return
+	}
+
+	t.processTCPSegment(conn, pkt, side, now)
+}
+
+// processTCPSegment accounts for a TCP segment sent by the given side of the connection, 0 being its local endpoint
+func (t *ebpfLessTracer) processTCPSegment(conn *ebpfLessConn, pkt *ebpfLessPacket, side int, now uint64) {
+	counters := &conn.stats.Monotonic
+
+	switch {
+	case pkt.syn && !pkt.ack:
+		conn.synTime = now
+	case pkt.syn && side == 0:
+		conn.synAckTime = now
+	case pkt.syn && conn.synTime != 0:
+		// the SYN-ACK answering the SYN of the local endpoint
+		setHandshakeRTT(conn, conn.synTime, now)
+		conn.synTime = 0
+	case side == 1 && conn.synAckTime != 0:
+		// the ACK answering the SYN-ACK of the local endpoint
+		setHandshakeRTT(conn, conn.synAckTime, now)
+		conn.synAckTime = 0
+	}
+
+	// the SYN and FIN flags take a sequence number, but aren't counted as bytes
+	end := pkt.seq + pkt.length
+	next := end
+	if pkt.syn || pkt.fin {
+		next++
+	}
+	switch {
+	case !conn.seqSet[side]:
+		conn.nextSeq[side], conn.seqSet[side] = next, true
+		addBytes(counters, side, pkt.length)
+	case int32(next-conn.nextSeq[side]) > 0:
+		if advance := int32(end - conn.nextSeq[side]); advance > 0 {
+			// the bytes preceding the segment may have been missed
+			if uint32(advance) > pkt.length {
+				advance = int32(pkt.length)
+			}
+			addBytes(counters, side, uint32(advance))
+		}
+		conn.nextSeq[side] = next
+	case pkt.length > 0 && side == 0:
+		counters.Retransmits++
+	}
+
+	if pkt.fin {
+		conn.finSeen[side] = true
+	}
+	if pkt.rst || (conn.finSeen[0] && conn.finSeen[1]) {
+		counters.TCPClosed = 1
+		t.closed = append(t.closed, conn.stats)
+		delete(t.conns, ebpfLessKey{src: conn.stats.Source, dst: conn.stats.Dest, sport: conn.stats.SPort, dport: conn.stats.DPort, typ: network.TCP})
+	}
+}
+
+// addBytes adds the bytes sent by the given side of a connection to its counters
+func addBytes(counters *network.StatCounters, side int, n uint32) {
+	if side == 0 {
+		counters.SentBytes += uint64(n)
+	} else {
+		counters.RecvBytes += uint64(n)
+	}
+}
+
+// newConn starts tracking the connection of a packet, and returns whether the packet was sent by its local endpoint
+func (t *ebpfLessTracer) newConn(pkt *ebpfLessPacket) (*ebpfLessConn, bool) {
+	key := pkt.key
+	clientSent := true
+	if key.typ == network.TCP {
+		switch {
+		case pkt.syn:
+			clientSent = !pkt.ack
+		default:
+			// the connection was established before it was seen, its server is assumed to use the lowest port
+			clientSent = key.sport > key.dport
+		}
+	}
+
+	_, srcLocal := t.localAddrs[key.src]
+	_, dstLocal := t.localAddrs[key.dst]
+	direction := network.OUTGOING
+	switch {
+	case srcLocal && !dstLocal:
+		if !clientSent {
+			direction = network.INCOMING
+		}
+	case dstLocal && !srcLocal:
+		key = key.flip()
+		if clientSent {
+			direction = network.INCOMING
+		}
+	default:
+		// the connections within the host, and the ones routed through it, are seen from their client
+		if !clientSent {
+			key = key.flip()
+		}
+	}
+
+	conn := &ebpfLessConn{
+		stats: network.ConnectionStats{
+			Source:    key.src,
+			Dest:      key.dst,
+			SPort:     key.sport,
+			DPort:     key.dport,
+			Type:      key.typ,
+			Family:    pkt.family,
+			Direction: direction,
+			Cookie:    rand.Uint32(),
+		},
+	}
+	if key.typ == network.TCP && pkt.syn {
+		conn.stats.Monotonic.TCPEstablished = 1
+	}
+	t.conns[key] = conn
+	return conn, key == pkt.key
+}
+
+// setHandshakeRTT sets the RTT of a TCP connection from the time elapsed between a segment of its handshake sent by
+// its local endpoint and the answer of the remote endpoint
+func setHandshakeRTT(conn *ebpfLessConn, sent, now uint64) {
+	rtt := uint32((now - sent) / uint64(time.Microsecond))
+	conn.stats.RTT, conn.stats.RTTVar = rtt, rtt/2
+}
+
+// refreshRootNsLocalAddrs refreshes the addresses of the interfaces of the root network namespace
+func (t *ebpfLessTracer) refreshRootNsLocalAddrs() {
+	ns, err := t.config.GetRootNetNs()
+	if err != nil {
+		log.Debugf("error retrieving the root network namespace: %s", err)
+		return
+	}
+	defer ns.Close()
+
+	err = util.WithNS(ns, func() error {
+		t.refreshLocalAddrs()
+		return nil
+	})
+	if err != nil {
+		log.Debugf("error refreshing the addresses of the host: %s", err)
+	}
+}
+
+// refreshLocalAddrs refreshes the addresses of the interfaces of the current network namespace
+func (t *ebpfLessTracer) refreshLocalAddrs() {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Debugf("error listing the addresses of the host: %s", err)
+		return
+	}
+
+	localAddrs := make(map[util.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			localAddrs[util.AddressFromNetIP(ipNet.IP)] = struct{}{}
+		}
+	}
+
+	t.mux.Lock()
+	t.localAddrs = localAddrs
+	t.mux.Unlock()
+}
+
+var _ Tracer = &ebpfLessTracer{}
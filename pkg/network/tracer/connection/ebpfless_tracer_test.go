@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package connection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+var (
+	ebpfLessLocal  = util.AddressFromString("10.0.0.1")
+	ebpfLessRemote = util.AddressFromString("10.0.0.2")
+)
+
+func newTestEbpfLessTracer(t *testing.T) *ebpfLessTracer {
+	tr, err := newEbpfLessTracer(config.New())
+	require.NoError(t, err)
+	tr.localAddrs[ebpfLessLocal] = struct{}{}
+	return tr
+}
+
+func tcpSegment(src, dst util.Address, sport, dport uint16, seq, length uint32, checksum uint16) *ebpfLessPacket {
+	return &ebpfLessPacket{
+		key:      ebpfLessKey{src: src, dst: dst, sport: sport, dport: dport, typ: network.TCP},
+		family:   network.AFINET,
+		length:   length,
+		ack:      true,
+		seq:      seq,
+		checksum: checksum,
+	}
+}
+
+func TestEbpfLessOutgoingTCPConnection(t *testing.T) {
+	tr := newTestEbpfLessTracer(t)
+	ms := uint64(time.Millisecond)
+
+	syn := tcpSegment(ebpfLessLocal, ebpfLessRemote, 40000, 80, 100, 0, 1)
+	syn.ack, syn.syn = false, true
+	tr.processPacket(syn, 1*ms)
+	synAck := tcpSegment(ebpfLessRemote, ebpfLessLocal, 80, 40000, 500, 0, 2)
+	synAck.syn = true
+	tr.processPacket(synAck, 3*ms)
+
+	// a segment captured twice, such as on the loopback interface, is counted once
+	data := tcpSegment(ebpfLessLocal, ebpfLessRemote, 40000, 80, 101, 10, 3)
+	tr.processPacket(data, 4*ms)
+	tr.processPacket(data, 4*ms)
+	// a retransmitted segment is counted as such
+	tr.processPacket(tcpSegment(ebpfLessRemote, ebpfLessLocal, 80, 40000, 501, 0, 4), 5*ms)
+	tr.processPacket(tcpSegment(ebpfLessLocal, ebpfLessRemote, 40000, 80, 101, 10, 3), 6*ms)
+	tr.processPacket(tcpSegment(ebpfLessRemote, ebpfLessLocal, 80, 40000, 501, 20, 5), 7*ms)
+
+	buffer := network.NewConnectionBuffer(8, 8)
+	require.NoError(t, tr.GetConnections(buffer, nil))
+	conns := buffer.Connections()
+	require.Len(t, conns, 1)
+
+	conn := conns[0]
+	assert.Equal(t, ebpfLessLocal, conn.Source)
+	assert.Equal(t, ebpfLessRemote, conn.Dest)
+	assert.Equal(t, uint16(40000), conn.SPort)
+	assert.Equal(t, uint16(80), conn.DPort)
+	assert.Equal(t, network.OUTGOING, conn.Direction)
+	assert.Equal(t, network.TCP, conn.Type)
+	assert.Equal(t, uint64(10), conn.Monotonic.SentBytes)
+	assert.Equal(t, uint64(20), conn.Monotonic.RecvBytes)
+	assert.Equal(t, uint64(3), conn.Monotonic.SentPackets)
+	assert.Equal(t, uint64(3), conn.Monotonic.RecvPackets)
+	assert.Equal(t, uint32(1), conn.Monotonic.Retransmits)
+	assert.Equal(t, uint32(1), conn.Monotonic.TCPEstablished)
+	assert.Equal(t, uint32(2000), conn.RTT)
+	assert.Equal(t, 7*ms, conn.LastUpdateEpoch)
+
+	// the connection is closed once both sides sent a FIN
+	var closed []network.ConnectionStats
+	tr.closedCallback = func(c []network.ConnectionStats) { closed = append(closed, c...) }
+	fin := tcpSegment(ebpfLessLocal, ebpfLessRemote, 40000, 80, 111, 0, 6)
+	fin.fin = true
+	tr.processPacket(fin, 8*ms)
+	fin = tcpSegment(ebpfLessRemote, ebpfLessLocal, 80, 40000, 521, 0, 7)
+	fin.fin = true
+	tr.processPacket(fin, 9*ms)
+	tr.FlushPending()
+
+	require.Len(t, closed, 1)
+	assert.Equal(t, uint32(1), closed[0].Monotonic.TCPClosed)
+	assert.Equal(t, uint64(10), closed[0].Monotonic.SentBytes)
+	assert.Equal(t, uint64(20), closed[0].Monotonic.RecvBytes)
+	assert.Empty(t, tr.conns)
+}
+
+func TestEbpfLessIncomingTCPConnection(t *testing.T) {
+	tr := newTestEbpfLessTracer(t)
+	ms := uint64(time.Millisecond)
+
+	syn := tcpSegment(ebpfLessRemote, ebpfLessLocal, 40000, 443, 100, 0, 1)
+	syn.ack, syn.syn = false, true
+	tr.processPacket(syn, 1*ms)
+	synAck := tcpSegment(ebpfLessLocal, ebpfLessRemote, 443, 40000, 500, 0, 2)
+	synAck.syn = true
+	tr.processPacket(synAck, 2*ms)
+	tr.processPacket(tcpSegment(ebpfLessRemote, ebpfLessLocal, 40000, 443, 101, 0, 3), 5*ms)
+
+	rst := tcpSegment(ebpfLessLocal, ebpfLessRemote, 443, 40000, 501, 0, 4)
+	rst.rst = true
+	tr.processPacket(rst, 6*ms)
+
+	require.Len(t, tr.closed, 1)
+	conn := tr.closed[0]
+	assert.Equal(t, ebpfLessLocal, conn.Source)
+	assert.Equal(t, uint16(443), conn.SPort)
+	assert.Equal(t, network.INCOMING, conn.Direction)
+	assert.Equal(t, uint32(3000), conn.RTT)
+	assert.Equal(t, uint32(1), conn.Monotonic.TCPClosed)
+}
+
+func TestEbpfLessUDPConnection(t *testing.T) {
+	tr := newTestEbpfLessTracer(t)
+
+	// the connections routed through the host are seen from their client
+	container := util.AddressFromString("172.17.0.2")
+	query := &ebpfLessPacket{
+		key:      ebpfLessKey{src: container, dst: ebpfLessRemote, sport: 5353, dport: 53, typ: network.UDP},
+		family:   network.AFINET,
+		length:   40,
+		checksum: 1,
+	}
+	tr.processPacket(query, 1)
+	answer := &ebpfLessPacket{
+		key:      query.key.flip(),
+		family:   network.AFINET,
+		length:   100,
+		checksum: 2,
+	}
+	tr.processPacket(answer, 2)
+
+	buffer := network.NewConnectionBuffer(8, 8)
+	require.NoError(t, tr.GetConnections(buffer, nil))
+	conns := buffer.Connections()
+	require.Len(t, conns, 1)
+	assert.Equal(t, container, conns[0].Source)
+	assert.Equal(t, network.OUTGOING, conns[0].Direction)
+	assert.Equal(t, uint64(40), conns[0].Monotonic.SentBytes)
+	assert.Equal(t, uint64(100), conns[0].Monotonic.RecvBytes)
+
+	require.NoError(t, tr.Remove(&conns[0]))
+	assert.Empty(t, tr.conns)
+}
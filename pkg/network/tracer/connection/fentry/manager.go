@@ -21,7 +21,11 @@ import (
 func initManager(mgr *manager.Manager, config *config.Config, closedHandler *ebpf.PerfHandler) {
 	mgr.Maps = []*manager.Map{
 		{Name: probes.ConnMap},
+		{Name: probes.ConnMapV6},
 		{Name: probes.TCPStatsMap},
+		{Name: probes.QUICStatsMap},
+		{Name: probes.ConnFiltersMap},
+		{Name: probes.ConnFilterPortsMap},
 		{Name: probes.TCPConnectSockPidMap},
 		{Name: probes.ConnCloseBatchMap},
 		{Name: "udp_recv_sock"},
@@ -34,18 +38,33 @@ func initManager(mgr *manager.Manager, config *config.Config, closedHandler *ebp
 		{Name: probes.PidFDBySockMap},
 		{Name: probes.MapErrTelemetryMap},
 		{Name: probes.HelperErrTelemetryMap},
+		{Name: probes.ClassificationProgsMap},
 	}
-	mgr.PerfMaps = []*manager.PerfMap{
-		{
-			Map: manager.Map{Name: probes.ConnCloseEventMap},
-			PerfMapOptions: manager.PerfMapOptions{
-				PerfRingBufferSize: 8 * os.Getpagesize(),
-				Watermark:          1,
-				RecordHandler:      closedHandler.RecordHandler,
-				LostHandler:        closedHandler.LostHandler,
-				RecordGetter:       closedHandler.RecordGetter,
+	closedBufferSize := ebpf.PerfBufferSize(&config.Config, probes.ConnCloseEventMap, 8*os.Getpagesize())
+	mgr.PerfMaps, mgr.RingBuffers = nil, nil
+	if ebpf.UseRingBuffers(&config.Config) {
+		mgr.RingBuffers = []*manager.RingBuffer{
+			{
+				Map: manager.Map{Name: probes.ConnCloseEventMap},
+				RingBufferOptions: manager.RingBufferOptions{
+					RingBufferSize: ebpf.RingBufferSize(closedBufferSize),
+					DataHandler:    closedHandler.RingBufferHandler,
+				},
 			},
-		},
+		}
+	} else {
+		mgr.PerfMaps = []*manager.PerfMap{
+			{
+				Map: manager.Map{Name: probes.ConnCloseEventMap},
+				PerfMapOptions: manager.PerfMapOptions{
+					PerfRingBufferSize: closedBufferSize,
+					Watermark:          1,
+					RecordHandler:      closedHandler.RecordHandler,
+					LostHandler:        closedHandler.LostHandler,
+					RecordGetter:       closedHandler.RecordGetter,
+				},
+			},
+		}
 	}
 
 	for funcName := range programs {
@@ -9,7 +9,11 @@
 package fentry
 
 import (
+	"path/filepath"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
 )
 
 const (
@@ -30,6 +34,8 @@ const (
 
 	// tcpRecvMsgReturn traces the return value for the tcp_recvmsg() system call
 	tcpRecvMsgReturn = "tcp_recvmsg_exit"
+	// tcpReadSockReturn traces the return value for the tcp_read_sock() kernel function
+	tcpReadSockReturn = "tcp_read_sock_exit"
 	// tcpClose traces the tcp_close() system call
 	tcpClose = "tcp_close"
 	// tcpCloseReturn traces the return of tcp_close() system call
@@ -38,10 +44,10 @@ const (
 	// We use the following two probes for UDP
 	udpRecvMsgReturn   = "udp_recvmsg_exit"
 	udpSendMsgReturn   = "udp_sendmsg_exit"
-	udpSendSkb         = "kprobe__udp_send_skb"
+	udpSendSkb         = "udp_send_skb"
 	udpv6RecvMsgReturn = "udpv6_recvmsg_exit"
 	udpv6SendMsgReturn = "udpv6_sendmsg_exit"
-	udpv6SendSkb       = "kprobe__udp_v6_send_skb"
+	udpv6SendSkb       = "udp_v6_send_skb"
 
 	// udpDestroySock traces the udp_destroy_sock() function
 	udpDestroySock = "udp_destroy_sock"
@@ -64,16 +70,22 @@ const (
 
 	// doSendfileRet is the kretprobe used to trace traffic via SENDFILE(2) syscall
 	doSendfileRet = "do_sendfile_exit"
+
+	// netDevQueue traces the net/net_dev_queue tracepoint, which maps the tuple of the packets to the tuple of their
+	// socket for the protocol classification
+	netDevQueue = "net_dev_queue"
 )
 
 var programs = map[string]struct{}{
-	doSendfileRet:        {}, // TODO: available but sockfd_lookup_light not available on some kernels
+	doSendfileRet:        {},
 	inet6BindRet:         {},
 	inetBindRet:          {},
 	inetCskAcceptReturn:  {},
 	inetCskListenStop:    {},
-	sockFDLookupRet:      {}, // TODO: not available on certain kernels, will have to one or more hooks to get equivalent functionality; affects do_sendfile and HTTPS monitoring (OpenSSL/GnuTLS/GoTLS)
+	netDevQueue:          {},
+	sockFDLookupRet:      {},
 	tcpRecvMsgReturn:     {},
+	tcpReadSockReturn:    {},
 	tcpClose:             {},
 	tcpCloseReturn:       {},
 	tcpConnect:           {},
@@ -89,6 +101,11 @@ var programs = map[string]struct{}{
 	udpv6RecvMsgReturn:   {},
 	udpv6SendMsgReturn:   {},
 	udpv6SendSkb:         {},
+
+	probes.ProtocolClassifierEntrySocketFilter:  {},
+	probes.ProtocolClassifierSocketFilter:       {},
+	probes.ProtocolClassifierQueuesSocketFilter: {},
+	probes.ProtocolClassifierDBsSocketFilter:    {},
 }
 
 func enableProgram(enabled map[string]struct{}, name string) {
@@ -101,8 +118,16 @@ func enableProgram(enabled map[string]struct{}, name string) {
 func enabledPrograms(c *config.Config) (map[string]struct{}, error) {
 	enabled := make(map[string]struct{}, 0)
 	if c.CollectTCPConns {
+		if ClassificationSupported(c) {
+			enableProgram(enabled, probes.ProtocolClassifierEntrySocketFilter)
+			enableProgram(enabled, probes.ProtocolClassifierSocketFilter)
+			enableProgram(enabled, probes.ProtocolClassifierQueuesSocketFilter)
+			enableProgram(enabled, probes.ProtocolClassifierDBsSocketFilter)
+			enableProgram(enabled, netDevQueue)
+		}
 		enableProgram(enabled, tcpSendMsgReturn)
 		enableProgram(enabled, tcpRecvMsgReturn)
+		enableProgram(enabled, tcpReadSockReturn)
 		enableProgram(enabled, tcpClose)
 		enableProgram(enabled, tcpCloseReturn)
 		enableProgram(enabled, tcpConnect)
@@ -112,14 +137,13 @@ func enabledPrograms(c *config.Config) (map[string]struct{}, error) {
 		enableProgram(enabled, tcpSetState)
 		enableProgram(enabled, tcpRetransmit)
 
-		// TODO: see comments above on availability for these
-		//       hooks
-		// ksymPath := filepath.Join(c.ProcRoot, "kallsyms")
-		// missing, err := ebpf.VerifyKernelFuncs(ksymPath, []string{"sockfd_lookup_light"})
-		// if err == nil && len(missing) == 0 {
-		// 	enableProgram(enabled, sockFDLookupRet)
-		// 	enableProgram(enabled, doSendfileRet)
-		// }
+		// sockfd_lookup_light is inlined on some kernels, in which case neither it nor do_sendfile can be traced
+		ksymPath := filepath.Join(c.ProcRoot, "kallsyms")
+		missing, err := ebpf.VerifyKernelFuncs(ksymPath, []string{"sockfd_lookup_light"})
+		if err == nil && len(missing) == 0 {
+			enableProgram(enabled, sockFDLookupRet)
+			enableProgram(enabled, doSendfileRet)
+		}
 	}
 
 	if c.CollectUDPConns {
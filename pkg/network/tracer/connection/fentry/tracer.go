@@ -17,17 +17,54 @@ import (
 	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/network/filter"
 	errtelemetry "github.com/DataDog/datadog-agent/pkg/network/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/fargate"
 )
 
 const probeUID = "net"
 
-var ErrorNotSupported = errors.New("fentry tracer is only supported on Fargate")
+var ErrorNotSupported = errors.New("fentry tracer is only supported on Fargate, or when enabled on hosts supporting fentry")
+
+// tailCalls are the programs of the protocol classification, tail called by the socket filter of the classification
+// the same way as in the kprobe tracer
+var tailCalls = []manager.TailCallRoute{
+	{
+		ProgArrayName: probes.ClassificationProgsMap,
+		Key:           uint32(probes.ClassificationDispatcher),
+		ProbeIdentificationPair: manager.ProbeIdentificationPair{
+			EBPFFuncName: probes.ProtocolClassifierSocketFilter,
+			UID:          probeUID,
+		},
+	},
+	{
+		ProgArrayName: probes.ClassificationProgsMap,
+		Key:           uint32(probes.ClassificationQueues),
+		ProbeIdentificationPair: manager.ProbeIdentificationPair{
+			EBPFFuncName: probes.ProtocolClassifierQueuesSocketFilter,
+			UID:          probeUID,
+		},
+	},
+	{
+		ProgArrayName: probes.ClassificationProgsMap,
+		Key:           uint32(probes.ClassificationDBs),
+		ProbeIdentificationPair: manager.ProbeIdentificationPair{
+			EBPFFuncName: probes.ProtocolClassifierDBsSocketFilter,
+			UID:          probeUID,
+		},
+	},
+}
+
+// ClassificationSupported returns true if the protocol classification is enabled. Unlike the kprobe tracer, no kernel
+// version is checked, as the kernels supporting fentry are all recent enough for the classification.
+func ClassificationSupported(config *config.Config) bool {
+	return config.ProtocolClassificationEnabled && config.CollectTCPConns
+}
 
 // LoadTracer loads a new tracer
 func LoadTracer(config *config.Config, m *manager.Manager, mgrOpts manager.Options, perfHandlerTCP *ddebpf.PerfHandler) (func(), error) {
-	if !fargate.IsFargateInstance() {
+	if !fargate.IsFargateInstance() && !(config.EnableFentry && ddebpf.IsFentrySupported()) {
 		return nil, ErrorNotSupported
 	}
 
@@ -36,6 +73,7 @@ func LoadTracer(config *config.Config, m *manager.Manager, mgrOpts manager.Optio
 		filename = "tracer-fentry-debug.o"
 	}
 
+	var closeProtocolClassifierSocketFilterFn func()
 	err := ddebpf.LoadCOREAsset(&config.Config, filename, func(ar bytecode.AssetReader, o manager.Options) error {
 		o.RLimit = mgrOpts.RLimit
 		o.MapSpecEditors = mgrOpts.MapSpecEditors
@@ -49,7 +87,28 @@ func LoadTracer(config *config.Config, m *manager.Manager, mgrOpts manager.Optio
 
 		initManager(m, config, perfHandlerTCP)
 
-		if err := errtelemetry.ActivateBPFTelemetry(m, nil); err != nil {
+		var undefinedProbes []manager.ProbeIdentificationPair
+		if ClassificationSupported(config) {
+			socketFilterProbe, _ := m.GetProbe(manager.ProbeIdentificationPair{
+				EBPFFuncName: probes.ProtocolClassifierEntrySocketFilter,
+				UID:          probeUID,
+			})
+			if socketFilterProbe == nil {
+				return fmt.Errorf("error retrieving protocol classifier socket filter")
+			}
+
+			closeProtocolClassifierSocketFilterFn, err = filter.HeadlessSocketFilter(config, socketFilterProbe)
+			if err != nil {
+				return fmt.Errorf("error enabling protocol classifier: %s", err)
+			}
+
+			for _, tc := range tailCalls {
+				undefinedProbes = append(undefinedProbes, tc.ProbeIdentificationPair)
+			}
+			o.TailCallRouter = append(o.TailCallRouter, tailCalls...)
+		}
+
+		if err := errtelemetry.ActivateBPFTelemetry(m, undefinedProbes); err != nil {
 			return fmt.Errorf("could not activate ebpf telemetry: %w", err)
 		}
 
@@ -62,23 +121,39 @@ func LoadTracer(config *config.Config, m *manager.Manager, mgrOpts manager.Optio
 				o.ExcludedFunctions = append(o.ExcludedFunctions, p.EBPFFuncName)
 			}
 		}
+		tailCallsIdentifiersSet := make(map[manager.ProbeIdentificationPair]struct{}, len(tailCalls))
+		for _, tailCall := range tailCalls {
+			tailCallsIdentifiersSet[tailCall.ProbeIdentificationPair] = struct{}{}
+		}
+
 		for funcName := range enabledProbes {
+			probeIdentifier := manager.ProbeIdentificationPair{
+				EBPFFuncName: funcName,
+				UID:          probeUID,
+			}
+			if _, ok := tailCallsIdentifiersSet[probeIdentifier]; ok {
+				// tail calls should be enabled (a.k.a. not excluded) but not activated.
+				continue
+			}
 			o.ActivatedProbes = append(
 				o.ActivatedProbes,
 				&manager.ProbeSelector{
-					ProbeIdentificationPair: manager.ProbeIdentificationPair{
-						EBPFFuncName: funcName,
-						UID:          probeUID,
-					},
+					ProbeIdentificationPair: probeIdentifier,
 				})
 		}
 
+		if err := ddebpf.EditRingBuffers(m, &o); err != nil {
+			return err
+		}
 		return m.InitWithOptions(ar, o)
 	})
 
 	if err != nil {
+		if closeProtocolClassifierSocketFilterFn != nil {
+			closeProtocolClassifierSocketFilterFn()
+		}
 		return nil, err
 	}
 
-	return nil, nil
+	return closeProtocolClassifierSocketFilterFn, nil
 }
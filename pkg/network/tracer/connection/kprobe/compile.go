@@ -9,6 +9,7 @@
 package kprobe
 
 import (
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode/runtime"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/process/statsd"
@@ -24,9 +25,27 @@ func getRuntimeCompiledTracer(config *config.Config) (runtime.CompiledOutput, er
 func getCFlags(config *config.Config) []string {
 	cflags := []string{"-g"}
 
+	if config.CollectIPv4Conns {
+		cflags = append(cflags, "-DFEATURE_IPV4_ENABLED")
+	}
 	if config.CollectIPv6Conns {
 		cflags = append(cflags, "-DFEATURE_IPV6_ENABLED")
 	}
+	if config.EnableQUICStats {
+		cflags = append(cflags, "-DFEATURE_QUIC_STATS_ENABLED")
+	}
+	if config.EnableKernelConnectionFilters {
+		cflags = append(cflags, "-DFEATURE_CONN_FILTERS_ENABLED")
+	}
+	if config.EnableTCPQueueLengthTracking {
+		cflags = append(cflags, "-DFEATURE_TCP_QUEUE_LENGTH_ENABLED")
+	}
+	if config.EnableTCPCongestionTracking {
+		cflags = append(cflags, "-DFEATURE_TCP_CONGESTION_ENABLED")
+	}
+	if ebpf.UseRingBuffers(&config.Config) {
+		cflags = append(cflags, "-DFEATURE_RING_BUFFERS_ENABLED")
+	}
 	if config.BPFDebug {
 		cflags = append(cflags, "-DDEBUG=1")
 	}
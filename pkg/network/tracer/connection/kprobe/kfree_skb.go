@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package kprobe
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	manager "github.com/DataDog/ebpf-manager"
+)
+
+// KfreeSkbFormatPath is the format of the skb/kfree_skb tracepoint, which lists the offsets of its fields and maps
+// the drop reasons to their names on kernels >= 5.17
+const KfreeSkbFormatPath = "/sys/kernel/debug/tracing/events/skb/kfree_skb/format"
+
+var (
+	// The skb/kfree_skb tracepoint reports the reason of the drops since 5.17.0
+	kfreeSkbReasonKernel = kernel.VersionCode(5, 17, 0)
+	// The skb/kfree_skb tracepoint reports the receiving socket since 6.11.0, before the protocol
+	kfreeSkbRxSkKernel = kernel.VersionCode(6, 11, 0)
+
+	// kfreeSkbFieldRegex matches the fields of the tracepoint format, such as
+	// `field:unsigned short protocol;	offset:24;	size:2;	signed:0;`
+	kfreeSkbFieldRegex = regexp.MustCompile(`field:[^;]*?(\w+);\s*offset:(\d+);`)
+)
+
+// kfreeSkbLayout holds the offsets of the fields of the skb/kfree_skb tracepoint read by the prebuilt tracer. The
+// offset of the reason is 0 when the tracepoint doesn't report it.
+type kfreeSkbLayout struct {
+	skbaddr  uint64
+	protocol uint64
+	reason   uint64
+}
+
+// getKfreeSkbLayout returns the layout of the skb/kfree_skb tracepoint, as described by its format. The layout of the
+// upstream kernels is used if the format can't be read.
+func getKfreeSkbLayout(kv kernel.Version) kfreeSkbLayout {
+	format, err := os.ReadFile(KfreeSkbFormatPath)
+	if err == nil {
+		var layout kfreeSkbLayout
+		if layout, err = parseKfreeSkbLayout(format); err == nil {
+			return layout
+		}
+	}
+	log.Debugf("unable to read the layout of the skb/kfree_skb tracepoint, assuming the one of kernel %s: %s", kv, err)
+	return defaultKfreeSkbLayout(kv)
+}
+
+// parseKfreeSkbLayout returns the offsets of the fields listed by the format of the skb/kfree_skb tracepoint
+func parseKfreeSkbLayout(format []byte) (kfreeSkbLayout, error) {
+	offsets := make(map[string]uint64)
+	for _, match := range kfreeSkbFieldRegex.FindAllSubmatch(format, -1) {
+		offset, err := strconv.ParseUint(string(match[2]), 10, 64)
+		if err != nil {
+			continue
+		}
+		offsets[string(match[1])] = offset
+	}
+
+	layout := kfreeSkbLayout{
+		skbaddr:  offsets["skbaddr"],
+		protocol: offsets["protocol"],
+		reason:   offsets["reason"],
+	}
+	if layout.skbaddr == 0 || layout.protocol == 0 {
+		return kfreeSkbLayout{}, fmt.Errorf("skbaddr or protocol field not found")
+	}
+	return layout, nil
+}
+
+// defaultKfreeSkbLayout returns the layout of the skb/kfree_skb tracepoint of the upstream kernel of the given version
+func defaultKfreeSkbLayout(kv kernel.Version) kfreeSkbLayout {
+	switch {
+	case kv >= kfreeSkbRxSkKernel:
+		return kfreeSkbLayout{skbaddr: 8, protocol: 32, reason: 36}
+	case kv >= kfreeSkbReasonKernel:
+		return kfreeSkbLayout{skbaddr: 8, protocol: 24, reason: 28}
+	default:
+		return kfreeSkbLayout{skbaddr: 8, protocol: 24}
+	}
+}
+
+func (l kfreeSkbLayout) constantEditors() []manager.ConstantEditor {
+	return []manager.ConstantEditor{
+		{Name: "kfree_skb_skbaddr_offset", Value: l.skbaddr},
+		{Name: "kfree_skb_protocol_offset", Value: l.protocol},
+		{Name: "kfree_skb_reason_offset", Value: l.reason},
+	}
+}
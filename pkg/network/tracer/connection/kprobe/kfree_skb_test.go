@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package kprobe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kernel"
+)
+
+const kfreeSkbCommonFields = `name: kfree_skb
+ID: 1465
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:unsigned char common_flags;	offset:2;	size:1;	signed:0;
+	field:unsigned char common_preempt_count;	offset:3;	size:1;	signed:0;
+	field:int common_pid;	offset:4;	size:4;	signed:1;
+
+`
+
+func TestParseKfreeSkbLayout(t *testing.T) {
+	t.Run("without reason", func(t *testing.T) {
+		layout, err := parseKfreeSkbLayout([]byte(kfreeSkbCommonFields + `	field:void * skbaddr;	offset:8;	size:8;	signed:0;
+	field:void * location;	offset:16;	size:8;	signed:0;
+	field:unsigned short protocol;	offset:24;	size:2;	signed:0;
+`))
+		require.NoError(t, err)
+		assert.Equal(t, kfreeSkbLayout{skbaddr: 8, protocol: 24}, layout)
+	})
+
+	t.Run("with reason", func(t *testing.T) {
+		layout, err := parseKfreeSkbLayout([]byte(kfreeSkbCommonFields + `	field:void * skbaddr;	offset:8;	size:8;	signed:0;
+	field:void * location;	offset:16;	size:8;	signed:0;
+	field:unsigned short protocol;	offset:24;	size:2;	signed:0;
+	field:enum skb_drop_reason reason;	offset:28;	size:4;	signed:0;
+`))
+		require.NoError(t, err)
+		assert.Equal(t, kfreeSkbLayout{skbaddr: 8, protocol: 24, reason: 28}, layout)
+	})
+
+	t.Run("with receiving socket", func(t *testing.T) {
+		layout, err := parseKfreeSkbLayout([]byte(kfreeSkbCommonFields + `	field:void * skbaddr;	offset:8;	size:8;	signed:0;
+	field:void * location;	offset:16;	size:8;	signed:0;
+	field:void * rx_sk;	offset:24;	size:8;	signed:0;
+	field:unsigned short protocol;	offset:32;	size:2;	signed:0;
+	field:enum skb_drop_reason reason;	offset:36;	size:4;	signed:0;
+`))
+		require.NoError(t, err)
+		assert.Equal(t, kfreeSkbLayout{skbaddr: 8, protocol: 32, reason: 36}, layout)
+		assert.Equal(t, layout, defaultKfreeSkbLayout(kernel.VersionCode(6, 11, 0)))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseKfreeSkbLayout([]byte(kfreeSkbCommonFields))
+		assert.Error(t, err)
+	})
+}
@@ -27,8 +27,11 @@ const (
 
 var mainProbes = []probes.ProbeFuncName{
 	probes.NetDevQueue,
+	probes.KfreeSkb,
 	probes.ProtocolClassifierEntrySocketFilter,
 	probes.ProtocolClassifierSocketFilter,
+	probes.ProtocolClassifierQueuesSocketFilter,
+	probes.ProtocolClassifierDBsSocketFilter,
 	probes.TCPSendMsg,
 	probes.TCPSendMsgReturn,
 	probes.TCPRecvMsg,
@@ -66,7 +69,12 @@ var mainProbes = []probes.ProbeFuncName{
 func initManager(mgr *manager.Manager, config *config.Config, closedHandler *ebpf.PerfHandler, runtimeTracer bool) {
 	mgr.Maps = []*manager.Map{
 		{Name: probes.ConnMap},
+		{Name: probes.ConnMapV6},
 		{Name: probes.TCPStatsMap},
+		{Name: probes.QUICStatsMap},
+		{Name: probes.ConnFiltersMap},
+		{Name: probes.ConnFilterPortsMap},
+		{Name: probes.TCPDropReasonsMap},
 		{Name: probes.TCPConnectSockPidMap},
 		{Name: probes.ConnCloseBatchMap},
 		{Name: "udp_recv_sock"},
@@ -86,17 +94,31 @@ func initManager(mgr *manager.Manager, config *config.Config, closedHandler *ebp
 		{Name: probes.TcpRecvMsgArgsMap},
 		{Name: probes.ClassificationProgsMap},
 	}
-	mgr.PerfMaps = []*manager.PerfMap{
-		{
-			Map: manager.Map{Name: probes.ConnCloseEventMap},
-			PerfMapOptions: manager.PerfMapOptions{
-				PerfRingBufferSize: 8 * os.Getpagesize(),
-				Watermark:          1,
-				RecordHandler:      closedHandler.RecordHandler,
-				LostHandler:        closedHandler.LostHandler,
-				RecordGetter:       closedHandler.RecordGetter,
+	closedBufferSize := ebpf.PerfBufferSize(&config.Config, probes.ConnCloseEventMap, 8*os.Getpagesize())
+	mgr.PerfMaps, mgr.RingBuffers = nil, nil
+	if ebpf.UseRingBuffers(&config.Config) {
+		mgr.RingBuffers = []*manager.RingBuffer{
+			{
+				Map: manager.Map{Name: probes.ConnCloseEventMap},
+				RingBufferOptions: manager.RingBufferOptions{
+					RingBufferSize: ebpf.RingBufferSize(closedBufferSize),
+					DataHandler:    closedHandler.RingBufferHandler,
+				},
 			},
-		},
+		}
+	} else {
+		mgr.PerfMaps = []*manager.PerfMap{
+			{
+				Map: manager.Map{Name: probes.ConnCloseEventMap},
+				PerfMapOptions: manager.PerfMapOptions{
+					PerfRingBufferSize: closedBufferSize,
+					Watermark:          1,
+					RecordHandler:      closedHandler.RecordHandler,
+					LostHandler:        closedHandler.LostHandler,
+					RecordGetter:       closedHandler.RecordGetter,
+				},
+			},
+		}
 	}
 	for _, funcName := range mainProbes {
 		p := &manager.Probe{
@@ -32,15 +32,34 @@ var (
 	// socket filter, and a tracepoint (4.7.0+).
 	classificationMinimumKernel = kernel.VersionCode(4, 7, 0)
 
+	// The tracking of TCP drops requires tracepoints (4.7.0+) and LRU maps (4.10.0+)
+	tcpDropTrackingMinimumKernel = kernel.VersionCode(4, 10, 0)
+
 	tailCalls = []manager.TailCallRoute{
 		{
 			ProgArrayName: probes.ClassificationProgsMap,
-			Key:           0,
+			Key:           uint32(probes.ClassificationDispatcher),
 			ProbeIdentificationPair: manager.ProbeIdentificationPair{
 				EBPFFuncName: probes.ProtocolClassifierSocketFilter,
 				UID:          probeUID,
 			},
 		},
+		{
+			ProgArrayName: probes.ClassificationProgsMap,
+			Key:           uint32(probes.ClassificationQueues),
+			ProbeIdentificationPair: manager.ProbeIdentificationPair{
+				EBPFFuncName: probes.ProtocolClassifierQueuesSocketFilter,
+				UID:          probeUID,
+			},
+		},
+		{
+			ProgArrayName: probes.ClassificationProgsMap,
+			Key:           uint32(probes.ClassificationDBs),
+			ProbeIdentificationPair: manager.ProbeIdentificationPair{
+				EBPFFuncName: probes.ProtocolClassifierDBsSocketFilter,
+				UID:          probeUID,
+			},
+		},
 	}
 )
 
@@ -63,6 +82,25 @@ func ClassificationSupported(config *config.Config) bool {
 	return currentKernelVersion >= classificationMinimumKernel
 }
 
+// TCPDropTrackingSupported returns true if the packets of the TCP connections dropped by the kernel can be tracked.
+// The prebuilt tracer finds the socket of the dropped packets with an offset guessed along with the ones of the
+// protocol classification, so it requires the classification to be supported as well.
+func TCPDropTrackingSupported(config *config.Config, runtimeTracer bool) bool {
+	if !config.EnableTCPDropTracking || !config.CollectTCPConns {
+		return false
+	}
+	if !runtimeTracer && !ClassificationSupported(config) {
+		return false
+	}
+	currentKernelVersion, err := kernel.HostVersion()
+	if err != nil {
+		log.Warn("could not determine the current kernel version. tcp drop tracking disabled.")
+		return false
+	}
+
+	return currentKernelVersion >= tcpDropTrackingMinimumKernel
+}
+
 // LoadTracer loads the prebuilt or runtime compiled tracer, depending on config
 func LoadTracer(config *config.Config, m *manager.Manager, mgrOpts manager.Options, perfHandlerTCP *ddebpf.PerfHandler) (func(), error) {
 	kprobeAttachMethod := manager.AttachKprobeWithPerfEventOpen
@@ -103,8 +141,31 @@ func LoadTracer(config *config.Config, m *manager.Manager, mgrOpts manager.Optio
 		return nil, fmt.Errorf("invalid probe configuration: %v", err)
 	}
 
+	if config.EnableTCPQueueLengthTracking && !runtimeTracer {
+		log.Warn("tcp queue length tracking is only supported by the runtime compiled tracer, the queue lengths won't be reported")
+	}
+	if config.EnableTCPCongestionTracking && !runtimeTracer {
+		log.Warn("tcp congestion tracking is only supported by the runtime compiled tracer, the congestion state won't be reported")
+	}
+
 	initManager(m, config, perfHandlerTCP, runtimeTracer)
 
+	if _, enabled := enabledProbes[probes.KfreeSkb]; enabled {
+		kv, err := kernel.HostVersion()
+		if err != nil {
+			return nil, err
+		}
+		mgrOpts.ConstantEditors = append(mgrOpts.ConstantEditors, getKfreeSkbLayout(kv).constantEditors()...)
+	} else {
+		// Kernels < 4.10.0 do not know about the LRU map used to track the drops, preventing the program to load
+		// even though we won't use it. We change the type to a simple hash map to circumvent that.
+		mgrOpts.MapSpecEditors[probes.TCPDropReasonsMap] = manager.MapSpecEditor{
+			Type:       ebpf.Hash,
+			MaxEntries: 1,
+			EditorFlag: manager.EditType | manager.EditMaxEntries,
+		}
+	}
+
 	telemetryMapKeys := errtelemetry.BuildTelemetryKeys(m)
 	mgrOpts.ConstantEditors = append(mgrOpts.ConstantEditors, telemetryMapKeys...)
 
@@ -125,7 +186,9 @@ func LoadTracer(config *config.Config, m *manager.Manager, mgrOpts manager.Optio
 			return nil, fmt.Errorf("error enabling protocol classifier: %s", err)
 		}
 
-		undefinedProbes = append(undefinedProbes, tailCalls[0].ProbeIdentificationPair)
+		for _, tc := range tailCalls {
+			undefinedProbes = append(undefinedProbes, tc.ProbeIdentificationPair)
+		}
 		mgrOpts.TailCallRouter = append(mgrOpts.TailCallRouter, tailCalls...)
 	} else {
 		// Kernels < 4.7.0 do not know about the per-cpu array map used
@@ -170,6 +233,9 @@ func LoadTracer(config *config.Config, m *manager.Manager, mgrOpts manager.Optio
 			})
 	}
 
+	if err := ddebpf.EditRingBuffers(m, &mgrOpts); err != nil {
+		return nil, err
+	}
 	if err := m.InitWithOptions(buf, mgrOpts); err != nil {
 		return nil, fmt.Errorf("failed to init ebpf manager: %v", err)
 	}
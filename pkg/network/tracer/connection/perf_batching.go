@@ -155,6 +155,8 @@ func (p *perfBatchManager) extractBatchInto(buffer *network.ConnectionBuffer, b
 		conn := buffer.Next()
 		populateConnStats(conn, &ct.Tup, &ct.Conn_stats)
 		updateTCPStats(conn, ct.Conn_stats.Cookie, &ct.Tcp_stats)
+		// the stats of the closed QUIC flows are carried by their TCP stats, see flush_quic_stats
+		updateQUICStats(conn, ct.Tcp_stats.Retransmits, ct.Tcp_stats.Rtt, ct.Tcp_stats.Rtt_var)
 	}
 }
 
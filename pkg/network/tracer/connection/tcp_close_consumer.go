@@ -97,7 +97,8 @@ func (c *tcpCloseConsumer) Start(callback func([]network.ConnectionStats)) {
 
 				c.perfReceived.Inc()
 				batch := netebpf.ToBatch(batchData.Data)
-				c.batchManager.ExtractBatchInto(c.buffer, batch, batchData.CPU)
+				// the batch tells its CPU, as the events of a ring buffer are all read as coming from CPU 0
+				c.batchManager.ExtractBatchInto(c.buffer, batch, int(batch.Cpu))
 				closedCount += c.buffer.Len()
 				callback(c.buffer.Connections())
 				c.buffer.Reset()
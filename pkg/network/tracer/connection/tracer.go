@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection/kprobe"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/atomicstats"
+	"github.com/DataDog/datadog-agent/pkg/util/fargate"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	manager "github.com/DataDog/ebpf-manager"
 )
@@ -39,6 +40,8 @@ type TracerType int
 const (
 	EBPFKProbe TracerType = iota
 	EBPFFentry
+	// EBPFLess tracks the connections from the packets read through a packet socket, without any eBPF program
+	EBPFLess
 )
 
 // Tracer is the common interface implemented by all connection tracers.
@@ -74,9 +77,13 @@ const (
 type tracer struct {
 	m *manager.Manager
 
-	conns    *ebpf.Map
-	tcpStats *ebpf.Map
-	config   *config.Config
+	conns     *ebpf.Map
+	conns6    *ebpf.Map
+	tcpStats  *ebpf.Map
+	quicStats *ebpf.Map
+	// tcpDropReasons is only set when the TCP drops are tracked
+	tcpDropReasons *ebpf.Map
+	config         *config.Config
 
 	// tcp_close events
 	closeConsumer *tcpCloseConsumer
@@ -108,6 +115,25 @@ func newTelemetry() telemetry {
 
 // NewTracer creates a new tracer
 func NewTracer(config *config.Config, constants []manager.ConstantEditor, bpfTelemetry *errtelemetry.EBPFTelemetry) (Tracer, error) {
+	maxConns4, maxConns6 := maxTrackedConnectionsPerFamily(config)
+
+	// the constants are shared with the caller, so they are appended to a copy
+	constants = constants[:len(constants):len(constants)]
+	if config.CollectIPv4Conns {
+		constants = append(constants, manager.ConstantEditor{Name: "ipv4_enabled", Value: uint64(1)})
+	}
+	if config.EnableQUICStats {
+		constants = append(constants, manager.ConstantEditor{Name: "quic_stats_enabled", Value: uint64(1)})
+	}
+
+	var filters *connFilters
+	if config.EnableKernelConnectionFilters {
+		filters = newConnFilters(network.ParseConnectionFilters(config.ExcludedSourceConnections), network.ParseConnectionFilters(config.ExcludedDestinationConnections))
+		constants = append(constants, manager.ConstantEditor{Name: "conn_filters_enabled", Value: uint64(1)})
+	} else {
+		filters = newConnFilters(nil, nil)
+	}
+
 	mgrOptions := manager.Options{
 		// Extend RLIMIT_MEMLOCK (8) size
 		// On some systems, the default for RLIMIT_MEMLOCK may be as low as 64 bytes.
@@ -120,8 +146,11 @@ func NewTracer(config *config.Config, constants []manager.ConstantEditor, bpfTel
 			Max: math.MaxUint64,
 		},
 		MapSpecEditors: map[string]manager.MapSpecEditor{
-			string(probes.ConnMap):                           {Type: ebpf.Hash, MaxEntries: uint32(config.MaxTrackedConnections), EditorFlag: manager.EditMaxEntries},
-			string(probes.TCPStatsMap):                       {Type: ebpf.Hash, MaxEntries: uint32(config.MaxTrackedConnections), EditorFlag: manager.EditMaxEntries},
+			string(probes.ConnMap):                           {Type: ebpf.Hash, MaxEntries: maxConns4, EditorFlag: manager.EditMaxEntries},
+			string(probes.ConnMapV6):                         {Type: ebpf.Hash, MaxEntries: maxConns6, EditorFlag: manager.EditMaxEntries},
+			string(probes.TCPStatsMap):                       {Type: ebpf.Hash, MaxEntries: maxConns4 + maxConns6, EditorFlag: manager.EditMaxEntries},
+			string(probes.QUICStatsMap):                      {Type: ebpf.Hash, MaxEntries: quicStatsMaxEntries(config), EditorFlag: manager.EditMaxEntries},
+			string(probes.TCPDropReasonsMap):                 {Type: ebpf.LRUHash, MaxEntries: tcpDropReasonsMaxEntries(config), EditorFlag: manager.EditMaxEntries},
 			string(probes.PortBindingsMap):                   {Type: ebpf.Hash, MaxEntries: uint32(config.MaxTrackedConnections), EditorFlag: manager.EditMaxEntries},
 			string(probes.UDPPortBindingsMap):                {Type: ebpf.Hash, MaxEntries: uint32(config.MaxTrackedConnections), EditorFlag: manager.EditMaxEntries},
 			string(probes.SockByPidFDMap):                    {Type: ebpf.Hash, MaxEntries: uint32(config.MaxTrackedConnections), EditorFlag: manager.EditMaxEntries},
@@ -130,6 +159,9 @@ func NewTracer(config *config.Config, constants []manager.ConstantEditor, bpfTel
 			string(probes.ConnectionTupleToSocketSKBConnMap): {Type: ebpf.Hash, MaxEntries: uint32(config.MaxTrackedConnections), EditorFlag: manager.EditMaxEntries}},
 		ConstantEditors: constants,
 	}
+	for name, editor := range filters.mapSpecEditors(config) {
+		mgrOptions.MapSpecEditors[name] = editor
+	}
 
 	closedChannelSize := defaultClosedChannelSize
 	if config.ClosedChannelSize > 0 {
@@ -144,8 +176,14 @@ func NewTracer(config *config.Config, constants []manager.ConstantEditor, bpfTel
 	var closeTracerFn func()
 	closeTracerFn, err := fentry.LoadTracer(config, m, mgrOptions, perfHandlerTCP)
 	if err != nil && !errors.Is(err, fentry.ErrorNotSupported) {
-		// failed to load fentry tracer
-		return nil, err
+		// failed to load fentry tracer; kprobes are not available on Fargate
+		if fargate.IsFargateInstance() {
+			return nil, err
+		}
+		log.Warnf("could not load fentry tracer: %s", err)
+		m = &manager.Manager{
+			DumpHandler: dumpMapsHandler,
+		}
 	}
 
 	if err != nil {
@@ -185,12 +223,39 @@ func NewTracer(config *config.Config, constants []manager.ConstantEditor, bpfTel
 		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.ConnMap, err)
 	}
 
+	tr.conns6, _, err = m.GetMap(string(probes.ConnMapV6))
+	if err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.ConnMapV6, err)
+	}
+
 	tr.tcpStats, _, err = m.GetMap(string(probes.TCPStatsMap))
 	if err != nil {
 		tr.Stop()
 		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.TCPStatsMap, err)
 	}
 
+	tr.quicStats, _, err = m.GetMap(string(probes.QUICStatsMap))
+	if err != nil {
+		tr.Stop()
+		return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.QUICStatsMap, err)
+	}
+
+	if config.EnableTCPDropTracking {
+		tr.tcpDropReasons, _, err = m.GetMap(string(probes.TCPDropReasonsMap))
+		if err != nil {
+			tr.Stop()
+			return nil, fmt.Errorf("error retrieving the bpf %s map: %s", probes.TCPDropReasonsMap, err)
+		}
+	}
+
+	if config.EnableKernelConnectionFilters {
+		if err := filters.load(m); err != nil {
+			tr.Stop()
+			return nil, err
+		}
+	}
+
 	if bpfTelemetry != nil {
 		bpfTelemetry.MapErrMap = tr.GetMap(string(probes.MapErrTelemetryMap))
 		bpfTelemetry.HelperErrMap = tr.GetMap(string(probes.HelperErrTelemetryMap))
@@ -204,6 +269,37 @@ func NewTracer(config *config.Config, constants []manager.ConstantEditor, bpfTel
 	return tr, nil
 }
 
+// maxTrackedConnectionsPerFamily returns the sizes of the maps of the IPv4 and IPv6 connections. The map of a family
+// which isn't collected is left empty.
+func maxTrackedConnectionsPerFamily(config *config.Config) (uint32, uint32) {
+	maxConns4, maxConns6 := uint32(1), uint32(1)
+	if config.CollectIPv4Conns {
+		maxConns4 = uint32(config.MaxTrackedConnections)
+	}
+	if config.CollectIPv6Conns {
+		maxConns6 = uint32(config.MaxTrackedIPv6Connections)
+	}
+	return maxConns4, maxConns6
+}
+
+// quicStatsMaxEntries returns the size of the map of the stats of the QUIC flows, which is left empty when they aren't
+// collected
+func quicStatsMaxEntries(config *config.Config) uint32 {
+	if !config.EnableQUICStats {
+		return 1
+	}
+	return uint32(config.MaxTrackedConnections)
+}
+
+// tcpDropReasonsMaxEntries returns the size of the map of the drops per connection and reason, which is left empty
+// when the drops aren't tracked
+func tcpDropReasonsMaxEntries(config *config.Config) uint32 {
+	if !config.EnableTCPDropTracking {
+		return 1
+	}
+	return uint32(config.MaxTrackedConnections)
+}
+
 func (t *tracer) Start(callback func([]network.ConnectionStats)) (err error) {
 	defer func() {
 		if err != nil {
@@ -216,8 +312,13 @@ func (t *tracer) Start(callback func([]network.ConnectionStats)) (err error) {
 		return fmt.Errorf("error initializing port binding maps: %s", err)
 	}
 
-	if err := t.m.Start(); err != nil {
-		return fmt.Errorf("could not start ebpf manager: %s", err)
+	err = t.m.Start()
+	failures := ddebpf.RecordAttachFailures("npm", t.m)
+	if err != nil {
+		return fmt.Errorf("could not start ebpf manager: %w", ddebpf.AttachFailuresError(err, failures))
+	}
+	for _, f := range failures {
+		log.Warn(f)
 	}
 
 	t.closeConsumer.Start(callback)
@@ -241,6 +342,7 @@ func (t *tracer) Stop() {
 func (t *tracer) GetMap(name string) *ebpf.Map {
 	switch name {
 	case string(probes.SockByPidFDMap):
+	case string(probes.TCPDropReasonsMap):
 	case string(probes.MapErrTelemetryMap):
 	case string(probes.HelperErrTelemetryMap):
 	default:
@@ -258,25 +360,31 @@ func (t *tracer) GetConnections(buffer *network.ConnectionBuffer, filter func(*n
 	// Cached objects
 	conn := new(network.ConnectionStats)
 	tcp := new(netebpf.TCPStats)
+	quic := new(netebpf.QUICStats)
 
 	tel := newTelemetry()
-	entries := t.conns.Iterate()
-	for entries.Next(unsafe.Pointer(key), unsafe.Pointer(stats)) {
-		populateConnStats(conn, key, stats)
-
-		tel.addConnection(conn)
-
-		if filter != nil && !filter(conn) {
-			continue
+	for _, conns := range []*ebpf.Map{t.conns, t.conns6} {
+		entries := conns.Iterate()
+		for entries.Next(unsafe.Pointer(key), unsafe.Pointer(stats)) {
+			populateConnStats(conn, key, stats)
+
+			tel.addConnection(conn)
+
+			if filter != nil && !filter(conn) {
+				continue
+			}
+			if t.getTCPStats(tcp, key, seen) {
+				updateTCPStats(conn, stats.Cookie, tcp)
+			}
+			if t.getQUICStats(quic, conn) {
+				updateQUICStats(conn, quic.Retransmits, quic.Rtt, quic.Rtt_var)
+			}
+			*buffer.Next() = *conn
 		}
-		if t.getTCPStats(tcp, key, seen) {
-			updateTCPStats(conn, stats.Cookie, tcp)
-		}
-		*buffer.Next() = *conn
-	}
 
-	if err := entries.Err(); err != nil {
-		return fmt.Errorf("unable to iterate connection map: %s", err)
+		if err := entries.Err(); err != nil {
+			return fmt.Errorf("unable to iterate connection map: %s", err)
+		}
 	}
 
 	t.telemetry.assign(tel)
@@ -339,8 +447,10 @@ func (t *tracer) Remove(conn *network.ConnectionStats) error {
 	t.removeTuple.Saddr_l, t.removeTuple.Saddr_h = util.ToLowHigh(conn.Source)
 	t.removeTuple.Daddr_l, t.removeTuple.Daddr_h = util.ToLowHigh(conn.Dest)
 
+	conns := t.conns
 	if conn.Family == network.AFINET6 {
 		t.removeTuple.Metadata = uint32(netebpf.IPv6)
+		conns = t.conns6
 	} else {
 		t.removeTuple.Metadata = uint32(netebpf.IPv4)
 	}
@@ -350,7 +460,7 @@ func (t *tracer) Remove(conn *network.ConnectionStats) error {
 		t.removeTuple.Metadata |= uint32(netebpf.UDP)
 	}
 
-	err := t.conns.Delete(unsafe.Pointer(t.removeTuple))
+	err := conns.Delete(unsafe.Pointer(t.removeTuple))
 	if err != nil {
 		// If this entry no longer exists in the eBPF map it means `tcp_close` has executed
 		// during this function call. In that case state.StoreClosedConnection() was already called for this connection,
@@ -364,8 +474,21 @@ func (t *tracer) Remove(conn *network.ConnectionStats) error {
 
 	// We have to remove the PID to remove the element from the TCP Map since we don't use the pid there
 	t.removeTuple.Pid = 0
-	// We can ignore the error for this map since it will not always contain the entry
+	// We can ignore the error for these maps since they will not always contain the entry
 	_ = t.tcpStats.Delete(unsafe.Pointer(t.removeTuple))
+	if t.tcpDropReasons != nil {
+		_ = t.tcpDropReasons.Delete(unsafe.Pointer(t.removeTuple))
+	}
+
+	// The QUIC flows are keyed by the tuple of the Initial packet of their client, without the network namespace, see
+	// quic_stats_t
+	if conn.Protocol == network.ProtocolHTTP3 {
+		t.removeTuple.Netns = 0
+		if t.quicStats.Delete(unsafe.Pointer(t.removeTuple)) != nil {
+			flipTuple(t.removeTuple)
+			_ = t.quicStats.Delete(unsafe.Pointer(t.removeTuple))
+		}
+	}
 
 	return nil
 }
@@ -393,13 +516,16 @@ func (t *tracer) GetTelemetry() map[string]int64 {
 		"closed_conn_polling_received": closeStats[perfReceivedStat],
 		"pid_collisions":               pidCollisions,
 
-		"tcp_failed_connects": int64(telemetry.Tcp_failed_connect),
-		"tcp_sent_miscounts":  int64(telemetry.Tcp_sent_miscounts),
-		"missed_tcp_close":    int64(telemetry.Missed_tcp_close),
-		"missed_udp_close":    int64(telemetry.Missed_udp_close),
-		"udp_sends_processed": int64(telemetry.Udp_sends_processed),
-		"udp_sends_missed":    int64(telemetry.Udp_sends_missed),
-		"udp_dropped_conns":   int64(telemetry.Udp_dropped_conns),
+		"tcp_failed_connects":    int64(telemetry.Tcp_failed_connect),
+		"tcp_failed_connects_v6": int64(telemetry.Tcp_failed_connect_v6),
+		"tcp_sent_miscounts":     int64(telemetry.Tcp_sent_miscounts),
+		"missed_tcp_close":       int64(telemetry.Missed_tcp_close),
+		"missed_udp_close":       int64(telemetry.Missed_udp_close),
+		"udp_sends_processed":    int64(telemetry.Udp_sends_processed),
+		"udp_sends_missed":       int64(telemetry.Udp_sends_missed),
+		"udp_dropped_conns":      int64(telemetry.Udp_dropped_conns),
+		"tcp_reused_tuples":      int64(telemetry.Tcp_reused_tuples),
+		"quic_failed_handshakes": int64(telemetry.Quic_failed_handshakes),
 	}
 
 	for k, v := range t.telemetry.get() {
@@ -476,6 +602,7 @@ func (t *tracer) getTCPStats(stats *netebpf.TCPStats, tuple *netebpf.ConnTuple,
 		if _, reported := seen[*tuple]; reported {
 			t.pidCollisions.Inc()
 			stats.Retransmits = 0
+			stats.Drops = 0
 			stats.State_transitions = 0
 		} else {
 			seen[*tuple] = struct{}{}
@@ -486,6 +613,37 @@ func (t *tracer) getTCPStats(stats *netebpf.TCPStats, tuple *netebpf.ConnTuple,
 	return true
 }
 
+// getQUICStats looks up the stats of a UDP flow carrying QUIC, which are keyed by the tuple of the Initial packet of
+// its client, without the PID and the network namespace
+func (t *tracer) getQUICStats(stats *netebpf.QUICStats, conn *network.ConnectionStats) bool {
+	if conn.Type != network.UDP || conn.Protocol != network.ProtocolHTTP3 {
+		return false
+	}
+
+	tuple := netebpf.ConnTuple{Sport: conn.SPort, Dport: conn.DPort}
+	tuple.Saddr_l, tuple.Saddr_h = util.ToLowHigh(conn.Source)
+	tuple.Daddr_l, tuple.Daddr_h = util.ToLowHigh(conn.Dest)
+	tuple.Metadata = uint32(netebpf.UDP)
+	if conn.Family == network.AFINET6 {
+		tuple.Metadata |= uint32(netebpf.IPv6)
+	} else {
+		tuple.Metadata |= uint32(netebpf.IPv4)
+	}
+
+	if t.quicStats.Lookup(unsafe.Pointer(&tuple), unsafe.Pointer(stats)) == nil {
+		return true
+	}
+	flipTuple(&tuple)
+	return t.quicStats.Lookup(unsafe.Pointer(&tuple), unsafe.Pointer(stats)) == nil
+}
+
+// flipTuple swaps the source and the destination of a tuple
+func flipTuple(tuple *netebpf.ConnTuple) {
+	tuple.Sport, tuple.Dport = tuple.Dport, tuple.Sport
+	tuple.Saddr_l, tuple.Daddr_l = tuple.Daddr_l, tuple.Saddr_l
+	tuple.Saddr_h, tuple.Daddr_h = tuple.Daddr_h, tuple.Saddr_h
+}
+
 func populateConnStats(stats *network.ConnectionStats, t *netebpf.ConnTuple, s *netebpf.ConnStats) {
 	*stats = network.ConnectionStats{
 		Pid:    t.Pid,
@@ -535,14 +693,37 @@ func populateConnStats(stats *network.ConnectionStats, t *netebpf.ConnTuple, s *
 	}
 }
 
+// updateQUICStats sets the stats of a UDP flow carrying QUIC: its retransmitted Initial packets and its RTT
+func updateQUICStats(conn *network.ConnectionStats, retransmits, rtt, rttVar uint32) {
+	if conn.Type != network.UDP {
+		return
+	}
+
+	conn.Monotonic.Retransmits = retransmits
+	conn.RTT = rtt
+	conn.RTTVar = rttVar
+}
+
 func updateTCPStats(conn *network.ConnectionStats, cookie uint32, tcpStats *netebpf.TCPStats) {
 	if conn.Type != network.TCP {
 		return
 	}
 
 	conn.Monotonic.Retransmits = tcpStats.Retransmits
+	conn.Monotonic.TCPDrops = tcpStats.Drops
 	conn.Monotonic.TCPEstablished = uint32(tcpStats.State_transitions >> netebpf.Established & 1)
 	conn.Monotonic.TCPClosed = uint32(tcpStats.State_transitions >> netebpf.Close & 1)
 	conn.RTT = tcpStats.Rtt
 	conn.RTTVar = tcpStats.Rtt_var
+	if tcpStats.Queue_samples > 0 {
+		conn.RecvQueueMax = tcpStats.Rcv_queue_max
+		conn.RecvQueueAvg = uint32(tcpStats.Rcv_queue_sum / uint64(tcpStats.Queue_samples))
+		conn.SendQueueMax = tcpStats.Snd_queue_max
+		conn.SendQueueAvg = uint32(tcpStats.Snd_queue_sum / uint64(tcpStats.Queue_samples))
+	}
+	if name := tcpStats.CongestionAlgorithm(); name != "" {
+		conn.CongestionAlgorithm = name
+		conn.SndCwnd = tcpStats.Snd_cwnd
+		conn.SndSsthresh = tcpStats.SndSsthresh()
+	}
 }
@@ -26,6 +26,7 @@ import (
 
 	manager "github.com/DataDog/ebpf-manager"
 
+	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
@@ -62,6 +63,7 @@ func newEbpfConntrackerStats() ebpfConntrackerStats {
 type ebpfConntracker struct {
 	m            *manager.Manager
 	ctMap        *ebpf.Map
+	sockLBMap    *ebpf.Map
 	telemetryMap *ebpf.Map
 	rootNS       uint32
 	// only kept around for stats purposes from initial dump
@@ -115,6 +117,12 @@ func NewEBPFConntracker(cfg *config.Config, bpfTelemetry *errtelemetry.EBPFTelem
 		return nil, fmt.Errorf("unable to get conntrack map: %w", err)
 	}
 
+	sockLBMap, _, err := m.GetMap(probes.SockLBMap)
+	if err != nil {
+		_ = m.Stop(manager.CleanAll)
+		return nil, fmt.Errorf("unable to get socket load balancing map: %w", err)
+	}
+
 	telemetryMap, _, err := m.GetMap(probes.ConntrackTelemetryMap)
 	if err != nil {
 		_ = m.Stop(manager.CleanAll)
@@ -129,6 +137,7 @@ func NewEBPFConntracker(cfg *config.Config, bpfTelemetry *errtelemetry.EBPFTelem
 	e := &ebpfConntracker{
 		m:            m,
 		ctMap:        ctMap,
+		sockLBMap:    sockLBMap,
 		telemetryMap: telemetryMap,
 		rootNS:       rootNS,
 		stats:        newEbpfConntrackerStats(),
@@ -245,6 +254,42 @@ func (e *ebpfConntracker) GetTranslationForConn(stats network.ConnectionStats) *
 	}
 }
 
+// GetSocketLBDestination returns the destination a connection was initially connected to, when it was translated at
+// connect time by a cgroup/connect4 program rather than by conntrack, as done by the socket-level load balancing of
+// Cilium's kube-proxy replacement
+func (e *ebpfConntracker) GetSocketLBDestination(stats network.ConnectionStats) (util.Address, uint16, bool) {
+	if stats.Type != network.TCP || stats.Family != network.AFINET {
+		return util.Address{}, 0, false
+	}
+
+	src := tuplePool.Get().(*netebpf.ConntrackTuple)
+	defer tuplePool.Put(src)
+	dst := tuplePool.Get().(*netebpf.ConntrackTuple)
+	defer tuplePool.Put(dst)
+
+	toConntrackTupleFromStats(src, &stats)
+	src.Netns = stats.NetNS
+	if err := e.sockLBMap.Lookup(unsafe.Pointer(src), unsafe.Pointer(dst)); err != nil {
+		if !errors.Is(err, ebpf.ErrKeyNotExist) {
+			log.Warnf("error looking up connection in ebpf socket load balancing map: %s", err)
+		}
+		return util.Address{}, 0, false
+	}
+	return dst.DestAddress(), dst.Dport, true
+}
+
+// DeleteSocketLBDestination deletes the destination a connection was initially connected to
+func (e *ebpfConntracker) DeleteSocketLBDestination(stats network.ConnectionStats) {
+	key := tuplePool.Get().(*netebpf.ConntrackTuple)
+	defer tuplePool.Put(key)
+
+	toConntrackTupleFromStats(key, &stats)
+	key.Netns = stats.NetNS
+	if err := e.sockLBMap.Delete(unsafe.Pointer(key)); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+		log.Warnf("unable to delete socket load balancing entry from eBPF map: %s", err)
+	}
+}
+
 func (*ebpfConntracker) IsSampling() bool {
 	return false
 }
@@ -297,6 +342,7 @@ func (e *ebpfConntracker) GetStats() map[string]int64 {
 		log.Tracef("error retrieving the telemetry struct: %s", err)
 	} else {
 		m["registers_total"] = int64(telemetry.Registers)
+		m["deletes_total"] = int64(telemetry.Deletes)
 	}
 
 	gets := e.stats.gets.Load()
@@ -383,6 +429,7 @@ func getManager(cfg *config.Config, buf io.ReaderAt, maxStateSize int, mapErrTel
 		Maps: []*manager.Map{
 			{Name: probes.ConntrackMap},
 			{Name: probes.ConntrackTelemetryMap},
+			{Name: probes.SockLBMap},
 		},
 		PerfMaps: []*manager.PerfMap{},
 		Probes: []*manager.Probe{
@@ -398,6 +445,12 @@ func getManager(cfg *config.Config, buf io.ReaderAt, maxStateSize int, mapErrTel
 					UID:          "conntracker",
 				},
 			},
+			{
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: probes.ConntrackDelete,
+					UID:          "conntracker",
+				},
+			},
 		},
 	}
 
@@ -405,6 +458,27 @@ func getManager(cfg *config.Config, buf io.ReaderAt, maxStateSize int, mapErrTel
 	if err != nil {
 		return nil, errors.New("failed to detect kernel version")
 	}
+	// the netfilter functions belong to kernel modules, and are therefore always traced with kprobes, while the
+	// socket-level load balancing probes use fentry/fexit on the hosts supporting them
+	sockLBProbes := []string{probes.ConntrackTCPv4PreConnect, probes.ConntrackTCPv4PreConnectReturn, probes.ConntrackTCPConnectSockLB}
+	sockLBFentryProbes := []string{probes.ConntrackTCPv4PreConnectFentry, probes.ConntrackTCPv4PreConnectFexit, probes.ConntrackTCPConnectSockLBFentry}
+	var excludedFunctions []string
+	if cfg.EnableFentry && ddebpf.IsFentrySupported() {
+		sockLBProbes, excludedFunctions = sockLBFentryProbes, sockLBProbes
+	} else {
+		excludedFunctions = sockLBFentryProbes
+	}
+	// cgroup/connect4 programs are run by tcp_v4_pre_connect since 4.17
+	if currKernelVersion >= kernel.VersionCode(4, 17, 0) {
+		for _, funcName := range sockLBProbes {
+			mgr.Probes = append(mgr.Probes, &manager.Probe{
+				ProbeIdentificationPair: manager.ProbeIdentificationPair{
+					EBPFFuncName: funcName,
+					UID:          "conntracker",
+				},
+			})
+		}
+	}
 	activateBPFTelemetry := currKernelVersion >= kernel.VersionCode(4, 14, 0)
 	mgr.InstructionPatcher = func(m *manager.Manager) error {
 		return errtelemetry.PatchEBPFTelemetry(m, activateBPFTelemetry, []manager.ProbeIdentificationPair{})
@@ -430,9 +504,11 @@ func getManager(cfg *config.Config, buf io.ReaderAt, maxStateSize int, mapErrTel
 		},
 		MapSpecEditors: map[string]manager.MapSpecEditor{
 			probes.ConntrackMap: {Type: ebpf.Hash, MaxEntries: uint32(cfg.ConntrackMaxStateSize), EditorFlag: manager.EditMaxEntries},
+			probes.SockLBMap:    {Type: ebpf.LRUHash, MaxEntries: uint32(cfg.ConntrackMaxStateSize), EditorFlag: manager.EditMaxEntries},
 		},
 		ConstantEditors:           telemetryMapKeys,
 		DefaultKprobeAttachMethod: kprobeAttachMethod,
+		ExcludedFunctions:         excludedFunctions,
 	}
 	if (mapErrTelemetryMap != nil) || (helperErrTelemetryMap != nil) {
 		opts.MapEditors = make(map[string]*ebpf.Map)
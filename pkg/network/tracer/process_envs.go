@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tracer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// processEnvs reads the environment variables of the processes of the connections from procfs, so that their
+// connections are tagged with the service, env and version of the Unified Service Tagging without relying on the
+// process event monitoring. The variables are read once per process, and only for the processes of the connections.
+type processEnvs struct {
+	sync.Mutex
+
+	procRoot string
+	// names holds the names of the environment variables which are read
+	names map[string]struct{}
+
+	// current and previous cache the variables of the processes of the connections of the current and the previous
+	// check, the PIDs of the processes which are gone being eventually reused by other processes
+	current  map[uint32]map[string]string
+	previous map[uint32]map[string]string
+}
+
+func newProcessEnvs(procRoot string, names []string) *processEnvs {
+	pe := &processEnvs{
+		procRoot: procRoot,
+		names:    make(map[string]struct{}, len(names)),
+		current:  make(map[uint32]map[string]string),
+		previous: make(map[uint32]map[string]string),
+	}
+	for _, name := range names {
+		pe.names[name] = struct{}{}
+	}
+	return pe
+}
+
+// get returns the environment variables of the process, or nil if it has none of them or is gone
+func (pe *processEnvs) get(pid uint32) map[string]string {
+	if pid == 0 {
+		return nil
+	}
+
+	pe.Lock()
+	defer pe.Unlock()
+
+	if envs, ok := pe.current[pid]; ok {
+		return envs
+	}
+	envs, ok := pe.previous[pid]
+	if !ok {
+		envs = pe.read(pid)
+	}
+	pe.current[pid] = envs
+	return envs
+}
+
+// rotate starts a new check, forgetting the processes which had no connection during the previous one
+func (pe *processEnvs) rotate() {
+	pe.Lock()
+	defer pe.Unlock()
+
+	pe.previous = pe.current
+	pe.current = make(map[uint32]map[string]string, len(pe.previous))
+}
+
+func (pe *processEnvs) read(pid uint32) map[string]string {
+	return readProcessEnvs(pe.procRoot, pid, pe.names)
+}
+
+// readProcessEnvs returns the environment variables of the process having the given names, or nil if it has none of
+// them or is gone
+func readProcessEnvs(procRoot string, pid uint32, names map[string]struct{}) map[string]string {
+	// the processes of the other users, such as the ones of the containers, are readable with CAP_SYS_PTRACE
+	environ, err := os.ReadFile(filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10), "environ"))
+	if err != nil {
+		// the process is gone, or is a kernel thread
+		return nil
+	}
+
+	var envs map[string]string
+	for _, env := range bytes.Split(environ, []byte{0}) {
+		name, value, found := bytes.Cut(env, []byte{'='})
+		if !found {
+			continue
+		}
+		if _, ok := names[string(name)]; !ok {
+			continue
+		}
+		if envs == nil {
+			envs = make(map[string]string, len(names))
+		}
+		envs[string(name)] = string(value)
+	}
+	return envs
+}
@@ -0,0 +1,47 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessEnvs(t *testing.T) {
+	procRoot := t.TempDir()
+	writeEnviron := func(pid int, environ string) {
+		dir := filepath.Join(procRoot, strconv.Itoa(pid))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "environ"), []byte(environ), 0644))
+	}
+	writeEnviron(100, "PATH=/usr/bin\x00DD_SERVICE=web\x00DD_ENV=prod\x00DD_VERSION=1.2=rc\x00")
+	writeEnviron(200, "PATH=/usr/bin\x00HOME=/root\x00")
+	pe := newProcessEnvs(procRoot, defaultFilteredEnvs)
+
+	assert.Equal(t, map[string]string{"DD_SERVICE": "web", "DD_ENV": "prod", "DD_VERSION": "1.2=rc"}, pe.get(100))
+	assert.Nil(t, pe.get(200))
+	assert.Nil(t, pe.get(300), "the process is gone")
+	assert.Nil(t, pe.get(0))
+
+	// the variables are read once per process
+	writeEnviron(100, "DD_SERVICE=api\x00")
+	assert.Equal(t, "web", pe.get(100)["DD_SERVICE"])
+	pe.rotate()
+	assert.Equal(t, "web", pe.get(100)["DD_SERVICE"])
+
+	// the processes without connection during a check are forgotten, their PID being eventually reused
+	pe.rotate()
+	pe.rotate()
+	assert.Equal(t, "api", pe.get(100)["DD_SERVICE"])
+}
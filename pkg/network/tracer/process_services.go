@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network/servicediscovery"
+)
+
+// processServices infers the service names of the processes of the connections from their command line and their
+// environment variables read from procfs, see servicediscovery.Infer. The names are inferred once per process.
+type processServices struct {
+	sync.Mutex
+
+	procRoot string
+	envNames map[string]struct{}
+
+	// current and previous cache the service names of the processes of the connections of the current and the
+	// previous check, the PIDs of the processes which are gone being eventually reused by other processes
+	current  map[uint32]string
+	previous map[uint32]string
+}
+
+func newProcessServices(procRoot string) *processServices {
+	ps := &processServices{
+		procRoot: procRoot,
+		envNames: make(map[string]struct{}, len(servicediscovery.EnvNames)),
+		current:  make(map[uint32]string),
+		previous: make(map[uint32]string),
+	}
+	for _, name := range servicediscovery.EnvNames {
+		ps.envNames[name] = struct{}{}
+	}
+	return ps
+}
+
+// get returns the inferred service name of the process, or an empty string if none could be inferred
+func (ps *processServices) get(pid uint32) string {
+	if pid == 0 {
+		return ""
+	}
+
+	ps.Lock()
+	defer ps.Unlock()
+
+	if service, ok := ps.current[pid]; ok {
+		return service
+	}
+	service, ok := ps.previous[pid]
+	if !ok {
+		service = ps.infer(pid)
+	}
+	ps.current[pid] = service
+	return service
+}
+
+// rotate starts a new check, forgetting the processes which had no connection during the previous one
+func (ps *processServices) rotate() {
+	ps.Lock()
+	defer ps.Unlock()
+
+	ps.previous = ps.current
+	ps.current = make(map[uint32]string, len(ps.previous))
+}
+
+func (ps *processServices) infer(pid uint32) string {
+	cmdline, err := os.ReadFile(filepath.Join(ps.procRoot, strconv.FormatUint(uint64(pid), 10), "cmdline"))
+	if err != nil || len(cmdline) == 0 {
+		// the process is gone, or is a kernel thread
+		return ""
+	}
+	args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+	return servicediscovery.Infer(args, readProcessEnvs(ps.procRoot, pid, ps.envNames))
+}
@@ -0,0 +1,46 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessServices(t *testing.T) {
+	procRoot := t.TempDir()
+	writeProcess := func(pid int, cmdline, environ string) {
+		dir := filepath.Join(procRoot, strconv.Itoa(pid))
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "environ"), []byte(environ), 0644))
+	}
+	writeProcess(100, "/usr/bin/python3\x00-m\x00gunicorn\x00shop.wsgi:application\x00", "")
+	writeProcess(200, "java\x00-version\x00", "KUBERNETES_SERVICE_HOST=10.96.0.1\x00HOSTNAME=frontend-7d9f8c6b54-x2k4z\x00")
+	writeProcess(300, "", "")
+	ps := newProcessServices(procRoot)
+
+	assert.Equal(t, "shop", ps.get(100))
+	assert.Equal(t, "frontend", ps.get(200))
+	assert.Empty(t, ps.get(300), "kernel thread")
+	assert.Empty(t, ps.get(400), "the process is gone")
+
+	// the names are inferred once per process, until it has no connection during a check
+	writeProcess(100, "/usr/local/bin/gunicorn\x00orders:app\x00", "")
+	ps.rotate()
+	assert.Equal(t, "shop", ps.get(100))
+	ps.rotate()
+	ps.rotate()
+	assert.Equal(t, "orders", ps.get(100))
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"unsafe"
+
+	netebpf "github.com/DataDog/datadog-agent/pkg/network/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network/ebpf/probes"
+	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection/kprobe"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// dropReasonRegex matches the entries of the `__print_symbolic` call of the tracepoint format, such as
+// `{ 2, "NOT_SPECIFIED" }`
+var dropReasonRegex = regexp.MustCompile(`\{\s*(\d+)\s*,\s*"(\w+)"\s*\}`)
+
+// TCPDrop is the number of packets of a TCP connection dropped by the kernel for a given reason
+type TCPDrop struct {
+	Source util.Address
+	Dest   util.Address
+	SPort  uint16
+	DPort  uint16
+	NetNS  uint32
+	Reason string
+	Count  uint32
+}
+
+// DebugTCPDrops returns the packets of the TCP connections dropped by the kernel, per connection and drop reason
+func (t *Tracer) DebugTCPDrops() (interface{}, error) {
+	if !t.config.EnableTCPDropTracking {
+		return nil, errors.New("tcp drop tracking is not enabled")
+	}
+	m := t.ebpfTracer.GetMap(probes.TCPDropReasonsMap)
+	if m == nil {
+		return nil, fmt.Errorf("unable to retrieve the bpf %s map", probes.TCPDropReasonsMap)
+	}
+
+	var reasons map[uint32]string
+	if format, err := os.ReadFile(kprobe.KfreeSkbFormatPath); err != nil {
+		log.Debugf("unable to read the drop reasons of the kernel: %s", err)
+	} else {
+		reasons = parseDropReasons(format)
+	}
+
+	var drops []TCPDrop
+	key, value := &netebpf.ConnTuple{}, &netebpf.TCPDrops{}
+	entries := m.Iterate()
+	for entries.Next(unsafe.Pointer(key), unsafe.Pointer(value)) {
+		for i := 0; i < netebpf.TCPDropReasonsMax; i++ {
+			// the slot is free, see tcp_drops_t
+			if value.Counts[i] == 0 {
+				continue
+			}
+			drops = append(drops, TCPDrop{
+				Source: key.SourceAddress(),
+				Dest:   key.DestAddress(),
+				SPort:  key.Sport,
+				DPort:  key.Dport,
+				NetNS:  key.Netns,
+				Reason: dropReasonName(reasons, value.Reasons[i]),
+				Count:  value.Counts[i],
+			})
+		}
+	}
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("unable to iterate the bpf %s map: %s", probes.TCPDropReasonsMap, err)
+	}
+	return drops, nil
+}
+
+// parseDropReasons returns the names of the drop reasons listed by the format of the skb/kfree_skb tracepoint
+func parseDropReasons(format []byte) map[uint32]string {
+	reasons := make(map[uint32]string)
+	for _, match := range dropReasonRegex.FindAllSubmatch(format, -1) {
+		value, err := strconv.ParseUint(string(match[1]), 10, 32)
+		if err != nil {
+			continue
+		}
+		reasons[uint32(value)] = string(match[2])
+	}
+	return reasons
+}
+
+func dropReasonName(reasons map[uint32]string, reason uint32) string {
+	if name, ok := reasons[reason]; ok {
+		return name
+	}
+	// kernels < 5.17 don't report the reason of the drops
+	if len(reasons) == 0 && reason == 0 {
+		return "UNKNOWN"
+	}
+	return fmt.Sprintf("REASON_%d", reason)
+}
@@ -0,0 +1,48 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+// +build linux_bpf
+
+package tracer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const kfreeSkbFormat = `name: kfree_skb
+ID: 1465
+format:
+	field:unsigned short common_type;	offset:0;	size:2;	signed:0;
+	field:unsigned char common_flags;	offset:2;	size:1;	signed:0;
+	field:unsigned char common_preempt_count;	offset:3;	size:1;	signed:0;
+	field:int common_pid;	offset:4;	size:4;	signed:1;
+
+	field:void * skbaddr;	offset:8;	size:8;	signed:0;
+	field:void * location;	offset:16;	size:8;	signed:0;
+	field:unsigned short protocol;	offset:24;	size:2;	signed:0;
+	field:enum skb_drop_reason reason;	offset:28;	size:4;	signed:0;
+
+print fmt: "skbaddr=%p protocol=%u location=%p reason: %s", REC->skbaddr, REC->protocol, REC->location, __print_symbolic(REC->reason, { 1, "NOT_DROPPED_YET" }, { 2, "NOT_SPECIFIED" }, { 3, "NO_SOCKET" }, { 7, "SOCKET_FILTER" }, { 61, "TCP_OFOMERGE" })
+`
+
+func TestParseDropReasons(t *testing.T) {
+	reasons := parseDropReasons([]byte(kfreeSkbFormat))
+	assert.Equal(t, map[uint32]string{
+		1:  "NOT_DROPPED_YET",
+		2:  "NOT_SPECIFIED",
+		3:  "NO_SOCKET",
+		7:  "SOCKET_FILTER",
+		61: "TCP_OFOMERGE",
+	}, reasons)
+
+	assert.Equal(t, "TCP_OFOMERGE", dropReasonName(reasons, 61))
+	assert.Equal(t, "REASON_62", dropReasonName(reasons, 62))
+
+	// kernels < 5.17 don't report any reason
+	assert.Equal(t, "UNKNOWN", dropReasonName(parseDropReasons(nil), 0))
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network/netlink"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	usmtelemetry "github.com/DataDog/datadog-agent/pkg/network/protocols/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/tls"
 	"github.com/DataDog/datadog-agent/pkg/network/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection"
 	"github.com/DataDog/datadog-agent/pkg/process/procutil"
@@ -77,10 +78,16 @@ type Tracer struct {
 	activeBuffer *network.ConnectionBuffer
 	bufferLock   sync.Mutex
 
+	// closedNotifier signals the streaming clients when closed connections are stored
+	closedNotifier closedConnectionsNotifier
+
 	// Connections for the tracer to exclude
 	sourceExcludes []*network.ConnectionFilter
 	destExcludes   []*network.ConnectionFilter
 
+	// agentProcesses identifies the agent processes whose HTTP stats are excluded, if enabled
+	agentProcesses *agentProcesses
+
 	gwLookup *gatewayLookup
 
 	sysctlUDPConnTimeout       *sysctl.Int
@@ -88,7 +95,16 @@ type Tracer struct {
 
 	processCache *processCache
 
+	// processEnvs reads the environment variables of the processes of the connections from procfs, if enabled
+	processEnvs *processEnvs
+
+	// processServices infers the service names of the processes of the connections, if enabled
+	processServices *processServices
+
 	timeResolver *TimeResolver
+
+	// degradedMode describes the reduced feature set the tracer runs with on old kernels, if any
+	degradedMode string
 }
 
 // NewTracer creates a Tracer
@@ -108,6 +124,10 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 // newTracer is an internal function used by tests primarily
 // (and NewTracer above)
 func newTracer(config *config.Config) (*Tracer, error) {
+	if config.EnableEbpfLess {
+		return newEbpfLessTracer(config)
+	}
+
 	// make sure debugfs is mounted
 	if mounted, err := kernel.IsDebugFSOrTraceFSMounted(); !mounted {
 		return nil, fmt.Errorf("system-probe unsupported: %s", err)
@@ -127,7 +147,10 @@ func newTracer(config *config.Config) (*Tracer, error) {
 	}
 
 	usmSupported := currKernelVersion >= http.MinimumKernelVersion
-	if !usmSupported && config.ServiceMonitoringEnabled {
+	if !usmSupported && config.ServiceMonitoringEnabled && http.FallbackSupported(currKernelVersion) {
+		log.Warnf("Universal Service Monitoring (USM) requires a Linux kernel version of %s or higher to be fully supported. We detected %s, so only the plaintext HTTP traffic will be monitored.", http.MinimumKernelVersion, currKernelVersion)
+		config.EnableHTTPSMonitoring = false
+	} else if !usmSupported && config.ServiceMonitoringEnabled {
 		errStr := fmt.Sprintf("Universal Service Monitoring (USM) requires a Linux kernel version of %s or higher. We detected %s", http.MinimumKernelVersion, currKernelVersion)
 		if !config.NPMEnabled {
 			return nil, fmt.Errorf(errStr)
@@ -137,6 +160,16 @@ func newTracer(config *config.Config) (*Tracer, error) {
 		config.EnableHTTPSMonitoring = false
 	}
 
+	if config.EnableKernelConnectionFilters && currKernelVersion != 0 && currKernelVersion < connection.KernelConnectionFiltersMinimumKernelVersion {
+		log.Warnf("filtering the connections in the kernel requires a Linux kernel version of %s or higher. We detected %s, so the connections are filtered in user space.", connection.KernelConnectionFiltersMinimumKernelVersion, currKernelVersion)
+		config.EnableKernelConnectionFilters = false
+	}
+
+	degradedMode := applyDegradedMode(config, currKernelVersion)
+	if degradedMode != "" {
+		log.Warnf("system-probe is running in degraded mode: %s", degradedMode)
+	}
+
 	offsetBuf, err := netebpf.ReadOffsetBPFModule(config.BPFDir, config.BPFDebug)
 	if err != nil {
 		return nil, fmt.Errorf("could not read offset bpf module: %s", err)
@@ -174,24 +207,63 @@ func newTracer(config *config.Config) (*Tracer, error) {
 		return nil, err
 	}
 
-	state := network.NewState(
-		config.ClientStateExpiry,
-		config.MaxClosedConnectionsBuffered,
-		config.MaxConnectionsStateBuffered,
-		config.MaxDNSStatsBuffered,
-		config.MaxHTTPStatsBuffered,
-	)
+	tr, err := newTracerWithConnectionTracer(config, ebpfTracer, conntracker, bpfTelemetry, constantEditors)
+	if err != nil {
+		return nil, err
+	}
+	tr.degradedMode = degradedMode
+	return tr, nil
+}
+
+// newEbpfLessTracer returns a Tracer which doesn't load any eBPF program: the connections are tracked and the HTTP
+// traffic is monitored from the packets read through packet sockets, and the NAT entries are read from netlink
+func newEbpfLessTracer(config *config.Config) (*Tracer, error) {
+	log.Info("system-probe running without eBPF, the connections are tracked from the packets of the root network namespace")
+
+	ebpfTracer, err := connection.NewEbpfLessTracer(config)
+	if err != nil {
+		return nil, err
+	}
+
+	conntracker, err := newConntracker(config, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTracerWithConnectionTracer(config, ebpfTracer, conntracker, nil, nil)
+}
+
+func newTracerWithConnectionTracer(config *config.Config, ebpfTracer connection.Tracer, conntracker netlink.Conntracker, bpfTelemetry *telemetry.EBPFTelemetry, constantEditors []manager.ConstantEditor) (*Tracer, error) {
+	state := network.NewState(network.StateConfig{
+		ClientExpiry:      config.ClientStateExpiry,
+		MaxClosedConns:    config.MaxClosedConnectionsBuffered,
+		MaxClientStats:    config.MaxConnectionsStateBuffered,
+		MaxDNSStats:       config.MaxDNSStatsBuffered,
+		MaxHTTPStats:      config.MaxHTTPStatsBuffered,
+		MaxKafkaStats:     config.MaxKafkaStatsBuffered,
+		MaxPostgresStats:  config.MaxPostgresStatsBuffered,
+		MaxMySQLStats:     config.MaxMySQLStatsBuffered,
+		MaxRedisStats:     config.MaxRedisStatsBuffered,
+		MaxMongoStats:     config.MaxMongoStatsBuffered,
+		MaxAMQPStats:      config.MaxAMQPStatsBuffered,
+		MaxGRPCStats:      config.MaxGRPCStatsBuffered,
+		MaxHTTP3Stats:     config.MaxHTTP3StatsBuffered,
+		MaxCassandraStats: config.MaxCassandraStatsBuffered,
+		MaxMemcachedStats: config.MaxMemcachedStatsBuffered,
+		MaxClientConns:    config.MaxConnectionsPerClient,
+	})
 
 	gwLookup := newGatewayLookup(config)
 	if gwLookup != nil {
 		log.Info("gateway lookup enabled")
 	}
 
+	reverseDNS := newReverseDNS(config)
 	tr := &Tracer{
 		config:                     config,
 		state:                      state,
-		reverseDNS:                 newReverseDNS(config),
-		httpMonitor:                newHTTPMonitor(config, ebpfTracer, bpfTelemetry, constantEditors),
+		reverseDNS:                 reverseDNS,
+		httpMonitor:                newHTTPMonitor(config, ebpfTracer, bpfTelemetry, constantEditors, reverseDNS),
 		activeBuffer:               network.NewConnectionBuffer(512, 256),
 		conntracker:                conntracker,
 		sourceExcludes:             network.ParseConnectionFilters(config.ExcludedSourceConnections),
@@ -209,7 +281,20 @@ func newTracer(config *config.Config) (*Tracer, error) {
 		bpfTelemetry:     bpfTelemetry,
 	}
 
+	if tr.httpMonitor != nil && config.ExcludeAgentTraffic {
+		tr.agentProcesses = newAgentProcesses(config.ProcRoot)
+	}
+
+	if config.EnableProcessEnvTags {
+		tr.processEnvs = newProcessEnvs(config.ProcRoot, defaultFilteredEnvs)
+	}
+
+	if config.EnableServiceNameInference {
+		tr.processServices = newProcessServices(config.ProcRoot)
+	}
+
 	if config.EnableProcessEventMonitoring {
+		var err error
 		if err = events.Init(); err != nil {
 			return nil, fmt.Errorf("could not initialize event monitoring: %w", err)
 		}
@@ -252,7 +337,7 @@ func newConntracker(cfg *config.Config, bpfTelemetry *telemetry.EBPFTelemetry) (
 
 	var c netlink.Conntracker
 	var err error
-	if cfg.EnableRuntimeCompiler {
+	if cfg.EnableRuntimeCompiler && cfg.EnableEbpfConntracker {
 		c, err = NewEBPFConntracker(cfg, bpfTelemetry)
 		if err == nil {
 			return c, nil
@@ -281,6 +366,14 @@ func newConntracker(cfg *config.Config, bpfTelemetry *telemetry.EBPFTelemetry) (
 }
 
 func newReverseDNS(c *config.Config) dns.ReverseDNS {
+	rdns := newDNSInspector(c)
+	if c.EnableReverseDNSEnrichment {
+		return dns.NewEnrichedReverseDNS(rdns, c)
+	}
+	return rdns
+}
+
+func newDNSInspector(c *config.Config) dns.ReverseDNS {
 	if !c.DNSInspection {
 		return dns.NewNullReverseDNS()
 	}
@@ -362,50 +455,129 @@ func (t *Tracer) storeClosedConnections(connections []network.ConnectionStats) {
 		if cs.IPTranslation != nil {
 			t.conntracker.DeleteTranslation(*cs)
 		}
+		t.translateSocketLB(cs, true)
+		t.addTLSBytes(cs, true)
 
 		t.addProcessInfo(cs)
+		network.AttributeIstioTraffic(cs)
 	}
 
 	connections = connections[rejected:]
 	t.closedConns.Add(int64(len(connections)))
 	t.skippedConns.Add(int64(rejected))
 	t.state.StoreClosedConnections(connections)
+	if len(connections) > 0 {
+		t.closedNotifier.notify()
+	}
 }
 
-func (t *Tracer) addProcessInfo(c *network.ConnectionStats) {
-	if t.processCache == nil {
+// socketLBResolver is implemented by the conntrackers able to resolve the destination of the connections translated
+// at connect time by socket-level load balancing, which never shows up in conntrack
+type socketLBResolver interface {
+	GetSocketLBDestination(stats network.ConnectionStats) (util.Address, uint16, bool)
+	DeleteSocketLBDestination(stats network.ConnectionStats)
+}
+
+// translateSocketLB reports the connections translated by socket-level load balancing, such as the one of Cilium's
+// kube-proxy replacement, the same way as the ones translated by conntrack
+func (t *Tracer) translateSocketLB(c *network.ConnectionStats, closed bool) {
+	resolver, ok := t.conntracker.(socketLBResolver)
+	if !ok || c.IPTranslation != nil {
 		return
 	}
 
-	c.ContainerID = nil
+	// the entry is keyed by the destination the connection was translated to
+	wire := *c
+	if addr, port, ok := resolver.GetSocketLBDestination(wire); ok {
+		network.TranslateSocketLB(c, addr, port)
+	}
+	if closed {
+		resolver.DeleteSocketLBDestination(wire)
+	}
+}
 
-	ts := t.timeResolver.ResolveMonotonicTimestamp(c.LastUpdateEpoch)
-	p, ok := t.processCache.Get(c.Pid, int64(ts))
-	if !ok {
+// addTLSBytes reports the bytes of the TCP connection that went through the TLS hooks of USM, so that the
+// connections only partially encrypted, such as the ones upgraded with STARTTLS, are represented accurately
+func (t *Tracer) addTLSBytes(c *network.ConnectionStats, closed bool) {
+	if c.Type != network.TCP || t.httpMonitor == nil {
 		return
 	}
 
-	log.TraceFunc(func() string {
-		return fmt.Sprintf("got process cache entry for pid %d: %+v", c.Pid, p)
-	})
+	tuple := toConnTuple(c)
+	c.Monotonic.TLSBytes = t.httpMonitor.GetTLSBytes(tuple)
+	if closed {
+		t.httpMonitor.DeleteTLSBytes(tuple)
+	}
+}
+
+func toConnTuple(c *network.ConnectionStats) netebpf.ConnTuple {
+	tuple := netebpf.ConnTuple{
+		Sport: c.SPort,
+		Dport: c.DPort,
+		Netns: c.NetNS,
+		Pid:   c.Pid,
+	}
+	tuple.Saddr_l, tuple.Saddr_h = util.ToLowHigh(c.Source)
+	tuple.Daddr_l, tuple.Daddr_h = util.ToLowHigh(c.Dest)
+
+	if c.Family == network.AFINET6 {
+		tuple.Metadata = uint32(netebpf.IPv6)
+	} else {
+		tuple.Metadata = uint32(netebpf.IPv4)
+	}
+	if c.Type == network.TCP {
+		tuple.Metadata |= uint32(netebpf.TCP)
+	} else {
+		tuple.Metadata |= uint32(netebpf.UDP)
+	}
+	return tuple
+}
 
-	if c.Tags == nil {
-		c.Tags = make(map[string]struct{})
+// inferredServiceTag is the tag of the connections holding the service name inferred from their process
+const inferredServiceTag = "inferred_service"
+
+func (t *Tracer) addProcessInfo(c *network.ConnectionStats) {
+	if t.processCache == nil && t.processEnvs == nil && t.processServices == nil {
+		return
+	}
+
+	var envs map[string]string
+	if t.processCache != nil {
+		c.ContainerID = nil
+
+		ts := t.timeResolver.ResolveMonotonicTimestamp(c.LastUpdateEpoch)
+		if p, ok := t.processCache.Get(c.Pid, int64(ts)); ok {
+			log.TraceFunc(func() string {
+				return fmt.Sprintf("got process cache entry for pid %d: %+v", c.Pid, p)
+			})
+
+			envs = p.Envs
+			if p.ContainerID != "" {
+				c.ContainerID = &p.ContainerID
+			}
+		}
+	}
+	// the processes started before the process event monitoring aren't in the cache
+	if envs == nil && t.processEnvs != nil {
+		envs = t.processEnvs.get(c.Pid)
 	}
 
 	addTag := func(k, v string) {
 		if v == "" {
 			return
 		}
+		if c.Tags == nil {
+			c.Tags = make(map[string]struct{})
+		}
 		c.Tags[k+":"+v] = struct{}{}
 	}
 
-	addTag("env", p.Envs["DD_ENV"])
-	addTag("version", p.Envs["DD_VERSION"])
-	addTag("service", p.Envs["DD_SERVICE"])
+	addTag("env", envs["DD_ENV"])
+	addTag("version", envs["DD_VERSION"])
+	addTag("service", envs["DD_SERVICE"])
 
-	if p.ContainerID != "" {
-		c.ContainerID = &p.ContainerID
+	if t.processServices != nil {
+		addTag(inferredServiceTag, t.processServices.get(c.Pid))
 	}
 }
 
@@ -428,15 +600,51 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	log.Tracef("GetActiveConnections clientID=%s", clientID)
 
 	t.ebpfTracer.FlushPending()
+	if t.processEnvs != nil {
+		t.processEnvs.rotate()
+	}
+	if t.processServices != nil {
+		t.processServices.rotate()
+	}
 	latestTime, err := t.getConnections(t.activeBuffer)
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving connections: %s", err)
 	}
 	active := t.activeBuffer.Connections()
 
-	delta := t.state.GetDelta(clientID, latestTime, active, t.reverseDNS.GetDNSStats(), t.httpMonitor.GetHTTPStats())
+	delta := t.state.GetDelta(clientID, latestTime, active, t.reverseDNS.GetDNSStats(), network.USMStats{
+		HTTP:      t.httpMonitor.GetHTTPStats(),
+		Kafka:     t.httpMonitor.GetKafkaStats(),
+		Postgres:  t.httpMonitor.GetPostgresStats(),
+		MySQL:     t.httpMonitor.GetMySQLStats(),
+		Redis:     t.httpMonitor.GetRedisStats(),
+		Mongo:     t.httpMonitor.GetMongoStats(),
+		AMQP:      t.httpMonitor.GetAMQPStats(),
+		GRPC:      t.httpMonitor.GetGRPCStats(),
+		HTTP3:     t.httpMonitor.GetHTTP3Stats(),
+		Cassandra: t.httpMonitor.GetCassandraStats(),
+		Memcached: t.httpMonitor.GetMemcachedStats(),
+	})
 	t.activeBuffer.Reset()
 
+	if t.agentProcesses != nil && len(delta.HTTP) > 0 {
+		if removed := t.agentProcesses.excludeHTTPStats(delta.Conns, delta.HTTP); removed > 0 {
+			log.Debugf("excluded %d http stats of the agent processes", removed)
+		}
+	}
+
+	if t.config.EnableConnectionDomains {
+		t.resolveDomains(delta.Conns)
+	}
+
+	if t.config.EnableTLSHandshakeMonitoring {
+		t.tagTLSHandshakes(delta.Conns)
+	}
+
+	if t.config.EnableConnectionRollup {
+		delta.Conns = network.RollupConnections(delta.Conns, delta.HTTP, delta.Kafka)
+	}
+
 	ips := make([]util.Address, 0, len(delta.Conns)*2)
 	for _, conn := range delta.Conns {
 		ips = append(ips, conn.Source, conn.Dest)
@@ -453,6 +661,16 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 		DNS:                         names,
 		DNSStats:                    delta.DNSStats,
 		HTTP:                        delta.HTTP,
+		Kafka:                       delta.Kafka,
+		Postgres:                    delta.Postgres,
+		MySQL:                       delta.MySQL,
+		Redis:                       delta.Redis,
+		Mongo:                       delta.Mongo,
+		AMQP:                        delta.AMQP,
+		GRPC:                        delta.GRPC,
+		HTTP3:                       delta.HTTP3,
+		Cassandra:                   delta.Cassandra,
+		Memcached:                   delta.Memcached,
 		ConnTelemetry:               ctm,
 		KernelHeaderFetchResult:     khfr,
 		CompilationTelemetryByAsset: rctm,
@@ -460,12 +678,75 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	}, nil
 }
 
+// tagTLSHandshakes tags the TCP connections with the metadata of their TLS handshake
+func (t *Tracer) tagTLSHandshakes(conns []network.ConnectionStats) {
+	tuples := make([]tls.KeyTuple, 0, len(conns))
+	for _, c := range conns {
+		if c.Type == network.TCP {
+			tuples = append(tuples, tls.NewKeyTuple(c.Source, c.Dest, c.SPort, c.DPort))
+		}
+	}
+
+	handshakes := t.httpMonitor.GetTLSHandshakes(tuples)
+	if len(handshakes) == 0 {
+		return
+	}
+	for i := range conns {
+		if conns[i].Type != network.TCP {
+			continue
+		}
+		handshake, ok := handshakes[tls.NewKeyTuple(conns[i].Source, conns[i].Dest, conns[i].SPort, conns[i].DPort)]
+		if !ok {
+			continue
+		}
+		if conns[i].Tags == nil {
+			conns[i].Tags = make(map[string]struct{})
+		}
+		for _, tag := range handshake.Tags() {
+			conns[i].Tags[tag] = struct{}{}
+		}
+	}
+}
+
+// resolveDomains sets the domain the destination of each outgoing connection was resolved from. The DNS responses are
+// attributed to the network namespaces of the DNS clients found among the connections.
+func (t *Tracer) resolveDomains(conns []network.ConnectionStats) {
+	var clients []dns.ClientKey
+	dests := make([]dns.DomainKey, 0, len(conns))
+	for _, c := range conns {
+		if c.DPort == 53 {
+			clients = append(clients, dns.ClientKey{NetNS: c.NetNS, IP: c.Source, Port: c.SPort})
+		}
+		if c.Direction == network.OUTGOING {
+			dests = append(dests, dns.DomainKey{NetNS: c.NetNS, Addr: c.Dest})
+		}
+	}
+
+	domains := t.reverseDNS.ResolveDomains(clients, dests)
+	for i := range conns {
+		if conns[i].Direction == network.OUTGOING {
+			conns[i].Domain = domains[dns.DomainKey{NetNS: conns[i].NetNS, Addr: conns[i].Dest}]
+		}
+	}
+}
+
 // RegisterClient registers a clientID with the tracer
 func (t *Tracer) RegisterClient(clientID string) error {
 	t.state.RegisterClient(clientID)
 	return nil
 }
 
+// SetClientMaxConnections sets the maximum number of connections returned to a client, registering it if needed
+func (t *Tracer) SetClientMaxConnections(clientID string, maxConns int) {
+	t.state.SetClientMaxConnections(clientID, maxConns)
+}
+
+// SubscribeClosedConnections returns a channel signaled when closed connections are stored, so that a client can
+// fetch them right away, and the function ending the subscription
+func (t *Tracer) SubscribeClosedConnections() (<-chan struct{}, func()) {
+	return t.closedNotifier.subscribe()
+}
+
 func (t *Tracer) getConnTelemetry(mapSize int) map[network.ConnTelemetryType]int64 {
 	kprobeStats := ddebpf.GetProbeTotals()
 	tm := map[network.ConnTelemetryType]int64{
@@ -578,6 +859,8 @@ func (t *Tracer) getConnections(activeBuffer *network.ConnectionBuffer) (latestU
 	_ = t.timeResolver.Sync()
 	for i := range active {
 		active[i].IPTranslation = t.conntracker.GetTranslationForConn(active[i])
+		t.translateSocketLB(&active[i], false)
+		t.addTLSBytes(&active[i], false)
 		// do gateway resolution only on active connections outside
 		// the map iteration loop to not add to connections while
 		// iterating (leads to ever-increasing connections in the map,
@@ -585,6 +868,7 @@ func (t *Tracer) getConnections(activeBuffer *network.ConnectionBuffer) (latestU
 		// endpoint)
 		t.connVia(&active[i])
 		t.addProcessInfo(&active[i])
+		network.AttributeIstioTraffic(&active[i])
 	}
 
 	entryCount := len(active)
@@ -625,6 +909,12 @@ func (t *Tracer) removeEntries(entries []network.ConnectionStats) {
 
 		// Delete conntrack entry for this connection
 		t.conntracker.DeleteTranslation(*entry)
+		if resolver, ok := t.conntracker.(socketLBResolver); ok {
+			resolver.DeleteSocketLBDestination(*entry)
+		}
+		if entry.Type == network.TCP {
+			t.httpMonitor.DeleteTLSBytes(toConnTuple(entry))
+		}
 
 		// Append the connection key to the keys to remove from the userspace state
 		toRemove = append(toRemove, entry)
@@ -672,6 +962,8 @@ const (
 	processCacheStats
 	bpfMapStats
 	bpfHelperStats
+	perfBufferStats
+	probeAttachStats
 )
 
 var allStats = []statsComp{
@@ -686,6 +978,8 @@ var allStats = []statsComp{
 	bpfHelperStats,
 	bpfMapStats,
 	httpStats,
+	perfBufferStats,
+	probeAttachStats,
 }
 
 func (t *Tracer) getStats(comps ...statsComp) (map[string]interface{}, error) {
@@ -715,6 +1009,9 @@ func (t *Tracer) getStats(comps ...statsComp) (map[string]interface{}, error) {
 		case tracerStats:
 			tracerStats := atomicstats.Report(t)
 			tracerStats["runtime"] = runtime.Tracer.GetTelemetry()
+			if t.degradedMode != "" {
+				tracerStats["degraded_mode"] = t.degradedMode
+			}
 			ret["tracer"] = tracerStats
 		case processCacheStats:
 			ret["process_cache"] = t.processCache.GetStats()
@@ -724,6 +1021,10 @@ func (t *Tracer) getStats(comps ...statsComp) (map[string]interface{}, error) {
 			ret["ebpf_helpers"] = t.bpfTelemetry.GetHelperTelemetry()
 		case httpStats:
 			ret["universal_service_monitoring"] = t.httpMonitor.GetUSMStats()
+		case perfBufferStats:
+			ret["perf_buffers"] = ddebpf.GetPerfBufferStats()
+		case probeAttachStats:
+			ret["probe_attach_failures"] = ddebpf.GetAttachFailures()
 		}
 	}
 
@@ -780,6 +1081,43 @@ func (t *Tracer) DebugEBPFMaps(maps ...string) (string, error) {
 	return "tracer:\n" + tracerMaps + "\nhttp_monitor:\n" + httpMaps, nil
 }
 
+// DebugHTTP2Connections returns the frames of the active HTTP/2 connections
+func (t *Tracer) DebugHTTP2Connections() (interface{}, error) {
+	if !t.config.EnableHTTP2Monitoring {
+		return nil, errors.New("http2 monitoring is not enabled")
+	}
+	return t.httpMonitor.GetHTTP2Connections()
+}
+
+// SustainedPerfBufferLoss returns the perf maps whose buffers keep losing samples, and would be grown by reloading the
+// tracer
+func (t *Tracer) SustainedPerfBufferLoss() []string {
+	return ddebpf.SustainedPerfBufferLoss(&t.config.Config)
+}
+
+// DebugUSMAttachments returns the binaries and the shared libraries whose uprobes are attached, or failed to attach,
+// along with the hits of the hooks counted over hitsWindow when it is set
+func (t *Tracer) DebugUSMAttachments(ctx context.Context, hitsWindow time.Duration) (interface{}, error) {
+	if t.httpMonitor == nil {
+		return nil, errors.New("usm is not enabled")
+	}
+	return t.httpMonitor.GetUprobeAttachments(ctx, hitsWindow)
+}
+
+// GetUSMProtocols returns whether each USM protocol that can be toggled at runtime is currently enabled. The
+// protocols disabled at startup are not part of the returned map.
+func (t *Tracer) GetUSMProtocols() (map[string]bool, error) {
+	if t.httpMonitor == nil {
+		return nil, errors.New("usm is not enabled")
+	}
+	return t.httpMonitor.GetProtocols(), nil
+}
+
+// SetUSMProtocolEnabled enables or disables the monitoring of the given USM protocol, without restarting the tracer
+func (t *Tracer) SetUSMProtocolEnabled(protocol string, enabled bool) error {
+	return t.httpMonitor.SetProtocolEnabled(protocol, enabled)
+}
+
 // connectionExpired returns true if the passed in connection has expired
 //
 // expiry is handled differently for UDP and TCP. For TCP where conntrack TTL is very long, we use a short expiry for userspace tracking
@@ -881,7 +1219,7 @@ func (t *Tracer) DebugDumpProcessCache(ctx context.Context) (interface{}, error)
 	return nil, nil
 }
 
-func newHTTPMonitor(c *config.Config, tracer connection.Tracer, bpfTelemetry *telemetry.EBPFTelemetry, offsets []manager.ConstantEditor) *http.Monitor {
+func newHTTPMonitor(c *config.Config, tracer connection.Tracer, bpfTelemetry *telemetry.EBPFTelemetry, offsets []manager.ConstantEditor, reverseDNS dns.ReverseDNS) *http.Monitor {
 	// Shared with the HTTP program
 	sockFDMap := tracer.GetMap(probes.SockByPidFDMap)
 
@@ -891,6 +1229,9 @@ func newHTTPMonitor(c *config.Config, tracer connection.Tracer, bpfTelemetry *te
 		return nil
 	}
 
+	// the plaintext of the DNS over TLS connections is read through the TLS hooks of the monitor
+	monitor.SetDNSOverTLSHandler(reverseDNS.ProcessTLSSegment)
+
 	if err := monitor.Start(); err != nil {
 		log.Error(err)
 		return nil
@@ -29,10 +29,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/network"
 	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/amqp"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/cassandra"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/memcached"
 	protocolsmongo "github.com/DataDog/datadog-agent/pkg/network/protocols/mongo"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/mysql"
 	pgutils "github.com/DataDog/datadog-agent/pkg/network/protocols/postgres"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/redis"
+	protocolsUtils "github.com/DataDog/datadog-agent/pkg/network/protocols/testutil"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/testutil/grpc"
 )
 
@@ -102,14 +105,18 @@ func composeSkips(skippers ...func(t *testing.T, ctx testContext)) func(t *testi
 }
 
 const (
-	mysqlPort    = "3306"
-	postgresPort = "5432"
-	mongoPort    = "27017"
-	redisPort    = "6379"
-	amqpPort     = "5672"
-	httpPort     = "8080"
-	tcpPort      = "9999"
-	http2Port    = "9090"
+	mysqlPort     = "3306"
+	postgresPort  = "5432"
+	mongoPort     = "27017"
+	redisPort     = "6379"
+	amqpPort      = "5672"
+	httpPort      = "8080"
+	tcpPort       = "9999"
+	http2Port     = "9090"
+	sshPort       = "2222"
+	rdpPort       = "3389"
+	cassandraPort = "9042"
+	memcachedPort = "11211"
 )
 
 func testProtocolClassification(t *testing.T, cfg *config.Config, clientHost, targetHost, serverHost string) {
@@ -145,10 +152,30 @@ func testProtocolClassification(t *testing.T, cfg *config.Config, clientHost, ta
 			name:     "http2",
 			testFunc: testHTTP2ProtocolClassification,
 		},
+		{
+			name:     "ssh",
+			testFunc: testSSHProtocolClassification,
+		},
+		{
+			name:     "rdp",
+			testFunc: testRDPProtocolClassification,
+		},
+		{
+			name:     "cassandra",
+			testFunc: testCassandraProtocolClassification,
+		},
+		{
+			name:     "memcached",
+			testFunc: testMemcachedProtocolClassification,
+		},
 		{
 			name:     "edge cases",
 			testFunc: testEdgeCasesProtocolClassification,
 		},
+		{
+			name:     "pcap replay",
+			testFunc: testPCAPReplayProtocolClassification,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1241,6 +1268,261 @@ func testHTTP2ProtocolClassification(t *testing.T, cfg *config.Config, clientHos
 	}
 }
 
+func testSSHProtocolClassification(t *testing.T, cfg *config.Config, clientHost, targetHost, serverHost string) {
+	defaultDialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{
+			IP: net.ParseIP(clientHost),
+		},
+	}
+
+	teardown := func(t *testing.T, ctx testContext) {
+		server, ok := ctx.extras["server"].(*TCPServer)
+		if ok {
+			server.Shutdown()
+		}
+	}
+
+	serverAddress := net.JoinHostPort(serverHost, sshPort)
+	targetAddress := net.JoinHostPort(targetHost, sshPort)
+	tests := []protocolClassificationAttributes{
+		{
+			// The server sends its identification string first, and the client replies with its own.
+			name: "ssh identification strings",
+			context: testContext{
+				serverPort:    sshPort,
+				serverAddress: serverAddress,
+				targetAddress: targetAddress,
+				extras:        map[string]interface{}{},
+			},
+			preTracerSetup: func(t *testing.T, ctx testContext) {
+				server := NewTCPServerOnAddress(ctx.serverAddress, func(c net.Conn) {
+					defer c.Close()
+					c.Write([]byte("SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1\r\n"))
+					r := bufio.NewReader(c)
+					r.ReadBytes(byte('\n'))
+				})
+				ctx.extras["server"] = server
+				require.NoError(t, server.Run())
+			},
+			postTracerSetup: func(t *testing.T, ctx testContext) {
+				timedContext, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				c, err := defaultDialer.DialContext(timedContext, "tcp", ctx.targetAddress)
+				require.NoError(t, err)
+				defer c.Close()
+				r := bufio.NewReader(c)
+				_, err = r.ReadBytes(byte('\n'))
+				require.NoError(t, err)
+				c.Write([]byte("SSH-2.0-Go\r\n"))
+				io.ReadAll(c)
+			},
+			teardown:   teardown,
+			validation: validateProtocolConnection(network.ProtocolSSH),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testProtocolClassificationInner(t, tt, cfg)
+		})
+	}
+}
+
+func testRDPProtocolClassification(t *testing.T, cfg *config.Config, clientHost, targetHost, serverHost string) {
+	defaultDialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{
+			IP: net.ParseIP(clientHost),
+		},
+	}
+
+	teardown := func(t *testing.T, ctx testContext) {
+		server, ok := ctx.extras["server"].(*TCPServer)
+		if ok {
+			server.Shutdown()
+		}
+	}
+
+	// The X.224 Connection Request holding the routing token of mstsc, and the RDP negotiation request of the
+	// TLS security protocol, followed by the Connection Confirm of the server selecting it.
+	connectionRequest := []byte("\x03\x00\x00\x2b\x26\xe0\x00\x00\x00\x00\x00Cookie: mstshash=user\r\n\x01\x00\x08\x00\x01\x00\x00\x00")
+	connectionConfirm := []byte("\x03\x00\x00\x13\x0e\xd0\x00\x00\x12\x34\x00\x02\x00\x08\x00\x01\x00\x00\x00")
+
+	serverAddress := net.JoinHostPort(serverHost, rdpPort)
+	targetAddress := net.JoinHostPort(targetHost, rdpPort)
+	tests := []protocolClassificationAttributes{
+		{
+			name: "x224 connection request",
+			context: testContext{
+				serverPort:    rdpPort,
+				serverAddress: serverAddress,
+				targetAddress: targetAddress,
+				extras:        map[string]interface{}{},
+			},
+			preTracerSetup: func(t *testing.T, ctx testContext) {
+				server := NewTCPServerOnAddress(ctx.serverAddress, func(c net.Conn) {
+					defer c.Close()
+					request := make([]byte, len(connectionRequest))
+					if _, err := io.ReadFull(c, request); err == nil {
+						c.Write(connectionConfirm)
+					}
+				})
+				ctx.extras["server"] = server
+				require.NoError(t, server.Run())
+			},
+			postTracerSetup: func(t *testing.T, ctx testContext) {
+				timedContext, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				c, err := defaultDialer.DialContext(timedContext, "tcp", ctx.targetAddress)
+				require.NoError(t, err)
+				defer c.Close()
+				c.Write(connectionRequest)
+				io.ReadAll(c)
+			},
+			teardown:   teardown,
+			validation: validateProtocolConnection(network.ProtocolRDP),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testProtocolClassificationInner(t, tt, cfg)
+		})
+	}
+}
+
+func testCassandraProtocolClassification(t *testing.T, cfg *config.Config, clientHost, targetHost, serverHost string) {
+	skipFunc := composeSkips(skipIfNotLinux, skipIfUsingNAT)
+	skipFunc(t, testContext{
+		serverAddress: serverHost,
+		serverPort:    cassandraPort,
+		targetAddress: targetHost,
+	})
+
+	defaultDialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{
+			IP: net.ParseIP(clientHost),
+		},
+	}
+
+	// The OPTIONS request of the version 4 of the CQL protocol, sent by the drivers before the STARTUP request, which
+	// is answered by a SUPPORTED response.
+	optionsRequest := []byte{0x04, 0x00, 0x00, 0x01, 0x05, 0x00, 0x00, 0x00, 0x00}
+
+	// Setting one instance of cassandra server for all tests.
+	serverAddress := net.JoinHostPort(serverHost, cassandraPort)
+	targetAddress := net.JoinHostPort(targetHost, cassandraPort)
+	cassandra.RunServer(t, serverHost, cassandraPort)
+
+	tests := []protocolClassificationAttributes{
+		{
+			name: "options",
+			context: testContext{
+				serverPort:    cassandraPort,
+				serverAddress: serverAddress,
+				targetAddress: targetAddress,
+				extras:        map[string]interface{}{},
+			},
+			postTracerSetup: func(t *testing.T, ctx testContext) {
+				timedContext, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+				defer cancel()
+				c, err := defaultDialer.DialContext(timedContext, "tcp", ctx.targetAddress)
+				require.NoError(t, err)
+				defer c.Close()
+				_, err = c.Write(optionsRequest)
+				require.NoError(t, err)
+				header := make([]byte, len(optionsRequest))
+				_, err = io.ReadFull(c, header)
+				require.NoError(t, err)
+			},
+			validation: validateProtocolConnection(network.ProtocolCassandra),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testProtocolClassificationInner(t, tt, cfg)
+		})
+	}
+}
+
+func testMemcachedProtocolClassification(t *testing.T, cfg *config.Config, clientHost, targetHost, serverHost string) {
+	skipFunc := composeSkips(skipIfNotLinux, skipIfUsingNAT)
+	skipFunc(t, testContext{
+		serverAddress: serverHost,
+		serverPort:    memcachedPort,
+		targetAddress: targetHost,
+	})
+
+	defaultDialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{
+			IP: net.ParseIP(clientHost),
+		},
+	}
+
+	// The GET request of the binary protocol for the "key" key, which is answered by a response with the key not
+	// found status.
+	binaryGetRequest := []byte{
+		0x80, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		'k', 'e', 'y',
+	}
+
+	// Setting one instance of memcached server for all tests.
+	serverAddress := net.JoinHostPort(serverHost, memcachedPort)
+	targetAddress := net.JoinHostPort(targetHost, memcachedPort)
+	memcached.RunServer(t, serverHost, memcachedPort)
+
+	tests := []protocolClassificationAttributes{
+		{
+			name: "text set",
+			context: testContext{
+				serverPort:    memcachedPort,
+				serverAddress: serverAddress,
+				targetAddress: targetAddress,
+				extras:        map[string]interface{}{},
+			},
+			postTracerSetup: func(t *testing.T, ctx testContext) {
+				timedContext, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+				defer cancel()
+				c, err := defaultDialer.DialContext(timedContext, "tcp", ctx.targetAddress)
+				require.NoError(t, err)
+				defer c.Close()
+				_, err = c.Write([]byte("set key 0 0 5\r\nvalue\r\n"))
+				require.NoError(t, err)
+				reply := make([]byte, len("STORED\r\n"))
+				_, err = io.ReadFull(c, reply)
+				require.NoError(t, err)
+			},
+			validation: validateProtocolConnection(network.ProtocolMemcached),
+		},
+		{
+			name: "binary get",
+			context: testContext{
+				serverPort:    memcachedPort,
+				serverAddress: serverAddress,
+				targetAddress: targetAddress,
+				extras:        map[string]interface{}{},
+			},
+			postTracerSetup: func(t *testing.T, ctx testContext) {
+				timedContext, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+				defer cancel()
+				c, err := defaultDialer.DialContext(timedContext, "tcp", ctx.targetAddress)
+				require.NoError(t, err)
+				defer c.Close()
+				_, err = c.Write(binaryGetRequest)
+				require.NoError(t, err)
+				header := make([]byte, 24)
+				_, err = io.ReadFull(c, header)
+				require.NoError(t, err)
+			},
+			validation: validateProtocolConnection(network.ProtocolMemcached),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testProtocolClassificationInner(t, tt, cfg)
+		})
+	}
+}
+
 func testEdgeCasesProtocolClassification(t *testing.T, cfg *config.Config, clientHost, targetHost, serverHost string) {
 	defaultDialer := &net.Dialer{
 		LocalAddr: &net.TCPAddr{
@@ -1361,6 +1643,70 @@ func testEdgeCasesProtocolClassification(t *testing.T, cfg *config.Config, clien
 	}
 }
 
+// goldenProtocols maps the directories of the golden classification corpus to the protocol they hold samples of.
+var goldenProtocols = map[string]network.ProtocolType{
+	network.ProtocolUnknown.String():  network.ProtocolUnknown,
+	network.ProtocolHTTP.String():     network.ProtocolHTTP,
+	network.ProtocolHTTP2.String():    network.ProtocolHTTP2,
+	network.ProtocolAMQP.String():     network.ProtocolAMQP,
+	network.ProtocolRedis.String():    network.ProtocolRedis,
+	network.ProtocolMongo.String():    network.ProtocolMongo,
+	network.ProtocolPostgres.String(): network.ProtocolPostgres,
+	network.ProtocolMySQL.String():    network.ProtocolMySQL,
+}
+
+// testPCAPReplayProtocolClassification replays the flows of the golden classification corpus (see
+// pkg/network/protocols/testdata/golden), and validates the kernel classification matches the expected protocol.
+func testPCAPReplayProtocolClassification(t *testing.T, cfg *config.Config, clientHost, targetHost, serverHost string) {
+	defaultDialer := &net.Dialer{
+		LocalAddr: &net.TCPAddr{
+			IP: net.ParseIP(clientHost),
+		},
+		Timeout: time.Second,
+	}
+
+	teardown := func(t *testing.T, ctx testContext) {
+		if ln, ok := ctx.extras["listener"].(net.Listener); ok {
+			ln.Close()
+		}
+	}
+
+	samples, err := protocolsUtils.GoldenSamples()
+	require.NoError(t, err)
+
+	for _, sample := range samples {
+		expectedProtocol, ok := goldenProtocols[sample.Protocol]
+		require.True(t, ok, "unknown protocol %q for golden sample %s", sample.Protocol, sample.Name)
+
+		for i, flow := range sample.Flows {
+			flow := flow
+			tt := protocolClassificationAttributes{
+				name: fmt.Sprintf("%s flow %d", sample.Name, i),
+				context: testContext{
+					serverPort:    tcpPort,
+					serverAddress: net.JoinHostPort(serverHost, tcpPort),
+					targetAddress: net.JoinHostPort(targetHost, tcpPort),
+					extras:        map[string]interface{}{},
+				},
+				preTracerSetup: func(t *testing.T, ctx testContext) {
+					ln, err := net.Listen("tcp", ctx.serverAddress)
+					require.NoError(t, err)
+					ctx.extras["listener"] = ln
+				},
+				postTracerSetup: func(t *testing.T, ctx testContext) {
+					ln := ctx.extras["listener"].(net.Listener)
+					require.NoError(t, protocolsUtils.ReplayFlow(flow, ln, defaultDialer, ctx.targetAddress))
+				},
+				teardown:   teardown,
+				validation: validateProtocolConnection(expectedProtocol),
+			}
+			t.Run(tt.name, func(t *testing.T) {
+				testProtocolClassificationInner(t, tt, cfg)
+			})
+		}
+	}
+}
+
 func testProtocolClassificationInner(t *testing.T, params protocolClassificationAttributes, cfg *config.Config) {
 	if params.skipCallback != nil {
 		params.skipCallback(t, params.context)
@@ -116,6 +116,76 @@ func TestTCPRemoveEntries(t *testing.T) {
 
 }
 
+func TestTCPReusedTuple(t *testing.T) {
+	tr := setupTracer(t, testConfig())
+	server := NewTCPServer(func(c net.Conn) {
+		io.Copy(io.Discard, c)
+		c.Close()
+	})
+	t.Cleanup(server.Shutdown)
+	require.NoError(t, server.Run())
+
+	dialer := &net.Dialer{
+		Timeout: 2 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			var opErr error
+			err := c.Control(func(fd uintptr) {
+				opErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1)
+			})
+			if err != nil {
+				return err
+			}
+			return opErr
+		},
+	}
+
+	// reading the socket cookies generates them, so that the stats of the connections are told apart by them
+	socketCookie := func(c net.Conn) uint64 {
+		rawConn, err := c.(*net.TCPConn).SyscallConn()
+		require.NoError(t, err)
+		var cookie uint64
+		var opErr error
+		require.NoError(t, rawConn.Control(func(fd uintptr) {
+			cookie, opErr = unix.GetsockoptUint64(int(fd), unix.SOL_SOCKET, unix.SO_COOKIE)
+		}))
+		require.NoError(t, opErr)
+		return cookie
+	}
+
+	c, err := dialer.Dial("tcp", server.address)
+	require.NoError(t, err)
+	cookie := socketCookie(c)
+	_, err = c.Write(genPayload(clientMessageSize))
+	require.NoError(t, err)
+	// the connection is reset, so its tuple can be reused right away
+	require.NoError(t, c.(*net.TCPConn).SetLinger(0))
+	require.NoError(t, c.Close())
+
+	// reuse the tuple of the first connection
+	dialer.LocalAddr = c.LocalAddr()
+	c2, err := dialer.Dial("tcp", server.address)
+	require.NoError(t, err)
+	defer c2.Close()
+	require.NotEqual(t, cookie, socketCookie(c2))
+	_, err = c2.Write(genPayload(2 * clientMessageSize))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		conns := searchConnections(getConnections(t, tr), func(cs network.ConnectionStats) bool {
+			return fmt.Sprintf("%s:%d", cs.Source, cs.SPort) == c2.LocalAddr().String() &&
+				fmt.Sprintf("%s:%d", cs.Dest, cs.DPort) == c2.RemoteAddr().String()
+		})
+		// the stats of both connections must not be merged
+		for _, conn := range conns {
+			if sent := int(conn.Monotonic.SentBytes); sent != clientMessageSize && sent != 2*clientMessageSize {
+				t.Logf("unexpected stats for %s", conn)
+				return false
+			}
+		}
+		return len(conns) > 0
+	}, 3*time.Second, 500*time.Millisecond)
+}
+
 func TestTCPRetransmit(t *testing.T) {
 	// Enable BPF-based system probe
 	tr := setupTracer(t, testConfig())
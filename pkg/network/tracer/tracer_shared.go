@@ -23,3 +23,14 @@ func (t *Tracer) shouldSkipConnection(conn *network.ConnectionStats) bool {
 	}
 	return false
 }
+
+// GetProcessStats returns the network activity of the processes since the last call of the client, aggregated from
+// the stats of their connections. The client gets its own connection deltas, as for GetActiveConnections.
+func (t *Tracer) GetProcessStats(clientID string) ([]network.ProcessStats, error) {
+	cs, err := t.GetActiveConnections(clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer network.Reclaim(cs)
+	return network.AggregateProcessStats(cs.Conns), nil
+}
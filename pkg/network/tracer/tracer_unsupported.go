@@ -10,6 +10,7 @@ package tracer
 
 import (
 	"context"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/network"
@@ -32,11 +33,24 @@ func (t *Tracer) GetActiveConnections(_ string) (*network.Connections, error) {
 	return nil, ebpf.ErrNotImplemented
 }
 
+// GetProcessStats is not implemented on this OS for Tracer
+func (t *Tracer) GetProcessStats(_ string) ([]network.ProcessStats, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
 // RegisterClient registers the client
 func (t *Tracer) RegisterClient(clientID string) error {
 	return ebpf.ErrNotImplemented
 }
 
+// SetClientMaxConnections is not implemented on this OS for Tracer
+func (t *Tracer) SetClientMaxConnections(clientID string, maxConns int) {}
+
+// SubscribeClosedConnections is not implemented on this OS for Tracer, the returned channel is never signaled
+func (t *Tracer) SubscribeClosedConnections() (<-chan struct{}, func()) {
+	return nil, func() {}
+}
+
 // GetStats is not implemented on this OS for Tracer
 func (t *Tracer) GetStats() (map[string]interface{}, error) {
 	return nil, ebpf.ErrNotImplemented
@@ -71,3 +85,33 @@ func (t *Tracer) DebugHostConntrack(ctx context.Context) (interface{}, error) {
 func (t *Tracer) DebugDumpProcessCache(ctx context.Context) (interface{}, error) {
 	return nil, ebpf.ErrNotImplemented
 }
+
+// DebugTCPDrops is not implemented on this OS for Tracer
+func (t *Tracer) DebugTCPDrops() (interface{}, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// DebugHTTP2Connections is not implemented on this OS for Tracer
+func (t *Tracer) DebugHTTP2Connections() (interface{}, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// SustainedPerfBufferLoss is not implemented on this OS for Tracer
+func (t *Tracer) SustainedPerfBufferLoss() []string {
+	return nil
+}
+
+// DebugUSMAttachments is not implemented on this OS for Tracer
+func (t *Tracer) DebugUSMAttachments(ctx context.Context, hitsWindow time.Duration) (interface{}, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// GetUSMProtocols is not implemented on this OS for Tracer
+func (t *Tracer) GetUSMProtocols() (map[string]bool, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// SetUSMProtocolEnabled is not implemented on this OS for Tracer
+func (t *Tracer) SetUSMProtocolEnabled(_ string, _ bool) error {
+	return ebpf.ErrNotImplemented
+}
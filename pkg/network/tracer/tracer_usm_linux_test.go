@@ -10,6 +10,7 @@ package tracer
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
 	"fmt"
@@ -38,9 +39,9 @@ import (
 	javatestutil "github.com/DataDog/datadog-agent/pkg/network/java/testutil"
 	netlink "github.com/DataDog/datadog-agent/pkg/network/netlink/testutil"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http/debugging"
 	"github.com/DataDog/datadog-agent/pkg/network/protocols/http/testutil"
 	nettestutil "github.com/DataDog/datadog-agent/pkg/network/testutil"
-	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/connection/kprobe"
 	tracertestutil "github.com/DataDog/datadog-agent/pkg/network/tracer/testutil"
 	"github.com/DataDog/datadog-agent/pkg/network/tracer/testutil/grpc"
@@ -154,6 +155,56 @@ func TestHTTPStats(t *testing.T) {
 	assert.Nil(t, httpReqStats.Stats(500), "500s")            // 500
 }
 
+func TestHTTPStatsConsistency(t *testing.T) {
+	if !httpSupported(t) {
+		t.Skip("HTTP monitoring feature not available")
+		return
+	}
+
+	cfg := testConfig()
+	cfg.EnableHTTPMonitoring = true
+	tr := setupTracer(t, cfg)
+
+	serverAddr := "127.0.0.1:8080"
+	accessLog := new(bytes.Buffer)
+	srvDoneFn := testutil.HTTPServer(t, serverAddr, testutil.Options{
+		EnableKeepAlives: true,
+		AccessLog:        accessLog,
+	})
+
+	client := new(nethttp.Client)
+	for i := 0; i < 50; i++ {
+		status := statusCodes[i%len(statusCodes)]
+		resp, err := client.Get(fmt.Sprintf("http://%s/%d/request-%d", serverAddr, status, i))
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	client.CloseIdleConnections()
+	srvDoneFn()
+
+	requireUSMConsistency(t, tr, accessLog)
+}
+
+// requireUSMConsistency ensures that the HTTP transactions reported by the tracer are exactly the ones present in the
+// given server access log, and reports every missed or extra transaction otherwise.
+func requireUSMConsistency(t *testing.T, tr *Tracer, accessLog *bytes.Buffer) {
+	entries, err := debugging.ParseAccessLog(accessLog)
+	require.NoError(t, err)
+
+	var summaries []debugging.RequestSummary
+	var report *debugging.ConsistencyReport
+	for start := time.Now(); time.Since(start) < 3*time.Second; time.Sleep(100 * time.Millisecond) {
+		payload := getConnections(t, tr)
+		summaries = append(summaries, debugging.HTTP(payload.HTTP, nil)...)
+		report = debugging.CheckConsistency(summaries, entries)
+		if report.Consistent() {
+			return
+		}
+	}
+	require.Truef(t, report.Consistent(), "USM stats are not consistent with the server access log:\n%s", report)
+}
+
 func TestHTTPSViaLibraryIntegration(t *testing.T) {
 	if !httpSupported(t) {
 		t.Skip("HTTPS feature not available on pre 4.14.0 kernels")
@@ -272,6 +323,44 @@ func testHTTPSLibrary(t *testing.T, fetchCmd []string, prefechLibs []string) {
 	}, 10*time.Second, 1*time.Second, "couldn't find HTTPS stats")
 }
 
+// TestHTTPSNodeJS makes sure the HTTPS requests issued through the OpenSSL statically linked into node are captured
+func TestHTTPSNodeJS(t *testing.T) {
+	if !httpSupported(t) {
+		t.Skip("HTTPS feature not available on pre 4.14.0 kernels")
+	}
+	if !httpsSupported(t) {
+		t.Skip("HTTPS feature not available/supported for this setup")
+	}
+
+	serverDoneFn := testutil.HTTPServer(t, "127.0.0.1:443", testutil.Options{
+		EnableTLS:        true,
+		EnableKeepAlives: true,
+	})
+	t.Cleanup(serverDoneFn)
+
+	cfg := testConfig()
+	cfg.EnableHTTPMonitoring = true
+	cfg.EnableHTTPSMonitoring = true
+	tr := setupTracer(t, cfg)
+
+	const requests = 10
+	testutil.NodeJSHTTPSClient(t, "https://127.0.0.1:443/200/nodejs", requests)
+
+	require.Eventuallyf(t, func() bool {
+		payload := getConnections(t, tr)
+		for key, stats := range payload.HTTP {
+			if key.Path.Content != "/200/nodejs" || !stats.HasStats(200) {
+				continue
+			}
+			if stats.Stats(200).StaticTags == tagOpenSSL {
+				return true
+			}
+			t.Logf("HTTP stat didn't match criteria %v tags 0x%x\n", key, stats.Stats(200).StaticTags)
+		}
+		return false
+	}, 10*time.Second, 1*time.Second, "couldn't find node HTTPS stats")
+}
+
 const (
 	numberOfRequests = 100
 )
@@ -339,6 +428,55 @@ func TestOpenSSLVersions(t *testing.T) {
 	}, 3*time.Second, time.Second, "connection not found")
 }
 
+// TestOpenSSLChunkedLastChunkOnItsOwn checks that a chunked response whose last chunk is written on its own is
+// reported while the connection is still open, as the response is known to be complete.
+func TestOpenSSLChunkedLastChunkOnItsOwn(t *testing.T) {
+	if !httpSupported(t) {
+		t.Skip("HTTPS feature not available on pre 4.14.0 kernels")
+	}
+
+	if !httpsSupported(t) {
+		t.Skip("HTTPS feature not available/supported for this setup")
+	}
+
+	cfg := testConfig()
+	cfg.EnableHTTPSMonitoring = true
+	cfg.EnableHTTPMonitoring = true
+	tr := setupTracer(t, cfg)
+
+	addressOfHTTPPythonServer := "127.0.0.1:8001"
+	closer, err := testutil.HTTPPythonServer(t, addressOfHTTPPythonServer, testutil.Options{
+		EnableTLS: true,
+	})
+	require.NoError(t, err)
+	defer closer()
+
+	// Giving the tracer time to install the hooks
+	time.Sleep(time.Second)
+	client := &nethttp.Client{
+		Transport: &nethttp.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: false,
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	req, err := nethttp.NewRequest(nethttp.MethodGet, fmt.Sprintf("https://%s/200/chunked", addressOfHTTPPythonServer), nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, []string{"chunked"}, resp.TransferEncoding)
+	require.Equal(t, "hello", string(body))
+
+	// the connection is kept open, so that the transaction is only reported if its last chunk was seen
+	require.Eventually(t, func() bool {
+		return isRequestIncluded(getConnections(t, tr).HTTP, req)
+	}, 3*time.Second, time.Second, "chunked response not found")
+}
+
 // TestOpenSSLVersionsSlowStart check we are able to capture TLS traffic even if we haven't captured the TLS handshake.
 // It can happen if the agent starts after connections have been made, or agent restart (OOM/upgrade).
 // Unfortunately, this is only a best-effort mechanism and it relies on some assumptions that are not always necessarily true
@@ -519,10 +657,6 @@ func testProtocolClassificationMapCleanup(t *testing.T, cfg *config.Config, clie
 
 		tr := setupTracer(t, cfg)
 
-		if tr.ebpfTracer.Type() == connection.EBPFFentry {
-			t.Skip("protocol classification not supported for fentry tracer")
-		}
-
 		HTTPServer := NewTCPServerOnAddress(serverHost, func(c net.Conn) {
 			r := bufio.NewReader(c)
 			input, err := r.ReadBytes(byte('\n'))
@@ -606,17 +740,19 @@ func TestJavaInjection(t *testing.T) {
 		extras map[string]interface{}
 	}
 
-	tests := []struct {
+	type javaInjectionTest struct {
 		name            string
 		context         testContext
 		preTracerSetup  func(t *testing.T, ctx testContext)
 		postTracerSetup func(t *testing.T, ctx testContext)
 		validation      func(t *testing.T, ctx testContext, tr *Tracer)
 		teardown        func(t *testing.T, ctx testContext)
-	}{
-		{
-			// Test the java hotspot injection is working
-			name: "java_hotspot_injection",
+	}
+
+	// injectionTest tests the injection is working on a JVM running the given image
+	injectionTest := func(name string, image string) javaInjectionTest {
+		return javaInjectionTest{
+			name: name,
 			context: testContext{
 				extras: make(map[string]interface{}),
 			},
@@ -631,7 +767,7 @@ func TestJavaInjection(t *testing.T) {
 				cfg.JavaAgentArgs += " testfile=/v/" + filepath.Base(tfile.Name())
 			},
 			postTracerSetup: func(t *testing.T, ctx testContext) {
-				javatestutil.RunJavaVersion(t, "openjdk:21-oraclelinux8", "JustWait")
+				javatestutil.RunJavaVersion(t, image, "JustWait")
 				// if RunJavaVersion failing to start it's probably because the java process has not been injected
 
 				cfg.JavaAgentArgs = ctx.extras["JavaAgentArgs"].(string)
@@ -644,7 +780,16 @@ func TestJavaInjection(t *testing.T) {
 				require.NoError(t, err)
 				os.Remove(testfile)
 			},
-		},
+		}
+	}
+
+	tests := []javaInjectionTest{
+		// Test the java hotspot injection is working
+		injectionTest("java_hotspot_injection", "openjdk:21-oraclelinux8"),
+		// Test the OpenJ9 attach protocol is working
+		injectionTest("java_openj9_injection", "ibm-semeru-runtimes:open-17-jdk"),
+		// Test the injection of Zing, which implements the hotspot attach protocol
+		injectionTest("java_zing_injection", "azul/prime:17"),
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -701,6 +846,20 @@ func TestHTTPGoTLSAttachProbes(t *testing.T) {
 		cfg.AllowRuntimeCompiledFallback = false
 		testHTTPGoTLSCaptureAlreadyRunning(t, cfg)
 	})
+
+	t.Run("new process (prebuilt)", func(t *testing.T) {
+		cfg := config.New()
+		cfg.EnableCORE = false
+		cfg.EnableRuntimeCompiler = false
+		testHTTPGoTLSCaptureNewProcess(t, cfg)
+	})
+
+	t.Run("already running process (prebuilt)", func(t *testing.T) {
+		cfg := config.New()
+		cfg.EnableCORE = false
+		cfg.EnableRuntimeCompiler = false
+		testHTTPGoTLSCaptureAlreadyRunning(t, cfg)
+	})
 }
 
 // Test that we can capture HTTPS traffic from Go processes started after the
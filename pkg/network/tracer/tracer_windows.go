@@ -48,6 +48,9 @@ type Tracer struct {
 	closedBuffer *network.ConnectionBuffer
 	connLock     sync.Mutex
 
+	// closedNotifier signals the streaming clients when closed connections are stored
+	closedNotifier closedConnectionsNotifier
+
 	timerInterval int
 
 	// Connections for the tracer to exclude
@@ -69,13 +72,24 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 		return nil, fmt.Errorf("could not create windows driver controller: %v", err)
 	}
 
-	state := network.NewState(
-		config.ClientStateExpiry,
-		config.MaxClosedConnectionsBuffered,
-		config.MaxConnectionsStateBuffered,
-		config.MaxDNSStatsBuffered,
-		config.MaxHTTPStatsBuffered,
-	)
+	state := network.NewState(network.StateConfig{
+		ClientExpiry:      config.ClientStateExpiry,
+		MaxClosedConns:    config.MaxClosedConnectionsBuffered,
+		MaxClientStats:    config.MaxConnectionsStateBuffered,
+		MaxDNSStats:       config.MaxDNSStatsBuffered,
+		MaxHTTPStats:      config.MaxHTTPStatsBuffered,
+		MaxKafkaStats:     config.MaxKafkaStatsBuffered,
+		MaxPostgresStats:  config.MaxPostgresStatsBuffered,
+		MaxMySQLStats:     config.MaxMySQLStatsBuffered,
+		MaxRedisStats:     config.MaxRedisStatsBuffered,
+		MaxMongoStats:     config.MaxMongoStatsBuffered,
+		MaxAMQPStats:      config.MaxAMQPStatsBuffered,
+		MaxGRPCStats:      config.MaxGRPCStatsBuffered,
+		MaxHTTP3Stats:     config.MaxHTTP3StatsBuffered,
+		MaxCassandraStats: config.MaxCassandraStatsBuffered,
+		MaxMemcachedStats: config.MaxMemcachedStatsBuffered,
+		MaxClientConns:    config.MaxConnectionsPerClient,
+	})
 
 	reverseDNS := dns.NewNullReverseDNS()
 	if config.DNSInspection {
@@ -84,6 +98,12 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 			return nil, err
 		}
 	}
+	if config.EnableReverseDNSEnrichment {
+		reverseDNS = dns.NewEnrichedReverseDNS(reverseDNS, config)
+		if err = reverseDNS.Start(); err != nil {
+			return nil, err
+		}
+	}
 
 	tr := &Tracer{
 		config:          config,
@@ -115,6 +135,9 @@ func NewTracer(config *config.Config) (*Tracer, error) {
 				closedConnStats := tr.closedBuffer.Connections()
 
 				tr.state.StoreClosedConnections(closedConnStats)
+				if len(closedConnStats) > 0 {
+					tr.closedNotifier.notify()
+				}
 
 			case windows.WAIT_FAILED:
 				break waitloop
@@ -169,14 +192,18 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 
 	var delta network.Delta
 	if t.httpMonitor != nil { //nolint
-		delta = t.state.GetDelta(clientID, uint64(time.Now().Nanosecond()), activeConnStats, t.reverseDNS.GetDNSStats(), t.httpMonitor.GetHTTPStats())
+		delta = t.state.GetDelta(clientID, uint64(time.Now().Nanosecond()), activeConnStats, t.reverseDNS.GetDNSStats(), network.USMStats{HTTP: t.httpMonitor.GetHTTPStats()})
 	} else {
-		delta = t.state.GetDelta(clientID, uint64(time.Now().Nanosecond()), activeConnStats, t.reverseDNS.GetDNSStats(), nil)
+		delta = t.state.GetDelta(clientID, uint64(time.Now().Nanosecond()), activeConnStats, t.reverseDNS.GetDNSStats(), network.USMStats{})
 	}
 
 	t.activeBuffer.Reset()
 	t.closedBuffer.Reset()
 
+	if t.config.EnableConnectionRollup {
+		delta.Conns = network.RollupConnections(delta.Conns, delta.HTTP, nil)
+	}
+
 	ips := make([]util.Address, 0, len(delta.Conns)*2)
 	for _, conn := range delta.Conns {
 		ips = append(ips, conn.Source, conn.Dest)
@@ -198,6 +225,17 @@ func (t *Tracer) RegisterClient(clientID string) error {
 	return nil
 }
 
+// SetClientMaxConnections sets the maximum number of connections returned to a client, registering it if needed
+func (t *Tracer) SetClientMaxConnections(clientID string, maxConns int) {
+	t.state.SetClientMaxConnections(clientID, maxConns)
+}
+
+// SubscribeClosedConnections returns a channel signaled when closed connections are stored, so that a client can
+// fetch them right away, and the function ending the subscription
+func (t *Tracer) SubscribeClosedConnections() (<-chan struct{}, func()) {
+	return t.closedNotifier.subscribe()
+}
+
 func (t *Tracer) getConnTelemetry() map[network.ConnTelemetryType]int64 {
 	tm := map[network.ConnTelemetryType]int64{}
 
@@ -272,6 +310,36 @@ func (t *Tracer) DebugDumpProcessCache(ctx context.Context) (interface{}, error)
 	return nil, ebpf.ErrNotImplemented
 }
 
+// DebugTCPDrops is not implemented on this OS for Tracer
+func (t *Tracer) DebugTCPDrops() (interface{}, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// DebugHTTP2Connections is not implemented on this OS for Tracer
+func (t *Tracer) DebugHTTP2Connections() (interface{}, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// SustainedPerfBufferLoss is not implemented on this OS for Tracer
+func (t *Tracer) SustainedPerfBufferLoss() []string {
+	return nil
+}
+
+// DebugUSMAttachments is not implemented on this OS for Tracer
+func (t *Tracer) DebugUSMAttachments(ctx context.Context, hitsWindow time.Duration) (interface{}, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// GetUSMProtocols is not implemented on this OS for Tracer
+func (t *Tracer) GetUSMProtocols() (map[string]bool, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
+// SetUSMProtocolEnabled is not implemented on this OS for Tracer
+func (t *Tracer) SetUSMProtocolEnabled(_ string, _ bool) error {
+	return ebpf.ErrNotImplemented
+}
+
 func newHttpMonitor(c *config.Config, dh driver.Handle) http.Monitor {
 	if !c.EnableHTTPMonitoring && !c.EnableHTTPSMonitoring {
 		return nil
@@ -15,6 +15,8 @@ import (
 
 	ddebpf "github.com/DataDog/datadog-agent/pkg/ebpf"
 	"github.com/DataDog/datadog-agent/pkg/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/network/config"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols/http"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/kernel"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -86,3 +88,48 @@ func verifyOSVersion(kernelCode kernel.Version, platform string, exclusionList [
 	errMsg += fmt.Sprintf("required functions missing: %s", strings.Join(missingFuncs, ", "))
 	return false, errMsg
 }
+
+// degradedModeKernelVersion is the kernel version below which the tracer runs with a reduced feature set, only
+// monitoring connections and DNS traffic, such as on the 3.10 kernels of RHEL/CentOS 7 or the 4.4 kernels of
+// Ubuntu 16.04. The features relying on newer eBPF capabilities would otherwise partially fail to load.
+var degradedModeKernelVersion = kernel.VersionCode(4, 7, 0)
+
+// applyDegradedMode disables the features not supported by the given kernel version, and returns a description of
+// the reduced feature set, or an empty string if the kernel supports the full feature set
+func applyDegradedMode(cfg *config.Config, kernelVersion kernel.Version) string {
+	if kernelVersion == 0 || kernelVersion >= degradedModeKernelVersion {
+		return ""
+	}
+
+	var disabled []string
+	disable := func(enabled *bool, feature string) {
+		if *enabled {
+			*enabled = false
+			disabled = append(disabled, feature)
+		}
+	}
+	disable(&cfg.ServiceMonitoringEnabled, "universal service monitoring")
+	// the plaintext HTTP traffic is still monitored by the socket filter fallback of the http monitoring
+	httpFallback := http.FallbackSupported(kernelVersion) && cfg.EnableHTTPMonitoring
+	if !httpFallback {
+		disable(&cfg.EnableHTTPMonitoring, "http monitoring")
+	}
+	disable(&cfg.EnableHTTPSMonitoring, "https monitoring")
+	disable(&cfg.ProtocolClassificationEnabled, "protocol classification")
+	disable(&cfg.EnableProcessEventMonitoring, "process event monitoring")
+	disable(&cfg.EnableFentry, "fentry")
+	// only the prebuilt programs are supported, which also means the netlink conntracker is used
+	disable(&cfg.EnableCORE, "co-re")
+	disable(&cfg.EnableRuntimeCompiler, "runtime compilation")
+	cfg.AllowPrecompiledFallback = true
+
+	monitored := "connections and DNS"
+	if httpFallback {
+		monitored = "connections, DNS and plaintext HTTP"
+	}
+	msg := fmt.Sprintf("kernel %s is older than %s, only %s are monitored", kernelVersion, degradedModeKernelVersion, monitored)
+	if len(disabled) > 0 {
+		msg += fmt.Sprintf(" (disabled: %s)", strings.Join(disabled, ", "))
+	}
+	return msg
+}
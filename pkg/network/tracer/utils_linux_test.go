@@ -13,6 +13,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/DataDog/datadog-agent/pkg/network/config"
 	"github.com/DataDog/datadog-agent/pkg/util/kernel"
 )
 
@@ -62,3 +63,47 @@ func TestExcludedKernelVersion(t *testing.T) {
 	assert.True(t, ok)
 	assert.Empty(t, msg)
 }
+
+func TestApplyDegradedMode(t *testing.T) {
+	newConfig := func() *config.Config {
+		cfg := config.New()
+		cfg.ServiceMonitoringEnabled = true
+		cfg.EnableHTTPMonitoring = true
+		cfg.ProtocolClassificationEnabled = true
+		cfg.EnableRuntimeCompiler = true
+		cfg.AllowPrecompiledFallback = false
+		return cfg
+	}
+
+	t.Run("old kernel", func(t *testing.T) {
+		for _, kv := range []kernel.Version{kernel.VersionCode(3, 10, 0), kernel.VersionCode(4, 4, 0)} {
+			cfg := newConfig()
+			msg := applyDegradedMode(cfg, kv)
+			assert.Contains(t, msg, "universal service monitoring")
+			assert.False(t, cfg.ServiceMonitoringEnabled)
+			// the plaintext HTTP traffic is monitored by the socket filter fallback from 4.4
+			if kv < kernel.VersionCode(4, 4, 0) {
+				assert.Contains(t, msg, "only connections and DNS are monitored")
+				assert.False(t, cfg.EnableHTTPMonitoring)
+			} else {
+				assert.Contains(t, msg, "only connections, DNS and plaintext HTTP are monitored")
+				assert.True(t, cfg.EnableHTTPMonitoring)
+			}
+			assert.False(t, cfg.ProtocolClassificationEnabled)
+			assert.False(t, cfg.EnableRuntimeCompiler)
+			assert.True(t, cfg.AllowPrecompiledFallback)
+			assert.True(t, cfg.CollectTCPConns)
+			assert.True(t, cfg.DNSInspection)
+		}
+	})
+
+	t.Run("supported kernel", func(t *testing.T) {
+		for _, kv := range []kernel.Version{0, kernel.VersionCode(4, 7, 0), kernel.VersionCode(5, 15, 0)} {
+			cfg := newConfig()
+			assert.Empty(t, applyDegradedMode(cfg, kv))
+			assert.True(t, cfg.ServiceMonitoringEnabled)
+			assert.True(t, cfg.ProtocolClassificationEnabled)
+			assert.True(t, cfg.EnableRuntimeCompiler)
+		}
+	})
+}
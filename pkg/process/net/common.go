@@ -21,6 +21,7 @@ import (
 
 	model "github.com/DataDog/agent-payload/v5/process"
 
+	"github.com/DataDog/datadog-agent/pkg/network"
 	netEncoding "github.com/DataDog/datadog-agent/pkg/network/encoding"
 	procEncoding "github.com/DataDog/datadog-agent/pkg/process/encoding"
 	reqEncoding "github.com/DataDog/datadog-agent/pkg/process/encoding/request"
@@ -167,6 +168,89 @@ func (r *RemoteSysProbeUtil) GetConnections(clientID string) (*model.Connections
 	return conns, nil
 }
 
+// GetProcessNetworkStats returns the network throughput and the connection counts of the processes aggregated over the
+// connections of the interval since the previous request of the client, retrieved from the system probe service
+func (r *RemoteSysProbeUtil) GetProcessNetworkStats(clientID string) ([]network.ProcessStats, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?client_id=%s", processNetworkStatsURL, clientID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("process network stats request failed: Probe Path %s, url: %s, status code: %d", r.path, processNetworkStatsURL, resp.StatusCode)
+	}
+
+	var stats []network.ProcessStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// StreamConnections receives the connection deltas pushed by the system probe service, every interval and as soon as
+// connections are closed, and calls fn with each of them. It returns once ctx is done, the stream ends or fn fails.
+// The interval is sent in whole seconds: it is rounded up, and intervals shorter than a second are rejected.
+func (r *RemoteSysProbeUtil) StreamConnections(ctx context.Context, clientID string, interval time.Duration, fn func(*model.Connections) error) error {
+	seconds, err := streamIntervalSeconds(interval)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s?client_id=%s&interval=%d", streamURL, clientID, seconds)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", contentTypeProtobuf)
+
+	// the response lasts as long as the stream, so it isn't bound by the timeout of the requests
+	client := r.httpClient
+	client.Timeout = 0
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("conn stream request failed: Probe Path %s, url: %s, status code: %d", r.path, streamURL, resp.StatusCode)
+	}
+
+	unmarshaler := netEncoding.GetUnmarshaler(resp.Header.Get("Content-type"))
+	for {
+		frame, err := netEncoding.ReadStreamFrame(resp.Body)
+		if err != nil {
+			if err == io.EOF || ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		conns, err := unmarshaler.Unmarshal(frame)
+		if err != nil {
+			return err
+		}
+		if err := fn(conns); err != nil {
+			return err
+		}
+	}
+}
+
+// streamIntervalSeconds returns the number of seconds of the interval of the connection stream, rounded up, as the
+// system probe service only supports whole seconds
+func streamIntervalSeconds(interval time.Duration) (int, error) {
+	if interval < time.Second {
+		return 0, fmt.Errorf("invalid conn stream interval %s: it must be at least 1s", interval)
+	}
+	return int((interval + time.Second - 1) / time.Second), nil
+}
+
 // GetStats returns the expvar stats of the system probe
 func (r *RemoteSysProbeUtil) GetStats() (map[string]interface{}, error) {
 	req, err := http.NewRequest("GET", statsURL, nil)
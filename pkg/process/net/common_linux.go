@@ -16,11 +16,13 @@ import (
 )
 
 const (
-	connectionsURL = "http://unix/" + string(sysconfig.NetworkTracerModule) + "/connections"
-	procStatsURL   = "http://unix/" + string(sysconfig.ProcessModule) + "/stats"
-	registerURL    = "http://unix/" + string(sysconfig.NetworkTracerModule) + "/register"
-	statsURL       = "http://unix/debug/stats"
-	netType        = "unix"
+	connectionsURL         = "http://unix/" + string(sysconfig.NetworkTracerModule) + "/connections"
+	streamURL              = "http://unix/" + string(sysconfig.NetworkTracerModule) + "/connections/stream"
+	procStatsURL           = "http://unix/" + string(sysconfig.ProcessModule) + "/stats"
+	registerURL            = "http://unix/" + string(sysconfig.NetworkTracerModule) + "/register"
+	processNetworkStatsURL = "http://unix/" + string(sysconfig.NetworkTracerModule) + "/processes"
+	statsURL               = "http://unix/debug/stats"
+	netType                = "unix"
 )
 
 // CheckPath is used in conjunction with calling the stats endpoint, since we are calling this
@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux || windows
+// +build linux windows
+
+package net
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamIntervalSeconds(t *testing.T) {
+	for _, tc := range []struct {
+		interval time.Duration
+		seconds  int
+	}{
+		{time.Second, 1},
+		{30 * time.Second, 30},
+		// the intervals are rounded up to the next second
+		{1500 * time.Millisecond, 2},
+	} {
+		seconds, err := streamIntervalSeconds(tc.interval)
+		require.NoError(t, err)
+		assert.Equal(t, tc.seconds, seconds, tc.interval)
+	}
+
+	// the sub-second intervals would be sent as 0, which the system probe rejects
+	for _, interval := range []time.Duration{0, 500 * time.Millisecond, -time.Second} {
+		_, err := streamIntervalSeconds(interval)
+		assert.Error(t, err, interval)
+	}
+}
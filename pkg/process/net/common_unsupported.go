@@ -12,6 +12,7 @@ import (
 	model "github.com/DataDog/agent-payload/v5/process"
 
 	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network"
 )
 
 var _ SysProbeUtil = &RemoteSysProbeUtil{}
@@ -39,6 +40,11 @@ func (r *RemoteSysProbeUtil) GetConnections(clientID string) (*model.Connections
 	return nil, ebpf.ErrNotImplemented
 }
 
+// GetProcessNetworkStats is not supported
+func (r *RemoteSysProbeUtil) GetProcessNetworkStats(clientID string) ([]network.ProcessStats, error) {
+	return nil, ebpf.ErrNotImplemented
+}
+
 // GetStats is not supported
 func (r *RemoteSysProbeUtil) GetStats() (map[string]interface{}, error) {
 	return nil, ebpf.ErrNotImplemented
@@ -22,7 +22,7 @@ import (
 	4. Add a method on the `Repository` to retrieved typed configs for the product.
 */
 
-var allProducts = []string{ProductAPMSampling, ProductCWSDD, ProductCWSCustom, ProductASMFeatures, ProductASMDD, ProductASMData, ProductAPMTracing}
+var allProducts = []string{ProductAPMSampling, ProductCWSDD, ProductCWSCustom, ProductASMFeatures, ProductASMDD, ProductASMData, ProductAPMTracing, ProductCSPMBundles, ProductCSPMExceptions}
 
 const (
 	// ProductAPMSampling is the apm sampling product
@@ -39,6 +39,10 @@ const (
 	ProductASMData = "ASM_DATA"
 	// ProductAPMTracing is the apm tracing product
 	ProductAPMTracing = "APM_TRACING"
+	// ProductCSPMBundles is the compliance product used to distribute signed bundles of compliance rules
+	ProductCSPMBundles = "CSPM_BUNDLES"
+	// ProductCSPMExceptions is the compliance product used to register exceptions to the compliance rules
+	ProductCSPMExceptions = "CSPM_EXCEPTIONS"
 )
 
 // ErrNoConfigVersion occurs when a target file's custom meta is missing the config version
@@ -62,6 +66,10 @@ func parseConfig(product string, raw []byte, metadata Metadata) (interface{}, er
 		c, err = parseConfigASMData(raw, metadata)
 	case ProductAPMTracing:
 		c, err = parseConfigAPMTracing(raw, metadata)
+	case ProductCSPMBundles:
+		c, err = parseConfigCSPMBundle(raw, metadata)
+	case ProductCSPMExceptions:
+		c, err = parseConfigCSPMExceptions(raw, metadata)
 	default:
 		return nil, fmt.Errorf("unknown product - %s", product)
 	}
@@ -346,6 +354,66 @@ func (r *Repository) APMTracingConfigs() map[string]APMTracingConfig {
 	return typedConfigs
 }
 
+// ConfigCSPMBundle is a signed bundle of compliance rules along with its
+// associated remote config metadata
+type ConfigCSPMBundle struct {
+	Config   []byte
+	Metadata Metadata
+}
+
+func parseConfigCSPMBundle(data []byte, metadata Metadata) (ConfigCSPMBundle, error) {
+	// The signature of the bundle is verified by the compliance agent
+	return ConfigCSPMBundle{
+		Config:   data,
+		Metadata: metadata,
+	}, nil
+}
+
+// CSPMBundleConfigs returns the currently active CSPM bundle configs
+func (r *Repository) CSPMBundleConfigs() map[string]ConfigCSPMBundle {
+	typedConfigs := make(map[string]ConfigCSPMBundle)
+	configs := r.getConfigs(ProductCSPMBundles)
+	for path, conf := range configs {
+		// We control this, so if this has gone wrong something has gone horribly wrong
+		typed, ok := conf.(ConfigCSPMBundle)
+		if !ok {
+			panic("unexpected config stored as ConfigCSPMBundle")
+		}
+		typedConfigs[path] = typed
+	}
+	return typedConfigs
+}
+
+// ConfigCSPMExceptions is a list of exceptions to the compliance rules along with its
+// associated remote config metadata
+type ConfigCSPMExceptions struct {
+	Config   []byte
+	Metadata Metadata
+}
+
+func parseConfigCSPMExceptions(data []byte, metadata Metadata) (ConfigCSPMExceptions, error) {
+	// We actually don't parse the payload here, we delegate this responsibility to the compliance agent
+	return ConfigCSPMExceptions{
+		Config:   data,
+		Metadata: metadata,
+	}, nil
+}
+
+// CSPMExceptionsConfigs returns the currently active CSPM exceptions configs
+func (r *Repository) CSPMExceptionsConfigs() map[string]ConfigCSPMExceptions {
+	typedConfigs := make(map[string]ConfigCSPMExceptions)
+	configs := r.getConfigs(ProductCSPMExceptions)
+	for path, conf := range configs {
+		// We control this, so if this has gone wrong something has gone horribly wrong
+		typed, ok := conf.(ConfigCSPMExceptions)
+		if !ok {
+			panic("unexpected config stored as ConfigCSPMExceptions")
+		}
+		typedConfigs[path] = typed
+	}
+	return typedConfigs
+}
+
 // Metadata stores remote config metadata for a given configuration
 type Metadata struct {
 	Product     string
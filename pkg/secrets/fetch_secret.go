@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/common"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 // PayloadVersion defines the current payload version sent to a secret backend
@@ -156,6 +157,8 @@ func fetchSecret(secretsHandle []string, origin string) (map[string]string, erro
 
 		// add it to the cache
 		secretCache[sec] = v.Value
+		// make sure the value is redacted from the logs, the status and the flares
+		scrubber.AddSecretValues(v.Value)
 		// keep track of place where a handle was found
 		secretOrigin[sec] = common.NewStringSet(origin)
 		res[sec] = v.Value
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/pkg/util/common"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
 
 var (
@@ -246,3 +247,21 @@ func TestFetchSecret(t *testing.T) {
 	}, secretCache)
 	assert.Equal(t, map[string]common.StringSet{"handle1": common.NewStringSet("test"), "handle2": common.NewStringSet("test")}, secretOrigin)
 }
+
+func TestFetchSecretRedacted(t *testing.T) {
+	defer func() {
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		scrubber.ClearSecretValues()
+	}()
+
+	runCommand = func(string) ([]byte, error) {
+		return []byte("{\"handle1\":{\"value\":\"password1\"}}"), nil
+	}
+	_, err := fetchSecret([]string{"handle1"}, "test")
+	require.NoError(t, err)
+
+	scrubbed, err := scrubber.ScrubString("custom_setting: password1")
+	require.NoError(t, err)
+	assert.Equal(t, "custom_setting: ********", scrubbed)
+}
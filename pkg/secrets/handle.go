@@ -0,0 +1,24 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secrets
+
+import "strings"
+
+// IsEnc returns true if a value is a secret handle of the form "ENC[handle]". Such a value is left as-is when the
+// secrets could not be resolved, or when the agent is compiled without the 'secrets' build tag.
+func IsEnc(str string) bool {
+	ok, _ := isEnc(str)
+	return ok
+}
+
+func isEnc(str string) (bool, string) {
+	// trimming space and tabs
+	str = strings.Trim(str, " 	")
+	if strings.HasPrefix(str, "ENC[") && strings.HasSuffix(str, "]") {
+		return true, str[4 : len(str)-1]
+	}
+	return false, ""
+}
@@ -9,17 +9,40 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"sort"
 	"strings"
 )
 
 // SecretInfo export troubleshooting information about the decrypted secrets
 type SecretInfo struct {
-	ExecutablePath string
-	Rights         string
-	RightDetails   string
-	UnixOwner      string
-	UnixGroup      string
-	SecretsHandles map[string][]string
+	ExecutablePath string              `json:"executable_path"`
+	Rights         string              `json:"rights"`
+	RightsOK       bool                `json:"rights_ok"`
+	RightDetails   string              `json:"right_details"`
+	UnixOwner      string              `json:"unix_owner,omitempty"`
+	UnixGroup      string              `json:"unix_group,omitempty"`
+	Backend        SecretBackendInfo   `json:"backend"`
+	SecretsHandles map[string][]string `json:"secrets_handles"`
+	// SecretsStatus holds the resolution status of every handle found in the configuration, including the ones that
+	// could not be decrypted
+	SecretsStatus map[string]SecretHandleStatus `json:"secrets_status"`
+}
+
+// SecretBackendInfo describes the configured secret backend. The arguments of the command are not exported as they
+// may hold credentials.
+type SecretBackendInfo struct {
+	Command            string `json:"command"`
+	ArgumentsCount     int    `json:"arguments_count"`
+	Timeout            int    `json:"timeout"`
+	OutputMaxSize      int    `json:"output_max_size"`
+	GroupExecPermitted bool   `json:"group_exec_permitted"`
+}
+
+// SecretHandleStatus is the resolution status of a secret handle
+type SecretHandleStatus struct {
+	Resolved bool     `json:"resolved"`
+	Origins  []string `json:"origins"`
+	Error    string   `json:"error,omitempty"`
 }
 
 // Print output a SecretInfo to a io.Writer
@@ -43,4 +66,20 @@ func (si *SecretInfo) Print(w io.Writer) {
 	for handle, origins := range si.SecretsHandles {
 		fmt.Fprintf(w, "- %s: from %s\n", handle, strings.Join(origins, ", "))
 	}
+
+	failed := []string{}
+	for handle, status := range si.SecretsStatus {
+		if !status.Resolved {
+			failed = append(failed, handle)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	sort.Strings(failed)
+	fmt.Fprintf(w, "Secrets handle that could not be decrypted:\n")
+	for _, handle := range failed {
+		status := si.SecretsStatus[handle]
+		fmt.Fprintf(w, "- %s: from %s: %s\n", handle, strings.Join(status.Origins, ", "), status.Error)
+	}
 }
@@ -22,6 +22,7 @@ func (info *SecretInfo) populateRights() {
 	} else {
 		info.Rights = fmt.Sprintf("OK, the executable has the correct rights")
 	}
+	info.RightsOK = err == nil
 
 	var stat syscall.Stat_t
 	if err := syscall.Stat(secretBackendCommand, &stat); err != nil {
@@ -23,6 +23,7 @@ func (info *SecretInfo) populateRights() {
 	} else {
 		info.Rights = fmt.Sprintf("OK, the executable has the correct rights")
 	}
+	info.RightsOK = err == nil
 
 	ps, err := exec.LookPath("powershell.exe")
 	if err != nil {
@@ -10,7 +10,6 @@ package secrets
 
 import (
 	"fmt"
-	"strings"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -22,6 +21,8 @@ var (
 	secretCache map[string]string
 	// list of handles and where they were found
 	secretOrigin map[string]common.StringSet
+	// list of handles that could not be decrypted and the last error returned for them
+	secretErrors map[string]secretError
 
 	secretBackendCommand               string
 	secretBackendArguments             []string
@@ -35,6 +36,12 @@ var (
 func init() {
 	secretCache = make(map[string]string)
 	secretOrigin = make(map[string]common.StringSet)
+	secretErrors = make(map[string]secretError)
+}
+
+type secretError struct {
+	origins common.StringSet
+	err     string
 }
 
 // Init initializes the command and other options of the secrets package. Since
@@ -115,15 +122,6 @@ func walk(data *interface{}, callback walkerCallback) error {
 	return nil
 }
 
-func isEnc(str string) (bool, string) {
-	// trimming space and tabs
-	str = strings.Trim(str, " 	")
-	if strings.HasPrefix(str, "ENC[") && strings.HasSuffix(str, "]") {
-		return true, str[4 : len(str)-1]
-	}
-	return false, ""
-}
-
 // testing purpose
 var secretFetcher = fetchSecret
 
@@ -170,8 +168,12 @@ func Decrypt(data []byte, origin string) ([]byte, error) {
 	if len(newHandles) != 0 {
 		secrets, err := secretFetcher(newHandles, origin)
 		if err != nil {
+			recordSecretErrors(newHandles, origin, err)
 			return nil, err
 		}
+		for _, handle := range newHandles {
+			delete(secretErrors, handle)
+		}
 
 		// Replace all new encrypted secrets in the config
 		err = walk(&config, func(str string) (string, error) {
@@ -206,9 +208,39 @@ func GetDebugInfo() (*SecretInfo, error) {
 	info := &SecretInfo{ExecutablePath: secretBackendCommand}
 	info.populateRights()
 
+	info.Backend = SecretBackendInfo{
+		Command:            secretBackendCommand,
+		ArgumentsCount:     len(secretBackendArguments),
+		Timeout:            secretBackendTimeout,
+		OutputMaxSize:      SecretBackendOutputMaxSize,
+		GroupExecPermitted: secretBackendCommandAllowGroupExec,
+	}
+
 	info.SecretsHandles = map[string][]string{}
+	info.SecretsStatus = map[string]SecretHandleStatus{}
 	for handle, originNames := range secretOrigin {
 		info.SecretsHandles[handle] = originNames.GetAll()
+		info.SecretsStatus[handle] = SecretHandleStatus{Resolved: true, Origins: originNames.GetAll()}
+	}
+	for handle, secretErr := range secretErrors {
+		info.SecretsStatus[handle] = SecretHandleStatus{Resolved: false, Origins: secretErr.origins.GetAll(), Error: secretErr.err}
 	}
 	return info, nil
 }
+
+// recordSecretErrors keeps track of the handles that could not be decrypted, so they are reported by GetDebugInfo
+func recordSecretErrors(handles []string, origin string, err error) {
+	for _, handle := range handles {
+		// the handles fetched before the error are decrypted
+		if _, ok := secretCache[handle]; ok {
+			continue
+		}
+		if secretErr, ok := secretErrors[handle]; ok {
+			secretErr.origins.Add(origin)
+			secretErr.err = err.Error()
+			secretErrors[handle] = secretErr
+			continue
+		}
+		secretErrors[handle] = secretError{origins: common.NewStringSet(origin), err: err.Error()}
+	}
+}
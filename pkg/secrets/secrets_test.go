@@ -184,6 +184,7 @@ func TestDecryptSecretError(t *testing.T) {
 	defer func() {
 		secretBackendCommand = ""
 		secretFetcher = fetchSecret
+		secretErrors = map[string]secretError{}
 	}()
 
 	secretFetcher = func(secrets []string, origin string) (map[string]string, error) {
@@ -342,3 +343,52 @@ func TestDebugInfo(t *testing.T) {
 		"pass3": {"test2"},
 	}, handles)
 }
+
+func TestDebugInfoFailedHandles(t *testing.T) {
+	secretBackendCommand = "some_command"
+	secretBackendArguments = []string{"--token", "some_token"}
+
+	defer func() {
+		secretBackendCommand = ""
+		secretBackendArguments = nil
+		secretCache = map[string]string{}
+		secretOrigin = map[string]common.StringSet{}
+		secretErrors = map[string]secretError{}
+		runCommand = execCommand
+	}()
+
+	runCommand = func(string) ([]byte, error) {
+		return []byte("{\"pass1\":{\"value\":\"password1\"},\"pass2\":{\"error\":\"access denied\"}}"), nil
+	}
+
+	_, err := Decrypt(testConf, "test")
+	require.NotNil(t, err)
+
+	info, err := GetDebugInfo()
+	require.Nil(t, err)
+
+	assert.Equal(t, SecretBackendInfo{
+		Command:        "some_command",
+		ArgumentsCount: 2,
+		Timeout:        secretBackendTimeout,
+		OutputMaxSize:  SecretBackendOutputMaxSize,
+	}, info.Backend)
+
+	assert.Equal(t, map[string][]string{"pass1": {"test"}}, info.SecretsHandles)
+	assert.Equal(t, SecretHandleStatus{Resolved: true, Origins: []string{"test"}}, info.SecretsStatus["pass1"])
+	assert.False(t, info.SecretsStatus["pass2"].Resolved)
+	assert.Equal(t, []string{"test"}, info.SecretsStatus["pass2"].Origins)
+	assert.Equal(t, "an error occurred while decrypting 'pass2': access denied", info.SecretsStatus["pass2"].Error)
+
+	// the handle is no longer reported as failed once it has been decrypted
+	runCommand = func(string) ([]byte, error) {
+		return []byte("{\"pass2\":{\"value\":\"password2\"},\"pass3\":{\"value\":\"password3\"}}"), nil
+	}
+	_, err = Decrypt(testConf, "test")
+	require.Nil(t, err)
+
+	info, err = GetDebugInfo()
+	require.Nil(t, err)
+	assert.True(t, info.SecretsStatus["pass2"].Resolved)
+	assert.Empty(t, info.SecretsStatus["pass2"].Error)
+}
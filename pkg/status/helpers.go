@@ -217,6 +217,8 @@ func complianceResult(result string) string {
 		return fmt.Sprintf("[%s]", color.RedString("FAILED"))
 	case "passed":
 		return fmt.Sprintf("[%s]", color.GreenString("PASSED"))
+	case "accepted_risk":
+		return fmt.Sprintf("[%s]", color.YellowString("ACCEPTED RISK"))
 	default:
 		return fmt.Sprintf("[%s]", color.YellowString("UNKNOWN"))
 	}
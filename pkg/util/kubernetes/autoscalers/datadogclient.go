@@ -17,6 +17,7 @@ import (
 	"gopkg.in/zorkian/go-datadog-api.v2"
 
 	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/secrets"
 	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -26,6 +27,10 @@ const (
 	metricsEndpointConfig = "external_metrics_provider.endpoint"
 )
 
+// errUnresolvedKeys is returned when the api/app keys are secret handles that could not be resolved, as they would be
+// rejected by Datadog
+var errUnresolvedKeys = errors.New("the api/app keys to query Datadog are unresolved secret handles, check the secret_backend_command setting")
+
 // NewDatadogClient configures and returns a new DatadogClient
 func NewDatadogClient() (DatadogClient, error) {
 	if config.Datadog.IsSet("external_metrics_provider.endpoints") {
@@ -66,6 +71,9 @@ func newDatadogSingleClient() (*datadog.Client, error) {
 	if appKey == "" || apiKey == "" {
 		return nil, errors.New("missing the api/app key pair to query Datadog")
 	}
+	if secrets.IsEnc(apiKey) || secrets.IsEnc(appKey) {
+		return nil, errUnresolvedKeys
+	}
 
 	log.Infof("Initialized the Datadog Client for HPA with endpoint %q", endpoint)
 
@@ -118,7 +126,16 @@ func newDatadogFallbackClient(endpoints []config.Endpoint) (*datadogFallbackClie
 		},
 	}
 	for _, endpoint := range endpoints {
-		client := datadog.NewClient(endpoint.APIKey, endpoint.APPKey)
+		apiKey := config.SanitizeAPIKey(endpoint.APIKey)
+		appKey := config.SanitizeAPIKey(endpoint.APPKey)
+		if apiKey == "" || appKey == "" {
+			return nil, fmt.Errorf("missing the api/app key pair to query %s", endpoint.URL)
+		}
+		if secrets.IsEnc(apiKey) || secrets.IsEnc(appKey) {
+			return nil, fmt.Errorf("%w for %s", errUnresolvedKeys, endpoint.URL)
+		}
+
+		client := datadog.NewClient(apiKey, appKey)
 		client.HttpClient.Transport = httputils.CreateHTTPTransport()
 		client.RetryTimeout = 3 * time.Second
 		client.ExtraHeader["User-Agent"] = "Datadog-Cluster-Agent"
@@ -61,6 +61,9 @@ var blankRegex = regexp.MustCompile(`^\s*$`)
 // Comments and blank lines are omitted. Comments are considered to begin with `#`.
 //
 // It then applies all MultiLine replacers to the entire text of the input.
+//
+// Finally, it redacts the values of the resolved secrets registered with
+// AddSecretValues, so they can't leak even where no replacer matches.
 type Scrubber struct {
 	singleLineReplacers, multiLineReplacers []Replacer
 }
@@ -117,7 +120,8 @@ func (c *Scrubber) ScrubBytes(file []byte) ([]byte, error) {
 // applied to URLs or to strings containing URLs.  It does not run multi-line
 // replacers, and should not be used on multi-line inputs.
 func (c *Scrubber) ScrubLine(message string) string {
-	return string(c.scrub([]byte(message), c.singleLineReplacers))
+	cleaned, _ := RedactSecretValues(c.scrub([]byte(message), c.singleLineReplacers))
+	return string(cleaned)
 }
 
 // scrubReader applies the cleaning algorithm to a Reader
@@ -153,6 +157,7 @@ func (c *Scrubber) scrubReader(file io.Reader, sizeHint int) ([]byte, error) {
 
 	// Then we apply multiline replacers on the cleaned file
 	cleanedFile := c.scrub(cleanedBuffer.Bytes(), c.multiLineReplacers)
+	cleanedFile, _ = RedactSecretValues(cleanedFile)
 
 	return cleanedFile, nil
 }
@@ -0,0 +1,100 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package scrubber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// minSecretValueLength is the minimum length of the secret values that are redacted. Shorter values would match
+// too much unrelated content to be redacted reliably.
+const minSecretValueLength = 8
+
+var secretValueRepl = []byte("********")
+
+// secretValues holds the values of the secrets resolved by the agent, longest first so that a value containing
+// another one is redacted entirely.
+var secretValues = struct {
+	sync.RWMutex
+	values [][]byte
+}{}
+
+// AddSecretValues registers the values of resolved secrets. Every scrubber redacts any occurrence of these values,
+// whatever the context they appear in, on top of its replacers.
+func AddSecretValues(values ...string) {
+	secretValues.Lock()
+	defer secretValues.Unlock()
+
+	for _, value := range values {
+		if !isRedactableSecretValue(value) {
+			continue
+		}
+		secretValues.values = appendSecretValue(secretValues.values, []byte(value))
+
+		// the value is also looked for as it appears in JSON output, such as the status
+		if encoded, err := json.Marshal(value); err == nil {
+			secretValues.values = appendSecretValue(secretValues.values, encoded[1:len(encoded)-1])
+		}
+	}
+
+	sort.SliceStable(secretValues.values, func(i, j int) bool {
+		return len(secretValues.values[i]) > len(secretValues.values[j])
+	})
+}
+
+// isRedactableSecretValue returns true if value can be redacted without redacting unrelated content. The JSON literals
+// and the numbers are never redacted, as they appear everywhere in the output of the agent.
+func isRedactableSecretValue(value string) bool {
+	if len(value) < minSecretValueLength {
+		return false
+	}
+	switch value {
+	case "true", "false", "null":
+		return false
+	}
+	// the numbers out of the range of float64 are numbers too
+	if _, err := strconv.ParseFloat(value, 64); err == nil || errors.Is(err, strconv.ErrRange) {
+		return false
+	}
+	return true
+}
+
+func appendSecretValue(values [][]byte, value []byte) [][]byte {
+	for _, v := range values {
+		if bytes.Equal(v, value) {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+// ClearSecretValues unregisters all the secret values. It is mostly meant to be used by tests.
+func ClearSecretValues() {
+	secretValues.Lock()
+	defer secretValues.Unlock()
+	secretValues.values = nil
+}
+
+// RedactSecretValues replaces every occurrence of a registered secret value in data. It returns true if any
+// value was found.
+func RedactSecretValues(data []byte) ([]byte, bool) {
+	secretValues.RLock()
+	defer secretValues.RUnlock()
+
+	found := false
+	for _, value := range secretValues.values {
+		if bytes.Contains(data, value) {
+			data = bytes.ReplaceAll(data, value, secretValueRepl)
+			found = true
+		}
+	}
+	return data, found
+}
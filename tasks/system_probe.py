@@ -209,7 +209,7 @@ def ninja_network_ebpf_programs(nw, build_dir, co_re_build_dir):
     network_prebuilt_dir = os.path.join(network_c_dir, "prebuilt")
 
     network_flags = "-Ipkg/network/ebpf/c -g"
-    network_programs = ["dns", "offset-guess", "tracer", "http", "usm_events_test"]
+    network_programs = ["dns", "offset-guess", "tracer", "http", "http-fallback", "usm_events_test"]
     network_co_re_programs = ["co-re/tracer-fentry", "runtime/http"]
 
     for prog in network_programs:
@@ -309,6 +309,66 @@ def ninja_cgo_type_files(nw, windows):
                 "pkg/network/ebpf/c/protocols/http/types.h",
                 "pkg/network/ebpf/c/protocols/classification/defs.h",
             ],
+            "pkg/network/protocols/kafka/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/kafka/defs.h",
+                "pkg/network/ebpf/c/protocols/kafka/types.h",
+            ],
+            "pkg/network/protocols/postgres/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/postgres/defs.h",
+                "pkg/network/ebpf/c/protocols/postgres/types.h",
+            ],
+            "pkg/network/protocols/mysql/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/mysql/defs.h",
+                "pkg/network/ebpf/c/protocols/mysql/types.h",
+            ],
+            "pkg/network/protocols/redis/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/redis/defs.h",
+                "pkg/network/ebpf/c/protocols/redis/types.h",
+            ],
+            "pkg/network/protocols/mongo/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/mongo/defs.h",
+                "pkg/network/ebpf/c/protocols/mongo/types.h",
+            ],
+            "pkg/network/protocols/amqp/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/amqp/defs.h",
+                "pkg/network/ebpf/c/protocols/amqp/types.h",
+            ],
+            "pkg/network/protocols/grpc/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/grpc/defs.h",
+                "pkg/network/ebpf/c/protocols/grpc/types.h",
+            ],
+            "pkg/network/protocols/http3/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/http3/defs.h",
+                "pkg/network/ebpf/c/protocols/http3/types.h",
+            ],
+            "pkg/network/protocols/tls/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/tls/tls-handshake-defs.h",
+                "pkg/network/ebpf/c/protocols/tls/tls-handshake-types.h",
+            ],
+            "pkg/network/protocols/cassandra/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/cassandra/defs.h",
+                "pkg/network/ebpf/c/protocols/cassandra/types.h",
+            ],
+            "pkg/network/protocols/memcached/types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/memcached/defs.h",
+                "pkg/network/ebpf/c/protocols/memcached/types.h",
+            ],
+            "pkg/network/dns/tls_types.go": [
+                "pkg/network/ebpf/c/tracer.h",
+                "pkg/network/ebpf/c/protocols/dns/defs.h",
+                "pkg/network/ebpf/c/protocols/dns/types.h",
+            ],
             "pkg/network/telemetry/telemetry_types.go": [
                 "pkg/ebpf/c/telemetry_types.h",
             ],